@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -12,11 +13,19 @@ import (
 	"notsofluffy-backend/internal/config"
 	"notsofluffy-backend/internal/database"
 	"notsofluffy-backend/internal/models"
+	"notsofluffy-backend/internal/version"
 
 	"golang.org/x/term"
 )
 
 func main() {
+	showVersion := flag.Bool("version", false, "print the build version and exit")
+	flag.Parse()
+	if *showVersion {
+		fmt.Println("notsofluffy create-admin", version.Get())
+		return
+	}
+
 	fmt.Println("Creating Super Admin User")
 	fmt.Println("========================")
 
@@ -31,7 +40,7 @@ func main() {
 	defer db.Close()
 
 	// Run migrations to ensure database is up to date
-	if err := database.Migrate(db); err != nil {
+	if err := database.Migrate(db, database.MigrationOptions{}); err != nil {
 		log.Fatal("Failed to run migrations:", err)
 	}
 
@@ -2,45 +2,108 @@ package main
 
 import (
 	"context"
+	"flag"
+	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"notsofluffy-backend/internal/captcha"
 	"notsofluffy-backend/internal/config"
 	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/exchangerates"
+	"notsofluffy-backend/internal/geoip"
 	"notsofluffy-backend/internal/handlers"
+	"notsofluffy-backend/internal/jobs"
+	"notsofluffy-backend/internal/mail"
 	"notsofluffy-backend/internal/middleware"
+	"notsofluffy-backend/internal/models"
+	"notsofluffy-backend/internal/openapi"
+	"notsofluffy-backend/internal/payments"
+	"notsofluffy-backend/internal/ratelimit"
+	"notsofluffy-backend/internal/shipping"
+	"notsofluffy-backend/internal/version"
+	"notsofluffy-backend/internal/webhooks"
 
 	"github.com/gin-gonic/gin"
 )
 
+// exportsDir holds the files generated by the exports job
+const exportsDir = "exports"
+
 func main() {
+	showVersion := flag.Bool("version", false, "print the build version and exit")
+	migrateOnly := flag.Bool("migrate-only", false, "run pending migrations and exit")
+	checkMigrations := flag.Bool("check-migrations", false, "list pending migrations that lock large tables or break the running release, and exit")
+	contract := flag.Bool("contract", false, "also run contract migrations, once no instance of the previous release is running")
+	forceMigrations := flag.Bool("force-migrations", false, "run migrations the pre-flight check finds dangerous")
+	flag.Parse()
+	if *showVersion {
+		fmt.Println("notsofluffy server", version.Get())
+		return
+	}
+
+	// Structured JSON logs; the log package writes through the same handler
+	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stdout, nil)))
+
 	cfg := config.Load()
 
-	db, err := database.Connect(cfg.DatabaseURL)
+	// Migrations and background jobs run without the statement timeout of request
+	// handlers: a cancelled CREATE INDEX CONCURRENTLY leaves an invalid index behind
+	jobsDB, err := database.Connect(cfg.DatabaseURL)
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
-	defer db.Close()
+	defer jobsDB.Close()
+	jobsDB.SetMaxOpenConns(5)
+
+	if *checkMigrations {
+		pending, err := database.CheckMigrations(jobsDB)
+		if err != nil {
+			log.Fatal("Failed to check migrations:", err)
+		}
+		if !printMigrationCheck(os.Stdout, pending) {
+			os.Exit(1)
+		}
+		return
+	}
 
-	if err := database.Migrate(db); err != nil {
+	if err := database.Migrate(jobsDB, database.MigrationOptions{Contract: *contract, Force: *forceMigrations}); err != nil {
 		log.Fatal("Failed to run migrations:", err)
 	}
+	if *migrateOnly {
+		return
+	}
+
+	db, err := database.Connect(database.WithStatementTimeout(cfg.DatabaseURL, time.Duration(cfg.DBStatementTimeoutMS)*time.Millisecond))
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	defer db.Close()
 
 	// Ensure uploads directory exists
 	if err := os.MkdirAll("uploads/images", 0755); err != nil {
 		log.Fatal("Failed to create uploads directory:", err)
 	}
 
-	r := gin.Default()
+	// Exports hold customer data, so they are kept outside the public uploads directory
+	if err := os.MkdirAll(exportsDir, 0750); err != nil {
+		log.Fatal("Failed to create exports directory:", err)
+	}
+
+	// gin.Default's text logger is replaced by RequestLogger
+	r := gin.New()
+	r.Use(gin.Recovery())
 
 	// Initialize session store
 	middleware.InitSessionStore(cfg.JWTSecret)
 
 	// Security and proxy middleware (must be first)
+	r.Use(middleware.RequestID())
 	r.Use(middleware.TrustedProxyHeaders())
 	r.Use(middleware.SecurityHeaders())
 	r.Use(middleware.RequestLogger())
@@ -48,22 +111,71 @@ func main() {
 	// Health check endpoint (before other middleware)
 	r.Use(middleware.HealthCheck("/health"))
 
+	// Debug captures of specific sessions or users, started from the admin panel
+	r.Use(middleware.DebugCaptureMiddleware(database.NewDebugCaptureQueries(db), database.NewSettingsQueries(db)))
+
+	// Error messages in the language of Accept-Language; registered before the
+	// middleware below so their errors are translated too
+	r.Use(middleware.Localize())
+
+	// Request body limits and handler deadlines; deadlines stay below the server's
+	// 30s WriteTimeout and uploads are the only routes taking large bodies
+	r.Use(middleware.RequestLimits(
+		middleware.RouteLimit{MaxBodyBytes: 1 << 20, Timeout: 15 * time.Second},
+		map[string]middleware.RouteLimit{
+			"POST /api/admin/images/upload":                 {MaxBodyBytes: 11 << 20, Timeout: 25 * time.Second},
+			"POST /api/admin/products/:id/attachments":      {MaxBodyBytes: 64 << 20, Timeout: 25 * time.Second},
+			"POST /api/admin/catalog/snapshots":             {MaxBodyBytes: 1 << 20, Timeout: 25 * time.Second},
+			"GET /api/admin/catalog/snapshots/:id/download": {Timeout: 25 * time.Second},
+			"POST /api/admin/catalog/snapshots/:id/restore": {MaxBodyBytes: 1 << 20, Timeout: 25 * time.Second},
+			"POST /api/admin/catalog/restore":               {MaxBodyBytes: 64 << 20, Timeout: 25 * time.Second},
+			"GET /api/admin/orders/labels":                  {Timeout: 25 * time.Second},
+			"GET /api/admin/orders/export":                  {Timeout: 25 * time.Second},
+			"POST /api/admin/orders/:id/shipment":           {Timeout: 25 * time.Second},
+			"GET /api/admin/orders/:id/shipment/label":      {Timeout: 25 * time.Second},
+			"POST /api/admin/discount-codes/import":          {MaxBodyBytes: 5 << 20, Timeout: 25 * time.Second},
+			"POST /api/admin/categories/import":              {MaxBodyBytes: 5 << 20, Timeout: 25 * time.Second},
+			"POST /api/admin/products/full":                  {MaxBodyBytes: 1 << 20, Timeout: 25 * time.Second},
+			"GET /api/admin/products/export":                 {Timeout: 25 * time.Second},
+			"POST /api/admin/products/import":                {MaxBodyBytes: 10 << 20, Timeout: 25 * time.Second},
+			"POST /api/admin/products/bulk-delete":           {Timeout: 25 * time.Second},
+			"POST /api/admin/orders/bulk-status":             {Timeout: 25 * time.Second},
+			"POST /api/orders":                               {Timeout: 25 * time.Second},
+			"POST /api/orders/:id/pay":                       {Timeout: 25 * time.Second},
+			"POST /api/payments/:provider/callback":          {Timeout: 25 * time.Second},
+		},
+	))
+
 	// CORS middleware with proxy support
 	r.Use(middleware.CORSWithProxy(cfg.AllowedOrigins))
 
 	// Session middleware
 	r.Use(middleware.SessionMiddleware())
 
+	// The shop (brand) serving the request's host
+	shopQueries := database.NewShopQueries(db)
+	r.Use(middleware.ShopMiddleware(shopQueries))
+
+	// The currency prices are shown in, from ?currency= or X-Currency
+	currencyQueries := database.NewCurrencyQueries(db)
+	r.Use(middleware.CurrencyMiddleware(currencyQueries))
+
 	// Maintenance mode middleware
 	r.Use(middleware.MaintenanceMiddleware(db, cfg.JWTSecret))
 
 	// Static file serving for uploads
 	r.Static("/uploads", "./uploads")
 
+	// Outgoing email; only logged until an SMTP server is configured
+	var mailer mail.Sender = mail.LogSender{}
+	if cfg.SMTPHost != "" {
+		mailer = mail.NewSMTPSender(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom)
+	}
+
 	// Initialize handlers
-	authHandler := handlers.NewAuthHandler(db, cfg.JWTSecret)
+	authHandler := handlers.NewAuthHandler(db, cfg.JWTSecret, mailer, cfg.SiteURL)
 	adminHandler := handlers.NewAdminHandler(db)
-	publicHandler := handlers.NewPublicHandler(db)
+	publicHandler := handlers.NewPublicHandler(db, cfg.SiteURL)
 	cartHandler := handlers.NewCartHandler(db)
 	profileHandler := handlers.NewProfileHandler(db)
 	
@@ -72,21 +184,260 @@ func main() {
 	cartQueries := database.NewCartQueries(db)
 	stockQueries := database.NewStockQueries(db)
 	discountQueries := database.NewDiscountQueries(db)
-	orderHandler := handlers.NewOrderHandler(orderQueries, cartQueries, stockQueries, discountQueries)
+	legalQueries := database.NewLegalQueries(db)
+	warehouseQueries := database.NewWarehouseQueries(db)
+	webhookQueries := database.NewWebhookQueries(db)
+	emailQueries := database.NewEmailQueries(db)
+	shippingMethodQueries := database.NewShippingMethodQueries(db)
+
+	// Initialize online payments; the provider is nil when they are off
+	paymentProvider, err := payments.NewIntentProvider(payments.Config{
+		Provider:            cfg.PaymentProvider,
+		StripeSecretKey:     cfg.StripeSecretKey,
+		StripeWebhookSecret: cfg.StripeWebhookSecret,
+		P24MerchantID:       cfg.P24MerchantID,
+		P24PosID:            cfg.P24PosID,
+		P24APIKey:           cfg.P24APIKey,
+		P24CRC:              cfg.P24CRC,
+		P24Sandbox:          cfg.P24Sandbox,
+		P24StatusURL:        cfg.PaymentCallbackURL + "/api/payments/przelewy24/callback",
+	}, 10*time.Second)
+	if err != nil {
+		log.Fatal("Invalid payment configuration:", err)
+	}
+	paymentHandler := handlers.NewPaymentHandler(orderQueries, database.NewPaymentQueries(db), webhookQueries, paymentProvider, cfg.PaymentReturnURL)
+	orderHandler := handlers.NewOrderHandler(handlers.OrderHandlerDeps{
+		OrderQueries:          orderQueries,
+		CartQueries:           cartQueries,
+		StockQueries:          stockQueries,
+		DiscountQueries:       discountQueries,
+		LegalQueries:          legalQueries,
+		WarehouseQueries:      warehouseQueries,
+		SettingsQueries:       database.NewSettingsQueries(db),
+		WebhookQueries:        webhookQueries,
+		PaymentHandler:        paymentHandler,
+		EmailQueries:          emailQueries,
+		ProfileQueries:        database.NewProfileQueries(db),
+		ShippingMethodQueries: shippingMethodQueries,
+	})
 	
 	// Initialize discount handler
 	discountHandler := handlers.NewDiscountHandler(discountQueries, cartQueries)
 
+	// Initialize refund handler
+	refundQueries := database.NewRefundQueries(db)
+	refundHandler := handlers.NewRefundHandler(refundQueries, orderQueries, payments.NewManualProvider())
+
+	// Initialize shipping label handler
+	orderLabelHandler := handlers.NewOrderLabelHandler(orderQueries, database.NewSettingsQueries(db))
+
+	// Initialize carrier shipments; the provider is nil when no carrier is integrated
+	shippingProvider, err := shipping.NewProvider(shipping.Config{
+		Provider:             cfg.ShippingProvider,
+		InPostToken:          cfg.InPostToken,
+		InPostOrganizationID: cfg.InPostOrganizationID,
+		InPostSandbox:        cfg.InPostSandbox,
+	}, 10*time.Second)
+	if err != nil {
+		log.Fatal("Invalid shipping configuration:", err)
+	}
+	shipmentHandler := handlers.NewShipmentHandler(orderQueries, database.NewShipmentQueries(db), shippingProvider)
+
+	// Initialize order item picking and packing
+	orderFulfillmentHandler := handlers.NewOrderFulfillmentHandler(orderQueries, database.NewSettingsQueries(db))
+
+	// Initialize order change request handler
+	orderChangeQueries := database.NewOrderChangeQueries(db)
+	orderChangeHandler := handlers.NewOrderChangeHandler(orderChangeQueries, orderQueries)
+
+	// Initialize data retention handler
+	retentionQueries := database.NewRetentionQueries(db)
+	retentionHandler := handlers.NewRetentionHandler(retentionQueries)
+
+	// Initialize legal documents handler
+	legalHandler := handlers.NewLegalHandler(legalQueries)
+
+	// Initialize admin session handler
+	adminSessionHandler := handlers.NewAdminSessionHandler(database.NewAdminSessionQueries(db), database.NewUserQueries(db))
+
+	// Initialize warehouse handler
+	warehouseHandler := handlers.NewWarehouseHandler(warehouseQueries)
+
+	// Initialize GeoIP country detection
+	var geoProvider geoip.Provider = geoip.NoopProvider{}
+	if cfg.GeoIPURL != "" {
+		geoProvider = geoip.NewCachedProvider(geoip.NewHTTPProvider(cfg.GeoIPURL, 2*time.Second), 6*time.Hour, 10000)
+	}
+	geoIP := middleware.GeoIPMiddleware(geoip.NewDetector(geoProvider, cfg.GeoIPCountryHeader))
+
+	// Challenge for public forms, switched on per endpoint in the captcha_* settings
+	captchaProvider, err := captcha.NewProvider(cfg.CaptchaProvider, cfg.CaptchaSecret, 5*time.Second)
+	if err != nil {
+		log.Fatal("Invalid captcha configuration:", err)
+	}
+	captchaHandler := handlers.NewCaptchaHandler(database.NewSettingsQueries(db), captchaProvider, cfg.CaptchaSiteKey)
+
+	// Rate limits of the endpoints open to abuse, shared between instances through Redis
+	var rateLimitStore ratelimit.Store = ratelimit.NewMemoryStore()
+	if cfg.RedisURL != "" {
+		redisStore, err := ratelimit.NewRedisStore(cfg.RedisURL)
+		if err != nil {
+			log.Fatal("Invalid Redis configuration:", err)
+		}
+		defer redisStore.Close()
+		rateLimitStore = redisStore
+	}
+	rateLimit := func(group, value string) gin.HandlerFunc {
+		rule, err := ratelimit.ParseRule(value)
+		if err != nil {
+			log.Fatalf("Invalid rate limit of %s: %v", group, err)
+		}
+		return middleware.RateLimit(rateLimitStore, group, rule)
+	}
+	authLimit := rateLimit("auth", cfg.RateLimitAuth)
+	discountLimit := rateLimit("discount", cfg.RateLimitDiscount)
+	ordersLimit := rateLimit("orders", cfg.RateLimitOrders)
+	contextHandler := handlers.NewContextHandler(database.NewSettingsQueries(db), cartQueries, currencyQueries, captchaHandler)
+
+	// Initialize analytics handler
+	analyticsHandler := handlers.NewAnalyticsHandler(database.NewAnalyticsQueries(db))
+
+	// Initialize catalog snapshot handler
+	catalogSnapshotHandler := handlers.NewCatalogSnapshotHandler(database.NewCatalogSnapshotQueries(db))
+
+	// Initialize product attachment handler
+	attachmentHandler := handlers.NewAttachmentHandler(database.NewAttachmentQueries(db), database.NewProductQueries(db), database.NewSettingsQueries(db))
+
+	// Initialize order quick action handler
+	orderActionHandler := handlers.NewOrderActionHandler(orderQueries, database.NewSettingsQueries(db), webhookQueries, refundHandler, mailer)
+
+	// Initialize status page handler
+	statusHandler := handlers.NewStatusHandler(db, database.NewSettingsQueries(db))
+
+	// Initialize partner API key handler
+	apiKeyHandler := handlers.NewAPIKeyHandler(database.NewAPIKeyQueries(db))
+	webhookHandler := handlers.NewWebhookHandler(webhookQueries)
+	emailHandler := handlers.NewEmailHandler(emailQueries)
+	emailFeedbackHandler := handlers.NewEmailFeedbackHandler(emailQueries, cfg.EmailWebhookSecret)
+
+	// Signed links of the storefront's unsubscribe page; emails go without them while
+	// SITE_URL is not set
+	unsubscribeURL := ""
+	if cfg.SiteURL != "" {
+		unsubscribeURL = cfg.SiteURL + "/unsubscribe"
+	}
+	unsubscribeLinks := mail.NewUnsubscribeLinks(cfg.JWTSecret, unsubscribeURL)
+	emailPreferenceHandler := handlers.NewEmailPreferenceHandler(emailQueries, database.NewUserQueries(db), unsubscribeLinks)
+
+	// Initialize production capacity handler
+	productionHandler := handlers.NewProductionHandler(database.NewProductionQueries(db))
+
+	// Initialize product option group handler
+	productOptionHandler := handlers.NewProductOptionHandler(database.NewOptionQueries(db), database.NewProductQueries(db))
+
+	// Initialize CSV import and export handler
+	importHandler := handlers.NewImportHandler(discountQueries, database.NewCategoryQueries(db), database.NewProductQueries(db), database.NewMaterialQueries(db), database.NewColorQueries(db), database.NewImageQueries(db), database.NewSettingsQueries(db))
+
+	// Initialize export center handler
+	exportQueries := database.NewExportQueries(db)
+	exportHandler := handlers.NewExportHandler(exportQueries, database.NewSettingsQueries(db))
+
+	// Initialize debug capture handler
+	debugCaptureHandler := handlers.NewDebugCaptureHandler(database.NewDebugCaptureQueries(db))
+
+	// Initialize trash handler
+	trashHandler := handlers.NewTrashHandler(database.NewTrashQueries(db))
+	contentChangeHandler := handlers.NewContentChangeHandler(db, adminHandler)
+	productRevisionHandler := handlers.NewProductRevisionHandler(database.NewProductRevisionQueries(db))
+
+	// Initialize frequently bought together handler
+	pairingQueries := database.NewPairingQueries(db)
+	pairingHandler := handlers.NewPairingHandler(pairingQueries)
+
+	// Initialize flash sale live stats handler
+	liveStatsHandler := handlers.NewLiveStatsHandler(database.NewLiveStatsQueries(db))
+
+	// Initialize customer product review handler
+	productReviewHandler := handlers.NewProductReviewHandler(database.NewProductReviewQueries(db))
+
+	// Background jobs
+	scheduler := jobs.NewScheduler()
+	scheduler.Add("retention", 24*time.Hour, jobs.Retention(database.NewRetentionQueries(jobsDB)))
+	scheduler.Add("product_pairings", 24*time.Hour, jobs.ProductPairings(database.NewPairingQueries(jobsDB)))
+	scheduler.Add("image_variants", 6*time.Hour, jobs.ImageVariants(database.NewImageQueries(jobsDB)))
+	scheduler.Add("email_outbox", 30*time.Second, jobs.EmailOutbox(database.NewEmailQueries(jobsDB), mailer, unsubscribeLinks))
+	scheduler.Add("webhook_deliveries", 30*time.Second, jobs.WebhookDeliveries(database.NewWebhookQueries(jobsDB), webhooks.NewClient(10*time.Second)))
+	scheduler.Add("order_archive", 24*time.Hour, jobs.OrderArchive(database.NewOrderQueries(jobsDB), database.NewSettingsQueries(jobsDB)))
+	scheduler.Add("cart_prices", 6*time.Hour, jobs.CartPrices(database.NewCartQueries(jobsDB), database.NewSettingsQueries(jobsDB)))
+	scheduler.Add("exports", 30*time.Second, jobs.Exports(database.NewExportQueries(jobsDB), database.NewEmailQueries(jobsDB), database.NewSettingsQueries(jobsDB), exportsDir, cfg.SiteURL))
+	scheduler.Add("discount_usage", 15*time.Minute, jobs.DiscountUsage(database.NewDiscountQueries(jobsDB), database.NewSettingsQueries(jobsDB)))
+	reservationSweeper := jobs.NewReservationSweeper(database.NewStockQueries(jobsDB))
+	scheduler.Add("stock_reservations", time.Minute, reservationSweeper.Run)
+	stockReservationHandler := handlers.NewStockReservationHandler(reservationSweeper)
+	returnTracker := jobs.NewReturnTracker(database.NewOrderReturnQueries(jobsDB), database.NewOrderQueries(jobsDB), database.NewEmailQueries(jobsDB), shippingProvider, cfg.SiteURL)
+	scheduler.Add("order_returns", 15*time.Minute, returnTracker.Run)
+	orderReturnHandler := handlers.NewOrderReturnHandler(database.NewOrderReturnQueries(db), orderQueries, database.NewSettingsQueries(db), shippingProvider, returnTracker)
+	nbpClient := exchangerates.NewNBPClient(exchangerates.NBPURL, 10*time.Second)
+	scheduler.Add("exchange_rates", 24*time.Hour, jobs.ExchangeRates(database.NewCurrencyQueries(jobsDB), database.NewSettingsQueries(jobsDB), nbpClient))
+	currencyHandler := handlers.NewCurrencyHandler(currencyQueries, nbpClient)
+
+	// Initialize shop handler
+	shopHandler := handlers.NewShopHandler(shopQueries)
+
+	// Initialize address reference data handler
+	referenceHandler := handlers.NewReferenceHandler(database.NewSettingsQueries(db))
+
+	// Initialize catalog translation handler
+	translationHandler := handlers.NewTranslationHandler(database.NewTranslationQueries(db))
+
+	// Initialize shipping method handler
+	shippingMethodHandler := handlers.NewShippingMethodHandler(shippingMethodQueries)
+	orderStatusHandler := handlers.NewOrderStatusHandler(database.NewOrderStatusQueries(db))
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	scheduler.Start(jobsCtx)
+
+	// Initialize search index handler
+	searchIndexHandler := handlers.NewSearchIndexHandler(jobs.NewSearchReindexer(jobsCtx, database.NewProductQueries(jobsDB)))
+
 	// Public routes
 	public := r.Group("/api")
 	{
 		public.GET("/categories", publicHandler.GetActiveCategories)
 		public.GET("/products", publicHandler.GetPublicProducts)
 		public.GET("/products/:id", publicHandler.GetPublicProduct)
+		public.GET("/products/:id/sizes/:sizeId/stock", publicHandler.GetSizeStock)
+		public.GET("/products/:id/reviews", productReviewHandler.ListPublicProductReviews)
+		public.POST("/products/:id/reviews", middleware.AuthMiddleware(db, cfg.JWTSecret), productReviewHandler.CreateProductReview)
+		public.POST("/size-recommendation", publicHandler.RecommendSize)
+		public.GET("/attachments/:id/download", attachmentHandler.DownloadAttachment)
 		public.GET("/search", publicHandler.SearchProducts)
 		public.GET("/search/suggestions", publicHandler.GetSearchSuggestions)
+		public.GET("/tags", publicHandler.GetTagCloud)
 		public.GET("/maintenance-status", publicHandler.GetMaintenanceStatus)
+		public.GET("/version", handlers.GetVersion)
+		public.GET("/shop", shopHandler.GetShopProfile)
+		public.GET("/currencies", currencyHandler.ListCurrencies)
+		public.GET("/reference/countries", referenceHandler.GetCountries)
+		public.GET("/shipping-methods", shippingMethodHandler.ListPublicShippingMethods)
+		public.GET("/status", statusHandler.GetStatus)
 		public.GET("/client-reviews", publicHandler.GetActiveClientReviews)
+		public.GET("/client-reviews/summary", publicHandler.GetClientReviewSummary)
+		public.GET("/legal/current", legalHandler.GetCurrentDocuments)
+		public.GET("/context", geoIP, contextHandler.GetContext)
+		public.GET("/captcha", captchaHandler.GetConfig)
+		public.GET("/email/unsubscribe", emailPreferenceHandler.GetUnsubscribe)
+		public.POST("/email/unsubscribe", emailPreferenceHandler.Unsubscribe)
+		public.POST("/events", middleware.OptionalAuthMiddleware(db, cfg.JWTSecret), geoIP, analyticsHandler.TrackEvents)
+	}
+
+	// Read-only catalog API for partners, authenticated by API key
+	partner := r.Group("/api/partner/v1")
+	partner.Use(middleware.PartnerAPIKey(db, ratelimit.New(), models.APIScopeCatalogRead))
+	{
+		partner.GET("/categories", publicHandler.GetActiveCategories)
+		partner.GET("/products", publicHandler.GetPublicProducts)
+		partner.GET("/products/:id", publicHandler.GetPublicProduct)
+		partner.GET("/search", publicHandler.SearchProducts)
 	}
 
 	// Cart routes (public but require session)
@@ -97,36 +448,61 @@ func main() {
 		cart.PUT("/update/:id", cartHandler.UpdateCartItem)
 		cart.DELETE("/remove/:id", cartHandler.RemoveFromCart)
 		cart.POST("/clear", cartHandler.ClearCart)
+		cart.POST("/price-changes/acknowledge", cartHandler.AcknowledgePriceChanges)
 		cart.GET("/count", cartHandler.GetCartCount)
 		
 		// Discount routes for cart
-		cart.POST("/discount/apply", discountHandler.ApplyDiscountToCart)
+		cart.POST("/discount/apply", discountLimit, middleware.CaptchaMiddleware(db, captchaProvider, captcha.EndpointDiscountApply), discountHandler.ApplyDiscountToCart)
 		cart.DELETE("/discount/remove", discountHandler.RemoveDiscountFromCart)
 	}
 
 	// Auth routes
 	auth := r.Group("/api/auth")
 	{
-		auth.POST("/register", authHandler.Register)
-		auth.POST("/login", authHandler.Login)
+		auth.POST("/register", authLimit, middleware.CaptchaMiddleware(db, captchaProvider, captcha.EndpointRegister), authHandler.Register)
+		auth.POST("/login", authLimit, authHandler.Login)
 		auth.POST("/refresh", authHandler.RefreshToken)
-		auth.GET("/profile", middleware.AuthMiddleware(cfg.JWTSecret), authHandler.Profile)
+		auth.POST("/forgot-password", authLimit, authHandler.ForgotPassword)
+		auth.POST("/reset-password", authLimit, authHandler.ResetPassword)
+		auth.POST("/logout", authHandler.Logout)
+		auth.POST("/logout-all", middleware.AuthMiddleware(db, cfg.JWTSecret), authHandler.LogoutAll)
+		auth.GET("/profile", middleware.AuthMiddleware(db, cfg.JWTSecret), authHandler.Profile)
 	}
 
 	// Order routes (with optional auth for user association)
+	checkoutOpen := middleware.CheckoutMiddleware(db)
 	orders := r.Group("/api/orders")
 	{
-		orders.POST("", middleware.OptionalAuthMiddleware(cfg.JWTSecret), orderHandler.CreateOrder)
-		orders.GET("/:id", middleware.OptionalAuthMiddleware(cfg.JWTSecret), orderHandler.GetOrder)
+		orders.POST("", ordersLimit, checkoutOpen, middleware.OptionalAuthMiddleware(db, cfg.JWTSecret), middleware.CaptchaMiddleware(db, captchaProvider, captcha.EndpointGuestCheckout), geoIP, middleware.TestOrderMiddleware(cfg.Development), orderHandler.CreateOrder)
+		orders.GET("/:id", middleware.OptionalAuthMiddleware(db, cfg.JWTSecret), orderHandler.GetOrder)
+		orders.POST("/:id/pay", checkoutOpen, middleware.OptionalAuthMiddleware(db, cfg.JWTSecret), paymentHandler.PayOrder)
 		orders.GET("/hash/:hash", orderHandler.GetOrderByHash)
+		orders.GET("/hash/:hash/change-requests", orderChangeHandler.GetOrderChangeRequests)
+		orders.POST("/hash/:hash/change-request", orderChangeHandler.CreateChangeRequest)
+		orders.GET("/hash/:hash/returns", orderReturnHandler.GetOrderReturns)
+		orders.POST("/hash/:hash/returns", orderReturnHandler.CreateReturn)
+		orders.GET("/hash/:hash/returns/:returnId/label", orderReturnHandler.GetOrderReturnLabel)
 	}
 
+	// Payment provider callbacks, verified by the provider's signature
+	r.POST("/api/payments/:provider/callback", paymentHandler.PaymentCallback)
+
+	// Email provider bounce and complaint notifications, authenticated by a shared token
+	r.POST("/api/email/events/:provider", emailFeedbackHandler.ReceiveEmailEvents)
+
 	// User routes (authenticated)
 	user := r.Group("/api/user")
-	user.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+	user.Use(middleware.AuthMiddleware(db, cfg.JWTSecret))
 	{
 		user.GET("/orders", orderHandler.GetUserOrders)
 		
+		// Account security
+		user.GET("/security/logins", authHandler.GetLoginHistory)
+
+		// Email preferences
+		user.GET("/preferences", emailPreferenceHandler.GetPreferences)
+		user.PUT("/preferences", emailPreferenceHandler.UpdatePreferences)
+
 		// Profile management
 		user.GET("/profile", profileHandler.GetProfile)
 		user.PUT("/profile", profileHandler.UpdateProfile)
@@ -137,98 +513,338 @@ func main() {
 		user.PUT("/addresses/:id", profileHandler.UpdateAddress)
 		user.DELETE("/addresses/:id", profileHandler.DeleteAddress)
 		user.PATCH("/addresses/:id/default", profileHandler.SetDefaultAddress)
+
+		// Terms and privacy policy acceptance
+		user.GET("/legal/pending", legalHandler.GetPendingDocuments)
+		user.POST("/legal/accept", legalHandler.AcceptDocuments)
+	}
+
+	// Content changes proposed by editors and admins
+	staff := r.Group("/api/admin/content")
+	staff.Use(middleware.StaffMiddleware(db, cfg.JWTSecret), middleware.AdminSessionMiddleware(db))
+	{
+		staff.POST("/products/:id/changes", contentChangeHandler.SubmitProductChange)
+		staff.GET("/changes", contentChangeHandler.ListChanges)
+		staff.GET("/changes/:id", contentChangeHandler.GetChange)
 	}
 
 	// Admin routes
 	admin := r.Group("/api/admin")
-	admin.Use(middleware.AdminMiddleware(cfg.JWTSecret))
+	admin.Use(middleware.AdminMiddleware(db, cfg.JWTSecret), middleware.AdminSessionMiddleware(db))
+	requireSudo := middleware.RequireSudo()
 	{
+		// Admin session, sudo mode and two-factor authentication
+		admin.GET("/session", adminSessionHandler.GetSession)
+		admin.POST("/sudo", adminSessionHandler.EnterSudo)
+		admin.POST("/logout", adminSessionHandler.Logout)
+		admin.POST("/totp/setup", requireSudo, adminSessionHandler.SetupTOTP)
+		admin.POST("/totp/enable", adminSessionHandler.EnableTOTP)
+		admin.POST("/totp/disable", requireSudo, adminSessionHandler.DisableTOTP)
+
 		// User management
 		admin.GET("/users", adminHandler.ListUsers)
 		admin.POST("/users", adminHandler.CreateUser)
 		admin.PUT("/users/:id", adminHandler.UpdateUser)
-		admin.DELETE("/users/:id", adminHandler.DeleteUser)
+		admin.DELETE("/users/:id", requireSudo, adminHandler.DeleteUser)
+		admin.POST("/users/:id/restore", adminHandler.RestoreUser)
 
 		// Image management
 		admin.POST("/images/upload", adminHandler.UploadImage)
 		admin.GET("/images", adminHandler.ListImages)
-		admin.DELETE("/images/:id", adminHandler.DeleteImage)
+		admin.GET("/images/tags", adminHandler.ListImageTags)
+		admin.GET("/images/report", adminHandler.GetImageReport)
+		admin.POST("/images/orphans/cleanup", adminHandler.CleanupOrphanImages)
+		admin.GET("/images/:id", adminHandler.GetImage)
+		admin.PUT("/images/:id/crop", adminHandler.UpdateImageCrop)
+		admin.PUT("/images/:id/seo", adminHandler.UpdateImageSEO)
+		admin.PUT("/images/:id/tags", adminHandler.SetImageTags)
+		admin.DELETE("/images/:id", requireSudo, adminHandler.DeleteImage)
 
 		// Category management
 		admin.GET("/categories", adminHandler.ListCategories)
 		admin.POST("/categories", adminHandler.CreateCategory)
+		admin.POST("/categories/bulk-activate", adminHandler.BulkActivateCategories)
+		admin.POST("/categories/bulk-deactivate", adminHandler.BulkDeactivateCategories)
 		admin.GET("/categories/:id", adminHandler.GetCategory)
 		admin.PUT("/categories/:id", adminHandler.UpdateCategory)
-		admin.DELETE("/categories/:id", adminHandler.DeleteCategory)
+		admin.DELETE("/categories/:id", requireSudo, adminHandler.DeleteCategory)
 		admin.PATCH("/categories/:id/toggle", adminHandler.ToggleCategoryActive)
+		admin.GET("/categories/export", importHandler.ExportCategories)
+		admin.POST("/categories/import", importHandler.ImportCategories)
+		admin.GET("/categories/:id/translations", translationHandler.ListCategoryTranslations)
+		admin.PUT("/categories/:id/translations/:language", translationHandler.SaveCategoryTranslation)
+		admin.DELETE("/categories/:id/translations/:language", translationHandler.DeleteCategoryTranslation)
+
+		// Slug generation preview for categories and products
+		admin.GET("/slugs/preview", adminHandler.PreviewSlug)
 
 		// Material management
 		admin.GET("/materials", adminHandler.ListMaterials)
 		admin.POST("/materials", adminHandler.CreateMaterial)
 		admin.GET("/materials/:id", adminHandler.GetMaterial)
 		admin.PUT("/materials/:id", adminHandler.UpdateMaterial)
-		admin.DELETE("/materials/:id", adminHandler.DeleteMaterial)
+		admin.DELETE("/materials/:id", requireSudo, adminHandler.DeleteMaterial)
 
 		// Color management
 		admin.GET("/colors", adminHandler.ListColors)
 		admin.POST("/colors", adminHandler.CreateColor)
 		admin.GET("/colors/:id", adminHandler.GetColor)
 		admin.PUT("/colors/:id", adminHandler.UpdateColor)
-		admin.DELETE("/colors/:id", adminHandler.DeleteColor)
+		admin.DELETE("/colors/:id", requireSudo, adminHandler.DeleteColor)
 
 		// Additional Service management
 		admin.GET("/additional-services", adminHandler.ListAdditionalServices)
 		admin.POST("/additional-services", adminHandler.CreateAdditionalService)
 		admin.GET("/additional-services/:id", adminHandler.GetAdditionalService)
 		admin.PUT("/additional-services/:id", adminHandler.UpdateAdditionalService)
-		admin.DELETE("/additional-services/:id", adminHandler.DeleteAdditionalService)
+		admin.DELETE("/additional-services/:id", requireSudo, adminHandler.DeleteAdditionalService)
+		admin.GET("/additional-services/:id/translations", translationHandler.ListAdditionalServiceTranslations)
+		admin.PUT("/additional-services/:id/translations/:language", translationHandler.SaveAdditionalServiceTranslation)
+		admin.DELETE("/additional-services/:id/translations/:language", translationHandler.DeleteAdditionalServiceTranslation)
 
 		// Product management
 		admin.GET("/products", adminHandler.ListProducts)
 		admin.POST("/products", adminHandler.CreateProduct)
+		admin.POST("/products/full", adminHandler.CreateProductSetup)
+		admin.GET("/products/export", importHandler.ExportProducts)
+		admin.POST("/products/import", importHandler.ImportProducts)
+		admin.POST("/products/bulk-delete", requireSudo, adminHandler.BulkDeleteProducts)
+		admin.GET("/products/tags", adminHandler.ListProductTags)
+		admin.PUT("/products/tags/:tag", adminHandler.RenameProductTag)
+		admin.DELETE("/products/tags/:tag", requireSudo, adminHandler.DeleteProductTag)
 		admin.GET("/products/:id", adminHandler.GetProduct)
 		admin.PUT("/products/:id", adminHandler.UpdateProduct)
-		admin.DELETE("/products/:id", adminHandler.DeleteProduct)
+		admin.DELETE("/products/:id", requireSudo, adminHandler.DeleteProduct)
+		admin.GET("/products/:id/completeness", adminHandler.GetProductCompleteness)
+		admin.GET("/products/:id/fulfillment", adminHandler.GetProductFulfillment)
+		admin.PUT("/products/:id/fulfillment", adminHandler.UpdateProductFulfillment)
+		admin.GET("/products/:id/shipping", adminHandler.GetProductShipping)
+		admin.PUT("/products/:id/shipping", adminHandler.UpdateProductShipping)
+		admin.PUT("/products/:id/images/:imageId/seo", adminHandler.UpdateProductImageSEO)
+		admin.PUT("/products/:id/tags", adminHandler.SetProductTags)
+		admin.GET("/products/:id/translations", translationHandler.ListProductTranslations)
+		admin.PUT("/products/:id/translations/:language", translationHandler.SaveProductTranslation)
+		admin.DELETE("/products/:id/translations/:language", translationHandler.DeleteProductTranslation)
+		admin.GET("/products/:id/pairings", pairingHandler.ListProductPairings)
+		admin.PUT("/products/:id/pairings/:relatedId", pairingHandler.SetPairingOverride)
+		admin.DELETE("/products/:id/pairings/:relatedId", pairingHandler.DeletePairingOverride)
+		admin.POST("/pairings/recompute", pairingHandler.RecomputePairings)
+		admin.GET("/products/:id/attachments", attachmentHandler.ListProductAttachments)
+		admin.POST("/products/:id/attachments", attachmentHandler.UploadProductAttachment)
+		admin.POST("/products/:id/attachments/reorder", attachmentHandler.ReorderProductAttachments)
+		admin.PUT("/products/:id/attachments/:attachmentId", attachmentHandler.UpdateProductAttachment)
+		admin.DELETE("/products/:id/attachments/:attachmentId", requireSudo, attachmentHandler.DeleteProductAttachment)
+		admin.GET("/products/:id/option-groups", productOptionHandler.ListProductOptionGroups)
+		admin.POST("/products/:id/option-groups", productOptionHandler.CreateProductOptionGroup)
+		admin.POST("/products/:id/option-groups/from-variants", productOptionHandler.CreateColorOptionGroup)
+		admin.PUT("/products/:id/option-groups/:groupId", productOptionHandler.UpdateProductOptionGroup)
+		admin.DELETE("/products/:id/option-groups/:groupId", requireSudo, productOptionHandler.DeleteProductOptionGroup)
+		admin.GET("/products/:id/revisions", productRevisionHandler.ListProductRevisions)
+		admin.GET("/products/:id/revisions/:revision", productRevisionHandler.GetProductRevision)
+		admin.POST("/products/:id/revisions/:revision/restore", productRevisionHandler.RestoreProductRevision)
+
+		// Catalog snapshots
+		admin.GET("/catalog/snapshots", catalogSnapshotHandler.ListSnapshots)
+		admin.POST("/catalog/snapshots", catalogSnapshotHandler.CreateSnapshot)
+		admin.GET("/catalog/snapshots/:id/download", catalogSnapshotHandler.DownloadSnapshot)
+		admin.DELETE("/catalog/snapshots/:id", requireSudo, catalogSnapshotHandler.DeleteSnapshot)
+		admin.POST("/catalog/snapshots/:id/restore", requireSudo, catalogSnapshotHandler.RestoreSnapshot)
+		admin.POST("/catalog/restore", requireSudo, catalogSnapshotHandler.RestoreArchive)
+		admin.GET("/api-keys", apiKeyHandler.ListAPIKeys)
+		admin.POST("/api-keys", requireSudo, apiKeyHandler.CreateAPIKey)
+		admin.GET("/api-keys/:id/usage", apiKeyHandler.GetAPIKeyUsage)
+		admin.DELETE("/api-keys/:id", requireSudo, apiKeyHandler.RevokeAPIKey)
+
+		// Webhook endpoints and their delivery log
+		admin.GET("/webhooks", webhookHandler.ListWebhookEndpoints)
+		admin.POST("/webhooks", requireSudo, webhookHandler.CreateWebhookEndpoint)
+		admin.PUT("/webhooks/:id", requireSudo, webhookHandler.UpdateWebhookEndpoint)
+		admin.DELETE("/webhooks/:id", requireSudo, webhookHandler.DeleteWebhookEndpoint)
+		admin.GET("/webhooks/deliveries", webhookHandler.ListWebhookDeliveries)
+		admin.POST("/webhooks/deliveries/:id/replay", webhookHandler.ReplayWebhookDelivery)
+
+		// Transactional email templates and outbox
+		admin.GET("/email-templates", emailHandler.ListEmailTemplates)
+		admin.PUT("/email-templates/:key", emailHandler.UpdateEmailTemplate)
+		admin.DELETE("/email-templates/:key", emailHandler.ResetEmailTemplate)
+		admin.GET("/email-outbox", emailHandler.ListEmailOutbox)
+		admin.POST("/email-outbox/:id/retry", emailHandler.RetryEmail)
+		admin.GET("/email-suppressions", emailHandler.ListEmailSuppressions)
+		admin.DELETE("/email-suppressions/:email", emailHandler.DeleteEmailSuppression)
+		// Export center
+		admin.GET("/exports", exportHandler.ListExports)
+		admin.POST("/exports", exportHandler.CreateExport)
+		admin.GET("/exports/:id", exportHandler.GetExport)
+		admin.GET("/exports/:id/download", exportHandler.DownloadExport)
+		admin.GET("/trash", trashHandler.ListTrash)
+		admin.GET("/trash/:id", trashHandler.GetTrashItem)
+		admin.POST("/trash/:id/restore", trashHandler.RestoreTrashItem)
+		admin.DELETE("/trash/:id", requireSudo, trashHandler.PurgeTrashItem)
+
+		// Content change approval
+		admin.POST("/content/changes/:id/approve", contentChangeHandler.ApproveChange)
+		admin.POST("/content/changes/:id/reject", contentChangeHandler.RejectChange)
+		admin.GET("/feeds/:channel", adminHandler.GetChannelFeed)
 
 		// Size management
 		admin.GET("/sizes", adminHandler.ListSizes)
 		admin.POST("/sizes", adminHandler.CreateSize)
 		admin.GET("/sizes/:id", adminHandler.GetSize)
 		admin.PUT("/sizes/:id", adminHandler.UpdateSize)
-		admin.DELETE("/sizes/:id", adminHandler.DeleteSize)
+		admin.DELETE("/sizes/:id", requireSudo, adminHandler.DeleteSize)
 
 		// Product Variant management
 		admin.GET("/product-variants", adminHandler.ListProductVariants)
 		admin.POST("/product-variants", adminHandler.CreateProductVariant)
 		admin.GET("/product-variants/:id", adminHandler.GetProductVariant)
 		admin.PUT("/product-variants/:id", adminHandler.UpdateProductVariant)
-		admin.DELETE("/product-variants/:id", adminHandler.DeleteProductVariant)
+		admin.DELETE("/product-variants/:id", requireSudo, adminHandler.DeleteProductVariant)
 
 		// Order management
+		admin.GET("/cart-sessions/:sessionID/history", cartHandler.GetCartSessionHistory)
 		admin.GET("/orders", adminHandler.ListOrders)
+		admin.GET("/orders/labels", orderLabelHandler.PrintShippingLabels)
+		admin.GET("/orders/export", exportHandler.ExportOrders)
+		admin.GET("/orders/totals-mismatches", adminHandler.ListTotalsMismatches)
+		admin.GET("/orders/calendar", adminHandler.GetOrderCalendar)
+		admin.GET("/orders/board", adminHandler.GetOrderBoard)
+		admin.GET("/orders/duplicates", adminHandler.ListDuplicateOrders)
+		admin.POST("/orders/bulk-status", adminHandler.BulkUpdateOrderStatus)
 		admin.GET("/orders/:id", adminHandler.GetOrderDetails)
+		admin.POST("/orders/:id/actions", orderActionHandler.RunOrderAction)
+		admin.POST("/orders/:id/shipment", shipmentHandler.CreateShipment)
+		admin.GET("/orders/:id/shipment", shipmentHandler.GetShipment)
+		admin.GET("/orders/:id/shipment/label", shipmentHandler.GetShipmentLabel)
+		admin.GET("/orders/:id/fulfillment", orderFulfillmentHandler.GetOrderFulfillment)
+		admin.PUT("/orders/:id/fulfillment", orderFulfillmentHandler.UpdateOrderFulfillment)
+		admin.GET("/orders/:id/packing-slip", orderFulfillmentHandler.PrintPackingSlip)
+		admin.POST("/fulfillment/scan", orderFulfillmentHandler.ScanOrderItem)
 		admin.PUT("/orders/:id/status", adminHandler.UpdateOrderStatus)
-		admin.DELETE("/orders/:id", adminHandler.DeleteOrder)
+		admin.PUT("/orders/:id/test", adminHandler.SetOrderTest)
+		admin.DELETE("/orders/:id", requireSudo, adminHandler.DeleteOrder)
+		admin.PUT("/orders/:id/shipping-address", adminHandler.UpdateOrderShippingAddress)
+		admin.PUT("/orders/:id/items/:itemId/size", adminHandler.UpdateOrderItemSize)
+		admin.GET("/orders/:id/legal-acceptances", legalHandler.GetOrderAcceptances)
+
+		// Production capacity per day and the days blocked out of it
+		admin.GET("/production/capacity", productionHandler.GetCapacity)
+		admin.GET("/production/blocked-days", productionHandler.ListBlockedDays)
+		admin.POST("/production/blocked-days", productionHandler.BlockDay)
+		admin.DELETE("/production/blocked-days/:date", productionHandler.UnblockDay)
+
+		// Order change request queue
+		admin.GET("/order-change-requests", orderChangeHandler.ListChangeRequests)
+		admin.POST("/order-change-requests/:id/approve", orderChangeHandler.ApproveChangeRequest)
+		admin.POST("/order-change-requests/:id/reject", orderChangeHandler.RejectChangeRequest)
+
+		// Order returns and their return labels
+		admin.GET("/returns", orderReturnHandler.ListReturns)
+		admin.GET("/returns/:id", orderReturnHandler.GetReturn)
+		admin.GET("/returns/:id/label", orderReturnHandler.GetReturnLabel)
+		admin.POST("/returns/:id/approve", orderReturnHandler.ApproveReturn)
+		admin.POST("/returns/:id/reject", orderReturnHandler.RejectReturn)
+		admin.POST("/returns/:id/receive", orderReturnHandler.ReceiveReturn)
+
+		// Refund management
+		admin.GET("/orders/:id/refunds", refundHandler.GetOrderRefunds)
+		admin.POST("/orders/:id/refunds", refundHandler.CreateRefund)
+		admin.GET("/reports/sales", refundHandler.GetSalesReport)
 		
 		// Discount code management
 		admin.GET("/discount-codes", discountHandler.GetDiscountCodes)
 		admin.POST("/discount-codes", discountHandler.CreateDiscountCode)
 		admin.GET("/discount-codes/:id", discountHandler.GetDiscountCode)
 		admin.PUT("/discount-codes/:id", discountHandler.UpdateDiscountCode)
-		admin.DELETE("/discount-codes/:id", discountHandler.DeleteDiscountCode)
+		admin.DELETE("/discount-codes/:id", requireSudo, discountHandler.DeleteDiscountCode)
 		admin.GET("/discount-codes/:id/usage", discountHandler.GetDiscountCodeUsage)
+		admin.GET("/discount-codes/:id/users", discountHandler.GetDiscountCodeUsers)
+		admin.POST("/discount-codes/:id/users", discountHandler.AddDiscountCodeUser)
+		admin.DELETE("/discount-codes/:id/users/:userId", discountHandler.RemoveDiscountCodeUser)
+		admin.GET("/discount-codes/export", importHandler.ExportDiscountCodes)
+		admin.POST("/discount-codes/import", importHandler.ImportDiscountCodes)
 		
 		// Settings management
 		admin.GET("/settings", adminHandler.GetSettings)
 		admin.PUT("/settings/:key", adminHandler.UpdateSetting)
+
+		// Terms and privacy policy versions
+		admin.GET("/legal/documents", legalHandler.ListDocuments)
+		admin.POST("/legal/documents", legalHandler.PublishDocument)
+
+		// Storefront analytics
+		admin.GET("/analytics/funnel", analyticsHandler.GetFunnelReport)
+
+		// Debug captures
+		admin.GET("/debug-captures", debugCaptureHandler.ListDebugCaptures)
+		admin.POST("/debug-captures", debugCaptureHandler.CreateDebugCapture)
+		admin.POST("/debug-captures/:id/stop", debugCaptureHandler.StopDebugCapture)
+		admin.GET("/debug-captures/:id/entries", debugCaptureHandler.GetDebugCaptureEntries)
+
+		// Data retention
+		admin.GET("/retention/preview", retentionHandler.PreviewRetention)
+		admin.POST("/retention/run", requireSudo, retentionHandler.RunRetention)
+
+		// Shops (brands) served by this backend
+		admin.GET("/shops", shopHandler.ListShops)
+		admin.POST("/shops", shopHandler.CreateShop)
+		admin.PUT("/shops/:id", shopHandler.UpdateShop)
+
+		// Currencies prices can be shown in and their exchange rates
+		admin.GET("/currencies", currencyHandler.ListAllCurrencies)
+		admin.PUT("/currencies/:code", currencyHandler.SaveCurrency)
+		admin.POST("/currencies/rates/update", currencyHandler.UpdateRates)
+
+		// Shipping methods offered at checkout
+		admin.GET("/shipping-methods", shippingMethodHandler.ListShippingMethods)
+		admin.POST("/shipping-methods", shippingMethodHandler.CreateShippingMethod)
+		admin.PUT("/shipping-methods/:id", shippingMethodHandler.UpdateShippingMethod)
+		admin.DELETE("/shipping-methods/:id", requireSudo, shippingMethodHandler.DeleteShippingMethod)
+		admin.GET("/order-statuses", orderStatusHandler.ListOrderStatuses)
+		admin.POST("/order-statuses", orderStatusHandler.CreateOrderStatus)
+		admin.PUT("/order-statuses/:id", orderStatusHandler.UpdateOrderStatus)
+		admin.DELETE("/order-statuses/:id", requireSudo, orderStatusHandler.DeleteOrderStatus)
+
+		// Warehouses and stock movements
+		admin.GET("/warehouses", warehouseHandler.ListWarehouses)
+		admin.POST("/warehouses", warehouseHandler.CreateWarehouse)
+		admin.PUT("/warehouses/:id", warehouseHandler.UpdateWarehouse)
+		admin.DELETE("/warehouses/:id", requireSudo, warehouseHandler.DeleteWarehouse)
+		admin.PUT("/warehouses/:id/stock", warehouseHandler.SetWarehouseStock)
+		admin.GET("/warehouses/:id/pick-list", warehouseHandler.GetPickList)
+		admin.POST("/warehouses/:id/pick-list/picked", warehouseHandler.MarkPicked)
+		admin.GET("/sizes/:id/warehouse-stock", warehouseHandler.GetSizeWarehouseStock)
+		admin.POST("/stock-transfers", warehouseHandler.TransferStock)
+		admin.PATCH("/stock", warehouseHandler.BulkUpdateStock)
+		admin.GET("/stock-movements", warehouseHandler.ListStockMovements)
+		admin.GET("/stock-reservations", stockReservationHandler.GetReservationStats)
+		admin.GET("/search/reindex", searchIndexHandler.GetReindexStatus)
+		admin.POST("/search/reindex", searchIndexHandler.Reindex)
+		admin.GET("/live-stats", liveStatsHandler.GetLiveStats)
 		
 		// Client reviews management
 		admin.GET("/client-reviews", adminHandler.ListClientReviews)
 		admin.POST("/client-reviews", adminHandler.CreateClientReview)
 		admin.GET("/client-reviews/:id", adminHandler.GetClientReview)
 		admin.PUT("/client-reviews/:id", adminHandler.UpdateClientReview)
-		admin.DELETE("/client-reviews/:id", adminHandler.DeleteClientReview)
+		admin.DELETE("/client-reviews/:id", requireSudo, adminHandler.DeleteClientReview)
 		admin.POST("/client-reviews/reorder", adminHandler.ReorderClientReviews)
+
+		// Customer product review moderation
+		admin.GET("/product-reviews", productReviewHandler.ListProductReviews)
+		admin.POST("/product-reviews/:id/approve", productReviewHandler.ApproveProductReview)
+		admin.POST("/product-reviews/:id/reject", productReviewHandler.RejectProductReview)
+		admin.DELETE("/product-reviews/:id", requireSudo, productReviewHandler.DeleteProductReview)
+	}
+
+	// OpenAPI specification of every route registered above
+	if cfg.APIDocs {
+		apiDocsHandler := handlers.NewAPIDocsHandler()
+		info := openapi.Info{Title: "NotSoFluffy API", Version: version.Get().Tag}
+		if err := apiDocsHandler.SetRoutes(info, r.Routes(), handlers.APIAnnotations()); err != nil {
+			log.Fatal("Failed to build API specification:", err)
+		}
+		r.GET("/api/docs", apiDocsHandler.GetUI)
+		r.GET("/api/docs/openapi.json", apiDocsHandler.GetSpec)
 	}
 
 	port := os.Getenv("PORT")
@@ -253,6 +869,7 @@ func main() {
 
 	// Log startup information
 	log.Printf("=== NotSoFluffy API Server ===")
+	log.Printf("Version: %s", version.Get())
 	log.Printf("Environment: %s", getEnv("ENVIRONMENT", "development"))
 	log.Printf("Port: %s", port)
 	log.Printf("Database SSL: %s", cfg.DBSSLMode)
@@ -279,6 +896,10 @@ func main() {
 
 	log.Println("Shutting down server...")
 
+	// Stop background jobs
+	stopJobs()
+	scheduler.Wait()
+
 	// Create a context with timeout for graceful shutdown
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"notsofluffy-backend/internal/database"
+)
+
+// printMigrationCheck lists the pending migrations with their pre-flight findings and
+// reports whether all of them can run without forcing
+func printMigrationCheck(w io.Writer, pending []database.PendingMigration) bool {
+	if len(pending) == 0 {
+		fmt.Fprintln(w, "No pending migrations")
+		return true
+	}
+
+	safe := true
+	for _, migration := range pending {
+		fmt.Fprintf(w, "Migration %d (%s)", migration.Version, migration.Phase)
+		if len(migration.Findings) == 0 {
+			fmt.Fprintln(w, ": ok")
+			continue
+		}
+		fmt.Fprintln(w)
+		for _, finding := range migration.Findings {
+			rows := "unknown size"
+			if finding.EstimatedRows >= 0 {
+				rows = fmt.Sprintf("~%d rows", finding.EstimatedRows)
+			}
+			fmt.Fprintf(w, "  %s: %s (%s) %s\n", finding.Severity, finding.Table, rows, finding.Message)
+		}
+		if migration.Dangerous() {
+			safe = false
+		}
+	}
+	if !safe {
+		fmt.Fprintln(w, "Dangerous migrations only run with -force-migrations")
+	}
+	return safe
+}
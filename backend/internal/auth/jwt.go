@@ -1,6 +1,8 @@
 package auth
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"time"
 
@@ -11,14 +13,21 @@ type Claims struct {
 	UserID int    `json:"user_id"`
 	Email  string `json:"email"`
 	Role   string `json:"role"`
+	// SessionID ties admin tokens to a server-side session used for idle timeout and sudo mode
+	SessionID string `json:"sid,omitempty"`
+	// TokenVersion is the user's token version at issue; signing out everywhere increments
+	// it, which revokes all tokens carrying an older one
+	TokenVersion int `json:"ver"`
 	jwt.RegisteredClaims
 }
 
-func GenerateAccessToken(userID int, email, role, secret string) (string, error) {
+func GenerateAccessToken(userID int, email, role, sessionID string, tokenVersion int, secret string) (string, error) {
 	claims := &Claims{
-		UserID: userID,
-		Email:  email,
-		Role:   role,
+		UserID:       userID,
+		Email:        email,
+		Role:         role,
+		SessionID:    sessionID,
+		TokenVersion: tokenVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(15 * time.Minute)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -32,17 +41,30 @@ func GenerateAccessToken(userID int, email, role, secret string) (string, error)
 	return token.SignedString([]byte(secret))
 }
 
-func GenerateRefreshToken(userID int, email, role, secret string) (string, error) {
+// RefreshTokenTTL is how long a refresh token works; every refresh rotates it
+const RefreshTokenTTL = 7 * 24 * time.Hour
+
+// GenerateRefreshToken returns a refresh token with a random ID, so no two tokens are
+// the same even when issued in the same second
+func GenerateRefreshToken(userID int, email, role, sessionID string, tokenVersion int, secret string) (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("failed to generate token ID: %w", err)
+	}
+
 	claims := &Claims{
-		UserID: userID,
-		Email:  email,
-		Role:   role,
+		UserID:       userID,
+		Email:        email,
+		Role:         role,
+		SessionID:    sessionID,
+		TokenVersion: tokenVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(7 * 24 * time.Hour)), // 7 days
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(RefreshTokenTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			Issuer:    "notsofluffy",
 			Subject:   fmt.Sprintf("%d", userID),
+			ID:        hex.EncodeToString(id),
 		},
 	}
 
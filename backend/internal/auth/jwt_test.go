@@ -0,0 +1,26 @@
+package auth
+
+import "testing"
+
+func TestTokenVersionClaim(t *testing.T) {
+	const secret = "test-secret"
+
+	access, err := GenerateAccessToken(7, "user@example.com", "client", "", 3, secret)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	refresh, err := GenerateRefreshToken(7, "user@example.com", "client", "", 3, secret)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for name, token := range map[string]string{"access": access, "refresh": refresh} {
+		claims, err := ValidateToken(token, secret)
+		if err != nil {
+			t.Fatalf("%s token: unexpected error: %v", name, err)
+		}
+		if claims.TokenVersion != 3 {
+			t.Errorf("%s token: got token version %d, want 3", name, claims.TokenVersion)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+func TestValidateTOTP(t *testing.T) {
+	// RFC 6238 SHA-1 test secret; the 8-digit vector at T=59s is 94287082
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(59, 0)
+
+	if !ValidateTOTP(secret, "287082", now) {
+		t.Fatal("expected RFC 6238 test vector to be valid")
+	}
+	if !ValidateTOTP(secret, "287082", now.Add(30*time.Second)) {
+		t.Error("expected code from the previous period to be accepted")
+	}
+	if ValidateTOTP(secret, "287082", now.Add(2*time.Minute)) {
+		t.Error("expected stale code to be rejected")
+	}
+	if ValidateTOTP(secret, "28708", now) {
+		t.Error("expected short code to be rejected")
+	}
+	if ValidateTOTP("not base32!", "287082", now) {
+		t.Error("expected invalid secret to be rejected")
+	}
+}
+
+func TestMatchTOTP(t *testing.T) {
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	step, ok := MatchTOTP(secret, "287082", time.Unix(59, 0))
+	if !ok || step != 1 {
+		t.Fatalf("got step %d, ok %v; want step 1", step, ok)
+	}
+	step, ok = MatchTOTP(secret, "287082", time.Unix(89, 0))
+	if !ok || step != 1 {
+		t.Errorf("got step %d, ok %v for the previous period; want step 1", step, ok)
+	}
+	if _, ok := MatchTOTP(secret, "000000", time.Unix(59, 0)); ok {
+		t.Error("expected wrong code to be rejected")
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	code := totpCode(mustDecode(t, secret), uint64(time.Now().Unix()/totpPeriod))
+	if !ValidateTOTP(secret, code, time.Now()) {
+		t.Error("expected code generated for a new secret to validate")
+	}
+}
+
+func mustDecode(t *testing.T, secret string) []byte {
+	t.Helper()
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		t.Fatalf("failed to decode secret: %v", err)
+	}
+	return key
+}
@@ -0,0 +1,65 @@
+package captcha
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSiteVerifyProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("failed to parse form: %v", err)
+		}
+		if r.PostForm.Get("secret") != "secret" || r.PostForm.Get("remoteip") != "203.0.113.7" {
+			t.Errorf("unexpected form %v", r.PostForm)
+		}
+		if r.PostForm.Get("response") == "good" {
+			w.Write([]byte(`{"success": true}`))
+			return
+		}
+		w.Write([]byte(`{"success": false, "error-codes": ["invalid-input-response"]}`))
+	}))
+	defer server.Close()
+
+	provider := newSiteVerifyProvider("test", server.URL, "secret", time.Second)
+
+	result, err := provider.Verify(context.Background(), "good", "203.0.113.7")
+	if err != nil || !result.Success {
+		t.Fatalf("Verify(good) = %+v, %v, want success", result, err)
+	}
+
+	result, err = provider.Verify(context.Background(), "bad", "203.0.113.7")
+	if err != nil || result.Success || len(result.ErrorCodes) != 1 || result.ErrorCodes[0] != "invalid-input-response" {
+		t.Fatalf("Verify(bad) = %+v, %v, want failure with error code", result, err)
+	}
+}
+
+func TestSiteVerifyProviderUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	provider := newSiteVerifyProvider("test", server.URL, "secret", time.Second)
+	if _, err := provider.Verify(context.Background(), "token", ""); err == nil {
+		t.Fatal("expected an error for a failing provider")
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	if provider, err := NewProvider("", "", time.Second); provider != nil || err != nil {
+		t.Errorf("NewProvider(\"\") = %v, %v, want nil, nil", provider, err)
+	}
+	for _, name := range []string{"hcaptcha", "turnstile"} {
+		provider, err := NewProvider(name, "secret", time.Second)
+		if err != nil || provider.Name() != name {
+			t.Errorf("NewProvider(%q) = %v, %v", name, provider, err)
+		}
+	}
+	if _, err := NewProvider("recaptcha", "secret", time.Second); err == nil {
+		t.Error("expected an error for an unknown provider")
+	}
+}
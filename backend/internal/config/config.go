@@ -26,8 +26,73 @@ type Config struct {
 	DBSSLKey      string
 	DBSSLRootCert string
 
+	// DBStatementTimeoutMS cancels database statements of request handlers running
+	// longer than this, so requests past their deadline do not keep queries alive.
+	// Migrations and background jobs run without it.
+	DBStatementTimeoutMS int
+
+	// Storefront base URL used for canonical links, e.g. https://notsofluffy.pl
+	SiteURL string
+
+	// GeoIP configuration: lookup API URL with an {ip} placeholder and an optional
+	// trusted proxy header carrying the visitor country (e.g. CF-IPCountry)
+	GeoIPURL           string
+	GeoIPCountryHeader string
+
+	// SMTP server for outgoing email; emails are only logged when SMTPHost is empty
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	MailFrom     string
+
+	// Secret the email provider's bounce and complaint notifications are sent with;
+	// they are refused while it is empty
+	EmailWebhookSecret string
+
+	// Challenge provider ("hcaptcha" or "turnstile") protecting public forms; the
+	// endpoints it applies to are switched on in the captcha_* settings
+	CaptchaProvider string
+	CaptchaSecret   string
+	CaptchaSiteKey  string
+
+	// Online payment provider ("stripe" or "przelewy24") charging orders paid online;
+	// online payments are off when PaymentProvider is empty
+	PaymentProvider     string
+	StripeSecretKey     string
+	StripeWebhookSecret string
+	P24MerchantID       int
+	P24PosID            int
+	P24APIKey           string
+	P24CRC              string
+	P24Sandbox          bool
+	// PaymentCallbackURL is this API's public base URL, e.g. https://api.notsofluffy.pl,
+	// which providers notify about payments
+	PaymentCallbackURL string
+	// PaymentReturnURL is where customers land after paying; {hash} is replaced with the
+	// order's public hash
+	PaymentReturnURL string
+
+	// Carrier API ("inpost") shipments are ordered from; shipments are created by hand
+	// when ShippingProvider is empty
+	ShippingProvider     string
+	InPostToken          string
+	InPostOrganizationID string
+	InPostSandbox        bool
+
+	// Token bucket rate limits per client IP of route groups, written as
+	// "<requests>/<period>[:<burst>]", e.g. "10/1m:20", or "off". Buckets are kept in
+	// Redis when RedisURL is set so all instances share them, in memory otherwise.
+	RedisURL          string
+	RateLimitAuth     string
+	RateLimitDiscount string
+	RateLimitOrders   string
+
 	// Development mode
 	Development bool
+
+	// Serve the OpenAPI specification and Swagger UI at /api/docs
+	APIDocs bool
 }
 
 func Load() *Config {
@@ -51,9 +116,61 @@ func Load() *Config {
 		DBSSLKey:      getEnv("DB_SSL_KEY", ""),
 		DBSSLRootCert: getEnv("DB_SSL_ROOT_CERT", ""),
 
+		DBStatementTimeoutMS: getIntEnv("DB_STATEMENT_TIMEOUT_MS", 15000),
+
+		// Storefront configuration
+		SiteURL: strings.TrimRight(getEnv("SITE_URL", ""), "/"),
+
+		// GeoIP configuration
+		GeoIPURL:           getEnv("GEOIP_URL", ""),
+		GeoIPCountryHeader: getEnv("GEOIP_COUNTRY_HEADER", ""),
+
+		// Email configuration
+		SMTPHost:     getEnv("SMTP_HOST", ""),
+		SMTPPort:     getEnv("SMTP_PORT", "587"),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		MailFrom:     getEnv("MAIL_FROM", "NotSoFluffy <no-reply@notsofluffy.pl>"),
+
+		EmailWebhookSecret: getEnv("EMAIL_WEBHOOK_SECRET", ""),
+
+		// Challenge configuration
+		CaptchaProvider: strings.ToLower(getEnv("CAPTCHA_PROVIDER", "")),
+		CaptchaSecret:   getEnv("CAPTCHA_SECRET", ""),
+		CaptchaSiteKey:  getEnv("CAPTCHA_SITE_KEY", ""),
+
+		// Payment configuration
+		PaymentProvider:     strings.ToLower(getEnv("PAYMENT_PROVIDER", "")),
+		StripeSecretKey:     getEnv("STRIPE_SECRET_KEY", ""),
+		StripeWebhookSecret: getEnv("STRIPE_WEBHOOK_SECRET", ""),
+		P24MerchantID:       getIntEnv("P24_MERCHANT_ID", 0),
+		P24PosID:            getIntEnv("P24_POS_ID", 0),
+		P24APIKey:           getEnv("P24_API_KEY", ""),
+		P24CRC:              getEnv("P24_CRC", ""),
+		P24Sandbox:          getBoolEnv("P24_SANDBOX", false),
+		PaymentCallbackURL:  strings.TrimRight(getEnv("PAYMENT_CALLBACK_URL", ""), "/"),
+		PaymentReturnURL:    getEnv("PAYMENT_RETURN_URL", ""),
+
+		// Shipping configuration
+		ShippingProvider:     strings.ToLower(getEnv("SHIPPING_PROVIDER", "")),
+		InPostToken:          getEnv("INPOST_API_TOKEN", ""),
+		InPostOrganizationID: getEnv("INPOST_ORGANIZATION_ID", ""),
+		InPostSandbox:        getBoolEnv("INPOST_SANDBOX", false),
+
+		// Rate limit configuration
+		RedisURL:          getEnv("REDIS_URL", ""),
+		RateLimitAuth:     getEnv("RATE_LIMIT_AUTH", "10/1m"),
+		RateLimitDiscount: getEnv("RATE_LIMIT_DISCOUNT", "10/1m"),
+		RateLimitOrders:   getEnv("RATE_LIMIT_ORDERS", "5/1m"),
+
 		// Development mode
 		Development: getBoolEnv("DEVELOPMENT", true),
 	}
+	cfg.APIDocs = getBoolEnv("API_DOCS", cfg.Development)
+
+	if cfg.PaymentReturnURL == "" && cfg.SiteURL != "" {
+		cfg.PaymentReturnURL = cfg.SiteURL + "/order/{hash}"
+	}
 
 	// Update database URL with SSL configuration if provided
 	if cfg.DBSSLMode != "disable" {
@@ -79,6 +196,15 @@ func getBoolEnv(key string, defaultValue bool) bool {
 	return defaultValue
 }
 
+func getIntEnv(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
 func getSliceEnv(key string, defaultValue []string) []string {
 	if value := os.Getenv(key); value != "" {
 		return strings.Split(value, ",")
@@ -0,0 +1,267 @@
+// Package csvimport reads and writes the CSV files of admin bulk imports and exports.
+// Files are UTF-8 with a header row; semicolon separated files, as saved by spreadsheets
+// in Polish locale, are read as well.
+package csvimport
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"notsofluffy-backend/internal/models"
+)
+
+// MaxRows bounds the data rows of one import
+const MaxRows = 5000
+
+// utf8BOM is written at the start of exports so spreadsheets detect the encoding
+const utf8BOM = "\xef\xbb\xbf"
+
+// Columns describes the header of an import
+type Columns struct {
+	Required []string
+	Optional []string
+}
+
+// All returns the required and optional columns in order, as used for exports
+func (c Columns) All() []string {
+	return append(append([]string{}, c.Required...), c.Optional...)
+}
+
+// Row is a data row of an import, keyed by column name
+type Row struct {
+	// Line is the line number in the file, counting the header as line 1
+	Line   int
+	values map[string]string
+}
+
+// Get returns the trimmed value of a column, or "" when the file doesn't have it
+func (r Row) Get(column string) string {
+	return strings.TrimSpace(r.values[column])
+}
+
+// Has reports whether the file has the column
+func (r Row) Has(column string) bool {
+	_, ok := r.values[column]
+	return ok
+}
+
+// Error returns a validation error of a cell in the row
+func (r Row) Error(column, message string) models.ImportRowError {
+	return models.ImportRowError{Line: r.Line, Column: column, Message: message}
+}
+
+// Read parses a CSV file. Header names are matched case-insensitively; a missing
+// required column or an unknown one fails the whole file.
+func Read(r io.Reader, columns Columns) ([]Row, error) {
+	buffered := bufio.NewReader(r)
+	if bom, _ := buffered.Peek(len(utf8BOM)); string(bom) == utf8BOM {
+		buffered.Discard(len(utf8BOM))
+	}
+	firstLine, _ := buffered.Peek(4096)
+	if end := bytes.IndexByte(firstLine, '\n'); end >= 0 {
+		firstLine = firstLine[:end]
+	}
+
+	reader := csv.NewReader(buffered)
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	if err := readHeader(header, columns); err != nil {
+		return nil, err
+	}
+
+	rows := []Row{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		if len(rows) == MaxRows {
+			return nil, fmt.Errorf("file has more than %d rows", MaxRows)
+		}
+
+		line, _ := reader.FieldPos(0)
+		rows = append(rows, newRow(line, header, record))
+	}
+	return rows, nil
+}
+
+// ReadRecords parses the rows of a spreadsheet like Read, the header first. Line numbers
+// count the records from 1; records with only empty cells are skipped.
+func ReadRecords(records [][]string, columns Columns) ([]Row, error) {
+	if len(records) == 0 {
+		return nil, fmt.Errorf("file is empty")
+	}
+	header := append([]string{}, records[0]...)
+	// Spreadsheets may keep empty cells after the last column
+	for len(header) > 0 && strings.TrimSpace(header[len(header)-1]) == "" {
+		header = header[:len(header)-1]
+	}
+	if err := readHeader(header, columns); err != nil {
+		return nil, err
+	}
+
+	rows := []Row{}
+	for i, record := range records[1:] {
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+		if len(rows) == MaxRows {
+			return nil, fmt.Errorf("file has more than %d rows", MaxRows)
+		}
+		rows = append(rows, newRow(i+2, header, record))
+	}
+	return rows, nil
+}
+
+// readHeader normalizes the column names of a header in place and checks them against
+// columns
+func readHeader(header []string, columns Columns) error {
+	known := map[string]bool{}
+	for _, column := range columns.All() {
+		known[column] = true
+	}
+	seen := map[string]bool{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !known[name] {
+			return fmt.Errorf("unknown column %q", name)
+		}
+		if seen[name] {
+			return fmt.Errorf("duplicate column %q", name)
+		}
+		seen[name] = true
+		header[i] = name
+	}
+	for _, column := range columns.Required {
+		if !seen[column] {
+			return fmt.Errorf("missing required column %q", column)
+		}
+	}
+	return nil
+}
+
+func newRow(line int, header, record []string) Row {
+	row := Row{Line: line, values: make(map[string]string, len(header))}
+	for i, name := range header {
+		if i < len(record) {
+			row.values[name] = record[i]
+		} else {
+			row.values[name] = ""
+		}
+	}
+	return row
+}
+
+// Write writes an export with the given header
+func Write(w io.Writer, header []string, records [][]string) error {
+	writer, err := NewWriter(w, header)
+	if err != nil {
+		return err
+	}
+	if err := writer.WriteAll(records); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// NewWriter starts an export with the given header, for exports written a row at a
+// time. Callers must Flush the writer and check its Error.
+func NewWriter(w io.Writer, header []string) (*csv.Writer, error) {
+	if _, err := io.WriteString(w, utf8BOM); err != nil {
+		return nil, err
+	}
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+// ParseBool parses yes/no values; empty cells give defaultValue
+func ParseBool(value string, defaultValue bool) (bool, error) {
+	switch strings.ToLower(value) {
+	case "":
+		return defaultValue, nil
+	case "true", "1", "yes", "y", "tak":
+		return true, nil
+	case "false", "0", "no", "n", "nie":
+		return false, nil
+	}
+	return false, fmt.Errorf("must be true or false")
+}
+
+// ParseFloat parses a number, accepting a decimal comma
+func ParseFloat(value string) (float64, error) {
+	number, err := strconv.ParseFloat(strings.ReplaceAll(value, ",", "."), 64)
+	if err != nil {
+		return 0, fmt.Errorf("must be a number")
+	}
+	return number, nil
+}
+
+// ParseOptionalInt parses a whole number; empty cells give nil
+func ParseOptionalInt(value string) (*int, error) {
+	if value == "" {
+		return nil, nil
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("must be a whole number")
+	}
+	return &number, nil
+}
+
+// timeLayouts are the accepted date formats; the ones without a zone are in loc
+var timeLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"}
+
+// ParseTime parses a date or date and time
+func ParseTime(value string, loc *time.Location) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("must be a date like 2024-05-01 or 2024-05-01 12:00")
+}
+
+// FormatBool formats a yes/no value for export
+func FormatBool(value bool) string {
+	return strconv.FormatBool(value)
+}
+
+// FormatFloat formats a number for export without needless digits
+func FormatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// FormatOptionalInt formats a number that may be missing
+func FormatOptionalInt(value *int) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.Itoa(*value)
+}
+
+// FormatTime formats a time in loc for export
+func FormatTime(value time.Time, loc *time.Location) string {
+	return value.In(loc).Format("2006-01-02 15:04:05")
+}
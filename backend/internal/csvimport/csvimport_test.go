@@ -0,0 +1,96 @@
+package csvimport
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testColumns = Columns{Required: []string{"slug", "name"}, Optional: []string{"active"}}
+
+func TestReadSemicolonFileWithBOM(t *testing.T) {
+	data := utf8BOM + "Slug;Name\nobroze;Obroże\n\n\"szelki\";\"Szelki; regulowane\"\n"
+	rows, err := Read(strings.NewReader(data), testColumns)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("Read() returned %d rows, want 2", len(rows))
+	}
+	if rows[0].Get("name") != "Obroże" || rows[0].Line != 2 {
+		t.Errorf("first row = %+v, want Obroże on line 2", rows[0])
+	}
+	if rows[1].Get("name") != "Szelki; regulowane" || rows[1].Line != 4 {
+		t.Errorf("second row = %+v, want quoted name on line 4", rows[1])
+	}
+	if rows[0].Has("active") {
+		t.Error("Has(active) = true for a column missing from the file")
+	}
+}
+
+func TestReadHeaderErrors(t *testing.T) {
+	tests := map[string]string{
+		"":                        "file is empty",
+		"slug\nx\n":               `missing required column "name"`,
+		"slug,name,color\n":       `unknown column "color"`,
+		"slug,name,slug\nx,y,z\n": `duplicate column "slug"`,
+	}
+	for data, want := range tests {
+		if _, err := Read(strings.NewReader(data), testColumns); err == nil || err.Error() != want {
+			t.Errorf("Read(%q) error = %v, want %q", data, err, want)
+		}
+	}
+}
+
+func TestReadRecordsSkipsEmptyRows(t *testing.T) {
+	records := [][]string{{"Slug", "Name", ""}, {"obroze", "Obroże"}, {}, {"", " "}, {"szelki", "Szelki", ""}}
+	rows, err := ReadRecords(records, testColumns)
+	if err != nil {
+		t.Fatalf("ReadRecords() error = %v", err)
+	}
+	if len(rows) != 2 || rows[0].Line != 2 || rows[1].Line != 5 || rows[1].Get("name") != "Szelki" {
+		t.Fatalf("ReadRecords() = %+v, want two rows on lines 2 and 5", rows)
+	}
+	if _, err := ReadRecords(nil, testColumns); err == nil || err.Error() != "file is empty" {
+		t.Errorf("ReadRecords(nil) error = %v, want file is empty", err)
+	}
+}
+
+func TestWriteRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, testColumns.All(), [][]string{{"a", "Name, with comma", "true"}}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	rows, err := Read(&buf, testColumns)
+	if err != nil || len(rows) != 1 || rows[0].Get("name") != "Name, with comma" {
+		t.Fatalf("Read(Write()) = %+v, %v", rows, err)
+	}
+}
+
+func TestParsers(t *testing.T) {
+	if v, err := ParseBool("TAK", false); err != nil || !v {
+		t.Errorf("ParseBool(TAK) = %v, %v", v, err)
+	}
+	if v, err := ParseBool("", true); err != nil || !v {
+		t.Errorf("ParseBool(\"\") = %v, %v, want default", v, err)
+	}
+	if _, err := ParseBool("maybe", false); err == nil {
+		t.Error("ParseBool(maybe) expected an error")
+	}
+	if v, err := ParseFloat("12,50"); err != nil || v != 12.5 {
+		t.Errorf("ParseFloat(12,50) = %v, %v", v, err)
+	}
+	if v, err := ParseOptionalInt(""); err != nil || v != nil {
+		t.Errorf("ParseOptionalInt(\"\") = %v, %v", v, err)
+	}
+
+	loc, _ := time.LoadLocation("Europe/Warsaw")
+	got, err := ParseTime("2024-07-01 10:00", loc)
+	if err != nil || !got.Equal(time.Date(2024, 7, 1, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("ParseTime() = %v, %v", got, err)
+	}
+	if FormatTime(got, loc) != "2024-07-01 10:00:00" {
+		t.Errorf("FormatTime() = %s", FormatTime(got, loc))
+	}
+}
@@ -0,0 +1,191 @@
+package database
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"notsofluffy-backend/internal/models"
+)
+
+type AdminSessionQueries struct {
+	db       *sql.DB
+	settings *SettingsQueries
+}
+
+func NewAdminSessionQueries(db *sql.DB) *AdminSessionQueries {
+	return &AdminSessionQueries{db: db, settings: NewSettingsQueries(db)}
+}
+
+// CreateSession starts a new admin session
+func (q *AdminSessionQueries) CreateSession(userID int, ipAddress string, userAgent *string) (*models.AdminSession, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, fmt.Errorf("failed to generate session ID: %w", err)
+	}
+
+	session := &models.AdminSession{
+		ID:        hex.EncodeToString(buf),
+		UserID:    userID,
+		IPAddress: ipAddress,
+		UserAgent: userAgent,
+	}
+
+	err := q.db.QueryRow(`
+		INSERT INTO admin_sessions (id, user_id, ip_address, user_agent)
+		VALUES ($1, $2, $3, $4)
+		RETURNING last_seen_at, created_at`,
+		session.ID, session.UserID, session.IPAddress, session.UserAgent,
+	).Scan(&session.LastSeenAt, &session.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create admin session: %w", err)
+	}
+
+	return session, nil
+}
+
+// GetSession retrieves an admin session by ID
+func (q *AdminSessionQueries) GetSession(id string) (*models.AdminSession, error) {
+	var s models.AdminSession
+	err := q.db.QueryRow(`
+		SELECT id, user_id, ip_address, user_agent, last_seen_at, sudo_at, revoked_at, created_at
+		FROM admin_sessions WHERE id = $1`,
+		id,
+	).Scan(&s.ID, &s.UserID, &s.IPAddress, &s.UserAgent, &s.LastSeenAt, &s.SudoAt, &s.RevokedAt, &s.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("admin session not found")
+		}
+		return nil, fmt.Errorf("failed to get admin session: %w", err)
+	}
+	return &s, nil
+}
+
+// GetIdleTimeout returns the configured admin idle timeout; zero disables it
+func (q *AdminSessionQueries) GetIdleTimeout() (time.Duration, error) {
+	minutes, err := q.settings.GetIntSetting(models.SettingAdminIdleTimeoutMinutes, models.DefaultAdminIdleTimeoutMinutes)
+	if err != nil {
+		return 0, err
+	}
+	if minutes < 0 {
+		minutes = 0
+	}
+	return time.Duration(minutes) * time.Minute, nil
+}
+
+// ValidateSession returns the session if it belongs to the user, is not revoked and not idle
+func (q *AdminSessionQueries) ValidateSession(id string, userID int) (*models.AdminSession, error) {
+	if id == "" {
+		return nil, fmt.Errorf("admin session not found")
+	}
+
+	session, err := q.GetSession(id)
+	if err != nil {
+		return nil, err
+	}
+	if session.UserID != userID || session.RevokedAt != nil {
+		return nil, fmt.Errorf("admin session not found")
+	}
+
+	timeout, err := q.GetIdleTimeout()
+	if err != nil {
+		return nil, err
+	}
+	if session.IsIdle(time.Now(), timeout) {
+		return nil, fmt.Errorf("admin session expired")
+	}
+
+	return session, nil
+}
+
+// Touch records admin activity; writes at most once a minute per session
+func (q *AdminSessionQueries) Touch(id string) error {
+	_, err := q.db.Exec(`
+		UPDATE admin_sessions SET last_seen_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND last_seen_at < CURRENT_TIMESTAMP - INTERVAL '1 minute'`,
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update admin session: %w", err)
+	}
+	return nil
+}
+
+// MarkSudo starts sudo mode for the session and returns the confirmation time
+func (q *AdminSessionQueries) MarkSudo(id string) (time.Time, error) {
+	var sudoAt time.Time
+	err := q.db.QueryRow(
+		"UPDATE admin_sessions SET sudo_at = CURRENT_TIMESTAMP, last_seen_at = CURRENT_TIMESTAMP WHERE id = $1 RETURNING sudo_at",
+		id,
+	).Scan(&sudoAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return time.Time{}, fmt.Errorf("admin session not found")
+		}
+		return time.Time{}, fmt.Errorf("failed to start sudo mode: %w", err)
+	}
+	return sudoAt, nil
+}
+
+// RevokeSession ends an admin session
+func (q *AdminSessionQueries) RevokeSession(id string) error {
+	_, err := q.db.Exec("UPDATE admin_sessions SET revoked_at = CURRENT_TIMESTAMP WHERE id = $1 AND revoked_at IS NULL", id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke admin session: %w", err)
+	}
+	return nil
+}
+
+// GetTOTP returns the user's TOTP secret (nil when never set up) and whether it is enabled
+func (q *AdminSessionQueries) GetTOTP(userID int) (*string, bool, error) {
+	var secret *string
+	var enabled bool
+	err := q.db.QueryRow("SELECT totp_secret, totp_enabled FROM users WHERE id = $1", userID).Scan(&secret, &enabled)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, false, fmt.Errorf("user not found")
+		}
+		return nil, false, fmt.Errorf("failed to get TOTP settings: %w", err)
+	}
+	return secret, enabled, nil
+}
+
+// SetTOTPSecret stores a new, not yet enabled TOTP secret
+func (q *AdminSessionQueries) SetTOTPSecret(userID int, secret string) error {
+	_, err := q.db.Exec("UPDATE users SET totp_secret = $1, totp_enabled = false WHERE id = $2", secret, userID)
+	if err != nil {
+		return fmt.Errorf("failed to store TOTP secret: %w", err)
+	}
+	return nil
+}
+
+// SetTOTPEnabled enables or disables TOTP; disabling also removes the secret
+func (q *AdminSessionQueries) SetTOTPEnabled(userID int, enabled bool) error {
+	query := "UPDATE users SET totp_enabled = true WHERE id = $1 AND totp_secret IS NOT NULL"
+	if !enabled {
+		query = "UPDATE users SET totp_enabled = false, totp_secret = NULL WHERE id = $1"
+	}
+	if _, err := q.db.Exec(query, userID); err != nil {
+		return fmt.Errorf("failed to update TOTP settings: %w", err)
+	}
+	return nil
+}
+
+// UseTOTPStep records the time step of an accepted TOTP code. It returns false
+// when a code of this or a later step was already used.
+func (q *AdminSessionQueries) UseTOTPStep(userID int, step int64) (bool, error) {
+	result, err := q.db.Exec(
+		"UPDATE users SET totp_last_step = $1 WHERE id = $2 AND (totp_last_step IS NULL OR totp_last_step < $1)",
+		step, userID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to record TOTP code: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to record TOTP code: %w", err)
+	}
+	return rows > 0, nil
+}
@@ -0,0 +1,167 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"notsofluffy-backend/internal/models"
+)
+
+// analyticsEventColumns is the number of columns written per event by InsertEvents
+const analyticsEventColumns = 14
+
+type AnalyticsQueries struct {
+	db *sql.DB
+}
+
+func NewAnalyticsQueries(db *sql.DB) *AnalyticsQueries {
+	return &AnalyticsQueries{db: db}
+}
+
+// InsertEvents stores a batch of events with a single multi-row INSERT
+func (q *AnalyticsQueries) InsertEvents(events []models.AnalyticsEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, 0, len(events))
+	args := make([]interface{}, 0, len(events)*analyticsEventColumns)
+	for i, event := range events {
+		row := make([]string, analyticsEventColumns)
+		for j := range row {
+			row[j] = fmt.Sprintf("$%d", i*analyticsEventColumns+j+1)
+		}
+		placeholders = append(placeholders, "("+strings.Join(row, ", ")+")")
+
+		var properties interface{}
+		if len(event.Properties) > 0 {
+			properties = string(event.Properties)
+		}
+		occurredAt := time.Now()
+		if event.OccurredAt != nil {
+			occurredAt = *event.OccurredAt
+		}
+
+		args = append(args, event.Type, event.SessionID, event.UserID, event.ProductID, event.OrderID, event.Value,
+			event.Path, event.Referrer, event.UTMSource, event.UTMMedium, event.UTMCampaign, event.Country,
+			properties, occurredAt)
+	}
+
+	query := `
+		INSERT INTO analytics_events (event_type, session_id, user_id, product_id, order_id, value, path, referrer, utm_source, utm_medium, utm_campaign, country, properties, occurred_at)
+		VALUES ` + strings.Join(placeholders, ", ")
+
+	if _, err := q.db.Exec(query, args...); err != nil {
+		return fmt.Errorf("failed to insert analytics events: %w", err)
+	}
+	return nil
+}
+
+// GetFunnelReport counts sessions that went through each funnel step in order, and
+// attributes purchases to the first UTM source seen in the session
+func (q *AnalyticsQueries) GetFunnelReport(from, to time.Time) (*models.FunnelReport, error) {
+	report := &models.FunnelReport{From: from, To: to}
+
+	// A session reaches a step only if it also reached every previous step
+	stepQuery := `
+		WITH sessions AS (
+			SELECT session_id,
+				bool_or(event_type = $3) AS viewed,
+				bool_or(event_type = $4) AS added,
+				bool_or(event_type = $5) AS checkout,
+				bool_or(event_type = $6) AS purchased
+			FROM analytics_events
+			WHERE occurred_at >= $1 AND occurred_at < $2
+			GROUP BY session_id
+		)
+		SELECT
+			COUNT(*) FILTER (WHERE viewed),
+			COUNT(*) FILTER (WHERE viewed AND added),
+			COUNT(*) FILTER (WHERE viewed AND added AND checkout),
+			COUNT(*) FILTER (WHERE viewed AND added AND checkout AND purchased)
+		FROM sessions`
+
+	counts := make([]int, len(models.FunnelSteps))
+	err := q.db.QueryRow(stepQuery, from, to, models.EventProductView, models.EventAddToCart, models.EventBeginCheckout, models.EventPurchase).
+		Scan(&counts[0], &counts[1], &counts[2], &counts[3])
+	if err != nil {
+		return nil, fmt.Errorf("failed to get funnel steps: %w", err)
+	}
+	report.Steps = buildFunnelSteps(counts)
+
+	// Revenue comes from the orders table, only for orders placed from the same session
+	sourceQuery := `
+		WITH first_source AS (
+			SELECT DISTINCT ON (session_id) session_id, utm_source
+			FROM analytics_events
+			WHERE occurred_at >= $1 AND occurred_at < $2 AND utm_source IS NOT NULL AND utm_source <> ''
+			ORDER BY session_id, occurred_at
+		),
+		purchases AS (
+			SELECT DISTINCT e.session_id, o.id AS order_id, o.total_amount
+			FROM analytics_events e
+			JOIN orders o ON o.id = e.order_id AND o.session_id = e.session_id AND NOT o.is_test
+			WHERE e.event_type = $3 AND e.occurred_at >= $1 AND e.occurred_at < $2
+		),
+		sessions AS (
+			SELECT DISTINCT session_id
+			FROM analytics_events
+			WHERE occurred_at >= $1 AND occurred_at < $2
+		)
+		SELECT COALESCE(fs.utm_source, '(direct)') AS source,
+			COUNT(DISTINCT s.session_id),
+			COUNT(p.order_id),
+			COALESCE(SUM(p.total_amount), 0) AS revenue
+		FROM sessions s
+		LEFT JOIN first_source fs ON fs.session_id = s.session_id
+		LEFT JOIN purchases p ON p.session_id = s.session_id
+		GROUP BY source
+		ORDER BY revenue DESC, COUNT(DISTINCT s.session_id) DESC
+		LIMIT 50`
+
+	rows, err := q.db.Query(sourceQuery, from, to, models.EventPurchase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get funnel sources: %w", err)
+	}
+	defer rows.Close()
+
+	report.Sources = []models.FunnelSource{}
+	for rows.Next() {
+		var source models.FunnelSource
+		if err := rows.Scan(&source.Source, &source.Sessions, &source.Purchases, &source.Revenue); err != nil {
+			return nil, fmt.Errorf("failed to scan funnel source: %w", err)
+		}
+		report.Sources = append(report.Sources, source)
+	}
+
+	return report, rows.Err()
+}
+
+// buildFunnelSteps computes step and overall conversion rates from session counts
+func buildFunnelSteps(counts []int) []models.FunnelStep {
+	steps := make([]models.FunnelStep, len(counts))
+	for i, count := range counts {
+		steps[i] = models.FunnelStep{Event: models.FunnelSteps[i], Sessions: count}
+		if i == 0 {
+			if count > 0 {
+				steps[i].StepRate = 1
+				steps[i].OverallRate = 1
+			}
+			continue
+		}
+		if counts[i-1] > 0 {
+			steps[i].StepRate = roundRate(float64(count) / float64(counts[i-1]))
+		}
+		if counts[0] > 0 {
+			steps[i].OverallRate = roundRate(float64(count) / float64(counts[0]))
+		}
+	}
+	return steps
+}
+
+func roundRate(rate float64) float64 {
+	return math.Round(rate*10000) / 10000
+}
@@ -0,0 +1,38 @@
+package database
+
+import (
+	"testing"
+
+	"notsofluffy-backend/internal/models"
+)
+
+func TestBuildFunnelSteps(t *testing.T) {
+	steps := buildFunnelSteps([]int{200, 50, 20, 5})
+
+	if len(steps) != len(models.FunnelSteps) {
+		t.Fatalf("expected %d steps, got %d", len(models.FunnelSteps), len(steps))
+	}
+
+	expected := []struct {
+		event       string
+		stepRate    float64
+		overallRate float64
+	}{
+		{models.EventProductView, 1, 1},
+		{models.EventAddToCart, 0.25, 0.25},
+		{models.EventBeginCheckout, 0.4, 0.1},
+		{models.EventPurchase, 0.25, 0.025},
+	}
+	for i, want := range expected {
+		got := steps[i]
+		if got.Event != want.event || got.StepRate != want.stepRate || got.OverallRate != want.overallRate {
+			t.Errorf("step %d: expected %+v, got %+v", i, want, got)
+		}
+	}
+
+	for _, step := range buildFunnelSteps([]int{0, 0, 0, 0}) {
+		if step.StepRate != 0 || step.OverallRate != 0 {
+			t.Errorf("expected zero rates without traffic, got %+v", step)
+		}
+	}
+}
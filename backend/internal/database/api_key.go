@@ -0,0 +1,192 @@
+package database
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"notsofluffy-backend/internal/models"
+)
+
+type APIKeyQueries struct {
+	db *sql.DB
+}
+
+func NewAPIKeyQueries(db *sql.DB) *APIKeyQueries {
+	return &APIKeyQueries{db: db}
+}
+
+// HashAPIKey returns the stored form of a key
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateAPIKey returns a new random key
+func generateAPIKey() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return models.APIKeyPrefix + hex.EncodeToString(b), nil
+}
+
+const apiKeyColumns = `k.id, k.name, k.key_prefix, k.tier, k.scopes, k.rate_limit_per_minute, k.last_used_at, k.revoked_at, k.created_by, k.created_at`
+
+func scanAPIKey(row interface{ Scan(...interface{}) error }, k *models.APIKey, extra ...interface{}) error {
+	dest := []interface{}{&k.ID, &k.Name, &k.KeyPrefix, &k.Tier, pq.Array(&k.Scopes), &k.RateLimitPerMinute,
+		&k.LastUsedAt, &k.RevokedAt, &k.CreatedBy, &k.CreatedAt}
+	return row.Scan(append(dest, extra...)...)
+}
+
+// ListAPIKeys returns all keys, newest first, with their request counts
+func (q *APIKeyQueries) ListAPIKeys() ([]models.APIKey, error) {
+	rows, err := q.db.Query(`
+		SELECT ` + apiKeyColumns + `,
+			COALESCE((SELECT u.requests FROM api_key_usage u WHERE u.api_key_id = k.id AND u.day = CURRENT_DATE), 0),
+			COALESCE((SELECT SUM(u.requests) FROM api_key_usage u WHERE u.api_key_id = k.id), 0)
+		FROM api_keys k
+		ORDER BY k.created_at DESC, k.id DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		var k models.APIKey
+		if err := scanAPIKey(rows, &k, &k.RequestsToday, &k.RequestsTotal); err != nil {
+			return nil, fmt.Errorf("failed to scan api key: %w", err)
+		}
+		keys = append(keys, k)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	return keys, nil
+}
+
+// CreateAPIKey issues a key. Only its hash is stored, so the returned key can't be
+// shown again.
+func (q *APIKeyQueries) CreateAPIKey(req *models.APIKeyRequest, createdBy *int) (*models.APIKeyCreatedResponse, error) {
+	key, err := generateAPIKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate api key: %w", err)
+	}
+
+	limit := models.APIKeyTierLimits[req.Tier]
+	if req.RateLimitPerMinute != nil {
+		limit = *req.RateLimitPerMinute
+	}
+
+	created := &models.APIKeyCreatedResponse{Key: key}
+	err = scanAPIKey(q.db.QueryRow(`
+		INSERT INTO api_keys AS k (name, key_prefix, key_hash, tier, scopes, rate_limit_per_minute, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING `+apiKeyColumns,
+		strings.TrimSpace(req.Name), key[:len(models.APIKeyPrefix)+8], HashAPIKey(key), req.Tier, pq.Array(req.Scopes), limit, createdBy,
+	), &created.APIKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create api key: %w", err)
+	}
+	return created, nil
+}
+
+// RevokeAPIKey stops a key from working; its usage is kept
+func (q *APIKeyQueries) RevokeAPIKey(id int) error {
+	result, err := q.db.Exec(`UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP WHERE id = $1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("api key not found")
+	}
+	return nil
+}
+
+// GetActiveAPIKey returns the key matching a key presented by a client, unless it was revoked
+func (q *APIKeyQueries) GetActiveAPIKey(key string) (*models.APIKey, error) {
+	var k models.APIKey
+	err := scanAPIKey(q.db.QueryRow(`SELECT `+apiKeyColumns+` FROM api_keys k
+		WHERE k.key_hash = $1 AND k.revoked_at IS NULL`, HashAPIKey(key)), &k)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("api key not found")
+		}
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+	return &k, nil
+}
+
+// RecordUsage counts a request of a key for today, as rejected when it was over the rate limit
+func (q *APIKeyQueries) RecordUsage(id int, rejected bool) error {
+	requests, rejections := 1, 0
+	if rejected {
+		requests, rejections = 0, 1
+	}
+
+	_, err := q.db.Exec(`
+		INSERT INTO api_key_usage (api_key_id, day, requests, rejected)
+		VALUES ($1, CURRENT_DATE, $2, $3)
+		ON CONFLICT (api_key_id, day) DO UPDATE
+		SET requests = api_key_usage.requests + EXCLUDED.requests, rejected = api_key_usage.rejected + EXCLUDED.rejected`,
+		id, requests, rejections)
+	if err != nil {
+		return fmt.Errorf("failed to record api key usage: %w", err)
+	}
+	if !rejected {
+		if _, err := q.db.Exec(`UPDATE api_keys SET last_used_at = CURRENT_TIMESTAMP WHERE id = $1`, id); err != nil {
+			return fmt.Errorf("failed to record api key usage: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetUsage returns the daily usage of a key over the last days, with days without
+// requests filled in
+func (q *APIKeyQueries) GetUsage(id, days int) (*models.APIKeyUsageResponse, error) {
+	var exists bool
+	if err := q.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM api_keys WHERE id = $1)`, id).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("api key not found")
+	}
+
+	rows, err := q.db.Query(`
+		SELECT d.day::date, COALESCE(u.requests, 0), COALESCE(u.rejected, 0)
+		FROM generate_series(CURRENT_DATE - ($2::int - 1), CURRENT_DATE, interval '1 day') AS d(day)
+		LEFT JOIN api_key_usage u ON u.api_key_id = $1 AND u.day = d.day::date
+		ORDER BY d.day`, id, days)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api key usage: %w", err)
+	}
+	defer rows.Close()
+
+	usage := &models.APIKeyUsageResponse{APIKeyID: id, Days: days, Usage: []models.APIKeyUsageDay{}}
+	for rows.Next() {
+		var day time.Time
+		var entry models.APIKeyUsageDay
+		if err := rows.Scan(&day, &entry.Requests, &entry.Rejected); err != nil {
+			return nil, fmt.Errorf("failed to scan api key usage: %w", err)
+		}
+		entry.Day = day.Format("2006-01-02")
+		usage.Requests += entry.Requests
+		usage.Rejected += entry.Rejected
+		usage.Usage = append(usage.Usage, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get api key usage: %w", err)
+	}
+	return usage, nil
+}
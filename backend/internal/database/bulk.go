@@ -0,0 +1,58 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// runBulk runs fn for each ID in one transaction, each in a savepoint of its own so that
+// an ID failing is rolled back alone. It returns the errors of the IDs that failed; an
+// error of the transaction itself fails every ID.
+func runBulk(db *sql.DB, ids []int, fn func(tx *sql.Tx, id int) error) (map[int]error, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	failed := map[int]error{}
+	for _, id := range ids {
+		if _, err := tx.Exec(`SAVEPOINT bulk_item`); err != nil {
+			return nil, fmt.Errorf("failed to create savepoint: %w", err)
+		}
+		if err := fn(tx, id); err != nil {
+			failed[id] = err
+			if _, err := tx.Exec(`ROLLBACK TO SAVEPOINT bulk_item`); err != nil {
+				return nil, fmt.Errorf("failed to roll back to savepoint: %w", err)
+			}
+			continue
+		}
+		if _, err := tx.Exec(`RELEASE SAVEPOINT bulk_item`); err != nil {
+			return nil, fmt.Errorf("failed to release savepoint: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return failed, nil
+}
+
+// BulkSetCategoriesActive activates or deactivates categories in one transaction.
+// Categories that can't be changed are returned with their errors.
+func (q *CategoryQueries) BulkSetCategoriesActive(ids []int, active bool) (map[int]error, error) {
+	return runBulk(q.db, ids, func(tx *sql.Tx, id int) error {
+		result, err := tx.Exec(`UPDATE categories SET active = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`, active, id)
+		if err != nil {
+			return fmt.Errorf("failed to update category: %w", err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			return fmt.Errorf("category not found")
+		}
+		return nil
+	})
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"notsofluffy-backend/internal/models"
+	"notsofluffy-backend/internal/pricing"
 )
 
 type CartQueries struct {
@@ -20,27 +21,34 @@ func NewCartQueries(db *sql.DB) *CartQueries {
 	return &CartQueries{db: db}
 }
 
-// generateServicesHash creates a consistent hash from service IDs
-func generateServicesHash(serviceIDs []int) string {
-	if len(serviceIDs) == 0 {
+// generateServicesHash creates a consistent hash from service IDs and option value IDs.
+// Items without options hash the same as before options existed.
+func generateServicesHash(serviceIDs []int, optionValueIDs []int) string {
+	if len(serviceIDs) == 0 && len(optionValueIDs) == 0 {
 		return ""
 	}
 
-	// Sort IDs to ensure consistent hash for same services
-	sortedIDs := make([]int, len(serviceIDs))
-	copy(sortedIDs, serviceIDs)
+	idsString := joinSortedIDs(serviceIDs)
+	if len(optionValueIDs) > 0 {
+		idsString += "|options:" + joinSortedIDs(optionValueIDs)
+	}
+
+	// Generate MD5 hash
+	hash := md5.Sum([]byte(idsString))
+	return fmt.Sprintf("%x", hash)
+}
+
+// joinSortedIDs sorts IDs so the same set always gives the same string
+func joinSortedIDs(ids []int) string {
+	sortedIDs := make([]int, len(ids))
+	copy(sortedIDs, ids)
 	sort.Ints(sortedIDs)
 
-	// Create string representation
 	idStrings := make([]string, len(sortedIDs))
 	for i, id := range sortedIDs {
 		idStrings[i] = strconv.Itoa(id)
 	}
-	idsString := strings.Join(idStrings, ",")
-
-	// Generate MD5 hash
-	hash := md5.Sum([]byte(idsString))
-	return fmt.Sprintf("%x", hash)
+	return strings.Join(idStrings, ",")
 }
 
 // GetOrCreateCartSession gets an existing cart session or creates a new one
@@ -123,10 +131,16 @@ func (q *CartQueries) UpdateCartSessionUser(cartSessionID int, userID int) error
 	return nil
 }
 
-// AddCartItem adds an item to the cart or updates quantity if it exists
-func (q *CartQueries) AddCartItem(cartSessionID int, item *models.CartItemRequest, pricePerItem float64) (*models.CartItem, error) {
+// AddCartItem adds an item to the cart or updates quantity if it exists. services and
+// options are the snapshots of the item's additional services and option values, stored
+// with a new item.
+func (q *CartQueries) AddCartItem(cartSessionID int, item *models.CartItemRequest, pricePerItem float64, services []models.CartItemServiceSnapshot, options []models.ProductOptionSelection) (*models.CartItem, error) {
 	// Generate services hash
-	servicesHash := generateServicesHash(item.AdditionalServiceIDs)
+	optionValueIDs := make([]int, len(options))
+	for i, option := range options {
+		optionValueIDs[i] = option.OptionValueID
+	}
+	servicesHash := generateServicesHash(item.AdditionalServiceIDs, optionValueIDs)
 
 	// Check if item already exists with same services
 	existing, err := q.GetCartItemByDetailsWithServices(cartSessionID, item.ProductID, item.VariantID, item.SizeID, servicesHash)
@@ -163,13 +177,23 @@ func (q *CartQueries) AddCartItem(cartSessionID int, item *models.CartItemReques
 	}
 
 	// Add additional services
-	if len(item.AdditionalServiceIDs) > 0 {
-		err = q.AddCartItemServices(cartItem.ID, item.AdditionalServiceIDs)
+	if len(services) > 0 {
+		err = q.AddCartItemServices(cartItem.ID, services)
 		if err != nil {
 			return nil, fmt.Errorf("failed to add cart item services: %w", err)
 		}
 	}
 
+	for _, option := range options {
+		_, err = q.db.Exec(`
+			INSERT INTO cart_item_options (cart_item_id, option_value_id, option_group_id, group_name, value_name, surcharge)
+			VALUES ($1, $2, $3, $4, $5, $6)`,
+			cartItem.ID, option.OptionValueID, option.OptionGroupID, option.GroupName, option.ValueName, option.Surcharge)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add cart item option: %w", err)
+		}
+	}
+
 	return cartItem, nil
 }
 
@@ -258,6 +282,20 @@ func (q *CartQueries) UpdateCartItemQuantity(cartItemID, quantity int) (*models.
 	return item, nil
 }
 
+// UpdateCartItemPrice brings a cart item's price in line with the catalog and flags the
+// change until the customer acknowledges it
+func (q *CartQueries) UpdateCartItemPrice(cartItemID int, pricePerItem float64) error {
+	_, err := q.db.Exec(`
+		UPDATE cart_items
+		SET previous_price = COALESCE(previous_price, price_per_item), price_changed_at = CURRENT_TIMESTAMP,
+			price_per_item = $1, price_checked_at = CURRENT_TIMESTAMP
+		WHERE id = $2 AND price_per_item <> $1`, pricePerItem, cartItemID)
+	if err != nil {
+		return fmt.Errorf("failed to update cart item price: %w", err)
+	}
+	return nil
+}
+
 // RemoveCartItem removes an item from the cart
 func (q *CartQueries) RemoveCartItem(cartItemID int) error {
 	query := `DELETE FROM cart_items WHERE id = $1`
@@ -315,6 +353,7 @@ func (q *CartQueries) GetCartItems(cartSessionID int) ([]models.CartItemResponse
 	query := `
 		SELECT 
 			ci.id, ci.product_id, ci.variant_id, ci.size_id, ci.quantity, ci.price_per_item, ci.created_at, ci.updated_at,
+			p.made_to_order OR c.custom, p.lead_time_days, ci.previous_price, ci.price_changed_at, p.oversize, p.shipping_surcharge,
 			p.id, p.name, p.short_description, p.description, p.material_id, p.main_image_id, p.category_id, p.created_at, p.updated_at,
 			mi.id, mi.filename, mi.original_name, mi.path, mi.size_bytes, mi.mime_type, mi.uploaded_by, mi.created_at, mi.updated_at,
 			pv.id, pv.product_id, pv.name, pv.color_id, pv.is_default, pv.created_at, pv.updated_at,
@@ -345,9 +384,13 @@ func (q *CartQueries) GetCartItems(cartSessionID int) ([]models.CartItemResponse
 		var color models.Color
 		var size models.Size
 		var itemCreatedAt, itemUpdatedAt time.Time
+		var leadTimeDays sql.NullInt64
+		var previousPrice sql.NullFloat64
+		var priceChangedAt sql.NullTime
 
 		err := rows.Scan(
 			&item.ID, &item.ProductID, &item.VariantID, &item.SizeID, &item.Quantity, &item.PricePerItem, &itemCreatedAt, &itemUpdatedAt,
+			&item.MadeToOrder, &leadTimeDays, &previousPrice, &priceChangedAt, &item.Oversize, &item.ShippingSurcharge,
 			&product.ID, &product.Name, &product.ShortDescription, &product.Description, &product.MaterialID, &product.MainImageID, &product.CategoryID, &product.CreatedAt, &product.UpdatedAt,
 			&mainImage.ID, &mainImage.Filename, &mainImage.OriginalName, &mainImage.Path, &mainImage.SizeBytes, &mainImage.MimeType, &mainImage.UploadedBy, &mainImage.CreatedAt, &mainImage.UpdatedAt,
 			&variant.ID, &variant.ProductID, &variant.Name, &variant.ColorID, &variant.IsDefault, &variant.CreatedAt, &variant.UpdatedAt,
@@ -419,56 +462,123 @@ func (q *CartQueries) GetCartItems(cartSessionID int) ([]models.CartItemResponse
 		item.CreatedAt = itemCreatedAt.Format(time.RFC3339)
 		item.UpdatedAt = itemUpdatedAt.Format(time.RFC3339)
 		item.TotalPrice = item.PricePerItem * float64(item.Quantity)
+		if item.MadeToOrder && leadTimeDays.Valid {
+			item.LeadTimeDays = int(leadTimeDays.Int64)
+		}
+		if previousPrice.Valid && priceChangedAt.Valid && previousPrice.Float64 != item.PricePerItem {
+			item.PriceChange = &models.CartItemPriceChange{
+				PreviousPrice: previousPrice.Float64,
+				CurrentPrice:  item.PricePerItem,
+				Difference:    pricing.Round(item.PricePerItem - previousPrice.Float64),
+				ChangedAt:     priceChangedAt.Time,
+			}
+		}
 
 		// Get additional services for this item
-		services, err := q.GetCartItemServices(item.ID)
+		services, removed, err := q.GetCartItemServices(item.ID)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get cart item services: %w", err)
 		}
 		item.AdditionalServices = services
 
+		options, removedOptions, err := q.GetCartItemOptions(item.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get cart item options: %w", err)
+		}
+		item.Options = options
+		removed = append(removed, removedOptions...)
+		if len(removed) > 0 {
+			item.Unavailable = true
+			item.UnavailableReason = fmt.Sprintf("No longer offered: %s", strings.Join(removed, ", "))
+		}
+
 		items = append(items, item)
 	}
 
 	return items, nil
 }
 
-// GetCartItemServices gets additional services for a cart item
-func (q *CartQueries) GetCartItemServices(cartItemID int) ([]models.AdditionalServiceResponse, error) {
+// GetCartItemServices gets additional services for a cart item at the price they were
+// added with, and the names of those that have since been deleted
+func (q *CartQueries) GetCartItemServices(cartItemID int) ([]models.AdditionalServiceResponse, []string, error) {
 	query := `
-		SELECT a.id, a.name, a.description, a.price, a.created_at, a.updated_at
-		FROM additional_services a
-		JOIN cart_item_services cis ON a.id = cis.additional_service_id
+		SELECT cis.service_id, cis.service_name, COALESCE(cis.service_description, ''), cis.service_price,
+			COALESCE(a.created_at, cis.created_at), COALESCE(a.updated_at, cis.created_at),
+			cis.additional_service_id IS NULL
+		FROM cart_item_services cis
+		LEFT JOIN additional_services a ON a.id = cis.additional_service_id
 		WHERE cis.cart_item_id = $1
-		ORDER BY a.name
+		ORDER BY cis.service_name
 	`
 
 	rows, err := q.db.Query(query, cartItemID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get cart item services: %w", err)
+		return nil, nil, fmt.Errorf("failed to get cart item services: %w", err)
 	}
 	defer rows.Close()
 
 	var services []models.AdditionalServiceResponse
+	var removed []string
 	for rows.Next() {
 		var service models.AdditionalServiceResponse
+		var deleted bool
 		err := rows.Scan(
-			&service.ID, &service.Name, &service.Description, &service.Price, &service.CreatedAt, &service.UpdatedAt,
+			&service.ID, &service.Name, &service.Description, &service.Price, &service.CreatedAt, &service.UpdatedAt, &deleted,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan service: %w", err)
+			return nil, nil, fmt.Errorf("failed to scan service: %w", err)
 		}
-		
+		if deleted {
+			removed = append(removed, service.Name)
+		}
+
 		service.Images = []models.ImageResponse{} // Initialize empty slice
 		services = append(services, service)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to get cart item services: %w", err)
+	}
 
-	return services, nil
+	return services, removed, nil
 }
 
-// AddCartItemServices adds additional services to a cart item
-func (q *CartQueries) AddCartItemServices(cartItemID int, serviceIDs []int) error {
-	if len(serviceIDs) == 0 {
+// GetCartItemOptions gets the option values chosen for a cart item as they were when it
+// was added, and the names of those that have since been deleted
+func (q *CartQueries) GetCartItemOptions(cartItemID int) ([]models.ProductOptionSelection, []string, error) {
+	rows, err := q.db.Query(`
+		SELECT option_group_id, COALESCE(option_value_id, 0), group_name, value_name, surcharge, option_value_id IS NULL
+		FROM cart_item_options
+		WHERE cart_item_id = $1
+		ORDER BY id`, cartItemID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get cart item options: %w", err)
+	}
+	defer rows.Close()
+
+	options := []models.ProductOptionSelection{}
+	var removed []string
+	for rows.Next() {
+		var option models.ProductOptionSelection
+		var deleted bool
+		if err := rows.Scan(&option.OptionGroupID, &option.OptionValueID, &option.GroupName, &option.ValueName, &option.Surcharge, &deleted); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan cart item option: %w", err)
+		}
+		if deleted {
+			removed = append(removed, option.GroupName+": "+option.ValueName)
+		}
+		options = append(options, option)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to get cart item options: %w", err)
+	}
+
+	return options, removed, nil
+}
+
+// AddCartItemServices adds additional services to a cart item, storing their name and
+// price so later changes to a service don't alter the cart
+func (q *CartQueries) AddCartItemServices(cartItemID int, services []models.CartItemServiceSnapshot) error {
+	if len(services) == 0 {
 		return nil
 	}
 
@@ -479,11 +589,13 @@ func (q *CartQueries) AddCartItemServices(cartItemID int, serviceIDs []int) erro
 	}
 
 	// Add new services
-	for _, serviceID := range serviceIDs {
-		_, err := q.db.Exec("INSERT INTO cart_item_services (cart_item_id, additional_service_id) VALUES ($1, $2)",
-			cartItemID, serviceID)
+	for _, service := range services {
+		_, err := q.db.Exec(`
+			INSERT INTO cart_item_services (cart_item_id, additional_service_id, service_id, service_name, service_description, service_price)
+			VALUES ($1, $2, $2, $3, $4, $5)`,
+			cartItemID, service.ServiceID, service.Name, service.Description, service.Price)
 		if err != nil {
-			return fmt.Errorf("failed to add service %d: %w", serviceID, err)
+			return fmt.Errorf("failed to add service %d: %w", service.ServiceID, err)
 		}
 	}
 
@@ -499,4 +611,35 @@ func (q *CartQueries) GetCartItemCount(cartSessionID int) (int, error) {
 		return 0, fmt.Errorf("failed to get cart item count: %w", err)
 	}
 	return count, nil
-}
\ No newline at end of file
+}
+// GetCartSummary returns the item count and totals of a session's cart without creating
+// the cart; a session without one gets an empty summary
+func (q *CartQueries) GetCartSummary(sessionID string) (*models.CartSummary, error) {
+	summary := &models.CartSummary{}
+	err := q.db.QueryRow(`
+		SELECT COALESCE((SELECT SUM(quantity) FROM cart_items WHERE cart_session_id = cs.id), 0),
+			COALESCE((SELECT SUM(ci.price_per_item * ci.quantity) FROM cart_items ci
+				WHERE ci.cart_session_id = cs.id AND NOT EXISTS (
+					SELECT 1 FROM cart_item_services cis
+					WHERE cis.cart_item_id = ci.id AND cis.additional_service_id IS NULL)
+				AND NOT EXISTS (
+					SELECT 1 FROM cart_item_options cio
+					WHERE cio.cart_item_id = ci.id AND cio.option_value_id IS NULL)), 0),
+			cs.discount_amount, dc.code
+		FROM cart_sessions cs
+		LEFT JOIN discount_codes dc ON dc.id = cs.applied_discount_code_id
+		WHERE cs.session_id = $1`, sessionID,
+	).Scan(&summary.ItemCount, &summary.Subtotal, &summary.DiscountAmount, &summary.DiscountCode)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return summary, nil
+		}
+		return nil, fmt.Errorf("failed to get cart summary: %w", err)
+	}
+
+	summary.TotalPrice = summary.Subtotal - summary.DiscountAmount
+	if summary.TotalPrice < 0 {
+		summary.TotalPrice = 0
+	}
+	return summary, nil
+}
@@ -69,7 +69,7 @@ func TestCartDiscountClearAfterOrder(t *testing.T) {
 		SizeID:    1,
 		Quantity:  1,
 	}
-	_, err = cartQueries.AddCartItem(cartSession.ID, cartItemReq, 100.0)
+	_, err = cartQueries.AddCartItem(cartSession.ID, cartItemReq, 100.0, nil, nil)
 	if err != nil {
 		t.Fatalf("Failed to add item to cart: %v", err)
 	}
@@ -206,7 +206,7 @@ func TestCartDiscountClearAfterOrder(t *testing.T) {
 		SizeID:    1,
 		Quantity:  1,
 	}
-	_, err = cartQueries.AddCartItem(cartSession.ID, newCartItemReq, 100.0)
+	_, err = cartQueries.AddCartItem(cartSession.ID, newCartItemReq, 100.0, nil, nil)
 	if err != nil {
 		t.Fatalf("Failed to add new item to cart: %v", err)
 	}
@@ -290,7 +290,7 @@ func TestCartDiscountClearGuestUser(t *testing.T) {
 		SizeID:    1,
 		Quantity:  1,
 	}
-	_, err = cartQueries.AddCartItem(cartSession.ID, cartItemReq, 100.0)
+	_, err = cartQueries.AddCartItem(cartSession.ID, cartItemReq, 100.0, nil, nil)
 	if err != nil {
 		t.Fatalf("Failed to add item to cart: %v", err)
 	}
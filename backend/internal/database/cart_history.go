@@ -0,0 +1,73 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"notsofluffy-backend/internal/models"
+)
+
+// RecordCartEvent appends a mutation to the cart session history
+func (q *CartQueries) RecordCartEvent(event *models.CartEvent) error {
+	return insertCartEvent(q.db, event)
+}
+
+func insertCartEvent(db interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}, event *models.CartEvent) error {
+	query := `
+		INSERT INTO cart_events (cart_session_id, action, cart_item_id, product_id, variant_id, size_id, quantity_before, quantity_after, price_per_item, user_id, order_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+
+	_, err := db.Exec(query, event.CartSessionID, event.Action, event.CartItemID, event.ProductID, event.VariantID, event.SizeID,
+		event.QuantityBefore, event.QuantityAfter, event.PricePerItem, event.UserID, event.OrderID)
+	if err != nil {
+		return fmt.Errorf("failed to record cart event: %w", err)
+	}
+	return nil
+}
+
+// GetCartHistory returns the mutation history of a cart session, oldest first
+func (q *CartQueries) GetCartHistory(sessionID string) (*models.CartHistoryResponse, error) {
+	var cartSessionID int
+	history := &models.CartHistoryResponse{SessionID: sessionID}
+
+	err := q.db.QueryRow(`SELECT id, user_id, created_at FROM cart_sessions WHERE session_id = $1`, sessionID).
+		Scan(&cartSessionID, &history.UserID, &history.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("cart session not found")
+		}
+		return nil, fmt.Errorf("failed to get cart session: %w", err)
+	}
+
+	query := `
+		SELECT e.id, e.action, e.cart_item_id, e.product_id, p.name, e.variant_id, pv.name, e.size_id, s.name,
+		       e.quantity_before, e.quantity_after, e.price_per_item, e.user_id, e.order_id, e.created_at
+		FROM cart_events e
+		LEFT JOIN products p ON p.id = e.product_id
+		LEFT JOIN product_variants pv ON pv.id = e.variant_id
+		LEFT JOIN sizes s ON s.id = e.size_id
+		WHERE e.cart_session_id = $1
+		ORDER BY e.created_at, e.id`
+
+	rows, err := q.db.Query(query, cartSessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cart history: %w", err)
+	}
+	defer rows.Close()
+
+	history.Events = []models.CartHistoryEntry{}
+	for rows.Next() {
+		var entry models.CartHistoryEntry
+		err := rows.Scan(&entry.ID, &entry.Action, &entry.CartItemID, &entry.ProductID, &entry.ProductName,
+			&entry.VariantID, &entry.VariantName, &entry.SizeID, &entry.SizeName,
+			&entry.QuantityBefore, &entry.QuantityAfter, &entry.PricePerItem, &entry.UserID, &entry.OrderID, &entry.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan cart event: %w", err)
+		}
+		history.Events = append(history.Events, entry)
+	}
+
+	return history, rows.Err()
+}
@@ -0,0 +1,200 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"notsofluffy-backend/internal/models"
+	"notsofluffy-backend/internal/pricing"
+)
+
+// MergeUserCarts makes the cart of the given browser session the user's cart after
+// login. Items from the user's other carts are moved in, summing the quantities of
+// items with the same product, variant, size and services. The session's own discount
+// code wins; without one, the code of the user's most recent other cart is carried
+// over. The discount is recalculated for the merged subtotal. It returns nil when
+// there was nothing to merge or the session's cart belongs to another user.
+func (q *CartQueries) MergeUserCarts(sessionID string, userID int) (*models.CartMergeResult, error) {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO cart_sessions (session_id, user_id, applied_discount_code_id, discount_amount)
+		VALUES ($1, $2, NULL, 0)
+		ON CONFLICT (session_id) DO NOTHING`, sessionID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cart session: %w", err)
+	}
+
+	var targetID int
+	var ownerID, discountCodeID *int
+	err = tx.QueryRow(`SELECT id, user_id, applied_discount_code_id FROM cart_sessions WHERE session_id = $1 FOR UPDATE`, sessionID).
+		Scan(&targetID, &ownerID, &discountCodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cart session: %w", err)
+	}
+	// A shared browser still holding someone else's cart is left alone
+	if ownerID != nil && *ownerID != userID {
+		return nil, nil
+	}
+
+	type sourceCart struct {
+		id           int
+		discountID   *int
+		discountCode *string
+	}
+	rows, err := tx.Query(`
+		SELECT cs.id, cs.applied_discount_code_id, dc.code
+		FROM cart_sessions cs
+		LEFT JOIN discount_codes dc ON dc.id = cs.applied_discount_code_id
+		WHERE cs.user_id = $1 AND cs.id <> $2
+		ORDER BY cs.updated_at DESC, cs.id DESC
+		FOR UPDATE OF cs`, userID, targetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user carts: %w", err)
+	}
+	var sources []sourceCart
+	for rows.Next() {
+		var s sourceCart
+		if err := rows.Scan(&s.id, &s.discountID, &s.discountCode); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan user cart: %w", err)
+		}
+		sources = append(sources, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate user carts: %w", err)
+	}
+
+	result := &models.CartMergeResult{}
+	for _, source := range sources {
+		merged, err := mergeCartItems(tx, source.id, targetID, userID)
+		if err != nil {
+			return nil, err
+		}
+		result.ItemsMerged += merged
+
+		if source.discountID != nil && source.discountCode != nil {
+			switch {
+			case discountCodeID == nil:
+				discountCodeID = source.discountID
+			case *discountCodeID != *source.discountID:
+				result.DroppedDiscountCodes = append(result.DroppedDiscountCodes, *source.discountCode)
+			}
+		}
+		// The code now lives on the merged cart, where the usage checks count it
+		_, err = tx.Exec(`
+			UPDATE cart_sessions SET applied_discount_code_id = NULL, discount_amount = 0, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $1`, source.id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to clear merged cart: %w", err)
+		}
+	}
+
+	discountAmount := 0.0
+	if discountCodeID != nil {
+		var code, discountType string
+		var discountValue, subtotal float64
+		err = tx.QueryRow(`
+			SELECT dc.code, dc.discount_type, dc.discount_value,
+				COALESCE((SELECT SUM(quantity * price_per_item) FROM cart_items WHERE cart_session_id = $2), 0)
+			FROM discount_codes dc WHERE dc.id = $1`, *discountCodeID, targetID).
+			Scan(&code, &discountType, &discountValue, &subtotal)
+		if err == sql.ErrNoRows {
+			discountCodeID = nil
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to get discount code: %w", err)
+		} else {
+			discountAmount = pricing.Discount(discountType, discountValue, subtotal)
+			result.DiscountCode = &code
+		}
+	}
+
+	_, err = tx.Exec(`
+		UPDATE cart_sessions SET user_id = $1, applied_discount_code_id = $2, discount_amount = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $4`, userID, discountCodeID, discountAmount, targetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update cart session: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if result.ItemsMerged == 0 && len(result.DroppedDiscountCodes) == 0 {
+		return nil, nil
+	}
+	return result, nil
+}
+
+// mergeCartItems moves the items of one cart into another, adding the quantity of an
+// item the target already has to it, and records a merged event per item
+func mergeCartItems(tx *sql.Tx, sourceID, targetID, userID int) (int, error) {
+	rows, err := tx.Query(`
+		SELECT s.id, s.product_id, s.variant_id, s.size_id, s.quantity, t.id, t.quantity, COALESCE(t.price_per_item, s.price_per_item)
+		FROM cart_items s
+		LEFT JOIN cart_items t ON t.cart_session_id = $2 AND t.product_id = s.product_id
+			AND t.variant_id = s.variant_id AND t.size_id = s.size_id AND t.services_hash = s.services_hash
+		WHERE s.cart_session_id = $1
+		ORDER BY s.id`, sourceID, targetID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get cart items: %w", err)
+	}
+
+	type cartItemMove struct {
+		sourceItemID, productID, variantID, sizeID, quantity int
+		targetItemID                                         *int
+		targetQuantity                                       *int
+		pricePerItem                                         float64
+	}
+	var moves []cartItemMove
+	for rows.Next() {
+		var m cartItemMove
+		err := rows.Scan(&m.sourceItemID, &m.productID, &m.variantID, &m.sizeID, &m.quantity, &m.targetItemID, &m.targetQuantity, &m.pricePerItem)
+		if err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan cart item: %w", err)
+		}
+		moves = append(moves, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to iterate cart items: %w", err)
+	}
+
+	for _, m := range moves {
+		itemID, before := m.sourceItemID, 0
+		if m.targetItemID != nil {
+			itemID, before = *m.targetItemID, *m.targetQuantity
+			if _, err := tx.Exec(`UPDATE cart_items SET quantity = quantity + $1 WHERE id = $2`, m.quantity, itemID); err != nil {
+				return 0, fmt.Errorf("failed to merge cart item: %w", err)
+			}
+			if _, err := tx.Exec(`DELETE FROM cart_items WHERE id = $1`, m.sourceItemID); err != nil {
+				return 0, fmt.Errorf("failed to remove merged cart item: %w", err)
+			}
+		} else if _, err := tx.Exec(`UPDATE cart_items SET cart_session_id = $1 WHERE id = $2`, targetID, m.sourceItemID); err != nil {
+			return 0, fmt.Errorf("failed to move cart item: %w", err)
+		}
+
+		err := insertCartEvent(tx, &models.CartEvent{
+			CartSessionID:  targetID,
+			Action:         models.CartActionMerged,
+			CartItemID:     &itemID,
+			ProductID:      &m.productID,
+			VariantID:      &m.variantID,
+			SizeID:         &m.sizeID,
+			QuantityBefore: before,
+			QuantityAfter:  before + m.quantity,
+			PricePerItem:   &m.pricePerItem,
+			UserID:         &userID,
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(moves), nil
+}
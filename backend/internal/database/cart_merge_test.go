@@ -0,0 +1,128 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"notsofluffy-backend/internal/models"
+
+	_ "github.com/lib/pq"
+)
+
+// TestMergeUserCarts tests that logging in merges the user's earlier cart into the guest cart
+func TestMergeUserCarts(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	cartQueries := NewCartQueries(db)
+	discountQueries := NewDiscountQueries(db)
+
+	_, _ = db.Exec("DELETE FROM cart_sessions WHERE session_id IN ('test-merge-guest', 'test-merge-old')")
+	_, _ = db.Exec("DELETE FROM discount_codes WHERE code = 'MERGE10'")
+	_, _ = db.Exec("DELETE FROM users WHERE email = 'mergetest@example.com'")
+
+	var userID int
+	err := db.QueryRow(
+		"INSERT INTO users (email, password_hash, role, created_at, updated_at) VALUES ($1, $2, $3, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP) RETURNING id",
+		"mergetest@example.com", "hashedpassword", "client",
+	).Scan(&userID)
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	discountCode, err := discountQueries.CreateDiscountCode(&models.DiscountCodeRequest{
+		Code:          "MERGE10",
+		Description:   "Test cart merge 10% discount",
+		DiscountType:  "percentage",
+		DiscountValue: 10.0,
+		UsageType:     "unlimited",
+		StartDate:     time.Now(),
+		Active:        true,
+	}, 1)
+	if err != nil {
+		t.Fatalf("Failed to create discount code: %v", err)
+	}
+
+	// The cart the user left on another device, with the discount code applied
+	oldCart, err := cartQueries.GetOrCreateCartSession("test-merge-old", &userID)
+	if err != nil {
+		t.Fatalf("Failed to create cart session: %v", err)
+	}
+	if _, err := cartQueries.AddCartItem(oldCart.ID, &models.CartItemRequest{ProductID: 1, VariantID: 1, SizeID: 1, Quantity: 2}, 100.0, nil, nil); err != nil {
+		t.Fatalf("Failed to add item to cart: %v", err)
+	}
+	if _, err := cartQueries.AddCartItem(oldCart.ID, &models.CartItemRequest{ProductID: 1, VariantID: 1, SizeID: 2, Quantity: 1}, 100.0, nil, nil); err != nil {
+		t.Fatalf("Failed to add item to cart: %v", err)
+	}
+	if err := discountQueries.ApplyDiscountToCartSession(oldCart.ID, discountCode.ID, 30.0); err != nil {
+		t.Fatalf("Failed to apply discount to cart session: %v", err)
+	}
+
+	// The guest cart of the browser the user logs in from
+	guestCart, err := cartQueries.GetOrCreateCartSession("test-merge-guest", nil)
+	if err != nil {
+		t.Fatalf("Failed to create cart session: %v", err)
+	}
+	if _, err := cartQueries.AddCartItem(guestCart.ID, &models.CartItemRequest{ProductID: 1, VariantID: 1, SizeID: 1, Quantity: 1}, 100.0, nil, nil); err != nil {
+		t.Fatalf("Failed to add item to cart: %v", err)
+	}
+
+	result, err := cartQueries.MergeUserCarts("test-merge-guest", userID)
+	if err != nil {
+		t.Fatalf("Failed to merge carts: %v", err)
+	}
+	if result == nil || result.ItemsMerged != 2 {
+		t.Fatalf("Expected 2 merged items, got %+v", result)
+	}
+	if result.DiscountCode == nil || *result.DiscountCode != "MERGE10" {
+		t.Errorf("Expected the discount code to be carried over, got %v", result.DiscountCode)
+	}
+
+	items, err := cartQueries.GetCartItems(guestCart.ID)
+	if err != nil {
+		t.Fatalf("Failed to get cart items: %v", err)
+	}
+	quantities := map[int]int{}
+	for _, item := range items {
+		quantities[item.SizeID] = item.Quantity
+	}
+	if quantities[1] != 3 || quantities[2] != 1 {
+		t.Errorf("Expected quantities 3 and 1, got %v", quantities)
+	}
+
+	merged, err := cartQueries.GetCartSessionByID("test-merge-guest")
+	if err != nil {
+		t.Fatalf("Failed to get cart session: %v", err)
+	}
+	if merged.UserID == nil || *merged.UserID != userID {
+		t.Errorf("Expected the cart to belong to user %d, got %v", userID, merged.UserID)
+	}
+	if merged.DiscountAmount != 40.0 {
+		t.Errorf("Expected the discount recalculated to 40.00, got %.2f", merged.DiscountAmount)
+	}
+
+	old, err := cartQueries.GetCartSessionByID("test-merge-old")
+	if err != nil {
+		t.Fatalf("Failed to get cart session: %v", err)
+	}
+	if old.AppliedDiscountCodeID != nil {
+		t.Error("Expected the discount to be cleared from the old cart")
+	}
+	if count, _ := cartQueries.GetCartItemCount(old.ID); count != 0 {
+		t.Errorf("Expected the old cart to be empty, got %d items", count)
+	}
+
+	// Logging in again has nothing left to merge
+	result, err = cartQueries.MergeUserCarts("test-merge-guest", userID)
+	if err != nil {
+		t.Fatalf("Failed to merge carts: %v", err)
+	}
+	if result != nil {
+		t.Errorf("Expected nothing to merge, got %+v", result)
+	}
+
+	// Cleanup
+	_, _ = db.Exec("DELETE FROM cart_sessions WHERE session_id IN ('test-merge-guest', 'test-merge-old')")
+	_, _ = db.Exec("DELETE FROM users WHERE id = $1", userID)
+	_, _ = db.Exec("DELETE FROM discount_codes WHERE id = $1", discountCode.ID)
+}
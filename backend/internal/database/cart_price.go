@@ -0,0 +1,80 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"notsofluffy-backend/internal/models"
+	"notsofluffy-backend/internal/pricing"
+)
+
+// cartPriceRefreshBatch bounds the items repriced in one run
+const cartPriceRefreshBatch = 1000
+
+// RefreshStaleCartPrices reprices cart items not checked since olderThan against the
+// current base prices. Services and options keep the prices snapshotted when the item
+// was added. Changed items are flagged with the price the customer saw before.
+func (q *CartQueries) RefreshStaleCartPrices(olderThan time.Time) (*models.CartPriceRefreshResult, error) {
+	rows, err := q.db.Query(`
+		SELECT ci.id, ci.price_per_item, s.base_price, c.custom,
+			COALESCE((SELECT SUM(cis.service_price) FROM cart_item_services cis WHERE cis.cart_item_id = ci.id), 0),
+			COALESCE((SELECT SUM(cio.surcharge) FROM cart_item_options cio WHERE cio.cart_item_id = ci.id), 0)
+		FROM cart_items ci
+		JOIN sizes s ON s.id = ci.size_id
+		JOIN product_variants pv ON pv.id = ci.variant_id
+		JOIN colors c ON c.id = pv.color_id
+		WHERE COALESCE(ci.price_checked_at, ci.created_at) < $1
+		ORDER BY ci.id
+		LIMIT $2`, olderThan, cartPriceRefreshBatch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stale cart items: %w", err)
+	}
+
+	type stalePrice struct {
+		id      int
+		current float64
+		price   float64
+	}
+	var stale []stalePrice
+	for rows.Next() {
+		var id int
+		var current, basePrice, services, surcharges float64
+		var custom bool
+		if err := rows.Scan(&id, &current, &basePrice, &custom, &services, &surcharges); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan stale cart item: %w", err)
+		}
+		price := pricing.UnitPrice(basePrice, custom, []float64{services}, []float64{surcharges})
+		stale = append(stale, stalePrice{id: id, current: current, price: price})
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, fmt.Errorf("failed to get stale cart items: %w", err)
+	}
+	rows.Close()
+
+	result := &models.CartPriceRefreshResult{}
+	for _, item := range stale {
+		if pricing.Round(item.current) != item.price {
+			if err := q.UpdateCartItemPrice(item.id, item.price); err != nil {
+				return nil, err
+			}
+			result.Changed++
+		} else if _, err := q.db.Exec(`UPDATE cart_items SET price_checked_at = CURRENT_TIMESTAMP WHERE id = $1`, item.id); err != nil {
+			return nil, fmt.Errorf("failed to mark cart item checked: %w", err)
+		}
+		result.Checked++
+	}
+	return result, nil
+}
+
+// AcknowledgePriceChanges clears the price change flags of a cart once the customer has seen them
+func (q *CartQueries) AcknowledgePriceChanges(cartSessionID int) error {
+	_, err := q.db.Exec(`
+		UPDATE cart_items SET previous_price = NULL, price_changed_at = NULL
+		WHERE cart_session_id = $1 AND price_changed_at IS NOT NULL`, cartSessionID)
+	if err != nil {
+		return fmt.Errorf("failed to acknowledge price changes: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,404 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"notsofluffy-backend/internal/models"
+)
+
+// snapshotTable describes how a table takes part in catalog snapshots
+type snapshotTable struct {
+	name string
+	// keyed tables have a serial id; rows are upserted and rows missing from the
+	// snapshot are deleted. Link tables are replaced as a whole.
+	keyed bool
+	// prune deletes keyed rows that are not in the snapshot
+	prune bool
+	// preserve lists columns that reflect live state and are never restored
+	preserve []string
+	// filter restricts restored link rows to ones whose parents still exist
+	filter string
+	// unsetFirst is a flag column cleared before rows are upserted: its partial unique
+	// index (one default per product) is checked row by row during the upsert
+	unsetFirst string
+}
+
+// catalogSnapshotTables are listed parents first, the order rows are restored in.
+// Images are only upserted: files uploaded after the snapshot stay in the library.
+var catalogSnapshotTables = []snapshotTable{
+	{name: "images", keyed: true},
+	{name: "categories", keyed: true, prune: true},
+	{name: "materials", keyed: true, prune: true},
+	{name: "colors", keyed: true, prune: true},
+	{name: "additional_services", keyed: true, prune: true},
+	{name: "products", keyed: true, prune: true},
+	{name: "sizes", keyed: true, prune: true, preserve: []string{"reserved_quantity"}},
+	{name: "product_variants", keyed: true, prune: true, unsetFirst: "is_default"},
+	{name: "additional_service_images"},
+	{name: "product_images"},
+	{name: "product_services"},
+	{name: "product_variant_images"},
+	{name: "warehouse_stock", filter: "warehouse_id IN (SELECT id FROM warehouses)"},
+}
+
+type CatalogSnapshotQueries struct {
+	db *sql.DB
+}
+
+func NewCatalogSnapshotQueries(db *sql.DB) *CatalogSnapshotQueries {
+	return &CatalogSnapshotQueries{db: db}
+}
+
+// ExportArchive reads the current catalog and inventory into a snapshot archive
+func (q *CatalogSnapshotQueries) ExportArchive() (*models.CatalogSnapshotArchive, error) {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Read all tables from one consistent view of the database
+	if _, err := tx.Exec(`SET TRANSACTION ISOLATION LEVEL REPEATABLE READ READ ONLY`); err != nil {
+		return nil, fmt.Errorf("failed to set transaction isolation: %w", err)
+	}
+
+	archive := &models.CatalogSnapshotArchive{
+		FormatVersion: models.CatalogSnapshotFormatVersion,
+		CreatedAt:     time.Now(),
+		Tables:        make(map[string]models.CatalogSnapshotTable, len(catalogSnapshotTables)),
+	}
+
+	for _, table := range catalogSnapshotTables {
+		columns, err := tableColumns(tx, table.name)
+		if err != nil {
+			return nil, err
+		}
+
+		var rows []byte
+		query := fmt.Sprintf(`SELECT COALESCE(json_agg(t), '[]'::json) FROM %s t`, pq.QuoteIdentifier(table.name))
+		if err := tx.QueryRow(query).Scan(&rows); err != nil {
+			return nil, fmt.Errorf("failed to export %s: %w", table.name, err)
+		}
+
+		archive.Tables[table.name] = models.CatalogSnapshotTable{Columns: columns, Rows: rows}
+	}
+
+	return archive, tx.Commit()
+}
+
+// CreateSnapshot exports the catalog and stores it as a new snapshot
+func (q *CatalogSnapshotQueries) CreateSnapshot(note *string, userID *int) (*models.CatalogSnapshot, error) {
+	archive, err := q.ExportArchive()
+	if err != nil {
+		return nil, err
+	}
+	return q.storeArchive(archive, note, userID)
+}
+
+func (q *CatalogSnapshotQueries) storeArchive(archive *models.CatalogSnapshotArchive, note *string, userID *int) (*models.CatalogSnapshot, error) {
+	data, err := json.Marshal(archive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	snapshot := &models.CatalogSnapshot{
+		Note:          note,
+		FormatVersion: archive.FormatVersion,
+		ProductCount:  countArchiveRows(archive, "products"),
+		SizeBytes:     len(data),
+		CreatedBy:     userID,
+	}
+
+	query := `
+		INSERT INTO catalog_snapshots (note, format_version, product_count, size_bytes, data, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at`
+	err = q.db.QueryRow(query, note, snapshot.FormatVersion, snapshot.ProductCount, snapshot.SizeBytes, data, userID).
+		Scan(&snapshot.ID, &snapshot.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store snapshot: %w", err)
+	}
+
+	return snapshot, nil
+}
+
+// ListSnapshots returns snapshot metadata, newest first
+func (q *CatalogSnapshotQueries) ListSnapshots() ([]models.CatalogSnapshot, error) {
+	rows, err := q.db.Query(`
+		SELECT id, note, format_version, product_count, size_bytes, created_by, created_at
+		FROM catalog_snapshots
+		ORDER BY created_at DESC, id DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	snapshots := []models.CatalogSnapshot{}
+	for rows.Next() {
+		var s models.CatalogSnapshot
+		if err := rows.Scan(&s.ID, &s.Note, &s.FormatVersion, &s.ProductCount, &s.SizeBytes, &s.CreatedBy, &s.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan snapshot: %w", err)
+		}
+		snapshots = append(snapshots, s)
+	}
+
+	return snapshots, rows.Err()
+}
+
+// GetSnapshotData returns the raw JSON archive of a snapshot
+func (q *CatalogSnapshotQueries) GetSnapshotData(id int) ([]byte, error) {
+	var data []byte
+	err := q.db.QueryRow(`SELECT data FROM catalog_snapshots WHERE id = $1`, id).Scan(&data)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("snapshot not found")
+		}
+		return nil, fmt.Errorf("failed to get snapshot: %w", err)
+	}
+	return data, nil
+}
+
+// DeleteSnapshot removes a stored snapshot
+func (q *CatalogSnapshotQueries) DeleteSnapshot(id int) error {
+	result, err := q.db.Exec(`DELETE FROM catalog_snapshots WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete snapshot: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("snapshot not found")
+	}
+	return nil
+}
+
+// RestoreSnapshot rolls the catalog back to a stored snapshot
+func (q *CatalogSnapshotQueries) RestoreSnapshot(id int, userID *int) (*models.CatalogRestoreResult, error) {
+	data, err := q.GetSnapshotData(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var archive models.CatalogSnapshotArchive
+	if err := json.Unmarshal(data, &archive); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+
+	result, err := q.RestoreArchive(&archive, userID)
+	if err != nil {
+		return nil, err
+	}
+	result.SnapshotID = &id
+	return result, nil
+}
+
+// RestoreArchive replaces the catalog with the archive contents in a single transaction.
+// The current catalog is stored as a backup snapshot first so the restore can be undone.
+func (q *CatalogSnapshotQueries) RestoreArchive(archive *models.CatalogSnapshotArchive, userID *int) (*models.CatalogRestoreResult, error) {
+	if err := validateArchive(archive); err != nil {
+		return nil, err
+	}
+
+	note := "Automatic backup before restore"
+	backup, err := q.CreateSnapshot(&note, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backup snapshot: %w", err)
+	}
+
+	tx, err := q.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result := &models.CatalogRestoreResult{BackupSnapshotID: backup.ID}
+	results := make(map[string]*models.CatalogRestoreTableResult, len(catalogSnapshotTables))
+
+	// Upsert parents first, then replace link tables
+	for _, table := range catalogSnapshotTables {
+		restored, err := restoreTable(tx, table, archive.Tables[table.name])
+		if err != nil {
+			return nil, err
+		}
+		results[table.name] = &models.CatalogRestoreTableResult{Table: table.name, Restored: restored}
+	}
+
+	// Delete rows created after the snapshot, children first
+	for i := len(catalogSnapshotTables) - 1; i >= 0; i-- {
+		table := catalogSnapshotTables[i]
+		if !table.prune {
+			continue
+		}
+		query := fmt.Sprintf(`DELETE FROM %s WHERE id NOT IN (SELECT (r->>'id')::int FROM json_array_elements($1::json) r)`,
+			pq.QuoteIdentifier(table.name))
+		res, err := tx.Exec(query, string(archive.Tables[table.name].Rows))
+		if err != nil {
+			return nil, fmt.Errorf("failed to prune %s: %w", table.name, err)
+		}
+		deleted, err := res.RowsAffected()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		results[table.name].Deleted = deleted
+	}
+
+	// Keep serial sequences ahead of restored ids
+	for _, table := range catalogSnapshotTables {
+		if !table.keyed {
+			continue
+		}
+		query := fmt.Sprintf(`SELECT setval(pg_get_serial_sequence('%s', 'id'), COALESCE((SELECT MAX(id) FROM %s), 0) + 1, false)`,
+			table.name, pq.QuoteIdentifier(table.name))
+		if _, err := tx.Exec(query); err != nil {
+			return nil, fmt.Errorf("failed to reset %s sequence: %w", table.name, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	for _, table := range catalogSnapshotTables {
+		result.Tables = append(result.Tables, *results[table.name])
+	}
+	return result, nil
+}
+
+// restoreTable writes the snapshot rows of one table and returns how many were written
+func restoreTable(tx *sql.Tx, table snapshotTable, snapshot models.CatalogSnapshotTable) (int64, error) {
+	current, err := tableColumns(tx, table.name)
+	if err != nil {
+		return 0, err
+	}
+	columns := restorableColumns(current, snapshot.Columns, table.preserve)
+	if len(columns) == 0 {
+		return 0, fmt.Errorf("snapshot has no usable columns for %s", table.name)
+	}
+
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = pq.QuoteIdentifier(column)
+	}
+	name := pq.QuoteIdentifier(table.name)
+	columnList := strings.Join(quoted, ", ")
+	source := fmt.Sprintf(`SELECT %s FROM json_populate_recordset(NULL::%s, $1::json)`, columnList, name)
+
+	var query string
+	if table.keyed {
+		updates := make([]string, 0, len(quoted))
+		for _, column := range quoted {
+			if column != `"id"` {
+				updates = append(updates, column+" = EXCLUDED."+column)
+			}
+		}
+		conflict := "DO NOTHING"
+		if len(updates) > 0 {
+			conflict = "DO UPDATE SET " + strings.Join(updates, ", ")
+		}
+		if table.unsetFirst != "" && containsString(columns, table.unsetFirst) {
+			flag := pq.QuoteIdentifier(table.unsetFirst)
+			if _, err := tx.Exec(fmt.Sprintf(`UPDATE %s SET %s = FALSE WHERE %s`, name, flag, flag)); err != nil {
+				return 0, fmt.Errorf("failed to clear %s.%s: %w", table.name, table.unsetFirst, err)
+			}
+		}
+		query = fmt.Sprintf(`INSERT INTO %s (%s) %s ON CONFLICT (id) %s`, name, columnList, source, conflict)
+	} else {
+		if _, err := tx.Exec(fmt.Sprintf(`DELETE FROM %s`, name)); err != nil {
+			return 0, fmt.Errorf("failed to clear %s: %w", table.name, err)
+		}
+		if table.filter != "" {
+			source = fmt.Sprintf(`SELECT * FROM (%s) restored WHERE %s`, source, table.filter)
+		}
+		query = fmt.Sprintf(`INSERT INTO %s (%s) %s`, name, columnList, source)
+	}
+
+	res, err := tx.Exec(query, string(snapshot.Rows))
+	if err != nil {
+		return 0, fmt.Errorf("failed to restore %s: %w", table.name, err)
+	}
+	return res.RowsAffected()
+}
+
+// tableColumns returns the current column names of a table
+func tableColumns(tx *sql.Tx, table string) ([]string, error) {
+	rows, err := tx.Query(`
+		SELECT column_name FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = $1
+		ORDER BY ordinal_position`, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get columns of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return nil, fmt.Errorf("failed to scan column: %w", err)
+		}
+		columns = append(columns, column)
+	}
+	return columns, rows.Err()
+}
+
+// restorableColumns keeps the columns present both in the table and the snapshot,
+// so archives taken before a migration can still be restored
+func restorableColumns(current, snapshot, preserve []string) []string {
+	inSnapshot := make(map[string]bool, len(snapshot))
+	for _, column := range snapshot {
+		inSnapshot[column] = true
+	}
+	for _, column := range preserve {
+		delete(inSnapshot, column)
+	}
+
+	var columns []string
+	for _, column := range current {
+		if inSnapshot[column] {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// validateArchive checks the archive format and that every table is present
+func validateArchive(archive *models.CatalogSnapshotArchive) error {
+	if archive.FormatVersion != models.CatalogSnapshotFormatVersion {
+		return fmt.Errorf("unsupported snapshot format version %d", archive.FormatVersion)
+	}
+	for _, table := range catalogSnapshotTables {
+		snapshot, ok := archive.Tables[table.name]
+		if !ok || len(snapshot.Rows) == 0 {
+			return fmt.Errorf("snapshot is missing table %s", table.name)
+		}
+		if table.keyed && !containsString(snapshot.Columns, "id") {
+			return fmt.Errorf("snapshot table %s has no id column", table.name)
+		}
+	}
+	return nil
+}
+
+func countArchiveRows(archive *models.CatalogSnapshotArchive, table string) int {
+	var rows []json.RawMessage
+	if err := json.Unmarshal(archive.Tables[table].Rows, &rows); err != nil {
+		return 0
+	}
+	return len(rows)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
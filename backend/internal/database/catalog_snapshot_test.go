@@ -0,0 +1,48 @@
+package database
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"notsofluffy-backend/internal/models"
+)
+
+func TestRestorableColumns(t *testing.T) {
+	current := []string{"id", "name", "stock_quantity", "reserved_quantity", "added_later"}
+	snapshot := []string{"id", "name", "stock_quantity", "reserved_quantity", "dropped_column"}
+
+	got := restorableColumns(current, snapshot, []string{"reserved_quantity"})
+	want := []string{"id", "name", "stock_quantity"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestValidateArchive(t *testing.T) {
+	archive := &models.CatalogSnapshotArchive{
+		FormatVersion: models.CatalogSnapshotFormatVersion,
+		Tables:        map[string]models.CatalogSnapshotTable{},
+	}
+	for _, table := range catalogSnapshotTables {
+		columns := []string{"product_id", "image_id"}
+		if table.keyed {
+			columns = []string{"id", "name"}
+		}
+		archive.Tables[table.name] = models.CatalogSnapshotTable{Columns: columns, Rows: json.RawMessage(`[]`)}
+	}
+
+	if err := validateArchive(archive); err != nil {
+		t.Fatalf("expected valid archive, got %v", err)
+	}
+
+	delete(archive.Tables, "sizes")
+	if err := validateArchive(archive); err == nil {
+		t.Error("expected error for archive missing a table")
+	}
+
+	archive.FormatVersion = models.CatalogSnapshotFormatVersion + 1
+	if err := validateArchive(archive); err == nil {
+		t.Error("expected error for unsupported format version")
+	}
+}
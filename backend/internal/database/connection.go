@@ -8,6 +8,7 @@ import (
 	"io/ioutil"
 	"log"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -42,6 +43,23 @@ func Connect(databaseURL string) (*sql.DB, error) {
 	return db, nil
 }
 
+// WithStatementTimeout adds a statement timeout to the connections opened from
+// databaseURL; lib/pq passes parameters it doesn't know to the server as session
+// settings. A timeout already set in databaseURL is kept.
+func WithStatementTimeout(databaseURL string, timeout time.Duration) string {
+	if timeout <= 0 || strings.Contains(databaseURL, "statement_timeout=") {
+		return databaseURL
+	}
+	setting := "statement_timeout=" + strconv.FormatInt(timeout.Milliseconds(), 10)
+	if !strings.HasPrefix(databaseURL, "postgres://") && !strings.HasPrefix(databaseURL, "postgresql://") {
+		return databaseURL + " " + setting
+	}
+	if strings.Contains(databaseURL, "?") {
+		return databaseURL + "&" + setting
+	}
+	return databaseURL + "?" + setting
+}
+
 // logSafeDatabaseURL logs the database URL without exposing credentials
 func logSafeDatabaseURL(databaseURL string) {
 	parsed, err := url.Parse(databaseURL)
@@ -0,0 +1,25 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWithStatementTimeout(t *testing.T) {
+	cases := []struct {
+		url, want string
+	}{
+		{"postgres://u:p@db:5432/shop", "postgres://u:p@db:5432/shop?statement_timeout=15000"},
+		{"postgres://u:p@db:5432/shop?sslmode=disable", "postgres://u:p@db:5432/shop?sslmode=disable&statement_timeout=15000"},
+		{"host=db dbname=shop", "host=db dbname=shop statement_timeout=15000"},
+		{"postgres://db/shop?statement_timeout=5000", "postgres://db/shop?statement_timeout=5000"},
+	}
+	for _, tc := range cases {
+		if got := WithStatementTimeout(tc.url, 15*time.Second); got != tc.want {
+			t.Errorf("WithStatementTimeout(%q) = %q, want %q", tc.url, got, tc.want)
+		}
+	}
+	if got := WithStatementTimeout("postgres://db/shop", 0); got != "postgres://db/shop" {
+		t.Errorf("zero timeout changed the URL to %q", got)
+	}
+}
@@ -0,0 +1,202 @@
+package database
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"notsofluffy-backend/internal/models"
+)
+
+type ContentChangeQueries struct {
+	db *sql.DB
+}
+
+func NewContentChangeQueries(db *sql.DB) *ContentChangeQueries {
+	return &ContentChangeQueries{db: db}
+}
+
+const contentChangeSelect = `
+	SELECT cc.id, cc.entity_type, cc.entity_id, cc.status, cc.payload, cc.base, cc.submitted_by, u.email,
+		cc.submitted_at, cc.reviewed_by, cc.reviewed_at, cc.review_note
+	FROM content_changes cc
+	LEFT JOIN users u ON u.id = cc.submitted_by`
+
+func scanContentChange(row interface{ Scan(...interface{}) error }, change *models.ContentChange) error {
+	var payload, base []byte
+	err := row.Scan(&change.ID, &change.EntityType, &change.EntityID, &change.Status, &payload, &base,
+		&change.SubmittedBy, &change.SubmittedByEmail, &change.SubmittedAt, &change.ReviewedBy, &change.ReviewedAt, &change.ReviewNote)
+	if err != nil {
+		return err
+	}
+	change.Payload = payload
+	change.Base = base
+	return nil
+}
+
+// CreateChange stores a content change; approved changes record the submitter as reviewer
+func (q *ContentChangeQueries) CreateChange(change *models.ContentChange) error {
+	query := `
+		INSERT INTO content_changes (entity_type, entity_id, status, payload, base, submitted_by, reviewed_by, reviewed_at)
+		VALUES ($1, $2, $3, $4, $5, $6,
+			CASE WHEN $3 = 'approved' THEN $6::int END,
+			CASE WHEN $3 = 'approved' THEN CURRENT_TIMESTAMP END)
+		RETURNING id, submitted_at, reviewed_by, reviewed_at`
+
+	err := q.db.QueryRow(query, change.EntityType, change.EntityID, change.Status, []byte(change.Payload), []byte(change.Base), change.SubmittedBy).
+		Scan(&change.ID, &change.SubmittedAt, &change.ReviewedBy, &change.ReviewedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create content change: %w", err)
+	}
+	return nil
+}
+
+// GetChange returns a content change with its diff
+func (q *ContentChangeQueries) GetChange(id int) (*models.ContentChange, error) {
+	var change models.ContentChange
+	if err := scanContentChange(q.db.QueryRow(contentChangeSelect+` WHERE cc.id = $1`, id), &change); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("content change not found")
+		}
+		return nil, fmt.Errorf("failed to get content change: %w", err)
+	}
+
+	diff, err := DiffContent(change.Base, change.Payload)
+	if err != nil {
+		return nil, err
+	}
+	change.Diff = diff
+	return &change, nil
+}
+
+// ListChanges returns content changes, newest first. Zero filters are ignored.
+func (q *ContentChangeQueries) ListChanges(page, limit int, status, entityType string, entityID, submittedBy int) (*models.ContentChangeListResponse, error) {
+	conditions := []string{}
+	args := []interface{}{}
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if status != "" {
+		addCondition("cc.status = $%d", status)
+	}
+	if entityType != "" {
+		addCondition("cc.entity_type = $%d", entityType)
+	}
+	if entityID > 0 {
+		addCondition("cc.entity_id = $%d", entityID)
+	}
+	if submittedBy > 0 {
+		addCondition("cc.submitted_by = $%d", submittedBy)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := q.db.QueryRow(`SELECT COUNT(*) FROM content_changes cc`+where, args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count content changes: %w", err)
+	}
+
+	query := contentChangeSelect + where + fmt.Sprintf(` ORDER BY cc.submitted_at DESC, cc.id DESC LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2)
+	args = append(args, limit, (page-1)*limit)
+
+	rows, err := q.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list content changes: %w", err)
+	}
+	defer rows.Close()
+
+	changes := []models.ContentChange{}
+	for rows.Next() {
+		var change models.ContentChange
+		if err := scanContentChange(rows, &change); err != nil {
+			return nil, fmt.Errorf("failed to scan content change: %w", err)
+		}
+		changes = append(changes, change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list content changes: %w", err)
+	}
+
+	return &models.ContentChangeListResponse{Changes: changes, Total: total, Page: page, Limit: limit}, nil
+}
+
+// ReviewChange approves or rejects a pending change
+func (q *ContentChangeQueries) ReviewChange(id int, status string, reviewerID *int, note string) error {
+	var reviewNote *string
+	if note != "" {
+		reviewNote = &note
+	}
+
+	result, err := q.db.Exec(`
+		UPDATE content_changes
+		SET status = $2, reviewed_by = $3, reviewed_at = CURRENT_TIMESTAMP, review_note = $4
+		WHERE id = $1 AND status = $5`, id, status, reviewerID, reviewNote, models.ContentChangePending)
+	if err != nil {
+		return fmt.Errorf("failed to review content change: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("content change already reviewed")
+	}
+	return nil
+}
+
+// DiffContent compares two JSON objects field by field and returns the changed fields
+// in name order
+func DiffContent(before, after json.RawMessage) ([]models.ContentFieldChange, error) {
+	beforeFields, err := decodeContentFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := decodeContentFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	return diffFields(beforeFields, afterFields), nil
+}
+
+// diffFields returns the fields whose values differ, in name order
+func diffFields(beforeFields, afterFields map[string]interface{}) []models.ContentFieldChange {
+	names := make([]string, 0, len(beforeFields)+len(afterFields))
+	for name := range beforeFields {
+		names = append(names, name)
+	}
+	for name := range afterFields {
+		if _, ok := beforeFields[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	changes := []models.ContentFieldChange{}
+	for _, name := range names {
+		if !reflect.DeepEqual(beforeFields[name], afterFields[name]) {
+			changes = append(changes, models.ContentFieldChange{Field: name, Before: beforeFields[name], After: afterFields[name]})
+		}
+	}
+	return changes
+}
+
+func decodeContentFields(data json.RawMessage) (map[string]interface{}, error) {
+	fields := map[string]interface{}{}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return fields, nil
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to decode content: %w", err)
+	}
+	return fields, nil
+}
@@ -0,0 +1,47 @@
+package database
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDiffContent(t *testing.T) {
+	before := json.RawMessage(`{"name":"Bed","image_ids":[1,2],"material_id":null,"channels":{"web":true}}`)
+	after := json.RawMessage(`{"name":"Bed","image_ids":[2,1],"material_id":3,"channels":{"web":true},"category_id":4}`)
+
+	diff, err := DiffContent(before, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fields := make([]string, len(diff))
+	for i, change := range diff {
+		fields[i] = change.Field
+	}
+	want := []string{"category_id", "image_ids", "material_id"}
+	if len(fields) != len(want) {
+		t.Fatalf("expected changed fields %v, got %v", want, fields)
+	}
+	for i := range want {
+		if fields[i] != want[i] {
+			t.Fatalf("expected changed fields %v, got %v", want, fields)
+		}
+	}
+	if diff[0].Before != nil || diff[0].After != float64(4) {
+		t.Fatalf("unexpected category_id change %+v", diff[0])
+	}
+}
+
+func TestDiffContentEmptyBase(t *testing.T) {
+	diff, err := DiffContent(nil, json.RawMessage(`{"name":"Bed"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff) != 1 || diff[0].Field != "name" || diff[0].After != "Bed" {
+		t.Fatalf("unexpected diff %+v", diff)
+	}
+
+	if _, err := DiffContent(json.RawMessage(`[1]`), nil); err == nil {
+		t.Fatal("expected an error for a non-object payload")
+	}
+}
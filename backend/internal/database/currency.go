@@ -0,0 +1,111 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"notsofluffy-backend/internal/models"
+)
+
+type CurrencyQueries struct {
+	db *sql.DB
+}
+
+func NewCurrencyQueries(db *sql.DB) *CurrencyQueries {
+	return &CurrencyQueries{db: db}
+}
+
+const currencyColumns = `code, name, rate, enabled, source, updated_at`
+
+func scanCurrency(row interface{ Scan(...interface{}) error }, c *models.Currency) error {
+	return row.Scan(&c.Code, &c.Name, &c.Rate, &c.Enabled, &c.Source, &c.UpdatedAt)
+}
+
+// ListCurrencies returns the currencies by code, the base currency first
+func (q *CurrencyQueries) ListCurrencies(enabledOnly bool) ([]models.Currency, error) {
+	rows, err := q.db.Query(`
+		SELECT `+currencyColumns+` FROM currencies
+		WHERE enabled OR NOT $1
+		ORDER BY code <> $2, code`, enabledOnly, models.PaymentCurrency)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list currencies: %w", err)
+	}
+	defer rows.Close()
+
+	currencies := []models.Currency{}
+	for rows.Next() {
+		var c models.Currency
+		if err := scanCurrency(rows, &c); err != nil {
+			return nil, fmt.Errorf("failed to scan currency: %w", err)
+		}
+		currencies = append(currencies, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list currencies: %w", err)
+	}
+	return currencies, nil
+}
+
+// SaveCurrency adds a currency or changes its name, rate and whether prices can be
+// shown in it. The base currency's rate is always 1 and it can't be disabled.
+func (q *CurrencyQueries) SaveCurrency(code string, req *models.CurrencyRequest) (*models.Currency, error) {
+	if code == models.PaymentCurrency {
+		return nil, fmt.Errorf("base currency cannot be changed")
+	}
+
+	var c models.Currency
+	err := scanCurrency(q.db.QueryRow(`
+		INSERT INTO currencies (code, name, rate, enabled, source) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (code) DO UPDATE
+		SET name = EXCLUDED.name, rate = EXCLUDED.rate, enabled = EXCLUDED.enabled,
+		    source = EXCLUDED.source, updated_at = CURRENT_TIMESTAMP
+		RETURNING `+currencyColumns,
+		code, req.Name, req.Rate, req.Enabled, models.CurrencySourceManual), &c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save currency: %w", err)
+	}
+	return &c, nil
+}
+
+// UpdateRates sets the rates of the currencies found in rates, keyed by code, and
+// returns them with the codes of the currencies rates has no rate for
+func (q *CurrencyQueries) UpdateRates(rates map[string]float64, source string) ([]models.Currency, []string, error) {
+	currencies, err := q.ListCurrencies(false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tx, err := q.db.Begin()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	updated := []models.Currency{}
+	missing := []string{}
+	for _, currency := range currencies {
+		if currency.Code == models.PaymentCurrency {
+			continue
+		}
+		rate, ok := rates[currency.Code]
+		if !ok {
+			missing = append(missing, currency.Code)
+			continue
+		}
+
+		var c models.Currency
+		err := scanCurrency(tx.QueryRow(`
+			UPDATE currencies SET rate = $2, source = $3, updated_at = CURRENT_TIMESTAMP
+			WHERE code = $1
+			RETURNING `+currencyColumns, currency.Code, rate, source), &c)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to update rate of %s: %w", currency.Code, err)
+		}
+		updated = append(updated, c)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return updated, missing, nil
+}
@@ -0,0 +1,38 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"notsofluffy-backend/internal/models"
+)
+
+// keysetCondition returns the condition for the rows after a cursor in a list ordered
+// by column DESC, idColumn DESC, with the cursor's value and ID as the arguments
+// valueArg and valueArg+1
+func keysetCondition(column, idColumn string, valueArg int) string {
+	return fmt.Sprintf("(%s < $%d OR (%s = $%d AND %s < $%d))", column, valueArg, column, valueArg, idColumn, valueArg+1)
+}
+
+// cursorValue returns the cursor's sort value, rejecting cursors without one
+func cursorValue(cursor *models.Cursor) (string, error) {
+	if cursor.Value == nil {
+		return "", fmt.Errorf("invalid cursor")
+	}
+	return *cursor.Value, nil
+}
+
+// timeCursor returns the cursor for a row of a list ordered by a timestamp and ID
+func timeCursor(t time.Time, id int) string {
+	value := t.Format(time.RFC3339Nano)
+	return models.Cursor{Value: &value, ID: id}.Encode()
+}
+
+// nextCursor returns the cursor of the last of rows when the page is full, so there
+// may be more, or "" on the last page
+func nextCursor(rows, limit int, last func() string) string {
+	if rows == 0 || rows < limit {
+		return ""
+	}
+	return last()
+}
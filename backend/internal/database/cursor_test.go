@@ -0,0 +1,61 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"notsofluffy-backend/internal/models"
+)
+
+func TestKeysetCondition(t *testing.T) {
+	got := keysetCondition("p.created_at", "p.id", 3)
+	want := "(p.created_at < $3 OR (p.created_at = $3 AND p.id < $4))"
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestNextCursorOnlyForFullPages(t *testing.T) {
+	last := func() string { return "next" }
+	if next := nextCursor(10, 10, last); next != "next" {
+		t.Fatalf("full page should have a next cursor, got %q", next)
+	}
+	if next := nextCursor(3, 10, last); next != "" {
+		t.Fatalf("last page should have no next cursor, got %q", next)
+	}
+	if next := nextCursor(0, 10, last); next != "" {
+		t.Fatalf("empty page should have no next cursor, got %q", next)
+	}
+}
+
+func TestUserCursorRoundTrip(t *testing.T) {
+	login := time.Date(2024, 5, 1, 12, 30, 0, 123456000, time.UTC)
+	user := models.AdminUserSummary{OrderCount: 4, LifetimeValue: 129.9, LastLoginAt: &login}
+	user.ID = 42
+
+	cursor, err := models.DecodeCursor(userCursor(user, models.UserSortLifetimeValue))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cursor.ID != 42 || cursor.Sort != models.UserSortLifetimeValue || cursor.Value == nil || *cursor.Value != "129.9" {
+		t.Fatalf("unexpected cursor %+v", cursor)
+	}
+
+	cursor, err = models.DecodeCursor(userCursor(user, models.UserSortLastLogin))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed, err := time.Parse(time.RFC3339Nano, *cursor.Value); err != nil || !parsed.Equal(login) {
+		t.Fatalf("last login should survive the cursor, got %v", *cursor.Value)
+	}
+
+	user.LastLoginAt = nil
+	cursor, err = models.DecodeCursor(userCursor(user, models.UserSortLastLogin))
+	if err != nil || cursor.Value != nil {
+		t.Fatalf("users who never logged in should have no cursor value, got %+v, %v", cursor, err)
+	}
+
+	if _, err := models.DecodeCursor("not a cursor"); err == nil {
+		t.Fatal("expected malformed cursor to be rejected")
+	}
+}
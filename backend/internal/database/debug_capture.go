@@ -0,0 +1,209 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"notsofluffy-backend/internal/models"
+)
+
+type DebugCaptureQueries struct {
+	db *sql.DB
+}
+
+func NewDebugCaptureQueries(db *sql.DB) *DebugCaptureQueries {
+	return &DebugCaptureQueries{db: db}
+}
+
+const debugCaptureColumns = `dc.id, dc.session_id, dc.user_id, u.email, dc.note, dc.expires_at,
+	dc.expires_at > CURRENT_TIMESTAMP, (SELECT COUNT(*) FROM debug_capture_entries e WHERE e.capture_id = dc.id),
+	dc.created_by, dc.created_at`
+
+func scanDebugCapture(row interface{ Scan(...interface{}) error }, capture *models.DebugCapture) error {
+	return row.Scan(&capture.ID, &capture.SessionID, &capture.UserID, &capture.UserEmail, &capture.Note, &capture.ExpiresAt,
+		&capture.Active, &capture.EntryCount, &capture.CreatedBy, &capture.CreatedAt)
+}
+
+// CreateDebugCapture starts capturing the traffic of a session or user for the given duration
+func (q *DebugCaptureQueries) CreateDebugCapture(req *models.DebugCaptureRequest, createdBy *int) (*models.DebugCapture, error) {
+	var sessionID *string
+	if req.SessionID != "" {
+		sessionID = &req.SessionID
+	}
+
+	var id int
+	err := q.db.QueryRow(`
+		INSERT INTO debug_captures (session_id, user_id, note, expires_at, created_by)
+		VALUES ($1, $2, $3, CURRENT_TIMESTAMP + $4 * INTERVAL '1 minute', $5)
+		RETURNING id`,
+		sessionID, req.UserID, req.Note, req.DurationMinutes, createdBy,
+	).Scan(&id)
+	if err != nil {
+		if strings.Contains(err.Error(), "debug_captures_user_id_fkey") {
+			return nil, fmt.Errorf("user not found")
+		}
+		return nil, fmt.Errorf("failed to create debug capture: %w", err)
+	}
+	return q.GetDebugCapture(id)
+}
+
+// GetDebugCapture returns a capture by ID
+func (q *DebugCaptureQueries) GetDebugCapture(id int) (*models.DebugCapture, error) {
+	var capture models.DebugCapture
+	err := scanDebugCapture(q.db.QueryRow(`
+		SELECT `+debugCaptureColumns+`
+		FROM debug_captures dc
+		LEFT JOIN users u ON u.id = dc.user_id
+		WHERE dc.id = $1`, id), &capture)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("debug capture not found")
+		}
+		return nil, fmt.Errorf("failed to get debug capture: %w", err)
+	}
+	return &capture, nil
+}
+
+// ListDebugCaptures returns captures, newest first
+func (q *DebugCaptureQueries) ListDebugCaptures(page, limit int) (*models.DebugCaptureListResponse, error) {
+	var total int
+	if err := q.db.QueryRow(`SELECT COUNT(*) FROM debug_captures`).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count debug captures: %w", err)
+	}
+
+	rows, err := q.db.Query(`
+		SELECT `+debugCaptureColumns+`
+		FROM debug_captures dc
+		LEFT JOIN users u ON u.id = dc.user_id
+		ORDER BY dc.created_at DESC, dc.id DESC
+		LIMIT $1 OFFSET $2`, limit, (page-1)*limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list debug captures: %w", err)
+	}
+	defer rows.Close()
+
+	captures := []models.DebugCapture{}
+	for rows.Next() {
+		var capture models.DebugCapture
+		if err := scanDebugCapture(rows, &capture); err != nil {
+			return nil, fmt.Errorf("failed to scan debug capture: %w", err)
+		}
+		captures = append(captures, capture)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list debug captures: %w", err)
+	}
+
+	return &models.DebugCaptureListResponse{Captures: captures, Total: total, Page: page, Limit: limit}, nil
+}
+
+// StopDebugCapture ends a capture early; its entries are kept
+func (q *DebugCaptureQueries) StopDebugCapture(id int) (*models.DebugCapture, error) {
+	result, err := q.db.Exec(`
+		UPDATE debug_captures SET expires_at = LEAST(expires_at, CURRENT_TIMESTAMP)
+		WHERE id = $1`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stop debug capture: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return nil, fmt.Errorf("debug capture not found")
+	}
+	return q.GetDebugCapture(id)
+}
+
+// GetActiveDebugCaptures returns the captures that have not expired
+func (q *DebugCaptureQueries) GetActiveDebugCaptures() ([]models.DebugCapture, error) {
+	rows, err := q.db.Query(`
+		SELECT id, session_id, user_id, expires_at
+		FROM debug_captures
+		WHERE expires_at > CURRENT_TIMESTAMP`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active debug captures: %w", err)
+	}
+	defer rows.Close()
+
+	captures := []models.DebugCapture{}
+	for rows.Next() {
+		capture := models.DebugCapture{Active: true}
+		if err := rows.Scan(&capture.ID, &capture.SessionID, &capture.UserID, &capture.ExpiresAt); err != nil {
+			return nil, fmt.Errorf("failed to scan debug capture: %w", err)
+		}
+		captures = append(captures, capture)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get active debug captures: %w", err)
+	}
+	return captures, nil
+}
+
+// RecordDebugCaptureEntry stores a captured request
+func (q *DebugCaptureQueries) RecordDebugCaptureEntry(entry *models.DebugCaptureEntry) error {
+	headers, err := json.Marshal(entry.RequestHeaders)
+	if err != nil {
+		return fmt.Errorf("failed to encode request headers: %w", err)
+	}
+
+	_, err = q.db.Exec(`
+		INSERT INTO debug_capture_entries (capture_id, method, path, query, status, duration_ms, client_ip,
+			session_id, user_id, request_headers, request_body, response_body, truncated)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
+		entry.CaptureID, entry.Method, entry.Path, entry.Query, entry.Status, entry.DurationMS, entry.ClientIP,
+		entry.SessionID, entry.UserID, headers, entry.RequestBody, entry.ResponseBody, entry.Truncated)
+	if err != nil {
+		return fmt.Errorf("failed to record debug capture entry: %w", err)
+	}
+	return nil
+}
+
+// ListDebugCaptureEntries returns a page of a capture's entries, oldest first so a
+// session can be replayed in order
+func (q *DebugCaptureQueries) ListDebugCaptureEntries(captureID, page, limit int) (*models.DebugCaptureEntriesResponse, error) {
+	capture, err := q.GetDebugCapture(captureID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.db.Query(`
+		SELECT id, capture_id, method, path, query, status, duration_ms, client_ip, session_id, user_id,
+			request_headers, request_body, response_body, truncated, created_at
+		FROM debug_capture_entries
+		WHERE capture_id = $1
+		ORDER BY created_at, id
+		LIMIT $2 OFFSET $3`, captureID, limit, (page-1)*limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list debug capture entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.DebugCaptureEntry{}
+	for rows.Next() {
+		var entry models.DebugCaptureEntry
+		var headers []byte
+		if err := rows.Scan(&entry.ID, &entry.CaptureID, &entry.Method, &entry.Path, &entry.Query, &entry.Status,
+			&entry.DurationMS, &entry.ClientIP, &entry.SessionID, &entry.UserID, &headers, &entry.RequestBody,
+			&entry.ResponseBody, &entry.Truncated, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan debug capture entry: %w", err)
+		}
+		if err := json.Unmarshal(headers, &entry.RequestHeaders); err != nil {
+			return nil, fmt.Errorf("failed to decode request headers: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list debug capture entries: %w", err)
+	}
+
+	return &models.DebugCaptureEntriesResponse{
+		Capture: *capture,
+		Entries: entries,
+		Total:   capture.EntryCount,
+		Page:    page,
+		Limit:   limit,
+	}, nil
+}
@@ -2,11 +2,13 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
 
 	"notsofluffy-backend/internal/models"
+	"notsofluffy-backend/internal/pricing"
 )
 
 type DiscountQueries struct {
@@ -31,36 +33,35 @@ func (q *DiscountQueries) ValidateDiscountCode(code string, cartTotal float64, u
 		return nil, fmt.Errorf("failed to get discount code: %w", err)
 	}
 
-	// Check if code is active
-	if !discountCode.Active {
+	// Check state, dates, recurring windows in shop time and minimum order amount
+	if problem := discountCodeProblem(discountCode, time.Now(), NewSettingsQueries(q.db).GetShopLocation(), cartTotal); problem != "" {
 		return &models.DiscountValidationResult{
 			IsValid:      false,
-			ErrorMessage: "Discount code is not active",
+			ErrorMessage: problem,
 		}, nil
 	}
 
-	// Check date validity
-	now := time.Now()
-	if now.Before(discountCode.StartDate) {
-		return &models.DiscountValidationResult{
-			IsValid:      false,
-			ErrorMessage: "Discount code is not yet valid",
-		}, nil
+	// Check customer restrictions; the order email is checked again at order creation
+	recipientValid, err := q.ValidateDiscountRecipient(discountCode.ID, userID, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate discount recipient: %w", err)
 	}
-
-	if discountCode.EndDate != nil && now.After(*discountCode.EndDate) {
-		return &models.DiscountValidationResult{
-			IsValid:      false,
-			ErrorMessage: "Discount code has expired",
-		}, nil
+	if !recipientValid.IsValid {
+		return recipientValid, nil
 	}
 
-	// Check minimum order amount
-	if cartTotal < discountCode.MinOrderAmount {
-		return &models.DiscountValidationResult{
-			IsValid:      false,
-			ErrorMessage: fmt.Sprintf("Minimum order amount of %.2f required", discountCode.MinOrderAmount),
-		}, nil
+	// Guests' earlier orders are only known by email, checked at order creation
+	if discountCode.IsFirstOrderOnly && userID != nil {
+		hasOrders, err := hasPriorOrders(q.db, userID, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to check previous orders: %w", err)
+		}
+		if hasOrders {
+			return &models.DiscountValidationResult{
+				IsValid:      false,
+				ErrorMessage: FirstOrderOnlyMessage,
+			}, nil
+		}
 	}
 
 	// Check usage limits
@@ -72,17 +73,8 @@ func (q *DiscountQueries) ValidateDiscountCode(code string, cartTotal float64, u
 		return usageValid, nil
 	}
 
-	// Calculate discount amount
-	var discountAmount float64
-	if discountCode.DiscountType == models.DiscountTypePercentage {
-		discountAmount = cartTotal * (discountCode.DiscountValue / 100)
-	} else {
-		discountAmount = discountCode.DiscountValue
-		// Don't discount more than the cart total
-		if discountAmount > cartTotal {
-			discountAmount = cartTotal
-		}
-	}
+	// Calculate discount amount, never more than the cart total
+	discountAmount := pricing.Discount(discountCode.DiscountType, discountCode.DiscountValue, cartTotal)
 
 	return &models.DiscountValidationResult{
 		IsValid:        true,
@@ -91,24 +83,62 @@ func (q *DiscountQueries) ValidateDiscountCode(code string, cartTotal float64, u
 	}, nil
 }
 
-// validateUsageLimits checks if the discount code can be used based on usage type
-func (q *DiscountQueries) validateUsageLimits(discountCode *models.DiscountCode, userID *int, sessionID string) (*models.DiscountValidationResult, error) {
-	switch discountCode.UsageType {
+// discountCodeProblem returns why a code can't be used at now on an order of orderTotal,
+// or "" when its state, dates, recurring windows and minimum amount allow it
+func discountCodeProblem(dc *models.DiscountCode, now time.Time, loc *time.Location, orderTotal float64) string {
+	if !dc.Active {
+		return "Discount code is not active"
+	}
+	if now.Before(dc.StartDate) {
+		return "Discount code is not yet valid"
+	}
+	if dc.EndDate != nil && now.After(*dc.EndDate) {
+		return "Discount code has expired"
+	}
+	if len(dc.Windows) > 0 && !discountWindowsOpen(dc.Windows, now.In(loc)) {
+		return "Discount code is not valid at this time"
+	}
+	if orderTotal < dc.MinOrderAmount {
+		return fmt.Sprintf("Minimum order amount of %.2f required", dc.MinOrderAmount)
+	}
+	return ""
+}
+
+// discountUsageProblem returns why a code's usage count doesn't allow another use, or ""
+func discountUsageProblem(dc *models.DiscountCode) string {
+	switch dc.UsageType {
 	case models.UsageTypeOneTime:
-		// Check if the code has been used at all
-		if discountCode.UsedCount > 0 {
-			return &models.DiscountValidationResult{
-				IsValid:      false,
-				ErrorMessage: "Discount code has already been used",
-			}, nil
+		if dc.UsedCount > 0 {
+			return "Discount code has already been used"
+		}
+	case models.UsageTypeUnlimited:
+		if dc.MaxUses != nil && dc.UsedCount >= *dc.MaxUses {
+			return "Discount code usage limit reached"
 		}
+	}
+	return ""
+}
+
+// guestOncePerUserMessage is shown when a guest uses a once-per-user code
+const guestOncePerUserMessage = "This discount code requires you to be logged in. Please sign in to use this discount."
+
+// validateUsageLimits checks if the discount code can be used based on usage type
+func (q *DiscountQueries) validateUsageLimits(discountCode *models.DiscountCode, userID *int, sessionID string) (*models.DiscountValidationResult, error) {
+	// Check the code's usage count against one-time use and max uses
+	if problem := discountUsageProblem(discountCode); problem != "" {
+		return &models.DiscountValidationResult{
+			IsValid:      false,
+			ErrorMessage: problem,
+		}, nil
+	}
 
+	switch discountCode.UsageType {
 	case models.UsageTypeOncePerUser:
 		// Restrict guest users from using "once per user" discount codes
 		if userID == nil {
 			return &models.DiscountValidationResult{
 				IsValid:      false,
-				ErrorMessage: "This discount code requires you to be logged in. Please sign in to use this discount.",
+				ErrorMessage: guestOncePerUserMessage,
 			}, nil
 		}
 
@@ -136,15 +166,6 @@ func (q *DiscountQueries) validateUsageLimits(discountCode *models.DiscountCode,
 				ErrorMessage: "This discount code is already applied to your cart",
 			}, nil
 		}
-
-	case models.UsageTypeUnlimited:
-		// Check max uses if specified
-		if discountCode.MaxUses != nil && discountCode.UsedCount >= *discountCode.MaxUses {
-			return &models.DiscountValidationResult{
-				IsValid:      false,
-				ErrorMessage: "Discount code usage limit reached",
-			}, nil
-		}
 	}
 
 	return &models.DiscountValidationResult{IsValid: true}, nil
@@ -154,8 +175,8 @@ func (q *DiscountQueries) validateUsageLimits(discountCode *models.DiscountCode,
 func (q *DiscountQueries) hasUserUsedCode(discountCodeID, userID int) (bool, error) {
 	var count int
 	err := q.db.QueryRow(
-		"SELECT COUNT(*) FROM discount_code_usage WHERE discount_code_id = $1 AND user_id = $2",
-		discountCodeID, userID,
+		"SELECT COUNT(*) FROM discount_code_usage WHERE discount_code_id = $1 AND user_id = $2 AND status <> $3",
+		discountCodeID, userID, models.DiscountUsageReleased,
 	).Scan(&count)
 	if err != nil {
 		return false, fmt.Errorf("failed to check user usage: %w", err)
@@ -167,8 +188,8 @@ func (q *DiscountQueries) hasUserUsedCode(discountCodeID, userID int) (bool, err
 func (q *DiscountQueries) hasSessionUsedCode(discountCodeID int, sessionID string) (bool, error) {
 	var count int
 	err := q.db.QueryRow(
-		"SELECT COUNT(*) FROM discount_code_usage WHERE discount_code_id = $1 AND session_id = $2",
-		discountCodeID, sessionID,
+		"SELECT COUNT(*) FROM discount_code_usage WHERE discount_code_id = $1 AND session_id = $2 AND status <> $3",
+		discountCodeID, sessionID, models.DiscountUsageReleased,
 	).Scan(&count)
 	if err != nil {
 		return false, fmt.Errorf("failed to check session usage: %w", err)
@@ -264,10 +285,29 @@ func (q *DiscountQueries) RecordDiscountUsage(discountCodeID int, userID *int, s
 	}
 	defer tx.Rollback()
 
+	if err := insertDiscountUsage(tx, discountCodeID, userID, sessionID, orderID, models.DiscountUsageConfirmed); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// insertDiscountUsage records a use of a discount code and counts it in tx
+func insertDiscountUsage(tx *sql.Tx, discountCodeID int, userID *int, sessionID string, orderID *int, status string) error {
+	var confirmedAt *time.Time
+	if status == models.DiscountUsageConfirmed {
+		now := time.Now()
+		confirmedAt = &now
+	}
+
 	// Insert usage record
-	_, err = tx.Exec(
-		"INSERT INTO discount_code_usage (discount_code_id, user_id, session_id, order_id) VALUES ($1, $2, $3, $4)",
-		discountCodeID, userID, sessionID, orderID,
+	_, err := tx.Exec(
+		"INSERT INTO discount_code_usage (discount_code_id, user_id, session_id, order_id, status, confirmed_at) VALUES ($1, $2, $3, $4, $5, $6)",
+		discountCodeID, userID, sessionID, orderID, status, confirmedAt,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to record discount usage: %w", err)
@@ -281,27 +321,47 @@ func (q *DiscountQueries) RecordDiscountUsage(discountCodeID int, userID *int, s
 	if err != nil {
 		return fmt.Errorf("failed to increment usage count: %w", err)
 	}
+	return nil
+}
 
-	if err = tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+// scanDiscountCode scans a row of the columns listed in the discount code queries
+func scanDiscountCode(row interface{ Scan(...interface{}) error }, dc *models.DiscountCode) error {
+	var windows []byte
+	err := row.Scan(
+		&dc.ID, &dc.Code, &dc.Description, &dc.DiscountType, &dc.DiscountValue,
+		&dc.MinOrderAmount, &dc.UsageType, &dc.MaxUses, &dc.UsedCount, &dc.Active,
+		&dc.StartDate, &dc.EndDate, &windows, &dc.IsFirstOrderOnly, &dc.CreatedBy, &dc.CreatedAt, &dc.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	dc.Windows = []models.DiscountWindow{}
+	if len(windows) > 0 {
+		if err := json.Unmarshal(windows, &dc.Windows); err != nil {
+			return fmt.Errorf("failed to decode discount windows: %w", err)
+		}
 	}
-
 	return nil
 }
 
+func discountWindowsJSON(windows []models.DiscountWindow) []byte {
+	if len(windows) == 0 {
+		return []byte("[]")
+	}
+	data, _ := json.Marshal(windows)
+	return data
+}
+
 // GetDiscountCodeByCode gets a discount code by its code string
 func (q *DiscountQueries) GetDiscountCodeByCode(code string) (*models.DiscountCode, error) {
 	var dc models.DiscountCode
-	err := q.db.QueryRow(
+	row := q.db.QueryRow(
 		`SELECT id, code, description, discount_type, discount_value, min_order_amount, 
-		 usage_type, max_uses, used_count, active, start_date, end_date, created_by, created_at, updated_at
+		 usage_type, max_uses, used_count, active, start_date, end_date, windows, is_first_order_only, created_by, created_at, updated_at
 		 FROM discount_codes WHERE code = $1`,
 		code,
-	).Scan(
-		&dc.ID, &dc.Code, &dc.Description, &dc.DiscountType, &dc.DiscountValue,
-		&dc.MinOrderAmount, &dc.UsageType, &dc.MaxUses, &dc.UsedCount, &dc.Active,
-		&dc.StartDate, &dc.EndDate, &dc.CreatedBy, &dc.CreatedAt, &dc.UpdatedAt,
 	)
+	err := scanDiscountCode(row, &dc)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("discount code not found")
@@ -316,19 +376,16 @@ func (q *DiscountQueries) GetDiscountCodeByCode(code string) (*models.DiscountCo
 // CreateDiscountCode creates a new discount code
 func (q *DiscountQueries) CreateDiscountCode(req *models.DiscountCodeRequest, createdBy int) (*models.DiscountCodeResponse, error) {
 	var dc models.DiscountCode
-	err := q.db.QueryRow(
+	row := q.db.QueryRow(
 		`INSERT INTO discount_codes (code, description, discount_type, discount_value, min_order_amount, 
-		 usage_type, max_uses, active, start_date, end_date, created_by)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		 usage_type, max_uses, active, start_date, end_date, windows, is_first_order_only, created_by)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 		 RETURNING id, code, description, discount_type, discount_value, min_order_amount, 
-		 usage_type, max_uses, used_count, active, start_date, end_date, created_by, created_at, updated_at`,
+		 usage_type, max_uses, used_count, active, start_date, end_date, windows, is_first_order_only, created_by, created_at, updated_at`,
 		req.Code, req.Description, req.DiscountType, req.DiscountValue, req.MinOrderAmount,
-		req.UsageType, req.MaxUses, req.Active, req.StartDate, req.EndDate, createdBy,
-	).Scan(
-		&dc.ID, &dc.Code, &dc.Description, &dc.DiscountType, &dc.DiscountValue,
-		&dc.MinOrderAmount, &dc.UsageType, &dc.MaxUses, &dc.UsedCount, &dc.Active,
-		&dc.StartDate, &dc.EndDate, &dc.CreatedBy, &dc.CreatedAt, &dc.UpdatedAt,
+		req.UsageType, req.MaxUses, req.Active, req.StartDate, req.EndDate, discountWindowsJSON(req.Windows), req.IsFirstOrderOnly, createdBy,
 	)
+	err := scanDiscountCode(row, &dc)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create discount code: %w", err)
 	}
@@ -366,7 +423,7 @@ func (q *DiscountQueries) GetDiscountCodes(page, limit int, activeFilter *bool)
 	// Get discount codes
 	query := fmt.Sprintf(`
 		SELECT id, code, description, discount_type, discount_value, min_order_amount, 
-		       usage_type, max_uses, used_count, active, start_date, end_date, created_by, created_at, updated_at
+		       usage_type, max_uses, used_count, active, start_date, end_date, windows, is_first_order_only, created_by, created_at, updated_at
 		FROM discount_codes %s
 		ORDER BY created_at DESC
 		LIMIT $%d OFFSET $%d`, whereClause, argIndex, argIndex+1)
@@ -382,11 +439,7 @@ func (q *DiscountQueries) GetDiscountCodes(page, limit int, activeFilter *bool)
 	var discountCodes []models.DiscountCodeResponse
 	for rows.Next() {
 		var dc models.DiscountCode
-		err := rows.Scan(
-			&dc.ID, &dc.Code, &dc.Description, &dc.DiscountType, &dc.DiscountValue,
-			&dc.MinOrderAmount, &dc.UsageType, &dc.MaxUses, &dc.UsedCount, &dc.Active,
-			&dc.StartDate, &dc.EndDate, &dc.CreatedBy, &dc.CreatedAt, &dc.UpdatedAt,
-		)
+		err := scanDiscountCode(rows, &dc)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan discount code: %w", err)
 		}
@@ -404,16 +457,13 @@ func (q *DiscountQueries) GetDiscountCodes(page, limit int, activeFilter *bool)
 // GetDiscountCodeByID gets a discount code by ID
 func (q *DiscountQueries) GetDiscountCodeByID(id int) (*models.DiscountCodeResponse, error) {
 	var dc models.DiscountCode
-	err := q.db.QueryRow(
+	row := q.db.QueryRow(
 		`SELECT id, code, description, discount_type, discount_value, min_order_amount, 
-		 usage_type, max_uses, used_count, active, start_date, end_date, created_by, created_at, updated_at
+		 usage_type, max_uses, used_count, active, start_date, end_date, windows, is_first_order_only, created_by, created_at, updated_at
 		 FROM discount_codes WHERE id = $1`,
 		id,
-	).Scan(
-		&dc.ID, &dc.Code, &dc.Description, &dc.DiscountType, &dc.DiscountValue,
-		&dc.MinOrderAmount, &dc.UsageType, &dc.MaxUses, &dc.UsedCount, &dc.Active,
-		&dc.StartDate, &dc.EndDate, &dc.CreatedBy, &dc.CreatedAt, &dc.UpdatedAt,
 	)
+	err := scanDiscountCode(row, &dc)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("discount code not found")
@@ -427,20 +477,17 @@ func (q *DiscountQueries) GetDiscountCodeByID(id int) (*models.DiscountCodeRespo
 // UpdateDiscountCode updates a discount code
 func (q *DiscountQueries) UpdateDiscountCode(id int, req *models.DiscountCodeRequest) (*models.DiscountCodeResponse, error) {
 	var dc models.DiscountCode
-	err := q.db.QueryRow(
+	row := q.db.QueryRow(
 		`UPDATE discount_codes SET 
 		 code = $1, description = $2, discount_type = $3, discount_value = $4, min_order_amount = $5,
-		 usage_type = $6, max_uses = $7, active = $8, start_date = $9, end_date = $10, updated_at = CURRENT_TIMESTAMP
-		 WHERE id = $11
+		 usage_type = $6, max_uses = $7, active = $8, start_date = $9, end_date = $10, windows = $11, is_first_order_only = $12, updated_at = CURRENT_TIMESTAMP
+		 WHERE id = $13
 		 RETURNING id, code, description, discount_type, discount_value, min_order_amount, 
-		 usage_type, max_uses, used_count, active, start_date, end_date, created_by, created_at, updated_at`,
+		 usage_type, max_uses, used_count, active, start_date, end_date, windows, is_first_order_only, created_by, created_at, updated_at`,
 		req.Code, req.Description, req.DiscountType, req.DiscountValue, req.MinOrderAmount,
-		req.UsageType, req.MaxUses, req.Active, req.StartDate, req.EndDate, id,
-	).Scan(
-		&dc.ID, &dc.Code, &dc.Description, &dc.DiscountType, &dc.DiscountValue,
-		&dc.MinOrderAmount, &dc.UsageType, &dc.MaxUses, &dc.UsedCount, &dc.Active,
-		&dc.StartDate, &dc.EndDate, &dc.CreatedBy, &dc.CreatedAt, &dc.UpdatedAt,
+		req.UsageType, req.MaxUses, req.Active, req.StartDate, req.EndDate, discountWindowsJSON(req.Windows), req.IsFirstOrderOnly, id,
 	)
+	err := scanDiscountCode(row, &dc)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("discount code not found")
@@ -483,7 +530,7 @@ func (q *DiscountQueries) GetDiscountCodeUsage(id int) ([]models.DiscountCodeUsa
 	}
 
 	rows, err := q.db.Query(
-		`SELECT id, discount_code_id, user_id, session_id, order_id, created_at
+		`SELECT id, discount_code_id, user_id, session_id, order_id, status, confirmed_at, released_at, created_at
 		 FROM discount_code_usage WHERE discount_code_id = $1 ORDER BY created_at DESC`,
 		id,
 	)
@@ -495,7 +542,7 @@ func (q *DiscountQueries) GetDiscountCodeUsage(id int) ([]models.DiscountCodeUsa
 	var usage []models.DiscountCodeUsage
 	for rows.Next() {
 		var u models.DiscountCodeUsage
-		err := rows.Scan(&u.ID, &u.DiscountCodeID, &u.UserID, &u.SessionID, &u.OrderID, &u.CreatedAt)
+		err := rows.Scan(&u.ID, &u.DiscountCodeID, &u.UserID, &u.SessionID, &u.OrderID, &u.Status, &u.ConfirmedAt, &u.ReleasedAt, &u.CreatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan usage record: %w", err)
 		}
@@ -524,10 +571,148 @@ func (q *DiscountQueries) buildDiscountCodeResponse(dc *models.DiscountCode) *mo
 		Active:          dc.Active,
 		StartDate:       dc.StartDate,
 		EndDate:         dc.EndDate,
+		Windows:         dc.Windows,
+		IsFirstOrderOnly: dc.IsFirstOrderOnly,
 		CreatedBy:       dc.CreatedBy,
 		CreatedAt:       dc.CreatedAt,
 		UpdatedAt:       dc.UpdatedAt,
 		IsExpired:       isExpired,
 		IsUsageExceeded: isUsageExceeded,
 	}
-}
\ No newline at end of file
+}
+
+// FirstOrderOnlyMessage is shown when a first-order-only code is used by a returning customer
+const FirstOrderOnlyMessage = "This discount code is only valid on your first order"
+
+// hasPriorOrders reports whether the customer placed an order before, matched by user
+// ID, the user's account email or email. Every order that was not cancelled counts, so
+// one still pending blocks a second use.
+func hasPriorOrders(db interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}, userID *int, email string) (bool, error) {
+	var exists bool
+	err := db.QueryRow(`
+		SELECT EXISTS(
+			SELECT 1 FROM orders
+			WHERE status <> $3 AND (
+				user_id = $1::int
+				OR LOWER(email) = LOWER($2)
+				OR LOWER(email) = (SELECT LOWER(email) FROM users WHERE id = $1::int)
+			)
+		)`, userID, strings.TrimSpace(email), models.OrderStatusCancelled).Scan(&exists)
+	return exists, err
+}
+
+// HasPriorOrders reports whether the customer placed an order before
+func (q *DiscountQueries) HasPriorOrders(userID *int, email string) (bool, error) {
+	hasOrders, err := hasPriorOrders(q.db, userID, email)
+	if err != nil {
+		return false, fmt.Errorf("failed to check previous orders: %w", err)
+	}
+	return hasOrders, nil
+}
+
+// checkFirstOrderDiscount rejects an order using a first-order-only code when the
+// customer ordered before. It runs in the order's transaction under a lock on the
+// customer, so two concurrent checkouts can't both get the first order discount.
+func checkFirstOrderDiscount(tx *sql.Tx, order *models.Order) error {
+	var firstOrderOnly bool
+	err := tx.QueryRow(`SELECT is_first_order_only FROM discount_codes WHERE id = $1`, *order.DiscountCodeID).Scan(&firstOrderOnly)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return fmt.Errorf("failed to get discount code: %w", err)
+	}
+	if !firstOrderOnly {
+		return nil
+	}
+
+	if order.UserID != nil {
+		if _, err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext('first_order_user'), $1)`, *order.UserID); err != nil {
+			return fmt.Errorf("failed to lock customer: %w", err)
+		}
+	}
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext('first_order_email'), hashtext(LOWER($1)))`, order.Email); err != nil {
+		return fmt.Errorf("failed to lock customer: %w", err)
+	}
+
+	hasOrders, err := hasPriorOrders(tx, order.UserID, order.Email)
+	if err != nil {
+		return fmt.Errorf("failed to check previous orders: %w", err)
+	}
+	if hasOrders {
+		return fmt.Errorf("discount code is only valid on the first order")
+	}
+	return nil
+}
+
+// DiscountRejectedError is returned by CreateOrder when the order's discount code can
+// no longer be used. Message is meant for the customer.
+type DiscountRejectedError struct {
+	Message string
+}
+
+func (e *DiscountRejectedError) Error() string {
+	return e.Message
+}
+
+// claimDiscount checks again, in the order's transaction, that the order's discount code
+// can be used. The code's row stays locked until the transaction ends, so two checkouts
+// can't both take the last use of a code; the use is recorded once the order has an ID.
+func claimDiscount(tx *sql.Tx, db *sql.DB, order *models.Order) error {
+	var dc models.DiscountCode
+	err := scanDiscountCode(tx.QueryRow(
+		`SELECT id, code, description, discount_type, discount_value, min_order_amount, 
+		 usage_type, max_uses, used_count, active, start_date, end_date, windows, is_first_order_only, created_by, created_at, updated_at
+		 FROM discount_codes WHERE id = $1 FOR UPDATE`,
+		*order.DiscountCodeID,
+	), &dc)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &DiscountRejectedError{Message: "Invalid discount code"}
+		}
+		return fmt.Errorf("failed to get discount code: %w", err)
+	}
+
+	if problem := discountCodeProblem(&dc, time.Now(), NewSettingsQueries(db).GetShopLocation(), order.Subtotal); problem != "" {
+		return &DiscountRejectedError{Message: problem}
+	}
+	if problem := discountUsageProblem(&dc); problem != "" {
+		return &DiscountRejectedError{Message: problem}
+	}
+	// The code may have been edited since the checkout computed the discount
+	if pricing.Discount(dc.DiscountType, dc.DiscountValue, order.Subtotal) != pricing.Round(order.DiscountAmount) {
+		return &DiscountRejectedError{Message: "This discount code has changed, please review your cart"}
+	}
+
+	if dc.UsageType == models.UsageTypeOncePerUser {
+		if order.UserID == nil {
+			return &DiscountRejectedError{Message: guestOncePerUserMessage}
+		}
+		var used bool
+		err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM discount_code_usage WHERE discount_code_id = $1 AND user_id = $2 AND status <> $3)`,
+			dc.ID, *order.UserID, models.DiscountUsageReleased).Scan(&used)
+		if err != nil {
+			return fmt.Errorf("failed to check user usage: %w", err)
+		}
+		if used {
+			return &DiscountRejectedError{Message: "You have already used this discount code"}
+		}
+	}
+
+	if dc.IsFirstOrderOnly {
+		return checkFirstOrderDiscount(tx, order)
+	}
+	return nil
+}
+
+// recordOrderDiscount records the use of the order's discount code in its transaction.
+// The use stays pending until the order is paid.
+func recordOrderDiscount(tx *sql.Tx, order *models.Order) error {
+	sessionID := ""
+	if order.SessionID != nil {
+		sessionID = *order.SessionID
+	}
+	return insertDiscountUsage(tx, *order.DiscountCodeID, order.UserID, sessionID, &order.ID, models.DiscountUsagePending)
+}
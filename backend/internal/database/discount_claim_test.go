@@ -0,0 +1,68 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"notsofluffy-backend/internal/models"
+)
+
+func TestDiscountCodeProblem(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	yesterday := now.AddDate(0, 0, -1)
+
+	code := func(change func(dc *models.DiscountCode)) *models.DiscountCode {
+		dc := &models.DiscountCode{Active: true, StartDate: now.AddDate(0, -1, 0), MinOrderAmount: 50}
+		if change != nil {
+			change(dc)
+		}
+		return dc
+	}
+
+	tests := []struct {
+		name  string
+		dc    *models.DiscountCode
+		total float64
+		want  string
+	}{
+		{"usable", code(nil), 50, ""},
+		{"inactive", code(func(dc *models.DiscountCode) { dc.Active = false }), 100, "Discount code is not active"},
+		{"not started", code(func(dc *models.DiscountCode) { dc.StartDate = now.Add(time.Hour) }), 100, "Discount code is not yet valid"},
+		{"expired", code(func(dc *models.DiscountCode) { dc.EndDate = &yesterday }), 100, "Discount code has expired"},
+		{"outside window", code(func(dc *models.DiscountCode) {
+			dc.Windows = []models.DiscountWindow{{StartTime: "17:00", EndTime: "19:00"}}
+		}), 100, "Discount code is not valid at this time"},
+		{"under minimum", code(nil), 49.99, "Minimum order amount of 50.00 required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := discountCodeProblem(tt.dc, now, time.UTC, tt.total); got != tt.want {
+				t.Errorf("discountCodeProblem() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiscountUsageProblem(t *testing.T) {
+	two := 2
+
+	tests := []struct {
+		name string
+		dc   models.DiscountCode
+		want string
+	}{
+		{"one time unused", models.DiscountCode{UsageType: models.UsageTypeOneTime}, ""},
+		{"one time used", models.DiscountCode{UsageType: models.UsageTypeOneTime, UsedCount: 1}, "Discount code has already been used"},
+		{"unlimited without max", models.DiscountCode{UsageType: models.UsageTypeUnlimited, UsedCount: 100}, ""},
+		{"under max uses", models.DiscountCode{UsageType: models.UsageTypeUnlimited, MaxUses: &two, UsedCount: 1}, ""},
+		{"max uses reached", models.DiscountCode{UsageType: models.UsageTypeUnlimited, MaxUses: &two, UsedCount: 2}, "Discount code usage limit reached"},
+		{"once per user is checked per user", models.DiscountCode{UsageType: models.UsageTypeOncePerUser, UsedCount: 5}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := discountUsageProblem(&tt.dc); got != tt.want {
+				t.Errorf("discountUsageProblem() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"notsofluffy-backend/internal/models"
+)
+
+// confirmOrderDiscount confirms the discount use of an order once it is paid. A use
+// already released because the payment took too long is counted again: the customer
+// paid for the order with the discount.
+func confirmOrderDiscount(tx *sql.Tx, orderID int) error {
+	_, err := tx.Exec(`
+		UPDATE discount_codes dc
+		SET used_count = dc.used_count + r.uses, updated_at = CURRENT_TIMESTAMP
+		FROM (
+			SELECT discount_code_id, COUNT(*) AS uses
+			FROM discount_code_usage
+			WHERE order_id = $1 AND status = $2
+			GROUP BY discount_code_id
+		) r
+		WHERE dc.id = r.discount_code_id`,
+		orderID, models.DiscountUsageReleased)
+	if err != nil {
+		return fmt.Errorf("failed to count discount usage: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE discount_code_usage
+		SET status = $1, confirmed_at = CURRENT_TIMESTAMP, released_at = NULL
+		WHERE order_id = $2 AND status <> $1`,
+		models.DiscountUsageConfirmed, orderID)
+	if err != nil {
+		return fmt.Errorf("failed to confirm discount usage: %w", err)
+	}
+	return nil
+}
+
+// releaseOrderDiscounts releases the discount uses of orders so the codes can be used
+// again, and returns how many were released
+func releaseOrderDiscounts(tx *sql.Tx, orderIDs []int) (int, error) {
+	_, err := tx.Exec(`
+		UPDATE discount_codes dc
+		SET used_count = GREATEST(dc.used_count - r.uses, 0), updated_at = CURRENT_TIMESTAMP
+		FROM (
+			SELECT discount_code_id, COUNT(*) AS uses
+			FROM discount_code_usage
+			WHERE order_id = ANY($1) AND status <> $2
+			GROUP BY discount_code_id
+		) r
+		WHERE dc.id = r.discount_code_id`,
+		pq.Array(orderIDs), models.DiscountUsageReleased)
+	if err != nil {
+		return 0, fmt.Errorf("failed to uncount discount usage: %w", err)
+	}
+
+	result, err := tx.Exec(`
+		UPDATE discount_code_usage
+		SET status = $1, released_at = CURRENT_TIMESTAMP
+		WHERE order_id = ANY($2) AND status <> $1`,
+		models.DiscountUsageReleased, pq.Array(orderIDs))
+	if err != nil {
+		return 0, fmt.Errorf("failed to release discount usage: %w", err)
+	}
+	released, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to release discount usage: %w", err)
+	}
+	return int(released), nil
+}
+
+// ReleaseExpiredDiscountUsage releases the pending discount uses of online orders
+// whose payment was not completed before the given time. Orders paid offline keep
+// their uses until they are paid or cancelled.
+func (q *DiscountQueries) ReleaseExpiredDiscountUsage(before time.Time) (int, error) {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		SELECT o.id
+		FROM orders o
+		WHERE o.payment_method = $1 AND o.payment_status IN ($2, $3)
+		  AND EXISTS (
+			SELECT 1 FROM discount_code_usage u
+			WHERE u.order_id = o.id AND u.status = $4 AND u.created_at < $5
+		  )
+		FOR UPDATE OF o SKIP LOCKED`,
+		models.PaymentMethodOnline, models.PaymentStatusPending, models.PaymentStatusFailed,
+		models.DiscountUsagePending, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get expired discount usage: %w", err)
+	}
+	orderIDs := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan order: %w", err)
+		}
+		orderIDs = append(orderIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to get expired discount usage: %w", err)
+	}
+	if len(orderIDs) == 0 {
+		return 0, nil
+	}
+
+	released, err := releaseOrderDiscounts(tx, orderIDs)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return released, nil
+}
@@ -123,16 +123,12 @@ func TestOncePerUserDiscountUsage(t *testing.T) {
 		},
 	}
 
-	orderResponse1, err := orderQueries.CreateOrder(order1, shippingAddr, billingAddr, items)
+	_, err = orderQueries.CreateOrder(order1, shippingAddr, billingAddr, items)
 	if err != nil {
 		t.Fatalf("Failed to create first order: %v", err)
 	}
 
-	// Step 4: Record discount usage (simulating what happens after order creation)
-	err = discountQueries.RecordDiscountUsage(discountCodeID, &userID, sessionID, &orderResponse1.ID)
-	if err != nil {
-		t.Fatalf("Failed to record discount usage: %v", err)
-	}
+	// Step 4: CreateOrder recorded the discount usage along with the order
 
 	// Step 5: Try to validate the same code again for the same user
 	validation2, err := discountQueries.ValidateDiscountCode("ONCEUSER10", 100.0, &userID, sessionID)
@@ -0,0 +1,126 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"notsofluffy-backend/internal/models"
+)
+
+// ListDiscountCodeUsers returns the customers a discount code is restricted to
+func (q *DiscountQueries) ListDiscountCodeUsers(discountCodeID int) ([]models.DiscountCodeUser, error) {
+	rows, err := q.db.Query(`
+		SELECT dcu.id, dcu.discount_code_id, dcu.user_id, u.email, dcu.email, dcu.created_by, dcu.created_at
+		FROM discount_code_users dcu
+		LEFT JOIN users u ON u.id = dcu.user_id
+		WHERE dcu.discount_code_id = $1
+		ORDER BY dcu.created_at, dcu.id`, discountCodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list discount code users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []models.DiscountCodeUser{}
+	for rows.Next() {
+		var user models.DiscountCodeUser
+		if err := rows.Scan(&user.ID, &user.DiscountCodeID, &user.UserID, &user.UserEmail, &user.Email, &user.CreatedBy, &user.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan discount code user: %w", err)
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list discount code users: %w", err)
+	}
+	return users, nil
+}
+
+// AddDiscountCodeUser restricts a discount code to a user or an email address, on top
+// of the ones it is already restricted to
+func (q *DiscountQueries) AddDiscountCodeUser(discountCodeID int, req *models.DiscountCodeUserRequest, createdBy *int) (*models.DiscountCodeUser, error) {
+	var email *string
+	if req.Email != "" {
+		normalized := strings.ToLower(strings.TrimSpace(req.Email))
+		email = &normalized
+	}
+
+	var user models.DiscountCodeUser
+	err := q.db.QueryRow(`
+		INSERT INTO discount_code_users (discount_code_id, user_id, email, created_by)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, discount_code_id, user_id, (SELECT email FROM users WHERE id = $2), email, created_by, created_at`,
+		discountCodeID, req.UserID, email, createdBy,
+	).Scan(&user.ID, &user.DiscountCodeID, &user.UserID, &user.UserEmail, &user.Email, &user.CreatedBy, &user.CreatedAt)
+	if err != nil {
+		errMsg := err.Error()
+		switch {
+		case strings.Contains(errMsg, "duplicate key"):
+			return nil, fmt.Errorf("discount code user already exists")
+		case strings.Contains(errMsg, "discount_code_users_discount_code_id_fkey"):
+			return nil, fmt.Errorf("discount code not found")
+		case strings.Contains(errMsg, "discount_code_users_user_id_fkey"):
+			return nil, fmt.Errorf("user not found")
+		}
+		return nil, fmt.Errorf("failed to add discount code user: %w", err)
+	}
+	return &user, nil
+}
+
+// RemoveDiscountCodeUser lifts one restriction of a discount code
+func (q *DiscountQueries) RemoveDiscountCodeUser(discountCodeID, id int) error {
+	result, err := q.db.Exec(`DELETE FROM discount_code_users WHERE id = $1 AND discount_code_id = $2`, id, discountCodeID)
+	if err != nil {
+		return fmt.Errorf("failed to remove discount code user: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("discount code user not found")
+	}
+	return nil
+}
+
+// ValidateDiscountRecipient checks that a customer may use a discount code restricted
+// to specific users or email addresses. email is the order's email address; when it is
+// not known yet (applying the code to a guest's cart) codes assigned to an email address
+// are let through and checked again at order creation.
+func (q *DiscountQueries) ValidateDiscountRecipient(discountCodeID int, userID *int, email string) (*models.DiscountValidationResult, error) {
+	var total, matches, emailEntries int
+	err := q.db.QueryRow(`
+		SELECT COUNT(*),
+			COUNT(*) FILTER (WHERE dcu.user_id = $2::int
+				OR LOWER(dcu.email) = LOWER($3)
+				OR LOWER(dcu.email) = (SELECT LOWER(email) FROM users WHERE id = $2::int)),
+			COUNT(*) FILTER (WHERE dcu.email IS NOT NULL)
+		FROM discount_code_users dcu
+		WHERE dcu.discount_code_id = $1`,
+		discountCodeID, userID, strings.TrimSpace(email),
+	).Scan(&total, &matches, &emailEntries)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to check discount code users: %w", err)
+	}
+
+	switch {
+	case total == 0 || matches > 0:
+		return &models.DiscountValidationResult{IsValid: true}, nil
+	case userID == nil && email == "" && emailEntries > 0:
+		return &models.DiscountValidationResult{IsValid: true}, nil
+	case userID == nil && email == "":
+		return &models.DiscountValidationResult{
+			IsValid:      false,
+			ErrorMessage: "This discount code requires you to be logged in. Please sign in to use this discount.",
+		}, nil
+	case email != "":
+		return &models.DiscountValidationResult{
+			IsValid:      false,
+			ErrorMessage: "This discount code is not available for this email address",
+		}, nil
+	}
+	return &models.DiscountValidationResult{
+		IsValid:      false,
+		ErrorMessage: "This discount code is not available for your account",
+	}, nil
+}
@@ -0,0 +1,97 @@
+package database
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"notsofluffy-backend/internal/models"
+)
+
+const minutesPerDay = 24 * 60
+
+// ValidateDiscountWindows checks the weekdays and times of discount windows and returns
+// a message describing the first problem, or "" when they are valid
+func ValidateDiscountWindows(windows []models.DiscountWindow) string {
+	for i, window := range windows {
+		for _, weekday := range window.Weekdays {
+			if weekday < 0 || weekday > 6 {
+				return fmt.Sprintf("Window %d: weekdays must be between 0 (Sunday) and 6 (Saturday)", i+1)
+			}
+		}
+		start, ok := parseWindowTime(window.StartTime, 0)
+		if !ok || start == minutesPerDay {
+			return fmt.Sprintf("Window %d: start time must be HH:MM", i+1)
+		}
+		end, ok := parseWindowTime(window.EndTime, minutesPerDay)
+		if !ok {
+			return fmt.Sprintf("Window %d: end time must be HH:MM", i+1)
+		}
+		if start == end {
+			return fmt.Sprintf("Window %d: start and end time must differ", i+1)
+		}
+	}
+	return ""
+}
+
+// discountWindowsOpen reports whether t falls into one of the windows; no windows
+// means no restriction
+func discountWindowsOpen(windows []models.DiscountWindow, t time.Time) bool {
+	if len(windows) == 0 {
+		return true
+	}
+
+	minute := t.Hour()*60 + t.Minute()
+	today := int(t.Weekday())
+	yesterday := (today + 6) % 7
+	for _, window := range windows {
+		start, _ := parseWindowTime(window.StartTime, 0)
+		end, _ := parseWindowTime(window.EndTime, minutesPerDay)
+		if start < end {
+			if windowOnDay(window, today) && minute >= start && minute < end {
+				return true
+			}
+			continue
+		}
+		// Past midnight: the evening part belongs to today, the morning part to yesterday
+		if (windowOnDay(window, today) && minute >= start) || (windowOnDay(window, yesterday) && minute < end) {
+			return true
+		}
+	}
+	return false
+}
+
+func windowOnDay(window models.DiscountWindow, weekday int) bool {
+	if len(window.Weekdays) == 0 {
+		return true
+	}
+	for _, day := range window.Weekdays {
+		if day == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// parseWindowTime returns the minute of the day of an "HH:MM" time, "24:00" being the
+// end of the day, or def for an empty one
+func parseWindowTime(value string, def int) (int, bool) {
+	if value == "" {
+		return def, true
+	}
+	if len(value) != 5 || value[2] != ':' {
+		return 0, false
+	}
+	hour, err := strconv.Atoi(value[:2])
+	if err != nil {
+		return 0, false
+	}
+	minute, err := strconv.Atoi(value[3:])
+	if err != nil {
+		return 0, false
+	}
+	if minute < 0 || minute > 59 || hour < 0 || hour > 24 || (hour == 24 && minute != 0) {
+		return 0, false
+	}
+	return hour*60 + minute, true
+}
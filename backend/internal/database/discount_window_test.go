@@ -0,0 +1,65 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"notsofluffy-backend/internal/models"
+)
+
+func TestDiscountWindowsOpen(t *testing.T) {
+	warsaw, err := time.LoadLocation("Europe/Warsaw")
+	if err != nil {
+		t.Skip("time zone data not available")
+	}
+	// 2026-10-16 is a Friday
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 10, day, hour, minute, 0, 0, warsaw)
+	}
+
+	weekend := []models.DiscountWindow{{Weekdays: []int{0, 6}}}
+	happyHour := []models.DiscountWindow{{StartTime: "17:00", EndTime: "19:00"}}
+	lateFriday := []models.DiscountWindow{{Weekdays: []int{5}, StartTime: "22:00", EndTime: "02:00"}}
+
+	tests := []struct {
+		name    string
+		windows []models.DiscountWindow
+		at      time.Time
+		want    bool
+	}{
+		{"no windows", nil, at(16, 12, 0), true},
+		{"weekend on saturday", weekend, at(17, 9, 0), true},
+		{"weekend on friday", weekend, at(16, 23, 59), false},
+		{"happy hour start", happyHour, at(16, 17, 0), true},
+		{"happy hour end is exclusive", happyHour, at(16, 19, 0), false},
+		{"past midnight evening", lateFriday, at(16, 23, 0), true},
+		{"past midnight morning after", lateFriday, at(17, 1, 30), true},
+		{"past midnight morning before", lateFriday, at(16, 1, 30), false},
+		{"utc instant in shop time", happyHour, time.Date(2026, 10, 16, 15, 30, 0, 0, time.UTC).In(warsaw), true},
+	}
+	for _, tt := range tests {
+		if got := discountWindowsOpen(tt.windows, tt.at); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidateDiscountWindows(t *testing.T) {
+	valid := []models.DiscountWindow{{Weekdays: []int{1, 2}, StartTime: "08:00", EndTime: "24:00"}, {}}
+	if msg := ValidateDiscountWindows(valid); msg != "" {
+		t.Fatalf("unexpected error: %s", msg)
+	}
+
+	invalid := [][]models.DiscountWindow{
+		{{Weekdays: []int{7}}},
+		{{StartTime: "8:00"}},
+		{{StartTime: "24:00"}},
+		{{EndTime: "12:60"}},
+		{{StartTime: "10:00", EndTime: "10:00"}},
+	}
+	for _, windows := range invalid {
+		if ValidateDiscountWindows(windows) == "" {
+			t.Errorf("expected %+v to be invalid", windows)
+		}
+	}
+}
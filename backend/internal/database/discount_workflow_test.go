@@ -160,11 +160,15 @@ func TestDiscountWorkflowRaceCondition(t *testing.T) {
 		t.Fatalf("Failed to create order: %v", err)
 	}
 
-	// Step 6: Record discount usage (this should happen in order creation, but let's test it separately)
-	err = discountQueries.RecordDiscountUsage(discountCodeID, &userID, sessionID, &orderResponse.ID)
+	// Step 6: Order creation records the discount usage in its transaction
+	var usageCount int
+	err = db.QueryRow("SELECT COUNT(*) FROM discount_code_usage WHERE discount_code_id = $1 AND order_id = $2",
+		discountCodeID, orderResponse.ID).Scan(&usageCount)
 	if err != nil {
-		t.Errorf("Failed to record discount usage: %v", err)
-		t.Logf("This could be the source of the bug - if usage recording fails silently!")
+		t.Fatalf("Failed to count discount usage: %v", err)
+	}
+	if usageCount != 1 {
+		t.Errorf("Expected the order to record 1 discount usage, got %d", usageCount)
 	}
 
 	// Step 7: Now try the workflow again with a new cart session
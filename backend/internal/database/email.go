@@ -0,0 +1,256 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"notsofluffy-backend/internal/mail"
+	"notsofluffy-backend/internal/models"
+)
+
+// emailClaimLease is how long a claimed email is hidden from other workers while it is sent
+const emailClaimLease = 5 * time.Minute
+
+type EmailQueries struct {
+	db *sql.DB
+}
+
+func NewEmailQueries(db *sql.DB) *EmailQueries {
+	return &EmailQueries{db: db}
+}
+
+// ListEmailTemplateOverrides returns the templates admins customized, by key
+func (q *EmailQueries) ListEmailTemplateOverrides() (map[string]models.EmailTemplate, error) {
+	rows, err := q.db.Query(`SELECT key, subject, text_body, html_body, updated_at FROM email_templates`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list email templates: %w", err)
+	}
+	defer rows.Close()
+
+	overrides := map[string]models.EmailTemplate{}
+	for rows.Next() {
+		t := models.EmailTemplate{Customized: true}
+		if err := rows.Scan(&t.Key, &t.Subject, &t.Text, &t.HTML, &t.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan email template: %w", err)
+		}
+		overrides[t.Key] = t
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list email templates: %w", err)
+	}
+	return overrides, nil
+}
+
+// GetEmailTemplate returns the template emails of key are rendered with: the admin's
+// customization, or the built-in default
+func (q *EmailQueries) GetEmailTemplate(key string) (mail.Template, error) {
+	var t mail.Template
+	err := q.db.QueryRow(`SELECT subject, text_body, html_body FROM email_templates WHERE key = $1`, key).Scan(&t.Subject, &t.Text, &t.HTML)
+	if err == sql.ErrNoRows {
+		if t, ok := mail.DefaultTemplates[key]; ok {
+			return t, nil
+		}
+		return t, fmt.Errorf("email template not found")
+	}
+	if err != nil {
+		return t, fmt.Errorf("failed to get email template: %w", err)
+	}
+	return t, nil
+}
+
+// SaveEmailTemplate stores a customized template
+func (q *EmailQueries) SaveEmailTemplate(key string, req *models.EmailTemplateRequest) (*models.EmailTemplate, error) {
+	t := models.EmailTemplate{Key: key, Subject: req.Subject, Text: req.Text, HTML: req.HTML, Customized: true}
+	err := q.db.QueryRow(`
+		INSERT INTO email_templates (key, subject, text_body, html_body)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (key) DO UPDATE SET subject = EXCLUDED.subject, text_body = EXCLUDED.text_body, html_body = EXCLUDED.html_body
+		RETURNING updated_at`, key, req.Subject, req.Text, req.HTML).Scan(&t.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save email template: %w", err)
+	}
+	return &t, nil
+}
+
+// DeleteEmailTemplate drops a customization, so the built-in default is used again
+func (q *EmailQueries) DeleteEmailTemplate(key string) error {
+	if _, err := q.db.Exec(`DELETE FROM email_templates WHERE key = $1`, key); err != nil {
+		return fmt.Errorf("failed to delete email template: %w", err)
+	}
+	return nil
+}
+
+// EnqueueEmail stores a rendered email in the outbox, to be sent by the email job.
+// Emails of a category the recipient opted out of are dropped. Emails to suppressed
+// addresses are stored as failed, so the outbox shows they never went out.
+func (q *EmailQueries) EnqueueEmail(template string, msg mail.Message, orderID *int) error {
+	wanted, err := q.emailWanted(msg.To, mail.TemplateCategory(template))
+	if err != nil {
+		return err
+	}
+	if !wanted {
+		return nil
+	}
+	suppression, err := q.getEmailSuppression(msg.To)
+	if err != nil {
+		return err
+	}
+	status := models.EmailOutboxPending
+	var lastError *string
+	if suppression != nil {
+		status = models.EmailOutboxFailed
+		reason := "Address undeliverable: " + suppression.Reason
+		lastError = &reason
+	}
+
+	_, err = q.db.Exec(`
+		INSERT INTO email_outbox (template, to_address, subject, text_body, html_body, order_id, status, last_error, next_attempt_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, CASE WHEN $9 THEN CURRENT_TIMESTAMP END)`,
+		template, msg.To, msg.Subject, msg.Body, msg.HTML, orderID, status, lastError, suppression == nil)
+	if err != nil {
+		return fmt.Errorf("failed to queue email: %w", err)
+	}
+	return nil
+}
+
+// DueEmail is a claimed outbox email ready to be sent
+type DueEmail struct {
+	ID       int
+	Template string
+	Attempts int
+	Message  mail.Message
+}
+
+// ClaimDueEmails picks up to limit pending emails whose next attempt is due and leases
+// them, so concurrent workers don't send the same email twice
+func (q *EmailQueries) ClaimDueEmails(limit int) ([]DueEmail, error) {
+	rows, err := q.db.Query(`
+		UPDATE email_outbox
+		SET next_attempt_at = CURRENT_TIMESTAMP + $2::int * INTERVAL '1 second'
+		WHERE id IN (
+			SELECT id FROM email_outbox
+			WHERE status = 'pending' AND next_attempt_at <= CURRENT_TIMESTAMP
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, template, attempts, to_address, subject, text_body, html_body`, limit, int(emailClaimLease.Seconds()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim emails: %w", err)
+	}
+	defer rows.Close()
+
+	var due []DueEmail
+	for rows.Next() {
+		var e DueEmail
+		if err := rows.Scan(&e.ID, &e.Template, &e.Attempts, &e.Message.To, &e.Message.Subject, &e.Message.Body, &e.Message.HTML); err != nil {
+			return nil, fmt.Errorf("failed to scan email: %w", err)
+		}
+		due = append(due, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to claim emails: %w", err)
+	}
+	return due, nil
+}
+
+// RecordEmailAttempt stores the outcome of sending an email. A failed attempt is
+// retried at retryAt, or the email is given up when retryAt is nil.
+func (q *EmailQueries) RecordEmailAttempt(id int, sendErr error, retryAt *time.Time) error {
+	var lastError *string
+	status := models.EmailOutboxSent
+	if sendErr != nil {
+		msg := sendErr.Error()
+		lastError = &msg
+		status = models.EmailOutboxFailed
+		if retryAt != nil {
+			status = models.EmailOutboxPending
+		}
+	}
+
+	_, err := q.db.Exec(`
+		UPDATE email_outbox
+		SET status = $1, attempts = attempts + 1, last_error = $2, last_attempt_at = CURRENT_TIMESTAMP, next_attempt_at = $3,
+			sent_at = CASE WHEN $1 = 'sent' THEN CURRENT_TIMESTAMP ELSE sent_at END
+		WHERE id = $4`, status, lastError, retryAt, id)
+	if err != nil {
+		return fmt.Errorf("failed to record email attempt: %w", err)
+	}
+	return nil
+}
+
+const outboxEmailColumns = `id, template, to_address, subject, order_id, status, attempts, next_attempt_at, last_error, sent_at, created_at`
+
+func scanOutboxEmail(row interface{ Scan(...interface{}) error }, e *models.OutboxEmail) error {
+	return row.Scan(&e.ID, &e.Template, &e.ToAddress, &e.Subject, &e.OrderID, &e.Status, &e.Attempts, &e.NextAttemptAt,
+		&e.LastError, &e.SentAt, &e.CreatedAt)
+}
+
+// ListOutbox returns outbox emails, newest first, optionally in one status
+func (q *EmailQueries) ListOutbox(status string, page, limit int) (*models.OutboxEmailListResponse, error) {
+	condition := "1=1"
+	args := []interface{}{}
+	if status != "" {
+		args = append(args, status)
+		condition = "status = $1"
+	}
+
+	var total int
+	if err := q.db.QueryRow(`SELECT COUNT(*) FROM email_outbox WHERE `+condition, args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count outbox emails: %w", err)
+	}
+
+	args = append(args, limit, (page-1)*limit)
+	rows, err := q.db.Query(fmt.Sprintf(`
+		SELECT `+outboxEmailColumns+` FROM email_outbox
+		WHERE %s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d`, condition, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list outbox emails: %w", err)
+	}
+	defer rows.Close()
+
+	emails := []models.OutboxEmail{}
+	for rows.Next() {
+		var e models.OutboxEmail
+		if err := scanOutboxEmail(rows, &e); err != nil {
+			return nil, fmt.Errorf("failed to scan outbox email: %w", err)
+		}
+		emails = append(emails, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list outbox emails: %w", err)
+	}
+
+	return &models.OutboxEmailListResponse{Emails: emails, Total: total, Page: page, Limit: limit}, nil
+}
+
+// RetryEmail queues a failed email to be sent again right away with a fresh set of
+// attempts. Emails to suppressed addresses stay failed.
+func (q *EmailQueries) RetryEmail(id int) (*models.OutboxEmail, error) {
+	var e models.OutboxEmail
+	err := scanOutboxEmail(q.db.QueryRow(`
+		UPDATE email_outbox
+		SET status = 'pending', attempts = 0, next_attempt_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'failed'
+		  AND NOT EXISTS (SELECT 1 FROM email_suppressions WHERE email = LOWER(email_outbox.to_address))
+		RETURNING `+outboxEmailColumns, id), &e)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			var suppressed bool
+			if err := q.db.QueryRow(`
+				SELECT EXISTS (SELECT 1 FROM email_outbox o JOIN email_suppressions s ON s.email = LOWER(o.to_address)
+				WHERE o.id = $1 AND o.status = 'failed')`, id).Scan(&suppressed); err != nil {
+				return nil, fmt.Errorf("failed to retry email: %w", err)
+			}
+			if suppressed {
+				return nil, fmt.Errorf("email address is suppressed")
+			}
+			return nil, fmt.Errorf("failed email not found")
+		}
+		return nil, fmt.Errorf("failed to retry email: %w", err)
+	}
+	return &e, nil
+}
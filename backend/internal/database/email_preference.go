@@ -0,0 +1,90 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"notsofluffy-backend/internal/mail"
+	"notsofluffy-backend/internal/models"
+)
+
+// emailCategoryColumns maps email categories to their email_preferences column
+var emailCategoryColumns = map[string]string{
+	mail.CategoryOrderUpdates: "order_updates",
+	mail.CategoryPromotions:   "promotions",
+	mail.CategoryBackInStock:  "back_in_stock",
+}
+
+// GetEmailPreferences returns the preferences of an email address, the defaults when
+// it has none saved
+func (q *EmailQueries) GetEmailPreferences(email string) (*models.EmailPreferences, error) {
+	prefs := models.DefaultEmailPreferences()
+	err := q.db.QueryRow(`
+		SELECT order_updates, promotions, back_in_stock, updated_at
+		FROM email_preferences WHERE email = $1`,
+		normalizeEmail(email)).Scan(&prefs.OrderUpdates, &prefs.Promotions, &prefs.BackInStock, &prefs.UpdatedAt)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get email preferences: %w", err)
+	}
+	return &prefs, nil
+}
+
+// SaveEmailPreferences stores the preferences of an email address
+func (q *EmailQueries) SaveEmailPreferences(email string, prefs *models.EmailPreferences) (*models.EmailPreferences, error) {
+	saved := *prefs
+	err := q.db.QueryRow(`
+		INSERT INTO email_preferences (email, order_updates, promotions, back_in_stock)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (email) DO UPDATE
+		SET order_updates = EXCLUDED.order_updates, promotions = EXCLUDED.promotions,
+		    back_in_stock = EXCLUDED.back_in_stock, updated_at = CURRENT_TIMESTAMP
+		RETURNING updated_at`,
+		normalizeEmail(email), prefs.OrderUpdates, prefs.Promotions, prefs.BackInStock).Scan(&saved.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save email preferences: %w", err)
+	}
+	return &saved, nil
+}
+
+// Unsubscribe opts an email address out of one category of email, keeping its other
+// preferences
+func (q *EmailQueries) Unsubscribe(email, category string) (*models.EmailPreferences, error) {
+	column, ok := emailCategoryColumns[category]
+	if !ok {
+		return nil, fmt.Errorf("unknown email category %q", category)
+	}
+	_, err := q.db.Exec(fmt.Sprintf(`
+		INSERT INTO email_preferences (email, %[1]s) VALUES ($1, false)
+		ON CONFLICT (email) DO UPDATE SET %[1]s = false, updated_at = CURRENT_TIMESTAMP`, column),
+		normalizeEmail(email))
+	if err != nil {
+		return nil, fmt.Errorf("failed to unsubscribe: %w", err)
+	}
+	return q.GetEmailPreferences(email)
+}
+
+// emailWanted reports whether an email address receives emails of category; emails
+// without a category always go out
+func (q *EmailQueries) emailWanted(email, category string) (bool, error) {
+	if category == "" {
+		return true, nil
+	}
+	prefs, err := q.GetEmailPreferences(email)
+	if err != nil {
+		return false, err
+	}
+	switch category {
+	case mail.CategoryOrderUpdates:
+		return prefs.OrderUpdates, nil
+	case mail.CategoryPromotions:
+		return prefs.Promotions, nil
+	case mail.CategoryBackInStock:
+		return prefs.BackInStock, nil
+	}
+	return true, nil
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
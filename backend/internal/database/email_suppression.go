@@ -0,0 +1,154 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"notsofluffy-backend/internal/models"
+)
+
+// SuppressEmail stops all email to an address after a permanent bounce or a complaint,
+// failing what is still queued for it. A complaint outranks an earlier bounce.
+func (q *EmailQueries) SuppressEmail(email, reason, provider, detail string) error {
+	email = normalizeEmail(email)
+	var detailValue *string
+	if detail != "" {
+		detailValue = &detail
+	}
+
+	tx, err := q.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO email_suppressions (email, reason, detail, provider) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (email) DO UPDATE
+		SET reason = CASE WHEN email_suppressions.reason = 'complaint' THEN email_suppressions.reason ELSE EXCLUDED.reason END,
+		    detail = COALESCE(EXCLUDED.detail, email_suppressions.detail), provider = EXCLUDED.provider,
+		    events = email_suppressions.events + 1, updated_at = CURRENT_TIMESTAMP`,
+		email, reason, detailValue, provider)
+	if err != nil {
+		return fmt.Errorf("failed to suppress email: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE email_outbox
+		SET status = 'failed', next_attempt_at = NULL, last_error = $2
+		WHERE LOWER(to_address) = $1 AND status = 'pending'`,
+		email, "Address undeliverable: "+reason)
+	if err != nil {
+		return fmt.Errorf("failed to cancel queued emails: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// getEmailSuppression returns why an address is suppressed, or nil when email to it
+// goes out
+func (q *EmailQueries) getEmailSuppression(email string) (*models.EmailSuppression, error) {
+	var s models.EmailSuppression
+	err := scanEmailSuppression(q.db.QueryRow(`SELECT `+emailSuppressionColumns+` FROM email_suppressions WHERE email = $1`,
+		normalizeEmail(email)), &s)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email suppression: %w", err)
+	}
+	return &s, nil
+}
+
+// GetSuppressedEmails returns which of the addresses are suppressed, by lower case address
+func (q *EmailQueries) GetSuppressedEmails(emails []string) (map[string]bool, error) {
+	suppressed := map[string]bool{}
+	if len(emails) == 0 {
+		return suppressed, nil
+	}
+	normalized := make([]string, len(emails))
+	for i, email := range emails {
+		normalized[i] = normalizeEmail(email)
+	}
+
+	rows, err := q.db.Query(`SELECT email FROM email_suppressions WHERE email = ANY($1)`, pq.Array(normalized))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get suppressed emails: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var email string
+		if err := rows.Scan(&email); err != nil {
+			return nil, fmt.Errorf("failed to scan suppressed email: %w", err)
+		}
+		suppressed[email] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get suppressed emails: %w", err)
+	}
+	return suppressed, nil
+}
+
+const emailSuppressionColumns = `email, reason, detail, provider, events, created_at, updated_at`
+
+func scanEmailSuppression(row interface{ Scan(...interface{}) error }, s *models.EmailSuppression) error {
+	return row.Scan(&s.Email, &s.Reason, &s.Detail, &s.Provider, &s.Events, &s.CreatedAt, &s.UpdatedAt)
+}
+
+// ListEmailSuppressions returns suppressed addresses, most recently reported first,
+// optionally those containing search
+func (q *EmailQueries) ListEmailSuppressions(search string, page, limit int) (*models.EmailSuppressionListResponse, error) {
+	condition := "1=1"
+	args := []interface{}{}
+	if search != "" {
+		args = append(args, "%"+normalizeEmail(search)+"%")
+		condition = "email LIKE $1"
+	}
+
+	var total int
+	if err := q.db.QueryRow(`SELECT COUNT(*) FROM email_suppressions WHERE `+condition, args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count email suppressions: %w", err)
+	}
+
+	args = append(args, limit, (page-1)*limit)
+	rows, err := q.db.Query(fmt.Sprintf(`
+		SELECT `+emailSuppressionColumns+` FROM email_suppressions
+		WHERE %s
+		ORDER BY updated_at DESC, email
+		LIMIT $%d OFFSET $%d`, condition, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list email suppressions: %w", err)
+	}
+	defer rows.Close()
+
+	suppressions := []models.EmailSuppression{}
+	for rows.Next() {
+		var s models.EmailSuppression
+		if err := scanEmailSuppression(rows, &s); err != nil {
+			return nil, fmt.Errorf("failed to scan email suppression: %w", err)
+		}
+		suppressions = append(suppressions, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list email suppressions: %w", err)
+	}
+
+	return &models.EmailSuppressionListResponse{Suppressions: suppressions, Total: total, Page: page, Limit: limit}, nil
+}
+
+// DeleteEmailSuppression lets email go out to an address again, once support has
+// confirmed it works
+func (q *EmailQueries) DeleteEmailSuppression(email string) error {
+	result, err := q.db.Exec(`DELETE FROM email_suppressions WHERE email = $1`, normalizeEmail(email))
+	if err != nil {
+		return fmt.Errorf("failed to delete email suppression: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("email suppression not found")
+	}
+	return nil
+}
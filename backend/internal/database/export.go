@@ -0,0 +1,320 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"notsofluffy-backend/internal/models"
+)
+
+// exportLease is how long an export may stay processing before it is assumed lost,
+// e.g. in a restart, and picked up again
+const exportLease = time.Hour
+
+type ExportQueries struct {
+	db *sql.DB
+}
+
+func NewExportQueries(db *sql.DB) *ExportQueries {
+	return &ExportQueries{db: db}
+}
+
+const exportColumns = `e.id, e.type, e.status, e.params, e.file_name, e.path, e.row_count, e.size_bytes, e.error,
+	e.requested_by, u.email, e.created_at, e.started_at, e.completed_at`
+
+func scanExport(row interface{ Scan(...interface{}) error }, e *models.Export) error {
+	var params []byte
+	err := row.Scan(&e.ID, &e.Type, &e.Status, &params, &e.FileName, &e.Path, &e.RowCount, &e.SizeBytes, &e.Error,
+		&e.RequestedBy, &e.RequestedByEmail, &e.CreatedAt, &e.StartedAt, &e.CompletedAt)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(params, &e.Params); err != nil {
+		return fmt.Errorf("failed to parse export params: %w", err)
+	}
+	return nil
+}
+
+// CreateExport queues an export for the exports job
+func (q *ExportQueries) CreateExport(exportType string, params models.ExportParams, requestedBy *int) (*models.Export, error) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode export params: %w", err)
+	}
+
+	var id int
+	err = q.db.QueryRow(`
+		INSERT INTO exports (type, status, params, requested_by)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`, exportType, models.ExportStatusPending, data, requestedBy).Scan(&id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create export: %w", err)
+	}
+	return q.GetExport(id)
+}
+
+// GetExport returns an export by ID
+func (q *ExportQueries) GetExport(id int) (*models.Export, error) {
+	var e models.Export
+	err := scanExport(q.db.QueryRow(`SELECT `+exportColumns+` FROM exports e LEFT JOIN users u ON u.id = e.requested_by WHERE e.id = $1`, id), &e)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("export not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get export: %w", err)
+	}
+	return &e, nil
+}
+
+// ListExports returns exports, newest first, optionally of one type
+func (q *ExportQueries) ListExports(exportType string, page, limit int) (*models.ExportListResponse, error) {
+	condition := "1=1"
+	args := []interface{}{}
+	if exportType != "" {
+		condition = "e.type = $1"
+		args = append(args, exportType)
+	}
+
+	response := &models.ExportListResponse{Exports: []models.Export{}, Page: page, Limit: limit}
+	if err := q.db.QueryRow(`SELECT COUNT(*) FROM exports e WHERE `+condition, args...).Scan(&response.Total); err != nil {
+		return nil, fmt.Errorf("failed to count exports: %w", err)
+	}
+
+	args = append(args, limit, (page-1)*limit)
+	rows, err := q.db.Query(fmt.Sprintf(`
+		SELECT `+exportColumns+`
+		FROM exports e
+		LEFT JOIN users u ON u.id = e.requested_by
+		WHERE %s
+		ORDER BY e.created_at DESC, e.id DESC
+		LIMIT $%d OFFSET $%d`, condition, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list exports: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e models.Export
+		if err := scanExport(rows, &e); err != nil {
+			return nil, fmt.Errorf("failed to scan export: %w", err)
+		}
+		response.Exports = append(response.Exports, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list exports: %w", err)
+	}
+	return response, nil
+}
+
+// ClaimExport marks the oldest pending export as processing and returns it, or nil
+// when there is none. Exports left processing past their lease are claimed again.
+func (q *ExportQueries) ClaimExport() (*models.Export, error) {
+	var id int
+	err := q.db.QueryRow(`
+		UPDATE exports
+		SET status = $1, started_at = CURRENT_TIMESTAMP
+		WHERE id = (
+			SELECT id FROM exports
+			WHERE status = $2 OR (status = $1 AND started_at < CURRENT_TIMESTAMP - $3::int * INTERVAL '1 second')
+			ORDER BY created_at, id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id`, models.ExportStatusProcessing, models.ExportStatusPending, int(exportLease.Seconds())).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim export: %w", err)
+	}
+	return q.GetExport(id)
+}
+
+// CompleteExport records the file an export produced
+func (q *ExportQueries) CompleteExport(id int, fileName, path string, rowCount int, sizeBytes int64) error {
+	_, err := q.db.Exec(`
+		UPDATE exports
+		SET status = $1, file_name = $2, path = $3, row_count = $4, size_bytes = $5, error = NULL, completed_at = CURRENT_TIMESTAMP
+		WHERE id = $6`, models.ExportStatusCompleted, fileName, path, rowCount, sizeBytes, id)
+	if err != nil {
+		return fmt.Errorf("failed to complete export: %w", err)
+	}
+	return nil
+}
+
+// FailExport records why an export could not be generated
+func (q *ExportQueries) FailExport(id int, exportErr error) error {
+	_, err := q.db.Exec(`
+		UPDATE exports SET status = $1, error = $2, completed_at = CURRENT_TIMESTAMP WHERE id = $3`,
+		models.ExportStatusFailed, exportErr.Error(), id)
+	if err != nil {
+		return fmt.Errorf("failed to fail export: %w", err)
+	}
+	return nil
+}
+
+// EachOrderExportRow calls fn for every order matching params, oldest first, reading
+// them one at a time so large ranges are not held in memory. Test orders are left out.
+func (q *ExportQueries) EachOrderExportRow(params models.ExportParams, fn func(*models.OrderExportRow) error) error {
+	where, args := orderExportFilter(params)
+	rows, err := q.db.Query(`
+		SELECT o.id, o.created_at, o.status, COALESCE(o.payment_status, ''), o.payment_method, o.email, o.phone,
+			COALESCE(sa.first_name, ''), COALESCE(sa.last_name, ''), sa.company, COALESCE(sa.city, ''), COALESCE(sa.country, ''),
+			o.nip, COALESCE((SELECT SUM(oi.quantity) FROM order_items oi WHERE oi.order_id = o.id), 0),
+			o.subtotal, COALESCE(o.discount_amount, 0), COALESCE(o.shipping_cost, 0), o.total_amount
+		FROM orders o
+		LEFT JOIN shipping_addresses sa ON sa.order_id = o.id
+		WHERE `+where+`
+		ORDER BY o.created_at, o.id`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to export orders: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r models.OrderExportRow
+		err := rows.Scan(&r.ID, &r.CreatedAt, &r.Status, &r.PaymentStatus, &r.PaymentMethod, &r.Email, &r.Phone,
+			&r.FirstName, &r.LastName, &r.Company, &r.City, &r.Country,
+			&r.NIP, &r.ItemCount, &r.Subtotal, &r.DiscountAmount, &r.ShippingCost, &r.TotalAmount)
+		if err != nil {
+			return fmt.Errorf("failed to scan order: %w", err)
+		}
+		if err := fn(&r); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// EachOrderLineExportRow calls fn for every item of the orders matching params, with
+// the order's addresses and discount, oldest order first. Like EachOrderExportRow it
+// streams the rows and leaves out test orders.
+func (q *ExportQueries) EachOrderLineExportRow(params models.ExportParams, fn func(*models.OrderLineExportRow) error) error {
+	where, args := orderExportFilter(params)
+	rows, err := q.db.Query(`
+		SELECT o.id, o.created_at, o.status, COALESCE(o.payment_status, ''), o.payment_method, o.email, o.phone,
+			COALESCE(o.requires_invoice, false), o.nip, dc.code, o.discount_description, COALESCE(o.discount_amount, 0),
+			o.subtotal, COALESCE(o.shipping_cost, 0), o.total_amount,
+			sa.first_name, sa.last_name, sa.company, sa.address_line1, sa.address_line2, sa.postal_code, sa.city, sa.country,
+			ba.first_name, ba.last_name, ba.company, ba.address_line1, ba.address_line2, ba.postal_code, ba.city, ba.country,
+			oi.product_id, oi.product_name, oi.variant_name, oi.variant_color_name, oi.size_name, oi.quantity, oi.unit_price, oi.total_price
+		FROM orders o
+		LEFT JOIN discount_codes dc ON dc.id = o.discount_code_id
+		LEFT JOIN shipping_addresses sa ON sa.order_id = o.id
+		LEFT JOIN billing_addresses ba ON ba.order_id = o.id
+		LEFT JOIN order_items oi ON oi.order_id = o.id
+		WHERE `+where+`
+		ORDER BY o.created_at, o.id, oi.id`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to export orders: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r models.OrderLineExportRow
+		s, b := &r.Shipping, &r.Billing
+		err := rows.Scan(&r.OrderID, &r.CreatedAt, &r.Status, &r.PaymentStatus, &r.PaymentMethod, &r.Email, &r.Phone,
+			&r.RequiresInvoice, &r.NIP, &r.DiscountCode, &r.DiscountDescription, &r.DiscountAmount,
+			&r.Subtotal, &r.ShippingCost, &r.TotalAmount,
+			&s.FirstName, &s.LastName, &s.Company, &s.AddressLine1, &s.AddressLine2, &s.PostalCode, &s.City, &s.Country,
+			&b.FirstName, &b.LastName, &b.Company, &b.AddressLine1, &b.AddressLine2, &b.PostalCode, &b.City, &b.Country,
+			&r.ProductID, &r.ProductName, &r.VariantName, &r.ColorName, &r.SizeName, &r.Quantity, &r.UnitPrice, &r.TotalPrice)
+		if err != nil {
+			return fmt.Errorf("failed to scan order: %w", err)
+		}
+		if err := fn(&r); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// orderExportFilter returns the WHERE conditions of an order export, with their arguments
+func orderExportFilter(params models.ExportParams) (string, []interface{}) {
+	conditions := []string{"NOT o.is_test"}
+	args := []interface{}{}
+	if params.From != nil {
+		args = append(args, *params.From)
+		conditions = append(conditions, fmt.Sprintf("o.created_at >= $%d", len(args)))
+	}
+	if params.To != nil {
+		args = append(args, *params.To)
+		conditions = append(conditions, fmt.Sprintf("o.created_at < $%d", len(args)))
+	}
+	if params.Status != "" {
+		args = append(args, params.Status)
+		conditions = append(conditions, fmt.Sprintf("o.status = $%d", len(args)))
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+// EachProductFeedRow calls fn for every size of the products offered in channel, or
+// of all products when channel is empty
+func (q *ExportQueries) EachProductFeedRow(channel string, fn func(*models.ProductFeedRow) error) error {
+	condition := "1=1"
+	if channel != "" {
+		column, err := productChannelColumn(channel)
+		if err != nil {
+			return err
+		}
+		condition = column + " = true"
+	}
+
+	rows, err := q.db.Query(`
+		SELECT p.id, p.name, COALESCE(p.slug, ''), c.name, m.name, s.id, s.name, s.base_price, s.use_stock,
+			GREATEST(0, s.stock_quantity - s.reserved_quantity)
+		FROM products p
+		JOIN sizes s ON s.product_id = p.id
+		LEFT JOIN categories c ON c.id = p.category_id
+		LEFT JOIN materials m ON m.id = p.material_id
+		WHERE ` + condition + `
+		ORDER BY p.id, s.id`)
+	if err != nil {
+		return fmt.Errorf("failed to export products: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r models.ProductFeedRow
+		err := rows.Scan(&r.ProductID, &r.ProductName, &r.Slug, &r.Category, &r.Material, &r.SizeID, &r.SizeName,
+			&r.Price, &r.UseStock, &r.AvailableQuantity)
+		if err != nil {
+			return fmt.Errorf("failed to scan product: %w", err)
+		}
+		if err := fn(&r); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// EachCustomerExportRow calls fn for every registered customer with their order totals,
+// which leave out cancelled and test orders like the admin user list
+func (q *ExportQueries) EachCustomerExportRow(fn func(*models.CustomerExportRow) error) error {
+	rows, err := q.db.Query(`
+		SELECT u.id, u.email, up.first_name, up.last_name, up.phone, u.created_at,
+			stats.order_count, stats.lifetime_value, stats.last_order_at`+userListFrom+`
+		LEFT JOIN user_profiles up ON up.user_id = u.id
+		WHERE u.role = $1
+		ORDER BY u.id`, models.RoleClient)
+	if err != nil {
+		return fmt.Errorf("failed to export customers: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r models.CustomerExportRow
+		err := rows.Scan(&r.ID, &r.Email, &r.FirstName, &r.LastName, &r.Phone, &r.CreatedAt,
+			&r.OrderCount, &r.LifetimeValue, &r.LastOrderAt)
+		if err != nil {
+			return fmt.Errorf("failed to scan customer: %w", err)
+		}
+		if err := fn(&r); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,62 @@
+package database
+
+import (
+	"fmt"
+	"testing"
+
+	"notsofluffy-backend/internal/models"
+)
+
+func TestExportLifecycle(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	q := NewExportQueries(db)
+	_, _ = db.Exec("DELETE FROM exports")
+
+	export, err := q.CreateExport(models.ExportTypeOrders, models.ExportParams{Status: "delivered"}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create export: %v", err)
+	}
+	defer db.Exec("DELETE FROM exports WHERE id = $1", export.ID)
+	if export.Status != models.ExportStatusPending || export.Params.Status != "delivered" {
+		t.Fatalf("new export = %+v, want pending with its params", export)
+	}
+
+	claimed, err := q.ClaimExport()
+	if err != nil {
+		t.Fatalf("Failed to claim export: %v", err)
+	}
+	if claimed == nil || claimed.ID != export.ID || claimed.Status != models.ExportStatusProcessing || claimed.StartedAt == nil {
+		t.Fatalf("claimed export = %+v, want export %d processing", claimed, export.ID)
+	}
+	if again, err := q.ClaimExport(); err != nil || again != nil {
+		t.Fatalf("export being processed should not be claimed again, got %+v, %v", again, err)
+	}
+
+	if err := q.CompleteExport(export.ID, "orders.csv", "exports/1.csv", 3, 120); err != nil {
+		t.Fatalf("Failed to complete export: %v", err)
+	}
+	list, err := q.ListExports(models.ExportTypeOrders, 1, 10)
+	if err != nil {
+		t.Fatalf("Failed to list exports: %v", err)
+	}
+	if list.Total != 1 || list.Exports[0].Status != models.ExportStatusCompleted || *list.Exports[0].RowCount != 3 {
+		t.Fatalf("exports = %+v, want the completed export", list)
+	}
+
+	if err := q.FailExport(export.ID, fmt.Errorf("disk full")); err != nil {
+		t.Fatalf("Failed to fail export: %v", err)
+	}
+	failed, err := q.GetExport(export.ID)
+	if err != nil {
+		t.Fatalf("Failed to get export: %v", err)
+	}
+	if failed.Status != models.ExportStatusFailed || failed.Error == nil || *failed.Error != "disk full" {
+		t.Fatalf("failed export = %+v, want its error", failed)
+	}
+
+	if _, err := q.GetExport(-1); err == nil || err.Error() != "export not found" {
+		t.Fatalf("GetExport(-1) error = %v, want export not found", err)
+	}
+}
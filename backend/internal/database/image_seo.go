@@ -0,0 +1,63 @@
+package database
+
+import (
+	"fmt"
+
+	"notsofluffy-backend/internal/models"
+)
+
+// productImageTextColumns selects the alt text and title of the main image with alias
+// image of the product with alias product, taking the product's override when the
+// image is also among its images
+func productImageTextColumns(product, image string) string {
+	override := func(column string) string {
+		return fmt.Sprintf(`COALESCE(NULLIF((
+		SELECT pi.%[1]s FROM product_images pi WHERE pi.product_id = %[2]s.id AND pi.image_id = %[3]s.id), ''), %[3]s.%[1]s, '')`,
+			column, product, image)
+	}
+	return override("alt_text") + ", " + override("title")
+}
+
+// UpdateImageSEO sets the alt text and title of an image
+func (q *ImageQueries) UpdateImageSEO(id int, altText, title string) error {
+	result, err := q.db.Exec(`
+		UPDATE images SET alt_text = NULLIF($2, ''), title = NULLIF($3, ''), updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1`, id, altText, title)
+	if err != nil {
+		return fmt.Errorf("failed to update image alt text: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("image not found")
+	}
+	return nil
+}
+
+// UpdateProductImageSEO sets the alt text and title a product shows an image of its
+// own with; empty values fall back to the image's
+func (q *ProductQueries) UpdateProductImageSEO(productID, imageID int, altText, title string) error {
+	result, err := q.db.Exec(`
+		UPDATE product_images SET alt_text = NULLIF($3, ''), title = NULLIF($4, '')
+		WHERE product_id = $1 AND image_id = $2`, productID, imageID, altText, title)
+	if err != nil {
+		return fmt.Errorf("failed to update product image alt text: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("product image not found")
+	}
+	return nil
+}
+
+// GetProductImages returns the images of a product with its overrides applied
+func (q *ProductQueries) GetProductImages(productID int) ([]models.ImageResponse, error) {
+	return q.getProductImages(productID)
+}
@@ -0,0 +1,84 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"notsofluffy-backend/internal/models"
+)
+
+// imageSizesColumn selects the size variants of the image with the given table alias
+// as a JSON array, smallest first
+func imageSizesColumn(alias string) string {
+	return fmt.Sprintf(`COALESCE((
+		SELECT json_agg(json_build_object('name', v.name, 'path', v.path, 'width', v.width, 'height', v.height,
+			'size_bytes', v.size_bytes, 'mime_type', v.mime_type) ORDER BY v.width)
+		FROM image_variants v WHERE v.image_id = %s.id), '[]')`, alias)
+}
+
+// parseImageSizes decodes a column selected with imageSizesColumn
+func parseImageSizes(data []byte) ([]models.ImageVariant, error) {
+	var sizes []models.ImageVariant
+	if err := json.Unmarshal(data, &sizes); err != nil {
+		return nil, fmt.Errorf("failed to parse image sizes: %w", err)
+	}
+	return sizes, nil
+}
+
+// SaveImageVariants stores the generated sizes of an image, replacing earlier ones
+// with the same name
+func (q *ImageQueries) SaveImageVariants(imageID int, variants []models.ImageVariant) error {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, v := range variants {
+		_, err := tx.Exec(`
+			INSERT INTO image_variants (image_id, name, path, width, height, size_bytes, mime_type)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			ON CONFLICT (image_id, name) DO UPDATE
+			SET path = EXCLUDED.path, width = EXCLUDED.width, height = EXCLUDED.height,
+				size_bytes = EXCLUDED.size_bytes, mime_type = EXCLUDED.mime_type, updated_at = CURRENT_TIMESTAMP`,
+			imageID, v.Name, v.Path, v.Width, v.Height, v.SizeBytes, v.MimeType)
+		if err != nil {
+			return fmt.Errorf("failed to save image variant: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// GetImageVariants returns the sizes of the images, smallest first
+func (q *ImageQueries) GetImageVariants(imageIDs []int) (map[int][]models.ImageVariant, error) {
+	variants := make(map[int][]models.ImageVariant, len(imageIDs))
+	if len(imageIDs) == 0 {
+		return variants, nil
+	}
+
+	rows, err := q.db.Query(`
+		SELECT image_id, name, path, width, height, size_bytes, mime_type
+		FROM image_variants
+		WHERE image_id = ANY($1)
+		ORDER BY image_id, width`, pq.Array(imageIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get image variants: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var imageID int
+		var v models.ImageVariant
+		if err := rows.Scan(&imageID, &v.Name, &v.Path, &v.Width, &v.Height, &v.SizeBytes, &v.MimeType); err != nil {
+			return nil, fmt.Errorf("failed to scan image variant: %w", err)
+		}
+		variants[imageID] = append(variants[imageID], v)
+	}
+	return variants, rows.Err()
+}
@@ -0,0 +1,441 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"notsofluffy-backend/internal/models"
+)
+
+// DiscountCodeImport is a validated row of a discount code import; ID is set when the
+// row updates an existing code
+type DiscountCodeImport struct {
+	ID      *int
+	Request models.DiscountCodeRequest
+}
+
+// CategoryImport is a validated row of a category import; ID is set when the row
+// updates an existing category
+type CategoryImport struct {
+	ID       *int
+	Category models.Category
+}
+
+// ProductImport is a validated product of a product import with the sizes and variants
+// the file has for it; ID is set when it updates an existing product
+type ProductImport struct {
+	ID *int
+	// Product is nil when the file only has sizes or variants of the product
+	Product *models.Product
+	// ImageIDs replaces the product's images unless nil
+	ImageIDs []int
+	Sizes    []SizeImport
+	Variants []VariantImport
+}
+
+// SizeImport is a validated size row of a product import; ID is set when the row
+// updates an existing size
+type SizeImport struct {
+	ID   *int
+	Size models.Size
+}
+
+// VariantImport is a validated variant row of a product import; ID is set when the
+// row updates an existing variant
+type VariantImport struct {
+	ID      *int
+	Variant models.ProductVariant
+	// ImageIDs replaces the variant's images unless nil
+	ImageIDs []int
+}
+
+// ProductExport is a product with its images, sizes and variants, for product exports
+// and imports
+type ProductExport struct {
+	Product      models.Product
+	CategorySlug string
+	ImageIDs     []int
+	Sizes        []models.Size
+	Variants     []VariantExport
+}
+
+// VariantExport is a product variant with its images
+type VariantExport struct {
+	Variant  models.ProductVariant
+	ImageIDs []int
+}
+
+// ListAllDiscountCodes returns every discount code ordered by code, for exports
+func (q *DiscountQueries) ListAllDiscountCodes() ([]models.DiscountCode, error) {
+	rows, err := q.db.Query(`
+		SELECT id, code, description, discount_type, discount_value, min_order_amount,
+		 usage_type, max_uses, used_count, active, start_date, end_date, windows, is_first_order_only, created_by, created_at, updated_at
+		FROM discount_codes
+		ORDER BY code`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list discount codes: %w", err)
+	}
+	defer rows.Close()
+
+	codes := []models.DiscountCode{}
+	for rows.Next() {
+		var dc models.DiscountCode
+		if err := scanDiscountCode(rows, &dc); err != nil {
+			return nil, fmt.Errorf("failed to scan discount code: %w", err)
+		}
+		codes = append(codes, dc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list discount codes: %w", err)
+	}
+	return codes, nil
+}
+
+// ImportDiscountCodes creates and updates discount codes in one transaction
+func (q *DiscountQueries) ImportDiscountCodes(items []DiscountCodeImport, createdBy *int) error {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, item := range items {
+		req := item.Request
+		if item.ID == nil {
+			_, err = tx.Exec(`
+				INSERT INTO discount_codes (code, description, discount_type, discount_value, min_order_amount,
+				 usage_type, max_uses, active, start_date, end_date, windows, is_first_order_only, created_by)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
+				req.Code, req.Description, req.DiscountType, req.DiscountValue, req.MinOrderAmount,
+				req.UsageType, req.MaxUses, req.Active, req.StartDate, req.EndDate, discountWindowsJSON(req.Windows), req.IsFirstOrderOnly, createdBy)
+		} else {
+			_, err = tx.Exec(`
+				UPDATE discount_codes SET
+				 description = $1, discount_type = $2, discount_value = $3, min_order_amount = $4, usage_type = $5,
+				 max_uses = $6, active = $7, start_date = $8, end_date = $9, windows = $10, is_first_order_only = $11,
+				 updated_at = CURRENT_TIMESTAMP
+				WHERE id = $12`,
+				req.Description, req.DiscountType, req.DiscountValue, req.MinOrderAmount, req.UsageType,
+				req.MaxUses, req.Active, req.StartDate, req.EndDate, discountWindowsJSON(req.Windows), req.IsFirstOrderOnly, *item.ID)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to import discount code %s: %w", req.Code, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit discount code import: %w", err)
+	}
+	return nil
+}
+
+// ListAllCategories returns every category ordered by name, for exports
+func (q *CategoryQueries) ListAllCategories() ([]models.Category, error) {
+	rows, err := q.db.Query(`
+		SELECT id, name, slug, image_id, active, chart_only, created_at, updated_at
+		FROM categories
+		ORDER BY name, id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list categories: %w", err)
+	}
+	defer rows.Close()
+
+	categories := []models.Category{}
+	for rows.Next() {
+		var category models.Category
+		if err := rows.Scan(&category.ID, &category.Name, &category.Slug, &category.ImageID, &category.Active,
+			&category.ChartOnly, &category.CreatedAt, &category.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan category: %w", err)
+		}
+		categories = append(categories, category)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list categories: %w", err)
+	}
+	return categories, nil
+}
+
+// ImportCategories creates and updates categories in one transaction
+func (q *CategoryQueries) ImportCategories(items []CategoryImport) error {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, item := range items {
+		category := item.Category
+		if item.ID == nil {
+			_, err = tx.Exec(`
+				INSERT INTO categories (name, slug, image_id, active, chart_only)
+				VALUES ($1, $2, $3, $4, $5)`,
+				category.Name, category.Slug, category.ImageID, category.Active, category.ChartOnly)
+		} else {
+			_, err = tx.Exec(`
+				UPDATE categories SET name = $1, image_id = $2, active = $3, chart_only = $4, updated_at = CURRENT_TIMESTAMP
+				WHERE id = $5`,
+				category.Name, category.ImageID, category.Active, category.ChartOnly, *item.ID)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to import category %s: %w", category.Slug, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit category import: %w", err)
+	}
+	return nil
+}
+
+// ListAllProducts returns every product ordered by name with its images, sizes and
+// variants, for exports
+func (q *ProductQueries) ListAllProducts() ([]ProductExport, error) {
+	rows, err := q.db.Query(`
+		SELECT p.id, p.name, COALESCE(p.slug, ''), p.short_description, p.description, p.material_id, p.main_image_id,
+		 p.category_id, COALESCE(c.slug, ''), p.created_at, p.updated_at
+		FROM products p
+		LEFT JOIN categories c ON c.id = p.category_id
+		ORDER BY p.name, p.id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list products: %w", err)
+	}
+	defer rows.Close()
+
+	products := []ProductExport{}
+	byID := map[int]*ProductExport{}
+	for rows.Next() {
+		var product ProductExport
+		p := &product.Product
+		if err := rows.Scan(&p.ID, &p.Name, &p.Slug, &p.ShortDescription, &p.Description, &p.MaterialID, &p.MainImageID,
+			&p.CategoryID, &product.CategorySlug, &p.CreatedAt, &p.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan product: %w", err)
+		}
+		products = append(products, product)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list products: %w", err)
+	}
+	for i := range products {
+		byID[products[i].Product.ID] = &products[i]
+	}
+
+	productImages, err := imageIDsByOwner(q.db, productImageLinks)
+	if err != nil {
+		return nil, err
+	}
+	for productID, imageIDs := range productImages {
+		if product, ok := byID[productID]; ok {
+			product.ImageIDs = imageIDs
+		}
+	}
+
+	sizeRows, err := q.db.Query(`
+		SELECT id, name, product_id, base_price, a, b, c, d, e, f, use_stock, stock_quantity, reserved_quantity, created_at, updated_at
+		FROM sizes
+		ORDER BY product_id, id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sizes: %w", err)
+	}
+	defer sizeRows.Close()
+	for sizeRows.Next() {
+		var size models.Size
+		if err := sizeRows.Scan(&size.ID, &size.Name, &size.ProductID, &size.BasePrice, &size.A, &size.B, &size.C, &size.D,
+			&size.E, &size.F, &size.UseStock, &size.StockQuantity, &size.ReservedQuantity, &size.CreatedAt, &size.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan size: %w", err)
+		}
+		if product, ok := byID[size.ProductID]; ok {
+			product.Sizes = append(product.Sizes, size)
+		}
+	}
+	if err := sizeRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list sizes: %w", err)
+	}
+
+	variantImages, err := imageIDsByOwner(q.db, variantImageLinks)
+	if err != nil {
+		return nil, err
+	}
+	variantRows, err := q.db.Query(`
+		SELECT id, product_id, name, color_id, is_default, created_at, updated_at
+		FROM product_variants
+		ORDER BY product_id, id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list product variants: %w", err)
+	}
+	defer variantRows.Close()
+	for variantRows.Next() {
+		var variant models.ProductVariant
+		if err := variantRows.Scan(&variant.ID, &variant.ProductID, &variant.Name, &variant.ColorID, &variant.IsDefault,
+			&variant.CreatedAt, &variant.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan product variant: %w", err)
+		}
+		if product, ok := byID[variant.ProductID]; ok {
+			product.Variants = append(product.Variants, VariantExport{Variant: variant, ImageIDs: variantImages[variant.ID]})
+		}
+	}
+	if err := variantRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list product variants: %w", err)
+	}
+	return products, nil
+}
+
+// imageIDsByOwner returns the IDs of the images of every owner of a link table
+func imageIDsByOwner(db *sql.DB, link imageLink) (map[int][]int, error) {
+	rows, err := db.Query(fmt.Sprintf("SELECT %s, image_id FROM %s ORDER BY 1, 2",
+		pq.QuoteIdentifier(link.ownerColumn), pq.QuoteIdentifier(link.table)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s images: %w", link.entityType, err)
+	}
+	defer rows.Close()
+
+	imageIDs := map[int][]int{}
+	for rows.Next() {
+		var ownerID, imageID int
+		if err := rows.Scan(&ownerID, &imageID); err != nil {
+			return nil, fmt.Errorf("failed to scan %s image: %w", link.entityType, err)
+		}
+		imageIDs[ownerID] = append(imageIDs[ownerID], imageID)
+	}
+	return imageIDs, rows.Err()
+}
+
+// ImportProducts creates and updates products with their sizes and variants in one
+// transaction. Stock changes of the sizes land in the default warehouse.
+func (q *ProductQueries) ImportProducts(items []ProductImport, userID *int) error {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, item := range items {
+		var productID int
+		if item.ID != nil {
+			productID = *item.ID
+		}
+		if product := item.Product; product != nil {
+			if item.ID == nil {
+				channels := models.DefaultProductChannels()
+				err = tx.QueryRow(`
+					INSERT INTO products (name, slug, short_description, description, material_id, main_image_id, category_id,
+					 visible_web, visible_marketplace, visible_b2b)
+					VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+					RETURNING id`,
+					product.Name, product.Slug, product.ShortDescription, product.Description, product.MaterialID, product.MainImageID,
+					product.CategoryID, channels.Web, channels.Marketplace, channels.B2B).Scan(&productID)
+			} else {
+				_, err = tx.Exec(`
+					UPDATE products SET name = $1, short_description = $2, description = $3, material_id = $4, main_image_id = $5,
+					 category_id = $6
+					WHERE id = $7`,
+					product.Name, product.ShortDescription, product.Description, product.MaterialID, product.MainImageID,
+					product.CategoryID, productID)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to import product %s: %w", product.Slug, err)
+			}
+		}
+		if item.ImageIDs != nil {
+			if err := replaceImageLinksTx(tx, productImageLinks, productID, item.ImageIDs); err != nil {
+				return err
+			}
+		}
+
+		for _, sizeItem := range item.Sizes {
+			size := sizeItem.Size
+			sizeID := 0
+			if sizeItem.ID == nil {
+				err = tx.QueryRow(`
+					INSERT INTO sizes (name, product_id, base_price, a, b, c, d, e, f, use_stock, stock_quantity)
+					VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+					RETURNING id`,
+					size.Name, productID, size.BasePrice, size.A, size.B, size.C, size.D, size.E, size.F, size.UseStock, size.StockQuantity).Scan(&sizeID)
+			} else {
+				sizeID = *sizeItem.ID
+				_, err = tx.Exec(`
+					UPDATE sizes SET base_price = $1, a = $2, b = $3, c = $4, d = $5, e = $6, f = $7, use_stock = $8, stock_quantity = $9
+					WHERE id = $10`,
+					size.BasePrice, size.A, size.B, size.C, size.D, size.E, size.F, size.UseStock, size.StockQuantity, sizeID)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to import size %s: %w", size.Name, err)
+			}
+			if err := reconcileDefaultWarehouse(tx, sizeID, userID); err != nil {
+				return err
+			}
+		}
+
+		for _, variantItem := range item.Variants {
+			variant := variantItem.Variant
+			if variant.IsDefault {
+				if err := ensureOnlyOneDefaultVariant(tx, productID, variantItem.ID); err != nil {
+					return err
+				}
+			}
+			variantID := 0
+			if variantItem.ID == nil {
+				err = tx.QueryRow(`
+					INSERT INTO product_variants (product_id, name, color_id, is_default)
+					VALUES ($1, $2, $3, $4)
+					RETURNING id`,
+					productID, variant.Name, variant.ColorID, variant.IsDefault).Scan(&variantID)
+			} else {
+				variantID = *variantItem.ID
+				_, err = tx.Exec(`
+					UPDATE product_variants SET color_id = $1, is_default = $2, updated_at = CURRENT_TIMESTAMP
+					WHERE id = $3`,
+					variant.ColorID, variant.IsDefault, variantID)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to import product variant %s: %w", variant.Name, err)
+			}
+			if variantItem.ImageIDs != nil {
+				if err := replaceImageLinksTx(tx, variantImageLinks, variantID, variantItem.ImageIDs); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit product import: %w", err)
+	}
+	return nil
+}
+
+// ExistingImageIDs returns which of the given image IDs exist
+func (q *ImageQueries) ExistingImageIDs(ids []int) (map[int]bool, error) {
+	return existingIDs(q.db, "images", ids)
+}
+
+// ExistingMaterialIDs returns which of the given material IDs exist
+func (q *MaterialQueries) ExistingMaterialIDs(ids []int) (map[int]bool, error) {
+	return existingIDs(q.db, "materials", ids)
+}
+
+// ExistingColorIDs returns which of the given color IDs exist
+func (q *ColorQueries) ExistingColorIDs(ids []int) (map[int]bool, error) {
+	return existingIDs(q.db, "colors", ids)
+}
+
+func existingIDs(db *sql.DB, table string, ids []int) (map[int]bool, error) {
+	existing := map[int]bool{}
+	if len(ids) == 0 {
+		return existing, nil
+	}
+	rows, err := db.Query(fmt.Sprintf("SELECT id FROM %s WHERE id = ANY($1)", pq.QuoteIdentifier(table)), pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check %s: %w", table, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan %s ID: %w", table, err)
+		}
+		existing[id] = true
+	}
+	return existing, rows.Err()
+}
@@ -0,0 +1,165 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"notsofluffy-backend/internal/models"
+)
+
+type LegalQueries struct {
+	db *sql.DB
+}
+
+func NewLegalQueries(db *sql.DB) *LegalQueries {
+	return &LegalQueries{db: db}
+}
+
+// PublishDocument stores a new document version, which becomes the current one for its type
+func (q *LegalQueries) PublishDocument(req *models.PublishLegalDocumentRequest, publishedBy *int) (*models.LegalDocument, error) {
+	doc := &models.LegalDocument{
+		Type:        req.Type,
+		Version:     req.Version,
+		Title:       req.Title,
+		URL:         req.URL,
+		Summary:     req.Summary,
+		PublishedBy: publishedBy,
+	}
+
+	err := q.db.QueryRow(`
+		INSERT INTO legal_documents (type, version, title, url, summary, published_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, published_at`,
+		doc.Type, doc.Version, doc.Title, doc.URL, doc.Summary, doc.PublishedBy,
+	).Scan(&doc.ID, &doc.PublishedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to publish legal document: %w", err)
+	}
+
+	return doc, nil
+}
+
+// ListDocuments returns all published document versions, newest first
+func (q *LegalQueries) ListDocuments() ([]models.LegalDocument, error) {
+	rows, err := q.db.Query(`
+		SELECT id, type, version, title, url, summary, published_by, published_at
+		FROM legal_documents
+		ORDER BY published_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get legal documents: %w", err)
+	}
+	defer rows.Close()
+
+	return scanLegalDocuments(rows)
+}
+
+// GetCurrentDocuments returns the latest published version of each document type
+func (q *LegalQueries) GetCurrentDocuments() ([]models.LegalDocument, error) {
+	rows, err := q.db.Query(`
+		SELECT DISTINCT ON (type) id, type, version, title, url, summary, published_by, published_at
+		FROM legal_documents
+		ORDER BY type, published_at DESC, id DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current legal documents: %w", err)
+	}
+	defer rows.Close()
+
+	return scanLegalDocuments(rows)
+}
+
+// GetPendingDocumentsForUser returns current document versions the user has not accepted yet
+func (q *LegalQueries) GetPendingDocumentsForUser(userID int) ([]models.LegalDocument, error) {
+	current, err := q.GetCurrentDocuments()
+	if err != nil {
+		return nil, err
+	}
+
+	pending := []models.LegalDocument{}
+	for _, doc := range current {
+		var accepted bool
+		err := q.db.QueryRow(
+			"SELECT EXISTS(SELECT 1 FROM legal_acceptances WHERE document_id = $1 AND user_id = $2)",
+			doc.ID, userID,
+		).Scan(&accepted)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check legal acceptance: %w", err)
+		}
+		if !accepted {
+			pending = append(pending, doc)
+		}
+	}
+
+	return pending, nil
+}
+
+// RecordAcceptance stores acceptance of the given documents by a user and/or an order
+func (q *LegalQueries) RecordAcceptance(docs []models.LegalDocument, userID, orderID *int, ipAddress string, userAgent *string) error {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	tx, err := q.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, doc := range docs {
+		_, err = tx.Exec(`
+			INSERT INTO legal_acceptances (document_id, user_id, order_id, ip_address, user_agent)
+			VALUES ($1, $2, $3, $4, $5)`,
+			doc.ID, userID, orderID, ipAddress, userAgent,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to record legal acceptance: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetAcceptances returns acceptance records for a user or an order
+func (q *LegalQueries) GetAcceptances(userID, orderID *int) ([]models.LegalAcceptance, error) {
+	rows, err := q.db.Query(`
+		SELECT la.id, la.document_id, ld.type, ld.version, la.user_id, la.order_id, la.ip_address, la.user_agent, la.accepted_at
+		FROM legal_acceptances la
+		JOIN legal_documents ld ON la.document_id = ld.id
+		WHERE ($1::int IS NULL OR la.user_id = $1) AND ($2::int IS NULL OR la.order_id = $2)
+		ORDER BY la.accepted_at DESC`,
+		userID, orderID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get legal acceptances: %w", err)
+	}
+	defer rows.Close()
+
+	acceptances := []models.LegalAcceptance{}
+	for rows.Next() {
+		var a models.LegalAcceptance
+		err := rows.Scan(&a.ID, &a.DocumentID, &a.DocumentType, &a.Version, &a.UserID, &a.OrderID, &a.IPAddress, &a.UserAgent, &a.AcceptedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan legal acceptance: %w", err)
+		}
+		acceptances = append(acceptances, a)
+	}
+
+	return acceptances, rows.Err()
+}
+
+func scanLegalDocuments(rows *sql.Rows) ([]models.LegalDocument, error) {
+	docs := []models.LegalDocument{}
+	for rows.Next() {
+		var d models.LegalDocument
+		err := rows.Scan(&d.ID, &d.Type, &d.Version, &d.Title, &d.URL, &d.Summary, &d.PublishedBy, &d.PublishedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan legal document: %w", err)
+		}
+		docs = append(docs, d)
+	}
+
+	return docs, rows.Err()
+}
@@ -0,0 +1,123 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"notsofluffy-backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// LiveStatsQueries reads the aggregates of the flash sale dashboard
+type LiveStatsQueries struct {
+	db *sql.DB
+}
+
+func NewLiveStatsQueries(db *sql.DB) *LiveStatsQueries {
+	return &LiveStatsQueries{db: db}
+}
+
+// activeCartsQuery selects the carts with items that were touched since $1
+const activeCartsQuery = `
+	SELECT cs.id FROM cart_sessions cs
+	WHERE EXISTS (SELECT 1 FROM cart_items ci WHERE ci.cart_session_id = cs.id)
+	  AND (cs.updated_at >= $1 OR EXISTS (
+		SELECT 1 FROM cart_items ci WHERE ci.cart_session_id = cs.id AND ci.updated_at >= $1))`
+
+// GetActiveCarts returns the number of carts touched since and the units in them
+func (q *LiveStatsQueries) GetActiveCarts(since time.Time) (int, int, error) {
+	var carts, units int
+	err := q.db.QueryRow(`
+		WITH active AS (`+activeCartsQuery+`)
+		SELECT (SELECT COUNT(*) FROM active),
+			COALESCE((SELECT SUM(ci.quantity) FROM cart_items ci JOIN active a ON a.id = ci.cart_session_id), 0)`,
+		since).Scan(&carts, &units)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count active carts: %w", err)
+	}
+	return carts, units, nil
+}
+
+// GetCartSizeStats returns the units of each size in the carts touched since next to
+// its stock. With sizeIDs or productIDs it reports those sizes, in carts or not;
+// otherwise the sizes with the most units in carts.
+func (q *LiveStatsQueries) GetCartSizeStats(since time.Time, sizeIDs, productIDs []int, limit int) ([]models.LiveSizeStats, error) {
+	filter := "ic.units IS NOT NULL"
+	args := []interface{}{since, limit}
+	if len(sizeIDs) > 0 || len(productIDs) > 0 {
+		filter = "(s.id = ANY($3) OR s.product_id = ANY($4))"
+		args = append(args, pq.Array(sizeIDs), pq.Array(productIDs))
+	}
+
+	rows, err := q.db.Query(`
+		WITH active AS (`+activeCartsQuery+`),
+		in_carts AS (
+			SELECT ci.size_id, SUM(ci.quantity) AS units, COUNT(DISTINCT ci.cart_session_id) AS carts
+			FROM cart_items ci
+			JOIN active a ON a.id = ci.cart_session_id
+			GROUP BY ci.size_id
+		)
+		SELECT s.id, s.name, p.id, p.name, COALESCE(ic.units, 0), COALESCE(ic.carts, 0),
+			s.use_stock, s.stock_quantity, s.reserved_quantity
+		FROM sizes s
+		JOIN products p ON p.id = s.product_id
+		LEFT JOIN in_carts ic ON ic.size_id = s.id
+		WHERE `+filter+`
+		ORDER BY COALESCE(ic.units, 0) DESC, s.id
+		LIMIT $2`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cart size stats: %w", err)
+	}
+	defer rows.Close()
+
+	sizes := []models.LiveSizeStats{}
+	for rows.Next() {
+		var size models.LiveSizeStats
+		if err := rows.Scan(&size.SizeID, &size.SizeName, &size.ProductID, &size.ProductName, &size.UnitsInCarts, &size.Carts,
+			&size.UseStock, &size.StockQuantity, &size.ReservedQuantity); err != nil {
+			return nil, fmt.Errorf("failed to scan cart size stats: %w", err)
+		}
+		if size.UseStock {
+			available := size.StockQuantity - size.ReservedQuantity
+			size.Available = &available
+		}
+		sizes = append(sizes, size)
+	}
+	return sizes, rows.Err()
+}
+
+// GetOrdersByMinute returns the number of orders placed in each minute since, test
+// orders left out. Minutes without orders are missing.
+func (q *LiveStatsQueries) GetOrdersByMinute(since time.Time) (map[time.Time]int, error) {
+	rows, err := q.db.Query(`
+		SELECT date_trunc('minute', created_at), COUNT(*)
+		FROM orders
+		WHERE created_at >= $1 AND NOT is_test
+		GROUP BY 1`, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get orders by minute: %w", err)
+	}
+	defer rows.Close()
+
+	orders := map[time.Time]int{}
+	for rows.Next() {
+		var minute time.Time
+		var count int
+		if err := rows.Scan(&minute, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan orders by minute: %w", err)
+		}
+		orders[minute.UTC()] = count
+	}
+	return orders, rows.Err()
+}
+
+// CountOrdersSince returns the number of orders placed since, test orders left out
+func (q *LiveStatsQueries) CountOrdersSince(since time.Time) (int, error) {
+	var count int
+	if err := q.db.QueryRow(`SELECT COUNT(*) FROM orders WHERE created_at >= $1 AND NOT is_test`, since).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count orders: %w", err)
+	}
+	return count, nil
+}
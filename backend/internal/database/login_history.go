@@ -0,0 +1,79 @@
+package database
+
+import (
+	"fmt"
+
+	"notsofluffy-backend/internal/models"
+)
+
+// RecordLogin stores a successful login in the user's history and as their last login.
+// The login counts as a new device when the user logged in before, but never from this
+// IP address with this user agent.
+func (q *UserQueries) RecordLogin(userID int, ipAddress string, userAgent *string) (*models.LoginRecord, error) {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var hasHistory, seen bool
+	err = tx.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM login_history WHERE user_id = $1),
+			EXISTS(SELECT 1 FROM login_history WHERE user_id = $1 AND ip_address = $2 AND user_agent IS NOT DISTINCT FROM $3)`,
+		userID, ipAddress, userAgent).Scan(&hasHistory, &seen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check login history: %w", err)
+	}
+
+	record := models.LoginRecord{IPAddress: ipAddress, UserAgent: userAgent, NewDevice: hasHistory && !seen}
+	err = tx.QueryRow(`
+		INSERT INTO login_history (user_id, ip_address, user_agent, new_device)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`,
+		userID, ipAddress, userAgent, record.NewDevice).Scan(&record.ID, &record.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record login: %w", err)
+	}
+
+	if _, err := tx.Exec(`UPDATE users SET last_login_at = $2 WHERE id = $1`, userID, record.CreatedAt); err != nil {
+		return nil, fmt.Errorf("failed to record last login: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &record, nil
+}
+
+// GetLoginHistory returns a page of the user's logins, newest first
+func (q *UserQueries) GetLoginHistory(userID, page, limit int) (*models.LoginHistoryResponse, error) {
+	var total int
+	if err := q.db.QueryRow(`SELECT COUNT(*) FROM login_history WHERE user_id = $1`, userID).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count logins: %w", err)
+	}
+
+	rows, err := q.db.Query(`
+		SELECT id, ip_address, user_agent, new_device, created_at
+		FROM login_history
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3`, userID, limit, (page-1)*limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get login history: %w", err)
+	}
+	defer rows.Close()
+
+	logins := []models.LoginRecord{}
+	for rows.Next() {
+		var record models.LoginRecord
+		if err := rows.Scan(&record.ID, &record.IPAddress, &record.UserAgent, &record.NewDevice, &record.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan login: %w", err)
+		}
+		logins = append(logins, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get login history: %w", err)
+	}
+
+	return &models.LoginHistoryResponse{Logins: logins, Total: total, Page: page, Limit: limit}, nil
+}
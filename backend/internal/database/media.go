@@ -0,0 +1,359 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"notsofluffy-backend/internal/imaging"
+	"notsofluffy-backend/internal/models"
+)
+
+// imageLink is a table associating images with an owner, e.g. product_images
+type imageLink struct {
+	table       string
+	ownerColumn string
+	// entityType names the owner in reference listings
+	entityType string
+	orderBy    string
+	// overrides is set when the link table has alt_text and title columns overriding
+	// the image's own for this owner
+	overrides bool
+}
+
+var (
+	productImageLinks = imageLink{table: "product_images", ownerColumn: "product_id", entityType: "product", orderBy: "i.created_at, i.id", overrides: true}
+	variantImageLinks = imageLink{table: "product_variant_images", ownerColumn: "product_variant_id", entityType: "product_variant", orderBy: "i.id"}
+	serviceImageLinks = imageLink{table: "additional_service_images", ownerColumn: "additional_service_id", entityType: "additional_service", orderBy: "i.created_at, i.id"}
+)
+
+// imageReference is a column pointing at images; together they are everything that
+// keeps an image in use
+type imageReference struct {
+	table      string
+	ownerCol   string
+	imageCol   string
+	entityType string
+}
+
+var imageReferences = []imageReference{
+	{table: "products", ownerCol: "id", imageCol: "main_image_id", entityType: "product_main"},
+	{table: productImageLinks.table, ownerCol: productImageLinks.ownerColumn, imageCol: "image_id", entityType: productImageLinks.entityType},
+	{table: variantImageLinks.table, ownerCol: variantImageLinks.ownerColumn, imageCol: "image_id", entityType: variantImageLinks.entityType},
+	{table: serviceImageLinks.table, ownerCol: serviceImageLinks.ownerColumn, imageCol: "image_id", entityType: serviceImageLinks.entityType},
+	{table: "categories", ownerCol: "id", imageCol: "image_id", entityType: "category"},
+	{table: "colors", ownerCol: "id", imageCol: "image_id", entityType: "color"},
+	{table: "client_reviews", ownerCol: "id", imageCol: "image_id", entityType: "client_review"},
+	{table: "product_option_values", ownerCol: "id", imageCol: "image_id", entityType: "product_option_value"},
+}
+
+// linkedImages returns the images associated with an owner, with the owner's alt text
+// and title overrides applied
+func linkedImages(db *sql.DB, link imageLink, ownerID int) ([]models.ImageResponse, error) {
+	images, err := linkedImagesByOwner(db, link, []int{ownerID})
+	if err != nil {
+		return nil, err
+	}
+	return images[ownerID], nil
+}
+
+// linkedImagesByOwner returns the images associated with each of the owners in one
+// query, for listings that would otherwise query every owner's images separately
+func linkedImagesByOwner(db *sql.DB, link imageLink, ownerIDs []int) (map[int][]models.ImageResponse, error) {
+	images := make(map[int][]models.ImageResponse, len(ownerIDs))
+	if len(ownerIDs) == 0 {
+		return images, nil
+	}
+
+	textColumns := "COALESCE(i.alt_text, ''), COALESCE(i.title, '')"
+	if link.overrides {
+		textColumns = "COALESCE(NULLIF(l.alt_text, ''), i.alt_text, ''), COALESCE(NULLIF(l.title, ''), i.title, '')"
+	}
+	owner := pq.QuoteIdentifier(link.ownerColumn)
+	query := fmt.Sprintf(`
+		SELECT l.%s, i.id, i.filename, i.original_name, i.path, i.size_bytes, i.mime_type, i.uploaded_by, i.created_at, i.updated_at,
+			%s, %s
+		FROM images i
+		JOIN %s l ON l.image_id = i.id
+		WHERE l.%s = ANY($1)
+		ORDER BY l.%s, %s`, owner, imageSizesColumn("i"), textColumns, pq.QuoteIdentifier(link.table), owner, owner, link.orderBy)
+
+	rows, err := db.Query(query, pq.Array(ownerIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", link.table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ownerID int
+		var image models.Image
+		var sizesJSON []byte
+		err := rows.Scan(&ownerID, &image.ID, &image.Filename, &image.OriginalName, &image.Path, &image.SizeBytes,
+			&image.MimeType, &image.UploadedBy, &image.CreatedAt, &image.UpdatedAt, &sizesJSON, &image.AltText, &image.Title)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan image: %w", err)
+		}
+		sizes, err := parseImageSizes(sizesJSON)
+		if err != nil {
+			return nil, err
+		}
+		images[ownerID] = append(images[ownerID], models.ImageResponse{
+			ID:           image.ID,
+			Filename:     image.Filename,
+			OriginalName: image.OriginalName,
+			Path:         image.Path,
+			SizeBytes:    image.SizeBytes,
+			MimeType:     image.MimeType,
+			UploadedBy:   image.UploadedBy,
+			AltText:      image.AltText,
+			Title:        image.Title,
+			Variants:     imaging.VariantPaths(image.Path, image.MimeType),
+			Sizes:        sizes,
+			CreatedAt:    image.CreatedAt.Format(time.RFC3339),
+			UpdatedAt:    image.UpdatedAt.Format(time.RFC3339),
+		})
+	}
+	return images, rows.Err()
+}
+
+// replaceImageLinks sets the images of an owner to exactly imageIDs. Images the owner
+// keeps keep their association, and with it any alt text and title overrides.
+func replaceImageLinks(db *sql.DB, link imageLink, ownerID int, imageIDs []int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := replaceImageLinksTx(tx, link, ownerID, imageIDs); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// replaceImageLinksTx is replaceImageLinks within a transaction
+func replaceImageLinksTx(tx *sql.Tx, link imageLink, ownerID int, imageIDs []int) error {
+	table := pq.QuoteIdentifier(link.table)
+	owner := pq.QuoteIdentifier(link.ownerColumn)
+
+	if imageIDs == nil {
+		imageIDs = []int{}
+	}
+	del := fmt.Sprintf("DELETE FROM %s WHERE %s = $1 AND NOT (image_id = ANY($2))", table, owner)
+	if _, err := tx.Exec(del, ownerID, pq.Array(imageIDs)); err != nil {
+		return fmt.Errorf("failed to delete existing image associations: %w", err)
+	}
+
+	insert := fmt.Sprintf("INSERT INTO %s (%s, image_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", table, owner)
+	for _, imageID := range imageIDs {
+		if _, err := tx.Exec(insert, ownerID, imageID); err != nil {
+			return fmt.Errorf("failed to add image association: %w", err)
+		}
+	}
+	return nil
+}
+
+// addImageLinks associates images with an owner, ignoring existing associations
+func addImageLinks(db *sql.DB, link imageLink, ownerID int, imageIDs []int) error {
+	insert := fmt.Sprintf("INSERT INTO %s (%s, image_id) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+		pq.QuoteIdentifier(link.table), pq.QuoteIdentifier(link.ownerColumn))
+	for _, imageID := range imageIDs {
+		if _, err := db.Exec(insert, ownerID, imageID); err != nil {
+			return fmt.Errorf("failed to add image association: %w", err)
+		}
+	}
+	return nil
+}
+
+// removeImageLinks removes images from an owner
+func removeImageLinks(db *sql.DB, link imageLink, ownerID int, imageIDs []int) error {
+	del := fmt.Sprintf("DELETE FROM %s WHERE %s = $1 AND image_id = $2",
+		pq.QuoteIdentifier(link.table), pq.QuoteIdentifier(link.ownerColumn))
+	for _, imageID := range imageIDs {
+		if _, err := db.Exec(del, ownerID, imageID); err != nil {
+			return fmt.Errorf("failed to remove image association: %w", err)
+		}
+	}
+	return nil
+}
+
+// imageReferencesQuery selects (image_id, entity_type, entity_id) of all references
+// to the images in $1
+func imageReferencesQuery() string {
+	parts := make([]string, len(imageReferences))
+	for i, ref := range imageReferences {
+		parts[i] = fmt.Sprintf("SELECT %s AS image_id, '%s' AS entity_type, %s AS entity_id FROM %s WHERE %s = ANY($1)",
+			pq.QuoteIdentifier(ref.imageCol), ref.entityType, pq.QuoteIdentifier(ref.ownerCol),
+			pq.QuoteIdentifier(ref.table), pq.QuoteIdentifier(ref.imageCol))
+	}
+	return strings.Join(parts, "\nUNION ALL\n")
+}
+
+// GetReferenceCounts returns how many records use each of the images
+func (q *ImageQueries) GetReferenceCounts(imageIDs []int) (map[int]int, error) {
+	counts := make(map[int]int, len(imageIDs))
+	if len(imageIDs) == 0 {
+		return counts, nil
+	}
+
+	query := `SELECT image_id, COUNT(*) FROM (` + imageReferencesQuery() + `) refs GROUP BY image_id`
+	rows, err := q.db.Query(query, pq.Array(imageIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to count image references: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var imageID, count int
+		if err := rows.Scan(&imageID, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan image reference count: %w", err)
+		}
+		counts[imageID] = count
+	}
+	return counts, rows.Err()
+}
+
+// GetImageReferences lists the records using an image
+func (q *ImageQueries) GetImageReferences(imageID int) ([]models.MediaReference, error) {
+	query := `SELECT entity_type, entity_id FROM (` + imageReferencesQuery() + `) refs ORDER BY entity_type, entity_id`
+	rows, err := q.db.Query(query, pq.Array([]int{imageID}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get image references: %w", err)
+	}
+	defer rows.Close()
+
+	references := []models.MediaReference{}
+	for rows.Next() {
+		var ref models.MediaReference
+		if err := rows.Scan(&ref.EntityType, &ref.EntityID); err != nil {
+			return nil, fmt.Errorf("failed to scan image reference: %w", err)
+		}
+		references = append(references, ref)
+	}
+	return references, rows.Err()
+}
+
+// GetImageTags returns the tags of the images
+func (q *ImageQueries) GetImageTags(imageIDs []int) (map[int][]string, error) {
+	tags := make(map[int][]string, len(imageIDs))
+	if len(imageIDs) == 0 {
+		return tags, nil
+	}
+
+	rows, err := q.db.Query(`SELECT image_id, tag FROM image_tags WHERE image_id = ANY($1) ORDER BY tag`, pq.Array(imageIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get image tags: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var imageID int
+		var tag string
+		if err := rows.Scan(&imageID, &tag); err != nil {
+			return nil, fmt.Errorf("failed to scan image tag: %w", err)
+		}
+		tags[imageID] = append(tags[imageID], tag)
+	}
+	return tags, rows.Err()
+}
+
+// SetImageTags replaces the tags of an image
+func (q *ImageQueries) SetImageTags(imageID int, tags []string) error {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM image_tags WHERE image_id = $1`, imageID); err != nil {
+		return fmt.Errorf("failed to clear image tags: %w", err)
+	}
+	for _, tag := range tags {
+		if _, err := tx.Exec(`INSERT INTO image_tags (image_id, tag) VALUES ($1, $2) ON CONFLICT DO NOTHING`, imageID, tag); err != nil {
+			if strings.Contains(err.Error(), "foreign key") {
+				return fmt.Errorf("image not found")
+			}
+			return fmt.Errorf("failed to add image tag: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ListImageTags returns all tags in use with their image counts
+func (q *ImageQueries) ListImageTags() ([]models.MediaTag, error) {
+	rows, err := q.db.Query(`SELECT tag, COUNT(*) FROM image_tags GROUP BY tag ORDER BY tag`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list image tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := []models.MediaTag{}
+	for rows.Next() {
+		var tag models.MediaTag
+		if err := rows.Scan(&tag.Tag, &tag.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan image tag: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+// NormalizeTags lowercases, trims and de-duplicates tags, dropping empty ones
+func NormalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	result := []string{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	return result
+}
+
+// ListImagesWithReferences returns every image with the number of records using it,
+// oldest first
+func (q *ImageQueries) ListImagesWithReferences() ([]models.ImageReportItem, error) {
+	parts := make([]string, len(imageReferences))
+	for i, ref := range imageReferences {
+		parts[i] = fmt.Sprintf("SELECT %s AS image_id FROM %s",
+			pq.QuoteIdentifier(ref.imageCol), pq.QuoteIdentifier(ref.table))
+	}
+
+	rows, err := q.db.Query(`
+		SELECT i.id, i.filename, i.original_name, i.path, i.size_bytes, i.mime_type, COALESCE(refs.count, 0), i.created_at
+		FROM images i
+		LEFT JOIN (
+			SELECT image_id, COUNT(*) AS count FROM (` + strings.Join(parts, "\nUNION ALL\n") + `) r
+			WHERE image_id IS NOT NULL
+			GROUP BY image_id
+		) refs ON refs.image_id = i.id
+		ORDER BY i.created_at, i.id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list images with references: %w", err)
+	}
+	defer rows.Close()
+
+	images := []models.ImageReportItem{}
+	for rows.Next() {
+		var image models.ImageReportItem
+		if err := rows.Scan(&image.ID, &image.Filename, &image.OriginalName, &image.Path, &image.SizeBytes,
+			&image.MimeType, &image.References, &image.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan image: %w", err)
+		}
+		images = append(images, image)
+	}
+	return images, rows.Err()
+}
@@ -0,0 +1,64 @@
+package database
+
+import "testing"
+
+func TestOptionValueImageIsReferenced(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	insertImage := func(name string) int {
+		var id int
+		err := db.QueryRow(`
+			INSERT INTO images (filename, original_name, path, size_bytes, mime_type)
+			VALUES ($1, $1, 'uploads/' || $1, 1, 'image/png')
+			RETURNING id`, name).Scan(&id)
+		if err != nil {
+			t.Fatalf("Failed to create image: %v", err)
+		}
+		return id
+	}
+	mainImageID := insertImage("option-test-main.png")
+	optionImageID := insertImage("option-test-value.png")
+
+	var productID, groupID, valueID int
+	if err := db.QueryRow(`
+		INSERT INTO products (name, short_description, description, main_image_id)
+		VALUES ('Option test product', 'x', 'x', $1)
+		RETURNING id`, mainImageID).Scan(&productID); err != nil {
+		t.Fatalf("Failed to create product: %v", err)
+	}
+	defer db.Exec("DELETE FROM images WHERE id = ANY(ARRAY[$1, $2]::int[])", mainImageID, optionImageID)
+	defer db.Exec("DELETE FROM products WHERE id = $1", productID)
+	if err := db.QueryRow(`INSERT INTO product_option_groups (product_id, name) VALUES ($1, 'Pattern') RETURNING id`, productID).Scan(&groupID); err != nil {
+		t.Fatalf("Failed to create option group: %v", err)
+	}
+	if err := db.QueryRow(`INSERT INTO product_option_values (group_id, name, image_id) VALUES ($1, 'Dots', $2) RETURNING id`, groupID, optionImageID).Scan(&valueID); err != nil {
+		t.Fatalf("Failed to create option value: %v", err)
+	}
+
+	q := NewImageQueries(db)
+	images, err := q.ListImagesWithReferences()
+	if err != nil {
+		t.Fatalf("Failed to list images: %v", err)
+	}
+	found := false
+	for _, image := range images {
+		if image.ID == optionImageID {
+			found = true
+			if image.References != 1 {
+				t.Fatalf("option value image has %d references, want 1 so it isn't reported as an orphan", image.References)
+			}
+		}
+	}
+	if !found {
+		t.Fatal("option value image missing from the image report")
+	}
+
+	refs, err := q.GetImageReferences(optionImageID)
+	if err != nil {
+		t.Fatalf("Failed to get image references: %v", err)
+	}
+	if len(refs) != 1 || refs[0].EntityType != "product_option_value" || refs[0].EntityID != valueID {
+		t.Fatalf("references = %+v, want option value %d", refs, valueID)
+	}
+}
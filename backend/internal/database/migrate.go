@@ -0,0 +1,400 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+)
+
+// Migration phases of an expand/contract rollout. Expand migrations only add to the
+// schema, so the release still running keeps working while they apply and they run
+// on startup. Contract migrations drop or rename what the previous release used and
+// are held until it is gone.
+const (
+	MigrationExpand   = "expand"
+	MigrationContract = "contract"
+)
+
+// Severities of pre-flight findings; dangerous migrations only run when forced
+const (
+	MigrationDanger  = "danger"
+	MigrationWarning = "warning"
+)
+
+// smallTableRows is the estimated size below which long-locking operations finish
+// quickly enough to only warn about
+const smallTableRows = 10000
+
+// migrationLockID is the advisory lock serializing migrations of instances starting
+// at the same time
+const migrationLockID = 7301946
+
+// MigrationOptions select which pending migrations Migrate runs
+type MigrationOptions struct {
+	// Contract also runs pending contract migrations
+	Contract bool
+	// Force runs migrations the pre-flight check finds dangerous
+	Force bool
+}
+
+// MigrationFinding is an operation of a migration that locks a table for long or
+// breaks the release still running
+type MigrationFinding struct {
+	Severity string
+	Table    string
+	Message  string
+	// EstimatedRows is the planner's estimate of the table's rows, -1 when unknown
+	EstimatedRows int64
+	// locks is set for findings about locking, which small tables make harmless
+	locks bool
+}
+
+// PendingMigration is a migration not yet applied with the pre-flight findings
+type PendingMigration struct {
+	Version  int
+	Phase    string
+	SQL      string
+	Findings []MigrationFinding
+}
+
+// Dangerous reports whether the migration needs forcing
+func (m PendingMigration) Dangerous() bool {
+	for _, finding := range m.Findings {
+		if finding.Severity == MigrationDanger {
+			return true
+		}
+	}
+	return false
+}
+
+// Migrate runs the pending migrations. Contract migrations only run with
+// opts.Contract, and nothing runs while a pending migration is dangerous unless
+// opts.Force is set. The first run with version tracking, on a new database or one
+// migrated before versions were recorded, runs and records every migration: they
+// are all written to be rerun.
+func Migrate(db *sql.DB, opts MigrationOptions) error {
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("failed to lock migrations: %w", err)
+	}
+	defer conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, migrationLockID)
+
+	baseline, err := ensureMigrationTable(ctx, conn)
+	if err != nil {
+		return err
+	}
+	migrations := schemaMigrations()
+	if baseline {
+		for i, migration := range migrations {
+			if err := runMigration(ctx, conn, i+1, migration); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	pending, err := pendingMigrations(ctx, conn, migrations)
+	if err != nil {
+		return err
+	}
+
+	run := []PendingMigration{}
+	held := 0
+	dangerous := []string{}
+	for _, migration := range pending {
+		if migration.Phase == MigrationContract && !opts.Contract {
+			held++
+			continue
+		}
+		run = append(run, migration)
+		if migration.Dangerous() {
+			dangerous = append(dangerous, fmt.Sprint(migration.Version))
+		}
+	}
+	if len(dangerous) > 0 && !opts.Force {
+		return fmt.Errorf("migrations %s lock large tables or break the running release; review them with -check-migrations and apply them with -force-migrations", strings.Join(dangerous, ", "))
+	}
+
+	for _, migration := range run {
+		if err := runMigration(ctx, conn, migration.Version, migration.SQL); err != nil {
+			return err
+		}
+	}
+	if held > 0 {
+		log.Printf("%d contract migrations are waiting; run them with -contract once no instance of the previous release is left", held)
+	}
+	return nil
+}
+
+// CheckMigrations returns the pending migrations of both phases with their pre-flight
+// findings, without running them
+func CheckMigrations(db *sql.DB) ([]PendingMigration, error) {
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	var tracked bool
+	if err := conn.QueryRowContext(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&tracked); err != nil {
+		return nil, fmt.Errorf("failed to check migration versions: %w", err)
+	}
+	if !tracked {
+		// The first Migrate records every migration without checks, see Migrate
+		return []PendingMigration{}, nil
+	}
+	return pendingMigrations(ctx, conn, schemaMigrations())
+}
+
+// ensureMigrationTable creates the table of applied versions and reports whether no
+// version is recorded yet
+func ensureMigrationTable(ctx context.Context, conn *sql.Conn) (bool, error) {
+	_, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		phase VARCHAR(20) NOT NULL,
+		applied_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return false, fmt.Errorf("failed to create migration versions: %w", err)
+	}
+
+	var empty bool
+	if err := conn.QueryRowContext(ctx, `SELECT NOT EXISTS (SELECT 1 FROM schema_migrations)`).Scan(&empty); err != nil {
+		return false, fmt.Errorf("failed to get migration versions: %w", err)
+	}
+	return empty, nil
+}
+
+// runMigration applies a migration and records its version. Migrations run outside a
+// transaction so they may create indexes concurrently; one interrupted before it is
+// recorded runs again.
+func runMigration(ctx context.Context, conn *sql.Conn, version int, migration string) error {
+	if _, err := conn.ExecContext(ctx, migration); err != nil {
+		return fmt.Errorf("failed to run migration %d: %w", version, err)
+	}
+	_, err := conn.ExecContext(ctx, `
+		INSERT INTO schema_migrations (version, phase) VALUES ($1, $2)
+		ON CONFLICT (version) DO NOTHING`,
+		version, migrationPhase(migration))
+	if err != nil {
+		return fmt.Errorf("failed to record migration %d: %w", version, err)
+	}
+	return nil
+}
+
+// pendingMigrations returns the migrations without a recorded version, with findings
+// on tables large enough for locks to matter
+func pendingMigrations(ctx context.Context, conn *sql.Conn, migrations []string) ([]PendingMigration, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get migration versions: %w", err)
+	}
+	applied := map[int]bool{}
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan migration version: %w", err)
+		}
+		applied[version] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get migration versions: %w", err)
+	}
+
+	pending := []PendingMigration{}
+	created := map[string]bool{}
+	for i, migration := range migrations {
+		if applied[i+1] {
+			continue
+		}
+		findings := lintMigration(migration, created)
+		for j := range findings {
+			if err := estimateFinding(ctx, conn, &findings[j]); err != nil {
+				return nil, err
+			}
+		}
+		pending = append(pending, PendingMigration{
+			Version:  i + 1,
+			Phase:    migrationPhase(migration),
+			SQL:      migration,
+			Findings: findings,
+		})
+	}
+	return pending, nil
+}
+
+// estimateFinding sets the estimated rows of a finding's table and lowers locking
+// findings on small tables to warnings. Tables never analyzed count as large.
+func estimateFinding(ctx context.Context, conn *sql.Conn, finding *MigrationFinding) error {
+	finding.EstimatedRows = -1
+	var rows sql.NullInt64
+	err := conn.QueryRowContext(ctx, `SELECT reltuples::bigint FROM pg_class WHERE oid = to_regclass($1)`, finding.Table).Scan(&rows)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to estimate table size: %w", err)
+	}
+	if err == sql.ErrNoRows {
+		// The table does not exist yet, an earlier pending migration creates it
+		finding.EstimatedRows = 0
+	} else if rows.Valid && rows.Int64 >= 0 {
+		finding.EstimatedRows = rows.Int64
+	}
+	if finding.locks && finding.EstimatedRows >= 0 && finding.EstimatedRows < smallTableRows {
+		finding.Severity = MigrationWarning
+	}
+	return nil
+}
+
+// migrationPhase returns the phase a migration declares with a leading comment
+func migrationPhase(migration string) string {
+	if strings.HasPrefix(strings.TrimSpace(migration), "-- contract") {
+		return MigrationContract
+	}
+	return MigrationExpand
+}
+
+var (
+	lineComment     = regexp.MustCompile(`--[^\n]*`)
+	dollarQuoted    = regexp.MustCompile(`(?s)\$\$.*?\$\$`)
+	createTableStmt = regexp.MustCompile(`(?is)^CREATE\s+(?:UNLOGGED\s+)?TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?([\w."]+)`)
+	alterTableStmt  = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+(?:IF\s+EXISTS\s+)?(?:ONLY\s+)?([\w."]+)\s+(.*)$`)
+	createIndexStmt = regexp.MustCompile(`(?is)^CREATE\s+(?:UNIQUE\s+)?INDEX\s+(CONCURRENTLY\s+)?.*?\sON\s+(?:ONLY\s+)?([\w."]+)`)
+	dropTableStmt   = regexp.MustCompile(`(?is)^DROP\s+TABLE\s+(?:IF\s+EXISTS\s+)?([\w."]+)`)
+	volatileDefault = regexp.MustCompile(`(?i)\bDEFAULT\s+(?:random|gen_random_uuid|uuid_generate_v4|clock_timestamp|nextval)\s*\(|\b(?:SMALL|BIG)?SERIAL\b`)
+	addConstraint   = regexp.MustCompile(`(?i)^ADD\s+(?:CONSTRAINT\s+\S+\s+)?(PRIMARY\s+KEY|UNIQUE|FOREIGN\s+KEY|CHECK|EXCLUDE)\b`)
+	addColumn       = regexp.MustCompile(`(?i)^ADD\s+(?:COLUMN\s+)?(?:IF\s+NOT\s+EXISTS\s+)?(\S+)`)
+	alterColumnType = regexp.MustCompile(`(?i)^ALTER\s+(?:COLUMN\s+)?(\S+)\s+(?:SET\s+DATA\s+)?TYPE\b`)
+	setNotNull      = regexp.MustCompile(`(?i)^ALTER\s+(?:COLUMN\s+)?(\S+)\s+SET\s+NOT\s+NULL\b`)
+	dropColumn      = regexp.MustCompile(`(?i)^DROP\s+(?:COLUMN\s+)?(?:IF\s+EXISTS\s+)?(\S+)`)
+	renameAction    = regexp.MustCompile(`(?i)^RENAME\b`)
+	notNullColumn   = regexp.MustCompile(`(?i)\bNOT\s+NULL\b`)
+	columnDefault   = regexp.MustCompile(`(?i)\bDEFAULT\b`)
+	notValid        = regexp.MustCompile(`(?i)\bNOT\s+VALID\b`)
+	usingIndex      = regexp.MustCompile(`(?i)\bUSING\s+INDEX\b`)
+	dropConstraint  = regexp.MustCompile(`(?i)^DROP\s+(?:CONSTRAINT|DEFAULT)\b`)
+)
+
+// lintMigration returns the operations of a migration that lock existing tables for
+// long or, outside contract migrations, break the release still running. created
+// holds tables created by earlier pending migrations, which nothing uses yet; the
+// migration's own are added to it.
+func lintMigration(migration string, created map[string]bool) []MigrationFinding {
+	contract := migrationPhase(migration) == MigrationContract
+	findings := []MigrationFinding{}
+	add := func(table string, locks bool, message string) {
+		if created[table] {
+			return
+		}
+		findings = append(findings, MigrationFinding{Severity: MigrationDanger, Table: table, Message: message, locks: locks})
+	}
+
+	for _, stmt := range migrationStatements(migration) {
+		if m := createTableStmt.FindStringSubmatch(stmt); m != nil {
+			created[tableName(m[1])] = true
+			continue
+		}
+		if m := createIndexStmt.FindStringSubmatch(stmt); m != nil {
+			if m[1] == "" {
+				add(tableName(m[2]), true, "creates an index without CONCURRENTLY, blocking writes while it builds")
+			}
+			continue
+		}
+		if m := dropTableStmt.FindStringSubmatch(stmt); m != nil {
+			if !contract {
+				add(tableName(m[1]), false, "drops a table the running release may still use; move it to a contract migration")
+			}
+			continue
+		}
+		m := alterTableStmt.FindStringSubmatch(stmt)
+		if m == nil {
+			continue
+		}
+		table := tableName(m[1])
+		for _, action := range splitTopLevel(m[2]) {
+			switch {
+			case addConstraint.MatchString(action):
+				kind := strings.ToUpper(strings.Join(strings.Fields(addConstraint.FindStringSubmatch(action)[1]), " "))
+				if kind == "PRIMARY KEY" || kind == "UNIQUE" || kind == "EXCLUDE" {
+					if !usingIndex.MatchString(action) {
+						add(table, true, "adds a "+kind+" constraint, building its index while blocking writes; create the index CONCURRENTLY and add the constraint USING INDEX")
+					}
+				} else if !notValid.MatchString(action) {
+					add(table, true, "adds a "+kind+" constraint checking every row under lock; add it NOT VALID and VALIDATE CONSTRAINT in a later migration")
+				}
+			case addColumn.MatchString(action):
+				column := addColumn.FindStringSubmatch(action)[1]
+				if volatileDefault.MatchString(action) {
+					add(table, true, "adds column "+column+" with a volatile default, rewriting the table under lock")
+				} else if notNullColumn.MatchString(action) && !columnDefault.MatchString(action) {
+					add(table, true, "adds NOT NULL column "+column+" without a default, which fails on a table with rows; add it nullable or with a default")
+				}
+			case alterColumnType.MatchString(action):
+				add(table, true, "changes the type of column "+alterColumnType.FindStringSubmatch(action)[1]+", which may rewrite the table under lock")
+			case setNotNull.MatchString(action):
+				add(table, true, "sets column "+setNotNull.FindStringSubmatch(action)[1]+" NOT NULL, scanning the table under lock; validate a CHECK (... IS NOT NULL) NOT VALID constraint first")
+			case dropConstraint.MatchString(action):
+				// Dropping a constraint or default only changes the catalog
+			case dropColumn.MatchString(action):
+				if !contract {
+					add(table, false, "drops column "+dropColumn.FindStringSubmatch(action)[1]+" the running release may still use; move it to a contract migration")
+				}
+			case renameAction.MatchString(action):
+				if !contract {
+					add(table, false, "renames what the running release may still use; add the new name alongside and drop the old one in a contract migration")
+				}
+			}
+		}
+	}
+	return findings
+}
+
+// migrationStatements splits a migration into its statements, without comments and
+// function bodies
+func migrationStatements(migration string) []string {
+	migration = dollarQuoted.ReplaceAllString(migration, "''")
+	migration = lineComment.ReplaceAllString(migration, "")
+	statements := []string{}
+	for _, stmt := range strings.Split(migration, ";") {
+		if stmt = strings.TrimSpace(stmt); stmt != "" {
+			statements = append(statements, stmt)
+		}
+	}
+	return statements
+}
+
+// splitTopLevel splits the actions of an ALTER TABLE at commas outside parentheses
+func splitTopLevel(actions string) []string {
+	parts := []string{}
+	depth, start := 0, 0
+	for i, r := range actions {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, strings.TrimSpace(actions[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, strings.TrimSpace(actions[start:]))
+}
+
+// tableName normalizes a table name to how to_regclass finds it in the public schema
+func tableName(name string) string {
+	name = strings.ToLower(strings.ReplaceAll(name, `"`, ""))
+	return strings.TrimPrefix(name, "public.")
+}
@@ -0,0 +1,79 @@
+package database
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLintMigrationLockingOperations(t *testing.T) {
+	cases := []struct {
+		migration string
+		want      string
+	}{
+		{`ALTER TABLE orders ADD COLUMN channel VARCHAR(20) NOT NULL;`, "without a default"},
+		{`ALTER TABLE orders ADD COLUMN token UUID DEFAULT gen_random_uuid();`, "volatile default"},
+		{`ALTER TABLE orders ALTER COLUMN notes TYPE TEXT;`, "changes the type"},
+		{`ALTER TABLE orders ALTER COLUMN email SET NOT NULL;`, "scanning the table"},
+		{`ALTER TABLE orders ADD CONSTRAINT orders_user_fk FOREIGN KEY (user_id) REFERENCES users(id);`, "NOT VALID"},
+		{`ALTER TABLE orders ADD CONSTRAINT orders_number_key UNIQUE (number);`, "USING INDEX"},
+		{`CREATE INDEX IF NOT EXISTS idx_orders_email ON orders(email);`, "without CONCURRENTLY"},
+	}
+	for _, tc := range cases {
+		findings := lintMigration(tc.migration, map[string]bool{})
+		if len(findings) != 1 || !strings.Contains(findings[0].Message, tc.want) {
+			t.Fatalf("%s: expected a finding about %q, got %+v", tc.migration, tc.want, findings)
+		}
+		if findings[0].Table != "orders" || findings[0].Severity != MigrationDanger || !findings[0].locks {
+			t.Fatalf("%s: expected a dangerous locking finding on orders, got %+v", tc.migration, findings[0])
+		}
+	}
+}
+
+func TestLintMigrationSafeOperations(t *testing.T) {
+	safe := []string{
+		`ALTER TABLE orders ADD COLUMN IF NOT EXISTS archived BOOLEAN NOT NULL DEFAULT false;`,
+		`ALTER TABLE orders ADD COLUMN IF NOT EXISTS notes TEXT, ADD COLUMN IF NOT EXISTS tags TEXT[];`,
+		`ALTER TABLE orders ADD CONSTRAINT orders_user_fk FOREIGN KEY (user_id) REFERENCES users(id) NOT VALID;`,
+		`ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_status_check;`,
+		`CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_orders_email ON orders(email);`,
+		`CREATE OR REPLACE FUNCTION touch() RETURNS TRIGGER AS $$
+		BEGIN
+			ALTER TABLE orders DROP COLUMN notes;
+			RETURN NEW;
+		END;
+		$$ language 'plpgsql';`,
+	}
+	for _, migration := range safe {
+		if findings := lintMigration(migration, map[string]bool{}); len(findings) != 0 {
+			t.Fatalf("%s: expected no findings, got %+v", migration, findings)
+		}
+	}
+}
+
+func TestLintMigrationNewTables(t *testing.T) {
+	created := map[string]bool{}
+	lintMigration(`CREATE TABLE IF NOT EXISTS parcels (id SERIAL PRIMARY KEY, order_id INTEGER NOT NULL);`, created)
+
+	findings := lintMigration(`CREATE INDEX IF NOT EXISTS idx_parcels_order_id ON parcels(order_id);`, created)
+	if len(findings) != 0 {
+		t.Fatalf("tables created by pending migrations are not in use yet, got %+v", findings)
+	}
+}
+
+func TestLintMigrationContractPhase(t *testing.T) {
+	drop := `ALTER TABLE orders DROP COLUMN IF EXISTS legacy_status;`
+	findings := lintMigration(drop, map[string]bool{})
+	if len(findings) != 1 || findings[0].locks {
+		t.Fatalf("dropping a column in an expand migration should break the running release, got %+v", findings)
+	}
+
+	if findings := lintMigration("-- contract\n"+drop, map[string]bool{}); len(findings) != 0 {
+		t.Fatalf("contract migrations may drop columns, got %+v", findings)
+	}
+	if migrationPhase("-- contract: remove legacy status\n"+drop) != MigrationContract {
+		t.Fatal("expected a contract migration")
+	}
+	if migrationPhase(drop) != MigrationExpand {
+		t.Fatal("migrations are expand migrations by default")
+	}
+}
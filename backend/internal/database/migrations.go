@@ -1,12 +1,11 @@
 package database
 
-import (
-	"database/sql"
-	"fmt"
-)
-
-func Migrate(db *sql.DB) error {
-	migrations := []string{
+// schemaMigrations returns the migrations in order; a migration's version is its
+// position counted from 1. Append new migrations at the end and never edit applied
+// ones. A migration starting with a "-- contract" comment removes what the previous
+// release stopped using and only runs in the contract phase, see Migrate.
+func schemaMigrations() []string {
+	return []string{
 		`CREATE TABLE IF NOT EXISTS users (
 			id SERIAL PRIMARY KEY,
 			email VARCHAR(255) UNIQUE NOT NULL,
@@ -515,13 +514,1184 @@ func Migrate(db *sql.DB) error {
 		BEFORE UPDATE ON client_reviews
 		FOR EACH ROW
 		EXECUTE FUNCTION update_updated_at_column();`,
-	}
 
-	for i, migration := range migrations {
-		if _, err := db.Exec(migration); err != nil {
-			return fmt.Errorf("failed to run migration %d: %w", i+1, err)
-		}
-	}
+		// Refunds table for full and partial order refunds
+		`CREATE TABLE IF NOT EXISTS refunds (
+			id SERIAL PRIMARY KEY,
+			order_id INTEGER NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+			amount DECIMAL(10,2) NOT NULL CHECK (amount > 0),
+			reason TEXT NOT NULL,
+			status VARCHAR(20) NOT NULL DEFAULT 'pending',
+			provider VARCHAR(50) NOT NULL,
+			provider_refund_id VARCHAR(255),
+			failure_reason TEXT,
+			created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_refunds_order_id ON refunds(order_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_refunds_status ON refunds(status);`,
+		`CREATE INDEX IF NOT EXISTS idx_refunds_created_at ON refunds(created_at);`,
+		`DROP TRIGGER IF EXISTS update_refunds_updated_at ON refunds;`,
+		`CREATE TRIGGER update_refunds_updated_at
+		BEFORE UPDATE ON refunds
+		FOR EACH ROW
+		EXECUTE FUNCTION update_updated_at_column();`,
+
+		// Refund items table linking refunds to the order items they cover
+		`CREATE TABLE IF NOT EXISTS refund_items (
+			id SERIAL PRIMARY KEY,
+			refund_id INTEGER NOT NULL REFERENCES refunds(id) ON DELETE CASCADE,
+			order_item_id INTEGER NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+			quantity INTEGER NOT NULL CHECK (quantity > 0),
+			amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_refund_items_refund_id ON refund_items(refund_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_refund_items_order_item_id ON refund_items(order_item_id);`,
+
+		// Customer order change requests awaiting admin approval
+		`CREATE TABLE IF NOT EXISTS order_change_requests (
+			id SERIAL PRIMARY KEY,
+			order_id INTEGER NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+			type VARCHAR(50) NOT NULL,
+			payload JSONB NOT NULL,
+			status VARCHAR(20) NOT NULL DEFAULT 'pending',
+			customer_note TEXT,
+			admin_note TEXT,
+			reviewed_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+			reviewed_at TIMESTAMP WITH TIME ZONE,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_order_change_requests_order_id ON order_change_requests(order_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_order_change_requests_status ON order_change_requests(status);`,
+		`DROP TRIGGER IF EXISTS update_order_change_requests_updated_at ON order_change_requests;`,
+		`CREATE TRIGGER update_order_change_requests_updated_at
+		BEFORE UPDATE ON order_change_requests
+		FOR EACH ROW
+		EXECUTE FUNCTION update_updated_at_column();`,
+
+		// GDPR data retention
+		`ALTER TABLE orders ADD COLUMN IF NOT EXISTS anonymized_at TIMESTAMP WITH TIME ZONE;`,
+		`CREATE INDEX IF NOT EXISTS idx_cart_sessions_updated_at ON cart_sessions(updated_at);`,
+		`INSERT INTO site_settings (key, value, description) VALUES
+		('retention_guest_orders_days', '1825', 'Anonymize guest orders older than this many days (0 disables)'),
+		('retention_abandoned_carts_days', '90', 'Delete carts inactive for this many days (0 disables)')
+		ON CONFLICT (key) DO NOTHING;`,
+
+		// Terms of service / privacy policy versions and acceptances
+		`CREATE TABLE IF NOT EXISTS legal_documents (
+			id SERIAL PRIMARY KEY,
+			type VARCHAR(20) NOT NULL,
+			version VARCHAR(50) NOT NULL,
+			title VARCHAR(255) NOT NULL,
+			url TEXT,
+			summary TEXT,
+			published_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+			published_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(type, version)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_legal_documents_type_published ON legal_documents(type, published_at DESC);`,
+		`CREATE TABLE IF NOT EXISTS legal_acceptances (
+			id SERIAL PRIMARY KEY,
+			document_id INTEGER NOT NULL REFERENCES legal_documents(id) ON DELETE CASCADE,
+			user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+			order_id INTEGER REFERENCES orders(id) ON DELETE CASCADE,
+			ip_address VARCHAR(64) NOT NULL,
+			user_agent TEXT,
+			accepted_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			CHECK (user_id IS NOT NULL OR order_id IS NOT NULL)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_legal_acceptances_user_id ON legal_acceptances(user_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_legal_acceptances_order_id ON legal_acceptances(order_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_legal_acceptances_document_id ON legal_acceptances(document_id);`,
+
+		// Warehouses and per-warehouse stock
+		`CREATE TABLE IF NOT EXISTS warehouses (
+			id SERIAL PRIMARY KEY,
+			code VARCHAR(20) UNIQUE NOT NULL,
+			name VARCHAR(255) NOT NULL,
+			city VARCHAR(100),
+			postal_code VARCHAR(20),
+			country VARCHAR(100) NOT NULL DEFAULT 'Poland',
+			priority INTEGER NOT NULL DEFAULT 0,
+			is_active BOOLEAN NOT NULL DEFAULT true,
+			is_default BOOLEAN NOT NULL DEFAULT false,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_warehouses_single_default ON warehouses(is_default) WHERE is_default = true;`,
+		`DROP TRIGGER IF EXISTS update_warehouses_updated_at ON warehouses;`,
+		`CREATE TRIGGER update_warehouses_updated_at
+		BEFORE UPDATE ON warehouses
+		FOR EACH ROW
+		EXECUTE FUNCTION update_updated_at_column();`,
+		`INSERT INTO warehouses (code, name, is_default)
+		SELECT 'MAIN', 'Main studio', true
+		WHERE NOT EXISTS (SELECT 1 FROM warehouses);`,
+		`CREATE TABLE IF NOT EXISTS warehouse_stock (
+			warehouse_id INTEGER NOT NULL REFERENCES warehouses(id) ON DELETE CASCADE,
+			size_id INTEGER NOT NULL REFERENCES sizes(id) ON DELETE CASCADE,
+			quantity INTEGER NOT NULL DEFAULT 0 CHECK (quantity >= 0),
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (warehouse_id, size_id)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_warehouse_stock_size_id ON warehouse_stock(size_id);`,
+		// Existing stock starts in the default warehouse
+		`INSERT INTO warehouse_stock (warehouse_id, size_id, quantity)
+		SELECT w.id, s.id, s.stock_quantity
+		FROM sizes s
+		CROSS JOIN warehouses w
+		WHERE w.is_default = true AND s.use_stock = true AND s.stock_quantity > 0
+		  AND NOT EXISTS (SELECT 1 FROM warehouse_stock ws WHERE ws.size_id = s.id)
+		ON CONFLICT DO NOTHING;`,
+		`CREATE TABLE IF NOT EXISTS stock_movements (
+			id SERIAL PRIMARY KEY,
+			size_id INTEGER NOT NULL REFERENCES sizes(id) ON DELETE CASCADE,
+			from_warehouse_id INTEGER REFERENCES warehouses(id) ON DELETE SET NULL,
+			to_warehouse_id INTEGER REFERENCES warehouses(id) ON DELETE SET NULL,
+			quantity INTEGER NOT NULL,
+			type VARCHAR(30) NOT NULL,
+			order_id INTEGER REFERENCES orders(id) ON DELETE SET NULL,
+			note TEXT,
+			created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_stock_movements_size_id ON stock_movements(size_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_stock_movements_created_at ON stock_movements(created_at);`,
+		`CREATE TABLE IF NOT EXISTS order_item_allocations (
+			id SERIAL PRIMARY KEY,
+			order_item_id INTEGER NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+			warehouse_id INTEGER NOT NULL REFERENCES warehouses(id) ON DELETE CASCADE,
+			quantity INTEGER NOT NULL CHECK (quantity > 0),
+			picked_at TIMESTAMP WITH TIME ZONE,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_order_item_allocations_item ON order_item_allocations(order_item_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_order_item_allocations_warehouse ON order_item_allocations(warehouse_id, picked_at);`,
+		`INSERT INTO site_settings (key, value, description) VALUES
+		('warehouse_allocation_strategy', 'priority', 'How order items are allocated to warehouses: priority or nearest')
+		ON CONFLICT (key) DO NOTHING;`,
+
+		// Sales channel visibility of products
+		`ALTER TABLE products ADD COLUMN IF NOT EXISTS visible_web BOOLEAN NOT NULL DEFAULT true;`,
+		`ALTER TABLE products ADD COLUMN IF NOT EXISTS visible_marketplace BOOLEAN NOT NULL DEFAULT true;`,
+		`ALTER TABLE products ADD COLUMN IF NOT EXISTS visible_b2b BOOLEAN NOT NULL DEFAULT true;`,
+
+		// Admin sessions for idle timeout and sudo mode
+		`CREATE TABLE IF NOT EXISTS admin_sessions (
+			id VARCHAR(64) PRIMARY KEY,
+			user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			ip_address VARCHAR(45) NOT NULL DEFAULT '',
+			user_agent TEXT,
+			last_seen_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			sudo_at TIMESTAMP WITH TIME ZONE,
+			revoked_at TIMESTAMP WITH TIME ZONE,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_admin_sessions_user_id ON admin_sessions(user_id);`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_secret VARCHAR(64);`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_enabled BOOLEAN NOT NULL DEFAULT false;`,
+		`INSERT INTO site_settings (key, value, description) VALUES
+		('admin_idle_timeout_minutes', '30', 'Minutes of inactivity after which admins must log in again (0 disables)')
+		ON CONFLICT (key) DO NOTHING;`,
+		`ALTER TABLE orders ADD COLUMN IF NOT EXISTS origin_country VARCHAR(2);`,
+		`CREATE INDEX IF NOT EXISTS idx_orders_origin_country ON orders(origin_country);`,
+		`CREATE TABLE IF NOT EXISTS analytics_events (
+			id BIGSERIAL PRIMARY KEY,
+			event_type VARCHAR(32) NOT NULL,
+			session_id VARCHAR(255) NOT NULL,
+			user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+			product_id INTEGER,
+			order_id INTEGER,
+			value DECIMAL(10,2),
+			path TEXT,
+			referrer TEXT,
+			utm_source VARCHAR(100),
+			utm_medium VARCHAR(100),
+			utm_campaign VARCHAR(100),
+			country VARCHAR(2),
+			properties JSONB,
+			occurred_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_analytics_events_occurred_at ON analytics_events USING BRIN (occurred_at);`,
+		`CREATE INDEX IF NOT EXISTS idx_analytics_events_session ON analytics_events(session_id, event_type);`,
+		`INSERT INTO site_settings (key, value, description) VALUES
+		('retention_analytics_events_days', '400', 'Delete raw analytics events older than this many days (0 disables)')
+		ON CONFLICT (key) DO NOTHING;`,
+		`CREATE TABLE IF NOT EXISTS cart_events (
+			id SERIAL PRIMARY KEY,
+			cart_session_id INTEGER NOT NULL REFERENCES cart_sessions(id) ON DELETE CASCADE,
+			action VARCHAR(20) NOT NULL,
+			cart_item_id INTEGER,
+			product_id INTEGER,
+			variant_id INTEGER,
+			size_id INTEGER,
+			quantity_before INTEGER NOT NULL DEFAULT 0,
+			quantity_after INTEGER NOT NULL DEFAULT 0,
+			price_per_item DECIMAL(10,2),
+			user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+			order_id INTEGER REFERENCES orders(id) ON DELETE SET NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_cart_events_session ON cart_events(cart_session_id, created_at);`,
+		`CREATE TABLE IF NOT EXISTS product_pairings (
+			product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+			related_product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+			co_orders INTEGER NOT NULL,
+			score DECIMAL(6,4) NOT NULL,
+			computed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (product_id, related_product_id)
+		);`,
+		`CREATE TABLE IF NOT EXISTS product_pairing_overrides (
+			product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+			related_product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+			mode VARCHAR(10) NOT NULL CHECK (mode IN ('pin', 'exclude')),
+			position INTEGER NOT NULL DEFAULT 0,
+			created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (product_id, related_product_id),
+			CHECK (product_id <> related_product_id)
+		);`,
+		`INSERT INTO site_settings (key, value, description) VALUES
+		('pairing_lookback_days', '365', 'Orders from this many days are used for frequently bought together suggestions'),
+		('pairing_min_orders', '2', 'Minimum number of shared orders before two products are suggested together')
+		ON CONFLICT (key) DO NOTHING;`,
+		`CREATE TABLE IF NOT EXISTS catalog_snapshots (
+			id SERIAL PRIMARY KEY,
+			note TEXT,
+			format_version INTEGER NOT NULL,
+			product_count INTEGER NOT NULL DEFAULT 0,
+			size_bytes INTEGER NOT NULL DEFAULT 0,
+			data JSONB NOT NULL,
+			created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE TABLE IF NOT EXISTS trash_items (
+			id SERIAL PRIMARY KEY,
+			entity_type VARCHAR(32) NOT NULL,
+			entity_id INTEGER NOT NULL,
+			label VARCHAR(256) NOT NULL,
+			data JSONB NOT NULL,
+			deleted_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+			deleted_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_trash_items_deleted_at ON trash_items(deleted_at);`,
+		`CREATE INDEX IF NOT EXISTS idx_trash_items_entity ON trash_items(entity_type, entity_id);`,
+		`INSERT INTO site_settings (key, value, description) VALUES
+		('retention_trash_days', '30', 'Permanently purge deleted items from the trash after this many days (0 keeps them)')
+		ON CONFLICT (key) DO NOTHING;`,
+		// Focal point and per-variant crops for generated image variants
+		`ALTER TABLE images ADD COLUMN IF NOT EXISTS focal_x DECIMAL(5, 4) NOT NULL DEFAULT 0.5 CHECK (focal_x >= 0 AND focal_x <= 1);`,
+		`ALTER TABLE images ADD COLUMN IF NOT EXISTS focal_y DECIMAL(5, 4) NOT NULL DEFAULT 0.5 CHECK (focal_y >= 0 AND focal_y <= 1);`,
+		`ALTER TABLE images ADD COLUMN IF NOT EXISTS crops JSONB NOT NULL DEFAULT '{}'::jsonb;`,
+		// Downloadable product attachments (care guides etc.)
+		`CREATE TABLE IF NOT EXISTS product_attachments (
+			id SERIAL PRIMARY KEY,
+			product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+			title VARCHAR(255) NOT NULL,
+			filename VARCHAR(255) NOT NULL UNIQUE,
+			original_name VARCHAR(255) NOT NULL,
+			path VARCHAR(500) NOT NULL,
+			size_bytes BIGINT NOT NULL,
+			mime_type VARCHAR(100) NOT NULL,
+			position INTEGER NOT NULL DEFAULT 0,
+			download_count INTEGER NOT NULL DEFAULT 0,
+			uploaded_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_product_attachments_product_id ON product_attachments(product_id, position);`,
+		`INSERT INTO site_settings (key, value, description) VALUES
+			('attachment_max_size_mb', '20', 'Maximum size of product attachments in MB')
+		ON CONFLICT (key) DO NOTHING;`,
+		// Media library tags
+		`CREATE TABLE IF NOT EXISTS image_tags (
+			image_id INTEGER NOT NULL REFERENCES images(id) ON DELETE CASCADE,
+			tag VARCHAR(50) NOT NULL,
+			PRIMARY KEY (image_id, tag)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_image_tags_tag ON image_tags(tag);`,
+		// Content changes proposed by editors, pending admin approval
+		`CREATE TABLE IF NOT EXISTS content_changes (
+			id SERIAL PRIMARY KEY,
+			entity_type VARCHAR(50) NOT NULL,
+			entity_id INTEGER NOT NULL,
+			status VARCHAR(20) NOT NULL DEFAULT 'pending',
+			payload JSONB NOT NULL,
+			base JSONB NOT NULL,
+			submitted_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+			submitted_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			reviewed_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+			reviewed_at TIMESTAMP WITH TIME ZONE,
+			review_note TEXT
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_content_changes_entity ON content_changes(entity_type, entity_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_content_changes_status ON content_changes(status, submitted_at);`,
+		`INSERT INTO site_settings (key, value, description) VALUES
+			('content_approval_required', 'false', 'Editors'' content changes wait for admin approval')
+		ON CONFLICT (key) DO NOTHING;`,
+		// Product revision history
+		`CREATE TABLE IF NOT EXISTS product_revisions (
+			id SERIAL PRIMARY KEY,
+			product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+			revision INTEGER NOT NULL,
+			action VARCHAR(32) NOT NULL,
+			restored_from INTEGER,
+			data JSONB NOT NULL,
+			created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (product_id, revision)
+		);`,
+		productRevisionBaselineSQL(),
+		// Return address printed on shipping labels
+		`INSERT INTO site_settings (key, value, description) VALUES
+			('label_sender_address', '', 'Return address printed on shipping labels, one line per row')
+		ON CONFLICT (key) DO NOTHING;`,
+		// Recurring discount windows, evaluated in the shop time zone
+		`ALTER TABLE discount_codes ADD COLUMN IF NOT EXISTS windows JSONB NOT NULL DEFAULT '[]';`,
+		`INSERT INTO site_settings (key, value, description) VALUES
+			('shop_timezone', 'Europe/Warsaw', 'Time zone of the shop, used for discount windows')
+		ON CONFLICT (key) DO NOTHING;`,
+		// Discount codes restricted to specific customers
+		`CREATE TABLE IF NOT EXISTS discount_code_users (
+			id SERIAL PRIMARY KEY,
+			discount_code_id INTEGER NOT NULL REFERENCES discount_codes(id) ON DELETE CASCADE,
+			user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+			email VARCHAR(255),
+			created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			CHECK ((user_id IS NULL) <> (email IS NULL))
+		);`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_discount_code_users_user ON discount_code_users(discount_code_id, user_id) WHERE user_id IS NOT NULL;`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_discount_code_users_email ON discount_code_users(discount_code_id, LOWER(email)) WHERE email IS NOT NULL;`,
+		// First-order-only discount codes
+		`ALTER TABLE discount_codes ADD COLUMN IF NOT EXISTS is_first_order_only BOOLEAN NOT NULL DEFAULT false;`,
+		// Last successful login of each user
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS last_login_at TIMESTAMP WITH TIME ZONE;`,
+		// Login history
+		`CREATE TABLE IF NOT EXISTS login_history (
+			id SERIAL PRIMARY KEY,
+			user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			ip_address VARCHAR(45) NOT NULL,
+			user_agent TEXT,
+			new_device BOOLEAN NOT NULL DEFAULT false,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_login_history_user ON login_history(user_id, created_at DESC);`,
+		`CREATE INDEX IF NOT EXISTS idx_login_history_created_at ON login_history(created_at);`,
+		`INSERT INTO site_settings (key, value, description) VALUES
+			('retention_login_history_days', '365', 'Days to keep login history')
+		ON CONFLICT (key) DO NOTHING;`,
+		// Challenge required on public endpoints, one switch per endpoint
+		`INSERT INTO site_settings (key, value, description) VALUES
+			('captcha_register', 'false', 'Require a challenge to register an account'),
+			('captcha_discount_apply', 'false', 'Require a challenge to apply a discount code'),
+			('captcha_guest_checkout', 'false', 'Require a challenge to place an order without an account')
+		ON CONFLICT (key) DO NOTHING;`,
+		// Debug captures of the API traffic of a session or user
+		`CREATE TABLE IF NOT EXISTS debug_captures (
+			id SERIAL PRIMARY KEY,
+			session_id VARCHAR(255),
+			user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+			note TEXT,
+			expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+			created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			CHECK ((session_id IS NULL) <> (user_id IS NULL))
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_debug_captures_expires_at ON debug_captures(expires_at);`,
+		`CREATE TABLE IF NOT EXISTS debug_capture_entries (
+			id SERIAL PRIMARY KEY,
+			capture_id INTEGER NOT NULL REFERENCES debug_captures(id) ON DELETE CASCADE,
+			method VARCHAR(10) NOT NULL,
+			path TEXT NOT NULL,
+			query TEXT NOT NULL DEFAULT '',
+			status INTEGER NOT NULL,
+			duration_ms BIGINT NOT NULL,
+			client_ip VARCHAR(45) NOT NULL,
+			session_id VARCHAR(255),
+			user_id INTEGER,
+			request_headers JSONB NOT NULL DEFAULT '{}',
+			request_body TEXT NOT NULL DEFAULT '',
+			response_body TEXT NOT NULL DEFAULT '',
+			truncated BOOLEAN NOT NULL DEFAULT false,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_debug_capture_entries_capture ON debug_capture_entries(capture_id, created_at);`,
+		`INSERT INTO site_settings (key, value, description) VALUES
+			('debug_capture_redact_fields', 'password,token,secret,authorization,cookie,card,cvc,cvv,iban,email,phone,first_name,last_name,address_line,nip', 'Fields whose values are redacted from debug captures, comma separated'),
+			('retention_debug_captures_days', '30', 'Days to keep debug captures')
+		ON CONFLICT (key) DO NOTHING;`,
+		`ALTER TABLE products ADD COLUMN IF NOT EXISTS slug VARCHAR(256);`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_products_slug ON products(slug) WHERE slug IS NOT NULL;`,
+		// Products created before slugs were stored get one made from their name the way
+		// the slug package does, suffixed -2, -3, ... when taken
+		`DO $$
+		DECLARE
+			r RECORD;
+			base TEXT;
+			candidate TEXT;
+			n INTEGER;
+		BEGIN
+			FOR r IN SELECT id, name FROM products WHERE slug IS NULL ORDER BY id LOOP
+				base := left(trim(BOTH '-' FROM regexp_replace(
+					lower(translate(r.name, 'ąćęłńóśźżĄĆĘŁŃÓŚŹŻ', 'acelnoszzACELNOSZZ')),
+					'[^a-z0-9]+', '-', 'g')), 200);
+				base := trim(BOTH '-' FROM base);
+				IF base = '' THEN
+					base := 'product';
+				END IF;
+				candidate := base;
+				n := 2;
+				WHILE EXISTS (SELECT 1 FROM products WHERE slug = candidate) LOOP
+					candidate := base || '-' || n;
+					n := n + 1;
+				END LOOP;
+				UPDATE products SET slug = candidate WHERE id = r.id;
+			END LOOP;
+		END $$;`,
+		`ALTER TABLE client_reviews ADD COLUMN IF NOT EXISTS rating SMALLINT CHECK (rating BETWEEN 1 AND 5);`,
+		`CREATE INDEX IF NOT EXISTS idx_client_reviews_rating ON client_reviews(rating) WHERE is_active = true;`,
+
+		// Cart item services keep the name and price the service had when it was added,
+		// and outlive the service so the cart can tell the customer it was removed
+		`ALTER TABLE cart_item_services ADD COLUMN IF NOT EXISTS service_id INTEGER;`,
+		`ALTER TABLE cart_item_services ADD COLUMN IF NOT EXISTS service_name VARCHAR(255);`,
+		`ALTER TABLE cart_item_services ADD COLUMN IF NOT EXISTS service_description TEXT;`,
+		`ALTER TABLE cart_item_services ADD COLUMN IF NOT EXISTS service_price DECIMAL(10, 2);`,
+		`ALTER TABLE cart_item_services ADD COLUMN IF NOT EXISTS created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP;`,
+		`UPDATE cart_item_services cis
+		SET service_id = a.id, service_name = a.name, service_description = a.description, service_price = a.price
+		FROM additional_services a
+		WHERE a.id = cis.additional_service_id AND cis.service_id IS NULL;`,
+		`ALTER TABLE cart_item_services
+			ALTER COLUMN service_id SET NOT NULL,
+			ALTER COLUMN service_name SET NOT NULL,
+			ALTER COLUMN service_price SET NOT NULL;`,
+		`ALTER TABLE cart_item_services DROP CONSTRAINT IF EXISTS cart_item_services_pkey;`,
+		`ALTER TABLE cart_item_services ALTER COLUMN additional_service_id DROP NOT NULL;`,
+		`ALTER TABLE cart_item_services DROP CONSTRAINT IF EXISTS cart_item_services_additional_service_id_fkey;`,
+		`ALTER TABLE cart_item_services ADD CONSTRAINT cart_item_services_additional_service_id_fkey
+			FOREIGN KEY (additional_service_id) REFERENCES additional_services(id) ON DELETE SET NULL;`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_cart_item_services_item_service ON cart_item_services(cart_item_id, service_id);`,
+
+		// Product option groups, e.g. fabric pattern or trim, next to the color variants
+		`CREATE TABLE IF NOT EXISTS product_option_groups (
+			id SERIAL PRIMARY KEY,
+			product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+			name VARCHAR(100) NOT NULL,
+			required BOOLEAN NOT NULL DEFAULT true,
+			position INTEGER NOT NULL DEFAULT 0,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(product_id, name)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_product_option_groups_product_id ON product_option_groups(product_id);`,
+		`CREATE TABLE IF NOT EXISTS product_option_values (
+			id SERIAL PRIMARY KEY,
+			group_id INTEGER NOT NULL REFERENCES product_option_groups(id) ON DELETE CASCADE,
+			name VARCHAR(100) NOT NULL,
+			image_id INTEGER REFERENCES images(id) ON DELETE SET NULL,
+			surcharge DECIMAL(10, 2) NOT NULL DEFAULT 0 CHECK (surcharge >= 0),
+			position INTEGER NOT NULL DEFAULT 0,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(group_id, name)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_product_option_values_group_id ON product_option_values(group_id);`,
+		`DROP TRIGGER IF EXISTS update_product_option_groups_updated_at ON product_option_groups;`,
+		`CREATE TRIGGER update_product_option_groups_updated_at
+		BEFORE UPDATE ON product_option_groups
+		FOR EACH ROW
+		EXECUTE FUNCTION update_updated_at_column();`,
+		`DROP TRIGGER IF EXISTS update_product_option_values_updated_at ON product_option_values;`,
+		`CREATE TRIGGER update_product_option_values_updated_at
+		BEFORE UPDATE ON product_option_values
+		FOR EACH ROW
+		EXECUTE FUNCTION update_updated_at_column();`,
+		// The chosen values are copied onto cart and order items like services are; a
+		// deleted value leaves the cart item unavailable
+		`CREATE TABLE IF NOT EXISTS cart_item_options (
+			id SERIAL PRIMARY KEY,
+			cart_item_id INTEGER NOT NULL REFERENCES cart_items(id) ON DELETE CASCADE,
+			option_value_id INTEGER REFERENCES product_option_values(id) ON DELETE SET NULL,
+			option_group_id INTEGER NOT NULL,
+			group_name VARCHAR(100) NOT NULL,
+			value_name VARCHAR(100) NOT NULL,
+			surcharge DECIMAL(10, 2) NOT NULL DEFAULT 0,
+			UNIQUE(cart_item_id, option_group_id)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_cart_item_options_cart_item_id ON cart_item_options(cart_item_id);`,
+		`CREATE TABLE IF NOT EXISTS order_item_options (
+			id SERIAL PRIMARY KEY,
+			order_item_id INTEGER NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+			option_group_id INTEGER NOT NULL,
+			option_value_id INTEGER NOT NULL,
+			group_name VARCHAR(100) NOT NULL,
+			value_name VARCHAR(100) NOT NULL,
+			surcharge DECIMAL(10, 2) NOT NULL DEFAULT 0,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_order_item_options_order_item_id ON order_item_options(order_item_id);`,
+
+		// Made-to-order products and the fulfilment choices recorded on orders
+		`ALTER TABLE products ADD COLUMN IF NOT EXISTS made_to_order BOOLEAN NOT NULL DEFAULT false;`,
+		`ALTER TABLE products ADD COLUMN IF NOT EXISTS lead_time_days INTEGER CHECK (lead_time_days > 0);`,
+		`ALTER TABLE orders ADD COLUMN IF NOT EXISTS split_shipment BOOLEAN NOT NULL DEFAULT false;`,
+		`ALTER TABLE orders ADD COLUMN IF NOT EXISTS lead_time_days INTEGER;`,
+		`INSERT INTO site_settings (key, value, description) VALUES
+			('made_to_order_lead_days', '14', 'Days needed to make a made-to-order item when the product sets no lead time'),
+			('in_stock_dispatch_days', '2', 'Days until in-stock items are dispatched')
+		ON CONFLICT (key) DO NOTHING;`,
+
+		// Read-only API keys for partners, with per-key rate limits and daily usage
+		`CREATE TABLE IF NOT EXISTS api_keys (
+			id SERIAL PRIMARY KEY,
+			name VARCHAR(100) NOT NULL,
+			key_prefix VARCHAR(16) NOT NULL UNIQUE,
+			key_hash VARCHAR(64) NOT NULL UNIQUE,
+			tier VARCHAR(20) NOT NULL,
+			scopes TEXT[] NOT NULL DEFAULT '{}',
+			rate_limit_per_minute INTEGER NOT NULL CHECK (rate_limit_per_minute > 0),
+			last_used_at TIMESTAMP WITH TIME ZONE,
+			revoked_at TIMESTAMP WITH TIME ZONE,
+			created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE TABLE IF NOT EXISTS api_key_usage (
+			api_key_id INTEGER NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+			day DATE NOT NULL,
+			requests BIGINT NOT NULL DEFAULT 0,
+			rejected BIGINT NOT NULL DEFAULT 0,
+			PRIMARY KEY (api_key_id, day)
+		);`,
+		`INSERT INTO site_settings (key, value, description) VALUES
+			('retention_api_key_usage_days', '730', 'Days to keep daily partner API key usage')
+		ON CONFLICT (key) DO NOTHING;`,
+
+		// Checkouts rejected because the client's totals differ from the server's
+		`CREATE TABLE IF NOT EXISTS order_totals_mismatches (
+			id SERIAL PRIMARY KEY,
+			session_id VARCHAR(255) NOT NULL,
+			user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+			email VARCHAR(255) NOT NULL,
+			ip_address VARCHAR(45) NOT NULL DEFAULT '',
+			expected JSONB NOT NULL,
+			computed JSONB NOT NULL,
+			mismatches JSONB NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_order_totals_mismatches_created_at ON order_totals_mismatches(created_at);`,
+		`INSERT INTO site_settings (key, value, description) VALUES
+			('retention_totals_mismatches_days', '365', 'Days to keep checkouts rejected for mismatching totals')
+		ON CONFLICT (key) DO NOTHING;`,
+
+		// States and incident notes of the public status page
+		`INSERT INTO site_settings (key, value, description) VALUES
+			('status_payments', 'operational', 'Payments state on the status page: operational, degraded or outage'),
+			('status_shipping', 'operational', 'Shipping integration state on the status page: operational, degraded or outage'),
+			('status_incidents', '[]', 'Incident notes on the status page, as a JSON array of {title, message, status, created_at, resolved_at}')
+		ON CONFLICT (key) DO NOTHING;`,
+
+		// Tracking details recorded when an order is shipped
+		`ALTER TABLE orders ADD COLUMN IF NOT EXISTS tracking_carrier VARCHAR(50);`,
+		`ALTER TABLE orders ADD COLUMN IF NOT EXISTS tracking_number VARCHAR(100);`,
+		`ALTER TABLE orders ADD COLUMN IF NOT EXISTS shipped_at TIMESTAMP;`,
+
+		// Stale cart prices refreshed against the catalog, with the price the customer last saw
+		`ALTER TABLE cart_items ADD COLUMN IF NOT EXISTS price_checked_at TIMESTAMP;`,
+		`ALTER TABLE cart_items ADD COLUMN IF NOT EXISTS previous_price DECIMAL(10, 2);`,
+		`ALTER TABLE cart_items ADD COLUMN IF NOT EXISTS price_changed_at TIMESTAMP;`,
+		`INSERT INTO site_settings (key, value, description) VALUES
+			('cart_price_refresh_days', '7', 'Days after which cart item prices are checked against current prices')
+		ON CONFLICT (key) DO NOTHING;`,
 
-	return nil
-}
\ No newline at end of file
+		// Webhook endpoints and their delivery log
+		`CREATE TABLE IF NOT EXISTS webhook_endpoints (
+			id SERIAL PRIMARY KEY,
+			url VARCHAR(500) NOT NULL,
+			description VARCHAR(255) NOT NULL DEFAULT '',
+			secret VARCHAR(128) NOT NULL,
+			events TEXT[] NOT NULL DEFAULT '{}',
+			active BOOLEAN NOT NULL DEFAULT true,
+			created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+			id SERIAL PRIMARY KEY,
+			endpoint_id INTEGER NOT NULL REFERENCES webhook_endpoints(id) ON DELETE CASCADE,
+			event VARCHAR(50) NOT NULL,
+			payload JSONB NOT NULL,
+			status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'succeeded', 'failed')),
+			attempts INTEGER NOT NULL DEFAULT 0,
+			next_attempt_at TIMESTAMP,
+			last_status_code INTEGER,
+			last_error TEXT,
+			last_attempt_at TIMESTAMP,
+			delivered_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`DROP TRIGGER IF EXISTS update_webhook_endpoints_updated_at ON webhook_endpoints;`,
+		`CREATE TRIGGER update_webhook_endpoints_updated_at
+		BEFORE UPDATE ON webhook_endpoints
+		FOR EACH ROW
+		EXECUTE FUNCTION update_updated_at_column();`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_endpoint ON webhook_deliveries(endpoint_id, created_at);`,
+		`INSERT INTO site_settings (key, value, description) VALUES
+			('retention_webhook_deliveries_days', '90', 'Days to keep finished webhook deliveries'),
+			('low_stock_threshold', '3', 'Available quantity of a size at which the stock.low webhook is sent')
+		ON CONFLICT (key) DO NOTHING;`,
+
+		// Order archive: finished orders past order_archive_after_years keep their item
+		// snapshots as one JSON document instead of order_items rows
+		`ALTER TABLE orders ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP WITH TIME ZONE;`,
+		`CREATE INDEX IF NOT EXISTS idx_orders_archived_at ON orders(archived_at);`,
+		`CREATE TABLE IF NOT EXISTS order_archives (
+			order_id INTEGER PRIMARY KEY REFERENCES orders(id) ON DELETE CASCADE,
+			items JSONB NOT NULL,
+			archived_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`INSERT INTO site_settings (key, value, description) VALUES
+		('order_archive_after_years', '5', 'Age in years after which delivered and cancelled orders are archived (0 disables archiving)')
+		ON CONFLICT (key) DO NOTHING;`,
+
+		// Payment intents: attempts to collect an order's payment at the payment provider
+		`CREATE TABLE IF NOT EXISTS payment_intents (
+			id SERIAL PRIMARY KEY,
+			order_id INTEGER NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+			provider VARCHAR(50) NOT NULL,
+			reference VARCHAR(100) NOT NULL UNIQUE,
+			provider_intent_id VARCHAR(255),
+			amount DECIMAL(10,2) NOT NULL,
+			currency VARCHAR(3) NOT NULL,
+			status VARCHAR(20) NOT NULL DEFAULT 'pending',
+			redirect_url TEXT,
+			client_secret TEXT,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (provider, provider_intent_id)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_payment_intents_order_id ON payment_intents(order_id);`,
+		`DROP TRIGGER IF EXISTS update_payment_intents_updated_at ON payment_intents;`,
+		`CREATE TRIGGER update_payment_intents_updated_at
+		BEFORE UPDATE ON payment_intents
+		FOR EACH ROW
+		EXECUTE FUNCTION update_updated_at_column();`,
+
+		// Transactional email: admin customized templates and the outbox emails are sent from
+		`CREATE TABLE IF NOT EXISTS email_templates (
+			key VARCHAR(50) PRIMARY KEY,
+			subject VARCHAR(255) NOT NULL,
+			text_body TEXT NOT NULL,
+			html_body TEXT NOT NULL,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`DROP TRIGGER IF EXISTS update_email_templates_updated_at ON email_templates;`,
+		`CREATE TRIGGER update_email_templates_updated_at
+		BEFORE UPDATE ON email_templates
+		FOR EACH ROW
+		EXECUTE FUNCTION update_updated_at_column();`,
+		`CREATE TABLE IF NOT EXISTS email_outbox (
+			id SERIAL PRIMARY KEY,
+			template VARCHAR(50) NOT NULL,
+			to_address VARCHAR(255) NOT NULL,
+			subject VARCHAR(255) NOT NULL,
+			text_body TEXT NOT NULL,
+			html_body TEXT NOT NULL DEFAULT '',
+			order_id INTEGER REFERENCES orders(id) ON DELETE SET NULL,
+			status VARCHAR(20) NOT NULL DEFAULT 'pending',
+			attempts INTEGER NOT NULL DEFAULT 0,
+			next_attempt_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			last_error TEXT,
+			last_attempt_at TIMESTAMP WITH TIME ZONE,
+			sent_at TIMESTAMP WITH TIME ZONE,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_email_outbox_due ON email_outbox(status, next_attempt_at);`,
+		`CREATE INDEX IF NOT EXISTS idx_email_outbox_created_at ON email_outbox(created_at);`,
+		`INSERT INTO site_settings (key, value, description) VALUES
+		('retention_email_outbox_days', '90', 'Days to keep sent and failed outbox emails')
+		ON CONFLICT (key) DO NOTHING;`,
+
+		// Production capacity: made-to-order items book slots on the days they will be
+		// made, up to production_capacity_per_day items a day; blocked days take none
+		`CREATE TABLE IF NOT EXISTS production_blocked_days (
+			day DATE PRIMARY KEY,
+			reason VARCHAR(255),
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE TABLE IF NOT EXISTS production_slots (
+			order_id INTEGER NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+			day DATE NOT NULL,
+			items INTEGER NOT NULL CHECK (items > 0),
+			PRIMARY KEY (order_id, day)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_production_slots_day ON production_slots(day);`,
+		`INSERT INTO site_settings (key, value, description) VALUES
+		('production_capacity_per_day', '0', 'Made-to-order items the workshop can make per day; 0 for unlimited')
+		ON CONFLICT (key) DO NOTHING;`,
+
+		// Password reset: single-use tokens, stored hashed, that expire after an hour
+		`CREATE TABLE IF NOT EXISTS password_reset_tokens (
+			id SERIAL PRIMARY KEY,
+			user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			token_hash VARCHAR(64) NOT NULL UNIQUE,
+			ip_address VARCHAR(45),
+			expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+			used_at TIMESTAMP WITH TIME ZONE,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_user_id ON password_reset_tokens(user_id, created_at);`,
+		`INSERT INTO site_settings (key, value, description) VALUES
+		('retention_password_reset_tokens_days', '30', 'Days to keep used and expired password reset tokens')
+		ON CONFLICT (key) DO NOTHING;`,
+
+		// Refresh tokens: stored hashed and rotated on every refresh. A family is one
+		// login; reusing a rotated token revokes the whole family.
+		`CREATE TABLE IF NOT EXISTS refresh_tokens (
+			id SERIAL PRIMARY KEY,
+			user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			token_hash VARCHAR(64) NOT NULL UNIQUE,
+			family_id VARCHAR(32) NOT NULL,
+			ip_address VARCHAR(45),
+			user_agent TEXT,
+			expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+			revoked_at TIMESTAMP WITH TIME ZONE,
+			replaced_by INTEGER REFERENCES refresh_tokens(id) ON DELETE SET NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens(user_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens(family_id);`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS sessions_revoked_at TIMESTAMP WITH TIME ZONE;`,
+		`INSERT INTO site_settings (key, value, description) VALUES
+		('retention_refresh_tokens_days', '30', 'Days to keep revoked and expired refresh tokens')
+		ON CONFLICT (key) DO NOTHING;`,
+
+		// Product reviews: star ratings from customers who bought the product, shown
+		// in the shop once approved by an admin
+		`CREATE TABLE IF NOT EXISTS product_reviews (
+			id SERIAL PRIMARY KEY,
+			product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+			user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			order_id INTEGER REFERENCES orders(id) ON DELETE SET NULL,
+			rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+			title VARCHAR(255),
+			body TEXT,
+			status VARCHAR(20) NOT NULL DEFAULT 'pending',
+			admin_note TEXT,
+			moderated_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+			moderated_at TIMESTAMP WITH TIME ZONE,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (product_id, user_id)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_product_reviews_product_status ON product_reviews(product_id, status);`,
+		`CREATE INDEX IF NOT EXISTS idx_product_reviews_status_created_at ON product_reviews(status, created_at);`,
+		`DROP TRIGGER IF EXISTS update_product_reviews_updated_at ON product_reviews;`,
+		`CREATE TRIGGER update_product_reviews_updated_at
+		BEFORE UPDATE ON product_reviews
+		FOR EACH ROW
+		EXECUTE FUNCTION update_updated_at_column();`,
+
+		// Read-only mode: the shop stays browsable but orders and payments are refused
+		`INSERT INTO site_settings (key, value, description) VALUES
+		('checkout_disabled', 'false', 'Refuse new orders and payments while the catalog and cart keep working'),
+		('checkout_disabled_message', 'Ordering is temporarily unavailable. Please try again later.', 'Message shown to customers while checkout is disabled')
+		ON CONFLICT (key) DO NOTHING;`,
+
+		// Duplicate order detection: customers who submit checkout twice
+		`INSERT INTO site_settings (key, value, description) VALUES
+		('duplicate_order_window_minutes', '30', 'Minutes within which a second order from the same email with a similar total is flagged as a duplicate'),
+		('duplicate_order_total_tolerance_percent', '5', 'How far in percent the totals of two orders may differ for them to count as duplicates')
+		ON CONFLICT (key) DO NOTHING;`,
+
+		// Stock reservations: checkout holds stock for a limited time, the sweeper returns
+		// what is left behind. reserved_quantity is the sum of the live reservations.
+		`CREATE TABLE IF NOT EXISTS stock_reservations (
+			id SERIAL PRIMARY KEY,
+			size_id INTEGER NOT NULL REFERENCES sizes(id) ON DELETE CASCADE,
+			quantity INTEGER NOT NULL CHECK (quantity > 0),
+			expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_stock_reservations_expires_at ON stock_reservations(expires_at);`,
+		`CREATE INDEX IF NOT EXISTS idx_stock_reservations_size_id ON stock_reservations(size_id);`,
+		`UPDATE sizes s SET reserved_quantity = COALESCE(r.quantity, 0)
+		FROM sizes s2
+		LEFT JOIN (SELECT size_id, SUM(quantity) AS quantity FROM stock_reservations GROUP BY size_id) r ON r.size_id = s2.id
+		WHERE s.id = s2.id AND s.reserved_quantity <> COALESCE(r.quantity, 0);`,
+		`INSERT INTO site_settings (key, value, description) VALUES
+		('stock_reservation_ttl_minutes', '15', 'Minutes stock reserved for a checkout is held before it is returned')
+		ON CONFLICT (key) DO NOTHING;`,
+
+		// Test orders: placed by staff to try checkout on production, left out of reports
+		`ALTER TABLE orders ADD COLUMN IF NOT EXISTS is_test BOOLEAN NOT NULL DEFAULT FALSE;`,
+		`CREATE INDEX IF NOT EXISTS idx_orders_is_test ON orders(is_test) WHERE is_test;`,
+
+		// Oversized products and per-product shipping surcharges
+		`ALTER TABLE products ADD COLUMN IF NOT EXISTS oversize BOOLEAN NOT NULL DEFAULT false;`,
+		`ALTER TABLE products ADD COLUMN IF NOT EXISTS shipping_surcharge DECIMAL(10, 2) NOT NULL DEFAULT 0 CHECK (shipping_surcharge >= 0);`,
+		`ALTER TABLE orders ADD COLUMN IF NOT EXISTS shipping_breakdown JSONB;`,
+		`INSERT INTO site_settings (key, value, description) VALUES
+		('oversize_shipping_fee', '49.00', 'Flat fee added to the shipping of orders with an oversized product')
+		ON CONFLICT (key) DO NOTHING;`,
+
+		// Resized WebP copies of uploaded images
+		`CREATE TABLE IF NOT EXISTS image_variants (
+			id SERIAL PRIMARY KEY,
+			image_id INTEGER NOT NULL REFERENCES images(id) ON DELETE CASCADE,
+			name VARCHAR(50) NOT NULL,
+			path VARCHAR(500) NOT NULL,
+			width INTEGER NOT NULL,
+			height INTEGER NOT NULL,
+			size_bytes BIGINT NOT NULL,
+			mime_type VARCHAR(100) NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (image_id, name)
+		);`,
+
+		// Export center: exports requested by admins and generated by the exports job
+		`CREATE TABLE IF NOT EXISTS exports (
+			id SERIAL PRIMARY KEY,
+			type VARCHAR(50) NOT NULL,
+			status VARCHAR(20) NOT NULL DEFAULT 'pending',
+			params JSONB NOT NULL DEFAULT '{}',
+			file_name VARCHAR(255),
+			path VARCHAR(500),
+			row_count INTEGER,
+			size_bytes BIGINT,
+			error TEXT,
+			requested_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			started_at TIMESTAMP WITH TIME ZONE,
+			completed_at TIMESTAMP WITH TIME ZONE
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_exports_status ON exports(status, created_at);`,
+		`CREATE INDEX IF NOT EXISTS idx_exports_created_at ON exports(created_at DESC);`,
+
+		// Free-form product tags for filtering and merchandising
+		`CREATE TABLE IF NOT EXISTS product_tags (
+			product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+			tag VARCHAR(50) NOT NULL,
+			PRIMARY KEY (product_id, tag)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_product_tags_tag ON product_tags(tag);`,
+
+		// Live stats count the carts touched recently
+		`CREATE INDEX IF NOT EXISTS idx_cart_items_updated_at ON cart_items(updated_at);`,
+
+		// Shops (brands) served by this backend. The default shop has id 1 so the shop_id
+		// columns of existing rows can default to it.
+		`CREATE TABLE IF NOT EXISTS shops (
+			id SERIAL PRIMARY KEY,
+			slug VARCHAR(50) UNIQUE NOT NULL,
+			name VARCHAR(255) NOT NULL,
+			domains TEXT[] NOT NULL DEFAULT '{}',
+			sender_email VARCHAR(255) NOT NULL,
+			sender_name VARCHAR(255),
+			theme JSONB NOT NULL DEFAULT '{}',
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`INSERT INTO shops (id, slug, name, sender_email, sender_name)
+		VALUES (1, 'notsofluffy', 'NotSoFluffy', 'no-reply@notsofluffy.pl', 'NotSoFluffy')
+		ON CONFLICT (id) DO NOTHING;`,
+		`SELECT setval(pg_get_serial_sequence('shops', 'id'), GREATEST((SELECT MAX(id) FROM shops), 1));`,
+		`ALTER TABLE products ADD COLUMN IF NOT EXISTS shop_id INTEGER NOT NULL DEFAULT 1 REFERENCES shops(id);`,
+		`ALTER TABLE categories ADD COLUMN IF NOT EXISTS shop_id INTEGER NOT NULL DEFAULT 1 REFERENCES shops(id);`,
+		`ALTER TABLE discount_codes ADD COLUMN IF NOT EXISTS shop_id INTEGER NOT NULL DEFAULT 1 REFERENCES shops(id);`,
+		`ALTER TABLE orders ADD COLUMN IF NOT EXISTS shop_id INTEGER NOT NULL DEFAULT 1 REFERENCES shops(id);`,
+		`CREATE INDEX IF NOT EXISTS idx_products_shop_id ON products(shop_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_categories_shop_id ON categories(shop_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_discount_codes_shop_id ON discount_codes(shop_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_orders_shop_id ON orders(shop_id);`,
+
+		// Shipments ordered from carrier APIs
+		`CREATE TABLE IF NOT EXISTS shipments (
+			id SERIAL PRIMARY KEY,
+			order_id INTEGER NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+			provider VARCHAR(50) NOT NULL,
+			provider_shipment_id VARCHAR(100) NOT NULL,
+			service VARCHAR(20) NOT NULL,
+			target_point VARCHAR(50),
+			status VARCHAR(50) NOT NULL,
+			tracking_number VARCHAR(100),
+			tracking_url TEXT,
+			created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (provider, provider_shipment_id)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_shipments_order_id ON shipments(order_id, created_at DESC);`,
+
+		// Shipping methods priced at checkout
+		`CREATE TABLE IF NOT EXISTS shipping_methods (
+			id SERIAL PRIMARY KEY,
+			name VARCHAR(100) NOT NULL,
+			description TEXT,
+			price DECIMAL(10,2) NOT NULL DEFAULT 0,
+			free_above DECIMAL(10,2),
+			active BOOLEAN NOT NULL DEFAULT true,
+			sort_order INTEGER NOT NULL DEFAULT 0,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		);`,
+
+		// Picking and packing progress of order items
+		`ALTER TABLE order_items ADD COLUMN IF NOT EXISTS fulfillment_status VARCHAR(20) NOT NULL DEFAULT 'pending'
+			CHECK (fulfillment_status IN ('pending', 'picked', 'packed', 'shipped'));`,
+		`ALTER TABLE order_items ADD COLUMN IF NOT EXISTS fulfillment_updated_at TIMESTAMP WITH TIME ZONE;`,
+
+		// Discount uses follow their order: pending until paid, released when it fails.
+		// Uses recorded before this count as confirmed.
+		`ALTER TABLE discount_code_usage ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'confirmed'
+			CHECK (status IN ('pending', 'confirmed', 'released'));`,
+		`ALTER TABLE discount_code_usage ADD COLUMN IF NOT EXISTS confirmed_at TIMESTAMP WITH TIME ZONE;`,
+		`ALTER TABLE discount_code_usage ADD COLUMN IF NOT EXISTS released_at TIMESTAMP WITH TIME ZONE;`,
+		`CREATE INDEX IF NOT EXISTS idx_discount_code_usage_pending ON discount_code_usage(created_at) WHERE status = 'pending';`,
+		`INSERT INTO site_settings (key, value, description) VALUES
+		('discount_usage_expiry_hours', '24', 'Hours after which the discount code use of an unpaid online order is released (0 keeps it)')
+		ON CONFLICT (key) DO NOTHING;`,
+
+		// Alt text and title of images, overridable where a product shows them
+		`ALTER TABLE images ADD COLUMN IF NOT EXISTS alt_text VARCHAR(255);`,
+		`ALTER TABLE images ADD COLUMN IF NOT EXISTS title VARCHAR(255);`,
+		`ALTER TABLE product_images ADD COLUMN IF NOT EXISTS alt_text VARCHAR(255);`,
+		`ALTER TABLE product_images ADD COLUMN IF NOT EXISTS title VARCHAR(255);`,
+
+		// Kinds of email each address receives, kept by address so guests can unsubscribe
+		`CREATE TABLE IF NOT EXISTS email_preferences (
+			email VARCHAR(255) PRIMARY KEY,
+			order_updates BOOLEAN NOT NULL DEFAULT true,
+			promotions BOOLEAN NOT NULL DEFAULT false,
+			back_in_stock BOOLEAN NOT NULL DEFAULT true,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		);`,
+
+		// Full-text search index of products, kept apart from products so reindexing
+		// does not touch their updated_at; triggers refresh the documents on changes
+		`CREATE TABLE IF NOT EXISTS product_search_index (
+			product_id INTEGER PRIMARY KEY REFERENCES products(id) ON DELETE CASCADE,
+			document TSVECTOR NOT NULL,
+			indexed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_product_search_index_document ON product_search_index USING GIN(document);`,
+		`CREATE OR REPLACE FUNCTION refresh_product_search(product_ids INTEGER[])
+		RETURNS void AS $$
+		BEGIN
+			INSERT INTO product_search_index (product_id, document, indexed_at)
+			SELECT p.id,
+				setweight(to_tsvector('simple', COALESCE(p.name, '')), 'A') ||
+				setweight(to_tsvector('simple', COALESCE(c.name, '') || ' ' || COALESCE(m.name, '') || ' ' ||
+					COALESCE((SELECT string_agg(t.tag, ' ') FROM product_tags t WHERE t.product_id = p.id), '')), 'B') ||
+				setweight(to_tsvector('simple', COALESCE(p.short_description, '')), 'C') ||
+				setweight(to_tsvector('simple', COALESCE(p.description, '')), 'D'),
+				CURRENT_TIMESTAMP
+			FROM products p
+			LEFT JOIN categories c ON c.id = p.category_id
+			LEFT JOIN materials m ON m.id = p.material_id
+			WHERE p.id = ANY(product_ids)
+			ON CONFLICT (product_id) DO UPDATE SET document = EXCLUDED.document, indexed_at = EXCLUDED.indexed_at;
+		END;
+		$$ language 'plpgsql';`,
+		`CREATE OR REPLACE FUNCTION refresh_product_search_trigger()
+		RETURNS TRIGGER AS $$
+		BEGIN
+			IF TG_TABLE_NAME = 'products' THEN
+				PERFORM refresh_product_search(ARRAY[NEW.id]);
+			ELSIF TG_TABLE_NAME = 'product_tags' THEN
+				IF TG_OP <> 'INSERT' THEN
+					PERFORM refresh_product_search(ARRAY[OLD.product_id]);
+				END IF;
+				IF TG_OP <> 'DELETE' THEN
+					PERFORM refresh_product_search(ARRAY[NEW.product_id]);
+				END IF;
+			ELSIF TG_TABLE_NAME = 'categories' THEN
+				PERFORM refresh_product_search(ARRAY(SELECT id FROM products WHERE category_id = NEW.id));
+			ELSIF TG_TABLE_NAME = 'materials' THEN
+				PERFORM refresh_product_search(ARRAY(SELECT id FROM products WHERE material_id = NEW.id));
+			END IF;
+			RETURN NULL;
+		END;
+		$$ language 'plpgsql';`,
+		`DROP TRIGGER IF EXISTS refresh_products_search ON products;`,
+		`CREATE TRIGGER refresh_products_search
+		AFTER INSERT OR UPDATE OF name, short_description, description, category_id, material_id ON products
+		FOR EACH ROW
+		EXECUTE FUNCTION refresh_product_search_trigger();`,
+		`DROP TRIGGER IF EXISTS refresh_product_tags_search ON product_tags;`,
+		`CREATE TRIGGER refresh_product_tags_search
+		AFTER INSERT OR UPDATE OR DELETE ON product_tags
+		FOR EACH ROW
+		EXECUTE FUNCTION refresh_product_search_trigger();`,
+		`DROP TRIGGER IF EXISTS refresh_categories_search ON categories;`,
+		`CREATE TRIGGER refresh_categories_search
+		AFTER UPDATE OF name ON categories
+		FOR EACH ROW
+		WHEN (OLD.name IS DISTINCT FROM NEW.name)
+		EXECUTE FUNCTION refresh_product_search_trigger();`,
+		`DROP TRIGGER IF EXISTS refresh_materials_search ON materials;`,
+		`CREATE TRIGGER refresh_materials_search
+		AFTER UPDATE OF name ON materials
+		FOR EACH ROW
+		WHEN (OLD.name IS DISTINCT FROM NEW.name)
+		EXECUTE FUNCTION refresh_product_search_trigger();`,
+		`SELECT refresh_product_search(ARRAY(SELECT id FROM products))
+		WHERE NOT EXISTS (SELECT 1 FROM product_search_index);`,
+
+		// Order board columns are pages of a status in creation order
+		`CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_orders_board ON orders(status, created_at, id) WHERE archived_at IS NULL;`,
+		`INSERT INTO site_settings (key, value, description) VALUES
+		('order_board_wip_limit_pending', '0', 'Pending orders the order board allows before flagging the column (0 for no limit)'),
+		('order_board_wip_limit_processing', '0', 'Orders in production the order board allows before flagging the column (0 for no limit)'),
+		('order_board_wip_limit_shipped', '0', 'Shipped orders awaiting delivery the order board allows before flagging the column (0 for no limit)')
+		ON CONFLICT (key) DO NOTHING;`,
+
+		// Addresses that hard bounced or complained; no email is sent to them
+		`CREATE TABLE IF NOT EXISTS email_suppressions (
+			email VARCHAR(255) PRIMARY KEY,
+			reason VARCHAR(20) NOT NULL CHECK (reason IN ('bounce', 'complaint')),
+			detail TEXT,
+			provider VARCHAR(50) NOT NULL,
+			events INTEGER NOT NULL DEFAULT 1,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		);`,
+
+		// Currencies prices can be shown in, with their rate in the base currency (PLN);
+		// orders keep the currency and rate they were placed with
+		`CREATE TABLE IF NOT EXISTS currencies (
+			code VARCHAR(3) PRIMARY KEY,
+			name VARCHAR(100) NOT NULL,
+			rate NUMERIC(12,6) NOT NULL CHECK (rate > 0),
+			enabled BOOLEAN NOT NULL DEFAULT FALSE,
+			source VARCHAR(20) NOT NULL DEFAULT 'manual',
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`INSERT INTO currencies (code, name, rate, enabled) VALUES ('PLN', 'Polish złoty', 1, TRUE)
+		ON CONFLICT (code) DO NOTHING;`,
+		`ALTER TABLE orders ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'PLN',
+		ADD COLUMN IF NOT EXISTS exchange_rate NUMERIC(12,6) NOT NULL DEFAULT 1;`,
+		`INSERT INTO site_settings (key, value, description) VALUES
+		('exchange_rates_auto_update', 'true', 'Update the rates of the currencies from NBP every day')
+		ON CONFLICT (key) DO NOTHING;`,
+
+		// Countries checkout address forms offer as shipping destinations
+		`INSERT INTO site_settings (key, value, description) VALUES
+		('shipping_countries', 'PL,DE,AT,CZ,SK,LT,FR,NL,IE,GB,US', 'Countries orders ship to, as comma separated ISO codes')
+		ON CONFLICT (key) DO NOTHING;`,
+
+		// Translations of catalog content, written in the first storefront language, to the others
+		`CREATE TABLE IF NOT EXISTS product_translations (
+			product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+			language VARCHAR(5) NOT NULL,
+			name VARCHAR(256) NOT NULL,
+			short_description VARCHAR(512) NOT NULL DEFAULT '',
+			description TEXT NOT NULL DEFAULT '',
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (product_id, language)
+		);`,
+		`CREATE TABLE IF NOT EXISTS category_translations (
+			category_id INTEGER NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+			language VARCHAR(5) NOT NULL,
+			name VARCHAR(256) NOT NULL,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (category_id, language)
+		);`,
+		`CREATE TABLE IF NOT EXISTS additional_service_translations (
+			service_id INTEGER NOT NULL REFERENCES additional_services(id) ON DELETE CASCADE,
+			language VARCHAR(5) NOT NULL,
+			name VARCHAR(256) NOT NULL,
+			description TEXT NOT NULL DEFAULT '',
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (service_id, language)
+		);`,
+
+		// Deleting a user deactivates the account; the system account takes over their images
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;`,
+		`INSERT INTO users (email, password_hash, role, deleted_at)
+		VALUES ('system@notsofluffy.invalid', '!', 'system', CURRENT_TIMESTAMP)
+		ON CONFLICT (email) DO NOTHING;`,
+		`ALTER TABLE images DROP CONSTRAINT IF EXISTS images_uploaded_by_fkey,
+		ADD CONSTRAINT images_uploaded_by_fkey FOREIGN KEY (uploaded_by) REFERENCES users(id) ON DELETE SET NULL NOT VALID;`,
+
+		// At most one default address per user and default variant per product. Extra
+		// defaults left by earlier races are cleared first, keeping the newest one.
+		`UPDATE user_addresses a SET is_default = FALSE
+		WHERE a.is_default AND EXISTS (
+			SELECT 1 FROM user_addresses b
+			WHERE b.user_id = a.user_id AND b.is_default AND b.id > a.id
+		);`,
+		`CREATE UNIQUE INDEX CONCURRENTLY IF NOT EXISTS idx_user_addresses_single_default ON user_addresses(user_id) WHERE is_default;`,
+		`UPDATE product_variants a SET is_default = FALSE
+		WHERE a.is_default AND EXISTS (
+			SELECT 1 FROM product_variants b
+			WHERE b.product_id = a.product_id AND b.is_default AND b.id > a.id
+		);`,
+		`CREATE UNIQUE INDEX CONCURRENTLY IF NOT EXISTS idx_product_variants_single_default ON product_variants(product_id) WHERE is_default;`,
+
+		// The shop's own order statuses, each refining a core status
+		`CREATE TABLE IF NOT EXISTS order_statuses (
+			id SERIAL PRIMARY KEY,
+			code VARCHAR(64) NOT NULL UNIQUE,
+			label VARCHAR(100) NOT NULL,
+			public_label VARCHAR(100) NOT NULL DEFAULT '',
+			color VARCHAR(7) NOT NULL DEFAULT '#6b7280',
+			core_status VARCHAR(20) NOT NULL CHECK (core_status IN ('pending', 'processing', 'shipped', 'delivered', 'cancelled')),
+			sort_order INTEGER NOT NULL DEFAULT 0,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`ALTER TABLE orders ADD COLUMN IF NOT EXISTS custom_status_id INTEGER REFERENCES order_statuses(id) ON DELETE SET NULL;`,
+
+		// Last TOTP time step used for sudo mode, so a code cannot be replayed
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_last_step BIGINT;`,
+
+		// Signing out everywhere bumps the token version embedded in the user's tokens
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS token_version INTEGER NOT NULL DEFAULT 0;`,
+		`-- contract: the token version replaced the sessions revoked time
+		ALTER TABLE users DROP COLUMN IF EXISTS sessions_revoked_at;`,
+
+		// Customer returns of shipped orders and their return labels
+		`CREATE TABLE IF NOT EXISTS order_returns (
+			id SERIAL PRIMARY KEY,
+			order_id INTEGER NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+			status VARCHAR(20) NOT NULL DEFAULT 'requested',
+			reason TEXT,
+			admin_note TEXT,
+			label_type VARCHAR(20),
+			provider VARCHAR(50),
+			provider_shipment_id VARCHAR(100),
+			carrier_status VARCHAR(50),
+			tracking_number VARCHAR(100),
+			tracking_url TEXT,
+			label_sent_at TIMESTAMP WITH TIME ZONE,
+			reviewed_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+			reviewed_at TIMESTAMP WITH TIME ZONE,
+			received_at TIMESTAMP WITH TIME ZONE,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_order_returns_order_id ON order_returns(order_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_order_returns_status ON order_returns(status);`,
+		`CREATE TABLE IF NOT EXISTS order_return_items (
+			id SERIAL PRIMARY KEY,
+			return_id INTEGER NOT NULL REFERENCES order_returns(id) ON DELETE CASCADE,
+			order_item_id INTEGER NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+			quantity INTEGER NOT NULL CHECK (quantity > 0)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_order_return_items_return_id ON order_return_items(return_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_order_return_items_order_item_id ON order_return_items(order_item_id);`,
+		`INSERT INTO site_settings (key, value, description) VALUES
+			('return_target_point', '', 'InPost parcel locker returns are shipped to; empty sends customers return instructions instead of a carrier label'),
+			('return_receiver_email', '', 'Email of the receiver of return shipments'),
+			('return_receiver_phone', '', 'Phone of the receiver of return shipments')
+		ON CONFLICT (key) DO NOTHING;`,
+	}
+}
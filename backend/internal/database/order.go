@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"notsofluffy-backend/internal/models"
+	"notsofluffy-backend/internal/pricing"
 )
 
 type OrderQueries struct {
@@ -36,6 +37,11 @@ func (q *OrderQueries) CreateOrder(order *models.Order, shippingAddr *models.Shi
 	}
 	defer tx.Rollback()
 
+	// The amounts were computed by the checkout; refuse to store any that don't add up
+	if err := pricing.VerifyOrder(order, items); err != nil {
+		return nil, fmt.Errorf("order totals are inconsistent: %w", err)
+	}
+
 	// Generate public hash for guest order access
 	publicHash, err := generatePublicHash()
 	if err != nil {
@@ -43,17 +49,38 @@ func (q *OrderQueries) CreateOrder(order *models.Order, shippingAddr *models.Shi
 	}
 	order.PublicHash = &publicHash
 
+	if order.DiscountCodeID != nil {
+		if err := claimDiscount(tx, q.db, order); err != nil {
+			return nil, err
+		}
+	}
+
+	productionSlots, err := bookProduction(tx, q.db, order)
+	if err != nil {
+		return nil, err
+	}
+
 	// Insert order
 	orderQuery := `
-		INSERT INTO orders (user_id, session_id, public_hash, email, phone, status, total_amount, subtotal, shipping_cost, tax_amount, discount_code_id, discount_amount, discount_description, payment_method, payment_status, notes, requires_invoice, nip)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+		INSERT INTO orders (user_id, session_id, public_hash, email, phone, status, total_amount, subtotal, shipping_cost, tax_amount, discount_code_id, discount_amount, discount_description, payment_method, payment_status, notes, requires_invoice, nip, origin_country, split_shipment, lead_time_days, is_test, shipping_breakdown, shop_id, currency, exchange_rate)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26)
 		RETURNING id, created_at, updated_at`
 	
-	err = tx.QueryRow(orderQuery, order.UserID, order.SessionID, order.PublicHash, order.Email, order.Phone, order.Status, order.TotalAmount, order.Subtotal, order.ShippingCost, order.TaxAmount, order.DiscountCodeID, order.DiscountAmount, order.DiscountDescription, order.PaymentMethod, order.PaymentStatus, order.Notes, order.RequiresInvoice, order.NIP).Scan(&order.ID, &order.CreatedAt, &order.UpdatedAt)
+	err = tx.QueryRow(orderQuery, order.UserID, order.SessionID, order.PublicHash, order.Email, order.Phone, order.Status, order.TotalAmount, order.Subtotal, order.ShippingCost, order.TaxAmount, order.DiscountCodeID, order.DiscountAmount, order.DiscountDescription, order.PaymentMethod, order.PaymentStatus, order.Notes, order.RequiresInvoice, order.NIP, order.OriginCountry, order.SplitShipment, order.LeadTimeDays, order.IsTest, shippingBreakdownJSON(order.ShippingBreakdown), shopID(order.ShopID), orderCurrency(order), orderExchangeRate(order)).Scan(&order.ID, &order.CreatedAt, &order.UpdatedAt)
 	if err != nil {
 		return nil, fmt.Errorf("failed to insert order: %w", err)
 	}
 
+	if err := insertProductionSlots(tx, order.ID, productionSlots); err != nil {
+		return nil, err
+	}
+
+	if order.DiscountCodeID != nil {
+		if err := recordOrderDiscount(tx, order); err != nil {
+			return nil, err
+		}
+	}
+
 	// Insert shipping address
 	shippingQuery := `
 		INSERT INTO shipping_addresses (order_id, first_name, last_name, company, address_line1, address_line2, city, state_province, postal_code, country, phone)
@@ -116,6 +143,17 @@ func (q *OrderQueries) CreateOrder(order *models.Order, shippingAddr *models.Shi
 			}
 			service.OrderItemID = item.ID
 		}
+
+		// Insert the chosen option values
+		for _, option := range item.Options {
+			_, err = tx.Exec(`
+				INSERT INTO order_item_options (order_item_id, option_group_id, option_value_id, group_name, value_name, surcharge)
+				VALUES ($1, $2, $3, $4, $5, $6)`,
+				item.ID, option.OptionGroupID, option.OptionValueID, option.GroupName, option.ValueName, option.Surcharge)
+			if err != nil {
+				return nil, fmt.Errorf("failed to insert order item option: %w", err)
+			}
+		}
 	}
 
 	// Commit transaction
@@ -144,6 +182,13 @@ func (q *OrderQueries) CreateOrder(order *models.Order, shippingAddr *models.Shi
 		Notes:              order.Notes,
 		RequiresInvoice:    order.RequiresInvoice,
 		NIP:                order.NIP,
+		OriginCountry:      order.OriginCountry,
+		SplitShipment:      order.SplitShipment,
+		LeadTimeDays:       order.LeadTimeDays,
+		Currency:           orderCurrency(order),
+		ExchangeRate:       orderExchangeRate(order),
+		IsTest:             order.IsTest,
+		ShippingBreakdown:  order.ShippingBreakdown,
 		ShippingAddress:    shippingAddr,
 		BillingAddress:     billingAddr,
 		Items:              items,
@@ -152,16 +197,59 @@ func (q *OrderQueries) CreateOrder(order *models.Order, shippingAddr *models.Shi
 	}, nil
 }
 
+// orderCurrency returns the currency an order is shown in, the base currency unless
+// checkout set another
+func orderCurrency(order *models.Order) string {
+	if order.Currency == "" {
+		return models.PaymentCurrency
+	}
+	return order.Currency
+}
+
+// orderExchangeRate returns the rate locked on an order, 1 for the base currency
+func orderExchangeRate(order *models.Order) float64 {
+	if order.ExchangeRate <= 0 || orderCurrency(order) == models.PaymentCurrency {
+		return 1
+	}
+	return order.ExchangeRate
+}
+
+// shippingBreakdownJSON encodes the shipping breakdown stored on an order
+func shippingBreakdownJSON(breakdown *models.ShippingBreakdown) interface{} {
+	if breakdown == nil {
+		return nil
+	}
+	data, err := json.Marshal(breakdown)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// parseShippingBreakdown decodes the shipping breakdown of an order; orders placed
+// before it was recorded have none
+func parseShippingBreakdown(data []byte) *models.ShippingBreakdown {
+	if len(data) == 0 {
+		return nil
+	}
+	var breakdown models.ShippingBreakdown
+	if err := json.Unmarshal(data, &breakdown); err != nil {
+		return nil
+	}
+	return &breakdown
+}
+
 // GetOrderByID retrieves an order by ID with all related data
 func (q *OrderQueries) GetOrderByID(id int) (*models.OrderResponse, error) {
 	// Get order
 	orderQuery := `
-		SELECT id, user_id, session_id, public_hash, email, phone, status, total_amount, subtotal, shipping_cost, tax_amount, discount_code_id, discount_amount, discount_description, payment_method, payment_status, notes, requires_invoice, nip, created_at, updated_at
+		SELECT id, user_id, session_id, public_hash, email, phone, status, `+orderCustomStatusColumns+`, total_amount, subtotal, shipping_cost, tax_amount, discount_code_id, discount_amount, discount_description, payment_method, payment_status, notes, requires_invoice, nip, origin_country, split_shipment, lead_time_days, currency, exchange_rate, is_test, shipping_breakdown, tracking_carrier, tracking_number, shipped_at, archived_at, created_at, updated_at
 		FROM orders
 		WHERE id = $1`
 	
 	var order models.Order
-	err := q.db.QueryRow(orderQuery, id).Scan(&order.ID, &order.UserID, &order.SessionID, &order.PublicHash, &order.Email, &order.Phone, &order.Status, &order.TotalAmount, &order.Subtotal, &order.ShippingCost, &order.TaxAmount, &order.DiscountCodeID, &order.DiscountAmount, &order.DiscountDescription, &order.PaymentMethod, &order.PaymentStatus, &order.Notes, &order.RequiresInvoice, &order.NIP, &order.CreatedAt, &order.UpdatedAt)
+	var shippingBreakdown []byte
+	err := q.db.QueryRow(orderQuery, id).Scan(&order.ID, &order.UserID, &order.SessionID, &order.PublicHash, &order.Email, &order.Phone, &order.Status, &order.CustomStatusID, &order.StatusLabel, &order.TotalAmount, &order.Subtotal, &order.ShippingCost, &order.TaxAmount, &order.DiscountCodeID, &order.DiscountAmount, &order.DiscountDescription, &order.PaymentMethod, &order.PaymentStatus, &order.Notes, &order.RequiresInvoice, &order.NIP, &order.OriginCountry, &order.SplitShipment, &order.LeadTimeDays, &order.Currency, &order.ExchangeRate, &order.IsTest, &shippingBreakdown, &order.TrackingCarrier, &order.TrackingNumber, &order.ShippedAt, &order.ArchivedAt, &order.CreatedAt, &order.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("order not found")
@@ -197,7 +285,7 @@ func (q *OrderQueries) GetOrderByID(id int) (*models.OrderResponse, error) {
 
 	// Get order items with product images
 	itemsQuery := `
-		SELECT oi.id, oi.product_id, oi.product_name, oi.product_description, oi.variant_id, oi.variant_name, oi.variant_color_name, oi.variant_color_custom, oi.size_id, oi.size_name, oi.size_dimensions, oi.quantity, oi.unit_price, oi.total_price, oi.created_at,
+		SELECT oi.id, oi.product_id, oi.product_name, oi.product_description, oi.variant_id, oi.variant_name, oi.variant_color_name, oi.variant_color_custom, oi.size_id, oi.size_name, oi.size_dimensions, oi.quantity, oi.unit_price, oi.total_price, oi.created_at, oi.fulfillment_status,
 		       mi.id as main_image_id, mi.filename as main_image_filename, mi.original_name as main_image_original_name, mi.path as main_image_path, mi.size_bytes as main_image_size_bytes, mi.mime_type as main_image_mime_type, mi.uploaded_by as main_image_uploaded_by, mi.created_at as main_image_created_at, mi.updated_at as main_image_updated_at
 		FROM order_items oi
 		LEFT JOIN products p ON oi.product_id = p.id
@@ -221,7 +309,7 @@ func (q *OrderQueries) GetOrderByID(id int) (*models.OrderResponse, error) {
 		var mainImageUploadedBy sql.NullInt64
 		var mainImageCreatedAt, mainImageUpdatedAt sql.NullTime
 		
-		err := rows.Scan(&item.ID, &item.ProductID, &item.ProductName, &item.ProductDescription, &item.VariantID, &item.VariantName, &item.VariantColorName, &item.VariantColorCustom, &item.SizeID, &item.SizeName, &dimensionsJSON, &item.Quantity, &item.UnitPrice, &item.TotalPrice, &item.CreatedAt,
+		err := rows.Scan(&item.ID, &item.ProductID, &item.ProductName, &item.ProductDescription, &item.VariantID, &item.VariantName, &item.VariantColorName, &item.VariantColorCustom, &item.SizeID, &item.SizeName, &dimensionsJSON, &item.Quantity, &item.UnitPrice, &item.TotalPrice, &item.CreatedAt, &item.FulfillmentStatus,
 			&mainImageID, &mainImageFilename, &mainImageOriginalName, &mainImagePath, &mainImageSizeBytes, &mainImageMimeType, &mainImageUploadedBy, &mainImageCreatedAt, &mainImageUpdatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan order item: %w", err)
@@ -279,6 +367,25 @@ func (q *OrderQueries) GetOrderByID(id int) (*models.OrderResponse, error) {
 			services = append(services, service)
 		}
 		items[i].Services = services
+
+		options, err := q.getOrderItemOptions(items[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		items[i].Options = options
+	}
+
+	// Archived orders keep their items in order_archives
+	if order.ArchivedAt != nil {
+		items, err = q.getArchivedOrderItems(order.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	refundedAmount, err := NewRefundQueries(q.db).GetRefundedAmount(order.ID)
+	if err != nil {
+		return nil, err
 	}
 
 	return &models.OrderResponse{
@@ -289,6 +396,8 @@ func (q *OrderQueries) GetOrderByID(id int) (*models.OrderResponse, error) {
 		Email:              order.Email,
 		Phone:              order.Phone,
 		Status:             order.Status,
+		CustomStatusID:     order.CustomStatusID,
+		StatusLabel:        order.StatusLabel,
 		TotalAmount:        order.TotalAmount,
 		Subtotal:           order.Subtotal,
 		ShippingCost:       order.ShippingCost,
@@ -301,6 +410,18 @@ func (q *OrderQueries) GetOrderByID(id int) (*models.OrderResponse, error) {
 		Notes:              order.Notes,
 		RequiresInvoice:    order.RequiresInvoice,
 		NIP:                order.NIP,
+		RefundedAmount:     refundedAmount,
+		OriginCountry:      order.OriginCountry,
+		SplitShipment:      order.SplitShipment,
+		LeadTimeDays:       order.LeadTimeDays,
+		Currency:           order.Currency,
+		ExchangeRate:       order.ExchangeRate,
+		IsTest:             order.IsTest,
+		ShippingBreakdown:  parseShippingBreakdown(shippingBreakdown),
+		TrackingCarrier:    order.TrackingCarrier,
+		TrackingNumber:     order.TrackingNumber,
+		ShippedAt:          order.ShippedAt,
+		ArchivedAt:         order.ArchivedAt,
 		ShippingAddress:    &shippingAddr,
 		BillingAddress:     &billingAddr,
 		Items:              items,
@@ -313,12 +434,13 @@ func (q *OrderQueries) GetOrderByID(id int) (*models.OrderResponse, error) {
 func (q *OrderQueries) GetOrderByHash(hash string) (*models.OrderResponse, error) {
 	// Get order
 	orderQuery := `
-		SELECT id, user_id, session_id, public_hash, email, phone, status, total_amount, subtotal, shipping_cost, tax_amount, discount_code_id, discount_amount, discount_description, payment_method, payment_status, notes, requires_invoice, nip, created_at, updated_at
+		SELECT id, user_id, session_id, public_hash, email, phone, status, `+orderCustomStatusColumns+`, total_amount, subtotal, shipping_cost, tax_amount, discount_code_id, discount_amount, discount_description, payment_method, payment_status, notes, requires_invoice, nip, split_shipment, lead_time_days, currency, exchange_rate, is_test, shipping_breakdown, tracking_carrier, tracking_number, shipped_at, archived_at, created_at, updated_at
 		FROM orders
 		WHERE public_hash = $1`
 	
 	var order models.Order
-	err := q.db.QueryRow(orderQuery, hash).Scan(&order.ID, &order.UserID, &order.SessionID, &order.PublicHash, &order.Email, &order.Phone, &order.Status, &order.TotalAmount, &order.Subtotal, &order.ShippingCost, &order.TaxAmount, &order.DiscountCodeID, &order.DiscountAmount, &order.DiscountDescription, &order.PaymentMethod, &order.PaymentStatus, &order.Notes, &order.RequiresInvoice, &order.NIP, &order.CreatedAt, &order.UpdatedAt)
+	var shippingBreakdown []byte
+	err := q.db.QueryRow(orderQuery, hash).Scan(&order.ID, &order.UserID, &order.SessionID, &order.PublicHash, &order.Email, &order.Phone, &order.Status, &order.CustomStatusID, &order.StatusLabel, &order.TotalAmount, &order.Subtotal, &order.ShippingCost, &order.TaxAmount, &order.DiscountCodeID, &order.DiscountAmount, &order.DiscountDescription, &order.PaymentMethod, &order.PaymentStatus, &order.Notes, &order.RequiresInvoice, &order.NIP, &order.SplitShipment, &order.LeadTimeDays, &order.Currency, &order.ExchangeRate, &order.IsTest, &shippingBreakdown, &order.TrackingCarrier, &order.TrackingNumber, &order.ShippedAt, &order.ArchivedAt, &order.CreatedAt, &order.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("order not found")
@@ -354,7 +476,7 @@ func (q *OrderQueries) GetOrderByHash(hash string) (*models.OrderResponse, error
 
 	// Get order items with product images
 	itemsQuery := `
-		SELECT oi.id, oi.product_id, oi.product_name, oi.product_description, oi.variant_id, oi.variant_name, oi.variant_color_name, oi.variant_color_custom, oi.size_id, oi.size_name, oi.size_dimensions, oi.quantity, oi.unit_price, oi.total_price, oi.created_at,
+		SELECT oi.id, oi.product_id, oi.product_name, oi.product_description, oi.variant_id, oi.variant_name, oi.variant_color_name, oi.variant_color_custom, oi.size_id, oi.size_name, oi.size_dimensions, oi.quantity, oi.unit_price, oi.total_price, oi.created_at, oi.fulfillment_status,
 		       mi.id as main_image_id, mi.filename as main_image_filename, mi.original_name as main_image_original_name, mi.path as main_image_path, mi.size_bytes as main_image_size_bytes, mi.mime_type as main_image_mime_type, mi.uploaded_by as main_image_uploaded_by, mi.created_at as main_image_created_at, mi.updated_at as main_image_updated_at
 		FROM order_items oi
 		LEFT JOIN products p ON oi.product_id = p.id
@@ -378,7 +500,7 @@ func (q *OrderQueries) GetOrderByHash(hash string) (*models.OrderResponse, error
 		var mainImageUploadedBy sql.NullInt64
 		var mainImageCreatedAt, mainImageUpdatedAt sql.NullTime
 		
-		err := rows.Scan(&item.ID, &item.ProductID, &item.ProductName, &item.ProductDescription, &item.VariantID, &item.VariantName, &item.VariantColorName, &item.VariantColorCustom, &item.SizeID, &item.SizeName, &dimensionsJSON, &item.Quantity, &item.UnitPrice, &item.TotalPrice, &item.CreatedAt,
+		err := rows.Scan(&item.ID, &item.ProductID, &item.ProductName, &item.ProductDescription, &item.VariantID, &item.VariantName, &item.VariantColorName, &item.VariantColorCustom, &item.SizeID, &item.SizeName, &dimensionsJSON, &item.Quantity, &item.UnitPrice, &item.TotalPrice, &item.CreatedAt, &item.FulfillmentStatus,
 			&mainImageID, &mainImageFilename, &mainImageOriginalName, &mainImagePath, &mainImageSizeBytes, &mainImageMimeType, &mainImageUploadedBy, &mainImageCreatedAt, &mainImageUpdatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan order item: %w", err)
@@ -436,6 +558,25 @@ func (q *OrderQueries) GetOrderByHash(hash string) (*models.OrderResponse, error
 			services = append(services, service)
 		}
 		items[i].Services = services
+
+		options, err := q.getOrderItemOptions(items[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		items[i].Options = options
+	}
+
+	// Archived orders keep their items in order_archives
+	if order.ArchivedAt != nil {
+		items, err = q.getArchivedOrderItems(order.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	refundedAmount, err := NewRefundQueries(q.db).GetRefundedAmount(order.ID)
+	if err != nil {
+		return nil, err
 	}
 
 	return &models.OrderResponse{
@@ -446,6 +587,8 @@ func (q *OrderQueries) GetOrderByHash(hash string) (*models.OrderResponse, error
 		Email:              order.Email,
 		Phone:              order.Phone,
 		Status:             order.Status,
+		CustomStatusID:     order.CustomStatusID,
+		StatusLabel:        order.StatusLabel,
 		TotalAmount:        order.TotalAmount,
 		Subtotal:           order.Subtotal,
 		ShippingCost:       order.ShippingCost,
@@ -458,6 +601,17 @@ func (q *OrderQueries) GetOrderByHash(hash string) (*models.OrderResponse, error
 		Notes:              order.Notes,
 		RequiresInvoice:    order.RequiresInvoice,
 		NIP:                order.NIP,
+		SplitShipment:      order.SplitShipment,
+		LeadTimeDays:       order.LeadTimeDays,
+		Currency:           order.Currency,
+		ExchangeRate:       order.ExchangeRate,
+		IsTest:             order.IsTest,
+		ShippingBreakdown:  parseShippingBreakdown(shippingBreakdown),
+		TrackingCarrier:    order.TrackingCarrier,
+		TrackingNumber:     order.TrackingNumber,
+		ShippedAt:          order.ShippedAt,
+		ArchivedAt:         order.ArchivedAt,
+		RefundedAmount:     refundedAmount,
 		ShippingAddress:    &shippingAddr,
 		BillingAddress:     &billingAddr,
 		Items:              items,
@@ -466,8 +620,10 @@ func (q *OrderQueries) GetOrderByHash(hash string) (*models.OrderResponse, error
 	}, nil
 }
 
-// ListOrders retrieves orders with pagination and filtering
-func (q *OrderQueries) ListOrders(page, limit int, userID *int, email, status string) (*models.OrderListResponse, error) {
+// ListOrders retrieves orders with pagination and filtering; archived orders are left
+// out unless includeArchived is set. With after set, the page starts after that cursor
+// and page is ignored.
+func (q *OrderQueries) ListOrders(page, limit int, after *models.Cursor, userID *int, email, status string, includeArchived bool) (*models.OrderListResponse, error) {
 	offset := (page - 1) * limit
 	
 	var conditions []string
@@ -492,6 +648,10 @@ func (q *OrderQueries) ListOrders(page, limit int, userID *int, email, status st
 		argIndex++
 	}
 
+	if !includeArchived {
+		conditions = append(conditions, "archived_at IS NULL")
+	}
+
 	whereClause := ""
 	if len(conditions) > 0 {
 		whereClause = "WHERE " + strings.Join(conditions, " AND ")
@@ -505,12 +665,25 @@ func (q *OrderQueries) ListOrders(page, limit int, userID *int, email, status st
 		return nil, fmt.Errorf("failed to count orders: %w", err)
 	}
 
+	// A cursor continues the list after its order instead of skipping rows
+	if after != nil {
+		value, err := cursorValue(after)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, keysetCondition("created_at", "id", argIndex))
+		args = append(args, value, after.ID)
+		argIndex += 2
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+		offset = 0
+	}
+
 	// Get orders
 	ordersQuery := fmt.Sprintf(`
-		SELECT id, user_id, session_id, email, phone, status, total_amount, subtotal, shipping_cost, tax_amount, payment_method, payment_status, notes, requires_invoice, nip, created_at, updated_at
+		SELECT id, user_id, session_id, email, phone, status, `+orderCustomStatusColumns+`, total_amount, subtotal, shipping_cost, tax_amount, payment_method, payment_status, notes, requires_invoice, nip, currency, exchange_rate, is_test, archived_at, created_at, updated_at
 		FROM orders
 		%s
-		ORDER BY created_at DESC
+		ORDER BY created_at DESC, id DESC
 		LIMIT $%d OFFSET $%d`, whereClause, argIndex, argIndex+1)
 	
 	args = append(args, limit, offset)
@@ -524,7 +697,7 @@ func (q *OrderQueries) ListOrders(page, limit int, userID *int, email, status st
 	var orders []models.OrderResponse
 	for rows.Next() {
 		var order models.Order
-		err := rows.Scan(&order.ID, &order.UserID, &order.SessionID, &order.Email, &order.Phone, &order.Status, &order.TotalAmount, &order.Subtotal, &order.ShippingCost, &order.TaxAmount, &order.PaymentMethod, &order.PaymentStatus, &order.Notes, &order.RequiresInvoice, &order.NIP, &order.CreatedAt, &order.UpdatedAt)
+		err := rows.Scan(&order.ID, &order.UserID, &order.SessionID, &order.Email, &order.Phone, &order.Status, &order.CustomStatusID, &order.StatusLabel, &order.TotalAmount, &order.Subtotal, &order.ShippingCost, &order.TaxAmount, &order.PaymentMethod, &order.PaymentStatus, &order.Notes, &order.RequiresInvoice, &order.NIP, &order.Currency, &order.ExchangeRate, &order.IsTest, &order.ArchivedAt, &order.CreatedAt, &order.UpdatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan order: %w", err)
 		}
@@ -536,6 +709,8 @@ func (q *OrderQueries) ListOrders(page, limit int, userID *int, email, status st
 			Email:           order.Email,
 			Phone:           order.Phone,
 			Status:          order.Status,
+			CustomStatusID:  order.CustomStatusID,
+			StatusLabel:     order.StatusLabel,
 			TotalAmount:     order.TotalAmount,
 			Subtotal:        order.Subtotal,
 			ShippingCost:    order.ShippingCost,
@@ -545,6 +720,10 @@ func (q *OrderQueries) ListOrders(page, limit int, userID *int, email, status st
 			Notes:           order.Notes,
 			RequiresInvoice: order.RequiresInvoice,
 			NIP:             order.NIP,
+			Currency:        order.Currency,
+			ExchangeRate:    order.ExchangeRate,
+			IsTest:          order.IsTest,
+			ArchivedAt:      order.ArchivedAt,
 			CreatedAt:       order.CreatedAt,
 			UpdatedAt:       order.UpdatedAt,
 		})
@@ -555,55 +734,151 @@ func (q *OrderQueries) ListOrders(page, limit int, userID *int, email, status st
 		Total:  total,
 		Page:   page,
 		Limit:  limit,
+		NextCursor: nextCursor(len(orders), limit, func() string {
+			last := orders[len(orders)-1]
+			return timeCursor(last.CreatedAt, last.ID)
+		}),
 	}, nil
 }
 
-// UpdateOrderStatus updates an order's status
-func (q *OrderQueries) UpdateOrderStatus(id int, status string) error {
-	query := `UPDATE orders SET status = $1 WHERE id = $2`
-	result, err := q.db.Exec(query, status, id)
+// UpdateOrderStatus updates an order's status and returns the change. customStatusID
+// sets the custom status refining it; nil clears the order's custom status.
+func (q *OrderQueries) UpdateOrderStatus(id int, status string, customStatusID *int) (*models.OrderStatusChangedEvent, error) {
+	tx, err := q.db.Begin()
 	if err != nil {
-		return fmt.Errorf("failed to update order status: %w", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	change, err := updateOrderStatus(tx, id, status, customStatusID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return change, nil
+}
+
+// BulkUpdateOrderStatus sets the status of orders in one transaction. It returns the
+// changes of the updated orders and the errors of the orders left unchanged.
+func (q *OrderQueries) BulkUpdateOrderStatus(ids []int, status string, customStatusID *int) ([]*models.OrderStatusChangedEvent, map[int]error, error) {
+	var changes []*models.OrderStatusChangedEvent
+	failed, err := runBulk(q.db, ids, func(tx *sql.Tx, id int) error {
+		change, err := updateOrderStatus(tx, id, status, customStatusID)
+		if err != nil {
+			return err
+		}
+		changes = append(changes, change)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return changes, failed, nil
+}
+
+// updateOrderStatus is UpdateOrderStatus within a transaction
+func updateOrderStatus(tx *sql.Tx, id int, status string, customStatusID *int) (*models.OrderStatusChangedEvent, error) {
+	// Cancelling an order frees the production capacity booked for it
+	query := `
+		WITH released AS (
+			DELETE FROM production_slots WHERE order_id = $2 AND $1 = $3
+		)
+		UPDATE orders o SET status = $1, custom_status_id = $4
+		FROM (SELECT id, status, custom_status_id FROM orders WHERE id = $2 FOR UPDATE) previous
+		WHERE o.id = previous.id
+		RETURNING o.id, o.email, previous.status, o.status, o.is_test,
+		 COALESCE((SELECT code FROM order_statuses WHERE id = previous.custom_status_id), ''),
+		 COALESCE((SELECT code FROM order_statuses WHERE id = o.custom_status_id), '')`
+	change := &models.OrderStatusChangedEvent{}
+	err := tx.QueryRow(query, status, id, models.OrderStatusCancelled, customStatusID).Scan(&change.OrderID, &change.Email, &change.PreviousStatus, &change.Status, &change.IsTest, &change.PreviousCustomStatus, &change.CustomStatus)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("order not found")
+		}
+		return nil, fmt.Errorf("failed to update order status: %w", err)
+	}
+
+	// and lets its discount code be used again
+	if change.Status == models.OrderStatusCancelled {
+		if _, err := releaseOrderDiscounts(tx, []int{id}); err != nil {
+			return nil, err
+		}
+	}
+	return change, nil
+}
+
+// SetOrderTest marks or unmarks an order as a test order
+func (q *OrderQueries) SetOrderTest(id int, isTest bool) error {
+	result, err := q.db.Exec(`UPDATE orders SET is_test = $1 WHERE id = $2`, isTest, id)
+	if err != nil {
+		return fmt.Errorf("failed to update order: %w", err)
 	}
-	
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
 		return fmt.Errorf("failed to get rows affected: %w", err)
 	}
-	
 	if rowsAffected == 0 {
 		return fmt.Errorf("order not found")
 	}
-	
 	return nil
 }
 
 // GetOrdersByUserID retrieves orders for a specific user
 func (q *OrderQueries) GetOrdersByUserID(userID int, page, limit int) (*models.OrderListResponse, error) {
-	return q.ListOrders(page, limit, &userID, "", "")
+	return q.ListOrders(page, limit, nil, &userID, "", "", false)
+}
+
+// orderHistoryFilter returns the WHERE clause and arguments selecting a user's orders
+// matching filter
+func orderHistoryFilter(userID int, filter models.OrderHistoryFilter) (string, []interface{}) {
+	conditions := []string{"user_id = $1", "archived_at IS NULL"}
+	args := []interface{}{userID}
+
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		conditions = append(conditions, fmt.Sprintf("created_at < $%d", len(args)))
+	}
+	if filter.Search != "" {
+		args = append(args, "%"+filter.Search+"%")
+		conditions = append(conditions, fmt.Sprintf("EXISTS (SELECT 1 FROM order_items si WHERE si.order_id = orders.id AND si.product_name ILIKE $%d)", len(args)))
+	}
+
+	return "WHERE " + strings.Join(conditions, " AND "), args
 }
 
 // GetOrdersByUserIDWithItems retrieves orders for a specific user with full order items, addresses and services
-func (q *OrderQueries) GetOrdersByUserIDWithItems(userID int, page, limit int) (*models.OrderListResponse, error) {
+func (q *OrderQueries) GetOrdersByUserIDWithItems(userID int, filter models.OrderHistoryFilter, page, limit int) (*models.OrderListResponse, error) {
 	offset := (page - 1) * limit
+	whereClause, args := orderHistoryFilter(userID, filter)
 	
 	// Count total orders for the user
-	countQuery := "SELECT COUNT(*) FROM orders WHERE user_id = $1"
+	countQuery := "SELECT COUNT(*) FROM orders " + whereClause
 	var total int
-	err := q.db.QueryRow(countQuery, userID).Scan(&total)
+	err := q.db.QueryRow(countQuery, args...).Scan(&total)
 	if err != nil {
 		return nil, fmt.Errorf("failed to count orders: %w", err)
 	}
 
 	// Get basic order information with pagination
-	ordersQuery := `
-		SELECT id, user_id, session_id, email, phone, status, total_amount, subtotal, shipping_cost, tax_amount, payment_method, payment_status, notes, requires_invoice, nip, created_at, updated_at
+	ordersQuery := fmt.Sprintf(`
+		SELECT id, user_id, session_id, email, phone, status, `+orderCustomStatusColumns+`, total_amount, subtotal, shipping_cost, tax_amount, payment_method, payment_status, notes, requires_invoice, nip, currency, exchange_rate, created_at, updated_at
 		FROM orders
-		WHERE user_id = $1
+		%s
 		ORDER BY created_at DESC
-		LIMIT $2 OFFSET $3`
+		LIMIT $%d OFFSET $%d`, whereClause, len(args)+1, len(args)+2)
 	
-	rows, err := q.db.Query(ordersQuery, userID, limit, offset)
+	rows, err := q.db.Query(ordersQuery, append(args, limit, offset)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get orders: %w", err)
 	}
@@ -612,7 +887,7 @@ func (q *OrderQueries) GetOrdersByUserIDWithItems(userID int, page, limit int) (
 	var orders []models.OrderResponse
 	for rows.Next() {
 		var order models.Order
-		err := rows.Scan(&order.ID, &order.UserID, &order.SessionID, &order.Email, &order.Phone, &order.Status, &order.TotalAmount, &order.Subtotal, &order.ShippingCost, &order.TaxAmount, &order.PaymentMethod, &order.PaymentStatus, &order.Notes, &order.RequiresInvoice, &order.NIP, &order.CreatedAt, &order.UpdatedAt)
+		err := rows.Scan(&order.ID, &order.UserID, &order.SessionID, &order.Email, &order.Phone, &order.Status, &order.CustomStatusID, &order.StatusLabel, &order.TotalAmount, &order.Subtotal, &order.ShippingCost, &order.TaxAmount, &order.PaymentMethod, &order.PaymentStatus, &order.Notes, &order.RequiresInvoice, &order.NIP, &order.Currency, &order.ExchangeRate, &order.CreatedAt, &order.UpdatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan order: %w", err)
 		}
@@ -651,7 +926,7 @@ func (q *OrderQueries) GetOrdersByUserIDWithItems(userID int, page, limit int) (
 
 		// Get order items for this order with product images
 		itemsQuery := `
-			SELECT oi.id, oi.product_id, oi.product_name, oi.product_description, oi.variant_id, oi.variant_name, oi.variant_color_name, oi.variant_color_custom, oi.size_id, oi.size_name, oi.size_dimensions, oi.quantity, oi.unit_price, oi.total_price, oi.created_at,
+			SELECT oi.id, oi.product_id, oi.product_name, oi.product_description, oi.variant_id, oi.variant_name, oi.variant_color_name, oi.variant_color_custom, oi.size_id, oi.size_name, oi.size_dimensions, oi.quantity, oi.unit_price, oi.total_price, oi.created_at, oi.fulfillment_status,
 			       mi.id as main_image_id, mi.filename as main_image_filename, mi.original_name as main_image_original_name, mi.path as main_image_path, mi.size_bytes as main_image_size_bytes, mi.mime_type as main_image_mime_type, mi.uploaded_by as main_image_uploaded_by, mi.created_at as main_image_created_at, mi.updated_at as main_image_updated_at
 			FROM order_items oi
 			LEFT JOIN products p ON oi.product_id = p.id
@@ -674,7 +949,7 @@ func (q *OrderQueries) GetOrdersByUserIDWithItems(userID int, page, limit int) (
 			var mainImageUploadedBy sql.NullInt64
 			var mainImageCreatedAt, mainImageUpdatedAt sql.NullTime
 			
-			err := itemRows.Scan(&item.ID, &item.ProductID, &item.ProductName, &item.ProductDescription, &item.VariantID, &item.VariantName, &item.VariantColorName, &item.VariantColorCustom, &item.SizeID, &item.SizeName, &dimensionsJSON, &item.Quantity, &item.UnitPrice, &item.TotalPrice, &item.CreatedAt,
+			err := itemRows.Scan(&item.ID, &item.ProductID, &item.ProductName, &item.ProductDescription, &item.VariantID, &item.VariantName, &item.VariantColorName, &item.VariantColorCustom, &item.SizeID, &item.SizeName, &dimensionsJSON, &item.Quantity, &item.UnitPrice, &item.TotalPrice, &item.CreatedAt, &item.FulfillmentStatus,
 				&mainImageID, &mainImageFilename, &mainImageOriginalName, &mainImagePath, &mainImageSizeBytes, &mainImageMimeType, &mainImageUploadedBy, &mainImageCreatedAt, &mainImageUpdatedAt)
 			if err != nil {
 				itemRows.Close()
@@ -735,6 +1010,11 @@ func (q *OrderQueries) GetOrdersByUserIDWithItems(userID int, page, limit int) (
 			serviceRows.Close()
 			
 			item.Services = services
+			item.Options, err = q.getOrderItemOptions(item.ID)
+			if err != nil {
+				itemRows.Close()
+				return nil, err
+			}
 			items = append(items, item)
 		}
 		itemRows.Close()
@@ -747,6 +1027,8 @@ func (q *OrderQueries) GetOrdersByUserIDWithItems(userID int, page, limit int) (
 			Email:           order.Email,
 			Phone:           order.Phone,
 			Status:          order.Status,
+			CustomStatusID:  order.CustomStatusID,
+			StatusLabel:     order.StatusLabel,
 			TotalAmount:     order.TotalAmount,
 			Subtotal:        order.Subtotal,
 			ShippingCost:    order.ShippingCost,
@@ -756,6 +1038,8 @@ func (q *OrderQueries) GetOrdersByUserIDWithItems(userID int, page, limit int) (
 			Notes:           order.Notes,
 			RequiresInvoice: order.RequiresInvoice,
 			NIP:             order.NIP,
+			Currency:        order.Currency,
+			ExchangeRate:    order.ExchangeRate,
 			ShippingAddress: shippingAddr,
 			BillingAddress:  billingAddr,
 			Items:           items,
@@ -834,4 +1118,156 @@ func (q *OrderQueries) DeleteOrder(id int) error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// UpdateOrderShippingAddress replaces the shipping address of an order
+func (q *OrderQueries) UpdateOrderShippingAddress(orderID int, addr *models.AddressRequest) error {
+	return updateOrderShippingAddress(q.db, orderID, addr)
+}
+
+func updateOrderShippingAddress(db interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}, orderID int, addr *models.AddressRequest) error {
+	query := `
+		UPDATE shipping_addresses
+		SET first_name = $1, last_name = $2, company = $3, address_line1 = $4, address_line2 = $5,
+		    city = $6, state_province = $7, postal_code = $8, country = $9, phone = $10
+		WHERE order_id = $11`
+
+	result, err := db.Exec(query, addr.FirstName, addr.LastName, addr.Company, addr.AddressLine1, addr.AddressLine2, addr.City, addr.StateProvince, addr.PostalCode, addr.Country, addr.Phone, orderID)
+	if err != nil {
+		return fmt.Errorf("failed to update shipping address: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("order not found")
+	}
+
+	return nil
+}
+
+// UpdateOrderItemSize swaps an order item to another size of the same product.
+// Stock already taken for the old size is returned and taken from the new size.
+// Prices are kept as ordered.
+func (q *OrderQueries) UpdateOrderItemSize(orderID, orderItemID, newSizeID int) error {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := updateOrderItemSize(tx, orderID, orderItemID, newSizeID); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func updateOrderItemSize(tx *sql.Tx, orderID, orderItemID, newSizeID int) error {
+	var productID, oldSizeID, quantity int
+	err := tx.QueryRow(
+		"SELECT product_id, size_id, quantity FROM order_items WHERE id = $1 AND order_id = $2 FOR UPDATE",
+		orderItemID, orderID,
+	).Scan(&productID, &oldSizeID, &quantity)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("order item not found")
+		}
+		return fmt.Errorf("failed to get order item: %w", err)
+	}
+
+	if oldSizeID == newSizeID {
+		return nil
+	}
+
+	var sizeName string
+	var useStock bool
+	var available int
+	var a, b, cDim, d, e, f float64
+	err = tx.QueryRow(`
+		SELECT name, use_stock, stock_quantity - reserved_quantity, a, b, c, d, e, f
+		FROM sizes
+		WHERE id = $1 AND product_id = $2
+		FOR UPDATE`,
+		newSizeID, productID,
+	).Scan(&sizeName, &useStock, &available, &a, &b, &cDim, &d, &e, &f)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("size not found for product")
+		}
+		return fmt.Errorf("failed to get size: %w", err)
+	}
+
+	if useStock && available < quantity {
+		return fmt.Errorf("insufficient stock: requested %d, available %d", quantity, available)
+	}
+
+	dimensionsJSON, err := json.Marshal(map[string]interface{}{
+		"a": a, "b": b, "c": cDim, "d": d, "e": e, "f": f,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal size dimensions: %w", err)
+	}
+
+	_, err = tx.Exec(
+		"UPDATE order_items SET size_id = $1, size_name = $2, size_dimensions = $3 WHERE id = $4",
+		newSizeID, sizeName, dimensionsJSON, orderItemID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update order item size: %w", err)
+	}
+
+	// Return stock to the old size (no-op if it does not track stock or was deleted)
+	_, err = tx.Exec(
+		"UPDATE sizes SET stock_quantity = stock_quantity + $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2 AND use_stock = true",
+		quantity, oldSizeID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to return stock: %w", err)
+	}
+
+	_, err = tx.Exec(
+		"UPDATE sizes SET stock_quantity = stock_quantity - $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2 AND use_stock = true",
+		quantity, newSizeID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to take stock: %w", err)
+	}
+
+	return nil
+}
+
+// getOrderItemOptions returns the option values chosen for an order item
+func (q *OrderQueries) getOrderItemOptions(orderItemID int) ([]models.ProductOptionSelection, error) {
+	rows, err := q.db.Query(`
+		SELECT option_group_id, option_value_id, group_name, value_name, surcharge
+		FROM order_item_options
+		WHERE order_item_id = $1
+		ORDER BY id`, orderItemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order item options: %w", err)
+	}
+	defer rows.Close()
+
+	var options []models.ProductOptionSelection
+	for rows.Next() {
+		var option models.ProductOptionSelection
+		if err := rows.Scan(&option.OptionGroupID, &option.OptionValueID, &option.GroupName, &option.ValueName, &option.Surcharge); err != nil {
+			return nil, fmt.Errorf("failed to scan order item option: %w", err)
+		}
+		options = append(options, option)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get order item options: %w", err)
+	}
+	return options, nil
+}
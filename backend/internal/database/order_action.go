@@ -0,0 +1,97 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"notsofluffy-backend/internal/models"
+)
+
+// lockOrderState returns the status and payment status of an order, locked for tx
+func lockOrderState(tx *sql.Tx, id int) (string, string, error) {
+	var status, paymentStatus string
+	err := tx.QueryRow(`SELECT status, payment_status FROM orders WHERE id = $1 FOR UPDATE`, id).Scan(&status, &paymentStatus)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", "", fmt.Errorf("order not found")
+		}
+		return "", "", fmt.Errorf("failed to get order: %w", err)
+	}
+	return status, paymentStatus, nil
+}
+
+// MarkOrderPaid records the payment of an order and moves a pending order on to
+// processing. It returns the status the order had before.
+func (q *OrderQueries) MarkOrderPaid(id int) (string, error) {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	status, paymentStatus, err := lockOrderState(tx, id)
+	if err != nil {
+		return "", err
+	}
+	if status == models.OrderStatusCancelled {
+		return "", fmt.Errorf("order is cancelled")
+	}
+	if paymentStatus != models.PaymentStatusPending && paymentStatus != models.PaymentStatusFailed {
+		return "", fmt.Errorf("order is already paid")
+	}
+
+	_, err = tx.Exec(`
+		UPDATE orders
+		SET payment_status = $1, status = CASE WHEN status = $2 THEN $3 ELSE status END,
+			custom_status_id = CASE WHEN status = $2 THEN NULL ELSE custom_status_id END
+		WHERE id = $4`,
+		models.PaymentStatusCompleted, models.OrderStatusPending, models.OrderStatusProcessing, id)
+	if err != nil {
+		return "", fmt.Errorf("failed to mark order paid: %w", err)
+	}
+	if err := confirmOrderDiscount(tx, id); err != nil {
+		return "", err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return status, nil
+}
+
+// MarkOrderShipped sets an order to shipped with its tracking details and returns the
+// status it had before. Shipping again corrects the tracking details but keeps the first
+// shipping time.
+func (q *OrderQueries) MarkOrderShipped(id int, carrier, trackingNumber string) (string, error) {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	status, _, err := lockOrderState(tx, id)
+	if err != nil {
+		return "", err
+	}
+	switch status {
+	case models.OrderStatusCancelled:
+		return "", fmt.Errorf("order is cancelled")
+	case models.OrderStatusDelivered:
+		return "", fmt.Errorf("order is already delivered")
+	}
+
+	_, err = tx.Exec(`
+		UPDATE orders
+		SET status = $1, tracking_carrier = $2, tracking_number = $3, shipped_at = COALESCE(shipped_at, CURRENT_TIMESTAMP),
+			custom_status_id = CASE WHEN status = $1 THEN custom_status_id ELSE NULL END
+		WHERE id = $4`,
+		models.OrderStatusShipped, carrier, trackingNumber, id)
+	if err != nil {
+		return "", fmt.Errorf("failed to mark order shipped: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return status, nil
+}
@@ -0,0 +1,122 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"notsofluffy-backend/internal/models"
+)
+
+// orderArchiveBatchSize bounds the orders archived in one run
+const orderArchiveBatchSize = 200
+
+// ArchiveOrders archives delivered and cancelled orders created before olderThan: their
+// items with service and option snapshots move into one JSON document in order_archives.
+// Orders with refunds keep their items, since refund lines point at them.
+func (q *OrderQueries) ArchiveOrders(olderThan time.Time) (*models.OrderArchiveResult, error) {
+	rows, err := q.db.Query(`
+		SELECT o.id FROM orders o
+		WHERE o.archived_at IS NULL AND o.created_at < $1 AND o.status = ANY($2)
+		  AND NOT EXISTS (
+			SELECT 1 FROM refund_items ri JOIN order_items oi ON oi.id = ri.order_item_id
+			WHERE oi.order_id = o.id)
+		ORDER BY o.created_at
+		LIMIT $3`, olderThan, pq.Array(models.OrderArchiveStatuses), orderArchiveBatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get orders to archive: %w", err)
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan order to archive: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get orders to archive: %w", err)
+	}
+
+	result := &models.OrderArchiveResult{Checked: len(ids)}
+	for _, id := range ids {
+		archived, err := q.archiveOrder(id)
+		if err != nil {
+			return result, err
+		}
+		if archived {
+			result.Archived++
+		}
+	}
+	return result, nil
+}
+
+// archiveOrder archives one order in a transaction; it reports false when the order
+// was archived or changed in the meantime
+func (q *OrderQueries) archiveOrder(id int) (bool, error) {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRow(`SELECT status FROM orders WHERE id = $1 AND archived_at IS NULL FOR UPDATE`, id).Scan(&status)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to lock order %d: %w", id, err)
+	}
+	if status != models.OrderStatusDelivered && status != models.OrderStatusCancelled {
+		return false, nil
+	}
+
+	// The order row is locked, so its items can't change while they're read
+	order, err := q.GetOrderByID(id)
+	if err != nil {
+		return false, err
+	}
+	items, err := json.Marshal(order.Items)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal items of order %d: %w", id, err)
+	}
+
+	if _, err := tx.Exec(`INSERT INTO order_archives (order_id, items) VALUES ($1, $2)`, id, items); err != nil {
+		return false, fmt.Errorf("failed to archive order %d: %w", id, err)
+	}
+	// Services, options and warehouse allocations go with their items
+	if _, err := tx.Exec(`DELETE FROM order_items WHERE order_id = $1`, id); err != nil {
+		return false, fmt.Errorf("failed to delete items of order %d: %w", id, err)
+	}
+	if _, err := tx.Exec(`UPDATE orders SET archived_at = CURRENT_TIMESTAMP WHERE id = $1`, id); err != nil {
+		return false, fmt.Errorf("failed to archive order %d: %w", id, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+// getArchivedOrderItems returns the items of an archived order from its archive document
+func (q *OrderQueries) getArchivedOrderItems(orderID int) ([]models.OrderItem, error) {
+	var raw []byte
+	err := q.db.QueryRow(`SELECT items FROM order_archives WHERE order_id = $1`, orderID).Scan(&raw)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get archived order items: %w", err)
+	}
+
+	var items []models.OrderItem
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal archived order items: %w", err)
+	}
+	return items, nil
+}
@@ -0,0 +1,100 @@
+package database
+
+import (
+	"fmt"
+
+	"notsofluffy-backend/internal/models"
+)
+
+// GetOrderBoard returns the board columns of statuses, each with the page of limit
+// orders pages gives for it (1 when missing)
+func (q *OrderQueries) GetOrderBoard(statuses []string, pages map[string]int, limit int) (*models.OrderBoardResponse, error) {
+	board := &models.OrderBoardResponse{Columns: []models.OrderBoardColumn{}, Limit: limit}
+
+	counts := map[string]int{}
+	rows, err := q.db.Query(`SELECT status, COUNT(*) FROM orders WHERE archived_at IS NULL GROUP BY status`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count orders by status: %w", err)
+	}
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan order count: %w", err)
+		}
+		counts[status] = count
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to count orders by status: %w", err)
+	}
+
+	settings := NewSettingsQueries(q.db)
+	for _, status := range statuses {
+		page := pages[status]
+		if page < 1 {
+			page = 1
+		}
+		wipLimit, err := settings.GetIntSetting(models.OrderBoardWIPLimitSetting(status), 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get WIP limit: %w", err)
+		}
+		if wipLimit < 0 {
+			wipLimit = 0
+		}
+
+		column := models.OrderBoardColumn{
+			Status:    status,
+			Count:     counts[status],
+			WIPLimit:  wipLimit,
+			OverLimit: wipLimit > 0 && counts[status] > wipLimit,
+			Page:      page,
+			HasMore:   page*limit < counts[status],
+		}
+		if column.Orders, err = q.getOrderBoardCards(status, page, limit); err != nil {
+			return nil, err
+		}
+		board.Columns = append(board.Columns, column)
+		board.Total += column.Count
+	}
+	return board, nil
+}
+
+func (q *OrderQueries) getOrderBoardCards(status string, page, limit int) ([]models.OrderBoardCard, error) {
+	order := "ASC"
+	for _, done := range models.OrderArchiveStatuses {
+		if status == done {
+			order = "DESC"
+		}
+	}
+
+	rows, err := q.db.Query(`
+		SELECT o.id, COALESCE(TRIM(sa.first_name || ' ' || sa.last_name), ''), o.email, o.total_amount,
+		       COALESCE(i.quantity, 0), o.payment_status, o.lead_time_days, o.is_test, o.created_at
+		FROM orders o
+		LEFT JOIN LATERAL (SELECT first_name, last_name FROM shipping_addresses WHERE order_id = o.id ORDER BY id LIMIT 1) sa ON true
+		LEFT JOIN LATERAL (SELECT SUM(quantity) AS quantity FROM order_items WHERE order_id = o.id) i ON true
+		WHERE o.status = $1 AND o.archived_at IS NULL
+		ORDER BY o.created_at `+order+`, o.id `+order+`
+		LIMIT $2 OFFSET $3`,
+		status, limit, (page-1)*limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get board orders: %w", err)
+	}
+	defer rows.Close()
+
+	cards := []models.OrderBoardCard{}
+	for rows.Next() {
+		var card models.OrderBoardCard
+		if err := rows.Scan(&card.ID, &card.CustomerName, &card.Email, &card.TotalAmount,
+			&card.Items, &card.PaymentStatus, &card.LeadTimeDays, &card.IsTest, &card.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan board order: %w", err)
+		}
+		cards = append(cards, card)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get board orders: %w", err)
+	}
+	return cards, nil
+}
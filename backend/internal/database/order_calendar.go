@@ -0,0 +1,99 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"notsofluffy-backend/internal/models"
+)
+
+// GetOrderCalendar returns the per-day workload of the month starting at monthStart,
+// with days taken in monthStart's time zone. An order is promised to ship lead_time_days
+// after the day it was placed.
+func (q *OrderQueries) GetOrderCalendar(monthStart time.Time) (*models.OrderCalendarResponse, error) {
+	loc := monthStart.Location()
+	monthEnd := monthStart.AddDate(0, 1, 0)
+	tz := loc.String()
+
+	calendar := &models.OrderCalendarResponse{
+		Month:    monthStart.Format("2006-01"),
+		Timezone: tz,
+		Days:     []models.OrderCalendarDay{},
+	}
+	index := map[string]int{}
+	for day := monthStart; day.Before(monthEnd); day = day.AddDate(0, 0, 1) {
+		index[day.Format("2006-01-02")] = len(calendar.Days)
+		calendar.Days = append(calendar.Days, models.OrderCalendarDay{Date: day.Format("2006-01-02"), DueOrderIDs: []int{}})
+	}
+
+	rows, err := q.db.Query(`
+		SELECT (o.created_at AT TIME ZONE $1)::date AS day, COUNT(*), COALESCE(SUM(o.total_amount), 0), COALESCE(SUM(i.quantity), 0)
+		FROM orders o
+		LEFT JOIN LATERAL (SELECT SUM(quantity) AS quantity FROM order_items WHERE order_id = o.id) i ON true
+		WHERE o.status <> $2 AND NOT o.is_test AND o.created_at >= $3 AND o.created_at < $4
+		GROUP BY day`,
+		tz, models.OrderStatusCancelled, monthStart, monthEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get orders per day: %w", err)
+	}
+	for rows.Next() {
+		var day time.Time
+		var orders, items int
+		var revenue float64
+		if err := rows.Scan(&day, &orders, &revenue, &items); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan orders per day: %w", err)
+		}
+		if i, ok := index[day.Format("2006-01-02")]; ok {
+			calendar.Days[i].Orders = orders
+			calendar.Days[i].Revenue = revenue
+			calendar.Days[i].Items = items
+			calendar.Orders += orders
+			calendar.Revenue += revenue
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get orders per day: %w", err)
+	}
+
+	// Orders placed after the month can't be due in it
+	rows, err = q.db.Query(`
+		SELECT o.id, (o.created_at AT TIME ZONE $1)::date + o.lead_time_days AS due, o.status, COALESCE(i.quantity, 0)
+		FROM orders o
+		LEFT JOIN LATERAL (SELECT SUM(quantity) AS quantity FROM order_items WHERE order_id = o.id) i ON true
+		WHERE o.status <> $2 AND NOT o.is_test AND o.lead_time_days IS NOT NULL AND o.created_at < $3
+		  AND (o.created_at AT TIME ZONE $1)::date + o.lead_time_days >= $4::date
+		  AND (o.created_at AT TIME ZONE $1)::date + o.lead_time_days < $5::date
+		ORDER BY o.id`,
+		tz, models.OrderStatusCancelled, monthEnd, monthStart.Format("2006-01-02"), monthEnd.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get orders due: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, items int
+		var due time.Time
+		var status string
+		if err := rows.Scan(&id, &due, &status, &items); err != nil {
+			return nil, fmt.Errorf("failed to scan orders due: %w", err)
+		}
+		i, ok := index[due.Format("2006-01-02")]
+		if !ok {
+			continue
+		}
+		day := &calendar.Days[i]
+		day.ShipsDue++
+		day.ShipsDueItems += items
+		day.DueOrderIDs = append(day.DueOrderIDs, id)
+		if status != models.OrderStatusShipped && status != models.OrderStatusDelivered {
+			day.Unshipped++
+		}
+		calendar.ShipsDue++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get orders due: %w", err)
+	}
+
+	return calendar, nil
+}
@@ -0,0 +1,235 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"notsofluffy-backend/internal/models"
+)
+
+type OrderChangeQueries struct {
+	db *sql.DB
+}
+
+func NewOrderChangeQueries(db *sql.DB) *OrderChangeQueries {
+	return &OrderChangeQueries{db: db}
+}
+
+const orderChangeColumns = `id, order_id, type, payload, status, customer_note, admin_note, reviewed_by, reviewed_at, created_at, updated_at`
+
+func scanOrderChangeRequest(scanner interface{ Scan(...interface{}) error }) (*models.OrderChangeRequest, error) {
+	var r models.OrderChangeRequest
+	var payload []byte
+	err := scanner.Scan(&r.ID, &r.OrderID, &r.Type, &payload, &r.Status, &r.CustomerNote, &r.AdminNote, &r.ReviewedBy, &r.ReviewedAt, &r.CreatedAt, &r.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	r.Payload = json.RawMessage(payload)
+	return &r, nil
+}
+
+// CreateChangeRequest stores a new pending change request for an order
+func (q *OrderChangeQueries) CreateChangeRequest(orderID int, changeType string, payload interface{}, note *string) (*models.OrderChangeRequest, error) {
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal change payload: %w", err)
+	}
+
+	row := q.db.QueryRow(`
+		INSERT INTO order_change_requests (order_id, type, payload, status, customer_note)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING `+orderChangeColumns,
+		orderID, changeType, payloadJSON, models.OrderChangeStatusPending, note,
+	)
+
+	request, err := scanOrderChangeRequest(row)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create change request: %w", err)
+	}
+
+	return request, nil
+}
+
+// GetChangeRequestByID retrieves a change request by ID
+func (q *OrderChangeQueries) GetChangeRequestByID(id int) (*models.OrderChangeRequest, error) {
+	row := q.db.QueryRow("SELECT "+orderChangeColumns+" FROM order_change_requests WHERE id = $1", id)
+
+	request, err := scanOrderChangeRequest(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("change request not found")
+		}
+		return nil, fmt.Errorf("failed to get change request: %w", err)
+	}
+
+	return request, nil
+}
+
+// GetChangeRequestsByOrderID returns all change requests submitted for an order
+func (q *OrderChangeQueries) GetChangeRequestsByOrderID(orderID int) ([]models.OrderChangeRequest, error) {
+	rows, err := q.db.Query("SELECT "+orderChangeColumns+" FROM order_change_requests WHERE order_id = $1 ORDER BY created_at DESC", orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get change requests: %w", err)
+	}
+	defer rows.Close()
+
+	requests := []models.OrderChangeRequest{}
+	for rows.Next() {
+		request, err := scanOrderChangeRequest(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan change request: %w", err)
+		}
+		requests = append(requests, *request)
+	}
+
+	return requests, rows.Err()
+}
+
+// ListChangeRequests returns the admin queue of change requests, optionally filtered by status
+func (q *OrderChangeQueries) ListChangeRequests(page, limit int, status string) (*models.OrderChangeRequestListResponse, error) {
+	offset := (page - 1) * limit
+
+	whereClause := ""
+	args := []interface{}{}
+	if status != "" {
+		whereClause = "WHERE status = $1"
+		args = append(args, status)
+	}
+
+	var total int
+	err := q.db.QueryRow("SELECT COUNT(*) FROM order_change_requests "+whereClause, args...).Scan(&total)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count change requests: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM order_change_requests
+		%s
+		ORDER BY created_at ASC
+		LIMIT $%d OFFSET $%d`, orderChangeColumns, whereClause, len(args)+1, len(args)+2)
+	args = append(args, limit, offset)
+
+	rows, err := q.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get change requests: %w", err)
+	}
+	defer rows.Close()
+
+	requests := []models.OrderChangeRequest{}
+	for rows.Next() {
+		request, err := scanOrderChangeRequest(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan change request: %w", err)
+		}
+		requests = append(requests, *request)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate change requests: %w", err)
+	}
+
+	return &models.OrderChangeRequestListResponse{
+		Requests: requests,
+		Total:    total,
+		Page:     page,
+		Limit:    limit,
+	}, nil
+}
+
+// ReviewChangeRequest records the admin decision on a pending change request
+func (q *OrderChangeQueries) ReviewChangeRequest(id int, status string, adminNote *string, reviewedBy *int) error {
+	result, err := q.db.Exec(`
+		UPDATE order_change_requests
+		SET status = $1, admin_note = $2, reviewed_by = $3, reviewed_at = CURRENT_TIMESTAMP
+		WHERE id = $4 AND status = $5`,
+		status, adminNote, reviewedBy, id, models.OrderChangeStatusPending,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to review change request: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("change request not found or already reviewed")
+	}
+
+	return nil
+}
+
+// ApproveChangeRequest approves a pending change request and applies it to its order in
+// one transaction. The request and its order are locked first, so concurrent approvals
+// apply the change once and only while the order is still pending.
+func (q *OrderChangeQueries) ApproveChangeRequest(id int, adminNote *string, reviewedBy *int) error {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	request, err := scanOrderChangeRequest(tx.QueryRow("SELECT "+orderChangeColumns+" FROM order_change_requests WHERE id = $1 FOR UPDATE", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("change request not found")
+		}
+		return fmt.Errorf("failed to get change request: %w", err)
+	}
+
+	var orderStatus string
+	if err := tx.QueryRow("SELECT status FROM orders WHERE id = $1 FOR UPDATE", request.OrderID).Scan(&orderStatus); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("order not found")
+		}
+		return fmt.Errorf("failed to get order: %w", err)
+	}
+	if orderStatus != models.OrderStatusPending {
+		return fmt.Errorf("order is not pending")
+	}
+
+	result, err := tx.Exec(`
+		UPDATE order_change_requests
+		SET status = $1, admin_note = $2, reviewed_by = $3, reviewed_at = CURRENT_TIMESTAMP
+		WHERE id = $4 AND status = $5`,
+		models.OrderChangeStatusApproved, adminNote, reviewedBy, id, models.OrderChangeStatusPending,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to review change request: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("change request not found or already reviewed")
+	}
+
+	// Apply the change the same way as manual admin order edits
+	switch request.Type {
+	case models.OrderChangeTypeSizeSwap:
+		var payload models.SizeSwapPayload
+		if err := json.Unmarshal(request.Payload, &payload); err != nil {
+			return fmt.Errorf("invalid change request payload: %w", err)
+		}
+		err = updateOrderItemSize(tx, request.OrderID, payload.OrderItemID, payload.NewSizeID)
+	case models.OrderChangeTypeAddressChange:
+		var payload models.AddressChangePayload
+		if err := json.Unmarshal(request.Payload, &payload); err != nil {
+			return fmt.Errorf("invalid change request payload: %w", err)
+		}
+		err = updateOrderShippingAddress(tx, request.OrderID, &payload.ShippingAddress)
+	default:
+		return fmt.Errorf("unsupported change request type")
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
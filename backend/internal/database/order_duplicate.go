@@ -0,0 +1,135 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"notsofluffy-backend/internal/models"
+)
+
+// duplicateOfJoin finds, for each order o, the latest earlier order it likely repeats:
+// same email, not cancelled, placed at most $window minutes before and with a total
+// within $tolerance percent. The placeholders are filled in by the caller.
+const duplicateOfJoin = `
+	JOIN LATERAL (
+		SELECT e.id, e.status, e.total_amount, e.created_at
+		FROM orders e
+		WHERE LOWER(e.email) = LOWER(o.email) AND e.status <> '` + models.OrderStatusCancelled + `'
+		  AND (e.created_at, e.id) < (o.created_at, o.id)
+		  AND e.created_at >= o.created_at - make_interval(mins => %[1]s::int)
+		  AND ABS(e.total_amount - o.total_amount) <= GREATEST(e.total_amount, o.total_amount) * %[2]s::decimal / 100
+		ORDER BY e.created_at DESC, e.id DESC
+		LIMIT 1
+	) d ON true`
+
+// GetDuplicateOrders returns the suspected duplicates placed since the given time that
+// are not cancelled yet, newest first
+func (q *OrderQueries) GetDuplicateOrders(since time.Time, rule models.DuplicateOrderRule) ([]models.DuplicateOrder, error) {
+	query := `
+		SELECT o.id, d.id, o.email, o.status, o.payment_status, o.total_amount, d.total_amount, d.status,
+			EXTRACT(EPOCH FROM o.created_at - d.created_at) / 60, o.created_at, d.created_at
+		FROM orders o` + fmt.Sprintf(duplicateOfJoin, "$2", "$3") + `
+		WHERE o.created_at >= $1 AND o.status <> $4 AND o.archived_at IS NULL
+		ORDER BY o.created_at DESC, o.id DESC`
+
+	rows, err := q.db.Query(query, since, rule.WindowMinutes, rule.TolerancePercent, models.OrderStatusCancelled)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get duplicate orders: %w", err)
+	}
+	defer rows.Close()
+
+	duplicates := []models.DuplicateOrder{}
+	for rows.Next() {
+		var d models.DuplicateOrder
+		err := rows.Scan(&d.OrderID, &d.DuplicateOfID, &d.Email, &d.Status, &d.PaymentStatus, &d.TotalAmount, &d.OriginalTotal, &d.OriginalStatus,
+			&d.MinutesApart, &d.CreatedAt, &d.OriginalCreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan duplicate order: %w", err)
+		}
+		duplicates = append(duplicates, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate duplicate orders: %w", err)
+	}
+	return duplicates, nil
+}
+
+// GetDuplicateOf maps each of the given orders that is a suspected duplicate to the
+// earlier order it repeats; cancelled orders are never flagged
+func (q *OrderQueries) GetDuplicateOf(orderIDs []int, rule models.DuplicateOrderRule) (map[int]int, error) {
+	duplicateOf := make(map[int]int)
+	if len(orderIDs) == 0 {
+		return duplicateOf, nil
+	}
+
+	query := `SELECT o.id, d.id FROM orders o` + fmt.Sprintf(duplicateOfJoin, "$2", "$3") + `
+		WHERE o.id = ANY($1) AND o.status <> $4`
+	rows, err := q.db.Query(query, pq.Array(orderIDs), rule.WindowMinutes, rule.TolerancePercent, models.OrderStatusCancelled)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get duplicate orders: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, originalID int
+		if err := rows.Scan(&id, &originalID); err != nil {
+			return nil, fmt.Errorf("failed to scan duplicate order: %w", err)
+		}
+		duplicateOf[id] = originalID
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate duplicate orders: %w", err)
+	}
+	return duplicateOf, nil
+}
+
+// CancelDuplicateOrder cancels a suspected duplicate that hasn't shipped and frees the
+// production capacity booked for it. It returns the status and payment status the order
+// had before, so the caller can refund a paid duplicate.
+func (q *OrderQueries) CancelDuplicateOrder(id int, rule models.DuplicateOrderRule) (string, string, error) {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	status, paymentStatus, err := lockOrderState(tx, id)
+	if err != nil {
+		return "", "", err
+	}
+	switch status {
+	case models.OrderStatusCancelled:
+		return "", "", fmt.Errorf("order is cancelled")
+	case models.OrderStatusShipped, models.OrderStatusDelivered:
+		return "", "", fmt.Errorf("order has already shipped")
+	}
+
+	var originalID int
+	err = tx.QueryRow(`SELECT d.id FROM orders o`+fmt.Sprintf(duplicateOfJoin, "$2", "$3")+` WHERE o.id = $1`,
+		id, rule.WindowMinutes, rule.TolerancePercent).Scan(&originalID)
+	if err == sql.ErrNoRows {
+		return "", "", fmt.Errorf("order is not a suspected duplicate")
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to check duplicate order: %w", err)
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE orders SET status = $1, custom_status_id = CASE WHEN status = $1 THEN custom_status_id ELSE NULL END
+		WHERE id = $2`, models.OrderStatusCancelled, id); err != nil {
+		return "", "", fmt.Errorf("failed to cancel order: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM production_slots WHERE order_id = $1`, id); err != nil {
+		return "", "", fmt.Errorf("failed to release production capacity: %w", err)
+	}
+	if _, err := releaseOrderDiscounts(tx, []int{id}); err != nil {
+		return "", "", err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", "", fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return status, paymentStatus, nil
+}
@@ -0,0 +1,106 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"notsofluffy-backend/internal/models"
+)
+
+// GetOrderFulfillment returns the fulfillment status of each item of an order
+func (q *OrderQueries) GetOrderFulfillment(orderID int) (*models.OrderFulfillment, error) {
+	return getOrderFulfillment(q.db, orderID)
+}
+
+// UpdateOrderItemsFulfillment sets the fulfillment status of items of an order. The
+// items must all belong to the order, which must not be cancelled.
+func (q *OrderQueries) UpdateOrderItemsFulfillment(orderID int, itemIDs []int, status string) (*models.OrderFulfillment, error) {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var orderStatus string
+	err = tx.QueryRow("SELECT status FROM orders WHERE id = $1 FOR UPDATE", orderID).Scan(&orderStatus)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("order not found")
+		}
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+	if orderStatus == models.OrderStatusCancelled {
+		return nil, fmt.Errorf("order is cancelled")
+	}
+
+	unique := map[int]bool{}
+	for _, id := range itemIDs {
+		unique[id] = true
+	}
+	result, err := tx.Exec(`
+		UPDATE order_items
+		SET fulfillment_status = $1,
+		    fulfillment_updated_at = CASE WHEN fulfillment_status = $1 THEN fulfillment_updated_at ELSE CURRENT_TIMESTAMP END
+		WHERE order_id = $2 AND id = ANY($3)`,
+		status, orderID, pq.Array(itemIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update order item fulfillment: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("failed to update order item fulfillment: %w", err)
+	} else if int(affected) != len(unique) {
+		return nil, fmt.Errorf("order item not found")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return getOrderFulfillment(q.db, orderID)
+}
+
+func getOrderFulfillment(db *sql.DB, orderID int) (*models.OrderFulfillment, error) {
+	fulfillment := &models.OrderFulfillment{
+		OrderID: orderID,
+		Items:   []models.OrderItemFulfillment{},
+		Counts:  map[string]int{},
+	}
+	err := db.QueryRow("SELECT status FROM orders WHERE id = $1", orderID).Scan(&fulfillment.OrderStatus)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("order not found")
+		}
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	rows, err := db.Query(`
+		SELECT id, product_name, variant_name, size_name, quantity, fulfillment_status, fulfillment_updated_at
+		FROM order_items
+		WHERE order_id = $1
+		ORDER BY id`,
+		orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order item fulfillment: %w", err)
+	}
+	defer rows.Close()
+
+	for _, status := range models.ItemFulfillmentStatuses {
+		fulfillment.Counts[status] = 0
+	}
+	for rows.Next() {
+		var item models.OrderItemFulfillment
+		if err := rows.Scan(&item.ItemID, &item.ProductName, &item.VariantName, &item.SizeName, &item.Quantity, &item.Status, &item.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan order item fulfillment: %w", err)
+		}
+		item.Code = models.OrderItemCode(orderID, item.ItemID)
+		fulfillment.Counts[item.Status]++
+		fulfillment.Items = append(fulfillment.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get order item fulfillment: %w", err)
+	}
+
+	fulfillment.Complete = len(fulfillment.Items) > 0 && fulfillment.Counts[models.ItemFulfillmentShipped] == len(fulfillment.Items)
+	return fulfillment, nil
+}
@@ -0,0 +1,76 @@
+package database
+
+import (
+	"fmt"
+
+	"notsofluffy-backend/internal/models"
+)
+
+// GetOrderSummariesByUserID returns a page of a user's orders matching filter in the
+// compact form, with the number of the user's orders in each status
+func (q *OrderQueries) GetOrderSummariesByUserID(userID int, filter models.OrderHistoryFilter, page, limit int) (*models.OrderSummaryListResponse, error) {
+	whereClause, args := orderHistoryFilter(userID, filter)
+
+	var total int
+	if err := q.db.QueryRow("SELECT COUNT(*) FROM orders "+whereClause, args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count orders: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, status, payment_status, total_amount, currency, exchange_rate, created_at,
+			COALESCE((SELECT SUM(quantity) FROM order_items WHERE order_id = orders.id), 0),
+			COALESCE((SELECT product_name FROM order_items WHERE order_id = orders.id ORDER BY id LIMIT 1), '')
+		FROM orders
+		%s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d`, whereClause, len(args)+1, len(args)+2)
+	rows, err := q.db.Query(query, append(args, limit, (page-1)*limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get orders: %w", err)
+	}
+	defer rows.Close()
+
+	orders := []models.OrderSummary{}
+	for rows.Next() {
+		var order models.OrderSummary
+		err := rows.Scan(&order.ID, &order.Status, &order.PaymentStatus, &order.TotalAmount, &order.Currency, &order.ExchangeRate, &order.CreatedAt,
+			&order.ItemCount, &order.FirstItemName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan order: %w", err)
+		}
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get orders: %w", err)
+	}
+
+	counts, err := q.db.Query(`
+		SELECT status, COUNT(*) FROM orders
+		WHERE user_id = $1 AND archived_at IS NULL
+		GROUP BY status`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count orders by status: %w", err)
+	}
+	defer counts.Close()
+
+	statusCounts := map[string]int{}
+	for counts.Next() {
+		var status string
+		var count int
+		if err := counts.Scan(&status, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan order count: %w", err)
+		}
+		statusCounts[status] = count
+	}
+	if err := counts.Err(); err != nil {
+		return nil, fmt.Errorf("failed to count orders by status: %w", err)
+	}
+
+	return &models.OrderSummaryListResponse{
+		Orders:       orders,
+		Total:        total,
+		Page:         page,
+		Limit:        limit,
+		StatusCounts: statusCounts,
+	}, nil
+}
@@ -0,0 +1,49 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/lib/pq"
+	"notsofluffy-backend/internal/models"
+)
+
+const shippingAddressSelect = `
+	SELECT sa.id, sa.order_id, sa.first_name, sa.last_name, sa.company, sa.address_line1, sa.address_line2,
+		sa.city, sa.state_province, sa.postal_code, sa.country, sa.phone, sa.created_at
+	FROM shipping_addresses sa
+	JOIN orders o ON o.id = sa.order_id`
+
+// GetShippingAddresses returns the shipping addresses of the given orders, in order ID
+// order. Orders without a shipping address are left out.
+func (q *OrderQueries) GetShippingAddresses(orderIDs []int) ([]models.ShippingAddress, error) {
+	return q.queryShippingAddresses(shippingAddressSelect+` WHERE o.id = ANY($1) ORDER BY o.id`, pq.Array(orderIDs))
+}
+
+// GetShippingAddressesByStatus returns the shipping addresses of up to limit orders in
+// a status, oldest order first
+func (q *OrderQueries) GetShippingAddressesByStatus(status string, limit int) ([]models.ShippingAddress, error) {
+	return q.queryShippingAddresses(shippingAddressSelect+` WHERE o.status = $1 ORDER BY o.created_at, o.id LIMIT $2`, status, limit)
+}
+
+func (q *OrderQueries) queryShippingAddresses(query string, args ...interface{}) ([]models.ShippingAddress, error) {
+	rows, err := q.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shipping addresses: %w", err)
+	}
+	defer rows.Close()
+
+	addresses := []models.ShippingAddress{}
+	for rows.Next() {
+		var addr models.ShippingAddress
+		err := rows.Scan(&addr.ID, &addr.OrderID, &addr.FirstName, &addr.LastName, &addr.Company, &addr.AddressLine1, &addr.AddressLine2,
+			&addr.City, &addr.StateProvince, &addr.PostalCode, &addr.Country, &addr.Phone, &addr.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan shipping address: %w", err)
+		}
+		addresses = append(addresses, addr)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get shipping addresses: %w", err)
+	}
+	return addresses, nil
+}
@@ -0,0 +1,336 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"notsofluffy-backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+type OrderReturnQueries struct {
+	db *sql.DB
+}
+
+func NewOrderReturnQueries(db *sql.DB) *OrderReturnQueries {
+	return &OrderReturnQueries{db: db}
+}
+
+const orderReturnColumns = `id, order_id, status, reason, admin_note, label_type, provider, provider_shipment_id, carrier_status, tracking_number, tracking_url, label_sent_at, reviewed_by, reviewed_at, received_at, created_at, updated_at`
+
+func scanOrderReturn(scanner interface{ Scan(...interface{}) error }, r *models.OrderReturn) error {
+	return scanner.Scan(&r.ID, &r.OrderID, &r.Status, &r.Reason, &r.AdminNote, &r.LabelType, &r.Provider, &r.ProviderShipmentID, &r.CarrierStatus,
+		&r.TrackingNumber, &r.TrackingURL, &r.LabelSentAt, &r.ReviewedBy, &r.ReviewedAt, &r.ReceivedAt, &r.CreatedAt, &r.UpdatedAt)
+}
+
+// CreateReturn validates a return request against its order and stores it as
+// requested. The order row is locked so concurrent requests cannot return an item
+// more times than it was ordered; rejected returns don't count.
+func (q *OrderReturnQueries) CreateReturn(orderID int, req *models.OrderReturnRequest) (*models.OrderReturn, error) {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var orderStatus string
+	if err := tx.QueryRow("SELECT status FROM orders WHERE id = $1 FOR UPDATE", orderID).Scan(&orderStatus); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("order not found")
+		}
+		return nil, fmt.Errorf("failed to lock order: %w", err)
+	}
+	if orderStatus != models.OrderStatusShipped && orderStatus != models.OrderStatusDelivered {
+		return nil, fmt.Errorf("order is not shipped")
+	}
+
+	requested := map[int]int{}
+	for _, item := range req.Items {
+		requested[item.OrderItemID] += item.Quantity
+	}
+	for orderItemID, quantity := range requested {
+		var orderedQuantity int
+		err = tx.QueryRow("SELECT quantity FROM order_items WHERE id = $1 AND order_id = $2", orderItemID, orderID).Scan(&orderedQuantity)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, fmt.Errorf("order item %d not found in order", orderItemID)
+			}
+			return nil, fmt.Errorf("failed to get order item: %w", err)
+		}
+
+		var returnedQuantity int
+		err = tx.QueryRow(`
+			SELECT COALESCE(SUM(ri.quantity), 0)
+			FROM order_return_items ri
+			JOIN order_returns r ON ri.return_id = r.id
+			WHERE ri.order_item_id = $1 AND r.status != $2`,
+			orderItemID, models.OrderReturnStatusRejected,
+		).Scan(&returnedQuantity)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get returned quantity: %w", err)
+		}
+		if returnedQuantity+quantity > orderedQuantity {
+			return nil, fmt.Errorf("return quantity exceeds ordered quantity for order item %d", orderItemID)
+		}
+	}
+
+	var r models.OrderReturn
+	err = scanOrderReturn(tx.QueryRow(`
+		INSERT INTO order_returns (order_id, status, reason)
+		VALUES ($1, $2, $3)
+		RETURNING `+orderReturnColumns,
+		orderID, models.OrderReturnStatusRequested, req.Reason,
+	), &r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create return: %w", err)
+	}
+
+	for _, item := range req.Items {
+		_, err = tx.Exec("INSERT INTO order_return_items (return_id, order_item_id, quantity) VALUES ($1, $2, $3)",
+			r.ID, item.OrderItemID, item.Quantity)
+		if err != nil {
+			return nil, fmt.Errorf("failed to insert return item: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if err := q.loadReturnItems([]*models.OrderReturn{&r}); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// GetReturnByID retrieves a return with its items
+func (q *OrderReturnQueries) GetReturnByID(id int) (*models.OrderReturn, error) {
+	var r models.OrderReturn
+	err := scanOrderReturn(q.db.QueryRow("SELECT "+orderReturnColumns+" FROM order_returns WHERE id = $1", id), &r)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("return not found")
+		}
+		return nil, fmt.Errorf("failed to get return: %w", err)
+	}
+
+	if err := q.loadReturnItems([]*models.OrderReturn{&r}); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// GetReturnsByOrderID returns all returns of an order, newest first
+func (q *OrderReturnQueries) GetReturnsByOrderID(orderID int) ([]models.OrderReturn, error) {
+	return q.queryReturns("SELECT "+orderReturnColumns+" FROM order_returns WHERE order_id = $1 ORDER BY created_at DESC, id DESC", orderID)
+}
+
+// ListReturns returns the admin queue of returns, optionally filtered by status
+func (q *OrderReturnQueries) ListReturns(page, limit int, status string) (*models.OrderReturnListResponse, error) {
+	offset := (page - 1) * limit
+
+	whereClause := ""
+	args := []interface{}{}
+	if status != "" {
+		whereClause = "WHERE status = $1"
+		args = append(args, status)
+	}
+
+	var total int
+	err := q.db.QueryRow("SELECT COUNT(*) FROM order_returns "+whereClause, args...).Scan(&total)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count returns: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM order_returns
+		%s
+		ORDER BY created_at ASC, id ASC
+		LIMIT $%d OFFSET $%d`, orderReturnColumns, whereClause, len(args)+1, len(args)+2)
+	args = append(args, limit, offset)
+
+	returns, err := q.queryReturns(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.OrderReturnListResponse{
+		Returns: returns,
+		Total:   total,
+		Page:    page,
+		Limit:   limit,
+	}, nil
+}
+
+// ListOpenReturns returns up to limit approved returns not received yet whose label
+// is still to be emailed or whose carrier shipment is followed, least recently updated
+// first, for the tracking job
+func (q *OrderReturnQueries) ListOpenReturns(limit int) ([]models.OrderReturn, error) {
+	return q.queryReturns(`
+		SELECT `+orderReturnColumns+`
+		FROM order_returns
+		WHERE status IN ($1, $2) AND (label_type = $3 OR (label_type = $4 AND label_sent_at IS NULL))
+		ORDER BY updated_at ASC, id ASC
+		LIMIT $5`,
+		models.OrderReturnStatusApproved, models.OrderReturnStatusInTransit, models.ReturnLabelCarrier, models.ReturnLabelInstructions, limit)
+}
+
+// ReviewReturn records the admin decision on a requested return. Only one decision
+// is ever recorded, so concurrent reviews approve or reject it once.
+func (q *OrderReturnQueries) ReviewReturn(id int, status string, adminNote *string, reviewedBy *int) (*models.OrderReturn, error) {
+	var r models.OrderReturn
+	err := scanOrderReturn(q.db.QueryRow(`
+		UPDATE order_returns
+		SET status = $1, admin_note = $2, reviewed_by = $3, reviewed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $4 AND status = $5
+		RETURNING `+orderReturnColumns,
+		status, adminNote, reviewedBy, id, models.OrderReturnStatusRequested,
+	), &r)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("return not found or already reviewed")
+		}
+		return nil, fmt.Errorf("failed to review return: %w", err)
+	}
+
+	if err := q.loadReturnItems([]*models.OrderReturn{&r}); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// SetReturnLabel stores the label of an approved return. provider, providerShipmentID
+// and carrierStatus are only set for carrier labels.
+func (q *OrderReturnQueries) SetReturnLabel(id int, labelType string, provider, providerShipmentID, carrierStatus *string) (*models.OrderReturn, error) {
+	return q.updateReturn(`
+		UPDATE order_returns
+		SET label_type = $1, provider = $2, provider_shipment_id = $3, carrier_status = $4, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $5
+		RETURNING `+orderReturnColumns,
+		labelType, provider, providerShipmentID, carrierStatus, id)
+}
+
+// UpdateReturnTracking stores what the carrier reported about a return shipment and
+// moves the return to status. Returns only move forward, so a stale report never
+// undoes a received return.
+func (q *OrderReturnQueries) UpdateReturnTracking(id int, status, carrierStatus string, trackingNumber, trackingURL *string) (*models.OrderReturn, error) {
+	return q.updateReturn(`
+		UPDATE order_returns
+		SET status = CASE WHEN status = $6 THEN status ELSE $1 END,
+			received_at = CASE WHEN $7 THEN COALESCE(received_at, CURRENT_TIMESTAMP) ELSE received_at END,
+			carrier_status = $2,
+			tracking_number = COALESCE($3, tracking_number),
+			tracking_url = COALESCE($4, tracking_url),
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $5
+		RETURNING `+orderReturnColumns,
+		status, carrierStatus, trackingNumber, trackingURL, id, models.OrderReturnStatusReceived, status == models.OrderReturnStatusReceived)
+}
+
+// ReceiveReturn marks an approved return received, e.g. a parcel sent without a
+// carrier label
+func (q *OrderReturnQueries) ReceiveReturn(id int) (*models.OrderReturn, error) {
+	r, err := q.updateReturn(`
+		UPDATE order_returns
+		SET status = $1, received_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2 AND status IN ($3, $4)
+		RETURNING `+orderReturnColumns,
+		models.OrderReturnStatusReceived, id, models.OrderReturnStatusApproved, models.OrderReturnStatusInTransit)
+	if err != nil && err.Error() == "return not found" {
+		return nil, fmt.Errorf("return not found or not approved")
+	}
+	return r, err
+}
+
+// MarkReturnLabelSent records that the customer was emailed the label of a return
+func (q *OrderReturnQueries) MarkReturnLabelSent(id int) error {
+	_, err := q.db.Exec("UPDATE order_returns SET label_sent_at = CURRENT_TIMESTAMP WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to mark return label sent: %w", err)
+	}
+	return nil
+}
+
+// updateReturn runs an UPDATE returning one return and loads its items
+func (q *OrderReturnQueries) updateReturn(query string, args ...interface{}) (*models.OrderReturn, error) {
+	var r models.OrderReturn
+	if err := scanOrderReturn(q.db.QueryRow(query, args...), &r); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("return not found")
+		}
+		return nil, fmt.Errorf("failed to update return: %w", err)
+	}
+
+	if err := q.loadReturnItems([]*models.OrderReturn{&r}); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// queryReturns runs a query selecting orderReturnColumns and loads the items
+func (q *OrderReturnQueries) queryReturns(query string, args ...interface{}) ([]models.OrderReturn, error) {
+	rows, err := q.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get returns: %w", err)
+	}
+	defer rows.Close()
+
+	returns := []models.OrderReturn{}
+	for rows.Next() {
+		var r models.OrderReturn
+		if err := scanOrderReturn(rows, &r); err != nil {
+			return nil, fmt.Errorf("failed to scan return: %w", err)
+		}
+		returns = append(returns, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate returns: %w", err)
+	}
+
+	pointers := make([]*models.OrderReturn, len(returns))
+	for i := range returns {
+		pointers[i] = &returns[i]
+	}
+	if err := q.loadReturnItems(pointers); err != nil {
+		return nil, err
+	}
+	return returns, nil
+}
+
+// loadReturnItems fills in the items of returns, described as they were ordered
+func (q *OrderReturnQueries) loadReturnItems(returns []*models.OrderReturn) error {
+	if len(returns) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(returns))
+	byID := map[int]*models.OrderReturn{}
+	for i, r := range returns {
+		ids[i] = int64(r.ID)
+		r.Items = []models.OrderReturnItem{}
+		byID[r.ID] = r
+	}
+
+	rows, err := q.db.Query(`
+		SELECT ri.return_id, ri.id, ri.order_item_id, oi.product_name, oi.variant_name, oi.size_name, ri.quantity
+		FROM order_return_items ri
+		JOIN order_items oi ON oi.id = ri.order_item_id
+		WHERE ri.return_id = ANY($1)
+		ORDER BY ri.id`,
+		pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to get return items: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var returnID int
+		var item models.OrderReturnItem
+		if err := rows.Scan(&returnID, &item.ID, &item.OrderItemID, &item.ProductName, &item.VariantName, &item.SizeName, &item.Quantity); err != nil {
+			return fmt.Errorf("failed to scan return item: %w", err)
+		}
+		byID[returnID].Items = append(byID[returnID].Items, item)
+	}
+	return rows.Err()
+}
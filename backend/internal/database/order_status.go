@@ -0,0 +1,137 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"notsofluffy-backend/internal/models"
+)
+
+type OrderStatusQueries struct {
+	db *sql.DB
+}
+
+func NewOrderStatusQueries(db *sql.DB) *OrderStatusQueries {
+	return &OrderStatusQueries{db: db}
+}
+
+// defaultOrderStatusColor is the color of custom statuses created without one
+const defaultOrderStatusColor = "#6b7280"
+
+// orderCustomStatusColumns selects an order's custom status and its public label, empty
+// labels as NULL
+const orderCustomStatusColumns = `custom_status_id, (SELECT NULLIF(public_label, '') FROM order_statuses WHERE id = orders.custom_status_id)`
+
+const orderStatusColumns = `id, code, label, public_label, color, core_status, sort_order, created_at, updated_at`
+
+func scanOrderStatus(scanner interface{ Scan(...interface{}) error }, s *models.OrderCustomStatus) error {
+	return scanner.Scan(&s.ID, &s.Code, &s.Label, &s.PublicLabel, &s.Color, &s.CoreStatus, &s.SortOrder, &s.CreatedAt, &s.UpdatedAt)
+}
+
+// ListOrderStatuses returns the custom order statuses grouped by core status in
+// workflow order
+func (q *OrderStatusQueries) ListOrderStatuses() ([]models.OrderCustomStatus, error) {
+	rows, err := q.db.Query(`
+		SELECT `+orderStatusColumns+`
+		FROM order_statuses
+		ORDER BY array_position($1::text[], core_status::text), sort_order, id`,
+		pq.Array(models.CoreOrderStatuses))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order statuses: %w", err)
+	}
+	defer rows.Close()
+
+	statuses := []models.OrderCustomStatus{}
+	for rows.Next() {
+		var s models.OrderCustomStatus
+		if err := scanOrderStatus(rows, &s); err != nil {
+			return nil, fmt.Errorf("failed to scan order status: %w", err)
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses, rows.Err()
+}
+
+// GetOrderStatusByCode retrieves a custom order status by code
+func (q *OrderStatusQueries) GetOrderStatusByCode(code string) (*models.OrderCustomStatus, error) {
+	var s models.OrderCustomStatus
+	err := scanOrderStatus(q.db.QueryRow("SELECT "+orderStatusColumns+" FROM order_statuses WHERE code = $1", code), &s)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("order status not found")
+		}
+		return nil, fmt.Errorf("failed to get order status: %w", err)
+	}
+	return &s, nil
+}
+
+// CreateOrderStatus creates a custom order status
+func (q *OrderStatusQueries) CreateOrderStatus(req *models.OrderCustomStatusRequest) (*models.OrderCustomStatus, error) {
+	var s models.OrderCustomStatus
+	err := scanOrderStatus(q.db.QueryRow(`
+		INSERT INTO order_statuses (code, label, public_label, color, core_status, sort_order)
+		VALUES ($1, $2, $3, COALESCE(NULLIF($4, ''), $7), $5, $6)
+		RETURNING `+orderStatusColumns,
+		req.Code, req.Label, req.PublicLabel, req.Color, req.CoreStatus, req.SortOrder, defaultOrderStatusColor,
+	), &s)
+	if err != nil {
+		if strings.Contains(err.Error(), "order_statuses_code_key") {
+			return nil, fmt.Errorf("order status code already exists")
+		}
+		return nil, fmt.Errorf("failed to create order status: %w", err)
+	}
+	return &s, nil
+}
+
+// UpdateOrderStatus updates a custom order status. Orders in it whose status differs
+// from a changed core status drop back to their core status.
+func (q *OrderStatusQueries) UpdateOrderStatus(id int, req *models.OrderCustomStatusRequest) (*models.OrderCustomStatus, error) {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var s models.OrderCustomStatus
+	err = scanOrderStatus(tx.QueryRow(`
+		UPDATE order_statuses
+		SET code = $1, label = $2, public_label = $3, color = COALESCE(NULLIF($4, ''), $8), core_status = $5, sort_order = $6,
+		 updated_at = CURRENT_TIMESTAMP
+		WHERE id = $7
+		RETURNING `+orderStatusColumns,
+		req.Code, req.Label, req.PublicLabel, req.Color, req.CoreStatus, req.SortOrder, id, defaultOrderStatusColor,
+	), &s)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("order status not found")
+		}
+		if strings.Contains(err.Error(), "order_statuses_code_key") {
+			return nil, fmt.Errorf("order status code already exists")
+		}
+		return nil, fmt.Errorf("failed to update order status: %w", err)
+	}
+
+	if _, err := tx.Exec(`UPDATE orders SET custom_status_id = NULL WHERE custom_status_id = $1 AND status <> $2`, id, s.CoreStatus); err != nil {
+		return nil, fmt.Errorf("failed to update orders in order status: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &s, nil
+}
+
+// DeleteOrderStatus deletes a custom order status; orders in it stay in its core status
+func (q *OrderStatusQueries) DeleteOrderStatus(id int) error {
+	result, err := q.db.Exec("DELETE FROM order_statuses WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete order status: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("order status not found")
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"notsofluffy-backend/internal/auth"
+	"notsofluffy-backend/internal/models"
+)
+
+// CreatePasswordResetToken issues a reset token for a user that works for ttl. Only
+// its hash is stored. It returns an empty token when the user already got
+// MaxPasswordResetsPerHour tokens in the last hour.
+func (q *UserQueries) CreatePasswordResetToken(userID int, ttl time.Duration, ipAddress string) (string, error) {
+	var recent int
+	err := q.db.QueryRow(`
+		SELECT COUNT(*) FROM password_reset_tokens
+		WHERE user_id = $1 AND created_at > CURRENT_TIMESTAMP - INTERVAL '1 hour'`, userID).Scan(&recent)
+	if err != nil {
+		return "", fmt.Errorf("failed to count password reset tokens: %w", err)
+	}
+	if recent >= models.MaxPasswordResetsPerHour {
+		return "", nil
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate password reset token: %w", err)
+	}
+	token := hex.EncodeToString(b)
+
+	_, err = q.db.Exec(`
+		INSERT INTO password_reset_tokens (user_id, token_hash, ip_address, expires_at)
+		VALUES ($1, $2, $3, CURRENT_TIMESTAMP + $4::int * INTERVAL '1 second')`,
+		userID, HashAPIKey(token), ipAddress, int(ttl.Seconds()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create password reset token: %w", err)
+	}
+	return token, nil
+}
+
+// ResetPassword sets a new password with a reset token. The token is used up, together
+// with every other open token of the user, and the user is signed out everywhere.
+func (q *UserQueries) ResetPassword(token, password string) (*models.User, error) {
+	hashedPassword, err := auth.HashPassword(password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	tx, err := q.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var userID int
+	err = tx.QueryRow(`
+		UPDATE password_reset_tokens SET used_at = CURRENT_TIMESTAMP
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+		RETURNING user_id`, HashAPIKey(token)).Scan(&userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("password reset token not found")
+		}
+		return nil, fmt.Errorf("failed to use password reset token: %w", err)
+	}
+
+	user := &models.User{ID: userID}
+	err = tx.QueryRow(`
+		UPDATE users SET password_hash = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+		RETURNING email, role, created_at, updated_at`, hashedPassword, userID,
+	).Scan(&user.Email, &user.Role, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update password: %w", err)
+	}
+
+	if _, err := tx.Exec(`UPDATE password_reset_tokens SET used_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND used_at IS NULL`, userID); err != nil {
+		return nil, fmt.Errorf("failed to use password reset tokens: %w", err)
+	}
+	if err := revokeUserSessions(tx, userID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return user, nil
+}
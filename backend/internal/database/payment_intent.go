@@ -0,0 +1,168 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"notsofluffy-backend/internal/models"
+	"notsofluffy-backend/internal/payments"
+)
+
+// paymentIntentReuseWindow is how long a started payment can be resumed before a new
+// one is created
+const paymentIntentReuseWindow = time.Hour
+
+type PaymentQueries struct {
+	db *sql.DB
+}
+
+func NewPaymentQueries(db *sql.DB) *PaymentQueries {
+	return &PaymentQueries{db: db}
+}
+
+const paymentIntentColumns = `id, order_id, provider, reference, provider_intent_id, amount, currency, status, redirect_url, client_secret, created_at, updated_at`
+
+func scanPaymentIntent(row interface{ Scan(...interface{}) error }, p *models.PaymentIntent) error {
+	return row.Scan(&p.ID, &p.OrderID, &p.Provider, &p.Reference, &p.ProviderIntentID, &p.Amount, &p.Currency, &p.Status,
+		&p.RedirectURL, &p.ClientSecret, &p.CreatedAt, &p.UpdatedAt)
+}
+
+// CreatePaymentIntent records a payment attempt before it is started at the provider
+func (q *PaymentQueries) CreatePaymentIntent(orderID int, provider, reference string, amount float64, currency string) (*models.PaymentIntent, error) {
+	var p models.PaymentIntent
+	err := scanPaymentIntent(q.db.QueryRow(`
+		INSERT INTO payment_intents (order_id, provider, reference, amount, currency)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING `+paymentIntentColumns,
+		orderID, provider, reference, amount, currency), &p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create payment intent: %w", err)
+	}
+	return &p, nil
+}
+
+// AttachProviderIntent stores what the provider returned for a started payment
+func (q *PaymentQueries) AttachProviderIntent(id int, intent *payments.Intent) (*models.PaymentIntent, error) {
+	var p models.PaymentIntent
+	err := scanPaymentIntent(q.db.QueryRow(`
+		UPDATE payment_intents
+		SET provider_intent_id = $1, redirect_url = NULLIF($2, ''), client_secret = NULLIF($3, '')
+		WHERE id = $4
+		RETURNING `+paymentIntentColumns,
+		intent.ProviderIntentID, intent.RedirectURL, intent.ClientSecret, id), &p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update payment intent: %w", err)
+	}
+	return &p, nil
+}
+
+// FailPaymentIntent marks a payment the provider refused to start as failed
+func (q *PaymentQueries) FailPaymentIntent(id int) error {
+	_, err := q.db.Exec(`UPDATE payment_intents SET status = $1 WHERE id = $2 AND status = $3`,
+		payments.IntentStatusFailed, id, payments.IntentStatusPending)
+	if err != nil {
+		return fmt.Errorf("failed to update payment intent: %w", err)
+	}
+	return nil
+}
+
+// GetResumablePaymentIntent returns a recently started, still pending payment of an order
+// for amount, so paying again continues it instead of starting another one
+func (q *PaymentQueries) GetResumablePaymentIntent(orderID int, provider string, amount float64) (*models.PaymentIntent, error) {
+	var p models.PaymentIntent
+	err := scanPaymentIntent(q.db.QueryRow(`
+		SELECT `+paymentIntentColumns+` FROM payment_intents
+		WHERE order_id = $1 AND provider = $2 AND amount = $3 AND status = $4
+		  AND provider_intent_id IS NOT NULL AND created_at > $5
+		ORDER BY created_at DESC
+		LIMIT 1`,
+		orderID, provider, amount, payments.IntentStatusPending, time.Now().Add(-paymentIntentReuseWindow)), &p)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("payment intent not found")
+		}
+		return nil, fmt.Errorf("failed to get payment intent: %w", err)
+	}
+	return &p, nil
+}
+
+// ApplyPaymentEvent applies a verified provider update to its payment intent and the
+// order's payment status in one transaction. A succeeded payment marks the order paid
+// and moves a pending order on to processing; a failed one marks a pending payment
+// failed. Repeated updates are ignored. It returns the order's status change.
+func (q *PaymentQueries) ApplyPaymentEvent(provider string, event *payments.CallbackEvent) (*models.OrderStatusChangedEvent, error) {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var intentID, orderID int
+	var amount float64
+	var status string
+	err = tx.QueryRow(`
+		SELECT id, order_id, amount, status FROM payment_intents
+		WHERE provider = $1 AND provider_intent_id = $2
+		FOR UPDATE`, provider, event.ProviderIntentID).Scan(&intentID, &orderID, &amount, &status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("payment intent not found")
+		}
+		return nil, fmt.Errorf("failed to get payment intent: %w", err)
+	}
+
+	orderStatus, paymentStatus, err := lockOrderState(tx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	change := &models.OrderStatusChangedEvent{OrderID: orderID, PreviousStatus: orderStatus, Status: orderStatus}
+	if err := tx.QueryRow(`SELECT email, is_test FROM orders WHERE id = $1`, orderID).Scan(&change.Email, &change.IsTest); err != nil {
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	// A succeeded payment is final; a failed one can still succeed when the customer retries
+	if status == payments.IntentStatusSucceeded || status == event.Status {
+		return change, tx.Commit()
+	}
+
+	if event.Status == payments.IntentStatusSucceeded && math.Abs(event.Amount-amount) > 0.005 {
+		return nil, fmt.Errorf("payment amount %.2f does not match intent amount %.2f", event.Amount, amount)
+	}
+
+	if _, err := tx.Exec(`UPDATE payment_intents SET status = $1 WHERE id = $2`, event.Status, intentID); err != nil {
+		return nil, fmt.Errorf("failed to update payment intent: %w", err)
+	}
+
+	switch event.Status {
+	case payments.IntentStatusSucceeded:
+		if paymentStatus == models.PaymentStatusPending || paymentStatus == models.PaymentStatusFailed {
+			err = tx.QueryRow(`
+				UPDATE orders
+				SET payment_status = $1, status = CASE WHEN status = $2 THEN $3 ELSE status END,
+					custom_status_id = CASE WHEN status = $2 THEN NULL ELSE custom_status_id END
+				WHERE id = $4
+				RETURNING status`,
+				models.PaymentStatusCompleted, models.OrderStatusPending, models.OrderStatusProcessing, orderID).Scan(&change.Status)
+			if err != nil {
+				return nil, fmt.Errorf("failed to mark order paid: %w", err)
+			}
+			if err := confirmOrderDiscount(tx, orderID); err != nil {
+				return nil, err
+			}
+		}
+	case payments.IntentStatusFailed:
+		if paymentStatus == models.PaymentStatusPending {
+			_, err = tx.Exec(`UPDATE orders SET payment_status = $1 WHERE id = $2`, models.PaymentStatusFailed, orderID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to mark order payment failed: %w", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return change, nil
+}
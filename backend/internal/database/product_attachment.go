@@ -0,0 +1,161 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"notsofluffy-backend/internal/models"
+)
+
+type AttachmentQueries struct {
+	db *sql.DB
+}
+
+func NewAttachmentQueries(db *sql.DB) *AttachmentQueries {
+	return &AttachmentQueries{db: db}
+}
+
+const attachmentColumns = `id, product_id, title, filename, original_name, path, size_bytes, mime_type,
+	position, download_count, uploaded_by, created_at, updated_at`
+
+func scanAttachment(row interface{ Scan(...interface{}) error }, a *models.ProductAttachment) error {
+	return row.Scan(&a.ID, &a.ProductID, &a.Title, &a.Filename, &a.OriginalName, &a.Path, &a.SizeBytes, &a.MimeType,
+		&a.Position, &a.DownloadCount, &a.UploadedBy, &a.CreatedAt, &a.UpdatedAt)
+}
+
+// ListProductAttachments returns the attachments of a product in display order
+func (q *AttachmentQueries) ListProductAttachments(productID int) ([]models.ProductAttachment, error) {
+	rows, err := q.db.Query(`SELECT `+attachmentColumns+` FROM product_attachments
+		WHERE product_id = $1 ORDER BY position, id`, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list product attachments: %w", err)
+	}
+	defer rows.Close()
+
+	attachments := []models.ProductAttachment{}
+	for rows.Next() {
+		var a models.ProductAttachment
+		if err := scanAttachment(rows, &a); err != nil {
+			return nil, fmt.Errorf("failed to scan product attachment: %w", err)
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, rows.Err()
+}
+
+// CreateAttachment adds an attachment at the end of the product's list
+func (q *AttachmentQueries) CreateAttachment(a *models.ProductAttachment) error {
+	query := `
+		INSERT INTO product_attachments (product_id, title, filename, original_name, path, size_bytes, mime_type, position, uploaded_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7,
+			(SELECT COALESCE(MAX(position) + 1, 0) FROM product_attachments WHERE product_id = $1), $8)
+		RETURNING id, position, download_count, created_at, updated_at`
+
+	err := q.db.QueryRow(query, a.ProductID, a.Title, a.Filename, a.OriginalName, a.Path, a.SizeBytes, a.MimeType, a.UploadedBy).
+		Scan(&a.ID, &a.Position, &a.DownloadCount, &a.CreatedAt, &a.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create product attachment: %w", err)
+	}
+	return nil
+}
+
+// GetAttachment returns an attachment of a product
+func (q *AttachmentQueries) GetAttachment(productID, id int) (*models.ProductAttachment, error) {
+	var a models.ProductAttachment
+	err := scanAttachment(q.db.QueryRow(`SELECT `+attachmentColumns+` FROM product_attachments
+		WHERE id = $1 AND product_id = $2`, id, productID), &a)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("attachment not found")
+		}
+		return nil, fmt.Errorf("failed to get product attachment: %w", err)
+	}
+	return &a, nil
+}
+
+// GetPublicAttachment returns an attachment for download if its product is sold in the web shop
+func (q *AttachmentQueries) GetPublicAttachment(id int) (*models.ProductAttachment, error) {
+	var a models.ProductAttachment
+	err := scanAttachment(q.db.QueryRow(`SELECT `+attachmentColumns+` FROM product_attachments
+		WHERE id = $1 AND EXISTS (SELECT 1 FROM products p WHERE p.id = product_id AND p.visible_web = true)`, id), &a)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("attachment not found")
+		}
+		return nil, fmt.Errorf("failed to get product attachment: %w", err)
+	}
+	return &a, nil
+}
+
+// UpdateAttachment changes the title and optionally the position of an attachment
+func (q *AttachmentQueries) UpdateAttachment(productID, id int, req *models.ProductAttachmentUpdateRequest) error {
+	result, err := q.db.Exec(`
+		UPDATE product_attachments
+		SET title = $3, position = COALESCE($4, position), updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND product_id = $2`, id, productID, req.Title, req.Position)
+	if err != nil {
+		return fmt.Errorf("failed to update product attachment: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("attachment not found")
+	}
+	return nil
+}
+
+// ReorderAttachments sets positions in the given order; every id must belong to the product
+func (q *AttachmentQueries) ReorderAttachments(productID int, ids []int) error {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for position, id := range ids {
+		result, err := tx.Exec(`
+			UPDATE product_attachments SET position = $3, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $1 AND product_id = $2`, id, productID, position)
+		if err != nil {
+			return fmt.Errorf("failed to reorder product attachments: %w", err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			return fmt.Errorf("attachment not found")
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// DeleteAttachment removes an attachment and returns it so the file can be deleted
+func (q *AttachmentQueries) DeleteAttachment(productID, id int) (*models.ProductAttachment, error) {
+	var a models.ProductAttachment
+	err := scanAttachment(q.db.QueryRow(`DELETE FROM product_attachments
+		WHERE id = $1 AND product_id = $2 RETURNING `+attachmentColumns, id, productID), &a)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("attachment not found")
+		}
+		return nil, fmt.Errorf("failed to delete product attachment: %w", err)
+	}
+	return &a, nil
+}
+
+// IncrementDownloadCount counts a download of an attachment
+func (q *AttachmentQueries) IncrementDownloadCount(id int) error {
+	_, err := q.db.Exec(`UPDATE product_attachments SET download_count = download_count + 1 WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to count attachment download: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"notsofluffy-backend/internal/models"
+)
+
+// GetProductFulfillment returns whether a product is made to order and its lead time
+func (q *ProductQueries) GetProductFulfillment(productID int) (*models.ProductFulfillment, error) {
+	fulfillment := &models.ProductFulfillment{ProductID: productID}
+	err := q.db.QueryRow(`SELECT made_to_order, lead_time_days FROM products WHERE id = $1`, productID).
+		Scan(&fulfillment.MadeToOrder, &fulfillment.LeadTimeDays)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("product not found")
+		}
+		return nil, fmt.Errorf("failed to get product fulfillment: %w", err)
+	}
+	return fulfillment, nil
+}
+
+// UpdateProductFulfillment sets whether a product is made to order and its lead time
+func (q *ProductQueries) UpdateProductFulfillment(productID int, req *models.ProductFulfillmentRequest) (*models.ProductFulfillment, error) {
+	fulfillment := &models.ProductFulfillment{ProductID: productID}
+	err := q.db.QueryRow(`
+		UPDATE products SET made_to_order = $2, lead_time_days = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING made_to_order, lead_time_days`, productID, req.MadeToOrder, req.LeadTimeDays).
+		Scan(&fulfillment.MadeToOrder, &fulfillment.LeadTimeDays)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("product not found")
+		}
+		return nil, fmt.Errorf("failed to update product fulfillment: %w", err)
+	}
+	return fulfillment, nil
+}
+
+// GetSizeStock returns the stock left of a size of a product sold in the web shop, less
+// what checkouts have reserved. LeadTimeDays is the product's own lead time, or 0.
+func (q *ProductQueries) GetSizeStock(productID, sizeID int) (*models.SizeStock, error) {
+	stock := &models.SizeStock{ProductID: productID, SizeID: sizeID}
+	var available sql.NullInt64
+	var leadTimeDays sql.NullInt64
+	err := q.db.QueryRow(`
+		SELECT CASE WHEN s.use_stock THEN GREATEST(0, s.stock_quantity - s.reserved_quantity) END,
+		 p.made_to_order, p.lead_time_days
+		FROM sizes s
+		JOIN products p ON p.id = s.product_id
+		WHERE s.id = $1 AND s.product_id = $2 AND p.visible_web`, sizeID, productID).
+		Scan(&available, &stock.MadeToOrder, &leadTimeDays)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("size not found")
+		}
+		return nil, fmt.Errorf("failed to check stock availability: %w", err)
+	}
+	if available.Valid {
+		quantity := int(available.Int64)
+		stock.Available = &quantity
+	}
+	stock.LeadTimeDays = int(leadTimeDays.Int64)
+	return stock, nil
+}
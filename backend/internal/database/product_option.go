@@ -0,0 +1,323 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+	"notsofluffy-backend/internal/models"
+)
+
+type OptionQueries struct {
+	db *sql.DB
+}
+
+func NewOptionQueries(db *sql.DB) *OptionQueries {
+	return &OptionQueries{db: db}
+}
+
+// ListProductOptionGroups returns the option groups of a product with their values, in
+// display order
+func (q *OptionQueries) ListProductOptionGroups(productID int) ([]models.ProductOptionGroup, error) {
+	rows, err := q.db.Query(`
+		SELECT id, product_id, name, required, position, created_at, updated_at
+		FROM product_option_groups
+		WHERE product_id = $1
+		ORDER BY position, id`, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list product option groups: %w", err)
+	}
+	defer rows.Close()
+
+	groups := []models.ProductOptionGroup{}
+	index := map[int]int{}
+	for rows.Next() {
+		var group models.ProductOptionGroup
+		if err := rows.Scan(&group.ID, &group.ProductID, &group.Name, &group.Required, &group.Position, &group.CreatedAt, &group.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan product option group: %w", err)
+		}
+		group.Values = []models.ProductOptionValue{}
+		index[group.ID] = len(groups)
+		groups = append(groups, group)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list product option groups: %w", err)
+	}
+	if len(groups) == 0 {
+		return groups, nil
+	}
+
+	valueRows, err := q.db.Query(`
+		SELECT v.id, v.group_id, v.name, v.image_id, i.path, v.surcharge, v.position, v.created_at, v.updated_at
+		FROM product_option_values v
+		JOIN product_option_groups g ON g.id = v.group_id
+		LEFT JOIN images i ON i.id = v.image_id
+		WHERE g.product_id = $1
+		ORDER BY v.position, v.id`, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list product option values: %w", err)
+	}
+	defer valueRows.Close()
+
+	for valueRows.Next() {
+		var value models.ProductOptionValue
+		if err := valueRows.Scan(&value.ID, &value.GroupID, &value.Name, &value.ImageID, &value.ImagePath,
+			&value.Surcharge, &value.Position, &value.CreatedAt, &value.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan product option value: %w", err)
+		}
+		if i, ok := index[value.GroupID]; ok {
+			groups[i].Values = append(groups[i].Values, value)
+		}
+	}
+	if err := valueRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list product option values: %w", err)
+	}
+	return groups, nil
+}
+
+// GetOptionGroup returns an option group of a product with its values
+func (q *OptionQueries) GetOptionGroup(productID, id int) (*models.ProductOptionGroup, error) {
+	groups, err := q.ListProductOptionGroups(productID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range groups {
+		if groups[i].ID == id {
+			return &groups[i], nil
+		}
+	}
+	return nil, fmt.Errorf("option group not found")
+}
+
+// CreateOptionGroup adds an option group with its values at the end of the product's groups
+func (q *OptionQueries) CreateOptionGroup(productID int, req *models.ProductOptionGroupRequest) (*models.ProductOptionGroup, error) {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	required := req.Required == nil || *req.Required
+	var id int
+	err = tx.QueryRow(`
+		INSERT INTO product_option_groups (product_id, name, required, position)
+		VALUES ($1, $2, $3, COALESCE($4, (SELECT COALESCE(MAX(position) + 1, 0) FROM product_option_groups WHERE product_id = $1)))
+		RETURNING id`, productID, strings.TrimSpace(req.Name), required, req.Position).Scan(&id)
+	if err != nil {
+		return nil, optionError("failed to create option group", err)
+	}
+
+	for position, value := range req.Values {
+		if err := insertOptionValue(tx, id, position, &value); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return q.GetOptionGroup(productID, id)
+}
+
+// UpdateOptionGroup replaces an option group and its values. Values keep their ID, so
+// carts holding them stay valid; values left out of the request are deleted.
+func (q *OptionQueries) UpdateOptionGroup(productID, id int, req *models.ProductOptionGroupRequest) (*models.ProductOptionGroup, error) {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE product_option_groups
+		SET name = $3, required = COALESCE($4, required), position = COALESCE($5, position), updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND product_id = $2`, id, productID, strings.TrimSpace(req.Name), req.Required, req.Position)
+	if err != nil {
+		return nil, optionError("failed to update option group", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return nil, fmt.Errorf("option group not found")
+	}
+
+	// Delete the dropped values first so the kept ones can take over their names
+	keep := []int{}
+	for _, value := range req.Values {
+		if value.ID != nil {
+			keep = append(keep, *value.ID)
+		}
+	}
+	if _, err := tx.Exec(`DELETE FROM product_option_values WHERE group_id = $1 AND NOT (id = ANY($2))`, id, pq.Array(keep)); err != nil {
+		return nil, fmt.Errorf("failed to delete option values: %w", err)
+	}
+
+	for position, value := range req.Values {
+		if value.ID == nil {
+			if err := insertOptionValue(tx, id, position, &value); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		result, err := tx.Exec(`
+			UPDATE product_option_values
+			SET name = $3, image_id = $4, surcharge = $5, position = $6, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $1 AND group_id = $2`, *value.ID, id, strings.TrimSpace(value.Name), value.ImageID, value.Surcharge, position)
+		if err != nil {
+			return nil, optionError("failed to update option value", err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			return nil, fmt.Errorf("option value not found")
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return q.GetOptionGroup(productID, id)
+}
+
+// DeleteOptionGroup removes an option group with its values; cart items holding one of
+// them become unavailable
+func (q *OptionQueries) DeleteOptionGroup(productID, id int) error {
+	result, err := q.db.Exec(`DELETE FROM product_option_groups WHERE id = $1 AND product_id = $2`, id, productID)
+	if err != nil {
+		return fmt.Errorf("failed to delete option group: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("option group not found")
+	}
+	return nil
+}
+
+// CreateColorOptionGroup copies the colors of a product's variants into a new option
+// group, one value per color with its image, as the first step of moving a product from
+// color variants to option groups. The variants are left as they are.
+func (q *OptionQueries) CreateColorOptionGroup(productID int, name string) (*models.ProductOptionGroup, error) {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow(`
+		INSERT INTO product_option_groups (product_id, name, required, position)
+		VALUES ($1, $2, true, (SELECT COALESCE(MAX(position) + 1, 0) FROM product_option_groups WHERE product_id = $1))
+		RETURNING id`, productID, name).Scan(&id)
+	if err != nil {
+		return nil, optionError("failed to create option group", err)
+	}
+
+	// The default variant's color comes first, then colors in the order they were added
+	result, err := tx.Exec(`
+		INSERT INTO product_option_values (group_id, name, image_id, position)
+		SELECT $1, colors.name, colors.image_id, ROW_NUMBER() OVER (ORDER BY colors.is_default DESC, colors.first_variant) - 1
+		FROM (
+			SELECT LEFT(c.name, 100) AS name, MIN(c.image_id) AS image_id,
+				BOOL_OR(pv.is_default) AS is_default, MIN(pv.id) AS first_variant
+			FROM product_variants pv
+			JOIN colors c ON c.id = pv.color_id
+			WHERE pv.product_id = $2
+			GROUP BY LEFT(c.name, 100)
+		) colors`, id, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy variant colors: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return nil, fmt.Errorf("product has no variants")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return q.GetOptionGroup(productID, id)
+}
+
+// ResolveOptionSelection matches the values chosen for an item against the product's
+// option groups and returns them with their current names and surcharges: each
+// value must belong to one of them, at most one value per group, and every required
+// group needs a value. The selections are returned in group order.
+func ResolveOptionSelection(groups []models.ProductOptionGroup, valueIDs []int) ([]models.ProductOptionSelection, error) {
+	chosen := map[int]bool{}
+	for _, id := range valueIDs {
+		chosen[id] = true
+	}
+
+	selections := []models.ProductOptionSelection{}
+	matched := 0
+	for _, group := range groups {
+		var selection *models.ProductOptionSelection
+		for _, value := range group.Values {
+			if !chosen[value.ID] {
+				continue
+			}
+			if selection != nil {
+				return nil, fmt.Errorf("only one value can be chosen for %s", group.Name)
+			}
+			selection = &models.ProductOptionSelection{
+				OptionGroupID: group.ID,
+				OptionValueID: value.ID,
+				GroupName:     group.Name,
+				ValueName:     value.Name,
+				Surcharge:     value.Surcharge,
+			}
+			matched++
+		}
+		if selection == nil {
+			if group.Required {
+				return nil, fmt.Errorf("%s must be chosen", group.Name)
+			}
+			continue
+		}
+		selections = append(selections, *selection)
+	}
+
+	if matched != len(chosen) {
+		return nil, fmt.Errorf("invalid option value for this product")
+	}
+	return selections, nil
+}
+
+func insertOptionValue(tx *sql.Tx, groupID, position int, value *models.ProductOptionValueRequest) error {
+	_, err := tx.Exec(`
+		INSERT INTO product_option_values (group_id, name, image_id, surcharge, position)
+		VALUES ($1, $2, $3, $4, $5)`, groupID, strings.TrimSpace(value.Name), value.ImageID, value.Surcharge, position)
+	if err != nil {
+		return optionError("failed to create option value", err)
+	}
+	return nil
+}
+
+// optionError turns constraint violations of option groups and values into errors the
+// handlers can show
+func optionError(message string, err error) error {
+	errMsg := err.Error()
+	switch {
+	case strings.Contains(errMsg, "product_option_groups_product_id_name_key"):
+		return fmt.Errorf("option group already exists")
+	case strings.Contains(errMsg, "product_option_values_group_id_name_key"):
+		return fmt.Errorf("option value already exists")
+	case strings.Contains(errMsg, "product_option_groups_product_id_fkey"):
+		return fmt.Errorf("product not found")
+	case strings.Contains(errMsg, "product_option_values_image_id_fkey"):
+		return fmt.Errorf("image not found")
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
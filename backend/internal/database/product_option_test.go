@@ -0,0 +1,47 @@
+package database
+
+import (
+	"testing"
+
+	"notsofluffy-backend/internal/models"
+)
+
+func TestResolveOptionSelection(t *testing.T) {
+	groups := []models.ProductOptionGroup{
+		{ID: 1, Name: "Pattern", Required: true, Values: []models.ProductOptionValue{
+			{ID: 10, Name: "Plain"},
+			{ID: 11, Name: "Checked", Surcharge: 25},
+		}},
+		{ID: 2, Name: "Trim", Required: false, Values: []models.ProductOptionValue{
+			{ID: 20, Name: "Piping", Surcharge: 15},
+		}},
+	}
+
+	selections, err := ResolveOptionSelection(groups, []int{11, 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(selections) != 2 || selections[0].OptionGroupID != 1 || selections[0].Surcharge != 25 || selections[1].ValueName != "Piping" {
+		t.Fatalf("unexpected selections %+v", selections)
+	}
+
+	selections, err = ResolveOptionSelection(groups, []int{10})
+	if err != nil || len(selections) != 1 {
+		t.Fatalf("optional group should be skippable, got %+v, %v", selections, err)
+	}
+
+	for name, ids := range map[string][]int{
+		"missing required group":   {20},
+		"two values of a group":    {10, 11},
+		"value of another product": {10, 99},
+	} {
+		if _, err := ResolveOptionSelection(groups, ids); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	selections, err = ResolveOptionSelection(nil, nil)
+	if err != nil || len(selections) != 0 {
+		t.Fatalf("product without options: got %+v, %v", selections, err)
+	}
+}
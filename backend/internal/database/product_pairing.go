@@ -0,0 +1,224 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"notsofluffy-backend/internal/models"
+)
+
+type PairingQueries struct {
+	db       *sql.DB
+	settings *SettingsQueries
+}
+
+func NewPairingQueries(db *sql.DB) *PairingQueries {
+	return &PairingQueries{db: db, settings: NewSettingsQueries(db)}
+}
+
+// RecomputePairings rebuilds the frequently bought together table from order history.
+// Pairs are ranked by the number of orders containing both products; the score is the
+// share of the product's orders that also contained the related product.
+func (q *PairingQueries) RecomputePairings() (*models.PairingRecomputeResult, error) {
+	lookbackDays, err := q.settings.GetIntSetting(models.SettingPairingLookbackDays, 365)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pairing lookback: %w", err)
+	}
+	minOrders, err := q.settings.GetIntSetting(models.SettingPairingMinOrders, 2)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pairing minimum orders: %w", err)
+	}
+	if minOrders < 1 {
+		minOrders = 1
+	}
+
+	tx, err := q.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM product_pairings`); err != nil {
+		return nil, fmt.Errorf("failed to clear product pairings: %w", err)
+	}
+
+	computedAt := time.Now()
+	query := `
+		WITH recent AS (
+			SELECT DISTINCT oi.order_id, oi.product_id
+			FROM order_items oi
+			JOIN orders o ON o.id = oi.order_id
+			WHERE o.status <> $1 AND NOT o.is_test AND ($2::int <= 0 OR o.created_at >= NOW() - make_interval(days => $2::int))
+		),
+		product_orders AS (
+			SELECT product_id, COUNT(*) AS orders FROM recent GROUP BY product_id
+		),
+		pairs AS (
+			SELECT a.product_id, b.product_id AS related_product_id, COUNT(*) AS co_orders
+			FROM recent a
+			JOIN recent b ON a.order_id = b.order_id AND a.product_id <> b.product_id
+			GROUP BY a.product_id, b.product_id
+			HAVING COUNT(*) >= $3
+		),
+		ranked AS (
+			SELECT pairs.product_id, pairs.related_product_id, pairs.co_orders,
+				pairs.co_orders::decimal / po.orders AS score,
+				ROW_NUMBER() OVER (PARTITION BY pairs.product_id ORDER BY pairs.co_orders DESC, pairs.related_product_id) AS pair_rank
+			FROM pairs
+			JOIN product_orders po ON po.product_id = pairs.product_id
+		)
+		INSERT INTO product_pairings (product_id, related_product_id, co_orders, score, computed_at)
+		SELECT r.product_id, r.related_product_id, r.co_orders, ROUND(r.score, 4), $5
+		FROM ranked r
+		JOIN products p ON p.id = r.product_id
+		JOIN products related ON related.id = r.related_product_id
+		WHERE r.pair_rank <= $4`
+
+	result, err := tx.Exec(query, models.OrderStatusCancelled, lookbackDays, minOrders, models.MaxPairingsPerProduct, computedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute product pairings: %w", err)
+	}
+	pairs, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &models.PairingRecomputeResult{Pairs: pairs, ComputedAt: computedAt}, nil
+}
+
+// GetFrequentlyBoughtTogether returns suggestions for the product page: pinned products
+// first, then computed pairs that were not excluded, limited to products sold in the web shop
+func (q *PairingQueries) GetFrequentlyBoughtTogether(productID, limit int) ([]models.PairedProduct, error) {
+	query := `
+		WITH candidates AS (
+			SELECT o.related_product_id, true AS pinned, 0 AS grp, o.position::decimal AS ord
+			FROM product_pairing_overrides o
+			WHERE o.product_id = $1 AND o.mode = $2
+			UNION ALL
+			SELECT pp.related_product_id, false, 1, -pp.score
+			FROM product_pairings pp
+			WHERE pp.product_id = $1 AND NOT EXISTS (
+				SELECT 1 FROM product_pairing_overrides o
+				WHERE o.product_id = pp.product_id AND o.related_product_id = pp.related_product_id
+			)
+		)
+		SELECT p.id, p.name, COALESCE(p.slug, ''), i.path, (SELECT MIN(s.base_price) FROM sizes s WHERE s.product_id = p.id), cand.pinned
+		FROM candidates cand
+		JOIN products p ON p.id = cand.related_product_id
+		JOIN images i ON i.id = p.main_image_id
+		LEFT JOIN categories c ON c.id = p.category_id
+		WHERE p.visible_web = true AND (c.active = true OR c.id IS NULL)
+		ORDER BY cand.grp, cand.ord, p.id
+		LIMIT $3`
+
+	rows, err := q.db.Query(query, productID, models.PairingModePin, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get frequently bought together products: %w", err)
+	}
+	defer rows.Close()
+
+	products := []models.PairedProduct{}
+	for rows.Next() {
+		var product models.PairedProduct
+		var minPrice sql.NullFloat64
+		if err := rows.Scan(&product.ID, &product.Name, &product.Slug, &product.ImagePath, &minPrice, &product.Pinned); err != nil {
+			return nil, fmt.Errorf("failed to scan paired product: %w", err)
+		}
+		if minPrice.Valid {
+			product.MinPrice = &minPrice.Float64
+		}
+		products = append(products, product)
+	}
+
+	return products, rows.Err()
+}
+
+// ListProductPairings returns computed pairs and overrides of a product for the admin panel
+func (q *PairingQueries) ListProductPairings(productID int) ([]models.ProductPairing, error) {
+	query := `
+		SELECT p.id, p.name, COALESCE(pp.co_orders, 0), COALESCE(pp.score, 0), pp.computed_at, o.mode, o.position
+		FROM (
+			SELECT related_product_id FROM product_pairings WHERE product_id = $1
+			UNION
+			SELECT related_product_id FROM product_pairing_overrides WHERE product_id = $1
+		) ids
+		JOIN products p ON p.id = ids.related_product_id
+		LEFT JOIN product_pairings pp ON pp.product_id = $1 AND pp.related_product_id = ids.related_product_id
+		LEFT JOIN product_pairing_overrides o ON o.product_id = $1 AND o.related_product_id = ids.related_product_id
+		ORDER BY (o.mode = 'pin') DESC NULLS LAST, o.position, pp.score DESC NULLS LAST, p.id`
+
+	rows, err := q.db.Query(query, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list product pairings: %w", err)
+	}
+	defer rows.Close()
+
+	pairings := []models.ProductPairing{}
+	for rows.Next() {
+		var pairing models.ProductPairing
+		var computedAt sql.NullTime
+		var mode sql.NullString
+		var position sql.NullInt64
+		err := rows.Scan(&pairing.RelatedProductID, &pairing.RelatedProductName, &pairing.CoOrders, &pairing.Score,
+			&computedAt, &mode, &position)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan product pairing: %w", err)
+		}
+		if computedAt.Valid {
+			pairing.ComputedAt = &computedAt.Time
+		}
+		if mode.Valid {
+			pairing.Override = &mode.String
+			pos := int(position.Int64)
+			pairing.Position = &pos
+		}
+		pairings = append(pairings, pairing)
+	}
+
+	return pairings, rows.Err()
+}
+
+// SetPairingOverride pins or excludes a related product for a product
+func (q *PairingQueries) SetPairingOverride(productID, relatedProductID int, req *models.ProductPairingOverrideRequest, userID *int) error {
+	if productID == relatedProductID {
+		return fmt.Errorf("product cannot be paired with itself")
+	}
+
+	query := `
+		INSERT INTO product_pairing_overrides (product_id, related_product_id, mode, position, created_by)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (product_id, related_product_id)
+		DO UPDATE SET mode = EXCLUDED.mode, position = EXCLUDED.position, created_by = EXCLUDED.created_by, created_at = CURRENT_TIMESTAMP`
+
+	_, err := q.db.Exec(query, productID, relatedProductID, req.Mode, req.Position, userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "foreign key") {
+			return fmt.Errorf("product not found")
+		}
+		return fmt.Errorf("failed to set pairing override: %w", err)
+	}
+	return nil
+}
+
+// DeletePairingOverride removes a pin or exclusion so the computed suggestion applies again
+func (q *PairingQueries) DeletePairingOverride(productID, relatedProductID int) error {
+	result, err := q.db.Exec(`DELETE FROM product_pairing_overrides WHERE product_id = $1 AND related_product_id = $2`, productID, relatedProductID)
+	if err != nil {
+		return fmt.Errorf("failed to delete pairing override: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("pairing override not found")
+	}
+	return nil
+}
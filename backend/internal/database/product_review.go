@@ -0,0 +1,230 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+	"notsofluffy-backend/internal/models"
+)
+
+type ProductReviewQueries struct {
+	db *sql.DB
+}
+
+func NewProductReviewQueries(db *sql.DB) *ProductReviewQueries {
+	return &ProductReviewQueries{db: db}
+}
+
+// productReviewOrderStatuses are the order statuses that count as having bought a product:
+// the parcel is on its way or has arrived
+var productReviewOrderStatuses = []string{models.OrderStatusShipped, models.OrderStatusDelivered}
+
+const productReviewColumns = `r.id, r.product_id, r.user_id, r.order_id, r.rating, r.title, r.body, r.status,
+	r.admin_note, r.moderated_by, r.moderated_at, r.created_at, r.updated_at,
+	TRIM(COALESCE(up.first_name, '') || ' ' || COALESCE(LEFT(up.last_name, 1) || '.', '')), p.name`
+
+const productReviewFrom = `
+	FROM product_reviews r
+	JOIN products p ON p.id = r.product_id
+	LEFT JOIN user_profiles up ON up.user_id = r.user_id`
+
+func scanProductReview(scanner interface{ Scan(...interface{}) error }) (*models.ProductReview, error) {
+	var r models.ProductReview
+	err := scanner.Scan(&r.ID, &r.ProductID, &r.UserID, &r.OrderID, &r.Rating, &r.Title, &r.Body, &r.Status,
+		&r.AdminNote, &r.ModeratedBy, &r.ModeratedAt, &r.CreatedAt, &r.UpdatedAt, &r.AuthorName, &r.ProductName)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// CreateProductReview stores a pending review by a customer who bought the product in
+// a shipped or delivered order; each customer reviews a product once
+func (q *ProductReviewQueries) CreateProductReview(productID, userID int, req models.CreateProductReviewRequest) (*models.ProductReview, error) {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var orderID int
+	err = tx.QueryRow(`
+		SELECT o.id FROM orders o
+		JOIN order_items oi ON oi.order_id = o.id
+		WHERE o.user_id = $1 AND oi.product_id = $2 AND o.status = ANY($3)
+		ORDER BY o.created_at DESC
+		LIMIT 1`, userID, productID, pq.Array(productReviewOrderStatuses)).Scan(&orderID)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("product not purchased")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to check purchase: %w", err)
+	}
+
+	var id int
+	err = tx.QueryRow(`
+		INSERT INTO product_reviews (product_id, user_id, order_id, rating, title, body, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (product_id, user_id) DO NOTHING
+		RETURNING id`,
+		productID, userID, orderID, req.Rating, trimmedOrNil(req.Title), trimmedOrNil(req.Body), models.ProductReviewStatusPending,
+	).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("product already reviewed")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create product review: %w", err)
+	}
+
+	review, err := scanProductReview(tx.QueryRow("SELECT "+productReviewColumns+productReviewFrom+" WHERE r.id = $1", id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product review: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return review, nil
+}
+
+// ListProductReviews returns a page of reviews, newest first, optionally only those of
+// one product and with one status
+func (q *ProductReviewQueries) ListProductReviews(page, limit int, productID *int, status string) (*models.ProductReviewListResponse, error) {
+	offset := (page - 1) * limit
+
+	var conditions []string
+	args := []interface{}{}
+	if productID != nil {
+		args = append(args, *productID)
+		conditions = append(conditions, fmt.Sprintf("r.product_id = $%d", len(args)))
+	}
+	if status != "" {
+		args = append(args, status)
+		conditions = append(conditions, fmt.Sprintf("r.status = $%d", len(args)))
+	}
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := q.db.QueryRow("SELECT COUNT(*) FROM product_reviews r"+whereClause, args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count product reviews: %w", err)
+	}
+
+	query := fmt.Sprintf("SELECT %s%s%s ORDER BY r.created_at DESC, r.id DESC LIMIT $%d OFFSET $%d",
+		productReviewColumns, productReviewFrom, whereClause, len(args)+1, len(args)+2)
+	args = append(args, limit, offset)
+
+	rows, err := q.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product reviews: %w", err)
+	}
+	defer rows.Close()
+
+	reviews := []models.ProductReview{}
+	for rows.Next() {
+		review, err := scanProductReview(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan product review: %w", err)
+		}
+		reviews = append(reviews, *review)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate product reviews: %w", err)
+	}
+
+	return &models.ProductReviewListResponse{
+		Reviews: reviews,
+		Total:   total,
+		Page:    page,
+		Limit:   limit,
+	}, nil
+}
+
+// ModerateProductReview approves or rejects a review; a decision can be changed later,
+// e.g. to take down an approved review
+func (q *ProductReviewQueries) ModerateProductReview(id int, status string, note *string, adminID *int) (*models.ProductReview, error) {
+	result, err := q.db.Exec(`
+		UPDATE product_reviews
+		SET status = $2, admin_note = $3, moderated_by = $4, moderated_at = CURRENT_TIMESTAMP
+		WHERE id = $1`, id, status, note, adminID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to moderate product review: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	} else if rows == 0 {
+		return nil, fmt.Errorf("product review not found")
+	}
+
+	review, err := scanProductReview(q.db.QueryRow("SELECT "+productReviewColumns+productReviewFrom+" WHERE r.id = $1", id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product review: %w", err)
+	}
+	return review, nil
+}
+
+// DeleteProductReview removes a review, letting the customer write a new one
+func (q *ProductReviewQueries) DeleteProductReview(id int) error {
+	result, err := q.db.Exec(`DELETE FROM product_reviews WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete product review: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("product review not found")
+	}
+	return nil
+}
+
+// GetProductRatings returns the approved review count and average rating of each of
+// the given products; products without approved reviews are left out
+func (q *ProductReviewQueries) GetProductRatings(productIDs []int) (map[int]models.ProductRating, error) {
+	ratings := make(map[int]models.ProductRating, len(productIDs))
+	if len(productIDs) == 0 {
+		return ratings, nil
+	}
+
+	rows, err := q.db.Query(`
+		SELECT product_id, COUNT(*), ROUND(AVG(rating), 2)
+		FROM product_reviews
+		WHERE product_id = ANY($1) AND status = $2
+		GROUP BY product_id`, pq.Array(productIDs), models.ProductReviewStatusApproved)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product ratings: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var productID int
+		var rating models.ProductRating
+		var average float64
+		if err := rows.Scan(&productID, &rating.ReviewCount, &average); err != nil {
+			return nil, fmt.Errorf("failed to scan product rating: %w", err)
+		}
+		rating.AverageRating = &average
+		ratings[productID] = rating
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate product ratings: %w", err)
+	}
+	return ratings, nil
+}
+
+// trimmedOrNil returns the trimmed string, or nil when it is nil or blank
+func trimmedOrNil(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*s)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
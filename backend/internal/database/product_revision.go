@@ -0,0 +1,381 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lib/pq"
+	"notsofluffy-backend/internal/models"
+)
+
+// revisionTable is a table captured in product revisions. where selects the rows of
+// the product ($1). Tables are restored in order, so parents come first.
+type revisionTable struct {
+	table string
+	where string
+	// keyed tables are upserted by id and rows missing from the revision are deleted;
+	// link tables are replaced as a whole
+	keyed bool
+	// preserve lists columns that reflect live state and are never restored
+	preserve []string
+	// filter skips restored link rows whose other parents no longer exist
+	filter string
+	// unsetFirst is a flag column cleared before keyed rows are upserted: its partial
+	// unique index (one default per product) is checked row by row during the upsert
+	unsetFirst string
+	// label names the rows in revision diffs. Keyed rows appear as label[id].column and
+	// link rows as one sorted list of valueColumn; with ownerColumn set, label is a
+	// format taking the owner id.
+	label       string
+	ownerColumn string
+	valueColumn string
+}
+
+var productRevisionTables = []revisionTable{
+	{table: "products", where: "id = $1", keyed: true},
+	{table: "sizes", where: "product_id = $1", keyed: true, preserve: []string{"stock_quantity", "reserved_quantity"}, label: "size"},
+	{table: "product_variants", where: "product_id = $1", keyed: true, unsetFirst: "is_default", label: "variant"},
+	{table: "product_images", where: "product_id = $1", filter: "image_id IN (SELECT id FROM images)", label: "image_ids", valueColumn: "image_id"},
+	{table: "product_services", where: "product_id = $1", filter: "additional_service_id IN (SELECT id FROM additional_services)", label: "additional_service_ids", valueColumn: "additional_service_id"},
+	{table: "product_variant_images", where: "product_variant_id IN (SELECT id FROM product_variants WHERE product_id = $1)", filter: "image_id IN (SELECT id FROM images)", label: "variant[%v].image_ids", ownerColumn: "product_variant_id", valueColumn: "image_id"},
+}
+
+// revisionIgnoredColumns change on every write and are left out of revision diffs
+var revisionIgnoredColumns = []string{"id", "product_id", "created_at", "updated_at"}
+
+type ProductRevisionQueries struct {
+	db *sql.DB
+}
+
+func NewProductRevisionQueries(db *sql.DB) *ProductRevisionQueries {
+	return &ProductRevisionQueries{db: db}
+}
+
+// productRevisionBaselineSQL records the current state of every product that has no
+// revisions yet, so history starts before the first tracked edit
+func productRevisionBaselineSQL() string {
+	parts := make([]string, len(productRevisionTables))
+	for i, t := range productRevisionTables {
+		parts[i] = fmt.Sprintf(`'%s', jsonb_build_object(
+			'columns', (SELECT jsonb_agg(column_name ORDER BY ordinal_position) FROM information_schema.columns
+				WHERE table_schema = current_schema() AND table_name = '%s'),
+			'rows', (SELECT COALESCE(jsonb_agg(t), '[]'::jsonb) FROM %s t WHERE %s))`,
+			t.table, t.table, pq.QuoteIdentifier(t.table), strings.ReplaceAll(t.where, "$1", "p.id"))
+	}
+	return fmt.Sprintf(`INSERT INTO product_revisions (product_id, revision, action, data)
+		SELECT p.id, 1, '%s', jsonb_build_object(%s)
+		FROM products p
+		WHERE NOT EXISTS (SELECT 1 FROM product_revisions r WHERE r.product_id = p.id);`,
+		models.ProductRevisionBaseline, strings.Join(parts, ",\n"))
+}
+
+// RecordRevision stores the current state of a product as its next revision
+func (q *ProductRevisionQueries) RecordRevision(productID int, action string, userID *int) (*models.ProductRevision, error) {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockProduct(tx, productID); err != nil {
+		return nil, err
+	}
+	revision, err := recordRevision(tx, productID, action, userID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return revision, nil
+}
+
+// ListRevisions returns the revisions of a product, newest first, with what each changed
+func (q *ProductRevisionQueries) ListRevisions(productID, page, limit int) (*models.ProductRevisionListResponse, error) {
+	var total int
+	if err := q.db.QueryRow(`SELECT COUNT(*) FROM product_revisions WHERE product_id = $1`, productID).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count product revisions: %w", err)
+	}
+
+	rows, err := q.db.Query(`
+		SELECT r.id, r.product_id, r.revision, r.action, r.restored_from, r.created_by, u.email, r.created_at, r.data, r.previous_data
+		FROM (
+			SELECT *, LAG(data) OVER (ORDER BY revision) AS previous_data
+			FROM product_revisions WHERE product_id = $1
+		) r
+		LEFT JOIN users u ON u.id = r.created_by
+		ORDER BY r.revision DESC
+		LIMIT $2 OFFSET $3`, productID, limit, (page-1)*limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list product revisions: %w", err)
+	}
+	defer rows.Close()
+
+	revisions := []models.ProductRevision{}
+	for rows.Next() {
+		var r models.ProductRevision
+		var data, previous []byte
+		err := rows.Scan(&r.ID, &r.ProductID, &r.Revision, &r.Action, &r.RestoredFrom, &r.CreatedBy, &r.CreatedByEmail, &r.CreatedAt, &data, &previous)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan product revision: %w", err)
+		}
+		if r.Changes, err = diffRevisionData(previous, data); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list product revisions: %w", err)
+	}
+
+	return &models.ProductRevisionListResponse{Revisions: revisions, Total: total, Page: page, Limit: limit}, nil
+}
+
+// GetRevision returns one revision of a product with its captured rows
+func (q *ProductRevisionQueries) GetRevision(productID, revision int) (*models.ProductRevision, error) {
+	var r models.ProductRevision
+	var data, previous []byte
+	err := q.db.QueryRow(`
+		SELECT r.id, r.product_id, r.revision, r.action, r.restored_from, r.created_by, u.email, r.created_at, r.data,
+			(SELECT p.data FROM product_revisions p WHERE p.product_id = r.product_id AND p.revision < r.revision
+			 ORDER BY p.revision DESC LIMIT 1)
+		FROM product_revisions r
+		LEFT JOIN users u ON u.id = r.created_by
+		WHERE r.product_id = $1 AND r.revision = $2`, productID, revision).
+		Scan(&r.ID, &r.ProductID, &r.Revision, &r.Action, &r.RestoredFrom, &r.CreatedBy, &r.CreatedByEmail, &r.CreatedAt, &data, &previous)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("product revision not found")
+		}
+		return nil, fmt.Errorf("failed to get product revision: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &r.Data); err != nil {
+		return nil, fmt.Errorf("failed to decode product revision: %w", err)
+	}
+	if r.Changes, err = diffRevisionData(previous, data); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// RestoreRevision puts the product, its sizes and variants back to a revision and
+// records the result as a new revision. Stock levels are live inventory and stay as
+// they are; sizes deleted by the restore take their warehouse stock with them.
+func (q *ProductRevisionQueries) RestoreRevision(productID, revision int, userID *int) (*models.ProductRevision, error) {
+	source, err := q.GetRevision(productID, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := q.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockProduct(tx, productID); err != nil {
+		return nil, err
+	}
+
+	for _, t := range productRevisionTables {
+		snapshot, ok := source.Data[t.table]
+		if !ok {
+			continue
+		}
+		if err := restoreRevisionTable(tx, t, productID, snapshot); err != nil {
+			if strings.Contains(err.Error(), "duplicate key") || strings.Contains(err.Error(), "foreign key") {
+				return nil, fmt.Errorf("product revision conflicts with current data: %w", err)
+			}
+			return nil, err
+		}
+	}
+
+	restored, err := recordRevision(tx, productID, models.ProductRevisionRestore, userID, &source.Revision)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return restored, nil
+}
+
+// lockProduct serializes revision writes of a product
+func lockProduct(tx *sql.Tx, productID int) error {
+	var id int
+	if err := tx.QueryRow(`SELECT id FROM products WHERE id = $1 FOR UPDATE`, productID).Scan(&id); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("product not found")
+		}
+		return fmt.Errorf("failed to lock product: %w", err)
+	}
+	return nil
+}
+
+// recordRevision captures the product inside tx, which must hold the product lock
+func recordRevision(tx *sql.Tx, productID int, action string, userID, restoredFrom *int) (*models.ProductRevision, error) {
+	data := make(map[string]models.CatalogSnapshotTable, len(productRevisionTables))
+	for _, t := range productRevisionTables {
+		captured, err := captureRows(tx, t.table, t.where, productID)
+		if err != nil {
+			return nil, err
+		}
+		data[t.table] = captured
+	}
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode product revision: %w", err)
+	}
+
+	revision := &models.ProductRevision{ProductID: productID, Action: action, RestoredFrom: restoredFrom, CreatedBy: userID}
+	err = tx.QueryRow(`
+		INSERT INTO product_revisions (product_id, revision, action, restored_from, data, created_by)
+		VALUES ($1, (SELECT COALESCE(MAX(revision), 0) + 1 FROM product_revisions WHERE product_id = $1), $2, $3, $4, $5)
+		RETURNING id, revision, created_at`, productID, action, restoredFrom, encoded, userID).
+		Scan(&revision.ID, &revision.Revision, &revision.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store product revision: %w", err)
+	}
+	return revision, nil
+}
+
+// restoreRevisionTable writes the revision rows of one table for the product
+func restoreRevisionTable(tx *sql.Tx, t revisionTable, productID int, snapshot models.CatalogSnapshotTable) error {
+	current, err := tableColumns(tx, t.table)
+	if err != nil {
+		return err
+	}
+	columns := restorableColumns(current, snapshot.Columns, t.preserve)
+	if len(columns) == 0 {
+		return fmt.Errorf("product revision has no usable columns for %s", t.table)
+	}
+
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = pq.QuoteIdentifier(column)
+	}
+	name := pq.QuoteIdentifier(t.table)
+	columnList := strings.Join(quoted, ", ")
+	source := fmt.Sprintf(`SELECT %s FROM json_populate_recordset(NULL::%s, $1::json)`, columnList, name)
+	rows := string(snapshot.Rows)
+
+	if !t.keyed {
+		if _, err := tx.Exec(fmt.Sprintf(`DELETE FROM %s WHERE %s`, name, t.where), productID); err != nil {
+			return fmt.Errorf("failed to clear %s: %w", t.table, err)
+		}
+		if t.filter != "" {
+			source = fmt.Sprintf(`SELECT * FROM (%s) restored WHERE %s`, source, t.filter)
+		}
+		if _, err := tx.Exec(fmt.Sprintf(`INSERT INTO %s (%s) %s`, name, columnList, source), rows); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", t.table, err)
+		}
+		return nil
+	}
+
+	prune := fmt.Sprintf(`DELETE FROM %s WHERE %s AND id NOT IN (SELECT id FROM json_populate_recordset(NULL::%s, $2::json))`,
+		name, t.where, name)
+	if _, err := tx.Exec(prune, productID, rows); err != nil {
+		return fmt.Errorf("failed to prune %s: %w", t.table, err)
+	}
+	if t.unsetFirst != "" && containsString(columns, t.unsetFirst) {
+		flag := pq.QuoteIdentifier(t.unsetFirst)
+		if _, err := tx.Exec(fmt.Sprintf(`UPDATE %s SET %s = FALSE WHERE %s AND %s`, name, flag, t.where, flag), productID); err != nil {
+			return fmt.Errorf("failed to clear %s.%s: %w", t.table, t.unsetFirst, err)
+		}
+	}
+
+	updates := make([]string, 0, len(quoted))
+	for _, column := range quoted {
+		if column != `"id"` {
+			updates = append(updates, column+" = EXCLUDED."+column)
+		}
+	}
+	conflict := "DO NOTHING"
+	if len(updates) > 0 {
+		conflict = "DO UPDATE SET " + strings.Join(updates, ", ")
+	}
+	query := fmt.Sprintf(`INSERT INTO %s (%s) %s ON CONFLICT (id) %s`, name, columnList, source, conflict)
+	if _, err := tx.Exec(query, rows); err != nil {
+		return fmt.Errorf("failed to restore %s: %w", t.table, err)
+	}
+	return nil
+}
+
+// diffRevisionData compares two encoded revisions field by field
+func diffRevisionData(before, after []byte) ([]models.ContentFieldChange, error) {
+	beforeFields, err := flattenRevisionData(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := flattenRevisionData(after)
+	if err != nil {
+		return nil, err
+	}
+	return diffFields(beforeFields, afterFields), nil
+}
+
+// flattenRevisionData turns an encoded revision into named fields, e.g. name,
+// size[12].base_price and variant[3].image_ids
+func flattenRevisionData(data []byte) (map[string]interface{}, error) {
+	fields := map[string]interface{}{}
+	if len(data) == 0 {
+		return fields, nil
+	}
+
+	var tables map[string]models.CatalogSnapshotTable
+	if err := json.Unmarshal(data, &tables); err != nil {
+		return nil, fmt.Errorf("failed to decode product revision: %w", err)
+	}
+
+	for _, t := range productRevisionTables {
+		snapshot, captured := tables[t.table]
+		var rows []map[string]interface{}
+		if captured && len(snapshot.Rows) > 0 {
+			if err := json.Unmarshal(snapshot.Rows, &rows); err != nil {
+				return nil, fmt.Errorf("failed to decode product revision rows of %s: %w", t.table, err)
+			}
+		}
+
+		if t.valueColumn != "" {
+			values := map[string][]float64{}
+			for _, row := range rows {
+				key := t.label
+				if t.ownerColumn != "" {
+					key = fmt.Sprintf(t.label, row[t.ownerColumn])
+				}
+				if value, ok := row[t.valueColumn].(float64); ok {
+					values[key] = append(values[key], value)
+				}
+			}
+			if captured && t.ownerColumn == "" && values[t.label] == nil {
+				values[t.label] = []float64{}
+			}
+			for key, list := range values {
+				sort.Float64s(list)
+				fields[key] = list
+			}
+			continue
+		}
+
+		for _, row := range rows {
+			for column, value := range row {
+				if containsString(revisionIgnoredColumns, column) || containsString(t.preserve, column) {
+					continue
+				}
+				name := column
+				if t.label != "" {
+					name = fmt.Sprintf("%s[%v].%s", t.label, row["id"], column)
+				}
+				fields[name] = value
+			}
+		}
+	}
+	return fields, nil
+}
@@ -0,0 +1,120 @@
+package database
+
+import (
+	"strings"
+	"testing"
+
+	"notsofluffy-backend/internal/models"
+)
+
+func TestDiffRevisionData(t *testing.T) {
+	before := []byte(`{
+		"products": {"columns": ["id", "name"], "rows": [{"id": 1, "name": "Bed", "updated_at": "2024-01-01"}]},
+		"sizes": {"columns": ["id", "base_price"], "rows": [{"id": 12, "product_id": 1, "base_price": 199.00, "stock_quantity": 5}]},
+		"product_images": {"columns": ["product_id", "image_id"], "rows": [{"product_id": 1, "image_id": 3}, {"product_id": 1, "image_id": 2}]},
+		"product_variant_images": {"columns": ["product_variant_id", "image_id"], "rows": [{"product_variant_id": 7, "image_id": 2}]}
+	}`)
+	after := []byte(`{
+		"products": {"columns": ["id", "name"], "rows": [{"id": 1, "name": "Bed", "updated_at": "2024-02-01"}]},
+		"sizes": {"columns": ["id", "base_price"], "rows": [{"id": 12, "product_id": 1, "base_price": 249.00, "stock_quantity": 1}]},
+		"product_images": {"columns": ["product_id", "image_id"], "rows": [{"product_id": 1, "image_id": 2}, {"product_id": 1, "image_id": 3}]},
+		"product_variant_images": {"columns": ["product_variant_id", "image_id"], "rows": [{"product_variant_id": 7, "image_id": 4}]}
+	}`)
+
+	changes, err := diffRevisionData(before, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %+v", changes)
+	}
+	if changes[0].Field != "size[12].base_price" || changes[0].Before != 199.0 || changes[0].After != 249.0 {
+		t.Fatalf("unexpected price change %+v", changes[0])
+	}
+	if changes[1].Field != "variant[7].image_ids" {
+		t.Fatalf("unexpected variant image change %+v", changes[1])
+	}
+}
+
+func TestDiffRevisionDataWithoutPrevious(t *testing.T) {
+	changes, err := diffRevisionData(nil, []byte(`{"products": {"columns": ["id", "name"], "rows": [{"id": 1, "name": "Bed"}]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].Field != "name" || changes[0].After != "Bed" {
+		t.Fatalf("unexpected changes %+v", changes)
+	}
+}
+
+func TestProductRevisionBaselineSQLCoversTables(t *testing.T) {
+	query := productRevisionBaselineSQL()
+	for _, table := range productRevisionTables {
+		if !strings.Contains(query, "'"+table.table+"'") {
+			t.Fatalf("baseline does not capture %s", table.table)
+		}
+	}
+	if strings.Contains(query, "$1") {
+		t.Fatal("baseline must not use placeholders")
+	}
+}
+
+func TestRestoreRevisionWithDifferentDefaultVariant(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	var imageID, materialID, colorID, productID int
+	if err := db.QueryRow(`
+		INSERT INTO images (filename, original_name, path, size_bytes, mime_type)
+		VALUES ('revision-default.png', 'revision-default.png', 'uploads/revision-default.png', 1, 'image/png')
+		RETURNING id`).Scan(&imageID); err != nil {
+		t.Fatalf("Failed to create image: %v", err)
+	}
+	defer db.Exec("DELETE FROM images WHERE id = $1", imageID)
+	if err := db.QueryRow(`INSERT INTO materials (name) VALUES ('Revision test material') RETURNING id`).Scan(&materialID); err != nil {
+		t.Fatalf("Failed to create material: %v", err)
+	}
+	defer db.Exec("DELETE FROM materials WHERE id = $1", materialID)
+	if err := db.QueryRow(`INSERT INTO colors (name, material_id) VALUES ('Revision test color', $1) RETURNING id`, materialID).Scan(&colorID); err != nil {
+		t.Fatalf("Failed to create color: %v", err)
+	}
+	if err := db.QueryRow(`
+		INSERT INTO products (name, short_description, description, main_image_id)
+		VALUES ('Revision test product', 'x', 'x', $1)
+		RETURNING id`, imageID).Scan(&productID); err != nil {
+		t.Fatalf("Failed to create product: %v", err)
+	}
+	defer db.Exec("DELETE FROM products WHERE id = $1", productID)
+
+	var firstID, secondID int
+	if err := db.QueryRow(`INSERT INTO product_variants (product_id, name, color_id, is_default) VALUES ($1, 'First', $2, TRUE) RETURNING id`,
+		productID, colorID).Scan(&firstID); err != nil {
+		t.Fatalf("Failed to create variant: %v", err)
+	}
+	if err := db.QueryRow(`INSERT INTO product_variants (product_id, name, color_id) VALUES ($1, 'Second', $2) RETURNING id`,
+		productID, colorID).Scan(&secondID); err != nil {
+		t.Fatalf("Failed to create variant: %v", err)
+	}
+
+	q := NewProductRevisionQueries(db)
+	revision, err := q.RecordRevision(productID, models.ProductRevisionUpdate, nil)
+	if err != nil {
+		t.Fatalf("Failed to record revision: %v", err)
+	}
+
+	// The second variant becomes the default after the revision
+	if _, err := db.Exec(`UPDATE product_variants SET is_default = (id = $2) WHERE product_id = $1`, productID, secondID); err != nil {
+		t.Fatalf("Failed to switch default variant: %v", err)
+	}
+
+	if _, err := q.RestoreRevision(productID, revision.Revision, nil); err != nil {
+		t.Fatalf("Failed to restore revision: %v", err)
+	}
+
+	var defaultID int
+	if err := db.QueryRow(`SELECT id FROM product_variants WHERE product_id = $1 AND is_default`, productID).Scan(&defaultID); err != nil {
+		t.Fatalf("Failed to get default variant: %v", err)
+	}
+	if defaultID != firstID {
+		t.Fatalf("default variant = %d, want %d", defaultID, firstID)
+	}
+}
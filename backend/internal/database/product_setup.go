@@ -0,0 +1,102 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"notsofluffy-backend/internal/models"
+)
+
+// ProductSetup is a new product with its images, services, sizes and variants
+type ProductSetup struct {
+	Product    *models.Product
+	ImageIDs   []int
+	ServiceIDs []int
+	Sizes      []models.Size
+	Variants   []VariantSetup
+}
+
+// VariantSetup is a variant of a ProductSetup with its images
+type VariantSetup struct {
+	Variant  models.ProductVariant
+	ImageIDs []int
+}
+
+// CreateProductSetup creates a product with its images, services, sizes and variants in
+// one transaction and sets the IDs of what it created. Stock of the sizes lands in the
+// default warehouse.
+func (q *ProductQueries) CreateProductSetup(setup *ProductSetup, userID *int) error {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	product := setup.Product
+	if product.Channels == nil {
+		channels := models.DefaultProductChannels()
+		product.Channels = &channels
+	}
+	err = tx.QueryRow(`
+		INSERT INTO products (name, slug, short_description, description, material_id, main_image_id, category_id,
+		 visible_web, visible_marketplace, visible_b2b)
+		VALUES ($1, NULLIF($2, ''), $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at, updated_at`,
+		product.Name, product.Slug, product.ShortDescription, product.Description, product.MaterialID, product.MainImageID,
+		product.CategoryID, product.Channels.Web, product.Channels.Marketplace, product.Channels.B2B).Scan(
+		&product.ID, &product.CreatedAt, &product.UpdatedAt)
+	if err != nil {
+		if strings.Contains(err.Error(), "idx_products_slug") {
+			return fmt.Errorf("product slug already exists")
+		}
+		return fmt.Errorf("failed to create product: %w", err)
+	}
+
+	if err := replaceImageLinksTx(tx, productImageLinks, product.ID, setup.ImageIDs); err != nil {
+		return err
+	}
+	for _, serviceID := range setup.ServiceIDs {
+		if _, err := tx.Exec(`INSERT INTO product_services (product_id, additional_service_id) VALUES ($1, $2)`,
+			product.ID, serviceID); err != nil {
+			return fmt.Errorf("failed to insert product service: %w", err)
+		}
+	}
+
+	for i := range setup.Sizes {
+		size := &setup.Sizes[i]
+		size.ProductID = product.ID
+		err := tx.QueryRow(`
+			INSERT INTO sizes (name, product_id, base_price, a, b, c, d, e, f, use_stock, stock_quantity)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			RETURNING id, created_at, updated_at`,
+			size.Name, size.ProductID, size.BasePrice, size.A, size.B, size.C, size.D, size.E, size.F, size.UseStock,
+			size.StockQuantity).Scan(&size.ID, &size.CreatedAt, &size.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create size %s: %w", size.Name, err)
+		}
+		if err := reconcileDefaultWarehouse(tx, size.ID, userID); err != nil {
+			return err
+		}
+	}
+
+	for i := range setup.Variants {
+		variant := &setup.Variants[i].Variant
+		variant.ProductID = product.ID
+		err := tx.QueryRow(`
+			INSERT INTO product_variants (product_id, name, color_id, is_default)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id, created_at, updated_at`,
+			variant.ProductID, variant.Name, variant.ColorID, variant.IsDefault).Scan(&variant.ID, &variant.CreatedAt, &variant.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create product variant %s: %w", variant.Name, err)
+		}
+		if err := replaceImageLinksTx(tx, variantImageLinks, variant.ID, setup.Variants[i].ImageIDs); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
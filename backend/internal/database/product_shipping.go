@@ -0,0 +1,39 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"notsofluffy-backend/internal/models"
+)
+
+// GetProductShipping returns whether a product ships oversized and its shipping surcharge
+func (q *ProductQueries) GetProductShipping(productID int) (*models.ProductShipping, error) {
+	shipping := &models.ProductShipping{ProductID: productID}
+	err := q.db.QueryRow(`SELECT oversize, shipping_surcharge FROM products WHERE id = $1`, productID).
+		Scan(&shipping.Oversize, &shipping.ShippingSurcharge)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("product not found")
+		}
+		return nil, fmt.Errorf("failed to get product shipping: %w", err)
+	}
+	return shipping, nil
+}
+
+// UpdateProductShipping sets whether a product ships oversized and its shipping surcharge
+func (q *ProductQueries) UpdateProductShipping(productID int, req *models.ProductShippingRequest) (*models.ProductShipping, error) {
+	shipping := &models.ProductShipping{ProductID: productID}
+	err := q.db.QueryRow(`
+		UPDATE products SET oversize = $2, shipping_surcharge = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING oversize, shipping_surcharge`, productID, req.Oversize, req.ShippingSurcharge).
+		Scan(&shipping.Oversize, &shipping.ShippingSurcharge)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("product not found")
+		}
+		return nil, fmt.Errorf("failed to update product shipping: %w", err)
+	}
+	return shipping, nil
+}
@@ -0,0 +1,216 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"notsofluffy-backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// GetProductTags returns the tags of the products
+func (q *ProductQueries) GetProductTags(productIDs []int) (map[int][]string, error) {
+	tags := make(map[int][]string, len(productIDs))
+	if len(productIDs) == 0 {
+		return tags, nil
+	}
+
+	rows, err := q.db.Query(`SELECT product_id, tag FROM product_tags WHERE product_id = ANY($1) ORDER BY tag`, pq.Array(productIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product tags: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var productID int
+		var tag string
+		if err := rows.Scan(&productID, &tag); err != nil {
+			return nil, fmt.Errorf("failed to scan product tag: %w", err)
+		}
+		tags[productID] = append(tags[productID], tag)
+	}
+	return tags, rows.Err()
+}
+
+// SetProductTags replaces the tags of a product
+func (q *ProductQueries) SetProductTags(productID int, tags []string) error {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM product_tags WHERE product_id = $1`, productID); err != nil {
+		return fmt.Errorf("failed to clear product tags: %w", err)
+	}
+	for _, tag := range tags {
+		if _, err := tx.Exec(`INSERT INTO product_tags (product_id, tag) VALUES ($1, $2) ON CONFLICT DO NOTHING`, productID, tag); err != nil {
+			if strings.Contains(err.Error(), "foreign key") {
+				return fmt.Errorf("product not found")
+			}
+			return fmt.Errorf("failed to add product tag: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ListProductTags returns all tags in use with their product counts
+func (q *ProductQueries) ListProductTags() ([]models.ProductTag, error) {
+	rows, err := q.db.Query(`SELECT tag, COUNT(*) FROM product_tags GROUP BY tag ORDER BY tag`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list product tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := []models.ProductTag{}
+	for rows.Next() {
+		var tag models.ProductTag
+		if err := rows.Scan(&tag.Tag, &tag.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan product tag: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+// RenameProductTag renames a tag on every product carrying it and returns the number
+// of products changed. Products already carrying the new tag keep just that one.
+func (q *ProductQueries) RenameProductTag(from, to string) (int, error) {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO product_tags (product_id, tag)
+		SELECT product_id, $2 FROM product_tags WHERE tag = $1
+		ON CONFLICT DO NOTHING`, from, to)
+	if err != nil {
+		return 0, fmt.Errorf("failed to rename product tag: %w", err)
+	}
+	added, _ := result.RowsAffected()
+
+	result, err = tx.Exec(`DELETE FROM product_tags WHERE tag = $1 AND $1 <> $2`, from, to)
+	if err != nil {
+		return 0, fmt.Errorf("failed to rename product tag: %w", err)
+	}
+	removed, _ := result.RowsAffected()
+	if added == 0 && removed == 0 {
+		return 0, fmt.Errorf("tag not found")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return int(removed), nil
+}
+
+// DeleteProductTag removes a tag from every product and returns the number of products
+// changed
+func (q *ProductQueries) DeleteProductTag(tag string) (int, error) {
+	result, err := q.db.Exec(`DELETE FROM product_tags WHERE tag = $1`, tag)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete product tag: %w", err)
+	}
+	removed, _ := result.RowsAffected()
+	if removed == 0 {
+		return 0, fmt.Errorf("tag not found")
+	}
+	return int(removed), nil
+}
+
+// GetTagCloud returns the most used tags of the products shown in the shop, optionally
+// only of products in categoryIDs, with their weight from 1 to 5. Tags are ordered by
+// name so the cloud doesn't reshuffle as counts change.
+func (q *ProductQueries) GetTagCloud(categoryIDs []int, limit int) ([]models.TagCloudItem, error) {
+	whereClause, args := publicProductFilter("", categoryIDs, nil)
+	args = append(args, limit)
+
+	rows, err := q.db.Query(fmt.Sprintf(`
+		SELECT tag, count FROM (
+			SELECT pt.tag, COUNT(*) AS count
+			FROM product_tags pt
+			JOIN products p ON p.id = pt.product_id
+			LEFT JOIN categories c ON p.category_id = c.id
+			%s
+			GROUP BY pt.tag
+			ORDER BY count DESC, pt.tag
+			LIMIT $%d
+		) top
+		ORDER BY tag`, whereClause, len(args)), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tag cloud: %w", err)
+	}
+	defer rows.Close()
+
+	cloud := []models.TagCloudItem{}
+	for rows.Next() {
+		var item models.TagCloudItem
+		if err := rows.Scan(&item.Tag, &item.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan tag cloud: %w", err)
+		}
+		cloud = append(cloud, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get tag cloud: %w", err)
+	}
+
+	setTagWeights(cloud)
+	return cloud, nil
+}
+
+// setTagWeights spreads the counts of a tag cloud linearly over weights 1 to 5
+func setTagWeights(cloud []models.TagCloudItem) {
+	if len(cloud) == 0 {
+		return
+	}
+	least, most := cloud[0].Count, cloud[0].Count
+	for _, item := range cloud {
+		least = min(least, item.Count)
+		most = max(most, item.Count)
+	}
+	for i := range cloud {
+		if most == least {
+			cloud[i].Weight = 3
+			continue
+		}
+		cloud[i].Weight = 1 + (cloud[i].Count-least)*4/(most-least)
+	}
+}
+
+// GetTagSuggestions returns the tags of products shown in the shop that contain query,
+// most used first
+func (q *ProductQueries) GetTagSuggestions(query string, limit int) ([]string, error) {
+	whereClause, args := publicProductFilter("", nil, nil)
+	args = append(args, "%"+query+"%", limit)
+
+	rows, err := q.db.Query(fmt.Sprintf(`
+		SELECT pt.tag
+		FROM product_tags pt
+		JOIN products p ON p.id = pt.product_id
+		LEFT JOIN categories c ON p.category_id = c.id
+		%s AND pt.tag ILIKE $%d
+		GROUP BY pt.tag
+		ORDER BY COUNT(*) DESC, pt.tag
+		LIMIT $%d`, whereClause, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tag suggestions: %w", err)
+	}
+	defer rows.Close()
+
+	tags := []string{}
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, fmt.Errorf("failed to scan tag suggestion: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
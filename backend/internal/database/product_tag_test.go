@@ -0,0 +1,61 @@
+package database
+
+import (
+	"strings"
+	"testing"
+
+	"notsofluffy-backend/internal/models"
+)
+
+func TestSetTagWeights(t *testing.T) {
+	cloud := []models.TagCloudItem{
+		{Tag: "handmade", Count: 2},
+		{Tag: "merino", Count: 10},
+		{Tag: "waterproof", Count: 6},
+	}
+	setTagWeights(cloud)
+
+	want := map[string]int{"handmade": 1, "merino": 5, "waterproof": 3}
+	for _, item := range cloud {
+		if item.Weight != want[item.Tag] {
+			t.Fatalf("expected weight %d for %q, got %d", want[item.Tag], item.Tag, item.Weight)
+		}
+	}
+}
+
+func TestSetTagWeightsEqualCounts(t *testing.T) {
+	cloud := []models.TagCloudItem{{Tag: "a", Count: 4}, {Tag: "b", Count: 4}}
+	setTagWeights(cloud)
+	for _, item := range cloud {
+		if item.Weight != 3 {
+			t.Fatalf("equal counts should share the middle weight, got %d", item.Weight)
+		}
+	}
+}
+
+func TestPublicProductFilterTags(t *testing.T) {
+	where, args := publicProductFilter("wool", []int{3}, []string{"handmade", "waterproof"})
+	if !strings.Contains(where, "tag = ANY($4) GROUP BY product_id HAVING COUNT(*) = $5") {
+		t.Fatalf("products should carry all tags, got %q", where)
+	}
+	if len(args) != 5 || args[4] != 2 {
+		t.Fatalf("expected search, categories, tags and tag count arguments, got %v", args)
+	}
+}
+
+func TestPublicProductFilterSearchIndex(t *testing.T) {
+	where, args := publicProductFilter("merino wool", nil, nil)
+	if !strings.Contains(where, "si.document @@ plainto_tsquery('simple', $2)") {
+		t.Fatalf("search should match the search index, got %q", where)
+	}
+	if len(args) != 2 || args[0] != "%merino wool%" || args[1] != "merino wool" {
+		t.Fatalf("expected pattern and query arguments, got %v", args)
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	got := NormalizeTags([]string{" Waterproof", "handmade", "", "WATERPROOF"})
+	if strings.Join(got, ",") != "waterproof,handmade" {
+		t.Fatalf("expected trimmed, lowercased, unique tags, got %v", got)
+	}
+}
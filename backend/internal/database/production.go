@@ -0,0 +1,234 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"notsofluffy-backend/internal/fulfillment"
+	"notsofluffy-backend/internal/models"
+)
+
+type ProductionQueries struct {
+	db *sql.DB
+}
+
+func NewProductionQueries(db *sql.DB) *ProductionQueries {
+	return &ProductionQueries{db: db}
+}
+
+// shopToday returns the shop's current date at midnight UTC, the form DATE columns scan into
+func shopToday(loc *time.Location) time.Time {
+	now := time.Now().In(loc)
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// productionCapacity returns the configured items per day; 0 means unlimited
+func productionCapacity(db *sql.DB) (int, error) {
+	capacity, err := NewSettingsQueries(db).GetIntSetting(models.SettingProductionCapacityPerDay, 0)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get production capacity: %w", err)
+	}
+	if capacity < 0 {
+		capacity = 0
+	}
+	return capacity, nil
+}
+
+// loadProductionDays returns days consecutive days from from with their bookings and
+// blocks, and the reasons days were blocked for
+func loadProductionDays(db interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}, from time.Time, days, capacity int) ([]fulfillment.Day, map[string]*string, error) {
+	rows, err := db.Query(`
+		SELECT d.day::date, COALESCE(s.items, 0), b.day IS NOT NULL, b.reason
+		FROM generate_series($1::date, $1::date + ($2::int - 1), interval '1 day') AS d(day)
+		LEFT JOIN (SELECT day, SUM(items) AS items FROM production_slots GROUP BY day) s ON s.day = d.day::date
+		LEFT JOIN production_blocked_days b ON b.day = d.day::date
+		ORDER BY d.day`, from.Format("2006-01-02"), days)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get production days: %w", err)
+	}
+	defer rows.Close()
+
+	result := []fulfillment.Day{}
+	reasons := map[string]*string{}
+	for rows.Next() {
+		day := fulfillment.Day{Capacity: capacity}
+		var reason *string
+		if err := rows.Scan(&day.Date, &day.Booked, &day.Blocked, &reason); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan production day: %w", err)
+		}
+		if reason != nil {
+			reasons[day.Date.Format("2006-01-02")] = reason
+		}
+		result = append(result, day)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to get production days: %w", err)
+	}
+	return result, reasons, nil
+}
+
+// bookProduction books capacity for the order's made-to-order items in its transaction
+// and pushes the order's lead time out when the slots run past it. The slots are
+// written once the order has an ID.
+func bookProduction(tx *sql.Tx, db *sql.DB, order *models.Order) ([]fulfillment.Slot, error) {
+	capacity, err := productionCapacity(db)
+	if err != nil || capacity == 0 || order.ProductionUnits <= 0 {
+		return nil, err
+	}
+
+	// Bookings are serialised, so two checkouts can't both take the last items of a day
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext('production_slots'))`); err != nil {
+		return nil, fmt.Errorf("failed to lock production slots: %w", err)
+	}
+
+	today := shopToday(NewSettingsQueries(db).GetShopLocation())
+	days, _, err := loadProductionDays(tx, today, models.ProductionHorizonDays, capacity)
+	if err != nil {
+		return nil, err
+	}
+	slots, ok := fulfillment.Book(days, order.ProductionUnits)
+	if !ok {
+		return nil, fmt.Errorf("production capacity exhausted")
+	}
+
+	if leadDays := fulfillment.BookedLeadDays(today, slots); order.LeadTimeDays == nil || leadDays > *order.LeadTimeDays {
+		order.LeadTimeDays = &leadDays
+	}
+	return slots, nil
+}
+
+// insertProductionSlots records the slots booked for an order
+func insertProductionSlots(tx *sql.Tx, orderID int, slots []fulfillment.Slot) error {
+	for _, slot := range slots {
+		_, err := tx.Exec(`INSERT INTO production_slots (order_id, day, items) VALUES ($1, $2, $3)`,
+			orderID, slot.Date.Format("2006-01-02"), slot.Items)
+		if err != nil {
+			return fmt.Errorf("failed to book production slot: %w", err)
+		}
+	}
+	return nil
+}
+
+// PreviewLeadDays returns the days until units made-to-order items ordered now could be
+// dispatched, as far as production capacity goes, without booking anything. It returns
+// 0 when capacity is unlimited.
+func (q *ProductionQueries) PreviewLeadDays(units int) (int, error) {
+	capacity, err := productionCapacity(q.db)
+	if err != nil || capacity == 0 || units <= 0 {
+		return 0, err
+	}
+
+	today := shopToday(NewSettingsQueries(q.db).GetShopLocation())
+	days, _, err := loadProductionDays(q.db, today, models.ProductionHorizonDays, capacity)
+	if err != nil {
+		return 0, err
+	}
+	slots, ok := fulfillment.Book(days, units)
+	if !ok {
+		return 0, fmt.Errorf("production capacity exhausted")
+	}
+	return fulfillment.BookedLeadDays(today, slots), nil
+}
+
+// GetCapacity returns the booked and remaining capacity of days consecutive days from
+// from, or from today in the shop's time zone when from is zero
+func (q *ProductionQueries) GetCapacity(from time.Time, days int) (*models.ProductionCapacityResponse, error) {
+	capacity, err := productionCapacity(q.db)
+	if err != nil {
+		return nil, err
+	}
+
+	loc := NewSettingsQueries(q.db).GetShopLocation()
+	if from.IsZero() {
+		from = shopToday(loc)
+	}
+
+	productionDays, reasons, err := loadProductionDays(q.db, from, days, capacity)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.ProductionCapacityResponse{
+		From:           from.Format("2006-01-02"),
+		Timezone:       loc.String(),
+		CapacityPerDay: capacity,
+		Unlimited:      capacity == 0,
+		Days:           []models.ProductionCapacityDay{},
+	}
+	for _, day := range productionDays {
+		date := day.Date.Format("2006-01-02")
+		entry := models.ProductionCapacityDay{
+			Date:      date,
+			Capacity:  day.Capacity,
+			Booked:    day.Booked,
+			Remaining: day.Remaining(),
+			Blocked:   day.Blocked,
+			Reason:    reasons[date],
+		}
+		if day.Blocked {
+			entry.Capacity = 0
+		}
+		response.Days = append(response.Days, entry)
+	}
+	return response, nil
+}
+
+// ListBlockedDays returns the blocked days from today on, earliest first
+func (q *ProductionQueries) ListBlockedDays() ([]models.ProductionBlockedDay, error) {
+	today := shopToday(NewSettingsQueries(q.db).GetShopLocation())
+	rows, err := q.db.Query(`SELECT day, reason, created_at FROM production_blocked_days WHERE day >= $1 ORDER BY day`,
+		today.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list blocked days: %w", err)
+	}
+	defer rows.Close()
+
+	days := []models.ProductionBlockedDay{}
+	for rows.Next() {
+		var day models.ProductionBlockedDay
+		var date time.Time
+		if err := rows.Scan(&date, &day.Reason, &day.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan blocked day: %w", err)
+		}
+		day.Date = date.Format("2006-01-02")
+		days = append(days, day)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list blocked days: %w", err)
+	}
+	return days, nil
+}
+
+// BlockDay blocks out a day, or updates the reason of a blocked one. Items already
+// booked on the day keep their slots; the admin capacity view shows them.
+func (q *ProductionQueries) BlockDay(date time.Time, reason *string) (*models.ProductionBlockedDay, error) {
+	day := &models.ProductionBlockedDay{Date: date.Format("2006-01-02")}
+	err := q.db.QueryRow(`
+		INSERT INTO production_blocked_days (day, reason) VALUES ($1, $2)
+		ON CONFLICT (day) DO UPDATE SET reason = EXCLUDED.reason
+		RETURNING reason, created_at`, day.Date, reason).Scan(&day.Reason, &day.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to block day: %w", err)
+	}
+	return day, nil
+}
+
+// UnblockDay opens a blocked day for bookings again
+func (q *ProductionQueries) UnblockDay(date time.Time) error {
+	result, err := q.db.Exec(`DELETE FROM production_blocked_days WHERE day = $1`, date.Format("2006-01-02"))
+	if err != nil {
+		return fmt.Errorf("failed to unblock day: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("blocked day not found")
+	}
+	return nil
+}
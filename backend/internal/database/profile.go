@@ -160,6 +160,28 @@ func (q *ProfileQueries) GetUserAddresses(userID int) ([]models.UserAddressRespo
 	return addresses, nil
 }
 
+// GetUserAddress retrieves one of a user's saved addresses
+func (q *ProfileQueries) GetUserAddress(userID, addressID int) (*models.UserAddress, error) {
+	query := `
+		SELECT id, user_id, label, first_name, last_name, company, address_line1, address_line2,
+		       city, state_province, postal_code, country, phone, is_default, created_at, updated_at
+		FROM user_addresses
+		WHERE user_id = $1 AND id = $2`
+
+	var addr models.UserAddress
+	err := q.db.QueryRow(query, userID, addressID).Scan(&addr.ID, &addr.UserID, &addr.Label, &addr.FirstName, &addr.LastName,
+		&addr.Company, &addr.AddressLine1, &addr.AddressLine2, &addr.City, &addr.StateProvince,
+		&addr.PostalCode, &addr.Country, &addr.Phone, &addr.IsDefault, &addr.CreatedAt, &addr.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("address not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get address: %w", err)
+	}
+
+	return &addr, nil
+}
+
 // CreateUserAddress creates a new address for a user
 func (q *ProfileQueries) CreateUserAddress(userID int, req *models.UserAddressRequest) (*models.UserAddressResponse, error) {
 	tx, err := q.db.Begin()
@@ -170,6 +192,9 @@ func (q *ProfileQueries) CreateUserAddress(userID int, req *models.UserAddressRe
 	
 	// If this is set as default, unset all other defaults for this user
 	if req.IsDefault {
+		if err := lockDefaultAddress(tx, userID); err != nil {
+			return nil, err
+		}
 		_, err = tx.Exec("UPDATE user_addresses SET is_default = FALSE WHERE user_id = $1", userID)
 		if err != nil {
 			return nil, fmt.Errorf("failed to unset default addresses: %w", err)
@@ -228,6 +253,9 @@ func (q *ProfileQueries) UpdateUserAddress(userID, addressID int, req *models.Us
 	
 	// If this is set as default, unset all other defaults for this user
 	if req.IsDefault {
+		if err := lockDefaultAddress(tx, userID); err != nil {
+			return nil, err
+		}
 		_, err = tx.Exec("UPDATE user_addresses SET is_default = FALSE WHERE user_id = $1 AND id != $2", userID, addressID)
 		if err != nil {
 			return nil, fmt.Errorf("failed to unset default addresses: %w", err)
@@ -279,23 +307,39 @@ func (q *ProfileQueries) UpdateUserAddress(userID, addressID int, req *models.Us
 	}, nil
 }
 
-// DeleteUserAddress deletes an address
+// DeleteUserAddress deletes an address. When it was the default, the most recently
+// updated of the remaining addresses becomes the default.
 func (q *ProfileQueries) DeleteUserAddress(userID, addressID int) error {
-	query := `DELETE FROM user_addresses WHERE user_id = $1 AND id = $2`
-	result, err := q.db.Exec(query, userID, addressID)
+	tx, err := q.db.Begin()
 	if err != nil {
-		return fmt.Errorf("failed to delete address: %w", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+	defer tx.Rollback()
+
+	if err := lockDefaultAddress(tx, userID); err != nil {
+		return err
 	}
-	
-	if rowsAffected == 0 {
+
+	var wasDefault bool
+	err = tx.QueryRow(`DELETE FROM user_addresses WHERE user_id = $1 AND id = $2 RETURNING COALESCE(is_default, FALSE)`, userID, addressID).Scan(&wasDefault)
+	if err == sql.ErrNoRows {
 		return fmt.Errorf("address not found")
+	} else if err != nil {
+		return fmt.Errorf("failed to delete address: %w", err)
+	}
+
+	if wasDefault {
+		_, err = tx.Exec(`
+			UPDATE user_addresses SET is_default = TRUE
+			WHERE id = (SELECT id FROM user_addresses WHERE user_id = $1 ORDER BY updated_at DESC, id DESC LIMIT 1)`, userID)
+		if err != nil {
+			return fmt.Errorf("failed to set default address: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
-	
 	return nil
 }
 
@@ -307,6 +351,10 @@ func (q *ProfileQueries) SetDefaultAddress(userID, addressID int) error {
 	}
 	defer tx.Rollback()
 	
+	if err := lockDefaultAddress(tx, userID); err != nil {
+		return err
+	}
+
 	// Unset all defaults for this user
 	_, err = tx.Exec("UPDATE user_addresses SET is_default = FALSE WHERE user_id = $1", userID)
 	if err != nil {
@@ -333,4 +381,14 @@ func (q *ProfileQueries) SetDefaultAddress(userID, addressID int) error {
 	}
 	
 	return nil
-}
\ No newline at end of file
+}
+
+// lockDefaultAddress serializes the changes to a user's default address until the end of
+// the transaction, so concurrent requests can't both keep or both unset a default. The
+// single default index rejects what gets past it.
+func lockDefaultAddress(tx *sql.Tx, userID int) error {
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext('default_address'), $1)`, userID); err != nil {
+		return fmt.Errorf("failed to lock default address: %w", err)
+	}
+	return nil
+}
@@ -2,10 +2,16 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 	"notsofluffy-backend/internal/auth"
+	"notsofluffy-backend/internal/imaging"
 	"notsofluffy-backend/internal/models"
+	"notsofluffy-backend/internal/validation"
 	"github.com/lib/pq"
 )
 
@@ -36,9 +42,9 @@ func (q *UserQueries) CreateUser(user *models.User) error {
 
 func (q *UserQueries) GetUserByEmail(email string) (*models.User, error) {
 	query := `
-		SELECT id, email, password_hash, role, created_at, updated_at
+		SELECT id, email, password_hash, role, created_at, updated_at, token_version
 		FROM users
-		WHERE email = $1
+		WHERE email = $1 AND deleted_at IS NULL
 	`
 	user := &models.User{}
 	err := q.db.QueryRow(query, email).Scan(
@@ -47,7 +53,7 @@ func (q *UserQueries) GetUserByEmail(email string) (*models.User, error) {
 		&user.PasswordHash,
 		&user.Role,
 		&user.CreatedAt,
-		&user.UpdatedAt,
+		&user.UpdatedAt,		&user.TokenVersion,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -60,9 +66,9 @@ func (q *UserQueries) GetUserByEmail(email string) (*models.User, error) {
 
 func (q *UserQueries) GetUserByID(id int) (*models.User, error) {
 	query := `
-		SELECT id, email, password_hash, role, created_at, updated_at
+		SELECT id, email, password_hash, role, created_at, updated_at, token_version
 		FROM users
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 	`
 	user := &models.User{}
 	err := q.db.QueryRow(query, id).Scan(
@@ -71,7 +77,7 @@ func (q *UserQueries) GetUserByID(id int) (*models.User, error) {
 		&user.PasswordHash,
 		&user.Role,
 		&user.CreatedAt,
-		&user.UpdatedAt,
+		&user.UpdatedAt,		&user.TokenVersion,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -83,25 +89,67 @@ func (q *UserQueries) GetUserByID(id int) (*models.User, error) {
 }
 
 
+// DeleteUser deactivates a user: they are signed out everywhere and can't log in, while
+// their orders, reviews and other records keep pointing at them. The images they
+// uploaded are handed over to the system account.
 func (q *UserQueries) DeleteUser(id int) error {
-	query := `DELETE FROM users WHERE id = $1`
-	result, err := q.db.Exec(query, id)
+	tx, err := q.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var role string
+	err = tx.QueryRow(`SELECT role FROM users WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, id).Scan(&role)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("user not found")
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if role == models.RoleSystem {
+		return fmt.Errorf("system user cannot be deleted")
+	}
+
+	_, err = tx.Exec(`
+		UPDATE images SET uploaded_by = (SELECT id FROM users WHERE email = $2)
+		WHERE uploaded_by = $1`, id, models.SystemUserEmail)
 	if err != nil {
+		return fmt.Errorf("failed to reassign images: %w", err)
+	}
+	if err := revokeUserSessions(tx, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE users SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = $1`, id); err != nil {
 		return fmt.Errorf("failed to delete user: %w", err)
 	}
-	
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// RestoreUser lets a deleted user log in again. Their images stay with the system account.
+func (q *UserQueries) RestoreUser(id int) error {
+	result, err := q.db.Exec(`
+		UPDATE users SET deleted_at = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND deleted_at IS NOT NULL AND role <> $2`, id, models.RoleSystem)
+	if err != nil {
+		return fmt.Errorf("failed to restore user: %w", err)
+	}
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
 		return fmt.Errorf("failed to get rows affected: %w", err)
 	}
-	
 	if rowsAffected == 0 {
 		return fmt.Errorf("user not found")
 	}
-	
 	return nil
 }
 
+// EmailExists reports whether an account uses email. Deleted users keep theirs, so it
+// can't be registered again while their orders refer to it.
 func (q *UserQueries) EmailExists(email string) (bool, error) {
 	query := `SELECT EXISTS(SELECT 1 FROM users WHERE email = $1)`
 	var exists bool
@@ -114,48 +162,153 @@ func (q *UserQueries) EmailExists(email string) (bool, error) {
 
 // Admin user management methods
 
-func (q *UserQueries) ListUsers(page, limit int, search string) ([]models.User, int, error) {
-	offset := (page - 1) * limit
-	var users []models.User
-	var total int
+// userListFrom joins each user with their order statistics
+const userListFrom = `
+	FROM users u
+	LEFT JOIN LATERAL (
+		SELECT COUNT(*) AS order_count,
+			COALESCE(SUM(o.total_amount - COALESCE(r.refunded, 0)), 0) AS lifetime_value,
+			MAX(o.created_at) AS last_order_at
+		FROM orders o
+		LEFT JOIN (
+			SELECT order_id, SUM(amount) AS refunded
+			FROM refunds
+			WHERE status = 'completed'
+			GROUP BY order_id
+		) r ON r.order_id = o.id
+		WHERE o.user_id = u.id AND o.status <> 'cancelled' AND NOT o.is_test
+	) stats ON true`
+
+// userListSortColumn is the column each sort orders users by, for keyset pagination
+var userListSortColumn = map[string]string{
+	models.UserSortCreated:       "u.created_at",
+	models.UserSortLastLogin:     "u.last_login_at",
+	models.UserSortOrderCount:    "stats.order_count",
+	models.UserSortLifetimeValue: "stats.lifetime_value",
+}
+
+// userCursor returns the cursor of a user in a list sorted by sort
+func userCursor(user models.AdminUserSummary, sort string) string {
+	cursor := models.Cursor{Sort: sort, ID: user.ID}
+	var value string
+	switch sort {
+	case models.UserSortLastLogin:
+		if user.LastLoginAt == nil {
+			return cursor.Encode()
+		}
+		value = user.LastLoginAt.Format(time.RFC3339Nano)
+	case models.UserSortOrderCount:
+		value = strconv.Itoa(user.OrderCount)
+	case models.UserSortLifetimeValue:
+		value = strconv.FormatFloat(user.LifetimeValue, 'f', -1, 64)
+	default:
+		value = user.CreatedAt.Format(time.RFC3339Nano)
+	}
+	cursor.Value = &value
+	return cursor.Encode()
+}
+
+var userListOrder = map[string]string{
+	models.UserSortCreated:       "u.created_at DESC",
+	models.UserSortLastLogin:     "u.last_login_at DESC NULLS LAST",
+	models.UserSortOrderCount:    "stats.order_count DESC",
+	models.UserSortLifetimeValue: "stats.lifetime_value DESC",
+}
+
+// ListUsers returns a page of users with their order and login activity and the cursor
+// of the next page. With after set, the page starts after that cursor and page is
+// ignored; the cursor must come from a list with the same sort.
+func (q *UserQueries) ListUsers(page, limit int, after *models.Cursor, filter models.UserListFilter) ([]models.AdminUserSummary, int, string, error) {
+	conditions := []string{}
+	args := []interface{}{}
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	addCondition("u.role <> $%d", models.RoleSystem)
+	if filter.Deleted {
+		conditions = append(conditions, "u.deleted_at IS NOT NULL")
+	} else {
+		conditions = append(conditions, "u.deleted_at IS NULL")
+	}
+	if filter.Search != "" {
+		addCondition("u.email ILIKE $%d", "%"+filter.Search+"%")
+	}
+	if filter.Role != "" {
+		addCondition("u.role = $%d", filter.Role)
+	}
+	if filter.CreatedFrom != nil {
+		addCondition("u.created_at >= $%d", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		addCondition("u.created_at < $%d", *filter.CreatedTo)
+	}
+	if filter.HasOrders != nil {
+		if *filter.HasOrders {
+			conditions = append(conditions, "stats.order_count > 0")
+		} else {
+			conditions = append(conditions, "stats.order_count = 0")
+		}
+	}
 
-	// Count total users
-	countQuery := `SELECT COUNT(*) FROM users`
-	countArgs := []interface{}{}
-	
-	if search != "" {
-		countQuery += ` WHERE email ILIKE $1`
-		countArgs = append(countArgs, "%"+search+"%")
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
 	}
-	
-	err := q.db.QueryRow(countQuery, countArgs...).Scan(&total)
-	if err != nil {
-		return nil, 0, fmt.Errorf("failed to count users: %w", err)
+
+	var total int
+	if err := q.db.QueryRow(`SELECT COUNT(*)`+userListFrom+where, args...).Scan(&total); err != nil {
+		return nil, 0, "", fmt.Errorf("failed to count users: %w", err)
 	}
 
-	// Get users
-	query := `
-		SELECT id, email, password_hash, role, created_at, updated_at
-		FROM users
-	`
-	args := []interface{}{}
-	
-	if search != "" {
-		query += ` WHERE email ILIKE $1`
-		args = append(args, "%"+search+"%")
+	sort := filter.Sort
+	order, ok := userListOrder[sort]
+	if !ok {
+		sort = models.UserSortCreated
+		order = userListOrder[sort]
 	}
-	
-	query += ` ORDER BY created_at DESC LIMIT $` + fmt.Sprintf("%d", len(args)+1) + ` OFFSET $` + fmt.Sprintf("%d", len(args)+2)
+
+	// A cursor continues the list after its user instead of skipping rows. Users who
+	// never logged in come last when sorting by last login.
+	offset := (page - 1) * limit
+	if after != nil {
+		if after.Sort != sort || (after.Value == nil && sort != models.UserSortLastLogin) {
+			return nil, 0, "", fmt.Errorf("invalid cursor")
+		}
+		column := userListSortColumn[sort]
+		var condition string
+		if after.Value == nil {
+			args = append(args, after.ID)
+			condition = fmt.Sprintf("(%s IS NULL AND u.id < $%d)", column, len(args))
+		} else {
+			condition = keysetCondition(column, "u.id", len(args)+1)
+			if sort == models.UserSortLastLogin {
+				condition = "(" + condition + " OR " + column + " IS NULL)"
+			}
+			args = append(args, *after.Value, after.ID)
+		}
+		if where == "" {
+			where = " WHERE " + condition
+		} else {
+			where += " AND " + condition
+		}
+		offset = 0
+	}
+	query := `
+		SELECT u.id, u.email, u.password_hash, u.role, u.created_at, u.updated_at, u.deleted_at, u.last_login_at,
+			stats.order_count, stats.lifetime_value, stats.last_order_at` + userListFrom + where +
+		fmt.Sprintf(` ORDER BY %s, u.id DESC LIMIT $%d OFFSET $%d`, order, len(args)+1, len(args)+2)
 	args = append(args, limit, offset)
 
 	rows, err := q.db.Query(query, args...)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to list users: %w", err)
+		return nil, 0, "", fmt.Errorf("failed to list users: %w", err)
 	}
 	defer rows.Close()
 
+	users := []models.AdminUserSummary{}
 	for rows.Next() {
-		var user models.User
+		var user models.AdminUserSummary
 		err := rows.Scan(
 			&user.ID,
 			&user.Email,
@@ -163,16 +316,28 @@ func (q *UserQueries) ListUsers(page, limit int, search string) ([]models.User,
 			&user.Role,
 			&user.CreatedAt,
 			&user.UpdatedAt,
+			&user.DeletedAt,
+			&user.LastLoginAt,
+			&user.OrderCount,
+			&user.LifetimeValue,
+			&user.LastOrderAt,
 		)
 		if err != nil {
-			return nil, 0, fmt.Errorf("failed to scan user: %w", err)
+			return nil, 0, "", fmt.Errorf("failed to scan user: %w", err)
 		}
 		users = append(users, user)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, "", fmt.Errorf("failed to list users: %w", err)
+	}
 
-	return users, total, nil
+	next := nextCursor(len(users), limit, func() string {
+		return userCursor(users[len(users)-1], sort)
+	})
+	return users, total, next, nil
 }
 
+
 func (q *UserQueries) CreateAdminUser(email, password, role string) (*models.User, error) {
 	hashedPassword, err := auth.HashPassword(password)
 	if err != nil {
@@ -249,7 +414,7 @@ func (q *ImageQueries) CreateImage(image *models.Image) error {
 	query := `
 		INSERT INTO images (filename, original_name, path, size_bytes, mime_type, uploaded_by)
 		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id, created_at, updated_at
+		RETURNING id, focal_x, focal_y, created_at, updated_at
 	`
 	err := q.db.QueryRow(query, 
 		image.Filename, 
@@ -260,6 +425,8 @@ func (q *ImageQueries) CreateImage(image *models.Image) error {
 		image.UploadedBy,
 	).Scan(
 		&image.ID,
+		&image.FocalX,
+		&image.FocalY,
 		&image.CreatedAt,
 		&image.UpdatedAt,
 	)
@@ -271,11 +438,12 @@ func (q *ImageQueries) CreateImage(image *models.Image) error {
 
 func (q *ImageQueries) GetImageByID(id int) (*models.Image, error) {
 	query := `
-		SELECT id, filename, original_name, path, size_bytes, mime_type, uploaded_by, created_at, updated_at
+		SELECT id, filename, original_name, path, size_bytes, mime_type, uploaded_by, focal_x, focal_y, crops, COALESCE(alt_text, ''), COALESCE(title, ''), created_at, updated_at
 		FROM images
 		WHERE id = $1
 	`
 	image := &models.Image{}
+	var crops []byte
 	err := q.db.QueryRow(query, id).Scan(
 		&image.ID,
 		&image.Filename,
@@ -284,6 +452,11 @@ func (q *ImageQueries) GetImageByID(id int) (*models.Image, error) {
 		&image.SizeBytes,
 		&image.MimeType,
 		&image.UploadedBy,
+		&image.FocalX,
+		&image.FocalY,
+		&crops,
+		&image.AltText,
+		&image.Title,
 		&image.CreatedAt,
 		&image.UpdatedAt,
 	)
@@ -293,36 +466,67 @@ func (q *ImageQueries) GetImageByID(id int) (*models.Image, error) {
 		}
 		return nil, fmt.Errorf("failed to get image: %w", err)
 	}
+	if err := json.Unmarshal(crops, &image.Crops); err != nil {
+		return nil, fmt.Errorf("failed to decode image crops: %w", err)
+	}
 	return image, nil
 }
 
-func (q *ImageQueries) ListImages(page, limit int) ([]models.Image, int, error) {
+// ListImages returns a page of images, optionally only those carrying tag, and the
+// cursor of the next page. With after set, the page starts after that cursor and page
+// is ignored.
+func (q *ImageQueries) ListImages(page, limit int, after *models.Cursor, tag string) ([]models.Image, int, string, error) {
 	offset := (page - 1) * limit
 	var images []models.Image
 	var total int
 
+	whereClause := ""
+	args := []interface{}{}
+	if tag != "" {
+		whereClause = "WHERE EXISTS (SELECT 1 FROM image_tags t WHERE t.image_id = images.id AND t.tag = $1)"
+		args = append(args, tag)
+	}
+
 	// Count total images
-	countQuery := `SELECT COUNT(*) FROM images`
-	err := q.db.QueryRow(countQuery).Scan(&total)
+	countQuery := `SELECT COUNT(*) FROM images ` + whereClause
+	err := q.db.QueryRow(countQuery, args...).Scan(&total)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to count images: %w", err)
+		return nil, 0, "", fmt.Errorf("failed to count images: %w", err)
+	}
+
+	if after != nil {
+		value, err := cursorValue(after)
+		if err != nil {
+			return nil, 0, "", err
+		}
+		if whereClause == "" {
+			whereClause = "WHERE "
+		} else {
+			whereClause += " AND "
+		}
+		whereClause += keysetCondition("created_at", "id", len(args)+1)
+		args = append(args, value, after.ID)
+		offset = 0
 	}
 
 	// Get images
 	query := `
-		SELECT id, filename, original_name, path, size_bytes, mime_type, uploaded_by, created_at, updated_at
+		SELECT id, filename, original_name, path, size_bytes, mime_type, uploaded_by, focal_x, focal_y, crops, COALESCE(alt_text, ''), COALESCE(title, ''), created_at, updated_at
 		FROM images
-		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2
-	`
-	rows, err := q.db.Query(query, limit, offset)
+		` + whereClause + fmt.Sprintf(`
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, len(args)+1, len(args)+2)
+	args = append(args, limit, offset)
+	rows, err := q.db.Query(query, args...)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to list images: %w", err)
+		return nil, 0, "", fmt.Errorf("failed to list images: %w", err)
 	}
 	defer rows.Close()
 
 	for rows.Next() {
 		var image models.Image
+		var crops []byte
 		err := rows.Scan(
 			&image.ID,
 			&image.Filename,
@@ -331,16 +535,55 @@ func (q *ImageQueries) ListImages(page, limit int) ([]models.Image, int, error)
 			&image.SizeBytes,
 			&image.MimeType,
 			&image.UploadedBy,
+			&image.FocalX,
+			&image.FocalY,
+			&crops,
+			&image.AltText,
+			&image.Title,
 			&image.CreatedAt,
 			&image.UpdatedAt,
 		)
 		if err != nil {
-			return nil, 0, fmt.Errorf("failed to scan image: %w", err)
+			return nil, 0, "", fmt.Errorf("failed to scan image: %w", err)
+		}
+		if err := json.Unmarshal(crops, &image.Crops); err != nil {
+			return nil, 0, "", fmt.Errorf("failed to decode image crops: %w", err)
 		}
 		images = append(images, image)
 	}
 
-	return images, total, nil
+	next := nextCursor(len(images), limit, func() string {
+		last := images[len(images)-1]
+		return timeCursor(last.CreatedAt, last.ID)
+	})
+	return images, total, next, nil
+}
+
+// UpdateImageCrop stores the focal point and per-variant crops used for generated variants
+func (q *ImageQueries) UpdateImageCrop(id int, focalX, focalY float64, crops map[string]models.ImageCrop) error {
+	if crops == nil {
+		crops = map[string]models.ImageCrop{}
+	}
+	encoded, err := json.Marshal(crops)
+	if err != nil {
+		return fmt.Errorf("failed to encode image crops: %w", err)
+	}
+
+	result, err := q.db.Exec(`
+		UPDATE images SET focal_x = $2, focal_y = $3, crops = $4, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1`, id, focalX, focalY, encoded)
+	if err != nil {
+		return fmt.Errorf("failed to update image crop: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("image not found")
+	}
+	return nil
 }
 
 func (q *ImageQueries) DeleteImage(id int) error {
@@ -451,6 +694,7 @@ func (q *CategoryQueries) GetCategoryByID(id int) (*models.CategoryWithImage, er
 			SizeBytes:    image.SizeBytes,
 			MimeType:     image.MimeType,
 			UploadedBy:   image.UploadedBy,
+			Variants:     imaging.VariantPaths(image.Path, image.MimeType),
 			CreatedAt:    image.CreatedAt.Format(time.RFC3339),
 			UpdatedAt:    image.UpdatedAt.Format(time.RFC3339),
 		}
@@ -576,6 +820,7 @@ func (q *CategoryQueries) ListCategories(page, limit int, search string, activeO
 				SizeBytes:    image.SizeBytes,
 				MimeType:     image.MimeType,
 				UploadedBy:   image.UploadedBy,
+				Variants:     imaging.VariantPaths(image.Path, image.MimeType),
 				CreatedAt:    image.CreatedAt.Format(time.RFC3339),
 				UpdatedAt:    image.UpdatedAt.Format(time.RFC3339),
 			}
@@ -592,9 +837,16 @@ func (q *CategoryQueries) GetActiveCategories() ([]models.CategoryWithImage, err
 	query := `
 		SELECT 
 			c.id, c.name, c.slug, c.image_id, c.active, c.chart_only, c.created_at, c.updated_at,
-			i.id, i.filename, i.original_name, i.path, i.size_bytes, i.mime_type, i.uploaded_by, i.created_at, i.updated_at
+			i.id, i.filename, i.original_name, i.path, i.size_bytes, i.mime_type, i.uploaded_by, i.created_at, i.updated_at,
+			COALESCE(pc.product_count, 0)
 		FROM categories c
 		LEFT JOIN images i ON c.image_id = i.id
+		LEFT JOIN (
+			SELECT category_id, COUNT(*) AS product_count
+			FROM products
+			WHERE visible_web = true
+			GROUP BY category_id
+		) pc ON pc.category_id = c.id
 		WHERE c.active = true
 		ORDER BY c.name
 	`
@@ -631,6 +883,7 @@ func (q *CategoryQueries) GetActiveCategories() ([]models.CategoryWithImage, err
 			&imageUploadedBy,
 			&imageCreatedAt,
 			&imageUpdatedAt,
+			&category.ProductCount,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan category: %w", err)
@@ -661,6 +914,7 @@ func (q *CategoryQueries) GetActiveCategories() ([]models.CategoryWithImage, err
 				SizeBytes:    image.SizeBytes,
 				MimeType:     image.MimeType,
 				UploadedBy:   image.UploadedBy,
+				Variants:     imaging.VariantPaths(image.Path, image.MimeType),
 				CreatedAt:    image.CreatedAt.Format(time.RFC3339),
 				UpdatedAt:    image.UpdatedAt.Format(time.RFC3339),
 			}
@@ -676,6 +930,7 @@ func (q *CategoryQueries) GetActiveCategories() ([]models.CategoryWithImage, err
 	return categories, nil
 }
 
+// UpdateCategory saves a category; an empty slug keeps the current one
 func (q *CategoryQueries) UpdateCategory(id int, name, slug string, imageID *int, active, chartOnly bool) (*models.Category, error) {
 	category := &models.Category{
 		ID:        id,
@@ -688,11 +943,12 @@ func (q *CategoryQueries) UpdateCategory(id int, name, slug string, imageID *int
 
 	query := `
 		UPDATE categories
-		SET name = $1, slug = $2, image_id = $3, active = $4, chart_only = $5, updated_at = CURRENT_TIMESTAMP
+		SET name = $1, slug = COALESCE(NULLIF($2, ''), slug), image_id = $3, active = $4, chart_only = $5, updated_at = CURRENT_TIMESTAMP
 		WHERE id = $6
-		RETURNING created_at, updated_at
+		RETURNING slug, created_at, updated_at
 	`
 	err := q.db.QueryRow(query, name, slug, imageID, active, chartOnly, id).Scan(
+		&category.Slug,
 		&category.CreatedAt,
 		&category.UpdatedAt,
 	)
@@ -1039,6 +1295,7 @@ func (q *ColorQueries) GetColorByID(id int) (*models.ColorWithRelations, error)
 			SizeBytes:    image.SizeBytes,
 			MimeType:     image.MimeType,
 			UploadedBy:   image.UploadedBy,
+			Variants:     imaging.VariantPaths(image.Path, image.MimeType),
 			CreatedAt:    image.CreatedAt.Format(time.RFC3339),
 			UpdatedAt:    image.UpdatedAt.Format(time.RFC3339),
 		}
@@ -1182,6 +1439,7 @@ func (q *ColorQueries) ListColors(page, limit int, search string, materialID *in
 				SizeBytes:    image.SizeBytes,
 				MimeType:     image.MimeType,
 				UploadedBy:   image.UploadedBy,
+				Variants:     imaging.VariantPaths(image.Path, image.MimeType),
 				CreatedAt:    image.CreatedAt.Format(time.RFC3339),
 				UpdatedAt:    image.UpdatedAt.Format(time.RFC3339),
 			}
@@ -1312,49 +1570,10 @@ func (q *AdditionalServiceQueries) GetAdditionalServiceByID(id int) (*models.Add
 	}
 
 	// Then get associated images
-	imagesQuery := `
-		SELECT i.id, i.filename, i.original_name, i.path, i.size_bytes, i.mime_type, i.uploaded_by, i.created_at, i.updated_at
-		FROM images i
-		INNER JOIN additional_service_images asi ON i.id = asi.image_id
-		WHERE asi.additional_service_id = $1
-		ORDER BY i.created_at ASC
-	`
-	rows, err := q.db.Query(imagesQuery, id)
+	images, err := linkedImages(q.db, serviceImageLinks, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get service images: %w", err)
 	}
-	defer rows.Close()
-
-	var images []models.ImageResponse
-	for rows.Next() {
-		var image models.Image
-		err := rows.Scan(
-			&image.ID,
-			&image.Filename,
-			&image.OriginalName,
-			&image.Path,
-			&image.SizeBytes,
-			&image.MimeType,
-			&image.UploadedBy,
-			&image.CreatedAt,
-			&image.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan service image: %w", err)
-		}
-
-		images = append(images, models.ImageResponse{
-			ID:           image.ID,
-			Filename:     image.Filename,
-			OriginalName: image.OriginalName,
-			Path:         image.Path,
-			SizeBytes:    image.SizeBytes,
-			MimeType:     image.MimeType,
-			UploadedBy:   image.UploadedBy,
-			CreatedAt:    image.CreatedAt.Format(time.RFC3339),
-			UpdatedAt:    image.UpdatedAt.Format(time.RFC3339),
-		})
-	}
 
 	service.Images = images
 	return service, nil
@@ -1434,51 +1653,11 @@ func (q *AdditionalServiceQueries) ListAdditionalServices(page, limit int, searc
 		}
 
 		// Get images for this service
-		imagesQuery := `
-			SELECT i.id, i.filename, i.original_name, i.path, i.size_bytes, i.mime_type, i.uploaded_by, i.created_at, i.updated_at
-			FROM images i
-			INNER JOIN additional_service_images asi ON i.id = asi.image_id
-			WHERE asi.additional_service_id = $1
-			ORDER BY i.created_at ASC
-		`
-		imageRows, err := q.db.Query(imagesQuery, service.ID)
+		images, err := linkedImages(q.db, serviceImageLinks, service.ID)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to get images for service %d: %w", service.ID, err)
 		}
 
-		var images []models.ImageResponse
-		for imageRows.Next() {
-			var image models.Image
-			err := imageRows.Scan(
-				&image.ID,
-				&image.Filename,
-				&image.OriginalName,
-				&image.Path,
-				&image.SizeBytes,
-				&image.MimeType,
-				&image.UploadedBy,
-				&image.CreatedAt,
-				&image.UpdatedAt,
-			)
-			if err != nil {
-				imageRows.Close()
-				return nil, 0, fmt.Errorf("failed to scan service image: %w", err)
-			}
-
-			images = append(images, models.ImageResponse{
-				ID:           image.ID,
-				Filename:     image.Filename,
-				OriginalName: image.OriginalName,
-				Path:         image.Path,
-				SizeBytes:    image.SizeBytes,
-				MimeType:     image.MimeType,
-				UploadedBy:   image.UploadedBy,
-				CreatedAt:    image.CreatedAt.Format(time.RFC3339),
-				UpdatedAt:    image.UpdatedAt.Format(time.RFC3339),
-			})
-		}
-		imageRows.Close()
-
 		service.Images = images
 		services = append(services, service)
 	}
@@ -1552,53 +1731,15 @@ func (q *AdditionalServiceQueries) NameExists(name string, excludeID *int) (bool
 // ManyToMany image management methods
 
 func (q *AdditionalServiceQueries) ReplaceImages(serviceID int, imageIDs []int) error {
-	// Start transaction
-	tx, err := q.db.Begin()
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
-	}
-	defer tx.Rollback()
-
-	// Delete existing associations
-	_, err = tx.Exec("DELETE FROM additional_service_images WHERE additional_service_id = $1", serviceID)
-	if err != nil {
-		return fmt.Errorf("failed to delete existing image associations: %w", err)
-	}
-
-	// Add new associations
-	for _, imageID := range imageIDs {
-		_, err = tx.Exec("INSERT INTO additional_service_images (additional_service_id, image_id) VALUES ($1, $2)", serviceID, imageID)
-		if err != nil {
-			return fmt.Errorf("failed to add image association: %w", err)
-		}
-	}
-
-	// Commit transaction
-	if err = tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
-	}
-
-	return nil
+	return replaceImageLinks(q.db, serviceImageLinks, serviceID, imageIDs)
 }
 
 func (q *AdditionalServiceQueries) AddImages(serviceID int, imageIDs []int) error {
-	for _, imageID := range imageIDs {
-		_, err := q.db.Exec("INSERT INTO additional_service_images (additional_service_id, image_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", serviceID, imageID)
-		if err != nil {
-			return fmt.Errorf("failed to add image association: %w", err)
-		}
-	}
-	return nil
+	return addImageLinks(q.db, serviceImageLinks, serviceID, imageIDs)
 }
 
 func (q *AdditionalServiceQueries) RemoveImages(serviceID int, imageIDs []int) error {
-	for _, imageID := range imageIDs {
-		_, err := q.db.Exec("DELETE FROM additional_service_images WHERE additional_service_id = $1 AND image_id = $2", serviceID, imageID)
-		if err != nil {
-			return fmt.Errorf("failed to remove image association: %w", err)
-		}
-	}
-	return nil
+	return removeImageLinks(q.db, serviceImageLinks, serviceID, imageIDs)
 }
 
 // Product Queries
@@ -1611,13 +1752,26 @@ func NewProductQueries(db *sql.DB) *ProductQueries {
 	return &ProductQueries{db: db}
 }
 
-func (q *ProductQueries) ListProducts(page, limit int, search string, categoryID, materialID *int) ([]models.ProductWithRelations, int, error) {
+// ListProducts returns products for the admin panel and channel feeds; a non-empty
+// channel limits the result to products offered in that sales channel
+// ListProducts returns a page of products for the admin panel; incomplete keeps only
+// products failing a completeness check. With after set, the page starts after that
+// cursor and page is ignored; the cursor of the next page is returned.
+func (q *ProductQueries) ListProducts(page, limit int, after *models.Cursor, search string, categoryID, materialID *int, channel string, incomplete bool) ([]models.ProductWithRelations, int, string, error) {
 	offset := (page - 1) * limit
 	
 	whereClause := "WHERE 1=1"
 	args := []interface{}{}
 	argCount := 0
 	
+	if channel != "" {
+		column, err := productChannelColumn(channel)
+		if err != nil {
+			return nil, 0, "", err
+		}
+		whereClause += " AND " + column + " = true"
+	}
+	
 	if search != "" {
 		argCount++
 		whereClause += fmt.Sprintf(" AND (p.name ILIKE $%d OR p.short_description ILIKE $%d OR p.description ILIKE $%d OR COALESCE(m.name, '') ILIKE $%d OR COALESCE(c.name, '') ILIKE $%d)", argCount, argCount, argCount, argCount, argCount)
@@ -1636,6 +1790,10 @@ func (q *ProductQueries) ListProducts(page, limit int, search string, categoryID
 		args = append(args, *materialID)
 	}
 	
+	if incomplete {
+		whereClause += " AND NOT (" + productCompleteCondition + ")"
+	}
+	
 	// First get total count
 	countQuery := fmt.Sprintf(`
 		SELECT COUNT(*) 
@@ -1646,7 +1804,19 @@ func (q *ProductQueries) ListProducts(page, limit int, search string, categoryID
 	var total int
 	err := q.db.QueryRow(countQuery, args...).Scan(&total)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to count products: %w", err)
+		return nil, 0, "", fmt.Errorf("failed to count products: %w", err)
+	}
+	
+	// A cursor continues the list after its product instead of skipping rows
+	if after != nil {
+		value, err := cursorValue(after)
+		if err != nil {
+			return nil, 0, "", err
+		}
+		whereClause += " AND " + keysetCondition("p.created_at", "p.id", argCount+1)
+		args = append(args, value, after.ID)
+		argCount += 2
+		offset = 0
 	}
 	
 	// Then get paginated results with all relations
@@ -1657,8 +1827,8 @@ func (q *ProductQueries) ListProducts(page, limit int, search string, categoryID
 	
 	query := fmt.Sprintf(`
 		SELECT 
-			p.id, p.name, p.short_description, p.description, p.material_id, p.main_image_id, p.category_id, p.created_at, p.updated_at,
-			mi.id, mi.filename, mi.original_name, mi.path, mi.size_bytes, mi.mime_type, mi.uploaded_by, mi.created_at, mi.updated_at,
+			p.id, p.name, COALESCE(p.slug, ''), p.short_description, p.description, p.material_id, p.main_image_id, p.category_id, p.visible_web, p.visible_marketplace, p.visible_b2b, p.created_at, p.updated_at,
+			mi.id, mi.filename, mi.original_name, mi.path, mi.size_bytes, mi.mime_type, mi.uploaded_by, mi.created_at, mi.updated_at, ` + imageSizesColumn("mi") + `, ` + productImageTextColumns("p", "mi") + `,
 			m.id, m.name, m.created_at, m.updated_at,
 			c.id, c.name, c.slug, c.image_id, c.active, c.chart_only, c.created_at, c.updated_at
 		FROM products p
@@ -1666,7 +1836,7 @@ func (q *ProductQueries) ListProducts(page, limit int, search string, categoryID
 		LEFT JOIN materials m ON p.material_id = m.id
 		LEFT JOIN categories c ON p.category_id = c.id
 		%s
-		ORDER BY p.created_at DESC
+		ORDER BY p.created_at DESC, p.id DESC
 		LIMIT $%d OFFSET $%d
 	`, whereClause, limitArg, offsetArg)
 	
@@ -1674,7 +1844,7 @@ func (q *ProductQueries) ListProducts(page, limit int, search string, categoryID
 	
 	rows, err := q.db.Query(query, args...)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to list products: %w", err)
+		return nil, 0, "", fmt.Errorf("failed to list products: %w", err)
 	}
 	defer rows.Close()
 	
@@ -1683,6 +1853,7 @@ func (q *ProductQueries) ListProducts(page, limit int, search string, categoryID
 	for rows.Next() {
 		var product models.ProductWithRelations
 		var mainImage models.ImageResponse
+		var mainImageSizes []byte
 		var material models.MaterialResponse
 		var category models.CategoryResponse
 		var materialID, categoryID sql.NullInt64
@@ -1692,17 +1863,22 @@ func (q *ProductQueries) ListProducts(page, limit int, search string, categoryID
 		var categoryActive, categoryChartOnly sql.NullBool
 		
 		err := rows.Scan(
-			&product.ID, &product.Name, &product.ShortDescription, &product.Description,
-			&product.MaterialID, &product.MainImageID, &product.CategoryID, &product.CreatedAt, &product.UpdatedAt,
+			&product.ID, &product.Name, &product.Slug, &product.ShortDescription, &product.Description,
+			&product.MaterialID, &product.MainImageID, &product.CategoryID,
+			&product.Channels.Web, &product.Channels.Marketplace, &product.Channels.B2B, &product.CreatedAt, &product.UpdatedAt,
 			&mainImage.ID, &mainImage.Filename, &mainImage.OriginalName, &mainImage.Path,
-			&mainImage.SizeBytes, &mainImage.MimeType, &mainImage.UploadedBy, &mainImage.CreatedAt, &mainImage.UpdatedAt,
+			&mainImage.SizeBytes, &mainImage.MimeType, &mainImage.UploadedBy, &mainImage.CreatedAt, &mainImage.UpdatedAt, &mainImageSizes, &mainImage.AltText, &mainImage.Title,
 			&materialID, &materialName, &materialCreatedAt, &materialUpdatedAt,
 			&categoryID, &categoryName, &categorySlug, &categoryImageID, &categoryActive, &categoryChartOnly, &categoryCreatedAt, &categoryUpdatedAt,
 		)
 		if err != nil {
-			return nil, 0, fmt.Errorf("failed to scan product: %w", err)
+			return nil, 0, "", fmt.Errorf("failed to scan product: %w", err)
 		}
 		
+		mainImage.Variants = imaging.VariantPaths(mainImage.Path, mainImage.MimeType)
+		if mainImage.Sizes, err = parseImageSizes(mainImageSizes); err != nil {
+			return nil, 0, "", err
+		}
 		product.MainImage = mainImage
 		
 		// Add material if exists
@@ -1730,77 +1906,59 @@ func (q *ProductQueries) ListProducts(page, limit int, search string, categoryID
 			product.Category = &category
 		}
 		
-		// Get product images
-		images, err := q.getProductImages(product.ID)
-		if err != nil {
-			return nil, 0, fmt.Errorf("failed to get product images: %w", err)
-		}
-		product.Images = images
-		
-		// Get product services
-		services, err := q.getProductServices(product.ID)
-		if err != nil {
-			return nil, 0, fmt.Errorf("failed to get product services: %w", err)
-		}
-		product.AdditionalServices = services
-		
 		products = append(products, product)
 	}
 	
-	return products, total, nil
+	if err := q.loadProductRelations(products); err != nil {
+		return nil, 0, "", err
+	}
+	
+	next := nextCursor(len(products), limit, func() string {
+		last := products[len(products)-1]
+		return timeCursor(last.CreatedAt, last.ID)
+	})
+	return products, total, next, nil
 }
 
 func (q *ProductQueries) getProductImages(productID int) ([]models.ImageResponse, error) {
-	query := `
-		SELECT i.id, i.filename, i.original_name, i.path, i.size_bytes, i.mime_type, i.uploaded_by, i.created_at, i.updated_at
-		FROM images i
-		JOIN product_images pi ON i.id = pi.image_id
-		WHERE pi.product_id = $1
-		ORDER BY i.created_at ASC
-	`
-	
-	rows, err := q.db.Query(query, productID)
+	return linkedImages(q.db, productImageLinks, productID)
+}
+
+func (q *ProductQueries) getProductServices(productID int) ([]models.AdditionalServiceResponse, error) {
+	services, err := q.getProductServicesByProduct([]int{productID})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get product images: %w", err)
-	}
-	defer rows.Close()
-	
-	var images []models.ImageResponse
-	for rows.Next() {
-		var image models.ImageResponse
-		err := rows.Scan(
-			&image.ID, &image.Filename, &image.OriginalName, &image.Path,
-			&image.SizeBytes, &image.MimeType, &image.UploadedBy, &image.CreatedAt, &image.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan image: %w", err)
-		}
-		images = append(images, image)
+		return nil, err
 	}
-	
-	return images, nil
+	return services[productID], nil
 }
 
-func (q *ProductQueries) getProductServices(productID int) ([]models.AdditionalServiceResponse, error) {
+// getProductServicesByProduct returns the additional services of each of the products
+// in one query
+func (q *ProductQueries) getProductServicesByProduct(productIDs []int) (map[int][]models.AdditionalServiceResponse, error) {
+	services := make(map[int][]models.AdditionalServiceResponse, len(productIDs))
+	if len(productIDs) == 0 {
+		return services, nil
+	}
+
 	query := `
-		SELECT a.id, a.name, a.description, a.price, a.created_at, a.updated_at
+		SELECT ps.product_id, a.id, a.name, a.description, a.price, a.created_at, a.updated_at
 		FROM additional_services a
 		JOIN product_services ps ON a.id = ps.additional_service_id
-		WHERE ps.product_id = $1
-		ORDER BY a.name ASC
+		WHERE ps.product_id = ANY($1)
+		ORDER BY ps.product_id, a.name ASC
 	`
 	
-	rows, err := q.db.Query(query, productID)
+	rows, err := q.db.Query(query, pq.Array(productIDs))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get product services: %w", err)
 	}
 	defer rows.Close()
 	
-	var services []models.AdditionalServiceResponse
 	for rows.Next() {
+		var productID int
 		var service models.AdditionalServiceResponse
 		err := rows.Scan(
-			&service.ID, &service.Name, &service.Description, &service.Price, &service.CreatedAt, &service.UpdatedAt,
+			&productID, &service.ID, &service.Name, &service.Description, &service.Price, &service.CreatedAt, &service.UpdatedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan service: %w", err)
@@ -1809,24 +1967,57 @@ func (q *ProductQueries) getProductServices(productID int) ([]models.AdditionalS
 		// For each service, get its images (empty for now, but maintaining structure)
 		service.Images = []models.ImageResponse{}
 		
-		services = append(services, service)
+		services[productID] = append(services[productID], service)
 	}
 	
-	return services, nil
+	return services, rows.Err()
+}
+
+// loadProductRelations sets the images and additional services of a page of products
+// with one query for each, rather than two per product
+func (q *ProductQueries) loadProductRelations(products []models.ProductWithRelations) error {
+	ids := make([]int, len(products))
+	for i, product := range products {
+		ids[i] = product.ID
+	}
+
+	images, err := linkedImagesByOwner(q.db, productImageLinks, ids)
+	if err != nil {
+		return fmt.Errorf("failed to get product images: %w", err)
+	}
+	services, err := q.getProductServicesByProduct(ids)
+	if err != nil {
+		return fmt.Errorf("failed to get product services: %w", err)
+	}
+
+	for i := range products {
+		products[i].Images = images[products[i].ID]
+		products[i].AdditionalServices = services[products[i].ID]
+	}
+	return nil
 }
 
 func (q *ProductQueries) CreateProduct(product *models.Product) error {
+	if product.Channels == nil {
+		channels := models.DefaultProductChannels()
+		product.Channels = &channels
+	}
+	
 	query := `
-		INSERT INTO products (name, short_description, description, material_id, main_image_id, category_id)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO products (name, slug, short_description, description, material_id, main_image_id, category_id, visible_web, visible_marketplace, visible_b2b)
+		VALUES ($1, NULLIF($2, ''), $3, $4, $5, $6, $7, $8, $9, $10)
 		RETURNING id, created_at, updated_at
 	`
 	
-	err := q.db.QueryRow(query, product.Name, product.ShortDescription, product.Description, 
-		product.MaterialID, product.MainImageID, product.CategoryID).Scan(
+	err := q.db.QueryRow(query, product.Name, product.Slug, product.ShortDescription, product.Description, 
+		product.MaterialID, product.MainImageID, product.CategoryID,
+		product.Channels.Web, product.Channels.Marketplace, product.Channels.B2B).Scan(
 		&product.ID, &product.CreatedAt, &product.UpdatedAt,
 	)
 	if err != nil {
+		if strings.Contains(err.Error(), "idx_products_slug") {
+			return fmt.Errorf("product slug already exists")
+		}
 		return fmt.Errorf("failed to create product: %w", err)
 	}
 	
@@ -1836,8 +2027,8 @@ func (q *ProductQueries) CreateProduct(product *models.Product) error {
 func (q *ProductQueries) GetProduct(id int) (*models.ProductWithRelations, error) {
 	query := `
 		SELECT 
-			p.id, p.name, p.short_description, p.description, p.material_id, p.main_image_id, p.category_id, p.created_at, p.updated_at,
-			mi.id, mi.filename, mi.original_name, mi.path, mi.size_bytes, mi.mime_type, mi.uploaded_by, mi.created_at, mi.updated_at,
+			p.id, p.name, COALESCE(p.slug, ''), p.short_description, p.description, p.material_id, p.main_image_id, p.category_id, p.visible_web, p.visible_marketplace, p.visible_b2b, p.created_at, p.updated_at,
+			mi.id, mi.filename, mi.original_name, mi.path, mi.size_bytes, mi.mime_type, mi.uploaded_by, mi.created_at, mi.updated_at, ` + imageSizesColumn("mi") + `, ` + productImageTextColumns("p", "mi") + `,
 			m.id, m.name, m.created_at, m.updated_at,
 			c.id, c.name, c.slug, c.image_id, c.active, c.chart_only, c.created_at, c.updated_at
 		FROM products p
@@ -1849,6 +2040,7 @@ func (q *ProductQueries) GetProduct(id int) (*models.ProductWithRelations, error
 	
 	var product models.ProductWithRelations
 	var mainImage models.ImageResponse
+	var mainImageSizes []byte
 	var material models.MaterialResponse
 	var category models.CategoryResponse
 	var materialID, categoryID sql.NullInt64
@@ -1858,10 +2050,11 @@ func (q *ProductQueries) GetProduct(id int) (*models.ProductWithRelations, error
 	var categoryActive, categoryChartOnly sql.NullBool
 	
 	err := q.db.QueryRow(query, id).Scan(
-		&product.ID, &product.Name, &product.ShortDescription, &product.Description,
-		&product.MaterialID, &product.MainImageID, &product.CategoryID, &product.CreatedAt, &product.UpdatedAt,
+		&product.ID, &product.Name, &product.Slug, &product.ShortDescription, &product.Description,
+		&product.MaterialID, &product.MainImageID, &product.CategoryID,
+		&product.Channels.Web, &product.Channels.Marketplace, &product.Channels.B2B, &product.CreatedAt, &product.UpdatedAt,
 		&mainImage.ID, &mainImage.Filename, &mainImage.OriginalName, &mainImage.Path,
-		&mainImage.SizeBytes, &mainImage.MimeType, &mainImage.UploadedBy, &mainImage.CreatedAt, &mainImage.UpdatedAt,
+		&mainImage.SizeBytes, &mainImage.MimeType, &mainImage.UploadedBy, &mainImage.CreatedAt, &mainImage.UpdatedAt, &mainImageSizes, &mainImage.AltText, &mainImage.Title,
 		&materialID, &materialName, &materialCreatedAt, &materialUpdatedAt,
 		&categoryID, &categoryName, &categorySlug, &categoryImageID, &categoryActive, &categoryChartOnly, &categoryCreatedAt, &categoryUpdatedAt,
 	)
@@ -1872,6 +2065,10 @@ func (q *ProductQueries) GetProduct(id int) (*models.ProductWithRelations, error
 		return nil, fmt.Errorf("failed to get product: %w", err)
 	}
 	
+	mainImage.Variants = imaging.VariantPaths(mainImage.Path, mainImage.MimeType)
+	if mainImage.Sizes, err = parseImageSizes(mainImageSizes); err != nil {
+		return nil, err
+	}
 	product.MainImage = mainImage
 	
 	// Add material if exists
@@ -1919,23 +2116,78 @@ func (q *ProductQueries) GetProduct(id int) (*models.ProductWithRelations, error
 func (q *ProductQueries) UpdateProduct(id int, product *models.Product) error {
 	query := `
 		UPDATE products 
-		SET name = $1, short_description = $2, description = $3, material_id = $4, main_image_id = $5, category_id = $6
-		WHERE id = $7
+		SET name = $1, short_description = $2, description = $3, material_id = $4, main_image_id = $5, category_id = $6,
+			visible_web = COALESCE($7, visible_web), visible_marketplace = COALESCE($8, visible_marketplace), visible_b2b = COALESCE($9, visible_b2b),
+			slug = COALESCE(NULLIF($11, ''), slug)
+		WHERE id = $10
 		RETURNING updated_at
 	`
 	
+	// Leave channel flags untouched when none were provided
+	var web, marketplace, b2b sql.NullBool
+	if product.Channels != nil {
+		web = sql.NullBool{Bool: product.Channels.Web, Valid: true}
+		marketplace = sql.NullBool{Bool: product.Channels.Marketplace, Valid: true}
+		b2b = sql.NullBool{Bool: product.Channels.B2B, Valid: true}
+	}
+	
 	err := q.db.QueryRow(query, product.Name, product.ShortDescription, product.Description,
-		product.MaterialID, product.MainImageID, product.CategoryID, id).Scan(&product.UpdatedAt)
+		product.MaterialID, product.MainImageID, product.CategoryID, web, marketplace, b2b, id, product.Slug).Scan(&product.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return fmt.Errorf("product not found")
 		}
+		if strings.Contains(err.Error(), "idx_products_slug") {
+			return fmt.Errorf("product slug already exists")
+		}
 		return fmt.Errorf("failed to update product: %w", err)
 	}
 	
 	return nil
 }
 
+// productCompleteCondition matches products passing every check of validation.Completeness
+var productCompleteCondition = fmt.Sprintf(`
+	EXISTS (SELECT 1 FROM sizes s WHERE s.product_id = p.id)
+	AND EXISTS (SELECT 1 FROM product_variants pv WHERE pv.product_id = p.id AND pv.is_default = true)
+	AND (SELECT COUNT(*) FROM product_images pi WHERE pi.product_id = p.id) >= %d
+	AND p.category_id IS NOT NULL
+	AND char_length(regexp_replace(p.description, '^\s+|\s+$', '', 'g')) >= %d`,
+	validation.MinProductImages, validation.MinDescriptionCharacters)
+
+// SlugExists reports whether another product already uses slug
+func (q *ProductQueries) SlugExists(slug string, excludeID *int) (bool, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM products WHERE slug = $1`
+	args := []interface{}{slug}
+	
+	if excludeID != nil {
+		query += ` AND id != $2`
+		args = append(args, *excludeID)
+	}
+	
+	query += `)`
+	
+	var exists bool
+	err := q.db.QueryRow(query, args...).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check product slug existence: %w", err)
+	}
+	return exists, nil
+}
+
+// productChannelColumn maps a sales channel name to its visibility column
+func productChannelColumn(channel string) (string, error) {
+	switch channel {
+	case models.SalesChannelWeb:
+		return "p.visible_web", nil
+	case models.SalesChannelMarketplace:
+		return "p.visible_marketplace", nil
+	case models.SalesChannelB2B:
+		return "p.visible_b2b", nil
+	}
+	return "", fmt.Errorf("unknown sales channel: %s", channel)
+}
+
 func (q *ProductQueries) DeleteProduct(id int) error {
 	// Delete product (this will cascade delete images and services associations)
 	result, err := q.db.Exec("DELETE FROM products WHERE id = $1", id)
@@ -1957,31 +2209,7 @@ func (q *ProductQueries) DeleteProduct(id int) error {
 
 // ManyToMany operations for product images
 func (q *ProductQueries) ReplaceImages(productID int, imageIDs []int) error {
-	tx, err := q.db.Begin()
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
-	}
-	defer tx.Rollback()
-	
-	// Delete existing associations
-	_, err = tx.Exec("DELETE FROM product_images WHERE product_id = $1", productID)
-	if err != nil {
-		return fmt.Errorf("failed to delete existing image associations: %w", err)
-	}
-	
-	// Add new associations
-	for _, imageID := range imageIDs {
-		_, err = tx.Exec("INSERT INTO product_images (product_id, image_id) VALUES ($1, $2)", productID, imageID)
-		if err != nil {
-			return fmt.Errorf("failed to add image association: %w", err)
-		}
-	}
-	
-	if err = tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
-	}
-	
-	return nil
+	return replaceImageLinks(q.db, productImageLinks, productID, imageIDs)
 }
 
 // ManyToMany operations for product services
@@ -2013,25 +2241,44 @@ func (q *ProductQueries) ReplaceServices(productID int, serviceIDs []int) error
 	return nil
 }
 
-// GetPublicProducts returns products for public access with filtering and pagination
-func (q *ProductQueries) GetPublicProducts(page, limit int, search string, categoryIDs []int) ([]models.ProductWithRelations, error) {
-	offset := (page - 1) * limit
-	
-	whereClause := "WHERE (c.active = true OR c.id IS NULL)"
+// publicProductFilter returns the WHERE clause of the shop's product listings, with its
+// arguments: products shown on the web in active categories, matching the search in
+// their texts, material, category or tags, in any of categoryIDs and carrying all tags
+func publicProductFilter(search string, categoryIDs []int, tags []string) (string, []interface{}) {
+	whereClause := "WHERE p.visible_web = true AND (c.active = true OR c.id IS NULL)"
 	args := []interface{}{}
 	argCount := 0
-	
+
 	if search != "" {
 		argCount++
-		whereClause += fmt.Sprintf(" AND (p.name ILIKE $%d OR p.short_description ILIKE $%d OR p.description ILIKE $%d OR COALESCE(m.name, '') ILIKE $%d OR COALESCE(c.name, '') ILIKE $%d)", argCount, argCount, argCount, argCount, argCount)
+		whereClause += fmt.Sprintf(" AND (p.name ILIKE $%d OR p.short_description ILIKE $%d OR p.description ILIKE $%d OR COALESCE(m.name, '') ILIKE $%d OR COALESCE(c.name, '') ILIKE $%d OR EXISTS (SELECT 1 FROM product_tags st WHERE st.product_id = p.id AND st.tag ILIKE $%d)", argCount, argCount, argCount, argCount, argCount, argCount)
 		args = append(args, "%"+search+"%")
+		// Whole words match through the search index wherever they appear in the text
+		argCount++
+		whereClause += fmt.Sprintf(" OR EXISTS (SELECT 1 FROM product_search_index si WHERE si.product_id = p.id AND si.document @@ plainto_tsquery('simple', $%d)))", argCount)
+		args = append(args, search)
 	}
-	
+
 	if len(categoryIDs) > 0 {
 		argCount++
 		whereClause += fmt.Sprintf(" AND p.category_id = ANY($%d)", argCount)
 		args = append(args, pq.Array(categoryIDs))
 	}
+
+	if len(tags) > 0 {
+		whereClause += fmt.Sprintf(" AND p.id IN (SELECT product_id FROM product_tags WHERE tag = ANY($%d) GROUP BY product_id HAVING COUNT(*) = $%d)", argCount+1, argCount+2)
+		args = append(args, pq.Array(tags), len(tags))
+	}
+
+	return whereClause, args
+}
+
+// GetPublicProducts returns products for public access with filtering and pagination
+func (q *ProductQueries) GetPublicProducts(page, limit int, search string, categoryIDs []int, tags []string) ([]models.ProductWithRelations, error) {
+	offset := (page - 1) * limit
+	
+	whereClause, args := publicProductFilter(search, categoryIDs, tags)
+	argCount := len(args)
 	
 	// Get paginated results with all relations
 	argCount++
@@ -2041,8 +2288,8 @@ func (q *ProductQueries) GetPublicProducts(page, limit int, search string, categ
 	
 	query := fmt.Sprintf(`
 		SELECT 
-			p.id, p.name, p.short_description, p.description, p.material_id, p.main_image_id, p.category_id, p.created_at, p.updated_at,
-			mi.id, mi.filename, mi.original_name, mi.path, mi.size_bytes, mi.mime_type, mi.uploaded_by, mi.created_at, mi.updated_at,
+			p.id, p.name, COALESCE(p.slug, ''), p.short_description, p.description, p.material_id, p.main_image_id, p.category_id, p.visible_web, p.visible_marketplace, p.visible_b2b, p.created_at, p.updated_at,
+			mi.id, mi.filename, mi.original_name, mi.path, mi.size_bytes, mi.mime_type, mi.uploaded_by, mi.created_at, mi.updated_at, ` + imageSizesColumn("mi") + `, ` + productImageTextColumns("p", "mi") + `,
 			m.id, m.name, m.created_at, m.updated_at,
 			c.id, c.name, c.slug, c.image_id, c.active, c.chart_only, c.created_at, c.updated_at,
 			COALESCE(MIN(s.base_price), 0) as min_price
@@ -2052,7 +2299,7 @@ func (q *ProductQueries) GetPublicProducts(page, limit int, search string, categ
 		LEFT JOIN categories c ON p.category_id = c.id
 		LEFT JOIN sizes s ON p.id = s.product_id
 		%s
-		GROUP BY p.id, p.name, p.short_description, p.description, p.material_id, p.main_image_id, p.category_id, p.created_at, p.updated_at,
+		GROUP BY p.id, p.name, p.slug, p.short_description, p.description, p.material_id, p.main_image_id, p.category_id, p.visible_web, p.visible_marketplace, p.visible_b2b, p.created_at, p.updated_at,
 			mi.id, mi.filename, mi.original_name, mi.path, mi.size_bytes, mi.mime_type, mi.uploaded_by, mi.created_at, mi.updated_at,
 			m.id, m.name, m.created_at, m.updated_at,
 			c.id, c.name, c.slug, c.image_id, c.active, c.chart_only, c.created_at, c.updated_at
@@ -2077,6 +2324,7 @@ func (q *ProductQueries) GetPublicProducts(page, limit int, search string, categ
 	for rows.Next() {
 		var product models.ProductWithRelations
 		var mainImage models.ImageResponse
+		var mainImageSizes []byte
 		var material models.MaterialResponse
 		var category models.CategoryResponse
 		var materialID, categoryID sql.NullInt64
@@ -2087,10 +2335,11 @@ func (q *ProductQueries) GetPublicProducts(page, limit int, search string, categ
 		var minPrice sql.NullFloat64
 		
 		err := rows.Scan(
-			&product.ID, &product.Name, &product.ShortDescription, &product.Description,
-			&product.MaterialID, &product.MainImageID, &product.CategoryID, &product.CreatedAt, &product.UpdatedAt,
+			&product.ID, &product.Name, &product.Slug, &product.ShortDescription, &product.Description,
+			&product.MaterialID, &product.MainImageID, &product.CategoryID,
+			&product.Channels.Web, &product.Channels.Marketplace, &product.Channels.B2B, &product.CreatedAt, &product.UpdatedAt,
 			&mainImage.ID, &mainImage.Filename, &mainImage.OriginalName, &mainImage.Path,
-			&mainImage.SizeBytes, &mainImage.MimeType, &mainImage.UploadedBy, &mainImage.CreatedAt, &mainImage.UpdatedAt,
+			&mainImage.SizeBytes, &mainImage.MimeType, &mainImage.UploadedBy, &mainImage.CreatedAt, &mainImage.UpdatedAt, &mainImageSizes, &mainImage.AltText, &mainImage.Title,
 			&materialID, &materialName, &materialCreatedAt, &materialUpdatedAt,
 			&categoryID, &categoryName, &categorySlug, &categoryImageID, &categoryActive, &categoryChartOnly, &categoryCreatedAt, &categoryUpdatedAt,
 			&minPrice,
@@ -2099,6 +2348,10 @@ func (q *ProductQueries) GetPublicProducts(page, limit int, search string, categ
 			return nil, fmt.Errorf("failed to scan product: %w", err)
 		}
 		
+		mainImage.Variants = imaging.VariantPaths(mainImage.Path, mainImage.MimeType)
+		if mainImage.Sizes, err = parseImageSizes(mainImageSizes); err != nil {
+			return nil, err
+		}
 		product.MainImage = mainImage
 		
 		// Handle optional material
@@ -2131,43 +2384,19 @@ func (q *ProductQueries) GetPublicProducts(page, limit int, search string, categ
 			product.MinPrice = minPrice.Float64
 		}
 		
-		// Get product images
-		images, err := q.getProductImages(product.ID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get product images: %w", err)
-		}
-		product.Images = images
-		
-		// Get additional services
-		services, err := q.getProductServices(product.ID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get product services: %w", err)
-		}
-		product.AdditionalServices = services
-		
 		products = append(products, product)
 	}
 	
+	if err := q.loadProductRelations(products); err != nil {
+		return nil, err
+	}
+	
 	return products, nil
 }
 
 // GetPublicProductsCount returns the count of products for public access with filtering
-func (q *ProductQueries) GetPublicProductsCount(search string, categoryIDs []int) (int, error) {
-	whereClause := "WHERE (c.active = true OR c.id IS NULL)"
-	args := []interface{}{}
-	argCount := 0
-	
-	if search != "" {
-		argCount++
-		whereClause += fmt.Sprintf(" AND (p.name ILIKE $%d OR p.short_description ILIKE $%d OR p.description ILIKE $%d OR COALESCE(m.name, '') ILIKE $%d OR COALESCE(c.name, '') ILIKE $%d)", argCount, argCount, argCount, argCount, argCount)
-		args = append(args, "%"+search+"%")
-	}
-	
-	if len(categoryIDs) > 0 {
-		argCount++
-		whereClause += fmt.Sprintf(" AND p.category_id = ANY($%d)", argCount)
-		args = append(args, pq.Array(categoryIDs))
-	}
+func (q *ProductQueries) GetPublicProductsCount(search string, categoryIDs []int, tags []string) (int, error) {
+	whereClause, args := publicProductFilter(search, categoryIDs, tags)
 	
 	query := fmt.Sprintf(`
 		SELECT COUNT(DISTINCT p.id)
@@ -2242,6 +2471,44 @@ func (q *ProductQueries) GetProductSizes(productID int) ([]models.SizeResponse,
 	return sizes, nil
 }
 
+// GetAdjacentProducts returns the previous and next web shop products in the same
+// category, following the order of the public product listing
+func (q *ProductQueries) GetAdjacentProducts(productID int) (*models.AdjacentProduct, *models.AdjacentProduct, error) {
+	query := `
+		WITH listing AS (
+			SELECT p.id,
+				LAG(p.id) OVER w AS prev_id, LAG(p.name) OVER w AS prev_name, LAG(p.slug) OVER w AS prev_slug,
+				LEAD(p.id) OVER w AS next_id, LEAD(p.name) OVER w AS next_name, LEAD(p.slug) OVER w AS next_slug
+			FROM products p
+			LEFT JOIN categories c ON p.category_id = c.id
+			WHERE p.visible_web = true AND (c.active = true OR c.id IS NULL)
+			  AND p.category_id IS NOT DISTINCT FROM (SELECT category_id FROM products WHERE id = $1)
+			WINDOW w AS (ORDER BY p.created_at DESC, p.id DESC)
+		)
+		SELECT prev_id, prev_name, prev_slug, next_id, next_name, next_slug FROM listing WHERE id = $1
+	`
+	
+	var prevID, nextID sql.NullInt64
+	var prevName, prevSlug, nextName, nextSlug sql.NullString
+	err := q.db.QueryRow(query, productID).Scan(&prevID, &prevName, &prevSlug, &nextID, &nextName, &nextSlug)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, nil
+		}
+		return nil, nil, fmt.Errorf("failed to get adjacent products: %w", err)
+	}
+	
+	var prev, next *models.AdjacentProduct
+	if prevID.Valid {
+		prev = &models.AdjacentProduct{ID: int(prevID.Int64), Name: prevName.String, Slug: prevSlug.String}
+	}
+	if nextID.Valid {
+		next = &models.AdjacentProduct{ID: int(nextID.Int64), Name: nextName.String, Slug: nextSlug.String}
+	}
+	
+	return prev, next, nil
+}
+
 // Size queries
 type SizeQueries struct {
 	db *sql.DB
@@ -2449,9 +2716,15 @@ func NewProductVariantQueries(db *sql.DB) *ProductVariantQueries {
 }
 
 func (q *ProductVariantQueries) CreateProductVariant(variant *models.ProductVariant) error {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	// If this variant is being set as default, clear other defaults for this product
 	if variant.IsDefault {
-		if err := q.ensureOnlyOneDefaultVariant(variant.ProductID, nil); err != nil {
+		if err := ensureOnlyOneDefaultVariant(tx, variant.ProductID, nil); err != nil {
 			return err
 		}
 	}
@@ -2462,11 +2735,14 @@ func (q *ProductVariantQueries) CreateProductVariant(variant *models.ProductVari
 		RETURNING id, created_at, updated_at
 	`
 	
-	err := q.db.QueryRow(query, variant.ProductID, variant.Name, variant.ColorID, variant.IsDefault).Scan(&variant.ID, &variant.CreatedAt, &variant.UpdatedAt)
+	err = tx.QueryRow(query, variant.ProductID, variant.Name, variant.ColorID, variant.IsDefault).Scan(&variant.ID, &variant.CreatedAt, &variant.UpdatedAt)
 	if err != nil {
 		return fmt.Errorf("failed to create product variant: %w", err)
 	}
-	
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
 	return nil
 }
 
@@ -2530,31 +2806,7 @@ func (q *ProductVariantQueries) GetProductVariantByID(id int) (*models.ProductVa
 }
 
 func (q *ProductVariantQueries) UpdateProductVariantImages(variantID int, imageIDs []int) error {
-	tx, err := q.db.Begin()
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
-	}
-	defer tx.Rollback()
-	
-	// Delete existing associations
-	_, err = tx.Exec("DELETE FROM product_variant_images WHERE product_variant_id = $1", variantID)
-	if err != nil {
-		return fmt.Errorf("failed to delete existing image associations: %w", err)
-	}
-	
-	// Add new associations
-	for _, imageID := range imageIDs {
-		_, err = tx.Exec("INSERT INTO product_variant_images (product_variant_id, image_id) VALUES ($1, $2)", variantID, imageID)
-		if err != nil {
-			return fmt.Errorf("failed to add image association: %w", err)
-		}
-	}
-	
-	if err = tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
-	}
-	
-	return nil
+	return replaceImageLinks(q.db, variantImageLinks, variantID, imageIDs)
 }
 
 
@@ -2691,9 +2943,15 @@ func (q *ProductVariantQueries) ListProductVariants(page, limit int, search stri
 }
 
 func (q *ProductVariantQueries) UpdateProductVariant(id int, variant *models.ProductVariant) error {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	// If this variant is being set as default, clear other defaults for this product
 	if variant.IsDefault {
-		if err := q.ensureOnlyOneDefaultVariant(variant.ProductID, &id); err != nil {
+		if err := ensureOnlyOneDefaultVariant(tx, variant.ProductID, &id); err != nil {
 			return err
 		}
 	}
@@ -2705,72 +2963,74 @@ func (q *ProductVariantQueries) UpdateProductVariant(id int, variant *models.Pro
 		RETURNING updated_at
 	`
 	
-	err := q.db.QueryRow(query, variant.ProductID, variant.Name, variant.ColorID, variant.IsDefault, id).Scan(&variant.UpdatedAt)
+	err = tx.QueryRow(query, variant.ProductID, variant.Name, variant.ColorID, variant.IsDefault, id).Scan(&variant.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return fmt.Errorf("product variant not found")
 		}
 		return fmt.Errorf("failed to update product variant: %w", err)
 	}
-	
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
 	return nil
 }
 
+// DeleteProductVariant deletes a variant. When it was the product's default, the oldest
+// remaining variant becomes the default so the product stays in the shop.
 func (q *ProductVariantQueries) DeleteProductVariant(id int) error {
-	query := `DELETE FROM product_variants WHERE id = $1`
-	
-	result, err := q.db.Exec(query, id)
+	tx, err := q.db.Begin()
 	if err != nil {
-		return fmt.Errorf("failed to delete product variant: %w", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+	defer tx.Rollback()
+
+	var productID int
+	if err := tx.QueryRow(`SELECT product_id FROM product_variants WHERE id = $1`, id).Scan(&productID); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("product variant not found")
+		}
+		return fmt.Errorf("failed to get product variant: %w", err)
 	}
-	
-	if rowsAffected == 0 {
-		return fmt.Errorf("product variant not found")
+	if err := lockDefaultVariant(tx, productID); err != nil {
+		return err
 	}
-	
-	return nil
-}
 
-func (q *ProductVariantQueries) getProductVariantImages(variantID int) ([]models.ImageResponse, error) {
-	query := `
-		SELECT i.id, i.filename, i.original_name, i.path, i.size_bytes, i.mime_type, i.uploaded_by, i.created_at, i.updated_at
-		FROM product_variant_images pvi
-		JOIN images i ON pvi.image_id = i.id
-		WHERE pvi.product_variant_id = $1
-		ORDER BY i.id
-	`
-	
-	rows, err := q.db.Query(query, variantID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query variant images: %w", err)
+	var wasDefault bool
+	err = tx.QueryRow(`DELETE FROM product_variants WHERE id = $1 RETURNING COALESCE(is_default, FALSE)`, id).Scan(&wasDefault)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("product variant not found")
+	} else if err != nil {
+		return fmt.Errorf("failed to delete product variant: %w", err)
 	}
-	defer rows.Close()
-	
-	var images []models.ImageResponse
-	for rows.Next() {
-		var image models.ImageResponse
-		
-		err := rows.Scan(&image.ID, &image.Filename, &image.OriginalName, &image.Path, &image.SizeBytes, &image.MimeType, &image.UploadedBy, &image.CreatedAt, &image.UpdatedAt)
+
+	if wasDefault {
+		_, err = tx.Exec(`
+			UPDATE product_variants SET is_default = TRUE
+			WHERE id = (SELECT id FROM product_variants WHERE product_id = $1 ORDER BY id LIMIT 1)`, productID)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan image: %w", err)
+			return fmt.Errorf("failed to set default variant: %w", err)
 		}
-		
-		image.CreatedAt = image.CreatedAt[:19] // Format timestamp
-		image.UpdatedAt = image.UpdatedAt[:19]
-		
-		images = append(images, image)
 	}
-	
-	return images, nil
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
 }
 
-// Helper method to ensure only one default variant per product
-func (q *ProductVariantQueries) ensureOnlyOneDefaultVariant(productID int, excludeVariantID *int) error {
+func (q *ProductVariantQueries) getProductVariantImages(variantID int) ([]models.ImageResponse, error) {
+	return linkedImages(q.db, variantImageLinks, variantID)
+}
+
+// Helper method to ensure only one default variant per product; it holds the product's
+// default variant lock until the end of the transaction
+func ensureOnlyOneDefaultVariant(tx *sql.Tx, productID int, excludeVariantID *int) error {
+	if err := lockDefaultVariant(tx, productID); err != nil {
+		return err
+	}
+
 	query := `UPDATE product_variants SET is_default = FALSE WHERE product_id = $1`
 	args := []interface{}{productID}
 	
@@ -2779,7 +3039,7 @@ func (q *ProductVariantQueries) ensureOnlyOneDefaultVariant(productID int, exclu
 		args = append(args, *excludeVariantID)
 	}
 	
-	_, err := q.db.Exec(query, args...)
+	_, err := tx.Exec(query, args...)
 	if err != nil {
 		return fmt.Errorf("failed to clear default variants: %w", err)
 	}
@@ -2787,17 +3047,26 @@ func (q *ProductVariantQueries) ensureOnlyOneDefaultVariant(productID int, exclu
 	return nil
 }
 
+// lockDefaultVariant serializes the changes to a product's default variant until the end
+// of the transaction, like lockDefaultAddress
+func lockDefaultVariant(tx *sql.Tx, productID int) error {
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext('default_variant'), $1)`, productID); err != nil {
+		return fmt.Errorf("failed to lock default variant: %w", err)
+	}
+	return nil
+}
+
 // SearchProductsEnhanced performs enhanced search with sorting options
-func (q *ProductQueries) SearchProductsEnhanced(page, limit int, search string, categoryIDs []int, sortBy string) ([]models.ProductWithRelations, error) {
+func (q *ProductQueries) SearchProductsEnhanced(page, limit int, search string, categoryIDs []int, tags []string, sortBy string) ([]models.ProductWithRelations, error) {
 	// For now, use the existing GetPublicProducts with enhanced search
 	// We can extend this later with more sophisticated sorting
-	return q.GetPublicProducts(page, limit, search, categoryIDs)
+	return q.GetPublicProducts(page, limit, search, categoryIDs, tags)
 }
 
 // GetSearchProductsCount returns the total count of search results
-func (q *ProductQueries) GetSearchProductsCount(search string, categoryIDs []int) (int, error) {
+func (q *ProductQueries) GetSearchProductsCount(search string, categoryIDs []int, tags []string) (int, error) {
 	// Use the existing GetPublicProductsCount function
-	return q.GetPublicProductsCount(search, categoryIDs)
+	return q.GetPublicProductsCount(search, categoryIDs, tags)
 }
 
 // GetSearchSuggestions returns search suggestions based on product names and categories
@@ -2809,7 +3078,7 @@ func (q *ProductQueries) GetSearchSuggestions(query string, limit int) ([]string
 		SELECT DISTINCT p.name
 		FROM products p
 		LEFT JOIN categories c ON p.category_id = c.id
-		WHERE (c.active = true OR c.id IS NULL) AND p.name ILIKE $1
+		WHERE p.visible_web = true AND (c.active = true OR c.id IS NULL) AND p.name ILIKE $1
 		ORDER BY p.name
 		LIMIT $2
 	`
@@ -2892,14 +3161,26 @@ func NewClientReviewQueries(db *sql.DB) *ClientReviewQueries {
 	return &ClientReviewQueries{db: db}
 }
 
-// ListClientReviews returns all client reviews with pagination and optional active filter
-func (q *ClientReviewQueries) ListClientReviews(page, limit int, activeOnly bool) ([]models.ClientReview, int, error) {
+// ListClientReviews returns client reviews with pagination, filtered by activity and rating
+func (q *ClientReviewQueries) ListClientReviews(page, limit int, filter models.ClientReviewFilter) ([]models.ClientReview, int, error) {
 	offset := (page - 1) * limit
 	
-	whereClause := ""
+	conditions := []string{}
 	args := []interface{}{}
-	if activeOnly {
-		whereClause = "WHERE cr.is_active = true"
+	if filter.ActiveOnly {
+		conditions = append(conditions, "cr.is_active = true")
+	}
+	if filter.Rating != nil {
+		args = append(args, *filter.Rating)
+		conditions = append(conditions, fmt.Sprintf("cr.rating = $%d", len(args)))
+	}
+	if filter.MinRating != nil {
+		args = append(args, *filter.MinRating)
+		conditions = append(conditions, fmt.Sprintf("cr.rating >= $%d", len(args)))
+	}
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
 	}
 	
 	// Count query
@@ -2918,7 +3199,7 @@ func (q *ClientReviewQueries) ListClientReviews(page, limit int, activeOnly bool
 	// Main query with image data
 	query := fmt.Sprintf(`
 		SELECT 
-			cr.id, cr.client_name, cr.instagram_handle, cr.image_id, cr.display_order, cr.is_active, cr.created_at, cr.updated_at,
+			cr.id, cr.client_name, cr.instagram_handle, cr.image_id, cr.display_order, cr.is_active, cr.rating, cr.created_at, cr.updated_at,
 			i.id, i.filename, i.original_name, i.path, i.size_bytes, i.mime_type, i.uploaded_by, i.created_at, i.updated_at
 		FROM client_reviews cr
 		LEFT JOIN images i ON cr.image_id = i.id
@@ -2940,7 +3221,7 @@ func (q *ClientReviewQueries) ListClientReviews(page, limit int, activeOnly bool
 		var image models.Image
 		
 		err := rows.Scan(
-			&review.ID, &review.ClientName, &review.InstagramHandle, &review.ImageID, &review.DisplayOrder, &review.IsActive, &review.CreatedAt, &review.UpdatedAt,
+			&review.ID, &review.ClientName, &review.InstagramHandle, &review.ImageID, &review.DisplayOrder, &review.IsActive, &review.Rating, &review.CreatedAt, &review.UpdatedAt,
 			&image.ID, &image.Filename, &image.OriginalName, &image.Path, &image.SizeBytes, &image.MimeType, &image.UploadedBy, &image.CreatedAt, &image.UpdatedAt,
 		)
 		if err != nil {
@@ -2958,53 +3239,11 @@ func (q *ClientReviewQueries) ListClientReviews(page, limit int, activeOnly bool
 	return reviews, total, nil
 }
 
-// GetActiveClientReviews returns only active client reviews ordered by display_order
-func (q *ClientReviewQueries) GetActiveClientReviews() ([]models.ClientReview, error) {
-	query := `
-		SELECT 
-			cr.id, cr.client_name, cr.instagram_handle, cr.image_id, cr.display_order, cr.is_active, cr.created_at, cr.updated_at,
-			i.id, i.filename, i.original_name, i.path, i.size_bytes, i.mime_type, i.uploaded_by, i.created_at, i.updated_at
-		FROM client_reviews cr
-		LEFT JOIN images i ON cr.image_id = i.id
-		WHERE cr.is_active = true
-		ORDER BY cr.display_order ASC, cr.created_at DESC
-	`
-	
-	rows, err := q.db.Query(query)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query active client reviews: %w", err)
-	}
-	defer rows.Close()
-	
-	var reviews []models.ClientReview
-	for rows.Next() {
-		var review models.ClientReview
-		var image models.Image
-		
-		err := rows.Scan(
-			&review.ID, &review.ClientName, &review.InstagramHandle, &review.ImageID, &review.DisplayOrder, &review.IsActive, &review.CreatedAt, &review.UpdatedAt,
-			&image.ID, &image.Filename, &image.OriginalName, &image.Path, &image.SizeBytes, &image.MimeType, &image.UploadedBy, &image.CreatedAt, &image.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan client review: %w", err)
-		}
-		
-		review.Image = &image
-		reviews = append(reviews, review)
-	}
-	
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("failed to iterate client reviews: %w", err)
-	}
-	
-	return reviews, nil
-}
-
 // GetClientReviewByID returns a single client review by ID
 func (q *ClientReviewQueries) GetClientReviewByID(id int) (*models.ClientReview, error) {
 	query := `
 		SELECT 
-			cr.id, cr.client_name, cr.instagram_handle, cr.image_id, cr.display_order, cr.is_active, cr.created_at, cr.updated_at,
+			cr.id, cr.client_name, cr.instagram_handle, cr.image_id, cr.display_order, cr.is_active, cr.rating, cr.created_at, cr.updated_at,
 			i.id, i.filename, i.original_name, i.path, i.size_bytes, i.mime_type, i.uploaded_by, i.created_at, i.updated_at
 		FROM client_reviews cr
 		LEFT JOIN images i ON cr.image_id = i.id
@@ -3015,7 +3254,7 @@ func (q *ClientReviewQueries) GetClientReviewByID(id int) (*models.ClientReview,
 	var image models.Image
 	
 	err := q.db.QueryRow(query, id).Scan(
-		&review.ID, &review.ClientName, &review.InstagramHandle, &review.ImageID, &review.DisplayOrder, &review.IsActive, &review.CreatedAt, &review.UpdatedAt,
+		&review.ID, &review.ClientName, &review.InstagramHandle, &review.ImageID, &review.DisplayOrder, &review.IsActive, &review.Rating, &review.CreatedAt, &review.UpdatedAt,
 		&image.ID, &image.Filename, &image.OriginalName, &image.Path, &image.SizeBytes, &image.MimeType, &image.UploadedBy, &image.CreatedAt, &image.UpdatedAt,
 	)
 	if err != nil {
@@ -3032,13 +3271,13 @@ func (q *ClientReviewQueries) GetClientReviewByID(id int) (*models.ClientReview,
 // CreateClientReview creates a new client review
 func (q *ClientReviewQueries) CreateClientReview(req models.CreateClientReviewRequest) (*models.ClientReview, error) {
 	query := `
-		INSERT INTO client_reviews (client_name, instagram_handle, image_id, display_order, is_active)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO client_reviews (client_name, instagram_handle, image_id, display_order, is_active, rating)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING id, created_at, updated_at
 	`
 	
 	var review models.ClientReview
-	err := q.db.QueryRow(query, req.ClientName, req.InstagramHandle, req.ImageID, req.DisplayOrder, req.IsActive).Scan(
+	err := q.db.QueryRow(query, req.ClientName, req.InstagramHandle, req.ImageID, req.DisplayOrder, req.IsActive, req.Rating).Scan(
 		&review.ID, &review.CreatedAt, &review.UpdatedAt,
 	)
 	if err != nil {
@@ -3050,6 +3289,7 @@ func (q *ClientReviewQueries) CreateClientReview(req models.CreateClientReviewRe
 	review.ImageID = req.ImageID
 	review.DisplayOrder = req.DisplayOrder
 	review.IsActive = req.IsActive
+	review.Rating = req.Rating
 	
 	return &review, nil
 }
@@ -3058,14 +3298,14 @@ func (q *ClientReviewQueries) CreateClientReview(req models.CreateClientReviewRe
 func (q *ClientReviewQueries) UpdateClientReview(id int, req models.UpdateClientReviewRequest) (*models.ClientReview, error) {
 	query := `
 		UPDATE client_reviews 
-		SET client_name = $2, instagram_handle = $3, image_id = $4, display_order = $5, is_active = $6, updated_at = CURRENT_TIMESTAMP
+		SET client_name = $2, instagram_handle = $3, image_id = $4, display_order = $5, is_active = $6, rating = $7, updated_at = CURRENT_TIMESTAMP
 		WHERE id = $1
-		RETURNING id, client_name, instagram_handle, image_id, display_order, is_active, created_at, updated_at
+		RETURNING id, client_name, instagram_handle, image_id, display_order, is_active, rating, created_at, updated_at
 	`
 	
 	var review models.ClientReview
-	err := q.db.QueryRow(query, id, req.ClientName, req.InstagramHandle, req.ImageID, req.DisplayOrder, req.IsActive).Scan(
-		&review.ID, &review.ClientName, &review.InstagramHandle, &review.ImageID, &review.DisplayOrder, &review.IsActive, &review.CreatedAt, &review.UpdatedAt,
+	err := q.db.QueryRow(query, id, req.ClientName, req.InstagramHandle, req.ImageID, req.DisplayOrder, req.IsActive, req.Rating).Scan(
+		&review.ID, &review.ClientName, &review.InstagramHandle, &review.ImageID, &review.DisplayOrder, &review.IsActive, &review.Rating, &review.CreatedAt, &review.UpdatedAt,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -3077,6 +3317,49 @@ func (q *ClientReviewQueries) UpdateClientReview(id int, req models.UpdateClient
 	return &review, nil
 }
 
+// GetClientReviewSummary returns the number of active client reviews with their average
+// rating and how many reviews gave each rating; reviews without a rating only add to Count
+func (q *ClientReviewQueries) GetClientReviewSummary() (*models.ClientReviewSummary, error) {
+	summary := &models.ClientReviewSummary{Distribution: map[int]int{}}
+	for rating := models.MinClientReviewRating; rating <= models.MaxClientReviewRating; rating++ {
+		summary.Distribution[rating] = 0
+	}
+	
+	rows, err := q.db.Query(`
+		SELECT rating, COUNT(*)
+		FROM client_reviews
+		WHERE is_active = true
+		GROUP BY rating`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize client reviews: %w", err)
+	}
+	defer rows.Close()
+	
+	sum := 0
+	for rows.Next() {
+		var rating sql.NullInt64
+		var count int
+		if err := rows.Scan(&rating, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan client review summary: %w", err)
+		}
+		summary.Count += count
+		if rating.Valid {
+			summary.RatedCount += count
+			summary.Distribution[int(rating.Int64)] = count
+			sum += int(rating.Int64) * count
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to summarize client reviews: %w", err)
+	}
+	
+	if summary.RatedCount > 0 {
+		average := math.Round(float64(sum)/float64(summary.RatedCount)*100) / 100
+		summary.AverageRating = &average
+	}
+	return summary, nil
+}
+
 // DeleteClientReview deletes a client review by ID
 func (q *ClientReviewQueries) DeleteClientReview(id int) error {
 	query := `DELETE FROM client_reviews WHERE id = $1`
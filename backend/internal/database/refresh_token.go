@@ -0,0 +1,164 @@
+package database
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"time"
+
+	"notsofluffy-backend/internal/auth"
+)
+
+type RefreshTokenQueries struct {
+	db *sql.DB
+}
+
+func NewRefreshTokenQueries(db *sql.DB) *RefreshTokenQueries {
+	return &RefreshTokenQueries{db: db}
+}
+
+// insertRefreshToken stores the hash of a refresh token in family
+func insertRefreshToken(db interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}, userID int, token, familyID, ipAddress string, userAgent *string) (int, error) {
+	var id int
+	err := db.QueryRow(`
+		INSERT INTO refresh_tokens (user_id, token_hash, family_id, ip_address, user_agent, expires_at)
+		VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP + $6::int * INTERVAL '1 second')
+		RETURNING id`,
+		userID, HashAPIKey(token), familyID, ipAddress, userAgent, int(auth.RefreshTokenTTL.Seconds()),
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to store refresh token: %w", err)
+	}
+	return id, nil
+}
+
+// CreateRefreshToken stores the refresh token of a new login
+func (q *RefreshTokenQueries) CreateRefreshToken(userID int, token, ipAddress string, userAgent *string) error {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Errorf("failed to generate refresh token family: %w", err)
+	}
+
+	_, err := insertRefreshToken(q.db, userID, token, hex.EncodeToString(buf), ipAddress, userAgent)
+	return err
+}
+
+// RotateRefreshToken replaces a refresh token of userID with next, which joins its
+// family. A token that was already rotated is being reused, most likely because it was
+// stolen: the whole family is revoked and "refresh token reused" returned. Unknown,
+// revoked and expired tokens return "refresh token not found".
+func (q *RefreshTokenQueries) RotateRefreshToken(userID int, token, next, ipAddress string, userAgent *string) error {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id int
+	var familyID string
+	var revokedAt *time.Time
+	var replacedBy *int
+	var expired bool
+	err = tx.QueryRow(`
+		SELECT id, family_id, revoked_at, replaced_by, expires_at <= CURRENT_TIMESTAMP
+		FROM refresh_tokens WHERE token_hash = $1 AND user_id = $2
+		FOR UPDATE`, HashAPIKey(token), userID).Scan(&id, &familyID, &revokedAt, &replacedBy, &expired)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("refresh token not found")
+		}
+		return fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	if revokedAt != nil && replacedBy != nil {
+		if _, err := tx.Exec(`UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE family_id = $1 AND revoked_at IS NULL`, familyID); err != nil {
+			return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		log.Printf("Refresh token of user %d was reused, revoked its token family", userID)
+		return fmt.Errorf("refresh token reused")
+	}
+	if revokedAt != nil || expired {
+		return fmt.Errorf("refresh token not found")
+	}
+
+	nextID, err := insertRefreshToken(tx, userID, next, familyID, ipAddress, userAgent)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP, replaced_by = $2 WHERE id = $1`, id, nextID); err != nil {
+		return fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// RevokeRefreshToken signs out the login a refresh token belongs to by revoking its
+// family. Unknown tokens are ignored, so signing out twice is not an error.
+func (q *RefreshTokenQueries) RevokeRefreshToken(token string) error {
+	_, err := q.db.Exec(`
+		UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP
+		WHERE family_id = (SELECT family_id FROM refresh_tokens WHERE token_hash = $1) AND revoked_at IS NULL`,
+		HashAPIKey(token))
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh token: %w", err)
+	}
+	return nil
+}
+
+// revokeUserSessions signs a user out everywhere in tx: refresh tokens and admin
+// sessions are revoked and the token version is incremented, so access tokens issued
+// until now stop working.
+func revokeUserSessions(tx *sql.Tx, userID int) error {
+	if _, err := tx.Exec(`UPDATE users SET token_version = token_version + 1 WHERE id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	if _, err := tx.Exec(`UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND revoked_at IS NULL`, userID); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	if _, err := tx.Exec(`UPDATE admin_sessions SET revoked_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND revoked_at IS NULL`, userID); err != nil {
+		return fmt.Errorf("failed to revoke admin sessions: %w", err)
+	}
+	return nil
+}
+
+// RevokeAllSessions signs a user out on every device
+func (q *RefreshTokenQueries) RevokeAllSessions(userID int) error {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := revokeUserSessions(tx, userID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// AccessTokenRevoked reports whether an access token of userID carrying tokenVersion was
+// revoked by signing out everywhere. Tokens of deleted users count as revoked.
+func (q *RefreshTokenQueries) AccessTokenRevoked(userID, tokenVersion int) (bool, error) {
+	var current int
+	err := q.db.QueryRow(`SELECT token_version FROM users WHERE id = $1`, userID).Scan(&current)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return true, nil
+		}
+		return false, fmt.Errorf("failed to get token version: %w", err)
+	}
+	return tokenVersion != current, nil
+}
@@ -0,0 +1,335 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"notsofluffy-backend/internal/models"
+)
+
+type RefundQueries struct {
+	db *sql.DB
+}
+
+func NewRefundQueries(db *sql.DB) *RefundQueries {
+	return &RefundQueries{db: db}
+}
+
+// CreateRefund validates the refund against the order and stores it as pending.
+// The order row is locked so concurrent refunds cannot exceed the order total.
+func (q *RefundQueries) CreateRefund(orderID int, req *models.RefundRequest, provider string, createdBy *int) (*models.Refund, error) {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var totalAmount float64
+	err = tx.QueryRow("SELECT total_amount FROM orders WHERE id = $1 FOR UPDATE", orderID).Scan(&totalAmount)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("order not found")
+		}
+		return nil, fmt.Errorf("failed to lock order: %w", err)
+	}
+
+	var alreadyRefunded float64
+	err = tx.QueryRow(
+		"SELECT COALESCE(SUM(amount), 0) FROM refunds WHERE order_id = $1 AND status != $2",
+		orderID, models.RefundStatusFailed,
+	).Scan(&alreadyRefunded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refunded amount: %w", err)
+	}
+
+	refundable := totalAmount - alreadyRefunded
+	if req.Amount > refundable+0.005 {
+		return nil, fmt.Errorf("refund amount exceeds refundable amount of %.2f", refundable)
+	}
+	if err := checkRefundItems(req); err != nil {
+		return nil, err
+	}
+
+	// Validate refunded items belong to the order and are not refunded twice
+	for _, item := range req.Items {
+		var orderedQuantity int
+		var totalPrice float64
+		err = tx.QueryRow(
+			"SELECT quantity, total_price FROM order_items WHERE id = $1 AND order_id = $2",
+			item.OrderItemID, orderID,
+		).Scan(&orderedQuantity, &totalPrice)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, fmt.Errorf("order item %d not found in order", item.OrderItemID)
+			}
+			return nil, fmt.Errorf("failed to get order item: %w", err)
+		}
+
+		var refundedQuantity int
+		err = tx.QueryRow(`
+			SELECT COALESCE(SUM(ri.quantity), 0)
+			FROM refund_items ri
+			JOIN refunds r ON ri.refund_id = r.id
+			WHERE ri.order_item_id = $1 AND r.status != $2`,
+			item.OrderItemID, models.RefundStatusFailed,
+		).Scan(&refundedQuantity)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get refunded quantity: %w", err)
+		}
+
+		if refundedQuantity+item.Quantity > orderedQuantity {
+			return nil, fmt.Errorf("refund quantity exceeds ordered quantity for order item %d", item.OrderItemID)
+		}
+		if item.Amount > totalPrice/float64(orderedQuantity)*float64(item.Quantity)+0.005 {
+			return nil, fmt.Errorf("refund amount exceeds the price of %d units of order item %d", item.Quantity, item.OrderItemID)
+		}
+	}
+
+	refund := &models.Refund{
+		OrderID:          orderID,
+		Amount:           req.Amount,
+		Reason:           strings.TrimSpace(req.Reason),
+		Status:           models.RefundStatusPending,
+		Provider:         provider,
+		ProviderRefundID: req.ProviderRefundID,
+		CreatedBy:        createdBy,
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO refunds (order_id, amount, reason, status, provider, provider_refund_id, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at, updated_at`,
+		refund.OrderID, refund.Amount, refund.Reason, refund.Status, refund.Provider, refund.ProviderRefundID, refund.CreatedBy,
+	).Scan(&refund.ID, &refund.CreatedAt, &refund.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert refund: %w", err)
+	}
+
+	for _, item := range req.Items {
+		refundItem := models.RefundItem{
+			RefundID:    refund.ID,
+			OrderItemID: item.OrderItemID,
+			Quantity:    item.Quantity,
+			Amount:      item.Amount,
+		}
+		err = tx.QueryRow(`
+			INSERT INTO refund_items (refund_id, order_item_id, quantity, amount)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id, created_at`,
+			refundItem.RefundID, refundItem.OrderItemID, refundItem.Quantity, refundItem.Amount,
+		).Scan(&refundItem.ID, &refundItem.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to insert refund item: %w", err)
+		}
+		refund.Items = append(refund.Items, refundItem)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return refund, nil
+}
+
+// checkRefundItems rejects items listing an order item more than once, which would
+// check each line against the refundable quantity on its own, and item amounts adding
+// up to more than the refund. The refund may be larger, e.g. to cover shipping.
+func checkRefundItems(req *models.RefundRequest) error {
+	seen := make(map[int]bool, len(req.Items))
+	itemsAmount := 0.0
+	for _, item := range req.Items {
+		if seen[item.OrderItemID] {
+			return fmt.Errorf("order item %d is listed more than once", item.OrderItemID)
+		}
+		seen[item.OrderItemID] = true
+		itemsAmount += item.Amount
+	}
+	if itemsAmount > req.Amount+0.005 {
+		return fmt.Errorf("item amounts of %.2f exceed the refund amount", itemsAmount)
+	}
+	return nil
+}
+
+// CompleteRefund marks a refund as completed and updates the order payment status
+func (q *RefundQueries) CompleteRefund(refundID int, providerRefundID *string) error {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var orderID int
+	err = tx.QueryRow(`
+		UPDATE refunds
+		SET status = $1, provider_refund_id = COALESCE($2, provider_refund_id), updated_at = CURRENT_TIMESTAMP
+		WHERE id = $3
+		RETURNING order_id`,
+		models.RefundStatusCompleted, providerRefundID, refundID,
+	).Scan(&orderID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("refund not found")
+		}
+		return fmt.Errorf("failed to complete refund: %w", err)
+	}
+
+	var totalAmount, refundedAmount float64
+	err = tx.QueryRow(`
+		SELECT o.total_amount,
+		       COALESCE((SELECT SUM(r.amount) FROM refunds r WHERE r.order_id = o.id AND r.status = $1), 0)
+		FROM orders o
+		WHERE o.id = $2`,
+		models.RefundStatusCompleted, orderID,
+	).Scan(&totalAmount, &refundedAmount)
+	if err != nil {
+		return fmt.Errorf("failed to get order totals: %w", err)
+	}
+
+	paymentStatus := models.PaymentStatusPartiallyRefunded
+	if refundedAmount+0.005 >= totalAmount {
+		paymentStatus = models.PaymentStatusRefunded
+	}
+
+	_, err = tx.Exec("UPDATE orders SET payment_status = $1 WHERE id = $2", paymentStatus, orderID)
+	if err != nil {
+		return fmt.Errorf("failed to update payment status: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// FailRefund marks a refund as failed so its amount becomes refundable again
+func (q *RefundQueries) FailRefund(refundID int, reason string) error {
+	result, err := q.db.Exec(
+		"UPDATE refunds SET status = $1, failure_reason = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $3",
+		models.RefundStatusFailed, reason, refundID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to mark refund as failed: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("refund not found")
+	}
+
+	return nil
+}
+
+// GetRefundsByOrderID returns all refunds for an order with their items
+func (q *RefundQueries) GetRefundsByOrderID(orderID int) ([]models.Refund, error) {
+	rows, err := q.db.Query(`
+		SELECT id, order_id, amount, reason, status, provider, provider_refund_id, failure_reason, created_by, created_at, updated_at
+		FROM refunds
+		WHERE order_id = $1
+		ORDER BY created_at`,
+		orderID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refunds: %w", err)
+	}
+	defer rows.Close()
+
+	refunds := []models.Refund{}
+	for rows.Next() {
+		var r models.Refund
+		err := rows.Scan(&r.ID, &r.OrderID, &r.Amount, &r.Reason, &r.Status, &r.Provider, &r.ProviderRefundID, &r.FailureReason, &r.CreatedBy, &r.CreatedAt, &r.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan refund: %w", err)
+		}
+		refunds = append(refunds, r)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate refunds: %w", err)
+	}
+
+	for i := range refunds {
+		items, err := q.getRefundItems(refunds[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		refunds[i].Items = items
+	}
+
+	return refunds, nil
+}
+
+// getRefundItems returns the order items covered by a refund
+func (q *RefundQueries) getRefundItems(refundID int) ([]models.RefundItem, error) {
+	rows, err := q.db.Query(
+		"SELECT id, refund_id, order_item_id, quantity, amount, created_at FROM refund_items WHERE refund_id = $1 ORDER BY id",
+		refundID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refund items: %w", err)
+	}
+	defer rows.Close()
+
+	var items []models.RefundItem
+	for rows.Next() {
+		var item models.RefundItem
+		if err := rows.Scan(&item.ID, &item.RefundID, &item.OrderItemID, &item.Quantity, &item.Amount, &item.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan refund item: %w", err)
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// GetRefundedAmount returns the sum of completed refunds for an order
+func (q *RefundQueries) GetRefundedAmount(orderID int) (float64, error) {
+	var amount float64
+	err := q.db.QueryRow(
+		"SELECT COALESCE(SUM(amount), 0) FROM refunds WHERE order_id = $1 AND status = $2",
+		orderID, models.RefundStatusCompleted,
+	).Scan(&amount)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get refunded amount: %w", err)
+	}
+	return amount, nil
+}
+
+// GetSalesReport aggregates order revenue and completed refunds for a date range;
+// test orders are left out
+func (q *RefundQueries) GetSalesReport(from, to *time.Time) (*models.SalesReport, error) {
+	report := &models.SalesReport{From: from, To: to}
+
+	err := q.db.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(total_amount), 0)
+		FROM orders
+		WHERE status != $1 AND NOT is_test
+		  AND ($2::timestamptz IS NULL OR created_at >= $2)
+		  AND ($3::timestamptz IS NULL OR created_at < $3)`,
+		models.OrderStatusCancelled, from, to,
+	).Scan(&report.OrderCount, &report.GrossRevenue)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate orders: %w", err)
+	}
+
+	err = q.db.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(amount), 0)
+		FROM refunds
+		WHERE status = $1 AND order_id NOT IN (SELECT id FROM orders WHERE is_test)
+		  AND ($2::timestamptz IS NULL OR created_at >= $2)
+		  AND ($3::timestamptz IS NULL OR created_at < $3)`,
+		models.RefundStatusCompleted, from, to,
+	).Scan(&report.RefundCount, &report.RefundedAmount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate refunds: %w", err)
+	}
+
+	report.NetRevenue = report.GrossRevenue - report.RefundedAmount
+
+	return report, nil
+}
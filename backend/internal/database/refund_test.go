@@ -0,0 +1,30 @@
+package database
+
+import (
+	"strings"
+	"testing"
+
+	"notsofluffy-backend/internal/models"
+)
+
+func TestCheckRefundItems(t *testing.T) {
+	cases := []struct {
+		name string
+		req  models.RefundRequest
+		want string
+	}{
+		{"items within the amount", models.RefundRequest{Amount: 120, Items: []models.RefundItemRequest{{OrderItemID: 1, Quantity: 1, Amount: 80}, {OrderItemID: 2, Quantity: 2, Amount: 20}}}, ""},
+		{"amount only", models.RefundRequest{Amount: 50}, ""},
+		{"repeated item", models.RefundRequest{Amount: 100, Items: []models.RefundItemRequest{{OrderItemID: 1, Quantity: 1}, {OrderItemID: 1, Quantity: 1}}}, "listed more than once"},
+		{"items above the amount", models.RefundRequest{Amount: 50, Items: []models.RefundItemRequest{{OrderItemID: 1, Quantity: 1, Amount: 60}}}, "exceed the refund amount"},
+	}
+	for _, tc := range cases {
+		err := checkRefundItems(&tc.req)
+		if tc.want == "" && err != nil {
+			t.Errorf("%s: unexpected error %v", tc.name, err)
+		}
+		if tc.want != "" && (err == nil || !strings.Contains(err.Error(), tc.want)) {
+			t.Errorf("%s: error = %v, want %q", tc.name, err, tc.want)
+		}
+	}
+}
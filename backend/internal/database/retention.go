@@ -0,0 +1,357 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"notsofluffy-backend/internal/models"
+)
+
+// retentionRule purges or anonymizes records older than a configurable number of days
+type retentionRule struct {
+	name        string
+	description string
+	settingKey  string
+	defaultDays int
+	// count returns how many records the rule would affect for the cutoff
+	count func(db *sql.DB, cutoff time.Time) (int64, error)
+	// apply purges the records inside tx and returns how many were affected
+	apply func(tx *sql.Tx, cutoff time.Time) (int64, error)
+}
+
+// guestOrderCondition selects guest orders that are past retention and not yet anonymized
+const guestOrderCondition = `user_id IS NULL AND anonymized_at IS NULL AND created_at < $1`
+
+// abandonedCartCondition selects cart sessions with no activity since the cutoff
+const abandonedCartCondition = `updated_at < $1 AND NOT EXISTS (
+	SELECT 1 FROM cart_items ci WHERE ci.cart_session_id = cart_sessions.id AND ci.updated_at >= $1
+)`
+
+var retentionRules = []retentionRule{
+	{
+		name:        "guest_orders",
+		description: "Anonymize personal data of guest orders",
+		settingKey:  models.SettingRetentionGuestOrdersDays,
+		defaultDays: 5 * 365,
+		count: func(db *sql.DB, cutoff time.Time) (int64, error) {
+			var n int64
+			err := db.QueryRow("SELECT COUNT(*) FROM orders WHERE "+guestOrderCondition, cutoff).Scan(&n)
+			return n, err
+		},
+		apply: anonymizeGuestOrders,
+	},
+	{
+		name:        "abandoned_carts",
+		description: "Delete abandoned cart sessions",
+		settingKey:  models.SettingRetentionAbandonedCartsDays,
+		defaultDays: 90,
+		count: func(db *sql.DB, cutoff time.Time) (int64, error) {
+			var n int64
+			err := db.QueryRow("SELECT COUNT(*) FROM cart_sessions WHERE "+abandonedCartCondition, cutoff).Scan(&n)
+			return n, err
+		},
+		apply: func(tx *sql.Tx, cutoff time.Time) (int64, error) {
+			result, err := tx.Exec("DELETE FROM cart_sessions WHERE "+abandonedCartCondition, cutoff)
+			if err != nil {
+				return 0, err
+			}
+			return result.RowsAffected()
+		},
+	},
+	{
+		name:        "analytics_events",
+		description: "Delete raw storefront analytics events",
+		settingKey:  models.SettingRetentionAnalyticsEventsDays,
+		defaultDays: 400,
+		count: func(db *sql.DB, cutoff time.Time) (int64, error) {
+			var n int64
+			err := db.QueryRow("SELECT COUNT(*) FROM analytics_events WHERE occurred_at < $1", cutoff).Scan(&n)
+			return n, err
+		},
+		apply: func(tx *sql.Tx, cutoff time.Time) (int64, error) {
+			result, err := tx.Exec("DELETE FROM analytics_events WHERE occurred_at < $1", cutoff)
+			if err != nil {
+				return 0, err
+			}
+			return result.RowsAffected()
+		},
+	},
+	{
+		name:        "login_history",
+		description: "Delete login history",
+		settingKey:  models.SettingRetentionLoginHistoryDays,
+		defaultDays: 365,
+		count: func(db *sql.DB, cutoff time.Time) (int64, error) {
+			var n int64
+			err := db.QueryRow("SELECT COUNT(*) FROM login_history WHERE created_at < $1", cutoff).Scan(&n)
+			return n, err
+		},
+		apply: func(tx *sql.Tx, cutoff time.Time) (int64, error) {
+			result, err := tx.Exec("DELETE FROM login_history WHERE created_at < $1", cutoff)
+			if err != nil {
+				return 0, err
+			}
+			return result.RowsAffected()
+		},
+	},
+	{
+		name:        "debug_captures",
+		description: "Delete expired debug captures with their recorded requests",
+		settingKey:  models.SettingRetentionDebugCapturesDays,
+		defaultDays: 30,
+		count: func(db *sql.DB, cutoff time.Time) (int64, error) {
+			var n int64
+			err := db.QueryRow("SELECT COUNT(*) FROM debug_captures WHERE expires_at < $1", cutoff).Scan(&n)
+			return n, err
+		},
+		apply: func(tx *sql.Tx, cutoff time.Time) (int64, error) {
+			result, err := tx.Exec("DELETE FROM debug_captures WHERE expires_at < $1", cutoff)
+			if err != nil {
+				return 0, err
+			}
+			return result.RowsAffected()
+		},
+	},
+	{
+		name:        "api_key_usage",
+		description: "Delete daily partner API key usage",
+		settingKey:  models.SettingRetentionAPIKeyUsageDays,
+		defaultDays: 730,
+		count: func(db *sql.DB, cutoff time.Time) (int64, error) {
+			var n int64
+			err := db.QueryRow("SELECT COUNT(*) FROM api_key_usage WHERE day < $1::date", cutoff).Scan(&n)
+			return n, err
+		},
+		apply: func(tx *sql.Tx, cutoff time.Time) (int64, error) {
+			result, err := tx.Exec("DELETE FROM api_key_usage WHERE day < $1::date", cutoff)
+			if err != nil {
+				return 0, err
+			}
+			return result.RowsAffected()
+		},
+	},
+	{
+		name:        "webhook_deliveries",
+		description: "Delete finished webhook deliveries",
+		settingKey:  models.SettingRetentionWebhookDeliveriesDays,
+		defaultDays: 90,
+		count: func(db *sql.DB, cutoff time.Time) (int64, error) {
+			var n int64
+			err := db.QueryRow("SELECT COUNT(*) FROM webhook_deliveries WHERE status <> 'pending' AND created_at < $1", cutoff).Scan(&n)
+			return n, err
+		},
+		apply: func(tx *sql.Tx, cutoff time.Time) (int64, error) {
+			result, err := tx.Exec("DELETE FROM webhook_deliveries WHERE status <> 'pending' AND created_at < $1", cutoff)
+			if err != nil {
+				return 0, err
+			}
+			return result.RowsAffected()
+		},
+	},
+	{
+		name:        "email_outbox",
+		description: "Delete sent and failed outbox emails",
+		settingKey:  models.SettingRetentionEmailOutboxDays,
+		defaultDays: 90,
+		count: func(db *sql.DB, cutoff time.Time) (int64, error) {
+			var n int64
+			err := db.QueryRow("SELECT COUNT(*) FROM email_outbox WHERE status <> 'pending' AND created_at < $1", cutoff).Scan(&n)
+			return n, err
+		},
+		apply: func(tx *sql.Tx, cutoff time.Time) (int64, error) {
+			result, err := tx.Exec("DELETE FROM email_outbox WHERE status <> 'pending' AND created_at < $1", cutoff)
+			if err != nil {
+				return 0, err
+			}
+			return result.RowsAffected()
+		},
+	},
+	{
+		name:        "password_reset_tokens",
+		description: "Delete used and expired password reset tokens",
+		settingKey:  models.SettingRetentionPasswordResetTokensDays,
+		defaultDays: 30,
+		count: func(db *sql.DB, cutoff time.Time) (int64, error) {
+			var n int64
+			err := db.QueryRow("SELECT COUNT(*) FROM password_reset_tokens WHERE (used_at IS NOT NULL OR expires_at < CURRENT_TIMESTAMP) AND created_at < $1", cutoff).Scan(&n)
+			return n, err
+		},
+		apply: func(tx *sql.Tx, cutoff time.Time) (int64, error) {
+			result, err := tx.Exec("DELETE FROM password_reset_tokens WHERE (used_at IS NOT NULL OR expires_at < CURRENT_TIMESTAMP) AND created_at < $1", cutoff)
+			if err != nil {
+				return 0, err
+			}
+			return result.RowsAffected()
+		},
+	},
+	{
+		name:        "refresh_tokens",
+		description: "Delete revoked and expired refresh tokens",
+		settingKey:  models.SettingRetentionRefreshTokensDays,
+		defaultDays: 30,
+		count: func(db *sql.DB, cutoff time.Time) (int64, error) {
+			var n int64
+			err := db.QueryRow("SELECT COUNT(*) FROM refresh_tokens WHERE (revoked_at IS NOT NULL OR expires_at < CURRENT_TIMESTAMP) AND created_at < $1", cutoff).Scan(&n)
+			return n, err
+		},
+		apply: func(tx *sql.Tx, cutoff time.Time) (int64, error) {
+			result, err := tx.Exec("DELETE FROM refresh_tokens WHERE (revoked_at IS NOT NULL OR expires_at < CURRENT_TIMESTAMP) AND created_at < $1", cutoff)
+			if err != nil {
+				return 0, err
+			}
+			return result.RowsAffected()
+		},
+	},
+	{
+		name:        "totals_mismatches",
+		description: "Delete checkouts rejected for mismatching totals",
+		settingKey:  models.SettingRetentionTotalsMismatchesDays,
+		defaultDays: 365,
+		count: func(db *sql.DB, cutoff time.Time) (int64, error) {
+			var n int64
+			err := db.QueryRow("SELECT COUNT(*) FROM order_totals_mismatches WHERE created_at < $1", cutoff).Scan(&n)
+			return n, err
+		},
+		apply: func(tx *sql.Tx, cutoff time.Time) (int64, error) {
+			result, err := tx.Exec("DELETE FROM order_totals_mismatches WHERE created_at < $1", cutoff)
+			if err != nil {
+				return 0, err
+			}
+			return result.RowsAffected()
+		},
+	},
+	{
+		name:        "trash",
+		description: "Permanently delete trashed catalog items",
+		settingKey:  models.SettingRetentionTrashDays,
+		defaultDays: models.DefaultTrashRetentionDays,
+		count: func(db *sql.DB, cutoff time.Time) (int64, error) {
+			var n int64
+			err := db.QueryRow("SELECT COUNT(*) FROM trash_items WHERE deleted_at < $1", cutoff).Scan(&n)
+			return n, err
+		},
+		apply: func(tx *sql.Tx, cutoff time.Time) (int64, error) {
+			result, err := tx.Exec("DELETE FROM trash_items WHERE deleted_at < $1", cutoff)
+			if err != nil {
+				return 0, err
+			}
+			return result.RowsAffected()
+		},
+	},
+}
+
+// anonymizeGuestOrders strips personal data from old guest orders while keeping
+// amounts, items, city and country for reporting
+func anonymizeGuestOrders(tx *sql.Tx, cutoff time.Time) (int64, error) {
+	statements := []string{
+		`UPDATE shipping_addresses
+		SET first_name = 'Anonymized', last_name = '', company = NULL, address_line1 = '', address_line2 = NULL, phone = NULL
+		WHERE order_id IN (SELECT id FROM orders WHERE ` + guestOrderCondition + `)`,
+		`UPDATE billing_addresses
+		SET first_name = 'Anonymized', last_name = '', company = NULL, address_line1 = '', address_line2 = NULL, phone = NULL
+		WHERE order_id IN (SELECT id FROM orders WHERE ` + guestOrderCondition + `)`,
+		`UPDATE order_change_requests
+		SET payload = '{}'::jsonb, customer_note = NULL
+		WHERE order_id IN (SELECT id FROM orders WHERE ` + guestOrderCondition + `)`,
+		`UPDATE discount_code_usage
+		SET session_id = NULL
+		WHERE order_id IN (SELECT id FROM orders WHERE ` + guestOrderCondition + `)`,
+	}
+
+	for _, stmt := range statements {
+		if _, err := tx.Exec(stmt, cutoff); err != nil {
+			return 0, err
+		}
+	}
+
+	result, err := tx.Exec(`
+		UPDATE orders
+		SET email = 'anonymized-' || id || '@anonymized.invalid', phone = '', notes = NULL, nip = NULL,
+		    session_id = NULL, anonymized_at = CURRENT_TIMESTAMP
+		WHERE `+guestOrderCondition, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+type RetentionQueries struct {
+	db       *sql.DB
+	settings *SettingsQueries
+}
+
+func NewRetentionQueries(db *sql.DB) *RetentionQueries {
+	return &RetentionQueries{db: db, settings: NewSettingsQueries(db)}
+}
+
+// PreviewRetention reports what each retention rule would purge without changing data
+func (q *RetentionQueries) PreviewRetention() (*models.RetentionReport, error) {
+	return q.run(true)
+}
+
+// ApplyRetention purges data according to the configured retention rules.
+// Each rule runs in its own transaction.
+func (q *RetentionQueries) ApplyRetention() (*models.RetentionReport, error) {
+	return q.run(false)
+}
+
+func (q *RetentionQueries) run(dryRun bool) (*models.RetentionReport, error) {
+	now := time.Now()
+	report := &models.RetentionReport{DryRun: dryRun, RunAt: now, Results: []models.RetentionRuleResult{}}
+
+	for _, rule := range retentionRules {
+		days, err := q.settings.GetIntSetting(rule.settingKey, rule.defaultDays)
+		if err != nil {
+			return nil, err
+		}
+
+		result := models.RetentionRuleResult{
+			Rule:        rule.name,
+			Description: rule.description,
+			SettingKey:  rule.settingKey,
+			Days:        days,
+			Enabled:     days > 0,
+		}
+
+		if result.Enabled {
+			cutoff := now.AddDate(0, 0, -days)
+			result.Cutoff = &cutoff
+
+			if dryRun {
+				result.AffectedCount, err = rule.count(q.db, cutoff)
+				if err != nil {
+					return nil, fmt.Errorf("failed to preview retention rule %s: %w", rule.name, err)
+				}
+			} else {
+				result.AffectedCount, err = q.applyRule(rule, cutoff)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		report.Results = append(report.Results, result)
+	}
+
+	return report, nil
+}
+
+func (q *RetentionQueries) applyRule(rule retentionRule, cutoff time.Time) (int64, error) {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	affected, err := rule.apply(tx, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to apply retention rule %s: %w", rule.name, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return affected, nil
+}
@@ -0,0 +1,65 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// GetProductIDsAfter returns up to limit product IDs greater than afterID in order, for
+// walking the catalogue in batches
+func (q *ProductQueries) GetProductIDsAfter(afterID, limit int) ([]int, error) {
+	rows, err := q.db.Query(`SELECT id FROM products WHERE id > $1 ORDER BY id LIMIT $2`, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product IDs: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan product ID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// CountProducts returns the number of products in the catalogue
+func (q *ProductQueries) CountProducts() (int, error) {
+	var count int
+	if err := q.db.QueryRow(`SELECT COUNT(*) FROM products`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count products: %w", err)
+	}
+	return count, nil
+}
+
+// RefreshSearchIndex rebuilds the search documents of products. Triggers keep the
+// documents current as products change; this is for rebuilding them all.
+func (q *ProductQueries) RefreshSearchIndex(productIDs []int) error {
+	if len(productIDs) == 0 {
+		return nil
+	}
+	if _, err := q.db.Exec(`SELECT refresh_product_search($1)`, pq.Array(productIDs)); err != nil {
+		return fmt.Errorf("failed to refresh search index: %w", err)
+	}
+	return nil
+}
+
+// GetSearchIndexStats returns how many products are in the search index and when a
+// document was last refreshed
+func (q *ProductQueries) GetSearchIndexStats() (int, *time.Time, error) {
+	var count int
+	var lastIndexedAt sql.NullTime
+	err := q.db.QueryRow(`SELECT COUNT(*), MAX(indexed_at) FROM product_search_index`).Scan(&count, &lastIndexedAt)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to get search index stats: %w", err)
+	}
+	if !lastIndexedAt.Valid {
+		return count, nil, nil
+	}
+	return count, &lastIndexedAt.Time, nil
+}
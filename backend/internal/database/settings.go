@@ -3,6 +3,11 @@ package database
 import (
 	"database/sql"
 	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
 	"notsofluffy-backend/internal/models"
 )
 
@@ -106,4 +111,141 @@ func (q *SettingsQueries) GetMaintenanceMode() (bool, error) {
 		return false, nil
 	}
 	return setting.Value == "true", nil
-}
\ No newline at end of file
+}
+
+// GetCheckoutStatus reports whether checkout is switched off and the message customers
+// see meanwhile
+func (q *SettingsQueries) GetCheckoutStatus() (*models.CheckoutStatus, error) {
+	disabled, err := q.GetBoolSetting(models.SettingCheckoutDisabled, false)
+	if err != nil {
+		return &models.CheckoutStatus{}, err
+	}
+	if !disabled {
+		return &models.CheckoutStatus{}, nil
+	}
+
+	status := &models.CheckoutStatus{Disabled: true, Message: models.DefaultCheckoutDisabledMessage}
+	setting, err := q.GetSettingByKey(models.SettingCheckoutDisabledMessage)
+	if err != nil {
+		return status, err
+	}
+	if setting != nil && strings.TrimSpace(setting.Value) != "" {
+		status.Message = strings.TrimSpace(setting.Value)
+	}
+	return status, nil
+}
+
+// GetDuplicateOrderRule returns the duplicate order heuristic from its settings
+func (q *SettingsQueries) GetDuplicateOrderRule() (models.DuplicateOrderRule, error) {
+	rule := models.DuplicateOrderRule{
+		WindowMinutes:    models.DefaultDuplicateOrderWindowMinutes,
+		TolerancePercent: models.DefaultDuplicateOrderTolerancePercent,
+	}
+	var err error
+	if rule.WindowMinutes, err = q.GetIntSetting(models.SettingDuplicateOrderWindowMinutes, rule.WindowMinutes); err != nil {
+		return rule, err
+	}
+	if rule.TolerancePercent, err = q.GetIntSetting(models.SettingDuplicateOrderTolerancePercent, rule.TolerancePercent); err != nil {
+		return rule, err
+	}
+	if rule.WindowMinutes < 0 {
+		rule.WindowMinutes = 0
+	}
+	if rule.TolerancePercent < 0 {
+		rule.TolerancePercent = 0
+	}
+	return rule, nil
+}
+
+// GetReturnReceiver returns the receiver of carrier return shipments from its settings.
+// TargetPoint is empty while returns are sent with instructions only.
+func (q *SettingsQueries) GetReturnReceiver() (models.ReturnReceiver, error) {
+	var receiver models.ReturnReceiver
+	for key, value := range map[string]*string{
+		models.SettingReturnTargetPoint:   &receiver.TargetPoint,
+		models.SettingReturnReceiverEmail: &receiver.Email,
+		models.SettingReturnReceiverPhone: &receiver.Phone,
+		models.SettingLabelSenderAddress:  &receiver.Name,
+	} {
+		setting, err := q.GetSettingByKey(key)
+		if err != nil {
+			return receiver, err
+		}
+		if setting != nil {
+			*value = strings.TrimSpace(setting.Value)
+		}
+	}
+	if i := strings.Index(receiver.Name, "\n"); i >= 0 {
+		receiver.Name = strings.TrimSpace(receiver.Name[:i])
+	}
+	receiver.TargetPoint = strings.ToUpper(receiver.TargetPoint)
+	return receiver, nil
+}
+
+// GetIntSetting returns a setting parsed as an integer, or defaultValue if it is missing or invalid
+func (q *SettingsQueries) GetIntSetting(key string, defaultValue int) (int, error) {
+	setting, err := q.GetSettingByKey(key)
+	if err != nil {
+		return defaultValue, err
+	}
+	if setting == nil {
+		return defaultValue, nil
+	}
+	value, err := strconv.Atoi(strings.TrimSpace(setting.Value))
+	if err != nil {
+		return defaultValue, nil
+	}
+	return value, nil
+}
+
+// GetFloatSetting returns a setting parsed as a number, or defaultValue if it is missing or invalid
+func (q *SettingsQueries) GetFloatSetting(key string, defaultValue float64) (float64, error) {
+	setting, err := q.GetSettingByKey(key)
+	if err != nil {
+		return defaultValue, err
+	}
+	if setting == nil {
+		return defaultValue, nil
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(setting.Value), 64)
+	if err != nil {
+		return defaultValue, nil
+	}
+	return value, nil
+}
+
+// GetBoolSetting returns a setting that is "true" or "false", or defaultValue if it is missing
+func (q *SettingsQueries) GetBoolSetting(key string, defaultValue bool) (bool, error) {
+	setting, err := q.GetSettingByKey(key)
+	if err != nil {
+		return defaultValue, err
+	}
+	if setting == nil {
+		return defaultValue, nil
+	}
+	switch strings.TrimSpace(setting.Value) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	return defaultValue, nil
+}
+
+// GetShopLocation returns the shop's time zone from the shop_timezone setting
+func (q *SettingsQueries) GetShopLocation() *time.Location {
+	name := models.DefaultShopTimezone
+	setting, err := q.GetSettingByKey(models.SettingShopTimezone)
+	if err != nil {
+		log.Printf("Failed to read %s, using %s: %v", models.SettingShopTimezone, name, err)
+	} else if setting != nil && setting.Value != "" {
+		name = setting.Value
+	}
+
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		log.Printf("Invalid shop time zone %q, using UTC: %v", name, err)
+		return time.UTC
+	}
+	return location
+}
@@ -0,0 +1,157 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"notsofluffy-backend/internal/models"
+)
+
+type ShipmentQueries struct {
+	db *sql.DB
+}
+
+func NewShipmentQueries(db *sql.DB) *ShipmentQueries {
+	return &ShipmentQueries{db: db}
+}
+
+const shipmentColumns = `id, order_id, provider, provider_shipment_id, service, target_point, status, tracking_number, tracking_url, created_by, created_at, updated_at`
+
+func scanShipment(scanner interface{ Scan(...interface{}) error }, s *models.Shipment) error {
+	return scanner.Scan(&s.ID, &s.OrderID, &s.Provider, &s.ProviderShipmentID, &s.Service, &s.TargetPoint, &s.Status, &s.TrackingNumber, &s.TrackingURL, &s.CreatedBy, &s.CreatedAt, &s.UpdatedAt)
+}
+
+// CreateShipment records a shipment ordered from a carrier. carrier is the name copied
+// to the order with the tracking number once there is one.
+func (q *ShipmentQueries) CreateShipment(shipment *models.Shipment, carrier string) (*models.Shipment, error) {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var s models.Shipment
+	err = scanShipment(tx.QueryRow(`
+		INSERT INTO shipments (order_id, provider, provider_shipment_id, service, target_point, status, tracking_number, tracking_url, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING `+shipmentColumns,
+		shipment.OrderID, shipment.Provider, shipment.ProviderShipmentID, shipment.Service, shipment.TargetPoint,
+		shipment.Status, shipment.TrackingNumber, shipment.TrackingURL, shipment.CreatedBy,
+	), &s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create shipment: %w", err)
+	}
+
+	if err := setOrderTracking(tx, &s, carrier); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &s, nil
+}
+
+// GetLatestShipment returns the most recent shipment of an order
+func (q *ShipmentQueries) GetLatestShipment(orderID int) (*models.Shipment, error) {
+	var s models.Shipment
+	err := scanShipment(q.db.QueryRow(`
+		SELECT `+shipmentColumns+`
+		FROM shipments
+		WHERE order_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1`,
+		orderID,
+	), &s)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("shipment not found")
+		}
+		return nil, fmt.Errorf("failed to get shipment: %w", err)
+	}
+	return &s, nil
+}
+
+// UpdateShipmentTracking stores the status and tracking number the carrier reported
+// for a shipment, copying a new tracking number to the order
+func (q *ShipmentQueries) UpdateShipmentTracking(id int, status string, trackingNumber, trackingURL *string, carrier string) (*models.Shipment, error) {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var s models.Shipment
+	err = scanShipment(tx.QueryRow(`
+		UPDATE shipments
+		SET status = $1, tracking_number = COALESCE($2, tracking_number), tracking_url = COALESCE($3, tracking_url), updated_at = CURRENT_TIMESTAMP
+		WHERE id = $4
+		RETURNING `+shipmentColumns,
+		status, trackingNumber, trackingURL, id,
+	), &s)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("shipment not found")
+		}
+		return nil, fmt.Errorf("failed to update shipment: %w", err)
+	}
+
+	if err := setOrderTracking(tx, &s, carrier); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &s, nil
+}
+
+// setOrderTracking copies the tracking number of a shipment to its order, where the
+// order emails and the order actions read it
+func setOrderTracking(tx *sql.Tx, s *models.Shipment, carrier string) error {
+	if s.TrackingNumber == nil {
+		return nil
+	}
+	_, err := tx.Exec(`
+		UPDATE orders SET tracking_carrier = $1, tracking_number = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $3 AND tracking_number IS DISTINCT FROM $2`,
+		carrier, *s.TrackingNumber, s.OrderID)
+	if err != nil {
+		return fmt.Errorf("failed to set order tracking: %w", err)
+	}
+	return nil
+}
+
+// GetOrderTracking returns the tracking of an order with a tracking number, with the
+// tracking page and status of the carrier shipment when it was created here
+func (q *OrderQueries) GetOrderTracking(order *models.OrderResponse) (*models.OrderTracking, error) {
+	if order.TrackingNumber == nil || *order.TrackingNumber == "" {
+		return nil, nil
+	}
+	tracking := &models.OrderTracking{Number: *order.TrackingNumber}
+	if order.TrackingCarrier != nil {
+		tracking.Carrier = *order.TrackingCarrier
+	}
+
+	var url sql.NullString
+	var status string
+	err := q.db.QueryRow(`
+		SELECT tracking_url, status
+		FROM shipments
+		WHERE order_id = $1 AND tracking_number = $2
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1`,
+		order.ID, *order.TrackingNumber,
+	).Scan(&url, &status)
+	if err == sql.ErrNoRows {
+		return tracking, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order tracking: %w", err)
+	}
+	if url.Valid {
+		tracking.URL = &url.String
+	}
+	tracking.Status = &status
+	return tracking, nil
+}
@@ -0,0 +1,133 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"notsofluffy-backend/internal/models"
+)
+
+type ShippingMethodQueries struct {
+	db *sql.DB
+}
+
+func NewShippingMethodQueries(db *sql.DB) *ShippingMethodQueries {
+	return &ShippingMethodQueries{db: db}
+}
+
+const shippingMethodColumns = `id, name, description, price, free_above, active, sort_order, created_at, updated_at`
+
+func scanShippingMethod(scanner interface{ Scan(...interface{}) error }, m *models.ShippingMethod) error {
+	return scanner.Scan(&m.ID, &m.Name, &m.Description, &m.Price, &m.FreeAbove, &m.Active, &m.SortOrder, &m.CreatedAt, &m.UpdatedAt)
+}
+
+// ListShippingMethods returns the shipping methods in checkout order, only the active
+// ones when activeOnly is set
+func (q *ShippingMethodQueries) ListShippingMethods(activeOnly bool) ([]models.ShippingMethod, error) {
+	query := "SELECT " + shippingMethodColumns + " FROM shipping_methods"
+	if activeOnly {
+		query += " WHERE active = true"
+	}
+	rows, err := q.db.Query(query + " ORDER BY sort_order, id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shipping methods: %w", err)
+	}
+	defer rows.Close()
+
+	methods := []models.ShippingMethod{}
+	for rows.Next() {
+		var m models.ShippingMethod
+		if err := scanShippingMethod(rows, &m); err != nil {
+			return nil, fmt.Errorf("failed to scan shipping method: %w", err)
+		}
+		methods = append(methods, m)
+	}
+
+	return methods, rows.Err()
+}
+
+// GetShippingMethodByID retrieves a shipping method by ID
+func (q *ShippingMethodQueries) GetShippingMethodByID(id int) (*models.ShippingMethod, error) {
+	var m models.ShippingMethod
+	err := scanShippingMethod(q.db.QueryRow("SELECT "+shippingMethodColumns+" FROM shipping_methods WHERE id = $1", id), &m)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("shipping method not found")
+		}
+		return nil, fmt.Errorf("failed to get shipping method: %w", err)
+	}
+	return &m, nil
+}
+
+// ResolveShippingMethod returns the active shipping method a cart or order ships with:
+// the one with id, or the first active one when id is nil. It returns nil without an
+// error when no method is active, so shops without methods keep free base shipping.
+func (q *ShippingMethodQueries) ResolveShippingMethod(id *int) (*models.ShippingMethod, error) {
+	if id != nil {
+		method, err := q.GetShippingMethodByID(*id)
+		if err != nil {
+			return nil, err
+		}
+		if !method.Active {
+			return nil, fmt.Errorf("shipping method not found")
+		}
+		return method, nil
+	}
+
+	var m models.ShippingMethod
+	err := scanShippingMethod(q.db.QueryRow("SELECT "+shippingMethodColumns+" FROM shipping_methods WHERE active = true ORDER BY sort_order, id LIMIT 1"), &m)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shipping method: %w", err)
+	}
+	return &m, nil
+}
+
+// CreateShippingMethod creates a shipping method
+func (q *ShippingMethodQueries) CreateShippingMethod(req *models.ShippingMethodRequest) (*models.ShippingMethod, error) {
+	var m models.ShippingMethod
+	err := scanShippingMethod(q.db.QueryRow(`
+		INSERT INTO shipping_methods (name, description, price, free_above, active, sort_order)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+shippingMethodColumns,
+		req.Name, req.Description, req.Price, req.FreeAbove, req.Active, req.SortOrder,
+	), &m)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create shipping method: %w", err)
+	}
+	return &m, nil
+}
+
+// UpdateShippingMethod updates a shipping method. Orders keep the name and price they
+// were placed with in their shipping breakdown.
+func (q *ShippingMethodQueries) UpdateShippingMethod(id int, req *models.ShippingMethodRequest) (*models.ShippingMethod, error) {
+	var m models.ShippingMethod
+	err := scanShippingMethod(q.db.QueryRow(`
+		UPDATE shipping_methods
+		SET name = $1, description = $2, price = $3, free_above = $4, active = $5, sort_order = $6, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $7
+		RETURNING `+shippingMethodColumns,
+		req.Name, req.Description, req.Price, req.FreeAbove, req.Active, req.SortOrder, id,
+	), &m)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("shipping method not found")
+		}
+		return nil, fmt.Errorf("failed to update shipping method: %w", err)
+	}
+	return &m, nil
+}
+
+// DeleteShippingMethod deletes a shipping method
+func (q *ShippingMethodQueries) DeleteShippingMethod(id int) error {
+	result, err := q.db.Exec("DELETE FROM shipping_methods WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete shipping method: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("shipping method not found")
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"notsofluffy-backend/internal/models"
+)
+
+type ShopQueries struct {
+	db *sql.DB
+}
+
+func NewShopQueries(db *sql.DB) *ShopQueries {
+	return &ShopQueries{db: db}
+}
+
+const shopColumns = `id, slug, name, domains, sender_email, sender_name, theme, created_at, updated_at`
+
+func scanShop(scanner interface{ Scan(...interface{}) error }, s *models.Shop) error {
+	var theme []byte
+	if err := scanner.Scan(&s.ID, &s.Slug, &s.Name, pq.Array(&s.Domains), &s.SenderEmail, &s.SenderName, &theme, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		return err
+	}
+	if s.Domains == nil {
+		s.Domains = []string{}
+	}
+	s.Theme = map[string]string{}
+	if err := json.Unmarshal(theme, &s.Theme); err != nil {
+		return fmt.Errorf("invalid theme: %w", err)
+	}
+	s.IsDefault = s.ID == models.DefaultShopID
+	return nil
+}
+
+// ListShops returns all shops, the default one first
+func (q *ShopQueries) ListShops() ([]models.Shop, error) {
+	rows, err := q.db.Query("SELECT " + shopColumns + " FROM shops ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shops: %w", err)
+	}
+	defer rows.Close()
+
+	shops := []models.Shop{}
+	for rows.Next() {
+		var s models.Shop
+		if err := scanShop(rows, &s); err != nil {
+			return nil, fmt.Errorf("failed to scan shop: %w", err)
+		}
+		shops = append(shops, s)
+	}
+
+	return shops, rows.Err()
+}
+
+// GetShopByID retrieves a shop by ID
+func (q *ShopQueries) GetShopByID(id int) (*models.Shop, error) {
+	var s models.Shop
+	err := scanShop(q.db.QueryRow("SELECT "+shopColumns+" FROM shops WHERE id = $1", id), &s)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("shop not found")
+		}
+		return nil, fmt.Errorf("failed to get shop: %w", err)
+	}
+	return &s, nil
+}
+
+// CreateShop creates a shop. A domain can only belong to one shop.
+func (q *ShopQueries) CreateShop(req *models.ShopRequest) (*models.Shop, error) {
+	domains := NormalizeDomains(req.Domains)
+	theme, err := shopTheme(req.Theme)
+	if err != nil {
+		return nil, err
+	}
+	if err := q.checkDomains(0, domains); err != nil {
+		return nil, err
+	}
+
+	var s models.Shop
+	err = scanShop(q.db.QueryRow(`
+		INSERT INTO shops (slug, name, domains, sender_email, sender_name, theme)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+shopColumns,
+		strings.ToLower(strings.TrimSpace(req.Slug)), req.Name, pq.Array(domains), req.SenderEmail, req.SenderName, theme,
+	), &s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create shop: %w", err)
+	}
+	return &s, nil
+}
+
+// UpdateShop updates a shop. A domain can only belong to one shop.
+func (q *ShopQueries) UpdateShop(id int, req *models.ShopRequest) (*models.Shop, error) {
+	domains := NormalizeDomains(req.Domains)
+	theme, err := shopTheme(req.Theme)
+	if err != nil {
+		return nil, err
+	}
+	if err := q.checkDomains(id, domains); err != nil {
+		return nil, err
+	}
+
+	var s models.Shop
+	err = scanShop(q.db.QueryRow(`
+		UPDATE shops
+		SET slug = $1, name = $2, domains = $3, sender_email = $4, sender_name = $5, theme = $6, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $7
+		RETURNING `+shopColumns,
+		strings.ToLower(strings.TrimSpace(req.Slug)), req.Name, pq.Array(domains), req.SenderEmail, req.SenderName, theme, id,
+	), &s)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("shop not found")
+		}
+		return nil, fmt.Errorf("failed to update shop: %w", err)
+	}
+	return &s, nil
+}
+
+// checkDomains fails when another shop than id already serves one of the domains
+func (q *ShopQueries) checkDomains(id int, domains []string) error {
+	if len(domains) == 0 {
+		return nil
+	}
+	var taken string
+	err := q.db.QueryRow(`
+		SELECT d FROM shops, unnest(domains) AS d
+		WHERE id != $1 AND d = ANY($2)
+		LIMIT 1`,
+		id, pq.Array(domains),
+	).Scan(&taken)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check shop domains: %w", err)
+	}
+	return fmt.Errorf("domain %s is already used by another shop", taken)
+}
+
+func shopTheme(theme map[string]string) ([]byte, error) {
+	if theme == nil {
+		theme = map[string]string{}
+	}
+	data, err := json.Marshal(theme)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode theme: %w", err)
+	}
+	return data, nil
+}
+
+// shopID returns id, or the default shop's when it is unset
+func shopID(id int) int {
+	if id == 0 {
+		return models.DefaultShopID
+	}
+	return id
+}
+
+// NormalizeDomains reduces domains to lower case host names, dropping any scheme, port,
+// path and trailing dot as well as empty values and duplicates, keeping the order
+func NormalizeDomains(domains []string) []string {
+	normalized := []string{}
+	seen := map[string]bool{}
+	for _, domain := range domains {
+		domain = NormalizeHost(domain)
+		if domain == "" || seen[domain] {
+			continue
+		}
+		seen[domain] = true
+		normalized = append(normalized, domain)
+	}
+	return normalized
+}
+
+// NormalizeHost reduces a Host header or URL to its lower case host name
+func NormalizeHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if i := strings.Index(host, "://"); i >= 0 {
+		host = host[i+3:]
+	}
+	if i := strings.IndexAny(host, "/?#"); i >= 0 {
+		host = host[:i]
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(host, ".")
+}
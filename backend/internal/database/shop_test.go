@@ -0,0 +1,21 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeDomains(t *testing.T) {
+	got := NormalizeDomains([]string{
+		" Shop.Example.com ",
+		"https://shop.example.com/pl?x=1",
+		"other.example.com:8443",
+		"brand.example.com.",
+		"",
+		"[::1]:8080",
+	})
+	want := []string{"shop.example.com", "other.example.com", "brand.example.com", "::1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("NormalizeDomains = %v, want %v", got, want)
+	}
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"database/sql"
 	"fmt"
+	"time"
 )
 
 type StockQueries struct {
@@ -64,89 +65,66 @@ func (q *StockQueries) GetStockLevel(sizeID int) (int, error) {
 	return availableStock, nil
 }
 
-// ReserveStock temporarily reserves stock for a size during checkout process
-func (q *StockQueries) ReserveStock(sizeID int, quantity int) error {
+// ReserveStock temporarily reserves stock for a size during checkout process. The
+// reservation expires after ttl, when the sweeper returns it; it returns the reservation
+// ID, or 0 when the size doesn't track stock.
+func (q *StockQueries) ReserveStock(sizeID int, quantity int, ttl time.Duration) (int, error) {
 	// First check if we have enough stock
 	available, availableStock, err := q.CheckStockAvailability(sizeID, quantity)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	
 	if !available {
-		return fmt.Errorf("insufficient stock: requested %d, available %d", quantity, availableStock)
+		return 0, fmt.Errorf("insufficient stock: requested %d, available %d", quantity, availableStock)
 	}
 	
 	// If stock management is disabled, do nothing
 	if availableStock == -1 {
-		return nil
+		return 0, nil
 	}
 	
-	// Reserve the stock
+	tx, err := q.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Reserve the stock, re-checking what is left under the row lock
 	query := `
 		UPDATE sizes 
 		SET reserved_quantity = reserved_quantity + $1, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $2 AND use_stock = true
+		WHERE id = $2 AND use_stock = true AND stock_quantity - reserved_quantity >= $1
 	`
 	
-	result, err := q.db.Exec(query, quantity, sizeID)
+	result, err := tx.Exec(query, quantity, sizeID)
 	if err != nil {
-		return fmt.Errorf("failed to reserve stock: %w", err)
+		return 0, fmt.Errorf("failed to reserve stock: %w", err)
 	}
 	
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
 	}
 	
 	if rowsAffected == 0 {
-		return fmt.Errorf("no stock reserved: size may not exist or stock management disabled")
+		return 0, fmt.Errorf("insufficient stock: requested %d", quantity)
 	}
-	
-	return nil
-}
 
-// ReleaseStock releases previously reserved stock (e.g., if checkout fails)
-func (q *StockQueries) ReleaseStock(sizeID int, quantity int) error {
-	query := `
-		UPDATE sizes 
-		SET reserved_quantity = GREATEST(0, reserved_quantity - $1), updated_at = CURRENT_TIMESTAMP
-		WHERE id = $2 AND use_stock = true
-	`
-	
-	_, err := q.db.Exec(query, quantity, sizeID)
+	var reservationID int
+	err = tx.QueryRow(`
+		INSERT INTO stock_reservations (size_id, quantity, expires_at)
+		VALUES ($1, $2, $3)
+		RETURNING id`, sizeID, quantity, time.Now().Add(ttl)).Scan(&reservationID)
 	if err != nil {
-		return fmt.Errorf("failed to release stock: %w", err)
+		return 0, fmt.Errorf("failed to record stock reservation: %w", err)
 	}
-	
-	return nil
-}
 
-// DecrementStock finalizes stock reduction (converts reserved to actual reduction)
-func (q *StockQueries) DecrementStock(sizeID int, quantity int) error {
-	query := `
-		UPDATE sizes 
-		SET 
-			stock_quantity = GREATEST(0, stock_quantity - $1),
-			reserved_quantity = GREATEST(0, reserved_quantity - $1),
-			updated_at = CURRENT_TIMESTAMP
-		WHERE id = $2 AND use_stock = true
-	`
-	
-	result, err := q.db.Exec(query, quantity, sizeID)
-	if err != nil {
-		return fmt.Errorf("failed to decrement stock: %w", err)
-	}
-	
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 	
-	if rowsAffected == 0 {
-		return fmt.Errorf("no stock decremented: size may not exist or stock management disabled")
-	}
-	
-	return nil
+	return reservationID, nil
 }
 
 // IncrementStock increases stock quantity (for returns, restocking, etc.)
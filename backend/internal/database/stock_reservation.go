@@ -0,0 +1,101 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"notsofluffy-backend/internal/models"
+)
+
+// ReleaseReservation returns the stock of a reservation, e.g. if checkout fails. A
+// reservation the sweeper already released is ignored.
+func (q *StockQueries) ReleaseReservation(reservationID int) error {
+	_, err := q.db.Exec(`
+		WITH released AS (
+			DELETE FROM stock_reservations WHERE id = $1 RETURNING size_id, quantity
+		)
+		UPDATE sizes s
+		SET reserved_quantity = GREATEST(0, s.reserved_quantity - r.quantity), updated_at = CURRENT_TIMESTAMP
+		FROM released r
+		WHERE s.id = r.size_id`, reservationID)
+	if err != nil {
+		return fmt.Errorf("failed to release stock reservation: %w", err)
+	}
+	return nil
+}
+
+// ConfirmReservation turns a reservation into a stock reduction once the order is placed.
+// If the reservation expired in the meantime only the stock quantity is reduced, as the
+// sweeper already took it off the reserved quantity.
+func (q *StockQueries) ConfirmReservation(reservationID, sizeID, quantity int) error {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var reserved int
+	err = tx.QueryRow(`DELETE FROM stock_reservations WHERE id = $1 RETURNING quantity`, reservationID).Scan(&reserved)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to confirm stock reservation: %w", err)
+	}
+
+	result, err := tx.Exec(`
+		UPDATE sizes
+		SET stock_quantity = GREATEST(0, stock_quantity - $1),
+			reserved_quantity = GREATEST(0, reserved_quantity - $2),
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $3 AND use_stock = true`, quantity, reserved, sizeID)
+	if err != nil {
+		return fmt.Errorf("failed to decrement stock: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("no stock decremented: size may not exist or stock management disabled")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ReleaseExpiredReservations deletes the reservations that expired before now and
+// returns their stock
+func (q *StockQueries) ReleaseExpiredReservations(now time.Time) (*models.StockReservationSweep, error) {
+	sweep := &models.StockReservationSweep{}
+	err := q.db.QueryRow(`
+		WITH expired AS (
+			DELETE FROM stock_reservations WHERE expires_at <= $1 RETURNING size_id, quantity
+		), released AS (
+			UPDATE sizes s
+			SET reserved_quantity = GREATEST(0, s.reserved_quantity - e.quantity), updated_at = CURRENT_TIMESTAMP
+			FROM (SELECT size_id, SUM(quantity) AS quantity FROM expired GROUP BY size_id) e
+			WHERE s.id = e.size_id
+			RETURNING s.id
+		)
+		SELECT COUNT(*), COALESCE(SUM(quantity), 0) FROM expired`, now).Scan(&sweep.Swept, &sweep.ReleasedQuantity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to release expired stock reservations: %w", err)
+	}
+	return sweep, nil
+}
+
+// GetReservationStats counts the reservations currently held and those expired but
+// not swept yet; the sweeper totals are filled in by the caller
+func (q *StockQueries) GetReservationStats(now time.Time) (*models.StockReservationStats, error) {
+	stats := &models.StockReservationStats{}
+	err := q.db.QueryRow(`
+		SELECT COUNT(*) FILTER (WHERE expires_at > $1),
+			COALESCE(SUM(quantity) FILTER (WHERE expires_at > $1), 0),
+			COUNT(*) FILTER (WHERE expires_at <= $1)
+		FROM stock_reservations`, now).Scan(&stats.ActiveReservations, &stats.ReservedQuantity, &stats.ExpiredPending)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stock reservation stats: %w", err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,76 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"notsofluffy-backend/internal/models"
+)
+
+// RecordTotalsMismatch keeps a checkout rejected for mismatching totals
+func (q *OrderQueries) RecordTotalsMismatch(entry *models.TotalsMismatchLog) error {
+	expected, err := json.Marshal(entry.Expected)
+	if err != nil {
+		return fmt.Errorf("failed to encode expected totals: %w", err)
+	}
+	computed, err := json.Marshal(entry.Computed)
+	if err != nil {
+		return fmt.Errorf("failed to encode computed totals: %w", err)
+	}
+	mismatches, err := json.Marshal(entry.Mismatches)
+	if err != nil {
+		return fmt.Errorf("failed to encode mismatches: %w", err)
+	}
+
+	_, err = q.db.Exec(`
+		INSERT INTO order_totals_mismatches (session_id, user_id, email, ip_address, expected, computed, mismatches)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		entry.SessionID, entry.UserID, entry.Email, entry.IPAddress, expected, computed, mismatches)
+	if err != nil {
+		return fmt.Errorf("failed to record totals mismatch: %w", err)
+	}
+	return nil
+}
+
+// ListTotalsMismatches returns rejected checkouts, newest first
+func (q *OrderQueries) ListTotalsMismatches(page, limit int) (*models.TotalsMismatchListResponse, error) {
+	var total int
+	if err := q.db.QueryRow(`SELECT COUNT(*) FROM order_totals_mismatches`).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count totals mismatches: %w", err)
+	}
+
+	rows, err := q.db.Query(`
+		SELECT id, session_id, user_id, email, ip_address, expected, computed, mismatches, created_at
+		FROM order_totals_mismatches
+		ORDER BY created_at DESC, id DESC
+		LIMIT $1 OFFSET $2`, limit, (page-1)*limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list totals mismatches: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.TotalsMismatchLog{}
+	for rows.Next() {
+		var entry models.TotalsMismatchLog
+		var expected, computed, mismatches []byte
+		if err := rows.Scan(&entry.ID, &entry.SessionID, &entry.UserID, &entry.Email, &entry.IPAddress,
+			&expected, &computed, &mismatches, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan totals mismatch: %w", err)
+		}
+		if err := json.Unmarshal(expected, &entry.Expected); err != nil {
+			return nil, fmt.Errorf("failed to decode expected totals: %w", err)
+		}
+		if err := json.Unmarshal(computed, &entry.Computed); err != nil {
+			return nil, fmt.Errorf("failed to decode computed totals: %w", err)
+		}
+		if err := json.Unmarshal(mismatches, &entry.Mismatches); err != nil {
+			return nil, fmt.Errorf("failed to decode mismatches: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list totals mismatches: %w", err)
+	}
+
+	return &models.TotalsMismatchListResponse{Mismatches: entries, Total: total, Page: page, Limit: limit}, nil
+}
@@ -0,0 +1,179 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"notsofluffy-backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+type TranslationQueries struct {
+	db *sql.DB
+}
+
+func NewTranslationQueries(db *sql.DB) *TranslationQueries {
+	return &TranslationQueries{db: db}
+}
+
+// translationTable describes where the translations of a kind of content are kept and
+// which of the translatable fields it has
+type translationTable struct {
+	table, key, parent            string
+	shortDescription, description bool
+}
+
+var translationTables = map[string]translationTable{
+	models.TranslationProduct:           {table: "product_translations", key: "product_id", parent: "products", shortDescription: true, description: true},
+	models.TranslationCategory:          {table: "category_translations", key: "category_id", parent: "categories"},
+	models.TranslationAdditionalService: {table: "additional_service_translations", key: "service_id", parent: "additional_services", description: true},
+}
+
+// columns returns the select list of the fields, with ” for the ones the kind lacks
+func (t translationTable) columns() string {
+	shortDescription, description := "''", "''"
+	if t.shortDescription {
+		shortDescription = "short_description"
+	}
+	if t.description {
+		description = "description"
+	}
+	return "language, name, " + shortDescription + ", " + description + ", updated_at"
+}
+
+func lookupTranslationTable(kind string) (translationTable, error) {
+	t, ok := translationTables[kind]
+	if !ok {
+		return t, fmt.Errorf("unknown translation kind %q", kind)
+	}
+	return t, nil
+}
+
+func scanTranslation(row interface{ Scan(...interface{}) error }, t *models.Translation) error {
+	return row.Scan(&t.Language, &t.Name, &t.ShortDescription, &t.Description, &t.UpdatedAt)
+}
+
+// ListTranslations returns the translations of a product, category or service by language
+func (q *TranslationQueries) ListTranslations(kind string, id int) ([]models.Translation, error) {
+	t, err := lookupTranslationTable(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	var exists bool
+	if err := q.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM `+t.parent+` WHERE id = $1)`, id).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to check %s: %w", kind, err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("%s not found", kind)
+	}
+
+	rows, err := q.db.Query(`SELECT `+t.columns()+` FROM `+t.table+` WHERE `+t.key+` = $1 ORDER BY language`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list translations: %w", err)
+	}
+	defer rows.Close()
+
+	translations := []models.Translation{}
+	for rows.Next() {
+		var translation models.Translation
+		if err := scanTranslation(rows, &translation); err != nil {
+			return nil, fmt.Errorf("failed to scan translation: %w", err)
+		}
+		translations = append(translations, translation)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list translations: %w", err)
+	}
+	return translations, nil
+}
+
+// SaveTranslation adds or replaces the translation of a product, category or service to
+// a language. Fields the kind doesn't have are ignored.
+func (q *TranslationQueries) SaveTranslation(kind string, id int, language string, req *models.TranslationRequest) (*models.Translation, error) {
+	t, err := lookupTranslationTable(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	columns, values := []string{t.key, "language", "name"}, []interface{}{id, language, req.Name}
+	if t.shortDescription {
+		columns, values = append(columns, "short_description"), append(values, req.ShortDescription)
+	}
+	if t.description {
+		columns, values = append(columns, "description"), append(values, req.Description)
+	}
+	placeholders, updates := make([]string, len(columns)), []string{"updated_at = CURRENT_TIMESTAMP"}
+	for i, column := range columns {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		if i >= 2 {
+			updates = append(updates, column+" = EXCLUDED."+column)
+		}
+	}
+
+	var translation models.Translation
+	err = scanTranslation(q.db.QueryRow(`
+		INSERT INTO `+t.table+` (`+strings.Join(columns, ", ")+`) VALUES (`+strings.Join(placeholders, ", ")+`)
+		ON CONFLICT (`+t.key+`, language) DO UPDATE SET `+strings.Join(updates, ", ")+`
+		RETURNING `+t.columns(), values...), &translation)
+	if err != nil {
+		if strings.Contains(err.Error(), "foreign key") {
+			return nil, fmt.Errorf("%s not found", kind)
+		}
+		return nil, fmt.Errorf("failed to save translation: %w", err)
+	}
+	return &translation, nil
+}
+
+// DeleteTranslation removes the translation of a product, category or service to a language
+func (q *TranslationQueries) DeleteTranslation(kind string, id int, language string) error {
+	t, err := lookupTranslationTable(kind)
+	if err != nil {
+		return err
+	}
+
+	result, err := q.db.Exec(`DELETE FROM `+t.table+` WHERE `+t.key+` = $1 AND language = $2`, id, language)
+	if err != nil {
+		return fmt.Errorf("failed to delete translation: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete translation: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("translation not found")
+	}
+	return nil
+}
+
+// GetTranslations returns the translations of the given products, categories or services
+// to a language, keyed by ID. Those without one are left out.
+func (q *TranslationQueries) GetTranslations(kind, language string, ids []int) (map[int]models.Translation, error) {
+	translations := make(map[int]models.Translation, len(ids))
+	if len(ids) == 0 {
+		return translations, nil
+	}
+	t, err := lookupTranslationTable(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.db.Query(`SELECT `+t.key+`, `+t.columns()+` FROM `+t.table+`
+		WHERE language = $1 AND `+t.key+` = ANY($2)`, language, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get translations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var translation models.Translation
+		if err := rows.Scan(&id, &translation.Language, &translation.Name, &translation.ShortDescription, &translation.Description, &translation.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan translation: %w", err)
+		}
+		translations[id] = translation
+	}
+	return translations, rows.Err()
+}
@@ -0,0 +1,383 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"notsofluffy-backend/internal/models"
+)
+
+// trashCapture is a set of rows saved with a trashed entity. where selects the rows
+// by the entity id ($1). Captures are restored in order, so parents come first.
+type trashCapture struct {
+	table string
+	where string
+	// relink restores only this foreign key column on existing rows instead of inserting
+	// them, for references that the delete set to NULL
+	relink string
+	// filter skips restored rows whose other parents no longer exist
+	filter string
+}
+
+// trashEntity describes how an entity type is captured and deleted
+type trashEntity struct {
+	table       string
+	labelColumn string
+	notFound    string
+	captures    []trashCapture
+}
+
+var trashEntities = map[string]trashEntity{
+	models.TrashEntityProduct: {
+		table:       "products",
+		labelColumn: "name",
+		notFound:    "product not found",
+		captures: []trashCapture{
+			{table: "products", where: "id = $1"},
+			{table: "sizes", where: "product_id = $1"},
+			{table: "product_variants", where: "product_id = $1"},
+			{table: "product_images", where: "product_id = $1", filter: "image_id IN (SELECT id FROM images)"},
+			{table: "product_services", where: "product_id = $1", filter: "additional_service_id IN (SELECT id FROM additional_services)"},
+			{table: "product_variant_images", where: "product_variant_id IN (SELECT id FROM product_variants WHERE product_id = $1)", filter: "image_id IN (SELECT id FROM images)"},
+			{table: "warehouse_stock", where: "size_id IN (SELECT id FROM sizes WHERE product_id = $1)", filter: "warehouse_id IN (SELECT id FROM warehouses)"},
+			{table: "product_attachments", where: "product_id = $1"},
+			{table: "product_revisions", where: "product_id = $1"},
+			{table: "product_pairing_overrides", where: "product_id = $1 OR related_product_id = $1", filter: "product_id IN (SELECT id FROM products) AND related_product_id IN (SELECT id FROM products)"},
+			{table: "product_reviews", where: "product_id = $1", filter: "user_id IN (SELECT id FROM users) AND (order_id IS NULL OR order_id IN (SELECT id FROM orders))"},
+		},
+	},
+	models.TrashEntityCategory: {
+		table:       "categories",
+		labelColumn: "name",
+		notFound:    "category not found",
+		captures: []trashCapture{
+			{table: "categories", where: "id = $1"},
+			{table: "products", where: "category_id = $1", relink: "category_id"},
+		},
+	},
+	models.TrashEntityColor: {
+		table:       "colors",
+		labelColumn: "name",
+		notFound:    "color not found",
+		captures: []trashCapture{
+			{table: "colors", where: "id = $1"},
+			{table: "product_variants", where: "color_id = $1", filter: "product_id IN (SELECT id FROM products)"},
+			{table: "product_variant_images", where: "product_variant_id IN (SELECT id FROM product_variants WHERE color_id = $1)", filter: "product_variant_id IN (SELECT id FROM product_variants) AND image_id IN (SELECT id FROM images)"},
+		},
+	},
+	models.TrashEntityAdditionalService: {
+		table:       "additional_services",
+		labelColumn: "name",
+		notFound:    "additional service not found",
+		captures: []trashCapture{
+			{table: "additional_services", where: "id = $1"},
+			{table: "additional_service_images", where: "additional_service_id = $1", filter: "image_id IN (SELECT id FROM images)"},
+			{table: "product_services", where: "additional_service_id = $1", filter: "product_id IN (SELECT id FROM products)"},
+		},
+	},
+	models.TrashEntityClientReview: {
+		table:       "client_reviews",
+		labelColumn: "client_name",
+		notFound:    "client review not found",
+		captures: []trashCapture{
+			{table: "client_reviews", where: "id = $1"},
+		},
+	},
+}
+
+type TrashQueries struct {
+	db       *sql.DB
+	settings *SettingsQueries
+}
+
+func NewTrashQueries(db *sql.DB) *TrashQueries {
+	return &TrashQueries{db: db, settings: NewSettingsQueries(db)}
+}
+
+// IsTrashEntityType reports whether deleted entities of this type go to the trash
+func IsTrashEntityType(entityType string) bool {
+	_, ok := trashEntities[entityType]
+	return ok
+}
+
+// MoveToTrash saves the entity with its dependent rows and deletes it in one transaction
+func (q *TrashQueries) MoveToTrash(entityType string, id int, userID *int) (int, error) {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	trashID, err := moveToTrash(tx, entityType, id, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return trashID, nil
+}
+
+// BulkMoveToTrash moves entities to the trash in one transaction. Entities that can't be
+// moved are left in place and returned with their errors.
+func (q *TrashQueries) BulkMoveToTrash(entityType string, ids []int, userID *int) (map[int]error, error) {
+	return runBulk(q.db, ids, func(tx *sql.Tx, id int) error {
+		_, err := moveToTrash(tx, entityType, id, userID)
+		return err
+	})
+}
+
+// moveToTrash is MoveToTrash within a transaction
+func moveToTrash(tx *sql.Tx, entityType string, id int, userID *int) (int, error) {
+	entity, ok := trashEntities[entityType]
+	if !ok {
+		return 0, fmt.Errorf("unknown trash entity type %s", entityType)
+	}
+
+	var label string
+	labelQuery := fmt.Sprintf(`SELECT %s FROM %s WHERE id = $1 FOR UPDATE`,
+		pq.QuoteIdentifier(entity.labelColumn), pq.QuoteIdentifier(entity.table))
+	if err := tx.QueryRow(labelQuery, id).Scan(&label); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("%s", entity.notFound)
+		}
+		return 0, fmt.Errorf("failed to get %s: %w", entity.table, err)
+	}
+
+	data := make(map[string]models.CatalogSnapshotTable, len(entity.captures))
+	for _, capture := range entity.captures {
+		captured, err := captureRows(tx, capture.table, capture.where, id)
+		if err != nil {
+			return 0, err
+		}
+		data[capture.table] = captured
+	}
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode trash item: %w", err)
+	}
+
+	var trashID int
+	err = tx.QueryRow(`
+		INSERT INTO trash_items (entity_type, entity_id, label, data, deleted_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`, entityType, id, label, encoded, userID).Scan(&trashID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to store trash item: %w", err)
+	}
+
+	// Dependent rows go with the entity through ON DELETE CASCADE / SET NULL
+	if _, err := tx.Exec(fmt.Sprintf(`DELETE FROM %s WHERE id = $1`, pq.QuoteIdentifier(entity.table)), id); err != nil {
+		return 0, fmt.Errorf("failed to delete %s: %w", entity.table, err)
+	}
+	return trashID, nil
+}
+
+// ListTrash returns trashed items, newest first, optionally filtered by entity type
+func (q *TrashQueries) ListTrash(page, limit int, entityType string) (*models.TrashListResponse, error) {
+	where := ""
+	args := []interface{}{}
+	if entityType != "" {
+		where = "WHERE entity_type = $1"
+		args = append(args, entityType)
+	}
+
+	var total int
+	if err := q.db.QueryRow(`SELECT COUNT(*) FROM trash_items `+where, args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count trash items: %w", err)
+	}
+
+	retentionDays, err := q.settings.GetIntSetting(models.SettingRetentionTrashDays, models.DefaultTrashRetentionDays)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trash retention: %w", err)
+	}
+
+	offset := (page - 1) * limit
+	query := fmt.Sprintf(`
+		SELECT id, entity_type, entity_id, label, deleted_by, deleted_at
+		FROM trash_items
+		%s
+		ORDER BY deleted_at DESC, id DESC
+		LIMIT $%d OFFSET $%d`, where, len(args)+1, len(args)+2)
+	args = append(args, limit, offset)
+
+	rows, err := q.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trash items: %w", err)
+	}
+	defer rows.Close()
+
+	items := []models.TrashItem{}
+	for rows.Next() {
+		var item models.TrashItem
+		if err := rows.Scan(&item.ID, &item.EntityType, &item.EntityID, &item.Label, &item.DeletedBy, &item.DeletedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan trash item: %w", err)
+		}
+		item.PurgeAt = trashPurgeAt(item.DeletedAt, retentionDays)
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list trash items: %w", err)
+	}
+
+	return &models.TrashListResponse{Items: items, Total: total, Page: page, Limit: limit}, nil
+}
+
+// GetTrashItem returns a trashed item with its captured rows
+func (q *TrashQueries) GetTrashItem(id int) (*models.TrashItem, error) {
+	var item models.TrashItem
+	var data []byte
+	err := q.db.QueryRow(`
+		SELECT id, entity_type, entity_id, label, data, deleted_by, deleted_at
+		FROM trash_items WHERE id = $1`, id).
+		Scan(&item.ID, &item.EntityType, &item.EntityID, &item.Label, &data, &item.DeletedBy, &item.DeletedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("trash item not found")
+		}
+		return nil, fmt.Errorf("failed to get trash item: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &item.Data); err != nil {
+		return nil, fmt.Errorf("failed to decode trash item: %w", err)
+	}
+
+	retentionDays, err := q.settings.GetIntSetting(models.SettingRetentionTrashDays, models.DefaultTrashRetentionDays)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trash retention: %w", err)
+	}
+	item.PurgeAt = trashPurgeAt(item.DeletedAt, retentionDays)
+
+	return &item, nil
+}
+
+// RestoreTrashItem re-creates the entity and its dependent rows, then removes it from the trash
+func (q *TrashQueries) RestoreTrashItem(id int) (*models.TrashRestoreResult, error) {
+	item, err := q.GetTrashItem(id)
+	if err != nil {
+		return nil, err
+	}
+	entity, ok := trashEntities[item.EntityType]
+	if !ok {
+		return nil, fmt.Errorf("unknown trash entity type %s", item.EntityType)
+	}
+
+	tx, err := q.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result := &models.TrashRestoreResult{EntityType: item.EntityType, EntityID: item.EntityID}
+	for _, capture := range entity.captures {
+		restored, err := restoreCapture(tx, capture, item.Data[capture.table])
+		if err != nil {
+			if strings.Contains(err.Error(), "duplicate key") || strings.Contains(err.Error(), "foreign key") {
+				return nil, fmt.Errorf("trash item conflicts with current data: %w", err)
+			}
+			return nil, err
+		}
+		result.Rows += restored
+	}
+
+	if _, err := tx.Exec(`DELETE FROM trash_items WHERE id = $1`, id); err != nil {
+		return nil, fmt.Errorf("failed to remove trash item: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return result, nil
+}
+
+// PurgeTrashItem permanently removes an item from the trash
+func (q *TrashQueries) PurgeTrashItem(id int) error {
+	result, err := q.db.Exec(`DELETE FROM trash_items WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to purge trash item: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("trash item not found")
+	}
+	return nil
+}
+
+// captureRows reads the rows of table matching where, with id as $1
+func captureRows(tx *sql.Tx, table, where string, id int) (models.CatalogSnapshotTable, error) {
+	columns, err := tableColumns(tx, table)
+	if err != nil {
+		return models.CatalogSnapshotTable{}, err
+	}
+
+	var rows []byte
+	query := fmt.Sprintf(`SELECT COALESCE(json_agg(t), '[]'::json) FROM %s t WHERE %s`,
+		pq.QuoteIdentifier(table), where)
+	if err := tx.QueryRow(query, id).Scan(&rows); err != nil {
+		return models.CatalogSnapshotTable{}, fmt.Errorf("failed to capture %s: %w", table, err)
+	}
+	return models.CatalogSnapshotTable{Columns: columns, Rows: rows}, nil
+}
+
+// restoreCapture inserts the captured rows of one table, or relinks them
+func restoreCapture(tx *sql.Tx, capture trashCapture, snapshot models.CatalogSnapshotTable) (int64, error) {
+	if len(snapshot.Rows) == 0 {
+		return 0, nil
+	}
+	name := pq.QuoteIdentifier(capture.table)
+
+	if capture.relink != "" {
+		column := pq.QuoteIdentifier(capture.relink)
+		query := fmt.Sprintf(`
+			UPDATE %s t SET %s = r.%s
+			FROM json_populate_recordset(NULL::%s, $1::json) r
+			WHERE t.id = r.id AND t.%s IS NULL`, name, column, column, name, column)
+		res, err := tx.Exec(query, string(snapshot.Rows))
+		if err != nil {
+			return 0, fmt.Errorf("failed to relink %s: %w", capture.table, err)
+		}
+		return res.RowsAffected()
+	}
+
+	current, err := tableColumns(tx, capture.table)
+	if err != nil {
+		return 0, err
+	}
+	columns := restorableColumns(current, snapshot.Columns, nil)
+	if len(columns) == 0 {
+		return 0, fmt.Errorf("trash item has no usable columns for %s", capture.table)
+	}
+	for i, column := range columns {
+		columns[i] = pq.QuoteIdentifier(column)
+	}
+	columnList := strings.Join(columns, ", ")
+
+	source := fmt.Sprintf(`SELECT %s FROM json_populate_recordset(NULL::%s, $1::json)`, columnList, name)
+	if capture.filter != "" {
+		source = fmt.Sprintf(`SELECT * FROM (%s) restored WHERE %s`, source, capture.filter)
+	}
+
+	res, err := tx.Exec(fmt.Sprintf(`INSERT INTO %s (%s) %s`, name, columnList, source), string(snapshot.Rows))
+	if err != nil {
+		return 0, fmt.Errorf("failed to restore %s: %w", capture.table, err)
+	}
+	return res.RowsAffected()
+}
+
+func trashPurgeAt(deletedAt time.Time, retentionDays int) *time.Time {
+	if retentionDays <= 0 {
+		return nil
+	}
+	purgeAt := deletedAt.AddDate(0, 0, retentionDays)
+	return &purgeAt
+}
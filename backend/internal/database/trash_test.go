@@ -0,0 +1,42 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"notsofluffy-backend/internal/models"
+)
+
+func TestTrashPurgeAt(t *testing.T) {
+	deletedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	purgeAt := trashPurgeAt(deletedAt, 30)
+	if purgeAt == nil || !purgeAt.Equal(time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected purge time %v", purgeAt)
+	}
+	if trashPurgeAt(deletedAt, 0) != nil {
+		t.Fatal("expected no purge time when retention is disabled")
+	}
+}
+
+func TestTrashEntitiesCaptureRootFirst(t *testing.T) {
+	types := []string{
+		models.TrashEntityProduct,
+		models.TrashEntityCategory,
+		models.TrashEntityColor,
+		models.TrashEntityAdditionalService,
+		models.TrashEntityClientReview,
+	}
+	for _, entityType := range types {
+		entity, ok := trashEntities[entityType]
+		if !ok {
+			t.Fatalf("missing trash entity %s", entityType)
+		}
+		if len(entity.captures) == 0 || entity.captures[0].table != entity.table || entity.captures[0].where != "id = $1" {
+			t.Errorf("%s must capture its own row first", entityType)
+		}
+	}
+	if IsTrashEntityType("material") {
+		t.Error("materials are not trashed")
+	}
+}
@@ -0,0 +1,811 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/lib/pq"
+
+	"notsofluffy-backend/internal/models"
+)
+
+type WarehouseQueries struct {
+	db       *sql.DB
+	settings *SettingsQueries
+}
+
+func NewWarehouseQueries(db *sql.DB) *WarehouseQueries {
+	return &WarehouseQueries{db: db, settings: NewSettingsQueries(db)}
+}
+
+const warehouseColumns = `id, code, name, city, postal_code, country, priority, is_active, is_default, created_at, updated_at`
+
+func scanWarehouse(scanner interface{ Scan(...interface{}) error }, w *models.Warehouse) error {
+	return scanner.Scan(&w.ID, &w.Code, &w.Name, &w.City, &w.PostalCode, &w.Country, &w.Priority, &w.IsActive, &w.IsDefault, &w.CreatedAt, &w.UpdatedAt)
+}
+
+// ListWarehouses returns all warehouses ordered by priority
+func (q *WarehouseQueries) ListWarehouses() ([]models.Warehouse, error) {
+	rows, err := q.db.Query("SELECT " + warehouseColumns + " FROM warehouses ORDER BY priority, id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get warehouses: %w", err)
+	}
+	defer rows.Close()
+
+	warehouses := []models.Warehouse{}
+	for rows.Next() {
+		var w models.Warehouse
+		if err := scanWarehouse(rows, &w); err != nil {
+			return nil, fmt.Errorf("failed to scan warehouse: %w", err)
+		}
+		warehouses = append(warehouses, w)
+	}
+
+	return warehouses, rows.Err()
+}
+
+// GetWarehouseByID retrieves a warehouse by ID
+func (q *WarehouseQueries) GetWarehouseByID(id int) (*models.Warehouse, error) {
+	var w models.Warehouse
+	err := scanWarehouse(q.db.QueryRow("SELECT "+warehouseColumns+" FROM warehouses WHERE id = $1", id), &w)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("warehouse not found")
+		}
+		return nil, fmt.Errorf("failed to get warehouse: %w", err)
+	}
+	return &w, nil
+}
+
+// CreateWarehouse creates a warehouse; making it default clears the previous default
+func (q *WarehouseQueries) CreateWarehouse(req *models.WarehouseRequest) (*models.Warehouse, error) {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if req.IsDefault {
+		if _, err := tx.Exec("UPDATE warehouses SET is_default = false WHERE is_default = true"); err != nil {
+			return nil, fmt.Errorf("failed to clear default warehouse: %w", err)
+		}
+	}
+
+	var w models.Warehouse
+	err = scanWarehouse(tx.QueryRow(`
+		INSERT INTO warehouses (code, name, city, postal_code, country, priority, is_active, is_default)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING `+warehouseColumns,
+		strings.ToUpper(strings.TrimSpace(req.Code)), req.Name, req.City, req.PostalCode, req.Country, req.Priority, req.IsActive, req.IsDefault,
+	), &w)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create warehouse: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &w, nil
+}
+
+// UpdateWarehouse updates a warehouse; making it default clears the previous default
+func (q *WarehouseQueries) UpdateWarehouse(id int, req *models.WarehouseRequest) (*models.Warehouse, error) {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if req.IsDefault {
+		if _, err := tx.Exec("UPDATE warehouses SET is_default = false WHERE is_default = true AND id != $1", id); err != nil {
+			return nil, fmt.Errorf("failed to clear default warehouse: %w", err)
+		}
+	}
+
+	var w models.Warehouse
+	err = scanWarehouse(tx.QueryRow(`
+		UPDATE warehouses
+		SET code = $1, name = $2, city = $3, postal_code = $4, country = $5, priority = $6, is_active = $7, is_default = $8
+		WHERE id = $9
+		RETURNING `+warehouseColumns,
+		strings.ToUpper(strings.TrimSpace(req.Code)), req.Name, req.City, req.PostalCode, req.Country, req.Priority, req.IsActive, req.IsDefault, id,
+	), &w)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("warehouse not found")
+		}
+		return nil, fmt.Errorf("failed to update warehouse: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &w, nil
+}
+
+// DeleteWarehouse deletes an empty, non-default warehouse
+func (q *WarehouseQueries) DeleteWarehouse(id int) error {
+	w, err := q.GetWarehouseByID(id)
+	if err != nil {
+		return err
+	}
+	if w.IsDefault {
+		return fmt.Errorf("cannot delete the default warehouse")
+	}
+
+	var stock int
+	err = q.db.QueryRow("SELECT COALESCE(SUM(quantity), 0) FROM warehouse_stock WHERE warehouse_id = $1", id).Scan(&stock)
+	if err != nil {
+		return fmt.Errorf("failed to get warehouse stock: %w", err)
+	}
+	if stock > 0 {
+		return fmt.Errorf("cannot delete warehouse that still holds stock")
+	}
+
+	if _, err := q.db.Exec("DELETE FROM warehouses WHERE id = $1", id); err != nil {
+		return fmt.Errorf("failed to delete warehouse: %w", err)
+	}
+	return nil
+}
+
+// GetSizeStock returns the stock of a size in every warehouse
+func (q *WarehouseQueries) GetSizeStock(sizeID int) ([]models.WarehouseStock, error) {
+	rows, err := q.db.Query(`
+		SELECT w.id, w.code, w.name, $1::int, COALESCE(ws.quantity, 0), COALESCE(ws.updated_at, w.updated_at)
+		FROM warehouses w
+		LEFT JOIN warehouse_stock ws ON ws.warehouse_id = w.id AND ws.size_id = $1
+		ORDER BY w.priority, w.id`,
+		sizeID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get warehouse stock: %w", err)
+	}
+	defer rows.Close()
+
+	stock := []models.WarehouseStock{}
+	for rows.Next() {
+		var s models.WarehouseStock
+		if err := rows.Scan(&s.WarehouseID, &s.WarehouseCode, &s.WarehouseName, &s.SizeID, &s.Quantity, &s.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan warehouse stock: %w", err)
+		}
+		stock = append(stock, s)
+	}
+
+	return stock, rows.Err()
+}
+
+// SetWarehouseStock sets the quantity of a size in a warehouse and adjusts the size total
+func (q *WarehouseQueries) SetWarehouseStock(warehouseID int, req *models.WarehouseStockRequest, userID *int) error {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var warehouseExists bool
+	err = tx.QueryRow("SELECT true FROM warehouses WHERE id = $1", warehouseID).Scan(&warehouseExists)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("warehouse not found")
+		}
+		return fmt.Errorf("failed to get warehouse: %w", err)
+	}
+
+	current, err := lockWarehouseStock(tx, warehouseID, req.SizeID)
+	if err != nil {
+		return err
+	}
+	if err := setWarehouseStock(tx, warehouseID, req.SizeID, current, req.Quantity, req.Note, userID); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// BulkUpdateStock sets or adds to the stock of many sizes in one warehouse, the default
+// one unless req names another. Either every row is applied or, when any row fails,
+// none is and the results tell which rows to fix.
+func (q *WarehouseQueries) BulkUpdateStock(req *models.BulkStockRequest, userID *int) (*models.BulkStockResponse, error) {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var warehouseID int
+	if req.WarehouseID != nil {
+		err = tx.QueryRow("SELECT id FROM warehouses WHERE id = $1", *req.WarehouseID).Scan(&warehouseID)
+	} else {
+		err = tx.QueryRow("SELECT id FROM warehouses WHERE is_default = true").Scan(&warehouseID)
+	}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("warehouse not found")
+		}
+		return nil, fmt.Errorf("failed to get warehouse: %w", err)
+	}
+
+	response := &models.BulkStockResponse{WarehouseID: warehouseID, Results: []models.BulkStockResult{}}
+	seen := map[int]int{}
+	for i, item := range req.Items {
+		result := models.BulkStockResult{SizeID: item.SizeID}
+		if row, duplicate := seen[item.SizeID]; duplicate {
+			result.Error = fmt.Sprintf("duplicates row %d", row+1)
+			response.Results = append(response.Results, result)
+			response.Errors++
+			continue
+		}
+		seen[item.SizeID] = i
+
+		current, err := lockWarehouseStock(tx, warehouseID, item.SizeID)
+		if err != nil {
+			if err.Error() != "size not found" {
+				return nil, err
+			}
+			result.Error = err.Error()
+			response.Results = append(response.Results, result)
+			response.Errors++
+			continue
+		}
+
+		target := item.Quantity
+		if req.Mode == models.BulkStockModeAdd {
+			target = current + item.Quantity
+		}
+		result.Previous, result.Quantity, result.Change = current, target, target-current
+		if target < 0 {
+			result.Error = fmt.Sprintf("stock would drop below zero (current %d)", current)
+			response.Results = append(response.Results, result)
+			response.Errors++
+			continue
+		}
+
+		if err := setWarehouseStock(tx, warehouseID, item.SizeID, current, target, req.Note, userID); err != nil {
+			return nil, err
+		}
+		response.Results = append(response.Results, result)
+	}
+
+	if response.Errors > 0 {
+		return response, nil
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	response.Applied = true
+	return response, nil
+}
+
+// lockWarehouseStock locks a size and returns its quantity in a warehouse
+func lockWarehouseStock(tx *sql.Tx, warehouseID, sizeID int) (int, error) {
+	var sizeExists bool
+	err := tx.QueryRow("SELECT true FROM sizes WHERE id = $1 FOR UPDATE", sizeID).Scan(&sizeExists)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("size not found")
+		}
+		return 0, fmt.Errorf("failed to lock size: %w", err)
+	}
+
+	var current int
+	err = tx.QueryRow("SELECT quantity FROM warehouse_stock WHERE warehouse_id = $1 AND size_id = $2 FOR UPDATE", warehouseID, sizeID).Scan(&current)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, fmt.Errorf("failed to get warehouse stock: %w", err)
+	}
+	return current, nil
+}
+
+// setWarehouseStock changes the quantity of a locked size in a warehouse from current to
+// quantity, adjusting the size total and logging the adjustment
+func setWarehouseStock(tx *sql.Tx, warehouseID, sizeID, current, quantity int, note *string, userID *int) error {
+	diff := quantity - current
+	if diff == 0 {
+		return nil
+	}
+
+	if err := upsertWarehouseStock(tx, warehouseID, sizeID, quantity); err != nil {
+		return err
+	}
+
+	_, err := tx.Exec("UPDATE sizes SET stock_quantity = GREATEST(0, stock_quantity + $1), updated_at = CURRENT_TIMESTAMP WHERE id = $2", diff, sizeID)
+	if err != nil {
+		return fmt.Errorf("failed to update size stock: %w", err)
+	}
+
+	var from, to *int
+	if diff > 0 {
+		to = &warehouseID
+	} else {
+		from = &warehouseID
+	}
+	return logStockMovement(tx, sizeID, from, to, diff, models.StockMovementAdjustment, nil, note, userID)
+}
+
+// TransferStock moves stock of a size from one warehouse to another
+func (q *WarehouseQueries) TransferStock(req *models.StockTransferRequest, userID *int) error {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var toExists bool
+	err = tx.QueryRow("SELECT true FROM warehouses WHERE id = $1", req.ToWarehouseID).Scan(&toExists)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("warehouse not found")
+		}
+		return fmt.Errorf("failed to get warehouse: %w", err)
+	}
+
+	var available int
+	err = tx.QueryRow("SELECT quantity FROM warehouse_stock WHERE warehouse_id = $1 AND size_id = $2 FOR UPDATE", req.FromWarehouseID, req.SizeID).Scan(&available)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to get warehouse stock: %w", err)
+	}
+
+	if available < req.Quantity {
+		return fmt.Errorf("insufficient stock: requested %d, available %d", req.Quantity, available)
+	}
+
+	_, err = tx.Exec("UPDATE warehouse_stock SET quantity = quantity - $1, updated_at = CURRENT_TIMESTAMP WHERE warehouse_id = $2 AND size_id = $3", req.Quantity, req.FromWarehouseID, req.SizeID)
+	if err != nil {
+		return fmt.Errorf("failed to take stock: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO warehouse_stock (warehouse_id, size_id, quantity)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (warehouse_id, size_id) DO UPDATE SET quantity = warehouse_stock.quantity + EXCLUDED.quantity, updated_at = CURRENT_TIMESTAMP`,
+		req.ToWarehouseID, req.SizeID, req.Quantity,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to add stock: %w", err)
+	}
+
+	if err := logStockMovement(tx, req.SizeID, &req.FromWarehouseID, &req.ToWarehouseID, req.Quantity, models.StockMovementTransfer, nil, req.Note, userID); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ReconcileDefaultWarehouse makes the default warehouse absorb differences between the
+// size total (edited directly on the size) and the sum of per-warehouse stock
+func (q *WarehouseQueries) ReconcileDefaultWarehouse(sizeID int, userID *int) error {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := reconcileDefaultWarehouse(tx, sizeID, userID); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// reconcileDefaultWarehouse is ReconcileDefaultWarehouse within a transaction
+func reconcileDefaultWarehouse(tx *sql.Tx, sizeID int, userID *int) error {
+	var useStock bool
+	var total int
+	err := tx.QueryRow("SELECT use_stock, stock_quantity FROM sizes WHERE id = $1 FOR UPDATE", sizeID).Scan(&useStock, &total)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("size not found")
+		}
+		return fmt.Errorf("failed to lock size: %w", err)
+	}
+	if !useStock {
+		return nil
+	}
+
+	var defaultID int
+	err = tx.QueryRow("SELECT id FROM warehouses WHERE is_default = true").Scan(&defaultID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return fmt.Errorf("failed to get default warehouse: %w", err)
+	}
+
+	var others, current int
+	err = tx.QueryRow(`
+		SELECT COALESCE(SUM(quantity) FILTER (WHERE warehouse_id != $2), 0),
+		       COALESCE(SUM(quantity) FILTER (WHERE warehouse_id = $2), 0)
+		FROM warehouse_stock WHERE size_id = $1`,
+		sizeID, defaultID,
+	).Scan(&others, &current)
+	if err != nil {
+		return fmt.Errorf("failed to sum warehouse stock: %w", err)
+	}
+
+	target := total - others
+	if target < 0 {
+		target = 0
+	}
+	if target == current {
+		return nil
+	}
+
+	if err := upsertWarehouseStock(tx, defaultID, sizeID, target); err != nil {
+		return err
+	}
+
+	diff := target - current
+	var from, to *int
+	if diff > 0 {
+		to = &defaultID
+	} else {
+		from = &defaultID
+	}
+	note := "Size stock edited"
+	return logStockMovement(tx, sizeID, from, to, diff, models.StockMovementAdjustment, nil, &note, userID)
+}
+
+// AllocateOrder assigns stock-tracked order items to warehouses using the configured
+// strategy and takes the allocated quantity from warehouse stock
+func (q *WarehouseQueries) AllocateOrder(orderID int) error {
+	strategy := models.AllocationStrategyPriority
+	if setting, err := q.settings.GetSettingByKey(models.SettingWarehouseAllocationStrategy); err == nil && setting != nil {
+		strategy = setting.Value
+	}
+
+	tx, err := q.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var country, postalCode string
+	err = tx.QueryRow("SELECT COALESCE(country, ''), COALESCE(postal_code, '') FROM shipping_addresses WHERE order_id = $1", orderID).Scan(&country, &postalCode)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to get shipping address: %w", err)
+	}
+
+	type itemToAllocate struct {
+		id, sizeID, quantity int
+	}
+	var items []itemToAllocate
+
+	rows, err := tx.Query(`
+		SELECT oi.id, oi.size_id, oi.quantity
+		FROM order_items oi
+		JOIN sizes s ON s.id = oi.size_id
+		WHERE oi.order_id = $1 AND s.use_stock = true
+		  AND NOT EXISTS (SELECT 1 FROM order_item_allocations a WHERE a.order_item_id = oi.id)`,
+		orderID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get order items: %w", err)
+	}
+	for rows.Next() {
+		var item itemToAllocate
+		if err := rows.Scan(&item.id, &item.sizeID, &item.quantity); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan order item: %w", err)
+		}
+		items = append(items, item)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate order items: %w", err)
+	}
+
+	if len(items) == 0 {
+		return nil
+	}
+
+	var warehouses []warehouseCandidate
+	rows, err = tx.Query("SELECT id, priority, is_default, country, COALESCE(postal_code, '') FROM warehouses WHERE is_active = true")
+	if err != nil {
+		return fmt.Errorf("failed to get warehouses: %w", err)
+	}
+	for rows.Next() {
+		var w warehouseCandidate
+		if err := rows.Scan(&w.id, &w.priority, &w.isDefault, &w.country, &w.postalCode); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan warehouse: %w", err)
+		}
+		warehouses = append(warehouses, w)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate warehouses: %w", err)
+	}
+
+	if len(warehouses) == 0 {
+		return nil
+	}
+
+	for _, item := range items {
+		stock := map[int]int{}
+		stockRows, err := tx.Query("SELECT warehouse_id, quantity FROM warehouse_stock WHERE size_id = $1 FOR UPDATE", item.sizeID)
+		if err != nil {
+			return fmt.Errorf("failed to lock warehouse stock: %w", err)
+		}
+		for stockRows.Next() {
+			var warehouseID, quantity int
+			if err := stockRows.Scan(&warehouseID, &quantity); err != nil {
+				stockRows.Close()
+				return fmt.Errorf("failed to scan warehouse stock: %w", err)
+			}
+			stock[warehouseID] = quantity
+		}
+		stockRows.Close()
+
+		candidates := make([]warehouseCandidate, len(warehouses))
+		copy(candidates, warehouses)
+		for i := range candidates {
+			candidates[i].available = stock[candidates[i].id]
+		}
+
+		ranked := rankWarehouses(candidates, strategy, country, postalCode)
+		allocations := allocateQuantity(ranked, item.quantity)
+
+		for _, alloc := range allocations {
+			_, err = tx.Exec("INSERT INTO order_item_allocations (order_item_id, warehouse_id, quantity) VALUES ($1, $2, $3)", item.id, alloc.warehouseID, alloc.quantity)
+			if err != nil {
+				return fmt.Errorf("failed to insert allocation: %w", err)
+			}
+
+			_, err = tx.Exec("UPDATE warehouse_stock SET quantity = GREATEST(0, quantity - $1), updated_at = CURRENT_TIMESTAMP WHERE warehouse_id = $2 AND size_id = $3", alloc.quantity, alloc.warehouseID, item.sizeID)
+			if err != nil {
+				return fmt.Errorf("failed to take warehouse stock: %w", err)
+			}
+
+			warehouseID := alloc.warehouseID
+			if err := logStockMovement(tx, item.sizeID, &warehouseID, nil, -alloc.quantity, models.StockMovementOrderAllocation, &orderID, nil, nil); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetPickList returns unpicked allocations of open orders for a warehouse
+func (q *WarehouseQueries) GetPickList(warehouseID int) ([]models.PickListItem, error) {
+	rows, err := q.db.Query(`
+		SELECT a.id, o.id, oi.id, o.status, oi.product_name, oi.variant_name, oi.variant_color_name, oi.size_id, oi.size_name, a.quantity, o.created_at
+		FROM order_item_allocations a
+		JOIN order_items oi ON oi.id = a.order_item_id
+		JOIN orders o ON o.id = oi.order_id
+		WHERE a.warehouse_id = $1 AND a.picked_at IS NULL AND o.status IN ($2, $3)
+		ORDER BY o.created_at, oi.id`,
+		warehouseID, models.OrderStatusPending, models.OrderStatusProcessing,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pick list: %w", err)
+	}
+	defer rows.Close()
+
+	items := []models.PickListItem{}
+	for rows.Next() {
+		var item models.PickListItem
+		err := rows.Scan(&item.AllocationID, &item.OrderID, &item.OrderItemID, &item.OrderStatus, &item.ProductName, &item.VariantName, &item.ColorName, &item.SizeID, &item.SizeName, &item.Quantity, &item.OrderedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan pick list item: %w", err)
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// MarkPicked marks allocations of a warehouse as picked and returns how many were updated
+func (q *WarehouseQueries) MarkPicked(warehouseID int, allocationIDs []int) (int64, error) {
+	result, err := q.db.Exec(
+		"UPDATE order_item_allocations SET picked_at = CURRENT_TIMESTAMP WHERE warehouse_id = $1 AND id = ANY($2) AND picked_at IS NULL",
+		warehouseID, pq.Array(allocationIDs),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark allocations as picked: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// ListStockMovements returns the stock movement log, optionally filtered by size or warehouse
+func (q *WarehouseQueries) ListStockMovements(page, limit int, sizeID, warehouseID *int) (*models.StockMovementListResponse, error) {
+	offset := (page - 1) * limit
+
+	where := "WHERE ($1::int IS NULL OR size_id = $1) AND ($2::int IS NULL OR from_warehouse_id = $2 OR to_warehouse_id = $2)"
+
+	var total int
+	if err := q.db.QueryRow("SELECT COUNT(*) FROM stock_movements "+where, sizeID, warehouseID).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count stock movements: %w", err)
+	}
+
+	rows, err := q.db.Query(`
+		SELECT id, size_id, from_warehouse_id, to_warehouse_id, quantity, type, order_id, note, created_by, created_at
+		FROM stock_movements `+where+`
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3 OFFSET $4`,
+		sizeID, warehouseID, limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stock movements: %w", err)
+	}
+	defer rows.Close()
+
+	movements := []models.StockMovement{}
+	for rows.Next() {
+		var m models.StockMovement
+		if err := rows.Scan(&m.ID, &m.SizeID, &m.FromWarehouseID, &m.ToWarehouseID, &m.Quantity, &m.Type, &m.OrderID, &m.Note, &m.CreatedBy, &m.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan stock movement: %w", err)
+		}
+		movements = append(movements, m)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate stock movements: %w", err)
+	}
+
+	return &models.StockMovementListResponse{
+		Movements: movements,
+		Total:     total,
+		Page:      page,
+		Limit:     limit,
+	}, nil
+}
+
+func upsertWarehouseStock(tx *sql.Tx, warehouseID, sizeID, quantity int) error {
+	_, err := tx.Exec(`
+		INSERT INTO warehouse_stock (warehouse_id, size_id, quantity)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (warehouse_id, size_id) DO UPDATE SET quantity = EXCLUDED.quantity, updated_at = CURRENT_TIMESTAMP`,
+		warehouseID, sizeID, quantity,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update warehouse stock: %w", err)
+	}
+	return nil
+}
+
+func logStockMovement(tx *sql.Tx, sizeID int, from, to *int, quantity int, movementType string, orderID *int, note *string, userID *int) error {
+	_, err := tx.Exec(`
+		INSERT INTO stock_movements (size_id, from_warehouse_id, to_warehouse_id, quantity, type, order_id, note, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		sizeID, from, to, quantity, movementType, orderID, note, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to log stock movement: %w", err)
+	}
+	return nil
+}
+
+// warehouseCandidate is a warehouse considered when allocating a single order item
+type warehouseCandidate struct {
+	id         int
+	priority   int
+	isDefault  bool
+	country    string
+	postalCode string
+	available  int
+}
+
+type warehouseAllocation struct {
+	warehouseID int
+	quantity    int
+}
+
+// rankWarehouses orders candidate warehouses by preference. The priority strategy uses
+// the warehouse priority (lower first). The nearest strategy prefers warehouses in the
+// destination country and then the closest postal code region, falling back to priority.
+func rankWarehouses(candidates []warehouseCandidate, strategy, country, postalCode string) []warehouseCandidate {
+	ranked := make([]warehouseCandidate, len(candidates))
+	copy(ranked, candidates)
+
+	destRegion := postalRegion(postalCode)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if strategy == models.AllocationStrategyNearest {
+			aSame := strings.EqualFold(a.country, country)
+			bSame := strings.EqualFold(b.country, country)
+			if aSame != bSame {
+				return aSame
+			}
+			aDist := regionDistance(postalRegion(a.postalCode), destRegion)
+			bDist := regionDistance(postalRegion(b.postalCode), destRegion)
+			if aDist != bDist {
+				return aDist < bDist
+			}
+		}
+		if a.priority != b.priority {
+			return a.priority < b.priority
+		}
+		if a.isDefault != b.isDefault {
+			return a.isDefault
+		}
+		return a.id < b.id
+	})
+
+	return ranked
+}
+
+// allocateQuantity ships from the best single warehouse that can fulfil the whole
+// quantity, otherwise splits across warehouses in ranked order. Any shortfall is
+// assigned to the best ranked warehouse so the item still appears on a pick list.
+func allocateQuantity(ranked []warehouseCandidate, quantity int) []warehouseAllocation {
+	if len(ranked) == 0 || quantity <= 0 {
+		return nil
+	}
+
+	for _, w := range ranked {
+		if w.available >= quantity {
+			return []warehouseAllocation{{warehouseID: w.id, quantity: quantity}}
+		}
+	}
+
+	var allocations []warehouseAllocation
+	remaining := quantity
+	for _, w := range ranked {
+		if w.available <= 0 {
+			continue
+		}
+		take := w.available
+		if take > remaining {
+			take = remaining
+		}
+		allocations = append(allocations, warehouseAllocation{warehouseID: w.id, quantity: take})
+		remaining -= take
+		if remaining == 0 {
+			return allocations
+		}
+	}
+
+	for i := range allocations {
+		if allocations[i].warehouseID == ranked[0].id {
+			allocations[i].quantity += remaining
+			return allocations
+		}
+	}
+	return append(allocations, warehouseAllocation{warehouseID: ranked[0].id, quantity: remaining})
+}
+
+// postalRegion returns the first two digits of a postal code (Polish postal regions), or -1
+func postalRegion(postalCode string) int {
+	region := 0
+	digits := 0
+	for _, r := range postalCode {
+		if !unicode.IsDigit(r) {
+			continue
+		}
+		region = region*10 + int(r-'0')
+		digits++
+		if digits == 2 {
+			return region
+		}
+	}
+	return -1
+}
+
+func regionDistance(a, b int) int {
+	if a < 0 || b < 0 {
+		return 100
+	}
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
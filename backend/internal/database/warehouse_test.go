@@ -0,0 +1,93 @@
+package database
+
+import (
+	"testing"
+
+	"notsofluffy-backend/internal/models"
+)
+
+func TestRankWarehouses(t *testing.T) {
+	warehouses := []warehouseCandidate{
+		{id: 1, priority: 0, isDefault: true, country: "Poland", postalCode: "00-950"},
+		{id: 2, priority: 1, country: "Poland", postalCode: "80-001"},
+		{id: 3, priority: 2, country: "Germany", postalCode: "10115"},
+	}
+
+	tests := []struct {
+		name       string
+		strategy   string
+		country    string
+		postalCode string
+		want       []int
+	}{
+		{"priority ignores destination", models.AllocationStrategyPriority, "Poland", "81-300", []int{1, 2, 3}},
+		{"nearest prefers closest region", models.AllocationStrategyNearest, "Poland", "81-300", []int{2, 1, 3}},
+		{"nearest prefers same country", models.AllocationStrategyNearest, "Germany", "10117", []int{3, 1, 2}},
+		{"nearest without postal code falls back to priority", models.AllocationStrategyNearest, "Poland", "", []int{1, 2, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ranked := rankWarehouses(warehouses, tt.strategy, tt.country, tt.postalCode)
+			for i, w := range ranked {
+				if w.id != tt.want[i] {
+					t.Fatalf("expected order %v, got warehouse %d at position %d", tt.want, w.id, i)
+				}
+			}
+		})
+	}
+}
+
+func TestAllocateQuantity(t *testing.T) {
+	tests := []struct {
+		name     string
+		ranked   []warehouseCandidate
+		quantity int
+		want     []warehouseAllocation
+	}{
+		{
+			name:     "first warehouse has enough",
+			ranked:   []warehouseCandidate{{id: 1, available: 5}, {id: 2, available: 5}},
+			quantity: 3,
+			want:     []warehouseAllocation{{1, 3}},
+		},
+		{
+			name:     "single warehouse preferred over split",
+			ranked:   []warehouseCandidate{{id: 1, available: 1}, {id: 2, available: 5}},
+			quantity: 3,
+			want:     []warehouseAllocation{{2, 3}},
+		},
+		{
+			name:     "split when no warehouse has enough",
+			ranked:   []warehouseCandidate{{id: 1, available: 2}, {id: 2, available: 0}, {id: 3, available: 2}},
+			quantity: 3,
+			want:     []warehouseAllocation{{1, 2}, {3, 1}},
+		},
+		{
+			name:     "shortfall goes to best ranked warehouse",
+			ranked:   []warehouseCandidate{{id: 1, available: 1}, {id: 2, available: 1}},
+			quantity: 4,
+			want:     []warehouseAllocation{{1, 3}, {2, 1}},
+		},
+		{
+			name:     "no stock anywhere",
+			ranked:   []warehouseCandidate{{id: 1}, {id: 2}},
+			quantity: 2,
+			want:     []warehouseAllocation{{1, 2}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := allocateQuantity(tt.ranked, tt.quantity)
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("expected %v, got %v", tt.want, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,294 @@
+package database
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"notsofluffy-backend/internal/models"
+)
+
+// webhookClaimLease keeps a claimed delivery from being picked up again while it is sent
+const webhookClaimLease = 5 * time.Minute
+
+type WebhookQueries struct {
+	db *sql.DB
+}
+
+func NewWebhookQueries(db *sql.DB) *WebhookQueries {
+	return &WebhookQueries{db: db}
+}
+
+// DueWebhookDelivery is a claimed delivery with what is needed to send it
+type DueWebhookDelivery struct {
+	ID       int
+	Event    string
+	Payload  []byte
+	Attempts int
+	URL      string
+	Secret   string
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random bytes: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+const webhookEndpointColumns = `id, url, description, events, active, created_by, created_at, updated_at`
+
+func scanWebhookEndpoint(row interface{ Scan(...interface{}) error }, e *models.WebhookEndpoint) error {
+	return row.Scan(&e.ID, &e.URL, &e.Description, pq.Array(&e.Events), &e.Active, &e.CreatedBy, &e.CreatedAt, &e.UpdatedAt)
+}
+
+// ListWebhookEndpoints returns all endpoints, oldest first
+func (q *WebhookQueries) ListWebhookEndpoints() ([]models.WebhookEndpoint, error) {
+	rows, err := q.db.Query(`SELECT ` + webhookEndpointColumns + ` FROM webhook_endpoints ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook endpoints: %w", err)
+	}
+	defer rows.Close()
+
+	endpoints := []models.WebhookEndpoint{}
+	for rows.Next() {
+		var e models.WebhookEndpoint
+		if err := scanWebhookEndpoint(rows, &e); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook endpoint: %w", err)
+		}
+		endpoints = append(endpoints, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list webhook endpoints: %w", err)
+	}
+	return endpoints, nil
+}
+
+// CreateWebhookEndpoint registers an endpoint, generating its secret unless one is given
+func (q *WebhookQueries) CreateWebhookEndpoint(req *models.WebhookEndpointRequest, createdBy *int) (*models.WebhookEndpointSecretResponse, error) {
+	secret := req.Secret
+	if secret == "" {
+		generated, err := randomHex(32)
+		if err != nil {
+			return nil, err
+		}
+		secret = "whsec_" + generated
+	}
+	active := req.Active == nil || *req.Active
+
+	created := &models.WebhookEndpointSecretResponse{Secret: secret}
+	err := scanWebhookEndpoint(q.db.QueryRow(`
+		INSERT INTO webhook_endpoints (url, description, secret, events, active, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+webhookEndpointColumns,
+		req.URL, strings.TrimSpace(req.Description), secret, pq.Array(req.Events), active, createdBy,
+	), &created.WebhookEndpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook endpoint: %w", err)
+	}
+	return created, nil
+}
+
+// UpdateWebhookEndpoint changes an endpoint; the secret is only replaced when req sets one
+func (q *WebhookQueries) UpdateWebhookEndpoint(id int, req *models.WebhookEndpointRequest) (*models.WebhookEndpoint, error) {
+	var e models.WebhookEndpoint
+	err := scanWebhookEndpoint(q.db.QueryRow(`
+		UPDATE webhook_endpoints
+		SET url = $1, description = $2, events = $3, active = COALESCE($4, active), secret = COALESCE(NULLIF($5, ''), secret)
+		WHERE id = $6
+		RETURNING `+webhookEndpointColumns,
+		req.URL, strings.TrimSpace(req.Description), pq.Array(req.Events), req.Active, req.Secret, id,
+	), &e)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("webhook endpoint not found")
+		}
+		return nil, fmt.Errorf("failed to update webhook endpoint: %w", err)
+	}
+	return &e, nil
+}
+
+// DeleteWebhookEndpoint removes an endpoint with its delivery log
+func (q *WebhookQueries) DeleteWebhookEndpoint(id int) error {
+	result, err := q.db.Exec(`DELETE FROM webhook_endpoints WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook endpoint: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("webhook endpoint not found")
+	}
+	return nil
+}
+
+// Enqueue queues an event for every active endpoint subscribed to it and returns how
+// many deliveries were queued
+func (q *WebhookQueries) Enqueue(event string, data interface{}) (int, error) {
+	eventID, err := randomHex(16)
+	if err != nil {
+		return 0, err
+	}
+	payload, err := json.Marshal(models.WebhookEvent{ID: eventID, Event: event, CreatedAt: time.Now().UTC(), Data: data})
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode webhook event: %w", err)
+	}
+
+	result, err := q.db.Exec(`
+		INSERT INTO webhook_deliveries (endpoint_id, event, payload, next_attempt_at)
+		SELECT id, $1::varchar, $2::jsonb, CURRENT_TIMESTAMP FROM webhook_endpoints
+		WHERE active AND $1::text = ANY(events)`, event, string(payload))
+	if err != nil {
+		return 0, fmt.Errorf("failed to queue webhook event: %w", err)
+	}
+	queued, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return int(queued), nil
+}
+
+// ClaimDueDeliveries picks up to limit pending deliveries whose next attempt is due and
+// leases them, so concurrent workers don't send the same delivery twice
+func (q *WebhookQueries) ClaimDueDeliveries(limit int) ([]DueWebhookDelivery, error) {
+	rows, err := q.db.Query(`
+		UPDATE webhook_deliveries d
+		SET next_attempt_at = CURRENT_TIMESTAMP + $2::int * INTERVAL '1 second'
+		FROM webhook_endpoints e
+		WHERE e.id = d.endpoint_id AND d.id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= CURRENT_TIMESTAMP
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING d.id, d.event, d.payload, d.attempts, e.url, e.secret`, limit, int(webhookClaimLease.Seconds()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var due []DueWebhookDelivery
+	for rows.Next() {
+		var d DueWebhookDelivery
+		if err := rows.Scan(&d.ID, &d.Event, &d.Payload, &d.Attempts, &d.URL, &d.Secret); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		due = append(due, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	return due, nil
+}
+
+// RecordDeliveryAttempt stores the outcome of an attempt. A failed attempt is retried at
+// retryAt, or the delivery is given up when retryAt is nil.
+func (q *WebhookQueries) RecordDeliveryAttempt(id int, statusCode int, attemptErr error, retryAt *time.Time) error {
+	var code *int
+	if statusCode != 0 {
+		code = &statusCode
+	}
+	var lastError *string
+	status := models.WebhookDeliverySucceeded
+	if attemptErr != nil {
+		msg := attemptErr.Error()
+		lastError = &msg
+		status = models.WebhookDeliveryFailed
+		if retryAt != nil {
+			status = models.WebhookDeliveryPending
+		}
+	}
+
+	_, err := q.db.Exec(`
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = attempts + 1, last_status_code = $2, last_error = $3,
+			last_attempt_at = CURRENT_TIMESTAMP, next_attempt_at = $4,
+			delivered_at = CASE WHEN $1 = 'succeeded' THEN CURRENT_TIMESTAMP ELSE delivered_at END
+		WHERE id = $5`, status, code, lastError, retryAt, id)
+	if err != nil {
+		return fmt.Errorf("failed to record webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// ReplayDelivery queues a delivery to be sent again right away with a fresh set of attempts
+func (q *WebhookQueries) ReplayDelivery(id int) (*models.WebhookDelivery, error) {
+	var d models.WebhookDelivery
+	err := scanWebhookDelivery(q.db.QueryRow(`
+		UPDATE webhook_deliveries
+		SET status = 'pending', attempts = 0, next_attempt_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING `+webhookDeliveryColumns, id), &d)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("webhook delivery not found")
+		}
+		return nil, fmt.Errorf("failed to replay webhook delivery: %w", err)
+	}
+	return &d, nil
+}
+
+const webhookDeliveryColumns = `id, endpoint_id, event, payload, status, attempts, next_attempt_at, last_status_code, last_error, last_attempt_at, delivered_at, created_at`
+
+func scanWebhookDelivery(row interface{ Scan(...interface{}) error }, d *models.WebhookDelivery) error {
+	var payload []byte
+	err := row.Scan(&d.ID, &d.EndpointID, &d.Event, &payload, &d.Status, &d.Attempts, &d.NextAttemptAt,
+		&d.LastStatusCode, &d.LastError, &d.LastAttemptAt, &d.DeliveredAt, &d.CreatedAt)
+	d.Payload = json.RawMessage(payload)
+	return err
+}
+
+// ListDeliveries returns deliveries, newest first, optionally of one endpoint or in one status
+func (q *WebhookQueries) ListDeliveries(endpointID *int, status string, page, limit int) (*models.WebhookDeliveryListResponse, error) {
+	where := []string{"1=1"}
+	args := []interface{}{}
+	if endpointID != nil {
+		args = append(args, *endpointID)
+		where = append(where, fmt.Sprintf("endpoint_id = $%d", len(args)))
+	}
+	if status != "" {
+		args = append(args, status)
+		where = append(where, fmt.Sprintf("status = $%d", len(args)))
+	}
+	condition := strings.Join(where, " AND ")
+
+	var total int
+	if err := q.db.QueryRow(`SELECT COUNT(*) FROM webhook_deliveries WHERE `+condition, args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count webhook deliveries: %w", err)
+	}
+
+	args = append(args, limit, (page-1)*limit)
+	rows, err := q.db.Query(fmt.Sprintf(`
+		SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries
+		WHERE %s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d`, condition, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		var d models.WebhookDelivery
+		if err := scanWebhookDelivery(rows, &d); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+
+	return &models.WebhookDeliveryListResponse{Deliveries: deliveries, Total: total, Page: page, Limit: limit}, nil
+}
@@ -0,0 +1,77 @@
+// Package exchangerates fetches the average exchange rates of the złoty published by
+// Narodowy Bank Polski (NBP).
+package exchangerates
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// NBPURL is the NBP web API
+const NBPURL = "https://api.nbp.pl/api"
+
+// Table is one day's rates, by currency code, in złoty per unit
+type Table struct {
+	EffectiveDate string
+	Rates         map[string]float64
+}
+
+// NBPClient reads the current table A of average rates, which covers the major
+// currencies and is published every working day
+type NBPClient struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewNBPClient creates a client of the API at baseURL, normally NBPURL
+func NewNBPClient(baseURL string, timeout time.Duration) *NBPClient {
+	return &NBPClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &http.Client{Timeout: timeout},
+	}
+}
+
+// Rates returns the latest published table
+func (c *NBPClient) Rates(ctx context.Context) (*Table, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/exchangerates/tables/A/?format=json", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create NBP request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call NBP: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("NBP returned status %d", resp.StatusCode)
+	}
+
+	var tables []struct {
+		EffectiveDate string `json:"effectiveDate"`
+		Rates         []struct {
+			Code string  `json:"code"`
+			Mid  float64 `json:"mid"`
+		} `json:"rates"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tables); err != nil {
+		return nil, fmt.Errorf("failed to decode NBP response: %w", err)
+	}
+	if len(tables) == 0 {
+		return nil, fmt.Errorf("NBP returned no table")
+	}
+
+	table := &Table{EffectiveDate: tables[0].EffectiveDate, Rates: map[string]float64{}}
+	for _, rate := range tables[0].Rates {
+		if rate.Mid > 0 {
+			table.Rates[strings.ToUpper(rate.Code)] = rate.Mid
+		}
+	}
+	return table, nil
+}
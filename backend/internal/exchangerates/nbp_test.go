@@ -0,0 +1,55 @@
+package exchangerates
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNBPClientRates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/exchangerates/tables/A/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`[{"table":"A","no":"199/A/NBP/2026","effectiveDate":"2026-10-14","rates":[
+			{"currency":"euro","code":"EUR","mid":4.2618},
+			{"currency":"dolar amerykański","code":"USD","mid":3.6542},
+			{"currency":"broken","code":"XXX","mid":0}]}]`))
+	}))
+	defer server.Close()
+
+	table, err := NewNBPClient(server.URL+"/", time.Second).Rates(context.Background())
+	if err != nil {
+		t.Fatalf("Rates: %v", err)
+	}
+	if table.EffectiveDate != "2026-10-14" || table.Rates["EUR"] != 4.2618 || table.Rates["USD"] != 3.6542 {
+		t.Fatalf("unexpected table %+v", table)
+	}
+	if _, ok := table.Rates["XXX"]; ok {
+		t.Fatal("expected rates of zero to be left out")
+	}
+}
+
+func TestNBPClientErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("format") != "json" {
+			t.Errorf("expected a JSON request, got %s", r.URL)
+		}
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	if _, err := NewNBPClient(server.URL, time.Second).Rates(context.Background()); err == nil {
+		t.Fatal("expected an error for an empty response")
+	}
+
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	if _, err := NewNBPClient(server.URL, time.Second).Rates(context.Background()); err == nil {
+		t.Fatal("expected an error for a failed request")
+	}
+}
@@ -0,0 +1,190 @@
+// Package exports writes the CSV files of the admin export center and the order export
+// for accounting. Rows are read from the database and written one at a time, so exports
+// of any size run in constant memory.
+package exports
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"notsofluffy-backend/internal/csvimport"
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/models"
+	"notsofluffy-backend/internal/xlsx"
+)
+
+var (
+	orderHeader = []string{"id", "created_at", "status", "payment_status", "payment_method", "email", "phone",
+		"first_name", "last_name", "company", "city", "country", "nip", "item_count",
+		"subtotal", "discount_amount", "shipping_cost", "total_amount"}
+	productFeedHeader = []string{"product_id", "name", "slug", "category", "material", "size_id", "size",
+		"price", "use_stock", "available_quantity"}
+	customerHeader = []string{"id", "email", "first_name", "last_name", "phone", "created_at",
+		"order_count", "lifetime_value", "last_order_at"}
+	orderLineHeader = []string{"order_id", "created_at", "status", "payment_status", "payment_method", "email", "phone",
+		"requires_invoice", "nip", "discount_code", "discount_description", "discount_amount",
+		"subtotal", "shipping_cost", "total_amount",
+		"shipping_first_name", "shipping_last_name", "shipping_company", "shipping_address_line1", "shipping_address_line2",
+		"shipping_postal_code", "shipping_city", "shipping_country",
+		"billing_first_name", "billing_last_name", "billing_company", "billing_address_line1", "billing_address_line2",
+		"billing_postal_code", "billing_city", "billing_country",
+		"product_id", "product_name", "variant", "color", "size", "quantity", "unit_price", "total_price"}
+	// orderLineNumeric are the columns of orderLineHeader written as numbers in spreadsheets
+	orderLineNumeric = []int{0, 11, 12, 13, 14, 31, 36, 37, 38}
+)
+
+// recordWriter writes an export a row at a time; csv.Writer and xlsx.Writer both are one
+type recordWriter interface {
+	Write(record []string) error
+	Flush()
+	Error() error
+}
+
+// FileName returns the name an export is downloaded as
+func FileName(export *models.Export) string {
+	return fmt.Sprintf("%s-%d-%s.csv", export.Type, export.ID, export.CreatedAt.Format("2006-01-02"))
+}
+
+// Write writes an export of the given type as CSV and returns the number of data rows.
+// Times are written in loc.
+func Write(w io.Writer, queries *database.ExportQueries, exportType string, params models.ExportParams, loc *time.Location) (int, error) {
+	switch exportType {
+	case models.ExportTypeOrders:
+		return WriteOrders(w, queries, params, loc)
+	case models.ExportTypeProductFeed:
+		return writeProductFeed(w, queries, params.Channel)
+	case models.ExportTypeCustomers:
+		return writeCustomers(w, queries, loc)
+	}
+	return 0, fmt.Errorf("unknown export type: %s", exportType)
+}
+
+// WriteOrders writes the orders matching params as CSV and returns their number
+func WriteOrders(w io.Writer, queries *database.ExportQueries, params models.ExportParams, loc *time.Location) (int, error) {
+	writer, err := csvimport.NewWriter(w, orderHeader)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	err = queries.EachOrderExportRow(params, func(o *models.OrderExportRow) error {
+		count++
+		return writer.Write([]string{
+			strconv.Itoa(o.ID), csvimport.FormatTime(o.CreatedAt, loc), o.Status, o.PaymentStatus, optional(o.PaymentMethod),
+			o.Email, o.Phone, o.FirstName, o.LastName, optional(o.Company), o.City, o.Country, optional(o.NIP),
+			strconv.Itoa(o.ItemCount), csvimport.FormatFloat(o.Subtotal), csvimport.FormatFloat(o.DiscountAmount),
+			csvimport.FormatFloat(o.ShippingCost), csvimport.FormatFloat(o.TotalAmount),
+		})
+	})
+	return finish(writer, count, err)
+}
+
+func writeProductFeed(w io.Writer, queries *database.ExportQueries, channel string) (int, error) {
+	writer, err := csvimport.NewWriter(w, productFeedHeader)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	err = queries.EachProductFeedRow(channel, func(p *models.ProductFeedRow) error {
+		count++
+		return writer.Write([]string{
+			strconv.Itoa(p.ProductID), p.ProductName, p.Slug, optional(p.Category), optional(p.Material),
+			strconv.Itoa(p.SizeID), p.SizeName, csvimport.FormatFloat(p.Price), csvimport.FormatBool(p.UseStock),
+			strconv.Itoa(p.AvailableQuantity),
+		})
+	})
+	return finish(writer, count, err)
+}
+
+func writeCustomers(w io.Writer, queries *database.ExportQueries, loc *time.Location) (int, error) {
+	writer, err := csvimport.NewWriter(w, customerHeader)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	err = queries.EachCustomerExportRow(func(u *models.CustomerExportRow) error {
+		count++
+		lastOrderAt := ""
+		if u.LastOrderAt != nil {
+			lastOrderAt = csvimport.FormatTime(*u.LastOrderAt, loc)
+		}
+		return writer.Write([]string{
+			strconv.Itoa(u.ID), u.Email, optional(u.FirstName), optional(u.LastName), optional(u.Phone),
+			csvimport.FormatTime(u.CreatedAt, loc), strconv.Itoa(u.OrderCount), csvimport.FormatFloat(u.LifetimeValue),
+			lastOrderAt,
+		})
+	})
+	return finish(writer, count, err)
+}
+
+// WriteOrderLines writes the items of the orders matching params, one row each with
+// their order's addresses and discount, as CSV or XLSX, and returns the number of rows
+func WriteOrderLines(w io.Writer, format string, queries *database.ExportQueries, params models.ExportParams, loc *time.Location) (int, error) {
+	var writer recordWriter
+	var sheet *xlsx.Writer
+	var err error
+	switch format {
+	case models.ExportFormatCSV:
+		writer, err = csvimport.NewWriter(w, orderLineHeader)
+	case models.ExportFormatXLSX:
+		sheet, err = xlsx.NewWriter(w, "Orders", orderLineHeader, orderLineNumeric...)
+		writer = sheet
+	default:
+		return 0, fmt.Errorf("unknown export format: %s", format)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = queries.EachOrderLineExportRow(params, func(o *models.OrderLineExportRow) error {
+		count++
+		record := []string{
+			strconv.Itoa(o.OrderID), csvimport.FormatTime(o.CreatedAt, loc), o.Status, o.PaymentStatus, optional(o.PaymentMethod),
+			o.Email, o.Phone, csvimport.FormatBool(o.RequiresInvoice), optional(o.NIP), optional(o.DiscountCode),
+			optional(o.DiscountDescription), csvimport.FormatFloat(o.DiscountAmount),
+			csvimport.FormatFloat(o.Subtotal), csvimport.FormatFloat(o.ShippingCost), csvimport.FormatFloat(o.TotalAmount),
+		}
+		record = append(record, addressRecord(o.Shipping)...)
+		record = append(record, addressRecord(o.Billing)...)
+		return writer.Write(append(record,
+			csvimport.FormatOptionalInt(o.ProductID), optional(o.ProductName), optional(o.VariantName), optional(o.ColorName),
+			optional(o.SizeName), csvimport.FormatOptionalInt(o.Quantity), optionalFloat(o.UnitPrice), optionalFloat(o.TotalPrice),
+		))
+	})
+	count, err = finish(writer, count, err)
+	if sheet != nil && err == nil {
+		err = sheet.Close()
+	}
+	return count, err
+}
+
+func addressRecord(a models.ExportAddress) []string {
+	return []string{
+		optional(a.FirstName), optional(a.LastName), optional(a.Company), optional(a.AddressLine1),
+		optional(a.AddressLine2), optional(a.PostalCode), optional(a.City), optional(a.Country),
+	}
+}
+
+func finish(writer recordWriter, count int, err error) (int, error) {
+	writer.Flush()
+	if err != nil {
+		return count, err
+	}
+	return count, writer.Error()
+}
+
+func optional(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func optionalFloat(f *float64) string {
+	if f == nil {
+		return ""
+	}
+	return csvimport.FormatFloat(*f)
+}
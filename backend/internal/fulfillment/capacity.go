@@ -0,0 +1,71 @@
+package fulfillment
+
+import (
+	"math"
+	"time"
+)
+
+// Day is a production day: how many made-to-order items the workshop can make on it
+// and how many are already booked. Blocked days, such as holidays, take no bookings.
+type Day struct {
+	Date     time.Time
+	Capacity int
+	Booked   int
+	Blocked  bool
+}
+
+// Remaining returns the items that can still be booked on the day
+func (d Day) Remaining() int {
+	if d.Blocked || d.Booked >= d.Capacity {
+		return 0
+	}
+	return d.Capacity - d.Booked
+}
+
+// Slot is the share of an order's made-to-order items booked on one day
+type Slot struct {
+	Date  time.Time
+	Items int
+}
+
+// MadeToOrderUnits counts the made-to-order items that have to be made
+func MadeToOrderUnits(items []Item) int {
+	units := 0
+	for _, item := range items {
+		if item.MadeToOrder {
+			units += item.Quantity
+		}
+	}
+	return units
+}
+
+// Book spreads units over the earliest days with capacity left, in the order of days.
+// It returns false when the days run out before all units are booked.
+func Book(days []Day, units int) ([]Slot, bool) {
+	slots := []Slot{}
+	for _, day := range days {
+		if units <= 0 {
+			break
+		}
+		remaining := day.Remaining()
+		if remaining == 0 {
+			continue
+		}
+		if remaining > units {
+			remaining = units
+		}
+		slots = append(slots, Slot{Date: day.Date, Items: remaining})
+		units -= remaining
+	}
+	return slots, units <= 0
+}
+
+// BookedLeadDays returns the days from today until the booked items can be dispatched,
+// the day after the last of them is made
+func BookedLeadDays(today time.Time, slots []Slot) int {
+	if len(slots) == 0 {
+		return 0
+	}
+	last := slots[len(slots)-1].Date
+	return int(math.Round(last.Sub(today).Hours()/24)) + 1
+}
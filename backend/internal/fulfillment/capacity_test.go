@@ -0,0 +1,57 @@
+package fulfillment
+
+import (
+	"testing"
+	"time"
+)
+
+func day(n int) time.Time {
+	return time.Date(2024, 12, 23, 0, 0, 0, 0, time.UTC).AddDate(0, 0, n)
+}
+
+func TestBookSpreadsOverDays(t *testing.T) {
+	days := []Day{
+		{Date: day(0), Capacity: 5, Booked: 4},
+		{Date: day(1), Capacity: 5, Blocked: true},
+		{Date: day(2), Capacity: 5, Booked: 5},
+		{Date: day(3), Capacity: 5, Booked: 1},
+		{Date: day(4), Capacity: 5},
+	}
+
+	slots, ok := Book(days, 6)
+	if !ok {
+		t.Fatal("expected the units to fit")
+	}
+	if len(slots) != 3 || slots[0].Items != 1 || slots[1].Items != 4 || slots[2].Items != 1 {
+		t.Fatalf("unexpected slots %+v", slots)
+	}
+	if !slots[1].Date.Equal(day(3)) || !slots[2].Date.Equal(day(4)) {
+		t.Fatalf("blocked and full days should be skipped, got %+v", slots)
+	}
+	if leadDays := BookedLeadDays(day(0), slots); leadDays != 5 {
+		t.Fatalf("expected 5 lead days, got %d", leadDays)
+	}
+}
+
+func TestBookRunsOutOfDays(t *testing.T) {
+	days := []Day{{Date: day(0), Capacity: 2}, {Date: day(1), Capacity: 2, Booked: 3}}
+	if _, ok := Book(days, 3); ok {
+		t.Fatal("expected booking to fail without enough capacity")
+	}
+	if slots, ok := Book(days, 0); !ok || len(slots) != 0 {
+		t.Fatalf("booking nothing should take no slots, got %+v", slots)
+	}
+}
+
+func TestProductionLeadDaysExtendMadeToOrder(t *testing.T) {
+	booked := Settings{InStockDispatchDays: 2, MadeToOrderLeadDays: 14, ProductionLeadDays: 20}
+	if days := ItemLeadDays(Item{MadeToOrder: true}, booked); days != 20 {
+		t.Fatalf("expected 20 days, got %d", days)
+	}
+	if days := ItemLeadDays(Item{}, booked); days != 2 {
+		t.Fatalf("in-stock items should not wait for production, got %d", days)
+	}
+	if units := MadeToOrderUnits([]Item{{Quantity: 2, MadeToOrder: true}, {Quantity: 3}}); units != 2 {
+		t.Fatalf("expected 2 units, got %d", units)
+	}
+}
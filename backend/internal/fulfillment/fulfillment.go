@@ -0,0 +1,97 @@
+// Package fulfillment works out when the items of a cart can ship: in-stock items are
+// dispatched within a few days, made-to-order ones once they have been made.
+package fulfillment
+
+import (
+	"fmt"
+
+	"notsofluffy-backend/internal/models"
+)
+
+// Item is a cart item as far as shipping times go. LeadTimeDays is only read for
+// made-to-order items; zero means the shop default.
+type Item struct {
+	ID           int
+	Quantity     int
+	MadeToOrder  bool
+	LeadTimeDays int
+}
+
+// Settings are the shop's shipping times in days. ProductionLeadDays is set when the
+// production capacity is booked out further than the lead times; made-to-order items
+// can't be dispatched before it.
+type Settings struct {
+	InStockDispatchDays int
+	MadeToOrderLeadDays int
+	ProductionLeadDays  int
+}
+
+// ItemLeadDays returns the days until an item can be dispatched
+func ItemLeadDays(item Item, settings Settings) int {
+	if !item.MadeToOrder {
+		return settings.InStockDispatchDays
+	}
+	days := settings.MadeToOrderLeadDays
+	if item.LeadTimeDays > 0 {
+		days = item.LeadTimeDays
+	}
+	if settings.ProductionLeadDays > days {
+		days = settings.ProductionLeadDays
+	}
+	return days
+}
+
+// LeadDays returns the days until all items can be dispatched together
+func LeadDays(items []Item, settings Settings) int {
+	days := 0
+	for _, item := range items {
+		if itemDays := ItemLeadDays(item, settings); itemDays > days {
+			days = itemDays
+		}
+	}
+	return days
+}
+
+// Mixed reports whether items contain both in-stock and made-to-order items, the only
+// case where a split shipment makes a difference
+func Mixed(items []Item) bool {
+	var inStock, madeToOrder bool
+	for _, item := range items {
+		if item.MadeToOrder {
+			madeToOrder = true
+		} else {
+			inStock = true
+		}
+	}
+	return inStock && madeToOrder
+}
+
+// Notice returns the notice for a cart mixing in-stock and made-to-order items, or nil
+// when all items ship on the same schedule
+func Notice(items []Item, settings Settings) *models.FulfillmentNotice {
+	if !Mixed(items) {
+		return nil
+	}
+
+	notice := &models.FulfillmentNotice{
+		Code:                   models.FulfillmentNoticeMixedStock,
+		InStockItemIDs:         []int{},
+		MadeToOrderItemIDs:     []int{},
+		InStockDispatchDays:    settings.InStockDispatchDays,
+		CombinedLeadDays:       LeadDays(items, settings),
+		SplitShipmentAvailable: true,
+	}
+	for _, item := range items {
+		if !item.MadeToOrder {
+			notice.InStockItemIDs = append(notice.InStockItemIDs, item.ID)
+			continue
+		}
+		notice.MadeToOrderItemIDs = append(notice.MadeToOrderItemIDs, item.ID)
+		if days := ItemLeadDays(item, settings); days > notice.MadeToOrderLeadDays {
+			notice.MadeToOrderLeadDays = days
+		}
+	}
+	notice.Message = fmt.Sprintf("Some items are made to order. Shipped together, your order leaves in about %d days; "+
+		"with a split shipment the items in stock leave within %d days.", notice.CombinedLeadDays, notice.InStockDispatchDays)
+	return notice
+}
@@ -0,0 +1,45 @@
+package fulfillment
+
+import "testing"
+
+var settings = Settings{InStockDispatchDays: 2, MadeToOrderLeadDays: 14}
+
+func TestNoticeMixedCart(t *testing.T) {
+	items := []Item{
+		{ID: 1},
+		{ID: 2, MadeToOrder: true},
+		{ID: 3, MadeToOrder: true, LeadTimeDays: 21},
+	}
+
+	notice := Notice(items, settings)
+	if notice == nil {
+		t.Fatal("expected a notice for a mixed cart")
+	}
+	if notice.CombinedLeadDays != 21 || notice.MadeToOrderLeadDays != 21 || notice.InStockDispatchDays != 2 {
+		t.Fatalf("unexpected lead times %+v", notice)
+	}
+	if len(notice.InStockItemIDs) != 1 || len(notice.MadeToOrderItemIDs) != 2 || !notice.SplitShipmentAvailable {
+		t.Fatalf("unexpected item split %+v", notice)
+	}
+}
+
+func TestNoticeSingleSchedule(t *testing.T) {
+	if Notice([]Item{{ID: 1}, {ID: 2}}, settings) != nil {
+		t.Fatal("in-stock cart should have no notice")
+	}
+	if Notice([]Item{{ID: 1, MadeToOrder: true}}, settings) != nil {
+		t.Fatal("made-to-order cart should have no notice")
+	}
+	if Notice(nil, settings) != nil {
+		t.Fatal("empty cart should have no notice")
+	}
+}
+
+func TestLeadDays(t *testing.T) {
+	if days := LeadDays([]Item{{ID: 1}}, settings); days != 2 {
+		t.Fatalf("expected 2 days, got %d", days)
+	}
+	if days := LeadDays([]Item{{ID: 1}, {ID: 2, MadeToOrder: true}}, settings); days != 14 {
+		t.Fatalf("expected the shop default of 14 days, got %d", days)
+	}
+}
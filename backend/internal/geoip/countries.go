@@ -0,0 +1,178 @@
+package geoip
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Region is a province, state or other first level subdivision of a country
+type Region struct {
+	// Code is the subdivision part of its ISO 3166-2 code, e.g. "BY" of DE-BY
+	Code string
+	Name string
+}
+
+// Country is the address reference data of a country the shop knows
+type Country struct {
+	Code string
+	Name string
+	// RegionLabel names the country's subdivisions, e.g. "State"; RegionRequired is set
+	// when addresses aren't deliverable without one
+	RegionLabel    string
+	RegionRequired bool
+	Regions        []Region
+	// PostalCodeFormat shows the format, with 9 for a digit and A for a letter
+	PostalCodeFormat string
+	postalCode       *regexp.Regexp
+}
+
+// ValidPostalCode reports whether a postal code has the country's format, in any case
+func (c Country) ValidPostalCode(code string) bool {
+	return c.postalCode == nil || c.postalCode.MatchString(strings.ToUpper(strings.TrimSpace(code)))
+}
+
+// PostalCodePattern returns the regular expression postal codes match, upper cased
+func (c Country) PostalCodePattern() string {
+	if c.postalCode == nil {
+		return ""
+	}
+	return c.postalCode.String()
+}
+
+// countries holds the reference data of the countries in countryDefaults. Region names
+// are written as on addresses in the country.
+var countries = map[string]Country{
+	"PL": {
+		Code: "PL", Name: "Poland", RegionLabel: "Voivodeship",
+		PostalCodeFormat: "99-999", postalCode: regexp.MustCompile(`^\d{2}-\d{3}$`),
+		Regions: []Region{
+			{"02", "Dolnośląskie"}, {"04", "Kujawsko-pomorskie"}, {"06", "Lubelskie"}, {"08", "Lubuskie"},
+			{"10", "Łódzkie"}, {"12", "Małopolskie"}, {"14", "Mazowieckie"}, {"16", "Opolskie"},
+			{"18", "Podkarpackie"}, {"20", "Podlaskie"}, {"22", "Pomorskie"}, {"24", "Śląskie"},
+			{"26", "Świętokrzyskie"}, {"28", "Warmińsko-mazurskie"}, {"30", "Wielkopolskie"}, {"32", "Zachodniopomorskie"},
+		},
+	},
+	"DE": {
+		Code: "DE", Name: "Germany", RegionLabel: "State",
+		PostalCodeFormat: "99999", postalCode: regexp.MustCompile(`^\d{5}$`),
+		Regions: []Region{
+			{"BW", "Baden-Württemberg"}, {"BY", "Bayern"}, {"BE", "Berlin"}, {"BB", "Brandenburg"},
+			{"HB", "Bremen"}, {"HH", "Hamburg"}, {"HE", "Hessen"}, {"MV", "Mecklenburg-Vorpommern"},
+			{"NI", "Niedersachsen"}, {"NW", "Nordrhein-Westfalen"}, {"RP", "Rheinland-Pfalz"}, {"SL", "Saarland"},
+			{"SN", "Sachsen"}, {"ST", "Sachsen-Anhalt"}, {"SH", "Schleswig-Holstein"}, {"TH", "Thüringen"},
+		},
+	},
+	"AT": {
+		Code: "AT", Name: "Austria", RegionLabel: "State",
+		PostalCodeFormat: "9999", postalCode: regexp.MustCompile(`^\d{4}$`),
+		Regions: []Region{
+			{"1", "Burgenland"}, {"2", "Kärnten"}, {"3", "Niederösterreich"}, {"4", "Oberösterreich"},
+			{"5", "Salzburg"}, {"6", "Steiermark"}, {"7", "Tirol"}, {"8", "Vorarlberg"}, {"9", "Wien"},
+		},
+	},
+	"CZ": {
+		Code: "CZ", Name: "Czech Republic", RegionLabel: "Region",
+		PostalCodeFormat: "999 99", postalCode: regexp.MustCompile(`^\d{3} ?\d{2}$`),
+		Regions: []Region{
+			{"10", "Praha"}, {"20", "Středočeský kraj"}, {"31", "Jihočeský kraj"}, {"32", "Plzeňský kraj"},
+			{"41", "Karlovarský kraj"}, {"42", "Ústecký kraj"}, {"51", "Liberecký kraj"}, {"52", "Královéhradecký kraj"},
+			{"53", "Pardubický kraj"}, {"63", "Kraj Vysočina"}, {"64", "Jihomoravský kraj"}, {"71", "Olomoucký kraj"},
+			{"72", "Zlínský kraj"}, {"80", "Moravskoslezský kraj"},
+		},
+	},
+	"SK": {
+		Code: "SK", Name: "Slovakia", RegionLabel: "Region",
+		PostalCodeFormat: "999 99", postalCode: regexp.MustCompile(`^\d{3} ?\d{2}$`),
+		Regions: []Region{
+			{"BC", "Banskobystrický kraj"}, {"BL", "Bratislavský kraj"}, {"KI", "Košický kraj"}, {"NI", "Nitriansky kraj"},
+			{"PV", "Prešovský kraj"}, {"TA", "Trnavský kraj"}, {"TC", "Trenčiansky kraj"}, {"ZI", "Žilinský kraj"},
+		},
+	},
+	"LT": {
+		Code: "LT", Name: "Lithuania", RegionLabel: "County",
+		PostalCodeFormat: "LT-99999", postalCode: regexp.MustCompile(`^(LT-)?\d{5}$`),
+		Regions: []Region{
+			{"AL", "Alytaus apskritis"}, {"KU", "Kauno apskritis"}, {"KL", "Klaipėdos apskritis"}, {"MR", "Marijampolės apskritis"},
+			{"PN", "Panevėžio apskritis"}, {"SA", "Šiaulių apskritis"}, {"TA", "Tauragės apskritis"}, {"TE", "Telšių apskritis"},
+			{"UT", "Utenos apskritis"}, {"VL", "Vilniaus apskritis"},
+		},
+	},
+	"FR": {
+		Code: "FR", Name: "France", RegionLabel: "Region",
+		PostalCodeFormat: "99999", postalCode: regexp.MustCompile(`^\d{5}$`),
+		Regions: []Region{
+			{"ARA", "Auvergne-Rhône-Alpes"}, {"BFC", "Bourgogne-Franche-Comté"}, {"BRE", "Bretagne"},
+			{"CVL", "Centre-Val de Loire"}, {"20R", "Corse"}, {"GES", "Grand Est"}, {"HDF", "Hauts-de-France"},
+			{"IDF", "Île-de-France"}, {"NOR", "Normandie"}, {"NAQ", "Nouvelle-Aquitaine"}, {"OCC", "Occitanie"},
+			{"PDL", "Pays de la Loire"}, {"PAC", "Provence-Alpes-Côte d'Azur"},
+		},
+	},
+	"NL": {
+		Code: "NL", Name: "Netherlands", RegionLabel: "Province",
+		PostalCodeFormat: "9999 AA", postalCode: regexp.MustCompile(`^\d{4} ?[A-Z]{2}$`),
+		Regions: []Region{
+			{"DR", "Drenthe"}, {"FL", "Flevoland"}, {"FR", "Fryslân"}, {"GE", "Gelderland"},
+			{"GR", "Groningen"}, {"LI", "Limburg"}, {"NB", "Noord-Brabant"}, {"NH", "Noord-Holland"},
+			{"OV", "Overijssel"}, {"UT", "Utrecht"}, {"ZE", "Zeeland"}, {"ZH", "Zuid-Holland"},
+		},
+	},
+	"IE": {
+		Code: "IE", Name: "Ireland", RegionLabel: "County",
+		PostalCodeFormat: "A99 AAAA", postalCode: regexp.MustCompile(`^[A-Z]\d[\dW] ?[\dA-Z]{4}$`),
+		Regions: []Region{
+			{"CW", "Carlow"}, {"CN", "Cavan"}, {"CE", "Clare"}, {"CO", "Cork"}, {"DL", "Donegal"},
+			{"D", "Dublin"}, {"G", "Galway"}, {"KY", "Kerry"}, {"KE", "Kildare"}, {"KK", "Kilkenny"},
+			{"LS", "Laois"}, {"LM", "Leitrim"}, {"LK", "Limerick"}, {"LD", "Longford"}, {"LH", "Louth"},
+			{"MO", "Mayo"}, {"MH", "Meath"}, {"MN", "Monaghan"}, {"OY", "Offaly"}, {"RN", "Roscommon"},
+			{"SO", "Sligo"}, {"TA", "Tipperary"}, {"WD", "Waterford"}, {"WH", "Westmeath"}, {"WX", "Wexford"},
+			{"WW", "Wicklow"},
+		},
+	},
+	"GB": {
+		Code: "GB", Name: "United Kingdom", RegionLabel: "Nation",
+		PostalCodeFormat: "AA9A 9AA", postalCode: regexp.MustCompile(`^[A-Z]{1,2}\d[A-Z\d]? ?\d[A-Z]{2}$`),
+		Regions: []Region{
+			{"ENG", "England"}, {"NIR", "Northern Ireland"}, {"SCT", "Scotland"}, {"WLS", "Wales"},
+		},
+	},
+	"US": {
+		Code: "US", Name: "United States", RegionLabel: "State", RegionRequired: true,
+		PostalCodeFormat: "99999", postalCode: regexp.MustCompile(`^\d{5}(-\d{4})?$`),
+		Regions: []Region{
+			{"AL", "Alabama"}, {"AK", "Alaska"}, {"AZ", "Arizona"}, {"AR", "Arkansas"}, {"CA", "California"},
+			{"CO", "Colorado"}, {"CT", "Connecticut"}, {"DE", "Delaware"}, {"DC", "District of Columbia"},
+			{"FL", "Florida"}, {"GA", "Georgia"}, {"HI", "Hawaii"}, {"ID", "Idaho"}, {"IL", "Illinois"},
+			{"IN", "Indiana"}, {"IA", "Iowa"}, {"KS", "Kansas"}, {"KY", "Kentucky"}, {"LA", "Louisiana"},
+			{"ME", "Maine"}, {"MD", "Maryland"}, {"MA", "Massachusetts"}, {"MI", "Michigan"}, {"MN", "Minnesota"},
+			{"MS", "Mississippi"}, {"MO", "Missouri"}, {"MT", "Montana"}, {"NE", "Nebraska"}, {"NV", "Nevada"},
+			{"NH", "New Hampshire"}, {"NJ", "New Jersey"}, {"NM", "New Mexico"}, {"NY", "New York"},
+			{"NC", "North Carolina"}, {"ND", "North Dakota"}, {"OH", "Ohio"}, {"OK", "Oklahoma"}, {"OR", "Oregon"},
+			{"PA", "Pennsylvania"}, {"RI", "Rhode Island"}, {"SC", "South Carolina"}, {"SD", "South Dakota"},
+			{"TN", "Tennessee"}, {"TX", "Texas"}, {"UT", "Utah"}, {"VT", "Vermont"}, {"VA", "Virginia"},
+			{"WA", "Washington"}, {"WV", "West Virginia"}, {"WI", "Wisconsin"}, {"WY", "Wyoming"},
+		},
+	},
+}
+
+// Countries returns the reference data of the known countries, the home country first
+// and the others by name
+func Countries() []Country {
+	result := make([]Country, 0, len(countries))
+	for _, country := range countries {
+		result = append(result, country)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if (result[i].Code == HomeCountry.Code) != (result[j].Code == HomeCountry.Code) {
+			return result[i].Code == HomeCountry.Code
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// LookupCountry returns the reference data of a country by code
+func LookupCountry(code string) (Country, bool) {
+	country, ok := countries[normalizeCountryCode(code)]
+	return country, ok
+}
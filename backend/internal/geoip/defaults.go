@@ -0,0 +1,59 @@
+package geoip
+
+import "strings"
+
+// CountryDefaults are the storefront defaults pre-selected for visitors from a country
+type CountryDefaults struct {
+	Code     string
+	Name     string
+	Currency string
+	Language string
+}
+
+// HomeCountry is used when the visitor country is unknown or not listed
+var HomeCountry = CountryDefaults{Code: "PL", Name: "Poland", Currency: "PLN", Language: "pl"}
+
+// countryDefaults lists the countries the shop ships to most often. Names match the
+// country values stored with shipping addresses.
+var countryDefaults = map[string]CountryDefaults{
+	"PL": HomeCountry,
+	"DE": {Code: "DE", Name: "Germany", Currency: "EUR", Language: "de"},
+	"AT": {Code: "AT", Name: "Austria", Currency: "EUR", Language: "de"},
+	"CZ": {Code: "CZ", Name: "Czech Republic", Currency: "CZK", Language: "cs"},
+	"SK": {Code: "SK", Name: "Slovakia", Currency: "EUR", Language: "sk"},
+	"LT": {Code: "LT", Name: "Lithuania", Currency: "EUR", Language: "lt"},
+	"FR": {Code: "FR", Name: "France", Currency: "EUR", Language: "fr"},
+	"NL": {Code: "NL", Name: "Netherlands", Currency: "EUR", Language: "nl"},
+	"IE": {Code: "IE", Name: "Ireland", Currency: "EUR", Language: "en"},
+	"GB": {Code: "GB", Name: "United Kingdom", Currency: "GBP", Language: "en"},
+	"US": {Code: "US", Name: "United States", Currency: "USD", Language: "en"},
+}
+
+// DefaultsFor returns the defaults for a country code. Unknown countries get the
+// home country for shipping and currency, with English as the language.
+func DefaultsFor(code string) CountryDefaults {
+	if defaults, ok := countryDefaults[normalizeCountryCode(code)]; ok {
+		return defaults
+	}
+	if code == "" {
+		return HomeCountry
+	}
+	defaults := HomeCountry
+	defaults.Language = "en"
+	return defaults
+}
+
+// CountryCode returns the code of a listed country given as stored with addresses,
+// by code or by name in any case, or "" when it isn't listed
+func CountryCode(country string) string {
+	if defaults, ok := countryDefaults[normalizeCountryCode(country)]; ok {
+		return defaults.Code
+	}
+	country = strings.TrimSpace(country)
+	for _, defaults := range countryDefaults {
+		if strings.EqualFold(defaults.Name, country) {
+			return defaults.Code
+		}
+	}
+	return ""
+}
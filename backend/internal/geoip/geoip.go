@@ -0,0 +1,139 @@
+package geoip
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Location is the result of an IP lookup
+type Location struct {
+	// CountryCode is the ISO 3166-1 alpha-2 code, e.g. "PL"
+	CountryCode string
+}
+
+// Provider is implemented by every IP geolocation backend
+type Provider interface {
+	// Lookup returns the location of a public IP address, or nil when unknown
+	Lookup(ctx context.Context, ip string) (*Location, error)
+}
+
+// NoopProvider is used when no geolocation backend is configured
+type NoopProvider struct{}
+
+// Lookup never finds a location
+func (NoopProvider) Lookup(ctx context.Context, ip string) (*Location, error) {
+	return nil, nil
+}
+
+// CachedProvider remembers lookups so repeat visitors don't hit the backend
+type CachedProvider struct {
+	provider Provider
+	ttl      time.Duration
+	maxSize  int
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	location  *Location
+	expiresAt time.Time
+}
+
+// NewCachedProvider wraps a provider with an in-memory cache
+func NewCachedProvider(provider Provider, ttl time.Duration, maxSize int) *CachedProvider {
+	return &CachedProvider{
+		provider: provider,
+		ttl:      ttl,
+		maxSize:  maxSize,
+		entries:  make(map[string]cacheEntry),
+	}
+}
+
+// Lookup returns a cached location or asks the wrapped provider
+func (p *CachedProvider) Lookup(ctx context.Context, ip string) (*Location, error) {
+	now := time.Now()
+
+	p.mu.Lock()
+	if entry, ok := p.entries[ip]; ok && now.Before(entry.expiresAt) {
+		p.mu.Unlock()
+		return entry.location, nil
+	}
+	p.mu.Unlock()
+
+	location, err := p.provider.Lookup(ctx, ip)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.entries) >= p.maxSize {
+		// Drop expired entries first, everything if the cache is still full
+		for key, entry := range p.entries {
+			if now.After(entry.expiresAt) {
+				delete(p.entries, key)
+			}
+		}
+		if len(p.entries) >= p.maxSize {
+			p.entries = make(map[string]cacheEntry)
+		}
+	}
+	p.entries[ip] = cacheEntry{location: location, expiresAt: now.Add(p.ttl)}
+
+	return location, nil
+}
+
+// Detector determines the visitor country from a trusted proxy header (e.g. CF-IPCountry)
+// or by looking up the client IP
+type Detector struct {
+	provider      Provider
+	countryHeader string
+}
+
+// NewDetector creates a detector; countryHeader may be empty
+func NewDetector(provider Provider, countryHeader string) *Detector {
+	if provider == nil {
+		provider = NoopProvider{}
+	}
+	return &Detector{provider: provider, countryHeader: countryHeader}
+}
+
+// DetectCountry returns the ISO country code of the request origin, or "" when unknown
+func (d *Detector) DetectCountry(ctx context.Context, r *http.Request, clientIP string) string {
+	if d.countryHeader != "" {
+		if code := normalizeCountryCode(r.Header.Get(d.countryHeader)); code != "" {
+			return code
+		}
+	}
+
+	if !isPublicIP(clientIP) {
+		return ""
+	}
+
+	location, err := d.provider.Lookup(ctx, clientIP)
+	if err != nil || location == nil {
+		return ""
+	}
+	return normalizeCountryCode(location.CountryCode)
+}
+
+func normalizeCountryCode(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 2 || code == "XX" || code == "T1" {
+		return ""
+	}
+	return code
+}
+
+func isPublicIP(value string) bool {
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return false
+	}
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsUnspecified() && !ip.IsLinkLocalUnicast()
+}
@@ -0,0 +1,177 @@
+package geoip
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type countingProvider struct {
+	calls int
+}
+
+func (p *countingProvider) Lookup(ctx context.Context, ip string) (*Location, error) {
+	p.calls++
+	return &Location{CountryCode: "de"}, nil
+}
+
+func TestDetectCountry(t *testing.T) {
+	provider := &countingProvider{}
+	detector := NewDetector(NewCachedProvider(provider, time.Hour, 10), "CF-IPCountry")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/context", nil)
+	req.Header.Set("CF-IPCountry", "pl")
+	if got := detector.DetectCountry(context.Background(), req, "8.8.8.8"); got != "PL" {
+		t.Errorf("expected country from header, got %q", got)
+	}
+
+	req.Header.Set("CF-IPCountry", "XX")
+	for i := 0; i < 3; i++ {
+		if got := detector.DetectCountry(context.Background(), req, "8.8.8.8"); got != "DE" {
+			t.Errorf("expected country from provider, got %q", got)
+		}
+	}
+	if provider.calls != 1 {
+		t.Errorf("expected cached lookups, provider called %d times", provider.calls)
+	}
+
+	if got := detector.DetectCountry(context.Background(), req, "192.168.1.10"); got != "" {
+		t.Errorf("expected no lookup for private IP, got %q", got)
+	}
+}
+
+func TestHTTPProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/8.8.8.8/json/" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		w.Write([]byte(`{"ip":"8.8.8.8","country_code":"US","country_name":"United States"}`))
+	}))
+	defer server.Close()
+
+	location, err := NewHTTPProvider(server.URL+"/{ip}/json/", time.Second).Lookup(context.Background(), "8.8.8.8")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if location == nil || location.CountryCode != "US" {
+		t.Errorf("expected US, got %+v", location)
+	}
+}
+
+func TestDefaultsFor(t *testing.T) {
+	if got := DefaultsFor("de"); got.Currency != "EUR" || got.Name != "Germany" {
+		t.Errorf("unexpected defaults for DE: %+v", got)
+	}
+	if got := DefaultsFor(""); got != HomeCountry {
+		t.Errorf("expected home country for unknown visitor, got %+v", got)
+	}
+	if got := DefaultsFor("BR"); got.Code != "PL" || got.Language != "en" {
+		t.Errorf("expected home country in English for unlisted country, got %+v", got)
+	}
+}
+
+func TestCountryCode(t *testing.T) {
+	for country, want := range map[string]string{"Poland": "PL", "germany ": "DE", "cz": "CZ", "Brazil": "", "": ""} {
+		if got := CountryCode(country); got != want {
+			t.Errorf("CountryCode(%q) = %q, want %q", country, got, want)
+		}
+	}
+}
+
+func TestNegotiateLanguage(t *testing.T) {
+	tests := []struct {
+		explicit, acceptLanguage, countryLanguage, want string
+	}{
+		{"", "", "pl", "pl"},
+		{"", "de-DE,de;q=0.9,en;q=0.8", "de", "en"},
+		{"", "en;q=0.5, pl-PL", "en", "pl"},
+		{"EN", "pl", "pl", "en"},
+		{"xx", "", "cs", "en"},
+		{"", "pl;q=0", "de", "en"},
+	}
+	for _, tt := range tests {
+		if got := NegotiateLanguage(tt.explicit, tt.acceptLanguage, tt.countryLanguage); got != tt.want {
+			t.Errorf("NegotiateLanguage(%q, %q, %q) = %q, want %q", tt.explicit, tt.acceptLanguage, tt.countryLanguage, got, tt.want)
+		}
+	}
+}
+
+func TestFormatFor(t *testing.T) {
+	if got := FormatFor("PLN", "pl"); got.Symbol != "zł" || got.SymbolPosition != "after" || got.DecimalSeparator != "," {
+		t.Errorf("unexpected PLN format in Polish: %+v", got)
+	}
+	if got := FormatFor("GBP", "en"); got.Symbol != "£" || got.SymbolPosition != "before" || got.DecimalSeparator != "." {
+		t.Errorf("unexpected GBP format in English: %+v", got)
+	}
+	if got := FormatFor("SEK", "en"); got.Symbol != "SEK" {
+		t.Errorf("expected the code as symbol for unknown currencies, got %+v", got)
+	}
+	if URLPrefix("pl") != "" || URLPrefix("en") != "/en" {
+		t.Errorf("unexpected URL prefixes %q, %q", URLPrefix("pl"), URLPrefix("en"))
+	}
+}
+
+func TestCurrencyFormatFormat(t *testing.T) {
+	cases := []struct {
+		currency, language string
+		amount             float64
+		want               string
+	}{
+		{"PLN", "pl", 129, "129,00 zł"},
+		{"PLN", "pl", 1299.5, "1 299,50 zł"},
+		{"PLN", "pl", 1234567.891, "1 234 567,89 zł"},
+		{"PLN", "en", 129, "zł 129.00"},
+		{"GBP", "en", 1299.999, "£1,300.00"},
+		{"EUR", "en", -12.5, "-€12.50"},
+		{"EUR", "pl", 0.005, "0,01 €"},
+		{"SEK", "en", 99.9, "SEK 99.90"},
+	}
+	for _, tc := range cases {
+		if got := FormatFor(tc.currency, tc.language).Format(tc.amount); got != tc.want {
+			t.Errorf("Format(%v) in %s/%s = %q, want %q", tc.amount, tc.currency, tc.language, got, tc.want)
+		}
+	}
+}
+
+func TestCountriesMatchDefaults(t *testing.T) {
+	list := Countries()
+	if len(list) != len(countryDefaults) || list[0].Code != HomeCountry.Code {
+		t.Fatalf("expected every listed country, the home country first, got %d", len(list))
+	}
+	for _, country := range list {
+		defaults, ok := countryDefaults[country.Code]
+		if !ok || defaults.Name != country.Name {
+			t.Errorf("%s: expected the name stored with addresses, got %q", country.Code, country.Name)
+		}
+		if len(country.Regions) == 0 || country.PostalCodeFormat == "" {
+			t.Errorf("%s: expected regions and a postal code format", country.Code)
+		}
+	}
+}
+
+func TestValidPostalCode(t *testing.T) {
+	cases := []struct {
+		country, code string
+		valid         bool
+	}{
+		{"PL", "00-950", true},
+		{"PL", "00950", false},
+		{"NL", "1012 ab", true},
+		{"CZ", "11000", true},
+		{"GB", "SW1A 1AA", true},
+		{"GB", "12345", false},
+		{"US", "94105-1234", true},
+		{"IE", "D02 X285", true},
+	}
+	for _, tc := range cases {
+		country, ok := LookupCountry(tc.country)
+		if !ok {
+			t.Fatalf("%s is not listed", tc.country)
+		}
+		if got := country.ValidPostalCode(tc.code); got != tc.valid {
+			t.Errorf("%s %q: expected valid=%v", tc.country, tc.code, tc.valid)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package geoip
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// HTTPProvider looks up IPs with a JSON geolocation API. The URL template must
+// contain {ip}, e.g. https://ipapi.co/{ip}/json/ or https://ipinfo.io/{ip}?token=...
+type HTTPProvider struct {
+	urlTemplate string
+	client      *http.Client
+}
+
+// NewHTTPProvider creates a provider calling the given API
+func NewHTTPProvider(urlTemplate string, timeout time.Duration) *HTTPProvider {
+	return &HTTPProvider{
+		urlTemplate: urlTemplate,
+		client:      &http.Client{Timeout: timeout},
+	}
+}
+
+// Lookup calls the API and reads the country code from common response fields
+func (p *HTTPProvider) Lookup(ctx context.Context, ip string) (*Location, error) {
+	endpoint := strings.ReplaceAll(p.urlTemplate, "{ip}", url.PathEscape(ip))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create geoip request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call geoip provider: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("geoip provider returned status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		CountryCode      string `json:"country_code"`
+		CountryCodeCamel string `json:"countryCode"`
+		Country          string `json:"country"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode geoip response: %w", err)
+	}
+
+	for _, code := range []string{body.CountryCode, body.CountryCodeCamel, body.Country} {
+		if code = normalizeCountryCode(code); code != "" {
+			return &Location{CountryCode: code}, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,153 @@
+package geoip
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// StorefrontLanguages are the languages the storefront is translated to; the first one
+// is served without a URL prefix
+var StorefrontLanguages = []string{"pl", "en"}
+
+// fallbackLanguage is used for visitors whose languages have no translation
+const fallbackLanguage = "en"
+
+// NegotiateLanguage picks the storefront language for a visitor: an explicitly chosen
+// language first, then the Accept-Language preferences, then the country default
+func NegotiateLanguage(explicit, acceptLanguage, countryLanguage string) string {
+	if language, ok := storefrontLanguage(explicit); ok {
+		return language
+	}
+	for _, tag := range parseAcceptLanguage(acceptLanguage) {
+		if language, ok := storefrontLanguage(tag); ok {
+			return language
+		}
+	}
+	if language, ok := storefrontLanguage(countryLanguage); ok {
+		return language
+	}
+	return fallbackLanguage
+}
+
+// URLPrefix returns the path prefix of the storefront pages in a language, e.g. "/en"
+func URLPrefix(language string) string {
+	if language == StorefrontLanguages[0] {
+		return ""
+	}
+	return "/" + language
+}
+
+// storefrontLanguage matches a language tag such as "en-GB" to a storefront language
+func storefrontLanguage(tag string) (string, bool) {
+	primary := strings.ToLower(strings.TrimSpace(strings.SplitN(tag, "-", 2)[0]))
+	for _, language := range StorefrontLanguages {
+		if primary == language {
+			return language, true
+		}
+	}
+	return "", false
+}
+
+// parseAcceptLanguage returns the tags of an Accept-Language header, most preferred first
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag     string
+		quality float64
+	}
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.TrimSpace(fields[0])
+		if tag == "" || tag == "*" {
+			continue
+		}
+		quality := 1.0
+		for _, param := range fields[1:] {
+			if value, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if q, err := strconv.ParseFloat(value, 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		if quality > 0 {
+			tags = append(tags, weighted{tag: tag, quality: quality})
+		}
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].quality > tags[j].quality })
+	result := make([]string, len(tags))
+	for i, tag := range tags {
+		result[i] = tag.tag
+	}
+	return result
+}
+
+// CurrencyFormat describes how prices are displayed
+type CurrencyFormat struct {
+	Code               string
+	Symbol             string
+	Decimals           int
+	DecimalSeparator   string
+	ThousandsSeparator string
+	// SymbolPosition is "before" (€12.50) or "after" (12,50 zł)
+	SymbolPosition string
+}
+
+var currencySymbols = map[string]string{
+	"PLN": "zł",
+	"EUR": "€",
+	"CZK": "Kč",
+	"GBP": "£",
+	"USD": "$",
+}
+
+// FormatFor returns the display rules of a currency for a storefront language. English
+// puts the symbol first with a decimal point; the other languages follow the
+// continental style.
+func FormatFor(currency, language string) CurrencyFormat {
+	symbol, ok := currencySymbols[currency]
+	if !ok {
+		symbol = currency
+	}
+
+	if language == "en" {
+		return CurrencyFormat{Code: currency, Symbol: symbol, Decimals: 2, DecimalSeparator: ".", ThousandsSeparator: ",", SymbolPosition: "before"}
+	}
+	return CurrencyFormat{Code: currency, Symbol: symbol, Decimals: 2, DecimalSeparator: ",", ThousandsSeparator: " ", SymbolPosition: "after"}
+}
+
+// Format formats an amount for display, e.g. "1 299,00 zł" or "£1,299.00". Amounts are
+// rounded half away from zero to the currency's decimals, as pricing rounds totals.
+func (f CurrencyFormat) Format(amount float64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	digits := strconv.FormatFloat(math.Round(amount*math.Pow10(f.Decimals))/math.Pow10(f.Decimals), 'f', f.Decimals, 64)
+	whole, fraction, _ := strings.Cut(digits, ".")
+
+	var b strings.Builder
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteString(f.ThousandsSeparator)
+		}
+		b.WriteRune(digit)
+	}
+	number := b.String()
+	if fraction != "" {
+		number += f.DecimalSeparator + fraction
+	}
+
+	if f.SymbolPosition == "after" {
+		return sign + number + " " + f.Symbol
+	}
+	// Letter symbols such as "zł" or an ISO code are set apart from the number
+	if utf8.RuneCountInString(f.Symbol) > 1 {
+		return sign + f.Symbol + " " + number
+	}
+	return sign + f.Symbol + number
+}
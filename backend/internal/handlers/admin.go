@@ -1,19 +1,21 @@
 package handlers
 
 import (
-	"crypto/rand"
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 
 	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/imaging"
+	"notsofluffy-backend/internal/media"
+	"notsofluffy-backend/internal/middleware"
 	"notsofluffy-backend/internal/models"
+	"notsofluffy-backend/internal/slug"
 
 	"github.com/gin-gonic/gin"
 )
@@ -30,8 +32,15 @@ type AdminHandler struct {
 	sizeQueries              *database.SizeQueries
 	productVariantQueries    *database.ProductVariantQueries
 	orderQueries             *database.OrderQueries
+	orderStatusQueries       *database.OrderStatusQueries
 	settingsQueries          *database.SettingsQueries
 	clientReviewQueries      *database.ClientReviewQueries
+	warehouseQueries         *database.WarehouseQueries
+	trashQueries             *database.TrashQueries
+	revisionQueries          *database.ProductRevisionQueries
+	webhookQueries           *database.WebhookQueries
+	emailQueries             *database.EmailQueries
+	mediaService             *media.Service
 }
 
 func NewAdminHandler(db *sql.DB) *AdminHandler {
@@ -47,17 +56,26 @@ func NewAdminHandler(db *sql.DB) *AdminHandler {
 		sizeQueries:              database.NewSizeQueries(db),
 		productVariantQueries:    database.NewProductVariantQueries(db),
 		orderQueries:             database.NewOrderQueries(db),
+		orderStatusQueries:       database.NewOrderStatusQueries(db),
 		settingsQueries:          database.NewSettingsQueries(db),
 		clientReviewQueries:      database.NewClientReviewQueries(db),
+		warehouseQueries:         database.NewWarehouseQueries(db),
+		trashQueries:             database.NewTrashQueries(db),
+		revisionQueries:          database.NewProductRevisionQueries(db),
+		webhookQueries:           database.NewWebhookQueries(db),
+		emailQueries:             database.NewEmailQueries(db),
+		mediaService:             media.NewService(media.NewLocalStorage("uploads")),
 	}
 }
 
 // User Management
 
+// ListUsers returns users with their order and login activity. Filters: search (email),
+// role, created_from and created_to (inclusive dates, YYYY-MM-DD), has_orders; sort is
+// created_at, last_login, order_count or lifetime_value.
 func (h *AdminHandler) ListUsers(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	search := c.Query("search")
 
 	if page < 1 {
 		page = 1
@@ -66,17 +84,80 @@ func (h *AdminHandler) ListUsers(c *gin.Context) {
 		limit = 10
 	}
 
-	users, total, err := h.userQueries.ListUsers(page, limit, search)
+	filter := models.UserListFilter{
+		Search: c.Query("search"),
+		Role:   c.Query("role"),
+		Sort:   c.DefaultQuery("sort", models.UserSortCreated),
+	}
+	switch filter.Role {
+	case "", models.RoleClient, models.RoleAdmin, models.RoleEditor:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role"})
+		return
+	}
+	switch filter.Sort {
+	case models.UserSortCreated, models.UserSortLastLogin, models.UserSortOrderCount, models.UserSortLifetimeValue:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sort"})
+		return
+	}
+	if from := c.Query("created_from"); from != "" {
+		date, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "created_from must be a date (YYYY-MM-DD)"})
+			return
+		}
+		filter.CreatedFrom = &date
+	}
+	if to := c.Query("created_to"); to != "" {
+		date, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "created_to must be a date (YYYY-MM-DD)"})
+			return
+		}
+		end := date.AddDate(0, 0, 1)
+		filter.CreatedTo = &end
+	}
+	if hasOrders := c.Query("has_orders"); hasOrders != "" {
+		value, err := strconv.ParseBool(hasOrders)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "has_orders must be true or false"})
+			return
+		}
+		filter.HasOrders = &value
+	}
+	if deleted := c.Query("deleted"); deleted != "" {
+		value, err := strconv.ParseBool(deleted)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "deleted must be true or false"})
+			return
+		}
+		filter.Deleted = value
+	}
+
+	after, ok := parseCursor(c)
+	if !ok {
+		return
+	}
+
+	users, total, next, err := h.userQueries.ListUsers(page, limit, after, filter)
 	if err != nil {
+		if err.Error() == "invalid cursor" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve users"})
 		return
 	}
+	h.flagUndeliverableUsers(c.Request.Context(), users)
 
 	response := models.UserListResponse{
-		Users: users,
-		Total: total,
-		Page:  page,
-		Limit: limit,
+		Users:      users,
+		Total:      total,
+		Page:       page,
+		Limit:      limit,
+		NextCursor: next,
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -92,6 +173,7 @@ func (h *AdminHandler) CreateUser(c *gin.Context) {
 	// Check if email already exists
 	exists, err := h.userQueries.EmailExists(req.Email)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check email"})
 		return
 	}
@@ -102,6 +184,7 @@ func (h *AdminHandler) CreateUser(c *gin.Context) {
 
 	user, err := h.userQueries.CreateAdminUser(req.Email, req.Password, req.Role)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
 		return
 	}
@@ -124,6 +207,7 @@ func (h *AdminHandler) UpdateUser(c *gin.Context) {
 
 	user, err := h.userQueries.UpdateUser(id, req.Email, req.Password, req.Role)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
 		return
 	}
@@ -140,57 +224,60 @@ func (h *AdminHandler) DeleteUser(c *gin.Context) {
 
 	err = h.userQueries.DeleteUser(id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
+		switch err.Error() {
+		case "user not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		case "system user cannot be deleted":
+			c.JSON(http.StatusBadRequest, gin.H{"error": "The system user can't be deleted"})
+		default:
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
+		}
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
 }
 
-// Image Management
-
-func (h *AdminHandler) UploadImage(c *gin.Context) {
-	file, header, err := c.Request.FormFile("image")
+// RestoreUser reactivates a deleted user
+func (h *AdminHandler) RestoreUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
 		return
 	}
-	defer file.Close()
 
-	// Validate file type
-	if !isValidImageType(header.Header.Get("Content-Type")) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file type. Only JPEG, PNG, and GIF are allowed"})
+	if err := h.userQueries.RestoreUser(id); err != nil {
+		if err.Error() == "user not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore user"})
 		return
 	}
 
-	// Validate file size (10MB limit)
-	if header.Size > 10*1024*1024 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "File size too large. Maximum 10MB allowed"})
-		return
-	}
+	c.JSON(http.StatusOK, gin.H{"message": "User restored successfully"})
+}
 
-	// Generate unique filename
-	ext := filepath.Ext(header.Filename)
-	filename := generateUUID() + ext
-	
-	// Create upload directory if it doesn't exist
-	uploadDir := "uploads/images"
-	if err := os.MkdirAll(uploadDir, 0755); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create upload directory"})
-		return
-	}
+// Image Management
 
-	// Save file
-	filePath := filepath.Join(uploadDir, filename)
-	out, err := os.Create(filePath)
+func (h *AdminHandler) UploadImage(c *gin.Context) {
+	file, header, err := c.Request.FormFile("image")
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create file"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
 		return
 	}
-	defer out.Close()
+	defer file.Close()
 
-	_, err = io.Copy(out, file)
+	stored, err := h.mediaService.Store(media.KindImage, file, header, 0)
 	if err != nil {
+		var validationErr *media.ValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Message})
+			return
+		}
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
 		return
 	}
@@ -201,32 +288,35 @@ func (h *AdminHandler) UploadImage(c *gin.Context) {
 
 	// Save image metadata to database
 	image := &models.Image{
-		Filename:     filename,
-		OriginalName: header.Filename,
-		Path:         filePath,
-		SizeBytes:    header.Size,
-		MimeType:     header.Header.Get("Content-Type"),
+		Filename:     stored.Filename,
+		OriginalName: stored.OriginalName,
+		Path:         stored.Path,
+		SizeBytes:    stored.SizeBytes,
+		MimeType:     stored.MimeType,
 		UploadedBy:   userIDInt,
 	}
 
 	err = h.imageQueries.CreateImage(image)
 	if err != nil {
 		// Clean up file if database save fails
-		os.Remove(filePath)
+		h.mediaService.Remove(stored.Path, stored.MimeType)
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save image metadata"})
 		return
 	}
 
-	response := models.ImageResponse{
-		ID:           image.ID,
-		Filename:     image.Filename,
-		OriginalName: image.OriginalName,
-		Path:         image.Path,
-		SizeBytes:    image.SizeBytes,
-		MimeType:     image.MimeType,
-		UploadedBy:   image.UploadedBy,
-		CreatedAt:    image.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:    image.UpdatedAt.Format(time.RFC3339),
+	// Generate the cropped variants and sizes; missing ones are retried by the image variants job
+	if err := h.mediaService.GenerateVariants(image.Path, image.MimeType, imaging.Meta{FocalX: image.FocalX, FocalY: image.FocalY}); err != nil {
+		middleware.Logger(c.Request.Context()).Error("failed to generate image variants", "image_id", image.ID, "error", err)
+	}
+	response := imageToResponse(image)
+	sizes, err := h.mediaService.GenerateSizes(image.Path, image.MimeType)
+	if err != nil {
+		middleware.Logger(c.Request.Context()).Error("failed to generate image sizes", "image_id", image.ID, "error", err)
+	} else if err := h.imageQueries.SaveImageVariants(image.ID, sizes); err != nil {
+		middleware.Logger(c.Request.Context()).Error("failed to save image sizes", "image_id", image.ID, "error", err)
+	} else {
+		response.Sizes = sizes
 	}
 
 	c.JSON(http.StatusCreated, response)
@@ -235,6 +325,7 @@ func (h *AdminHandler) UploadImage(c *gin.Context) {
 func (h *AdminHandler) ListImages(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	tag := strings.ToLower(strings.TrimSpace(c.Query("tag")))
 
 	if page < 1 {
 		page = 1
@@ -243,38 +334,68 @@ func (h *AdminHandler) ListImages(c *gin.Context) {
 		limit = 10
 	}
 
-	images, total, err := h.imageQueries.ListImages(page, limit)
+	after, ok := parseCursor(c)
+	if !ok {
+		return
+	}
+
+	images, total, next, err := h.imageQueries.ListImages(page, limit, after, tag)
 	if err != nil {
+		if err.Error() == "invalid cursor" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve images"})
 		return
 	}
 
+	ids := make([]int, len(images))
+	for i := range images {
+		ids[i] = images[i].ID
+	}
+	tags, err := h.imageQueries.GetImageTags(ids)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve image tags"})
+		return
+	}
+	counts, err := h.imageQueries.GetReferenceCounts(ids)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve image usage"})
+		return
+	}
+	sizes, err := h.imageQueries.GetImageVariants(ids)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve image sizes"})
+		return
+	}
+
 	// Convert to response format
 	imageResponses := make([]models.ImageResponse, len(images))
-	for i, img := range images {
-		imageResponses[i] = models.ImageResponse{
-			ID:           img.ID,
-			Filename:     img.Filename,
-			OriginalName: img.OriginalName,
-			Path:         img.Path,
-			SizeBytes:    img.SizeBytes,
-			MimeType:     img.MimeType,
-			UploadedBy:   img.UploadedBy,
-			CreatedAt:    img.CreatedAt.Format(time.RFC3339),
-			UpdatedAt:    img.UpdatedAt.Format(time.RFC3339),
-		}
+	for i := range images {
+		imageResponses[i] = imageToResponse(&images[i])
+		imageResponses[i].Tags = tags[images[i].ID]
+		imageResponses[i].Sizes = sizes[images[i].ID]
+		count := counts[images[i].ID]
+		imageResponses[i].ReferenceCount = &count
 	}
 
 	response := models.ImageListResponse{
-		Images: imageResponses,
-		Total:  total,
-		Page:   page,
-		Limit:  limit,
+		Images:     imageResponses,
+		Total:      total,
+		Page:       page,
+		Limit:      limit,
+		NextCursor: next,
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
+// DeleteImage deletes an image that is no longer used. Images still in use are only
+// deleted with force=true, which unlinks them everywhere except as a product main image.
 func (h *AdminHandler) DeleteImage(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
@@ -289,19 +410,194 @@ func (h *AdminHandler) DeleteImage(c *gin.Context) {
 		return
 	}
 
+	references, err := h.imageQueries.GetImageReferences(id)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check image usage"})
+		return
+	}
+	if len(references) > 0 && c.Query("force") != "true" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Image is in use", "references": references})
+		return
+	}
+
 	// Delete from database
 	err = h.imageQueries.DeleteImage(id)
 	if err != nil {
+		if strings.Contains(err.Error(), "foreign key") {
+			c.JSON(http.StatusConflict, gin.H{"error": "Image is the main image of a product", "references": references})
+			return
+		}
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete image"})
 		return
 	}
 
-	// Delete file from filesystem
-	os.Remove(image.Path)
+	// Delete file and its variants from storage
+	h.mediaService.Remove(image.Path, image.MimeType)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Image deleted successfully"})
 }
 
+func (h *AdminHandler) GetImage(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid image ID"})
+		return
+	}
+
+	image, err := h.imageQueries.GetImageByID(id)
+	if err != nil {
+		if err.Error() == "image not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Image not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get image"})
+		return
+	}
+
+	tags, err := h.imageQueries.GetImageTags([]int{id})
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get image tags"})
+		return
+	}
+	references, err := h.imageQueries.GetImageReferences(id)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get image usage"})
+		return
+	}
+
+	response := imageToResponse(image)
+	response.Tags = tags[id]
+	count := len(references)
+	response.ReferenceCount = &count
+
+	c.JSON(http.StatusOK, gin.H{"image": response, "references": references})
+}
+
+// SetImageTags replaces the tags of an image
+func (h *AdminHandler) SetImageTags(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid image ID"})
+		return
+	}
+
+	var req models.MediaTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tags := database.NormalizeTags(req.Tags)
+	if err := h.imageQueries.SetImageTags(id, tags); err != nil {
+		if err.Error() == "image not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Image not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update image tags"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tags": tags})
+}
+
+// ListImageTags returns all media tags with their usage counts
+func (h *AdminHandler) ListImageTags(c *gin.Context) {
+	tags, err := h.imageQueries.ListImageTags()
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list image tags"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tags": tags})
+}
+
+// UpdateImageCrop sets the focal point and per-variant crops of an image and
+// regenerates its variants
+func (h *AdminHandler) UpdateImageCrop(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid image ID"})
+		return
+	}
+
+	var req models.ImageCropRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	for variant, crop := range req.Crops {
+		if err := imaging.ValidateCrop(variant, crop); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	image, err := h.imageQueries.GetImageByID(id)
+	if err != nil {
+		if err.Error() == "image not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Image not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get image"})
+		return
+	}
+
+	// Omitted fields keep their current value
+	if req.FocalX != nil {
+		image.FocalX = *req.FocalX
+	}
+	if req.FocalY != nil {
+		image.FocalY = *req.FocalY
+	}
+	if req.Crops != nil {
+		image.Crops = req.Crops
+	}
+
+	if err := h.imageQueries.UpdateImageCrop(id, image.FocalX, image.FocalY, image.Crops); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update image crop"})
+		return
+	}
+
+	meta := imaging.Meta{FocalX: image.FocalX, FocalY: image.FocalY, Crops: image.Crops}
+	if err := h.mediaService.GenerateVariants(image.Path, image.MimeType, meta); err != nil {
+		middleware.Logger(c.Request.Context()).Error("failed to regenerate image variants", "image_id", image.ID, "error", err)
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Crop saved but image variants could not be regenerated"})
+		return
+	}
+
+	c.JSON(http.StatusOK, imageToResponse(image))
+}
+
+// imageToResponse converts an image with its crop settings for the admin panel
+func imageToResponse(image *models.Image) models.ImageResponse {
+	return models.ImageResponse{
+		ID:           image.ID,
+		Filename:     image.Filename,
+		OriginalName: image.OriginalName,
+		Path:         image.Path,
+		SizeBytes:    image.SizeBytes,
+		MimeType:     image.MimeType,
+		UploadedBy:   image.UploadedBy,
+		AltText:      image.AltText,
+		Title:        image.Title,
+		FocalPoint:   &models.ImageFocalPoint{X: image.FocalX, Y: image.FocalY},
+		Crops:        image.Crops,
+		Variants:     imaging.VariantPaths(image.Path, image.MimeType),
+		CreatedAt:    image.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:    image.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
 // Category Management
 
 func (h *AdminHandler) ListCategories(c *gin.Context) {
@@ -332,6 +628,7 @@ func (h *AdminHandler) ListCategories(c *gin.Context) {
 
 	categories, total, err := h.categoryQueries.ListCategories(page, limit, search, activeOnly, chartOnly)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve categories"})
 		return
 	}
@@ -369,14 +666,11 @@ func (h *AdminHandler) CreateCategory(c *gin.Context) {
 		return
 	}
 
-	// Check if slug already exists
-	exists, err := h.categoryQueries.SlugExists(req.Slug, nil)
+	// Use the given slug if it is free, or generate one from the name
+	exists, _ := h.slugExists(models.SlugTypeCategory, nil)
+	categorySlug, err := resolveSlug(req.Slug, req.Name, models.SlugTypeCategory, exists)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check slug"})
-		return
-	}
-	if exists {
-		c.JSON(http.StatusConflict, gin.H{"error": "Slug already exists"})
+		respondSlugError(c, err)
 		return
 	}
 
@@ -391,7 +685,7 @@ func (h *AdminHandler) CreateCategory(c *gin.Context) {
 
 	category := &models.Category{
 		Name:      req.Name,
-		Slug:      req.Slug,
+		Slug:      categorySlug,
 		ImageID:   req.ImageID,
 		Active:    req.Active,
 		ChartOnly: req.ChartOnly,
@@ -399,6 +693,7 @@ func (h *AdminHandler) CreateCategory(c *gin.Context) {
 
 	err = h.categoryQueries.CreateCategory(category)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create category"})
 		return
 	}
@@ -458,15 +753,13 @@ func (h *AdminHandler) UpdateCategory(c *gin.Context) {
 		return
 	}
 
-	// Check if slug already exists (excluding current category)
-	exists, err := h.categoryQueries.SlugExists(req.Slug, &id)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check slug"})
-		return
-	}
-	if exists {
-		c.JSON(http.StatusConflict, gin.H{"error": "Slug already exists"})
-		return
+	// Check if slug already exists (excluding current category); an empty one keeps the current slug
+	if req.Slug != "" {
+		exists, _ := h.slugExists(models.SlugTypeCategory, &id)
+		if _, err := resolveSlug(req.Slug, req.Name, models.SlugTypeCategory, exists); err != nil {
+			respondSlugError(c, err)
+			return
+		}
 	}
 
 	// Validate image ID if provided
@@ -480,6 +773,7 @@ func (h *AdminHandler) UpdateCategory(c *gin.Context) {
 
 	category, err := h.categoryQueries.UpdateCategory(id, req.Name, req.Slug, req.ImageID, req.Active, req.ChartOnly)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update category"})
 		return
 	}
@@ -505,13 +799,18 @@ func (h *AdminHandler) DeleteCategory(c *gin.Context) {
 		return
 	}
 
-	err = h.categoryQueries.DeleteCategory(id)
+	trashID, err := h.trashQueries.MoveToTrash(models.TrashEntityCategory, id, getUserIDPtr(c))
 	if err != nil {
+		if err.Error() == "category not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+			return
+		}
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete category"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Category deleted successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "Category deleted successfully", "trash_id": trashID})
 }
 
 func (h *AdminHandler) ToggleCategoryActive(c *gin.Context) {
@@ -523,6 +822,7 @@ func (h *AdminHandler) ToggleCategoryActive(c *gin.Context) {
 
 	err = h.categoryQueries.ToggleActive(id)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to toggle category status"})
 		return
 	}
@@ -546,6 +846,7 @@ func (h *AdminHandler) ListMaterials(c *gin.Context) {
 
 	materials, total, err := h.materialQueries.ListMaterials(page, limit, search)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve materials"})
 		return
 	}
@@ -581,6 +882,7 @@ func (h *AdminHandler) CreateMaterial(c *gin.Context) {
 	// Check if name already exists
 	exists, err := h.materialQueries.NameExists(req.Name, nil)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check material name"})
 		return
 	}
@@ -595,6 +897,7 @@ func (h *AdminHandler) CreateMaterial(c *gin.Context) {
 
 	err = h.materialQueries.CreateMaterial(material)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create material"})
 		return
 	}
@@ -648,6 +951,7 @@ func (h *AdminHandler) UpdateMaterial(c *gin.Context) {
 	// Check if name already exists (excluding current material)
 	exists, err := h.materialQueries.NameExists(req.Name, &id)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check material name"})
 		return
 	}
@@ -658,6 +962,7 @@ func (h *AdminHandler) UpdateMaterial(c *gin.Context) {
 
 	material, err := h.materialQueries.UpdateMaterial(id, req.Name)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update material"})
 		return
 	}
@@ -681,6 +986,7 @@ func (h *AdminHandler) DeleteMaterial(c *gin.Context) {
 
 	err = h.materialQueries.DeleteMaterial(id)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete material"})
 		return
 	}
@@ -719,6 +1025,7 @@ func (h *AdminHandler) ListColors(c *gin.Context) {
 
 	colors, total, err := h.colorQueries.ListColors(page, limit, search, materialID, customOnly)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve colors"})
 		return
 	}
@@ -775,6 +1082,7 @@ func (h *AdminHandler) CreateColor(c *gin.Context) {
 	// Check if name already exists for this material
 	exists, err := h.colorQueries.NameExistsForMaterial(req.Name, req.MaterialID, nil)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check color name"})
 		return
 	}
@@ -792,6 +1100,7 @@ func (h *AdminHandler) CreateColor(c *gin.Context) {
 
 	err = h.colorQueries.CreateColor(color)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create color"})
 		return
 	}
@@ -869,6 +1178,7 @@ func (h *AdminHandler) UpdateColor(c *gin.Context) {
 	// Check if name already exists for this material (excluding current color)
 	exists, err := h.colorQueries.NameExistsForMaterial(req.Name, req.MaterialID, &id)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check color name"})
 		return
 	}
@@ -879,6 +1189,7 @@ func (h *AdminHandler) UpdateColor(c *gin.Context) {
 
 	color, err := h.colorQueries.UpdateColor(id, req.Name, req.ImageID, req.Custom, req.MaterialID)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update color"})
 		return
 	}
@@ -903,13 +1214,18 @@ func (h *AdminHandler) DeleteColor(c *gin.Context) {
 		return
 	}
 
-	err = h.colorQueries.DeleteColor(id)
+	trashID, err := h.trashQueries.MoveToTrash(models.TrashEntityColor, id, getUserIDPtr(c))
 	if err != nil {
+		if err.Error() == "color not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Color not found"})
+			return
+		}
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete color"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Color deleted successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "Color deleted successfully", "trash_id": trashID})
 }
 
 // Additional Service Management
@@ -943,6 +1259,7 @@ func (h *AdminHandler) ListAdditionalServices(c *gin.Context) {
 
 	services, total, err := h.additionalServiceQueries.ListAdditionalServices(page, limit, search, minPrice, maxPrice)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve additional services"})
 		return
 	}
@@ -981,6 +1298,7 @@ func (h *AdminHandler) CreateAdditionalService(c *gin.Context) {
 	// Check if name already exists
 	exists, err := h.additionalServiceQueries.NameExists(req.Name, nil)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check service name"})
 		return
 	}
@@ -1006,6 +1324,7 @@ func (h *AdminHandler) CreateAdditionalService(c *gin.Context) {
 
 	err = h.additionalServiceQueries.CreateAdditionalService(service)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create additional service"})
 		return
 	}
@@ -1014,6 +1333,7 @@ func (h *AdminHandler) CreateAdditionalService(c *gin.Context) {
 	if len(req.ImageIDs) > 0 {
 		err = h.additionalServiceQueries.ReplaceImages(service.ID, req.ImageIDs)
 		if err != nil {
+			c.Error(err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to associate images with service"})
 			return
 		}
@@ -1074,6 +1394,7 @@ func (h *AdminHandler) UpdateAdditionalService(c *gin.Context) {
 	// Check if name already exists (excluding current service)
 	exists, err := h.additionalServiceQueries.NameExists(req.Name, &id)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check service name"})
 		return
 	}
@@ -1093,6 +1414,7 @@ func (h *AdminHandler) UpdateAdditionalService(c *gin.Context) {
 
 	service, err := h.additionalServiceQueries.UpdateAdditionalService(id, req.Name, req.Description, req.Price)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update additional service"})
 		return
 	}
@@ -1100,6 +1422,7 @@ func (h *AdminHandler) UpdateAdditionalService(c *gin.Context) {
 	// Update image associations
 	err = h.additionalServiceQueries.ReplaceImages(service.ID, req.ImageIDs)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update service images"})
 		return
 	}
@@ -1107,6 +1430,7 @@ func (h *AdminHandler) UpdateAdditionalService(c *gin.Context) {
 	// Get updated service with images
 	updatedService, err := h.additionalServiceQueries.GetAdditionalServiceByID(service.ID)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get updated service"})
 		return
 	}
@@ -1131,38 +1455,18 @@ func (h *AdminHandler) DeleteAdditionalService(c *gin.Context) {
 		return
 	}
 
-	err = h.additionalServiceQueries.DeleteAdditionalService(id)
+	trashID, err := h.trashQueries.MoveToTrash(models.TrashEntityAdditionalService, id, getUserIDPtr(c))
 	if err != nil {
+		if err.Error() == "additional service not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Additional service not found"})
+			return
+		}
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete additional service"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Additional service deleted successfully"})
-}
-
-// Helper functions
-
-func generateUUID() string {
-	bytes := make([]byte, 16)
-	rand.Read(bytes)
-	return fmt.Sprintf("%x-%x-%x-%x-%x", bytes[0:4], bytes[4:6], bytes[6:8], bytes[8:10], bytes[10:16])
-}
-
-func isValidImageType(mimeType string) bool {
-	allowedTypes := []string{
-		"image/jpeg",
-		"image/png",
-		"image/gif",
-		"image/webp",
-	}
-	
-	for _, t := range allowedTypes {
-		if strings.EqualFold(mimeType, t) {
-			return true
-		}
-	}
-	
-	return false
+	c.JSON(http.StatusOK, gin.H{"message": "Additional service deleted successfully", "trash_id": trashID})
 }
 
 // Product Management
@@ -1191,8 +1495,26 @@ func (h *AdminHandler) ListProducts(c *gin.Context) {
 		}
 	}
 	
-	products, total, err := h.productQueries.ListProducts(page, limit, search, categoryID, materialID)
+	channel := c.Query("channel")
+	if channel != "" && !isValidSalesChannel(channel) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sales channel"})
+		return
+	}
+	
+	incomplete := c.Query("incomplete") == "true"
+	
+	after, ok := parseCursor(c)
+	if !ok {
+		return
+	}
+	
+	products, total, next, err := h.productQueries.ListProducts(page, limit, after, search, categoryID, materialID, channel, incomplete)
 	if err != nil {
+		if err.Error() == "invalid cursor" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve products"})
 		return
 	}
@@ -1200,58 +1522,122 @@ func (h *AdminHandler) ListProducts(c *gin.Context) {
 	// Convert to response format
 	var responseProducts []models.ProductResponse
 	for _, product := range products {
-		responseProduct := models.ProductResponse{
-			ID:                 product.ID,
-			Name:               product.Name,
-			ShortDescription:   product.ShortDescription,
-			Description:        product.Description,
-			MaterialID:         product.MaterialID,
-			MainImageID:        product.MainImageID,
-			CategoryID:         product.CategoryID,
-			CreatedAt:          product.CreatedAt.Format(time.RFC3339),
-			UpdatedAt:          product.UpdatedAt.Format(time.RFC3339),
-			Material:           product.Material,
-			MainImage:          product.MainImage,
-			Category:           product.Category,
-			Images:             product.Images,
-			AdditionalServices: product.AdditionalServices,
-		}
+		responseProduct := buildProductResponse(&product)
 		responseProducts = append(responseProducts, responseProduct)
 	}
 	
 	response := models.ProductListResponse{
-		Products: responseProducts,
-		Total:    total,
-		Page:     page,
-		Limit:    limit,
+		Products:   responseProducts,
+		Total:      total,
+		Page:       page,
+		Limit:      limit,
+		NextCursor: next,
 	}
 	
 	c.JSON(http.StatusOK, response)
 }
 
-func (h *AdminHandler) CreateProduct(c *gin.Context) {
-	var req models.ProductRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+// GetChannelFeed exports products offered in a sales channel (marketplace, B2B catalog)
+func (h *AdminHandler) GetChannelFeed(c *gin.Context) {
+	channel := c.Param("channel")
+	if !isValidSalesChannel(channel) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sales channel"})
 		return
 	}
 	
-	// Validate main image exists
-	if !h.validateImageExists(req.MainImageID) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Main image not found"})
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 50
+	}
+	
+	products, total, _, err := h.productQueries.ListProducts(page, limit, nil, "", nil, nil, channel, false)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve channel feed"})
 		return
 	}
 	
-	// Validate all image IDs exist
-	for _, imageID := range req.ImageIDs {
-		if !h.validateImageExists(imageID) {
-			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Image with ID %d not found", imageID)})
+	items := make([]models.ChannelFeedItem, 0, len(products))
+	for _, product := range products {
+		sizes, err := h.productQueries.GetProductSizes(product.ID)
+		if err != nil {
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve product sizes"})
 			return
 		}
+		
+		items = append(items, models.ChannelFeedItem{
+			Product: buildProductResponse(&product),
+			Sizes: sizes,
+		})
 	}
 	
-	// Validate main image is included in images array
-	mainImageIncluded := false
+	c.JSON(http.StatusOK, models.ChannelFeedResponse{
+		Channel: channel,
+		Items:   items,
+		Total:   total,
+		Page:    page,
+		Limit:   limit,
+	})
+}
+
+func isValidSalesChannel(channel string) bool {
+	switch channel {
+	case models.SalesChannelWeb, models.SalesChannelMarketplace, models.SalesChannelB2B:
+		return true
+	}
+	return false
+}
+
+// buildProductResponse converts a product with its relations to the admin API shape
+func buildProductResponse(product *models.ProductWithRelations) models.ProductResponse {
+	return models.ProductResponse{
+		ID:                 product.ID,
+		Name:               product.Name,
+		Slug:               product.Slug,
+		ShortDescription:   product.ShortDescription,
+		Description:        product.Description,
+		MaterialID:         product.MaterialID,
+		MainImageID:        product.MainImageID,
+		CategoryID:         product.CategoryID,
+		Channels:           &product.Channels,
+		CreatedAt:          product.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:          product.UpdatedAt.Format(time.RFC3339),
+		Material:           product.Material,
+		MainImage:          product.MainImage,
+		Category:           product.Category,
+		Images:             product.Images,
+		AdditionalServices: product.AdditionalServices,
+	}
+}
+
+func (h *AdminHandler) CreateProduct(c *gin.Context) {
+	var req models.ProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	
+	// Validate main image exists
+	if !h.validateImageExists(req.MainImageID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Main image not found"})
+		return
+	}
+	
+	// Validate all image IDs exist
+	for _, imageID := range req.ImageIDs {
+		if !h.validateImageExists(imageID) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Image with ID %d not found", imageID)})
+			return
+		}
+	}
+	
+	// Validate main image is included in images array
+	mainImageIncluded := false
 	for _, imageID := range req.ImageIDs {
 		if imageID == req.MainImageID {
 			mainImageIncluded = true
@@ -1283,18 +1669,33 @@ func (h *AdminHandler) CreateProduct(c *gin.Context) {
 		return
 	}
 	
+	// Use the given slug if it is free, or generate one from the name
+	exists, _ := h.slugExists(models.SlugTypeProduct, nil)
+	newSlug, err := resolveSlug(req.Slug, req.Name, models.SlugTypeProduct, exists)
+	if err != nil {
+		respondSlugError(c, err)
+		return
+	}
+	
 	product := &models.Product{
 		Name:             req.Name,
+		Slug:             newSlug,
 		ShortDescription: req.ShortDescription,
 		Description:      req.Description,
 		MaterialID:       req.MaterialID,
 		MainImageID:      req.MainImageID,
 		CategoryID:       req.CategoryID,
+		Channels:         req.Channels,
 	}
 	
 	// Create product
-	err := h.productQueries.CreateProduct(product)
+	err = h.productQueries.CreateProduct(product)
 	if err != nil {
+		if err.Error() == "product slug already exists" {
+			respondSlugError(c, errSlugTaken)
+			return
+		}
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create product"})
 		return
 	}
@@ -1302,6 +1703,7 @@ func (h *AdminHandler) CreateProduct(c *gin.Context) {
 	// Set product images
 	err = h.productQueries.ReplaceImages(product.ID, req.ImageIDs)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set product images"})
 		return
 	}
@@ -1309,35 +1711,27 @@ func (h *AdminHandler) CreateProduct(c *gin.Context) {
 	// Set product services
 	err = h.productQueries.ReplaceServices(product.ID, req.AdditionalServiceIDs)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set product services"})
 		return
 	}
 	
+	h.recordProductRevision(c.Request.Context(), product.ID, models.ProductRevisionCreate, getUserIDPtr(c))
+	
 	// Return the created product with relations
 	createdProduct, err := h.productQueries.GetProduct(product.ID)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve created product"})
 		return
 	}
 	
-	response := models.ProductResponse{
-		ID:                 createdProduct.ID,
-		Name:               createdProduct.Name,
-		ShortDescription:   createdProduct.ShortDescription,
-		Description:        createdProduct.Description,
-		MaterialID:         createdProduct.MaterialID,
-		MainImageID:        createdProduct.MainImageID,
-		CategoryID:         createdProduct.CategoryID,
-		CreatedAt:          createdProduct.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:          createdProduct.UpdatedAt.Format(time.RFC3339),
-		Material:           createdProduct.Material,
-		MainImage:          createdProduct.MainImage,
-		Category:           createdProduct.Category,
-		Images:             createdProduct.Images,
-		AdditionalServices: createdProduct.AdditionalServices,
-	}
+	response := buildProductResponse(createdProduct)
 	
-	c.JSON(http.StatusCreated, response)
+	c.JSON(http.StatusCreated, models.ProductMutationResponse{
+		ProductResponse: response,
+		Warnings:        h.productWarnings(c.Request.Context(), createdProduct),
+	})
 }
 
 func (h *AdminHandler) GetProduct(c *gin.Context) {
@@ -1353,26 +1747,20 @@ func (h *AdminHandler) GetProduct(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
 			return
 		}
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve product"})
 		return
 	}
 	
-	response := models.ProductResponse{
-		ID:                 product.ID,
-		Name:               product.Name,
-		ShortDescription:   product.ShortDescription,
-		Description:        product.Description,
-		MaterialID:         product.MaterialID,
-		MainImageID:        product.MainImageID,
-		CategoryID:         product.CategoryID,
-		CreatedAt:          product.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:          product.UpdatedAt.Format(time.RFC3339),
-		Material:           product.Material,
-		MainImage:          product.MainImage,
-		Category:           product.Category,
-		Images:             product.Images,
-		AdditionalServices: product.AdditionalServices,
+	response := buildProductResponse(product)
+
+	tags, err := h.productQueries.GetProductTags([]int{id})
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve product"})
+		return
 	}
+	response.Tags = tags[id]
 	
 	c.JSON(http.StatusOK, response)
 }
@@ -1390,17 +1778,87 @@ func (h *AdminHandler) UpdateProduct(c *gin.Context) {
 		return
 	}
 	
+	if msg := h.validateProductRequest(&req); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	
+	// An empty slug keeps the current one
+	if req.Slug != "" {
+		exists, _ := h.slugExists(models.SlugTypeProduct, &id)
+		if _, err := resolveSlug(req.Slug, req.Name, models.SlugTypeProduct, exists); err != nil {
+			respondSlugError(c, err)
+			return
+		}
+	}
+	
+	if err := h.applyProductUpdate(c.Request.Context(), id, &req, getUserIDPtr(c)); err != nil {
+		if err.Error() == "product not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		if err.Error() == "product slug already exists" {
+			respondSlugError(c, errSlugTaken)
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	
+	// Return the updated product with relations
+	updatedProduct, err := h.productQueries.GetProduct(id)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve updated product"})
+		return
+	}
+	
+	response := buildProductResponse(updatedProduct)
+	
+	c.JSON(http.StatusOK, models.ProductMutationResponse{
+		ProductResponse: response,
+		Warnings:        h.productWarnings(c.Request.Context(), updatedProduct),
+	})
+}
+
+func (h *AdminHandler) DeleteProduct(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+	
+	trashID, err := h.trashQueries.MoveToTrash(models.TrashEntityProduct, id, getUserIDPtr(c))
+	if err != nil {
+		if err.Error() == "product not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete product"})
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{"message": "Product deleted successfully", "trash_id": trashID})
+}
+
+// validateProductRequest checks the references of a product update and returns
+// a message for the client, or "" when the request is valid
+func (h *AdminHandler) validateProductRequest(req *models.ProductRequest) string {
+	if req.Slug != "" && !slug.Valid(req.Slug) {
+		return "Slug may only contain lowercase letters, digits and single dashes"
+	}
+	
 	// Validate main image exists
 	if !h.validateImageExists(req.MainImageID) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Main image not found"})
-		return
+		return "Main image not found"
 	}
 	
 	// Validate all image IDs exist
 	for _, imageID := range req.ImageIDs {
 		if !h.validateImageExists(imageID) {
-			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Image with ID %d not found", imageID)})
-			return
+			return fmt.Sprintf("Image with ID %d not found", imageID)
 		}
 	}
 	
@@ -1413,109 +1871,72 @@ func (h *AdminHandler) UpdateProduct(c *gin.Context) {
 		}
 	}
 	if !mainImageIncluded {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Main image must be included in the images list"})
-		return
+		return "Main image must be included in the images list"
 	}
 	
 	// Validate additional service IDs exist
 	for _, serviceID := range req.AdditionalServiceIDs {
 		if !h.validateAdditionalServiceExists(serviceID) {
-			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Additional service with ID %d not found", serviceID)})
-			return
+			return fmt.Sprintf("Additional service with ID %d not found", serviceID)
 		}
 	}
 	
 	// Validate material exists if provided
 	if req.MaterialID != nil && !h.validateMaterialExists(*req.MaterialID) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Material not found"})
-		return
+		return "Material not found"
 	}
 	
 	// Validate category exists if provided
 	if req.CategoryID != nil && !h.validateCategoryExists(*req.CategoryID) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Category not found"})
-		return
+		return "Category not found"
 	}
 	
+	return ""
+}
+
+// applyProductUpdate writes a validated product update with its images and services
+// and records a revision by userID. Errors other than "product not found" carry a
+// message for the client.
+func (h *AdminHandler) applyProductUpdate(ctx context.Context, id int, req *models.ProductRequest, userID *int) error {
 	product := &models.Product{
 		Name:             req.Name,
+		Slug:             req.Slug,
 		ShortDescription: req.ShortDescription,
 		Description:      req.Description,
 		MaterialID:       req.MaterialID,
 		MainImageID:      req.MainImageID,
 		CategoryID:       req.CategoryID,
+		Channels:         req.Channels,
 	}
 	
 	// Update product
-	err = h.productQueries.UpdateProduct(id, product)
-	if err != nil {
-		if err.Error() == "product not found" {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
-			return
+	if err := h.productQueries.UpdateProduct(id, product); err != nil {
+		if err.Error() == "product not found" || err.Error() == "product slug already exists" {
+			return err
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product"})
-		return
+		return fmt.Errorf("Failed to update product")
 	}
 	
 	// Update product images
-	err = h.productQueries.ReplaceImages(id, req.ImageIDs)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product images"})
-		return
+	if err := h.productQueries.ReplaceImages(id, req.ImageIDs); err != nil {
+		return fmt.Errorf("Failed to update product images")
 	}
 	
 	// Update product services
-	err = h.productQueries.ReplaceServices(id, req.AdditionalServiceIDs)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product services"})
-		return
+	if err := h.productQueries.ReplaceServices(id, req.AdditionalServiceIDs); err != nil {
+		return fmt.Errorf("Failed to update product services")
 	}
 	
-	// Return the updated product with relations
-	updatedProduct, err := h.productQueries.GetProduct(id)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve updated product"})
-		return
-	}
-	
-	response := models.ProductResponse{
-		ID:                 updatedProduct.ID,
-		Name:               updatedProduct.Name,
-		ShortDescription:   updatedProduct.ShortDescription,
-		Description:        updatedProduct.Description,
-		MaterialID:         updatedProduct.MaterialID,
-		MainImageID:        updatedProduct.MainImageID,
-		CategoryID:         updatedProduct.CategoryID,
-		CreatedAt:          updatedProduct.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:          updatedProduct.UpdatedAt.Format(time.RFC3339),
-		Material:           updatedProduct.Material,
-		MainImage:          updatedProduct.MainImage,
-		Category:           updatedProduct.Category,
-		Images:             updatedProduct.Images,
-		AdditionalServices: updatedProduct.AdditionalServices,
-	}
-	
-	c.JSON(http.StatusOK, response)
+	h.recordProductRevision(ctx, id, models.ProductRevisionUpdate, userID)
+	return nil
 }
 
-func (h *AdminHandler) DeleteProduct(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
-		return
-	}
-	
-	err = h.productQueries.DeleteProduct(id)
-	if err != nil {
-		if err.Error() == "product not found" {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete product"})
-		return
+// recordProductRevision snapshots a product after an edit. The edit is already
+// saved, so a failure is only logged.
+func (h *AdminHandler) recordProductRevision(ctx context.Context, productID int, action string, userID *int) {
+	if _, err := h.revisionQueries.RecordRevision(productID, action, userID); err != nil {
+		middleware.Logger(ctx).Error("failed to record product revision", "action", action, "product_id", productID, "error", err)
 	}
-	
-	c.JSON(http.StatusOK, gin.H{"message": "Product deleted successfully"})
 }
 
 // Validation helper methods for products
@@ -1571,6 +1992,7 @@ func (h *AdminHandler) ListSizes(c *gin.Context) {
 
 	sizes, total, err := h.sizeQueries.ListSizes(page, limit, search, productID)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -1611,10 +2033,17 @@ func (h *AdminHandler) CreateSize(c *gin.Context) {
 	}
 
 	if err := h.sizeQueries.CreateSize(size); err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	// New stock lands in the default warehouse
+	if err := h.warehouseQueries.ReconcileDefaultWarehouse(size.ID, getUserIDPtr(c)); err != nil {
+		middleware.Logger(c.Request.Context()).Error("failed to sync warehouse stock", "size_id", size.ID, "error", err)
+	}
+	h.recordProductRevision(c.Request.Context(), size.ProductID, models.ProductRevisionSizeCreate, getUserIDPtr(c))
+
 	c.JSON(http.StatusCreated, gin.H{"message": "Size created successfully", "id": size.ID})
 }
 
@@ -1631,6 +2060,7 @@ func (h *AdminHandler) GetSize(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Size not found"})
 			return
 		}
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -1673,6 +2103,17 @@ func (h *AdminHandler) UpdateSize(c *gin.Context) {
 		return
 	}
 
+	existing, err := h.sizeQueries.GetSizeByID(id)
+	if err != nil {
+		if err.Error() == "size not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Size not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	size := &models.Size{
 		ID:            id,
 		Name:          req.Name,
@@ -1693,10 +2134,20 @@ func (h *AdminHandler) UpdateSize(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Size not found"})
 			return
 		}
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	// Stock edited on the size is absorbed by the default warehouse
+	if err := h.warehouseQueries.ReconcileDefaultWarehouse(id, getUserIDPtr(c)); err != nil {
+		middleware.Logger(c.Request.Context()).Error("failed to sync warehouse stock", "size_id", id, "error", err)
+	}
+	h.recordProductRevision(c.Request.Context(), req.ProductID, models.ProductRevisionSizeUpdate, getUserIDPtr(c))
+	if existing.ProductID != req.ProductID {
+		h.recordProductRevision(c.Request.Context(), existing.ProductID, models.ProductRevisionSizeUpdate, getUserIDPtr(c))
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "Size updated successfully"})
 }
 
@@ -1707,15 +2158,29 @@ func (h *AdminHandler) DeleteSize(c *gin.Context) {
 		return
 	}
 
+	size, err := h.sizeQueries.GetSizeByID(id)
+	if err != nil {
+		if err.Error() == "size not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Size not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	if err := h.sizeQueries.DeleteSize(id); err != nil {
 		if err.Error() == "size not found" {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Size not found"})
 			return
 		}
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	h.recordProductRevision(c.Request.Context(), size.ProductID, models.ProductRevisionSizeDelete, getUserIDPtr(c))
+
 	c.JSON(http.StatusOK, gin.H{"message": "Size deleted successfully"})
 }
 
@@ -1763,6 +2228,7 @@ func (h *AdminHandler) ListProductVariants(c *gin.Context) {
 
 	variants, total, err := h.productVariantQueries.ListProductVariants(page, limit, search, productID, colorID)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -1808,17 +2274,24 @@ func (h *AdminHandler) CreateProductVariant(c *gin.Context) {
 	}
 
 	if err := h.productVariantQueries.CreateProductVariant(variant); err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	// Associate images with variant
 	if err := h.productVariantQueries.UpdateProductVariantImages(variant.ID, req.ImageIDs); err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to associate images"})
 		return
 	}
+	h.recordProductRevision(c.Request.Context(), variant.ProductID, models.ProductRevisionVariantCreate, getUserIDPtr(c))
 
-	c.JSON(http.StatusCreated, gin.H{"message": "Product variant created successfully", "id": variant.ID})
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Product variant created successfully",
+		"id":       variant.ID,
+		"warnings": h.variantWarnings(c.Request.Context(), variant.ID),
+	})
 }
 
 func (h *AdminHandler) GetProductVariant(c *gin.Context) {
@@ -1834,6 +2307,7 @@ func (h *AdminHandler) GetProductVariant(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Product variant not found"})
 			return
 		}
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -1885,6 +2359,17 @@ func (h *AdminHandler) UpdateProductVariant(c *gin.Context) {
 		return
 	}
 
+	existing, err := h.productVariantQueries.GetProductVariantByID(id)
+	if err != nil {
+		if err.Error() == "product variant not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product variant not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	variant := &models.ProductVariant{
 		ID:        id,
 		ProductID: req.ProductID,
@@ -1898,17 +2383,26 @@ func (h *AdminHandler) UpdateProductVariant(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Product variant not found"})
 			return
 		}
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	// Update images associated with variant
 	if err := h.productVariantQueries.UpdateProductVariantImages(id, req.ImageIDs); err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update images"})
 		return
 	}
+	h.recordProductRevision(c.Request.Context(), req.ProductID, models.ProductRevisionVariantUpdate, getUserIDPtr(c))
+	if existing.ProductID != req.ProductID {
+		h.recordProductRevision(c.Request.Context(), existing.ProductID, models.ProductRevisionVariantUpdate, getUserIDPtr(c))
+	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Product variant updated successfully"})
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Product variant updated successfully",
+		"warnings": h.variantWarnings(c.Request.Context(), id),
+	})
 }
 
 func (h *AdminHandler) DeleteProductVariant(c *gin.Context) {
@@ -1918,15 +2412,29 @@ func (h *AdminHandler) DeleteProductVariant(c *gin.Context) {
 		return
 	}
 
+	variant, err := h.productVariantQueries.GetProductVariantByID(id)
+	if err != nil {
+		if err.Error() == "product variant not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product variant not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	if err := h.productVariantQueries.DeleteProductVariant(id); err != nil {
 		if err.Error() == "product variant not found" {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Product variant not found"})
 			return
 		}
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	h.recordProductRevision(c.Request.Context(), variant.ProductID, models.ProductRevisionVariantDelete, getUserIDPtr(c))
+
 	c.JSON(http.StatusOK, gin.H{"message": "Product variant deleted successfully"})
 }
 
@@ -1938,6 +2446,8 @@ func (h *AdminHandler) ListOrders(c *gin.Context) {
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
 	email := c.Query("email")
 	status := c.Query("status")
+	// Archived orders are only listed for compliance lookups
+	includeArchived := c.Query("include_archived") == "true"
 
 	if page < 1 {
 		page = 1
@@ -1946,15 +2456,49 @@ func (h *AdminHandler) ListOrders(c *gin.Context) {
 		limit = 10
 	}
 
-	orders, err := h.orderQueries.ListOrders(page, limit, nil, email, status)
+	after, ok := parseCursor(c)
+	if !ok {
+		return
+	}
+
+	orders, err := h.orderQueries.ListOrders(page, limit, after, nil, email, status, includeArchived)
 	if err != nil {
+		if err.Error() == "invalid cursor" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get orders"})
 		return
 	}
+	h.flagDuplicateOrders(c.Request.Context(), orders.Orders)
+	h.flagUndeliverableOrders(c.Request.Context(), orders.Orders)
 
 	c.JSON(http.StatusOK, orders)
 }
 
+// ListTotalsMismatches returns checkouts rejected because the client's totals differed
+// from the server's, newest first
+func (h *AdminHandler) ListTotalsMismatches(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	response, err := h.orderQueries.ListTotalsMismatches(page, limit)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get totals mismatches"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
 func (h *AdminHandler) GetOrderDetails(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
@@ -1969,11 +2513,14 @@ func (h *AdminHandler) GetOrderDetails(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
 			return
 		}
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get order"})
 		return
 	}
+	flagged := []models.OrderResponse{*order}
+	h.flagUndeliverableOrders(c.Request.Context(), flagged)
 
-	c.JSON(http.StatusOK, order)
+	c.JSON(http.StatusOK, flagged[0])
 }
 
 func (h *AdminHandler) UpdateOrderStatus(c *gin.Context) {
@@ -1990,39 +2537,122 @@ func (h *AdminHandler) UpdateOrderStatus(c *gin.Context) {
 		return
 	}
 
-	// Validate status
-	validStatuses := []string{
-		models.OrderStatusPending,
-		models.OrderStatusProcessing,
-		models.OrderStatusShipped,
-		models.OrderStatusDelivered,
-		models.OrderStatusCancelled,
+	// The status is a core status or the code of a custom one
+	status, customStatusID, ok := resolveOrderStatus(c, h.orderStatusQueries, req.Status)
+	if !ok {
+		return
 	}
-	
-	isValid := false
-	for _, status := range validStatuses {
-		if req.Status == status {
-			isValid = true
-			break
+
+	change, err := h.orderQueries.UpdateOrderStatus(id, status, customStatusID)
+	if err != nil {
+		if err.Error() == "order not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update order status"})
+		return
+	}
+	publishStatusChange(c.Request.Context(), h.webhookQueries, change)
+	if req.NotifyCustomer == nil || *req.NotifyCustomer {
+		queueStatusEmail(c.Request.Context(), h.emailQueries, h.orderQueries, change)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Order status updated successfully"})
+}
+
+// SetOrderTest marks an order as a test order, leaving it out of reports and webhooks,
+// or turns a test order back into a real one
+func (h *AdminHandler) SetOrderTest(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	var req models.OrderTestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.orderQueries.SetOrderTest(id, req.IsTest); err != nil {
+		if err.Error() == "order not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+			return
 		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update order"})
+		return
 	}
-	
-	if !isValid {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Order updated successfully", "is_test": req.IsTest})
+}
+
+// UpdateOrderShippingAddress replaces the shipping address of an order
+func (h *AdminHandler) UpdateOrderShippingAddress(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
 		return
 	}
 
-	err = h.orderQueries.UpdateOrderStatus(id, req.Status)
+	var req models.AddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.orderQueries.UpdateOrderShippingAddress(id, &req)
 	if err != nil {
 		if err.Error() == "order not found" {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update order status"})
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update shipping address"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Order status updated successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "Shipping address updated successfully"})
+}
+
+// UpdateOrderItemSize swaps an order item to another size of the same product
+func (h *AdminHandler) UpdateOrderItemSize(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	itemID, err := strconv.Atoi(c.Param("itemId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order item ID"})
+		return
+	}
+
+	var req models.OrderItemSizeUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.orderQueries.UpdateOrderItemSize(id, itemID, req.SizeID)
+	if err != nil {
+		switch {
+		case err.Error() == "order item not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order item not found"})
+		case err.Error() == "size not found for product", strings.Contains(err.Error(), "insufficient stock"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update order item size"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Order item size updated successfully"})
 }
 
 func (h *AdminHandler) DeleteOrder(c *gin.Context) {
@@ -2039,6 +2669,7 @@ func (h *AdminHandler) DeleteOrder(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
 			return
 		}
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete order"})
 		return
 	}
@@ -2051,6 +2682,7 @@ func (h *AdminHandler) DeleteOrder(c *gin.Context) {
 func (h *AdminHandler) GetSettings(c *gin.Context) {
 	settings, err := h.settingsQueries.GetAllSettings()
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get settings"})
 		return
 	}
@@ -2077,12 +2709,53 @@ func (h *AdminHandler) UpdateSetting(c *gin.Context) {
 		return
 	}
 
+	if (key == models.SettingContentApprovalRequired || strings.HasPrefix(key, "captcha_")) && req.Value != "true" && req.Value != "false" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": key + " must be 'true' or 'false'"})
+		return
+	}
+
+	// Validate retention periods, the admin idle timeout, pairing thresholds, the attachment size limit, the cart price refresh age, the low stock threshold, the order archive age and the daily production capacity
+	if strings.HasPrefix(key, "retention_") || strings.HasPrefix(key, "pairing_") || key == models.SettingAdminIdleTimeoutMinutes ||
+		key == models.SettingAttachmentMaxSizeMB || key == models.SettingCartPriceRefreshDays || key == models.SettingLowStockThreshold ||
+		key == models.SettingOrderArchiveAfterYears || key == models.SettingProductionCapacityPerDay {
+		if days, err := strconv.Atoi(req.Value); err != nil || days < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": key + " must be a non-negative number"})
+			return
+		}
+	}
+
+	if key == models.SettingShopTimezone {
+		if _, err := time.LoadLocation(req.Value); err != nil || req.Value == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": key + " must be an IANA time zone such as Europe/Warsaw"})
+			return
+		}
+	}
+
+	// Validate the states and incident notes of the status page
+	if (key == models.SettingStatusPayments || key == models.SettingStatusShipping) && !validComponentStatus(req.Value) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": key + " must be 'operational', 'degraded' or 'outage'"})
+		return
+	}
+	if key == models.SettingStatusIncidents {
+		if _, err := parseStatusIncidents(req.Value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": key + " must be a JSON array of incidents with title, message, status and created_at"})
+			return
+		}
+	}
+
+	// Validate warehouse allocation strategy
+	if key == models.SettingWarehouseAllocationStrategy && req.Value != models.AllocationStrategyPriority && req.Value != models.AllocationStrategyNearest {
+		c.JSON(http.StatusBadRequest, gin.H{"error": key + " must be 'priority' or 'nearest'"})
+		return
+	}
+
 	err := h.settingsQueries.UpdateSetting(key, req.Value)
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Setting not found"})
 			return
 		}
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update setting"})
 		return
 	}
@@ -2090,6 +2763,7 @@ func (h *AdminHandler) UpdateSetting(c *gin.Context) {
 	// Get updated setting
 	setting, err := h.settingsQueries.GetSettingByKey(key)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get updated setting"})
 		return
 	}
@@ -2111,8 +2785,15 @@ func (h *AdminHandler) ListClientReviews(c *gin.Context) {
 		limit = 20
 	}
 
-	reviews, total, err := h.clientReviewQueries.ListClientReviews(page, limit, activeOnly)
+	filter := models.ClientReviewFilter{ActiveOnly: activeOnly}
+	var ok bool
+	if filter.Rating, ok = ratingQuery(c, "rating"); !ok {
+		return
+	}
+
+	reviews, total, err := h.clientReviewQueries.ListClientReviews(page, limit, filter)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve client reviews"})
 		return
 	}
@@ -2141,6 +2822,7 @@ func (h *AdminHandler) GetClientReview(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Client review not found"})
 			return
 		}
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get client review"})
 		return
 	}
@@ -2164,6 +2846,7 @@ func (h *AdminHandler) CreateClientReview(c *gin.Context) {
 
 	review, err := h.clientReviewQueries.CreateClientReview(req)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create client review"})
 		return
 	}
@@ -2198,6 +2881,7 @@ func (h *AdminHandler) UpdateClientReview(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Client review not found"})
 			return
 		}
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update client review"})
 		return
 	}
@@ -2213,17 +2897,18 @@ func (h *AdminHandler) DeleteClientReview(c *gin.Context) {
 		return
 	}
 
-	err = h.clientReviewQueries.DeleteClientReview(id)
+	trashID, err := h.trashQueries.MoveToTrash(models.TrashEntityClientReview, id, getUserIDPtr(c))
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Client review not found"})
 			return
 		}
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete client review"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Client review deleted successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "Client review deleted successfully", "trash_id": trashID})
 }
 
 func (h *AdminHandler) ReorderClientReviews(c *gin.Context) {
@@ -2244,6 +2929,7 @@ func (h *AdminHandler) ReorderClientReviews(c *gin.Context) {
 
 	err := h.clientReviewQueries.ReorderClientReviews(orders)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reorder client reviews"})
 		return
 	}
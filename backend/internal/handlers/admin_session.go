@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"notsofluffy-backend/internal/auth"
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/middleware"
+	"notsofluffy-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const totpIssuer = "NotSoFluffy"
+
+type AdminSessionHandler struct {
+	sessionQueries *database.AdminSessionQueries
+	userQueries    *database.UserQueries
+}
+
+func NewAdminSessionHandler(sessionQueries *database.AdminSessionQueries, userQueries *database.UserQueries) *AdminSessionHandler {
+	return &AdminSessionHandler{sessionQueries: sessionQueries, userQueries: userQueries}
+}
+
+// GetSession returns idle timeout and sudo mode state of the current admin session
+func (h *AdminSessionHandler) GetSession(c *gin.Context) {
+	session := middleware.GetAdminSession(c)
+	if session == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Admin session not found"})
+		return
+	}
+
+	timeout, err := h.sessionQueries.GetIdleTimeout()
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get idle timeout"})
+		return
+	}
+
+	_, totpEnabled, err := h.sessionQueries.GetTOTP(session.UserID)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get TOTP settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.AdminSessionResponse{
+		IdleTimeoutMinutes: int(timeout / time.Minute),
+		LastSeenAt:         session.LastSeenAt,
+		SudoUntil:          session.SudoUntil(time.Now()),
+		TOTPEnabled:        totpEnabled,
+	})
+}
+
+// EnterSudo re-authenticates the admin with a password or TOTP code to unlock destructive actions
+func (h *AdminSessionHandler) EnterSudo(c *gin.Context) {
+	session := middleware.GetAdminSession(c)
+	if session == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Admin session not found"})
+		return
+	}
+
+	var req models.SudoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch {
+	case req.TOTPCode != "":
+		secret, enabled, err := h.sessionQueries.GetTOTP(session.UserID)
+		if err != nil {
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get TOTP settings"})
+			return
+		}
+		if !enabled || secret == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Two-factor authentication is not enabled"})
+			return
+		}
+		step, ok := auth.MatchTOTP(*secret, req.TOTPCode, time.Now())
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid code"})
+			return
+		}
+		fresh, err := h.sessionQueries.UseTOTPStep(session.UserID, step)
+		if err != nil {
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check TOTP code"})
+			return
+		}
+		if !fresh {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "This code was already used, wait for the next one"})
+			return
+		}
+	case req.Password != "":
+		user, err := h.userQueries.GetUserByID(session.UserID)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			return
+		}
+		if !auth.CheckPassword(req.Password, user.PasswordHash) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid password"})
+			return
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Password or TOTP code is required"})
+		return
+	}
+
+	sudoAt, err := h.sessionQueries.MarkSudo(session.ID)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start sudo mode"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sudo_until": sudoAt.Add(models.SudoWindow)})
+}
+
+// Logout revokes the current admin session so its tokens stop working
+func (h *AdminSessionHandler) Logout(c *gin.Context) {
+	session := middleware.GetAdminSession(c)
+	if session == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Admin session not found"})
+		return
+	}
+
+	if err := h.sessionQueries.RevokeSession(session.ID); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+// SetupTOTP generates a new TOTP secret; it becomes active after EnableTOTP
+func (h *AdminSessionHandler) SetupTOTP(c *gin.Context) {
+	session := middleware.GetAdminSession(c)
+	if session == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Admin session not found"})
+		return
+	}
+
+	user, err := h.userQueries.GetUserByID(session.UserID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate TOTP secret"})
+		return
+	}
+
+	if err := h.sessionQueries.SetTOTPSecret(user.ID, secret); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store TOTP secret"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.TOTPSetupResponse{
+		Secret:     secret,
+		OTPAuthURL: auth.TOTPURL(totpIssuer, user.Email, secret),
+	})
+}
+
+// EnableTOTP activates the pending TOTP secret after checking a code from the authenticator app
+func (h *AdminSessionHandler) EnableTOTP(c *gin.Context) {
+	session := middleware.GetAdminSession(c)
+	if session == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Admin session not found"})
+		return
+	}
+
+	var req models.TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	secret, _, err := h.sessionQueries.GetTOTP(session.UserID)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get TOTP settings"})
+		return
+	}
+	if secret == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Set up two-factor authentication first"})
+		return
+	}
+	step, ok := auth.MatchTOTP(*secret, req.Code, time.Now())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid code"})
+		return
+	}
+	// The enrollment code must not also start sudo mode
+	if _, err := h.sessionQueries.UseTOTPStep(session.UserID, step); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check TOTP code"})
+		return
+	}
+
+	if err := h.sessionQueries.SetTOTPEnabled(session.UserID, true); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable two-factor authentication"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication enabled"})
+}
+
+// DisableTOTP turns off TOTP and removes the secret
+func (h *AdminSessionHandler) DisableTOTP(c *gin.Context) {
+	session := middleware.GetAdminSession(c)
+	if session == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Admin session not found"})
+		return
+	}
+
+	if err := h.sessionQueries.SetTOTPEnabled(session.UserID, false); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable two-factor authentication"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/middleware"
+	"notsofluffy-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// maxEventPropertiesSize caps the free-form properties payload of a single event
+	maxEventPropertiesSize = 4096
+	// maxEventClockSkew is how far a client-reported occurred_at may be from server time
+	maxEventClockSkew = 24 * time.Hour
+)
+
+type AnalyticsHandler struct {
+	analyticsQueries *database.AnalyticsQueries
+}
+
+func NewAnalyticsHandler(analyticsQueries *database.AnalyticsQueries) *AnalyticsHandler {
+	return &AnalyticsHandler{analyticsQueries: analyticsQueries}
+}
+
+// TrackEvents stores a single event or a batch ({"events": [...]}) from the storefront
+func (h *AnalyticsHandler) TrackEvents(c *gin.Context) {
+	sessionID := middleware.GetSessionID(c)
+	if sessionID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Session not found"})
+		return
+	}
+
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	var payload struct {
+		models.AnalyticsEventRequest
+		Events []models.AnalyticsEventRequest `json:"events"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	requests := payload.Events
+	if len(requests) == 0 && payload.Type != "" {
+		requests = []models.AnalyticsEventRequest{payload.AnalyticsEventRequest}
+	}
+	if len(requests) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No events provided"})
+		return
+	}
+	if len(requests) > models.MaxEventBatchSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Too many events in one batch"})
+		return
+	}
+
+	userID := getUserIDPtr(c)
+	var country *string
+	if code := middleware.GetCountryCode(c); code != "" {
+		country = &code
+	}
+
+	now := time.Now()
+	events := make([]models.AnalyticsEvent, 0, len(requests))
+	for _, req := range requests {
+		if errMsg := validateAnalyticsEvent(&req, now); errMsg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errMsg})
+			return
+		}
+		events = append(events, models.AnalyticsEvent{
+			AnalyticsEventRequest: req,
+			SessionID:             sessionID,
+			UserID:                userID,
+			Country:               country,
+		})
+	}
+
+	if err := h.analyticsQueries.InsertEvents(events); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store events"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"accepted": len(events)})
+}
+
+// GetFunnelReport returns funnel conversion and purchase attribution, by default for the last 30 days
+func (h *AnalyticsHandler) GetFunnelReport(c *gin.Context) {
+	to := time.Now()
+	from := to.AddDate(0, 0, -30)
+
+	if fromStr := c.Query("from"); fromStr != "" {
+		t, err := time.Parse("2006-01-02", fromStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date, expected YYYY-MM-DD"})
+			return
+		}
+		from = t
+	}
+
+	if toStr := c.Query("to"); toStr != "" {
+		t, err := time.Parse("2006-01-02", toStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date, expected YYYY-MM-DD"})
+			return
+		}
+		// Include the whole end day
+		to = t.AddDate(0, 0, 1)
+	}
+
+	if !from.Before(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be before to"})
+		return
+	}
+
+	report, err := h.analyticsQueries.GetFunnelReport(from, to)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate funnel report"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// validateAnalyticsEvent checks the event type and trims oversized fields; it returns an error message or ""
+func validateAnalyticsEvent(req *models.AnalyticsEventRequest, now time.Time) string {
+	valid := false
+	for _, step := range models.FunnelSteps {
+		if req.Type == step {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return "Invalid event type: " + req.Type
+	}
+
+	if len(req.Properties) > maxEventPropertiesSize {
+		return "Event properties are too large"
+	}
+	if len(req.Properties) > 0 && !json.Valid(req.Properties) {
+		return "Event properties must be valid JSON"
+	}
+
+	// Ignore client timestamps that are clearly wrong rather than rejecting the batch
+	if req.OccurredAt != nil && (req.OccurredAt.After(now.Add(time.Minute)) || now.Sub(*req.OccurredAt) > maxEventClockSkew) {
+		req.OccurredAt = nil
+	}
+
+	req.Path = truncateString(req.Path, 2048)
+	req.Referrer = truncateString(req.Referrer, 2048)
+	req.UTMSource = truncateString(req.UTMSource, 100)
+	req.UTMMedium = truncateString(req.UTMMedium, 100)
+	req.UTMCampaign = truncateString(req.UTMCampaign, 100)
+
+	return ""
+}
+
+func truncateString(value *string, max int) *string {
+	if value == nil {
+		return nil
+	}
+	runes := []rune(*value)
+	if len(runes) <= max {
+		return value
+	}
+	truncated := string(runes[:max])
+	return &truncated
+}
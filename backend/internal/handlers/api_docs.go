@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"notsofluffy-backend/internal/openapi"
+)
+
+// swaggerUIVersion pins the Swagger UI assets loaded from unpkg
+const swaggerUIVersion = "5.17.14"
+
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>NotSoFluffy API</title>
+<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@` + swaggerUIVersion + `/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="https://unpkg.com/swagger-ui-dist@` + swaggerUIVersion + `/swagger-ui-bundle.js"></script>
+<script>
+window.ui = SwaggerUIBundle({url: "/api/docs/openapi.json", dom_id: "#swagger-ui"});
+</script>
+</body>
+</html>`
+
+// swaggerUICSP relaxes the API's policy for the Swagger UI assets
+const swaggerUICSP = "default-src 'self'; script-src 'self' 'unsafe-inline' https://unpkg.com; " +
+	"style-src 'self' 'unsafe-inline' https://unpkg.com; img-src 'self' data: https:; " +
+	"connect-src 'self'; object-src 'none'; frame-ancestors 'none'; base-uri 'self'"
+
+type APIDocsHandler struct {
+	spec []byte
+}
+
+func NewAPIDocsHandler() *APIDocsHandler {
+	return &APIDocsHandler{}
+}
+
+// SetRoutes builds the specification of the routes registered on the router. It is
+// called once every route is registered, before the server starts.
+func (h *APIDocsHandler) SetRoutes(info openapi.Info, routes gin.RoutesInfo, annotations openapi.Annotations) error {
+	spec, err := json.Marshal(openapi.Build(info, routes, annotations))
+	if err != nil {
+		return err
+	}
+	h.spec = spec
+	return nil
+}
+
+// GetSpec serves the OpenAPI specification
+func (h *APIDocsHandler) GetSpec(c *gin.Context) {
+	if h.spec == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "API specification is not available"})
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", h.spec)
+}
+
+// GetUI serves Swagger UI for the specification
+func (h *APIDocsHandler) GetUI(c *gin.Context) {
+	c.Header("Content-Security-Policy", swaggerUICSP)
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerUIPage))
+}
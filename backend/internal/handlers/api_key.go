@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/models"
+)
+
+// maxAPIKeyUsageDays bounds the usage history of one request
+const maxAPIKeyUsageDays = 366
+
+type APIKeyHandler struct {
+	keyQueries *database.APIKeyQueries
+}
+
+func NewAPIKeyHandler(keyQueries *database.APIKeyQueries) *APIKeyHandler {
+	return &APIKeyHandler{keyQueries: keyQueries}
+}
+
+// ListAPIKeys returns all partner API keys with their request counts
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	keys, err := h.keyQueries.ListAPIKeys()
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get API keys"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"api_keys": keys})
+}
+
+// CreateAPIKey issues a partner API key. The key is only part of this response.
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	var req models.APIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	key, err := h.keyQueries.CreateAPIKey(&req, getUserIDPtr(c))
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API key"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, key)
+}
+
+// RevokeAPIKey stops a partner API key from working
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+		return
+	}
+
+	if err := h.keyQueries.RevokeAPIKey(id); err != nil {
+		if err.Error() == "api key not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API key"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked successfully"})
+}
+
+// GetAPIKeyUsage returns the daily requests of a key over the last ?days=30
+func (h *APIKeyHandler) GetAPIKeyUsage(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days < 1 || days > maxAPIKeyUsageDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 366"})
+		return
+	}
+
+	usage, err := h.keyQueries.GetUsage(id, days)
+	if err != nil {
+		if err.Error() == "api key not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get API key usage"})
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
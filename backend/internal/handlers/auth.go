@@ -1,27 +1,50 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"notsofluffy-backend/internal/auth"
 	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/mail"
+	"notsofluffy-backend/internal/middleware"
 	"notsofluffy-backend/internal/models"
+	"notsofluffy-backend/internal/ratelimit"
 
 	"github.com/gin-gonic/gin"
 )
 
 type AuthHandler struct {
-	userQueries    *database.UserQueries
-	profileQueries *database.ProfileQueries
-	jwtSecret      string
+	userQueries     *database.UserQueries
+	profileQueries  *database.ProfileQueries
+	legalQueries    *database.LegalQueries
+	sessionQueries  *database.AdminSessionQueries
+	tokenQueries    *database.RefreshTokenQueries
+	settingsQueries *database.SettingsQueries
+	cartQueries     *database.CartQueries
+	mailer          mail.Sender
+	resetLimiter    *ratelimit.Limiter
+	jwtSecret       string
+	siteURL         string
 }
 
-func NewAuthHandler(db *sql.DB, jwtSecret string) *AuthHandler {
+func NewAuthHandler(db *sql.DB, jwtSecret string, mailer mail.Sender, siteURL string) *AuthHandler {
 	return &AuthHandler{
-		userQueries:    database.NewUserQueries(db),
-		profileQueries: database.NewProfileQueries(db),
-		jwtSecret:      jwtSecret,
+		userQueries:     database.NewUserQueries(db),
+		profileQueries:  database.NewProfileQueries(db),
+		legalQueries:    database.NewLegalQueries(db),
+		sessionQueries:  database.NewAdminSessionQueries(db),
+		tokenQueries:    database.NewRefreshTokenQueries(db),
+		settingsQueries: database.NewSettingsQueries(db),
+		cartQueries:     database.NewCartQueries(db),
+		mailer:          mailer,
+		resetLimiter:    ratelimit.New(),
+		jwtSecret:       jwtSecret,
+		siteURL:         siteURL,
 	}
 }
 
@@ -35,6 +58,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	// Check if email already exists
 	exists, err := h.userQueries.EmailExists(req.Email)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check email"})
 		return
 	}
@@ -43,9 +67,22 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
+	// Require acceptance of the current terms and privacy policy
+	currentDocs, err := h.legalQueries.GetCurrentDocuments()
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get legal documents"})
+		return
+	}
+	if missing := missingAcceptances(currentDocs, req.AcceptedDocuments); len(missing) > 0 {
+		respondLegalAcceptanceRequired(c, http.StatusBadRequest, missing)
+		return
+	}
+
 	// Hash password
 	hashedPassword, err := auth.HashPassword(req.Password)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
 		return
 	}
@@ -70,10 +107,18 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	}
 
 	if err := h.userQueries.CreateUser(user); err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
 		return
 	}
 
+	// Record legal acceptance
+	if err := h.legalQueries.RecordAcceptance(currentDocs, &user.ID, nil, middleware.GetClientIP(c), userAgentPtr(c)); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record legal acceptance"})
+		return
+	}
+
 	// Create user profile
 	_, err = h.profileQueries.CreateUserProfile(user.ID)
 	if err != nil {
@@ -82,14 +127,16 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	}
 
 	// Generate tokens
-	accessToken, err := auth.GenerateAccessToken(user.ID, user.Email, user.Role, h.jwtSecret)
+	accessToken, err := auth.GenerateAccessToken(user.ID, user.Email, user.Role, "", user.TokenVersion, h.jwtSecret)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate access token"})
 		return
 	}
 
-	refreshToken, err := auth.GenerateRefreshToken(user.ID, user.Email, user.Role, h.jwtSecret)
+	refreshToken, err := h.issueRefreshToken(c, user, "")
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate refresh token"})
 		return
 	}
@@ -123,23 +170,71 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	// Force re-acceptance when a new terms or privacy policy version was published
+	pendingDocs, err := h.legalQueries.GetPendingDocumentsForUser(user.ID)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get legal documents"})
+		return
+	}
+	if missing := missingAcceptances(pendingDocs, req.AcceptedDocuments); len(missing) > 0 {
+		respondLegalAcceptanceRequired(c, http.StatusForbidden, missing)
+		return
+	}
+	if err := h.legalQueries.RecordAcceptance(pendingDocs, &user.ID, nil, middleware.GetClientIP(c), userAgentPtr(c)); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record legal acceptance"})
+		return
+	}
+
+	// Staff logins get a server-side session for idle timeout and sudo mode
+	var sessionID string
+	if models.IsStaffRole(user.Role) {
+		session, err := h.sessionQueries.CreateSession(user.ID, middleware.GetClientIP(c), userAgentPtr(c))
+		if err != nil {
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create admin session"})
+			return
+		}
+		sessionID = session.ID
+	}
+
 	// Generate tokens
-	accessToken, err := auth.GenerateAccessToken(user.ID, user.Email, user.Role, h.jwtSecret)
+	accessToken, err := auth.GenerateAccessToken(user.ID, user.Email, user.Role, sessionID, user.TokenVersion, h.jwtSecret)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate access token"})
 		return
 	}
 
-	refreshToken, err := auth.GenerateRefreshToken(user.ID, user.Email, user.Role, h.jwtSecret)
+	refreshToken, err := h.issueRefreshToken(c, user, sessionID)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate refresh token"})
 		return
 	}
 
+	login, err := h.userQueries.RecordLogin(user.ID, middleware.GetClientIP(c), userAgentPtr(c))
+	if err != nil {
+		middleware.Logger(c.Request.Context()).Error("failed to record login", "user_id", user.ID, "error", err)
+	} else if login.NewDevice {
+		go h.sendNewDeviceAlert(c.Request.Context(), user.Email, login)
+	}
+
+	// What the guest put in the cart joins what the user left in carts on other devices
+	var cartMerge *models.CartMergeResult
+	if cartSessionID := middleware.GetSessionID(c); cartSessionID != "" {
+		cartMerge, err = h.cartQueries.MergeUserCarts(cartSessionID, user.ID)
+		if err != nil {
+			middleware.Logger(c.Request.Context()).Error("failed to merge carts", "user_id", user.ID, "error", err)
+		}
+	}
+
 	response := models.AuthResponse{
 		User:         *user,
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
+		CartMerge:    cartMerge,
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -165,20 +260,54 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
 		return
 	}
+	if claims.TokenVersion != user.TokenVersion {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Session revoked, please log in again",
+			"code":  models.SessionRevokedCode,
+		})
+		return
+	}
+
+	// Refreshing does not extend an idle admin session
+	var sessionID string
+	if models.IsStaffRole(user.Role) {
+		if _, err := h.sessionQueries.ValidateSession(claims.SessionID, user.ID); err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Admin session expired, please log in again",
+				"code":  models.AdminSessionExpiredCode,
+			})
+			return
+		}
+		sessionID = claims.SessionID
+	}
 
 	// Generate new tokens
-	accessToken, err := auth.GenerateAccessToken(user.ID, user.Email, user.Role, h.jwtSecret)
+	accessToken, err := auth.GenerateAccessToken(user.ID, user.Email, user.Role, sessionID, user.TokenVersion, h.jwtSecret)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate access token"})
 		return
 	}
 
-	newRefreshToken, err := auth.GenerateRefreshToken(user.ID, user.Email, user.Role, h.jwtSecret)
+	newRefreshToken, err := auth.GenerateRefreshToken(user.ID, user.Email, user.Role, sessionID, user.TokenVersion, h.jwtSecret)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate refresh token"})
 		return
 	}
 
+	// Every refresh token works once; reusing one signs out the login it belongs to
+	err = h.tokenQueries.RotateRefreshToken(user.ID, req.RefreshToken, newRefreshToken, middleware.GetClientIP(c), userAgentPtr(c))
+	if err != nil {
+		if err.Error() == "refresh token not found" || err.Error() == "refresh token reused" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate refresh token"})
+		return
+	}
+
 	response := models.AuthResponse{
 		User:         *user,
 		AccessToken:  accessToken,
@@ -188,6 +317,54 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// issueRefreshToken returns a refresh token for a new login and stores it
+func (h *AuthHandler) issueRefreshToken(c *gin.Context, user *models.User, sessionID string) (string, error) {
+	token, err := auth.GenerateRefreshToken(user.ID, user.Email, user.Role, sessionID, user.TokenVersion, h.jwtSecret)
+	if err != nil {
+		return "", err
+	}
+	if err := h.tokenQueries.CreateRefreshToken(user.ID, token, middleware.GetClientIP(c), userAgentPtr(c)); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Logout signs out the login a refresh token belongs to, including its admin session.
+// Access tokens already issued to it expire on their own.
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var req models.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.tokenQueries.RevokeRefreshToken(req.RefreshToken); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+
+	if claims, err := auth.ValidateToken(req.RefreshToken, h.jwtSecret); err == nil && claims.SessionID != "" {
+		if err := h.sessionQueries.RevokeSession(claims.SessionID); err != nil {
+			middleware.Logger(c.Request.Context()).Error("failed to revoke admin session", "user_id", claims.UserID, "error", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+// LogoutAll signs the current user out on every device. Access tokens issued until
+// now stop working too.
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	if err := h.tokenQueries.RevokeAllSessions(c.GetInt("user_id")); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out on all devices"})
+}
+
 func (h *AuthHandler) Profile(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -197,6 +374,7 @@ func (h *AuthHandler) Profile(c *gin.Context) {
 
 	id, ok := userID.(int)
 	if !ok {
+		c.Error(fmt.Errorf("invalid user ID type %T", userID))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID type"})
 		return
 	}
@@ -208,4 +386,50 @@ func (h *AuthHandler) Profile(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{"user": user})
-}
\ No newline at end of file
+}
+
+// GetLoginHistory returns the current user's recent logins
+func (h *AuthHandler) GetLoginHistory(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	history, err := h.userQueries.GetLoginHistory(c.GetInt("user_id"), page, limit)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get login history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// sendNewDeviceAlert tells the user about a login from a device or network they
+// haven't used before
+func (h *AuthHandler) sendNewDeviceAlert(ctx context.Context, email string, login *models.LoginRecord) {
+	userAgent := "unknown"
+	if login.UserAgent != nil {
+		userAgent = *login.UserAgent
+	}
+	when := login.CreatedAt.In(h.settingsQueries.GetShopLocation()).Format("2006-01-02 15:04 MST")
+
+	msg := mail.Message{
+		To:      email,
+		Subject: "New sign-in to your NotSoFluffy account",
+		Body: fmt.Sprintf("Your account was signed in to from a new device or network.\n\n"+
+			"Time: %s\nIP address: %s\nBrowser: %s\n\n"+
+			"If this was you, you can ignore this email. Otherwise change your password right away.\n",
+			when, login.IPAddress, userAgent),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := h.mailer.Send(ctx, msg); err != nil {
+		middleware.Logger(ctx).Error("failed to send new device alert", "email", email, "error", err)
+	}
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"net/http"
+
+	"notsofluffy-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bulkErrors maps the errors of single records of bulk operations to the messages
+// reported for them; other errors are reported as the operation's failure message
+var bulkErrors = map[string]string{
+	"product not found":  "Product not found",
+	"category not found": "Category not found",
+	"order not found":    "Order not found",
+}
+
+// BulkDeleteProducts moves the products in ids to the trash in one transaction
+func (h *AdminHandler) BulkDeleteProducts(c *gin.Context) {
+	var req models.BulkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ids := uniqueIDs(req.IDs)
+	failed, err := h.trashQueries.BulkMoveToTrash(models.TrashEntityProduct, ids, getUserIDPtr(c))
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete products"})
+		return
+	}
+	c.JSON(http.StatusOK, bulkResponse(c, ids, failed, "Failed to delete product"))
+}
+
+// BulkActivateCategories activates the categories in ids in one transaction
+func (h *AdminHandler) BulkActivateCategories(c *gin.Context) {
+	h.bulkSetCategoriesActive(c, true)
+}
+
+// BulkDeactivateCategories deactivates the categories in ids in one transaction
+func (h *AdminHandler) BulkDeactivateCategories(c *gin.Context) {
+	h.bulkSetCategoriesActive(c, false)
+}
+
+func (h *AdminHandler) bulkSetCategoriesActive(c *gin.Context, active bool) {
+	var req models.BulkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ids := uniqueIDs(req.IDs)
+	failed, err := h.categoryQueries.BulkSetCategoriesActive(ids, active)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update categories"})
+		return
+	}
+	c.JSON(http.StatusOK, bulkResponse(c, ids, failed, "Failed to update category"))
+}
+
+// BulkUpdateOrderStatus sets the status of the orders in ids in one transaction. Webhooks
+// and customer emails go out for the orders that changed, as for single updates.
+func (h *AdminHandler) BulkUpdateOrderStatus(c *gin.Context) {
+	var req models.BulkOrderStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	status, customStatusID, ok := resolveOrderStatus(c, h.orderStatusQueries, req.Status)
+	if !ok {
+		return
+	}
+
+	ids := uniqueIDs(req.IDs)
+	changes, failed, err := h.orderQueries.BulkUpdateOrderStatus(ids, status, customStatusID)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update order status"})
+		return
+	}
+	for _, change := range changes {
+		publishStatusChange(c.Request.Context(), h.webhookQueries, change)
+		if req.NotifyCustomer == nil || *req.NotifyCustomer {
+			queueStatusEmail(c.Request.Context(), h.emailQueries, h.orderQueries, change)
+		}
+	}
+	c.JSON(http.StatusOK, bulkResponse(c, ids, failed, "Failed to update order status"))
+}
+
+// bulkResponse reports the outcome of a bulk operation for each ID. Unexpected errors
+// are logged and reported as failureMessage.
+func bulkResponse(c *gin.Context, ids []int, failed map[int]error, failureMessage string) models.BulkResponse {
+	response := models.BulkResponse{Results: make([]models.BulkItemResult, len(ids))}
+	for i, id := range ids {
+		result := models.BulkItemResult{ID: id, Success: true}
+		if err, ok := failed[id]; ok {
+			result.Success = false
+			if message, known := bulkErrors[err.Error()]; known {
+				result.Error = message
+			} else {
+				c.Error(err)
+				result.Error = failureMessage
+			}
+			response.Failed++
+		} else {
+			response.Succeeded++
+		}
+		response.Results[i] = result
+	}
+	return response
+}
+
+// uniqueIDs returns ids without repetitions, in their order
+func uniqueIDs(ids []int) []int {
+	seen := make(map[int]bool, len(ids))
+	unique := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
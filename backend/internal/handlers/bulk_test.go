@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBulkResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+	ids := uniqueIDs([]int{3, 1, 3, 2})
+	failed := map[int]error{1: errors.New("product not found"), 2: errors.New("failed to delete products: deadlock")}
+	response := bulkResponse(c, ids, failed, "Failed to delete product")
+
+	if response.Succeeded != 1 || response.Failed != 2 || len(response.Results) != 3 {
+		t.Fatalf("expected 1 success and 2 failures for 3 unique IDs, got %+v", response)
+	}
+	if r := response.Results[0]; r.ID != 3 || !r.Success {
+		t.Fatalf("expected the first ID to succeed, got %+v", r)
+	}
+	if r := response.Results[1]; r.Error != "Product not found" {
+		t.Fatalf("expected a known error to be reported as is, got %q", r.Error)
+	}
+	if r := response.Results[2]; r.Error != "Failed to delete product" || len(c.Errors) != 1 {
+		t.Fatalf("expected an unexpected error to be logged and reported generically, got %q", r.Error)
+	}
+}
+
+func TestBulkUpdateOrderStatusRejectsInvalidStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/admin/orders/bulk-status", bytes.NewBufferString(`{"ids":[1],"status":"Lost"}`))
+	(&AdminHandler{}).BulkUpdateOrderStatus(c)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected a bad request, got %d", w.Code)
+	}
+}
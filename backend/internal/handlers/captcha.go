@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"notsofluffy-backend/internal/captcha"
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/middleware"
+	"notsofluffy-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CaptchaHandler struct {
+	settingsQueries *database.SettingsQueries
+	provider        captcha.Provider
+	siteKey         string
+}
+
+func NewCaptchaHandler(settingsQueries *database.SettingsQueries, provider captcha.Provider, siteKey string) *CaptchaHandler {
+	return &CaptchaHandler{
+		settingsQueries: settingsQueries,
+		provider:        provider,
+		siteKey:         siteKey,
+	}
+}
+
+// GetConfig returns the challenge provider and the endpoints it is enabled on. Without a
+// provider no endpoint is listed, matching CaptchaMiddleware letting requests through.
+func (h *CaptchaHandler) GetConfig(c *gin.Context) {
+	c.JSON(http.StatusOK, h.config(c.Request.Context()))
+}
+
+func (h *CaptchaHandler) config(ctx context.Context) models.CaptchaConfigResponse {
+	response := models.CaptchaConfigResponse{Endpoints: []string{}}
+	if h.provider == nil {
+		return response
+	}
+
+	name := h.provider.Name()
+	response.Provider = &name
+	response.SiteKey = &h.siteKey
+	for _, endpoint := range captcha.Endpoints {
+		enabled, err := h.settingsQueries.GetBoolSetting(captcha.SettingKey(endpoint), false)
+		if err != nil {
+			middleware.Logger(ctx).Error("failed to check captcha setting", "endpoint", endpoint, "error", err)
+			continue
+		}
+		if enabled {
+			response.Endpoints = append(response.Endpoints, endpoint)
+		}
+	}
+	return response
+}
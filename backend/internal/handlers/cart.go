@@ -1,40 +1,51 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
 	"net/http"
 	"strconv"
 
 	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/fulfillment"
 	"notsofluffy-backend/internal/middleware"
 	"notsofluffy-backend/internal/models"
+	"notsofluffy-backend/internal/pricing"
 
 	"github.com/gin-gonic/gin"
 )
 
 // CartHandler handles cart-related requests
 type CartHandler struct {
-	db              *sql.DB
-	cartQueries     *database.CartQueries
-	productQueries  *database.ProductQueries
-	variantQueries  *database.ProductVariantQueries
-	sizeQueries     *database.SizeQueries
-	serviceQueries  *database.AdditionalServiceQueries
-	stockQueries    *database.StockQueries
-	discountQueries *database.DiscountQueries
+	db                *sql.DB
+	cartQueries       *database.CartQueries
+	productQueries    *database.ProductQueries
+	variantQueries    *database.ProductVariantQueries
+	sizeQueries       *database.SizeQueries
+	serviceQueries    *database.AdditionalServiceQueries
+	stockQueries      *database.StockQueries
+	discountQueries   *database.DiscountQueries
+	optionQueries     *database.OptionQueries
+	settingsQueries   *database.SettingsQueries
+	productionQueries *database.ProductionQueries
+	shippingMethodQueries *database.ShippingMethodQueries
 }
 
 // NewCartHandler creates a new cart handler
 func NewCartHandler(db *sql.DB) *CartHandler {
 	return &CartHandler{
-		db:              db,
-		cartQueries:     database.NewCartQueries(db),
-		productQueries:  database.NewProductQueries(db),
-		variantQueries:  database.NewProductVariantQueries(db),
-		sizeQueries:     database.NewSizeQueries(db),
-		serviceQueries:  database.NewAdditionalServiceQueries(db),
-		stockQueries:    database.NewStockQueries(db),
-		discountQueries: database.NewDiscountQueries(db),
+		db:                db,
+		cartQueries:       database.NewCartQueries(db),
+		productQueries:    database.NewProductQueries(db),
+		variantQueries:    database.NewProductVariantQueries(db),
+		sizeQueries:       database.NewSizeQueries(db),
+		serviceQueries:    database.NewAdditionalServiceQueries(db),
+		stockQueries:      database.NewStockQueries(db),
+		discountQueries:   database.NewDiscountQueries(db),
+		optionQueries:     database.NewOptionQueries(db),
+		settingsQueries:   database.NewSettingsQueries(db),
+		productionQueries: database.NewProductionQueries(db),
+		shippingMethodQueries: database.NewShippingMethodQueries(db),
 	}
 }
 
@@ -56,6 +67,7 @@ func (h *CartHandler) GetCart(c *gin.Context) {
 	// Get or create cart session
 	cartSession, err := h.cartQueries.GetOrCreateCartSession(sessionID, userID)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get cart session", "details": err.Error()})
 		return
 	}
@@ -63,14 +75,22 @@ func (h *CartHandler) GetCart(c *gin.Context) {
 	// Get cart items
 	items, err := h.cartQueries.GetCartItems(cartSession.ID)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get cart items", "details": err.Error()})
 		return
 	}
 
-	// Calculate totals
+	// Calculate totals; items whose services were deleted don't count
 	var totalItems int
 	var subtotal float64
+	var priceChanges int
 	for _, item := range items {
+		if item.PriceChange != nil {
+			priceChanges++
+		}
+		if item.Unavailable {
+			continue
+		}
 		totalItems += item.Quantity
 		subtotal += item.TotalPrice
 	}
@@ -93,19 +113,41 @@ func (h *CartHandler) GetCart(c *gin.Context) {
 		}
 	}
 
-	totalPrice := subtotal - discountAmount
-	if totalPrice < 0 {
-		totalPrice = 0
+	// Shipping is previewed with ?shipping_method_id=, the first active method by default
+	var shippingMethodID *int
+	if value := c.Query("shipping_method_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shipping method ID"})
+			return
+		}
+		shippingMethodID = &id
+	}
+	shippingMethod, ok := resolveShippingMethod(c, h.shippingMethodQueries, shippingMethodID)
+	if !ok {
+		return
 	}
 
+	shipping := pricing.Shipping(items, shippingMethod, subtotal-discountAmount, oversizeShippingFee(c.Request.Context(), h.settingsQueries))
+	totalPrice := pricing.Total(subtotal, discountAmount, shipping.Total, 0)
+
+	shippingSettings := fulfillmentSettings(h.settingsQueries)
+	shippingSettings.ProductionLeadDays = productionLeadDays(c.Request.Context(), h.productionQueries, items)
+	shippingItems := applyLeadTimes(items, shippingSettings)
+
 	response := models.CartResponse{
-		Items:           items,
-		TotalItems:      totalItems,
-		Subtotal:        subtotal,
-		DiscountAmount:  discountAmount,
-		TotalPrice:      totalPrice,
-		AppliedDiscount: appliedDiscount,
-	}
+		Items:             items,
+		TotalItems:        totalItems,
+		Subtotal:          subtotal,
+		DiscountAmount:    discountAmount,
+		ShippingCost:      shipping.Total,
+		TotalPrice:        totalPrice,
+		AppliedDiscount:   appliedDiscount,
+		Shipping:          shipping,
+		FulfillmentNotice: fulfillment.Notice(shippingItems, shippingSettings),
+		PriceChanges:      priceChanges,
+	}
+	formatCart(priceFormat(c), &response)
 
 	c.JSON(http.StatusOK, response)
 }
@@ -134,13 +176,14 @@ func (h *CartHandler) AddToCart(c *gin.Context) {
 	// Get or create cart session
 	cartSession, err := h.cartQueries.GetOrCreateCartSession(sessionID, userID)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get cart session", "details": err.Error()})
 		return
 	}
 
-	// Validate product exists
-	_, err = h.productQueries.GetProduct(req.ProductID)
-	if err != nil {
+	// Validate product exists and is sold in the web shop
+	product, err := h.productQueries.GetProduct(req.ProductID)
+	if err != nil || !product.Channels.Web {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
 		return
 	}
@@ -162,6 +205,7 @@ func (h *CartHandler) AddToCart(c *gin.Context) {
 	// Check stock availability
 	available, availableStock, err := h.stockQueries.CheckStockAvailability(req.SizeID, req.Quantity)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check stock availability", "details": err.Error()})
 		return
 	}
@@ -179,35 +223,65 @@ func (h *CartHandler) AddToCart(c *gin.Context) {
 		return
 	}
 
-	// Validate additional services exist
-	var totalServicePrice float64
+	// Validate additional services exist and snapshot their price
+	var servicePrices []float64
+	var services []models.CartItemServiceSnapshot
 	for _, serviceID := range req.AdditionalServiceIDs {
 		service, err := h.serviceQueries.GetAdditionalServiceByID(serviceID)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid additional service ID"})
 			return
 		}
-		totalServicePrice += service.Price
+		servicePrices = append(servicePrices, service.Price)
+		services = append(services, models.CartItemServiceSnapshot{
+			ServiceID:   service.ID,
+			Name:        service.Name,
+			Description: service.Description,
+			Price:       service.Price,
+		})
 	}
 
-	// Calculate price per item
-	pricePerItem := size.BasePrice
-	
-	// Apply 10% markup for custom colors
-	if variant.Color.Custom {
-		pricePerItem *= 1.1
+	// Validate the chosen option values against the product's option groups
+	optionGroups, err := h.optionQueries.ListProductOptionGroups(req.ProductID)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get product options", "details": err.Error()})
+		return
 	}
-	
-	// Add additional services price
-	pricePerItem += totalServicePrice
+	options, err := database.ResolveOptionSelection(optionGroups, req.OptionValueIDs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Calculate price per item, with the custom color markup, services and option surcharges
+	var surcharges []float64
+	for _, option := range options {
+		surcharges = append(surcharges, option.Surcharge)
+	}
+	pricePerItem := pricing.UnitPrice(size.BasePrice, variant.Color.Custom, servicePrices, surcharges)
 
 	// Add item to cart
-	_, err = h.cartQueries.AddCartItem(cartSession.ID, &req, pricePerItem)
+	cartItem, err := h.cartQueries.AddCartItem(cartSession.ID, &req, pricePerItem, services, options)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add item to cart", "details": err.Error()})
 		return
 	}
 
+	h.recordCartEvent(c.Request.Context(), models.CartEvent{
+		CartSessionID:  cartSession.ID,
+		Action:         models.CartActionAdded,
+		CartItemID:     &cartItem.ID,
+		ProductID:      &cartItem.ProductID,
+		VariantID:      &cartItem.VariantID,
+		SizeID:         &cartItem.SizeID,
+		QuantityBefore: cartItem.Quantity - req.Quantity,
+		QuantityAfter:  cartItem.Quantity,
+		PricePerItem:   &cartItem.PricePerItem,
+		UserID:         userID,
+	})
+
 	c.JSON(http.StatusCreated, gin.H{"message": "Item added to cart successfully"})
 }
 
@@ -241,6 +315,7 @@ func (h *CartHandler) UpdateCartItem(c *gin.Context) {
 	// Get cart session
 	cartSession, err := h.cartQueries.GetOrCreateCartSession(sessionID, userID)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get cart session", "details": err.Error()})
 		return
 	}
@@ -248,6 +323,7 @@ func (h *CartHandler) UpdateCartItem(c *gin.Context) {
 	// Verify the cart item belongs to this session (security check)
 	items, err := h.cartQueries.GetCartItems(cartSession.ID)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get cart items", "details": err.Error()})
 		return
 	}
@@ -268,6 +344,7 @@ func (h *CartHandler) UpdateCartItem(c *gin.Context) {
 	// Check stock availability for the new quantity
 	available, availableStock, err := h.stockQueries.CheckStockAvailability(currentItem.SizeID, req.Quantity)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check stock availability", "details": err.Error()})
 		return
 	}
@@ -288,10 +365,24 @@ func (h *CartHandler) UpdateCartItem(c *gin.Context) {
 	// Update quantity
 	_, err = h.cartQueries.UpdateCartItemQuantity(cartItemID, req.Quantity)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update cart item", "details": err.Error()})
 		return
 	}
 
+	h.recordCartEvent(c.Request.Context(), models.CartEvent{
+		CartSessionID:  cartSession.ID,
+		Action:         models.CartActionQuantityChanged,
+		CartItemID:     &currentItem.ID,
+		ProductID:      &currentItem.ProductID,
+		VariantID:      &currentItem.VariantID,
+		SizeID:         &currentItem.SizeID,
+		QuantityBefore: currentItem.Quantity,
+		QuantityAfter:  req.Quantity,
+		PricePerItem:   &currentItem.PricePerItem,
+		UserID:         userID,
+	})
+
 	c.JSON(http.StatusOK, gin.H{"message": "Cart item updated successfully"})
 }
 
@@ -319,6 +410,7 @@ func (h *CartHandler) RemoveFromCart(c *gin.Context) {
 	// Get cart session
 	cartSession, err := h.cartQueries.GetOrCreateCartSession(sessionID, userID)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get cart session", "details": err.Error()})
 		return
 	}
@@ -326,19 +418,20 @@ func (h *CartHandler) RemoveFromCart(c *gin.Context) {
 	// Verify the cart item belongs to this session (security check)
 	items, err := h.cartQueries.GetCartItems(cartSession.ID)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get cart items", "details": err.Error()})
 		return
 	}
 
-	found := false
+	var removedItem *models.CartItemResponse
 	for _, item := range items {
 		if item.ID == cartItemID {
-			found = true
+			removedItem = &item
 			break
 		}
 	}
 
-	if !found {
+	if removedItem == nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Cart item not found"})
 		return
 	}
@@ -346,10 +439,24 @@ func (h *CartHandler) RemoveFromCart(c *gin.Context) {
 	// Remove item
 	err = h.cartQueries.RemoveCartItem(cartItemID)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove cart item", "details": err.Error()})
 		return
 	}
 
+	h.recordCartEvent(c.Request.Context(), models.CartEvent{
+		CartSessionID:  cartSession.ID,
+		Action:         models.CartActionRemoved,
+		CartItemID:     &removedItem.ID,
+		ProductID:      &removedItem.ProductID,
+		VariantID:      &removedItem.VariantID,
+		SizeID:         &removedItem.SizeID,
+		QuantityBefore: removedItem.Quantity,
+		QuantityAfter:  0,
+		PricePerItem:   &removedItem.PricePerItem,
+		UserID:         userID,
+	})
+
 	c.JSON(http.StatusOK, gin.H{"message": "Item removed from cart successfully"})
 }
 
@@ -371,20 +478,65 @@ func (h *CartHandler) ClearCart(c *gin.Context) {
 	// Get cart session
 	cartSession, err := h.cartQueries.GetOrCreateCartSession(sessionID, userID)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get cart session", "details": err.Error()})
 		return
 	}
 
+	itemCount, err := h.cartQueries.GetCartItemCount(cartSession.ID)
+	if err != nil {
+		middleware.Logger(c.Request.Context()).Error("failed to count cart items before clearing cart", "cart_id", cartSession.ID, "error", err)
+	}
+
 	// Clear cart
 	err = h.cartQueries.ClearCart(cartSession.ID)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear cart", "details": err.Error()})
 		return
 	}
 
+	h.recordCartEvent(c.Request.Context(), models.CartEvent{
+		CartSessionID:  cartSession.ID,
+		Action:         models.CartActionCleared,
+		QuantityBefore: itemCount,
+		UserID:         userID,
+	})
+
 	c.JSON(http.StatusOK, gin.H{"message": "Cart cleared successfully"})
 }
 
+// AcknowledgePriceChanges clears the price changes shown in the cart once the customer has seen them
+func (h *CartHandler) AcknowledgePriceChanges(c *gin.Context) {
+	sessionID := middleware.GetSessionID(c)
+	if sessionID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No session found"})
+		return
+	}
+
+	// Get user ID if authenticated
+	var userID *int
+	if userIDInterface, exists := c.Get("user_id"); exists {
+		uid := userIDInterface.(int)
+		userID = &uid
+	}
+
+	cartSession, err := h.cartQueries.GetOrCreateCartSession(sessionID, userID)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get cart session", "details": err.Error()})
+		return
+	}
+
+	if err := h.cartQueries.AcknowledgePriceChanges(cartSession.ID); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to acknowledge price changes", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Price changes acknowledged"})
+}
+
 // GetCartCount returns the number of items in the cart
 func (h *CartHandler) GetCartCount(c *gin.Context) {
 	sessionID := middleware.GetSessionID(c)
@@ -415,4 +567,26 @@ func (h *CartHandler) GetCartCount(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, models.CartCountResponse{Count: count})
-}
\ No newline at end of file
+}
+// GetCartSessionHistory returns the recorded cart mutations of a session for support (admin)
+func (h *CartHandler) GetCartSessionHistory(c *gin.Context) {
+	history, err := h.cartQueries.GetCartHistory(c.Param("sessionID"))
+	if err != nil {
+		if err.Error() == "cart session not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Cart session not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get cart history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// recordCartEvent stores a cart mutation in the session history; failures never fail the request
+func (h *CartHandler) recordCartEvent(ctx context.Context, event models.CartEvent) {
+	if err := h.cartQueries.RecordCartEvent(&event); err != nil {
+		middleware.Logger(ctx).Error("failed to record cart event", "cart_id", event.CartSessionID, "error", err)
+	}
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/models"
+)
+
+type CatalogSnapshotHandler struct {
+	snapshotQueries *database.CatalogSnapshotQueries
+}
+
+func NewCatalogSnapshotHandler(snapshotQueries *database.CatalogSnapshotQueries) *CatalogSnapshotHandler {
+	return &CatalogSnapshotHandler{snapshotQueries: snapshotQueries}
+}
+
+// ListSnapshots returns stored catalog snapshots
+func (h *CatalogSnapshotHandler) ListSnapshots(c *gin.Context) {
+	snapshots, err := h.snapshotQueries.ListSnapshots()
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get snapshots"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"snapshots": snapshots})
+}
+
+// CreateSnapshot stores a snapshot of the current catalog and inventory
+func (h *CatalogSnapshotHandler) CreateSnapshot(c *gin.Context) {
+	var req models.CatalogSnapshotRequest
+	if err := c.ShouldBindJSON(&req); err != nil && c.Request.ContentLength > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	snapshot, err := h.snapshotQueries.CreateSnapshot(req.Note, getUserIDPtr(c))
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create snapshot"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, snapshot)
+}
+
+// DownloadSnapshot returns the JSON archive of a snapshot as a file
+func (h *CatalogSnapshotHandler) DownloadSnapshot(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid snapshot ID"})
+		return
+	}
+
+	data, err := h.snapshotQueries.GetSnapshotData(id)
+	if err != nil {
+		if err.Error() == "snapshot not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Snapshot not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get snapshot"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=catalog-snapshot-%d.json", id))
+	c.Data(http.StatusOK, "application/json", data)
+}
+
+// DeleteSnapshot removes a stored snapshot
+func (h *CatalogSnapshotHandler) DeleteSnapshot(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid snapshot ID"})
+		return
+	}
+
+	if err := h.snapshotQueries.DeleteSnapshot(id); err != nil {
+		if err.Error() == "snapshot not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Snapshot not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete snapshot"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Snapshot deleted successfully"})
+}
+
+// RestoreSnapshot rolls the catalog back to a stored snapshot
+func (h *CatalogSnapshotHandler) RestoreSnapshot(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid snapshot ID"})
+		return
+	}
+
+	result, err := h.snapshotQueries.RestoreSnapshot(id, getUserIDPtr(c))
+	if err != nil {
+		h.restoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// RestoreArchive restores the catalog from an uploaded JSON archive
+func (h *CatalogSnapshotHandler) RestoreArchive(c *gin.Context) {
+	var archive models.CatalogSnapshotArchive
+	if err := c.ShouldBindJSON(&archive); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid snapshot archive"})
+		return
+	}
+
+	result, err := h.snapshotQueries.RestoreArchive(&archive, getUserIDPtr(c))
+	if err != nil {
+		h.restoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *CatalogSnapshotHandler) restoreError(c *gin.Context, err error) {
+	msg := err.Error()
+	switch {
+	case msg == "snapshot not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Snapshot not found"})
+	case strings.HasPrefix(msg, "unsupported snapshot") || strings.HasPrefix(msg, "snapshot "):
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+	default:
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore snapshot", "details": msg})
+	}
+}
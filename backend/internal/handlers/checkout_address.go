@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"notsofluffy-backend/internal/middleware"
+	"notsofluffy-backend/internal/models"
+)
+
+// resolveCheckoutAddress returns the address given in the order request, or a snapshot
+// of the user's saved address when only its ID was given. Saved addresses without a
+// phone number take the order's phone.
+func (h *OrderHandler) resolveCheckoutAddress(userID *int, addr *models.AddressRequest, addressID *int, phone string) (*models.AddressRequest, error) {
+	switch {
+	case addr != nil && addressID != nil:
+		return nil, fmt.Errorf("address and saved address both given")
+	case addr != nil:
+		return addr, nil
+	case addressID == nil:
+		return nil, fmt.Errorf("address required")
+	case userID == nil:
+		return nil, fmt.Errorf("login required")
+	}
+
+	saved, err := h.profileQueries.GetUserAddress(*userID, *addressID)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := &models.AddressRequest{
+		FirstName:     saved.FirstName,
+		LastName:      saved.LastName,
+		Company:       saved.Company,
+		AddressLine1:  saved.AddressLine1,
+		AddressLine2:  saved.AddressLine2,
+		City:          saved.City,
+		StateProvince: saved.StateProvince,
+		PostalCode:    saved.PostalCode,
+		Country:       saved.Country,
+		Phone:         phone,
+	}
+	if saved.Phone != nil && strings.TrimSpace(*saved.Phone) != "" {
+		resolved.Phone = *saved.Phone
+	}
+	return resolved, nil
+}
+
+// checkoutAddressError maps a resolveCheckoutAddress error to a response
+func checkoutAddressError(kind string, err error) (int, string) {
+	switch err.Error() {
+	case "address and saved address both given":
+		return http.StatusBadRequest, fmt.Sprintf("Give either the %s address or a saved address, not both", kind)
+	case "address required":
+		return http.StatusBadRequest, fmt.Sprintf("The %s address is required", kind)
+	case "login required":
+		return http.StatusUnauthorized, "Log in to use a saved address"
+	case "address not found":
+		return http.StatusNotFound, fmt.Sprintf("Saved %s address not found", kind)
+	default:
+		return http.StatusInternalServerError, fmt.Sprintf("Failed to get saved %s address", kind)
+	}
+}
+
+// saveCheckoutAddress adds an address entered at checkout to the user's address book,
+// unless the book already has it. Failures are logged; the order stands regardless.
+func (h *OrderHandler) saveCheckoutAddress(ctx context.Context, userID int, addr *models.AddressRequest) {
+	existing, err := h.profileQueries.GetUserAddresses(userID)
+	if err != nil {
+		middleware.Logger(ctx).Error("failed to get addresses", "user_id", userID, "error", err)
+		return
+	}
+	for _, a := range existing {
+		if sameAddress(&a, addr) {
+			return
+		}
+	}
+
+	label := addr.AddressLine1 + ", " + addr.City
+	if runes := []rune(label); len(runes) > 100 {
+		label = string(runes[:100])
+	}
+	phone := addr.Phone
+	_, err = h.profileQueries.CreateUserAddress(userID, &models.UserAddressRequest{
+		Label:         label,
+		FirstName:     addr.FirstName,
+		LastName:      addr.LastName,
+		Company:       addr.Company,
+		AddressLine1:  addr.AddressLine1,
+		AddressLine2:  addr.AddressLine2,
+		City:          addr.City,
+		StateProvince: addr.StateProvince,
+		PostalCode:    addr.PostalCode,
+		Country:       addr.Country,
+		Phone:         &phone,
+		IsDefault:     len(existing) == 0,
+	})
+	if err != nil {
+		middleware.Logger(ctx).Error("failed to save checkout address", "user_id", userID, "error", err)
+	}
+}
+
+// sameAddress reports whether a saved address matches one entered at checkout
+func sameAddress(saved *models.UserAddressResponse, addr *models.AddressRequest) bool {
+	same := func(a, b string) bool {
+		return strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b))
+	}
+	optional := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+	return same(saved.FirstName, addr.FirstName) &&
+		same(saved.LastName, addr.LastName) &&
+		same(optional(saved.Company), optional(addr.Company)) &&
+		same(saved.AddressLine1, addr.AddressLine1) &&
+		same(optional(saved.AddressLine2), optional(addr.AddressLine2)) &&
+		same(saved.City, addr.City) &&
+		same(saved.PostalCode, addr.PostalCode) &&
+		same(saved.Country, addr.Country)
+}
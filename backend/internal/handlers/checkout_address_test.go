@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"notsofluffy-backend/internal/models"
+)
+
+func TestResolveCheckoutAddress(t *testing.T) {
+	h := &OrderHandler{}
+	addr := &models.AddressRequest{FirstName: "Anna", LastName: "Nowak", AddressLine1: "Długa 5", City: "Kraków", PostalCode: "31-147", Country: "PL", Phone: "123"}
+	addressID := 7
+	userID := 1
+
+	resolved, err := h.resolveCheckoutAddress(&userID, addr, nil, "456")
+	if err != nil || resolved != addr {
+		t.Fatalf("resolveCheckoutAddress(addr) = %v, %v, want the given address", resolved, err)
+	}
+
+	cases := []struct {
+		userID    *int
+		addr      *models.AddressRequest
+		addressID *int
+		status    int
+	}{
+		{&userID, addr, &addressID, http.StatusBadRequest},
+		{&userID, nil, nil, http.StatusBadRequest},
+		{nil, nil, &addressID, http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		_, err := h.resolveCheckoutAddress(tc.userID, tc.addr, tc.addressID, "456")
+		if err == nil {
+			t.Fatalf("resolveCheckoutAddress(%v, %v, %v) should fail", tc.userID, tc.addr, tc.addressID)
+		}
+		if status, _ := checkoutAddressError("shipping", err); status != tc.status {
+			t.Errorf("resolveCheckoutAddress(%v, %v, %v) status = %d, want %d", tc.userID, tc.addr, tc.addressID, status, tc.status)
+		}
+	}
+}
+
+func TestSameAddress(t *testing.T) {
+	company := "Fluffy sp. z o.o."
+	saved := &models.UserAddressResponse{FirstName: "Anna", LastName: "Nowak", Company: &company, AddressLine1: "Długa 5", City: "Kraków", PostalCode: "31-147", Country: "PL"}
+	addr := &models.AddressRequest{FirstName: "anna", LastName: "Nowak ", Company: &company, AddressLine1: "Długa 5", City: "Kraków", PostalCode: "31-147", Country: "PL"}
+
+	if !sameAddress(saved, addr) {
+		t.Error("addresses differing only in case and spacing should match")
+	}
+	addr.AddressLine1 = "Długa 7"
+	if sameAddress(saved, addr) {
+		t.Error("addresses on different streets should not match")
+	}
+}
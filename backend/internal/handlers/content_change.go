@@ -0,0 +1,342 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/middleware"
+	"notsofluffy-backend/internal/models"
+)
+
+// ContentChangeHandler lets editors propose product changes that an admin approves
+// before they go live
+type ContentChangeHandler struct {
+	changeQueries   *database.ContentChangeQueries
+	productQueries  *database.ProductQueries
+	settingsQueries *database.SettingsQueries
+	adminHandler    *AdminHandler
+}
+
+func NewContentChangeHandler(db *sql.DB, adminHandler *AdminHandler) *ContentChangeHandler {
+	return &ContentChangeHandler{
+		changeQueries:   database.NewContentChangeQueries(db),
+		productQueries:  database.NewProductQueries(db),
+		settingsQueries: database.NewSettingsQueries(db),
+		adminHandler:    adminHandler,
+	}
+}
+
+// productRequestFromProduct returns the editable state of a product in the shape
+// of an update request, so changes and snapshots diff field by field
+func productRequestFromProduct(p *models.ProductWithRelations) *models.ProductRequest {
+	imageIDs := make([]int, len(p.Images))
+	for i, image := range p.Images {
+		imageIDs[i] = image.ID
+	}
+	serviceIDs := make([]int, len(p.AdditionalServices))
+	for i, service := range p.AdditionalServices {
+		serviceIDs[i] = service.ID
+	}
+	channels := p.Channels
+
+	return &models.ProductRequest{
+		Name:                 p.Name,
+		ShortDescription:     p.ShortDescription,
+		Description:          p.Description,
+		MaterialID:           p.MaterialID,
+		MainImageID:          p.MainImageID,
+		CategoryID:           p.CategoryID,
+		ImageIDs:             imageIDs,
+		AdditionalServiceIDs: serviceIDs,
+		Channels:             &channels,
+	}
+}
+
+// currentProductState returns the product's editable state as JSON
+func (h *ContentChangeHandler) currentProductState(id int) (json.RawMessage, error) {
+	product, err := h.productQueries.GetProduct(id)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(productRequestFromProduct(product))
+}
+
+// SubmitProductChange proposes an update of a product. Admins' changes, and everyone's
+// when approval is not required, are applied right away and recorded as approved.
+func (h *ContentChangeHandler) SubmitProductChange(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var req models.ProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	base, err := h.currentProductState(id)
+	if err != nil {
+		if err.Error() == "product not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get product"})
+		return
+	}
+	// Channels are part of the diff; keep the current ones when omitted
+	if req.Channels == nil {
+		var current models.ProductRequest
+		if err := json.Unmarshal(base, &current); err == nil {
+			req.Channels = current.Channels
+		}
+	}
+
+	if msg := h.adminHandler.validateProductRequest(&req); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	payload, err := json.Marshal(&req)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save content change"})
+		return
+	}
+
+	approvalRequired, err := h.settingsQueries.GetBoolSetting(models.SettingContentApprovalRequired, false)
+	if err != nil {
+		middleware.Logger(c.Request.Context()).Warn("failed to read setting, requiring approval", "setting", models.SettingContentApprovalRequired, "error", err)
+		approvalRequired = true
+	}
+
+	change := &models.ContentChange{
+		EntityType:  models.ContentEntityProduct,
+		EntityID:    id,
+		Status:      models.ContentChangePending,
+		Payload:     payload,
+		Base:        base,
+		SubmittedBy: getUserIDPtr(c),
+	}
+
+	if !approvalRequired || c.GetString("user_role") == models.RoleAdmin {
+		if err := h.adminHandler.applyProductUpdate(c.Request.Context(), id, &req, change.SubmittedBy); err != nil {
+			if err.Error() == "product not found" {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+				return
+			}
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		change.Status = models.ContentChangeApproved
+	}
+
+	if err := h.changeQueries.CreateChange(change); err != nil {
+		if change.Status == models.ContentChangeApproved {
+			// The product is already updated; only the record is missing
+			middleware.Logger(c.Request.Context()).Error("failed to record applied content change", "product_id", id, "error", err)
+		} else {
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save content change"})
+			return
+		}
+	}
+
+	status := http.StatusAccepted
+	if change.Status == models.ContentChangeApproved {
+		status = http.StatusOK
+	}
+	c.JSON(status, change)
+}
+
+// ListChanges returns content changes; editors only see their own
+func (h *ContentChangeHandler) ListChanges(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	status := c.Query("status")
+	entityID, _ := strconv.Atoi(c.Query("entity_id"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	switch status {
+	case "", models.ContentChangePending, models.ContentChangeApproved, models.ContentChangeRejected:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+		return
+	}
+
+	submittedBy := 0
+	if c.GetString("user_role") != models.RoleAdmin {
+		submittedBy = c.GetInt("user_id")
+	}
+
+	response, err := h.changeQueries.ListChanges(page, limit, status, c.Query("entity_type"), entityID, submittedBy)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list content changes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetChange returns a content change with the diff against the state it was based on
+func (h *ContentChangeHandler) GetChange(c *gin.Context) {
+	change, ok := h.loadChange(c)
+	if !ok {
+		return
+	}
+
+	if c.GetString("user_role") != models.RoleAdmin &&
+		(change.SubmittedBy == nil || *change.SubmittedBy != c.GetInt("user_id")) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Content change not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, change)
+}
+
+// ApproveChange applies a pending change. When the product was edited since the change
+// was submitted the request fails with the conflicting fields unless force=true.
+func (h *ContentChangeHandler) ApproveChange(c *gin.Context) {
+	var req models.ContentChangeReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil && c.Request.ContentLength > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	change, ok := h.loadChange(c)
+	if !ok {
+		return
+	}
+	if change.Status != models.ContentChangePending {
+		c.JSON(http.StatusConflict, gin.H{"error": "Content change was already reviewed"})
+		return
+	}
+
+	current, err := h.currentProductState(change.EntityID)
+	if err != nil {
+		if err.Error() == "product not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get product"})
+		return
+	}
+	if c.Query("force") != "true" {
+		conflicts, err := database.DiffContent(change.Base, current)
+		if err != nil {
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compare product state"})
+			return
+		}
+		if len(conflicts) > 0 {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":     "Product was changed after this change was submitted",
+				"conflicts": conflicts,
+			})
+			return
+		}
+	}
+
+	var productReq models.ProductRequest
+	if err := json.Unmarshal(change.Payload, &productReq); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read content change"})
+		return
+	}
+	// Referenced images or services may have been deleted meanwhile
+	if msg := h.adminHandler.validateProductRequest(&productReq); msg != "" {
+		c.JSON(http.StatusConflict, gin.H{"error": msg})
+		return
+	}
+
+	// The revision is credited to the editor who wrote the change
+	if err := h.adminHandler.applyProductUpdate(c.Request.Context(), change.EntityID, &productReq, change.SubmittedBy); err != nil {
+		if err.Error() == "product not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.changeQueries.ReviewChange(change.ID, models.ContentChangeApproved, getUserIDPtr(c), req.Note); err != nil {
+		middleware.Logger(c.Request.Context()).Error("failed to mark content change approved", "change_id", change.ID, "error", err)
+	}
+
+	h.respondChange(c, change.ID)
+}
+
+// RejectChange discards a pending change
+func (h *ContentChangeHandler) RejectChange(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid content change ID"})
+		return
+	}
+
+	var req models.ContentChangeReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil && c.Request.ContentLength > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.changeQueries.ReviewChange(id, models.ContentChangeRejected, getUserIDPtr(c), req.Note); err != nil {
+		if err.Error() == "content change already reviewed" {
+			if _, getErr := h.changeQueries.GetChange(id); getErr != nil && getErr.Error() == "content change not found" {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Content change not found"})
+				return
+			}
+			c.JSON(http.StatusConflict, gin.H{"error": "Content change was already reviewed"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reject content change"})
+		return
+	}
+
+	h.respondChange(c, id)
+}
+
+func (h *ContentChangeHandler) loadChange(c *gin.Context) (*models.ContentChange, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid content change ID"})
+		return nil, false
+	}
+
+	change, err := h.changeQueries.GetChange(id)
+	if err != nil {
+		if err.Error() == "content change not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Content change not found"})
+			return nil, false
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get content change"})
+		return nil, false
+	}
+	return change, true
+}
+
+func (h *ContentChangeHandler) respondChange(c *gin.Context, id int) {
+	change, err := h.changeQueries.GetChange(id)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get content change"})
+		return
+	}
+	c.JSON(http.StatusOK, change)
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+
+	"notsofluffy-backend/internal/captcha"
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/geoip"
+	"notsofluffy-backend/internal/middleware"
+	"notsofluffy-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ContextHandler struct {
+	settingsQueries *database.SettingsQueries
+	cartQueries     *database.CartQueries
+	currencyQueries *database.CurrencyQueries
+	captchaHandler  *CaptchaHandler
+}
+
+func NewContextHandler(settingsQueries *database.SettingsQueries, cartQueries *database.CartQueries, currencyQueries *database.CurrencyQueries, captchaHandler *CaptchaHandler) *ContextHandler {
+	return &ContextHandler{
+		settingsQueries: settingsQueries,
+		cartQueries:     cartQueries,
+		currencyQueries: currencyQueries,
+		captchaHandler:  captchaHandler,
+	}
+}
+
+// GetContext returns everything the storefront needs to bootstrap in one call: the
+// shipping country, currency and language to pre-select for the visitor, how to display
+// prices, feature flags, maintenance status and the cart summary. The language can be
+// forced with ?lang=; otherwise it is negotiated from Accept-Language. The currency is
+// the one asked for with ?currency= or X-Currency, otherwise the country's when prices
+// can be shown in it.
+func (h *ContextHandler) GetContext(c *gin.Context) {
+	country := middleware.GetCountryCode(c)
+	defaults := geoip.DefaultsFor(country)
+	language := geoip.NegotiateLanguage(c.Query("lang"), c.GetHeader("Accept-Language"), defaults.Language)
+
+	currencies := []string{models.PaymentCurrency}
+	if enabled, err := h.currencyQueries.ListCurrencies(true); err != nil {
+		middleware.Logger(c.Request.Context()).Error("failed to list currencies", "error", err)
+	} else if len(enabled) > 0 {
+		currencies = currencies[:0]
+		for _, currency := range enabled {
+			currencies = append(currencies, currency.Code)
+		}
+	}
+	currency := middleware.GetCurrency(c).Code
+	if _, requested := c.Get(middleware.CurrencyKey); !requested && slices.Contains(currencies, defaults.Currency) {
+		currency = defaults.Currency
+	}
+	format := geoip.FormatFor(currency, language)
+
+	response := models.StorefrontContext{
+		ShippingCountryCode: defaults.Code,
+		ShippingCountry:     defaults.Name,
+		Currency:            currency,
+		Currencies:          currencies,
+		Language:            language,
+		Locale:              language + "-" + defaults.Code,
+		URLPrefix:           geoip.URLPrefix(language),
+		CurrencyFormat: models.CurrencyFormat{
+			Code:               format.Code,
+			Symbol:             format.Symbol,
+			Decimals:           format.Decimals,
+			DecimalSeparator:   format.DecimalSeparator,
+			ThousandsSeparator: format.ThousandsSeparator,
+			SymbolPosition:     format.SymbolPosition,
+		},
+		Captcha: h.captchaHandler.config(c.Request.Context()),
+		Cart:    models.CartSummary{},
+	}
+	if country != "" {
+		response.DetectedCountry = &country
+	}
+	if shop := middleware.GetShop(c); shop != nil {
+		profile := shop.Profile()
+		response.Shop = &profile
+	}
+
+	// A failing lookup degrades that part of the context rather than the whole bootstrap
+	maintenanceMode, err := h.settingsQueries.GetMaintenanceMode()
+	if err != nil {
+		middleware.Logger(c.Request.Context()).Error("failed to get maintenance mode", "error", err)
+	}
+	response.MaintenanceMode = maintenanceMode
+
+	checkout, err := h.settingsQueries.GetCheckoutStatus()
+	if err != nil {
+		middleware.Logger(c.Request.Context()).Error("failed to get checkout status", "error", err)
+	}
+	response.Checkout = *checkout
+
+	response.Features = map[string]bool{}
+	for _, endpoint := range captcha.Endpoints {
+		response.Features[captcha.SettingKey(endpoint)] = false
+	}
+	for _, endpoint := range response.Captcha.Endpoints {
+		response.Features[captcha.SettingKey(endpoint)] = true
+	}
+
+	if sessionID := middleware.GetSessionID(c); sessionID != "" {
+		summary, err := h.cartQueries.GetCartSummary(sessionID)
+		if err != nil {
+			middleware.Logger(c.Request.Context()).Error("failed to get cart summary", "error", err)
+		} else {
+			response.Cart = *summary
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/exchangerates"
+	"notsofluffy-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// currencyCode matches ISO 4217 codes
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// rateSource publishes exchange rates, such as NBP
+type rateSource interface {
+	Rates(ctx context.Context) (*exchangerates.Table, error)
+}
+
+type CurrencyHandler struct {
+	currencyQueries *database.CurrencyQueries
+	rates           rateSource
+}
+
+func NewCurrencyHandler(currencyQueries *database.CurrencyQueries, rates *exchangerates.NBPClient) *CurrencyHandler {
+	return &CurrencyHandler{currencyQueries: currencyQueries, rates: rates}
+}
+
+// ListCurrencies returns the currencies the storefront can show prices in
+func (h *CurrencyHandler) ListCurrencies(c *gin.Context) {
+	h.listCurrencies(c, true)
+}
+
+// ListAllCurrencies returns every currency, including the disabled ones
+func (h *CurrencyHandler) ListAllCurrencies(c *gin.Context) {
+	h.listCurrencies(c, false)
+}
+
+func (h *CurrencyHandler) listCurrencies(c *gin.Context, enabledOnly bool) {
+	currencies, err := h.currencyQueries.ListCurrencies(enabledOnly)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get currencies"})
+		return
+	}
+	c.JSON(http.StatusOK, models.CurrencyListResponse{Base: models.PaymentCurrency, Currencies: currencies})
+}
+
+// SaveCurrency adds a currency or sets its rate by hand
+func (h *CurrencyHandler) SaveCurrency(c *gin.Context) {
+	code := strings.ToUpper(c.Param("code"))
+	if !currencyCode.MatchString(code) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid currency code"})
+		return
+	}
+	if code == models.PaymentCurrency {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The base currency can't be changed"})
+		return
+	}
+
+	var req models.CurrencyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	currency, err := h.currencyQueries.SaveCurrency(code, &req)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save currency"})
+		return
+	}
+	c.JSON(http.StatusOK, currency)
+}
+
+// UpdateRates sets the rates of the currencies to NBP's current average rates. Rates
+// set by hand are overwritten; currencies NBP has no rate for keep theirs.
+func (h *CurrencyHandler) UpdateRates(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	table, err := h.rates.Rates(ctx)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to fetch exchange rates"})
+		return
+	}
+
+	updated, missing, err := h.currencyQueries.UpdateRates(table.Rates, models.CurrencySourceNBP)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update exchange rates"})
+		return
+	}
+	c.JSON(http.StatusOK, models.ExchangeRateUpdateResponse{EffectiveDate: table.EffectiveDate, Updated: updated, Missing: missing})
+}
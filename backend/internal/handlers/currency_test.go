@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"notsofluffy-backend/internal/middleware"
+	"notsofluffy-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPriceFormatConvertsToRequestCurrency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Set(middleware.CurrencyKey, &models.Currency{Code: "EUR", Rate: 4.25, Enabled: true})
+
+	cart := &models.CartResponse{Subtotal: 100, TotalPrice: 100}
+	formatCart(priceFormat(c), cart)
+	if cart.Formatted["subtotal"] != "€23.53" {
+		t.Fatalf("expected 100 zł shown in euro, got %q", cart.Formatted["subtotal"])
+	}
+	if cart.Subtotal != 100 {
+		t.Fatalf("expected the amount to stay in the base currency, got %v", cart.Subtotal)
+	}
+
+	// Orders keep the currency and rate they were placed with
+	order := &models.OrderResponse{TotalAmount: 100, Currency: "EUR", ExchangeRate: 4}
+	formatOrder(orderPriceFormat(c, order.Currency, order.ExchangeRate), order)
+	if order.Formatted["total_amount"] != "€25.00" {
+		t.Fatalf("expected the locked rate, got %q", order.Formatted["total_amount"])
+	}
+	order = &models.OrderResponse{TotalAmount: 100}
+	formatOrder(orderPriceFormat(c, order.Currency, order.ExchangeRate), order)
+	if order.Formatted["total_amount"] != orderPriceFormat(c, models.PaymentCurrency, 1).Format(100) {
+		t.Fatalf("expected orders without a currency in the base currency, got %q", order.Formatted["total_amount"])
+	}
+}
+
+func TestConvertPrice(t *testing.T) {
+	cases := []struct{ amount, rate, want float64 }{
+		{100, 1, 100},
+		{100, 0, 100},
+		{129.99, 4.2618, 30.5},
+		{0.05, 4, 0.01},
+	}
+	for _, tc := range cases {
+		if got := convertPrice(tc.amount, tc.rate); got != tc.want {
+			t.Fatalf("convertPrice(%v, %v) = %v, want %v", tc.amount, tc.rate, got, tc.want)
+		}
+	}
+}
+
+func TestSaveCurrencyRejectsInvalidCodes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewCurrencyHandler(nil, nil)
+	for _, code := range []string{"EURO", "E1R", "pln"} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{{Key: "code", Value: code}}
+		c.Request = httptest.NewRequest(http.MethodPut, "/api/admin/currencies/"+code, bytes.NewBufferString(`{"name":"x","rate":1}`))
+		h.SaveCurrency(c)
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected a bad request, got %d", code, w.Code)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DebugCaptureHandler struct {
+	debugCaptureQueries *database.DebugCaptureQueries
+}
+
+func NewDebugCaptureHandler(debugCaptureQueries *database.DebugCaptureQueries) *DebugCaptureHandler {
+	return &DebugCaptureHandler{debugCaptureQueries: debugCaptureQueries}
+}
+
+// ListDebugCaptures returns debug captures, newest first
+func (h *DebugCaptureHandler) ListDebugCaptures(c *gin.Context) {
+	page, limit := debugCapturePage(c)
+
+	response, err := h.debugCaptureQueries.ListDebugCaptures(page, limit)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list debug captures"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateDebugCapture starts recording the API traffic of a cart session or a user. The
+// session ID is the one shown in the cart session history.
+func (h *DebugCaptureHandler) CreateDebugCapture(c *gin.Context) {
+	var req models.DebugCaptureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	req.SessionID = strings.TrimSpace(req.SessionID)
+	if (req.SessionID == "") == (req.UserID == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Provide either session_id or user_id"})
+		return
+	}
+	if req.DurationMinutes > models.MaxDebugCaptureMinutes {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "duration_minutes must be at most " + strconv.Itoa(models.MaxDebugCaptureMinutes)})
+		return
+	}
+
+	capture, err := h.debugCaptureQueries.CreateDebugCapture(&req, getUserIDPtr(c))
+	if err != nil {
+		if err.Error() == "user not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create debug capture"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, capture)
+}
+
+// StopDebugCapture ends a debug capture before it expires
+func (h *DebugCaptureHandler) StopDebugCapture(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid debug capture ID"})
+		return
+	}
+
+	capture, err := h.debugCaptureQueries.StopDebugCapture(id)
+	if err != nil {
+		if err.Error() == "debug capture not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Debug capture not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to stop debug capture"})
+		return
+	}
+
+	c.JSON(http.StatusOK, capture)
+}
+
+// GetDebugCaptureEntries returns the recorded requests of a capture in the order they happened
+func (h *DebugCaptureHandler) GetDebugCaptureEntries(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid debug capture ID"})
+		return
+	}
+	page, limit := debugCapturePage(c)
+
+	response, err := h.debugCaptureQueries.ListDebugCaptureEntries(id, page, limit)
+	if err != nil {
+		if err.Error() == "debug capture not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Debug capture not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get debug capture entries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func debugCapturePage(c *gin.Context) (int, int) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	return page, limit
+}
@@ -52,6 +52,7 @@ func (h *DiscountHandler) ApplyDiscountToCart(c *gin.Context) {
 	// Get or create cart session
 	cartSession, err := h.cartQueries.GetOrCreateCartSession(sessionIDStr, userID)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get cart session"})
 		return
 	}
@@ -59,6 +60,7 @@ func (h *DiscountHandler) ApplyDiscountToCart(c *gin.Context) {
 	// Get current cart
 	cart, err := h.cartQueries.GetCartItems(cartSession.ID)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get cart items"})
 		return
 	}
@@ -71,12 +73,15 @@ func (h *DiscountHandler) ApplyDiscountToCart(c *gin.Context) {
 	// Calculate current cart total
 	var cartTotal float64
 	for _, item := range cart {
-		cartTotal += item.TotalPrice
+		if !item.Unavailable {
+			cartTotal += item.TotalPrice
+		}
 	}
 
 	// Validate discount code
 	validationResult, err := h.discountQueries.ValidateDiscountCode(code, cartTotal, userID, sessionIDStr)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate discount code"})
 		return
 	}
@@ -89,6 +94,7 @@ func (h *DiscountHandler) ApplyDiscountToCart(c *gin.Context) {
 	// Apply discount to cart session
 	err = h.discountQueries.ApplyDiscountToCartSession(cartSession.ID, validationResult.DiscountCode.ID, validationResult.DiscountAmount)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply discount"})
 		return
 	}
@@ -129,6 +135,7 @@ func (h *DiscountHandler) RemoveDiscountFromCart(c *gin.Context) {
 	// Get cart session
 	cartSession, err := h.cartQueries.GetOrCreateCartSession(sessionIDStr, userID)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get cart session"})
 		return
 	}
@@ -136,6 +143,7 @@ func (h *DiscountHandler) RemoveDiscountFromCart(c *gin.Context) {
 	// Remove discount from cart session
 	err = h.discountQueries.RemoveDiscountFromCartSession(cartSession.ID)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove discount"})
 		return
 	}
@@ -163,15 +171,8 @@ func (h *DiscountHandler) CreateDiscountCode(c *gin.Context) {
 	// Normalize code to uppercase
 	req.Code = strings.ToUpper(strings.TrimSpace(req.Code))
 
-	// Validate percentage discount
-	if req.DiscountType == models.DiscountTypePercentage && req.DiscountValue > 100 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Percentage discount cannot exceed 100%"})
-		return
-	}
-
-	// Validate date range
-	if req.EndDate != nil && req.EndDate.Before(req.StartDate) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "End date must be after start date"})
+	if msg := validateDiscountCodeRequest(&req); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
 		return
 	}
 
@@ -183,6 +184,7 @@ func (h *DiscountHandler) CreateDiscountCode(c *gin.Context) {
 			c.JSON(http.StatusConflict, gin.H{"error": "Discount code already exists"})
 			return
 		}
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create discount code"})
 		return
 	}
@@ -214,6 +216,7 @@ func (h *DiscountHandler) GetDiscountCodes(c *gin.Context) {
 
 	discountCodes, err := h.discountQueries.GetDiscountCodes(page, limit, activeFilter)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get discount codes"})
 		return
 	}
@@ -236,6 +239,7 @@ func (h *DiscountHandler) GetDiscountCode(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Discount code not found"})
 			return
 		}
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get discount code"})
 		return
 	}
@@ -261,15 +265,8 @@ func (h *DiscountHandler) UpdateDiscountCode(c *gin.Context) {
 	// Normalize code to uppercase
 	req.Code = strings.ToUpper(strings.TrimSpace(req.Code))
 
-	// Validate percentage discount
-	if req.DiscountType == models.DiscountTypePercentage && req.DiscountValue > 100 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Percentage discount cannot exceed 100%"})
-		return
-	}
-
-	// Validate date range
-	if req.EndDate != nil && req.EndDate.Before(req.StartDate) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "End date must be after start date"})
+	if msg := validateDiscountCodeRequest(&req); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
 		return
 	}
 
@@ -283,6 +280,7 @@ func (h *DiscountHandler) UpdateDiscountCode(c *gin.Context) {
 			c.JSON(http.StatusConflict, gin.H{"error": "Discount code already exists"})
 			return
 		}
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update discount code"})
 		return
 	}
@@ -305,6 +303,7 @@ func (h *DiscountHandler) DeleteDiscountCode(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Discount code not found"})
 			return
 		}
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete discount code"})
 		return
 	}
@@ -327,9 +326,105 @@ func (h *DiscountHandler) GetDiscountCodeUsage(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Discount code not found"})
 			return
 		}
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get usage statistics"})
 		return
 	}
 
 	c.JSON(http.StatusOK, usage)
-}
\ No newline at end of file
+}
+// GetDiscountCodeUsers lists the customers a discount code is restricted to (admin only)
+func (h *DiscountHandler) GetDiscountCodeUsers(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid discount code ID"})
+		return
+	}
+
+	users, err := h.discountQueries.ListDiscountCodeUsers(id)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get discount code users"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"users": users})
+}
+
+// AddDiscountCodeUser restricts a discount code to a user account or an email address,
+// e.g. a compensation coupon for one customer (admin only)
+func (h *DiscountHandler) AddDiscountCodeUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid discount code ID"})
+		return
+	}
+
+	var req models.DiscountCodeUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Email = strings.TrimSpace(req.Email)
+	if (req.UserID == nil) == (req.Email == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Either user_id or email is required"})
+		return
+	}
+
+	user, err := h.discountQueries.AddDiscountCodeUser(id, &req, getUserIDPtr(c))
+	if err != nil {
+		switch err.Error() {
+		case "discount code not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": "Discount code not found"})
+		case "user not found":
+			c.JSON(http.StatusBadRequest, gin.H{"error": "User not found"})
+		case "discount code user already exists":
+			c.JSON(http.StatusConflict, gin.H{"error": "Discount code is already assigned to this customer"})
+		default:
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to assign discount code"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, user)
+}
+
+// RemoveDiscountCodeUser removes a customer from a discount code's restrictions; the
+// code is open to everyone again once none are left (admin only)
+func (h *DiscountHandler) RemoveDiscountCodeUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid discount code ID"})
+		return
+	}
+	assignmentID, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid discount code user ID"})
+		return
+	}
+
+	if err := h.discountQueries.RemoveDiscountCodeUser(id, assignmentID); err != nil {
+		if err.Error() == "discount code user not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Discount code user not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove discount code user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Discount code user removed successfully"})
+}
+
+// validateDiscountCodeRequest checks the rules of a discount code not covered by the
+// request bindings and returns the error message, or "" when the code is valid
+func validateDiscountCodeRequest(req *models.DiscountCodeRequest) string {
+	if req.DiscountType == models.DiscountTypePercentage && req.DiscountValue > 100 {
+		return "Percentage discount cannot exceed 100%"
+	}
+	if req.EndDate != nil && req.EndDate.Before(req.StartDate) {
+		return "End date must be after start date"
+	}
+	return database.ValidateDiscountWindows(req.Windows)
+}
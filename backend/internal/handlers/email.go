@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/mail"
+	"notsofluffy-backend/internal/middleware"
+	"notsofluffy-backend/internal/models"
+)
+
+type EmailHandler struct {
+	emailQueries *database.EmailQueries
+}
+
+func NewEmailHandler(emailQueries *database.EmailQueries) *EmailHandler {
+	return &EmailHandler{emailQueries: emailQueries}
+}
+
+// queueEmail renders the template of key for an order and puts the email in the
+// outbox. Emails are a side effect, so a failure is logged and never fails the request.
+func queueEmail(ctx context.Context, emailQueries *database.EmailQueries, key string, data models.EmailTemplateData) {
+	tpl, err := emailQueries.GetEmailTemplate(key)
+	if err != nil {
+		middleware.Logger(ctx).Error("failed to get email template", "template", key, "error", err)
+		return
+	}
+	msg, err := mail.Render(tpl, data.Order.Email, data)
+	if err != nil {
+		middleware.Logger(ctx).Error("failed to render email", "template", key, "order_id", data.Order.ID, "error", err)
+		return
+	}
+	if err := emailQueries.EnqueueEmail(key, msg, &data.Order.ID); err != nil {
+		middleware.Logger(ctx).Error("failed to queue email", "template", key, "order_id", data.Order.ID, "error", err)
+	}
+}
+
+// queueStatusEmail tells the customer that an admin changed the status of their order.
+// Moving between custom statuses of the same core status sends nothing.
+func queueStatusEmail(ctx context.Context, emailQueries *database.EmailQueries, orderQueries *database.OrderQueries, change *models.OrderStatusChangedEvent) {
+	if change.PreviousStatus == change.Status {
+		return
+	}
+	order, err := orderQueries.GetOrderByID(change.OrderID)
+	if err != nil {
+		middleware.Logger(ctx).Error("failed to get order for its status email", "order_id", change.OrderID, "error", err)
+		return
+	}
+	queueEmail(ctx, emailQueries, mail.TemplateOrderStatus, models.EmailTemplateData{Order: order, PreviousStatus: change.PreviousStatus, Status: change.Status})
+}
+
+// sampleEmailData is what customized templates are test rendered with before they are saved
+func sampleEmailData() models.EmailTemplateData {
+	carrier, tracking := "InPost", "600000000000000000000000"
+	return models.EmailTemplateData{
+		Order: &models.OrderResponse{
+			ID:              1001,
+			Email:           "customer@example.com",
+			Status:          models.OrderStatusShipped,
+			Subtotal:        129.9,
+			ShippingCost:    20,
+			TotalAmount:     149.9,
+			PaymentStatus:   models.PaymentStatusCompleted,
+			TrackingCarrier: &carrier,
+			TrackingNumber:  &tracking,
+			Items: []models.OrderItem{
+				{ProductName: "Sample product", VariantName: "Sample variant", SizeName: "M", Quantity: 1, UnitPrice: 129.9, TotalPrice: 129.9},
+			},
+			CreatedAt: time.Now(),
+		},
+		PreviousStatus: models.OrderStatusProcessing,
+		Status:         models.OrderStatusShipped,
+		Return: &models.OrderReturn{
+			ID:             1,
+			OrderID:        1001,
+			Status:         models.OrderReturnStatusApproved,
+			TrackingNumber: &tracking,
+			Items: []models.OrderReturnItem{
+				{ProductName: "Sample product", VariantName: "Sample variant", SizeName: "M", Quantity: 1},
+			},
+		},
+		OrderURL: "https://example.com/order/sample",
+	}
+}
+
+// ListEmailTemplates returns every email template, customized or default
+func (h *EmailHandler) ListEmailTemplates(c *gin.Context) {
+	overrides, err := h.emailQueries.ListEmailTemplateOverrides()
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get email templates"})
+		return
+	}
+
+	templates := []models.EmailTemplate{}
+	for key, def := range mail.DefaultTemplates {
+		if override, ok := overrides[key]; ok {
+			templates = append(templates, override)
+			continue
+		}
+		templates = append(templates, models.EmailTemplate{Key: key, Subject: def.Subject, Text: def.Text, HTML: def.HTML})
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Key < templates[j].Key })
+
+	c.JSON(http.StatusOK, gin.H{"templates": templates})
+}
+
+// UpdateEmailTemplate customizes a template. It is rendered with sample data first, so
+// a broken template is rejected instead of failing the emails sent with it.
+func (h *EmailHandler) UpdateEmailTemplate(c *gin.Context) {
+	key := c.Param("key")
+	if _, ok := mail.DefaultTemplates[key]; !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Email template not found"})
+		return
+	}
+
+	var req models.EmailTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := mail.Render(mail.Template{Subject: req.Subject, Text: req.Text, HTML: req.HTML}, "customer@example.com", sampleEmailData()); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	template, err := h.emailQueries.SaveEmailTemplate(key, &req)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save email template"})
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// ResetEmailTemplate drops the customization of a template
+func (h *EmailHandler) ResetEmailTemplate(c *gin.Context) {
+	key := c.Param("key")
+	def, ok := mail.DefaultTemplates[key]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Email template not found"})
+		return
+	}
+
+	if err := h.emailQueries.DeleteEmailTemplate(key); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset email template"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.EmailTemplate{Key: key, Subject: def.Subject, Text: def.Text, HTML: def.HTML})
+}
+
+// ListEmailOutbox returns queued, sent and failed emails, optionally in ?status=
+func (h *EmailHandler) ListEmailOutbox(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	status := c.Query("status")
+	switch status {
+	case "", models.EmailOutboxPending, models.EmailOutboxSent, models.EmailOutboxFailed:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be 'pending', 'sent' or 'failed'"})
+		return
+	}
+
+	emails, err := h.emailQueries.ListOutbox(status, page, limit)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get outbox emails"})
+		return
+	}
+
+	c.JSON(http.StatusOK, emails)
+}
+
+// RetryEmail sends an email that was given up again
+func (h *EmailHandler) RetryEmail(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email ID"})
+		return
+	}
+
+	email, err := h.emailQueries.RetryEmail(id)
+	if err != nil {
+		if err.Error() == "failed email not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Failed email not found"})
+			return
+		}
+		if err.Error() == "email address is suppressed" {
+			c.JSON(http.StatusConflict, gin.H{"error": "Email address is suppressed"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retry email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, email)
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/mail"
+	"notsofluffy-backend/internal/models"
+)
+
+// EmailPreferenceHandler lets customers choose which emails they receive, from their
+// account or with the signed unsubscribe link in an email
+type EmailPreferenceHandler struct {
+	emailQueries *database.EmailQueries
+	userQueries  *database.UserQueries
+	unsubscribe  *mail.UnsubscribeLinks
+}
+
+func NewEmailPreferenceHandler(emailQueries *database.EmailQueries, userQueries *database.UserQueries, unsubscribe *mail.UnsubscribeLinks) *EmailPreferenceHandler {
+	return &EmailPreferenceHandler{emailQueries: emailQueries, userQueries: userQueries, unsubscribe: unsubscribe}
+}
+
+// GetPreferences returns the email preferences of the authenticated user
+func (h *EmailPreferenceHandler) GetPreferences(c *gin.Context) {
+	email, ok := h.userEmail(c)
+	if !ok {
+		return
+	}
+
+	prefs, err := h.emailQueries.GetEmailPreferences(email)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get email preferences"})
+		return
+	}
+
+	c.JSON(http.StatusOK, prefs)
+}
+
+// UpdatePreferences changes the email preferences of the authenticated user
+func (h *EmailPreferenceHandler) UpdatePreferences(c *gin.Context) {
+	var req models.EmailPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	email, ok := h.userEmail(c)
+	if !ok {
+		return
+	}
+
+	prefs, err := h.emailQueries.GetEmailPreferences(email)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get email preferences"})
+		return
+	}
+	applyEmailPreferences(prefs, &req)
+
+	saved, err := h.emailQueries.SaveEmailPreferences(email, prefs)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save email preferences"})
+		return
+	}
+
+	c.JSON(http.StatusOK, saved)
+}
+
+// GetUnsubscribe describes what an unsubscribe token opts out of, for the page
+// confirming it
+func (h *EmailPreferenceHandler) GetUnsubscribe(c *gin.Context) {
+	email, category, ok := h.unsubscribe.Parse(c.Query("token"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid unsubscribe link"})
+		return
+	}
+
+	prefs, err := h.emailQueries.GetEmailPreferences(email)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get email preferences"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"email": email, "category": category, "preferences": prefs})
+}
+
+// Unsubscribe opts the address of a signed unsubscribe token out of its category of
+// email. It needs no login; the token is only ever in emails sent to the address.
+func (h *EmailPreferenceHandler) Unsubscribe(c *gin.Context) {
+	var req models.UnsubscribeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	email, category, ok := h.unsubscribe.Parse(req.Token)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid unsubscribe link"})
+		return
+	}
+
+	prefs, err := h.emailQueries.Unsubscribe(email, category)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unsubscribe"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"email": email, "category": category, "preferences": prefs})
+}
+
+// userEmail returns the current email address of the authenticated user, responding
+// with an error when it can't
+func (h *EmailPreferenceHandler) userEmail(c *gin.Context) (string, bool) {
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return "", false
+	}
+	user, err := h.userQueries.GetUserByID(userID)
+	if err != nil {
+		if err.Error() == "user not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return "", false
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user"})
+		return "", false
+	}
+	return user.Email, true
+}
+
+// applyEmailPreferences sets the preferences given in a request
+func applyEmailPreferences(prefs *models.EmailPreferences, req *models.EmailPreferencesRequest) {
+	if req.OrderUpdates != nil {
+		prefs.OrderUpdates = *req.OrderUpdates
+	}
+	if req.Promotions != nil {
+		prefs.Promotions = *req.Promotions
+	}
+	if req.BackInStock != nil {
+		prefs.BackInStock = *req.BackInStock
+	}
+}
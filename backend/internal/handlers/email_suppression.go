@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/mail"
+	"notsofluffy-backend/internal/middleware"
+	"notsofluffy-backend/internal/models"
+)
+
+type EmailFeedbackHandler struct {
+	emailQueries *database.EmailQueries
+	secret       string
+}
+
+// NewEmailFeedbackHandler creates the handler for the email provider's bounce and
+// complaint notifications; they are refused while secret is empty
+func NewEmailFeedbackHandler(emailQueries *database.EmailQueries, secret string) *EmailFeedbackHandler {
+	return &EmailFeedbackHandler{emailQueries: emailQueries, secret: secret}
+}
+
+// ReceiveEmailEvents suppresses the addresses that hard bounced or complained in a
+// provider's notification. The provider sends the secret as ?token= or the
+// X-Webhook-Token header. Suppressing is idempotent, so providers may retry.
+func (h *EmailFeedbackHandler) ReceiveEmailEvents(c *gin.Context) {
+	if h.secret == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Email events are not enabled"})
+		return
+	}
+	token := c.GetHeader("X-Webhook-Token")
+	if token == "" {
+		token = c.Query("token")
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(h.secret)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid webhook token"})
+		return
+	}
+
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read notification"})
+		return
+	}
+	provider := c.Param("provider")
+	feedback, err := mail.ParseFeedback(provider, body)
+	if err != nil {
+		if errors.Is(err, mail.ErrUnknownFeedbackProvider) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Unknown email provider"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification"})
+		return
+	}
+
+	suppressed := 0
+	for _, f := range feedback {
+		// Temporary bounces are retried by the provider and the outbox
+		if !f.Permanent {
+			continue
+		}
+		reason := models.EmailSuppressionBounce
+		if f.Kind == mail.FeedbackComplaint {
+			reason = models.EmailSuppressionComplaint
+		}
+		if err := h.emailQueries.SuppressEmail(f.Email, reason, provider, f.Detail); err != nil {
+			middleware.Logger(c.Request.Context()).Error("failed to suppress email address", "email", f.Email, "kind", f.Kind, "error", err)
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to suppress email"})
+			return
+		}
+		suppressed++
+	}
+
+	c.JSON(http.StatusOK, gin.H{"received": len(feedback), "suppressed": suppressed})
+}
+
+// ListEmailSuppressions returns the addresses no email is sent to, optionally those
+// matching ?search=
+func (h *EmailHandler) ListEmailSuppressions(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	suppressions, err := h.emailQueries.ListEmailSuppressions(strings.TrimSpace(c.Query("search")), page, limit)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get email suppressions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, suppressions)
+}
+
+// DeleteEmailSuppression sends email to an address again, e.g. after the customer
+// fixed their mailbox
+func (h *EmailHandler) DeleteEmailSuppression(c *gin.Context) {
+	if err := h.emailQueries.DeleteEmailSuppression(c.Param("email")); err != nil {
+		if err.Error() == "email suppression not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Email suppression not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete email suppression"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email suppression removed"})
+}
+
+// flagUndeliverableOrders marks orders whose address bounced or complained in admin
+// views; the flag is a hint, so the orders are served without it when the check fails
+func (h *AdminHandler) flagUndeliverableOrders(ctx context.Context, orders []models.OrderResponse) {
+	emails := make([]string, len(orders))
+	for i, order := range orders {
+		emails[i] = order.Email
+	}
+	suppressed, err := h.emailQueries.GetSuppressedEmails(emails)
+	if err != nil {
+		middleware.Logger(ctx).Error("failed to flag undeliverable order emails", "error", err)
+		return
+	}
+	for i := range orders {
+		orders[i].EmailUndeliverable = suppressed[strings.ToLower(strings.TrimSpace(orders[i].Email))]
+	}
+}
+
+// flagUndeliverableUsers marks users whose address bounced or complained in the admin
+// user list
+func (h *AdminHandler) flagUndeliverableUsers(ctx context.Context, users []models.AdminUserSummary) {
+	emails := make([]string, len(users))
+	for i, user := range users {
+		emails[i] = user.Email
+	}
+	suppressed, err := h.emailQueries.GetSuppressedEmails(emails)
+	if err != nil {
+		middleware.Logger(ctx).Error("failed to flag undeliverable user emails", "error", err)
+		return
+	}
+	for i := range users {
+		users[i].EmailUndeliverable = suppressed[strings.ToLower(strings.TrimSpace(users[i].Email))]
+	}
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestReceiveEmailEventsRefused(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cases := []struct {
+		secret, url, token string
+		want               int
+	}{
+		{"", "/api/email/events/postmark?token=", "", http.StatusNotFound},
+		{"s3cret", "/api/email/events/postmark", "", http.StatusUnauthorized},
+		{"s3cret", "/api/email/events/postmark?token=guess", "", http.StatusUnauthorized},
+		{"s3cret", "/api/email/events/mailchimp", "s3cret", http.StatusNotFound},
+		{"s3cret", "/api/email/events/postmark?token=s3cret", "", http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		r := gin.New()
+		r.POST("/api/email/events/:provider", NewEmailFeedbackHandler(nil, tc.secret).ReceiveEmailEvents)
+		req := httptest.NewRequest(http.MethodPost, tc.url, strings.NewReader("not json"))
+		if tc.token != "" {
+			req.Header.Set("X-Webhook-Token", tc.token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Fatalf("%s: expected %d, got %d %s", tc.url, tc.want, w.Code, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"notsofluffy-backend/internal/csvimport"
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/exports"
+	"notsofluffy-backend/internal/middleware"
+	"notsofluffy-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportHandler serves the export center. Exports are generated by the exports job
+// rather than in the request, so large ones don't run into HTTP timeouts; the admin
+// who requested one is emailed when it is ready.
+type ExportHandler struct {
+	exportQueries   *database.ExportQueries
+	settingsQueries *database.SettingsQueries
+}
+
+func NewExportHandler(exportQueries *database.ExportQueries, settingsQueries *database.SettingsQueries) *ExportHandler {
+	return &ExportHandler{exportQueries: exportQueries, settingsQueries: settingsQueries}
+}
+
+// ListExports returns previously requested exports with their status, newest first,
+// optionally of one type
+func (h *ExportHandler) ListExports(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	exportType := c.Query("type")
+	switch exportType {
+	case "", models.ExportTypeOrders, models.ExportTypeProductFeed, models.ExportTypeCustomers:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid export type"})
+		return
+	}
+
+	response, err := h.exportQueries.ListExports(exportType, page, limit)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list exports"})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateExport queues an export for the exports job
+func (h *ExportHandler) CreateExport(c *gin.Context) {
+	var req models.ExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.From != nil && req.To != nil && !req.From.Before(*req.To) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be before to"})
+		return
+	}
+
+	export, err := h.exportQueries.CreateExport(req.Type, req.ExportParams, getUserIDPtr(c))
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to request export"})
+		return
+	}
+	c.JSON(http.StatusAccepted, export)
+}
+
+// GetExport returns an export and its status
+func (h *ExportHandler) GetExport(c *gin.Context) {
+	export, ok := h.getExport(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, export)
+}
+
+// DownloadExport sends the file of a completed export
+func (h *ExportHandler) DownloadExport(c *gin.Context) {
+	export, ok := h.getExport(c)
+	if !ok {
+		return
+	}
+	if export.Status != models.ExportStatusCompleted || export.Path == nil || export.FileName == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Export is not ready", "status": export.Status})
+		return
+	}
+	c.FileAttachment(*export.Path, *export.FileName)
+}
+
+// ExportOrders streams the items of the orders placed between ?from= and ?to= (dates or
+// times in the shop's time zone, to's whole day included) as ?format=csv or xlsx for
+// accounting, optionally of one ?status=. Rows are written as they are read, so memory
+// stays flat for any range; ranges too large for one request go through the export center.
+func (h *ExportHandler) ExportOrders(c *gin.Context) {
+	format := c.DefaultQuery("format", models.ExportFormatCSV)
+	if format != models.ExportFormatCSV && format != models.ExportFormatXLSX {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or xlsx"})
+		return
+	}
+
+	loc := h.settingsQueries.GetShopLocation()
+	var params models.ExportParams
+	if value := c.Query("from"); value != "" {
+		from, err := csvimport.ParseTime(value, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date, expected YYYY-MM-DD"})
+			return
+		}
+		params.From = &from
+	}
+	if value := c.Query("to"); value != "" {
+		to, err := csvimport.ParseTime(value, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date, expected YYYY-MM-DD"})
+			return
+		}
+		if len(value) == len("2006-01-02") {
+			// Include the whole end day
+			to = to.AddDate(0, 0, 1)
+		}
+		params.To = &to
+	}
+	if params.From != nil && params.To != nil && !params.From.Before(*params.To) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be before to"})
+		return
+	}
+	switch params.Status = c.Query("status"); params.Status {
+	case "", models.OrderStatusPending, models.OrderStatusProcessing, models.OrderStatusShipped,
+		models.OrderStatusDelivered, models.OrderStatusCancelled:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+		return
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if format == models.ExportFormatXLSX {
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=orders-%s.%s", time.Now().In(loc).Format("2006-01-02"), format))
+	c.Status(http.StatusOK)
+
+	// The response has started, so a failure can only cut the file short
+	if rows, err := exports.WriteOrderLines(c.Writer, format, h.exportQueries, params, loc); err != nil {
+		middleware.Logger(c.Request.Context()).Error("order export failed", "rows", rows, "error", err)
+		c.Abort()
+	}
+}
+
+func (h *ExportHandler) getExport(c *gin.Context) (*models.Export, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid export ID"})
+		return nil, false
+	}
+
+	export, err := h.exportQueries.GetExport(id)
+	if err != nil {
+		if err.Error() == "export not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Export not found"})
+			return nil, false
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get export"})
+		return nil, false
+	}
+	return export, true
+}
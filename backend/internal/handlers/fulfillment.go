@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/fulfillment"
+	"notsofluffy-backend/internal/middleware"
+	"notsofluffy-backend/internal/models"
+)
+
+// fulfillmentSettings reads the shop's shipping times, falling back to the defaults
+func fulfillmentSettings(settingsQueries *database.SettingsQueries) fulfillment.Settings {
+	settings := fulfillment.Settings{
+		InStockDispatchDays: models.DefaultInStockDispatchDays,
+		MadeToOrderLeadDays: models.DefaultMadeToOrderLeadDays,
+	}
+	if days, err := settingsQueries.GetIntSetting(models.SettingInStockDispatchDays, settings.InStockDispatchDays); err == nil && days >= 0 {
+		settings.InStockDispatchDays = days
+	}
+	if days, err := settingsQueries.GetIntSetting(models.SettingMadeToOrderLeadDays, settings.MadeToOrderLeadDays); err == nil && days > 0 {
+		settings.MadeToOrderLeadDays = days
+	}
+	return settings
+}
+
+// productionLeadDays returns the days until the cart's made-to-order items could be made
+// with the production capacity left, or 0 when capacity is unlimited or can't be read
+func productionLeadDays(ctx context.Context, productionQueries *database.ProductionQueries, items []models.CartItemResponse) int {
+	units := 0
+	for _, item := range items {
+		if item.MadeToOrder && !item.Unavailable {
+			units += item.Quantity
+		}
+	}
+
+	days, err := productionQueries.PreviewLeadDays(units)
+	if err != nil {
+		middleware.Logger(ctx).Error("failed to estimate production lead time", "error", err)
+		return 0
+	}
+	return days
+}
+
+// applyLeadTimes sets the days until each cart item can be dispatched and returns the
+// available items for the fulfillment package
+func applyLeadTimes(items []models.CartItemResponse, settings fulfillment.Settings) []fulfillment.Item {
+	result := []fulfillment.Item{}
+	for i := range items {
+		item := fulfillment.Item{ID: items[i].ID, Quantity: items[i].Quantity, MadeToOrder: items[i].MadeToOrder, LeadTimeDays: items[i].LeadTimeDays}
+		items[i].LeadTimeDays = fulfillment.ItemLeadDays(item, settings)
+		if !items[i].Unavailable {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// GetSizeStock returns whether ?quantity= (default 1) of a size can be added to the
+// cart, how many are left and when it would ship, so the storefront can disable adding
+// it before the cart refuses. The lead time leaves out the production capacity the cart
+// also checks, keeping the call cheap enough for every size selection.
+func (h *PublicHandler) GetSizeStock(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+	sizeID, err := strconv.Atoi(c.Param("sizeId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid size ID"})
+		return
+	}
+	quantity, err := strconv.Atoi(c.DefaultQuery("quantity", "1"))
+	if err != nil || quantity < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quantity"})
+		return
+	}
+
+	stock, err := h.productQueries.GetSizeStock(productID, sizeID)
+	if err != nil {
+		if err.Error() == "size not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Size not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check stock availability"})
+		return
+	}
+
+	stock.InStock = stock.Available == nil || *stock.Available >= quantity
+	item := fulfillment.Item{Quantity: quantity, MadeToOrder: stock.MadeToOrder, LeadTimeDays: stock.LeadTimeDays}
+	stock.LeadTimeDays = fulfillment.ItemLeadDays(item, fulfillmentSettings(h.settingsQueries))
+	c.JSON(http.StatusOK, stock)
+}
+
+// GetProductFulfillment returns whether a product is made to order
+func (h *AdminHandler) GetProductFulfillment(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	productFulfillment, err := h.productQueries.GetProductFulfillment(id)
+	if err != nil {
+		if err.Error() == "product not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve product fulfillment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, productFulfillment)
+}
+
+// UpdateProductFulfillment marks a product as made to order or in stock
+func (h *AdminHandler) UpdateProductFulfillment(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var req models.ProductFulfillmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	productFulfillment, err := h.productQueries.UpdateProductFulfillment(id, &req)
+	if err != nil {
+		if err.Error() == "product not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product fulfillment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, productFulfillment)
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"notsofluffy-backend/internal/middleware"
+	"notsofluffy-backend/internal/models"
+)
+
+// GetImageReport returns the storage used by the media library per MIME type, the
+// images nothing refers to and the images whose files are missing from storage
+func (h *AdminHandler) GetImageReport(c *gin.Context) {
+	images, err := h.imageQueries.ListImagesWithReferences()
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list images"})
+		return
+	}
+
+	report := models.ImageReport{
+		ByMimeType:   []models.ImageMimeUsage{},
+		Unreferenced: []models.ImageReportItem{},
+		MissingFiles: []models.ImageReportItem{},
+		GeneratedAt:  time.Now(),
+	}
+	usage := map[string]*models.ImageMimeUsage{}
+	for _, image := range images {
+		report.TotalImages++
+		report.TotalBytes += image.SizeBytes
+
+		mime := usage[image.MimeType]
+		if mime == nil {
+			mime = &models.ImageMimeUsage{MimeType: image.MimeType}
+			usage[image.MimeType] = mime
+		}
+		mime.Count++
+		mime.TotalBytes += image.SizeBytes
+
+		if image.References == 0 {
+			report.Unreferenced = append(report.Unreferenced, image)
+			report.UnreferencedBytes += image.SizeBytes
+		}
+
+		exists, err := h.mediaService.Exists(image.Path)
+		if err != nil {
+			middleware.Logger(c.Request.Context()).Error("failed to check image file", "image_id", image.ID, "error", err)
+			continue
+		}
+		if !exists {
+			report.MissingFiles = append(report.MissingFiles, image)
+		}
+	}
+
+	for _, mime := range usage {
+		report.ByMimeType = append(report.ByMimeType, *mime)
+	}
+	sort.Slice(report.ByMimeType, func(i, j int) bool {
+		return report.ByMimeType[i].TotalBytes > report.ByMimeType[j].TotalBytes
+	})
+
+	c.JSON(http.StatusOK, report)
+}
+
+// CleanupOrphanImages queues the deletion of images nothing refers to that were uploaded
+// at least ?min_age_hours= ago (24 by default). Images are deleted in the background and
+// each is checked again right before, so one attached in the meantime is kept.
+func (h *AdminHandler) CleanupOrphanImages(c *gin.Context) {
+	minAgeHours, err := strconv.Atoi(c.DefaultQuery("min_age_hours", strconv.Itoa(models.DefaultOrphanImageMinAgeHours)))
+	if err != nil || minAgeHours < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "min_age_hours must be a non-negative number"})
+		return
+	}
+
+	images, err := h.imageQueries.ListImagesWithReferences()
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list images"})
+		return
+	}
+
+	cutoff := time.Now().Add(-time.Duration(minAgeHours) * time.Hour)
+	orphans := []models.ImageReportItem{}
+	response := models.ImageCleanupResponse{MinAgeHours: minAgeHours}
+	for _, image := range images {
+		if image.References == 0 && image.CreatedAt.Before(cutoff) {
+			orphans = append(orphans, image)
+			response.Queued++
+			response.QueuedBytes += image.SizeBytes
+		}
+	}
+
+	if len(orphans) > 0 {
+		go h.deleteOrphanImages(c.Request.Context(), orphans)
+	}
+	c.JSON(http.StatusAccepted, response)
+}
+
+func (h *AdminHandler) deleteOrphanImages(ctx context.Context, images []models.ImageReportItem) {
+	deleted := 0
+	for _, image := range images {
+		references, err := h.imageQueries.GetImageReferences(image.ID)
+		if err != nil {
+			middleware.Logger(ctx).Error("failed to check usage of orphan image", "image_id", image.ID, "error", err)
+			continue
+		}
+		if len(references) > 0 {
+			continue
+		}
+
+		if err := h.imageQueries.DeleteImage(image.ID); err != nil {
+			middleware.Logger(ctx).Error("failed to delete orphan image", "image_id", image.ID, "error", err)
+			continue
+		}
+		h.mediaService.Remove(image.Path, image.MimeType)
+		deleted++
+	}
+	middleware.Logger(ctx).Info("orphan image cleanup finished", "deleted", deleted, "orphans", len(images))
+}
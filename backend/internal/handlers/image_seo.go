@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"notsofluffy-backend/internal/models"
+)
+
+// UpdateImageSEO sets the alt text and title of an image, used wherever the image is
+// shown unless a product overrides them
+func (h *AdminHandler) UpdateImageSEO(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid image ID"})
+		return
+	}
+
+	var req models.ImageSEORequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	image, err := h.imageQueries.GetImageByID(id)
+	if err != nil {
+		if err.Error() == "image not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Image not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get image"})
+		return
+	}
+
+	// Omitted fields keep their current value
+	if req.AltText != nil {
+		image.AltText = strings.TrimSpace(*req.AltText)
+	}
+	if req.Title != nil {
+		image.Title = strings.TrimSpace(*req.Title)
+	}
+
+	if err := h.imageQueries.UpdateImageSEO(id, image.AltText, image.Title); err != nil {
+		if err.Error() == "image not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Image not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update image alt text"})
+		return
+	}
+
+	c.JSON(http.StatusOK, imageToResponse(image))
+}
+
+// UpdateProductImageSEO sets the alt text and title a product shows one of its images
+// with. Empty values fall back to the image's own.
+func (h *AdminHandler) UpdateProductImageSEO(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+	imageID, err := strconv.Atoi(c.Param("imageId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid image ID"})
+		return
+	}
+
+	var req models.ProductImageSEORequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	altText, title := strings.TrimSpace(req.AltText), strings.TrimSpace(req.Title)
+	if err := h.productQueries.UpdateProductImageSEO(id, imageID, altText, title); err != nil {
+		if err.Error() == "product image not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product image not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product image alt text"})
+		return
+	}
+	h.recordProductRevision(c.Request.Context(), id, models.ProductRevisionUpdate, getUserIDPtr(c))
+
+	images, err := h.productQueries.GetProductImages(id)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get product images"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"images": images})
+}
@@ -0,0 +1,976 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"notsofluffy-backend/internal/csvimport"
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/models"
+	"notsofluffy-backend/internal/slug"
+	"notsofluffy-backend/internal/xlsx"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ImportHandler serves the CSV imports and exports of the admin panel. Imports take the
+// file, CSV or XLSX, as a multipart "file" field or as the request body; ?dry_run=true
+// validates the file and reports what would change without saving.
+type ImportHandler struct {
+	discountQueries *database.DiscountQueries
+	categoryQueries *database.CategoryQueries
+	productQueries  *database.ProductQueries
+	materialQueries *database.MaterialQueries
+	colorQueries    *database.ColorQueries
+	imageQueries    *database.ImageQueries
+	settingsQueries *database.SettingsQueries
+}
+
+func NewImportHandler(discountQueries *database.DiscountQueries, categoryQueries *database.CategoryQueries, productQueries *database.ProductQueries, materialQueries *database.MaterialQueries, colorQueries *database.ColorQueries, imageQueries *database.ImageQueries, settingsQueries *database.SettingsQueries) *ImportHandler {
+	return &ImportHandler{
+		discountQueries: discountQueries,
+		categoryQueries: categoryQueries,
+		productQueries:  productQueries,
+		materialQueries: materialQueries,
+		colorQueries:    colorQueries,
+		imageQueries:    imageQueries,
+		settingsQueries: settingsQueries,
+	}
+}
+
+// Dates of discount codes are read and written in the shop time zone. used_count is
+// exported for reference and ignored on import.
+var discountCodeColumns = csvimport.Columns{
+	Required: []string{"code", "description", "discount_type", "discount_value", "usage_type", "start_date"},
+	Optional: []string{"min_order_amount", "max_uses", "active", "end_date", "is_first_order_only", "windows", "used_count"},
+}
+
+var categoryColumns = csvimport.Columns{
+	Required: []string{"slug", "name"},
+	Optional: []string{"active", "chart_only", "image_id"},
+}
+
+// Products are imported from one file with a row per record: "product" rows hold the
+// product's fields, "size" and "variant" rows one size or variant of the product with
+// the row's slug, found by name. Cells of columns a record doesn't use are ignored.
+var productColumns = csvimport.Columns{
+	Required: []string{"record", "slug"},
+	Optional: []string{"name", "short_description", "description", "category", "material_id", "main_image_id", "image_ids",
+		"base_price", "a", "b", "c", "d", "e", "f", "use_stock", "stock_quantity", "color_id", "is_default"},
+}
+
+// productNumeric are the columns of productColumns written as numbers in spreadsheets
+var productNumeric = []int{6, 7, 9, 10, 11, 12, 13, 14, 15, 17, 18}
+
+// The record types of a product import
+const (
+	productRecord = "product"
+	sizeRecord    = "size"
+	variantRecord = "variant"
+)
+
+// ExportDiscountCodes downloads all discount codes as CSV
+func (h *ImportHandler) ExportDiscountCodes(c *gin.Context) {
+	codes, err := h.discountQueries.ListAllDiscountCodes()
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export discount codes"})
+		return
+	}
+
+	loc := h.settingsQueries.GetShopLocation()
+	records := make([][]string, len(codes))
+	for i, dc := range codes {
+		endDate := ""
+		if dc.EndDate != nil {
+			endDate = csvimport.FormatTime(*dc.EndDate, loc)
+		}
+		windows := ""
+		if len(dc.Windows) > 0 {
+			encoded, _ := json.Marshal(dc.Windows)
+			windows = string(encoded)
+		}
+		records[i] = []string{
+			dc.Code, dc.Description, dc.DiscountType, csvimport.FormatFloat(dc.DiscountValue), dc.UsageType,
+			csvimport.FormatTime(dc.StartDate, loc), csvimport.FormatFloat(dc.MinOrderAmount), csvimport.FormatOptionalInt(dc.MaxUses),
+			csvimport.FormatBool(dc.Active), endDate, csvimport.FormatBool(dc.IsFirstOrderOnly), windows, fmt.Sprint(dc.UsedCount),
+		}
+	}
+
+	writeCSV(c, "discount-codes", discountCodeColumns.All(), records)
+}
+
+// ImportDiscountCodes creates discount codes from a CSV file and updates the ones whose
+// code already exists; columns missing from the file keep their current values
+func (h *ImportHandler) ImportDiscountCodes(c *gin.Context) {
+	rows, ok := readImportFile(c, discountCodeColumns)
+	if !ok {
+		return
+	}
+
+	existing, err := h.discountQueries.ListAllDiscountCodes()
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load discount codes"})
+		return
+	}
+	byCode := make(map[string]models.DiscountCode, len(existing))
+	for _, dc := range existing {
+		byCode[dc.Code] = dc
+	}
+
+	loc := h.settingsQueries.GetShopLocation()
+	result := newImportResult(c, len(rows))
+	items := []database.DiscountCodeImport{}
+	seen := map[string]int{}
+	for _, row := range rows {
+		code := strings.ToUpper(row.Get("code"))
+		if line, duplicate := seen[code]; duplicate {
+			result.Errors = append(result.Errors, row.Error("code", fmt.Sprintf("duplicates the code on line %d", line)))
+			continue
+		}
+		seen[code] = row.Line
+
+		item := database.DiscountCodeImport{Request: models.DiscountCodeRequest{Code: code, Active: true}}
+		if current, ok := byCode[code]; ok {
+			id := current.ID
+			item.ID = &id
+			item.Request = models.DiscountCodeRequest{
+				Code:             current.Code,
+				Description:      current.Description,
+				DiscountType:     current.DiscountType,
+				DiscountValue:    current.DiscountValue,
+				MinOrderAmount:   current.MinOrderAmount,
+				UsageType:        current.UsageType,
+				MaxUses:          current.MaxUses,
+				Active:           current.Active,
+				StartDate:        current.StartDate,
+				EndDate:          current.EndDate,
+				Windows:          current.Windows,
+				IsFirstOrderOnly: current.IsFirstOrderOnly,
+			}
+		}
+
+		if rowErrors := parseDiscountCodeRow(row, &item.Request, loc); len(rowErrors) > 0 {
+			result.Errors = append(result.Errors, rowErrors...)
+			continue
+		}
+		if item.ID == nil {
+			result.Created++
+		} else {
+			result.Updated++
+		}
+		items = append(items, item)
+	}
+
+	if len(result.Errors) == 0 && !result.DryRun {
+		if err := h.discountQueries.ImportDiscountCodes(items, getUserIDPtr(c)); err != nil {
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import discount codes", "details": err.Error()})
+			return
+		}
+		result.Applied = true
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// parseDiscountCodeRow applies the row's cells to req and validates the result
+func parseDiscountCodeRow(row csvimport.Row, req *models.DiscountCodeRequest, loc *time.Location) []models.ImportRowError {
+	var errs []models.ImportRowError
+	fail := func(column string, err error) {
+		errs = append(errs, row.Error(column, err.Error()))
+	}
+
+	if len(req.Code) < 2 || len(req.Code) > 50 {
+		errs = append(errs, row.Error("code", "must be 2-50 characters"))
+	}
+	req.Description = row.Get("description")
+	if req.Description == "" || len(req.Description) > 500 {
+		errs = append(errs, row.Error("description", "must be 1-500 characters"))
+	}
+	req.DiscountType = row.Get("discount_type")
+	if req.DiscountType != models.DiscountTypePercentage && req.DiscountType != models.DiscountTypeFixedAmount {
+		errs = append(errs, row.Error("discount_type", "must be percentage or fixed_amount"))
+	}
+	if value, err := csvimport.ParseFloat(row.Get("discount_value")); err != nil {
+		fail("discount_value", err)
+	} else if value <= 0 {
+		errs = append(errs, row.Error("discount_value", "must be greater than 0"))
+	} else {
+		req.DiscountValue = value
+	}
+	req.UsageType = row.Get("usage_type")
+	switch req.UsageType {
+	case models.UsageTypeOneTime, models.UsageTypeOncePerUser, models.UsageTypeUnlimited:
+	default:
+		errs = append(errs, row.Error("usage_type", "must be one_time, once_per_user or unlimited"))
+	}
+	if start, err := csvimport.ParseTime(row.Get("start_date"), loc); err != nil {
+		fail("start_date", err)
+	} else {
+		req.StartDate = start
+	}
+
+	if row.Has("min_order_amount") {
+		if value := row.Get("min_order_amount"); value == "" {
+			req.MinOrderAmount = 0
+		} else if amount, err := csvimport.ParseFloat(value); err != nil {
+			fail("min_order_amount", err)
+		} else if amount < 0 {
+			errs = append(errs, row.Error("min_order_amount", "must not be negative"))
+		} else {
+			req.MinOrderAmount = amount
+		}
+	}
+	if row.Has("max_uses") {
+		if maxUses, err := csvimport.ParseOptionalInt(row.Get("max_uses")); err != nil {
+			fail("max_uses", err)
+		} else {
+			req.MaxUses = maxUses
+		}
+	}
+	if row.Has("active") {
+		if active, err := csvimport.ParseBool(row.Get("active"), true); err != nil {
+			fail("active", err)
+		} else {
+			req.Active = active
+		}
+	}
+	if row.Has("end_date") {
+		req.EndDate = nil
+		if value := row.Get("end_date"); value != "" {
+			if end, err := csvimport.ParseTime(value, loc); err != nil {
+				fail("end_date", err)
+			} else {
+				req.EndDate = &end
+			}
+		}
+	}
+	if row.Has("is_first_order_only") {
+		if firstOrderOnly, err := csvimport.ParseBool(row.Get("is_first_order_only"), false); err != nil {
+			fail("is_first_order_only", err)
+		} else {
+			req.IsFirstOrderOnly = firstOrderOnly
+		}
+	}
+	if row.Has("windows") {
+		req.Windows = nil
+		if value := row.Get("windows"); value != "" {
+			if err := json.Unmarshal([]byte(value), &req.Windows); err != nil {
+				errs = append(errs, row.Error("windows", `must be a JSON list like [{"weekdays":[5],"start_time":"18:00","end_time":"23:00"}]`))
+			}
+		}
+	}
+
+	if len(errs) == 0 {
+		if msg := validateDiscountCodeRequest(req); msg != "" {
+			errs = append(errs, row.Error("", msg))
+		}
+	}
+	return errs
+}
+
+// ExportCategories downloads all categories as CSV
+func (h *ImportHandler) ExportCategories(c *gin.Context) {
+	categories, err := h.categoryQueries.ListAllCategories()
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export categories"})
+		return
+	}
+
+	records := make([][]string, len(categories))
+	for i, category := range categories {
+		records[i] = []string{
+			category.Slug, category.Name, csvimport.FormatBool(category.Active),
+			csvimport.FormatBool(category.ChartOnly), csvimport.FormatOptionalInt(category.ImageID),
+		}
+	}
+
+	writeCSV(c, "categories", categoryColumns.All(), records)
+}
+
+// ImportCategories creates categories from a CSV file and updates the ones whose slug
+// already exists; columns missing from the file keep their current values
+func (h *ImportHandler) ImportCategories(c *gin.Context) {
+	rows, ok := readImportFile(c, categoryColumns)
+	if !ok {
+		return
+	}
+
+	existing, err := h.categoryQueries.ListAllCategories()
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load categories"})
+		return
+	}
+	bySlug := make(map[string]models.Category, len(existing))
+	for _, category := range existing {
+		bySlug[category.Slug] = category
+	}
+
+	var imageIDs []int
+	for _, row := range rows {
+		if id, err := csvimport.ParseOptionalInt(row.Get("image_id")); err == nil && id != nil {
+			imageIDs = append(imageIDs, *id)
+		}
+	}
+	images, err := h.imageQueries.ExistingImageIDs(imageIDs)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check images"})
+		return
+	}
+
+	result := newImportResult(c, len(rows))
+	items := []database.CategoryImport{}
+	seen := map[string]int{}
+	for _, row := range rows {
+		slug := row.Get("slug")
+		if line, duplicate := seen[slug]; duplicate {
+			result.Errors = append(result.Errors, row.Error("slug", fmt.Sprintf("duplicates the slug on line %d", line)))
+			continue
+		}
+		seen[slug] = row.Line
+
+		item := database.CategoryImport{Category: models.Category{Slug: slug, Active: true}}
+		if current, ok := bySlug[slug]; ok {
+			id := current.ID
+			item.ID = &id
+			item.Category = current
+		}
+
+		var rowErrors []models.ImportRowError
+		if slug == "" || len(slug) > 256 {
+			rowErrors = append(rowErrors, row.Error("slug", "must be 1-256 characters"))
+		}
+		item.Category.Name = row.Get("name")
+		if item.Category.Name == "" || len(item.Category.Name) > 256 {
+			rowErrors = append(rowErrors, row.Error("name", "must be 1-256 characters"))
+		}
+		if row.Has("active") {
+			if active, err := csvimport.ParseBool(row.Get("active"), true); err != nil {
+				rowErrors = append(rowErrors, row.Error("active", err.Error()))
+			} else {
+				item.Category.Active = active
+			}
+		}
+		if row.Has("chart_only") {
+			if chartOnly, err := csvimport.ParseBool(row.Get("chart_only"), false); err != nil {
+				rowErrors = append(rowErrors, row.Error("chart_only", err.Error()))
+			} else {
+				item.Category.ChartOnly = chartOnly
+			}
+		}
+		if row.Has("image_id") {
+			if imageID, err := csvimport.ParseOptionalInt(row.Get("image_id")); err != nil {
+				rowErrors = append(rowErrors, row.Error("image_id", err.Error()))
+			} else if imageID != nil && !images[*imageID] {
+				rowErrors = append(rowErrors, row.Error("image_id", "image does not exist"))
+			} else {
+				item.Category.ImageID = imageID
+			}
+		}
+
+		if len(rowErrors) > 0 {
+			result.Errors = append(result.Errors, rowErrors...)
+			continue
+		}
+		if item.ID == nil {
+			result.Created++
+		} else {
+			result.Updated++
+		}
+		items = append(items, item)
+	}
+
+	if len(result.Errors) == 0 && !result.DryRun {
+		if err := h.categoryQueries.ImportCategories(items); err != nil {
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import categories", "details": err.Error()})
+			return
+		}
+		result.Applied = true
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ExportProducts downloads all products with their sizes and variants as ?format=csv or
+// xlsx, in the layout ImportProducts reads
+func (h *ImportHandler) ExportProducts(c *gin.Context) {
+	format := c.DefaultQuery("format", models.ExportFormatCSV)
+	if format != models.ExportFormatCSV && format != models.ExportFormatXLSX {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or xlsx"})
+		return
+	}
+
+	products, err := h.productQueries.ListAllProducts()
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export products"})
+		return
+	}
+
+	records := [][]string{}
+	for _, p := range products {
+		product := p.Product
+		records = append(records, []string{
+			productRecord, product.Slug, product.Name, product.ShortDescription, product.Description, p.CategorySlug,
+			csvimport.FormatOptionalInt(product.MaterialID), strconv.Itoa(product.MainImageID), formatIDList(p.ImageIDs),
+			"", "", "", "", "", "", "", "", "", "", "",
+		})
+		for _, size := range p.Sizes {
+			records = append(records, []string{
+				sizeRecord, product.Slug, size.Name, "", "", "", "", "", "",
+				csvimport.FormatFloat(size.BasePrice), csvimport.FormatFloat(size.A), csvimport.FormatFloat(size.B),
+				csvimport.FormatFloat(size.C), csvimport.FormatFloat(size.D), csvimport.FormatFloat(size.E), csvimport.FormatFloat(size.F),
+				csvimport.FormatBool(size.UseStock), strconv.Itoa(size.StockQuantity), "", "",
+			})
+		}
+		for _, v := range p.Variants {
+			records = append(records, []string{
+				variantRecord, product.Slug, v.Variant.Name, "", "", "", "", "", formatIDList(v.ImageIDs),
+				"", "", "", "", "", "", "", "", "", strconv.Itoa(v.Variant.ColorID), csvimport.FormatBool(v.Variant.IsDefault),
+			})
+		}
+	}
+
+	if format == models.ExportFormatXLSX {
+		writeXLSX(c, "products", "Products", productColumns.All(), productNumeric, records)
+		return
+	}
+	writeCSV(c, "products", productColumns.All(), records)
+}
+
+// ImportProducts creates products, sizes and variants from a CSV or XLSX file and
+// updates the ones that already exist: products by slug, sizes and variants by name
+// within their product. Columns missing from the file and empty cells of required
+// fields keep the current values; nothing is deleted. Sizes and variants may belong to
+// a product created by the same file, whatever the order of the rows.
+func (h *ImportHandler) ImportProducts(c *gin.Context) {
+	rows, ok := readImportFile(c, productColumns)
+	if !ok {
+		return
+	}
+
+	existing, err := h.productQueries.ListAllProducts()
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load products"})
+		return
+	}
+	bySlug := make(map[string]*database.ProductExport, len(existing))
+	for i := range existing {
+		if existing[i].Product.Slug != "" {
+			bySlug[existing[i].Product.Slug] = &existing[i]
+		}
+	}
+	refs, err := h.loadProductImportRefs(rows)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load products"})
+		return
+	}
+
+	result := newImportResult(c, len(rows))
+	count := func(id *int) {
+		if id == nil {
+			result.Created++
+		} else {
+			result.Updated++
+		}
+	}
+	items := []*database.ProductImport{}
+	bySlugItem := map[string]*database.ProductImport{}
+	invalid := map[string]bool{}
+	seen := map[string]int{}
+
+	// Product rows go first, so sizes and variants can belong to products the file creates
+	for _, row := range rows {
+		record := strings.ToLower(row.Get("record"))
+		switch record {
+		case productRecord, sizeRecord, variantRecord:
+		default:
+			result.Errors = append(result.Errors, row.Error("record", "must be product, size or variant"))
+			continue
+		}
+		if record != productRecord {
+			continue
+		}
+
+		productSlug := row.Get("slug")
+		if line, duplicate := seen[productSlug]; duplicate {
+			result.Errors = append(result.Errors, row.Error("slug", fmt.Sprintf("duplicates the product on line %d", line)))
+			continue
+		}
+		seen[productSlug] = row.Line
+
+		item := &database.ProductImport{Product: &models.Product{Slug: productSlug}}
+		var currentImages []int
+		var rowErrors []models.ImportRowError
+		if current, ok := bySlug[productSlug]; ok {
+			id := current.Product.ID
+			item.ID = &id
+			product := current.Product
+			item.Product = &product
+			currentImages = current.ImageIDs
+		} else if !slug.Valid(productSlug) {
+			rowErrors = append(rowErrors, row.Error("slug", "may only contain lowercase letters, digits and single dashes"))
+		}
+
+		imageIDs, productErrors := parseProductRow(row, item.Product, currentImages, item.ID == nil, refs)
+		item.ImageIDs = imageIDs
+		if rowErrors = append(rowErrors, productErrors...); len(rowErrors) > 0 {
+			result.Errors = append(result.Errors, rowErrors...)
+			invalid[productSlug] = true
+			continue
+		}
+		count(item.ID)
+		items = append(items, item)
+		bySlugItem[productSlug] = item
+	}
+
+	defaults := map[string]int{}
+	for _, row := range rows {
+		record := strings.ToLower(row.Get("record"))
+		if record != sizeRecord && record != variantRecord {
+			continue
+		}
+		productSlug, name := row.Get("slug"), row.Get("name")
+		if invalid[productSlug] {
+			// The product row's errors are reported already
+			continue
+		}
+		if name == "" || utf8.RuneCountInString(name) > 256 {
+			result.Errors = append(result.Errors, row.Error("name", "must be 1-256 characters"))
+			continue
+		}
+		key := record + "\x00" + productSlug + "\x00" + name
+		if line, duplicate := seen[key]; duplicate {
+			result.Errors = append(result.Errors, row.Error("name", fmt.Sprintf("duplicates the %s on line %d", record, line)))
+			continue
+		}
+		seen[key] = row.Line
+
+		current := bySlug[productSlug]
+		item := bySlugItem[productSlug]
+		if item == nil {
+			if current == nil {
+				result.Errors = append(result.Errors, row.Error("slug", "no product with this slug in the file or the shop"))
+				continue
+			}
+			id := current.Product.ID
+			item = &database.ProductImport{ID: &id}
+			items = append(items, item)
+			bySlugItem[productSlug] = item
+		}
+
+		if record == sizeRecord {
+			sizeItem := database.SizeImport{Size: models.Size{Name: name}}
+			if current != nil {
+				for _, size := range current.Sizes {
+					if size.Name == name {
+						id := size.ID
+						sizeItem.ID = &id
+						sizeItem.Size = size
+						break
+					}
+				}
+			}
+			if rowErrors := parseSizeRow(row, &sizeItem.Size, sizeItem.ID == nil); len(rowErrors) > 0 {
+				result.Errors = append(result.Errors, rowErrors...)
+				continue
+			}
+			count(sizeItem.ID)
+			item.Sizes = append(item.Sizes, sizeItem)
+			continue
+		}
+
+		variantItem := database.VariantImport{Variant: models.ProductVariant{Name: name}}
+		if current != nil {
+			for _, v := range current.Variants {
+				if v.Variant.Name == name {
+					id := v.Variant.ID
+					variantItem.ID = &id
+					variantItem.Variant = v.Variant
+					break
+				}
+			}
+		}
+		imageIDs, rowErrors := parseVariantRow(row, &variantItem.Variant, variantItem.ID == nil, refs)
+		if variantItem.Variant.IsDefault && len(rowErrors) == 0 {
+			if line, duplicate := defaults[productSlug]; duplicate {
+				rowErrors = append(rowErrors, row.Error("is_default", fmt.Sprintf("the variant on line %d is the default already", line)))
+			}
+			defaults[productSlug] = row.Line
+		}
+		if len(rowErrors) > 0 {
+			result.Errors = append(result.Errors, rowErrors...)
+			continue
+		}
+		variantItem.ImageIDs = imageIDs
+		count(variantItem.ID)
+		item.Variants = append(item.Variants, variantItem)
+	}
+
+	if len(result.Errors) == 0 && !result.DryRun {
+		imports := make([]database.ProductImport, len(items))
+		for i, item := range items {
+			imports[i] = *item
+		}
+		if err := h.productQueries.ImportProducts(imports, getUserIDPtr(c)); err != nil {
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import products", "details": err.Error()})
+			return
+		}
+		result.Applied = true
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// productImportRefs holds what the rows of a product import may refer to: categories
+// by slug and which of the images, materials and colors in the file exist
+type productImportRefs struct {
+	categories map[string]int
+	images     map[int]bool
+	materials  map[int]bool
+	colors     map[int]bool
+}
+
+func (h *ImportHandler) loadProductImportRefs(rows []csvimport.Row) (*productImportRefs, error) {
+	var imageIDs, materialIDs, colorIDs []int
+	for _, row := range rows {
+		if ids, err := parseIDList(row.Get("image_ids")); err == nil {
+			imageIDs = append(imageIDs, ids...)
+		}
+		if id, err := csvimport.ParseOptionalInt(row.Get("main_image_id")); err == nil && id != nil {
+			imageIDs = append(imageIDs, *id)
+		}
+		if id, err := csvimport.ParseOptionalInt(row.Get("material_id")); err == nil && id != nil {
+			materialIDs = append(materialIDs, *id)
+		}
+		if id, err := csvimport.ParseOptionalInt(row.Get("color_id")); err == nil && id != nil {
+			colorIDs = append(colorIDs, *id)
+		}
+	}
+
+	refs := &productImportRefs{categories: map[string]int{}}
+	categories, err := h.categoryQueries.ListAllCategories()
+	if err != nil {
+		return nil, err
+	}
+	for _, category := range categories {
+		refs.categories[category.Slug] = category.ID
+	}
+	if refs.images, err = h.imageQueries.ExistingImageIDs(imageIDs); err != nil {
+		return nil, err
+	}
+	if refs.materials, err = h.materialQueries.ExistingMaterialIDs(materialIDs); err != nil {
+		return nil, err
+	}
+	if refs.colors, err = h.colorQueries.ExistingColorIDs(colorIDs); err != nil {
+		return nil, err
+	}
+	return refs, nil
+}
+
+// parseProductRow applies a product row's cells to product and validates the result.
+// It returns the product's new images, or nil when they stay unchanged.
+func parseProductRow(row csvimport.Row, product *models.Product, currentImages []int, isNew bool, refs *productImportRefs) ([]int, []models.ImportRowError) {
+	var errs []models.ImportRowError
+	required := func(column string) {
+		if isNew {
+			errs = append(errs, row.Error(column, "is required for new products"))
+		}
+	}
+
+	for _, field := range []struct {
+		column string
+		value  *string
+		max    int
+	}{
+		{"name", &product.Name, 256},
+		{"short_description", &product.ShortDescription, 512},
+		{"description", &product.Description, 0},
+	} {
+		value := row.Get(field.column)
+		if value == "" {
+			required(field.column)
+		} else if field.max > 0 && utf8.RuneCountInString(value) > field.max {
+			errs = append(errs, row.Error(field.column, fmt.Sprintf("must be at most %d characters", field.max)))
+		} else {
+			*field.value = value
+		}
+	}
+
+	if row.Has("category") {
+		product.CategoryID = nil
+		if value := row.Get("category"); value != "" {
+			if id, ok := refs.categories[value]; ok {
+				product.CategoryID = &id
+			} else {
+				errs = append(errs, row.Error("category", "no category with this slug"))
+			}
+		}
+	}
+	if row.Has("material_id") {
+		if materialID, err := csvimport.ParseOptionalInt(row.Get("material_id")); err != nil {
+			errs = append(errs, row.Error("material_id", err.Error()))
+		} else if materialID != nil && !refs.materials[*materialID] {
+			errs = append(errs, row.Error("material_id", "material does not exist"))
+		} else {
+			product.MaterialID = materialID
+		}
+	}
+
+	if value := row.Get("main_image_id"); value == "" {
+		required("main_image_id")
+	} else if id, err := strconv.Atoi(value); err != nil {
+		errs = append(errs, row.Error("main_image_id", "must be a whole number"))
+	} else if !refs.images[id] {
+		errs = append(errs, row.Error("main_image_id", "image does not exist"))
+	} else {
+		product.MainImageID = id
+	}
+
+	var imageIDs []int
+	if value := row.Get("image_ids"); value == "" {
+		required("image_ids")
+	} else if ids, err := parseImageIDs(value, refs); err != nil {
+		errs = append(errs, row.Error("image_ids", err.Error()))
+	} else {
+		imageIDs = ids
+	}
+
+	if len(errs) == 0 {
+		images := imageIDs
+		if images == nil {
+			images = currentImages
+		}
+		if !containsID(images, product.MainImageID) {
+			errs = append(errs, row.Error("main_image_id", "must be one of the product's images"))
+		}
+	}
+	return imageIDs, errs
+}
+
+// parseSizeRow applies a size row's cells to size and validates the result
+func parseSizeRow(row csvimport.Row, size *models.Size, isNew bool) []models.ImportRowError {
+	var errs []models.ImportRowError
+	for _, field := range []struct {
+		column string
+		value  *float64
+	}{
+		{"base_price", &size.BasePrice}, {"a", &size.A}, {"b", &size.B}, {"c", &size.C},
+		{"d", &size.D}, {"e", &size.E}, {"f", &size.F},
+	} {
+		value := row.Get(field.column)
+		if value == "" {
+			if isNew {
+				errs = append(errs, row.Error(field.column, "is required for new sizes"))
+			}
+		} else if number, err := csvimport.ParseFloat(value); err != nil {
+			errs = append(errs, row.Error(field.column, err.Error()))
+		} else if number < 0 {
+			errs = append(errs, row.Error(field.column, "must not be negative"))
+		} else {
+			*field.value = number
+		}
+	}
+
+	if useStock, err := csvimport.ParseBool(row.Get("use_stock"), size.UseStock); err != nil {
+		errs = append(errs, row.Error("use_stock", err.Error()))
+	} else {
+		size.UseStock = useStock
+	}
+	if quantity, err := csvimport.ParseOptionalInt(row.Get("stock_quantity")); err != nil {
+		errs = append(errs, row.Error("stock_quantity", err.Error()))
+	} else if quantity != nil && *quantity < 0 {
+		errs = append(errs, row.Error("stock_quantity", "must not be negative"))
+	} else if quantity != nil {
+		size.StockQuantity = *quantity
+	}
+	return errs
+}
+
+// parseVariantRow applies a variant row's cells to variant and validates the result.
+// It returns the variant's new images, or nil when they stay unchanged.
+func parseVariantRow(row csvimport.Row, variant *models.ProductVariant, isNew bool, refs *productImportRefs) ([]int, []models.ImportRowError) {
+	var errs []models.ImportRowError
+	if value := row.Get("color_id"); value == "" {
+		if isNew {
+			errs = append(errs, row.Error("color_id", "is required for new variants"))
+		}
+	} else if id, err := strconv.Atoi(value); err != nil {
+		errs = append(errs, row.Error("color_id", "must be a whole number"))
+	} else if !refs.colors[id] {
+		errs = append(errs, row.Error("color_id", "color does not exist"))
+	} else {
+		variant.ColorID = id
+	}
+
+	if isDefault, err := csvimport.ParseBool(row.Get("is_default"), variant.IsDefault); err != nil {
+		errs = append(errs, row.Error("is_default", err.Error()))
+	} else {
+		variant.IsDefault = isDefault
+	}
+
+	var imageIDs []int
+	if value := row.Get("image_ids"); value == "" {
+		if isNew {
+			errs = append(errs, row.Error("image_ids", "is required for new variants"))
+		}
+	} else if ids, err := parseImageIDs(value, refs); err != nil {
+		errs = append(errs, row.Error("image_ids", err.Error()))
+	} else {
+		imageIDs = ids
+	}
+	return imageIDs, errs
+}
+
+// parseImageIDs parses a list of image IDs and checks that the images exist
+func parseImageIDs(value string, refs *productImportRefs) ([]int, error) {
+	ids, err := parseIDList(value)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		if !refs.images[id] {
+			return nil, fmt.Errorf("image %d does not exist", id)
+		}
+	}
+	return ids, nil
+}
+
+// parseIDList parses IDs separated by "|", commas, semicolons or spaces, dropping
+// repeated ones
+func parseIDList(value string) ([]int, error) {
+	fields := strings.FieldsFunc(value, func(r rune) bool {
+		return r == '|' || r == ',' || r == ';' || r == ' '
+	})
+	ids := make([]int, 0, len(fields))
+	for _, field := range fields {
+		id, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("must be whole numbers separated by |")
+		}
+		if !containsID(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func formatIDList(ids []int) string {
+	values := make([]string, len(ids))
+	for i, id := range ids {
+		values[i] = strconv.Itoa(id)
+	}
+	return strings.Join(values, "|")
+}
+
+func containsID(ids []int, id int) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
+
+// readImportFile parses the uploaded CSV or XLSX file, writing the error response when
+// it can't
+func readImportFile(c *gin.Context, columns csvimport.Columns) ([]csvimport.Row, bool) {
+	var body io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		file, _, err := c.Request.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+			return nil, false
+		}
+		defer file.Close()
+		body = file
+	}
+
+	// XLSX files are zip archives; anything else is read as CSV
+	buffered := bufio.NewReader(body)
+	var rows []csvimport.Row
+	var err error
+	if magic, _ := buffered.Peek(4); string(magic) == "PK\x03\x04" {
+		rows, err = readSpreadsheet(buffered, columns)
+	} else {
+		rows, err = csvimport.Read(buffered, columns)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid import file: " + err.Error()})
+		return nil, false
+	}
+	return rows, true
+}
+
+func readSpreadsheet(r io.Reader, columns csvimport.Columns) ([]csvimport.Row, error) {
+	data, err := io.ReadAll(io.LimitReader(r, xlsx.MaxReadSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > xlsx.MaxReadSize {
+		return nil, fmt.Errorf("file is too large")
+	}
+	records, err := xlsx.Read(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	return csvimport.ReadRecords(records, columns)
+}
+
+func newImportResult(c *gin.Context, rows int) *models.ImportResult {
+	return &models.ImportResult{
+		DryRun: c.Query("dry_run") == "true",
+		Rows:   rows,
+		Errors: []models.ImportRowError{},
+	}
+}
+
+func writeCSV(c *gin.Context, name string, header []string, records [][]string) {
+	var buf bytes.Buffer
+	if err := csvimport.Write(&buf, header, records); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to write CSV"})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s-%s.csv", name, time.Now().Format("2006-01-02")))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
+
+func writeXLSX(c *gin.Context, name, sheetName string, header []string, numeric []int, records [][]string) {
+	var buf bytes.Buffer
+	sheet, err := xlsx.NewWriter(&buf, sheetName, header, numeric...)
+	if err == nil {
+		for _, record := range records {
+			if err = sheet.Write(record); err != nil {
+				break
+			}
+		}
+	}
+	if err == nil {
+		err = sheet.Close()
+	}
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to write XLSX"})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s-%s.xlsx", name, time.Now().Format("2006-01-02")))
+	c.Data(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", buf.Bytes())
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"notsofluffy-backend/internal/csvimport"
+	"notsofluffy-backend/internal/models"
+	"notsofluffy-backend/internal/xlsx"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseProductRow(t *testing.T) {
+	refs := &productImportRefs{
+		categories: map[string]int{"obroze": 3},
+		images:     map[int]bool{10: true, 11: true},
+		materials:  map[int]bool{},
+		colors:     map[int]bool{},
+	}
+	rows, err := csvimport.Read(strings.NewReader(
+		"record,slug,name,short_description,description,category,main_image_id,image_ids\n"+
+			"product,obroza,Obroża,Krótko,Opis,obroze,11,10|11\n"+
+			"product,szelki,Szelki,,,,12,10\n"+
+			"product,smycz,,,,,11,\n"), productColumns)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+
+	product := &models.Product{Slug: "obroza"}
+	imageIDs, errs := parseProductRow(rows[0], product, nil, true, refs)
+	if len(errs) > 0 {
+		t.Fatalf("valid row: errors %+v", errs)
+	}
+	if !reflect.DeepEqual(imageIDs, []int{10, 11}) || product.MainImageID != 11 || product.CategoryID == nil || *product.CategoryID != 3 {
+		t.Fatalf("valid row parsed as %+v with images %v", product, imageIDs)
+	}
+
+	_, errs = parseProductRow(rows[1], &models.Product{}, nil, true, refs)
+	columns := map[string]bool{}
+	for _, e := range errs {
+		columns[e.Column] = true
+	}
+	for _, column := range []string{"short_description", "description", "main_image_id"} {
+		if !columns[column] {
+			t.Errorf("new product without %s: errors %+v", column, errs)
+		}
+	}
+
+	// Existing products keep their images, which must include the new main image
+	existing := &models.Product{Name: "Smycz", ShortDescription: "Krótko", Description: "Opis", MainImageID: 10}
+	imageIDs, errs = parseProductRow(rows[2], existing, []int{10}, false, refs)
+	if imageIDs != nil || len(errs) != 1 || errs[0].Column != "main_image_id" {
+		t.Fatalf("update with a main image outside the images: images %v, errors %+v", imageIDs, errs)
+	}
+}
+
+func TestReadImportFileAcceptsXLSX(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var buf bytes.Buffer
+	sheet, err := xlsx.NewWriter(&buf, "Categories", categoryColumns.All())
+	if err != nil {
+		t.Fatalf("NewWriter() error = %v", err)
+	}
+	sheet.Write([]string{"obroze", "Obroże", "true"})
+	if err := sheet.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/admin/categories/import", &buf)
+	rows, ok := readImportFile(c, categoryColumns)
+	if !ok || len(rows) != 1 || rows[0].Get("name") != "Obroże" {
+		t.Fatalf("readImportFile() = %+v, %v", rows, ok)
+	}
+}
+
+func TestParseIDList(t *testing.T) {
+	ids, err := parseIDList("3| 1,3;2")
+	if err != nil || !reflect.DeepEqual(ids, []int{3, 1, 2}) {
+		t.Fatalf("parseIDList() = %v, %v", ids, err)
+	}
+	if formatIDList(ids) != "3|1|2" {
+		t.Errorf("formatIDList() = %q", formatIDList(ids))
+	}
+	if _, err := parseIDList("1|x"); err == nil {
+		t.Error("parseIDList accepted a non-number")
+	}
+}
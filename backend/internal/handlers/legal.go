@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/middleware"
+	"notsofluffy-backend/internal/models"
+)
+
+type LegalHandler struct {
+	legalQueries *database.LegalQueries
+}
+
+func NewLegalHandler(legalQueries *database.LegalQueries) *LegalHandler {
+	return &LegalHandler{legalQueries: legalQueries}
+}
+
+// GetCurrentDocuments returns the current terms of service and privacy policy versions
+func (h *LegalHandler) GetCurrentDocuments(c *gin.Context) {
+	docs, err := h.legalQueries.GetCurrentDocuments()
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get legal documents"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"documents": docs})
+}
+
+// GetPendingDocuments returns current document versions the logged-in user still has to accept
+func (h *LegalHandler) GetPendingDocuments(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	docs, err := h.legalQueries.GetPendingDocumentsForUser(userID)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get legal documents"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"documents": docs})
+}
+
+// AcceptDocuments records that the logged-in user accepted the current document versions
+func (h *LegalHandler) AcceptDocuments(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.AcceptLegalDocumentsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pending, err := h.legalQueries.GetPendingDocumentsForUser(userID)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get legal documents"})
+		return
+	}
+
+	if missing := missingAcceptances(pending, req.AcceptedDocuments); len(missing) > 0 {
+		respondLegalAcceptanceRequired(c, http.StatusBadRequest, missing)
+		return
+	}
+
+	if err := h.legalQueries.RecordAcceptance(pending, &userID, nil, middleware.GetClientIP(c), userAgentPtr(c)); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record acceptance"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Documents accepted"})
+}
+
+// ListDocuments returns every published document version for the admin panel
+func (h *LegalHandler) ListDocuments(c *gin.Context) {
+	docs, err := h.legalQueries.ListDocuments()
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get legal documents"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"documents": docs})
+}
+
+// PublishDocument publishes a new document version; users and shoppers must accept it again
+func (h *LegalHandler) PublishDocument(c *gin.Context) {
+	var req models.PublishLegalDocumentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req.Version = strings.TrimSpace(req.Version)
+
+	doc, err := h.legalQueries.PublishDocument(&req, getUserIDPtr(c))
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			c.JSON(http.StatusConflict, gin.H{"error": "This version has already been published"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to publish legal document"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, doc)
+}
+
+// GetOrderAcceptances returns the document versions accepted with an order
+func (h *LegalHandler) GetOrderAcceptances(c *gin.Context) {
+	orderID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	acceptances, err := h.legalQueries.GetAcceptances(nil, &orderID)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get legal acceptances"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"acceptances": acceptances})
+}
+
+// missingAcceptances returns the documents from required that are not covered by accepted
+func missingAcceptances(required []models.LegalDocument, accepted []models.AcceptedDocument) []models.LegalDocument {
+	missing := []models.LegalDocument{}
+	for _, doc := range required {
+		found := false
+		for _, a := range accepted {
+			if a.Type == doc.Type && strings.TrimSpace(a.Version) == doc.Version {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, doc)
+		}
+	}
+	return missing
+}
+
+// respondLegalAcceptanceRequired aborts the request asking the client to accept the listed documents
+func respondLegalAcceptanceRequired(c *gin.Context, status int, docs []models.LegalDocument) {
+	c.JSON(status, models.LegalAcceptanceRequiredResponse{
+		Error:     "You must accept the current terms of service and privacy policy",
+		Code:      models.LegalAcceptanceRequiredCode,
+		Documents: docs,
+	})
+}
+
+// getUserID returns the authenticated user ID from the context
+func getUserID(c *gin.Context) (int, bool) {
+	if id := getUserIDPtr(c); id != nil {
+		return *id, true
+	}
+	return 0, false
+}
+
+// userAgentPtr returns the request User-Agent header, or nil if empty
+func userAgentPtr(c *gin.Context) *string {
+	ua := c.GetHeader("User-Agent")
+	if ua == "" {
+		return nil
+	}
+	return &ua
+}
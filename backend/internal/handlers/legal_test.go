@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"testing"
+
+	"notsofluffy-backend/internal/models"
+)
+
+func TestMissingAcceptances(t *testing.T) {
+	required := []models.LegalDocument{
+		{ID: 1, Type: models.LegalDocumentTerms, Version: "2024-06"},
+		{ID: 2, Type: models.LegalDocumentPrivacy, Version: "v3"},
+	}
+
+	tests := []struct {
+		name     string
+		accepted []models.AcceptedDocument
+		want     []int
+	}{
+		{"nothing accepted", nil, []int{1, 2}},
+		{"all accepted", []models.AcceptedDocument{{Type: "terms", Version: "2024-06"}, {Type: "privacy", Version: "v3"}}, nil},
+		{"old version accepted", []models.AcceptedDocument{{Type: "terms", Version: "2023-01"}, {Type: "privacy", Version: "v3"}}, []int{1}},
+		{"version of other type", []models.AcceptedDocument{{Type: "privacy", Version: "2024-06"}}, []int{1, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			missing := missingAcceptances(required, tt.accepted)
+			if len(missing) != len(tt.want) {
+				t.Fatalf("expected %d missing documents, got %d", len(tt.want), len(missing))
+			}
+			for i, doc := range missing {
+				if doc.ID != tt.want[i] {
+					t.Errorf("expected document %d, got %d", tt.want[i], doc.ID)
+				}
+			}
+		})
+	}
+
+	if missing := missingAcceptances(nil, nil); len(missing) != 0 {
+		t.Errorf("expected no missing documents when nothing is published, got %d", len(missing))
+	}
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/models"
+)
+
+const (
+	// liveStatsTTL is how long live stats are served from memory, so a room of dashboards
+	// polling during a sale costs a handful of queries
+	liveStatsTTL = 10 * time.Second
+	// maxLiveStatsEntries bounds the cached size selections
+	maxLiveStatsEntries = 100
+)
+
+type LiveStatsHandler struct {
+	liveStatsQueries *database.LiveStatsQueries
+
+	mu      sync.Mutex
+	entries map[string]liveStatsEntry
+}
+
+type liveStatsEntry struct {
+	stats     *models.LiveStats
+	expiresAt time.Time
+}
+
+func NewLiveStatsHandler(liveStatsQueries *database.LiveStatsQueries) *LiveStatsHandler {
+	return &LiveStatsHandler{liveStatsQueries: liveStatsQueries, entries: make(map[string]liveStatsEntry)}
+}
+
+// GetLiveStats returns the active carts, the units of the promoted sizes (?size_id= and
+// ?product_id=, repeatable) sitting in them next to their stock, and the order rate.
+// Without promoted sizes the sizes with the most units in carts are reported. Results
+// are cached briefly; generated_at tells how fresh they are.
+func (h *LiveStatsHandler) GetLiveStats(c *gin.Context) {
+	sizeIDs, err := idsQuery(c.QueryArray("size_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid size ID"})
+		return
+	}
+	productIDs, err := idsQuery(c.QueryArray("product_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	key := fmt.Sprint(sizeIDs, productIDs)
+	now := time.Now()
+	h.mu.Lock()
+	entry, ok := h.entries[key]
+	h.mu.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		c.JSON(http.StatusOK, entry.stats)
+		return
+	}
+
+	stats, err := h.liveStats(now, sizeIDs, productIDs)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get live stats"})
+		return
+	}
+
+	h.mu.Lock()
+	if len(h.entries) >= maxLiveStatsEntries {
+		for k, e := range h.entries {
+			if now.After(e.expiresAt) {
+				delete(h.entries, k)
+			}
+		}
+		if len(h.entries) >= maxLiveStatsEntries {
+			h.entries = make(map[string]liveStatsEntry)
+		}
+	}
+	h.entries[key] = liveStatsEntry{stats: stats, expiresAt: now.Add(liveStatsTTL)}
+	h.mu.Unlock()
+
+	c.JSON(http.StatusOK, stats)
+}
+
+func (h *LiveStatsHandler) liveStats(now time.Time, sizeIDs, productIDs []int) (*models.LiveStats, error) {
+	cartsSince := now.Add(-models.LiveStatsActiveCartMinutes * time.Minute)
+	carts, units, err := h.liveStatsQueries.GetActiveCarts(cartsSince)
+	if err != nil {
+		return nil, err
+	}
+	sizes, err := h.liveStatsQueries.GetCartSizeStats(cartsSince, sizeIDs, productIDs, models.MaxLiveStatsSizes)
+	if err != nil {
+		return nil, err
+	}
+
+	recent, err := h.liveStatsQueries.CountOrdersSince(now.Add(-models.LiveStatsOrderRateMinutes * time.Minute))
+	if err != nil {
+		return nil, err
+	}
+	firstMinute := now.UTC().Truncate(time.Minute).Add(-(models.LiveStatsOrderHistoryMinutes - 1) * time.Minute)
+	byMinute, err := h.liveStatsQueries.GetOrdersByMinute(firstMinute)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.LiveStats{
+		ActiveCarts:     carts,
+		UnitsInCarts:    units,
+		Sizes:           sizes,
+		OrdersPerMinute: float64(recent) / models.LiveStatsOrderRateMinutes,
+		OrdersByMinute:  ordersByMinute(firstMinute, models.LiveStatsOrderHistoryMinutes, byMinute),
+		GeneratedAt:     now,
+	}, nil
+}
+
+// ordersByMinute lists count minutes from first, including the ones without orders
+func ordersByMinute(first time.Time, count int, orders map[time.Time]int) []models.OrdersMinute {
+	minutes := make([]models.OrdersMinute, count)
+	for i := range minutes {
+		minute := first.Add(time.Duration(i) * time.Minute)
+		minutes[i] = models.OrdersMinute{Minute: minute, Orders: orders[minute]}
+	}
+	return minutes
+}
+
+// idsQuery parses repeated or comma separated ID query values, sorted and unique so
+// equal selections share a cache entry
+func idsQuery(values []string) ([]int, error) {
+	seen := map[int]bool{}
+	ids := []int{}
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			id, err := strconv.Atoi(part)
+			if err != nil || id < 1 {
+				return nil, fmt.Errorf("invalid ID %q", part)
+			}
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	sort.Ints(ids)
+	return ids, nil
+}
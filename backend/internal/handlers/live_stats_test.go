@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestIDsQuery(t *testing.T) {
+	ids, err := idsQuery([]string{"12,3", " 7 ", "3"})
+	if err != nil || fmt.Sprint(ids) != "[3 7 12]" {
+		t.Fatalf("idsQuery = %v, %v, want sorted unique [3 7 12]", ids, err)
+	}
+	if _, err := idsQuery([]string{"3,abc"}); err == nil {
+		t.Fatal("expected an error for a non-numeric ID")
+	}
+	if _, err := idsQuery([]string{"0"}); err == nil {
+		t.Fatal("expected an error for a zero ID")
+	}
+}
+
+func TestOrdersByMinuteFillsGaps(t *testing.T) {
+	first := time.Date(2024, 11, 29, 10, 0, 0, 0, time.UTC)
+	orders := map[time.Time]int{first.Add(2 * time.Minute): 4}
+
+	minutes := ordersByMinute(first, 3, orders)
+	if len(minutes) != 3 {
+		t.Fatalf("expected 3 minutes, got %d", len(minutes))
+	}
+	if minutes[0].Orders != 0 || minutes[1].Orders != 0 || minutes[2].Orders != 4 {
+		t.Fatalf("unexpected orders by minute: %+v", minutes)
+	}
+	if !minutes[2].Minute.Equal(first.Add(2 * time.Minute)) {
+		t.Fatalf("expected the last minute at 10:02, got %v", minutes[2].Minute)
+	}
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"math"
+
+	"notsofluffy-backend/internal/geoip"
+	"notsofluffy-backend/internal/middleware"
+	"notsofluffy-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// priceDisplay formats prices, kept in the base currency, in the currency they are
+// shown in. The amounts of responses stay in the base currency, which is what is
+// charged; only their formatted display strings are converted.
+type priceDisplay struct {
+	format geoip.CurrencyFormat
+	// rate is the price of one unit of the shown currency in the base currency
+	rate float64
+}
+
+// Format converts an amount from the base currency and formats it
+func (d priceDisplay) Format(amount float64) string {
+	return d.format.Format(convertPrice(amount, d.rate))
+}
+
+// convertPrice converts an amount in the base currency at rate, rounded to the grosz
+// (cent) half away from zero as pricing rounds
+func convertPrice(amount, rate float64) float64 {
+	if rate <= 0 || rate == 1 {
+		return amount
+	}
+	return math.Round(amount/rate*100) / 100
+}
+
+// priceFormat returns how the shop's prices are displayed in the currency and language
+// of the request
+func priceFormat(c *gin.Context) priceDisplay {
+	currency := middleware.GetCurrency(c)
+	return priceDisplay{format: geoip.FormatFor(currency.Code, middleware.GetLanguage(c)), rate: currency.Rate}
+}
+
+// orderPriceFormat returns how an order's prices are displayed: in the currency and at
+// the rate locked when it was placed, whatever the request asks for
+func orderPriceFormat(c *gin.Context, currency string, rate float64) priceDisplay {
+	if currency == "" {
+		currency, rate = models.PaymentCurrency, 1
+	}
+	return priceDisplay{format: geoip.FormatFor(currency, middleware.GetLanguage(c)), rate: rate}
+}
+
+func formatProducts(format priceDisplay, products []models.ProductResponse) {
+	for i := range products {
+		products[i].Formatted = models.FormattedPrices{"min_price": format.Format(products[i].MinPrice)}
+	}
+}
+
+func formatSizes(format priceDisplay, sizes []models.SizeResponse) {
+	for i := range sizes {
+		sizes[i].Formatted = models.FormattedPrices{"base_price": format.Format(sizes[i].BasePrice)}
+	}
+}
+
+func formatCart(format priceDisplay, cart *models.CartResponse) {
+	for i := range cart.Items {
+		item := &cart.Items[i]
+		item.Formatted = models.FormattedPrices{
+			"price_per_item":     format.Format(item.PricePerItem),
+			"total_price":        format.Format(item.TotalPrice),
+			"shipping_surcharge": format.Format(item.ShippingSurcharge),
+		}
+	}
+	cart.Formatted = models.FormattedPrices{
+		"subtotal":        format.Format(cart.Subtotal),
+		"discount_amount": format.Format(cart.DiscountAmount),
+		"shipping_cost":   format.Format(cart.ShippingCost),
+		"total_price":     format.Format(cart.TotalPrice),
+	}
+}
+
+func formatOrder(format priceDisplay, order *models.OrderResponse) {
+	for i := range order.Items {
+		item := &order.Items[i]
+		item.Formatted = models.FormattedPrices{
+			"unit_price":  format.Format(item.UnitPrice),
+			"total_price": format.Format(item.TotalPrice),
+		}
+	}
+	order.Formatted = models.FormattedPrices{
+		"total_amount":    format.Format(order.TotalAmount),
+		"subtotal":        format.Format(order.Subtotal),
+		"shipping_cost":   format.Format(order.ShippingCost),
+		"tax_amount":      format.Format(order.TaxAmount),
+		"discount_amount": format.Format(order.DiscountAmount),
+		"refunded_amount": format.Format(order.RefundedAmount),
+	}
+}
+
+func formatShippingMethods(format priceDisplay, methods []models.ShippingMethod) {
+	for i := range methods {
+		method := &methods[i]
+		method.Formatted = models.FormattedPrices{"price": format.Format(method.Price)}
+		if method.FreeAbove != nil {
+			method.Formatted["free_above"] = format.Format(*method.FreeAbove)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"net/http"
+
+	"notsofluffy-backend/internal/models"
+	"notsofluffy-backend/internal/openapi"
+)
+
+// APIAnnotations describe the bodies and query parameters of handlers for the OpenAPI
+// specification. Routes of handlers without an annotation are still documented, only
+// without their bodies.
+func APIAnnotations() openapi.Annotations {
+	a := openapi.Annotations{}
+	message := openapi.Fields{"message": ""}
+	listing := []string{"page", "limit"}
+
+	// Auth
+	a.Add((*AuthHandler).Register, openapi.Operation{Request: models.UserRequest{}, Response: models.AuthResponse{}, Status: http.StatusCreated})
+	a.Add((*AuthHandler).Login, openapi.Operation{Request: models.LoginRequest{}, Response: models.AuthResponse{}})
+	a.Add((*AuthHandler).RefreshToken, openapi.Operation{Request: models.RefreshRequest{}, Response: models.AuthResponse{}})
+	a.Add((*AuthHandler).Logout, openapi.Operation{Request: models.RefreshRequest{}, Response: message})
+	a.Add((*AuthHandler).LogoutAll, openapi.Operation{Response: message, Auth: true})
+	a.Add((*AuthHandler).Profile, openapi.Operation{Summary: "Get the signed in user", Response: openapi.Fields{"user": models.User{}}, Auth: true})
+	a.Add((*AuthHandler).ForgotPassword, openapi.Operation{Request: models.ForgotPasswordRequest{}, Response: message})
+	a.Add((*AuthHandler).ResetPassword, openapi.Operation{Request: models.ResetPasswordRequest{}, Response: message})
+	a.Add((*AuthHandler).GetLoginHistory, openapi.Operation{Response: models.LoginHistoryResponse{}, Query: listing, Auth: true})
+
+	// Cart
+	a.Add((*CartHandler).GetCart, openapi.Operation{Response: models.CartResponse{}})
+	a.Add((*CartHandler).AddToCart, openapi.Operation{Request: models.CartItemRequest{}, Status: http.StatusCreated})
+	a.Add((*CartHandler).UpdateCartItem, openapi.Operation{Request: models.CartItemUpdateRequest{}})
+	a.Add((*CartHandler).RemoveFromCart, openapi.Operation{Response: message})
+	a.Add((*CartHandler).ClearCart, openapi.Operation{Response: message})
+	a.Add((*CartHandler).GetCartCount, openapi.Operation{Response: models.CartCountResponse{}})
+	a.Add((*DiscountHandler).ApplyDiscountToCart, openapi.Operation{Request: models.ApplyDiscountRequest{}, Response: models.ApplyDiscountResponse{}})
+
+	// Orders
+	a.Add((*OrderHandler).CreateOrder, openapi.Operation{Request: models.OrderRequest{}, Response: models.OrderResponse{}, Status: http.StatusCreated})
+	a.Add((*OrderHandler).GetOrder, openapi.Operation{Response: models.OrderResponse{}})
+	a.Add((*OrderHandler).GetOrderByHash, openapi.Operation{Response: models.OrderResponse{}})
+	a.Add((*OrderHandler).GetUserOrders, openapi.Operation{
+		Response: models.OrderListResponse{},
+		Query:    []string{"status", "from", "to", "search", "view", "page", "limit"},
+	})
+	a.Add((*OrderHandler).ListOrders, openapi.Operation{
+		Response: models.OrderListResponse{},
+		Query:    []string{"page", "limit", "cursor", "email", "status", "include_archived"},
+	})
+	a.Add((*OrderHandler).UpdateOrderStatus, openapi.Operation{Request: models.OrderStatusUpdateRequest{}, Response: message})
+
+	// Profile
+	a.Add((*ProfileHandler).GetProfile, openapi.Operation{Response: models.UserProfileResponse{}})
+	a.Add((*ProfileHandler).UpdateProfile, openapi.Operation{Request: models.UserProfileRequest{}, Response: models.UserProfileResponse{}})
+	a.Add((*ProfileHandler).GetAddresses, openapi.Operation{Response: models.UserAddressListResponse{}})
+	a.Add((*ProfileHandler).CreateAddress, openapi.Operation{Request: models.UserAddressRequest{}, Response: models.UserAddressResponse{}, Status: http.StatusCreated})
+	a.Add((*ProfileHandler).UpdateAddress, openapi.Operation{Request: models.UserAddressRequest{}, Response: models.UserAddressResponse{}})
+	a.Add((*ProfileHandler).DeleteAddress, openapi.Operation{Response: message})
+	a.Add((*ProfileHandler).SetDefaultAddress, openapi.Operation{Response: message})
+	a.Add((*EmailPreferenceHandler).GetPreferences, openapi.Operation{Response: models.EmailPreferences{}})
+	a.Add((*EmailPreferenceHandler).UpdatePreferences, openapi.Operation{Request: models.EmailPreferencesRequest{}, Response: models.EmailPreferences{}})
+	unsubscribed := openapi.Fields{"email": "", "category": "", "preferences": models.EmailPreferences{}}
+	a.Add((*EmailPreferenceHandler).GetUnsubscribe, openapi.Operation{Response: unsubscribed, Query: []string{"token"}})
+	a.Add((*EmailPreferenceHandler).Unsubscribe, openapi.Operation{Request: models.UnsubscribeRequest{}, Response: unsubscribed})
+
+	// Public catalog
+	a.Add((*PublicHandler).GetActiveCategories, openapi.Operation{
+		Response: openapi.Fields{"categories": []models.CategoryResponse{}, "total": 0},
+	})
+	a.Add((*PublicHandler).GetPublicProducts, openapi.Operation{
+		Response: openapi.Fields{"products": []models.ProductResponse{}, "total": 0, "page": 0, "limit": 0},
+		Query:    []string{"page", "limit", "search", "category", "tag"},
+	})
+	a.Add((*PublicHandler).GetPublicProduct, openapi.Operation{
+		Response: openapi.Fields{
+			"product":     models.ProductResponse{},
+			"breadcrumbs": []models.BreadcrumbItem{},
+			"canonical":   "",
+			"navigation":  models.ProductNavigation{},
+		},
+	})
+	a.Add((*PublicHandler).GetSizeStock, openapi.Operation{Response: models.SizeStock{}, Query: []string{"quantity"}})
+	a.Add((*PublicHandler).SearchProducts, openapi.Operation{
+		Response: openapi.Fields{"products": []models.ProductResponse{}, "total": 0, "page": 0, "limit": 0, "query": "", "sort": ""},
+		Query:    []string{"q", "sort", "page", "limit"},
+	})
+	a.Add((*PublicHandler).GetSearchSuggestions, openapi.Operation{
+		Response: openapi.Fields{"suggestions": []string{}, "tags": []string{}, "query": ""},
+		Query:    []string{"q", "limit"},
+	})
+	a.Add((*PublicHandler).GetTagCloud, openapi.Operation{Response: openapi.Fields{"tags": []models.TagCloudItem{}}, Query: []string{"limit"}})
+	a.Add((*PublicHandler).GetMaintenanceStatus, openapi.Operation{
+		Response: openapi.Fields{"maintenance_mode": false, "checkout_disabled": false, "checkout_message": ""},
+	})
+	a.Add((*PublicHandler).GetClientReviewSummary, openapi.Operation{Response: models.ClientReviewSummary{}})
+	a.Add((*CurrencyHandler).ListCurrencies, openapi.Operation{Response: models.CurrencyListResponse{}})
+	a.Add((*ReferenceHandler).GetCountries, openapi.Operation{Response: models.CountryReferenceResponse{}, Query: []string{"shipping"}})
+	a.Add((*LegalHandler).GetCurrentDocuments, openapi.Operation{Response: openapi.Fields{"documents": []models.LegalDocument{}}})
+	a.Add((*LegalHandler).GetPendingDocuments, openapi.Operation{Response: openapi.Fields{"documents": []models.LegalDocument{}}})
+	a.Add((*LegalHandler).AcceptDocuments, openapi.Operation{Request: models.AcceptLegalDocumentsRequest{}, Response: message})
+
+	// Admin
+	a.Add((*CurrencyHandler).ListAllCurrencies, openapi.Operation{Response: models.CurrencyListResponse{}})
+	a.Add((*CurrencyHandler).SaveCurrency, openapi.Operation{Request: models.CurrencyRequest{}, Response: models.Currency{}})
+	a.Add((*CurrencyHandler).UpdateRates, openapi.Operation{Summary: "Update exchange rates from NBP", Response: models.ExchangeRateUpdateResponse{}})
+	a.Add((*TranslationHandler).ListProductTranslations, openapi.Operation{Response: models.TranslationListResponse{}})
+	a.Add((*TranslationHandler).SaveProductTranslation, openapi.Operation{Request: models.TranslationRequest{}, Response: models.Translation{}})
+	a.Add((*TranslationHandler).DeleteProductTranslation, openapi.Operation{Response: message})
+	a.Add((*TranslationHandler).ListCategoryTranslations, openapi.Operation{Response: models.TranslationListResponse{}})
+	a.Add((*TranslationHandler).SaveCategoryTranslation, openapi.Operation{Request: models.TranslationRequest{}, Response: models.Translation{}})
+	a.Add((*TranslationHandler).DeleteCategoryTranslation, openapi.Operation{Response: message})
+	a.Add((*TranslationHandler).ListAdditionalServiceTranslations, openapi.Operation{Response: models.TranslationListResponse{}})
+	a.Add((*TranslationHandler).SaveAdditionalServiceTranslation, openapi.Operation{Request: models.TranslationRequest{}, Response: models.Translation{}})
+	a.Add((*TranslationHandler).DeleteAdditionalServiceTranslation, openapi.Operation{Response: message})
+	a.Add((*AdminHandler).GetOrderBoard, openapi.Operation{Response: models.OrderBoardResponse{}, Query: []string{"status", "page[status]", "limit"}})
+	a.Add((*AdminHandler).CreateProductSetup, openapi.Operation{Summary: "Create a product with its sizes and variants", Request: models.ProductSetupRequest{}, Response: models.ProductSetupResponse{}, Status: http.StatusCreated})
+	a.Add((*OrderStatusHandler).ListOrderStatuses, openapi.Operation{Response: models.OrderStatusListResponse{}})
+	a.Add((*OrderStatusHandler).CreateOrderStatus, openapi.Operation{Request: models.OrderCustomStatusRequest{}, Response: models.OrderCustomStatus{}, Status: http.StatusCreated})
+	a.Add((*OrderStatusHandler).UpdateOrderStatus, openapi.Operation{Request: models.OrderCustomStatusRequest{}, Response: models.OrderCustomStatus{}})
+	a.Add((*OrderStatusHandler).DeleteOrderStatus, openapi.Operation{Response: message})
+	a.Add((*AdminHandler).BulkDeleteProducts, openapi.Operation{Request: models.BulkRequest{}, Response: models.BulkResponse{}})
+	a.Add((*AdminHandler).BulkActivateCategories, openapi.Operation{Request: models.BulkRequest{}, Response: models.BulkResponse{}})
+	a.Add((*AdminHandler).BulkDeactivateCategories, openapi.Operation{Request: models.BulkRequest{}, Response: models.BulkResponse{}})
+	a.Add((*AdminHandler).BulkUpdateOrderStatus, openapi.Operation{Request: models.BulkOrderStatusRequest{}, Response: models.BulkResponse{}})
+	a.Add((*SearchIndexHandler).Reindex, openapi.Operation{Response: models.SearchReindexStatus{}, Status: http.StatusAccepted})
+	a.Add((*SearchIndexHandler).GetReindexStatus, openapi.Operation{Response: models.SearchReindexStatus{}})
+	a.Add((*StockReservationHandler).GetReservationStats, openapi.Operation{Response: models.StockReservationStats{}})
+
+	return a
+}
@@ -1,30 +1,69 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/fulfillment"
+	"notsofluffy-backend/internal/mail"
+	"notsofluffy-backend/internal/middleware"
 	"notsofluffy-backend/internal/models"
+	"notsofluffy-backend/internal/pricing"
 )
 
 type OrderHandler struct {
-	orderQueries    *database.OrderQueries
-	cartQueries     *database.CartQueries
-	stockQueries    *database.StockQueries
-	discountQueries *database.DiscountQueries
+	orderQueries          *database.OrderQueries
+	cartQueries           *database.CartQueries
+	stockQueries          *database.StockQueries
+	discountQueries       *database.DiscountQueries
+	legalQueries          *database.LegalQueries
+	warehouseQueries      *database.WarehouseQueries
+	settingsQueries       *database.SettingsQueries
+	webhookQueries        *database.WebhookQueries
+	paymentHandler        *PaymentHandler
+	emailQueries          *database.EmailQueries
+	profileQueries        *database.ProfileQueries
+	shippingMethodQueries *database.ShippingMethodQueries
 }
 
-func NewOrderHandler(orderQueries *database.OrderQueries, cartQueries *database.CartQueries, stockQueries *database.StockQueries, discountQueries *database.DiscountQueries) *OrderHandler {
+// OrderHandlerDeps lists what the order handler needs from the rest of the server
+type OrderHandlerDeps struct {
+	OrderQueries          *database.OrderQueries
+	CartQueries           *database.CartQueries
+	StockQueries          *database.StockQueries
+	DiscountQueries       *database.DiscountQueries
+	LegalQueries          *database.LegalQueries
+	WarehouseQueries      *database.WarehouseQueries
+	SettingsQueries       *database.SettingsQueries
+	WebhookQueries        *database.WebhookQueries
+	PaymentHandler        *PaymentHandler
+	EmailQueries          *database.EmailQueries
+	ProfileQueries        *database.ProfileQueries
+	ShippingMethodQueries *database.ShippingMethodQueries
+}
+
+func NewOrderHandler(deps OrderHandlerDeps) *OrderHandler {
 	return &OrderHandler{
-		orderQueries:    orderQueries,
-		cartQueries:     cartQueries,
-		stockQueries:    stockQueries,
-		discountQueries: discountQueries,
+		orderQueries:          deps.OrderQueries,
+		cartQueries:           deps.CartQueries,
+		stockQueries:          deps.StockQueries,
+		discountQueries:       deps.DiscountQueries,
+		legalQueries:          deps.LegalQueries,
+		warehouseQueries:      deps.WarehouseQueries,
+		settingsQueries:       deps.SettingsQueries,
+		webhookQueries:        deps.WebhookQueries,
+		paymentHandler:        deps.PaymentHandler,
+		emailQueries:          deps.EmailQueries,
+		profileQueries:        deps.ProfileQueries,
+		shippingMethodQueries: deps.ShippingMethodQueries,
 	}
 }
 
@@ -46,6 +85,25 @@ func validateNIP(nip string) bool {
 	return true
 }
 
+// recordTotalsMismatch logs a checkout rejected for mismatching totals for fraud analysis
+func (h *OrderHandler) recordTotalsMismatch(c *gin.Context, sessionID string, userID *int, email string, expected, computed *models.OrderTotals, mismatches []models.TotalsMismatch) {
+	middleware.Logger(c.Request.Context()).Warn("order totals mismatch", "session_id", sessionID, "email", email,
+		"differences", len(mismatches), "expected_total", expected.TotalAmount, "computed_total", computed.TotalAmount)
+
+	entry := &models.TotalsMismatchLog{
+		SessionID:  sessionID,
+		UserID:     userID,
+		Email:      email,
+		IPAddress:  middleware.GetClientIP(c),
+		Expected:   *expected,
+		Computed:   *computed,
+		Mismatches: mismatches,
+	}
+	if err := h.orderQueries.RecordTotalsMismatch(entry); err != nil {
+		middleware.Logger(c.Request.Context()).Error("failed to record totals mismatch", "error", err)
+	}
+}
+
 // CreateOrder creates a new order from cart
 func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	var req models.OrderRequest
@@ -67,6 +125,12 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 		}
 	}
 
+	payOnline := req.PaymentMethod != nil && *req.PaymentMethod == models.PaymentMethodOnline
+	if payOnline && !h.paymentHandler.enabled() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Online payments are not available"})
+		return
+	}
+
 	// Get session ID
 	sessionID, exists := c.Get("session_id")
 	if !exists {
@@ -84,9 +148,56 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 		}
 	}
 
+	// Resolve saved addresses into snapshots so later edits to the address book
+	// don't change the order
+	shippingAddress, err := h.resolveCheckoutAddress(userID, req.ShippingAddress, req.ShippingAddressID, req.Phone)
+	if err != nil {
+		status, message := checkoutAddressError("shipping", err)
+		if status == http.StatusInternalServerError {
+			c.Error(err)
+		}
+		c.JSON(status, gin.H{"error": message})
+		return
+	}
+	billingAddress := shippingAddress
+	if !req.SameAsShipping || req.BillingAddress != nil || req.BillingAddressID != nil {
+		billingAddress, err = h.resolveCheckoutAddress(userID, req.BillingAddress, req.BillingAddressID, req.Phone)
+		if err != nil {
+			status, message := checkoutAddressError("billing", err)
+			if status == http.StatusInternalServerError {
+				c.Error(err)
+			}
+			c.JSON(status, gin.H{"error": message})
+			return
+		}
+	}
+
+	// Require acceptance of the current terms and privacy policy. Logged-in users
+	// only need to confirm versions published since they last accepted.
+	legalDocs, err := h.legalQueries.GetCurrentDocuments()
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get legal documents"})
+		return
+	}
+	requiredDocs := legalDocs
+	if userID != nil {
+		requiredDocs, err = h.legalQueries.GetPendingDocumentsForUser(*userID)
+		if err != nil {
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get legal documents"})
+			return
+		}
+	}
+	if missing := missingAcceptances(requiredDocs, req.AcceptedDocuments); len(missing) > 0 {
+		respondLegalAcceptanceRequired(c, http.StatusBadRequest, missing)
+		return
+	}
+
 	// Get cart session
 	cartSession, err := h.cartQueries.GetOrCreateCartSession(sessionIDStr, userID)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get cart session"})
 		return
 	}
@@ -94,6 +205,7 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	// Get cart items
 	items, err := h.cartQueries.GetCartItems(cartSession.ID)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get cart items"})
 		return
 	}
@@ -103,13 +215,48 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 		return
 	}
 
-	// Calculate totals
-	var totalItems int
-	var subtotal float64
+	// Items whose services were deleted must be removed before checkout
+	var unavailableItems []int
 	for _, item := range items {
-		totalItems += item.Quantity
-		subtotal += item.TotalPrice
+		if item.Unavailable {
+			unavailableItems = append(unavailableItems, item.ID)
+		}
+	}
+	if len(unavailableItems) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":             "Some items in your cart are no longer available",
+			"unavailable_items": unavailableItems,
+		})
+		return
+	}
+
+	// Recompute every item price from the catalog and the service and option prices
+	// snapshotted on the cart instead of trusting the stored cart prices
+	var computed models.OrderTotals
+	for i := range items {
+		item := &items[i]
+		var servicePrices, surcharges []float64
+		for _, service := range item.AdditionalServices {
+			servicePrices = append(servicePrices, service.Price)
+		}
+		for _, option := range item.Options {
+			surcharges = append(surcharges, option.Surcharge)
+		}
+
+		unitPrice := pricing.UnitPrice(item.Size.BasePrice, item.Variant.Color.Custom, servicePrices, surcharges)
+		if unitPrice != item.PricePerItem {
+			// Reprice the cart so the customer is shown what the order will cost
+			if err := h.cartQueries.UpdateCartItemPrice(item.ID, unitPrice); err != nil {
+				middleware.Logger(c.Request.Context()).Error("failed to reprice cart item", "cart_item_id", item.ID, "error", err)
+			}
+			item.PricePerItem = unitPrice
+		}
+		item.TotalPrice = pricing.Round(unitPrice * float64(item.Quantity))
+
+		computed.Subtotal += item.TotalPrice
+		computed.Items = append(computed.Items, models.OrderItemTotal{CartItemID: item.ID, UnitPrice: unitPrice, TotalPrice: item.TotalPrice})
 	}
+	computed.Subtotal = pricing.Round(computed.Subtotal)
 
 	// Check for applied discount and calculate discount details
 	var discountCodeID *int
@@ -117,25 +264,76 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	var discountDescription *string
 	if cartSession.AppliedDiscountCodeID != nil {
 		discountCodeID = cartSession.AppliedDiscountCodeID
-		discountAmount = cartSession.DiscountAmount
-		
-		// Get discount code for description
+
+		// The cart's discount was computed when the code was applied, so it is computed
+		// again from the code and the repriced subtotal
 		discountCode, err := h.discountQueries.GetDiscountCodeByID(*cartSession.AppliedDiscountCodeID)
-		if err == nil {
-			desc := fmt.Sprintf("%s: %s", discountCode.Code, discountCode.Description)
-			discountDescription = &desc
+		if err != nil {
+			if err.Error() == "discount code not found" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid discount code"})
+				return
+			}
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate discount code"})
+			return
+		}
+		desc := fmt.Sprintf("%s: %s", discountCode.Code, discountCode.Description)
+		discountDescription = &desc
+		discountAmount = pricing.Discount(discountCode.DiscountType, discountCode.DiscountValue, computed.Subtotal)
+
+		// Codes restricted to specific customers must match the order's email
+		recipientValid, err := h.discountQueries.ValidateDiscountRecipient(*discountCodeID, userID, req.Email)
+		if err != nil {
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate discount code"})
+			return
+		}
+		if !recipientValid.IsValid {
+			c.JSON(http.StatusBadRequest, gin.H{"error": recipientValid.ErrorMessage})
+			return
+		}
+
+		if discountCode.IsFirstOrderOnly {
+			hasOrders, err := h.discountQueries.HasPriorOrders(userID, req.Email)
+			if err != nil {
+				c.Error(err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate discount code"})
+				return
+			}
+			if hasOrders {
+				c.JSON(http.StatusBadRequest, gin.H{"error": database.FirstOrderOnlyMessage})
+				return
+			}
 		}
 	}
 
 	// Calculate final totals
-	discountedSubtotal := subtotal - discountAmount
-	if discountedSubtotal < 0 {
-		discountedSubtotal = 0
+	computed.DiscountAmount = discountAmount
+	shippingMethod, ok := resolveShippingMethod(c, h.shippingMethodQueries, req.ShippingMethodID)
+	if !ok {
+		return
+	}
+	shipping := pricing.Shipping(items, shippingMethod, computed.Subtotal-computed.DiscountAmount, oversizeShippingFee(c.Request.Context(), h.settingsQueries))
+	computed.ShippingCost = shipping.Total
+	computed.TaxAmount = 0.0    // TODO: implement tax calculation
+	computed.TotalAmount = pricing.Total(computed.Subtotal, computed.DiscountAmount, computed.ShippingCost, computed.TaxAmount)
+
+	// Reject the order when the customer was shown different totals, e.g. after a price change
+	if req.ExpectedTotals != nil {
+		if mismatches := pricing.Compare(req.ExpectedTotals, &computed); len(mismatches) > 0 {
+			h.recordTotalsMismatch(c, sessionIDStr, userID, req.Email, req.ExpectedTotals, &computed, mismatches)
+			c.JSON(http.StatusConflict, gin.H{
+				"error":      "Order totals have changed, please review your cart",
+				"code":       models.TotalsMismatchCode,
+				"mismatches": mismatches,
+				"totals":     computed,
+			})
+			return
+		}
 	}
-	
-	shippingCost := 0.0 // TODO: implement shipping calculation
-	taxAmount := 0.0    // TODO: implement tax calculation
-	totalAmount := discountedSubtotal + shippingCost + taxAmount
+
+	// Admins can place test orders on production, anyone can outside of it
+	isTest := (req.IsTest && c.GetString("user_role") == models.RoleAdmin) || middleware.IsTestOrder(c)
 
 	// Create order
 	order := &models.Order{
@@ -144,10 +342,10 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 		Email:               req.Email,
 		Phone:               req.Phone,
 		Status:              models.OrderStatusPending,
-		TotalAmount:         totalAmount,
-		Subtotal:            subtotal,
-		ShippingCost:        shippingCost,
-		TaxAmount:           taxAmount,
+		TotalAmount:         computed.TotalAmount,
+		Subtotal:            computed.Subtotal,
+		ShippingCost:        computed.ShippingCost,
+		TaxAmount:           computed.TaxAmount,
 		DiscountCodeID:      discountCodeID,
 		DiscountAmount:      discountAmount,
 		DiscountDescription: discountDescription,
@@ -156,39 +354,65 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 		Notes:               req.Notes,
 		RequiresInvoice:     req.RequiresInvoice,
 		NIP:                 req.NIP,
-	}
+		IsTest:              isTest,
+		ShippingBreakdown:   &shipping,
+		ShopID:              middleware.GetShopID(c),
+	}
+	// The customer saw the prices in this currency; its rate is locked on the order
+	currency := middleware.GetCurrency(c)
+	order.Currency, order.ExchangeRate = currency.Code, currency.Rate
+	if country := middleware.GetCountryCode(c); country != "" {
+		order.OriginCountry = &country
+	}
+
+	// Record the shipping estimate, and the split shipment request when the cart mixes
+	// in-stock and made-to-order items. CreateOrder books production capacity for the
+	// made-to-order items and pushes the estimate out when the workshop is booked up.
+	shippingSettings := fulfillmentSettings(h.settingsQueries)
+	shippingItems := applyLeadTimes(items, shippingSettings)
+	leadTimeDays := fulfillment.LeadDays(shippingItems, shippingSettings)
+	order.LeadTimeDays = &leadTimeDays
+	order.ProductionUnits = fulfillment.MadeToOrderUnits(shippingItems)
+	order.SplitShipment = req.SplitShipment && fulfillment.Mixed(shippingItems)
 
 	// Create shipping address
 	shippingAddr := &models.ShippingAddress{
-		FirstName:     req.ShippingAddress.FirstName,
-		LastName:      req.ShippingAddress.LastName,
-		Company:       req.ShippingAddress.Company,
-		AddressLine1:  req.ShippingAddress.AddressLine1,
-		AddressLine2:  req.ShippingAddress.AddressLine2,
-		City:          req.ShippingAddress.City,
-		StateProvince: req.ShippingAddress.StateProvince,
-		PostalCode:    req.ShippingAddress.PostalCode,
-		Country:       req.ShippingAddress.Country,
-		Phone:         req.ShippingAddress.Phone,
+		FirstName:     shippingAddress.FirstName,
+		LastName:      shippingAddress.LastName,
+		Company:       shippingAddress.Company,
+		AddressLine1:  shippingAddress.AddressLine1,
+		AddressLine2:  shippingAddress.AddressLine2,
+		City:          shippingAddress.City,
+		StateProvince: shippingAddress.StateProvince,
+		PostalCode:    shippingAddress.PostalCode,
+		Country:       shippingAddress.Country,
+		Phone:         shippingAddress.Phone,
 	}
 
 	// Create billing address
 	billingAddr := &models.BillingAddress{
-		FirstName:      req.BillingAddress.FirstName,
-		LastName:       req.BillingAddress.LastName,
-		Company:        req.BillingAddress.Company,
-		AddressLine1:   req.BillingAddress.AddressLine1,
-		AddressLine2:   req.BillingAddress.AddressLine2,
-		City:           req.BillingAddress.City,
-		StateProvince:  req.BillingAddress.StateProvince,
-		PostalCode:     req.BillingAddress.PostalCode,
-		Country:        req.BillingAddress.Country,
-		Phone:          req.BillingAddress.Phone,
+		FirstName:      billingAddress.FirstName,
+		LastName:       billingAddress.LastName,
+		Company:        billingAddress.Company,
+		AddressLine1:   billingAddress.AddressLine1,
+		AddressLine2:   billingAddress.AddressLine2,
+		City:           billingAddress.City,
+		StateProvince:  billingAddress.StateProvince,
+		PostalCode:     billingAddress.PostalCode,
+		Country:        billingAddress.Country,
+		Phone:          billingAddress.Phone,
 		SameAsShipping: req.SameAsShipping,
 	}
 
-	// Reserve stock for all items first
+	// Reserve stock for all items first; the reservations expire if the request dies
+	ttlMinutes, err := h.settingsQueries.GetIntSetting(models.SettingStockReservationTTLMinutes, models.DefaultStockReservationTTLMinutes)
+	if err != nil || ttlMinutes <= 0 {
+		ttlMinutes = models.DefaultStockReservationTTLMinutes
+	}
+	reservationTTL := time.Duration(ttlMinutes) * time.Minute
+
 	var stockReservations []struct {
+		ID       int
 		SizeID   int
 		Quantity int
 	}
@@ -197,6 +421,7 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 		// Check and reserve stock
 		available, availableStock, err := h.stockQueries.CheckStockAvailability(cartItem.SizeID, cartItem.Quantity)
 		if err != nil {
+			c.Error(err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check stock availability", "details": err.Error()})
 			return
 		}
@@ -216,20 +441,26 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 		}
 		
 		// Reserve stock
-		err = h.stockQueries.ReserveStock(cartItem.SizeID, cartItem.Quantity)
+		reservationID, err := h.stockQueries.ReserveStock(cartItem.SizeID, cartItem.Quantity, reservationTTL)
 		if err != nil {
 			// Release any previously reserved stock
 			for _, reservation := range stockReservations {
-				h.stockQueries.ReleaseStock(reservation.SizeID, reservation.Quantity)
+				h.stockQueries.ReleaseReservation(reservation.ID)
 			}
+			c.Error(err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reserve stock", "details": err.Error()})
 			return
 		}
+		if reservationID == 0 {
+			// The size doesn't track stock
+			continue
+		}
 		
 		stockReservations = append(stockReservations, struct {
+			ID       int
 			SizeID   int
 			Quantity int
-		}{cartItem.SizeID, cartItem.Quantity})
+		}{reservationID, cartItem.SizeID, cartItem.Quantity})
 	}
 
 	// Convert cart items to order items
@@ -270,6 +501,7 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 				ServicePrice:       service.Price,
 			})
 		}
+		orderItem.Options = cartItem.Options
 
 		orderItems = append(orderItems, orderItem)
 	}
@@ -279,41 +511,144 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	if err != nil {
 		// Release reserved stock if order creation fails
 		for _, reservation := range stockReservations {
-			h.stockQueries.ReleaseStock(reservation.SizeID, reservation.Quantity)
+			h.stockQueries.ReleaseReservation(reservation.ID)
 		}
+		// Another order of this customer with the same first-order code won the race
+		if err.Error() == "discount code is only valid on the first order" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": database.FirstOrderOnlyMessage})
+			return
+		}
+		// The discount code was used up or expired since it was applied to the cart
+		var rejected *database.DiscountRejectedError
+		if errors.As(err, &rejected) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": rejected.Message})
+			return
+		}
+		if err.Error() == "production capacity exhausted" {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "Made-to-order items can't be booked into production right now",
+				"code":  models.ProductionCapacityExhaustedCode,
+			})
+			return
+		}
+		middleware.Logger(c.Request.Context()).Error("failed to create order", "email", req.Email, "error", err)
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create order"})
 		return
 	}
 
 	// Decrement stock for all items after successful order creation
 	for _, reservation := range stockReservations {
-		err = h.stockQueries.DecrementStock(reservation.SizeID, reservation.Quantity)
+		err = h.stockQueries.ConfirmReservation(reservation.ID, reservation.SizeID, reservation.Quantity)
 		if err != nil {
 			// Log error but don't fail the request since order was created
-			// TODO: implement proper logging
-			// In a production system, you might want to track this for inventory correction
+			middleware.Logger(c.Request.Context()).Error("failed to decrement stock", "size_id", reservation.SizeID, "order_id", orderResponse.ID, "error", err)
 		}
 	}
 
-	// Record discount usage if discount was applied
-	if discountCodeID != nil {
-		err = h.discountQueries.RecordDiscountUsage(*discountCodeID, userID, sessionIDStr, &orderResponse.ID)
-		if err != nil {
-			// Log error but don't fail the request since order was created
-			// TODO: implement proper logging
-		}
+	h.publishLowStock(c.Request.Context(), orderItems)
+
+	// Assign the ordered items to warehouses for picking
+	if err := h.warehouseQueries.AllocateOrder(orderResponse.ID); err != nil {
+		middleware.Logger(c.Request.Context()).Error("failed to allocate order to warehouses", "order_id", orderResponse.ID, "error", err)
+	}
+
+	// Record which terms and privacy policy versions the order was placed under
+	err = h.legalQueries.RecordAcceptance(legalDocs, userID, &orderResponse.ID, middleware.GetClientIP(c), userAgentPtr(c))
+	if err != nil {
+		middleware.Logger(c.Request.Context()).Error("failed to record legal acceptance", "order_id", orderResponse.ID, "error", err)
 	}
 
 	// Clear cart after successful order
+	itemCount, err := h.cartQueries.GetCartItemCount(cartSession.ID)
+	if err != nil {
+		middleware.Logger(c.Request.Context()).Error("failed to count cart items", "order_id", orderResponse.ID, "error", err)
+	}
+	err = h.cartQueries.RecordCartEvent(&models.CartEvent{
+		CartSessionID:  cartSession.ID,
+		Action:         models.CartActionCheckedOut,
+		QuantityBefore: itemCount,
+		UserID:         userID,
+		OrderID:        &orderResponse.ID,
+	})
+	if err != nil {
+		middleware.Logger(c.Request.Context()).Error("failed to record checkout in cart history", "order_id", orderResponse.ID, "error", err)
+	}
+
 	err = h.cartQueries.ClearCart(cartSession.ID)
 	if err != nil {
 		// Log error but don't fail the request since order was created
 		// TODO: implement proper logging
 	}
 
+	if userID != nil {
+		if req.SaveShippingAddress && req.ShippingAddress != nil {
+			h.saveCheckoutAddress(c.Request.Context(), *userID, req.ShippingAddress)
+		}
+		if req.SaveBillingAddress && req.BillingAddress != nil {
+			h.saveCheckoutAddress(c.Request.Context(), *userID, req.BillingAddress)
+		}
+	}
+
+	if !orderResponse.IsTest {
+		publishWebhook(c.Request.Context(), h.webhookQueries, models.WebhookEventOrderCreated, orderResponse)
+	}
+	queueEmail(c.Request.Context(), h.emailQueries, mail.TemplateOrderConfirmation, models.EmailTemplateData{Order: orderResponse})
+
+	// The order stands even when the payment can't be started; the customer can retry
+	// through /api/orders/:id/pay
+	if payOnline {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+		defer cancel()
+		intent, err := h.paymentHandler.startPayment(ctx, orderResponse)
+		if err != nil {
+			middleware.Logger(c.Request.Context()).Error("failed to start payment", "order_id", orderResponse.ID, "error", err)
+		}
+		orderResponse.Payment = intent
+	}
+	formatOrder(orderPriceFormat(c, orderResponse.Currency, orderResponse.ExchangeRate), orderResponse)
+
 	c.JSON(http.StatusCreated, orderResponse)
 }
 
+// publishLowStock sends stock.low for the sizes this order took to or below the low
+// stock threshold. Sizes that were already low before the order don't send it again.
+func (h *OrderHandler) publishLowStock(ctx context.Context, items []models.OrderItem) {
+	threshold, err := h.settingsQueries.GetIntSetting(models.SettingLowStockThreshold, 3)
+	if err != nil {
+		middleware.Logger(ctx).Error("failed to get low stock threshold", "error", err)
+		return
+	}
+
+	ordered := map[int]int{}
+	var sizes []models.OrderItem
+	for _, item := range items {
+		if _, seen := ordered[item.SizeID]; !seen {
+			sizes = append(sizes, item)
+		}
+		ordered[item.SizeID] += item.Quantity
+	}
+
+	for _, item := range sizes {
+		level, err := h.stockQueries.GetStockLevel(item.SizeID)
+		if err != nil {
+			middleware.Logger(ctx).Error("failed to get stock level", "size_id", item.SizeID, "error", err)
+			continue
+		}
+		// -1 means the size doesn't track stock
+		if level < 0 || level > threshold || level+ordered[item.SizeID] <= threshold {
+			continue
+		}
+		publishWebhook(ctx, h.webhookQueries, models.WebhookEventStockLow, models.StockLowEvent{
+			ProductID: item.ProductID,
+			SizeID:    item.SizeID,
+			SizeName:  item.SizeName,
+			Available: level,
+			Threshold: threshold,
+		})
+	}
+}
+
 // GetOrder retrieves an order by ID
 func (h *OrderHandler) GetOrder(c *gin.Context) {
 	idStr := c.Param("id")
@@ -329,41 +664,45 @@ func (h *OrderHandler) GetOrder(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
 			return
 		}
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get order"})
 		return
 	}
 
 	// Check if user has permission to view this order
+	if !canAccessOrder(c, order) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return
+	}
+	formatOrder(orderPriceFormat(c, order.Currency, order.ExchangeRate), order)
+
+	c.JSON(http.StatusOK, order)
+}
+
+// canAccessOrder reports whether the requester may see an order: its owner or an admin
+// when authenticated, otherwise the session a guest order was placed in
+func canAccessOrder(c *gin.Context, order *models.OrderResponse) bool {
 	if userIDValue, exists := c.Get("user_id"); exists {
 		// User is authenticated - only check user ownership and admin role
 		if userID, ok := userIDValue.(int); ok {
 			// User can view their own orders
 			if order.UserID != nil && *order.UserID == userID {
-				c.JSON(http.StatusOK, order)
-				return
+				return true
 			}
 			// Admin can view all orders (check role)
 			if userRole, roleExists := c.Get("user_role"); roleExists {
 				if role, roleOk := userRole.(string); roleOk && role == "admin" {
-					c.JSON(http.StatusOK, order)
-					return
+					return true
 				}
 			}
 			// Authenticated user cannot access this order - deny access
-			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
-			return
+			return false
 		}
 	}
 
 	// User is NOT authenticated - check session for guest orders only
 	sessionID, exists := c.Get("session_id")
-	if exists && order.UserID == nil && order.SessionID != nil && *order.SessionID == sessionID.(string) {
-		// This is a guest order and session matches
-		c.JSON(http.StatusOK, order)
-		return
-	}
-
-	c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+	return exists && order.UserID == nil && order.SessionID != nil && *order.SessionID == sessionID.(string)
 }
 
 // ListOrders lists orders for admin
@@ -373,6 +712,8 @@ func (h *OrderHandler) ListOrders(c *gin.Context) {
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
 	email := c.Query("email")
 	status := c.Query("status")
+	// Archived orders are only listed for compliance lookups
+	includeArchived := c.Query("include_archived") == "true"
 
 	if page < 1 {
 		page = 1
@@ -381,8 +722,18 @@ func (h *OrderHandler) ListOrders(c *gin.Context) {
 		limit = 10
 	}
 
-	orders, err := h.orderQueries.ListOrders(page, limit, nil, email, status)
+	after, ok := parseCursor(c)
+	if !ok {
+		return
+	}
+
+	orders, err := h.orderQueries.ListOrders(page, limit, after, nil, email, status, includeArchived)
 	if err != nil {
+		if err.Error() == "invalid cursor" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get orders"})
 		return
 	}
@@ -427,20 +778,28 @@ func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
 		return
 	}
 
-	err = h.orderQueries.UpdateOrderStatus(id, req.Status)
+	change, err := h.orderQueries.UpdateOrderStatus(id, req.Status, nil)
 	if err != nil {
 		if err.Error() == "order not found" {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
 			return
 		}
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update order status"})
 		return
 	}
 
+	publishStatusChange(c.Request.Context(), h.webhookQueries, change)
+	if req.NotifyCustomer == nil || *req.NotifyCustomer {
+		queueStatusEmail(c.Request.Context(), h.emailQueries, h.orderQueries, change)
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "Order status updated successfully"})
 }
 
-// GetUserOrders retrieves orders for the authenticated user
+// GetUserOrders retrieves orders for the authenticated user, optionally of one ?status=,
+// placed from ?from= to ?to= (inclusive dates, YYYY-MM-DD) and with a product named like
+// ?search=. ?view=summary returns the compact orders for the account dashboard.
 func (h *OrderHandler) GetUserOrders(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -465,11 +824,31 @@ func (h *OrderHandler) GetUserOrders(c *gin.Context) {
 		limit = 10
 	}
 
-	orders, err := h.orderQueries.GetOrdersByUserIDWithItems(id, page, limit)
+	filter, ok := parseOrderHistoryFilter(c, h.settingsQueries.GetShopLocation())
+	if !ok {
+		return
+	}
+
+	switch c.DefaultQuery("view", models.OrderHistoryViewFull) {
+	case models.OrderHistoryViewFull:
+	case models.OrderHistoryViewSummary:
+		h.getUserOrderSummaries(c, id, filter, page, limit)
+		return
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "view must be full or summary"})
+		return
+	}
+
+	orders, err := h.orderQueries.GetOrdersByUserIDWithItems(id, filter, page, limit)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get orders"})
 		return
 	}
+	for i := range orders.Orders {
+		order := &orders.Orders[i]
+		formatOrder(orderPriceFormat(c, order.Currency, order.ExchangeRate), order)
+	}
 
 	c.JSON(http.StatusOK, orders)
 }
@@ -488,9 +867,16 @@ func (h *OrderHandler) GetOrderByHash(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
 			return
 		}
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get order"})
 		return
 	}
+	formatOrder(orderPriceFormat(c, order.Currency, order.ExchangeRate), order)
+
+	// The page still shows without the tracking when it can't be read
+	if order.Tracking, err = h.orderQueries.GetOrderTracking(order); err != nil {
+		middleware.Logger(c.Request.Context()).Error("failed to get order tracking", "order_id", order.ID, "error", err)
+	}
 
 	c.JSON(http.StatusOK, order)
 }
\ No newline at end of file
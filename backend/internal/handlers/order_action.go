@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/labels"
+	"notsofluffy-backend/internal/mail"
+	"notsofluffy-backend/internal/middleware"
+	"notsofluffy-backend/internal/models"
+)
+
+type OrderActionHandler struct {
+	orderQueries    *database.OrderQueries
+	settingsQueries *database.SettingsQueries
+	webhookQueries  *database.WebhookQueries
+	refundHandler   *RefundHandler
+	mailer          mail.Sender
+}
+
+func NewOrderActionHandler(orderQueries *database.OrderQueries, settingsQueries *database.SettingsQueries, webhookQueries *database.WebhookQueries, refundHandler *RefundHandler, mailer mail.Sender) *OrderActionHandler {
+	return &OrderActionHandler{orderQueries: orderQueries, settingsQueries: settingsQueries, webhookQueries: webhookQueries, refundHandler: refundHandler, mailer: mailer}
+}
+
+// RunOrderAction runs a quick action of the admin command palette on an order: it
+// changes the order in one transaction, then emails the customer, and returns the
+// updated order. print_label returns the order's label PDF.
+func (h *OrderActionHandler) RunOrderAction(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	var req models.OrderActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.TrackingCarrier = strings.TrimSpace(req.TrackingCarrier)
+	req.TrackingNumber = strings.TrimSpace(req.TrackingNumber)
+
+	var message, previousStatus, paymentStatus string
+	switch req.Action {
+	case models.OrderActionPrintLabel:
+		h.printLabel(c, id)
+		return
+	case models.OrderActionMarkPaid:
+		previousStatus, err = h.orderQueries.MarkOrderPaid(id)
+		message = "Order marked as paid"
+	case models.OrderActionMarkShipped:
+		if req.TrackingCarrier == "" || req.TrackingNumber == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "tracking_carrier and tracking_number are required"})
+			return
+		}
+		previousStatus, err = h.orderQueries.MarkOrderShipped(id, req.TrackingCarrier, req.TrackingNumber)
+		message = "Order marked as shipped"
+	case models.OrderActionResendEmail:
+		message = "Order email sent"
+	case models.OrderActionCancelDuplicate:
+		rule, ruleErr := h.settingsQueries.GetDuplicateOrderRule()
+		if ruleErr != nil {
+			middleware.Logger(c.Request.Context()).Warn("failed to read duplicate order settings, using defaults", "error", ruleErr)
+		}
+		previousStatus, paymentStatus, err = h.orderQueries.CancelDuplicateOrder(id, rule)
+		message = "Duplicate order cancelled"
+	}
+	if err != nil {
+		switch err.Error() {
+		case "order not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		case "order is cancelled", "order is already paid", "order is already delivered", "order has already shipped", "order is not a suspected duplicate":
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update order"})
+		}
+		return
+	}
+
+	order, err := h.orderQueries.GetOrderByID(id)
+	if err != nil {
+		if err.Error() == "order not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get order"})
+		return
+	}
+
+	if previousStatus != "" {
+		publishStatusChange(c.Request.Context(), h.webhookQueries, &models.OrderStatusChangedEvent{
+			OrderID:        order.ID,
+			Email:          order.Email,
+			PreviousStatus: previousStatus,
+			Status:         order.Status,
+			IsTest:         order.IsTest,
+		})
+	}
+
+	// A paid duplicate is refunded in full; the cancellation stands if the refund fails,
+	// so it can be retried from the refunds screen
+	var refund *models.Refund
+	if req.Action == models.OrderActionCancelDuplicate && (paymentStatus == models.PaymentStatusCompleted || paymentStatus == models.PaymentStatusPartiallyRefunded) {
+		refund, err = h.refundDuplicate(c, order)
+		if err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Order cancelled but the refund failed: " + err.Error(), "order": order})
+			return
+		}
+		if refund != nil {
+			message = "Duplicate order cancelled and refunded"
+		}
+	}
+
+	// Resending is the point of resend_email; the others notify unless told not to
+	emailSent := false
+	if req.Action == models.OrderActionResendEmail || req.NotifyCustomer == nil || *req.NotifyCustomer {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+		defer cancel()
+		if err := h.mailer.Send(ctx, orderActionEmail(req.Action, order)); err != nil {
+			middleware.Logger(c.Request.Context()).Error("failed to send order action email", "action", req.Action, "order_id", id, "error", err)
+			if req.Action == models.OrderActionResendEmail {
+				c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to send order email"})
+				return
+			}
+		} else {
+			emailSent = true
+		}
+	}
+
+	c.JSON(http.StatusOK, models.OrderActionResponse{Action: req.Action, Message: message, EmailSent: emailSent, Order: order, Refund: refund})
+}
+
+// refundDuplicate refunds what is left to refund of a cancelled duplicate order and
+// returns the refund, or nil when nothing was left to refund
+func (h *OrderActionHandler) refundDuplicate(c *gin.Context, order *models.OrderResponse) (*models.Refund, error) {
+	refundable := order.TotalAmount - order.RefundedAmount
+	if refundable < 0.005 {
+		return nil, nil
+	}
+
+	req := &models.RefundRequest{Amount: refundable, Reason: "Duplicate order"}
+	refund, err := h.refundHandler.refundQueries.CreateRefund(order.ID, req, h.refundHandler.provider.Name(), getUserIDPtr(c))
+	if err != nil {
+		middleware.Logger(c.Request.Context()).Error("failed to create refund for duplicate order", "order_id", order.ID, "error", err)
+		return nil, errors.New("Failed to create refund")
+	}
+	if _, err := h.refundHandler.refundAtProvider(c, order, refund, nil); err != nil {
+		return nil, err
+	}
+
+	order.RefundedAmount += refund.Amount
+	order.PaymentStatus = models.PaymentStatusRefunded
+	return refund, nil
+}
+
+// printLabel returns the address label of one order
+func (h *OrderActionHandler) printLabel(c *gin.Context, id int) {
+	addresses, err := h.orderQueries.GetShippingAddresses([]int{id})
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get shipping address"})
+		return
+	}
+	if len(addresses) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order has no shipping address"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=shipping-label-%d.pdf", id))
+	c.Data(http.StatusOK, "application/pdf", labels.Render([]labels.Label{shippingLabel(addresses[0])}, labelSender(c.Request.Context(), h.settingsQueries)))
+}
+
+// orderActionEmail tells the customer about an order after a quick action
+func orderActionEmail(action string, order *models.OrderResponse) mail.Message {
+	var b strings.Builder
+	subject := fmt.Sprintf("Your NotSoFluffy order #%d", order.ID)
+
+	switch action {
+	case models.OrderActionMarkPaid:
+		subject = fmt.Sprintf("Payment received for order #%d", order.ID)
+		b.WriteString("Thank you, we have received your payment and started preparing your order.\n\n")
+	case models.OrderActionMarkShipped:
+		subject = fmt.Sprintf("Your order #%d has shipped", order.ID)
+		b.WriteString("Your order is on its way.\n\n")
+		if order.TrackingCarrier != nil && order.TrackingNumber != nil {
+			fmt.Fprintf(&b, "Carrier: %s\nTracking number: %s\n\n", *order.TrackingCarrier, *order.TrackingNumber)
+		}
+	case models.OrderActionCancelDuplicate:
+		subject = fmt.Sprintf("Your order #%d has been cancelled", order.ID)
+		b.WriteString("It looks like this order was placed twice, so we have cancelled this copy. Your other order is not affected.\n\n")
+		if order.RefundedAmount > 0 {
+			fmt.Fprintf(&b, "We have refunded %.2f to your original payment method.\n\n", order.RefundedAmount)
+		}
+	default:
+		fmt.Fprintf(&b, "Here is a summary of your order. Its current status is: %s.\n\n", order.Status)
+	}
+
+	for _, item := range order.Items {
+		fmt.Fprintf(&b, "%d x %s (%s, %s) - %.2f\n", item.Quantity, item.ProductName, item.VariantName, item.SizeName, item.TotalPrice)
+	}
+	if order.DiscountAmount > 0 {
+		fmt.Fprintf(&b, "Discount: -%.2f\n", order.DiscountAmount)
+	}
+	fmt.Fprintf(&b, "Total: %.2f\n", order.TotalAmount)
+
+	return mail.Message{To: order.Email, Subject: subject, Body: b.String()}
+}
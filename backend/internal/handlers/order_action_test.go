@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"notsofluffy-backend/internal/models"
+)
+
+func TestOrderActionEmail(t *testing.T) {
+	carrier, number := "InPost", "6200123456"
+	order := &models.OrderResponse{
+		ID:              42,
+		Email:           "anna@example.com",
+		Status:          models.OrderStatusShipped,
+		TotalAmount:     199.9,
+		TrackingCarrier: &carrier,
+		TrackingNumber:  &number,
+		Items: []models.OrderItem{
+			{ProductName: "Dog bed", VariantName: "Grey", SizeName: "M", Quantity: 1, TotalPrice: 199.9},
+		},
+	}
+
+	msg := orderActionEmail(models.OrderActionMarkShipped, order)
+	if msg.To != "anna@example.com" || msg.Subject != "Your order #42 has shipped" {
+		t.Fatalf("unexpected message %+v", msg)
+	}
+	for _, want := range []string{"Tracking number: 6200123456", "1 x Dog bed (Grey, M) - 199.90", "Total: 199.90"} {
+		if !strings.Contains(msg.Body, want) {
+			t.Errorf("expected body to contain %q, got:\n%s", want, msg.Body)
+		}
+	}
+
+	if msg := orderActionEmail(models.OrderActionResendEmail, order); !strings.Contains(msg.Body, "status is: shipped") {
+		t.Errorf("expected the summary to show the status, got:\n%s", msg.Body)
+	}
+}
+
+func TestOrderActionEmailCancelDuplicate(t *testing.T) {
+	order := &models.OrderResponse{ID: 43, Email: "anna@example.com", Status: models.OrderStatusCancelled, TotalAmount: 199.9, RefundedAmount: 199.9}
+
+	msg := orderActionEmail(models.OrderActionCancelDuplicate, order)
+	if msg.Subject != "Your order #43 has been cancelled" {
+		t.Fatalf("unexpected subject %q", msg.Subject)
+	}
+	if !strings.Contains(msg.Body, "We have refunded 199.90") {
+		t.Errorf("expected the refund to be mentioned, got:\n%s", msg.Body)
+	}
+
+	order.RefundedAmount = 0
+	if msg := orderActionEmail(models.OrderActionCancelDuplicate, order); strings.Contains(msg.Body, "refunded") {
+		t.Errorf("expected no refund line for an unpaid order, got:\n%s", msg.Body)
+	}
+}
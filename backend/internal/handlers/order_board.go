@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"notsofluffy-backend/internal/models"
+)
+
+// orderBoardQuery is what part of the order board a request asks for
+type orderBoardQuery struct {
+	statuses []string
+	pages    map[string]int
+	limit    int
+}
+
+// parseOrderBoardQuery reads the columns (?status=, repeated or comma separated, all by
+// default), their pages (?page[processing]=2) and the orders per column (?limit=, 20 by
+// default) and responds with an error when they are invalid
+func parseOrderBoardQuery(c *gin.Context) (orderBoardQuery, bool) {
+	query := orderBoardQuery{pages: map[string]int{}, limit: 20}
+
+	requested := map[string]bool{}
+	for _, value := range c.QueryArray("status") {
+		for _, status := range strings.Split(value, ",") {
+			if status = strings.TrimSpace(status); status != "" {
+				requested[status] = true
+			}
+		}
+	}
+	for status := range requested {
+		if !isOrderBoardStatus(status) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+			return query, false
+		}
+	}
+	for _, status := range models.OrderBoardStatuses {
+		if len(requested) == 0 || requested[status] {
+			query.statuses = append(query.statuses, status)
+		}
+	}
+
+	for status, value := range c.QueryMap("page") {
+		page, err := strconv.Atoi(value)
+		if !isOrderBoardStatus(status) || err != nil || page < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page"})
+			return query, false
+		}
+		query.pages[status] = page
+	}
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+			return query, false
+		}
+		query.limit = limit
+	}
+	return query, true
+}
+
+func isOrderBoardStatus(status string) bool {
+	for _, s := range models.OrderBoardStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// GetOrderBoard returns orders grouped by status for the production board, with each
+// column's count and work in progress limit
+func (h *AdminHandler) GetOrderBoard(c *gin.Context) {
+	query, ok := parseOrderBoardQuery(c)
+	if !ok {
+		return
+	}
+
+	board, err := h.orderQueries.GetOrderBoard(query.statuses, query.pages, query.limit)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get order board"})
+		return
+	}
+
+	c.JSON(http.StatusOK, board)
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseOrderBoardQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/admin/orders/board?status=shipped,pending&status=processing&page[processing]=3&limit=5", nil)
+	query, ok := parseOrderBoardQuery(c)
+	if !ok {
+		t.Fatalf("expected a valid query, got %s", w.Body.String())
+	}
+	if len(query.statuses) != 3 || query.statuses[0] != "pending" || query.statuses[1] != "processing" || query.statuses[2] != "shipped" {
+		t.Fatalf("expected the requested columns in workflow order, got %v", query.statuses)
+	}
+	if query.pages["processing"] != 3 || query.limit != 5 {
+		t.Fatalf("unexpected pages %v and limit %d", query.pages, query.limit)
+	}
+
+	c, _ = gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/admin/orders/board", nil)
+	if query, ok = parseOrderBoardQuery(c); !ok || len(query.statuses) != 5 || query.limit != 20 {
+		t.Fatalf("expected every column with 20 orders by default, got %+v", query)
+	}
+}
+
+func TestParseOrderBoardQueryInvalid(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for _, query := range []string{"status=lost", "page[lost]=2", "page[pending]=0", "page[pending]=x", "limit=0", "limit=101"} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/admin/orders/board?"+query, nil)
+		if _, ok := parseOrderBoardQuery(c); ok || w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected a bad request, got %d", query, w.Code)
+		}
+	}
+}
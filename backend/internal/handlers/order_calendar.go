@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseCalendarMonth returns the start of the month given as YYYY-MM in loc, or of the
+// month of now when value is empty
+func parseCalendarMonth(value string, now time.Time, loc *time.Location) (time.Time, error) {
+	if value == "" {
+		now = now.In(loc)
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc), nil
+	}
+	return time.ParseInLocation("2006-01", value, loc)
+}
+
+// GetOrderCalendar returns the orders placed and the orders promised to ship on each
+// day of ?month=YYYY-MM (default: this month), in the shop's time zone
+func (h *AdminHandler) GetOrderCalendar(c *gin.Context) {
+	monthStart, err := parseCalendarMonth(c.Query("month"), time.Now(), h.settingsQueries.GetShopLocation())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "month must be formatted as YYYY-MM"})
+		return
+	}
+
+	calendar, err := h.orderQueries.GetOrderCalendar(monthStart)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get order calendar"})
+		return
+	}
+
+	c.JSON(http.StatusOK, calendar)
+}
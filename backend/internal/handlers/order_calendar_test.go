@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCalendarMonth(t *testing.T) {
+	warsaw, err := time.LoadLocation("Europe/Warsaw")
+	if err != nil {
+		t.Skip("time zone data not available")
+	}
+	// Already March in Warsaw, still February in UTC
+	now := time.Date(2024, 2, 29, 23, 30, 0, 0, time.UTC)
+
+	month, err := parseCalendarMonth("", now, warsaw)
+	if err != nil || !month.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, warsaw)) {
+		t.Fatalf("parseCalendarMonth(\"\") = %v, %v, want March 2024 in Warsaw", month, err)
+	}
+
+	month, err = parseCalendarMonth("2024-07", now, warsaw)
+	if err != nil || !month.Equal(time.Date(2024, 7, 1, 0, 0, 0, 0, warsaw)) {
+		t.Fatalf("parseCalendarMonth(2024-07) = %v, %v", month, err)
+	}
+
+	for _, value := range []string{"2024-13", "07-2024", "2024-07-01"} {
+		if _, err := parseCalendarMonth(value, now, warsaw); err == nil {
+			t.Errorf("parseCalendarMonth(%q) should fail", value)
+		}
+	}
+}
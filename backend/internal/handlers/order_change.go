@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/models"
+)
+
+type OrderChangeHandler struct {
+	changeQueries *database.OrderChangeQueries
+	orderQueries  *database.OrderQueries
+}
+
+func NewOrderChangeHandler(changeQueries *database.OrderChangeQueries, orderQueries *database.OrderQueries) *OrderChangeHandler {
+	return &OrderChangeHandler{
+		changeQueries: changeQueries,
+		orderQueries:  orderQueries,
+	}
+}
+
+// CreateChangeRequest lets a customer request a change to a pending order
+func (h *OrderChangeHandler) CreateChangeRequest(c *gin.Context) {
+	hash := c.Param("hash")
+	if hash == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Hash is required"})
+		return
+	}
+
+	var req models.OrderChangeRequestInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	order, err := h.orderQueries.GetOrderByHash(hash)
+	if err != nil {
+		if err.Error() == "order not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get order"})
+		return
+	}
+
+	if order.Status != models.OrderStatusPending {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only pending orders can be changed"})
+		return
+	}
+
+	var payload interface{}
+	switch req.Type {
+	case models.OrderChangeTypeSizeSwap:
+		if req.SizeSwap == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "size_swap payload is required"})
+			return
+		}
+
+		found := false
+		for _, item := range order.Items {
+			if item.ID == req.SizeSwap.OrderItemID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Order item not found in order"})
+			return
+		}
+		payload = req.SizeSwap
+	case models.OrderChangeTypeAddressChange:
+		if req.AddressChange == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "address_change payload is required"})
+			return
+		}
+		payload = req.AddressChange
+	}
+
+	request, err := h.changeQueries.CreateChangeRequest(order.ID, req.Type, payload, req.Note)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create change request"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, request)
+}
+
+// GetOrderChangeRequests lists change requests for an order accessed by public hash
+func (h *OrderChangeHandler) GetOrderChangeRequests(c *gin.Context) {
+	order, err := h.orderQueries.GetOrderByHash(c.Param("hash"))
+	if err != nil {
+		if err.Error() == "order not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get order"})
+		return
+	}
+
+	requests, err := h.changeQueries.GetChangeRequestsByOrderID(order.ID)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get change requests"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"requests": requests})
+}
+
+// ListChangeRequests returns the admin approval queue
+func (h *OrderChangeHandler) ListChangeRequests(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	status := c.DefaultQuery("status", models.OrderChangeStatusPending)
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	if status == "all" {
+		status = ""
+	}
+
+	requests, err := h.changeQueries.ListChangeRequests(page, limit, status)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get change requests"})
+		return
+	}
+
+	c.JSON(http.StatusOK, requests)
+}
+
+// ApproveChangeRequest applies a pending change request to its order
+func (h *OrderChangeHandler) ApproveChangeRequest(c *gin.Context) {
+	request, ok := h.getPendingRequest(c)
+	if !ok {
+		return
+	}
+
+	var review models.OrderChangeReviewRequest
+	if err := c.ShouldBindJSON(&review); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.changeQueries.ApproveChangeRequest(request.ID, review.Note, getUserIDPtr(c)); err != nil {
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "already reviewed"):
+			c.JSON(http.StatusConflict, gin.H{"error": "Change request already reviewed"})
+		case msg == "order is not pending":
+			c.JSON(http.StatusConflict, gin.H{"error": "Only pending orders can be changed"})
+		case msg == "unsupported change request type":
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported change request type"})
+		case strings.HasPrefix(msg, "invalid change request payload"):
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid change request payload"})
+		case strings.Contains(msg, "not found") || strings.Contains(msg, "insufficient stock"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to apply change: " + msg})
+		default:
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply change"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Change request approved and applied"})
+}
+
+// RejectChangeRequest rejects a pending change request without modifying the order
+func (h *OrderChangeHandler) RejectChangeRequest(c *gin.Context) {
+	request, ok := h.getPendingRequest(c)
+	if !ok {
+		return
+	}
+
+	var review models.OrderChangeReviewRequest
+	if err := c.ShouldBindJSON(&review); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.changeQueries.ReviewChangeRequest(request.ID, models.OrderChangeStatusRejected, review.Note, getUserIDPtr(c)); err != nil {
+		if strings.Contains(err.Error(), "already reviewed") {
+			c.JSON(http.StatusConflict, gin.H{"error": "Change request already reviewed"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update change request"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Change request rejected"})
+}
+
+// getPendingRequest loads the change request from the URL and ensures it is still pending
+func (h *OrderChangeHandler) getPendingRequest(c *gin.Context) (*models.OrderChangeRequest, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid change request ID"})
+		return nil, false
+	}
+
+	request, err := h.changeQueries.GetChangeRequestByID(id)
+	if err != nil {
+		if err.Error() == "change request not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Change request not found"})
+			return nil, false
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get change request"})
+		return nil, false
+	}
+
+	if request.Status != models.OrderChangeStatusPending {
+		c.JSON(http.StatusConflict, gin.H{"error": "Change request already reviewed"})
+		return nil, false
+	}
+
+	return request, true
+}
+
+// getUserIDPtr returns the authenticated user ID from the context, if any
+func getUserIDPtr(c *gin.Context) *int {
+	if userIDValue, exists := c.Get("user_id"); exists {
+		if id, ok := userIDValue.(int); ok {
+			return &id
+		}
+	}
+	return nil
+}
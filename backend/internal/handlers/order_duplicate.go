@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"notsofluffy-backend/internal/middleware"
+	"notsofluffy-backend/internal/models"
+)
+
+// ListDuplicateOrders returns the suspected duplicate orders of the last ?days= days
+// (default 30): later orders from the same email with a similar total, placed shortly
+// after another one, usually from submitting checkout twice
+func (h *AdminHandler) ListDuplicateOrders(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days < 1 || days > 365 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days must be a number from 1 to 365"})
+		return
+	}
+
+	rule, err := h.settingsQueries.GetDuplicateOrderRule()
+	if err != nil {
+		middleware.Logger(c.Request.Context()).Warn("failed to read duplicate order settings, using defaults", "error", err)
+	}
+
+	duplicates, err := h.orderQueries.GetDuplicateOrders(time.Now().AddDate(0, 0, -days), rule)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get duplicate orders"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.DuplicateOrderReport{Days: days, Rule: rule, Duplicates: duplicates})
+}
+
+// flagDuplicateOrders marks the suspected duplicates in a page of the admin order list;
+// the flag is a hint, so the list is served without it when the check fails
+func (h *AdminHandler) flagDuplicateOrders(ctx context.Context, orders []models.OrderResponse) {
+	rule, err := h.settingsQueries.GetDuplicateOrderRule()
+	if err != nil {
+		middleware.Logger(ctx).Warn("failed to read duplicate order settings, using defaults", "error", err)
+	}
+
+	ids := make([]int, len(orders))
+	for i, order := range orders {
+		ids[i] = order.ID
+	}
+	duplicateOf, err := h.orderQueries.GetDuplicateOf(ids, rule)
+	if err != nil {
+		middleware.Logger(ctx).Error("failed to flag duplicate orders", "error", err)
+		return
+	}
+	for i := range orders {
+		if originalID, ok := duplicateOf[orders[i].ID]; ok {
+			orders[i].DuplicateOfOrderID = &originalID
+		}
+	}
+}
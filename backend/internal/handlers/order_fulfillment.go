@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/labels"
+	"notsofluffy-backend/internal/models"
+)
+
+// OrderFulfillmentHandler tracks the picking and packing of orders item by item, so a
+// large order can be processed over several sessions
+type OrderFulfillmentHandler struct {
+	orderQueries    *database.OrderQueries
+	settingsQueries *database.SettingsQueries
+}
+
+func NewOrderFulfillmentHandler(orderQueries *database.OrderQueries, settingsQueries *database.SettingsQueries) *OrderFulfillmentHandler {
+	return &OrderFulfillmentHandler{orderQueries: orderQueries, settingsQueries: settingsQueries}
+}
+
+// GetOrderFulfillment returns the fulfillment status of each item of an order
+func (h *OrderFulfillmentHandler) GetOrderFulfillment(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	fulfillment, err := h.orderQueries.GetOrderFulfillment(id)
+	if err != nil {
+		if err.Error() == "order not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get order fulfillment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, fulfillment)
+}
+
+// UpdateOrderFulfillment sets the fulfillment status of several items of an order
+func (h *OrderFulfillmentHandler) UpdateOrderFulfillment(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	var req models.OrderItemFulfillmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.update(c, id, req.ItemIDs, req.Status)
+}
+
+// ScanOrderItem updates the order item of a code scanned from a packing slip. Without
+// a status in the payload the item moves on to its next status.
+func (h *OrderFulfillmentHandler) ScanOrderItem(c *gin.Context) {
+	var req models.FulfillmentScanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	orderID, itemID, ok := models.ParseOrderItemCode(req.Code)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order item code"})
+		return
+	}
+
+	status := req.Status
+	if status == "" {
+		fulfillment, err := h.orderQueries.GetOrderFulfillment(orderID)
+		if err != nil {
+			if err.Error() == "order not found" {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Order item not found"})
+				return
+			}
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get order fulfillment"})
+			return
+		}
+		current := ""
+		for _, item := range fulfillment.Items {
+			if item.ItemID == itemID {
+				current = item.Status
+			}
+		}
+		if current == "" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order item not found"})
+			return
+		}
+		if status = nextItemFulfillmentStatus(current); status == "" {
+			c.JSON(http.StatusConflict, gin.H{"error": "Order item is already shipped", "fulfillment": fulfillment})
+			return
+		}
+	}
+
+	h.update(c, orderID, []int{itemID}, status)
+}
+
+// update sets the fulfillment status of items of an order and responds with the order's
+// fulfillment
+func (h *OrderFulfillmentHandler) update(c *gin.Context, orderID int, itemIDs []int, status string) {
+	fulfillment, err := h.orderQueries.UpdateOrderItemsFulfillment(orderID, itemIDs, status)
+	if err != nil {
+		switch err.Error() {
+		case "order not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		case "order item not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order item not found"})
+		case "order is cancelled":
+			c.JSON(http.StatusConflict, gin.H{"error": "order is cancelled"})
+		default:
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update order fulfillment"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, fulfillment)
+}
+
+// PrintPackingSlip returns a PDF packing slip of an order listing its items with their
+// codes for scanning and ticking off the steps they have been through
+func (h *OrderFulfillmentHandler) PrintPackingSlip(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	fulfillment, err := h.orderQueries.GetOrderFulfillment(id)
+	if err != nil {
+		if err.Error() == "order not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get order fulfillment"})
+		return
+	}
+
+	addresses, err := h.orderQueries.GetShippingAddresses([]int{id})
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get shipping addresses"})
+		return
+	}
+
+	slip := labels.PackingSlip{Reference: fmt.Sprintf("Order #%d", id)}
+	if len(addresses) > 0 {
+		slip.Recipient = shippingLabel(addresses[0]).Recipient
+	}
+	for _, item := range fulfillment.Items {
+		slip.Items = append(slip.Items, labels.PackingSlipItem{
+			Code:        item.Code,
+			Description: packingSlipDescription(item),
+			Quantity:    item.Quantity,
+			Status:      item.Status,
+		})
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=packing-slip-order-%d.pdf", id))
+	c.Data(http.StatusOK, "application/pdf", labels.RenderPackingSlip(slip, labelSender(c.Request.Context(), h.settingsQueries)))
+}
+
+// nextItemFulfillmentStatus returns the status following status, or "" after the last
+func nextItemFulfillmentStatus(status string) string {
+	for i, s := range models.ItemFulfillmentStatuses {
+		if s == status && i+1 < len(models.ItemFulfillmentStatuses) {
+			return models.ItemFulfillmentStatuses[i+1]
+		}
+	}
+	return ""
+}
+
+// packingSlipDescription names an item as "Product, Variant, Size"
+func packingSlipDescription(item models.OrderItemFulfillment) string {
+	parts := []string{}
+	for _, part := range []string{item.ProductName, item.VariantName, item.SizeName} {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
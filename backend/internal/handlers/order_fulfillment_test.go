@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"testing"
+
+	"notsofluffy-backend/internal/models"
+)
+
+func TestNextItemFulfillmentStatus(t *testing.T) {
+	cases := map[string]string{
+		models.ItemFulfillmentPending: models.ItemFulfillmentPicked,
+		models.ItemFulfillmentPicked:  models.ItemFulfillmentPacked,
+		models.ItemFulfillmentPacked:  models.ItemFulfillmentShipped,
+		models.ItemFulfillmentShipped: "",
+		"lost":                        "",
+	}
+	for status, want := range cases {
+		if got := nextItemFulfillmentStatus(status); got != want {
+			t.Errorf("nextItemFulfillmentStatus(%q) = %q, want %q", status, got, want)
+		}
+	}
+}
+
+func TestParseOrderItemCode(t *testing.T) {
+	code := models.OrderItemCode(17, 42)
+	if orderID, itemID, ok := models.ParseOrderItemCode(code); !ok || orderID != 17 || itemID != 42 {
+		t.Fatalf("ParseOrderItemCode(%q) = %d, %d, %v", code, orderID, itemID, ok)
+	}
+	// Scanners in lower case mode or appending a newline
+	if orderID, itemID, ok := models.ParseOrderItemCode(" oi-17-42\n"); !ok || orderID != 17 || itemID != 42 {
+		t.Fatalf("ParseOrderItemCode should accept lower case codes, got %d, %d, %v", orderID, itemID, ok)
+	}
+	for _, code := range []string{"", "17-42", "OI-17", "OI-17-42-1", "OI-x-42", "OI-0-42", "XX-17-42"} {
+		if _, _, ok := models.ParseOrderItemCode(code); ok {
+			t.Errorf("ParseOrderItemCode(%q) should fail", code)
+		}
+	}
+}
+
+func TestPackingSlipDescription(t *testing.T) {
+	item := models.OrderItemFulfillment{ProductName: "Bluza", VariantName: "Różowa", SizeName: " "}
+	if got := packingSlipDescription(item); got != "Bluza, Różowa" {
+		t.Fatalf("packingSlipDescription = %q", got)
+	}
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"notsofluffy-backend/internal/models"
+)
+
+// parseOrderHistoryFilter reads the order history filter from the query, with dates in
+// the shop's time zone, and responds with an error when it is invalid
+func parseOrderHistoryFilter(c *gin.Context, loc *time.Location) (models.OrderHistoryFilter, bool) {
+	filter := models.OrderHistoryFilter{
+		Status: c.Query("status"),
+		Search: strings.TrimSpace(c.Query("search")),
+	}
+	switch filter.Status {
+	case "", models.OrderStatusPending, models.OrderStatusProcessing, models.OrderStatusShipped,
+		models.OrderStatusDelivered, models.OrderStatusCancelled:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+		return filter, false
+	}
+
+	if value := c.Query("from"); value != "" {
+		from, err := time.ParseInLocation("2006-01-02", value, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date, expected YYYY-MM-DD"})
+			return filter, false
+		}
+		filter.From = &from
+	}
+	if value := c.Query("to"); value != "" {
+		to, err := time.ParseInLocation("2006-01-02", value, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date, expected YYYY-MM-DD"})
+			return filter, false
+		}
+		// Include the whole end day
+		to = to.AddDate(0, 0, 1)
+		filter.To = &to
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be before to"})
+		return filter, false
+	}
+	return filter, true
+}
+
+// getUserOrderSummaries responds with the compact order history of a user
+func (h *OrderHandler) getUserOrderSummaries(c *gin.Context, userID int, filter models.OrderHistoryFilter, page, limit int) {
+	orders, err := h.orderQueries.GetOrderSummariesByUserID(userID, filter, page, limit)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get orders"})
+		return
+	}
+
+	for i := range orders.Orders {
+		order := &orders.Orders[i]
+		format := orderPriceFormat(c, order.Currency, order.ExchangeRate)
+		order.Formatted = models.FormattedPrices{"total_amount": format.Format(order.TotalAmount)}
+	}
+
+	c.JSON(http.StatusOK, orders)
+}
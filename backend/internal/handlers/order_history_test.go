@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseOrderHistoryFilter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	warsaw, err := time.LoadLocation("Europe/Warsaw")
+	if err != nil {
+		t.Skip("time zone data not available")
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/user/orders?status=shipped&from=2024-03-01&to=2024-03-31&search=+scarf+", nil)
+	filter, ok := parseOrderHistoryFilter(c, warsaw)
+	if !ok {
+		t.Fatalf("expected a valid filter, got %s", w.Body.String())
+	}
+	if filter.Status != "shipped" || filter.Search != "scarf" {
+		t.Fatalf("unexpected filter %+v", filter)
+	}
+	if !filter.From.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, warsaw)) || !filter.To.Equal(time.Date(2024, 4, 1, 0, 0, 0, 0, warsaw)) {
+		t.Fatalf("expected March in Warsaw with the end day included, got %v to %v", filter.From, filter.To)
+	}
+}
+
+func TestParseOrderHistoryFilterInvalid(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for _, query := range []string{"status=lost", "from=01-03-2024", "to=tomorrow", "from=2024-03-02&to=2024-03-01"} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/user/orders?"+query, nil)
+		if _, ok := parseOrderHistoryFilter(c, time.UTC); ok || w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, w.Code)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/labels"
+	"notsofluffy-backend/internal/middleware"
+	"notsofluffy-backend/internal/models"
+)
+
+// maxLabelsPerRequest bounds one printout; the day's shipments fit in a few sheets
+const maxLabelsPerRequest = 200
+
+type OrderLabelHandler struct {
+	orderQueries    *database.OrderQueries
+	settingsQueries *database.SettingsQueries
+}
+
+func NewOrderLabelHandler(orderQueries *database.OrderQueries, settingsQueries *database.SettingsQueries) *OrderLabelHandler {
+	return &OrderLabelHandler{orderQueries: orderQueries, settingsQueries: settingsQueries}
+}
+
+// PrintShippingLabels returns a PDF of address labels for the orders in ids (comma
+// separated), or for the orders in status (processing by default) when ids is omitted.
+// These are plain address labels; the carrier's label of a shipment ordered through the
+// shipping provider is served by ShipmentHandler.GetShipmentLabel.
+func (h *OrderLabelHandler) PrintShippingLabels(c *gin.Context) {
+	var addresses []models.ShippingAddress
+	var err error
+
+	if idsParam := c.Query("ids"); idsParam != "" {
+		orderIDs, ok := parseOrderIDs(idsParam)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order IDs"})
+			return
+		}
+		if len(orderIDs) > maxLabelsPerRequest {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d orders can be printed at once", maxLabelsPerRequest)})
+			return
+		}
+
+		addresses, err = h.orderQueries.GetShippingAddresses(orderIDs)
+		if err != nil {
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get shipping addresses"})
+			return
+		}
+
+		// A missing label would mean a parcel shipped without one
+		if missing := missingOrderIDs(orderIDs, addresses); len(missing) > 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Some orders have no shipping address", "missing_order_ids": missing})
+			return
+		}
+	} else {
+		status := c.DefaultQuery("status", models.OrderStatusProcessing)
+		switch status {
+		case models.OrderStatusPending, models.OrderStatusProcessing, models.OrderStatusShipped,
+			models.OrderStatusDelivered, models.OrderStatusCancelled:
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+			return
+		}
+
+		addresses, err = h.orderQueries.GetShippingAddressesByStatus(status, maxLabelsPerRequest)
+		if err != nil {
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get shipping addresses"})
+			return
+		}
+		if len(addresses) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No orders to print labels for"})
+			return
+		}
+	}
+
+	items := make([]labels.Label, len(addresses))
+	for i, addr := range addresses {
+		items[i] = shippingLabel(addr)
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=shipping-labels-%s.pdf", time.Now().Format("2006-01-02")))
+	c.Data(http.StatusOK, "application/pdf", labels.Render(items, labelSender(c.Request.Context(), h.settingsQueries)))
+}
+
+// labelSender returns the sender address lines printed on labels
+func labelSender(ctx context.Context, settingsQueries *database.SettingsQueries) []string {
+	setting, err := settingsQueries.GetSettingByKey(models.SettingLabelSenderAddress)
+	if err != nil {
+		middleware.Logger(ctx).Warn("failed to read setting, printing labels without sender", "setting", models.SettingLabelSenderAddress, "error", err)
+		return nil
+	}
+	if setting == nil {
+		return nil
+	}
+	return strings.Split(setting.Value, "\n")
+}
+
+func shippingLabel(addr models.ShippingAddress) labels.Label {
+	recipient := labels.Address{
+		Name:       strings.TrimSpace(addr.FirstName + " " + addr.LastName),
+		Lines:      []string{addr.AddressLine1},
+		PostalCode: addr.PostalCode,
+		City:       addr.City,
+		Country:    addr.Country,
+		Phone:      addr.Phone,
+	}
+	if addr.Company != nil {
+		recipient.Company = *addr.Company
+	}
+	if addr.AddressLine2 != nil {
+		recipient.Lines = append(recipient.Lines, *addr.AddressLine2)
+	}
+	if addr.StateProvince != "" {
+		recipient.Lines = append(recipient.Lines, addr.StateProvince)
+	}
+
+	return labels.Label{Reference: fmt.Sprintf("Order #%d", addr.OrderID), Recipient: recipient}
+}
+
+// parseOrderIDs parses a comma separated list of order IDs, dropping duplicates
+func parseOrderIDs(param string) ([]int, bool) {
+	seen := map[int]bool{}
+	ids := []int{}
+	for _, part := range strings.Split(param, ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || id < 1 {
+			return nil, false
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids, true
+}
+
+func missingOrderIDs(orderIDs []int, addresses []models.ShippingAddress) []int {
+	found := map[int]bool{}
+	for _, addr := range addresses {
+		found[addr.OrderID] = true
+	}
+	missing := []int{}
+	for _, id := range orderIDs {
+		if !found[id] {
+			missing = append(missing, id)
+		}
+	}
+	sort.Ints(missing)
+	return missing
+}
@@ -0,0 +1,458 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/geoip"
+	"notsofluffy-backend/internal/jobs"
+	"notsofluffy-backend/internal/labels"
+	"notsofluffy-backend/internal/mail"
+	"notsofluffy-backend/internal/middleware"
+	"notsofluffy-backend/internal/models"
+	"notsofluffy-backend/internal/shipping"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OrderReturnHandler lets customers request returns of shipped orders and staff review
+// them. Approved returns get a return shipment ordered from the carrier, or without a
+// carrier or return settings a PDF with return instructions; the tracker emails the
+// label and follows the parcel back.
+type OrderReturnHandler struct {
+	returnQueries   *database.OrderReturnQueries
+	orderQueries    *database.OrderQueries
+	settingsQueries *database.SettingsQueries
+	provider        shipping.Provider
+	tracker         *jobs.ReturnTracker
+}
+
+func NewOrderReturnHandler(returnQueries *database.OrderReturnQueries, orderQueries *database.OrderQueries, settingsQueries *database.SettingsQueries, provider shipping.Provider, tracker *jobs.ReturnTracker) *OrderReturnHandler {
+	return &OrderReturnHandler{
+		returnQueries:   returnQueries,
+		orderQueries:    orderQueries,
+		settingsQueries: settingsQueries,
+		provider:        provider,
+		tracker:         tracker,
+	}
+}
+
+// CreateReturn lets a customer request the return of items of a shipped order
+func (h *OrderReturnHandler) CreateReturn(c *gin.Context) {
+	var req models.OrderReturnRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	order, ok := h.orderByHash(c)
+	if !ok {
+		return
+	}
+	if order.Status != models.OrderStatusShipped && order.Status != models.OrderStatusDelivered {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only shipped orders can be returned"})
+		return
+	}
+
+	ret, err := h.returnQueries.CreateReturn(order.ID, &req)
+	if err != nil {
+		msg := err.Error()
+		switch {
+		case msg == "order is not shipped":
+			c.JSON(http.StatusConflict, gin.H{"error": "Only shipped orders can be returned"})
+		case strings.HasSuffix(msg, "not found in order"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Order item not found in order"})
+		case strings.HasPrefix(msg, "return quantity exceeds"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Return quantity exceeds the ordered quantity"})
+		default:
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create return"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, ret)
+}
+
+// GetOrderReturns lists the returns of an order accessed by public hash
+func (h *OrderReturnHandler) GetOrderReturns(c *gin.Context) {
+	order, ok := h.orderByHash(c)
+	if !ok {
+		return
+	}
+
+	returns, err := h.returnQueries.GetReturnsByOrderID(order.ID)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get returns"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"returns": returns})
+}
+
+// GetOrderReturnLabel returns the label PDF of a return of an order accessed by
+// public hash
+func (h *OrderReturnHandler) GetOrderReturnLabel(c *gin.Context) {
+	order, ok := h.orderByHash(c)
+	if !ok {
+		return
+	}
+	ret, ok := h.getReturn(c, c.Param("returnId"))
+	if !ok {
+		return
+	}
+	if ret.OrderID != order.ID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Return not found"})
+		return
+	}
+	h.serveLabel(c, ret)
+}
+
+// ListReturns returns the admin queue of returns, requested ones by default
+func (h *OrderReturnHandler) ListReturns(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	status := c.DefaultQuery("status", models.OrderReturnStatusRequested)
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	switch status {
+	case "all":
+		status = ""
+	case models.OrderReturnStatusRequested, models.OrderReturnStatusApproved, models.OrderReturnStatusRejected,
+		models.OrderReturnStatusInTransit, models.OrderReturnStatusReceived:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+		return
+	}
+
+	returns, err := h.returnQueries.ListReturns(page, limit, status)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get returns"})
+		return
+	}
+
+	c.JSON(http.StatusOK, returns)
+}
+
+// GetReturn returns a return
+func (h *OrderReturnHandler) GetReturn(c *gin.Context) {
+	ret, ok := h.getReturn(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, ret)
+}
+
+// GetReturnLabel returns the label PDF of a return
+func (h *OrderReturnHandler) GetReturnLabel(c *gin.Context) {
+	ret, ok := h.getReturn(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	h.serveLabel(c, ret)
+}
+
+// ApproveReturn approves a requested return and creates its label. When the carrier
+// shipment can't be created the customer gets the return instructions, and warnings
+// says why.
+func (h *OrderReturnHandler) ApproveReturn(c *gin.Context) {
+	ret, ok := h.getRequestedReturn(c)
+	if !ok {
+		return
+	}
+
+	var req models.OrderReturnApproveRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.ParcelTemplate == "" {
+		req.ParcelTemplate = "small"
+	}
+
+	ret, err := h.returnQueries.ReviewReturn(ret.ID, models.OrderReturnStatusApproved, req.Note, getUserIDPtr(c))
+	if err != nil {
+		if strings.Contains(err.Error(), "already reviewed") {
+			c.JSON(http.StatusConflict, gin.H{"error": "Return already reviewed"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update return"})
+		return
+	}
+
+	warnings := []string{}
+	labelType := models.ReturnLabelInstructions
+	var provider, providerShipmentID, carrierStatus *string
+	shipment, warning := h.createReturnShipment(c, ret, req.ParcelTemplate)
+	if warning != "" {
+		warnings = append(warnings, warning)
+	}
+	if shipment != nil {
+		name := h.provider.Name()
+		labelType, provider, providerShipmentID, carrierStatus = models.ReturnLabelCarrier, &name, &shipment.ProviderShipmentID, &shipment.Status
+	}
+
+	ret, err = h.returnQueries.SetReturnLabel(ret.ID, labelType, provider, providerShipmentID, carrierStatus)
+	if err != nil {
+		// A carrier shipment exists, so staff must be able to find it there
+		if providerShipmentID != nil {
+			middleware.Logger(c.Request.Context()).Error("failed to save return label", "provider", *provider, "shipment", *providerShipmentID, "error", err)
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save return label", "provider_shipment_id": providerShipmentID})
+		return
+	}
+
+	// The label is emailed once it's ready, which for carrier labels is usually on a
+	// later tracking run
+	if tracked, err := h.tracker.Track(c.Request.Context(), ret); err != nil {
+		middleware.Logger(c.Request.Context()).Error("failed to track return", "return_id", ret.ID, "error", err)
+	} else {
+		ret = tracked
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Return approved", "return": ret, "warnings": warnings})
+}
+
+// RejectReturn rejects a requested return and tells the customer
+func (h *OrderReturnHandler) RejectReturn(c *gin.Context) {
+	ret, ok := h.getRequestedReturn(c)
+	if !ok {
+		return
+	}
+
+	var req models.OrderReturnRejectRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ret, err := h.returnQueries.ReviewReturn(ret.ID, models.OrderReturnStatusRejected, req.Note, getUserIDPtr(c))
+	if err != nil {
+		if strings.Contains(err.Error(), "already reviewed") {
+			c.JSON(http.StatusConflict, gin.H{"error": "Return already reviewed"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update return"})
+		return
+	}
+
+	if err := h.tracker.Notify(c.Request.Context(), mail.TemplateReturnRejected, ret); err != nil {
+		middleware.Logger(c.Request.Context()).Error("failed to queue return email", "template", mail.TemplateReturnRejected, "return_id", ret.ID, "error", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Return rejected", "return": ret})
+}
+
+// ReceiveReturn marks an approved return received by hand, e.g. one sent with the
+// return instructions, and tells the customer
+func (h *OrderReturnHandler) ReceiveReturn(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid return ID"})
+		return
+	}
+
+	ret, err := h.returnQueries.ReceiveReturn(id)
+	if err != nil {
+		if err.Error() == "return not found or not approved" {
+			c.JSON(http.StatusConflict, gin.H{"error": "Only approved returns can be received"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update return"})
+		return
+	}
+
+	if err := h.tracker.Notify(c.Request.Context(), mail.TemplateReturnReceived, ret); err != nil {
+		middleware.Logger(c.Request.Context()).Error("failed to queue return email", "template", mail.TemplateReturnReceived, "return_id", ret.ID, "error", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Return received", "return": ret})
+}
+
+// createReturnShipment orders a return shipment from the customer's shipping address
+// to the shop's return parcel locker, which the customer drops off at any point. It
+// returns nil when the return is sent with instructions instead, with a warning when
+// that is not because no carrier or return locker is configured.
+func (h *OrderReturnHandler) createReturnShipment(c *gin.Context, ret *models.OrderReturn, parcelTemplate string) (*shipping.Shipment, string) {
+	if h.provider == nil {
+		return nil, ""
+	}
+	receiver, err := h.settingsQueries.GetReturnReceiver()
+	if err != nil {
+		middleware.Logger(c.Request.Context()).Error("failed to read return settings", "error", err)
+		return nil, "Failed to read the return settings, the customer gets return instructions instead"
+	}
+	if receiver.TargetPoint == "" {
+		return nil, ""
+	}
+	if !receiver.Complete() {
+		return nil, "The return receiver settings are incomplete, the customer gets return instructions instead"
+	}
+
+	order, err := h.orderQueries.GetOrderByID(ret.OrderID)
+	if err != nil {
+		middleware.Logger(c.Request.Context()).Error("failed to get order of return", "return_id", ret.ID, "error", err)
+		return nil, "Failed to get the order, the customer gets return instructions instead"
+	}
+	addresses, err := h.orderQueries.GetShippingAddresses([]int{ret.OrderID})
+	if err != nil {
+		middleware.Logger(c.Request.Context()).Error("failed to get shipping address of return", "return_id", ret.ID, "error", err)
+		return nil, "Failed to get the shipping address, the customer gets return instructions instead"
+	}
+	if len(addresses) == 0 {
+		return nil, "The order has no shipping address, the customer gets return instructions instead"
+	}
+	addr := addresses[0]
+	country := geoip.CountryCode(addr.Country)
+	if country == "" {
+		return nil, "The shipping country is not supported, the customer gets return instructions instead"
+	}
+
+	sender := shipping.Address{
+		Name:       strings.TrimSpace(addr.FirstName + " " + addr.LastName),
+		Line1:      addr.AddressLine1,
+		PostalCode: addr.PostalCode,
+		City:       addr.City,
+		Country:    country,
+		Phone:      addr.Phone,
+		Email:      order.Email,
+	}
+	if addr.Company != nil {
+		sender.Company = *addr.Company
+	}
+	if addr.AddressLine2 != nil {
+		sender.Line2 = *addr.AddressLine2
+	}
+
+	shipment, err := h.provider.CreateShipment(c.Request.Context(), shipping.ShipmentParams{
+		OrderID:     ret.OrderID,
+		Reference:   returnReference(ret),
+		Service:     shipping.ServiceLocker,
+		TargetPoint: receiver.TargetPoint,
+		Receiver:    shipping.Address{Name: receiver.Name, Email: receiver.Email, Phone: receiver.Phone},
+		Sender:      &sender,
+		DropOff:     true,
+		Parcel:      shipping.Parcel{Template: parcelTemplate},
+		Currency:    models.PaymentCurrency,
+	})
+	if err != nil {
+		middleware.Logger(c.Request.Context()).Error("failed to create return shipment", "provider", h.provider.Name(), "return_id", ret.ID, "error", err)
+		return nil, "Failed to create the return shipment, the customer gets return instructions instead: " + err.Error()
+	}
+	return shipment, ""
+}
+
+// serveLabel returns the carrier's label of a return shipment or the return
+// instructions PDF
+func (h *OrderReturnHandler) serveLabel(c *gin.Context, ret *models.OrderReturn) {
+	if ret.LabelType == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Return has no label", "status": ret.Status})
+		return
+	}
+
+	var label []byte
+	switch *ret.LabelType {
+	case models.ReturnLabelCarrier:
+		if h.provider == nil || ret.Provider == nil || *ret.Provider != h.provider.Name() || ret.ProviderShipmentID == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "Shipment was created with another shipping provider"})
+			return
+		}
+		var err error
+		label, err = h.provider.Label(c.Request.Context(), *ret.ProviderShipmentID)
+		if err != nil {
+			if errors.Is(err, shipping.ErrLabelNotReady) {
+				c.JSON(http.StatusConflict, gin.H{"error": "Shipment label is not ready yet"})
+				return
+			}
+			middleware.Logger(c.Request.Context()).Error("failed to get return label", "provider", *ret.Provider, "shipment", *ret.ProviderShipmentID, "error", err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to get shipment label"})
+			return
+		}
+	default:
+		items := make([]labels.ReturnItem, len(ret.Items))
+		for i, item := range ret.Items {
+			items[i] = labels.ReturnItem{
+				Description: fmt.Sprintf("%s (%s, %s)", item.ProductName, item.VariantName, item.SizeName),
+				Quantity:    item.Quantity,
+			}
+		}
+		label = labels.RenderReturnInstructions(labels.ReturnInstructions{
+			Reference: returnReference(ret),
+			Address:   labelSender(c.Request.Context(), h.settingsQueries),
+			Items:     items,
+		})
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=return-%d.pdf", ret.ID))
+	c.Data(http.StatusOK, "application/pdf", label)
+}
+
+// orderByHash loads the order of the public hash in the path
+func (h *OrderReturnHandler) orderByHash(c *gin.Context) (*models.OrderResponse, bool) {
+	order, err := h.orderQueries.GetOrderByHash(c.Param("hash"))
+	if err != nil {
+		if err.Error() == "order not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+			return nil, false
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get order"})
+		return nil, false
+	}
+	return order, true
+}
+
+// getReturn loads the return of idParam
+func (h *OrderReturnHandler) getReturn(c *gin.Context, idParam string) (*models.OrderReturn, bool) {
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid return ID"})
+		return nil, false
+	}
+
+	ret, err := h.returnQueries.GetReturnByID(id)
+	if err != nil {
+		if err.Error() == "return not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Return not found"})
+			return nil, false
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get return"})
+		return nil, false
+	}
+	return ret, true
+}
+
+// getRequestedReturn loads the return in the path and ensures it awaits review
+func (h *OrderReturnHandler) getRequestedReturn(c *gin.Context) (*models.OrderReturn, bool) {
+	ret, ok := h.getReturn(c, c.Param("id"))
+	if !ok {
+		return nil, false
+	}
+	if ret.Status != models.OrderReturnStatusRequested {
+		c.JSON(http.StatusConflict, gin.H{"error": "Return already reviewed"})
+		return nil, false
+	}
+	return ret, true
+}
+
+// returnReference identifies a return on its label
+func returnReference(ret *models.OrderReturn) string {
+	return fmt.Sprintf("Return #%d, order #%d", ret.ID, ret.OrderID)
+}
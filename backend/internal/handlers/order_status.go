@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// orderStatusCode matches the codes of custom order statuses
+var orderStatusCode = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+type OrderStatusHandler struct {
+	orderStatusQueries *database.OrderStatusQueries
+}
+
+func NewOrderStatusHandler(orderStatusQueries *database.OrderStatusQueries) *OrderStatusHandler {
+	return &OrderStatusHandler{orderStatusQueries: orderStatusQueries}
+}
+
+// ListOrderStatuses returns the statuses orders can be set to: the core statuses and the
+// custom ones refining them
+func (h *OrderStatusHandler) ListOrderStatuses(c *gin.Context) {
+	statuses, err := h.orderStatusQueries.ListOrderStatuses()
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get order statuses"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.OrderStatusListResponse{CoreStatuses: models.CoreOrderStatuses, CustomStatuses: statuses})
+}
+
+// CreateOrderStatus creates a custom order status
+func (h *OrderStatusHandler) CreateOrderStatus(c *gin.Context) {
+	var req models.OrderCustomStatusRequest
+	if !bindOrderStatusRequest(c, &req) {
+		return
+	}
+
+	status, err := h.orderStatusQueries.CreateOrderStatus(&req)
+	if err != nil {
+		if err.Error() == "order status code already exists" {
+			c.JSON(http.StatusConflict, gin.H{"error": "Order status code already exists"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create order status"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, status)
+}
+
+// UpdateOrderStatus updates a custom order status. Changing its core status takes the
+// orders in another core status out of it.
+func (h *OrderStatusHandler) UpdateOrderStatus(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order status ID"})
+		return
+	}
+
+	var req models.OrderCustomStatusRequest
+	if !bindOrderStatusRequest(c, &req) {
+		return
+	}
+
+	status, err := h.orderStatusQueries.UpdateOrderStatus(id, &req)
+	if err != nil {
+		switch err.Error() {
+		case "order status not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order status not found"})
+		case "order status code already exists":
+			c.JSON(http.StatusConflict, gin.H{"error": "Order status code already exists"})
+		default:
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update order status"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// DeleteOrderStatus deletes a custom order status; orders in it keep its core status
+func (h *OrderStatusHandler) DeleteOrderStatus(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order status ID"})
+		return
+	}
+
+	if err := h.orderStatusQueries.DeleteOrderStatus(id); err != nil {
+		if err.Error() == "order status not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order status not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete order status"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Order status deleted successfully"})
+}
+
+// bindOrderStatusRequest binds a custom order status, answering 400 for codes that
+// aren't lowercase letters, digits and underscores or are taken by a core status
+func bindOrderStatusRequest(c *gin.Context, req *models.OrderCustomStatusRequest) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	if !orderStatusCode.MatchString(req.Code) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order status code"})
+		return false
+	}
+	if models.IsCoreOrderStatus(req.Code) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Order status code is a core status"})
+		return false
+	}
+	return true
+}
+
+// resolveOrderStatus returns the core status an order is set to by status, a core
+// status or the code of a custom one, and the ID of the custom status. It answers 400
+// for unknown statuses.
+func resolveOrderStatus(c *gin.Context, queries *database.OrderStatusQueries, status string) (string, *int, bool) {
+	if models.IsCoreOrderStatus(status) {
+		return status, nil, true
+	}
+	if !orderStatusCode.MatchString(status) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+		return "", nil, false
+	}
+
+	custom, err := queries.GetOrderStatusByCode(status)
+	if err != nil {
+		if err.Error() == "order status not found" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+			return "", nil, false
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update order status"})
+		return "", nil, false
+	}
+	return custom.CoreStatus, &custom.ID, true
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"notsofluffy-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBindOrderStatusRequestRejectsInvalidCodes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for _, code := range []string{"Awaiting fabric", "1st_check", "processing"} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		body := `{"code":"` + code + `","label":"x","core_status":"processing"}`
+		c.Request = httptest.NewRequest(http.MethodPost, "/api/admin/order-statuses", bytes.NewBufferString(body))
+		var req models.OrderCustomStatusRequest
+		if bindOrderStatusRequest(c, &req) || w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected a bad request, got %d", code, w.Code)
+		}
+	}
+}
+
+func TestResolveOrderStatusAcceptsCoreStatuses(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	status, customStatusID, ok := resolveOrderStatus(c, nil, models.OrderStatusShipped)
+	if !ok || status != models.OrderStatusShipped || customStatusID != nil {
+		t.Fatalf("expected the core status as is, got %q %v %v", status, customStatusID, ok)
+	}
+}
@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"notsofluffy-backend/internal/models"
+)
+
+// parseCursor reads the ?cursor= of a list supporting keyset pagination; lists
+// requested without one are paged by ?page= as before. It responds 400 and returns
+// false when the cursor is malformed.
+func parseCursor(c *gin.Context) (*models.Cursor, bool) {
+	value := c.Query("cursor")
+	if value == "" {
+		return nil, true
+	}
+	cursor, err := models.DecodeCursor(value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return nil, false
+	}
+	return cursor, true
+}
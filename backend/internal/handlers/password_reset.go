@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"notsofluffy-backend/internal/mail"
+	"notsofluffy-backend/internal/middleware"
+	"notsofluffy-backend/internal/models"
+)
+
+// passwordResetRequestsPerMinute limits the password reset requests of one client IP
+const passwordResetRequestsPerMinute = 5
+
+// allowPasswordReset counts a password reset request of the client and rejects it with
+// 429 when the client is over the limit
+func (h *AuthHandler) allowPasswordReset(c *gin.Context, endpoint string) bool {
+	result := h.resetLimiter.Allow(endpoint+":"+middleware.GetClientIP(c), passwordResetRequestsPerMinute)
+	if result.Allowed {
+		return true
+	}
+
+	retryAfter := int(math.Ceil(time.Until(result.Reset).Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests, try again later", "code": models.APIRateLimitedCode})
+	return false
+}
+
+// ForgotPassword emails a password reset link. The response is the same whether or
+// not an account exists for the email, so it can't be used to find out.
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.allowPasswordReset(c, "forgot") {
+		return
+	}
+
+	user, err := h.userQueries.GetUserByEmail(req.Email)
+	if err != nil {
+		if err.Error() != "user not found" {
+			middleware.Logger(c.Request.Context()).Error("failed to get user for password reset", "error", err)
+		}
+	} else {
+		token, err := h.userQueries.CreatePasswordResetToken(user.ID, models.PasswordResetTokenTTL, middleware.GetClientIP(c))
+		if err != nil {
+			middleware.Logger(c.Request.Context()).Error("failed to create password reset token", "user_id", user.ID, "error", err)
+		} else if token != "" {
+			go h.sendPasswordResetEmail(c.Request.Context(), user.Email, token)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "If an account exists for this email, a password reset link has been sent to it"})
+}
+
+// ResetPassword sets a new password with the token from a reset link. Each token
+// works once.
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.allowPasswordReset(c, "reset") {
+		return
+	}
+
+	user, err := h.userQueries.ResetPassword(req.Token, req.Password)
+	if err != nil {
+		if err.Error() == "password reset token not found" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired password reset link"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+
+	go h.sendPasswordChangedEmail(c.Request.Context(), user.Email)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset successfully"})
+}
+
+// sendPasswordResetEmail sends the reset link. It is sent directly rather than through
+// the outbox, which admins can read.
+func (h *AuthHandler) sendPasswordResetEmail(ctx context.Context, email, token string) {
+	msg := mail.Message{
+		To:      email,
+		Subject: "Reset your NotSoFluffy password",
+		Body: fmt.Sprintf("We received a request to reset the password of your account.\n\n"+
+			"Set a new password here within %d minutes:\n%s/reset-password?token=%s\n\n"+
+			"If you didn't ask for this, you can ignore this email; your password stays the same.\n",
+			int(models.PasswordResetTokenTTL.Minutes()), h.siteURL, url.QueryEscape(token)),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := h.mailer.Send(ctx, msg); err != nil {
+		middleware.Logger(ctx).Error("failed to send password reset email", "email", email, "error", err)
+	}
+}
+
+// sendPasswordChangedEmail tells the user their password was reset
+func (h *AuthHandler) sendPasswordChangedEmail(ctx context.Context, email string) {
+	msg := mail.Message{
+		To:      email,
+		Subject: "Your NotSoFluffy password was changed",
+		Body: "The password of your account was just reset.\n\n" +
+			"If this wasn't you, reset it again right away and contact us.\n",
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := h.mailer.Send(ctx, msg); err != nil {
+		middleware.Logger(ctx).Error("failed to send password changed email", "email", email, "error", err)
+	}
+}
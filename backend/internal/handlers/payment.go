@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/middleware"
+	"notsofluffy-backend/internal/models"
+	"notsofluffy-backend/internal/payments"
+)
+
+type PaymentHandler struct {
+	orderQueries   *database.OrderQueries
+	paymentQueries *database.PaymentQueries
+	webhookQueries *database.WebhookQueries
+	provider       payments.IntentProvider
+	returnURL      string
+}
+
+// NewPaymentHandler creates the handler for online payments; provider is nil when they
+// are off. {hash} in returnURL is replaced with the order's public hash.
+func NewPaymentHandler(orderQueries *database.OrderQueries, paymentQueries *database.PaymentQueries, webhookQueries *database.WebhookQueries, provider payments.IntentProvider, returnURL string) *PaymentHandler {
+	return &PaymentHandler{
+		orderQueries:   orderQueries,
+		paymentQueries: paymentQueries,
+		webhookQueries: webhookQueries,
+		provider:       provider,
+		returnURL:      returnURL,
+	}
+}
+
+// enabled reports whether orders can be paid online
+func (h *PaymentHandler) enabled() bool {
+	return h.provider != nil
+}
+
+// startPayment starts collecting the payment of an order at the provider, or resumes
+// a payment started within the last hour for the same amount
+func (h *PaymentHandler) startPayment(ctx context.Context, order *models.OrderResponse) (*models.PaymentIntent, error) {
+	if existing, err := h.paymentQueries.GetResumablePaymentIntent(order.ID, h.provider.Name(), order.TotalAmount); err == nil {
+		return existing, nil
+	} else if err.Error() != "payment intent not found" {
+		return nil, err
+	}
+
+	suffix := make([]byte, 6)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, fmt.Errorf("failed to generate payment reference: %w", err)
+	}
+	reference := fmt.Sprintf("order-%d-%s", order.ID, hex.EncodeToString(suffix))
+
+	intent, err := h.paymentQueries.CreatePaymentIntent(order.ID, h.provider.Name(), reference, order.TotalAmount, models.PaymentCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	returnURL := h.returnURL
+	if order.PublicHash != nil {
+		returnURL = strings.ReplaceAll(returnURL, "{hash}", *order.PublicHash)
+	}
+	started, err := h.provider.CreateIntent(ctx, payments.IntentParams{
+		OrderID:     order.ID,
+		Reference:   reference,
+		Amount:      order.TotalAmount,
+		Currency:    models.PaymentCurrency,
+		Email:       order.Email,
+		Description: fmt.Sprintf("NotSoFluffy order #%d", order.ID),
+		ReturnURL:   returnURL,
+	})
+	if err != nil {
+		if failErr := h.paymentQueries.FailPaymentIntent(intent.ID); failErr != nil {
+			middleware.Logger(ctx).Error("failed to mark payment intent failed", "intent_id", intent.ID, "error", failErr)
+		}
+		return nil, err
+	}
+
+	return h.paymentQueries.AttachProviderIntent(intent.ID, started)
+}
+
+// PayOrder starts or resumes the online payment of an unpaid order. The customer is
+// sent to the returned redirect_url, or the storefront confirms the payment with the
+// client_secret.
+func (h *PaymentHandler) PayOrder(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	if !h.enabled() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Online payments are not available"})
+		return
+	}
+
+	order, err := h.orderQueries.GetOrderByID(id)
+	if err != nil {
+		if err.Error() == "order not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get order"})
+		return
+	}
+	if !canAccessOrder(c, order) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return
+	}
+
+	if order.Status == models.OrderStatusCancelled {
+		c.JSON(http.StatusConflict, gin.H{"error": "order is cancelled"})
+		return
+	}
+	if order.PaymentStatus != models.PaymentStatusPending && order.PaymentStatus != models.PaymentStatusFailed {
+		c.JSON(http.StatusConflict, gin.H{"error": "order is already paid"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+	intent, err := h.startPayment(ctx, order)
+	if err != nil {
+		middleware.Logger(ctx).Error("failed to start payment", "order_id", order.ID, "error", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to start payment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, intent)
+}
+
+// PaymentCallback receives payment updates from the provider. The callback is
+// signed by the provider and applied once, so providers may safely retry it.
+func (h *PaymentHandler) PaymentCallback(c *gin.Context) {
+	if !h.enabled() || c.Param("provider") != h.provider.Name() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown payment provider"})
+		return
+	}
+
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read callback"})
+		return
+	}
+
+	event, err := h.provider.HandleCallback(c.Request.Context(), c.Request.Header, body)
+	if err != nil {
+		if errors.Is(err, payments.ErrInvalidSignature) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid signature"})
+			return
+		}
+		middleware.Logger(c.Request.Context()).Error("failed to handle payment callback", "provider", h.provider.Name(), "error", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to handle callback"})
+		return
+	}
+	if event == nil {
+		c.JSON(http.StatusOK, gin.H{"received": true})
+		return
+	}
+
+	change, err := h.paymentQueries.ApplyPaymentEvent(h.provider.Name(), event)
+	if err != nil {
+		if err.Error() == "payment intent not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Payment not found"})
+			return
+		}
+		c.Error(fmt.Errorf("failed to apply %s payment intent %s: %w", h.provider.Name(), event.ProviderIntentID, err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply payment"})
+		return
+	}
+	publishStatusChange(c.Request.Context(), h.webhookQueries, change)
+
+	c.JSON(http.StatusOK, gin.H{"received": true})
+}
@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/media"
+	"notsofluffy-backend/internal/middleware"
+	"notsofluffy-backend/internal/models"
+)
+
+type AttachmentHandler struct {
+	attachmentQueries *database.AttachmentQueries
+	productQueries    *database.ProductQueries
+	settingsQueries   *database.SettingsQueries
+	mediaService      *media.Service
+}
+
+func NewAttachmentHandler(attachmentQueries *database.AttachmentQueries, productQueries *database.ProductQueries, settingsQueries *database.SettingsQueries) *AttachmentHandler {
+	return &AttachmentHandler{
+		attachmentQueries: attachmentQueries,
+		productQueries:    productQueries,
+		settingsQueries:   settingsQueries,
+		mediaService:      media.NewService(media.NewLocalStorage("uploads")),
+	}
+}
+
+// attachmentDownloadURL is the counted public download link of an attachment
+func attachmentDownloadURL(id int) string {
+	return fmt.Sprintf("/api/attachments/%d/download", id)
+}
+
+// ListProductAttachments returns the attachments of a product for the admin panel
+func (h *AttachmentHandler) ListProductAttachments(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	attachments, err := h.attachmentQueries.ListProductAttachments(productID)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get product attachments"})
+		return
+	}
+	for i := range attachments {
+		attachments[i].DownloadURL = attachmentDownloadURL(attachments[i].ID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"attachments": attachments})
+}
+
+// UploadProductAttachment stores a PDF uploaded as "file" with an optional "title"
+func (h *AttachmentHandler) UploadProductAttachment(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	if _, err := h.productQueries.GetProduct(productID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		return
+	}
+	defer file.Close()
+
+	maxSizeMB, err := h.settingsQueries.GetIntSetting(models.SettingAttachmentMaxSizeMB, models.DefaultAttachmentMaxSizeMB)
+	if err != nil || maxSizeMB <= 0 {
+		maxSizeMB = models.DefaultAttachmentMaxSizeMB
+	}
+
+	title := strings.TrimSpace(c.PostForm("title"))
+	if title == "" {
+		title = strings.TrimSuffix(header.Filename, filepath.Ext(header.Filename))
+	}
+	if len(title) > 255 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Title must be at most 255 characters"})
+		return
+	}
+
+	stored, err := h.mediaService.Store(media.KindDocument, file, header, int64(maxSizeMB)*1024*1024)
+	if err != nil {
+		var validationErr *media.ValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Message})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
+		return
+	}
+
+	attachment := &models.ProductAttachment{
+		ProductID:    productID,
+		Title:        title,
+		Filename:     stored.Filename,
+		OriginalName: stored.OriginalName,
+		Path:         stored.Path,
+		SizeBytes:    stored.SizeBytes,
+		MimeType:     stored.MimeType,
+		UploadedBy:   getUserIDPtr(c),
+	}
+	if err := h.attachmentQueries.CreateAttachment(attachment); err != nil {
+		h.mediaService.Remove(stored.Path, stored.MimeType)
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save attachment"})
+		return
+	}
+	attachment.DownloadURL = attachmentDownloadURL(attachment.ID)
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// UpdateProductAttachment changes the title or position of an attachment
+func (h *AttachmentHandler) UpdateProductAttachment(c *gin.Context) {
+	productID, attachmentID, ok := parseAttachmentParams(c)
+	if !ok {
+		return
+	}
+
+	var req models.ProductAttachmentUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.attachmentQueries.UpdateAttachment(productID, attachmentID, &req); err != nil {
+		if err.Error() == "attachment not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update attachment"})
+		return
+	}
+
+	attachment, err := h.attachmentQueries.GetAttachment(productID, attachmentID)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get attachment"})
+		return
+	}
+	attachment.DownloadURL = attachmentDownloadURL(attachment.ID)
+
+	c.JSON(http.StatusOK, attachment)
+}
+
+// ReorderProductAttachments sets the display order of a product's attachments
+func (h *AttachmentHandler) ReorderProductAttachments(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var req models.ReorderProductAttachmentsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.attachmentQueries.ReorderAttachments(productID, req.AttachmentIDs); err != nil {
+		if err.Error() == "attachment not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reorder attachments"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Attachments reordered successfully"})
+}
+
+// DeleteProductAttachment removes an attachment and its file
+func (h *AttachmentHandler) DeleteProductAttachment(c *gin.Context) {
+	productID, attachmentID, ok := parseAttachmentParams(c)
+	if !ok {
+		return
+	}
+
+	attachment, err := h.attachmentQueries.DeleteAttachment(productID, attachmentID)
+	if err != nil {
+		if err.Error() == "attachment not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete attachment"})
+		return
+	}
+	h.mediaService.Remove(attachment.Path, attachment.MimeType)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Attachment deleted successfully"})
+}
+
+// DownloadAttachment serves an attachment of a web shop product and counts the download
+func (h *AttachmentHandler) DownloadAttachment(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attachment ID"})
+		return
+	}
+
+	attachment, err := h.attachmentQueries.GetPublicAttachment(id)
+	if err != nil {
+		if err.Error() == "attachment not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get attachment"})
+		return
+	}
+
+	if _, err := os.Stat(attachment.Path); err != nil {
+		middleware.Logger(c.Request.Context()).Warn("attachment file missing", "attachment_id", attachment.ID, "error", err)
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+		return
+	}
+
+	if err := h.attachmentQueries.IncrementDownloadCount(attachment.ID); err != nil {
+		middleware.Logger(c.Request.Context()).Error("failed to count attachment download", "attachment_id", attachment.ID, "error", err)
+	}
+
+	c.FileAttachment(attachment.Path, attachment.OriginalName)
+}
+
+// publicAttachments converts product attachments for the product page
+func publicAttachments(attachments []models.ProductAttachment) []models.PublicProductAttachment {
+	result := make([]models.PublicProductAttachment, len(attachments))
+	for i, a := range attachments {
+		result[i] = models.PublicProductAttachment{
+			ID:           a.ID,
+			Title:        a.Title,
+			OriginalName: a.OriginalName,
+			SizeBytes:    a.SizeBytes,
+			DownloadURL:  attachmentDownloadURL(a.ID),
+		}
+	}
+	return result
+}
+
+func parseAttachmentParams(c *gin.Context) (int, int, bool) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return 0, 0, false
+	}
+	attachmentID, err := strconv.Atoi(c.Param("attachmentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attachment ID"})
+		return 0, 0, false
+	}
+	return productID, attachmentID, true
+}
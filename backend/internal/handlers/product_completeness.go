@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"notsofluffy-backend/internal/validation"
+)
+
+// GetProductCompleteness returns the checklist of what a product still lacks before it
+// should go live, with a completeness score
+func (h *AdminHandler) GetProductCompleteness(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	product, err := h.productQueries.GetProduct(id)
+	if err != nil {
+		if err.Error() == "product not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve product"})
+		return
+	}
+
+	sizes, err := h.productQueries.GetProductSizes(id)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve product sizes"})
+		return
+	}
+
+	variants, err := h.productQueries.GetProductVariants(id)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve product variants"})
+		return
+	}
+	_, defaultVariants := countDefaultVariants(variants)
+
+	c.JSON(http.StatusOK, validation.Completeness(id, validation.ProductContent{
+		Sizes:           len(sizes),
+		DefaultVariants: defaultVariants,
+		Images:          len(product.Images),
+		Category:        product.CategoryID != nil,
+		Description:     product.Description,
+	}))
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/models"
+)
+
+type ProductOptionHandler struct {
+	optionQueries  *database.OptionQueries
+	productQueries *database.ProductQueries
+}
+
+func NewProductOptionHandler(optionQueries *database.OptionQueries, productQueries *database.ProductQueries) *ProductOptionHandler {
+	return &ProductOptionHandler{
+		optionQueries:  optionQueries,
+		productQueries: productQueries,
+	}
+}
+
+// ListProductOptionGroups returns the option groups of a product with their values
+func (h *ProductOptionHandler) ListProductOptionGroups(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	groups, err := h.optionQueries.ListProductOptionGroups(productID)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get product options"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"option_groups": groups})
+}
+
+// CreateProductOptionGroup adds an option group with its values to a product
+func (h *ProductOptionHandler) CreateProductOptionGroup(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var req models.ProductOptionGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	group, err := h.optionQueries.CreateOptionGroup(productID, &req)
+	if err != nil {
+		respondOptionError(c, err, "Failed to create option group")
+		return
+	}
+
+	c.JSON(http.StatusCreated, group)
+}
+
+// UpdateProductOptionGroup replaces an option group and its values
+func (h *ProductOptionHandler) UpdateProductOptionGroup(c *gin.Context) {
+	productID, groupID, ok := optionGroupParams(c)
+	if !ok {
+		return
+	}
+
+	var req models.ProductOptionGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	group, err := h.optionQueries.UpdateOptionGroup(productID, groupID, &req)
+	if err != nil {
+		respondOptionError(c, err, "Failed to update option group")
+		return
+	}
+
+	c.JSON(http.StatusOK, group)
+}
+
+// DeleteProductOptionGroup removes an option group with its values
+func (h *ProductOptionHandler) DeleteProductOptionGroup(c *gin.Context) {
+	productID, groupID, ok := optionGroupParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.optionQueries.DeleteOptionGroup(productID, groupID); err != nil {
+		respondOptionError(c, err, "Failed to delete option group")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Option group deleted successfully"})
+}
+
+// CreateColorOptionGroup turns the colors of a product's variants into an option group,
+// named by the optional "name" query parameter
+func (h *ProductOptionHandler) CreateColorOptionGroup(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	if _, err := h.productQueries.GetProduct(productID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+
+	name := strings.TrimSpace(c.DefaultQuery("name", models.DefaultColorOptionGroupName))
+	if name == "" || len(name) > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid option group name"})
+		return
+	}
+
+	group, err := h.optionQueries.CreateColorOptionGroup(productID, name)
+	if err != nil {
+		respondOptionError(c, err, "Failed to create option group")
+		return
+	}
+
+	c.JSON(http.StatusCreated, group)
+}
+
+func optionGroupParams(c *gin.Context) (int, int, bool) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return 0, 0, false
+	}
+	groupID, err := strconv.Atoi(c.Param("groupId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid option group ID"})
+		return 0, 0, false
+	}
+	return productID, groupID, true
+}
+
+func respondOptionError(c *gin.Context, err error, message string) {
+	switch err.Error() {
+	case "product not found", "option group not found", "option value not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case "option group already exists", "option value already exists":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case "image not found", "product has no variants":
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
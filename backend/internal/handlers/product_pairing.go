@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/models"
+)
+
+type PairingHandler struct {
+	pairingQueries *database.PairingQueries
+}
+
+func NewPairingHandler(pairingQueries *database.PairingQueries) *PairingHandler {
+	return &PairingHandler{pairingQueries: pairingQueries}
+}
+
+// ListProductPairings returns computed frequently bought together pairs and overrides of a product
+func (h *PairingHandler) ListProductPairings(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	pairings, err := h.pairingQueries.ListProductPairings(productID)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get product pairings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"pairings": pairings})
+}
+
+// SetPairingOverride pins or excludes a related product
+func (h *PairingHandler) SetPairingOverride(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+	relatedID, err := strconv.Atoi(c.Param("relatedId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid related product ID"})
+		return
+	}
+
+	var req models.ProductPairingOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.pairingQueries.SetPairingOverride(productID, relatedID, &req, getUserIDPtr(c)); err != nil {
+		switch err.Error() {
+		case "product cannot be paired with itself":
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Product cannot be paired with itself"})
+		case "product not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		default:
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set pairing override"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Pairing override saved"})
+}
+
+// DeletePairingOverride removes a pin or exclusion
+func (h *PairingHandler) DeletePairingOverride(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+	relatedID, err := strconv.Atoi(c.Param("relatedId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid related product ID"})
+		return
+	}
+
+	if err := h.pairingQueries.DeletePairingOverride(productID, relatedID); err != nil {
+		if err.Error() == "pairing override not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Pairing override not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete pairing override"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Pairing override removed"})
+}
+
+// RecomputePairings rebuilds frequently bought together pairs immediately instead of waiting for the daily job
+func (h *PairingHandler) RecomputePairings(c *gin.Context) {
+	result, err := h.pairingQueries.RecomputePairings()
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to recompute product pairings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
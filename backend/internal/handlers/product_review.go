@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/models"
+)
+
+type ProductReviewHandler struct {
+	reviewQueries *database.ProductReviewQueries
+}
+
+func NewProductReviewHandler(reviewQueries *database.ProductReviewQueries) *ProductReviewHandler {
+	return &ProductReviewHandler{reviewQueries: reviewQueries}
+}
+
+// CreateProductReview lets a customer who bought a product rate and review it; the
+// review is shown once an admin approves it
+func (h *ProductReviewHandler) CreateProductReview(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	var req models.CreateProductReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	review, err := h.reviewQueries.CreateProductReview(productID, userID, req)
+	if err != nil {
+		switch err.Error() {
+		case "product not purchased":
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only customers who received this product can review it"})
+		case "product already reviewed":
+			c.JSON(http.StatusConflict, gin.H{"error": "You have already reviewed this product"})
+		default:
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create review"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, review)
+}
+
+// ListPublicProductReviews returns the approved reviews of a product shown in the shop
+func (h *ProductReviewHandler) ListPublicProductReviews(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+	page, limit := reviewPagination(c)
+
+	reviews, err := h.reviewQueries.ListProductReviews(page, limit, &productID, models.ProductReviewStatusApproved)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get reviews"})
+		return
+	}
+	// Customers see the first name and initial only, not who moderated the review
+	for i := range reviews.Reviews {
+		reviews.Reviews[i].UserID = 0
+		reviews.Reviews[i].OrderID = nil
+		reviews.Reviews[i].AdminNote = nil
+		reviews.Reviews[i].ModeratedBy = nil
+	}
+
+	c.JSON(http.StatusOK, reviews)
+}
+
+// ListProductReviews returns the admin moderation queue, pending reviews by default;
+// ?status=all lists every review and ?product_id= narrows it to one product
+func (h *ProductReviewHandler) ListProductReviews(c *gin.Context) {
+	page, limit := reviewPagination(c)
+	status := c.DefaultQuery("status", models.ProductReviewStatusPending)
+	if status == "all" {
+		status = ""
+	}
+
+	var productID *int
+	if raw := c.Query("product_id"); raw != "" {
+		id, err := strconv.Atoi(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+			return
+		}
+		productID = &id
+	}
+
+	reviews, err := h.reviewQueries.ListProductReviews(page, limit, productID, status)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get reviews"})
+		return
+	}
+
+	c.JSON(http.StatusOK, reviews)
+}
+
+// ApproveProductReview publishes a review in the shop
+func (h *ProductReviewHandler) ApproveProductReview(c *gin.Context) {
+	h.moderate(c, models.ProductReviewStatusApproved)
+}
+
+// RejectProductReview hides a review from the shop
+func (h *ProductReviewHandler) RejectProductReview(c *gin.Context) {
+	h.moderate(c, models.ProductReviewStatusRejected)
+}
+
+func (h *ProductReviewHandler) moderate(c *gin.Context, status string) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
+		return
+	}
+
+	var req models.ProductReviewModerationRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	review, err := h.reviewQueries.ModerateProductReview(id, status, req.Note, getUserIDPtr(c))
+	if err != nil {
+		if err.Error() == "product review not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update review"})
+		return
+	}
+
+	c.JSON(http.StatusOK, review)
+}
+
+// DeleteProductReview removes a review
+func (h *ProductReviewHandler) DeleteProductReview(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
+		return
+	}
+
+	if err := h.reviewQueries.DeleteProductReview(id); err != nil {
+		if err.Error() == "product review not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete review"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Review deleted successfully"})
+}
+
+// reviewPagination reads ?page= and ?limit=, falling back to the first page of 20
+func reviewPagination(c *gin.Context) (int, int) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	return page, limit
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"notsofluffy-backend/internal/database"
+)
+
+type ProductRevisionHandler struct {
+	revisionQueries *database.ProductRevisionQueries
+}
+
+func NewProductRevisionHandler(revisionQueries *database.ProductRevisionQueries) *ProductRevisionHandler {
+	return &ProductRevisionHandler{revisionQueries: revisionQueries}
+}
+
+// ListProductRevisions returns the edit history of a product, newest first
+func (h *ProductRevisionHandler) ListProductRevisions(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	response, err := h.revisionQueries.ListRevisions(productID, page, limit)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list product revisions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetProductRevision returns one revision with its saved rows
+func (h *ProductRevisionHandler) GetProductRevision(c *gin.Context) {
+	productID, revision, ok := parseRevisionParams(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.revisionQueries.GetRevision(productID, revision)
+	if err != nil {
+		if err.Error() == "product revision not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product revision not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get product revision"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// RestoreProductRevision puts a product back to a revision, recorded as a new revision
+func (h *ProductRevisionHandler) RestoreProductRevision(c *gin.Context) {
+	productID, revision, ok := parseRevisionParams(c)
+	if !ok {
+		return
+	}
+
+	restored, err := h.revisionQueries.RestoreRevision(productID, revision, getUserIDPtr(c))
+	if err != nil {
+		switch {
+		case err.Error() == "product revision not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product revision not found"})
+		case err.Error() == "product not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		case strings.HasPrefix(err.Error(), "product revision conflicts"):
+			c.JSON(http.StatusConflict, gin.H{"error": "Revision references data that no longer exists"})
+		default:
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore product revision"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, restored)
+}
+
+func parseRevisionParams(c *gin.Context) (int, int, bool) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return 0, 0, false
+	}
+	revision, err := strconv.Atoi(c.Param("revision"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid revision"})
+		return 0, 0, false
+	}
+	return productID, revision, true
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+
+	"notsofluffy-backend/internal/models"
+	"notsofluffy-backend/internal/slug"
+)
+
+// productPath is the storefront path of a product page; the ID keeps it stable when the name changes
+func productPath(id int, productSlug string) string {
+	if productSlug == "" {
+		return fmt.Sprintf("/products/%d", id)
+	}
+	return fmt.Sprintf("/products/%d/%s", id, productSlug)
+}
+
+// productSlug is the stored slug of a product, or one made from its name for products
+// saved before slugs were stored
+func productSlug(stored, name string) string {
+	if stored != "" {
+		return stored
+	}
+	return slug.Make(name)
+}
+
+func productCanonical(siteURL string, id int, storedSlug, name string) models.ProductCanonical {
+	canonicalSlug := productSlug(storedSlug, name)
+	canonical := models.ProductCanonical{Slug: canonicalSlug, Path: productPath(id, canonicalSlug)}
+	if siteURL != "" {
+		canonical.URL = siteURL + canonical.Path
+	}
+	return canonical
+}
+
+// productBreadcrumbs builds the trail home > products > category > product
+func productBreadcrumbs(product *models.ProductWithRelations, canonical models.ProductCanonical) []models.BreadcrumbItem {
+	breadcrumbs := []models.BreadcrumbItem{
+		{Name: "Home", Path: "/"},
+		{Name: "Products", Path: "/products"},
+	}
+	if product.Category != nil {
+		breadcrumbs = append(breadcrumbs, models.BreadcrumbItem{
+			Name: product.Category.Name,
+			Path: "/products?category=" + url.QueryEscape(product.Category.Slug),
+		})
+	}
+	return append(breadcrumbs, models.BreadcrumbItem{Name: product.Name, Path: canonical.Path})
+}
+
+func withProductPath(p *models.AdjacentProduct) *models.AdjacentProduct {
+	if p == nil {
+		return nil
+	}
+	p.Slug = productSlug(p.Slug, p.Name)
+	p.Path = productPath(p.ID, p.Slug)
+	return p
+}
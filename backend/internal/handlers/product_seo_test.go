@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"testing"
+
+	"notsofluffy-backend/internal/models"
+)
+
+func TestProductBreadcrumbs(t *testing.T) {
+	product := &models.ProductWithRelations{
+		ID:       7,
+		Name:     "Legowisko Puszek",
+		Category: &models.CategoryResponse{Name: "Legowiska", Slug: "legowiska"},
+	}
+	canonical := productCanonical("https://notsofluffy.pl", product.ID, "", product.Name)
+
+	if canonical.URL != "https://notsofluffy.pl/products/7/legowisko-puszek" {
+		t.Fatalf("unexpected canonical URL %q", canonical.URL)
+	}
+
+	breadcrumbs := productBreadcrumbs(product, canonical)
+	if len(breadcrumbs) != 4 {
+		t.Fatalf("expected 4 breadcrumbs, got %d", len(breadcrumbs))
+	}
+	if breadcrumbs[2].Path != "/products?category=legowiska" {
+		t.Errorf("unexpected category path %q", breadcrumbs[2].Path)
+	}
+	if breadcrumbs[3].Path != canonical.Path {
+		t.Errorf("expected last breadcrumb to link the canonical path, got %q", breadcrumbs[3].Path)
+	}
+
+	if stored := productCanonical("", product.ID, "puszek", product.Name); stored.Path != "/products/7/puszek" {
+		t.Errorf("expected the stored slug in the canonical path, got %q", stored.Path)
+	}
+
+	product.Category = nil
+	if got := len(productBreadcrumbs(product, canonical)); got != 3 {
+		t.Errorf("expected 3 breadcrumbs without category, got %d", got)
+	}
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateProductSetup creates a product with its sizes, variants and their images in one
+// transaction. Every invalid field is reported at once, by its path in the request.
+func (h *AdminHandler) CreateProductSetup(c *gin.Context) {
+	var req models.ProductSetupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fieldErrors := productSetupErrors(&req)
+	refErrors, err := h.productSetupRefErrors(&req)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create product"})
+		return
+	}
+	fieldErrors = append(fieldErrors, refErrors...)
+
+	// Use the given slug if it is free, or generate one from the name
+	exists, _ := h.slugExists(models.SlugTypeProduct, nil)
+	newSlug, err := resolveSlug(req.Product.Slug, req.Product.Name, models.SlugTypeProduct, exists)
+	switch err {
+	case nil:
+	case errInvalidSlug:
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "product.slug", Message: "may only contain lowercase letters, digits and single dashes"})
+	case errSlugTaken:
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "product.slug", Message: "already exists"})
+	default:
+		respondSlugError(c, err)
+		return
+	}
+
+	if len(fieldErrors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product", "errors": fieldErrors})
+		return
+	}
+
+	setup := &database.ProductSetup{
+		Product: &models.Product{
+			Name:             req.Product.Name,
+			Slug:             newSlug,
+			ShortDescription: req.Product.ShortDescription,
+			Description:      req.Product.Description,
+			MaterialID:       req.Product.MaterialID,
+			MainImageID:      req.Product.MainImageID,
+			CategoryID:       req.Product.CategoryID,
+			Channels:         req.Product.Channels,
+		},
+		ImageIDs:   req.Product.ImageIDs,
+		ServiceIDs: req.Product.AdditionalServiceIDs,
+	}
+	for _, size := range req.Sizes {
+		setup.Sizes = append(setup.Sizes, models.Size{
+			Name:          size.Name,
+			BasePrice:     size.BasePrice,
+			A:             size.A,
+			B:             size.B,
+			C:             size.C,
+			D:             size.D,
+			E:             size.E,
+			F:             size.F,
+			UseStock:      size.UseStock,
+			StockQuantity: size.StockQuantity,
+		})
+	}
+	for _, variant := range req.Variants {
+		setup.Variants = append(setup.Variants, database.VariantSetup{
+			Variant:  models.ProductVariant{Name: variant.Name, ColorID: variant.ColorID, IsDefault: variant.IsDefault},
+			ImageIDs: variant.ImageIDs,
+		})
+	}
+
+	if err := h.productQueries.CreateProductSetup(setup, getUserIDPtr(c)); err != nil {
+		if err.Error() == "product slug already exists" {
+			respondSlugError(c, errSlugTaken)
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create product"})
+		return
+	}
+	productID := setup.Product.ID
+
+	h.recordProductRevision(c.Request.Context(), productID, models.ProductRevisionCreate, getUserIDPtr(c))
+
+	// Return the created product with its sizes and variants
+	createdProduct, err := h.productQueries.GetProduct(productID)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve created product"})
+		return
+	}
+	sizes, _, err := h.sizeQueries.ListSizes(1, len(setup.Sizes)+1, "", &productID)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve created product"})
+		return
+	}
+	variants, _, err := h.productVariantQueries.ListProductVariants(1, len(setup.Variants)+1, "", &productID, nil)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve created product"})
+		return
+	}
+
+	response := buildProductResponse(createdProduct)
+
+	c.JSON(http.StatusCreated, models.ProductSetupResponse{
+		ProductMutationResponse: models.ProductMutationResponse{
+			ProductResponse: response,
+			Warnings:        h.productWarnings(c.Request.Context(), createdProduct),
+		},
+		Sizes:    sizes,
+		Variants: variants,
+	})
+}
+
+// productSetupErrors checks a product setup for errors that don't need the database
+func productSetupErrors(req *models.ProductSetupRequest) []models.FieldError {
+	var errs []models.FieldError
+
+	if !containsID(req.Product.ImageIDs, req.Product.MainImageID) {
+		errs = append(errs, models.FieldError{Field: "product.main_image_id", Message: "must be included in the images list"})
+	}
+
+	sizeNames := map[string]int{}
+	for i, size := range req.Sizes {
+		name := strings.ToLower(strings.TrimSpace(size.Name))
+		if first, ok := sizeNames[name]; ok {
+			errs = append(errs, models.FieldError{Field: fmt.Sprintf("sizes[%d].name", i), Message: fmt.Sprintf("duplicates sizes[%d]", first)})
+			continue
+		}
+		sizeNames[name] = i
+	}
+
+	defaultVariant := -1
+	for i, variant := range req.Variants {
+		if !variant.IsDefault {
+			continue
+		}
+		if defaultVariant >= 0 {
+			errs = append(errs, models.FieldError{Field: fmt.Sprintf("variants[%d].is_default", i), Message: fmt.Sprintf("variants[%d] is already the default", defaultVariant)})
+			continue
+		}
+		defaultVariant = i
+	}
+	return errs
+}
+
+// productSetupRefErrors checks that everything a product setup refers to exists
+func (h *AdminHandler) productSetupRefErrors(req *models.ProductSetupRequest) ([]models.FieldError, error) {
+	var errs []models.FieldError
+
+	imageIDs := append([]int{}, req.Product.ImageIDs...)
+	colorIDs := []int{}
+	for _, variant := range req.Variants {
+		imageIDs = append(imageIDs, variant.ImageIDs...)
+		colorIDs = append(colorIDs, variant.ColorID)
+	}
+	images, err := h.imageQueries.ExistingImageIDs(imageIDs)
+	if err != nil {
+		return nil, err
+	}
+	colors, err := h.colorQueries.ExistingColorIDs(colorIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	if !images[req.Product.MainImageID] {
+		errs = append(errs, models.FieldError{Field: "product.main_image_id", Message: "image not found"})
+	}
+	for i, imageID := range req.Product.ImageIDs {
+		if !images[imageID] {
+			errs = append(errs, models.FieldError{Field: fmt.Sprintf("product.image_ids[%d]", i), Message: fmt.Sprintf("image %d not found", imageID)})
+		}
+	}
+	for i, serviceID := range req.Product.AdditionalServiceIDs {
+		if !h.validateAdditionalServiceExists(serviceID) {
+			errs = append(errs, models.FieldError{Field: fmt.Sprintf("product.additional_service_ids[%d]", i), Message: fmt.Sprintf("additional service %d not found", serviceID)})
+		}
+	}
+	if req.Product.MaterialID != nil && !h.validateMaterialExists(*req.Product.MaterialID) {
+		errs = append(errs, models.FieldError{Field: "product.material_id", Message: "material not found"})
+	}
+	if req.Product.CategoryID != nil && !h.validateCategoryExists(*req.Product.CategoryID) {
+		errs = append(errs, models.FieldError{Field: "product.category_id", Message: "category not found"})
+	}
+
+	for i, variant := range req.Variants {
+		if !colors[variant.ColorID] {
+			errs = append(errs, models.FieldError{Field: fmt.Sprintf("variants[%d].color_id", i), Message: "color not found"})
+		}
+		for j, imageID := range variant.ImageIDs {
+			if !images[imageID] {
+				errs = append(errs, models.FieldError{Field: fmt.Sprintf("variants[%d].image_ids[%d]", i, j), Message: fmt.Sprintf("image %d not found", imageID)})
+			}
+		}
+	}
+	return errs, nil
+}
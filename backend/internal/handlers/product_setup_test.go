@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"testing"
+
+	"notsofluffy-backend/internal/models"
+)
+
+func TestProductSetupErrors(t *testing.T) {
+	req := &models.ProductSetupRequest{
+		Product: models.ProductRequest{MainImageID: 3, ImageIDs: []int{1, 2}},
+		Sizes:   []models.ProductSetupSizeRequest{{Name: "S"}, {Name: "M"}, {Name: " s"}},
+		Variants: []models.ProductSetupVariantRequest{
+			{Name: "Red", IsDefault: true},
+			{Name: "Blue"},
+			{Name: "Green", IsDefault: true},
+		},
+	}
+
+	errs := productSetupErrors(req)
+	want := []models.FieldError{
+		{Field: "product.main_image_id", Message: "must be included in the images list"},
+		{Field: "sizes[2].name", Message: "duplicates sizes[0]"},
+		{Field: "variants[2].is_default", Message: "variants[0] is already the default"},
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("expected %d errors, got %v", len(want), errs)
+	}
+	for i := range want {
+		if errs[i] != want[i] {
+			t.Fatalf("error %d: expected %v, got %v", i, want[i], errs[i])
+		}
+	}
+
+	req.Product.MainImageID = 1
+	req.Sizes = req.Sizes[:2]
+	req.Variants = req.Variants[:2]
+	if errs := productSetupErrors(req); len(errs) != 0 {
+		t.Fatalf("expected a valid setup, got %v", errs)
+	}
+}
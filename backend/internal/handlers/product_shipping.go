@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/middleware"
+	"notsofluffy-backend/internal/models"
+)
+
+// GetProductShipping returns whether a product ships oversized and its shipping surcharge
+func (h *AdminHandler) GetProductShipping(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	shipping, err := h.productQueries.GetProductShipping(id)
+	if err != nil {
+		if err.Error() == "product not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve product shipping"})
+		return
+	}
+
+	c.JSON(http.StatusOK, shipping)
+}
+
+// UpdateProductShipping marks a product as oversized and sets its per-unit shipping surcharge
+func (h *AdminHandler) UpdateProductShipping(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var req models.ProductShippingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	shipping, err := h.productQueries.UpdateProductShipping(id, &req)
+	if err != nil {
+		if err.Error() == "product not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product shipping"})
+		return
+	}
+
+	c.JSON(http.StatusOK, shipping)
+}
+
+// oversizeShippingFee reads the oversize_shipping_fee setting, falling back to the default
+func oversizeShippingFee(ctx context.Context, settingsQueries *database.SettingsQueries) float64 {
+	fee, err := settingsQueries.GetFloatSetting(models.SettingOversizeShippingFee, models.DefaultOversizeShippingFee)
+	if err != nil {
+		middleware.Logger(ctx).Warn("failed to read the oversize shipping fee, using the default", "error", err)
+	}
+	if fee < 0 {
+		return models.DefaultOversizeShippingFee
+	}
+	return fee
+}
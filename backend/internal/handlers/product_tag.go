@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/models"
+)
+
+// SetProductTags replaces the tags of a product
+func (h *AdminHandler) SetProductTags(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var req models.ProductTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tags := database.NormalizeTags(req.Tags)
+	if err := h.productQueries.SetProductTags(id, tags); err != nil {
+		if err.Error() == "product not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product tags"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tags": tags})
+}
+
+// ListProductTags returns all product tags with the number of products carrying them
+func (h *AdminHandler) ListProductTags(c *gin.Context) {
+	tags, err := h.productQueries.ListProductTags()
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list product tags"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tags": tags})
+}
+
+// RenameProductTag renames a tag on every product, merging it into the new tag when
+// that is already in use
+func (h *AdminHandler) RenameProductTag(c *gin.Context) {
+	var req models.ProductTagRenameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	from := strings.ToLower(strings.TrimSpace(c.Param("tag")))
+	to := strings.ToLower(strings.TrimSpace(req.Tag))
+	if to == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tag is required"})
+		return
+	}
+
+	products, err := h.productQueries.RenameProductTag(from, to)
+	if err != nil {
+		if err.Error() == "tag not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rename product tag"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tag": to, "products": products})
+}
+
+// DeleteProductTag removes a tag from every product
+func (h *AdminHandler) DeleteProductTag(c *gin.Context) {
+	tag := strings.ToLower(strings.TrimSpace(c.Param("tag")))
+
+	products, err := h.productQueries.DeleteProductTag(tag)
+	if err != nil {
+		if err.Error() == "tag not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete product tag"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Tag deleted successfully", "products": products})
+}
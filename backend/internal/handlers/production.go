@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/models"
+)
+
+type ProductionHandler struct {
+	productionQueries *database.ProductionQueries
+}
+
+func NewProductionHandler(productionQueries *database.ProductionQueries) *ProductionHandler {
+	return &ProductionHandler{productionQueries: productionQueries}
+}
+
+// parseProductionDate parses a YYYY-MM-DD day into midnight UTC, the form DATE columns use
+func parseProductionDate(value string) (time.Time, error) {
+	return time.Parse("2006-01-02", value)
+}
+
+// GetCapacity returns the booked and remaining production capacity per day over the
+// next ?days=30 days, starting today or at ?from=YYYY-MM-DD
+func (h *ProductionHandler) GetCapacity(c *gin.Context) {
+	var from time.Time
+	if value := c.Query("from"); value != "" {
+		parsed, err := parseProductionDate(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be a date in YYYY-MM-DD format"})
+			return
+		}
+		from = parsed
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days < 1 || days > models.ProductionHorizonDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 365"})
+		return
+	}
+
+	capacity, err := h.productionQueries.GetCapacity(from, days)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get production capacity"})
+		return
+	}
+
+	c.JSON(http.StatusOK, capacity)
+}
+
+// ListBlockedDays returns the upcoming days blocked out of production
+func (h *ProductionHandler) ListBlockedDays(c *gin.Context) {
+	days, err := h.productionQueries.ListBlockedDays()
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get blocked days"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"blocked_days": days})
+}
+
+// BlockDay blocks a day out of production, e.g. for a holiday
+func (h *ProductionHandler) BlockDay(c *gin.Context) {
+	var req models.ProductionBlockedDayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	date, err := parseProductionDate(req.Date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "date must be in YYYY-MM-DD format"})
+		return
+	}
+
+	day, err := h.productionQueries.BlockDay(date, req.Reason)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to block day"})
+		return
+	}
+
+	c.JSON(http.StatusOK, day)
+}
+
+// UnblockDay opens a blocked day for production again
+func (h *ProductionHandler) UnblockDay(c *gin.Context) {
+	date, err := parseProductionDate(c.Param("date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "date must be in YYYY-MM-DD format"})
+		return
+	}
+
+	if err := h.productionQueries.UnblockDay(date); err != nil {
+		if err.Error() == "blocked day not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Blocked day not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unblock day"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Day unblocked successfully"})
+}
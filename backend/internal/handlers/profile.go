@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"database/sql"
+	"fmt"
 	"net/http"
 	"strconv"
 
@@ -31,12 +32,14 @@ func (h *ProfileHandler) GetProfile(c *gin.Context) {
 
 	id, ok := userID.(int)
 	if !ok {
+		c.Error(fmt.Errorf("invalid user ID type %T", userID))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID type"})
 		return
 	}
 
 	profile, err := h.profileQueries.GetUserProfile(id)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user profile"})
 		return
 	}
@@ -54,6 +57,7 @@ func (h *ProfileHandler) UpdateProfile(c *gin.Context) {
 
 	id, ok := userID.(int)
 	if !ok {
+		c.Error(fmt.Errorf("invalid user ID type %T", userID))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID type"})
 		return
 	}
@@ -66,6 +70,7 @@ func (h *ProfileHandler) UpdateProfile(c *gin.Context) {
 
 	profile, err := h.profileQueries.UpdateUserProfile(id, &req)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user profile"})
 		return
 	}
@@ -83,12 +88,14 @@ func (h *ProfileHandler) GetAddresses(c *gin.Context) {
 
 	id, ok := userID.(int)
 	if !ok {
+		c.Error(fmt.Errorf("invalid user ID type %T", userID))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID type"})
 		return
 	}
 
 	addresses, err := h.profileQueries.GetUserAddresses(id)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user addresses"})
 		return
 	}
@@ -111,6 +118,7 @@ func (h *ProfileHandler) CreateAddress(c *gin.Context) {
 
 	id, ok := userID.(int)
 	if !ok {
+		c.Error(fmt.Errorf("invalid user ID type %T", userID))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID type"})
 		return
 	}
@@ -123,6 +131,7 @@ func (h *ProfileHandler) CreateAddress(c *gin.Context) {
 
 	address, err := h.profileQueries.CreateUserAddress(id, &req)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create address"})
 		return
 	}
@@ -140,6 +149,7 @@ func (h *ProfileHandler) UpdateAddress(c *gin.Context) {
 
 	userIDInt, ok := userID.(int)
 	if !ok {
+		c.Error(fmt.Errorf("invalid user ID type %T", userID))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID type"})
 		return
 	}
@@ -163,6 +173,7 @@ func (h *ProfileHandler) UpdateAddress(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Address not found"})
 			return
 		}
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update address"})
 		return
 	}
@@ -180,6 +191,7 @@ func (h *ProfileHandler) DeleteAddress(c *gin.Context) {
 
 	userIDInt, ok := userID.(int)
 	if !ok {
+		c.Error(fmt.Errorf("invalid user ID type %T", userID))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID type"})
 		return
 	}
@@ -197,6 +209,7 @@ func (h *ProfileHandler) DeleteAddress(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Address not found"})
 			return
 		}
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete address"})
 		return
 	}
@@ -214,6 +227,7 @@ func (h *ProfileHandler) SetDefaultAddress(c *gin.Context) {
 
 	userIDInt, ok := userID.(int)
 	if !ok {
+		c.Error(fmt.Errorf("invalid user ID type %T", userID))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID type"})
 		return
 	}
@@ -231,6 +245,7 @@ func (h *ProfileHandler) SetDefaultAddress(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Address not found"})
 			return
 		}
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set default address"})
 		return
 	}
@@ -1,13 +1,16 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
 	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/middleware"
 	"notsofluffy-backend/internal/models"
 
 	"github.com/gin-gonic/gin"
@@ -20,23 +23,37 @@ type PublicHandler struct {
 	productQueries      *database.ProductQueries
 	settingsQueries     *database.SettingsQueries
 	clientReviewQueries *database.ClientReviewQueries
+	pairingQueries      *database.PairingQueries
+	attachmentQueries   *database.AttachmentQueries
+	optionQueries       *database.OptionQueries
+	reviewQueries       *database.ProductReviewQueries
+	translationQueries  *database.TranslationQueries
+	siteURL             string
 }
 
 // NewPublicHandler creates a new public handler
-func NewPublicHandler(db *sql.DB) *PublicHandler {
+func NewPublicHandler(db *sql.DB, siteURL string) *PublicHandler {
 	return &PublicHandler{
 		db:                  db,
+		siteURL:             siteURL,
 		categoryQueries:     database.NewCategoryQueries(db),
 		productQueries:      database.NewProductQueries(db),
 		settingsQueries:     database.NewSettingsQueries(db),
 		clientReviewQueries: database.NewClientReviewQueries(db),
+		pairingQueries:      database.NewPairingQueries(db),
+		attachmentQueries:   database.NewAttachmentQueries(db),
+		optionQueries:       database.NewOptionQueries(db),
+		reviewQueries:       database.NewProductReviewQueries(db),
+		translationQueries:  database.NewTranslationQueries(db),
 	}
 }
 
-// GetActiveCategories returns all active categories with images
+// GetActiveCategories returns all active categories with images and the number of
+// products each shows in the web shop
 func (h *PublicHandler) GetActiveCategories(c *gin.Context) {
 	categories, err := h.categoryQueries.GetActiveCategories()
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categories"})
 		return
 	}
@@ -44,6 +61,7 @@ func (h *PublicHandler) GetActiveCategories(c *gin.Context) {
 	// Convert to response format
 	categoryResponses := make([]models.CategoryResponse, len(categories))
 	for i, cat := range categories {
+		productCount := cat.ProductCount
 		categoryResponses[i] = models.CategoryResponse{
 			ID:        cat.ID,
 			Name:      cat.Name,
@@ -54,8 +72,10 @@ func (h *PublicHandler) GetActiveCategories(c *gin.Context) {
 			CreatedAt: cat.CreatedAt.Format(time.RFC3339),
 			UpdatedAt: cat.UpdatedAt.Format(time.RFC3339),
 			Image:     cat.Image,
+			ProductCount: &productCount,
 		}
 	}
+	h.translateCategories(c, categoryResponses)
 
 	c.JSON(http.StatusOK, gin.H{
 		"categories": categoryResponses,
@@ -91,17 +111,20 @@ func (h *PublicHandler) GetPublicProducts(c *gin.Context) {
 			}
 		}
 	}
+	tags := database.NormalizeTags(c.QueryArray("tag"))
 
 	// Call the database query method
-	products, err := h.productQueries.GetPublicProducts(page, limit, search, categoryIDs)
+	products, err := h.productQueries.GetPublicProducts(page, limit, search, categoryIDs, tags)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch products", "details": err.Error()})
 		return
 	}
 
 	// Get total count for pagination
-	total, err := h.productQueries.GetPublicProductsCount(search, categoryIDs)
+	total, err := h.productQueries.GetPublicProductsCount(search, categoryIDs, tags)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch product count", "details": err.Error()})
 		return
 	}
@@ -112,6 +135,7 @@ func (h *PublicHandler) GetPublicProducts(c *gin.Context) {
 		productResponses[i] = models.ProductResponse{
 			ID:               product.ID,
 			Name:             product.Name,
+			Slug:             product.Slug,
 			ShortDescription: product.ShortDescription,
 			Description:      product.Description,
 			MaterialID:       product.MaterialID,
@@ -127,6 +151,10 @@ func (h *PublicHandler) GetPublicProducts(c *gin.Context) {
 			MinPrice:         product.MinPrice,
 		}
 	}
+	h.attachRatings(c.Request.Context(), productResponses)
+	h.attachTags(c.Request.Context(), productResponses)
+	h.translateProducts(c, productResponses)
+	formatProducts(priceFormat(c), productResponses)
 
 	c.JSON(http.StatusOK, gin.H{
 		"products": productResponses,
@@ -152,14 +180,22 @@ func (h *PublicHandler) GetPublicProduct(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
 			return
 		}
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch product", "details": err.Error()})
 		return
 	}
 
+	// Products hidden from the web shop are only available through other channels
+	if !product.Channels.Web {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+
 	// Convert to response format
 	productResponse := models.ProductResponse{
 		ID:               product.ID,
 		Name:             product.Name,
+		Slug:             product.Slug,
 		ShortDescription: product.ShortDescription,
 		Description:      product.Description,
 		MaterialID:       product.MaterialID,
@@ -174,10 +210,18 @@ func (h *PublicHandler) GetPublicProduct(c *gin.Context) {
 		AdditionalServices: product.AdditionalServices,
 		MinPrice:         product.MinPrice,
 	}
+	responses := []models.ProductResponse{productResponse}
+	h.attachRatings(c.Request.Context(), responses)
+	h.attachTags(c.Request.Context(), responses)
+	h.translateProducts(c, responses)
+	format := priceFormat(c)
+	formatProducts(format, responses)
+	productResponse = responses[0]
 
 	// Get product variants
 	variants, err := h.productQueries.GetProductVariants(productID)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch product variants", "details": err.Error()})
 		return
 	}
@@ -185,14 +229,60 @@ func (h *PublicHandler) GetPublicProduct(c *gin.Context) {
 	// Get product sizes
 	sizes, err := h.productQueries.GetProductSizes(productID)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch product sizes", "details": err.Error()})
 		return
 	}
+	formatSizes(format, sizes)
+
+	// Previous/next product in the same category
+	prev, next, err := h.productQueries.GetAdjacentProducts(productID)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch adjacent products", "details": err.Error()})
+		return
+	}
+
+	// Frequently bought together suggestions are optional, never fail the page for them
+	pairedProducts, err := h.pairingQueries.GetFrequentlyBoughtTogether(productID, models.PairingSuggestionLimit)
+	if err != nil {
+		middleware.Logger(c.Request.Context()).Error("failed to get frequently bought together", "product_id", productID, "error", err)
+		pairedProducts = []models.PairedProduct{}
+	}
+	for i := range pairedProducts {
+		pairedProducts[i].Slug = productSlug(pairedProducts[i].Slug, pairedProducts[i].Name)
+		pairedProducts[i].Path = productPath(pairedProducts[i].ID, pairedProducts[i].Slug)
+	}
+
+	attachments, err := h.attachmentQueries.ListProductAttachments(productID)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch product attachments", "details": err.Error()})
+		return
+	}
+
+	optionGroups, err := h.optionQueries.ListProductOptionGroups(productID)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch product options", "details": err.Error()})
+		return
+	}
+
+	canonical := productCanonical(h.siteURL, product.ID, product.Slug, product.Name)
 
 	c.JSON(http.StatusOK, gin.H{
-		"product":  productResponse,
-		"variants": variants,
-		"sizes":    sizes,
+		"product":       productResponse,
+		"variants":      variants,
+		"sizes":         sizes,
+		"option_groups": optionGroups,
+		"breadcrumbs":   productBreadcrumbs(product, canonical),
+		"canonical":     canonical,
+		"navigation": models.ProductNavigation{
+			Previous: withProductPath(prev),
+			Next:     withProductPath(next),
+		},
+		"frequently_bought_together": pairedProducts,
+		"attachments":                publicAttachments(attachments),
 	})
 }
 
@@ -224,6 +314,7 @@ func (h *PublicHandler) SearchProducts(c *gin.Context) {
 			}
 		}
 	}
+	tags := database.NormalizeTags(c.QueryArray("tag"))
 
 	// Validate and set limits
 	if page < 1 {
@@ -235,14 +326,16 @@ func (h *PublicHandler) SearchProducts(c *gin.Context) {
 
 	// If no search query, return popular/recent products
 	if query == "" {
-		products, err := h.productQueries.GetPublicProducts(page, limit, "", categoryIDs)
+		products, err := h.productQueries.GetPublicProducts(page, limit, "", categoryIDs, tags)
 		if err != nil {
+			c.Error(err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch products", "details": err.Error()})
 			return
 		}
 
-		total, err := h.productQueries.GetPublicProductsCount("", categoryIDs)
+		total, err := h.productQueries.GetPublicProductsCount("", categoryIDs, tags)
 		if err != nil {
+			c.Error(err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch product count", "details": err.Error()})
 			return
 		}
@@ -253,6 +346,7 @@ func (h *PublicHandler) SearchProducts(c *gin.Context) {
 			productResponses[i] = models.ProductResponse{
 				ID:               product.ID,
 				Name:             product.Name,
+				Slug:             product.Slug,
 				ShortDescription: product.ShortDescription,
 				Description:      product.Description,
 				MaterialID:       product.MaterialID,
@@ -268,6 +362,9 @@ func (h *PublicHandler) SearchProducts(c *gin.Context) {
 				MinPrice:         product.MinPrice,
 			}
 		}
+		h.attachTags(c.Request.Context(), productResponses)
+		h.translateProducts(c, productResponses)
+		formatProducts(priceFormat(c), productResponses)
 
 		c.JSON(http.StatusOK, gin.H{
 			"products": productResponses,
@@ -282,15 +379,17 @@ func (h *PublicHandler) SearchProducts(c *gin.Context) {
 	}
 
 	// Perform search with the query
-	products, err := h.productQueries.SearchProductsEnhanced(page, limit, query, categoryIDs, sortBy)
+	products, err := h.productQueries.SearchProductsEnhanced(page, limit, query, categoryIDs, tags, sortBy)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Search failed", "details": err.Error()})
 		return
 	}
 
 	// Get total count for pagination
-	total, err := h.productQueries.GetSearchProductsCount(query, categoryIDs)
+	total, err := h.productQueries.GetSearchProductsCount(query, categoryIDs, tags)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get search count", "details": err.Error()})
 		return
 	}
@@ -301,6 +400,7 @@ func (h *PublicHandler) SearchProducts(c *gin.Context) {
 		productResponses[i] = models.ProductResponse{
 			ID:               product.ID,
 			Name:             product.Name,
+			Slug:             product.Slug,
 			ShortDescription: product.ShortDescription,
 			Description:      product.Description,
 			MaterialID:       product.MaterialID,
@@ -316,6 +416,9 @@ func (h *PublicHandler) SearchProducts(c *gin.Context) {
 			MinPrice:         product.MinPrice,
 		}
 	}
+	h.attachTags(c.Request.Context(), productResponses)
+	h.translateProducts(c, productResponses)
+	formatProducts(priceFormat(c), productResponses)
 
 	c.JSON(http.StatusOK, gin.H{
 		"products": productResponses,
@@ -346,38 +449,186 @@ func (h *PublicHandler) GetSearchSuggestions(c *gin.Context) {
 
 	suggestions, err := h.productQueries.GetSearchSuggestions(query, limit)
 	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get suggestions", "details": err.Error()})
+		return
+	}
+
+	tags, err := h.productQueries.GetTagSuggestions(query, limit)
+	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get suggestions", "details": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"suggestions": suggestions,
+		"tags":        tags,
 		"query":       query,
 	})
 }
 
-// GetMaintenanceStatus returns the current maintenance mode status
+// GetTagCloud returns the most used product tags with their weight from 1 to 5 for
+// merchandising pages, optionally only of products in the given categories
+func (h *PublicHandler) GetTagCloud(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(models.DefaultTagCloudLimit)))
+	if limit < 1 || limit > 100 {
+		limit = models.DefaultTagCloudLimit
+	}
+
+	var categoryIDs []int
+	if categoryNames := c.QueryArray("category"); len(categoryNames) > 0 {
+		categories, err := h.categoryQueries.GetActiveCategories()
+		if err != nil {
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categories", "details": err.Error()})
+			return
+		}
+		for _, name := range categoryNames {
+			name = strings.TrimSpace(name)
+			for _, cat := range categories {
+				if cat.Name == name || cat.Slug == name {
+					categoryIDs = append(categoryIDs, cat.ID)
+					break
+				}
+			}
+		}
+		// Unknown categories have no tags rather than all of them
+		if len(categoryIDs) == 0 {
+			c.JSON(http.StatusOK, gin.H{"tags": []models.TagCloudItem{}})
+			return
+		}
+	}
+
+	cloud, err := h.productQueries.GetTagCloud(categoryIDs, limit)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get tag cloud", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tags": cloud})
+}
+
+// GetMaintenanceStatus returns the current maintenance mode and checkout status
 func (h *PublicHandler) GetMaintenanceStatus(c *gin.Context) {
 	isMaintenanceMode, err := h.settingsQueries.GetMaintenanceMode()
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get maintenance status"})
 		return
 	}
 
+	// Checkout status is informational here; the order endpoints enforce it
+	checkout, err := h.settingsQueries.GetCheckoutStatus()
+	if err != nil {
+		middleware.Logger(c.Request.Context()).Error("failed to get checkout status", "error", err)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"maintenance_mode": isMaintenanceMode,
+		"maintenance_mode":  isMaintenanceMode,
+		"checkout_disabled": checkout.Disabled,
+		"checkout_message":  checkout.Message,
 	})
 }
 
-// GetActiveClientReviews returns all active client reviews for the homepage gallery
+// GetActiveClientReviews returns a page of active client reviews for the homepage
+// gallery, optionally only those with ?rating= or at least ?min_rating= stars
 func (h *PublicHandler) GetActiveClientReviews(c *gin.Context) {
-	reviews, err := h.clientReviewQueries.GetActiveClientReviews()
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	filter := models.ClientReviewFilter{ActiveOnly: true}
+	var ok bool
+	if filter.Rating, ok = ratingQuery(c, "rating"); !ok {
+		return
+	}
+	if filter.MinRating, ok = ratingQuery(c, "min_rating"); !ok {
+		return
+	}
+
+	reviews, total, err := h.clientReviewQueries.ListClientReviews(page, limit, filter)
 	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch client reviews"})
 		return
 	}
+	if reviews == nil {
+		reviews = []models.ClientReview{}
+	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"client_reviews": reviews,
+	c.JSON(http.StatusOK, models.ClientReviewListResponse{
+		ClientReviews: reviews,
+		Total:         total,
+		Page:          page,
+		Limit:         limit,
 	})
-}
\ No newline at end of file
+}
+
+// GetClientReviewSummary returns the count and average rating of active client reviews
+func (h *PublicHandler) GetClientReviewSummary(c *gin.Context) {
+	summary, err := h.clientReviewQueries.GetClientReviewSummary()
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch client review summary"})
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// attachRatings sets the approved review rating of each product; ratings are optional,
+// so the listing is served without them when they can't be loaded
+func (h *PublicHandler) attachRatings(ctx context.Context, products []models.ProductResponse) {
+	ids := make([]int, len(products))
+	for i, product := range products {
+		ids[i] = product.ID
+	}
+	ratings, err := h.reviewQueries.GetProductRatings(ids)
+	if err != nil {
+		middleware.Logger(ctx).Error("failed to get product ratings", "error", err)
+		return
+	}
+	for i := range products {
+		if rating, ok := ratings[products[i].ID]; ok {
+			products[i].Rating = &rating
+		}
+	}
+}
+
+// attachTags sets the tags of each product; like ratings they are optional
+func (h *PublicHandler) attachTags(ctx context.Context, products []models.ProductResponse) {
+	ids := make([]int, len(products))
+	for i, product := range products {
+		ids[i] = product.ID
+	}
+	tags, err := h.productQueries.GetProductTags(ids)
+	if err != nil {
+		middleware.Logger(ctx).Error("failed to get product tags", "error", err)
+		return
+	}
+	for i := range products {
+		products[i].Tags = tags[products[i].ID]
+	}
+}
+
+// ratingQuery parses an optional star rating query parameter, writing a 400 response
+// and returning false when it is not a number from 1 to 5
+func ratingQuery(c *gin.Context, name string) (*int, bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return nil, true
+	}
+	rating, err := strconv.Atoi(raw)
+	if err != nil || rating < models.MinClientReviewRating || rating > models.MaxClientReviewRating {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s must be a number from %d to %d", name, models.MinClientReviewRating, models.MaxClientReviewRating)})
+		return nil, false
+	}
+	return &rating, true
+}
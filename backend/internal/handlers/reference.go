@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/geoip"
+	"notsofluffy-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReferenceHandler struct {
+	settingsQueries *database.SettingsQueries
+}
+
+func NewReferenceHandler(settingsQueries *database.SettingsQueries) *ReferenceHandler {
+	return &ReferenceHandler{settingsQueries: settingsQueries}
+}
+
+// GetCountries returns the countries addresses can be in with their regions and postal
+// code formats, and whether orders ship there per the shipping_countries setting.
+// ?shipping=true leaves out the countries orders don't ship to.
+func (h *ReferenceHandler) GetCountries(c *gin.Context) {
+	setting, err := h.settingsQueries.GetSettingByKey(models.SettingShippingCountries)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get shipping countries"})
+		return
+	}
+	var value string
+	if setting != nil {
+		value = setting.Value
+	}
+	ships := shippingCountries(value)
+	onlyShipping := c.Query("shipping") == "true"
+
+	response := models.CountryReferenceResponse{HomeCountry: geoip.HomeCountry.Code, Countries: []models.ReferenceCountry{}}
+	for _, country := range geoip.Countries() {
+		if onlyShipping && !ships[country.Code] {
+			continue
+		}
+		defaults := geoip.DefaultsFor(country.Code)
+		reference := models.ReferenceCountry{
+			Code:              country.Code,
+			Name:              country.Name,
+			Ships:             ships[country.Code],
+			Currency:          defaults.Currency,
+			Language:          defaults.Language,
+			RegionLabel:       country.RegionLabel,
+			RegionRequired:    country.RegionRequired,
+			Regions:           make([]models.ReferenceRegion, len(country.Regions)),
+			PostalCodeFormat:  country.PostalCodeFormat,
+			PostalCodePattern: country.PostalCodePattern(),
+		}
+		for i, region := range country.Regions {
+			reference.Regions[i] = models.ReferenceRegion{Code: country.Code + "-" + region.Code, Name: region.Name}
+		}
+		response.Countries = append(response.Countries, reference)
+	}
+
+	// The data only changes with a deploy or the setting
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, response)
+}
+
+// shippingCountries parses the shipping_countries setting; when it is empty orders ship
+// to every known country
+func shippingCountries(value string) map[string]bool {
+	ships := map[string]bool{}
+	for _, code := range strings.Split(value, ",") {
+		if country, ok := geoip.LookupCountry(code); ok {
+			ships[country.Code] = true
+		}
+	}
+	if len(ships) == 0 {
+		for _, country := range geoip.Countries() {
+			ships[country.Code] = true
+		}
+	}
+	return ships
+}
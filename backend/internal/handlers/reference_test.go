@@ -0,0 +1,13 @@
+package handlers
+
+import "testing"
+
+func TestShippingCountries(t *testing.T) {
+	ships := shippingCountries(" pl, DE,XX,,cz")
+	if len(ships) != 3 || !ships["PL"] || !ships["DE"] || !ships["CZ"] {
+		t.Fatalf("expected the listed known countries, got %v", ships)
+	}
+	if ships := shippingCountries(""); !ships["PL"] || !ships["US"] {
+		t.Fatalf("expected every country without the setting, got %v", ships)
+	}
+}
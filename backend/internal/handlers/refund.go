@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/middleware"
+	"notsofluffy-backend/internal/models"
+	"notsofluffy-backend/internal/payments"
+)
+
+type RefundHandler struct {
+	refundQueries *database.RefundQueries
+	orderQueries  *database.OrderQueries
+	provider      payments.Provider
+}
+
+func NewRefundHandler(refundQueries *database.RefundQueries, orderQueries *database.OrderQueries, provider payments.Provider) *RefundHandler {
+	return &RefundHandler{
+		refundQueries: refundQueries,
+		orderQueries:  orderQueries,
+		provider:      provider,
+	}
+}
+
+// CreateRefund issues a full or partial refund for an order through the payment provider
+func (h *RefundHandler) CreateRefund(c *gin.Context) {
+	orderID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	var req models.RefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	order, err := h.orderQueries.GetOrderByID(orderID)
+	if err != nil {
+		if err.Error() == "order not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get order"})
+		return
+	}
+
+	var createdBy *int
+	if userIDValue, exists := c.Get("user_id"); exists {
+		if id, ok := userIDValue.(int); ok {
+			createdBy = &id
+		}
+	}
+
+	refund, err := h.refundQueries.CreateRefund(orderID, &req, h.provider.Name(), createdBy)
+	if err != nil {
+		if strings.Contains(err.Error(), "exceed") || strings.Contains(err.Error(), "not found in order") || strings.Contains(err.Error(), "more than once") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create refund"})
+		return
+	}
+
+	if status, err := h.refundAtProvider(c, order, refund, req.ProviderRefundID); err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, refund)
+}
+
+// refundAtProvider sends a pending refund to the payment provider and records the
+// outcome on it. On failure it returns the response status and message for the admin.
+func (h *RefundHandler) refundAtProvider(c *gin.Context, order *models.OrderResponse, refund *models.Refund, providerRefundID *string) (int, error) {
+	params := payments.RefundParams{
+		OrderID: order.ID,
+		Amount:  refund.Amount,
+		Reason:  refund.Reason,
+	}
+	if order.PaymentMethod != nil {
+		params.PaymentMethod = *order.PaymentMethod
+	}
+
+	result, err := h.provider.Refund(c.Request.Context(), params)
+	if err != nil {
+		middleware.Logger(c.Request.Context()).Warn("refund failed at provider", "refund_id", refund.ID, "order_id", order.ID, "provider", h.provider.Name(), "error", err)
+		if failErr := h.refundQueries.FailRefund(refund.ID, err.Error()); failErr != nil {
+			middleware.Logger(c.Request.Context()).Error("failed to mark refund failed", "refund_id", refund.ID, "error", failErr)
+		}
+		return http.StatusBadGateway, errors.New("Payment provider rejected the refund")
+	}
+
+	// Manual refunds have no provider ID; keep the reference entered by staff
+	if result != nil && result.ProviderRefundID != "" {
+		providerRefundID = &result.ProviderRefundID
+	}
+
+	if err := h.refundQueries.CompleteRefund(refund.ID, providerRefundID); err != nil {
+		c.Error(err)
+		return http.StatusInternalServerError, errors.New("Failed to complete refund")
+	}
+
+	refund.Status = models.RefundStatusCompleted
+	refund.ProviderRefundID = providerRefundID
+	return 0, nil
+}
+
+// GetOrderRefunds lists refunds issued for an order
+func (h *RefundHandler) GetOrderRefunds(c *gin.Context) {
+	orderID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	order, err := h.orderQueries.GetOrderByID(orderID)
+	if err != nil {
+		if err.Error() == "order not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get order"})
+		return
+	}
+
+	refunds, err := h.refundQueries.GetRefundsByOrderID(orderID)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get refunds"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.OrderRefundsResponse{
+		Refunds:          refunds,
+		RefundedAmount:   order.RefundedAmount,
+		RefundableAmount: order.TotalAmount - order.RefundedAmount,
+	})
+}
+
+// GetSalesReport returns revenue, refunds and net revenue for an optional date range
+func (h *RefundHandler) GetSalesReport(c *gin.Context) {
+	var from, to *time.Time
+
+	if fromStr := c.Query("from"); fromStr != "" {
+		t, err := time.Parse("2006-01-02", fromStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date, expected YYYY-MM-DD"})
+			return
+		}
+		from = &t
+	}
+
+	if toStr := c.Query("to"); toStr != "" {
+		t, err := time.Parse("2006-01-02", toStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date, expected YYYY-MM-DD"})
+			return
+		}
+		// Include the whole end day
+		t = t.AddDate(0, 0, 1)
+		to = &t
+	}
+
+	report, err := h.refundQueries.GetSalesReport(from, to)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate sales report"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"notsofluffy-backend/internal/database"
+)
+
+type RetentionHandler struct {
+	retentionQueries *database.RetentionQueries
+}
+
+func NewRetentionHandler(retentionQueries *database.RetentionQueries) *RetentionHandler {
+	return &RetentionHandler{retentionQueries: retentionQueries}
+}
+
+// PreviewRetention returns a dry-run report of what the retention rules would purge
+func (h *RetentionHandler) PreviewRetention(c *gin.Context) {
+	report, err := h.retentionQueries.PreviewRetention()
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to preview retention"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// RunRetention applies the retention rules immediately instead of waiting for the scheduled job
+func (h *RetentionHandler) RunRetention(c *gin.Context) {
+	report, err := h.retentionQueries.ApplyRetention()
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply retention"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
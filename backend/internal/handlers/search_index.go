@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"notsofluffy-backend/internal/jobs"
+)
+
+type SearchIndexHandler struct {
+	reindexer *jobs.SearchReindexer
+}
+
+func NewSearchIndexHandler(reindexer *jobs.SearchReindexer) *SearchIndexHandler {
+	return &SearchIndexHandler{reindexer: reindexer}
+}
+
+// Reindex starts rebuilding the product search index in the background; poll
+// GetReindexStatus for its progress
+func (h *SearchIndexHandler) Reindex(c *gin.Context) {
+	if !h.reindexer.Start() {
+		c.JSON(http.StatusConflict, gin.H{"error": "Search reindex is already running"})
+		return
+	}
+
+	status, err := h.reindexer.Status()
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get search reindex status"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, status)
+}
+
+// GetReindexStatus returns the progress of the last search index rebuild
+func (h *SearchIndexHandler) GetReindexStatus(c *gin.Context) {
+	status, err := h.reindexer.Status()
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get search reindex status"})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
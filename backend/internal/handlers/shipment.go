@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/geoip"
+	"notsofluffy-backend/internal/middleware"
+	"notsofluffy-backend/internal/models"
+	"notsofluffy-backend/internal/shipping"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ShipmentHandler orders shipments for orders from the configured carrier and serves
+// their labels. Without a carrier the endpoints answer 503 and orders are shipped by
+// hand with the mark_shipped order action.
+type ShipmentHandler struct {
+	orderQueries    *database.OrderQueries
+	shipmentQueries *database.ShipmentQueries
+	provider        shipping.Provider
+}
+
+func NewShipmentHandler(orderQueries *database.OrderQueries, shipmentQueries *database.ShipmentQueries, provider shipping.Provider) *ShipmentHandler {
+	return &ShipmentHandler{orderQueries: orderQueries, shipmentQueries: shipmentQueries, provider: provider}
+}
+
+// CreateShipment orders a shipment for an order to its shipping address. The carrier
+// assigns the tracking number shortly after; it is fetched with GetShipment.
+func (h *ShipmentHandler) CreateShipment(c *gin.Context) {
+	id, ok := h.orderID(c)
+	if !ok {
+		return
+	}
+
+	var req models.ShipmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.TargetPoint = strings.ToUpper(strings.TrimSpace(req.TargetPoint))
+	switch req.Service {
+	case shipping.ServiceLocker:
+		if req.TargetPoint == "" || req.ParcelTemplate == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "target_point and parcel_template are required for locker shipments"})
+			return
+		}
+	case shipping.ServiceCourier:
+		if req.WeightKg <= 0 || req.LengthCm <= 0 || req.WidthCm <= 0 || req.HeightCm <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "weight_kg and the parcel dimensions are required for courier shipments"})
+			return
+		}
+	}
+
+	order, err := h.orderQueries.GetOrderByID(id)
+	if err != nil {
+		if err.Error() == "order not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get order"})
+		return
+	}
+	if order.Status == models.OrderStatusCancelled {
+		c.JSON(http.StatusConflict, gin.H{"error": "order is cancelled"})
+		return
+	}
+	if !req.Replace {
+		if existing, err := h.shipmentQueries.GetLatestShipment(id); err == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "Order already has a shipment", "shipment": existing})
+			return
+		} else if err.Error() != "shipment not found" {
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get shipment"})
+			return
+		}
+	}
+
+	addresses, err := h.orderQueries.GetShippingAddresses([]int{id})
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get shipping addresses"})
+		return
+	}
+	if len(addresses) == 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Order has no shipping address"})
+		return
+	}
+	addr := addresses[0]
+	country := geoip.CountryCode(addr.Country)
+	if country == "" {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Unsupported shipping country"})
+		return
+	}
+
+	params := shipping.ShipmentParams{
+		OrderID:     id,
+		Reference:   fmt.Sprintf("Order #%d", id),
+		Service:     req.Service,
+		TargetPoint: req.TargetPoint,
+		Receiver: shipping.Address{
+			Name:       strings.TrimSpace(addr.FirstName + " " + addr.LastName),
+			Line1:      addr.AddressLine1,
+			PostalCode: addr.PostalCode,
+			City:       addr.City,
+			Country:    country,
+			Phone:      addr.Phone,
+			Email:      order.Email,
+		},
+		Parcel: shipping.Parcel{
+			Template: req.ParcelTemplate,
+			WeightKg: req.WeightKg,
+			LengthCm: req.LengthCm,
+			WidthCm:  req.WidthCm,
+			HeightCm: req.HeightCm,
+		},
+		Currency: models.PaymentCurrency,
+	}
+	if addr.Company != nil {
+		params.Receiver.Company = *addr.Company
+	}
+	if addr.AddressLine2 != nil {
+		params.Receiver.Line2 = *addr.AddressLine2
+	}
+	if req.Insure {
+		params.InsuranceAmount = order.TotalAmount
+	}
+
+	created, err := h.provider.CreateShipment(c.Request.Context(), params)
+	if err != nil {
+		middleware.Logger(c.Request.Context()).Error("failed to create shipment", "provider", h.provider.Name(), "order_id", id, "error", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to create shipment: " + err.Error()})
+		return
+	}
+
+	shipment := &models.Shipment{
+		OrderID:            id,
+		Provider:           h.provider.Name(),
+		ProviderShipmentID: created.ProviderShipmentID,
+		Service:            req.Service,
+		Status:             created.Status,
+		CreatedBy:          getUserIDPtr(c),
+	}
+	if req.TargetPoint != "" {
+		shipment.TargetPoint = &req.TargetPoint
+	}
+	shipment.TrackingNumber, shipment.TrackingURL = h.tracking(created.TrackingNumber)
+
+	saved, err := h.shipmentQueries.CreateShipment(shipment, h.provider.Carrier())
+	if err != nil {
+		// The carrier has the shipment, so staff must be able to find it there
+		middleware.Logger(c.Request.Context()).Error("failed to save shipment", "provider", shipment.Provider, "shipment", shipment.ProviderShipmentID, "order_id", id, "error", err)
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save shipment", "provider_shipment_id": shipment.ProviderShipmentID})
+		return
+	}
+
+	c.JSON(http.StatusCreated, saved)
+}
+
+// GetShipment returns the latest shipment of an order, asking the carrier for its
+// status and tracking number while it has none
+func (h *ShipmentHandler) GetShipment(c *gin.Context) {
+	shipment, ok := h.refreshedShipment(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, shipment)
+}
+
+// GetShipmentLabel returns the carrier's PDF label of the latest shipment of an order
+func (h *ShipmentHandler) GetShipmentLabel(c *gin.Context) {
+	shipment, ok := h.refreshedShipment(c)
+	if !ok {
+		return
+	}
+	if shipment.Provider != h.provider.Name() {
+		c.JSON(http.StatusConflict, gin.H{"error": "Shipment was created with another shipping provider"})
+		return
+	}
+
+	label, err := h.provider.Label(c.Request.Context(), shipment.ProviderShipmentID)
+	if err != nil {
+		if errors.Is(err, shipping.ErrLabelNotReady) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Shipment label is not ready yet", "status": shipment.Status})
+			return
+		}
+		middleware.Logger(c.Request.Context()).Error("failed to get shipment label", "provider", shipment.Provider, "shipment", shipment.ProviderShipmentID, "error", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to get shipment label"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=label-order-%d.pdf", shipment.OrderID))
+	c.Data(http.StatusOK, "application/pdf", label)
+}
+
+// refreshedShipment returns the latest shipment of the order in the path, updated
+// from the carrier when it has no tracking number yet. A failing carrier leaves the
+// stored shipment as is.
+func (h *ShipmentHandler) refreshedShipment(c *gin.Context) (*models.Shipment, bool) {
+	id, ok := h.orderID(c)
+	if !ok {
+		return nil, false
+	}
+
+	shipment, err := h.shipmentQueries.GetLatestShipment(id)
+	if err != nil {
+		if err.Error() == "shipment not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Shipment not found"})
+			return nil, false
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get shipment"})
+		return nil, false
+	}
+	if shipment.TrackingNumber != nil || shipment.Provider != h.provider.Name() {
+		return shipment, true
+	}
+
+	current, err := h.provider.GetShipment(c.Request.Context(), shipment.ProviderShipmentID)
+	if err != nil {
+		middleware.Logger(c.Request.Context()).Error("failed to get shipment", "provider", shipment.Provider, "shipment", shipment.ProviderShipmentID, "error", err)
+		return shipment, true
+	}
+	trackingNumber, trackingURL := h.tracking(current.TrackingNumber)
+	updated, err := h.shipmentQueries.UpdateShipmentTracking(shipment.ID, current.Status, trackingNumber, trackingURL, h.provider.Carrier())
+	if err != nil {
+		middleware.Logger(c.Request.Context()).Error("failed to update shipment", "shipment_id", shipment.ID, "error", err)
+		return shipment, true
+	}
+	return updated, true
+}
+
+// orderID parses the order ID in the path and checks a carrier is configured
+func (h *ShipmentHandler) orderID(c *gin.Context) (int, bool) {
+	if h.provider == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "No shipping provider is configured"})
+		return 0, false
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return 0, false
+	}
+	return id, true
+}
+
+// tracking returns the tracking number and page to store, nil while there is no number
+func (h *ShipmentHandler) tracking(number string) (*string, *string) {
+	if number == "" {
+		return nil, nil
+	}
+	url := h.provider.TrackingURL(number)
+	return &number, &url
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ShippingMethodHandler struct {
+	shippingMethodQueries *database.ShippingMethodQueries
+}
+
+func NewShippingMethodHandler(shippingMethodQueries *database.ShippingMethodQueries) *ShippingMethodHandler {
+	return &ShippingMethodHandler{shippingMethodQueries: shippingMethodQueries}
+}
+
+// ListPublicShippingMethods returns the active shipping methods in checkout order; the
+// first one is used when the customer doesn't pick one
+func (h *ShippingMethodHandler) ListPublicShippingMethods(c *gin.Context) {
+	methods, err := h.shippingMethodQueries.ListShippingMethods(true)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get shipping methods"})
+		return
+	}
+	formatShippingMethods(priceFormat(c), methods)
+
+	c.JSON(http.StatusOK, gin.H{"shipping_methods": methods})
+}
+
+// ListShippingMethods returns all shipping methods, including inactive ones
+func (h *ShippingMethodHandler) ListShippingMethods(c *gin.Context) {
+	methods, err := h.shippingMethodQueries.ListShippingMethods(false)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get shipping methods"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"shipping_methods": methods})
+}
+
+// CreateShippingMethod creates a new shipping method
+func (h *ShippingMethodHandler) CreateShippingMethod(c *gin.Context) {
+	var req models.ShippingMethodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	method, err := h.shippingMethodQueries.CreateShippingMethod(&req)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create shipping method"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, method)
+}
+
+// UpdateShippingMethod updates a shipping method
+func (h *ShippingMethodHandler) UpdateShippingMethod(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shipping method ID"})
+		return
+	}
+
+	var req models.ShippingMethodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	method, err := h.shippingMethodQueries.UpdateShippingMethod(id, &req)
+	if err != nil {
+		if err.Error() == "shipping method not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Shipping method not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update shipping method"})
+		return
+	}
+
+	c.JSON(http.StatusOK, method)
+}
+
+// DeleteShippingMethod deletes a shipping method; placed orders keep its name and price
+func (h *ShippingMethodHandler) DeleteShippingMethod(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shipping method ID"})
+		return
+	}
+
+	if err := h.shippingMethodQueries.DeleteShippingMethod(id); err != nil {
+		if err.Error() == "shipping method not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Shipping method not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete shipping method"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Shipping method deleted successfully"})
+}
+
+// resolveShippingMethod returns the shipping method a cart or order ships with, answering
+// 400 for unknown or inactive methods
+func resolveShippingMethod(c *gin.Context, queries *database.ShippingMethodQueries, id *int) (*models.ShippingMethod, bool) {
+	method, err := queries.ResolveShippingMethod(id)
+	if err != nil {
+		if err.Error() == "shipping method not found" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shipping method"})
+			return nil, false
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get shipping method"})
+		return nil, false
+	}
+	return method, true
+}
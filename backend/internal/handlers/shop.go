@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/middleware"
+	"notsofluffy-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ShopHandler struct {
+	shopQueries *database.ShopQueries
+}
+
+func NewShopHandler(shopQueries *database.ShopQueries) *ShopHandler {
+	return &ShopHandler{shopQueries: shopQueries}
+}
+
+// GetShopProfile returns the brand name, canonical domain and theme tokens of the shop
+// serving the request's host
+func (h *ShopHandler) GetShopProfile(c *gin.Context) {
+	shop := middleware.GetShop(c)
+	if shop == nil {
+		var err error
+		if shop, err = h.shopQueries.GetShopByID(models.DefaultShopID); err != nil {
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get shop"})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, shop.Profile())
+}
+
+// ListShops returns all shops
+func (h *ShopHandler) ListShops(c *gin.Context) {
+	shops, err := h.shopQueries.ListShops()
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get shops"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"shops": shops})
+}
+
+// CreateShop creates a new shop
+func (h *ShopHandler) CreateShop(c *gin.Context) {
+	var req models.ShopRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	shop, err := h.shopQueries.CreateShop(&req)
+	if err != nil {
+		if !h.shopConflict(c, err) {
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create shop"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, shop)
+}
+
+// UpdateShop updates a shop
+func (h *ShopHandler) UpdateShop(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shop ID"})
+		return
+	}
+
+	var req models.ShopRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	shop, err := h.shopQueries.UpdateShop(id, &req)
+	if err != nil {
+		if err.Error() == "shop not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Shop not found"})
+			return
+		}
+		if !h.shopConflict(c, err) {
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update shop"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, shop)
+}
+
+// shopConflict responds 409 when err is a taken slug or domain and reports whether it did
+func (h *ShopHandler) shopConflict(c *gin.Context, err error) bool {
+	switch {
+	case strings.Contains(err.Error(), "duplicate key"):
+		c.JSON(http.StatusConflict, gin.H{"error": "Shop slug already exists"})
+	case strings.HasPrefix(err.Error(), "domain "):
+		c.JSON(http.StatusConflict, gin.H{"error": "Domain is already used by another shop"})
+	default:
+		return false
+	}
+	return true
+}
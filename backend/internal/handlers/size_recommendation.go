@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"notsofluffy-backend/internal/models"
+	"notsofluffy-backend/internal/sizing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RecommendSize returns the size of a product that fits a pet's measurements best, so
+// the web shop and mobile apps recommend the same size
+func (h *PublicHandler) RecommendSize(c *gin.Context) {
+	var req models.SizeRecommendationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	for dimension, value := range req.Measurements {
+		if !isSizeDimension(dimension) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown measurement " + dimension + ", expected one of a-f"})
+			return
+		}
+		if value < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Measurement " + dimension + " must not be negative"})
+			return
+		}
+	}
+
+	product, err := h.productQueries.GetProduct(req.ProductID)
+	if err != nil {
+		if err.Error() == "product not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch product"})
+		return
+	}
+	if !product.Channels.Web {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+
+	sizes, err := h.productQueries.GetProductSizes(req.ProductID)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch product sizes"})
+		return
+	}
+
+	candidates := make([]sizing.Size, len(sizes))
+	for i, size := range sizes {
+		candidates[i] = sizing.Size{
+			ID:   size.ID,
+			Name: size.Name,
+			Values: map[string]float64{
+				"a": size.A, "b": size.B, "c": size.C, "d": size.D, "e": size.E, "f": size.F,
+			},
+		}
+	}
+
+	recommendation, err := sizing.Recommend(candidates, req.Measurements)
+	if err != nil {
+		switch {
+		case errors.Is(err, sizing.ErrNoSizes):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "This product has no sizes to recommend", "code": "no_sizes"})
+		case errors.Is(err, sizing.ErrNoMeasurements):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "None of the measurements apply to this product's sizes", "code": "no_matching_measurements"})
+		default:
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to recommend a size"})
+		}
+		return
+	}
+
+	response := models.SizeRecommendationResponse{
+		ProductID:             req.ProductID,
+		Fits:                  recommendation.Fits,
+		Confidence:            recommendation.Confidence,
+		ConstrainingDimension: recommendation.ConstrainingDimension,
+		Comparisons:           make([]models.SizeComparison, len(recommendation.Comparisons)),
+		UnmatchedDimensions:   recommendation.Unmatched,
+	}
+	for _, size := range sizes {
+		if size.ID == recommendation.Size.ID {
+			response.Size = size
+			break
+		}
+	}
+	for i, comparison := range recommendation.Comparisons {
+		response.Comparisons[i] = models.SizeComparison{
+			Dimension:   comparison.Dimension,
+			Measurement: comparison.Measurement,
+			SizeValue:   comparison.SizeValue,
+			Difference:  comparison.Difference,
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func isSizeDimension(dimension string) bool {
+	for _, known := range sizing.Dimensions {
+		if dimension == known {
+			return true
+		}
+	}
+	return false
+}
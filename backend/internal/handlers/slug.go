@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"notsofluffy-backend/internal/models"
+	"notsofluffy-backend/internal/slug"
+)
+
+var (
+	errInvalidSlug = errors.New("invalid slug")
+	errSlugTaken   = errors.New("slug already exists")
+)
+
+// resolveSlug returns requested when it is a valid slug nothing else uses, or a free
+// slug generated from name when requested is empty
+func resolveSlug(requested, name, fallback string, exists func(string) (bool, error)) (string, error) {
+	if requested == "" {
+		return slug.Unique(name, fallback, exists)
+	}
+	if !slug.Valid(requested) {
+		return "", errInvalidSlug
+	}
+	taken, err := exists(requested)
+	if err != nil {
+		return "", err
+	}
+	if taken {
+		return "", errSlugTaken
+	}
+	return requested, nil
+}
+
+// respondSlugError writes the response for an error returned by resolveSlug
+func respondSlugError(c *gin.Context, err error) {
+	switch err {
+	case errInvalidSlug:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Slug may only contain lowercase letters, digits and single dashes"})
+	case errSlugTaken:
+		c.JSON(http.StatusConflict, gin.H{"error": "Slug already exists"})
+	default:
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check slug"})
+	}
+}
+
+// slugExists returns the uniqueness check of the given entity type, ignoring the record
+// being edited when excludeID is set
+func (h *AdminHandler) slugExists(slugType string, excludeID *int) (func(string) (bool, error), bool) {
+	switch slugType {
+	case models.SlugTypeCategory:
+		return func(s string) (bool, error) { return h.categoryQueries.SlugExists(s, excludeID) }, true
+	case models.SlugTypeProduct:
+		return func(s string) (bool, error) { return h.productQueries.SlugExists(s, excludeID) }, true
+	}
+	return nil, false
+}
+
+// PreviewSlug returns the slug a category or product named ?name= would get when saved
+// without one. ?exclude_id= leaves out the record being edited.
+func (h *AdminHandler) PreviewSlug(c *gin.Context) {
+	name := c.Query("name")
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+	slugType := c.DefaultQuery("type", models.SlugTypeProduct)
+
+	var excludeID *int
+	if raw := c.Query("exclude_id"); raw != "" {
+		id, err := strconv.Atoi(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid exclude_id"})
+			return
+		}
+		excludeID = &id
+	}
+
+	exists, ok := h.slugExists(slugType, excludeID)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type must be category or product"})
+		return
+	}
+
+	generated, err := slug.Unique(name, slugType, exists)
+	if err != nil {
+		respondSlugError(c, err)
+		return
+	}
+
+	base := slug.Make(name)
+	if base == "" {
+		base = slugType
+	}
+	c.JSON(http.StatusOK, models.SlugPreviewResponse{
+		Type: slugType,
+		Name: name,
+		Base: base,
+		Slug: generated,
+	})
+}
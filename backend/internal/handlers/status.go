@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/models"
+)
+
+// statusPingTimeout bounds the database check of the status page
+const statusPingTimeout = 2 * time.Second
+
+type StatusHandler struct {
+	db              *sql.DB
+	settingsQueries *database.SettingsQueries
+}
+
+func NewStatusHandler(db *sql.DB, settingsQueries *database.SettingsQueries) *StatusHandler {
+	return &StatusHandler{db: db, settingsQueries: settingsQueries}
+}
+
+// GetStatus returns the data of the public status page. The API state is checked live;
+// payments, shipping and the incident notes are maintained in the status_* settings.
+func (h *StatusHandler) GetStatus(c *gin.Context) {
+	now := time.Now()
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), statusPingTimeout)
+	defer cancel()
+	if err := h.db.PingContext(ctx); err != nil {
+		components := []models.StatusComponent{
+			{Name: "api", Status: models.StatusOutage},
+			{Name: "payments", Status: models.StatusOperational},
+			{Name: "shipping", Status: models.StatusOperational},
+		}
+		c.JSON(http.StatusOK, models.StatusResponse{
+			Status:     overallStatus(components),
+			Components: components,
+			Incidents:  []models.StatusIncident{},
+			CheckedAt:  now,
+		})
+		return
+	}
+
+	apiStatus := models.StatusOperational
+	if maintenance, err := h.settingsQueries.GetMaintenanceMode(); err == nil && maintenance {
+		apiStatus = models.StatusDegraded
+	}
+	components := []models.StatusComponent{
+		{Name: "api", Status: apiStatus},
+		{Name: "payments", Status: h.componentStatus(models.SettingStatusPayments)},
+		{Name: "shipping", Status: h.componentStatus(models.SettingStatusShipping)},
+	}
+
+	var incidents []models.StatusIncident
+	if setting, err := h.settingsQueries.GetSettingByKey(models.SettingStatusIncidents); err == nil && setting != nil {
+		incidents, _ = parseStatusIncidents(setting.Value)
+	}
+
+	c.JSON(http.StatusOK, models.StatusResponse{
+		Status:     overallStatus(components),
+		Components: components,
+		Incidents:  recentIncidents(incidents, now),
+		CheckedAt:  now,
+	})
+}
+
+// componentStatus returns the state an admin reported in key, operational when unset
+func (h *StatusHandler) componentStatus(key string) string {
+	setting, err := h.settingsQueries.GetSettingByKey(key)
+	if err != nil || setting == nil || !validComponentStatus(setting.Value) {
+		return models.StatusOperational
+	}
+	return setting.Value
+}
+
+func validComponentStatus(status string) bool {
+	return status == models.StatusOperational || status == models.StatusDegraded || status == models.StatusOutage
+}
+
+// overallStatus is operational only when every component is
+func overallStatus(components []models.StatusComponent) string {
+	for _, component := range components {
+		if component.Status != models.StatusOperational {
+			return models.StatusDegraded
+		}
+	}
+	return models.StatusOperational
+}
+
+// parseStatusIncidents reads the incident notes of the status_incidents setting
+func parseStatusIncidents(value string) ([]models.StatusIncident, error) {
+	var incidents []models.StatusIncident
+	if value == "" {
+		return incidents, nil
+	}
+	if err := json.Unmarshal([]byte(value), &incidents); err != nil {
+		return nil, err
+	}
+	return incidents, nil
+}
+
+// recentIncidents returns the newest incidents of the last StatusIncidentDays. Unresolved
+// incidents are always shown.
+func recentIncidents(incidents []models.StatusIncident, now time.Time) []models.StatusIncident {
+	cutoff := now.AddDate(0, 0, -models.StatusIncidentDays)
+	recent := []models.StatusIncident{}
+	for _, incident := range incidents {
+		if incident.ResolvedAt == nil || incident.CreatedAt.After(cutoff) {
+			recent = append(recent, incident)
+		}
+	}
+	sort.SliceStable(recent, func(i, j int) bool { return recent[i].CreatedAt.After(recent[j].CreatedAt) })
+	if len(recent) > models.MaxStatusIncidents {
+		recent = recent[:models.MaxStatusIncidents]
+	}
+	return recent
+}
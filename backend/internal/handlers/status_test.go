@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"notsofluffy-backend/internal/models"
+)
+
+func TestOverallStatus(t *testing.T) {
+	components := []models.StatusComponent{
+		{Name: "api", Status: models.StatusOperational},
+		{Name: "payments", Status: models.StatusOperational},
+	}
+	if got := overallStatus(components); got != models.StatusOperational {
+		t.Fatalf("expected operational, got %s", got)
+	}
+	components[1].Status = models.StatusOutage
+	if got := overallStatus(components); got != models.StatusDegraded {
+		t.Fatalf("expected degraded when a component is down, got %s", got)
+	}
+}
+
+func TestRecentIncidents(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	incidents, err := parseStatusIncidents(`[
+		{"title": "Old", "message": "", "status": "outage", "created_at": "2024-05-01T10:00:00Z", "resolved_at": "2024-05-01T12:00:00Z"},
+		{"title": "Ongoing", "message": "", "status": "degraded", "created_at": "2024-04-01T10:00:00Z"},
+		{"title": "New", "message": "", "status": "degraded", "created_at": "2024-06-14T10:00:00Z", "resolved_at": "2024-06-14T11:00:00Z"}
+	]`)
+	if err != nil {
+		t.Fatalf("failed to parse incidents: %v", err)
+	}
+
+	recent := recentIncidents(incidents, now)
+	if len(recent) != 2 || recent[0].Title != "New" || recent[1].Title != "Ongoing" {
+		t.Fatalf("expected New and Ongoing newest first, got %+v", recent)
+	}
+
+	if _, err := parseStatusIncidents("not json"); err == nil {
+		t.Error("expected invalid incident notes to be rejected")
+	}
+}
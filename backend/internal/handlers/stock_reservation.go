@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"net/http"
+
+	"notsofluffy-backend/internal/jobs"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StockReservationHandler struct {
+	sweeper *jobs.ReservationSweeper
+}
+
+func NewStockReservationHandler(sweeper *jobs.ReservationSweeper) *StockReservationHandler {
+	return &StockReservationHandler{sweeper: sweeper}
+}
+
+// GetReservationStats returns the stock reservations held by checkouts in progress and
+// how many expired ones the sweeper has returned since the server started
+func (h *StockReservationHandler) GetReservationStats(c *gin.Context) {
+	stats, err := h.sweeper.Stats()
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get stock reservations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
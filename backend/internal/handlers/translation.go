@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/geoip"
+	"notsofluffy-backend/internal/middleware"
+	"notsofluffy-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TranslationHandler manages the translations of products, categories and additional
+// services. Content is written in the first storefront language and translated to the
+// others.
+type TranslationHandler struct {
+	translationQueries *database.TranslationQueries
+}
+
+func NewTranslationHandler(translationQueries *database.TranslationQueries) *TranslationHandler {
+	return &TranslationHandler{translationQueries: translationQueries}
+}
+
+// translationKinds maps the kinds of content to the messages of their invalid and
+// missing IDs
+var translationKinds = map[string]struct{ invalidID, notFound string }{
+	models.TranslationProduct:           {"Invalid product ID", "Product not found"},
+	models.TranslationCategory:          {"Invalid category ID", "Category not found"},
+	models.TranslationAdditionalService: {"Invalid service ID", "Additional service not found"},
+}
+
+// contentLanguage returns the language catalog content is written in
+func contentLanguage() string {
+	return geoip.StorefrontLanguages[0]
+}
+
+// translationLanguage reports whether content can be translated to a language
+func translationLanguage(language string) bool {
+	for _, l := range geoip.StorefrontLanguages[1:] {
+		if language == l {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *TranslationHandler) ListProductTranslations(c *gin.Context) {
+	h.listTranslations(c, models.TranslationProduct)
+}
+
+func (h *TranslationHandler) SaveProductTranslation(c *gin.Context) {
+	h.saveTranslation(c, models.TranslationProduct)
+}
+
+func (h *TranslationHandler) DeleteProductTranslation(c *gin.Context) {
+	h.deleteTranslation(c, models.TranslationProduct)
+}
+
+func (h *TranslationHandler) ListCategoryTranslations(c *gin.Context) {
+	h.listTranslations(c, models.TranslationCategory)
+}
+
+func (h *TranslationHandler) SaveCategoryTranslation(c *gin.Context) {
+	h.saveTranslation(c, models.TranslationCategory)
+}
+
+func (h *TranslationHandler) DeleteCategoryTranslation(c *gin.Context) {
+	h.deleteTranslation(c, models.TranslationCategory)
+}
+
+func (h *TranslationHandler) ListAdditionalServiceTranslations(c *gin.Context) {
+	h.listTranslations(c, models.TranslationAdditionalService)
+}
+
+func (h *TranslationHandler) SaveAdditionalServiceTranslation(c *gin.Context) {
+	h.saveTranslation(c, models.TranslationAdditionalService)
+}
+
+func (h *TranslationHandler) DeleteAdditionalServiceTranslation(c *gin.Context) {
+	h.deleteTranslation(c, models.TranslationAdditionalService)
+}
+
+// translationParams parses the ID and, when withLanguage is set, the language of a
+// translation request, writing a 400 response and returning false when one is invalid
+func translationParams(c *gin.Context, kind string, withLanguage bool) (int, string, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": translationKinds[kind].invalidID})
+		return 0, "", false
+	}
+	if !withLanguage {
+		return id, "", true
+	}
+	language := strings.ToLower(c.Param("language"))
+	if !translationLanguage(language) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported language"})
+		return 0, "", false
+	}
+	return id, language, true
+}
+
+func (h *TranslationHandler) listTranslations(c *gin.Context, kind string) {
+	id, _, ok := translationParams(c, kind, false)
+	if !ok {
+		return
+	}
+
+	translations, err := h.translationQueries.ListTranslations(kind, id)
+	if err != nil {
+		if err.Error() == kind+" not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": translationKinds[kind].notFound})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get translations"})
+		return
+	}
+	c.JSON(http.StatusOK, models.TranslationListResponse{SourceLanguage: contentLanguage(), Translations: translations})
+}
+
+func (h *TranslationHandler) saveTranslation(c *gin.Context, kind string) {
+	id, language, ok := translationParams(c, kind, true)
+	if !ok {
+		return
+	}
+
+	var req models.TranslationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	translation, err := h.translationQueries.SaveTranslation(kind, id, language, &req)
+	if err != nil {
+		if err.Error() == kind+" not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": translationKinds[kind].notFound})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save translation"})
+		return
+	}
+	c.JSON(http.StatusOK, translation)
+}
+
+func (h *TranslationHandler) deleteTranslation(c *gin.Context, kind string) {
+	id, language, ok := translationParams(c, kind, true)
+	if !ok {
+		return
+	}
+
+	if err := h.translationQueries.DeleteTranslation(kind, id, language); err != nil {
+		if err.Error() == "translation not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Translation not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete translation"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Translation deleted successfully"})
+}
+
+// applyTranslation overwrites the fields a translation has text for
+func applyTranslation(translation models.Translation, name, shortDescription, description *string) {
+	if translation.Name != "" {
+		*name = translation.Name
+	}
+	if shortDescription != nil && translation.ShortDescription != "" {
+		*shortDescription = translation.ShortDescription
+	}
+	if description != nil && translation.Description != "" {
+		*description = translation.Description
+	}
+}
+
+// translateProducts shows products, their categories and services in the language of
+// the request. Text without a translation stays in the content language, as does
+// everything when translations can't be loaded.
+func (h *PublicHandler) translateProducts(c *gin.Context, products []models.ProductResponse) {
+	language := middleware.GetLanguage(c)
+	if language == contentLanguage() || len(products) == 0 {
+		return
+	}
+
+	var productIDs, categoryIDs, serviceIDs []int
+	for _, product := range products {
+		productIDs = append(productIDs, product.ID)
+		if product.Category != nil {
+			categoryIDs = append(categoryIDs, product.Category.ID)
+		}
+		for _, service := range product.AdditionalServices {
+			serviceIDs = append(serviceIDs, service.ID)
+		}
+	}
+	productTranslations := h.getTranslations(c.Request.Context(), models.TranslationProduct, language, productIDs)
+	categoryTranslations := h.getTranslations(c.Request.Context(), models.TranslationCategory, language, categoryIDs)
+	serviceTranslations := h.getTranslations(c.Request.Context(), models.TranslationAdditionalService, language, serviceIDs)
+
+	for i := range products {
+		product := &products[i]
+		applyTranslation(productTranslations[product.ID], &product.Name, &product.ShortDescription, &product.Description)
+		if product.Category != nil {
+			category := *product.Category
+			applyTranslation(categoryTranslations[category.ID], &category.Name, nil, nil)
+			product.Category = &category
+		}
+		services := make([]models.AdditionalServiceResponse, len(product.AdditionalServices))
+		for j, service := range product.AdditionalServices {
+			applyTranslation(serviceTranslations[service.ID], &service.Name, nil, &service.Description)
+			services[j] = service
+		}
+		if product.AdditionalServices != nil {
+			product.AdditionalServices = services
+		}
+	}
+}
+
+// translateCategories shows categories in the language of the request
+func (h *PublicHandler) translateCategories(c *gin.Context, categories []models.CategoryResponse) {
+	language := middleware.GetLanguage(c)
+	if language == contentLanguage() || len(categories) == 0 {
+		return
+	}
+
+	ids := make([]int, len(categories))
+	for i, category := range categories {
+		ids[i] = category.ID
+	}
+	translations := h.getTranslations(c.Request.Context(), models.TranslationCategory, language, ids)
+	for i := range categories {
+		applyTranslation(translations[categories[i].ID], &categories[i].Name, nil, nil)
+	}
+}
+
+// getTranslations loads translations for the public API, logging failures and returning
+// none
+func (h *PublicHandler) getTranslations(ctx context.Context, kind, language string, ids []int) map[int]models.Translation {
+	translations, err := h.translationQueries.GetTranslations(kind, language, ids)
+	if err != nil {
+		middleware.Logger(ctx).Error("failed to get translations", "kind", kind, "error", err)
+		return nil
+	}
+	return translations
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"notsofluffy-backend/internal/middleware"
+	"notsofluffy-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSaveTranslationRejectsInvalidParams(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewTranslationHandler(nil)
+	cases := []struct{ id, language, want string }{
+		{"x", "en", "Invalid product ID"},
+		{"1", "pl", "Unsupported language"},
+		{"1", "de", "Unsupported language"},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{{Key: "id", Value: tc.id}, {Key: "language", Value: tc.language}}
+		c.Request = httptest.NewRequest(http.MethodPut, "/api/admin/products/"+tc.id+"/translations/"+tc.language, bytes.NewBufferString(`{"name":"x"}`))
+		h.SaveProductTranslation(c)
+		if w.Code != http.StatusBadRequest || !bytes.Contains(w.Body.Bytes(), []byte(tc.want)) {
+			t.Fatalf("%s/%s: expected %q, got %d %s", tc.id, tc.language, tc.want, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestApplyTranslationKeepsUntranslatedFields(t *testing.T) {
+	name, short, description := "Legowisko", "Krótki opis", "Opis"
+	applyTranslation(models.Translation{Name: "Dog bed", Description: "Description"}, &name, &short, &description)
+	if name != "Dog bed" || short != "Krótki opis" || description != "Description" {
+		t.Fatalf("unexpected translation: %q %q %q", name, short, description)
+	}
+
+	// A missing translation leaves the content as written
+	applyTranslation(models.Translation{}, &name, nil, nil)
+	if name != "Dog bed" {
+		t.Fatalf("expected the name to stay, got %q", name)
+	}
+}
+
+func TestTranslateProductsSkipsContentLanguage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Set(middleware.LanguageKey, contentLanguage())
+
+	// Without translation queries this would panic if translations were loaded
+	h := &PublicHandler{}
+	products := []models.ProductResponse{{ID: 1, Name: "Legowisko"}}
+	h.translateProducts(c, products)
+	if products[0].Name != "Legowisko" {
+		t.Fatalf("expected the name to stay, got %q", products[0].Name)
+	}
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"notsofluffy-backend/internal/database"
+)
+
+type TrashHandler struct {
+	trashQueries *database.TrashQueries
+}
+
+func NewTrashHandler(trashQueries *database.TrashQueries) *TrashHandler {
+	return &TrashHandler{trashQueries: trashQueries}
+}
+
+// ListTrash returns deleted items that can still be restored
+func (h *TrashHandler) ListTrash(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	entityType := c.Query("entity_type")
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	if entityType != "" && !database.IsTrashEntityType(entityType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entity type"})
+		return
+	}
+
+	response, err := h.trashQueries.ListTrash(page, limit, entityType)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list trash"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetTrashItem returns a deleted item with its saved state
+func (h *TrashHandler) GetTrashItem(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid trash item ID"})
+		return
+	}
+
+	item, err := h.trashQueries.GetTrashItem(id)
+	if err != nil {
+		if err.Error() == "trash item not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Trash item not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get trash item"})
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+// RestoreTrashItem puts a deleted item back with its dependent records
+func (h *TrashHandler) RestoreTrashItem(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid trash item ID"})
+		return
+	}
+
+	result, err := h.trashQueries.RestoreTrashItem(id)
+	if err != nil {
+		switch {
+		case err.Error() == "trash item not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": "Trash item not found"})
+		case strings.HasPrefix(err.Error(), "trash item conflicts"):
+			c.JSON(http.StatusConflict, gin.H{"error": "Item cannot be restored because it conflicts with current data"})
+		default:
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore trash item"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Item restored successfully", "result": result})
+}
+
+// PurgeTrashItem permanently deletes an item from the trash
+func (h *TrashHandler) PurgeTrashItem(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid trash item ID"})
+		return
+	}
+
+	if err := h.trashQueries.PurgeTrashItem(id); err != nil {
+		if err.Error() == "trash item not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Trash item not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to purge trash item"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Trash item purged permanently"})
+}
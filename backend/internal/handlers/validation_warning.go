@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+
+	"notsofluffy-backend/internal/imaging"
+	"notsofluffy-backend/internal/middleware"
+	"notsofluffy-backend/internal/models"
+	"notsofluffy-backend/internal/validation"
+)
+
+// imageInfo returns an image with its pixel size, left zero when the file can't be read
+func imageInfo(ctx context.Context, image models.ImageResponse) validation.Image {
+	info := validation.Image{ID: image.ID, HasAltText: strings.TrimSpace(image.AltText) != ""}
+	if !imaging.Supported(image.MimeType) {
+		return info
+	}
+	width, height, err := imaging.Dimensions(image.Path)
+	if err != nil {
+		middleware.Logger(ctx).Warn("failed to read image dimensions", "image_id", image.ID, "error", err)
+		return info
+	}
+	info.Width, info.Height = width, height
+	return info
+}
+
+// countDefaultVariants returns the number of variants and how many of them are the default
+func countDefaultVariants(variants []models.ProductVariantResponse) (int, int) {
+	defaults := 0
+	for _, variant := range variants {
+		if variant.IsDefault {
+			defaults++
+		}
+	}
+	return len(variants), defaults
+}
+
+// productWarnings runs the soft validation of a saved product. Lookups that fail are
+// logged and skipped; warnings never fail the request that saved the product.
+func (h *AdminHandler) productWarnings(ctx context.Context, product *models.ProductWithRelations) []models.ValidationWarning {
+	facts := validation.Product{
+		MainImage: imageInfo(ctx, product.MainImage),
+		Category:  product.CategoryID != nil,
+		Channels:  product.Channels,
+	}
+	for _, image := range product.Images {
+		facts.Images = append(facts.Images, imageInfo(ctx, image))
+	}
+
+	sizes, err := h.productQueries.GetProductSizes(product.ID)
+	if err != nil {
+		middleware.Logger(ctx).Error("failed to get sizes for validation", "product_id", product.ID, "error", err)
+		return []models.ValidationWarning{}
+	}
+	facts.Sizes = sizes
+
+	variants, err := h.productQueries.GetProductVariants(product.ID)
+	if err != nil {
+		middleware.Logger(ctx).Error("failed to get variants for validation", "product_id", product.ID, "error", err)
+		return []models.ValidationWarning{}
+	}
+	facts.Variants, facts.DefaultVariants = countDefaultVariants(variants)
+
+	return validation.CheckProduct(facts)
+}
+
+// variantWarnings runs the soft validation of a saved product variant
+func (h *AdminHandler) variantWarnings(ctx context.Context, variantID int) []models.ValidationWarning {
+	variant, err := h.productVariantQueries.GetProductVariantByID(variantID)
+	if err != nil {
+		middleware.Logger(ctx).Error("failed to get product variant for validation", "variant_id", variantID, "error", err)
+		return []models.ValidationWarning{}
+	}
+
+	facts := validation.Variant{}
+	for _, image := range variant.Images {
+		facts.Images = append(facts.Images, imageInfo(ctx, image))
+	}
+
+	sizes, err := h.productQueries.GetProductSizes(variant.ProductID)
+	if err != nil {
+		middleware.Logger(ctx).Error("failed to get sizes for validation", "product_id", variant.ProductID, "error", err)
+		return []models.ValidationWarning{}
+	}
+	facts.ProductSizes = len(sizes)
+
+	variants, err := h.productQueries.GetProductVariants(variant.ProductID)
+	if err != nil {
+		middleware.Logger(ctx).Error("failed to get variants for validation", "product_id", variant.ProductID, "error", err)
+		return []models.ValidationWarning{}
+	}
+	_, facts.ProductDefaultVariants = countDefaultVariants(variants)
+
+	return validation.CheckVariant(facts)
+}
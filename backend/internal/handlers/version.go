@@ -0,0 +1,13 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"notsofluffy-backend/internal/version"
+)
+
+// GetVersion reports the deployed build
+func GetVersion(c *gin.Context) {
+	c.JSON(http.StatusOK, version.Get())
+}
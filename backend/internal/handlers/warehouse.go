@@ -0,0 +1,323 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/models"
+)
+
+type WarehouseHandler struct {
+	warehouseQueries *database.WarehouseQueries
+}
+
+func NewWarehouseHandler(warehouseQueries *database.WarehouseQueries) *WarehouseHandler {
+	return &WarehouseHandler{warehouseQueries: warehouseQueries}
+}
+
+// ListWarehouses returns all warehouses
+func (h *WarehouseHandler) ListWarehouses(c *gin.Context) {
+	warehouses, err := h.warehouseQueries.ListWarehouses()
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get warehouses"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"warehouses": warehouses})
+}
+
+// CreateWarehouse creates a new warehouse
+func (h *WarehouseHandler) CreateWarehouse(c *gin.Context) {
+	var req models.WarehouseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	warehouse, err := h.warehouseQueries.CreateWarehouse(&req)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			c.JSON(http.StatusConflict, gin.H{"error": "Warehouse code already exists"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create warehouse"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, warehouse)
+}
+
+// UpdateWarehouse updates a warehouse
+func (h *WarehouseHandler) UpdateWarehouse(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid warehouse ID"})
+		return
+	}
+
+	var req models.WarehouseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	warehouse, err := h.warehouseQueries.UpdateWarehouse(id, &req)
+	if err != nil {
+		if err.Error() == "warehouse not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Warehouse not found"})
+			return
+		}
+		if strings.Contains(err.Error(), "duplicate key") {
+			c.JSON(http.StatusConflict, gin.H{"error": "Warehouse code already exists"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update warehouse"})
+		return
+	}
+
+	c.JSON(http.StatusOK, warehouse)
+}
+
+// DeleteWarehouse deletes an empty warehouse
+func (h *WarehouseHandler) DeleteWarehouse(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid warehouse ID"})
+		return
+	}
+
+	if err := h.warehouseQueries.DeleteWarehouse(id); err != nil {
+		switch {
+		case err.Error() == "warehouse not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": "Warehouse not found"})
+		case strings.HasPrefix(err.Error(), "cannot delete"):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete warehouse"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Warehouse deleted successfully"})
+}
+
+// SetWarehouseStock sets the quantity of a size held in a warehouse
+func (h *WarehouseHandler) SetWarehouseStock(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid warehouse ID"})
+		return
+	}
+
+	var req models.WarehouseStockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.warehouseQueries.SetWarehouseStock(id, &req, getUserIDPtr(c)); err != nil {
+		if strings.HasSuffix(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update warehouse stock"})
+		return
+	}
+
+	stock, err := h.warehouseQueries.GetSizeStock(req.SizeID)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get warehouse stock"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"size_id": req.SizeID, "stock": stock})
+}
+
+// BulkUpdateStock sets or adds to the stock of many sizes in one call. All rows are
+// applied in one transaction; when any fails nothing changes and 422 lists the results.
+func (h *WarehouseHandler) BulkUpdateStock(c *gin.Context) {
+	var req models.BulkStockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Mode == "" {
+		req.Mode = models.BulkStockModeSet
+	}
+	if req.Mode == models.BulkStockModeSet {
+		for _, item := range req.Items {
+			if item.Quantity < 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Quantity cannot be negative in set mode"})
+				return
+			}
+		}
+	}
+
+	response, err := h.warehouseQueries.BulkUpdateStock(&req, getUserIDPtr(c))
+	if err != nil {
+		if err.Error() == "warehouse not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Warehouse not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update stock"})
+		return
+	}
+
+	if !response.Applied {
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetSizeWarehouseStock returns the per-warehouse stock of a size
+func (h *WarehouseHandler) GetSizeWarehouseStock(c *gin.Context) {
+	sizeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid size ID"})
+		return
+	}
+
+	stock, err := h.warehouseQueries.GetSizeStock(sizeID)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get warehouse stock"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"size_id": sizeID, "stock": stock})
+}
+
+// TransferStock moves stock between warehouses
+func (h *WarehouseHandler) TransferStock(c *gin.Context) {
+	var req models.StockTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.warehouseQueries.TransferStock(&req, getUserIDPtr(c)); err != nil {
+		switch {
+		case err.Error() == "warehouse not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": "Warehouse not found"})
+		case strings.HasPrefix(err.Error(), "insufficient stock"):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to transfer stock"})
+		}
+		return
+	}
+
+	stock, err := h.warehouseQueries.GetSizeStock(req.SizeID)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get warehouse stock"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"size_id": req.SizeID, "stock": stock})
+}
+
+// GetPickList returns order items allocated to a warehouse that still need picking
+func (h *WarehouseHandler) GetPickList(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid warehouse ID"})
+		return
+	}
+
+	warehouse, err := h.warehouseQueries.GetWarehouseByID(id)
+	if err != nil {
+		if err.Error() == "warehouse not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Warehouse not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get warehouse"})
+		return
+	}
+
+	items, err := h.warehouseQueries.GetPickList(id)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get pick list"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.PickListResponse{Warehouse: *warehouse, Items: items})
+}
+
+// MarkPicked marks pick list entries of a warehouse as picked
+func (h *WarehouseHandler) MarkPicked(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid warehouse ID"})
+		return
+	}
+
+	var req models.MarkPickedRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updated, err := h.warehouseQueries.MarkPicked(id, req.AllocationIDs)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark items as picked"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"updated": updated})
+}
+
+// ListStockMovements returns the stock movement log
+func (h *WarehouseHandler) ListStockMovements(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 50
+	}
+
+	var sizeID, warehouseID *int
+	if v := c.Query("size_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid size ID"})
+			return
+		}
+		sizeID = &id
+	}
+	if v := c.Query("warehouse_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid warehouse ID"})
+			return
+		}
+		warehouseID = &id
+	}
+
+	response, err := h.warehouseQueries.ListStockMovements(page, limit, sizeID, warehouseID)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get stock movements"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/middleware"
+	"notsofluffy-backend/internal/models"
+)
+
+type WebhookHandler struct {
+	webhookQueries *database.WebhookQueries
+}
+
+func NewWebhookHandler(webhookQueries *database.WebhookQueries) *WebhookHandler {
+	return &WebhookHandler{webhookQueries: webhookQueries}
+}
+
+// publishWebhook queues an event for the endpoints subscribed to it. Webhooks are a side
+// effect, so a failure is logged and never fails the request.
+func publishWebhook(ctx context.Context, webhookQueries *database.WebhookQueries, event string, data interface{}) {
+	if _, err := webhookQueries.Enqueue(event, data); err != nil {
+		middleware.Logger(ctx).Error("failed to queue webhook", "event", event, "error", err)
+	}
+}
+
+// publishStatusChange sends order.status_changed, also for changes between custom
+// statuses, and order.cancelled when the order was cancelled. Test orders are kept out
+// of the feeds.
+func publishStatusChange(ctx context.Context, webhookQueries *database.WebhookQueries, change *models.OrderStatusChangedEvent) {
+	if (change.PreviousStatus == change.Status && change.PreviousCustomStatus == change.CustomStatus) || change.IsTest {
+		return
+	}
+	publishWebhook(ctx, webhookQueries, models.WebhookEventOrderStatusChanged, change)
+	if change.Status == models.OrderStatusCancelled && change.PreviousStatus != change.Status {
+		publishWebhook(ctx, webhookQueries, models.WebhookEventOrderCancelled, change)
+	}
+}
+
+// ListWebhookEndpoints returns the registered endpoints and the events they can subscribe to
+func (h *WebhookHandler) ListWebhookEndpoints(c *gin.Context) {
+	endpoints, err := h.webhookQueries.ListWebhookEndpoints()
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get webhook endpoints"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"endpoints": endpoints, "events": models.WebhookEvents})
+}
+
+// CreateWebhookEndpoint registers an endpoint. Its secret is only part of this response.
+func (h *WebhookHandler) CreateWebhookEndpoint(c *gin.Context) {
+	var req models.WebhookEndpointRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	endpoint, err := h.webhookQueries.CreateWebhookEndpoint(&req, getUserIDPtr(c))
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create webhook endpoint"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, endpoint)
+}
+
+// UpdateWebhookEndpoint changes an endpoint; a secret in the request replaces the current one
+func (h *WebhookHandler) UpdateWebhookEndpoint(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook endpoint ID"})
+		return
+	}
+
+	var req models.WebhookEndpointRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	endpoint, err := h.webhookQueries.UpdateWebhookEndpoint(id, &req)
+	if err != nil {
+		if err.Error() == "webhook endpoint not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Webhook endpoint not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update webhook endpoint"})
+		return
+	}
+
+	if req.Secret != "" {
+		c.JSON(http.StatusOK, models.WebhookEndpointSecretResponse{WebhookEndpoint: *endpoint, Secret: req.Secret})
+		return
+	}
+	c.JSON(http.StatusOK, endpoint)
+}
+
+// DeleteWebhookEndpoint removes an endpoint and its delivery log
+func (h *WebhookHandler) DeleteWebhookEndpoint(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook endpoint ID"})
+		return
+	}
+
+	if err := h.webhookQueries.DeleteWebhookEndpoint(id); err != nil {
+		if err.Error() == "webhook endpoint not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Webhook endpoint not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete webhook endpoint"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook endpoint deleted successfully"})
+}
+
+// ListWebhookDeliveries returns the delivery log, optionally of ?endpoint_id= or in ?status=
+func (h *WebhookHandler) ListWebhookDeliveries(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	var endpointID *int
+	if param := c.Query("endpoint_id"); param != "" {
+		id, err := strconv.Atoi(param)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook endpoint ID"})
+			return
+		}
+		endpointID = &id
+	}
+
+	status := c.Query("status")
+	switch status {
+	case "", models.WebhookDeliveryPending, models.WebhookDeliverySucceeded, models.WebhookDeliveryFailed:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be 'pending', 'succeeded' or 'failed'"})
+		return
+	}
+
+	deliveries, err := h.webhookQueries.ListDeliveries(endpointID, status, page, limit)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get webhook deliveries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// ReplayWebhookDelivery sends a delivery again, e.g. after a failed endpoint was fixed
+func (h *WebhookHandler) ReplayWebhookDelivery(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook delivery ID"})
+		return
+	}
+
+	delivery, err := h.webhookQueries.ReplayDelivery(id)
+	if err != nil {
+		if err.Error() == "webhook delivery not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Webhook delivery not found"})
+			return
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to replay webhook delivery"})
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
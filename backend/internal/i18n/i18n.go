@@ -0,0 +1,114 @@
+// Package i18n translates the messages of API responses. Handlers and the database layer
+// write English messages; Translate looks them up in the catalog of the requested
+// language. Messages with dynamic parts are keyed by their format string, e.g.
+// "Image with ID %d not found", and translated with the values put back in order.
+package i18n
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Languages of the message catalogs; English is the language messages are written in
+const (
+	English = "en"
+	Polish  = "pl"
+)
+
+// failurePrefix starts the generic errors of failed operations, e.g. "Failed to get order"
+const failurePrefix = "Failed to "
+
+// catalog is the translations of one language
+type catalog struct {
+	messages map[string]string
+	patterns []pattern
+	// failure translates "Failed to <action>" messages, given the translated action
+	failure  func(action string) string
+	failures map[string]string
+}
+
+// pattern matches a message written from a format string
+type pattern struct {
+	re          *regexp.Regexp
+	translation string
+}
+
+var catalogs = map[string]*catalog{
+	Polish: newCatalog(polish, polishFailures, func(action string) string { return "Nie udało się " + action }),
+}
+
+// formatVerb matches the verbs allowed in catalog keys
+var formatVerb = regexp.MustCompile(`%(s|d|\.2f)`)
+
+// verbPatterns are what the values of each verb look like in a message
+var verbPatterns = map[string]string{
+	"%s":   `(.+?)`,
+	"%d":   `(-?\d+)`,
+	"%.2f": `(-?\d+\.\d{2})`,
+}
+
+func newCatalog(messages, failures map[string]string, failure func(string) string) *catalog {
+	c := &catalog{messages: map[string]string{}, failures: failures, failure: failure}
+	for key, translation := range messages {
+		if !formatVerb.MatchString(key) {
+			c.messages[key] = translation
+			continue
+		}
+
+		var expr strings.Builder
+		expr.WriteString("^")
+		last := 0
+		for _, loc := range formatVerb.FindAllStringIndex(key, -1) {
+			expr.WriteString(regexp.QuoteMeta(key[last:loc[0]]))
+			expr.WriteString(verbPatterns[key[loc[0]:loc[1]]])
+			last = loc[1]
+		}
+		expr.WriteString(regexp.QuoteMeta(key[last:]))
+		expr.WriteString("$")
+
+		// Values are put back as the text they were matched from
+		c.patterns = append(c.patterns, pattern{
+			re:          regexp.MustCompile(expr.String()),
+			translation: formatVerb.ReplaceAllString(translation, "%s"),
+		})
+	}
+	// Longer keys are more specific, so they are tried first
+	sort.SliceStable(c.patterns, func(i, j int) bool {
+		return len(c.patterns[i].re.String()) > len(c.patterns[j].re.String())
+	})
+	return c
+}
+
+// Translate returns message in language, or message itself when the language or the
+// message has no translation. Request validation errors are rewritten into one
+// readable sentence per field, in English too.
+func Translate(language, message string) string {
+	if translated, ok := translateValidation(language, message); ok {
+		return translated
+	}
+
+	c, ok := catalogs[language]
+	if !ok {
+		return message
+	}
+	if translated, ok := c.messages[message]; ok {
+		return translated
+	}
+	if action, ok := strings.CutPrefix(message, failurePrefix); ok {
+		if translated, ok := c.failures[action]; ok {
+			return c.failure(translated)
+		}
+	}
+	for _, p := range c.patterns {
+		if match := p.re.FindStringSubmatch(message); match != nil {
+			values := make([]interface{}, len(match)-1)
+			for i, value := range match[1:] {
+				values[i] = Translate(language, value)
+			}
+			return fmt.Sprintf(p.translation, values...)
+		}
+	}
+	return message
+}
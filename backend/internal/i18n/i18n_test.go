@@ -0,0 +1,103 @@
+package i18n
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestTranslate(t *testing.T) {
+	cases := []struct {
+		language, message, want string
+	}{
+		{Polish, "Cart is empty", "Koszyk jest pusty"},
+		{Polish, "Failed to get order", "Nie udało się pobrać zamówienia"},
+		{Polish, "Minimum order amount of 150.00 required", "Wymagana minimalna wartość zamówienia: 150.00"},
+		{Polish, "Failed to apply change: insufficient stock: requested 3, available 1",
+			"Nie udało się wprowadzić zmiany: niewystarczająca ilość w magazynie: zamówiono 3, dostępne 1"},
+		{Polish, "Something nobody translated", "Something nobody translated"},
+		{English, "Cart is empty", "Cart is empty"},
+		{"de", "Cart is empty", "Cart is empty"},
+	}
+	for _, tc := range cases {
+		if got := Translate(tc.language, tc.message); got != tc.want {
+			t.Errorf("Translate(%s, %q) = %q, want %q", tc.language, tc.message, got, tc.want)
+		}
+	}
+}
+
+func TestTranslateValidation(t *testing.T) {
+	message := "Key: 'OrderRequest.ShippingAddress.FirstName' Error:Field validation for 'FirstName' failed on the 'required' tag\n" +
+		"Key: 'OrderRequest.Email' Error:Field validation for 'Email' failed on the 'email' tag"
+
+	if got, want := Translate(English, message), "shipping_address.first_name is required; email must be a valid email address"; got != want {
+		t.Errorf("English = %q, want %q", got, want)
+	}
+	if got, want := Translate(Polish, message), "Pole shipping_address.first_name jest wymagane; Pole email musi zawierać poprawny adres e-mail"; got != want {
+		t.Errorf("Polish = %q, want %q", got, want)
+	}
+
+	typeErr := "json: cannot unmarshal string into Go struct field CartItemRequest.quantity of type int"
+	if got := Translate(Polish, typeErr); got != "Pole quantity ma nieprawidłowy typ" {
+		t.Errorf("type error = %q", got)
+	}
+	if got := Translate(Polish, "EOF"); got != "Treść żądania jest pusta" {
+		t.Errorf("empty body = %q", got)
+	}
+}
+
+func TestFieldPath(t *testing.T) {
+	cases := map[string]string{
+		"Items[0].SizeID":   "items[0].size_id",
+		"NIP":               "nip",
+		"ShippingAddressID": "shipping_address_id",
+		"HTMLBody":          "html_body",
+		"AddressLine1":      "address_line1",
+	}
+	for path, want := range cases {
+		if got := fieldPath(path); got != want {
+			t.Errorf("fieldPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestCatalogPlaceholders(t *testing.T) {
+	// A translation must use the same values as the message it translates
+	for key, translation := range polish {
+		if got, want := formatVerb.FindAllString(translation, -1), formatVerb.FindAllString(key, -1); strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Errorf("translation of %q has placeholders %v, want %v", key, got, want)
+		}
+	}
+}
+
+func TestCatalogSorted(t *testing.T) {
+	// Keys are sorted within each group of lines so a new message has one place to go
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "pl.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ast.Inspect(file, func(n ast.Node) bool {
+		lit, ok := n.(*ast.CompositeLit)
+		if !ok {
+			return true
+		}
+		prevKey, prevLine := "", 0
+		for _, elt := range lit.Elts {
+			kv := elt.(*ast.KeyValueExpr)
+			key, err := strconv.Unquote(kv.Key.(*ast.BasicLit).Value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			line := fset.Position(kv.Pos()).Line
+			if line == prevLine+1 && key < prevKey {
+				t.Errorf("%q is out of order, it goes before %q", key, prevKey)
+			}
+			prevKey, prevLine = key, line
+		}
+		return false
+	})
+}
@@ -0,0 +1,636 @@
+package i18n
+
+// polish translates the English messages of API responses. Messages with dynamic parts
+// are keyed by their format string.
+var polish = map[string]string{
+	// Authentication and sessions
+	"API key is not allowed to access this resource": "Klucz API nie ma dostępu do tego zasobu",
+	"API key not found":                          "Nie znaleziono klucza API",
+	"API key required":                           "Wymagany jest klucz API",
+	"Access denied":                              "Brak dostępu",
+	"Admin access required":                      "Wymagane uprawnienia administratora",
+	"Admin session expired, please log in again": "Sesja administratora wygasła, zaloguj się ponownie",
+	"Admin session not found":                    "Nie znaleziono sesji administratora",
+	"Authentication required":                    "Wymagane jest zalogowanie",
+	"Authorization header is required":           "Wymagany jest nagłówek Authorization",
+	"Email already exists":                       "Konto z tym adresem e-mail już istnieje",
+	"Invalid API key":                            "Nieprawidłowy klucz API",
+	"Invalid authorization format":               "Nieprawidłowy format autoryzacji",
+	"Invalid code":                               "Nieprawidłowy kod",
+	"Invalid credentials":                        "Nieprawidłowy e-mail lub hasło",
+	"Invalid or expired password reset link":     "Link do resetowania hasła jest nieprawidłowy lub wygasł",
+	"Invalid password":                           "Nieprawidłowe hasło",
+	"Invalid refresh token":                      "Nieprawidłowy token odświeżania",
+	"Invalid signature":                          "Nieprawidłowy podpis",
+	"Invalid token":                              "Nieprawidłowy token",
+	"Invalid unsubscribe link":                   "Nieprawidłowy link do wypisania się",
+	"Invalid webhook token":                      "Nieprawidłowy token webhooka",
+	"No session found":                           "Nie znaleziono sesji",
+	"Password or TOTP code is required":          "Wymagane jest hasło lub kod TOTP",
+	"Please confirm your password to continue":   "Potwierdź hasło, aby kontynuować",
+	"Session not found":                          "Nie znaleziono sesji",
+	"Session revoked, please log in again":       "Sesja została unieważniona, zaloguj się ponownie",
+	"Set up two-factor authentication first":     "Najpierw skonfiguruj uwierzytelnianie dwuskładnikowe",
+	"Staff access required":                      "Wymagane uprawnienia pracownika",
+	"Two-factor authentication is not enabled":   "Uwierzytelnianie dwuskładnikowe nie jest włączone",
+	"User ID not found in context":               "Nie znaleziono identyfikatora użytkownika",
+	"User not authenticated":                     "Użytkownik nie jest zalogowany",
+	"User not found":                             "Nie znaleziono użytkownika",
+	"User role not found":                        "Nie znaleziono roli użytkownika",
+
+	// Requests and limits
+	"Challenge could not be verified, please try again": "Nie udało się zweryfikować zabezpieczenia, spróbuj ponownie",
+	"Challenge verification failed, please try again":   "Weryfikacja zabezpieczenia nie powiodła się, spróbuj ponownie",
+	"Invalid cursor":                          "Nieprawidłowy kursor",
+	"Invalid page":                            "Nieprawidłowy numer strony",
+	"Invalid request body":                    "Nieprawidłowa treść żądania",
+	"Please complete the challenge":           "Rozwiąż zabezpieczenie",
+	"Rate limit exceeded":                     "Przekroczono limit żądań",
+	"Request body too large":                  "Treść żądania jest za duża",
+	"Request body was not received in time":   "Treść żądania nie dotarła na czas",
+	"Request took too long, please try again": "Żądanie trwało zbyt długo, spróbuj ponownie",
+	"Site is under maintenance":               "Trwają prace serwisowe",
+	"Too many requests, try again later":      "Zbyt wiele żądań, spróbuj później",
+
+	// Cart, checkout and stock
+	"%s must be chosen":                    "należy wybrać: %s",
+	"Cart is empty":                        "Koszyk jest pusty",
+	"Cart item not found":                  "Nie znaleziono produktu w koszyku",
+	"Cart session not found":               "Nie znaleziono koszyka",
+	"Failed to get saved billing address":  "Nie udało się pobrać zapisanego adresu rozliczeniowego",
+	"Failed to get saved shipping address": "Nie udało się pobrać zapisanego adresu dostawy",
+	"Give either the billing address or a saved address, not both":  "Podaj adres rozliczeniowy albo wybierz zapisany adres, nie oba naraz",
+	"Give either the shipping address or a saved address, not both": "Podaj adres dostawy albo wybierz zapisany adres, nie oba naraz",
+	"Insufficient stock available":                                  "Niewystarczająca ilość w magazynie",
+	"Insufficient stock for one or more items":                      "Niewystarczająca ilość w magazynie dla co najmniej jednego produktu",
+	"Invalid NIP format. NIP must be 10 digits.":                    "Nieprawidłowy NIP. NIP musi składać się z 10 cyfr.",
+	"Invalid size for this product":                                 "Nieprawidłowy rozmiar dla tego produktu",
+	"Invalid variant for this product":                              "Nieprawidłowy wariant dla tego produktu",
+	"Log in to use a saved address":                                 "Zaloguj się, aby użyć zapisanego adresu",
+	"Made-to-order items can't be booked into production right now": "Nie można teraz zaplanować produkcji produktów na zamówienie",
+	"NIP is required when invoice is requested":                     "NIP jest wymagany do wystawienia faktury",
+	"One or more items are out of stock":                            "Co najmniej jeden produkt jest niedostępny",
+	"Online payments are not available":                             "Płatności online są niedostępne",
+	"Order totals have changed, please review your cart":            "Kwoty zamówienia uległy zmianie, sprawdź koszyk",
+	"Saved billing address not found":                               "Nie znaleziono zapisanego adresu rozliczeniowego",
+	"Saved shipping address not found":                              "Nie znaleziono zapisanego adresu dostawy",
+	"Some items in your cart are no longer available":               "Niektóre produkty w koszyku nie są już dostępne",
+	"The billing address is required":                               "Adres rozliczeniowy jest wymagany",
+	"The shipping address is required":                              "Adres dostawy jest wymagany",
+	"This size is out of stock":                                     "Ten rozmiar jest niedostępny",
+	"insufficient stock: requested %d":                              "niewystarczająca ilość w magazynie: zamówiono %d",
+	"insufficient stock: requested %d, available %d":                "niewystarczająca ilość w magazynie: zamówiono %d, dostępne %d",
+	"invalid option value for this product":                         "nieprawidłowa wartość opcji dla tego produktu",
+	"only one value can be chosen for %s":                           "dla opcji %s można wybrać tylko jedną wartość",
+
+	// Discount codes
+	"Discount code already exists":                                                          "Kod rabatowy już istnieje",
+	"Discount code has already been used":                                                   "Kod rabatowy został już wykorzystany",
+	"Discount code has expired":                                                             "Kod rabatowy wygasł",
+	"Discount code is already assigned to this customer":                                    "Kod rabatowy jest już przypisany do tego klienta",
+	"Discount code is not active":                                                           "Kod rabatowy jest nieaktywny",
+	"Discount code is not valid at this time":                                               "Kod rabatowy nie obowiązuje w tym czasie",
+	"Discount code is not yet valid":                                                        "Kod rabatowy nie jest jeszcze ważny",
+	"Discount code not found":                                                               "Nie znaleziono kodu rabatowego",
+	"Discount code usage limit reached":                                                     "Osiągnięto limit użyć kodu rabatowego",
+	"Discount code user not found":                                                          "Nie znaleziono przypisania kodu rabatowego",
+	"Invalid discount code":                                                                 "Nieprawidłowy kod rabatowy",
+	"Minimum order amount of %.2f required":                                                 "Wymagana minimalna wartość zamówienia: %.2f",
+	"This discount code has changed, please review your cart":                               "Kod rabatowy uległ zmianie, sprawdź koszyk",
+	"This discount code is already applied to your cart":                                    "Ten kod rabatowy jest już dodany do koszyka",
+	"This discount code is not available for this email address":                            "Ten kod rabatowy nie jest dostępny dla tego adresu e-mail",
+	"This discount code is not available for your account":                                  "Ten kod rabatowy nie jest dostępny dla Twojego konta",
+	"This discount code is only valid on your first order":                                  "Ten kod rabatowy obowiązuje tylko przy pierwszym zamówieniu",
+	"This discount code requires you to be logged in. Please sign in to use this discount.": "Ten kod rabatowy wymaga zalogowania. Zaloguj się, aby z niego skorzystać.",
+	"You have already used this discount code":                                              "Ten kod rabatowy został już przez Ciebie wykorzystany",
+	"discount code is only valid on the first order":                                        "kod rabatowy obowiązuje tylko przy pierwszym zamówieniu",
+	"discount code not found":                                                               "nie znaleziono kodu rabatowego",
+
+	// Orders and payments
+	"At most %d orders can be printed at once":                               "Jednorazowo można wydrukować najwyżej %d zamówień",
+	"Change request already reviewed":                                        "Prośba o zmianę została już rozpatrzona",
+	"Change request not found":                                               "Nie znaleziono prośby o zmianę",
+	"Failed to apply change: %s":                                             "Nie udało się wprowadzić zmiany: %s",
+	"Failed to create shipment: %s":                                          "Nie udało się utworzyć przesyłki: %s",
+	"Invalid change request payload":                                         "Nieprawidłowa treść prośby o zmianę",
+	"No orders to print labels for":                                          "Brak zamówień do wydrukowania etykiet",
+	"No shipping provider is configured":                                     "Nie skonfigurowano przewoźnika",
+	"Only approved returns can be received":                                  "Odebrać można tylko zaakceptowane zwroty towaru",
+	"Only pending orders can be changed":                                     "Można zmieniać tylko oczekujące zamówienia",
+	"Only shipped orders can be returned":                                    "Zwrócić można tylko wysłane zamówienia",
+	"Order already has a shipment":                                           "Zamówienie ma już przesyłkę",
+	"Order cancelled but the refund failed: %s":                              "Zamówienie anulowano, ale zwrot się nie powiódł: %s",
+	"Order has no shipping address":                                          "Zamówienie nie ma adresu dostawy",
+	"Order item is already shipped":                                          "Pozycja zamówienia została już wysłana",
+	"Order item not found":                                                   "Nie znaleziono pozycji zamówienia",
+	"Order item not found in order":                                          "Nie znaleziono pozycji w tym zamówieniu",
+	"Order not found":                                                        "Nie znaleziono zamówienia",
+	"Order status code already exists":                                       "Status zamówienia o tym kodzie już istnieje",
+	"Order status code is a core status":                                     "Kod statusu zamówienia jest statusem podstawowym",
+	"Order status not found":                                                 "Nie znaleziono statusu zamówienia",
+	"Payment not found":                                                      "Nie znaleziono płatności",
+	"Return already reviewed":                                                "Zwrot towaru został już rozpatrzony",
+	"Return has no label":                                                    "Zwrot towaru nie ma etykiety",
+	"Return not found":                                                       "Nie znaleziono zwrotu towaru",
+	"Return quantity exceeds the ordered quantity":                           "Liczba zwracanych sztuk przekracza zamówioną",
+	"Shipment label is not ready yet":                                        "Etykieta przesyłki nie jest jeszcze gotowa",
+	"Shipment not found":                                                     "Nie znaleziono przesyłki",
+	"Shipment was created with another shipping provider":                    "Przesyłka została utworzona u innego przewoźnika",
+	"Shipping method not found":                                              "Nie znaleziono metody dostawy",
+	"Some orders have no shipping address":                                   "Niektóre zamówienia nie mają adresu dostawy",
+	"Unknown email provider":                                                 "Nieznany dostawca poczty",
+	"Unknown payment provider":                                               "Nieznany operator płatności",
+	"Unsupported change request type":                                        "Nieobsługiwany typ prośby o zmianę",
+	"Unsupported shipping country":                                           "Nieobsługiwany kraj dostawy",
+	"address_change payload is required":                                     "Wymagane są dane zmiany adresu (address_change)",
+	"order is already paid":                                                  "zamówienie jest już opłacone",
+	"order is cancelled":                                                     "zamówienie jest anulowane",
+	"size_swap payload is required":                                          "Wymagane są dane zamiany rozmiaru (size_swap)",
+	"target_point and parcel_template are required for locker shipments":     "Przesyłki do paczkomatu wymagają target_point i parcel_template",
+	"tracking_carrier and tracking_number are required":                      "Wymagane są tracking_carrier i tracking_number",
+	"weight_kg and the parcel dimensions are required for courier shipments": "Przesyłki kurierskie wymagają weight_kg i wymiarów paczki",
+
+	// Profiles and addresses
+	"Address not found":                    "Nie znaleziono adresu",
+	"Either user_id or email is required":  "Wymagany jest user_id lub e-mail",
+	"Provide either session_id or user_id": "Podaj session_id lub user_id",
+
+	// Reviews and size recommendations
+	"%s must be a number from %d to %d":                      "%s musi być liczbą od %d do %d",
+	"Client review not found":                                "Nie znaleziono opinii klienta",
+	"Measurement %s must not be negative":                    "Wymiar %s nie może być ujemny",
+	"None of the measurements apply to this product's sizes": "Żaden z wymiarów nie dotyczy rozmiarów tego produktu",
+	"Only customers who received this product can review it": "Opinię mogą wystawić tylko klienci, którzy otrzymali ten produkt",
+	"Review not found":                                       "Nie znaleziono opinii",
+	"This product has no sizes to recommend":                 "Ten produkt nie ma rozmiarów do polecenia",
+	"Unknown measurement %s, expected one of a-f":            "Nieznany wymiar %s, oczekiwano jednego z a-f",
+	"You have already reviewed this product":                 "Ten produkt został już przez Ciebie oceniony",
+
+	// Catalog
+	"Additional service not found":                                      "Nie znaleziono usługi dodatkowej",
+	"Additional service with ID %d not found":                           "Nie znaleziono usługi dodatkowej o ID %d",
+	"At least one image is required":                                    "Wymagany jest co najmniej jeden obraz",
+	"Attachment not found":                                              "Nie znaleziono załącznika",
+	"Category not found":                                                "Nie znaleziono kategorii",
+	"Color name already exists for this material":                       "Kolor o tej nazwie już istnieje dla tego materiału",
+	"Color not found":                                                   "Nie znaleziono koloru",
+	"Content change not found":                                          "Nie znaleziono zmiany treści",
+	"Content change was already reviewed":                               "Zmiana treści została już rozpatrzona",
+	"Crop saved but image variants could not be regenerated":            "Kadrowanie zapisano, ale nie udało się ponownie wygenerować wariantów obrazu",
+	"Image is in use":                                                   "Obraz jest w użyciu",
+	"Image is the main image of a product":                              "Obraz jest głównym obrazem produktu",
+	"Image not found":                                                   "Nie znaleziono obrazu",
+	"Image with ID %d not found":                                        "Nie znaleziono obrazu o ID %d",
+	"Invalid image ID: %d":                                              "Nieprawidłowe ID obrazu: %d",
+	"Invalid import file: %s":                                           "Nieprawidłowy plik importu: %s",
+	"Invalid option group name":                                         "Nieprawidłowa nazwa grupy opcji",
+	"Invalid snapshot archive":                                          "Nieprawidłowe archiwum kopii katalogu",
+	"Item cannot be restored because it conflicts with current data":    "Nie można przywrócić elementu, ponieważ koliduje z aktualnymi danymi",
+	"Main image must be included in the images list":                    "Obraz główny musi znajdować się na liście obrazów",
+	"Main image not found":                                              "Nie znaleziono obrazu głównego",
+	"Material name already exists":                                      "Materiał o tej nazwie już istnieje",
+	"Material not found":                                                "Nie znaleziono materiału",
+	"No file uploaded":                                                  "Nie przesłano pliku",
+	"Pairing override not found":                                        "Nie znaleziono ręcznego powiązania produktów",
+	"Product cannot be paired with itself":                              "Produktu nie można powiązać z samym sobą",
+	"Product image not found":                                           "Nie znaleziono obrazu produktu",
+	"Product not found":                                                 "Nie znaleziono produktu",
+	"Product revision not found":                                        "Nie znaleziono wersji produktu",
+	"Product variant not found":                                         "Nie znaleziono wariantu produktu",
+	"Product was changed after this change was submitted":               "Produkt został zmieniony po zgłoszeniu tej zmiany",
+	"Quantity cannot be negative in set mode":                           "Ilość nie może być ujemna w trybie ustawiania",
+	"Revision references data that no longer exists":                    "Wersja odwołuje się do danych, które już nie istnieją",
+	"Search failed":                                                     "Wyszukiwanie nie powiodło się",
+	"Service name already exists":                                       "Usługa o tej nazwie już istnieje",
+	"Size not found":                                                    "Nie znaleziono rozmiaru",
+	"Slug already exists":                                               "Taki slug już istnieje",
+	"Slug may only contain lowercase letters, digits and single dashes": "Slug może zawierać tylko małe litery, cyfry i pojedyncze myślniki",
+	"Snapshot not found":                                                "Nie znaleziono kopii katalogu",
+	"Tag is required":                                                   "Tag jest wymagany",
+	"Tag not found":                                                     "Nie znaleziono tagu",
+	"Title must be at most 255 characters":                              "Tytuł może mieć najwyżej 255 znaków",
+	"Translation not found":                                             "Nie znaleziono tłumaczenia",
+	"Unsupported language":                                              "Nieobsługiwany język",
+
+	// Administration
+	"Blocked day not found":                             "Nie znaleziono zablokowanego dnia",
+	"Debug capture not found":                           "Nie znaleziono przechwytywania debugowania",
+	"Domain is already used by another shop":            "Domena jest już używana przez inny sklep",
+	"Email address is suppressed":                       "Adres e-mail jest zablokowany po odrzuceniu wiadomości lub zgłoszeniu spamu",
+	"Email events are not enabled":                      "Powiadomienia od dostawcy poczty nie są włączone",
+	"Email suppression not found":                       "Nie znaleziono blokady adresu e-mail",
+	"Email template not found":                          "Nie znaleziono szablonu wiadomości",
+	"Export is not ready":                               "Eksport nie jest jeszcze gotowy",
+	"Export not found":                                  "Nie znaleziono eksportu",
+	"Failed email not found":                            "Nie znaleziono nieudanej wiadomości",
+	"Hash is required":                                  "Wymagany jest hash",
+	"Invalid currency code":                             "Nieprawidłowy kod waluty",
+	"Invalid from date, expected YYYY-MM-DD":            "Nieprawidłowa data from, oczekiwano RRRR-MM-DD",
+	"Invalid notification":                              "Nieprawidłowe powiadomienie",
+	"Invalid to date, expected YYYY-MM-DD":              "Nieprawidłowa data to, oczekiwano RRRR-MM-DD",
+	"No events provided":                                "Nie przesłano zdarzeń",
+	"Search reindex is already running":                 "Przebudowa indeksu wyszukiwania już trwa",
+	"Setting key is required":                           "Wymagany jest klucz ustawienia",
+	"Setting not found":                                 "Nie znaleziono ustawienia",
+	"Shop not found":                                    "Nie znaleziono sklepu",
+	"Shop slug already exists":                          "Sklep o tym slugu już istnieje",
+	"The base currency can't be changed":                "Nie można zmienić waluty podstawowej",
+	"The system user can't be deleted":                  "Nie można usunąć użytkownika systemowego",
+	"This version has already been published":           "Ta wersja została już opublikowana",
+	"Too many events in one batch":                      "Zbyt wiele zdarzeń w jednej paczce",
+	"Trash item not found":                              "Nie znaleziono elementu w koszu",
+	"Warehouse code already exists":                     "Magazyn o tym kodzie już istnieje",
+	"Warehouse not found":                               "Nie znaleziono magazynu",
+	"Webhook delivery not found":                        "Nie znaleziono dostarczenia webhooka",
+	"Webhook endpoint not found":                        "Nie znaleziono punktu końcowego webhooka",
+	"created_from must be a date (YYYY-MM-DD)":          "created_from musi być datą (RRRR-MM-DD)",
+	"created_to must be a date (YYYY-MM-DD)":            "created_to musi być datą (RRRR-MM-DD)",
+	"date must be in YYYY-MM-DD format":                 "date musi mieć format RRRR-MM-DD",
+	"days must be a number from 1 to 365":               "days musi być liczbą od 1 do 365",
+	"days must be between 1 and 365":                    "days musi mieścić się w zakresie od 1 do 365",
+	"days must be between 1 and 366":                    "days musi mieścić się w zakresie od 1 do 366",
+	"deleted must be true or false":                     "deleted musi mieć wartość true lub false",
+	"duration_minutes must be at most %d":               "duration_minutes może wynosić najwyżej %d",
+	"format must be csv or xlsx":                        "format musi mieć wartość csv lub xlsx",
+	"from must be a date in YYYY-MM-DD format":          "from musi być datą w formacie RRRR-MM-DD",
+	"from must be before to":                            "from musi być wcześniejsze niż to",
+	"has_orders must be true or false":                  "has_orders musi mieć wartość true lub false",
+	"limit must be between 1 and 100":                   "limit musi mieścić się w zakresie od 1 do 100",
+	"maintenance_mode must be 'true' or 'false'":        "maintenance_mode musi mieć wartość 'true' lub 'false'",
+	"min_age_hours must be a non-negative number":       "min_age_hours musi być liczbą nieujemną",
+	"month must be formatted as YYYY-MM":                "month musi mieć format RRRR-MM",
+	"name is required":                                  "Nazwa jest wymagana",
+	"status must be 'pending', 'sent' or 'failed'":      "status musi mieć wartość 'pending', 'sent' lub 'failed'",
+	"status must be 'pending', 'succeeded' or 'failed'": "status musi mieć wartość 'pending', 'succeeded' lub 'failed'",
+	"type must be category or product":                  "type musi mieć wartość category lub product",
+	"view must be full or summary":                      "view musi mieć wartość full lub summary",
+
+	// Invalid identifiers and filters
+	"Invalid API key ID":            "Nieprawidłowe ID klucza API",
+	"Invalid additional service ID": "Nieprawidłowe ID usługi dodatkowej",
+	"Invalid address ID":            "Nieprawidłowe ID adresu",
+	"Invalid attachment ID":         "Nieprawidłowe ID załącznika",
+	"Invalid cart item ID":          "Nieprawidłowe ID produktu w koszyku",
+	"Invalid category ID":           "Nieprawidłowe ID kategorii",
+	"Invalid change request ID":     "Nieprawidłowe ID prośby o zmianę",
+	"Invalid client review ID":      "Nieprawidłowe ID opinii klienta",
+	"Invalid color ID":              "Nieprawidłowe ID koloru",
+	"Invalid content change ID":     "Nieprawidłowe ID zmiany treści",
+	"Invalid debug capture ID":      "Nieprawidłowe ID przechwytywania debugowania",
+	"Invalid discount code ID":      "Nieprawidłowe ID kodu rabatowego",
+	"Invalid discount code user ID": "Nieprawidłowe ID przypisania kodu rabatowego",
+	"Invalid email ID":              "Nieprawidłowe ID wiadomości",
+	"Invalid entity type":           "Nieprawidłowy typ obiektu",
+	"Invalid exclude_id":            "Nieprawidłowe exclude_id",
+	"Invalid export ID":             "Nieprawidłowe ID eksportu",
+	"Invalid export type":           "Nieprawidłowy typ eksportu",
+	"Invalid image ID":              "Nieprawidłowe ID obrazu",
+	"Invalid material ID":           "Nieprawidłowe ID materiału",
+	"Invalid option group ID":       "Nieprawidłowe ID grupy opcji",
+	"Invalid order ID":              "Nieprawidłowe ID zamówienia",
+	"Invalid order IDs":             "Nieprawidłowe ID zamówień",
+	"Invalid order item ID":         "Nieprawidłowe ID pozycji zamówienia",
+	"Invalid order item code":       "Nieprawidłowy kod pozycji zamówienia",
+	"Invalid order status ID":       "Nieprawidłowe ID statusu zamówienia",
+	"Invalid order status code":     "Nieprawidłowy kod statusu zamówienia",
+	"Invalid product":               "Nieprawidłowy produkt",
+	"Invalid product ID":            "Nieprawidłowe ID produktu",
+	"Invalid product variant ID":    "Nieprawidłowe ID wariantu produktu",
+	"Invalid quantity":              "Nieprawidłowa ilość",
+	"Invalid related product ID":    "Nieprawidłowe ID powiązanego produktu",
+	"Invalid return ID":             "Nieprawidłowe ID zwrotu towaru",
+	"Invalid review ID":             "Nieprawidłowe ID opinii",
+	"Invalid revision":              "Nieprawidłowa wersja",
+	"Invalid role":                  "Nieprawidłowa rola",
+	"Invalid sales channel":         "Nieprawidłowy kanał sprzedaży",
+	"Invalid service ID":            "Nieprawidłowe ID usługi",
+	"Invalid shipping method":       "Nieprawidłowa metoda dostawy",
+	"Invalid shipping method ID":    "Nieprawidłowe ID metody dostawy",
+	"Invalid shop ID":               "Nieprawidłowe ID sklepu",
+	"Invalid size ID":               "Nieprawidłowe ID rozmiaru",
+	"Invalid snapshot ID":           "Nieprawidłowe ID kopii katalogu",
+	"Invalid sort":                  "Nieprawidłowe sortowanie",
+	"Invalid status":                "Nieprawidłowy status",
+	"Invalid trash item ID":         "Nieprawidłowe ID elementu w koszu",
+	"Invalid user ID":               "Nieprawidłowe ID użytkownika",
+	"Invalid user ID type":          "Nieprawidłowy typ ID użytkownika",
+	"Invalid warehouse ID":          "Nieprawidłowe ID magazynu",
+	"Invalid webhook delivery ID":   "Nieprawidłowe ID dostarczenia webhooka",
+	"Invalid webhook endpoint ID":   "Nieprawidłowe ID punktu końcowego webhooka",
+
+	// Request body errors, see validation.go
+	"%s has the wrong type":          "Pole %s ma nieprawidłowy typ",
+	"Request body is empty":          "Treść żądania jest pusta",
+	"Request body is not valid JSON": "Treść żądania nie jest poprawnym JSON-em",
+}
+
+// polishFailures translates the objects of "Failed to <action>" server errors; the
+// message becomes "Nie udało się <action>"
+var polishFailures = map[string]string{
+	"acknowledge price changes":         "potwierdzić zmian cen",
+	"add item to cart":                  "dodać produktu do koszyka",
+	"apply change":                      "wprowadzić zmiany",
+	"apply discount":                    "zastosować rabatu",
+	"apply payment":                     "zaksięgować płatności",
+	"apply retention":                   "zastosować zasad przechowywania danych",
+	"assign discount code":              "przypisać kodu rabatowego",
+	"associate images":                  "powiązać obrazów",
+	"associate images with service":     "powiązać obrazów z usługą",
+	"block day":                         "zablokować dnia",
+	"check API key":                     "sprawdzić klucza API",
+	"check admin session":               "sprawdzić sesji administratora",
+	"check color name":                  "sprawdzić nazwy koloru",
+	"check email":                       "sprawdzić adresu e-mail",
+	"check image usage":                 "sprawdzić użycia obrazu",
+	"check images":                      "sprawdzić obrazów",
+	"check material name":               "sprawdzić nazwy materiału",
+	"check service name":                "sprawdzić nazwy usługi",
+	"check session":                     "sprawdzić sesji",
+	"check slug":                        "sprawdzić sluga",
+	"check stock availability":          "sprawdzić dostępności w magazynie",
+	"clear cart":                        "wyczyścić koszyka",
+	"compare product state":             "porównać stanu produktu",
+	"create API key":                    "utworzyć klucza API",
+	"create additional service":         "utworzyć usługi dodatkowej",
+	"create address":                    "utworzyć adresu",
+	"create admin session":              "utworzyć sesji administratora",
+	"create category":                   "utworzyć kategorii",
+	"create change request":             "utworzyć prośby o zmianę",
+	"create client review":              "utworzyć opinii klienta",
+	"create color":                      "utworzyć koloru",
+	"create debug capture":              "utworzyć przechwytywania debugowania",
+	"create discount code":              "utworzyć kodu rabatowego",
+	"create material":                   "utworzyć materiału",
+	"create order":                      "złożyć zamówienia",
+	"create order status":               "utworzyć statusu zamówienia",
+	"create product":                    "utworzyć produktu",
+	"create refund":                     "utworzyć zwrotu",
+	"create return":                     "utworzyć zwrotu towaru",
+	"create review":                     "dodać opinii",
+	"create shipping method":            "utworzyć metody dostawy",
+	"create shop":                       "utworzyć sklepu",
+	"create snapshot":                   "utworzyć kopii katalogu",
+	"create user":                       "utworzyć użytkownika",
+	"create warehouse":                  "utworzyć magazynu",
+	"create webhook endpoint":           "utworzyć punktu końcowego webhooka",
+	"delete additional service":         "usunąć usługi dodatkowej",
+	"delete address":                    "usunąć adresu",
+	"delete attachment":                 "usunąć załącznika",
+	"delete category":                   "usunąć kategorii",
+	"delete client review":              "usunąć opinii klienta",
+	"delete color":                      "usunąć koloru",
+	"delete discount code":              "usunąć kodu rabatowego",
+	"delete email suppression":          "usunąć blokady adresu e-mail",
+	"delete image":                      "usunąć obrazu",
+	"delete material":                   "usunąć materiału",
+	"delete order":                      "usunąć zamówienia",
+	"delete order status":               "usunąć statusu zamówienia",
+	"delete pairing override":           "usunąć ręcznego powiązania produktów",
+	"delete product":                    "usunąć produktu",
+	"delete product tag":                "usunąć tagu produktu",
+	"delete products":                   "usunąć produktów",
+	"delete review":                     "usunąć opinii",
+	"delete shipping method":            "usunąć metody dostawy",
+	"delete snapshot":                   "usunąć kopii katalogu",
+	"delete translation":                "usunąć tłumaczenia",
+	"delete user":                       "usunąć użytkownika",
+	"delete warehouse":                  "usunąć magazynu",
+	"delete webhook endpoint":           "usunąć punktu końcowego webhooka",
+	"disable two-factor authentication": "wyłączyć uwierzytelniania dwuskładnikowego",
+	"enable two-factor authentication":  "włączyć uwierzytelniania dwuskładnikowego",
+	"export categories":                 "wyeksportować kategorii",
+	"export discount codes":             "wyeksportować kodów rabatowych",
+	"export products":                   "wyeksportować produktów",
+	"fetch adjacent products":           "pobrać sąsiednich produktów",
+	"fetch categories":                  "pobrać kategorii",
+	"fetch client review summary":       "pobrać podsumowania opinii klientów",
+	"fetch client reviews":              "pobrać opinii klientów",
+	"fetch exchange rates":              "pobrać kursów walut",
+	"fetch product":                     "pobrać produktu",
+	"fetch product attachments":         "pobrać załączników produktu",
+	"fetch product count":               "pobrać liczby produktów",
+	"fetch product options":             "pobrać opcji produktu",
+	"fetch product sizes":               "pobrać rozmiarów produktu",
+	"fetch product variants":            "pobrać wariantów produktu",
+	"fetch products":                    "pobrać produktów",
+	"generate TOTP secret":              "wygenerować sekretu TOTP",
+	"generate access token":             "wygenerować tokenu dostępu",
+	"generate funnel report":            "wygenerować raportu lejka",
+	"generate refresh token":            "wygenerować tokenu odświeżania",
+	"generate sales report":             "wygenerować raportu sprzedaży",
+	"get API key usage":                 "pobrać użycia klucza API",
+	"get API keys":                      "pobrać kluczy API",
+	"get TOTP settings":                 "pobrać ustawień TOTP",
+	"get attachment":                    "pobrać załącznika",
+	"get blocked days":                  "pobrać zablokowanych dni",
+	"get cart history":                  "pobrać historii koszyka",
+	"get cart items":                    "pobrać zawartości koszyka",
+	"get cart session":                  "pobrać koszyka",
+	"get change request":                "pobrać prośby o zmianę",
+	"get change requests":               "pobrać próśb o zmianę",
+	"get client review":                 "pobrać opinii klienta",
+	"get content change":                "pobrać zmiany treści",
+	"get currencies":                    "pobrać walut",
+	"get data":                          "pobrać danych",
+	"get debug capture entries":         "pobrać wpisów przechwytywania debugowania",
+	"get discount code":                 "pobrać kodu rabatowego",
+	"get discount code users":           "pobrać przypisań kodu rabatowego",
+	"get discount codes":                "pobrać kodów rabatowych",
+	"get duplicate orders":              "pobrać zduplikowanych zamówień",
+	"get email preferences":             "pobrać ustawień powiadomień e-mail",
+	"get email suppressions":            "pobrać zablokowanych adresów e-mail",
+	"get email templates":               "pobrać szablonów wiadomości",
+	"get export":                        "pobrać eksportu",
+	"get idle timeout":                  "pobrać limitu bezczynności",
+	"get image":                         "pobrać obrazu",
+	"get image tags":                    "pobrać tagów obrazu",
+	"get image usage":                   "pobrać użycia obrazu",
+	"get legal acceptances":             "pobrać akceptacji dokumentów prawnych",
+	"get legal documents":               "pobrać dokumentów prawnych",
+	"get live stats":                    "pobrać statystyk na żywo",
+	"get login history":                 "pobrać historii logowań",
+	"get maintenance status":            "pobrać stanu prac serwisowych",
+	"get order":                         "pobrać zamówienia",
+	"get order board":                   "pobrać tablicy zamówień",
+	"get order calendar":                "pobrać kalendarza zamówień",
+	"get order fulfillment":             "pobrać stanu kompletacji zamówienia",
+	"get order statuses":                "pobrać statusów zamówień",
+	"get orders":                        "pobrać zamówień",
+	"get outbox emails":                 "pobrać wiadomości do wysłania",
+	"get pick list":                     "pobrać listy kompletacji",
+	"get product":                       "pobrać produktu",
+	"get product attachments":           "pobrać załączników produktu",
+	"get product images":                "pobrać obrazów produktu",
+	"get product options":               "pobrać opcji produktu",
+	"get product pairings":              "pobrać powiązań produktu",
+	"get product revision":              "pobrać wersji produktu",
+	"get production capacity":           "pobrać mocy produkcyjnych",
+	"get refunds":                       "pobrać zwrotów",
+	"get return":                        "pobrać zwrotu towaru",
+	"get returns":                       "pobrać zwrotów towaru",
+	"get reviews":                       "pobrać opinii",
+	"get search count":                  "pobrać liczby wyników wyszukiwania",
+	"get search reindex status":         "pobrać stanu przebudowy indeksu wyszukiwania",
+	"get settings":                      "pobrać ustawień",
+	"get shipment":                      "pobrać przesyłki",
+	"get shipment label":                "pobrać etykiety przesyłki",
+	"get shipping address":              "pobrać adresu dostawy",
+	"get shipping addresses":            "pobrać adresów dostawy",
+	"get shipping countries":            "pobrać krajów dostawy",
+	"get shipping method":               "pobrać metody dostawy",
+	"get shipping methods":              "pobrać metod dostawy",
+	"get shop":                          "pobrać sklepu",
+	"get shops":                         "pobrać sklepów",
+	"get snapshot":                      "pobrać kopii katalogu",
+	"get snapshots":                     "pobrać kopii katalogu",
+	"get stock movements":               "pobrać ruchów magazynowych",
+	"get stock reservations":            "pobrać rezerwacji magazynowych",
+	"get suggestions":                   "pobrać podpowiedzi",
+	"get tag cloud":                     "pobrać chmury tagów",
+	"get totals mismatches":             "pobrać rozbieżności kwot",
+	"get translations":                  "pobrać tłumaczeń",
+	"get trash item":                    "pobrać elementu z kosza",
+	"get updated service":               "pobrać zaktualizowanej usługi",
+	"get updated setting":               "pobrać zaktualizowanego ustawienia",
+	"get usage statistics":              "pobrać statystyk użycia",
+	"get user":                          "pobrać użytkownika",
+	"get user addresses":                "pobrać adresów użytkownika",
+	"get user profile":                  "pobrać profilu użytkownika",
+	"get warehouse":                     "pobrać magazynu",
+	"get warehouse stock":               "pobrać stanów magazynu",
+	"get warehouses":                    "pobrać magazynów",
+	"get webhook deliveries":            "pobrać dostarczeń webhooków",
+	"get webhook endpoints":             "pobrać punktów końcowych webhooków",
+	"handle callback":                   "obsłużyć powiadomienia zwrotnego",
+	"hash password":                     "zabezpieczyć hasła",
+	"import categories":                 "zaimportować kategorii",
+	"import discount codes":             "zaimportować kodów rabatowych",
+	"import products":                   "zaimportować produktów",
+	"list content changes":              "pobrać listy zmian treści",
+	"list debug captures":               "pobrać listy przechwytywań debugowania",
+	"list exports":                      "pobrać listy eksportów",
+	"list image tags":                   "pobrać listy tagów obrazów",
+	"list images":                       "pobrać listy obrazów",
+	"list product revisions":            "pobrać listy wersji produktu",
+	"list product tags":                 "pobrać listy tagów produktów",
+	"list trash":                        "pobrać zawartości kosza",
+	"load categories":                   "wczytać kategorii",
+	"load discount codes":               "wczytać kodów rabatowych",
+	"load products":                     "wczytać produktów",
+	"log out":                           "wylogować",
+	"mark items as picked":              "oznaczyć pozycji jako skompletowanych",
+	"preview retention":                 "wyświetlić podglądu zasad przechowywania danych",
+	"publish legal document":            "opublikować dokumentu prawnego",
+	"purge trash item":                  "trwale usunąć elementu z kosza",
+	"read callback":                     "odczytać powiadomienia zwrotnego",
+	"read content change":               "odczytać zmiany treści",
+	"read notification":                 "odczytać powiadomienia",
+	"recommend a size":                  "polecić rozmiaru",
+	"recompute product pairings":        "przeliczyć powiązań produktów",
+	"record acceptance":                 "zapisać akceptacji",
+	"record legal acceptance":           "zapisać akceptacji dokumentu prawnego",
+	"reject content change":             "odrzucić zmiany treści",
+	"remove cart item":                  "usunąć produktu z koszyka",
+	"remove discount":                   "usunąć rabatu",
+	"remove discount code user":         "usunąć przypisania kodu rabatowego",
+	"rename product tag":                "zmienić nazwy tagu produktu",
+	"reorder attachments":               "zmienić kolejności załączników",
+	"reorder client reviews":            "zmienić kolejności opinii klientów",
+	"replay webhook delivery":           "ponowić dostarczenia webhooka",
+	"request export":                    "zlecić eksportu",
+	"reserve stock":                     "zarezerwować towaru",
+	"reset email template":              "przywrócić szablonu wiadomości",
+	"reset password":                    "zresetować hasła",
+	"restore product revision":          "przywrócić wersji produktu",
+	"restore snapshot":                  "przywrócić kopii katalogu",
+	"restore trash item":                "przywrócić elementu z kosza",
+	"restore user":                      "przywrócić użytkownika",
+	"retrieve additional services":      "pobrać usług dodatkowych",
+	"retrieve categories":               "pobrać kategorii",
+	"retrieve channel feed":             "pobrać feedu kanału sprzedaży",
+	"retrieve client reviews":           "pobrać opinii klientów",
+	"retrieve colors":                   "pobrać kolorów",
+	"retrieve created product":          "pobrać utworzonego produktu",
+	"retrieve image sizes":              "pobrać rozmiarów obrazu",
+	"retrieve image tags":               "pobrać tagów obrazu",
+	"retrieve image usage":              "pobrać użycia obrazu",
+	"retrieve images":                   "pobrać obrazów",
+	"retrieve materials":                "pobrać materiałów",
+	"retrieve product":                  "pobrać produktu",
+	"retrieve product fulfillment":      "pobrać ustawień realizacji produktu",
+	"retrieve product shipping":         "pobrać ustawień wysyłki produktu",
+	"retrieve product sizes":            "pobrać rozmiarów produktu",
+	"retrieve product variants":         "pobrać wariantów produktu",
+	"retrieve products":                 "pobrać produktów",
+	"retrieve updated product":          "pobrać zaktualizowanego produktu",
+	"retrieve users":                    "pobrać użytkowników",
+	"retry email":                       "ponowić wysyłki wiadomości",
+	"revoke API key":                    "unieważnić klucza API",
+	"rotate refresh token":              "odnowić tokenu odświeżania",
+	"save attachment":                   "zapisać załącznika",
+	"save content change":               "zapisać zmiany treści",
+	"save currency":                     "zapisać waluty",
+	"save email preferences":            "zapisać ustawień powiadomień e-mail",
+	"save email template":               "zapisać szablonu wiadomości",
+	"save file":                         "zapisać pliku",
+	"save image metadata":               "zapisać metadanych obrazu",
+	"save return label":                 "zapisać etykiety zwrotu towaru",
+	"save session":                      "zapisać sesji",
+	"save shipment":                     "zapisać przesyłki",
+	"save translation":                  "zapisać tłumaczenia",
+	"send order email":                  "wysłać wiadomości o zamówieniu",
+	"set default address":               "ustawić adresu domyślnego",
+	"set pairing override":              "ustawić ręcznego powiązania produktów",
+	"set product images":                "ustawić obrazów produktu",
+	"set product services":              "ustawić usług produktu",
+	"start payment":                     "rozpocząć płatności",
+	"start sudo mode":                   "włączyć trybu sudo",
+	"stop debug capture":                "zatrzymać przechwytywania debugowania",
+	"store TOTP secret":                 "zapisać sekretu TOTP",
+	"store events":                      "zapisać zdarzeń",
+	"suppress email":                    "zablokować adresu e-mail",
+	"toggle category status":            "zmienić statusu kategorii",
+	"transfer stock":                    "przesunąć towaru",
+	"unblock day":                       "odblokować dnia",
+	"unsubscribe":                       "wypisać adresu z wiadomości",
+	"update additional service":         "zaktualizować usługi dodatkowej",
+	"update address":                    "zaktualizować adresu",
+	"update attachment":                 "zaktualizować załącznika",
+	"update cart item":                  "zaktualizować produktu w koszyku",
+	"update categories":                 "zaktualizować kategorii",
+	"update category":                   "zaktualizować kategorii",
+	"update change request":             "zaktualizować prośby o zmianę",
+	"update client review":              "zaktualizować opinii klienta",
+	"update color":                      "zaktualizować koloru",
+	"update discount code":              "zaktualizować kodu rabatowego",
+	"update exchange rates":             "zaktualizować kursów walut",
+	"update image alt text":             "zaktualizować tekstu alternatywnego obrazu",
+	"update image crop":                 "zaktualizować kadrowania obrazu",
+	"update image tags":                 "zaktualizować tagów obrazu",
+	"update images":                     "zaktualizować obrazów",
+	"update material":                   "zaktualizować materiału",
+	"update order":                      "zaktualizować zamówienia",
+	"update order fulfillment":          "zaktualizować stanu kompletacji zamówienia",
+	"update order item size":            "zmienić rozmiaru pozycji zamówienia",
+	"update order status":               "zmienić statusu zamówienia",
+	"update product fulfillment":        "zaktualizować ustawień realizacji produktu",
+	"update product image alt text":     "zaktualizować tekstu alternatywnego obrazu produktu",
+	"update product shipping":           "zaktualizować ustawień wysyłki produktu",
+	"update product tags":               "zaktualizować tagów produktu",
+	"update return":                     "zaktualizować zwrotu towaru",
+	"update review":                     "zaktualizować opinii",
+	"update service images":             "zaktualizować obrazów usługi",
+	"update setting":                    "zaktualizować ustawienia",
+	"update shipping address":           "zaktualizować adresu dostawy",
+	"update shipping method":            "zaktualizować metody dostawy",
+	"update shop":                       "zaktualizować sklepu",
+	"update stock":                      "zaktualizować stanu magazynowego",
+	"update user":                       "zaktualizować użytkownika",
+	"update user profile":               "zaktualizować profilu użytkownika",
+	"update warehouse":                  "zaktualizować magazynu",
+	"update warehouse stock":            "zaktualizować stanów magazynu",
+	"update webhook endpoint":           "zaktualizować punktu końcowego webhooka",
+	"validate discount code":            "sprawdzić kodu rabatowego",
+	"write CSV":                         "zapisać pliku CSV",
+	"write XLSX":                        "zapisać pliku XLSX",
+}
@@ -0,0 +1,119 @@
+package i18n
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// fieldError matches one failed rule in the error of gin's request binding, e.g.
+// "Key: 'OrderRequest.ShippingAddress.FirstName' Error:Field validation for 'FirstName' failed on the 'required' tag"
+var fieldError = regexp.MustCompile(`Key: '([^']+)' Error:Field validation for '[^']*' failed on the '([^']+)' tag`)
+
+// typeError matches the error of a JSON value of the wrong type
+var typeError = regexp.MustCompile(`^json: cannot unmarshal \S+ into Go (?:struct field \S+?\.(\S+)|value) of type \S+$`)
+
+// ruleMessages phrase a failed validation rule for a field, by binding tag
+var ruleMessages = map[string]map[string]string{
+	English: {
+		"required": "%s is required",
+		"email":    "%s must be a valid email address",
+		"url":      "%s must be a valid URL",
+		"min":      "%s is below the minimum",
+		"gt":       "%s is below the minimum",
+		"gte":      "%s is below the minimum",
+		"max":      "%s exceeds the maximum",
+		"lt":       "%s exceeds the maximum",
+		"lte":      "%s exceeds the maximum",
+		"len":      "%s has the wrong length",
+		"oneof":    "%s must be one of the allowed values",
+		"nefield":  "%s must differ from the other field",
+		"":         "%s is invalid",
+	},
+	Polish: {
+		"required": "Pole %s jest wymagane",
+		"email":    "Pole %s musi zawierać poprawny adres e-mail",
+		"url":      "Pole %s musi zawierać poprawny adres URL",
+		"min":      "Wartość pola %s jest poniżej minimum",
+		"gt":       "Wartość pola %s jest poniżej minimum",
+		"gte":      "Wartość pola %s jest poniżej minimum",
+		"max":      "Wartość pola %s przekracza maksimum",
+		"lt":       "Wartość pola %s przekracza maksimum",
+		"lte":      "Wartość pola %s przekracza maksimum",
+		"len":      "Pole %s ma nieprawidłową długość",
+		"oneof":    "Pole %s musi mieć jedną z dozwolonych wartości",
+		"nefield":  "Pole %s musi różnić się od drugiego pola",
+		"":         "Pole %s jest nieprawidłowe",
+	},
+}
+
+// translateValidation rewrites a request binding error, which names Go struct fields,
+// into sentences naming the JSON fields. ok is false for other messages.
+func translateValidation(language, message string) (string, bool) {
+	rules, known := ruleMessages[language]
+	if !known {
+		rules = ruleMessages[English]
+	}
+
+	switch message {
+	case "EOF":
+		return Translate(language, "Request body is empty"), true
+	case "unexpected EOF":
+		return Translate(language, "Request body is not valid JSON"), true
+	}
+	if strings.HasPrefix(message, "invalid character ") {
+		return Translate(language, "Request body is not valid JSON"), true
+	}
+	if match := typeError.FindStringSubmatch(message); match != nil {
+		if match[1] == "" {
+			return Translate(language, "Invalid request body"), true
+		}
+		return Translate(language, fieldPath(match[1])+" has the wrong type"), true
+	}
+
+	matches := fieldError.FindAllStringSubmatch(message, -1)
+	if matches == nil {
+		return "", false
+	}
+	sentences := make([]string, len(matches))
+	for i, match := range matches {
+		// The key starts with the name of the request struct
+		_, field, _ := strings.Cut(match[1], ".")
+		tag := match[2]
+		if strings.HasPrefix(tag, "required_") {
+			tag = "required"
+		}
+		format, ok := rules[tag]
+		if !ok {
+			format = rules[""]
+		}
+		sentences[i] = strings.Replace(format, "%s", fieldPath(field), 1)
+	}
+	return strings.Join(sentences, "; "), true
+}
+
+// fieldPath turns a Go field path such as "Items[0].SizeID" into the JSON one,
+// "items[0].size_id", following the snake_case names of the request models
+func fieldPath(path string) string {
+	parts := strings.Split(path, ".")
+	for i, part := range parts {
+		parts[i] = snakeCase(part)
+	}
+	return strings.Join(parts, ".")
+}
+
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
@@ -0,0 +1,320 @@
+// Package imaging generates the cropped image variants used by the storefront.
+// Each variant is cut around the image's focal point (or an explicit crop set
+// by an admin) and scaled to a fixed size. Sizes are WebP copies of the whole
+// image scaled down to fit thumbnail, medium and large boxes.
+package imaging
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif" // decode only; GIF variants are written as PNG
+	"image/jpeg"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"notsofluffy-backend/internal/models"
+)
+
+// Variant is a generated rendition of an image for one use
+type Variant struct {
+	Name   string
+	Width  int
+	Height int
+}
+
+// Variant names
+const (
+	VariantCategoryTile = "category_tile"
+	VariantProductCard  = "product_card"
+)
+
+// Variants are generated for every uploaded image
+var Variants = []Variant{
+	{Name: VariantCategoryTile, Width: 600, Height: 600},
+	{Name: VariantProductCard, Width: 600, Height: 800},
+}
+
+// DefaultFocal keeps the center of the image in view
+const DefaultFocal = 0.5
+
+const jpegQuality = 85
+
+// Meta holds the crop settings of an image
+type Meta struct {
+	FocalX float64
+	FocalY float64
+	Crops  map[string]models.ImageCrop
+}
+
+// GetVariant returns the variant with the given name
+func GetVariant(name string) (Variant, bool) {
+	for _, v := range Variants {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return Variant{}, false
+}
+
+// Supported reports whether variants can be generated for the mime type
+func Supported(mimeType string) bool {
+	switch mimeType {
+	case "image/jpeg", "image/png", "image/gif":
+		return true
+	}
+	return false
+}
+
+// VariantPath returns where the variant of the image at path is stored.
+// JPEG sources produce JPEG variants, everything else PNG.
+func VariantPath(path, mimeType, variant string) string {
+	ext := ".png"
+	if mimeType == "image/jpeg" {
+		ext = ".jpg"
+	}
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return filepath.Join(filepath.Dir(path), "variants", variant, base+ext)
+}
+
+// VariantPaths returns the variant paths of an image, or nil when its type is not supported
+func VariantPaths(path, mimeType string) map[string]string {
+	if !Supported(mimeType) || path == "" {
+		return nil
+	}
+	paths := make(map[string]string, len(Variants))
+	for _, v := range Variants {
+		paths[v.Name] = VariantPath(path, mimeType, v.Name)
+	}
+	return paths
+}
+
+// HasVariants reports whether all variant files of the image exist
+func HasVariants(path, mimeType string) bool {
+	for _, variantPath := range VariantPaths(path, mimeType) {
+		if _, err := os.Stat(variantPath); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// RemoveVariants deletes the generated variant files of an image
+func RemoveVariants(path, mimeType string) {
+	for _, variantPath := range VariantPaths(path, mimeType) {
+		os.Remove(variantPath)
+	}
+}
+
+// ValidateCrop checks that a crop names a known variant and lies inside the image
+func ValidateCrop(variant string, crop models.ImageCrop) error {
+	if _, ok := GetVariant(variant); !ok {
+		return fmt.Errorf("unknown image variant %s", variant)
+	}
+	if crop.X < 0 || crop.Y < 0 || crop.Width <= 0 || crop.Height <= 0 ||
+		crop.X+crop.Width > 1.0001 || crop.Y+crop.Height > 1.0001 {
+		return fmt.Errorf("crop for %s must lie within the image", variant)
+	}
+	return nil
+}
+
+// Generate writes all variants of the image at path
+func Generate(path, mimeType string, meta Meta) error {
+	if !Supported(mimeType) {
+		return nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open image: %w", err)
+	}
+	defer file.Close()
+
+	src, _, err := image.Decode(file)
+	if err != nil {
+		return fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	for _, v := range Variants {
+		var crop *models.ImageCrop
+		if c, ok := meta.Crops[v.Name]; ok {
+			crop = &c
+		}
+		rect := CropRect(src.Bounds(), v, meta.FocalX, meta.FocalY, crop)
+		dst := Resize(src, rect, v.Width, v.Height)
+
+		if err := writeImage(VariantPath(path, mimeType, v.Name), mimeType, dst); err != nil {
+			return fmt.Errorf("failed to write %s variant: %w", v.Name, err)
+		}
+	}
+	return nil
+}
+
+// CropRect returns the source rectangle for a variant. An explicit crop is used as
+// given (then scaled to the variant size); otherwise the largest rectangle with the
+// variant's aspect ratio is centered on the focal point and kept inside the image.
+func CropRect(bounds image.Rectangle, v Variant, focalX, focalY float64, crop *models.ImageCrop) image.Rectangle {
+	w, h := bounds.Dx(), bounds.Dy()
+	if w <= 0 || h <= 0 {
+		return bounds
+	}
+
+	if crop != nil {
+		x0 := bounds.Min.X + int(math.Round(crop.X*float64(w)))
+		y0 := bounds.Min.Y + int(math.Round(crop.Y*float64(h)))
+		x1 := x0 + int(math.Max(1, math.Round(crop.Width*float64(w))))
+		y1 := y0 + int(math.Max(1, math.Round(crop.Height*float64(h))))
+		return image.Rect(x0, y0, x1, y1).Intersect(bounds)
+	}
+
+	cropW, cropH := w, h
+	target := float64(v.Width) / float64(v.Height)
+	if float64(w)/float64(h) > target {
+		cropW = int(math.Round(float64(h) * target))
+	} else {
+		cropH = int(math.Round(float64(w) / target))
+	}
+	if cropW < 1 {
+		cropW = 1
+	}
+	if cropH < 1 {
+		cropH = 1
+	}
+
+	x0 := clampInt(int(math.Round(clampFloat(focalX)*float64(w)-float64(cropW)/2)), 0, w-cropW)
+	y0 := clampInt(int(math.Round(clampFloat(focalY)*float64(h)-float64(cropH)/2)), 0, h-cropH)
+	return image.Rect(bounds.Min.X+x0, bounds.Min.Y+y0, bounds.Min.X+x0+cropW, bounds.Min.Y+y0+cropH)
+}
+
+// Resize scales the rect of src to width x height by averaging the source pixels
+// covered by each destination pixel
+func Resize(src image.Image, rect image.Rectangle, width, height int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	if rect.Empty() {
+		return dst
+	}
+
+	scaleX := float64(rect.Dx()) / float64(width)
+	scaleY := float64(rect.Dy()) / float64(height)
+
+	for y := 0; y < height; y++ {
+		sy0 := rect.Min.Y + int(float64(y)*scaleY)
+		sy1 := rect.Min.Y + int(math.Ceil(float64(y+1)*scaleY))
+		if sy1 <= sy0 {
+			sy1 = sy0 + 1
+		}
+		sy1 = minInt(sy1, rect.Max.Y)
+
+		for x := 0; x < width; x++ {
+			sx0 := rect.Min.X + int(float64(x)*scaleX)
+			sx1 := rect.Min.X + int(math.Ceil(float64(x+1)*scaleX))
+			if sx1 <= sx0 {
+				sx1 = sx0 + 1
+			}
+			sx1 = minInt(sx1, rect.Max.X)
+
+			var r, g, b, a, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r += uint64(pr)
+					g += uint64(pg)
+					b += uint64(pb)
+					a += uint64(pa)
+					n++
+				}
+			}
+			if n == 0 {
+				continue
+			}
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / n >> 8),
+				G: uint8(g / n >> 8),
+				B: uint8(b / n >> 8),
+				A: uint8(a / n >> 8),
+			})
+		}
+	}
+	return dst
+}
+
+// writeImage encodes img as mimeType next to its final path and renames it into
+// place, so requests never see a half-written variant
+func writeImage(path, mimeType string, img *image.RGBA) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".variant-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	switch mimeType {
+	case "image/jpeg":
+		// JPEG has no alpha; flatten onto white
+		flat := image.NewRGBA(img.Bounds())
+		draw.Draw(flat, flat.Bounds(), image.White, image.Point{}, draw.Src)
+		draw.Draw(flat, flat.Bounds(), img, image.Point{}, draw.Over)
+		err = jpeg.Encode(tmp, flat, &jpeg.Options{Quality: jpegQuality})
+	case SizeMimeType:
+		err = EncodeWebP(tmp, img)
+	default:
+		err = png.Encode(tmp, img)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func clampFloat(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
+
+func clampInt(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Dimensions returns the pixel size of the image at path without decoding all of it
+func Dimensions(path string) (int, int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to open image: %w", err)
+	}
+	defer file.Close()
+
+	config, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to decode image: %w", err)
+	}
+	return config.Width, config.Height, nil
+}
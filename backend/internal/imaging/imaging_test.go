@@ -0,0 +1,126 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"notsofluffy-backend/internal/models"
+)
+
+func TestCropRectFollowsFocalPoint(t *testing.T) {
+	bounds := image.Rect(0, 0, 1000, 500)
+	square := Variant{Name: VariantCategoryTile, Width: 600, Height: 600}
+
+	tests := []struct {
+		name   string
+		focalX float64
+		want   image.Rectangle
+	}{
+		{"center", 0.5, image.Rect(250, 0, 750, 500)},
+		{"left edge", 0, image.Rect(0, 0, 500, 500)},
+		{"right of center", 0.7, image.Rect(450, 0, 950, 500)},
+		{"clamped right", 0.95, image.Rect(500, 0, 1000, 500)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CropRect(bounds, square, tt.focalX, 0.5, nil); got != tt.want {
+				t.Errorf("CropRect() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCropRectPortraitFromLandscape(t *testing.T) {
+	bounds := image.Rect(0, 0, 1200, 900)
+	card := Variant{Name: VariantProductCard, Width: 600, Height: 800}
+
+	got := CropRect(bounds, card, 0.5, 0.5, nil)
+	if got.Dx() != 675 || got.Dy() != 900 {
+		t.Fatalf("expected a 3:4 crop of full height, got %v", got)
+	}
+}
+
+func TestCropRectExplicitCrop(t *testing.T) {
+	bounds := image.Rect(0, 0, 1000, 800)
+	crop := &models.ImageCrop{X: 0.1, Y: 0.25, Width: 0.5, Height: 0.5}
+
+	got := CropRect(bounds, Variants[0], 0.5, 0.5, crop)
+	if want := image.Rect(100, 200, 600, 600); got != want {
+		t.Errorf("CropRect() = %v, want %v", got, want)
+	}
+}
+
+func TestValidateCrop(t *testing.T) {
+	if err := ValidateCrop(VariantProductCard, models.ImageCrop{X: 0.2, Y: 0, Width: 0.8, Height: 1}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := ValidateCrop("banner", models.ImageCrop{Width: 1, Height: 1}); err == nil {
+		t.Error("expected unknown variant to be rejected")
+	}
+	if err := ValidateCrop(VariantProductCard, models.ImageCrop{X: 0.5, Width: 0.6, Height: 1}); err == nil {
+		t.Error("expected crop outside the image to be rejected")
+	}
+}
+
+func TestVariantPaths(t *testing.T) {
+	paths := VariantPaths("uploads/images/abc.jpeg", "image/jpeg")
+	if want := filepath.Join("uploads/images/variants", VariantCategoryTile, "abc.jpg"); paths[VariantCategoryTile] != want {
+		t.Errorf("got %s, want %s", paths[VariantCategoryTile], want)
+	}
+	if VariantPaths("uploads/images/abc.webp", "image/webp") != nil {
+		t.Error("expected no variants for unsupported types")
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "source.png")
+
+	// Left half red, right half blue
+	src := image.NewRGBA(image.Rect(0, 0, 400, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 400; x++ {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= 200 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			src.SetRGBA(x, y, c)
+		}
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(file, src); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	if err := Generate(path, "image/png", Meta{FocalX: 1, FocalY: 0.5}); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	if !HasVariants(path, "image/png") {
+		t.Fatal("expected all variants to be written")
+	}
+
+	out, err := os.Open(VariantPath(path, "image/png", VariantCategoryTile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	tile, err := png.Decode(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tile.Bounds().Dx() != 600 || tile.Bounds().Dy() != 600 {
+		t.Fatalf("unexpected tile size %v", tile.Bounds())
+	}
+	// Focal point on the right keeps the blue half in view
+	if r, _, b, _ := tile.At(300, 300).RGBA(); b>>8 != 255 || r != 0 {
+		t.Errorf("expected blue tile, got r=%d b=%d", r>>8, b>>8)
+	}
+}
@@ -0,0 +1,110 @@
+package imaging
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"notsofluffy-backend/internal/models"
+)
+
+// Size is a WebP rendition of the whole image scaled to fit within a box, so the
+// storefront can serve an image at about the size it is shown
+type Size struct {
+	Name      string
+	MaxWidth  int
+	MaxHeight int
+}
+
+// Size names
+const (
+	SizeThumbnail = "thumbnail"
+	SizeMedium    = "medium"
+	SizeLarge     = "large"
+)
+
+// Sizes are generated for every uploaded image
+var Sizes = []Size{
+	{Name: SizeThumbnail, MaxWidth: 200, MaxHeight: 200},
+	{Name: SizeMedium, MaxWidth: 800, MaxHeight: 800},
+	{Name: SizeLarge, MaxWidth: 1600, MaxHeight: 1600},
+}
+
+// SizeMimeType is the type all sizes are written as
+const SizeMimeType = "image/webp"
+
+// SizePath returns where the size of the image at path is stored
+func SizePath(path, size string) string {
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return filepath.Join(filepath.Dir(path), "sizes", size, base+".webp")
+}
+
+// SizePaths returns the size paths of an image, or nil when its type is not supported
+func SizePaths(path, mimeType string) map[string]string {
+	if !Supported(mimeType) || path == "" {
+		return nil
+	}
+	paths := make(map[string]string, len(Sizes))
+	for _, s := range Sizes {
+		paths[s.Name] = SizePath(path, s.Name)
+	}
+	return paths
+}
+
+// FitSize scales width x height down to fit within maxWidth x maxHeight, keeping the
+// aspect ratio. Smaller images keep their size.
+func FitSize(width, height, maxWidth, maxHeight int) (int, int) {
+	if width <= maxWidth && height <= maxHeight {
+		return width, height
+	}
+	scale := math.Min(float64(maxWidth)/float64(width), float64(maxHeight)/float64(height))
+	w := int(math.Max(1, math.Round(float64(width)*scale)))
+	h := int(math.Max(1, math.Round(float64(height)*scale)))
+	return w, h
+}
+
+// GenerateSizes writes all sizes of the image at path
+func GenerateSizes(path, mimeType string) ([]models.ImageVariant, error) {
+	if !Supported(mimeType) {
+		return nil, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open image: %w", err)
+	}
+	defer file.Close()
+
+	src, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	bounds := src.Bounds()
+	generated := make([]models.ImageVariant, 0, len(Sizes))
+	for _, s := range Sizes {
+		width, height := FitSize(bounds.Dx(), bounds.Dy(), s.MaxWidth, s.MaxHeight)
+		dst := Resize(src, bounds, width, height)
+
+		sizePath := SizePath(path, s.Name)
+		if err := writeImage(sizePath, SizeMimeType, dst); err != nil {
+			return nil, fmt.Errorf("failed to write %s size: %w", s.Name, err)
+		}
+		info, err := os.Stat(sizePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat %s size: %w", s.Name, err)
+		}
+		generated = append(generated, models.ImageVariant{
+			Name:      s.Name,
+			Path:      sizePath,
+			Width:     width,
+			Height:    height,
+			SizeBytes: info.Size(),
+			MimeType:  SizeMimeType,
+		})
+	}
+	return generated, nil
+}
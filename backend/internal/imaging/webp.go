@@ -0,0 +1,522 @@
+package imaging
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"sort"
+)
+
+// EncodeWebP writes img as a lossless WebP (VP8L). The pixels go through the
+// subtract-green and predictor transforms and runs of repeated pixels are coded as
+// backward references, which keeps flat product backgrounds small.
+func EncodeWebP(w io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width < 1 || height < 1 || width > 1<<14 || height > 1<<14 {
+		return fmt.Errorf("webp: invalid image size %dx%d", width, height)
+	}
+
+	argb := make([]uint32, width*height)
+	alphaUsed := false
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := color.NRGBAModel.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.NRGBA)
+			if c.A != 0xff {
+				alphaUsed = true
+			}
+			argb[y*width+x] = uint32(c.A)<<24 | uint32(c.R)<<16 | uint32(c.G)<<8 | uint32(c.B)
+		}
+	}
+
+	bw := &bitWriter{}
+	bw.writeBits(0x2f, 8) // VP8L signature
+	bw.writeBits(uint32(width-1), 14)
+	bw.writeBits(uint32(height-1), 14)
+	if alphaUsed {
+		bw.writeBits(1, 1)
+	} else {
+		bw.writeBits(0, 1)
+	}
+	bw.writeBits(0, 3) // version
+
+	// Transforms, undone by the decoder in reverse order
+	subtractGreen(argb)
+	bw.writeBits(1, 1)
+	bw.writeBits(webpSubtractGreenTransform, 2)
+
+	modes := predict(argb, width, height)
+	bw.writeBits(1, 1)
+	bw.writeBits(webpPredictorTransform, 2)
+	bw.writeBits(webpPredictorBits-2, 3)
+	bw.writeBits(0, 1) // no color cache
+	writeEntropyImage(bw, modes)
+
+	bw.writeBits(0, 1) // no more transforms
+	bw.writeBits(0, 1) // no color cache
+	bw.writeBits(0, 1) // a single prefix code group
+	writeEntropyImage(bw, argb)
+
+	data := bw.bytes()
+	padded := len(data) + len(data)%2
+	out := bufio.NewWriter(w)
+	out.WriteString("RIFF")
+	binary.Write(out, binary.LittleEndian, uint32(4+8+padded))
+	out.WriteString("WEBPVP8L")
+	binary.Write(out, binary.LittleEndian, uint32(len(data)))
+	out.Write(data)
+	if padded != len(data) {
+		out.WriteByte(0)
+	}
+	return out.Flush()
+}
+
+const (
+	webpPredictorTransform     = 0
+	webpSubtractGreenTransform = 2
+
+	// webpPredictorBits sets the 16x16 blocks that each pick a predictor
+	webpPredictorBits = 4
+
+	webpGreenAlphabet    = 256 + 24 // literals and length prefixes, no color cache
+	webpDistanceAlphabet = 40
+	webpMaxCopyLength    = 4096
+	webpMinCopyLength    = 3
+
+	// webpLeftDistanceCode is the short distance code of the pixel to the left
+	webpLeftDistanceCode = 2
+)
+
+// webpCodeLengthOrder is the order in which code length code lengths are written
+var webpCodeLengthOrder = [19]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// webpPredictorModes are the predictors tried for each block: left, top, top-left,
+// the average of left and top, and the clamped gradient
+var webpPredictorModes = []int{1, 2, 4, 7, 12}
+
+func subtractGreen(argb []uint32) {
+	for i, p := range argb {
+		green := (p >> 8) & 0xff
+		red := ((p >> 16) - green) & 0xff
+		blue := (p - green) & 0xff
+		argb[i] = p&0xff00ff00 | red<<16 | blue
+	}
+}
+
+// predict replaces the pixels with their residuals from the best predictor of each
+// block and returns the block modes as a sub-image
+func predict(argb []uint32, width, height int) []uint32 {
+	blockSize := 1 << webpPredictorBits
+	blocksWide := (width + blockSize - 1) / blockSize
+	blocksHigh := (height + blockSize - 1) / blockSize
+	modes := make([]uint32, blocksWide*blocksHigh)
+
+	// Residuals are computed from the original pixels, so work on a copy
+	src := make([]uint32, len(argb))
+	copy(src, argb)
+
+	for by := 0; by < blocksHigh; by++ {
+		for bx := 0; bx < blocksWide; bx++ {
+			x0, y0 := bx*blockSize, by*blockSize
+			x1, y1 := minInt(x0+blockSize, width), minInt(y0+blockSize, height)
+
+			best, bestCost := webpPredictorModes[0], -1
+			for _, mode := range webpPredictorModes {
+				cost := 0
+				for y := y0; y < y1; y++ {
+					for x := x0; x < x1; x++ {
+						cost += residualCost(src[y*width+x], predictPixel(src, width, x, y, mode))
+					}
+				}
+				if bestCost < 0 || cost < bestCost {
+					best, bestCost = mode, cost
+				}
+			}
+
+			modes[by*blocksWide+bx] = 0xff000000 | uint32(best)<<8
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					argb[y*width+x] = subPixels(src[y*width+x], predictPixel(src, width, x, y, best))
+				}
+			}
+		}
+	}
+	return modes
+}
+
+// predictPixel predicts the pixel at x, y. The first pixel, row and column have
+// fixed predictors whatever the block's mode.
+func predictPixel(argb []uint32, width, x, y, mode int) uint32 {
+	switch {
+	case x == 0 && y == 0:
+		return 0xff000000
+	case y == 0:
+		return argb[x-1]
+	case x == 0:
+		return argb[(y-1)*width]
+	}
+
+	left := argb[y*width+x-1]
+	top := argb[(y-1)*width+x]
+	topLeft := argb[(y-1)*width+x-1]
+	switch mode {
+	case 1:
+		return left
+	case 2:
+		return top
+	case 4:
+		return topLeft
+	case 7:
+		return average2(left, top)
+	default:
+		return clampAddSubtractFull(left, top, topLeft)
+	}
+}
+
+func average2(a, b uint32) uint32 {
+	return (((a ^ b) & 0xfefefefe) >> 1) + (a & b)
+}
+
+func clampAddSubtractFull(a, b, c uint32) uint32 {
+	var out uint32
+	for shift := uint(0); shift < 32; shift += 8 {
+		v := int((a>>shift)&0xff) + int((b>>shift)&0xff) - int((c>>shift)&0xff)
+		out |= uint32(clampInt(v, 0, 255)) << shift
+	}
+	return out
+}
+
+// subPixels subtracts each channel of b from a, modulo 256
+func subPixels(a, b uint32) uint32 {
+	alphaGreen := 0x00ff00ff + (a & 0xff00ff00) - (b & 0xff00ff00)
+	redBlue := 0xff00ff00 + (a & 0x00ff00ff) - (b & 0x00ff00ff)
+	return alphaGreen&0xff00ff00 | redBlue&0x00ff00ff
+}
+
+// residualCost estimates how well a prediction fits by the size of the residuals
+func residualCost(actual, predicted uint32) int {
+	residual := subPixels(actual, predicted)
+	cost := 0
+	for shift := uint(0); shift < 32; shift += 8 {
+		v := int(int8(residual >> shift))
+		if v < 0 {
+			v = -v
+		}
+		cost += v
+	}
+	return cost
+}
+
+// webpSymbol is a literal pixel or a copy of the previous pixel
+type webpSymbol struct {
+	pixel  uint32
+	length int // 0 for a literal
+}
+
+// writeEntropyImage writes the prefix codes and the pixels of an image. Runs of the
+// same pixel are copies from the pixel to the left.
+func writeEntropyImage(bw *bitWriter, argb []uint32) {
+	var symbols []webpSymbol
+	for i := 0; i < len(argb); {
+		run := 0
+		if i > 0 {
+			for i+run < len(argb) && run < webpMaxCopyLength && argb[i+run] == argb[i-1] {
+				run++
+			}
+		}
+		if run >= webpMinCopyLength {
+			symbols = append(symbols, webpSymbol{length: run})
+			i += run
+			continue
+		}
+		symbols = append(symbols, webpSymbol{pixel: argb[i]})
+		i++
+	}
+
+	green := make([]int, webpGreenAlphabet)
+	red := make([]int, 256)
+	blue := make([]int, 256)
+	alpha := make([]int, 256)
+	distance := make([]int, webpDistanceAlphabet)
+	distancePrefix, _, _ := webpPrefix(webpLeftDistanceCode)
+	for _, s := range symbols {
+		if s.length > 0 {
+			prefix, _, _ := webpPrefix(s.length)
+			green[256+prefix]++
+			distance[distancePrefix]++
+			continue
+		}
+		green[(s.pixel>>8)&0xff]++
+		red[(s.pixel>>16)&0xff]++
+		blue[s.pixel&0xff]++
+		alpha[s.pixel>>24]++
+	}
+
+	codes := make([]*prefixCode, 5)
+	for i, counts := range [][]int{green, red, blue, alpha, distance} {
+		codes[i] = newPrefixCode(counts, 15)
+		writePrefixCode(bw, codes[i])
+	}
+
+	for _, s := range symbols {
+		if s.length > 0 {
+			prefix, extraBits, extra := webpPrefix(s.length)
+			codes[0].write(bw, 256+prefix)
+			bw.writeBits(extra, extraBits)
+			codes[4].write(bw, distancePrefix)
+			continue
+		}
+		codes[0].write(bw, int((s.pixel>>8)&0xff))
+		codes[1].write(bw, int((s.pixel>>16)&0xff))
+		codes[2].write(bw, int(s.pixel&0xff))
+		codes[3].write(bw, int(s.pixel>>24))
+	}
+}
+
+// webpPrefix splits a length or distance into its prefix code and extra bits
+func webpPrefix(value int) (int, int, uint32) {
+	if value <= 4 {
+		return value - 1, 0, 0
+	}
+	d := value - 1
+	highest := 0
+	for d>>(highest+1) != 0 {
+		highest++
+	}
+	second := (d >> (highest - 1)) & 1
+	extraBits := highest - 1
+	return 2*highest + second, extraBits, uint32(d & (1<<extraBits - 1))
+}
+
+// prefixCode is a canonical Huffman code
+type prefixCode struct {
+	lengths []uint8
+	codes   []uint32 // bit-reversed, as the stream is read from the least significant bit
+	symbols []int    // symbols in use
+}
+
+func newPrefixCode(counts []int, maxLength int) *prefixCode {
+	c := &prefixCode{lengths: huffmanLengths(counts, maxLength)}
+	for s, l := range c.lengths {
+		if l > 0 {
+			c.symbols = append(c.symbols, s)
+		}
+	}
+	c.codes = canonicalCodes(c.lengths)
+	return c
+}
+
+// write writes the code of a symbol; a code with a single symbol takes no bits
+func (c *prefixCode) write(bw *bitWriter, symbol int) {
+	if len(c.symbols) > 1 {
+		bw.writeBits(c.codes[symbol], int(c.lengths[symbol]))
+	}
+}
+
+// writePrefixCode writes a code with up to two small symbols in the simple form and
+// anything else as code lengths, themselves prefix coded
+func writePrefixCode(bw *bitWriter, c *prefixCode) {
+	if len(c.symbols) <= 2 && (len(c.symbols) == 0 || c.symbols[len(c.symbols)-1] < 256) {
+		symbols := c.symbols
+		if len(symbols) == 0 {
+			symbols = []int{0}
+		}
+		bw.writeBits(1, 1)
+		bw.writeBits(uint32(len(symbols)-1), 1)
+		if symbols[0] < 2 {
+			bw.writeBits(0, 1)
+			bw.writeBits(uint32(symbols[0]), 1)
+		} else {
+			bw.writeBits(1, 1)
+			bw.writeBits(uint32(symbols[0]), 8)
+		}
+		if len(symbols) == 2 {
+			bw.writeBits(uint32(symbols[1]), 8)
+		}
+		return
+	}
+
+	// Code lengths, with runs of zeros as repeat codes 17 and 18
+	type token struct {
+		code, extraBits int
+		extra           uint32
+	}
+	var tokens []token
+	for i := 0; i < len(c.lengths); {
+		if c.lengths[i] != 0 {
+			tokens = append(tokens, token{code: int(c.lengths[i])})
+			i++
+			continue
+		}
+		run := 0
+		for i+run < len(c.lengths) && c.lengths[i+run] == 0 && run < 138 {
+			run++
+		}
+		switch {
+		case run >= 11:
+			tokens = append(tokens, token{code: 18, extraBits: 7, extra: uint32(run - 11)})
+		case run >= 3:
+			tokens = append(tokens, token{code: 17, extraBits: 3, extra: uint32(run - 3)})
+		default:
+			run = 1
+			tokens = append(tokens, token{code: 0})
+		}
+		i += run
+	}
+
+	counts := make([]int, len(webpCodeLengthOrder))
+	for _, t := range tokens {
+		counts[t.code]++
+	}
+	lengthCode := newPrefixCode(counts, 7)
+
+	count := len(webpCodeLengthOrder)
+	for count > 4 && lengthCode.lengths[webpCodeLengthOrder[count-1]] == 0 {
+		count--
+	}
+	bw.writeBits(0, 1)
+	bw.writeBits(uint32(count-4), 4)
+	for _, code := range webpCodeLengthOrder[:count] {
+		bw.writeBits(uint32(lengthCode.lengths[code]), 3)
+	}
+	bw.writeBits(0, 1) // lengths for the whole alphabet follow
+	for _, t := range tokens {
+		lengthCode.write(bw, t.code)
+		bw.writeBits(t.extra, t.extraBits)
+	}
+}
+
+// huffmanLengths returns code lengths of at most maxLength for the symbol counts,
+// flattening the counts until the tree is shallow enough. A lone symbol gets length 1.
+func huffmanLengths(counts []int, maxLength int) []uint8 {
+	lengths := make([]uint8, len(counts))
+	weights := make([]int, len(counts))
+	var used []int
+	for s, n := range counts {
+		if n > 0 {
+			used = append(used, s)
+			weights[s] = n
+		}
+	}
+	if len(used) == 0 {
+		return lengths
+	}
+	if len(used) == 1 {
+		lengths[used[0]] = 1
+		return lengths
+	}
+
+	for {
+		type node struct {
+			weight, parent int
+		}
+		nodes := make([]node, 0, 2*len(used))
+		for _, s := range used {
+			nodes = append(nodes, node{weight: weights[s], parent: -1})
+		}
+		queue := make([]int, len(used))
+		for i := range queue {
+			queue[i] = i
+		}
+		sort.SliceStable(queue, func(a, b int) bool { return nodes[queue[a]].weight < nodes[queue[b]].weight })
+
+		// Two queues: the sorted leaves and the merged nodes, which come out in order
+		var merged []int
+		take := func() int {
+			if len(merged) == 0 || (len(queue) > 0 && nodes[queue[0]].weight <= nodes[merged[0]].weight) {
+				n := queue[0]
+				queue = queue[1:]
+				return n
+			}
+			n := merged[0]
+			merged = merged[1:]
+			return n
+		}
+		for len(queue)+len(merged) > 1 {
+			a, b := take(), take()
+			nodes = append(nodes, node{weight: nodes[a].weight + nodes[b].weight, parent: -1})
+			nodes[a].parent = len(nodes) - 1
+			nodes[b].parent = len(nodes) - 1
+			merged = append(merged, len(nodes)-1)
+		}
+
+		depths := make([]int, len(nodes))
+		for i := len(nodes) - 2; i >= 0; i-- {
+			depths[i] = depths[nodes[i].parent] + 1
+		}
+		deepest := 0
+		for i := range used {
+			if depths[i] > deepest {
+				deepest = depths[i]
+			}
+		}
+		if deepest <= maxLength {
+			for i, s := range used {
+				lengths[s] = uint8(depths[i])
+			}
+			return lengths
+		}
+		for _, s := range used {
+			weights[s] = (weights[s] + 1) / 2
+		}
+	}
+}
+
+// canonicalCodes assigns codes by length, then symbol, and reverses their bits
+func canonicalCodes(lengths []uint8) []uint32 {
+	var lengthCount [16]int
+	for _, l := range lengths {
+		if l > 0 {
+			lengthCount[l]++
+		}
+	}
+	var next [16]uint32
+	code := uint32(0)
+	for l := 1; l < 16; l++ {
+		code = (code + uint32(lengthCount[l-1])) << 1
+		next[l] = code
+	}
+
+	codes := make([]uint32, len(lengths))
+	for s, l := range lengths {
+		if l == 0 {
+			continue
+		}
+		c := next[l]
+		next[l]++
+		var reversed uint32
+		for i := 0; i < int(l); i++ {
+			reversed = reversed<<1 | (c>>i)&1
+		}
+		codes[s] = reversed
+	}
+	return codes
+}
+
+// bitWriter packs bits starting from the least significant bit of each byte
+type bitWriter struct {
+	buf   []byte
+	acc   uint64
+	nbits int
+}
+
+func (b *bitWriter) writeBits(v uint32, n int) {
+	b.acc |= uint64(v) << b.nbits
+	b.nbits += n
+	for b.nbits >= 8 {
+		b.buf = append(b.buf, byte(b.acc))
+		b.acc >>= 8
+		b.nbits -= 8
+	}
+}
+
+func (b *bitWriter) bytes() []byte {
+	if b.nbits > 0 {
+		b.buf = append(b.buf, byte(b.acc))
+		b.acc, b.nbits = 0, 0
+	}
+	return b.buf
+}
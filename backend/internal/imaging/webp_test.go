@@ -0,0 +1,140 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// webpSize reads the dimensions from the header of a lossless WebP
+func webpSize(t *testing.T, data []byte) (int, int) {
+	t.Helper()
+	if len(data) < 25 || string(data[0:4]) != "RIFF" || string(data[8:16]) != "WEBPVP8L" || data[20] != 0x2f {
+		t.Fatalf("not a lossless WebP: % x", data[:minInt(len(data), 25)])
+	}
+	if riffSize := binary.LittleEndian.Uint32(data[4:8]); int(riffSize) != len(data)-8 {
+		t.Fatalf("RIFF size %d, file has %d bytes", riffSize, len(data))
+	}
+	bits := binary.LittleEndian.Uint32(data[21:25])
+	return int(bits&0x3fff) + 1, int(bits>>14&0x3fff) + 1
+}
+
+func TestEncodeWebP(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 37, 21))
+	for y := 0; y < 21; y++ {
+		for x := 0; x < 37; x++ {
+			img.SetRGBA(x, y, color.RGBA{R: uint8(x * 7), G: uint8(y * 12), B: 255, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := EncodeWebP(&buf, img); err != nil {
+		t.Fatalf("EncodeWebP() error: %v", err)
+	}
+	if w, h := webpSize(t, buf.Bytes()); w != 37 || h != 21 {
+		t.Errorf("got %dx%d, want 37x21", w, h)
+	}
+
+	// A flat image is mostly copies and ends up tiny
+	flat := image.NewRGBA(image.Rect(0, 0, 400, 400))
+	for i := range flat.Pix {
+		flat.Pix[i] = 0xff
+	}
+	buf.Reset()
+	if err := EncodeWebP(&buf, flat); err != nil {
+		t.Fatalf("EncodeWebP() error: %v", err)
+	}
+	if buf.Len() > 1024 {
+		t.Errorf("flat 400x400 image took %d bytes", buf.Len())
+	}
+}
+
+func TestHuffmanLengthsLimit(t *testing.T) {
+	// Fibonacci counts make the deepest unlimited tree
+	counts := make([]int, 30)
+	a, b := 1, 1
+	for i := range counts {
+		counts[i] = a
+		a, b = b, a+b
+	}
+	lengths := huffmanLengths(counts, 15)
+
+	kraft := 0.0
+	for s, l := range lengths {
+		if l == 0 || l > 15 {
+			t.Fatalf("symbol %d got length %d", s, l)
+		}
+		kraft += 1 / float64(uint(1)<<l)
+	}
+	if kraft != 1 {
+		t.Errorf("code is not complete, Kraft sum %v", kraft)
+	}
+}
+
+func TestFitSize(t *testing.T) {
+	tests := []struct {
+		width, height, max int
+		wantW, wantH       int
+	}{
+		{4000, 3000, 1600, 1600, 1200},
+		{1000, 2000, 800, 400, 800},
+		{150, 100, 200, 150, 100},
+	}
+	for _, tt := range tests {
+		if w, h := FitSize(tt.width, tt.height, tt.max, tt.max); w != tt.wantW || h != tt.wantH {
+			t.Errorf("FitSize(%d, %d, %d) = %dx%d, want %dx%d", tt.width, tt.height, tt.max, w, h, tt.wantW, tt.wantH)
+		}
+	}
+}
+
+func TestGenerateSizes(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "source.png")
+	writeTestPNG(t, path, 1000, 500)
+
+	sizes, err := GenerateSizes(path, "image/png")
+	if err != nil {
+		t.Fatalf("GenerateSizes() error: %v", err)
+	}
+	if len(sizes) != len(Sizes) {
+		t.Fatalf("got %d sizes, want %d", len(sizes), len(Sizes))
+	}
+
+	want := map[string][2]int{SizeThumbnail: {200, 100}, SizeMedium: {800, 400}, SizeLarge: {1000, 500}}
+	for _, size := range sizes {
+		data, err := os.ReadFile(size.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w, h := webpSize(t, data)
+		if w != want[size.Name][0] || h != want[size.Name][1] || size.Width != w || size.Height != h {
+			t.Errorf("%s: file %dx%d, recorded %dx%d, want %v", size.Name, w, h, size.Width, size.Height, want[size.Name])
+		}
+		if size.SizeBytes != int64(len(data)) || size.MimeType != "image/webp" {
+			t.Errorf("%s: recorded %d bytes of %s, file has %d", size.Name, size.SizeBytes, size.MimeType, len(data))
+		}
+	}
+}
+
+func writeTestPNG(t *testing.T, path string, width, height int) {
+	t.Helper()
+	src := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			src.SetRGBA(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if err := png.Encode(file, src); err != nil {
+		t.Fatal(err)
+	}
+}
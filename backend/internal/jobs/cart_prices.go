@@ -0,0 +1,34 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/models"
+)
+
+// CartPrices returns a job that reprices cart items older than the cart_price_refresh_days
+// setting against current prices, so returning customers see what changed
+func CartPrices(cartQueries *database.CartQueries, settingsQueries *database.SettingsQueries) Func {
+	return func(ctx context.Context) error {
+		days, err := settingsQueries.GetIntSetting(models.SettingCartPriceRefreshDays, 7)
+		if err != nil {
+			return err
+		}
+		if days <= 0 {
+			return nil
+		}
+
+		result, err := cartQueries.RefreshStaleCartPrices(time.Now().AddDate(0, 0, -days))
+		if err != nil {
+			return err
+		}
+
+		if result.Changed > 0 {
+			log.Printf("Repriced %d of %d stale cart items", result.Changed, result.Checked)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,30 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/models"
+)
+
+// DiscountUsage returns a job that releases the discount uses of online orders left
+// unpaid for longer than the discount_usage_expiry_hours setting
+func DiscountUsage(discountQueries *database.DiscountQueries, settingsQueries *database.SettingsQueries) Func {
+	return func(ctx context.Context) error {
+		hours, err := settingsQueries.GetIntSetting(models.SettingDiscountUsageExpiryHours, models.DefaultDiscountUsageExpiryHours)
+		if err != nil {
+			return err
+		}
+		if hours <= 0 {
+			return nil
+		}
+
+		released, err := discountQueries.ReleaseExpiredDiscountUsage(time.Now().Add(-time.Duration(hours) * time.Hour))
+		if released > 0 {
+			log.Printf("Released %d discount code uses of unpaid orders", released)
+		}
+		return err
+	}
+}
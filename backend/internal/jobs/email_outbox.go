@@ -0,0 +1,58 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/mail"
+)
+
+const emailBatchSize = 50
+
+// EmailOutbox returns a job that sends due outbox emails, retrying failures with
+// exponential backoff until mail.MaxAttempts is reached. Emails customers can opt out
+// of get an unsubscribe link.
+func EmailOutbox(emailQueries *database.EmailQueries, mailer mail.Sender, unsubscribe *mail.UnsubscribeLinks) Func {
+	return func(ctx context.Context) error {
+		for {
+			due, err := emailQueries.ClaimDueEmails(emailBatchSize)
+			if err != nil {
+				return err
+			}
+
+			for _, e := range due {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+
+				if category := mail.TemplateCategory(e.Template); category != "" {
+					e.Message = unsubscribe.Apply(e.Message, category)
+				}
+
+				sendCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+				sendErr := mailer.Send(sendCtx, e.Message)
+				cancel()
+
+				var retryAt *time.Time
+				if sendErr != nil {
+					attempts := e.Attempts + 1
+					if attempts < mail.MaxAttempts {
+						next := time.Now().Add(mail.RetryDelay(attempts))
+						retryAt = &next
+					} else {
+						log.Printf("Giving up %s email %d to %s after %d attempts: %v", e.Template, e.ID, e.Message.To, attempts, sendErr)
+					}
+				}
+				if err := emailQueries.RecordEmailAttempt(e.ID, sendErr, retryAt); err != nil {
+					return err
+				}
+			}
+
+			if len(due) < emailBatchSize {
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package jobs
+
+import (
+	"context"
+	"log"
+
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/exchangerates"
+	"notsofluffy-backend/internal/models"
+)
+
+// ExchangeRates returns a job that updates the rates of the currencies from NBP unless
+// the exchange_rates_auto_update setting is off
+func ExchangeRates(currencyQueries *database.CurrencyQueries, settingsQueries *database.SettingsQueries, client *exchangerates.NBPClient) Func {
+	return func(ctx context.Context) error {
+		enabled, err := settingsQueries.GetBoolSetting(models.SettingExchangeRatesAutoUpdate, true)
+		if err != nil {
+			return err
+		}
+		if !enabled {
+			return nil
+		}
+
+		table, err := client.Rates(ctx)
+		if err != nil {
+			return err
+		}
+		updated, missing, err := currencyQueries.UpdateRates(table.Rates, models.CurrencySourceNBP)
+		if err != nil {
+			return err
+		}
+
+		if len(updated) > 0 {
+			log.Printf("Updated %d exchange rates to the NBP table of %s", len(updated), table.EffectiveDate)
+		}
+		if len(missing) > 0 {
+			log.Printf("NBP has no exchange rate for %v", missing)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,96 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/exports"
+	"notsofluffy-backend/internal/mail"
+	"notsofluffy-backend/internal/models"
+)
+
+// Exports returns a job that generates requested exports into dir, one at a time, and
+// emails the admin who requested each one when it is ready or has failed
+func Exports(exportQueries *database.ExportQueries, emailQueries *database.EmailQueries, settingsQueries *database.SettingsQueries, dir, siteURL string) Func {
+	return func(ctx context.Context) error {
+		for ctx.Err() == nil {
+			export, err := exportQueries.ClaimExport()
+			if err != nil {
+				return err
+			}
+			if export == nil {
+				return nil
+			}
+
+			fileName := exports.FileName(export)
+			path := filepath.Join(dir, strconv.Itoa(export.ID)+".csv")
+			rows, size, genErr := generateExport(exportQueries, settingsQueries, export, path)
+			if genErr != nil {
+				log.Printf("Export %d failed: %v", export.ID, genErr)
+				if err := exportQueries.FailExport(export.ID, genErr); err != nil {
+					return err
+				}
+			} else if err := exportQueries.CompleteExport(export.ID, fileName, path, rows, size); err != nil {
+				return err
+			}
+
+			if export.RequestedByEmail != nil {
+				msg := exportEmail(export, fileName, rows, genErr, siteURL)
+				msg.To = *export.RequestedByEmail
+				if err := emailQueries.EnqueueEmail("export_ready", msg, nil); err != nil {
+					log.Printf("Failed to queue export %d email: %v", export.ID, err)
+				}
+			}
+		}
+		return ctx.Err()
+	}
+}
+
+// generateExport writes an export to a temporary file renamed to path once complete,
+// so a crash never leaves a partial file that looks finished
+func generateExport(exportQueries *database.ExportQueries, settingsQueries *database.SettingsQueries, export *models.Export, path string) (int, int64, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".export-*")
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to create export file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	rows, err := exports.Write(tmp, exportQueries, export.Type, export.Params, settingsQueries.GetShopLocation())
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	info, err := os.Stat(tmp.Name())
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to stat export file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, 0, fmt.Errorf("failed to store export file: %w", err)
+	}
+	return rows, info.Size(), nil
+}
+
+func exportEmail(export *models.Export, fileName string, rows int, genErr error, siteURL string) mail.Message {
+	if genErr != nil {
+		return mail.Message{
+			Subject: fmt.Sprintf("Export %s failed", fileName),
+			Body: fmt.Sprintf("The %s export you requested could not be generated:\n%v\n\n"+
+				"You can request it again in the export center:\n%s/admin/exports\n",
+				export.Type, genErr, siteURL),
+		}
+	}
+	return mail.Message{
+		Subject: fmt.Sprintf("Export %s is ready", fileName),
+		Body: fmt.Sprintf("The %s export you requested is ready, with %d rows.\n\n"+
+			"Download it from the export center:\n%s/admin/exports\n",
+			export.Type, rows, siteURL),
+	}
+}
@@ -0,0 +1,83 @@
+package jobs
+
+import (
+	"context"
+	"log"
+
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/imaging"
+	"notsofluffy-backend/internal/models"
+)
+
+const imageVariantsPageSize = 100
+
+// ImageVariants returns a job that generates missing variants and sizes, e.g. for
+// images uploaded before they existed or whose generation failed
+func ImageVariants(imageQueries *database.ImageQueries) Func {
+	return func(ctx context.Context) error {
+		generated, sized := 0, 0
+		// Walk the images by cursor, so uploads and deletes during the run don't shift pages
+		var after *models.Cursor
+		for {
+			images, _, next, err := imageQueries.ListImages(1, imageVariantsPageSize, after, "")
+			if err != nil {
+				return err
+			}
+
+			ids := make([]int, len(images))
+			for i := range images {
+				ids[i] = images[i].ID
+			}
+			sizes, err := imageQueries.GetImageVariants(ids)
+			if err != nil {
+				return err
+			}
+
+			for _, image := range images {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				if !imaging.Supported(image.MimeType) {
+					continue
+				}
+
+				if !imaging.HasVariants(image.Path, image.MimeType) {
+					meta := imaging.Meta{FocalX: image.FocalX, FocalY: image.FocalY, Crops: image.Crops}
+					if err := imaging.Generate(image.Path, image.MimeType, meta); err != nil {
+						log.Printf("Failed to generate variants for image %d: %v", image.ID, err)
+						continue
+					}
+					generated++
+				}
+
+				if len(sizes[image.ID]) < len(imaging.Sizes) {
+					generatedSizes, err := imaging.GenerateSizes(image.Path, image.MimeType)
+					if err != nil {
+						log.Printf("Failed to generate sizes for image %d: %v", image.ID, err)
+						continue
+					}
+					if err := imageQueries.SaveImageVariants(image.ID, generatedSizes); err != nil {
+						log.Printf("Failed to save sizes for image %d: %v", image.ID, err)
+						continue
+					}
+					sized++
+				}
+			}
+
+			if next == "" {
+				break
+			}
+			if after, err = models.DecodeCursor(next); err != nil {
+				return err
+			}
+		}
+
+		if generated > 0 {
+			log.Printf("Generated variants for %d images", generated)
+		}
+		if sized > 0 {
+			log.Printf("Generated sizes for %d images", sized)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,30 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/models"
+)
+
+// OrderArchive returns a job that archives delivered and cancelled orders older than the
+// order_archive_after_years setting
+func OrderArchive(orderQueries *database.OrderQueries, settingsQueries *database.SettingsQueries) Func {
+	return func(ctx context.Context) error {
+		years, err := settingsQueries.GetIntSetting(models.SettingOrderArchiveAfterYears, 5)
+		if err != nil {
+			return err
+		}
+		if years <= 0 {
+			return nil
+		}
+
+		result, err := orderQueries.ArchiveOrders(time.Now().AddDate(-years, 0, 0))
+		if result != nil && result.Archived > 0 {
+			log.Printf("Archived %d of %d orders", result.Archived, result.Checked)
+		}
+		return err
+	}
+}
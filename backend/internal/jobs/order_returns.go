@@ -0,0 +1,146 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/mail"
+	"notsofluffy-backend/internal/models"
+	"notsofluffy-backend/internal/shipping"
+)
+
+// returnsPerRun bounds the carrier requests of one tracking run
+const returnsPerRun = 100
+
+// ReturnTracker follows approved order returns: it emails customers their return
+// label once it is ready and moves returns shipped with the carrier to in transit and
+// received as the carrier reports the parcel's progress
+type ReturnTracker struct {
+	returnQueries *database.OrderReturnQueries
+	orderQueries  *database.OrderQueries
+	emailQueries  *database.EmailQueries
+	provider      shipping.Provider
+	siteURL       string
+}
+
+// NewReturnTracker creates a tracker; register its Run with the scheduler. provider
+// is nil when no carrier is integrated.
+func NewReturnTracker(returnQueries *database.OrderReturnQueries, orderQueries *database.OrderQueries, emailQueries *database.EmailQueries, provider shipping.Provider, siteURL string) *ReturnTracker {
+	return &ReturnTracker{
+		returnQueries: returnQueries,
+		orderQueries:  orderQueries,
+		emailQueries:  emailQueries,
+		provider:      provider,
+		siteURL:       siteURL,
+	}
+}
+
+// Run tracks the open returns. A return that fails is logged and tried again on the
+// next run.
+func (t *ReturnTracker) Run(ctx context.Context) error {
+	returns, err := t.returnQueries.ListOpenReturns(returnsPerRun)
+	if err != nil {
+		return err
+	}
+	for i := range returns {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if _, err := t.Track(ctx, &returns[i]); err != nil {
+			log.Printf("Failed to track return %d: %v", returns[i].ID, err)
+		}
+	}
+	return nil
+}
+
+// Track updates an approved return from its carrier shipment and emails the customer
+// the label once it is ready, and a confirmation once the parcel arrives. It returns
+// the return as updated.
+func (t *ReturnTracker) Track(ctx context.Context, ret *models.OrderReturn) (*models.OrderReturn, error) {
+	if t.followed(ret) {
+		shipment, err := t.provider.GetShipment(ctx, *ret.ProviderShipmentID)
+		if err != nil {
+			return ret, err
+		}
+		var trackingNumber, trackingURL *string
+		if shipment.TrackingNumber != "" {
+			url := t.provider.TrackingURL(shipment.TrackingNumber)
+			trackingNumber, trackingURL = &shipment.TrackingNumber, &url
+		}
+
+		previous := ret.Status
+		updated, err := t.returnQueries.UpdateReturnTracking(ret.ID, returnStatus(ret.Status, shipment.Stage), shipment.Status, trackingNumber, trackingURL)
+		if err != nil {
+			return ret, err
+		}
+		ret = updated
+		if ret.Status == models.OrderReturnStatusReceived && previous != models.OrderReturnStatusReceived {
+			if err := t.Notify(ctx, mail.TemplateReturnReceived, ret); err != nil {
+				return ret, err
+			}
+		}
+	}
+
+	if ret.Status == models.OrderReturnStatusApproved && ret.LabelSentAt == nil && labelReady(ret) {
+		if err := t.Notify(ctx, mail.TemplateReturnLabel, ret); err != nil {
+			return ret, err
+		}
+		if err := t.returnQueries.MarkReturnLabelSent(ret.ID); err != nil {
+			return ret, err
+		}
+		now := time.Now()
+		ret.LabelSentAt = &now
+	}
+	return ret, nil
+}
+
+// Notify emails the customer of a return the template rendered for it
+func (t *ReturnTracker) Notify(ctx context.Context, template string, ret *models.OrderReturn) error {
+	order, err := t.orderQueries.GetOrderByID(ret.OrderID)
+	if err != nil {
+		return err
+	}
+	tpl, err := t.emailQueries.GetEmailTemplate(template)
+	if err != nil {
+		return err
+	}
+
+	data := models.EmailTemplateData{Order: order, Return: ret}
+	if t.siteURL != "" && order.PublicHash != nil {
+		data.OrderURL = t.siteURL + "/order/" + *order.PublicHash
+	}
+	msg, err := mail.Render(tpl, order.Email, data)
+	if err != nil {
+		return err
+	}
+	return t.emailQueries.EnqueueEmail(template, msg, &order.ID)
+}
+
+// followed reports whether a return was shipped with the configured carrier
+func (t *ReturnTracker) followed(ret *models.OrderReturn) bool {
+	return t.provider != nil && ret.LabelType != nil && *ret.LabelType == models.ReturnLabelCarrier &&
+		ret.Provider != nil && *ret.Provider == t.provider.Name() && ret.ProviderShipmentID != nil
+}
+
+// labelReady reports whether the label of a return can be downloaded. Carrier labels
+// are ready once the carrier assigned the tracking number.
+func labelReady(ret *models.OrderReturn) bool {
+	if ret.LabelType == nil {
+		return false
+	}
+	return *ret.LabelType == models.ReturnLabelInstructions || ret.TrackingNumber != nil
+}
+
+// returnStatus returns the status of a return whose shipment reached stage. Returns
+// never move back, e.g. on a stale carrier status.
+func returnStatus(current, stage string) string {
+	switch {
+	case stage == shipping.StageDelivered:
+		return models.OrderReturnStatusReceived
+	case stage == shipping.StageInTransit && current == models.OrderReturnStatusApproved:
+		return models.OrderReturnStatusInTransit
+	}
+	return current
+}
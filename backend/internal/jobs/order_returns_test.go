@@ -0,0 +1,42 @@
+package jobs
+
+import (
+	"testing"
+
+	"notsofluffy-backend/internal/models"
+	"notsofluffy-backend/internal/shipping"
+)
+
+func TestReturnStatus(t *testing.T) {
+	tests := []struct {
+		current, stage, want string
+	}{
+		{models.OrderReturnStatusApproved, shipping.StageCreated, models.OrderReturnStatusApproved},
+		{models.OrderReturnStatusApproved, shipping.StageInTransit, models.OrderReturnStatusInTransit},
+		{models.OrderReturnStatusApproved, shipping.StageDelivered, models.OrderReturnStatusReceived},
+		{models.OrderReturnStatusInTransit, shipping.StageCreated, models.OrderReturnStatusInTransit},
+		{models.OrderReturnStatusInTransit, shipping.StageDelivered, models.OrderReturnStatusReceived},
+	}
+	for _, tt := range tests {
+		if got := returnStatus(tt.current, tt.stage); got != tt.want {
+			t.Errorf("returnStatus(%q, %q) = %q, want %q", tt.current, tt.stage, got, tt.want)
+		}
+	}
+}
+
+func TestLabelReady(t *testing.T) {
+	carrier, instructions, tracking := models.ReturnLabelCarrier, models.ReturnLabelInstructions, "520000011395200025754311"
+
+	if labelReady(&models.OrderReturn{}) {
+		t.Error("a return without a label should not be ready")
+	}
+	if !labelReady(&models.OrderReturn{LabelType: &instructions}) {
+		t.Error("return instructions should be ready right away")
+	}
+	if labelReady(&models.OrderReturn{LabelType: &carrier}) {
+		t.Error("a carrier label should wait for the tracking number")
+	}
+	if !labelReady(&models.OrderReturn{LabelType: &carrier, TrackingNumber: &tracking}) {
+		t.Error("a carrier label with a tracking number should be ready")
+	}
+}
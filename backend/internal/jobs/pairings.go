@@ -0,0 +1,21 @@
+package jobs
+
+import (
+	"context"
+	"log"
+
+	"notsofluffy-backend/internal/database"
+)
+
+// ProductPairings returns a job that recomputes frequently bought together suggestions
+func ProductPairings(pairingQueries *database.PairingQueries) Func {
+	return func(ctx context.Context) error {
+		result, err := pairingQueries.RecomputePairings()
+		if err != nil {
+			return err
+		}
+
+		log.Printf("Recomputed %d frequently bought together pairs", result.Pairs)
+		return nil
+	}
+}
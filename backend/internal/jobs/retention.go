@@ -0,0 +1,25 @@
+package jobs
+
+import (
+	"context"
+	"log"
+
+	"notsofluffy-backend/internal/database"
+)
+
+// Retention returns a job that applies the configured data retention rules
+func Retention(retentionQueries *database.RetentionQueries) Func {
+	return func(ctx context.Context) error {
+		report, err := retentionQueries.ApplyRetention()
+		if err != nil {
+			return err
+		}
+
+		for _, result := range report.Results {
+			if result.AffectedCount > 0 {
+				log.Printf("Retention rule %s affected %d records", result.Rule, result.AffectedCount)
+			}
+		}
+		return nil
+	}
+}
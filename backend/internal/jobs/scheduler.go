@@ -0,0 +1,77 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Func is the work performed by a scheduled job
+type Func func(ctx context.Context) error
+
+type job struct {
+	name     string
+	interval time.Duration
+	run      Func
+}
+
+// Scheduler runs registered jobs in the background at fixed intervals
+type Scheduler struct {
+	jobs []job
+	wg   sync.WaitGroup
+}
+
+// NewScheduler creates an empty scheduler
+func NewScheduler() *Scheduler {
+	return &Scheduler{}
+}
+
+// Add registers a job that runs once at startup and then every interval
+func (s *Scheduler) Add(name string, interval time.Duration, run Func) {
+	s.jobs = append(s.jobs, job{name: name, interval: interval, run: run})
+}
+
+// Start launches all registered jobs. They stop when ctx is cancelled.
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, j := range s.jobs {
+		s.wg.Add(1)
+		go func(j job) {
+			defer s.wg.Done()
+			s.loop(ctx, j)
+		}(j)
+	}
+}
+
+// Wait blocks until all jobs have returned after ctx cancellation
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, j job) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		s.runOnce(ctx, j)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) runOnce(ctx context.Context, j job) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Job %s panicked: %v", j.name, r)
+		}
+	}()
+
+	start := time.Now()
+	if err := j.run(ctx); err != nil {
+		log.Printf("Job %s failed after %s: %v", j.name, time.Since(start), err)
+	}
+}
@@ -0,0 +1,55 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSchedulerRunsJobsUntilCancelled(t *testing.T) {
+	var runs int32
+	s := NewScheduler()
+	s.Add("counter", 10*time.Millisecond, func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Start(ctx)
+	time.Sleep(35 * time.Millisecond)
+	cancel()
+	s.Wait()
+
+	got := atomic.LoadInt32(&runs)
+	if got < 2 {
+		t.Fatalf("expected job to run at least twice, ran %d times", got)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if after := atomic.LoadInt32(&runs); after != got {
+		t.Fatalf("job kept running after cancel: %d -> %d", got, after)
+	}
+}
+
+func TestSchedulerSurvivesFailingJobs(t *testing.T) {
+	var runs int32
+	s := NewScheduler()
+	s.Add("failing", 5*time.Millisecond, func(ctx context.Context) error {
+		if atomic.AddInt32(&runs, 1) == 1 {
+			panic("boom")
+		}
+		return errors.New("still failing")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Start(ctx)
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	s.Wait()
+
+	if atomic.LoadInt32(&runs) < 2 {
+		t.Fatal("expected job to keep running after a panic")
+	}
+}
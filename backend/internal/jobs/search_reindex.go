@@ -0,0 +1,116 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/models"
+)
+
+// searchReindexBatch is how many products a rebuild refreshes per statement
+const searchReindexBatch = 200
+
+// SearchReindexer rebuilds the product search index in the background on request and
+// reports the progress of the last rebuild. Day to day the index is kept current by
+// database triggers; a rebuild is for after bulk changes made outside the API.
+type SearchReindexer struct {
+	ctx            context.Context
+	productQueries *database.ProductQueries
+
+	mu     sync.Mutex
+	status models.SearchReindexStatus
+}
+
+// NewSearchReindexer creates a reindexer whose rebuilds stop when ctx is cancelled
+func NewSearchReindexer(ctx context.Context, productQueries *database.ProductQueries) *SearchReindexer {
+	return &SearchReindexer{ctx: ctx, productQueries: productQueries}
+}
+
+// Start begins a rebuild unless one is already running, and reports whether it did
+func (r *SearchReindexer) Start() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.status.Running {
+		return false
+	}
+
+	now := time.Now()
+	r.status = models.SearchReindexStatus{Running: true, StartedAt: &now}
+	go r.run()
+	return true
+}
+
+// run refreshes the documents of all products in batches of IDs
+func (r *SearchReindexer) run() {
+	err := r.rebuild()
+
+	now := time.Now()
+	r.mu.Lock()
+	r.status.Running = false
+	r.status.FinishedAt = &now
+	if err != nil {
+		r.status.Error = err.Error()
+	}
+	indexed := r.status.Indexed
+	r.mu.Unlock()
+
+	if err != nil {
+		log.Printf("Search reindex failed after %d products: %v", indexed, err)
+		return
+	}
+	log.Printf("Search reindex finished: %d products", indexed)
+}
+
+func (r *SearchReindexer) rebuild() error {
+	total, err := r.productQueries.CountProducts()
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.status.Total = total
+	r.mu.Unlock()
+
+	afterID := 0
+	for {
+		if err := r.ctx.Err(); err != nil {
+			return err
+		}
+		ids, err := r.productQueries.GetProductIDsAfter(afterID, searchReindexBatch)
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		if err := r.productQueries.RefreshSearchIndex(ids); err != nil {
+			return err
+		}
+		afterID = ids[len(ids)-1]
+
+		r.mu.Lock()
+		r.status.Indexed += len(ids)
+		// Products added during the rebuild are indexed too
+		if r.status.Indexed > r.status.Total {
+			r.status.Total = r.status.Indexed
+		}
+		r.mu.Unlock()
+	}
+}
+
+// Status returns the progress of the last rebuild with the current size of the index
+func (r *SearchReindexer) Status() (*models.SearchReindexStatus, error) {
+	indexedProducts, lastIndexedAt, err := r.productQueries.GetSearchIndexStats()
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	status := r.status
+	r.mu.Unlock()
+	status.IndexedProducts = indexedProducts
+	status.LastIndexedAt = lastIndexedAt
+	return &status, nil
+}
@@ -0,0 +1,68 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/models"
+)
+
+// ReservationSweeper releases expired stock reservations and keeps count of what it
+// swept since the server started
+type ReservationSweeper struct {
+	stockQueries *database.StockQueries
+
+	mu               sync.Mutex
+	runs             int64
+	swept            int64
+	releasedQuantity int64
+	lastSweepAt      *time.Time
+	lastSwept        int
+}
+
+// NewReservationSweeper creates a sweeper; register its Run with the scheduler
+func NewReservationSweeper(stockQueries *database.StockQueries) *ReservationSweeper {
+	return &ReservationSweeper{stockQueries: stockQueries}
+}
+
+// Run releases the reservations that have expired
+func (s *ReservationSweeper) Run(ctx context.Context) error {
+	now := time.Now()
+	sweep, err := s.stockQueries.ReleaseExpiredReservations(now)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.runs++
+	s.swept += int64(sweep.Swept)
+	s.releasedQuantity += int64(sweep.ReleasedQuantity)
+	s.lastSweepAt = &now
+	s.lastSwept = sweep.Swept
+	s.mu.Unlock()
+
+	if sweep.Swept > 0 {
+		log.Printf("Released %d expired stock reservations (%d units)", sweep.Swept, sweep.ReleasedQuantity)
+	}
+	return nil
+}
+
+// Stats adds the sweeper's totals to the current reservation counts
+func (s *ReservationSweeper) Stats() (*models.StockReservationStats, error) {
+	stats, err := s.stockQueries.GetReservationStats(time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats.SweeperRuns = s.runs
+	stats.Swept = s.swept
+	stats.ReleasedQuantity = s.releasedQuantity
+	stats.LastSweepAt = s.lastSweepAt
+	stats.LastSwept = s.lastSwept
+	return stats, nil
+}
@@ -0,0 +1,50 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/webhooks"
+)
+
+const webhookBatchSize = 50
+
+// WebhookDeliveries returns a job that sends due webhook deliveries, retrying failures
+// with exponential backoff until webhooks.MaxAttempts is reached
+func WebhookDeliveries(webhookQueries *database.WebhookQueries, client *webhooks.Client) Func {
+	return func(ctx context.Context) error {
+		for {
+			due, err := webhookQueries.ClaimDueDeliveries(webhookBatchSize)
+			if err != nil {
+				return err
+			}
+
+			for _, d := range due {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+
+				status, deliverErr := client.Deliver(ctx, webhooks.Delivery{ID: d.ID, URL: d.URL, Secret: d.Secret, Event: d.Event, Body: d.Payload})
+				var retryAt *time.Time
+				if deliverErr != nil {
+					attempts := d.Attempts + 1
+					if attempts < webhooks.MaxAttempts {
+						next := time.Now().Add(webhooks.Backoff(attempts))
+						retryAt = &next
+					} else {
+						log.Printf("Giving up webhook delivery %d (%s) after %d attempts: %v", d.ID, d.Event, attempts, deliverErr)
+					}
+				}
+				if err := webhookQueries.RecordDeliveryAttempt(d.ID, status, deliverErr, retryAt); err != nil {
+					return err
+				}
+			}
+
+			if len(due) < webhookBatchSize {
+				return nil
+			}
+		}
+	}
+}
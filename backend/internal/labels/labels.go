@@ -0,0 +1,107 @@
+// Package labels lays out address labels for parcels on A4 sheets, eight per page in
+// two columns, to be cut or printed on 105x74 mm label sheets, and the packing slips
+// that go into the parcels.
+package labels
+
+import (
+	"strings"
+
+	"notsofluffy-backend/internal/pdf"
+)
+
+const (
+	columns = 2
+	rows    = 4
+	padding = 8 // mm
+)
+
+// Address is a postal address as printed on a label
+type Address struct {
+	Name       string
+	Company    string
+	Lines      []string
+	PostalCode string
+	City       string
+	Country    string
+	Phone      string
+}
+
+// Label is one parcel's label; Reference identifies the parcel, e.g. "Order #123"
+type Label struct {
+	Reference string
+	Recipient Address
+}
+
+// Render returns a PDF with the labels in order. sender, when not empty, is printed
+// in small type at the top of each label.
+func Render(items []Label, sender []string) []byte {
+	doc := pdf.New(pdf.A4Width, pdf.A4Height)
+	if len(items) == 0 {
+		doc.AddPage()
+		return doc.Bytes()
+	}
+
+	cellWidth := pdf.A4Width / columns
+	cellHeight := pdf.A4Height / rows
+	senderLine := ""
+	if len(sender) > 0 {
+		senderLine = "From: " + strings.Join(nonEmpty(sender), ", ")
+	}
+
+	for i, item := range items {
+		slot := i % (columns * rows)
+		if slot == 0 {
+			doc.AddPage()
+		}
+		x := float64(slot%columns) * cellWidth
+		y := float64(slot/columns) * cellHeight
+		doc.DashedRect(x, y, cellWidth, cellHeight, 0.3)
+		drawLabel(doc, x+pdf.MM(padding), y+pdf.MM(padding), cellWidth-2*pdf.MM(padding), cellHeight-2*pdf.MM(padding), item, senderLine)
+	}
+
+	return doc.Bytes()
+}
+
+func drawLabel(doc *pdf.Document, x, top, width, height float64, item Label, senderLine string) {
+	y := top
+	line := func(size float64, bold bool, text string) {
+		y += size * 1.25
+		doc.Text(x, y, size, bold, pdf.Fit(text, width, size, bold))
+	}
+
+	if senderLine != "" {
+		line(7, false, senderLine)
+		y += 8
+	}
+
+	recipient := item.Recipient
+	line(14, true, recipient.Name)
+	if recipient.Company != "" {
+		line(11, false, recipient.Company)
+	}
+	for _, addressLine := range nonEmpty(recipient.Lines) {
+		line(11, false, addressLine)
+	}
+	line(12, true, strings.TrimSpace(recipient.PostalCode+" "+recipient.City))
+	if recipient.Country != "" {
+		line(11, false, recipient.Country)
+	}
+	if recipient.Phone != "" {
+		line(9, false, "Tel. "+recipient.Phone)
+	}
+
+	// The reference sits at the bottom so long addresses don't push it off the label
+	if item.Reference != "" {
+		doc.Text(x, top+height, 8, false, pdf.Fit(item.Reference, width, 8, false))
+	}
+}
+
+func nonEmpty(lines []string) []string {
+	result := []string{}
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			result = append(result, line)
+		}
+	}
+	return result
+}
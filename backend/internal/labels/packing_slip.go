@@ -0,0 +1,112 @@
+package labels
+
+import (
+	"strconv"
+	"strings"
+
+	"notsofluffy-backend/internal/pdf"
+)
+
+// Steps ticked off on a packing slip, in order
+var packingSteps = []string{"picked", "packed", "shipped"}
+
+// PackingSlipItem is one line of a packing slip. Status is its fulfillment status;
+// the steps up to it are ticked.
+type PackingSlipItem struct {
+	Code        string
+	Description string
+	Quantity    int
+	Status      string
+}
+
+// PackingSlip lists what goes into an order's parcel
+type PackingSlip struct {
+	Reference string
+	Recipient Address
+	Items     []PackingSlipItem
+}
+
+// RenderPackingSlip returns an A4 PDF of a packing slip, continued on further pages
+// when the items don't fit on one
+func RenderPackingSlip(slip PackingSlip, sender []string) []byte {
+	doc := pdf.New(pdf.A4Width, pdf.A4Height)
+	doc.AddPage()
+
+	margin := pdf.MM(15)
+	width := pdf.A4Width - 2*margin
+	y := margin
+	line := func(size float64, bold bool, text string) {
+		y += size * 1.25
+		doc.Text(margin, y, size, bold, pdf.Fit(text, width, size, bold))
+	}
+
+	line(18, true, "Packing slip "+slip.Reference)
+	if senderLine := strings.Join(nonEmpty(sender), ", "); senderLine != "" {
+		line(8, false, "From: "+senderLine)
+	}
+	y += 10
+
+	recipient := slip.Recipient
+	line(11, true, recipient.Name)
+	if recipient.Company != "" {
+		line(10, false, recipient.Company)
+	}
+	for _, addressLine := range nonEmpty(recipient.Lines) {
+		line(10, false, addressLine)
+	}
+	line(10, false, strings.TrimSpace(recipient.PostalCode+" "+recipient.City))
+	if recipient.Country != "" {
+		line(10, false, recipient.Country)
+	}
+	y += 16
+
+	// Columns: code, quantity, description, then a box per step
+	stepWidth := 42.0
+	codeX, quantityX, descriptionX := margin, margin+90, margin+120
+	stepsX := margin + width - float64(len(packingSteps))*stepWidth
+	header := func() {
+		y += 10
+		doc.Text(codeX, y, 9, true, "Code")
+		doc.Text(quantityX, y, 9, true, "Qty")
+		doc.Text(descriptionX, y, 9, true, "Item")
+		for i, step := range packingSteps {
+			doc.Text(stepsX+float64(i)*stepWidth, y, 9, true, step)
+		}
+		y += 6
+	}
+	header()
+
+	rowHeight := 20.0
+	for _, item := range slip.Items {
+		if y+rowHeight > pdf.A4Height-margin {
+			doc.AddPage()
+			y = margin
+			header()
+		}
+		y += rowHeight
+		doc.Text(codeX, y, 10, true, pdf.Fit(item.Code, quantityX-codeX-6, 10, true))
+		doc.Text(quantityX, y, 10, false, strconv.Itoa(item.Quantity))
+		doc.Text(descriptionX, y, 10, false, pdf.Fit(item.Description, stepsX-descriptionX-6, 10, false))
+
+		done := stepsDone(item.Status)
+		for i := range packingSteps {
+			x := stepsX + float64(i)*stepWidth
+			doc.Rect(x, y-9, 10, 10, 0.6)
+			if i < done {
+				doc.Text(x+2, y-0.5, 10, true, "X")
+			}
+		}
+	}
+
+	return doc.Bytes()
+}
+
+// stepsDone returns how many packing steps an item in status has been through
+func stepsDone(status string) int {
+	for i, step := range packingSteps {
+		if step == status {
+			return i + 1
+		}
+	}
+	return 0
+}
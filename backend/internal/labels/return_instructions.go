@@ -0,0 +1,83 @@
+package labels
+
+import (
+	"strconv"
+
+	"notsofluffy-backend/internal/pdf"
+)
+
+// returnSteps are printed on return instructions, in order
+var returnSteps = []string{
+	"1. Pack the items listed below in a sturdy parcel, with this page inside.",
+	"2. Cut out the label and stick it on the parcel.",
+	"3. Send the parcel with a carrier of your choice and keep the receipt.",
+}
+
+// ReturnItem is one line of return instructions
+type ReturnItem struct {
+	Description string
+	Quantity    int
+}
+
+// ReturnInstructions tell a customer how to send back a return that has no carrier
+// label. Address is the shop's return address, one line per row.
+type ReturnInstructions struct {
+	Reference string
+	Address   []string
+	Items     []ReturnItem
+}
+
+// RenderReturnInstructions returns an A4 PDF with the steps of a return, a label to
+// cut out and the returned items
+func RenderReturnInstructions(instructions ReturnInstructions) []byte {
+	doc := pdf.New(pdf.A4Width, pdf.A4Height)
+	doc.AddPage()
+
+	margin := pdf.MM(15)
+	width := pdf.A4Width - 2*margin
+	y := margin
+	line := func(size float64, bold bool, text string) {
+		y += size * 1.25
+		doc.Text(margin, y, size, bold, pdf.Fit(text, width, size, bold))
+	}
+
+	line(18, true, "Return "+instructions.Reference)
+	y += 6
+	for _, step := range returnSteps {
+		line(10, false, step)
+	}
+	y += 12
+
+	// The label has the size of one on a label sheet, so it fits any parcel
+	labelWidth := pdf.A4Width / columns
+	labelHeight := pdf.A4Height / rows
+	doc.DashedRect(margin, y, labelWidth, labelHeight, 0.3)
+	drawLabel(doc, margin+pdf.MM(padding), y+pdf.MM(padding), labelWidth-2*pdf.MM(padding), labelHeight-2*pdf.MM(padding),
+		Label{Reference: instructions.Reference, Recipient: returnRecipient(instructions.Address)}, "")
+	y += labelHeight + 16
+
+	quantityX, descriptionX := margin, margin+30
+	line(11, true, "Returned items")
+	y += 4
+	for _, item := range instructions.Items {
+		if y+14 > pdf.A4Height-margin {
+			doc.AddPage()
+			y = margin
+		}
+		y += 14
+		doc.Text(quantityX, y, 10, false, strconv.Itoa(item.Quantity)+" x")
+		doc.Text(descriptionX, y, 10, false, pdf.Fit(item.Description, width-(descriptionX-margin), 10, false))
+	}
+
+	return doc.Bytes()
+}
+
+// returnRecipient returns the label address of the return address lines, whose first
+// line is the name
+func returnRecipient(address []string) Address {
+	lines := nonEmpty(address)
+	if len(lines) == 0 {
+		return Address{}
+	}
+	return Address{Name: lines[0], Lines: lines[1:]}
+}
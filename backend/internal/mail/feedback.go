@@ -0,0 +1,143 @@
+package mail
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Kinds of delivery feedback an email provider reports
+const (
+	FeedbackBounce    = "bounce"
+	FeedbackComplaint = "complaint"
+)
+
+// ErrUnknownFeedbackProvider is returned for notifications of a provider without a parser
+var ErrUnknownFeedbackProvider = errors.New("unknown email feedback provider")
+
+// Feedback is a bounce or spam complaint about an email sent to Email. Permanent is
+// set on bounces the address won't recover from; complaints are always permanent.
+type Feedback struct {
+	Email     string
+	Kind      string
+	Permanent bool
+	Detail    string
+}
+
+// feedbackParsers read the notifications of each provider; "generic" is for relays
+// forwarding feedback in this package's own shape
+var feedbackParsers = map[string]func([]byte) ([]Feedback, error){
+	"postmark": parsePostmarkFeedback,
+	"sendgrid": parseSendGridFeedback,
+	"generic":  parseGenericFeedback,
+}
+
+// ParseFeedback returns the bounces and complaints in a provider's notification.
+// Events other than bounces and complaints, such as deliveries, are skipped.
+func ParseFeedback(provider string, body []byte) ([]Feedback, error) {
+	parse, ok := feedbackParsers[provider]
+	if !ok {
+		return nil, ErrUnknownFeedbackProvider
+	}
+	feedback, err := parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s notification: %w", provider, err)
+	}
+
+	valid := feedback[:0]
+	for _, f := range feedback {
+		f.Email = strings.ToLower(strings.TrimSpace(f.Email))
+		if f.Email != "" {
+			valid = append(valid, f)
+		}
+	}
+	return valid, nil
+}
+
+// unmarshalEvents decodes a body holding one event or an array of them
+func unmarshalEvents(body []byte, events interface{}) error {
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '{' {
+		body = append(append([]byte{'['}, body...), ']')
+	}
+	return json.Unmarshal(body, events)
+}
+
+// postmarkPermanentBounces are the Postmark bounce types the address won't recover from
+var postmarkPermanentBounces = map[string]bool{
+	"HardBounce":          true,
+	"BadEmailAddress":     true,
+	"ManuallyDeactivated": true,
+}
+
+func parsePostmarkFeedback(body []byte) ([]Feedback, error) {
+	var events []struct {
+		RecordType  string `json:"RecordType"`
+		Type        string `json:"Type"`
+		Email       string `json:"Email"`
+		Description string `json:"Description"`
+	}
+	if err := unmarshalEvents(body, &events); err != nil {
+		return nil, err
+	}
+
+	feedback := []Feedback{}
+	for _, e := range events {
+		switch e.RecordType {
+		case "Bounce":
+			feedback = append(feedback, Feedback{Email: e.Email, Kind: FeedbackBounce, Permanent: postmarkPermanentBounces[e.Type], Detail: e.Description})
+		case "SpamComplaint":
+			feedback = append(feedback, Feedback{Email: e.Email, Kind: FeedbackComplaint, Permanent: true, Detail: e.Description})
+		}
+	}
+	return feedback, nil
+}
+
+func parseSendGridFeedback(body []byte) ([]Feedback, error) {
+	var events []struct {
+		Event  string `json:"event"`
+		Type   string `json:"type"`
+		Email  string `json:"email"`
+		Reason string `json:"reason"`
+	}
+	if err := unmarshalEvents(body, &events); err != nil {
+		return nil, err
+	}
+
+	feedback := []Feedback{}
+	for _, e := range events {
+		switch e.Event {
+		case "bounce":
+			// Blocked messages were refused for now, not because the address is gone
+			feedback = append(feedback, Feedback{Email: e.Email, Kind: FeedbackBounce, Permanent: e.Type != "blocked", Detail: e.Reason})
+		case "spamreport":
+			feedback = append(feedback, Feedback{Email: e.Email, Kind: FeedbackComplaint, Permanent: true})
+		}
+	}
+	return feedback, nil
+}
+
+func parseGenericFeedback(body []byte) ([]Feedback, error) {
+	var events []struct {
+		Type      string `json:"type"`
+		Email     string `json:"email"`
+		Permanent bool   `json:"permanent"`
+		Reason    string `json:"reason"`
+	}
+	if err := unmarshalEvents(body, &events); err != nil {
+		return nil, err
+	}
+
+	feedback := []Feedback{}
+	for _, e := range events {
+		switch e.Type {
+		case FeedbackBounce:
+			feedback = append(feedback, Feedback{Email: e.Email, Kind: FeedbackBounce, Permanent: e.Permanent, Detail: e.Reason})
+		case FeedbackComplaint:
+			feedback = append(feedback, Feedback{Email: e.Email, Kind: FeedbackComplaint, Permanent: true, Detail: e.Reason})
+		}
+	}
+	return feedback, nil
+}
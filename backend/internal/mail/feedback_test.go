@@ -0,0 +1,57 @@
+package mail
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseFeedbackPostmark(t *testing.T) {
+	feedback, err := ParseFeedback("postmark", []byte(`{"RecordType":"Bounce","Type":"HardBounce","Email":" Jan@Example.com ","Description":"Unknown user"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(feedback) != 1 || feedback[0] != (Feedback{Email: "jan@example.com", Kind: FeedbackBounce, Permanent: true, Detail: "Unknown user"}) {
+		t.Fatalf("unexpected feedback %+v", feedback)
+	}
+
+	feedback, err = ParseFeedback("postmark", []byte(`{"RecordType":"Bounce","Type":"SoftBounce","Email":"jan@example.com"}`))
+	if err != nil || len(feedback) != 1 || feedback[0].Permanent {
+		t.Fatalf("soft bounces are temporary, got %+v, %v", feedback, err)
+	}
+	feedback, err = ParseFeedback("postmark", []byte(`{"RecordType":"SpamComplaint","Email":"jan@example.com"}`))
+	if err != nil || len(feedback) != 1 || feedback[0].Kind != FeedbackComplaint {
+		t.Fatalf("expected a complaint, got %+v, %v", feedback, err)
+	}
+}
+
+func TestParseFeedbackSendGrid(t *testing.T) {
+	body := `[
+		{"event":"delivered","email":"ola@example.com"},
+		{"event":"bounce","type":"bounce","email":"jan@example.com","reason":"550 5.1.1 no such user"},
+		{"event":"bounce","type":"blocked","email":"ewa@example.com"},
+		{"event":"spamreport","email":"adam@example.com"}
+	]`
+	feedback, err := ParseFeedback("sendgrid", []byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(feedback) != 3 {
+		t.Fatalf("expected deliveries to be skipped, got %+v", feedback)
+	}
+	if !feedback[0].Permanent || feedback[1].Permanent || feedback[2].Kind != FeedbackComplaint {
+		t.Fatalf("unexpected feedback %+v", feedback)
+	}
+}
+
+func TestParseFeedbackInvalid(t *testing.T) {
+	if _, err := ParseFeedback("mailchimp", []byte(`{}`)); !errors.Is(err, ErrUnknownFeedbackProvider) {
+		t.Fatalf("expected an unknown provider, got %v", err)
+	}
+	if _, err := ParseFeedback("generic", []byte(`not json`)); err == nil {
+		t.Fatal("expected malformed notifications to fail")
+	}
+	feedback, err := ParseFeedback("generic", []byte(`[{"type":"complaint","email":""}]`))
+	if err != nil || len(feedback) != 0 {
+		t.Fatalf("feedback without an address is skipped, got %+v, %v", feedback, err)
+	}
+}
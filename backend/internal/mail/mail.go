@@ -0,0 +1,134 @@
+// Package mail sends transactional emails to customers and staff.
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Message is a plain text email, optionally with an HTML alternative. Unsubscribe is
+// the link opting the recipient out of emails like it, sent as List-Unsubscribe.
+type Message struct {
+	To          string
+	Subject     string
+	Body        string
+	HTML        string
+	Unsubscribe string
+}
+
+// Sender is implemented by every way of delivering email
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// LogSender writes emails to the log instead of sending them; used in development and
+// when no SMTP server is configured
+type LogSender struct{}
+
+// Send logs the recipient and subject of the message
+func (LogSender) Send(ctx context.Context, msg Message) error {
+	log.Printf("Email to %s not sent (no SMTP server configured): %s", msg.To, msg.Subject)
+	return nil
+}
+
+// SMTPSender delivers email through an SMTP server, upgrading to TLS with STARTTLS when
+// the server offers it
+type SMTPSender struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+}
+
+// NewSMTPSender creates a sender for the server at host:port. Username may be empty
+// for servers that accept mail without authentication.
+func NewSMTPSender(host, port, username, password, from string) *SMTPSender {
+	return &SMTPSender{
+		addr:     net.JoinHostPort(host, port),
+		host:     host,
+		username: username,
+		password: password,
+		from:     from,
+	}
+}
+
+// Send delivers the message. net/smtp takes no context; the call is bounded by the
+// server's own timeouts.
+func (s *SMTPSender) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if s.username != "" {
+		auth = smtp.PlainAuth("", s.username, s.password, s.host)
+	}
+
+	if err := smtp.SendMail(s.addr, auth, s.from, []string{msg.To}, buildMessage(s.from, msg, time.Now())); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// buildMessage renders the message with UTF-8 headers and body
+func buildMessage(from string, msg Message, date time.Time) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", headerValue(msg.To))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", headerValue(msg.Subject)))
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	if msg.Unsubscribe != "" {
+		fmt.Fprintf(&b, "List-Unsubscribe: <%s>\r\n", headerValue(msg.Unsubscribe))
+	}
+	b.WriteString("MIME-Version: 1.0\r\n")
+
+	if msg.HTML == "" {
+		writePart(&b, "text/plain", msg.Body)
+		return b.Bytes()
+	}
+
+	boundary := alternativeBoundary(msg)
+	fmt.Fprintf(&b, "Content-Type: multipart/alternative; boundary=%q\r\n", boundary)
+	b.WriteString("\r\n")
+	for _, part := range []struct{ contentType, body string }{{"text/plain", msg.Body}, {"text/html", msg.HTML}} {
+		fmt.Fprintf(&b, "--%s\r\n", boundary)
+		writePart(&b, part.contentType, part.body)
+	}
+	fmt.Fprintf(&b, "--%s--\r\n", boundary)
+	return b.Bytes()
+}
+
+// writePart writes the headers and CRLF terminated body of a UTF-8 text part
+func writePart(b *bytes.Buffer, contentType, body string) {
+	fmt.Fprintf(b, "Content-Type: %s; charset=utf-8\r\n", contentType)
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+	// smtp.SendMail dot-stuffs the lines itself
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	if !strings.HasSuffix(body, "\n") {
+		b.WriteString("\r\n")
+	}
+}
+
+// alternativeBoundary returns a multipart boundary that can't occur in the message parts
+func alternativeBoundary(msg Message) string {
+	sum := sha256.Sum256([]byte(msg.Body + msg.HTML))
+	return "nsf-" + hex.EncodeToString(sum[:12])
+}
+
+// headerValue strips line breaks so values can't inject headers
+func headerValue(value string) string {
+	return strings.NewReplacer("\r", "", "\n", " ").Replace(value)
+}
@@ -0,0 +1,51 @@
+package mail
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildMessage(t *testing.T) {
+	date := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	msg := buildMessage("Shop <shop@example.com>", Message{
+		To:      "jan@example.com\r\nBcc: evil@example.com",
+		Subject: "Nowe logowanie na koncie",
+		Body:    "Cześć,\nline two",
+	}, date)
+	text := string(msg)
+
+	if !strings.Contains(text, "To: jan@example.com Bcc: evil@example.com\r\n") {
+		t.Errorf("line breaks in the recipient must not start a header:\n%s", text)
+	}
+	if !strings.Contains(text, "Subject: Nowe logowanie na koncie\r\n") {
+		t.Errorf("ASCII subject should stay readable:\n%s", text)
+	}
+	if !strings.HasSuffix(text, "\r\n\r\nCześć,\r\nline two\r\n") {
+		t.Errorf("body should use CRLF line endings:\n%q", text)
+	}
+
+	encoded := string(buildMessage("shop@example.com", Message{To: "a@example.com", Subject: "Zażółć", Body: "x"}, date))
+	if !strings.Contains(encoded, "Subject: =?utf-8?q?") {
+		t.Errorf("non-ASCII subject should be Q-encoded:\n%s", encoded)
+	}
+}
+
+func TestBuildMessageAlternative(t *testing.T) {
+	date := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	msg := Message{To: "a@example.com", Subject: "Order", Body: "plain", HTML: "<p>html</p>"}
+	text := string(buildMessage("shop@example.com", msg, date))
+
+	boundary := alternativeBoundary(msg)
+	if !strings.Contains(text, "Content-Type: multipart/alternative; boundary=\""+boundary+"\"\r\n") {
+		t.Fatalf("message with HTML should be multipart/alternative:\n%s", text)
+	}
+	plain := strings.Index(text, "Content-Type: text/plain; charset=utf-8\r\nContent-Transfer-Encoding: 8bit\r\n\r\nplain\r\n")
+	html := strings.Index(text, "Content-Type: text/html; charset=utf-8\r\nContent-Transfer-Encoding: 8bit\r\n\r\n<p>html</p>\r\n")
+	if plain < 0 || html < plain {
+		t.Errorf("plain text part should come before the HTML part:\n%s", text)
+	}
+	if !strings.HasSuffix(text, "--"+boundary+"--\r\n") {
+		t.Errorf("multipart body should be closed:\n%s", text)
+	}
+}
@@ -0,0 +1,27 @@
+package mail
+
+import "time"
+
+// MaxAttempts is how often an outbox email is tried before it is given up
+const MaxAttempts = 6
+
+// Retry delays start at baseRetryDelay and double per attempt up to maxRetryDelay
+const (
+	baseRetryDelay = time.Minute
+	maxRetryDelay  = 2 * time.Hour
+)
+
+// RetryDelay returns how long to wait before retrying an email that failed attempts times
+func RetryDelay(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	delay := baseRetryDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= maxRetryDelay {
+			return maxRetryDelay
+		}
+	}
+	return delay
+}
@@ -0,0 +1,144 @@
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Template keys
+const (
+	TemplateOrderConfirmation = "order_confirmation"
+	TemplateOrderStatus       = "order_status"
+	TemplateReturnLabel       = "return_label"
+	TemplateReturnRejected    = "return_rejected"
+	TemplateReturnReceived    = "return_received"
+)
+
+// Template is an email rendered from Go templates: the subject and text body with
+// text/template, the HTML body with html/template, which escapes the data
+type Template struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// funcs are available in every template
+var funcs = map[string]interface{}{
+	"price": func(amount float64) string {
+		return strings.Replace(fmt.Sprintf("%.2f zł", amount), ".", ",", 1)
+	},
+}
+
+// DefaultTemplates are used for every email whose template admins haven't customized
+var DefaultTemplates = map[string]Template{
+	TemplateOrderConfirmation: {
+		Subject: "Thank you for your order #{{.Order.ID}}",
+		Text: `Thank you for your order! We will let you know when it ships.
+
+{{range .Order.Items}}{{.Quantity}} x {{.ProductName}} ({{.VariantName}}, {{.SizeName}}) - {{price .TotalPrice}}
+{{end}}{{if gt .Order.DiscountAmount 0.0}}Discount: -{{price .Order.DiscountAmount}}
+{{end}}Shipping: {{price .Order.ShippingCost}}
+Total: {{price .Order.TotalAmount}}
+`,
+		HTML: `<p>Thank you for your order! We will let you know when it ships.</p>
+<table>
+{{range .Order.Items}}<tr><td>{{.Quantity}} x {{.ProductName}} ({{.VariantName}}, {{.SizeName}})</td><td>{{price .TotalPrice}}</td></tr>
+{{end}}{{if gt .Order.DiscountAmount 0.0}}<tr><td>Discount</td><td>-{{price .Order.DiscountAmount}}</td></tr>
+{{end}}<tr><td>Shipping</td><td>{{price .Order.ShippingCost}}</td></tr>
+<tr><td><strong>Total</strong></td><td><strong>{{price .Order.TotalAmount}}</strong></td></tr>
+</table>
+`,
+	},
+	TemplateOrderStatus: {
+		Subject: "Your order #{{.Order.ID}} is now {{.Status}}",
+		Text: `The status of your order #{{.Order.ID}} changed from {{.PreviousStatus}} to {{.Status}}.
+{{if .Order.TrackingNumber}}
+Carrier: {{.Order.TrackingCarrier}}
+Tracking number: {{.Order.TrackingNumber}}
+{{end}}`,
+		HTML: `<p>The status of your order #{{.Order.ID}} changed from <strong>{{.PreviousStatus}}</strong> to <strong>{{.Status}}</strong>.</p>
+{{if .Order.TrackingNumber}}<p>Carrier: {{.Order.TrackingCarrier}}<br>Tracking number: {{.Order.TrackingNumber}}</p>
+{{end}}`,
+	},
+	TemplateReturnLabel: {
+		Subject: "Your return label for order #{{.Order.ID}}",
+		Text: `Your return of order #{{.Order.ID}} was approved. Please send back:
+
+{{range .Return.Items}}{{.Quantity}} x {{.ProductName}} ({{.VariantName}}, {{.SizeName}})
+{{end}}
+{{if .Return.TrackingNumber}}Print the return label, stick it on the parcel and drop it off at any of the carrier's points.
+Tracking number: {{.Return.TrackingNumber}}
+{{else}}Print the return instructions, which have a label to cut out, and send the parcel with a carrier of your choice.
+{{end}}{{if .OrderURL}}
+Download it from your order page: {{.OrderURL}}
+{{end}}`,
+		HTML: `<p>Your return of order #{{.Order.ID}} was approved. Please send back:</p>
+<ul>
+{{range .Return.Items}}<li>{{.Quantity}} x {{.ProductName}} ({{.VariantName}}, {{.SizeName}})</li>
+{{end}}</ul>
+{{if .Return.TrackingNumber}}<p>Print the return label, stick it on the parcel and drop it off at any of the carrier's points.<br>Tracking number: {{.Return.TrackingNumber}}</p>
+{{else}}<p>Print the return instructions, which have a label to cut out, and send the parcel with a carrier of your choice.</p>
+{{end}}{{if .OrderURL}}<p><a href="{{.OrderURL}}">Download it from your order page</a></p>
+{{end}}`,
+	},
+	TemplateReturnRejected: {
+		Subject: "Your return request for order #{{.Order.ID}}",
+		Text: `We are sorry, your return request for order #{{.Order.ID}} was not approved.
+{{if .Return.AdminNote}}
+{{.Return.AdminNote}}
+{{end}}`,
+		HTML: `<p>We are sorry, your return request for order #{{.Order.ID}} was not approved.</p>
+{{if .Return.AdminNote}}<p>{{.Return.AdminNote}}</p>
+{{end}}`,
+	},
+	TemplateReturnReceived: {
+		Subject: "We received your return of order #{{.Order.ID}}",
+		Text: `Your returned items from order #{{.Order.ID}} arrived:
+
+{{range .Return.Items}}{{.Quantity}} x {{.ProductName}} ({{.VariantName}}, {{.SizeName}})
+{{end}}`,
+		HTML: `<p>Your returned items from order #{{.Order.ID}} arrived:</p>
+<ul>
+{{range .Return.Items}}<li>{{.Quantity}} x {{.ProductName}} ({{.VariantName}}, {{.SizeName}})</li>
+{{end}}</ul>
+`,
+	},
+}
+
+// Render renders a template for the recipient to
+func Render(tpl Template, to string, data interface{}) (Message, error) {
+	subject, err := renderText("subject", tpl.Subject, data)
+	if err != nil {
+		return Message{}, err
+	}
+	text, err := renderText("text", tpl.Text, data)
+	if err != nil {
+		return Message{}, err
+	}
+
+	parsed, err := htmltemplate.New("html").Funcs(funcs).Parse(tpl.HTML)
+	if err != nil {
+		return Message{}, fmt.Errorf("invalid html template: %w", err)
+	}
+	var html bytes.Buffer
+	if err := parsed.Execute(&html, data); err != nil {
+		return Message{}, fmt.Errorf("failed to render html template: %w", err)
+	}
+
+	return Message{To: to, Subject: strings.TrimSpace(subject), Body: text, HTML: html.String()}, nil
+}
+
+func renderText(name, source string, data interface{}) (string, error) {
+	parsed, err := texttemplate.New(name).Funcs(funcs).Parse(source)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s template: %w", name, err)
+	}
+	var b bytes.Buffer
+	if err := parsed.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %w", name, err)
+	}
+	return b.String(), nil
+}
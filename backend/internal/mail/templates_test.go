@@ -0,0 +1,97 @@
+package mail
+
+import (
+	"strings"
+	"testing"
+
+	"notsofluffy-backend/internal/models"
+)
+
+func TestRenderDefaultTemplates(t *testing.T) {
+	carrier, tracking := "InPost", "<6209>"
+	order := &models.OrderResponse{
+		ID:              42,
+		TotalAmount:     149.9,
+		ShippingCost:    20,
+		TrackingCarrier: &carrier,
+		TrackingNumber:  &tracking,
+		Items: []models.OrderItem{
+			{ProductName: "Kocyk <Fluffy>", VariantName: "Beige", SizeName: "M", Quantity: 1, TotalPrice: 129.9},
+		},
+	}
+
+	msg, err := Render(DefaultTemplates[TemplateOrderConfirmation], "jan@example.com", models.EmailTemplateData{Order: order})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.To != "jan@example.com" || msg.Subject != "Thank you for your order #42" {
+		t.Errorf("unexpected recipient or subject %q, %q", msg.To, msg.Subject)
+	}
+	if !strings.Contains(msg.Body, "1 x Kocyk <Fluffy> (Beige, M) - 129,90 zł") || !strings.Contains(msg.Body, "Total: 149,90 zł") {
+		t.Errorf("unexpected text body:\n%s", msg.Body)
+	}
+	if !strings.Contains(msg.HTML, "Kocyk &lt;Fluffy&gt;") || strings.Contains(msg.HTML, "Discount") {
+		t.Errorf("HTML body should escape data and skip a missing discount:\n%s", msg.HTML)
+	}
+
+	msg, err = Render(DefaultTemplates[TemplateOrderStatus], "jan@example.com", models.EmailTemplateData{Order: order, PreviousStatus: "processing", Status: "shipped"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Subject != "Your order #42 is now shipped" || !strings.Contains(msg.Body, "Tracking number: <6209>") {
+		t.Errorf("unexpected status email %q:\n%s", msg.Subject, msg.Body)
+	}
+	if !strings.Contains(msg.HTML, "Tracking number: &lt;6209&gt;") {
+		t.Errorf("tracking number should be escaped in HTML:\n%s", msg.HTML)
+	}
+}
+
+func TestRenderReturnTemplates(t *testing.T) {
+	order := &models.OrderResponse{ID: 42}
+	ret := &models.OrderReturn{
+		Items: []models.OrderReturnItem{{ProductName: "Kocyk <Fluffy>", VariantName: "Beige", SizeName: "M", Quantity: 2}},
+	}
+	data := models.EmailTemplateData{Order: order, Return: ret, OrderURL: "https://notsofluffy.pl/order/abc"}
+
+	msg, err := Render(DefaultTemplates[TemplateReturnLabel], "jan@example.com", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(msg.Body, "2 x Kocyk <Fluffy> (Beige, M)") || !strings.Contains(msg.Body, "return instructions") || !strings.Contains(msg.Body, "https://notsofluffy.pl/order/abc") {
+		t.Errorf("unexpected instructions label email:\n%s", msg.Body)
+	}
+
+	tracking := "<6209>"
+	ret.TrackingNumber = &tracking
+	msg, err = Render(DefaultTemplates[TemplateReturnLabel], "jan@example.com", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(msg.Body, "Tracking number: <6209>") || !strings.Contains(msg.HTML, "Tracking number: &lt;6209&gt;") {
+		t.Errorf("carrier label email should show the tracking number:\n%s\n%s", msg.Body, msg.HTML)
+	}
+
+	note := "Worn items can't be returned"
+	ret.AdminNote = &note
+	msg, err = Render(DefaultTemplates[TemplateReturnRejected], "jan@example.com", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(msg.Body, note) {
+		t.Errorf("rejection email should give the reason:\n%s", msg.Body)
+	}
+
+	if _, err := Render(DefaultTemplates[TemplateReturnReceived], "jan@example.com", data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRenderInvalidTemplate(t *testing.T) {
+	data := models.EmailTemplateData{Order: &models.OrderResponse{ID: 1}}
+	if _, err := Render(Template{Subject: "{{.Order.ID", Text: "x", HTML: "x"}, "a@example.com", data); err == nil {
+		t.Error("unparsable subject should fail")
+	}
+	if _, err := Render(Template{Subject: "x", Text: "{{.Order.Missing}}", HTML: "x"}, "a@example.com", data); err == nil {
+		t.Error("unknown field should fail")
+	}
+}
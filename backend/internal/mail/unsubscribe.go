@@ -0,0 +1,109 @@
+package mail
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"html"
+	"net/url"
+	"strings"
+)
+
+// Categories of email customers can opt out of. Emails without a category, such as
+// order confirmations and password resets, are always sent.
+const (
+	CategoryOrderUpdates = "order_updates"
+	CategoryPromotions   = "promotions"
+	CategoryBackInStock  = "back_in_stock"
+)
+
+// Categories lists the email categories
+var Categories = []string{CategoryOrderUpdates, CategoryPromotions, CategoryBackInStock}
+
+// templateCategories maps templates to the category customers opt out of them with
+var templateCategories = map[string]string{
+	TemplateOrderStatus: CategoryOrderUpdates,
+}
+
+// TemplateCategory returns the category of emails of template, or "" when they can't
+// be opted out of
+func TemplateCategory(template string) string {
+	return templateCategories[template]
+}
+
+// ValidCategory reports whether category is an email category
+func ValidCategory(category string) bool {
+	for _, c := range Categories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// UnsubscribeLinks signs links unsubscribing a recipient from one category of email
+// without logging in. Links don't expire; they only ever opt out.
+type UnsubscribeLinks struct {
+	secret  []byte
+	pageURL string
+}
+
+// NewUnsubscribeLinks creates links to the storefront page at pageURL, which confirms
+// the token with the API. The key is derived from secret so it can't be used to sign
+// anything else.
+func NewUnsubscribeLinks(secret, pageURL string) *UnsubscribeLinks {
+	return &UnsubscribeLinks{secret: []byte("unsubscribe:" + secret), pageURL: pageURL}
+}
+
+// Token returns the signed token of an email address and category
+func (u *UnsubscribeLinks) Token(email, category string) string {
+	payload := strings.ToLower(strings.TrimSpace(email)) + "\n" + category
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + u.sign(payload)
+}
+
+// Parse returns the email address and category of a token signed by Token
+func (u *UnsubscribeLinks) Parse(token string) (email, category string, ok bool) {
+	encoded, signature, found := strings.Cut(token, ".")
+	if !found {
+		return "", "", false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || !hmac.Equal([]byte(signature), []byte(u.sign(string(payload)))) {
+		return "", "", false
+	}
+	email, category, found = strings.Cut(string(payload), "\n")
+	if !found || email == "" || !ValidCategory(category) {
+		return "", "", false
+	}
+	return email, category, true
+}
+
+// URL returns the unsubscribe link of an email address and category, or "" when no
+// page is configured
+func (u *UnsubscribeLinks) URL(email, category string) string {
+	if u.pageURL == "" {
+		return ""
+	}
+	return u.pageURL + "?token=" + url.QueryEscape(u.Token(email, category))
+}
+
+// Apply adds the unsubscribe link of category to a message, in its footer and as the
+// List-Unsubscribe header
+func (u *UnsubscribeLinks) Apply(msg Message, category string) Message {
+	link := u.URL(msg.To, category)
+	if link == "" {
+		return msg
+	}
+	msg.Unsubscribe = link
+	msg.Body = strings.TrimRight(msg.Body, "\n") + "\n\n--\nUnsubscribe from these emails: " + link + "\n"
+	if msg.HTML != "" {
+		msg.HTML += `<p style="font-size:12px;color:#888"><a href="` + html.EscapeString(link) + `">Unsubscribe from these emails</a></p>` + "\n"
+	}
+	return msg
+}
+
+func (u *UnsubscribeLinks) sign(payload string) string {
+	mac := hmac.New(sha256.New, u.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,57 @@
+package mail
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUnsubscribeToken(t *testing.T) {
+	links := NewUnsubscribeLinks("secret", "https://shop.example/unsubscribe")
+	token := links.Token(" Jan@Example.com ", CategoryOrderUpdates)
+
+	email, category, ok := links.Parse(token)
+	if !ok || email != "jan@example.com" || category != CategoryOrderUpdates {
+		t.Fatalf("Parse(%q) = %q, %q, %v", token, email, category, ok)
+	}
+
+	other := NewUnsubscribeLinks("other secret", "")
+	if _, _, ok := other.Parse(token); ok {
+		t.Error("a token signed with another secret should be rejected")
+	}
+	forged := links.Token("jan@example.com", CategoryPromotions)
+	payload, _, _ := strings.Cut(forged, ".")
+	_, signature, _ := strings.Cut(token, ".")
+	if _, _, ok := links.Parse(payload + "." + signature); ok {
+		t.Error("a token with another payload should be rejected")
+	}
+	for _, bad := range []string{"", "abc", "abc.def", links.Token("jan@example.com", "newsletter")} {
+		if _, _, ok := links.Parse(bad); ok {
+			t.Errorf("Parse(%q) should fail", bad)
+		}
+	}
+}
+
+func TestUnsubscribeApply(t *testing.T) {
+	links := NewUnsubscribeLinks("secret", "https://shop.example/unsubscribe")
+	msg := links.Apply(Message{To: "jan@example.com", Body: "Your order shipped\n", HTML: "<p>Your order shipped</p>"}, CategoryOrderUpdates)
+
+	if !strings.HasPrefix(msg.Unsubscribe, "https://shop.example/unsubscribe?token=") {
+		t.Fatalf("unexpected link %q", msg.Unsubscribe)
+	}
+	if !strings.Contains(msg.Body, "\n\n--\nUnsubscribe from these emails: "+msg.Unsubscribe+"\n") {
+		t.Errorf("text body should end with the link:\n%s", msg.Body)
+	}
+	if !strings.Contains(msg.HTML, `href="`+msg.Unsubscribe+`"`) {
+		t.Errorf("HTML body should link to the page:\n%s", msg.HTML)
+	}
+	if !strings.Contains(string(buildMessage("shop@example.com", msg, time.Now())), "List-Unsubscribe: <"+msg.Unsubscribe+">\r\n") {
+		t.Error("message should carry the List-Unsubscribe header")
+	}
+
+	// Without a page there is nothing to link to
+	plain := Message{To: "jan@example.com", Body: "x"}
+	if got := NewUnsubscribeLinks("secret", "").Apply(plain, CategoryOrderUpdates); got != plain {
+		t.Errorf("message should be unchanged, got %+v", got)
+	}
+}
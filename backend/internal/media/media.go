@@ -0,0 +1,175 @@
+// Package media handles uploads for the media library: validating files by kind,
+// storing them and generating image variants.
+package media
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"notsofluffy-backend/internal/imaging"
+	"notsofluffy-backend/internal/models"
+)
+
+// Kind is a class of media with its own validation rules
+type Kind string
+
+const (
+	KindImage    Kind = "image"
+	KindDocument Kind = "document"
+)
+
+// Rule describes what is accepted for a kind and where it is stored
+type Rule struct {
+	Dir       string
+	MimeTypes []string
+	// Extension forces the stored file extension; empty keeps the uploaded one
+	Extension string
+	MaxBytes  int64
+}
+
+// Rules are the default upload rules per kind
+var Rules = map[Kind]Rule{
+	KindImage: {
+		Dir:       "images",
+		MimeTypes: []string{"image/jpeg", "image/png", "image/gif", "image/webp"},
+		MaxBytes:  10 * 1024 * 1024,
+	},
+	KindDocument: {
+		Dir:       "attachments",
+		MimeTypes: []string{"application/pdf"},
+		Extension: ".pdf",
+		MaxBytes:  20 * 1024 * 1024,
+	},
+}
+
+// ValidationError is returned when an upload is rejected; its message is safe to show
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// StoredFile is an accepted upload
+type StoredFile struct {
+	Filename     string
+	OriginalName string
+	Path         string
+	SizeBytes    int64
+	MimeType     string
+}
+
+// Service validates and stores uploads
+type Service struct {
+	storage Storage
+	rules   map[Kind]Rule
+}
+
+func NewService(storage Storage) *Service {
+	rules := make(map[Kind]Rule, len(Rules))
+	for kind, rule := range Rules {
+		rules[kind] = rule
+	}
+	return &Service{storage: storage, rules: rules}
+}
+
+// Store validates an uploaded file against the rule of kind and saves it. maxBytes
+// overrides the rule's limit when positive. Images get their variants generated.
+func (s *Service) Store(kind Kind, file multipart.File, header *multipart.FileHeader, maxBytes int64) (*StoredFile, error) {
+	rule, ok := s.rules[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown media kind %s", kind)
+	}
+	if maxBytes <= 0 {
+		maxBytes = rule.MaxBytes
+	}
+	if header.Size > maxBytes {
+		return nil, &ValidationError{Message: fmt.Sprintf("File size too large. Maximum %dMB allowed", maxBytes/(1024*1024))}
+	}
+
+	// The declared type must be allowed and match the content
+	declared := header.Header.Get("Content-Type")
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(file, head)
+	head = head[:n]
+	if !containsString(rule.MimeTypes, declared) || !contentMatches(declared, head) {
+		return nil, &ValidationError{Message: "Invalid file type. Allowed types: " + strings.Join(rule.MimeTypes, ", ")}
+	}
+
+	ext := rule.Extension
+	if ext == "" {
+		ext = strings.ToLower(filepath.Ext(header.Filename))
+	}
+	filename := newFilename() + ext
+
+	path, size, err := s.storage.Save(rule.Dir, filename, io.MultiReader(bytes.NewReader(head), file))
+	if err != nil {
+		return nil, err
+	}
+
+	return &StoredFile{
+		Filename:     filename,
+		OriginalName: header.Filename,
+		Path:         path,
+		SizeBytes:    size,
+		MimeType:     declared,
+	}, nil
+}
+
+// GenerateVariants writes the cropped variants of an image
+func (s *Service) GenerateVariants(path, mimeType string, meta imaging.Meta) error {
+	return imaging.Generate(path, mimeType, meta)
+}
+
+// GenerateSizes writes the resized WebP copies of an image
+func (s *Service) GenerateSizes(path, mimeType string) ([]models.ImageVariant, error) {
+	return imaging.GenerateSizes(path, mimeType)
+}
+
+// Remove deletes a stored file with its generated variants and sizes
+func (s *Service) Remove(path, mimeType string) {
+	s.storage.Remove(path)
+	for _, variantPath := range imaging.VariantPaths(path, mimeType) {
+		s.storage.Remove(variantPath)
+	}
+	for _, sizePath := range imaging.SizePaths(path, mimeType) {
+		s.storage.Remove(sizePath)
+	}
+}
+
+// Exists reports whether the stored file at path is still there
+func (s *Service) Exists(path string) (bool, error) {
+	return s.storage.Exists(path)
+}
+
+// contentMatches sniffs the content so a renamed file can't pass as another type
+func contentMatches(mimeType string, head []byte) bool {
+	switch mimeType {
+	case "application/pdf":
+		return bytes.HasPrefix(head, []byte("%PDF-"))
+	default:
+		return http.DetectContentType(head) == mimeType
+	}
+}
+
+func newFilename() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
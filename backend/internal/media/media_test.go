@@ -0,0 +1,96 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"path/filepath"
+	"testing"
+)
+
+type memoryFile struct {
+	*bytes.Reader
+}
+
+func (memoryFile) Close() error { return nil }
+
+type memoryStorage struct {
+	files map[string][]byte
+}
+
+func (s *memoryStorage) Save(dir, name string, r io.Reader) (string, int64, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", 0, err
+	}
+	path := filepath.Join(dir, name)
+	s.files[path] = data
+	return path, int64(len(data)), nil
+}
+
+func (s *memoryStorage) Remove(path string) error {
+	delete(s.files, path)
+	return nil
+}
+
+func (s *memoryStorage) Exists(path string) (bool, error) {
+	_, ok := s.files[path]
+	return ok, nil
+}
+
+func upload(content []byte, filename, contentType string) (multipart.File, *multipart.FileHeader) {
+	header := &multipart.FileHeader{
+		Filename: filename,
+		Header:   textproto.MIMEHeader{"Content-Type": {contentType}},
+		Size:     int64(len(content)),
+	}
+	return memoryFile{bytes.NewReader(content)}, header
+}
+
+func TestStoreDocument(t *testing.T) {
+	storage := &memoryStorage{files: map[string][]byte{}}
+	service := NewService(storage)
+
+	content := []byte("%PDF-1.4 care guide")
+	file, header := upload(content, "Care Guide.PDF", "application/pdf")
+
+	stored, err := service.Store(KindDocument, file, header, 0)
+	if err != nil {
+		t.Fatalf("Store() error: %v", err)
+	}
+	if filepath.Ext(stored.Filename) != ".pdf" || stored.OriginalName != "Care Guide.PDF" {
+		t.Errorf("unexpected stored file %+v", stored)
+	}
+	if !bytes.Equal(storage.files[stored.Path], content) {
+		t.Error("stored content differs from the upload")
+	}
+}
+
+func TestStoreRejectsInvalidUploads(t *testing.T) {
+	service := NewService(&memoryStorage{files: map[string][]byte{}})
+
+	tests := []struct {
+		name        string
+		kind        Kind
+		content     []byte
+		contentType string
+		maxBytes    int64
+	}{
+		{"pdf renamed from text", KindDocument, []byte("just text"), "application/pdf", 0},
+		{"wrong declared type", KindDocument, []byte("%PDF-1.4"), "text/plain", 0},
+		{"image with text content", KindImage, []byte("not an image at all"), "image/png", 0},
+		{"over size limit", KindDocument, []byte("%PDF-1.4 long"), "application/pdf", 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, header := upload(tt.content, "upload", tt.contentType)
+			_, err := service.Store(tt.kind, file, header, tt.maxBytes)
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Errorf("expected validation error, got %v", err)
+			}
+		})
+	}
+}
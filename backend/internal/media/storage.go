@@ -0,0 +1,67 @@
+package media
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Storage keeps uploaded media files
+type Storage interface {
+	// Save writes r as name inside dir and returns the stored path and size
+	Save(dir, name string, r io.Reader) (string, int64, error)
+	// Remove deletes a stored file; missing files are not an error
+	Remove(path string) error
+	// Exists reports whether a stored file is still there
+	Exists(path string) (bool, error)
+}
+
+// LocalStorage stores files on the local disk below root
+type LocalStorage struct {
+	root string
+}
+
+func NewLocalStorage(root string) *LocalStorage {
+	return &LocalStorage{root: root}
+}
+
+func (s *LocalStorage) Save(dir, name string, r io.Reader) (string, int64, error) {
+	fullDir := filepath.Join(s.root, dir)
+	if err := os.MkdirAll(fullDir, 0755); err != nil {
+		return "", 0, fmt.Errorf("failed to create upload directory: %w", err)
+	}
+
+	path := filepath.Join(fullDir, name)
+	out, err := os.Create(path)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create file: %w", err)
+	}
+
+	size, err := io.Copy(out, r)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return "", 0, fmt.Errorf("failed to save file: %w", err)
+	}
+	return path, size, nil
+}
+
+func (s *LocalStorage) Remove(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStorage) Exists(path string) (bool, error) {
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminSessionMiddleware enforces the admin idle timeout and records activity.
+// It must run after AdminMiddleware.
+func AdminSessionMiddleware(db *sql.DB) gin.HandlerFunc {
+	sessionQueries := database.NewAdminSessionQueries(db)
+
+	return func(c *gin.Context) {
+		session, err := sessionQueries.ValidateSession(c.GetString("admin_session_id"), c.GetInt("user_id"))
+		if err != nil {
+			if err.Error() == "admin session not found" || err.Error() == "admin session expired" {
+				c.JSON(http.StatusUnauthorized, gin.H{
+					"error": "Admin session expired, please log in again",
+					"code":  models.AdminSessionExpiredCode,
+				})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check admin session"})
+			}
+			c.Abort()
+			return
+		}
+
+		if err := sessionQueries.Touch(session.ID); err != nil {
+			log.Printf("Failed to record admin activity for session of user %d: %v", session.UserID, err)
+		}
+
+		c.Set("admin_session", session)
+		c.Next()
+	}
+}
+
+// RequireSudo allows the request only when the admin confirmed their identity
+// within the sudo window. It must run after AdminSessionMiddleware.
+func RequireSudo() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		session := GetAdminSession(c)
+		if session == nil || session.SudoUntil(time.Now()) == nil {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Please confirm your password to continue",
+				"code":  models.SudoRequiredCode,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// GetAdminSession returns the admin session loaded by AdminSessionMiddleware
+func GetAdminSession(c *gin.Context) *models.AdminSession {
+	if value, exists := c.Get("admin_session"); exists {
+		if session, ok := value.(*models.AdminSession); ok {
+			return session
+		}
+	}
+	return nil
+}
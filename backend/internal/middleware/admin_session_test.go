@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"notsofluffy-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireSudo(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	recent := time.Now().Add(-time.Minute)
+	expired := time.Now().Add(-models.SudoWindow - time.Minute)
+
+	tests := []struct {
+		name    string
+		session *models.AdminSession
+		want    int
+	}{
+		{"no session", nil, http.StatusForbidden},
+		{"no sudo", &models.AdminSession{ID: "s1", UserID: 1}, http.StatusForbidden},
+		{"expired sudo", &models.AdminSession{ID: "s1", UserID: 1, SudoAt: &expired}, http.StatusForbidden},
+		{"recent sudo", &models.AdminSession{ID: "s1", UserID: 1, SudoAt: &recent}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		r := gin.New()
+		r.POST("/api/admin/products/bulk-delete", func(c *gin.Context) {
+			if tt.session != nil {
+				c.Set("admin_session", tt.session)
+			}
+			c.Next()
+		}, RequireSudo(), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/products/bulk-delete", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != tt.want {
+			t.Errorf("%s: got status %d, want %d", tt.name, w.Code, tt.want)
+			continue
+		}
+		if tt.want == http.StatusForbidden {
+			var body map[string]string
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("%s: failed to decode response: %v", tt.name, err)
+			}
+			if body["code"] != models.SudoRequiredCode {
+				t.Errorf("%s: got code %q, want %q", tt.name, body["code"], models.SudoRequiredCode)
+			}
+		}
+	}
+}
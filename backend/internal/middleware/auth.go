@@ -1,16 +1,21 @@
 package middleware
 
 import (
+	"database/sql"
 	"net/http"
 	"strings"
 
 	"notsofluffy-backend/internal/auth"
+	"notsofluffy-backend/internal/database"
 	"notsofluffy-backend/internal/models"
 
 	"github.com/gin-gonic/gin"
 )
 
-func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
+// AuthMiddleware requires a valid access token that wasn't revoked by signing out everywhere
+func AuthMiddleware(db *sql.DB, jwtSecret string) gin.HandlerFunc {
+	tokenQueries := database.NewRefreshTokenQueries(db)
+
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -33,17 +38,35 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 			return
 		}
 
+		revoked, err := tokenQueries.AccessTokenRevoked(claims.UserID, claims.TokenVersion)
+		if err != nil {
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check session"})
+			c.Abort()
+			return
+		}
+		if revoked {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Session revoked, please log in again",
+				"code":  models.SessionRevokedCode,
+			})
+			c.Abort()
+			return
+		}
+
 		c.Set("user_id", claims.UserID)
 		c.Set("user_email", claims.Email)
 		c.Set("user_role", claims.Role)
+		c.Set("admin_session_id", claims.SessionID)
 		c.Next()
 	}
 }
 
-func AdminMiddleware(jwtSecret string) gin.HandlerFunc {
+func AdminMiddleware(db *sql.DB, jwtSecret string) gin.HandlerFunc {
+	authMiddleware := AuthMiddleware(db, jwtSecret)
+
 	return func(c *gin.Context) {
 		// First run the regular auth middleware
-		authMiddleware := AuthMiddleware(jwtSecret)
 		authMiddleware(c)
 		
 		// If auth middleware aborted, don't continue
@@ -70,9 +93,32 @@ func AdminMiddleware(jwtSecret string) gin.HandlerFunc {
 	}
 }
 
+// StaffMiddleware allows admins and editors
+func StaffMiddleware(db *sql.DB, jwtSecret string) gin.HandlerFunc {
+	authMiddleware := AuthMiddleware(db, jwtSecret)
+
+	return func(c *gin.Context) {
+		authMiddleware(c)
+		if c.IsAborted() {
+			return
+		}
+
+		role, _ := c.Get("user_role")
+		if r, ok := role.(string); !ok || !models.IsStaffRole(r) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Staff access required"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
 // OptionalAuthMiddleware extracts user info from JWT token if present, but doesn't require it
 // This allows both authenticated and guest users to access the endpoint
-func OptionalAuthMiddleware(jwtSecret string) gin.HandlerFunc {
+func OptionalAuthMiddleware(db *sql.DB, jwtSecret string) gin.HandlerFunc {
+	tokenQueries := database.NewRefreshTokenQueries(db)
+
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -95,6 +141,12 @@ func OptionalAuthMiddleware(jwtSecret string) gin.HandlerFunc {
 			return
 		}
 
+		// Revoked token - continue as guest user
+		if revoked, err := tokenQueries.AccessTokenRevoked(claims.UserID, claims.TokenVersion); err != nil || revoked {
+			c.Next()
+			return
+		}
+
 		// Valid token - set user context
 		c.Set("user_id", claims.UserID)
 		c.Set("user_email", claims.Email)
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strings"
+
+	"notsofluffy-backend/internal/captcha"
+	"notsofluffy-backend/internal/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CaptchaTokenHeader carries the token of the challenge solved by the visitor
+const CaptchaTokenHeader = "X-Captcha-Token"
+
+// Error codes of requests rejected by CaptchaMiddleware
+const (
+	CaptchaRequiredCode    = "captcha_required"
+	CaptchaFailedCode      = "captcha_failed"
+	CaptchaUnavailableCode = "captcha_unavailable"
+)
+
+// CaptchaMiddleware requires a solved challenge on endpoint when its captcha_<endpoint>
+// setting is on. Logged in customers skip the guest checkout challenge. Without a
+// provider configured the setting has no effect, so enabling it can't lock customers out.
+func CaptchaMiddleware(db *sql.DB, provider captcha.Provider, endpoint string) gin.HandlerFunc {
+	settingsQueries := database.NewSettingsQueries(db)
+
+	return func(c *gin.Context) {
+		if endpoint == captcha.EndpointGuestCheckout {
+			if _, loggedIn := c.Get("user_id"); loggedIn {
+				c.Next()
+				return
+			}
+		}
+
+		enabled, err := settingsQueries.GetBoolSetting(captcha.SettingKey(endpoint), false)
+		if err != nil {
+			log.Printf("Failed to check captcha setting for %s: %v", endpoint, err)
+		}
+		if !enabled {
+			c.Next()
+			return
+		}
+		if provider == nil {
+			log.Printf("Captcha is enabled for %s but no provider is configured", endpoint)
+			c.Next()
+			return
+		}
+
+		token := strings.TrimSpace(c.GetHeader(CaptchaTokenHeader))
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error":    "Please complete the challenge",
+				"code":     CaptchaRequiredCode,
+				"provider": provider.Name(),
+			})
+			return
+		}
+
+		result, err := provider.Verify(c.Request.Context(), token, GetClientIP(c))
+		if err != nil {
+			// Fail closed: the challenge is on because the endpoint is being abused
+			log.Printf("Failed to verify captcha for %s: %v", endpoint, err)
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+				"error": "Challenge could not be verified, please try again",
+				"code":  CaptchaUnavailableCode,
+			})
+			return
+		}
+		if !result.Success {
+			errorCodes := result.ErrorCodes
+			if errorCodes == nil {
+				errorCodes = []string{}
+			}
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error":       "Challenge verification failed, please try again",
+				"code":        CaptchaFailedCode,
+				"provider":    provider.Name(),
+				"error_codes": errorCodes,
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
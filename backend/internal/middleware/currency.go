@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CurrencyKey is the context key of the currency resolved by CurrencyMiddleware
+const CurrencyKey = "currency"
+
+// CurrencyHeader names the currency of the prices when ?currency= isn't given; the
+// response's header names the currency actually used
+const CurrencyHeader = "X-Currency"
+
+// currencyRefresh is how long the currencies are cached; rate changes take effect
+// within it
+const currencyRefresh = time.Minute
+
+// CurrencyMiddleware resolves the currency prices are shown in from ?currency= or the
+// X-Currency header and stores it as "currency". Currencies that are unknown or not
+// enabled, and every request while the currencies can't be loaded, get the base
+// currency.
+func CurrencyMiddleware(queries *database.CurrencyQueries) gin.HandlerFunc {
+	cache := &currencyCache{queries: queries}
+
+	return func(c *gin.Context) {
+		code := c.Query("currency")
+		if code == "" {
+			code = c.GetHeader(CurrencyHeader)
+		}
+		code = strings.ToUpper(strings.TrimSpace(code))
+
+		if code != "" && code != models.PaymentCurrency {
+			if currency := cache.get(code); currency != nil {
+				c.Set(CurrencyKey, currency)
+			}
+		}
+		c.Header(CurrencyHeader, GetCurrency(c).Code)
+		c.Next()
+	}
+}
+
+// GetCurrency returns the currency resolved by CurrencyMiddleware, or the base currency
+func GetCurrency(c *gin.Context) *models.Currency {
+	if currency, ok := c.Get(CurrencyKey); ok {
+		if currency, ok := currency.(*models.Currency); ok {
+			return currency
+		}
+	}
+	return &models.Currency{Code: models.PaymentCurrency, Rate: 1, Enabled: true}
+}
+
+type currencyCache struct {
+	queries *database.CurrencyQueries
+
+	mu       sync.Mutex
+	loadedAt time.Time
+	byCode   map[string]*models.Currency
+}
+
+func (cache *currencyCache) get(code string) *models.Currency {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if time.Since(cache.loadedAt) >= currencyRefresh {
+		// On errors the previous currencies are kept and loading is retried after the refresh interval
+		cache.loadedAt = time.Now()
+		if currencies, err := cache.queries.ListCurrencies(true); err != nil {
+			log.Printf("Failed to load currencies: %v", err)
+		} else {
+			cache.byCode = map[string]*models.Currency{}
+			for i := range currencies {
+				cache.byCode[currencies[i].Code] = &currencies[i]
+			}
+		}
+	}
+	return cache.byCode[code]
+}
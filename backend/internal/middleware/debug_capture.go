@@ -0,0 +1,308 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// debugCaptureMaxPayload bounds how much of each request and response body is kept
+	debugCaptureMaxPayload = 256 << 10
+	// debugCaptureRefresh is how long the list of active captures is cached, so requests
+	// outside a capture cost no database query
+	debugCaptureRefresh = 10 * time.Second
+	redactedValue       = "[REDACTED]"
+)
+
+// DebugCaptureMiddleware records the requests and responses of sessions and users with
+// an active debug capture. Register it before RequestLimits so it sees the responses the
+// client actually got. Only JSON and form payloads are stored, with the values of the
+// fields in the debug_capture_redact_fields setting replaced; other bodies are
+// summarized.
+func DebugCaptureMiddleware(queries *database.DebugCaptureQueries, settingsQueries *database.SettingsQueries) gin.HandlerFunc {
+	cache := &debugCaptureCache{queries: queries, settingsQueries: settingsQueries}
+
+	return func(c *gin.Context) {
+		captures, redactFields := cache.get()
+		if len(captures) == 0 || c.Request.Method == http.MethodOptions ||
+			strings.HasPrefix(c.Request.URL.Path, "/api/admin/debug-captures") {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		requestBody := &cappedBuffer{max: debugCaptureMaxPayload}
+		if c.Request.Body != nil {
+			c.Request.Body = &teeBody{ReadCloser: c.Request.Body, copy: requestBody}
+		}
+		writer := &captureWriter{ResponseWriter: c.Writer, body: &cappedBuffer{max: debugCaptureMaxPayload}}
+		c.Writer = writer
+
+		c.Next()
+
+		// The session and user are only known once the session and auth middleware ran
+		sessionID := c.GetString("session_id")
+		var userID *int
+		if id, ok := c.Get("user_id"); ok {
+			if id, ok := id.(int); ok {
+				userID = &id
+			}
+		}
+
+		var matched []int
+		for _, capture := range captures {
+			if (capture.SessionID != nil && *capture.SessionID == sessionID) ||
+				(capture.UserID != nil && userID != nil && *capture.UserID == *userID) {
+				matched = append(matched, capture.ID)
+			}
+		}
+		if len(matched) == 0 {
+			return
+		}
+
+		entry := models.DebugCaptureEntry{
+			Method:         c.Request.Method,
+			Path:           c.Request.URL.Path,
+			Query:          redactQuery(c.Request.URL.RawQuery, redactFields),
+			Status:         writer.Status(),
+			DurationMS:     time.Since(start).Milliseconds(),
+			ClientIP:       GetClientIP(c),
+			UserID:         userID,
+			RequestHeaders: redactHeaders(c.Request.Header, redactFields),
+			RequestBody:    redactBody(c.Request.Header.Get("Content-Type"), requestBody, redactFields),
+			ResponseBody:   redactBody(writer.Header().Get("Content-Type"), writer.body, redactFields),
+			Truncated:      requestBody.truncated || writer.body.truncated,
+		}
+		if sessionID != "" {
+			entry.SessionID = &sessionID
+		}
+
+		go func() {
+			for _, captureID := range matched {
+				entry.CaptureID = captureID
+				if err := queries.RecordDebugCaptureEntry(&entry); err != nil {
+					log.Printf("Failed to record debug capture entry: %v", err)
+				}
+			}
+		}()
+	}
+}
+
+// debugCaptureCache keeps the active captures and redaction rules for debugCaptureRefresh
+type debugCaptureCache struct {
+	queries         *database.DebugCaptureQueries
+	settingsQueries *database.SettingsQueries
+
+	mu           sync.Mutex
+	loadedAt     time.Time
+	captures     []models.DebugCapture
+	redactFields []string
+}
+
+func (cache *debugCaptureCache) get() ([]models.DebugCapture, []string) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if time.Since(cache.loadedAt) >= debugCaptureRefresh {
+		// On errors the previous state is kept and loading is retried after the refresh interval
+		cache.loadedAt = time.Now()
+		if captures, err := cache.queries.GetActiveDebugCaptures(); err != nil {
+			log.Printf("Failed to load debug captures: %v", err)
+		} else {
+			cache.captures = captures
+		}
+		fields := models.DefaultDebugCaptureRedactFields
+		if setting, err := cache.settingsQueries.GetSettingByKey(models.SettingDebugCaptureRedactFields); err == nil {
+			fields = setting.Value
+		}
+		cache.redactFields = ParseRedactFields(fields)
+	}
+
+	// Captures that expired since the last refresh stop recording right away
+	active := make([]models.DebugCapture, 0, len(cache.captures))
+	for _, capture := range cache.captures {
+		if time.Now().Before(capture.ExpiresAt) {
+			active = append(active, capture)
+		}
+	}
+	return active, cache.redactFields
+}
+
+// ParseRedactFields splits the comma separated redaction setting into lower case field names
+func ParseRedactFields(value string) []string {
+	fields := []string{}
+	for _, field := range strings.Split(value, ",") {
+		if field = strings.ToLower(strings.TrimSpace(field)); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+func isRedactedField(name string, fields []string) bool {
+	name = strings.ToLower(name)
+	for _, field := range fields {
+		if strings.Contains(name, field) {
+			return true
+		}
+	}
+	return false
+}
+
+func redactHeaders(header http.Header, fields []string) map[string]string {
+	headers := make(map[string]string, len(header))
+	for name, values := range header {
+		if isRedactedField(name, fields) {
+			headers[name] = redactedValue
+			continue
+		}
+		headers[name] = strings.Join(values, ", ")
+	}
+	return headers
+}
+
+func redactQuery(rawQuery string, fields []string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "[unparseable query omitted]"
+	}
+	return redactValues(values, fields).Encode()
+}
+
+func redactValues(values url.Values, fields []string) url.Values {
+	for name := range values {
+		if isRedactedField(name, fields) {
+			values[name] = []string{redactedValue}
+		}
+	}
+	return values
+}
+
+// redactBody returns a stored form of a payload. Bodies other than JSON and forms are
+// not stored since their content can't be redacted; neither are truncated JSON bodies.
+func redactBody(contentType string, body *cappedBuffer, fields []string) string {
+	if body.size == 0 {
+		return ""
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		if body.truncated {
+			return fmt.Sprintf("[truncated JSON body omitted, %d bytes]", body.size)
+		}
+		redacted, err := RedactJSON(body.buf.Bytes(), fields)
+		if err != nil {
+			return fmt.Sprintf("[invalid JSON body omitted, %d bytes]", body.size)
+		}
+		return string(redacted)
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(body.buf.String())
+		if err != nil {
+			return fmt.Sprintf("[invalid form body omitted, %d bytes]", body.size)
+		}
+		return redactValues(values, fields).Encode()
+	}
+
+	if mediaType == "" {
+		mediaType = "unknown"
+	}
+	return fmt.Sprintf("[%s body omitted, %d bytes]", mediaType, body.size)
+}
+
+// RedactJSON replaces the values of object fields matching fields, at any depth
+func RedactJSON(data []byte, fields []string) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return json.Marshal(redactJSONValue(value, fields))
+}
+
+func redactJSONValue(value interface{}, fields []string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, nested := range v {
+			if isRedactedField(key, fields) {
+				v[key] = redactedValue
+			} else {
+				v[key] = redactJSONValue(nested, fields)
+			}
+		}
+	case []interface{}:
+		for i, nested := range v {
+			v[i] = redactJSONValue(nested, fields)
+		}
+	}
+	return value
+}
+
+// cappedBuffer keeps the first max bytes written to it and counts the rest
+type cappedBuffer struct {
+	buf       bytes.Buffer
+	max       int
+	size      int
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	b.size += len(p)
+	if room := b.max - b.buf.Len(); room > 0 {
+		if len(p) > room {
+			b.buf.Write(p[:room])
+			b.truncated = true
+		} else {
+			b.buf.Write(p)
+		}
+	} else if len(p) > 0 {
+		b.truncated = true
+	}
+	return len(p), nil
+}
+
+// teeBody copies the request body as the handler reads it
+type teeBody struct {
+	io.ReadCloser
+	copy *cappedBuffer
+}
+
+func (b *teeBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.copy.Write(p[:n])
+	return n, err
+}
+
+// captureWriter copies the response body as it is written
+type captureWriter struct {
+	gin.ResponseWriter
+	body *cappedBuffer
+}
+
+func (w *captureWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *captureWriter) WriteString(s string) (int, error) {
+	w.body.Write([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
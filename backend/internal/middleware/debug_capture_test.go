@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"strings"
+	"testing"
+)
+
+var testRedactFields = ParseRedactFields(" Password, email ,address_line,,token")
+
+func TestParseRedactFields(t *testing.T) {
+	want := []string{"password", "email", "address_line", "token"}
+	if len(testRedactFields) != len(want) {
+		t.Fatalf("ParseRedactFields() = %v, want %v", testRedactFields, want)
+	}
+	for i := range want {
+		if testRedactFields[i] != want[i] {
+			t.Fatalf("ParseRedactFields() = %v, want %v", testRedactFields, want)
+		}
+	}
+}
+
+func TestRedactJSON(t *testing.T) {
+	body := `{"email":"jan@example.com","items":[{"size_id":3,"quantity":2}],` +
+		`"shipping_address":{"address_line1":"Polna 1","city":"Kraków"},"new_password":"x","total":12.50}`
+
+	redacted, err := RedactJSON([]byte(body), testRedactFields)
+	if err != nil {
+		t.Fatalf("RedactJSON() error = %v", err)
+	}
+
+	got := string(redacted)
+	for _, leaked := range []string{"jan@example.com", "Polna 1", `"x"`} {
+		if strings.Contains(got, leaked) {
+			t.Errorf("RedactJSON() = %s, leaks %s", got, leaked)
+		}
+	}
+	for _, kept := range []string{`"city":"Kraków"`, `"quantity":2`, `"total":12.50`} {
+		if !strings.Contains(got, kept) {
+			t.Errorf("RedactJSON() = %s, want it to keep %s", got, kept)
+		}
+	}
+}
+
+func TestRedactBody(t *testing.T) {
+	write := func(max int, data string) *cappedBuffer {
+		b := &cappedBuffer{max: max}
+		b.Write([]byte(data))
+		return b
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        *cappedBuffer
+		want        string
+	}{
+		{"empty", "application/json", write(64, ""), ""},
+		{"form", "application/x-www-form-urlencoded", write(64, "email=a%40b.pl&page=2"), "email=%5BREDACTED%5D&page=2"},
+		{"truncated JSON", "application/json; charset=utf-8", write(4, `{"a":1}`), "[truncated JSON body omitted, 7 bytes]"},
+		{"binary", "application/pdf", write(64, "%PDF-1.4"), "[application/pdf body omitted, 8 bytes]"},
+	}
+	for _, tt := range tests {
+		if got := redactBody(tt.contentType, tt.body, testRedactFields); got != tt.want {
+			t.Errorf("%s: redactBody() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"notsofluffy-backend/internal/geoip"
+
+	"github.com/gin-gonic/gin"
+)
+
+// geoIPLookupTimeout caps how long a request waits for the GeoIP provider
+const geoIPLookupTimeout = 2 * time.Second
+
+// GeoIPMiddleware detects the visitor country and stores it as "country_code".
+// A failed or slow lookup leaves the country empty and never blocks the request.
+func GeoIPMiddleware(detector *geoip.Detector) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), geoIPLookupTimeout)
+		defer cancel()
+
+		if code := detector.DetectCountry(ctx, c.Request, GetClientIP(c)); code != "" {
+			c.Set("country_code", code)
+		}
+		c.Next()
+	}
+}
+
+// GetCountryCode returns the country detected by GeoIPMiddleware, or "" when unknown
+func GetCountryCode(c *gin.Context) string {
+	return c.GetString("country_code")
+}
@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Error codes of requests rejected by RequestLimits
+const (
+	RequestTooLargeCode = "request_too_large"
+	RequestTimeoutCode  = "request_timeout"
+	ServiceTimeoutCode  = "service_timeout"
+)
+
+// RouteLimit bounds the body size and handling time of a request. Zero disables a limit.
+type RouteLimit struct {
+	MaxBodyBytes int64
+	// Timeout is the deadline of the request context; keep it below the server's
+	// WriteTimeout so the client still gets an answer
+	Timeout time.Duration
+}
+
+// RequestLimits applies defaults to every request and the limit in routes to matching
+// ones, keyed by method and route pattern, e.g. "POST /api/admin/images/upload".
+//
+// Handlers write their usual errors; the response is replaced with a structured one
+// when the error was caused by a limit: 413 for an oversized body, 408 when the client
+// was too slow sending it and 503 when the handler ran past its deadline. Database
+// queries of handlers are cut short by the statement timeout of their connection pool,
+// which is no longer than the default deadline.
+func RequestLimits(defaults RouteLimit, routes map[string]RouteLimit) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := defaults
+		if routeLimit, ok := routes[c.Request.Method+" "+c.FullPath()]; ok {
+			limit = routeLimit
+		}
+
+		if limit.MaxBodyBytes > 0 && c.Request.ContentLength > limit.MaxBodyBytes {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error": "Request body too large",
+				"code":  RequestTooLargeCode,
+			})
+			return
+		}
+
+		body := &limitedBody{ReadCloser: c.Request.Body}
+		if limit.MaxBodyBytes > 0 && c.Request.Body != nil {
+			body.ReadCloser = http.MaxBytesReader(c.Writer, c.Request.Body, limit.MaxBodyBytes)
+		}
+		if c.Request.Body != nil {
+			c.Request.Body = body
+		}
+
+		if limit.Timeout > 0 {
+			ctx, cancel := context.WithTimeout(c.Request.Context(), limit.Timeout)
+			defer cancel()
+			c.Request = c.Request.WithContext(ctx)
+		}
+
+		c.Writer = &limitsWriter{ResponseWriter: c.Writer, ctx: c.Request.Context(), body: body}
+		c.Next()
+	}
+}
+
+// limitedBody remembers why reading the request body failed
+type limitedBody struct {
+	io.ReadCloser
+	tooLarge bool
+	timedOut bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && err != io.EOF {
+		var maxBytesErr *http.MaxBytesError
+		var netErr net.Error
+		switch {
+		case errors.As(err, &maxBytesErr):
+			b.tooLarge = true
+		case errors.As(err, &netErr) && netErr.Timeout():
+			b.timedOut = true
+		}
+	}
+	return n, err
+}
+
+// limitsWriter swaps an error response caused by a limit for a structured one and
+// drops whatever the handler writes afterwards
+type limitsWriter struct {
+	gin.ResponseWriter
+	ctx      context.Context
+	body     *limitedBody
+	replaced bool
+}
+
+func (w *limitsWriter) WriteHeader(code int) {
+	if w.replaced {
+		return
+	}
+
+	var status int
+	var payload gin.H
+	switch {
+	case code >= http.StatusBadRequest && w.body.tooLarge:
+		status, payload = http.StatusRequestEntityTooLarge, gin.H{"error": "Request body too large", "code": RequestTooLargeCode}
+	case code >= http.StatusBadRequest && w.body.timedOut:
+		status, payload = http.StatusRequestTimeout, gin.H{"error": "Request body was not received in time", "code": RequestTimeoutCode}
+	case code >= http.StatusInternalServerError && errors.Is(w.ctx.Err(), context.DeadlineExceeded):
+		status, payload = http.StatusServiceUnavailable, gin.H{"error": "Request took too long, please try again", "code": ServiceTimeoutCode}
+	default:
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+
+	w.replaced = true
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Del("Content-Length")
+	w.ResponseWriter.WriteHeader(status)
+	encoded, _ := json.Marshal(payload)
+	w.ResponseWriter.Write(encoded)
+}
+
+func (w *limitsWriter) Write(data []byte) (int, error) {
+	if w.replaced {
+		return len(data), nil
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *limitsWriter) WriteString(s string) (int, error) {
+	if w.replaced {
+		return len(s), nil
+	}
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newLimitsRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestLimits(RouteLimit{MaxBodyBytes: 16, Timeout: time.Second}, map[string]RouteLimit{
+		"POST /upload": {MaxBodyBytes: 1024},
+		"GET /slow":    {Timeout: time.Millisecond},
+	}))
+	bind := func(c *gin.Context) {
+		var body map[string]interface{}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, body)
+	}
+	r.POST("/json", bind)
+	r.POST("/upload", bind)
+	r.GET("/slow", func(c *gin.Context) {
+		<-c.Request.Context().Done()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get data"})
+	})
+	return r
+}
+
+func TestRequestLimitsBodySize(t *testing.T) {
+	r := newLimitsRouter()
+	body := `{"name":"a long enough value"}`
+
+	tests := []struct {
+		path    string
+		chunked bool
+		want    int
+	}{
+		{"/json", false, http.StatusRequestEntityTooLarge},
+		{"/json", true, http.StatusRequestEntityTooLarge},
+		{"/upload", false, http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(body))
+		if tt.chunked {
+			req.ContentLength = -1
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != tt.want {
+			t.Fatalf("%s (chunked %v): expected %d, got %d: %s", tt.path, tt.chunked, tt.want, w.Code, w.Body.String())
+		}
+		if tt.want != http.StatusOK && !strings.Contains(w.Body.String(), RequestTooLargeCode) {
+			t.Fatalf("expected structured error, got %s", w.Body.String())
+		}
+	}
+}
+
+func TestRequestLimitsTimeout(t *testing.T) {
+	w := httptest.NewRecorder()
+	newLimitsRouter().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), ServiceTimeoutCode) || strings.Contains(w.Body.String(), "Failed to get data") {
+		t.Fatalf("expected only the timeout error, got %s", w.Body.String())
+	}
+}
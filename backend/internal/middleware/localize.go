@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"notsofluffy-backend/internal/geoip"
+	"notsofluffy-backend/internal/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LanguageKey is the context key of the language negotiated by Localize
+const LanguageKey = "language"
+
+// Localize picks the language of the response from ?lang= or Accept-Language, falling
+// back to English, and translates the "error" message of JSON error responses to it.
+// Handlers keep writing English messages. Binding errors are rewritten into readable
+// field messages in every language. Error responses also get the request's ID from
+// RequestID, registered before it.
+func Localize() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		language := geoip.NegotiateLanguage(c.Query("lang"), c.GetHeader("Accept-Language"), "")
+		c.Set(LanguageKey, language)
+		c.Header("Content-Language", language)
+
+		writer := &localizeWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		writer.finish(language, GetRequestID(c))
+	}
+}
+
+// GetLanguage returns the language negotiated by Localize, or English
+func GetLanguage(c *gin.Context) string {
+	if language := c.GetString(LanguageKey); language != "" {
+		return language
+	}
+	return i18n.English
+}
+
+// localizeWriter holds back the body of JSON error responses so their message can be
+// translated once the handler is done
+type localizeWriter struct {
+	gin.ResponseWriter
+	body     bytes.Buffer
+	buffered bool
+}
+
+func (w *localizeWriter) holdBack() bool {
+	if w.buffered {
+		return true
+	}
+	w.buffered = w.Status() >= http.StatusBadRequest &&
+		strings.HasPrefix(w.Header().Get("Content-Type"), "application/json")
+	return w.buffered
+}
+
+func (w *localizeWriter) Write(data []byte) (int, error) {
+	if w.holdBack() {
+		return w.body.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *localizeWriter) WriteString(s string) (int, error) {
+	if w.holdBack() {
+		return w.body.WriteString(s)
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+// finish writes the held back body with its message translated and the request ID
+// added. Bodies that aren't a JSON object are written unchanged.
+func (w *localizeWriter) finish(language, requestID string) {
+	if !w.buffered {
+		return
+	}
+
+	body := w.body.Bytes()
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var payload map[string]interface{}
+	if err := decoder.Decode(&payload); err == nil {
+		if message, ok := payload["error"].(string); ok {
+			payload["error"] = i18n.Translate(language, message)
+			if requestID != "" {
+				payload[RequestIDKey] = requestID
+			}
+			if encoded, err := json.Marshal(payload); err == nil {
+				body = encoded
+			}
+		}
+	}
+	w.ResponseWriter.Write(body)
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLocalizeTranslatesErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Localize())
+	r.GET("/missing", func(c *gin.Context) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found", "order_id": 12})
+	})
+	r.GET("/ok", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"message": "Order not found"})
+	})
+
+	request := func(path, acceptLanguage string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept-Language", acceptLanguage)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request("/missing", "pl-PL,pl;q=0.9,en;q=0.8")
+	if w.Code != http.StatusNotFound || w.Header().Get("Content-Language") != "pl" {
+		t.Fatalf("status %d, Content-Language %q", w.Code, w.Header().Get("Content-Language"))
+	}
+	if body := w.Body.String(); !strings.Contains(body, "Nie znaleziono zamówienia") || !strings.Contains(body, `"order_id":12`) {
+		t.Errorf("expected the Polish message with the other fields kept, got %s", body)
+	}
+
+	if body := request("/missing", "de-DE").Body.String(); !strings.Contains(body, "Order not found") {
+		t.Errorf("unsupported languages should get English, got %s", body)
+	}
+	if body := request("/ok", "pl").Body.String(); !strings.Contains(body, "Order not found") {
+		t.Errorf("successful responses should be left alone, got %s", body)
+	}
+}
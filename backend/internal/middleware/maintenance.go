@@ -2,11 +2,13 @@ package middleware
 
 import (
 	"database/sql"
+	"log"
 	"net/http"
 	"strings"
 
 	"notsofluffy-backend/internal/auth"
 	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/models"
 
 	"github.com/gin-gonic/gin"
 )
@@ -20,10 +22,15 @@ func MaintenanceMiddleware(db *sql.DB, jwtSecret string) gin.HandlerFunc {
 		// Skip maintenance check for certain paths
 		path := c.Request.URL.Path
 		
-		// Always allow access to admin routes, auth routes, maintenance status, and static files
+		// Always allow access to admin routes, auth routes, maintenance status, version, the status page, the storefront context and shop profile, payment callbacks and static files
 		if strings.HasPrefix(path, "/api/admin") ||
 			strings.HasPrefix(path, "/api/auth") ||
 			strings.HasPrefix(path, "/api/maintenance-status") ||
+			path == "/api/version" ||
+			path == "/api/status" ||
+			path == "/api/context" ||
+			path == "/api/shop" ||
+			strings.HasPrefix(path, "/api/payments/") ||
 			strings.HasPrefix(path, "/uploads") ||
 			path == "/api/maintenance-status" {
 			c.Next()
@@ -76,4 +83,29 @@ func MaintenanceMiddleware(db *sql.DB, jwtSecret string) gin.HandlerFunc {
 		})
 		c.Abort()
 	}
-}
\ No newline at end of file
+}
+
+// CheckoutMiddleware refuses new orders and payments while the checkout_disabled setting
+// is on, so stock can be counted without hiding the shop. Payment provider callbacks for
+// earlier payments are not routed through it.
+func CheckoutMiddleware(db *sql.DB) gin.HandlerFunc {
+	settingsQueries := database.NewSettingsQueries(db)
+
+	return func(c *gin.Context) {
+		// Like maintenance mode, checkout stays open when the setting can't be read
+		status, err := settingsQueries.GetCheckoutStatus()
+		if err != nil {
+			log.Printf("Failed to check checkout status: %v", err)
+		}
+		if !status.Disabled {
+			c.Next()
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+			"error":             status.Message,
+			"code":              models.CheckoutDisabledCode,
+			"checkout_disabled": true,
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"database/sql"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/models"
+	"notsofluffy-backend/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PartnerAPIKey authenticates partner API requests by the key in the X-API-Key header,
+// requires scope and enforces the key's rate limit. Every request is counted in the
+// key's daily usage; the rate limit headers tell the client where it stands.
+func PartnerAPIKey(db *sql.DB, limiter *ratelimit.Limiter, scope string) gin.HandlerFunc {
+	keyQueries := database.NewAPIKeyQueries(db)
+
+	return func(c *gin.Context) {
+		presented := c.GetHeader("X-API-Key")
+		if presented == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "API key required", "code": models.APIKeyInvalidCode})
+			return
+		}
+
+		key, err := keyQueries.GetActiveAPIKey(presented)
+		if err != nil {
+			if err.Error() == "api key not found" {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key", "code": models.APIKeyInvalidCode})
+			} else {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check API key"})
+			}
+			return
+		}
+
+		if !key.HasScope(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API key is not allowed to access this resource", "code": models.APIKeyScopeCode})
+			return
+		}
+
+		result := limiter.Allow(strconv.Itoa(key.ID), key.RateLimitPerMinute)
+		c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.FormatInt(result.Reset.Unix(), 10))
+
+		if err := keyQueries.RecordUsage(key.ID, !result.Allowed); err != nil {
+			log.Printf("Failed to record usage of API key %d: %v", key.ID, err)
+		}
+
+		if !result.Allowed {
+			retryAfter := int(math.Ceil(time.Until(result.Reset).Seconds()))
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Rate limit exceeded", "code": models.APIRateLimitedCode})
+			return
+		}
+
+		c.Set("api_key", key)
+		c.Next()
+	}
+}
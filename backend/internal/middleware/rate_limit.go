@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"notsofluffy-backend/internal/models"
+	"notsofluffy-backend/internal/ratelimit"
+)
+
+// RateLimit limits the requests of each client IP to the routes of group with a token
+// bucket rule; a disabled rule lets everything through. Responses carry the rate limit
+// headers and requests over the limit get 429 with Retry-After. When the store fails
+// the request is let through, so an unreachable Redis doesn't block checkout.
+func RateLimit(store ratelimit.Store, group string, rule ratelimit.Rule) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !rule.Enabled() {
+			c.Next()
+			return
+		}
+
+		decision, err := store.Take(c.Request.Context(), group+":"+GetClientIP(c), rule)
+		if err != nil {
+			log.Printf("Rate limit of %s not checked: %v", group, err)
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(decision.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+		if !decision.Allowed {
+			retryAfter := int(math.Ceil(decision.RetryAfter.Seconds()))
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests, try again later", "code": models.APIRateLimitedCode})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"notsofluffy-backend/internal/ratelimit"
+)
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	store := ratelimit.NewMemoryStore()
+	r.POST("/login", RateLimit(store, "auth", ratelimit.Rule{Requests: 1, Period: time.Minute, Burst: 2}), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	r.POST("/open", RateLimit(store, "open", ratelimit.Rule{}), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	post := func(path, ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := post("/login", "10.0.0.1"); w.Code != http.StatusNoContent {
+			t.Fatalf("request %d: expected 204, got %d", i+1, w.Code)
+		}
+	}
+	w := post("/login", "10.0.0.1")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 over the limit, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") != "60" || w.Header().Get("X-RateLimit-Remaining") != "0" {
+		t.Errorf("unexpected headers %v", w.Header())
+	}
+
+	if w := post("/login", "10.0.0.2"); w.Code != http.StatusNoContent {
+		t.Errorf("other clients should not be limited, got %d", w.Code)
+	}
+	for i := 0; i < 5; i++ {
+		if w := post("/open", "10.0.0.1"); w.Code != http.StatusNoContent {
+			t.Fatalf("a disabled rule should not limit, got %d", w.Code)
+		}
+	}
+}
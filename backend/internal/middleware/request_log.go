@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// RequestIDHeader carries the ID of a request, from the client or proxy when it sets
+	// one and generated otherwise, and is echoed on every response
+	RequestIDHeader = "X-Request-ID"
+	// RequestIDKey is the context key of the request ID
+	RequestIDKey = "request_id"
+)
+
+type requestIDContextKey struct{}
+
+// validRequestID bounds the IDs taken from clients, so they can't inject into logs
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{8,64}$`)
+
+// RequestID gives every request an ID, shown on error responses so customers can quote
+// it to support and logged with everything the request does
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = newRequestID()
+		}
+		c.Set(RequestIDKey, id)
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), requestIDContextKey{}, id))
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return time.Now().UTC().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(b)
+}
+
+// GetRequestID returns the ID RequestID gave the request
+func GetRequestID(c *gin.Context) string {
+	return c.GetString(RequestIDKey)
+}
+
+// RequestIDFromContext returns the request ID of a request's context, for code that
+// only gets the context
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey{}).(string)
+	return id
+}
+
+// Logger returns the logger of a request, adding its ID to every entry
+func Logger(ctx context.Context) *slog.Logger {
+	if id := RequestIDFromContext(ctx); id != "" {
+		return slog.Default().With(RequestIDKey, id)
+	}
+	return slog.Default()
+}
+
+// RequestLogger logs every request as one structured entry with its ID, the client's
+// real IP and the errors handlers attached with c.Error, such as failed database
+// queries behind a 500
+func RequestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		clientIP := c.GetString("real_ip")
+		if clientIP == "" {
+			clientIP = c.ClientIP()
+		}
+		attrs := []slog.Attr{
+			slog.String(RequestIDKey, GetRequestID(c)),
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("client_ip", clientIP),
+			slog.Int("bytes", c.Writer.Size()),
+		}
+		if userID, ok := c.Get("user_id"); ok {
+			attrs = append(attrs, slog.Any("user_id", userID))
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.Any("errors", c.Errors.Errors()))
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+		slog.LogAttrs(c.Request.Context(), level, "request", attrs...)
+	}
+}
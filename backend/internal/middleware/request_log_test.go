@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestIDOnErrorResponses(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestID(), Localize())
+	r.GET("/fail", func(c *gin.Context) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get orders"})
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fail", nil))
+	id := w.Header().Get(RequestIDHeader)
+	if len(id) != 32 {
+		t.Fatalf("expected a generated request ID, got %q", id)
+	}
+	var body map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body[RequestIDKey] != id {
+		t.Fatalf("expected the request ID in the error body, got %s", w.Body.String())
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/fail", nil)
+	req.Header.Set(RequestIDHeader, "lb-1234abcd")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Header().Get(RequestIDHeader) != "lb-1234abcd" {
+		t.Fatalf("expected the proxy's request ID to be kept, got %q", w.Header().Get(RequestIDHeader))
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/fail", nil)
+	req.Header.Set(RequestIDHeader, "bad id\ninjected")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if id := w.Header().Get(RequestIDHeader); strings.Contains(id, "injected") || len(id) != 32 {
+		t.Fatalf("expected an invalid request ID to be replaced, got %q", id)
+	}
+}
+
+func TestRequestLoggerLogsErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var out bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&out, nil)))
+	defer slog.SetDefault(previous)
+
+	r := gin.New()
+	r.Use(RequestID(), RequestLogger())
+	r.GET("/fail", func(c *gin.Context) {
+		c.Error(errors.New("failed to get orders: connection refused"))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get orders"})
+	})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fail", nil))
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+		t.Fatalf("expected one JSON entry, got %s", out.String())
+	}
+	if entry["level"] != "ERROR" || entry[RequestIDKey] != w.Header().Get(RequestIDHeader) || entry["status"] != float64(500) {
+		t.Fatalf("unexpected entry %v", entry)
+	}
+	if errs, _ := entry["errors"].([]interface{}); len(errs) != 1 || !strings.Contains(errs[0].(string), "connection refused") {
+		t.Fatalf("expected the handler's error, got %v", entry["errors"])
+	}
+}
@@ -1,7 +1,6 @@
 package middleware
 
 import (
-	"fmt"
 	"net/http"
 	"strings"
 	"time"
@@ -94,7 +93,8 @@ func CORSWithProxy(allowedOrigins []string) gin.HandlerFunc {
 		if allowed {
 			c.Header("Access-Control-Allow-Origin", origin)
 			c.Header("Access-Control-Allow-Credentials", "true")
-			c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Authorization, X-Requested-With")
+			c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Authorization, X-Requested-With, X-Captcha-Token, X-Test-Order, X-Request-ID, X-Currency")
+			c.Header("Access-Control-Expose-Headers", "X-Request-ID, X-Currency")
 			c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 			c.Header("Access-Control-Max-Age", "86400") // 24 hours
 		}
@@ -109,30 +109,6 @@ func CORSWithProxy(allowedOrigins []string) gin.HandlerFunc {
 	}
 }
 
-// RequestLogger middleware logs requests with real IP addresses
-func RequestLogger() gin.HandlerFunc {
-	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
-		// Get real IP if available
-		realIP := param.ClientIP
-		if ip, exists := param.Keys["real_ip"]; exists {
-			if ipStr, ok := ip.(string); ok {
-				realIP = ipStr
-			}
-		}
-
-		// Format log entry
-		return fmt.Sprintf("[GIN] %v | %3d | %13v | %15s | %-7s %s %s",
-			param.TimeStamp.Format("2006/01/02 - 15:04:05"),
-			param.StatusCode,
-			param.Latency,
-			realIP,
-			param.Method,
-			param.Path,
-			param.ErrorMessage,
-		)
-	})
-}
-
 // isSecureRequest checks if the request is HTTPS (considering proxy headers)
 func isSecureRequest(c *gin.Context) bool {
 	// Check X-Forwarded-Proto header (set by Nginx)
@@ -177,18 +153,6 @@ func buildCSP(isHTTPS bool) string {
 	return csp
 }
 
-// RateLimitByIP creates a simple in-memory rate limiter by IP
-func RateLimitByIP() gin.HandlerFunc {
-	// This is a simple implementation
-	// For production, consider using Redis or a proper rate limiting library
-	
-	return func(c *gin.Context) {
-		// Simple rate limiting logic would go here
-		// For now, just continue - implement proper rate limiting as needed
-		c.Next()
-	}
-}
-
 // APIKeyAuth middleware for API authentication (optional)
 func APIKeyAuth(validAPIKeys []string) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -238,4 +202,13 @@ func HealthCheck(endpoint string) gin.HandlerFunc {
 		}
 		c.Next()
 	}
-}
\ No newline at end of file
+}
+// GetClientIP returns the client IP set by TrustedProxyHeaders, falling back to Gin's ClientIP
+func GetClientIP(c *gin.Context) string {
+	if ip, exists := c.Get("real_ip"); exists {
+		if ipStr, ok := ip.(string); ok && ipStr != "" {
+			return ipStr
+		}
+	}
+	return c.ClientIP()
+}
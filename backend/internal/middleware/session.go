@@ -51,6 +51,7 @@ func SessionMiddleware() gin.HandlerFunc {
 
 		// Save session
 		if err := session.Save(c.Request, c.Writer); err != nil {
+			c.Error(err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save session"})
 			c.Abort()
 			return
@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"notsofluffy-backend/internal/database"
+	"notsofluffy-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ShopKey is the context key of the shop resolved by ShopMiddleware
+const ShopKey = "shop"
+
+// shopRefresh is how long the shops are cached, so resolving one costs no database
+// query; changes made in the admin take effect within it
+const shopRefresh = 30 * time.Second
+
+// ShopMiddleware resolves the shop (brand) of the request from its host, as forwarded by
+// the proxy, and stores it as "shop". Hosts no shop claims, and every request while
+// the shops can't be loaded, get the default shop. Register it after TrustedProxyHeaders.
+func ShopMiddleware(queries *database.ShopQueries) gin.HandlerFunc {
+	cache := &shopCache{queries: queries}
+
+	return func(c *gin.Context) {
+		host := c.GetString("original_host")
+		if host == "" {
+			host = c.Request.Host
+		}
+		if shop := cache.get(database.NormalizeHost(host)); shop != nil {
+			c.Set(ShopKey, shop)
+		}
+		c.Next()
+	}
+}
+
+// GetShop returns the shop resolved by ShopMiddleware, or nil when it couldn't be
+func GetShop(c *gin.Context) *models.Shop {
+	if shop, ok := c.Get(ShopKey); ok {
+		if shop, ok := shop.(*models.Shop); ok {
+			return shop
+		}
+	}
+	return nil
+}
+
+// GetShopID returns the ID of the shop of the request, the default shop when unknown
+func GetShopID(c *gin.Context) int {
+	if shop := GetShop(c); shop != nil {
+		return shop.ID
+	}
+	return models.DefaultShopID
+}
+
+type shopCache struct {
+	queries *database.ShopQueries
+
+	mu       sync.Mutex
+	loadedAt time.Time
+	byDomain map[string]*models.Shop
+	fallback *models.Shop
+}
+
+func (cache *shopCache) get(host string) *models.Shop {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if time.Since(cache.loadedAt) >= shopRefresh {
+		// On errors the previous shops are kept and loading is retried after the refresh interval
+		cache.loadedAt = time.Now()
+		if shops, err := cache.queries.ListShops(); err != nil {
+			log.Printf("Failed to load shops: %v", err)
+		} else {
+			cache.byDomain, cache.fallback = indexShops(shops)
+		}
+	}
+
+	if shop, ok := cache.byDomain[host]; ok {
+		return shop
+	}
+	return cache.fallback
+}
+
+// indexShops maps the domains of the shops to them and picks the default shop
+func indexShops(shops []models.Shop) (map[string]*models.Shop, *models.Shop) {
+	byDomain := map[string]*models.Shop{}
+	var fallback *models.Shop
+	for i := range shops {
+		shop := &shops[i]
+		for _, domain := range shop.Domains {
+			byDomain[domain] = shop
+		}
+		if shop.IsDefault {
+			fallback = shop
+		}
+	}
+	return byDomain, fallback
+}
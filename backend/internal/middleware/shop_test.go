@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"testing"
+	"time"
+
+	"notsofluffy-backend/internal/models"
+)
+
+func TestShopCacheResolvesHosts(t *testing.T) {
+	shops := []models.Shop{
+		{ID: models.DefaultShopID, Slug: "notsofluffy", Domains: []string{"notsofluffy.pl"}, IsDefault: true},
+		{ID: 2, Slug: "second", Domains: []string{"second.example.com", "www.second.example.com"}},
+	}
+	cache := &shopCache{loadedAt: time.Now()}
+	cache.byDomain, cache.fallback = indexShops(shops)
+
+	for host, want := range map[string]string{
+		"notsofluffy.pl":         "notsofluffy",
+		"www.second.example.com": "second",
+		"localhost":              "notsofluffy",
+	} {
+		if shop := cache.get(host); shop == nil || shop.Slug != want {
+			t.Fatalf("host %q should resolve to %q, got %+v", host, want, shop)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestOrderHeader is the request header that places a test order outside production
+const TestOrderHeader = "X-Test-Order"
+
+// TestOrderMiddleware marks the request as placing a test order when it carries
+// X-Test-Order: true. It does nothing unless enabled, so production ignores the header.
+func TestOrderMiddleware(enabled bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if enabled {
+			if isTest, err := strconv.ParseBool(c.GetHeader(TestOrderHeader)); err == nil && isTest {
+				c.Set("test_order", true)
+			}
+		}
+		c.Next()
+	}
+}
+
+// IsTestOrder reports whether TestOrderMiddleware marked the request as a test order
+func IsTestOrder(c *gin.Context) bool {
+	return c.GetBool("test_order")
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTestOrderMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		enabled bool
+		header  string
+		want    bool
+	}{
+		{true, "true", true},
+		{true, "1", true},
+		{true, "false", false},
+		{true, "", false},
+		{false, "true", false},
+	}
+	for _, tt := range tests {
+		r := gin.New()
+		r.POST("/orders", TestOrderMiddleware(tt.enabled), func(c *gin.Context) {
+			c.String(http.StatusOK, strconv.FormatBool(IsTestOrder(c)))
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/orders", nil)
+		if tt.header != "" {
+			req.Header.Set(TestOrderHeader, tt.header)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if got := w.Body.String(); got != strconv.FormatBool(tt.want) {
+			t.Errorf("enabled=%v header=%q: got test order %s, want %v", tt.enabled, tt.header, got, tt.want)
+		}
+	}
+}
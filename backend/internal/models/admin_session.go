@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+)
+
+// Admin session constants
+const (
+	SettingAdminIdleTimeoutMinutes = "admin_idle_timeout_minutes"
+	DefaultAdminIdleTimeoutMinutes = 30
+
+	// SudoWindow is how long a password or TOTP confirmation unlocks destructive actions
+	SudoWindow = 10 * time.Minute
+
+	AdminSessionExpiredCode = "admin_session_expired"
+	SudoRequiredCode        = "sudo_required"
+)
+
+// AdminSession is the server-side state behind an admin login
+type AdminSession struct {
+	ID         string     `json:"id"`
+	UserID     int        `json:"user_id"`
+	IPAddress  string     `json:"ip_address"`
+	UserAgent  *string    `json:"user_agent,omitempty"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	SudoAt     *time.Time `json:"sudo_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// IsIdle reports whether the session saw no admin activity within the timeout
+func (s *AdminSession) IsIdle(now time.Time, timeout time.Duration) bool {
+	return timeout > 0 && now.Sub(s.LastSeenAt) > timeout
+}
+
+// SudoUntil returns when the current sudo mode ends, or nil when it is not active
+func (s *AdminSession) SudoUntil(now time.Time) *time.Time {
+	if s.SudoAt == nil {
+		return nil
+	}
+	until := s.SudoAt.Add(SudoWindow)
+	if !until.After(now) {
+		return nil
+	}
+	return &until
+}
+
+// SudoRequest confirms the admin identity with a password or a TOTP code
+type SudoRequest struct {
+	Password string `json:"password,omitempty"`
+	TOTPCode string `json:"totp_code,omitempty"`
+}
+
+// AdminSessionResponse describes the current admin session
+type AdminSessionResponse struct {
+	IdleTimeoutMinutes int        `json:"idle_timeout_minutes"`
+	LastSeenAt         time.Time  `json:"last_seen_at"`
+	SudoUntil          *time.Time `json:"sudo_until,omitempty"`
+	TOTPEnabled        bool       `json:"totp_enabled"`
+}
+
+// TOTPSetupResponse contains the secret to add to an authenticator app
+type TOTPSetupResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"`
+}
+
+// TOTPCodeRequest confirms TOTP enrollment with a code from the authenticator app
+type TOTPCodeRequest struct {
+	Code string `json:"code" binding:"required,len=6"`
+}
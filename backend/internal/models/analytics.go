@@ -0,0 +1,77 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Analytics event types, in funnel order
+const (
+	EventProductView   = "product_view"
+	EventAddToCart     = "add_to_cart"
+	EventBeginCheckout = "begin_checkout"
+	EventPurchase      = "purchase"
+)
+
+// FunnelSteps lists the event types that make up the storefront funnel
+var FunnelSteps = []string{EventProductView, EventAddToCart, EventBeginCheckout, EventPurchase}
+
+// MaxEventBatchSize caps how many events a single POST /api/events may carry
+const MaxEventBatchSize = 50
+
+// SettingRetentionAnalyticsEventsDays is the retention period of raw analytics events
+const SettingRetentionAnalyticsEventsDays = "retention_analytics_events_days"
+
+// AnalyticsEventRequest is a single event sent by the storefront
+type AnalyticsEventRequest struct {
+	Type        string          `json:"type" binding:"required"`
+	ProductID   *int            `json:"product_id,omitempty"`
+	OrderID     *int            `json:"order_id,omitempty"`
+	Value       *float64        `json:"value,omitempty"`
+	Path        *string         `json:"path,omitempty"`
+	Referrer    *string         `json:"referrer,omitempty"`
+	UTMSource   *string         `json:"utm_source,omitempty"`
+	UTMMedium   *string         `json:"utm_medium,omitempty"`
+	UTMCampaign *string         `json:"utm_campaign,omitempty"`
+	Properties  json.RawMessage `json:"properties,omitempty"`
+	OccurredAt  *time.Time      `json:"occurred_at,omitempty"`
+}
+
+// AnalyticsEventBatchRequest is a batch of events; the storefront should buffer and send them together
+type AnalyticsEventBatchRequest struct {
+	Events []AnalyticsEventRequest `json:"events" binding:"required"`
+}
+
+// AnalyticsEvent is a stored event
+type AnalyticsEvent struct {
+	AnalyticsEventRequest
+	SessionID string  `json:"session_id"`
+	UserID    *int    `json:"user_id,omitempty"`
+	Country   *string `json:"country,omitempty"`
+}
+
+// FunnelStep is one step of the funnel report
+type FunnelStep struct {
+	Event    string `json:"event"`
+	Sessions int    `json:"sessions"`
+	// StepRate is the share of sessions that reached this step from the previous one
+	StepRate float64 `json:"step_rate"`
+	// OverallRate is the share of sessions that reached this step from the first one
+	OverallRate float64 `json:"overall_rate"`
+}
+
+// FunnelSource is purchase attribution for one UTM source
+type FunnelSource struct {
+	Source    string  `json:"source"`
+	Sessions  int     `json:"sessions"`
+	Purchases int     `json:"purchases"`
+	Revenue   float64 `json:"revenue"`
+}
+
+// FunnelReport is the admin funnel report for a date range
+type FunnelReport struct {
+	From    time.Time      `json:"from"`
+	To      time.Time      `json:"to"`
+	Steps   []FunnelStep   `json:"steps"`
+	Sources []FunnelSource `json:"sources"`
+}
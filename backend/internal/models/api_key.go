@@ -0,0 +1,95 @@
+package models
+
+import "time"
+
+// API key scopes
+const (
+	APIScopeCatalogRead = "catalog:read"
+)
+
+// APIScopes lists the scopes a key can be issued with
+var APIScopes = []string{APIScopeCatalogRead}
+
+// API key tiers with their default rate limits in requests per minute
+const (
+	APIKeyTierBasic   = "basic"
+	APIKeyTierPartner = "partner"
+	APIKeyTierPremium = "premium"
+)
+
+// APIKeyTierLimits are the rate limits of the tiers
+var APIKeyTierLimits = map[string]int{
+	APIKeyTierBasic:   60,
+	APIKeyTierPartner: 300,
+	APIKeyTierPremium: 1200,
+}
+
+// APIKeyPrefix starts every issued key, so leaked keys are easy to recognise
+const APIKeyPrefix = "nsf_"
+
+// SettingRetentionAPIKeyUsageDays limits how long daily key usage is kept
+const SettingRetentionAPIKeyUsageDays = "retention_api_key_usage_days"
+
+// APIKeyInvalidCode, APIKeyScopeCode and APIRateLimitedCode mark rejected partner API requests
+const (
+	APIKeyInvalidCode  = "api_key_invalid"
+	APIKeyScopeCode    = "api_key_scope"
+	APIRateLimitedCode = "rate_limited"
+)
+
+// APIKey is a partner API key; the key itself is only shown once, when it is issued
+type APIKey struct {
+	ID                 int        `json:"id"`
+	Name               string     `json:"name"`
+	KeyPrefix          string     `json:"key_prefix"`
+	Tier               string     `json:"tier"`
+	Scopes             []string   `json:"scopes"`
+	RateLimitPerMinute int        `json:"rate_limit_per_minute"`
+	LastUsedAt         *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt          *time.Time `json:"revoked_at,omitempty"`
+	CreatedBy          *int       `json:"created_by,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+	// RequestsToday and RequestsTotal are filled in for the admin list
+	RequestsToday int64 `json:"requests_today"`
+	RequestsTotal int64 `json:"requests_total"`
+}
+
+// HasScope reports whether the key was issued with scope
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKeyRequest issues a key. RateLimitPerMinute overrides the tier's limit.
+type APIKeyRequest struct {
+	Name               string   `json:"name" binding:"required,max=100"`
+	Tier               string   `json:"tier" binding:"required,oneof=basic partner premium"`
+	Scopes             []string `json:"scopes" binding:"required,min=1,dive,oneof=catalog:read"`
+	RateLimitPerMinute *int     `json:"rate_limit_per_minute" binding:"omitempty,min=1,max=100000"`
+}
+
+// APIKeyCreatedResponse returns a new key with its secret, which is not stored
+type APIKeyCreatedResponse struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// APIKeyUsageDay is the usage of a key on one day
+type APIKeyUsageDay struct {
+	Day      string `json:"day"`
+	Requests int64  `json:"requests"`
+	Rejected int64  `json:"rejected"`
+}
+
+// APIKeyUsageResponse is the daily usage of a key, oldest day first
+type APIKeyUsageResponse struct {
+	APIKeyID int              `json:"api_key_id"`
+	Days     int              `json:"days"`
+	Requests int64            `json:"requests"`
+	Rejected int64            `json:"rejected"`
+	Usage    []APIKeyUsageDay `json:"usage"`
+}
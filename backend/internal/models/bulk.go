@@ -0,0 +1,29 @@
+package models
+
+// BulkRequest selects the records of a bulk admin operation
+type BulkRequest struct {
+	IDs []int `json:"ids" binding:"required,min=1,max=500"`
+}
+
+// BulkOrderStatusRequest sets the status of many orders at once
+type BulkOrderStatusRequest struct {
+	IDs    []int  `json:"ids" binding:"required,min=1,max=500"`
+	Status string `json:"status" binding:"required"`
+	// NotifyCustomer emails the customers about the change unless set to false
+	NotifyCustomer *bool `json:"notify_customer,omitempty"`
+}
+
+// BulkItemResult is the outcome of a bulk operation for one record
+type BulkItemResult struct {
+	ID      int    `json:"id"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// BulkResponse reports a bulk operation. Records are changed independently: the ones
+// that succeeded are saved even when others failed.
+type BulkResponse struct {
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Results   []BulkItemResult `json:"results"`
+}
@@ -0,0 +1,9 @@
+package models
+
+// CaptchaConfigResponse tells the storefront which challenge widget to load and which
+// endpoints need a solved challenge in the X-Captcha-Token header
+type CaptchaConfigResponse struct {
+	Provider  *string  `json:"provider"`
+	SiteKey   *string  `json:"site_key"`
+	Endpoints []string `json:"endpoints"`
+}
@@ -35,6 +35,14 @@ type CartItemService struct {
 	AdditionalServiceID int `json:"additional_service_id"`
 }
 
+// CartItemServiceSnapshot is an additional service as it was when it was added to a cart item
+type CartItemServiceSnapshot struct {
+	ServiceID   int
+	Name        string
+	Description string
+	Price       float64
+}
+
 // CartItemRequest represents the request to add an item to cart
 type CartItemRequest struct {
 	ProductID            int   `json:"product_id" binding:"required"`
@@ -42,6 +50,8 @@ type CartItemRequest struct {
 	SizeID               int   `json:"size_id" binding:"required"`
 	Quantity             int   `json:"quantity" binding:"required,min=1"`
 	AdditionalServiceIDs []int `json:"additional_service_ids"`
+	// OptionValueIDs holds one value per option group of the product
+	OptionValueIDs       []int `json:"option_value_ids"`
 }
 
 // CartItemUpdateRequest represents the request to update cart item quantity
@@ -62,6 +72,21 @@ type CartItemResponse struct {
 	PricePerItem       float64                      `json:"price_per_item"`
 	TotalPrice         float64                      `json:"total_price"`
 	AdditionalServices []AdditionalServiceResponse  `json:"additional_services"`
+	Options            []ProductOptionSelection     `json:"options"`
+	// MadeToOrder items are made after ordering, e.g. in a custom color; LeadTimeDays is
+	// the days until the item can be dispatched
+	MadeToOrder        bool                         `json:"made_to_order"`
+	LeadTimeDays       int                          `json:"lead_time_days"`
+	// Oversize and ShippingSurcharge are the product's shipping extras
+	Oversize           bool                         `json:"oversize"`
+	ShippingSurcharge  float64                      `json:"shipping_surcharge"`
+	// Unavailable items had one of their services or options deleted; they are left out of the
+	// cart totals and block checkout until the customer removes them
+	Unavailable        bool                         `json:"unavailable"`
+	UnavailableReason  string                       `json:"unavailable_reason,omitempty"`
+	// PriceChange is set when the price changed since the customer last saw the cart
+	PriceChange        *CartItemPriceChange         `json:"price_change,omitempty"`
+	Formatted          FormattedPrices              `json:"formatted,omitempty"`
 	CreatedAt          string                       `json:"created_at"`
 	UpdatedAt          string                       `json:"updated_at"`
 }
@@ -72,11 +97,106 @@ type CartResponse struct {
 	TotalItems       int                `json:"total_items"`
 	Subtotal         float64            `json:"subtotal"`
 	DiscountAmount   float64            `json:"discount_amount"`
+	ShippingCost     float64            `json:"shipping_cost"`
 	TotalPrice       float64            `json:"total_price"`
 	AppliedDiscount  *CartDiscount      `json:"applied_discount,omitempty"`
+	// Shipping is what the shipping cost is made of
+	Shipping         ShippingBreakdown  `json:"shipping"`
+	// FulfillmentNotice is set when the cart mixes in-stock and made-to-order items
+	FulfillmentNotice *FulfillmentNotice `json:"fulfillment_notice,omitempty"`
+	// PriceChanges counts the items whose price changed since the customer last saw the cart
+	PriceChanges      int                `json:"price_changes"`
+	Formatted         FormattedPrices    `json:"formatted,omitempty"`
+}
+
+// SettingCartPriceRefreshDays is the age in days after which cart prices are checked
+// against current prices
+const SettingCartPriceRefreshDays = "cart_price_refresh_days"
+
+// CartItemPriceChange is how a cart item's price moved; Difference is negative when
+// the item got cheaper
+type CartItemPriceChange struct {
+	PreviousPrice float64   `json:"previous_price"`
+	CurrentPrice  float64   `json:"current_price"`
+	Difference    float64   `json:"difference"`
+	ChangedAt     time.Time `json:"changed_at"`
+}
+
+// CartPriceRefreshResult reports a run of the cart price refresh
+type CartPriceRefreshResult struct {
+	Checked int `json:"checked"`
+	Changed int `json:"changed"`
+}
+
+// CartSummary is the item count and totals shown in the storefront header
+type CartSummary struct {
+	ItemCount      int     `json:"item_count"`
+	Subtotal       float64 `json:"subtotal"`
+	DiscountAmount float64 `json:"discount_amount"`
+	TotalPrice     float64 `json:"total_price"`
+	DiscountCode   *string `json:"discount_code"`
 }
 
 // CartCountResponse represents the cart item count
 type CartCountResponse struct {
 	Count int `json:"count"`
-}
\ No newline at end of file
+}
+// Cart history actions
+const (
+	CartActionAdded           = "added"
+	CartActionQuantityChanged = "quantity_changed"
+	CartActionRemoved         = "removed"
+	CartActionCleared         = "cleared"
+	CartActionCheckedOut      = "checked_out"
+	CartActionMerged          = "merged"
+)
+
+// CartEvent is one recorded cart mutation
+type CartEvent struct {
+	CartSessionID  int      `json:"cart_session_id"`
+	Action         string   `json:"action"`
+	CartItemID     *int     `json:"cart_item_id,omitempty"`
+	ProductID      *int     `json:"product_id,omitempty"`
+	VariantID      *int     `json:"variant_id,omitempty"`
+	SizeID         *int     `json:"size_id,omitempty"`
+	QuantityBefore int      `json:"quantity_before"`
+	QuantityAfter  int      `json:"quantity_after"`
+	PricePerItem   *float64 `json:"price_per_item,omitempty"`
+	UserID         *int     `json:"user_id,omitempty"`
+	OrderID        *int     `json:"order_id,omitempty"`
+}
+
+// CartHistoryEntry is a cart mutation as shown to support
+type CartHistoryEntry struct {
+	ID             int       `json:"id"`
+	Action         string    `json:"action"`
+	CartItemID     *int      `json:"cart_item_id,omitempty"`
+	ProductID      *int      `json:"product_id,omitempty"`
+	ProductName    *string   `json:"product_name,omitempty"`
+	VariantID      *int      `json:"variant_id,omitempty"`
+	VariantName    *string   `json:"variant_name,omitempty"`
+	SizeID         *int      `json:"size_id,omitempty"`
+	SizeName       *string   `json:"size_name,omitempty"`
+	QuantityBefore int       `json:"quantity_before"`
+	QuantityAfter  int       `json:"quantity_after"`
+	PricePerItem   *float64  `json:"price_per_item,omitempty"`
+	UserID         *int      `json:"user_id,omitempty"`
+	OrderID        *int      `json:"order_id,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// CartHistoryResponse is the mutation history of a cart session, oldest first
+type CartHistoryResponse struct {
+	SessionID string             `json:"session_id"`
+	UserID    *int               `json:"user_id,omitempty"`
+	CreatedAt time.Time          `json:"created_at"`
+	Events    []CartHistoryEntry `json:"events"`
+}
+
+// CartMergeResult describes what logging in moved into the session's cart from the
+// user's other carts
+type CartMergeResult struct {
+	ItemsMerged          int      `json:"items_merged"`
+	DiscountCode         *string  `json:"discount_code,omitempty"`
+	DroppedDiscountCodes []string `json:"dropped_discount_codes,omitempty"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// CatalogSnapshotFormatVersion is bumped whenever the archive layout changes
+const CatalogSnapshotFormatVersion = 1
+
+// CatalogSnapshotArchive is the exported JSON archive of the catalog and inventory
+type CatalogSnapshotArchive struct {
+	FormatVersion int                             `json:"format_version"`
+	CreatedAt     time.Time                       `json:"created_at"`
+	Tables        map[string]CatalogSnapshotTable `json:"tables"`
+}
+
+// CatalogSnapshotTable holds the rows of one table as exported by PostgreSQL
+type CatalogSnapshotTable struct {
+	Columns []string        `json:"columns"`
+	Rows    json.RawMessage `json:"rows"`
+}
+
+// CatalogSnapshot is the metadata of a stored snapshot
+type CatalogSnapshot struct {
+	ID            int       `json:"id"`
+	Note          *string   `json:"note,omitempty"`
+	FormatVersion int       `json:"format_version"`
+	ProductCount  int       `json:"product_count"`
+	SizeBytes     int       `json:"size_bytes"`
+	CreatedBy     *int      `json:"created_by,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// CatalogSnapshotRequest creates a snapshot with an optional note
+type CatalogSnapshotRequest struct {
+	Note *string `json:"note"`
+}
+
+// CatalogRestoreTableResult reports what a restore changed in one table
+type CatalogRestoreTableResult struct {
+	Table    string `json:"table"`
+	Restored int64  `json:"restored"`
+	Deleted  int64  `json:"deleted"`
+}
+
+// CatalogRestoreResult is the outcome of restoring a snapshot
+type CatalogRestoreResult struct {
+	SnapshotID       *int                        `json:"snapshot_id,omitempty"`
+	BackupSnapshotID int                         `json:"backup_snapshot_id"`
+	Tables           []CatalogRestoreTableResult `json:"tables"`
+}
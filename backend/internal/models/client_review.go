@@ -4,6 +4,12 @@ import (
 	"time"
 )
 
+// Bounds of a client review's star rating
+const (
+	MinClientReviewRating = 1
+	MaxClientReviewRating = 5
+)
+
 // ClientReview represents a client review with photo and optional Instagram handle
 type ClientReview struct {
 	ID              int       `json:"id"`
@@ -12,6 +18,7 @@ type ClientReview struct {
 	ImageID         int       `json:"image_id"`
 	DisplayOrder    int       `json:"display_order"`
 	IsActive        bool      `json:"is_active"`
+	Rating          *int      `json:"rating,omitempty"`
 	CreatedAt       time.Time `json:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
 	// Related data
@@ -25,6 +32,7 @@ type CreateClientReviewRequest struct {
 	ImageID         int     `json:"image_id" binding:"required,min=1"`
 	DisplayOrder    int     `json:"display_order"`
 	IsActive        bool    `json:"is_active"`
+	Rating          *int    `json:"rating,omitempty" binding:"omitempty,min=1,max=5"`
 }
 
 // UpdateClientReviewRequest represents the request to update an existing client review
@@ -34,6 +42,7 @@ type UpdateClientReviewRequest struct {
 	ImageID         int     `json:"image_id" binding:"required,min=1"`
 	DisplayOrder    int     `json:"display_order"`
 	IsActive        bool    `json:"is_active"`
+	Rating          *int    `json:"rating,omitempty" binding:"omitempty,min=1,max=5"`
 }
 
 // ClientReviewListResponse represents the response for listing client reviews
@@ -44,6 +53,22 @@ type ClientReviewListResponse struct {
 	Limit         int            `json:"limit"`
 }
 
+// ClientReviewFilter narrows a client review listing; Rating and MinRating are nil when unused
+type ClientReviewFilter struct {
+	ActiveOnly bool
+	Rating     *int
+	MinRating  *int
+}
+
+// ClientReviewSummary is the aggregate of active client reviews shown in the page header
+type ClientReviewSummary struct {
+	Count         int      `json:"count"`
+	RatedCount    int      `json:"rated_count"`
+	AverageRating *float64 `json:"average_rating"`
+	// Distribution counts the reviews giving each rating, 1-5
+	Distribution map[int]int `json:"distribution"`
+}
+
 // ReorderClientReviewsRequest represents the request to reorder client reviews
 type ReorderClientReviewsRequest struct {
 	ReviewOrders []struct {
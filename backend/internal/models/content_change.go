@@ -0,0 +1,57 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Content change statuses
+const (
+	ContentChangePending  = "pending"
+	ContentChangeApproved = "approved"
+	ContentChangeRejected = "rejected"
+)
+
+// ContentEntityProduct is the only entity with an approval workflow so far
+const ContentEntityProduct = "product"
+
+// SettingContentApprovalRequired makes editors' changes wait for admin approval
+const SettingContentApprovalRequired = "content_approval_required"
+
+// ContentChange is a proposed change to a content entity. Base is the entity as it
+// was when the change was submitted, Payload the proposed state.
+type ContentChange struct {
+	ID               int                  `json:"id"`
+	EntityType       string               `json:"entity_type"`
+	EntityID         int                  `json:"entity_id"`
+	Status           string               `json:"status"`
+	Payload          json.RawMessage      `json:"payload"`
+	Base             json.RawMessage      `json:"base"`
+	SubmittedBy      *int                 `json:"submitted_by,omitempty"`
+	SubmittedByEmail *string              `json:"submitted_by_email,omitempty"`
+	SubmittedAt      time.Time            `json:"submitted_at"`
+	ReviewedBy       *int                 `json:"reviewed_by,omitempty"`
+	ReviewedAt       *time.Time           `json:"reviewed_at,omitempty"`
+	ReviewNote       *string              `json:"review_note,omitempty"`
+	Diff             []ContentFieldChange `json:"diff,omitempty"`
+}
+
+// ContentFieldChange is one changed field of a content change
+type ContentFieldChange struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// ContentChangeListResponse is a paginated list of content changes
+type ContentChangeListResponse struct {
+	Changes []ContentChange `json:"changes"`
+	Total   int             `json:"total"`
+	Page    int             `json:"page"`
+	Limit   int             `json:"limit"`
+}
+
+// ContentChangeReviewRequest carries the reviewer's note on approve or reject
+type ContentChangeReviewRequest struct {
+	Note string `json:"note" binding:"max=1000"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// Where the rate of a currency came from
+const (
+	CurrencySourceManual = "manual"
+	CurrencySourceNBP    = "nbp"
+)
+
+// SettingExchangeRatesAutoUpdate turns the daily NBP rate update on and off
+const SettingExchangeRatesAutoUpdate = "exchange_rates_auto_update"
+
+// Currency is a currency prices can be shown in. Prices are kept and charged in
+// PaymentCurrency; Rate is the price of one unit of the currency in PaymentCurrency,
+// as NBP publishes it.
+type Currency struct {
+	Code      string    `json:"code"`
+	Name      string    `json:"name"`
+	Rate      float64   `json:"rate"`
+	Enabled   bool      `json:"enabled"`
+	Source    string    `json:"source"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CurrencyRequest adds a currency or changes its rate; a rate set this way is kept
+// until the next NBP update
+type CurrencyRequest struct {
+	Name    string  `json:"name" binding:"required,max=100"`
+	Rate    float64 `json:"rate" binding:"required,gt=0"`
+	Enabled bool    `json:"enabled"`
+}
+
+// CurrencyListResponse lists the currencies
+type CurrencyListResponse struct {
+	Base       string     `json:"base"`
+	Currencies []Currency `json:"currencies"`
+}
+
+// ExchangeRateUpdateResponse is the result of updating the rates from NBP
+type ExchangeRateUpdateResponse struct {
+	EffectiveDate string     `json:"effective_date"`
+	Updated       []Currency `json:"updated"`
+	// Missing are the currencies NBP published no rate for; they keep their rate
+	Missing []string `json:"missing"`
+}
@@ -0,0 +1,76 @@
+package models
+
+import "time"
+
+// Debug capture settings
+const (
+	// SettingDebugCaptureRedactFields lists the JSON and form fields whose values are
+	// replaced before payloads are stored, comma separated; a field matches when its
+	// name contains one of them
+	SettingDebugCaptureRedactFields   = "debug_capture_redact_fields"
+	SettingRetentionDebugCapturesDays = "retention_debug_captures_days"
+)
+
+// DefaultDebugCaptureRedactFields is used when the redaction setting is missing
+const DefaultDebugCaptureRedactFields = "password,token,secret,authorization,cookie,card,cvc,cvv,iban,email,phone,first_name,last_name,address_line,nip"
+
+// MaxDebugCaptureMinutes bounds how long a capture can run
+const MaxDebugCaptureMinutes = 24 * 60
+
+// DebugCapture records the API traffic of one cart session or user until it expires
+type DebugCapture struct {
+	ID         int       `json:"id"`
+	SessionID  *string   `json:"session_id,omitempty"`
+	UserID     *int      `json:"user_id,omitempty"`
+	UserEmail  *string   `json:"user_email,omitempty"`
+	Note       *string   `json:"note,omitempty"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Active     bool      `json:"active"`
+	EntryCount int       `json:"entry_count"`
+	CreatedBy  *int      `json:"created_by,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// DebugCaptureRequest starts a capture for a session ID or a user
+type DebugCaptureRequest struct {
+	SessionID       string  `json:"session_id"`
+	UserID          *int    `json:"user_id"`
+	DurationMinutes int     `json:"duration_minutes" binding:"required,min=1"`
+	Note            *string `json:"note"`
+}
+
+// DebugCaptureEntry is one captured request with its response, redacted
+type DebugCaptureEntry struct {
+	ID             int               `json:"id"`
+	CaptureID      int               `json:"capture_id"`
+	Method         string            `json:"method"`
+	Path           string            `json:"path"`
+	Query          string            `json:"query,omitempty"`
+	Status         int               `json:"status"`
+	DurationMS     int64             `json:"duration_ms"`
+	ClientIP       string            `json:"client_ip"`
+	SessionID      *string           `json:"session_id,omitempty"`
+	UserID         *int              `json:"user_id,omitempty"`
+	RequestHeaders map[string]string `json:"request_headers"`
+	RequestBody    string            `json:"request_body"`
+	ResponseBody   string            `json:"response_body"`
+	Truncated      bool              `json:"truncated"`
+	CreatedAt      time.Time         `json:"created_at"`
+}
+
+// DebugCaptureListResponse is a page of captures, newest first
+type DebugCaptureListResponse struct {
+	Captures []DebugCapture `json:"captures"`
+	Total    int            `json:"total"`
+	Page     int            `json:"page"`
+	Limit    int            `json:"limit"`
+}
+
+// DebugCaptureEntriesResponse is a page of a capture's entries in the order they happened
+type DebugCaptureEntriesResponse struct {
+	Capture DebugCapture        `json:"capture"`
+	Entries []DebugCaptureEntry `json:"entries"`
+	Total   int                 `json:"total"`
+	Page    int                 `json:"page"`
+	Limit   int                 `json:"limit"`
+}
@@ -17,6 +17,38 @@ const (
 	UsageTypeUnlimited   = "unlimited"
 )
 
+// Discount usage statuses. A use made at checkout is pending until the order is paid;
+// it is released when the order is cancelled or its online payment never completes, and
+// released uses no longer count against the code's limits.
+const (
+	DiscountUsagePending   = "pending"
+	DiscountUsageConfirmed = "confirmed"
+	DiscountUsageReleased  = "released"
+)
+
+// SettingDiscountUsageExpiryHours is how long an online payment may stay incomplete
+// before the discount use of its order is released; 0 keeps such uses pending
+const SettingDiscountUsageExpiryHours = "discount_usage_expiry_hours"
+
+// DefaultDiscountUsageExpiryHours is used when the setting is missing
+const DefaultDiscountUsageExpiryHours = 24
+
+// SettingShopTimezone is the IANA time zone discount windows are evaluated in
+const SettingShopTimezone = "shop_timezone"
+
+// DefaultShopTimezone is used when the shop_timezone setting is missing or invalid
+const DefaultShopTimezone = "Europe/Warsaw"
+
+// DiscountWindow is a recurring period in which a discount code can be used, in shop
+// time. Weekdays are 0 (Sunday) to 6 (Saturday), empty meaning every day. Times are
+// "HH:MM"; the end is exclusive and empty times mean the whole day. A window ending
+// before it starts runs past midnight and belongs to the weekday it starts on.
+type DiscountWindow struct {
+	Weekdays  []int  `json:"weekdays,omitempty"`
+	StartTime string `json:"start_time,omitempty"`
+	EndTime   string `json:"end_time,omitempty"`
+}
+
 // DiscountCode represents a discount code in the database
 type DiscountCode struct {
 	ID             int       `json:"id"`
@@ -31,6 +63,8 @@ type DiscountCode struct {
 	Active         bool      `json:"active"`
 	StartDate      time.Time `json:"start_date"`
 	EndDate        *time.Time `json:"end_date,omitempty"`
+	Windows        []DiscountWindow `json:"windows"`
+	IsFirstOrderOnly bool    `json:"is_first_order_only"`
 	CreatedBy      *int      `json:"created_by,omitempty"`
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
@@ -48,6 +82,10 @@ type DiscountCodeRequest struct {
 	Active         bool       `json:"active"`
 	StartDate      time.Time  `json:"start_date" binding:"required"`
 	EndDate        *time.Time `json:"end_date,omitempty"`
+	// Windows restrict the code to recurring periods; empty means any time between the dates
+	Windows        []DiscountWindow `json:"windows,omitempty"`
+	// IsFirstOrderOnly limits the code to customers without earlier orders
+	IsFirstOrderOnly bool        `json:"is_first_order_only"`
 }
 
 // DiscountCodeResponse represents a discount code response with additional information
@@ -64,6 +102,8 @@ type DiscountCodeResponse struct {
 	Active         bool      `json:"active"`
 	StartDate      time.Time `json:"start_date"`
 	EndDate        *time.Time `json:"end_date,omitempty"`
+	Windows        []DiscountWindow `json:"windows"`
+	IsFirstOrderOnly bool    `json:"is_first_order_only"`
 	CreatedBy      *int      `json:"created_by,omitempty"`
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
@@ -98,12 +138,15 @@ type ApplyDiscountResponse struct {
 
 // DiscountCodeUsage represents a record of discount code usage
 type DiscountCodeUsage struct {
-	ID             int       `json:"id"`
-	DiscountCodeID int       `json:"discount_code_id"`
-	UserID         *int      `json:"user_id,omitempty"`
-	SessionID      string    `json:"session_id"`
-	OrderID        *int      `json:"order_id,omitempty"`
-	CreatedAt      time.Time `json:"created_at"`
+	ID             int        `json:"id"`
+	DiscountCodeID int        `json:"discount_code_id"`
+	UserID         *int       `json:"user_id,omitempty"`
+	SessionID      string     `json:"session_id"`
+	OrderID        *int       `json:"order_id,omitempty"`
+	Status         string     `json:"status"`
+	ConfirmedAt    *time.Time `json:"confirmed_at,omitempty"`
+	ReleasedAt     *time.Time `json:"released_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
 }
 
 // DiscountValidationResult represents the result of discount code validation
@@ -129,4 +172,21 @@ type OrderDiscount struct {
 	CodeID      *int    `json:"code_id,omitempty"`
 	Amount      float64 `json:"amount"`
 	Description string  `json:"description"`
-}
\ No newline at end of file
+}
+// DiscountCodeUser restricts a discount code to a user account or an email address.
+// Codes without any are open to everyone.
+type DiscountCodeUser struct {
+	ID             int       `json:"id"`
+	DiscountCodeID int       `json:"discount_code_id"`
+	UserID         *int      `json:"user_id,omitempty"`
+	UserEmail      *string   `json:"user_email,omitempty"`
+	Email          *string   `json:"email,omitempty"`
+	CreatedBy      *int      `json:"created_by,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// DiscountCodeUserRequest assigns a discount code to either a user or an email address
+type DiscountCodeUserRequest struct {
+	UserID *int   `json:"user_id,omitempty"`
+	Email  string `json:"email,omitempty" binding:"omitempty,email,max=255"`
+}
@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// Email outbox statuses
+const (
+	EmailOutboxPending = "pending"
+	EmailOutboxSent    = "sent"
+	EmailOutboxFailed  = "failed"
+)
+
+// SettingRetentionEmailOutboxDays limits how long sent and failed outbox emails are kept
+const SettingRetentionEmailOutboxDays = "retention_email_outbox_days"
+
+// EmailTemplateData is what email templates are rendered with. PreviousStatus and
+// Status are only set for status updates, Return and OrderURL for return emails.
+type EmailTemplateData struct {
+	Order          *OrderResponse
+	PreviousStatus string
+	Status         string
+	Return         *OrderReturn
+	// OrderURL is the customer's order page on the storefront
+	OrderURL string
+}
+
+// EmailTemplate is the template of one kind of email; Customized is false while the
+// built-in default is used
+type EmailTemplate struct {
+	Key        string     `json:"key"`
+	Subject    string     `json:"subject"`
+	Text       string     `json:"text"`
+	HTML       string     `json:"html"`
+	Customized bool       `json:"customized"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"`
+}
+
+// EmailTemplateRequest customizes a template
+type EmailTemplateRequest struct {
+	Subject string `json:"subject" binding:"required,max=255"`
+	Text    string `json:"text" binding:"required"`
+	HTML    string `json:"html" binding:"required"`
+}
+
+// OutboxEmail is an email queued for sending
+type OutboxEmail struct {
+	ID            int        `json:"id"`
+	Template      string     `json:"template"`
+	ToAddress     string     `json:"to_address"`
+	Subject       string     `json:"subject"`
+	OrderID       *int       `json:"order_id,omitempty"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	LastError     *string    `json:"last_error,omitempty"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// OutboxEmailListResponse is a page of the email outbox
+type OutboxEmailListResponse struct {
+	Emails []OutboxEmail `json:"emails"`
+	Total  int           `json:"total"`
+	Page   int           `json:"page"`
+	Limit  int           `json:"limit"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// EmailPreferences are the kinds of email an address receives. They are kept per
+// address so guests can unsubscribe too; addresses without saved preferences get the
+// defaults.
+type EmailPreferences struct {
+	OrderUpdates bool       `json:"order_updates"`
+	Promotions   bool       `json:"promotions"`
+	BackInStock  bool       `json:"back_in_stock"`
+	UpdatedAt    *time.Time `json:"updated_at,omitempty"`
+}
+
+// DefaultEmailPreferences are the preferences of addresses that haven't chosen;
+// promotions need consent
+func DefaultEmailPreferences() EmailPreferences {
+	return EmailPreferences{OrderUpdates: true, Promotions: false, BackInStock: true}
+}
+
+// EmailPreferencesRequest changes email preferences; omitted fields keep their value
+type EmailPreferencesRequest struct {
+	OrderUpdates *bool `json:"order_updates"`
+	Promotions   *bool `json:"promotions"`
+	BackInStock  *bool `json:"back_in_stock"`
+}
+
+// UnsubscribeRequest opts the address of a signed unsubscribe token out of its category
+type UnsubscribeRequest struct {
+	Token string `json:"token" binding:"required,max=1000"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Reasons an email address is suppressed
+const (
+	EmailSuppressionBounce    = "bounce"
+	EmailSuppressionComplaint = "complaint"
+)
+
+// EmailSuppression is an address no email is sent to any more because it hard bounced
+// or its owner marked an email as spam
+type EmailSuppression struct {
+	Email    string  `json:"email"`
+	Reason   string  `json:"reason"`
+	Detail   *string `json:"detail,omitempty"`
+	Provider string  `json:"provider"`
+	// Events counts the notifications received about the address
+	Events    int       `json:"events"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// EmailSuppressionListResponse is a page of suppressed addresses
+type EmailSuppressionListResponse struct {
+	Suppressions []EmailSuppression `json:"suppressions"`
+	Total        int                `json:"total"`
+	Page         int                `json:"page"`
+	Limit        int                `json:"limit"`
+}
@@ -0,0 +1,157 @@
+package models
+
+import "time"
+
+// Export types admins can request from the export center
+const (
+	ExportTypeOrders      = "orders"
+	ExportTypeProductFeed = "product_feed"
+	ExportTypeCustomers   = "customers"
+)
+
+// Export states; pending exports are picked up by the exports job
+const (
+	ExportStatusPending    = "pending"
+	ExportStatusProcessing = "processing"
+	ExportStatusCompleted  = "completed"
+	ExportStatusFailed     = "failed"
+)
+
+// File formats of the order export for accounting
+const (
+	ExportFormatCSV  = "csv"
+	ExportFormatXLSX = "xlsx"
+)
+
+// ExportParams narrow what is exported. From and To limit orders by placement date
+// (To is exclusive), Status limits orders and Channel limits the product feed to
+// products offered in a sales channel.
+type ExportParams struct {
+	From    *time.Time `json:"from,omitempty"`
+	To      *time.Time `json:"to,omitempty"`
+	Status  string     `json:"status,omitempty" binding:"omitempty,oneof=pending processing shipped delivered cancelled"`
+	Channel string     `json:"channel,omitempty" binding:"omitempty,oneof=web marketplace b2b"`
+}
+
+// Export is a file generated in the background for an admin to download
+type Export struct {
+	ID               int          `json:"id"`
+	Type             string       `json:"type"`
+	Status           string       `json:"status"`
+	Params           ExportParams `json:"params"`
+	FileName         *string      `json:"file_name,omitempty"`
+	Path             *string      `json:"-"`
+	RowCount         *int         `json:"row_count,omitempty"`
+	SizeBytes        *int64       `json:"size_bytes,omitempty"`
+	Error            *string      `json:"error,omitempty"`
+	RequestedBy      *int         `json:"requested_by,omitempty"`
+	RequestedByEmail *string      `json:"requested_by_email,omitempty"`
+	CreatedAt        time.Time    `json:"created_at"`
+	StartedAt        *time.Time   `json:"started_at,omitempty"`
+	CompletedAt      *time.Time   `json:"completed_at,omitempty"`
+}
+
+// ExportRequest asks for a new export
+type ExportRequest struct {
+	Type string `json:"type" binding:"required,oneof=orders product_feed customers"`
+	ExportParams
+}
+
+// ExportListResponse is a page of exports, newest first
+type ExportListResponse struct {
+	Exports []Export `json:"exports"`
+	Total   int      `json:"total"`
+	Page    int      `json:"page"`
+	Limit   int      `json:"limit"`
+}
+
+// OrderExportRow is an order as exported for accounting
+type OrderExportRow struct {
+	ID             int
+	CreatedAt      time.Time
+	Status         string
+	PaymentStatus  string
+	PaymentMethod  *string
+	Email          string
+	Phone          string
+	FirstName      string
+	LastName       string
+	Company        *string
+	City           string
+	Country        string
+	NIP            *string
+	ItemCount      int
+	Subtotal       float64
+	DiscountAmount float64
+	ShippingCost   float64
+	TotalAmount    float64
+}
+
+// OrderLineExportRow is an order item with its order, as exported for accounting.
+// Orders without items are exported as one row with the item fields empty.
+type OrderLineExportRow struct {
+	OrderID             int
+	CreatedAt           time.Time
+	Status              string
+	PaymentStatus       string
+	PaymentMethod       *string
+	Email               string
+	Phone               string
+	RequiresInvoice     bool
+	NIP                 *string
+	DiscountCode        *string
+	DiscountDescription *string
+	DiscountAmount      float64
+	Subtotal            float64
+	ShippingCost        float64
+	TotalAmount         float64
+	Shipping            ExportAddress
+	Billing             ExportAddress
+	ProductID           *int
+	ProductName         *string
+	VariantName         *string
+	ColorName           *string
+	SizeName            *string
+	Quantity            *int
+	UnitPrice           *float64
+	TotalPrice          *float64
+}
+
+// ExportAddress is an order address as exported; fields are nil when the order has none
+type ExportAddress struct {
+	FirstName    *string
+	LastName     *string
+	Company      *string
+	AddressLine1 *string
+	AddressLine2 *string
+	PostalCode   *string
+	City         *string
+	Country      *string
+}
+
+// ProductFeedRow is a size of a product as exported to a sales channel
+type ProductFeedRow struct {
+	ProductID         int
+	ProductName       string
+	Slug              string
+	Category          *string
+	Material          *string
+	SizeID            int
+	SizeName          string
+	Price             float64
+	UseStock          bool
+	AvailableQuantity int
+}
+
+// CustomerExportRow is a registered customer with their order totals
+type CustomerExportRow struct {
+	ID            int
+	Email         string
+	FirstName     *string
+	LastName      *string
+	Phone         *string
+	CreatedAt     time.Time
+	OrderCount    int
+	LifetimeValue float64
+	LastOrderAt   *time.Time
+}
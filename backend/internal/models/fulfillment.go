@@ -0,0 +1,49 @@
+package models
+
+// Fulfilment settings and their defaults
+const (
+	SettingMadeToOrderLeadDays  = "made_to_order_lead_days"
+	SettingInStockDispatchDays  = "in_stock_dispatch_days"
+	DefaultMadeToOrderLeadDays  = 14
+	DefaultInStockDispatchDays  = 2
+	FulfillmentNoticeMixedStock = "mixed_stock"
+)
+
+// ProductFulfillment tells whether a product is made after it is ordered. LeadTimeDays
+// overrides the shop's made-to-order lead time when set.
+type ProductFulfillment struct {
+	ProductID    int  `json:"product_id"`
+	MadeToOrder  bool `json:"made_to_order"`
+	LeadTimeDays *int `json:"lead_time_days"`
+}
+
+// ProductFulfillmentRequest sets whether a product is made to order
+type ProductFulfillmentRequest struct {
+	MadeToOrder  bool `json:"made_to_order"`
+	LeadTimeDays *int `json:"lead_time_days" binding:"omitempty,min=1,max=365"`
+}
+
+// FulfillmentNotice warns about a cart mixing in-stock and made-to-order items: shipped
+// together, the whole order waits for the made-to-order ones. Customers can ask for a
+// split shipment so the in-stock items leave first.
+type FulfillmentNotice struct {
+	Code                   string `json:"code"`
+	Message                string `json:"message"`
+	InStockItemIDs         []int  `json:"in_stock_item_ids"`
+	MadeToOrderItemIDs     []int  `json:"made_to_order_item_ids"`
+	InStockDispatchDays    int    `json:"in_stock_dispatch_days"`
+	MadeToOrderLeadDays    int    `json:"made_to_order_lead_days"`
+	CombinedLeadDays       int    `json:"combined_lead_days"`
+	SplitShipmentAvailable bool   `json:"split_shipment_available"`
+}
+
+// SizeStock is what the storefront shows about a product size before it is added to the
+// cart. Available is nil for sizes that don't track stock, which never run out.
+type SizeStock struct {
+	ProductID    int  `json:"product_id"`
+	SizeID       int  `json:"size_id"`
+	Available    *int `json:"available"`
+	InStock      bool `json:"in_stock"`
+	MadeToOrder  bool `json:"made_to_order"`
+	LeadTimeDays int  `json:"lead_time_days"`
+}
@@ -0,0 +1,23 @@
+package models
+
+// ImageCrop is a crop rectangle in fractions (0-1) of the source image size
+type ImageCrop struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// ImageFocalPoint is the point (0-1 from the top left) kept in view when cropping
+type ImageFocalPoint struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// ImageCropRequest updates how generated variants of an image are cropped.
+// Crops maps a variant name to an explicit crop that overrides the focal point.
+type ImageCropRequest struct {
+	FocalX *float64             `json:"focal_x" binding:"omitempty,min=0,max=1"`
+	FocalY *float64             `json:"focal_y" binding:"omitempty,min=0,max=1"`
+	Crops  map[string]ImageCrop `json:"crops"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// DefaultOrphanImageMinAgeHours keeps fresh uploads out of orphan cleanup; the admin
+// panel uploads images before saving the record that uses them
+const DefaultOrphanImageMinAgeHours = 24
+
+// ImageReportItem is an image listed in the image report
+type ImageReportItem struct {
+	ID           int    `json:"id"`
+	Filename     string `json:"filename"`
+	OriginalName string `json:"original_name"`
+	Path         string `json:"path"`
+	SizeBytes    int64  `json:"size_bytes"`
+	MimeType     string `json:"mime_type"`
+	// References counts the records using the image
+	References int       `json:"references"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ImageMimeUsage is the storage used by the images of one MIME type
+type ImageMimeUsage struct {
+	MimeType   string `json:"mime_type"`
+	Count      int    `json:"count"`
+	TotalBytes int64  `json:"total_bytes"`
+}
+
+// ImageReport summarizes the media library: storage used, images nothing refers to and
+// images whose files are gone
+type ImageReport struct {
+	TotalImages       int               `json:"total_images"`
+	TotalBytes        int64             `json:"total_bytes"`
+	ByMimeType        []ImageMimeUsage  `json:"by_mime_type"`
+	Unreferenced      []ImageReportItem `json:"unreferenced"`
+	UnreferencedBytes int64             `json:"unreferenced_bytes"`
+	MissingFiles      []ImageReportItem `json:"missing_files"`
+	GeneratedAt       time.Time         `json:"generated_at"`
+}
+
+// ImageCleanupResponse answers a request to clean up orphaned images
+type ImageCleanupResponse struct {
+	Queued      int   `json:"queued"`
+	QueuedBytes int64 `json:"queued_bytes"`
+	MinAgeHours int   `json:"min_age_hours"`
+}
@@ -0,0 +1,15 @@
+package models
+
+// ImageSEORequest sets the alt text and title of an image. Omitted fields keep their
+// current value; an empty string clears them.
+type ImageSEORequest struct {
+	AltText *string `json:"alt_text" binding:"omitempty,max=255"`
+	Title   *string `json:"title" binding:"omitempty,max=255"`
+}
+
+// ProductImageSEORequest overrides the alt text and title of an image where a product
+// shows it. Empty values fall back to the image's own.
+type ProductImageSEORequest struct {
+	AltText string `json:"alt_text" binding:"max=255"`
+	Title   string `json:"title" binding:"max=255"`
+}
@@ -0,0 +1,12 @@
+package models
+
+// ImageVariant is a resized WebP copy of an image for serving it at the size it is
+// shown, e.g. a thumbnail in listings
+type ImageVariant struct {
+	Name      string `json:"name"`
+	Path      string `json:"path"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	SizeBytes int64  `json:"size_bytes"`
+	MimeType  string `json:"mime_type"`
+}
@@ -0,0 +1,19 @@
+package models
+
+// ImportRowError is a validation error of one cell or row of an imported file
+type ImportRowError struct {
+	Line    int    `json:"line"`
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportResult reports an import. Imports are all or nothing: when any row has an
+// error nothing is saved, and a dry run reports what would be saved without saving it.
+type ImportResult struct {
+	DryRun  bool             `json:"dry_run"`
+	Applied bool             `json:"applied"`
+	Rows    int              `json:"rows"`
+	Created int              `json:"created"`
+	Updated int              `json:"updated"`
+	Errors  []ImportRowError `json:"errors"`
+}
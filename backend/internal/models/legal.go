@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+)
+
+// Legal document type constants
+const (
+	LegalDocumentTerms   = "terms"
+	LegalDocumentPrivacy = "privacy"
+)
+
+// LegalDocument represents a published version of the terms of service or privacy policy
+type LegalDocument struct {
+	ID          int       `json:"id"`
+	Type        string    `json:"type"`
+	Version     string    `json:"version"`
+	Title       string    `json:"title"`
+	URL         *string   `json:"url,omitempty"`
+	Summary     *string   `json:"summary,omitempty"`
+	PublishedBy *int      `json:"published_by,omitempty"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// LegalAcceptance records that a user or an order accepted a document version
+type LegalAcceptance struct {
+	ID           int       `json:"id"`
+	DocumentID   int       `json:"document_id"`
+	DocumentType string    `json:"document_type"`
+	Version      string    `json:"version"`
+	UserID       *int      `json:"user_id,omitempty"`
+	OrderID      *int      `json:"order_id,omitempty"`
+	IPAddress    string    `json:"ip_address"`
+	UserAgent    *string   `json:"user_agent,omitempty"`
+	AcceptedAt   time.Time `json:"accepted_at"`
+}
+
+// PublishLegalDocumentRequest represents an admin request to publish a new document version
+type PublishLegalDocumentRequest struct {
+	Type    string  `json:"type" binding:"required,oneof=terms privacy"`
+	Version string  `json:"version" binding:"required,min=1,max=50"`
+	Title   string  `json:"title" binding:"required,min=1,max=255"`
+	URL     *string `json:"url,omitempty" binding:"omitempty,url"`
+	Summary *string `json:"summary,omitempty"`
+}
+
+// AcceptedDocument identifies a document version the client accepted
+type AcceptedDocument struct {
+	Type    string `json:"type" binding:"required"`
+	Version string `json:"version" binding:"required"`
+}
+
+// AcceptLegalDocumentsRequest represents a logged-in user accepting current documents
+type AcceptLegalDocumentsRequest struct {
+	AcceptedDocuments []AcceptedDocument `json:"accepted_documents" binding:"required,min=1,dive"`
+}
+
+// LegalAcceptanceRequiredResponse is returned when current document versions must be accepted first
+type LegalAcceptanceRequiredResponse struct {
+	Error     string          `json:"error"`
+	Code      string          `json:"code"`
+	Documents []LegalDocument `json:"documents"`
+}
+
+// LegalAcceptanceRequiredCode is the error code clients use to show the acceptance dialog
+const LegalAcceptanceRequiredCode = "legal_acceptance_required"
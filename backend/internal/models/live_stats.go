@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// Windows of the live stats dashboard: carts touched within LiveStatsActiveCartMinutes
+// count as active, the order rate is averaged over LiveStatsOrderRateMinutes and the
+// order history covers LiveStatsOrderHistoryMinutes
+const (
+	LiveStatsActiveCartMinutes   = 30
+	LiveStatsOrderRateMinutes    = 5
+	LiveStatsOrderHistoryMinutes = 15
+	// MaxLiveStatsSizes caps the sizes reported, promoted or busiest in carts
+	MaxLiveStatsSizes = 50
+)
+
+// LiveSizeStats compares the units of a size sitting in active carts with its stock.
+// Available is nil for sizes that don't track stock.
+type LiveSizeStats struct {
+	SizeID           int    `json:"size_id"`
+	SizeName         string `json:"size_name"`
+	ProductID        int    `json:"product_id"`
+	ProductName      string `json:"product_name"`
+	UnitsInCarts     int    `json:"units_in_carts"`
+	Carts            int    `json:"carts"`
+	UseStock         bool   `json:"use_stock"`
+	StockQuantity    int    `json:"stock_quantity"`
+	ReservedQuantity int    `json:"reserved_quantity"`
+	Available        *int   `json:"available"`
+}
+
+// OrdersMinute is the number of orders placed in one minute
+type OrdersMinute struct {
+	Minute time.Time `json:"minute"`
+	Orders int       `json:"orders"`
+}
+
+// LiveStats are the numbers of the flash sale dashboard. Sizes are the promoted sizes
+// asked for, or the sizes with the most units in carts.
+type LiveStats struct {
+	ActiveCarts     int             `json:"active_carts"`
+	UnitsInCarts    int             `json:"units_in_carts"`
+	Sizes           []LiveSizeStats `json:"sizes"`
+	OrdersPerMinute float64         `json:"orders_per_minute"`
+	OrdersByMinute  []OrdersMinute  `json:"orders_by_minute"`
+	GeneratedAt     time.Time       `json:"generated_at"`
+}
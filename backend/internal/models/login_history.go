@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// SettingRetentionLoginHistoryDays is how long login history is kept
+const SettingRetentionLoginHistoryDays = "retention_login_history_days"
+
+// LoginRecord is one successful login
+type LoginRecord struct {
+	ID        int       `json:"id"`
+	IPAddress string    `json:"ip_address"`
+	UserAgent *string   `json:"user_agent,omitempty"`
+	NewDevice bool      `json:"new_device"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// LoginHistoryResponse is a page of a user's logins, newest first
+type LoginHistoryResponse struct {
+	Logins []LoginRecord `json:"logins"`
+	Total  int           `json:"total"`
+	Page   int           `json:"page"`
+	Limit  int           `json:"limit"`
+}
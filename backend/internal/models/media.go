@@ -0,0 +1,21 @@
+package models
+
+// MaxMediaTagLength limits the length of a single media tag
+const MaxMediaTagLength = 50
+
+// MediaReference is a record that uses a media item
+type MediaReference struct {
+	EntityType string `json:"entity_type"`
+	EntityID   int    `json:"entity_id"`
+}
+
+// MediaTag is a tag with the number of items carrying it
+type MediaTag struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// MediaTagsRequest replaces the tags of a media item
+type MediaTagsRequest struct {
+	Tags []string `json:"tags" binding:"max=20,dive,max=50"`
+}
@@ -19,31 +19,56 @@ const (
 	PaymentStatusCompleted = "completed"
 	PaymentStatusFailed    = "failed"
 	PaymentStatusRefunded  = "refunded"
+
+	PaymentStatusPartiallyRefunded = "partially_refunded"
 )
 
 // Order represents an order in the database
 type Order struct {
-	ID                  int       `json:"id"`
-	UserID              *int      `json:"user_id,omitempty"`
-	SessionID           *string   `json:"session_id,omitempty"`
-	PublicHash          *string   `json:"public_hash,omitempty"`
-	Email               string    `json:"email"`
-	Phone               string    `json:"phone"`
-	Status              string    `json:"status"`
-	TotalAmount         float64   `json:"total_amount"`
-	Subtotal            float64   `json:"subtotal"`
-	ShippingCost        float64   `json:"shipping_cost"`
-	TaxAmount           float64   `json:"tax_amount"`
-	DiscountCodeID      *int      `json:"discount_code_id,omitempty"`
-	DiscountAmount      float64   `json:"discount_amount"`
-	DiscountDescription *string   `json:"discount_description,omitempty"`
-	PaymentMethod       *string   `json:"payment_method,omitempty"`
-	PaymentStatus       string    `json:"payment_status"`
-	Notes               *string   `json:"notes,omitempty"`
-	RequiresInvoice     bool      `json:"requires_invoice"`
-	NIP                 *string   `json:"nip,omitempty"`
-	CreatedAt           time.Time `json:"created_at"`
-	UpdatedAt           time.Time `json:"updated_at"`
+	ID                  int        `json:"id"`
+	UserID              *int       `json:"user_id,omitempty"`
+	SessionID           *string    `json:"session_id,omitempty"`
+	PublicHash          *string    `json:"public_hash,omitempty"`
+	Email               string     `json:"email"`
+	Phone               string     `json:"phone"`
+	Status              string     `json:"status"`
+	// CustomStatusID is the shop's own status refining Status, if the order is in one;
+	// StatusLabel is its label for customers
+	CustomStatusID      *int       `json:"custom_status_id,omitempty"`
+	StatusLabel         *string    `json:"status_label,omitempty"`
+	TotalAmount         float64    `json:"total_amount"`
+	Subtotal            float64    `json:"subtotal"`
+	ShippingCost        float64    `json:"shipping_cost"`
+	TaxAmount           float64    `json:"tax_amount"`
+	DiscountCodeID      *int       `json:"discount_code_id,omitempty"`
+	DiscountAmount      float64    `json:"discount_amount"`
+	DiscountDescription *string    `json:"discount_description,omitempty"`
+	PaymentMethod       *string    `json:"payment_method,omitempty"`
+	PaymentStatus       string     `json:"payment_status"`
+	Notes               *string    `json:"notes,omitempty"`
+	RequiresInvoice     bool       `json:"requires_invoice"`
+	NIP                 *string    `json:"nip,omitempty"`
+	OriginCountry       *string    `json:"origin_country,omitempty"`
+	SplitShipment       bool       `json:"split_shipment"`
+	LeadTimeDays        *int       `json:"lead_time_days,omitempty"`
+	// Currency is what the customer saw prices in at checkout, at ExchangeRate, the
+	// price of one unit in PaymentCurrency; the amounts are in PaymentCurrency
+	Currency            string     `json:"currency"`
+	ExchangeRate        float64    `json:"exchange_rate"`
+	// IsTest marks an order placed by staff to test checkout; it is left out of reports
+	IsTest              bool       `json:"is_test"`
+	// ShippingBreakdown is what ShippingCost was made of at checkout
+	ShippingBreakdown   *ShippingBreakdown `json:"shipping_breakdown,omitempty"`
+	// ProductionUnits are the made-to-order items CreateOrder books production capacity for
+	ProductionUnits     int        `json:"-"`
+	// ShopID is the shop the order is placed in; only written by CreateOrder
+	ShopID              int        `json:"-"`
+	TrackingCarrier     *string    `json:"tracking_carrier,omitempty"`
+	TrackingNumber      *string    `json:"tracking_number,omitempty"`
+	ShippedAt           *time.Time `json:"shipped_at,omitempty"`
+	ArchivedAt          *time.Time `json:"archived_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
 }
 
 // ShippingAddress represents a shipping address
@@ -100,6 +125,9 @@ type OrderItem struct {
 	TotalPrice           float64                 `json:"total_price"`
 	MainImage            *ImageResponse          `json:"main_image,omitempty"`
 	Services             []OrderItemService      `json:"services,omitempty"`
+	Options              []ProductOptionSelection `json:"options,omitempty"`
+	Formatted            FormattedPrices         `json:"formatted,omitempty"`
+	FulfillmentStatus    string                  `json:"fulfillment_status,omitempty"`
 	CreatedAt            time.Time               `json:"created_at"`
 }
 
@@ -132,15 +160,39 @@ type AddressRequest struct {
 
 // OrderRequest represents order creation request
 type OrderRequest struct {
-	Email           string         `json:"email" binding:"required,email"`
-	Phone           string         `json:"phone" binding:"required"`
-	ShippingAddress AddressRequest `json:"shipping_address" binding:"required"`
-	BillingAddress  AddressRequest `json:"billing_address" binding:"required"`
-	SameAsShipping  bool           `json:"same_as_shipping"`
-	PaymentMethod   *string        `json:"payment_method,omitempty"`
-	Notes           *string        `json:"notes,omitempty"`
-	RequiresInvoice bool           `json:"requires_invoice"`
-	NIP             *string        `json:"nip,omitempty"`
+	Email             string             `json:"email" binding:"required,email"`
+	Phone             string             `json:"phone" binding:"required"`
+	// Each address is given in full or, for logged-in users, as the ID of a saved
+	// address; the billing address may be left out when it is the same as shipping
+	ShippingAddress   *AddressRequest    `json:"shipping_address,omitempty"`
+	BillingAddress    *AddressRequest    `json:"billing_address,omitempty"`
+	ShippingAddressID *int               `json:"shipping_address_id,omitempty"`
+	BillingAddressID  *int               `json:"billing_address_id,omitempty"`
+	SameAsShipping    bool               `json:"same_as_shipping"`
+	// SaveShippingAddress and SaveBillingAddress add an address entered at checkout
+	// to the logged-in user's address book
+	SaveShippingAddress bool             `json:"save_shipping_address"`
+	SaveBillingAddress  bool             `json:"save_billing_address"`
+	PaymentMethod     *string            `json:"payment_method,omitempty"`
+	Notes             *string            `json:"notes,omitempty"`
+	RequiresInvoice   bool               `json:"requires_invoice"`
+	NIP               *string            `json:"nip,omitempty"`
+	AcceptedDocuments []AcceptedDocument `json:"accepted_documents,omitempty" binding:"omitempty,dive"`
+	// ShippingMethodID picks an active shipping method; the first one is used when omitted
+	ShippingMethodID  *int               `json:"shipping_method_id,omitempty"`
+	// SplitShipment asks for in-stock items to ship ahead of made-to-order ones; it is
+	// ignored unless the cart mixes both
+	SplitShipment     bool               `json:"split_shipment"`
+	// ExpectedTotals are the totals shown to the customer; the order is rejected when
+	// they differ from the server's
+	ExpectedTotals    *OrderTotals       `json:"expected_totals,omitempty"`
+	// IsTest places a test order; it is only honoured for admins
+	IsTest            bool               `json:"is_test"`
+}
+
+// OrderTestRequest marks or unmarks an order as a test order
+type OrderTestRequest struct {
+	IsTest bool `json:"is_test"`
 }
 
 // OrderResponse represents order response to frontend
@@ -152,9 +204,12 @@ type OrderResponse struct {
 	Email               string                  `json:"email"`
 	Phone               string                  `json:"phone"`
 	Status              string                  `json:"status"`
+	CustomStatusID      *int                    `json:"custom_status_id,omitempty"`
+	StatusLabel         *string                 `json:"status_label,omitempty"`
 	TotalAmount         float64                 `json:"total_amount"`
 	Subtotal            float64                 `json:"subtotal"`
 	ShippingCost        float64                 `json:"shipping_cost"`
+	ShippingBreakdown   *ShippingBreakdown      `json:"shipping_breakdown,omitempty"`
 	TaxAmount           float64                 `json:"tax_amount"`
 	DiscountCodeID      *int                    `json:"discount_code_id,omitempty"`
 	DiscountAmount      float64                 `json:"discount_amount"`
@@ -164,22 +219,59 @@ type OrderResponse struct {
 	Notes               *string                 `json:"notes,omitempty"`
 	RequiresInvoice     bool                    `json:"requires_invoice"`
 	NIP                 *string                 `json:"nip,omitempty"`
+	RefundedAmount      float64                 `json:"refunded_amount"`
+	OriginCountry       *string                 `json:"origin_country,omitempty"`
+	// SplitShipment is set when the customer asked for in-stock items to ship ahead of
+	// made-to-order ones; LeadTimeDays is the estimate for the whole order at checkout
+	SplitShipment       bool                    `json:"split_shipment"`
+	LeadTimeDays        *int                    `json:"lead_time_days,omitempty"`
+	// Currency and ExchangeRate are locked at checkout; customers' views of the order
+	// show its prices converted with them
+	Currency            string                  `json:"currency"`
+	ExchangeRate        float64                 `json:"exchange_rate"`
+	IsTest              bool                    `json:"is_test"`
+	TrackingCarrier     *string                 `json:"tracking_carrier,omitempty"`
+	TrackingNumber      *string                 `json:"tracking_number,omitempty"`
+	ShippedAt           *time.Time              `json:"shipped_at,omitempty"`
+	// Tracking is set on the public order page once the order has a tracking number
+	Tracking            *OrderTracking          `json:"tracking,omitempty"`
+	ArchivedAt          *time.Time              `json:"archived_at,omitempty"`
+	// DuplicateOfOrderID is set in the admin order list on suspected duplicates
+	DuplicateOfOrderID  *int                    `json:"duplicate_of_order_id,omitempty"`
+	// EmailUndeliverable is set in admin views when the order's email address bounced
+	// or complained, so its confirmation never arrived
+	EmailUndeliverable  bool                    `json:"email_undeliverable,omitempty"`
+	// Payment is the online payment started with the order, if it is paid online
+	Payment             *PaymentIntent          `json:"payment,omitempty"`
 	ShippingAddress     *ShippingAddress        `json:"shipping_address,omitempty"`
 	BillingAddress      *BillingAddress         `json:"billing_address,omitempty"`
 	Items               []OrderItem             `json:"items,omitempty"`
+	Formatted           FormattedPrices         `json:"formatted,omitempty"`
 	CreatedAt           time.Time               `json:"created_at"`
 	UpdatedAt           time.Time               `json:"updated_at"`
 }
 
 // OrderListResponse represents paginated order list response
 type OrderListResponse struct {
-	Orders []OrderResponse `json:"orders"`
-	Total  int             `json:"total"`
-	Page   int             `json:"page"`
-	Limit  int             `json:"limit"`
+	Orders     []OrderResponse `json:"orders"`
+	Total      int             `json:"total"`
+	Page       int             `json:"page"`
+	Limit      int             `json:"limit"`
+	NextCursor string          `json:"next_cursor,omitempty"`
 }
 
 // OrderStatusUpdateRequest represents order status update request
 type OrderStatusUpdateRequest struct {
+	// Status is a core status or the code of a custom one
 	Status string `json:"status" binding:"required"`
-}
\ No newline at end of file
+	// NotifyCustomer emails the customer about the change unless set to false
+	NotifyCustomer *bool `json:"notify_customer,omitempty"`
+}
+
+// OrderItemSizeUpdateRequest represents an admin edit of an order item's size
+type OrderItemSizeUpdateRequest struct {
+	SizeID int `json:"size_id" binding:"required"`
+}
+
+// SettingLabelSenderAddress is the return address printed on shipping labels, one line per row
+const SettingLabelSenderAddress = "label_sender_address"
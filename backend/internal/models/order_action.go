@@ -0,0 +1,30 @@
+package models
+
+// Quick actions of the admin command palette, each run as one request
+const (
+	OrderActionMarkPaid    = "mark_paid"
+	OrderActionMarkShipped = "mark_shipped_with_tracking"
+	OrderActionPrintLabel  = "print_label"
+	OrderActionResendEmail = "resend_email"
+	// OrderActionCancelDuplicate cancels a suspected duplicate order and refunds what was paid for it
+	OrderActionCancelDuplicate = "cancel_duplicate"
+)
+
+// OrderActionRequest runs a quick action on an order. The tracking fields are required
+// for mark_shipped_with_tracking; NotifyCustomer defaults to true.
+type OrderActionRequest struct {
+	Action          string `json:"action" binding:"required,oneof=mark_paid mark_shipped_with_tracking print_label resend_email cancel_duplicate"`
+	TrackingCarrier string `json:"tracking_carrier" binding:"max=50"`
+	TrackingNumber  string `json:"tracking_number" binding:"max=100"`
+	NotifyCustomer  *bool  `json:"notify_customer"`
+}
+
+// OrderActionResponse is the order after a quick action; print_label returns the label PDF instead
+type OrderActionResponse struct {
+	Action    string         `json:"action"`
+	Message   string         `json:"message"`
+	EmailSent bool           `json:"email_sent"`
+	Order     *OrderResponse `json:"order"`
+	// Refund is the refund issued by cancel_duplicate for a paid order
+	Refund *Refund `json:"refund,omitempty"`
+}
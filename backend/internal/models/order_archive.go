@@ -0,0 +1,13 @@
+package models
+
+// SettingOrderArchiveAfterYears is the age in years after which finished orders are archived
+const SettingOrderArchiveAfterYears = "order_archive_after_years"
+
+// OrderArchiveStatuses are the statuses of orders that can be archived
+var OrderArchiveStatuses = []string{OrderStatusDelivered, OrderStatusCancelled}
+
+// OrderArchiveResult sums up one archival run
+type OrderArchiveResult struct {
+	Checked  int `json:"checked"`
+	Archived int `json:"archived"`
+}
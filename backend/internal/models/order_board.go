@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// OrderBoardStatuses are the columns of the order board, in workflow order
+var OrderBoardStatuses = []string{
+	OrderStatusPending,
+	OrderStatusProcessing,
+	OrderStatusShipped,
+	OrderStatusDelivered,
+	OrderStatusCancelled,
+}
+
+// OrderBoardWIPLimitSetting returns the key of the setting limiting how many orders
+// may sit in a status column; 0 or a missing setting means no limit
+func OrderBoardWIPLimitSetting(status string) string {
+	return "order_board_wip_limit_" + status
+}
+
+// OrderBoardCard is an order as the board shows it
+type OrderBoardCard struct {
+	ID            int       `json:"id"`
+	CustomerName  string    `json:"customer_name"`
+	Email         string    `json:"email"`
+	TotalAmount   float64   `json:"total_amount"`
+	Items         int       `json:"items"`
+	PaymentStatus string    `json:"payment_status"`
+	LeadTimeDays  *int      `json:"lead_time_days,omitempty"`
+	IsTest        bool      `json:"is_test"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// OrderBoardColumn is a page of the orders in a status with the column's count and
+// work in progress limit. Orders still in progress are listed oldest first, delivered
+// and cancelled ones newest first.
+type OrderBoardColumn struct {
+	Status    string           `json:"status"`
+	Count     int              `json:"count"`
+	WIPLimit  int              `json:"wip_limit"`
+	OverLimit bool             `json:"over_limit"`
+	Page      int              `json:"page"`
+	HasMore   bool             `json:"has_more"`
+	Orders    []OrderBoardCard `json:"orders"`
+}
+
+// OrderBoardResponse is the order board; archived orders are left out
+type OrderBoardResponse struct {
+	Columns []OrderBoardColumn `json:"columns"`
+	Limit   int                `json:"limit"`
+	Total   int                `json:"total"`
+}
@@ -0,0 +1,26 @@
+package models
+
+// OrderCalendarDay is the workload of one day in the shop's time zone: the orders
+// placed that day and the orders promised to ship that day by their lead time
+type OrderCalendarDay struct {
+	Date    string  `json:"date"`
+	Orders  int     `json:"orders"`
+	Revenue float64 `json:"revenue"`
+	Items   int     `json:"items"`
+	// ShipsDue counts the orders promised to ship this day, ShipsDueItems their items
+	// and Unshipped the ones among them that haven't shipped yet
+	ShipsDue      int   `json:"ships_due"`
+	ShipsDueItems int   `json:"ships_due_items"`
+	Unshipped     int   `json:"unshipped"`
+	DueOrderIDs   []int `json:"due_order_ids"`
+}
+
+// OrderCalendarResponse is the per-day workload of a month; cancelled orders are left out
+type OrderCalendarResponse struct {
+	Month    string             `json:"month"`
+	Timezone string             `json:"timezone"`
+	Orders   int                `json:"orders"`
+	Revenue  float64            `json:"revenue"`
+	ShipsDue int                `json:"ships_due"`
+	Days     []OrderCalendarDay `json:"days"`
+}
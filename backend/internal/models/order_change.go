@@ -0,0 +1,67 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Order change request type constants
+const (
+	OrderChangeTypeSizeSwap      = "size_swap"
+	OrderChangeTypeAddressChange = "address_change"
+)
+
+// Order change request status constants
+const (
+	OrderChangeStatusPending  = "pending"
+	OrderChangeStatusApproved = "approved"
+	OrderChangeStatusRejected = "rejected"
+)
+
+// OrderChangeRequest represents a customer-submitted change to a pending order
+type OrderChangeRequest struct {
+	ID           int             `json:"id"`
+	OrderID      int             `json:"order_id"`
+	Type         string          `json:"type"`
+	Payload      json.RawMessage `json:"payload"`
+	Status       string          `json:"status"`
+	CustomerNote *string         `json:"customer_note,omitempty"`
+	AdminNote    *string         `json:"admin_note,omitempty"`
+	ReviewedBy   *int            `json:"reviewed_by,omitempty"`
+	ReviewedAt   *time.Time      `json:"reviewed_at,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+}
+
+// SizeSwapPayload describes swapping an ordered item to another size of the same product
+type SizeSwapPayload struct {
+	OrderItemID int `json:"order_item_id" binding:"required"`
+	NewSizeID   int `json:"new_size_id" binding:"required"`
+}
+
+// AddressChangePayload describes replacing the shipping address of an order
+type AddressChangePayload struct {
+	ShippingAddress AddressRequest `json:"shipping_address" binding:"required"`
+}
+
+// OrderChangeRequestInput represents a customer change request; exactly one payload
+// matching Type must be provided
+type OrderChangeRequestInput struct {
+	Type          string                `json:"type" binding:"required,oneof=size_swap address_change"`
+	SizeSwap      *SizeSwapPayload      `json:"size_swap,omitempty"`
+	AddressChange *AddressChangePayload `json:"address_change,omitempty"`
+	Note          *string               `json:"note,omitempty" binding:"omitempty,max=1000"`
+}
+
+// OrderChangeReviewRequest represents an admin decision on a change request
+type OrderChangeReviewRequest struct {
+	Note *string `json:"note,omitempty" binding:"omitempty,max=1000"`
+}
+
+// OrderChangeRequestListResponse represents paginated change request list response
+type OrderChangeRequestListResponse struct {
+	Requests []OrderChangeRequest `json:"requests"`
+	Total    int                  `json:"total"`
+	Page     int                  `json:"page"`
+	Limit    int                  `json:"limit"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Settings of the duplicate order heuristic: a later order from the same email with a
+// total within the tolerance, placed within the window, is a suspected duplicate
+const (
+	SettingDuplicateOrderWindowMinutes    = "duplicate_order_window_minutes"
+	SettingDuplicateOrderTolerancePercent = "duplicate_order_total_tolerance_percent"
+	DefaultDuplicateOrderWindowMinutes    = 30
+	DefaultDuplicateOrderTolerancePercent = 5
+)
+
+// DuplicateOrderRule is the duplicate order heuristic as configured
+type DuplicateOrderRule struct {
+	WindowMinutes    int `json:"window_minutes"`
+	TolerancePercent int `json:"total_tolerance_percent"`
+}
+
+// DuplicateOrder pairs a suspected duplicate with the earlier order it repeats
+type DuplicateOrder struct {
+	OrderID           int       `json:"order_id"`
+	DuplicateOfID     int       `json:"duplicate_of_order_id"`
+	Email             string    `json:"email"`
+	Status            string    `json:"status"`
+	PaymentStatus     string    `json:"payment_status"`
+	TotalAmount       float64   `json:"total_amount"`
+	OriginalTotal     float64   `json:"original_total_amount"`
+	OriginalStatus    string    `json:"original_status"`
+	MinutesApart      float64   `json:"minutes_apart"`
+	CreatedAt         time.Time `json:"created_at"`
+	OriginalCreatedAt time.Time `json:"original_created_at"`
+}
+
+// DuplicateOrderReport lists the suspected duplicates placed in the last Days days
+type DuplicateOrderReport struct {
+	Days       int                `json:"days"`
+	Rule       DuplicateOrderRule `json:"rule"`
+	Duplicates []DuplicateOrder   `json:"duplicates"`
+}
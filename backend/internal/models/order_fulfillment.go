@@ -0,0 +1,78 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Fulfillment statuses of order items, in the order items go through them
+const (
+	ItemFulfillmentPending = "pending"
+	ItemFulfillmentPicked  = "picked"
+	ItemFulfillmentPacked  = "packed"
+	ItemFulfillmentShipped = "shipped"
+)
+
+// ItemFulfillmentStatuses lists the item fulfillment statuses in order
+var ItemFulfillmentStatuses = []string{ItemFulfillmentPending, ItemFulfillmentPicked, ItemFulfillmentPacked, ItemFulfillmentShipped}
+
+// OrderItemFulfillment is where one order item is in picking and packing. Code is
+// printed on the packing slip and identifies the item when scanned.
+type OrderItemFulfillment struct {
+	ItemID      int        `json:"item_id"`
+	Code        string     `json:"code"`
+	ProductName string     `json:"product_name"`
+	VariantName string     `json:"variant_name"`
+	SizeName    string     `json:"size_name"`
+	Quantity    int        `json:"quantity"`
+	Status      string     `json:"status"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+}
+
+// OrderFulfillment is the progress of processing an order item by item. Complete is set
+// once every item is shipped; the order itself is marked shipped with the order actions.
+type OrderFulfillment struct {
+	OrderID     int                    `json:"order_id"`
+	OrderStatus string                 `json:"order_status"`
+	Items       []OrderItemFulfillment `json:"items"`
+	Counts      map[string]int         `json:"counts"`
+	Complete    bool                   `json:"complete"`
+}
+
+// OrderItemFulfillmentRequest sets the fulfillment status of items of an order at once
+type OrderItemFulfillmentRequest struct {
+	ItemIDs []int  `json:"item_ids" binding:"required,min=1,max=200"`
+	Status  string `json:"status" binding:"required,oneof=pending picked packed shipped"`
+}
+
+// FulfillmentScanRequest is a scanned order item code. Without a status the item moves
+// on to its next status, so repeated scans pick, pack and ship it.
+type FulfillmentScanRequest struct {
+	Code   string `json:"code" binding:"required,max=50"`
+	Status string `json:"status" binding:"omitempty,oneof=pending picked packed shipped"`
+}
+
+// OrderItemCode returns the code of an order item printed on packing slips, e.g. "OI-17-42"
+func OrderItemCode(orderID, itemID int) string {
+	return fmt.Sprintf("OI-%d-%d", orderID, itemID)
+}
+
+// ParseOrderItemCode returns the order and item IDs of a scanned order item code.
+// Scanners may send it in lower case or with surrounding whitespace.
+func ParseOrderItemCode(code string) (orderID, itemID int, ok bool) {
+	parts := strings.Split(strings.ToUpper(strings.TrimSpace(code)), "-")
+	if len(parts) != 3 || parts[0] != "OI" {
+		return 0, 0, false
+	}
+	orderID, err := strconv.Atoi(parts[1])
+	if err != nil || orderID <= 0 {
+		return 0, 0, false
+	}
+	itemID, err = strconv.Atoi(parts[2])
+	if err != nil || itemID <= 0 {
+		return 0, 0, false
+	}
+	return orderID, itemID, true
+}
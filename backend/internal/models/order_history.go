@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// OrderHistoryFilter narrows a customer's order history; zero values are ignored. To is
+// exclusive and Search matches the names of the products ordered.
+type OrderHistoryFilter struct {
+	Status string
+	From   *time.Time
+	To     *time.Time
+	Search string
+}
+
+// Views of the customer order history: full orders with items and addresses, or the
+// compact summary for the account dashboard
+const (
+	OrderHistoryViewFull    = "full"
+	OrderHistoryViewSummary = "summary"
+)
+
+// OrderSummary is an order in the compact order history
+type OrderSummary struct {
+	ID            int             `json:"id"`
+	Status        string          `json:"status"`
+	PaymentStatus string          `json:"payment_status"`
+	TotalAmount   float64         `json:"total_amount"`
+	ItemCount     int             `json:"item_count"`
+	FirstItemName string          `json:"first_item_name"`
+	Currency      string          `json:"currency"`
+	ExchangeRate  float64         `json:"exchange_rate"`
+	Formatted     FormattedPrices `json:"formatted,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// OrderSummaryListResponse is a page of the compact order history with the number of
+// the customer's orders in each status, whatever the filter
+type OrderSummaryListResponse struct {
+	Orders       []OrderSummary `json:"orders"`
+	Total        int            `json:"total"`
+	Page         int            `json:"page"`
+	Limit        int            `json:"limit"`
+	StatusCounts map[string]int `json:"status_counts"`
+}
@@ -0,0 +1,111 @@
+package models
+
+import "time"
+
+// Order return status constants. Approved returns move to in_transit once the parcel
+// is on its way back and to received when it arrives.
+const (
+	OrderReturnStatusRequested = "requested"
+	OrderReturnStatusApproved  = "approved"
+	OrderReturnStatusRejected  = "rejected"
+	OrderReturnStatusInTransit = "in_transit"
+	OrderReturnStatusReceived  = "received"
+)
+
+// Return label types
+const (
+	// ReturnLabelCarrier is a prepaid label of a return shipment ordered from the carrier
+	ReturnLabelCarrier = "carrier"
+	// ReturnLabelInstructions is a printable PDF with the return address and steps
+	ReturnLabelInstructions = "instructions"
+)
+
+// Settings of carrier return shipments. Returns are shipped to the parcel locker in
+// SettingReturnTargetPoint, addressed to the first line of the label sender address;
+// without these settings customers get the instructions PDF instead.
+const (
+	SettingReturnTargetPoint   = "return_target_point"
+	SettingReturnReceiverEmail = "return_receiver_email"
+	SettingReturnReceiverPhone = "return_receiver_phone"
+)
+
+// ReturnReceiver is where carrier return shipments go: the shop's parcel locker and
+// who the carrier notifies when a return is there. Name is the first line of the
+// label sender address.
+type ReturnReceiver struct {
+	TargetPoint string
+	Name        string
+	Email       string
+	Phone       string
+}
+
+// Complete reports whether carrier return shipments can be addressed to the receiver
+func (r ReturnReceiver) Complete() bool {
+	return r.TargetPoint != "" && r.Name != "" && r.Email != "" && r.Phone != ""
+}
+
+// OrderReturn is a customer's request to send back items of an order (an RMA).
+// The label fields are set when it is approved; the tracking fields once the carrier
+// assigns a tracking number to a return shipment.
+type OrderReturn struct {
+	ID                 int               `json:"id"`
+	OrderID            int               `json:"order_id"`
+	Status             string            `json:"status"`
+	Reason             *string           `json:"reason,omitempty"`
+	AdminNote          *string           `json:"admin_note,omitempty"`
+	LabelType          *string           `json:"label_type,omitempty"`
+	Provider           *string           `json:"provider,omitempty"`
+	ProviderShipmentID *string           `json:"provider_shipment_id,omitempty"`
+	CarrierStatus      *string           `json:"carrier_status,omitempty"`
+	TrackingNumber     *string           `json:"tracking_number,omitempty"`
+	TrackingURL        *string           `json:"tracking_url,omitempty"`
+	LabelSentAt        *time.Time        `json:"label_sent_at,omitempty"`
+	ReviewedBy         *int              `json:"reviewed_by,omitempty"`
+	ReviewedAt         *time.Time        `json:"reviewed_at,omitempty"`
+	ReceivedAt         *time.Time        `json:"received_at,omitempty"`
+	Items              []OrderReturnItem `json:"items"`
+	CreatedAt          time.Time         `json:"created_at"`
+	UpdatedAt          time.Time         `json:"updated_at"`
+}
+
+// OrderReturnItem is how many units of an order item are sent back
+type OrderReturnItem struct {
+	ID          int    `json:"id"`
+	OrderItemID int    `json:"order_item_id"`
+	ProductName string `json:"product_name"`
+	VariantName string `json:"variant_name"`
+	SizeName    string `json:"size_name"`
+	Quantity    int    `json:"quantity"`
+}
+
+// OrderReturnItemRequest is one returned order item in a return request
+type OrderReturnItemRequest struct {
+	OrderItemID int `json:"order_item_id" binding:"required"`
+	Quantity    int `json:"quantity" binding:"required,min=1"`
+}
+
+// OrderReturnRequest is a customer's return request
+type OrderReturnRequest struct {
+	Items  []OrderReturnItemRequest `json:"items" binding:"required,min=1,dive"`
+	Reason *string                  `json:"reason,omitempty" binding:"omitempty,max=1000"`
+}
+
+// OrderReturnApproveRequest approves a return. ParcelTemplate is the size of a carrier
+// return shipment's parcel, small by default.
+type OrderReturnApproveRequest struct {
+	Note           *string `json:"note,omitempty" binding:"omitempty,max=1000"`
+	ParcelTemplate string  `json:"parcel_template" binding:"omitempty,oneof=small medium large"`
+}
+
+// OrderReturnRejectRequest rejects a return, telling the customer why in Note
+type OrderReturnRejectRequest struct {
+	Note *string `json:"note,omitempty" binding:"omitempty,max=1000"`
+}
+
+// OrderReturnListResponse represents paginated return list response
+type OrderReturnListResponse struct {
+	Returns []OrderReturn `json:"returns"`
+	Total   int           `json:"total"`
+	Page    int           `json:"page"`
+	Limit   int           `json:"limit"`
+}
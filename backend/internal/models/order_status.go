@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// CoreOrderStatuses are the statuses of the order workflow. Stock, production capacity,
+// discounts, webhooks and emails follow them; custom statuses only refine them.
+var CoreOrderStatuses = []string{
+	OrderStatusPending,
+	OrderStatusProcessing,
+	OrderStatusShipped,
+	OrderStatusDelivered,
+	OrderStatusCancelled,
+}
+
+// IsCoreOrderStatus reports whether status is one of CoreOrderStatuses
+func IsCoreOrderStatus(status string) bool {
+	for _, core := range CoreOrderStatuses {
+		if status == core {
+			return true
+		}
+	}
+	return false
+}
+
+// OrderCustomStatus is a status the shop defines on top of a core status, e.g.
+// "awaiting fabric" while processing. Orders set to it are in CoreStatus. Label and
+// Color are for the admin; customers see PublicLabel, or the core status when it's
+// empty.
+type OrderCustomStatus struct {
+	ID          int       `json:"id"`
+	Code        string    `json:"code"`
+	Label       string    `json:"label"`
+	PublicLabel string    `json:"public_label"`
+	Color       string    `json:"color"`
+	CoreStatus  string    `json:"core_status"`
+	SortOrder   int       `json:"sort_order"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// OrderCustomStatusRequest creates or updates a custom order status
+type OrderCustomStatusRequest struct {
+	Code        string `json:"code" binding:"required,min=1,max=64"`
+	Label       string `json:"label" binding:"required,min=1,max=100"`
+	PublicLabel string `json:"public_label" binding:"max=100"`
+	Color       string `json:"color" binding:"omitempty,hexcolor,max=7"`
+	CoreStatus  string `json:"core_status" binding:"required,oneof=pending processing shipped delivered cancelled"`
+	SortOrder   int    `json:"sort_order"`
+}
+
+// OrderStatusListResponse lists the statuses orders can be set to: the core ones and
+// the shop's custom ones
+type OrderStatusListResponse struct {
+	CoreStatuses   []string            `json:"core_statuses"`
+	CustomStatuses []OrderCustomStatus `json:"custom_statuses"`
+}
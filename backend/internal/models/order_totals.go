@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// SettingRetentionTotalsMismatchesDays limits how long rejected checkout totals are kept
+const SettingRetentionTotalsMismatchesDays = "retention_totals_mismatches_days"
+
+// TotalsMismatchCode marks orders rejected because the client's totals differ from the server's
+const TotalsMismatchCode = "totals_mismatch"
+
+// OrderItemTotal is the price of one cart item as shown to the customer
+type OrderItemTotal struct {
+	CartItemID int     `json:"cart_item_id" binding:"required"`
+	UnitPrice  float64 `json:"unit_price"`
+	TotalPrice float64 `json:"total_price"`
+}
+
+// OrderTotals are the amounts of an order. Sent with an order, they are the totals the
+// customer saw; Items is optional.
+type OrderTotals struct {
+	Subtotal       float64          `json:"subtotal"`
+	DiscountAmount float64          `json:"discount_amount"`
+	ShippingCost   float64          `json:"shipping_cost"`
+	TaxAmount      float64          `json:"tax_amount"`
+	TotalAmount    float64          `json:"total_amount"`
+	Items          []OrderItemTotal `json:"items,omitempty" binding:"omitempty,dive"`
+}
+
+// TotalsMismatch is one amount the client got wrong. Field is e.g. "subtotal" or
+// "items.unit_price"; items missing from either side are "items.missing" and "items.unknown".
+type TotalsMismatch struct {
+	Field      string  `json:"field"`
+	CartItemID *int    `json:"cart_item_id,omitempty"`
+	Expected   float64 `json:"expected"`
+	Computed   float64 `json:"computed"`
+}
+
+// TotalsMismatchLog is a rejected checkout kept for fraud analysis
+type TotalsMismatchLog struct {
+	ID         int              `json:"id"`
+	SessionID  string           `json:"session_id"`
+	UserID     *int             `json:"user_id,omitempty"`
+	Email      string           `json:"email"`
+	IPAddress  string           `json:"ip_address"`
+	Expected   OrderTotals      `json:"expected"`
+	Computed   OrderTotals      `json:"computed"`
+	Mismatches []TotalsMismatch `json:"mismatches"`
+	CreatedAt  time.Time        `json:"created_at"`
+}
+
+// TotalsMismatchListResponse is a page of rejected checkouts, newest first
+type TotalsMismatchListResponse struct {
+	Mismatches []TotalsMismatchLog `json:"mismatches"`
+	Total      int                 `json:"total"`
+	Page       int                 `json:"page"`
+	Limit      int                 `json:"limit"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// Cursor is a keyset pagination position: the sort value and ID of the last row of a
+// page. Lists return it as an opaque next_cursor that clients send back as ?cursor=
+// to get the page after it, which stays fast however deep the client pages, unlike
+// ?page=. Sort is set for lists that can be sorted several ways.
+type Cursor struct {
+	Sort  string  `json:"s,omitempty"`
+	Value *string `json:"v,omitempty"`
+	ID    int     `json:"id"`
+}
+
+// Encode returns the cursor in its opaque form
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor returned by Encode
+func DecodeCursor(value string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID <= 0 {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	return &cursor, nil
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// PasswordResetTokenTTL is how long a password reset link works
+const PasswordResetTokenTTL = time.Hour
+
+// MaxPasswordResetsPerHour limits the reset emails one account gets, however many
+// clients ask for them
+const MaxPasswordResetsPerHour = 3
+
+// SettingRetentionPasswordResetTokensDays limits how long used and expired reset tokens are kept
+const SettingRetentionPasswordResetTokensDays = "retention_password_reset_tokens_days"
+
+// ForgotPasswordRequest asks for a password reset link
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequest sets a new password with the token from a reset link
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=6"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// PaymentMethodOnline is the payment method of orders charged through the payment provider
+const PaymentMethodOnline = "online"
+
+// PaymentCurrency is the currency orders are charged in
+const PaymentCurrency = "PLN"
+
+// PaymentIntent is one attempt to collect the payment of an order at the provider
+type PaymentIntent struct {
+	ID               int       `json:"id"`
+	OrderID          int       `json:"order_id"`
+	Provider         string    `json:"provider"`
+	Reference        string    `json:"reference"`
+	ProviderIntentID *string   `json:"provider_intent_id,omitempty"`
+	Amount           float64   `json:"amount"`
+	Currency         string    `json:"currency"`
+	Status           string    `json:"status"`
+	RedirectURL      *string   `json:"redirect_url,omitempty"`
+	ClientSecret     *string   `json:"client_secret,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// SettingAttachmentMaxSizeMB limits the size of uploaded product attachments
+const SettingAttachmentMaxSizeMB = "attachment_max_size_mb"
+
+// DefaultAttachmentMaxSizeMB is used when the setting is missing or invalid
+const DefaultAttachmentMaxSizeMB = 20
+
+// AttachmentMimeType is the only accepted attachment type
+const AttachmentMimeType = "application/pdf"
+
+// ProductAttachment is a downloadable file attached to a product, e.g. a care guide
+type ProductAttachment struct {
+	ID            int       `json:"id"`
+	ProductID     int       `json:"product_id"`
+	Title         string    `json:"title"`
+	Filename      string    `json:"filename"`
+	OriginalName  string    `json:"original_name"`
+	Path          string    `json:"-"`
+	SizeBytes     int64     `json:"size_bytes"`
+	MimeType      string    `json:"mime_type"`
+	Position      int       `json:"position"`
+	DownloadCount int       `json:"download_count"`
+	UploadedBy    *int      `json:"uploaded_by,omitempty"`
+	DownloadURL   string    `json:"download_url"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// PublicProductAttachment is an attachment as shown on the product page
+type PublicProductAttachment struct {
+	ID           int    `json:"id"`
+	Title        string `json:"title"`
+	OriginalName string `json:"original_name"`
+	SizeBytes    int64  `json:"size_bytes"`
+	DownloadURL  string `json:"download_url"`
+}
+
+// ProductAttachmentUpdateRequest renames or moves an attachment
+type ProductAttachmentUpdateRequest struct {
+	Title    string `json:"title" binding:"required,max=255"`
+	Position *int   `json:"position" binding:"omitempty,min=0"`
+}
+
+// ReorderProductAttachmentsRequest sets the order of all attachments of a product
+type ReorderProductAttachmentsRequest struct {
+	AttachmentIDs []int `json:"attachment_ids" binding:"required,min=1"`
+}
@@ -0,0 +1,29 @@
+package models
+
+// Keys of the product completeness checklist
+const (
+	CompletenessHasSizes          = "has_sizes"
+	CompletenessHasDefaultVariant = "has_default_variant"
+	CompletenessHasImages         = "has_images"
+	CompletenessHasCategory       = "has_category"
+	CompletenessDescriptionLength = "description_length"
+)
+
+// CompletenessCheck is one item of the product completeness checklist
+type CompletenessCheck struct {
+	Key     string `json:"key"`
+	Label   string `json:"label"`
+	Passed  bool   `json:"passed"`
+	Current int    `json:"current"`
+	// Required is the value Current has to reach
+	Required int `json:"required"`
+}
+
+// ProductCompleteness tells how far a product is from being ready to go live
+type ProductCompleteness struct {
+	ProductID int `json:"product_id"`
+	// Score is the percentage of passed checks
+	Score    int                 `json:"score"`
+	Complete bool                `json:"complete"`
+	Checks   []CompletenessCheck `json:"checks"`
+}
@@ -0,0 +1,28 @@
+package models
+
+// BreadcrumbItem is one step of the storefront breadcrumb trail
+type BreadcrumbItem struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+// ProductCanonical holds the canonical slug and link of a product page
+type ProductCanonical struct {
+	Slug string `json:"slug"`
+	Path string `json:"path"`
+	URL  string `json:"url,omitempty"`
+}
+
+// AdjacentProduct is the previous or next product in the same category
+type AdjacentProduct struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+	Path string `json:"path"`
+}
+
+// ProductNavigation links a product page to its neighbours in the category listing
+type ProductNavigation struct {
+	Previous *AdjacentProduct `json:"previous"`
+	Next     *AdjacentProduct `json:"next"`
+}
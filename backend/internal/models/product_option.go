@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// DefaultColorOptionGroupName names the group created from a product's color variants
+const DefaultColorOptionGroupName = "Color"
+
+// ProductOptionGroup is a choice the customer makes for a product on top of its color
+// variant, e.g. fabric pattern or trim
+type ProductOptionGroup struct {
+	ID        int                  `json:"id"`
+	ProductID int                  `json:"product_id"`
+	Name      string               `json:"name"`
+	Required  bool                 `json:"required"`
+	Position  int                  `json:"position"`
+	Values    []ProductOptionValue `json:"values"`
+	CreatedAt time.Time            `json:"created_at"`
+	UpdatedAt time.Time            `json:"updated_at"`
+}
+
+// ProductOptionValue is one value of an option group; Surcharge is added to the price
+// of the item
+type ProductOptionValue struct {
+	ID        int       `json:"id"`
+	GroupID   int       `json:"group_id"`
+	Name      string    `json:"name"`
+	ImageID   *int      `json:"image_id,omitempty"`
+	ImagePath *string   `json:"image_path,omitempty"`
+	Surcharge float64   `json:"surcharge"`
+	Position  int       `json:"position"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ProductOptionGroupRequest creates or replaces an option group with its values, in
+// display order. Values with an ID are updated, values without one are added and
+// values left out are deleted.
+type ProductOptionGroupRequest struct {
+	Name     string                      `json:"name" binding:"required,max=100"`
+	Required *bool                       `json:"required"`
+	Position *int                        `json:"position" binding:"omitempty,min=0"`
+	Values   []ProductOptionValueRequest `json:"values" binding:"required,min=1,dive"`
+}
+
+// ProductOptionValueRequest is one value of a ProductOptionGroupRequest
+type ProductOptionValueRequest struct {
+	ID        *int    `json:"id"`
+	Name      string  `json:"name" binding:"required,max=100"`
+	ImageID   *int    `json:"image_id"`
+	Surcharge float64 `json:"surcharge" binding:"min=0"`
+}
+
+// ProductOptionSelection is an option value chosen for a cart or order item, copied so
+// later edits of the option don't change it
+type ProductOptionSelection struct {
+	OptionGroupID int     `json:"option_group_id"`
+	OptionValueID int     `json:"option_value_id"`
+	GroupName     string  `json:"group_name"`
+	ValueName     string  `json:"value_name"`
+	Surcharge     float64 `json:"surcharge"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// Pairing override modes
+const (
+	PairingModePin     = "pin"
+	PairingModeExclude = "exclude"
+)
+
+// Frequently bought together tuning
+const (
+	// MaxPairingsPerProduct caps how many computed suggestions are stored per product
+	MaxPairingsPerProduct = 10
+	// PairingSuggestionLimit is how many suggestions the product page shows
+	PairingSuggestionLimit = 4
+	// SettingPairingLookbackDays limits which orders are used to compute pairings
+	SettingPairingLookbackDays = "pairing_lookback_days"
+	// SettingPairingMinOrders is the minimum number of shared orders for a pair to be suggested
+	SettingPairingMinOrders = "pairing_min_orders"
+)
+
+// PairedProduct is a "frequently bought together" suggestion shown on the product page
+type PairedProduct struct {
+	ID        int      `json:"id"`
+	Name      string   `json:"name"`
+	Slug      string   `json:"slug"`
+	Path      string   `json:"path"`
+	ImagePath string   `json:"image_path"`
+	MinPrice  *float64 `json:"min_price,omitempty"`
+	Pinned    bool     `json:"pinned"`
+}
+
+// ProductPairing is a computed pair and its admin override, as shown in the admin panel
+type ProductPairing struct {
+	RelatedProductID   int        `json:"related_product_id"`
+	RelatedProductName string     `json:"related_product_name"`
+	CoOrders           int        `json:"co_orders"`
+	Score              float64    `json:"score"`
+	ComputedAt         *time.Time `json:"computed_at,omitempty"`
+	Override           *string    `json:"override,omitempty"`
+	Position           *int       `json:"position,omitempty"`
+}
+
+// ProductPairingOverrideRequest pins or excludes a suggestion for a product
+type ProductPairingOverrideRequest struct {
+	Mode     string `json:"mode" binding:"required,oneof=pin exclude"`
+	Position int    `json:"position"`
+}
+
+// PairingRecomputeResult is the outcome of recomputing all pairings
+type PairingRecomputeResult struct {
+	Pairs      int64     `json:"pairs"`
+	ComputedAt time.Time `json:"computed_at"`
+}
@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// Product review moderation statuses
+const (
+	ProductReviewStatusPending  = "pending"
+	ProductReviewStatusApproved = "approved"
+	ProductReviewStatusRejected = "rejected"
+)
+
+// ProductReview is a customer's star rating and opinion of a product they bought.
+// Only approved reviews are shown in the shop.
+type ProductReview struct {
+	ID          int        `json:"id"`
+	ProductID   int        `json:"product_id"`
+	UserID      int        `json:"user_id,omitempty"`
+	OrderID     *int       `json:"order_id,omitempty"`
+	Rating      int        `json:"rating"`
+	Title       *string    `json:"title,omitempty"`
+	Body        *string    `json:"body,omitempty"`
+	Status      string     `json:"status"`
+	AdminNote   *string    `json:"admin_note,omitempty"`
+	ModeratedBy *int       `json:"moderated_by,omitempty"`
+	ModeratedAt *time.Time `json:"moderated_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	// Related data
+	AuthorName  string `json:"author_name"`
+	ProductName string `json:"product_name,omitempty"`
+}
+
+// CreateProductReviewRequest is a customer's review of a product
+type CreateProductReviewRequest struct {
+	Rating int     `json:"rating" binding:"required,min=1,max=5"`
+	Title  *string `json:"title,omitempty" binding:"omitempty,max=255"`
+	Body   *string `json:"body,omitempty" binding:"omitempty,max=5000"`
+}
+
+// ProductReviewModerationRequest carries an optional note with an admin decision
+type ProductReviewModerationRequest struct {
+	Note *string `json:"note,omitempty" binding:"omitempty,max=1000"`
+}
+
+// ProductReviewListResponse represents a page of product reviews
+type ProductReviewListResponse struct {
+	Reviews []ProductReview `json:"reviews"`
+	Total   int             `json:"total"`
+	Page    int             `json:"page"`
+	Limit   int             `json:"limit"`
+}
+
+// ProductRating is the aggregate of a product's approved reviews
+type ProductRating struct {
+	ReviewCount   int      `json:"review_count"`
+	AverageRating *float64 `json:"average_rating"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Product revision actions
+const (
+	ProductRevisionBaseline      = "baseline"
+	ProductRevisionCreate        = "create"
+	ProductRevisionUpdate        = "update"
+	ProductRevisionSizeCreate    = "size_create"
+	ProductRevisionSizeUpdate    = "size_update"
+	ProductRevisionSizeDelete    = "size_delete"
+	ProductRevisionVariantCreate = "variant_create"
+	ProductRevisionVariantUpdate = "variant_update"
+	ProductRevisionVariantDelete = "variant_delete"
+	ProductRevisionRestore       = "restore"
+)
+
+// ProductRevision is the state of a product with its sizes and variants after an edit
+type ProductRevision struct {
+	ID             int       `json:"id"`
+	ProductID      int       `json:"product_id"`
+	Revision       int       `json:"revision"`
+	Action         string    `json:"action"`
+	RestoredFrom   *int      `json:"restored_from,omitempty"`
+	CreatedBy      *int      `json:"created_by,omitempty"`
+	CreatedByEmail *string   `json:"created_by_email,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	// Changes lists what this revision changed compared to the previous one
+	Changes []ContentFieldChange `json:"changes"`
+	// Data holds the captured rows per table; only returned for a single revision
+	Data map[string]CatalogSnapshotTable `json:"data,omitempty"`
+}
+
+// ProductRevisionListResponse is a paginated list of product revisions, newest first
+type ProductRevisionListResponse struct {
+	Revisions []ProductRevision `json:"revisions"`
+	Total     int               `json:"total"`
+	Page      int               `json:"page"`
+	Limit     int               `json:"limit"`
+}
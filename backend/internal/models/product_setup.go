@@ -0,0 +1,46 @@
+package models
+
+// ProductSetupRequest creates a product together with its sizes and variants. Nothing is
+// saved unless all of it is valid.
+type ProductSetupRequest struct {
+	Product  ProductRequest               `json:"product" binding:"required"`
+	Sizes    []ProductSetupSizeRequest    `json:"sizes" binding:"max=100,dive"`
+	Variants []ProductSetupVariantRequest `json:"variants" binding:"max=100,dive"`
+}
+
+// ProductSetupSizeRequest is a size of a ProductSetupRequest
+type ProductSetupSizeRequest struct {
+	Name          string  `json:"name" binding:"required,min=1,max=256"`
+	BasePrice     float64 `json:"base_price" binding:"required,min=0"`
+	A             float64 `json:"a" binding:"required,min=0"`
+	B             float64 `json:"b" binding:"required,min=0"`
+	C             float64 `json:"c" binding:"required,min=0"`
+	D             float64 `json:"d" binding:"required,min=0"`
+	E             float64 `json:"e" binding:"required,min=0"`
+	F             float64 `json:"f" binding:"required,min=0"`
+	UseStock      bool    `json:"use_stock"`
+	StockQuantity int     `json:"stock_quantity" binding:"min=0"`
+}
+
+// ProductSetupVariantRequest is a variant of a ProductSetupRequest
+type ProductSetupVariantRequest struct {
+	Name      string `json:"name" binding:"required,min=1,max=256"`
+	ColorID   int    `json:"color_id" binding:"required"`
+	IsDefault bool   `json:"is_default"`
+	ImageIDs  []int  `json:"image_ids" binding:"required,min=1"`
+}
+
+// FieldError is a validation error of one field of a request. Field is the path of the
+// field in the request, e.g. "variants[1].color_id".
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ProductSetupResponse is the product created by a ProductSetupRequest with its sizes
+// and variants
+type ProductSetupResponse struct {
+	ProductMutationResponse
+	Sizes    []SizeResponse           `json:"sizes"`
+	Variants []ProductVariantResponse `json:"variants"`
+}
@@ -0,0 +1,32 @@
+package models
+
+// MaxProductTagLength limits the length of a single product tag
+const MaxProductTagLength = 50
+
+// DefaultTagCloudLimit is how many tags a tag cloud shows unless asked otherwise
+const DefaultTagCloudLimit = 30
+
+// ProductTag is a tag with the number of products carrying it
+type ProductTag struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// TagCloudItem is a tag of a tag cloud; Weight runs from 1 for the least used tag to
+// 5 for the most used one, for sizing it on merchandising pages
+type TagCloudItem struct {
+	Tag    string `json:"tag"`
+	Count  int    `json:"count"`
+	Weight int    `json:"weight"`
+}
+
+// ProductTagsRequest replaces the tags of a product
+type ProductTagsRequest struct {
+	Tags []string `json:"tags" binding:"max=20,dive,max=50"`
+}
+
+// ProductTagRenameRequest renames a tag on every product; renaming to an existing tag
+// merges the two
+type ProductTagRenameRequest struct {
+	Tag string `json:"tag" binding:"required,max=50"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// SettingProductionCapacityPerDay is the number of made-to-order items the workshop can
+// make per day; 0 leaves production capacity unlimited
+const SettingProductionCapacityPerDay = "production_capacity_per_day"
+
+// ProductionHorizonDays is how far ahead made-to-order items can be booked
+const ProductionHorizonDays = 365
+
+// ProductionCapacityExhaustedCode marks a checkout rejected for lack of production capacity
+const ProductionCapacityExhaustedCode = "production_capacity_exhausted"
+
+// ProductionBlockedDay is a day the workshop makes nothing, e.g. a holiday
+type ProductionBlockedDay struct {
+	Date      string    `json:"date"`
+	Reason    *string   `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ProductionBlockedDayRequest blocks out a day, given as YYYY-MM-DD
+type ProductionBlockedDayRequest struct {
+	Date   string  `json:"date" binding:"required"`
+	Reason *string `json:"reason" binding:"omitempty,max=255"`
+}
+
+// ProductionCapacityDay is the booked and remaining capacity of one day
+type ProductionCapacityDay struct {
+	Date      string  `json:"date"`
+	Capacity  int     `json:"capacity"`
+	Booked    int     `json:"booked"`
+	Remaining int     `json:"remaining"`
+	Blocked   bool    `json:"blocked"`
+	Reason    *string `json:"reason,omitempty"`
+}
+
+// ProductionCapacityResponse is the production capacity of consecutive days in the
+// shop's time zone. Unlimited is set when no daily capacity is configured.
+type ProductionCapacityResponse struct {
+	From           string                  `json:"from"`
+	Timezone       string                  `json:"timezone"`
+	CapacityPerDay int                     `json:"capacity_per_day"`
+	Unlimited      bool                    `json:"unlimited"`
+	Days           []ProductionCapacityDay `json:"days"`
+}
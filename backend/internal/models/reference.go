@@ -0,0 +1,36 @@
+package models
+
+// SettingShippingCountries lists the ISO 3166-1 alpha-2 codes of the countries orders
+// ship to, comma separated
+const SettingShippingCountries = "shipping_countries"
+
+// ReferenceRegion is a province, state or other subdivision of a country; Code is its
+// ISO 3166-2 code, e.g. "DE-BY"
+type ReferenceRegion struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+// ReferenceCountry is what address forms need to know about a country
+type ReferenceCountry struct {
+	Code     string `json:"code"`
+	Name     string `json:"name"`
+	Ships    bool   `json:"ships"`
+	Currency string `json:"currency"`
+	Language string `json:"language"`
+	// RegionLabel names the subdivisions, e.g. "State"; RegionRequired is set when an
+	// address needs one
+	RegionLabel    string            `json:"region_label"`
+	RegionRequired bool              `json:"region_required"`
+	Regions        []ReferenceRegion `json:"regions"`
+	// PostalCodeFormat shows the format with 9 for a digit and A for a letter;
+	// PostalCodePattern is the regular expression upper cased postal codes match
+	PostalCodeFormat  string `json:"postal_code_format"`
+	PostalCodePattern string `json:"postal_code_pattern"`
+}
+
+// CountryReferenceResponse lists the countries addresses can be in
+type CountryReferenceResponse struct {
+	HomeCountry string             `json:"home_country"`
+	Countries   []ReferenceCountry `json:"countries"`
+}
@@ -0,0 +1,8 @@
+package models
+
+// SessionRevokedCode marks requests with a token issued before the user signed out
+// everywhere
+const SessionRevokedCode = "session_revoked"
+
+// SettingRetentionRefreshTokensDays limits how long revoked and expired refresh tokens are kept
+const SettingRetentionRefreshTokensDays = "retention_refresh_tokens_days"
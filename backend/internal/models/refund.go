@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+)
+
+// Refund status constants
+const (
+	RefundStatusPending   = "pending"
+	RefundStatusCompleted = "completed"
+	RefundStatusFailed    = "failed"
+)
+
+// Refund represents a full or partial refund issued for an order
+type Refund struct {
+	ID               int          `json:"id"`
+	OrderID          int          `json:"order_id"`
+	Amount           float64      `json:"amount"`
+	Reason           string       `json:"reason"`
+	Status           string       `json:"status"`
+	Provider         string       `json:"provider"`
+	ProviderRefundID *string      `json:"provider_refund_id,omitempty"`
+	FailureReason    *string      `json:"failure_reason,omitempty"`
+	CreatedBy        *int         `json:"created_by,omitempty"`
+	Items            []RefundItem `json:"items,omitempty"`
+	CreatedAt        time.Time    `json:"created_at"`
+	UpdatedAt        time.Time    `json:"updated_at"`
+}
+
+// RefundItem records which order item (and how many units) a refund covers
+type RefundItem struct {
+	ID          int       `json:"id"`
+	RefundID    int       `json:"refund_id"`
+	OrderItemID int       `json:"order_item_id"`
+	Quantity    int       `json:"quantity"`
+	Amount      float64   `json:"amount"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// RefundItemRequest represents a single refunded order item in a refund request
+type RefundItemRequest struct {
+	OrderItemID int     `json:"order_item_id" binding:"required"`
+	Quantity    int     `json:"quantity" binding:"required,min=1"`
+	Amount      float64 `json:"amount" binding:"gte=0"`
+}
+
+// RefundRequest represents an admin request to refund an order
+type RefundRequest struct {
+	Amount           float64             `json:"amount" binding:"required,gt=0"`
+	Reason           string              `json:"reason" binding:"required,min=1,max=500"`
+	Items            []RefundItemRequest `json:"items"`
+	ProviderRefundID *string             `json:"provider_refund_id,omitempty"`
+}
+
+// OrderRefundsResponse lists refunds for an order together with totals
+type OrderRefundsResponse struct {
+	Refunds          []Refund `json:"refunds"`
+	RefundedAmount   float64  `json:"refunded_amount"`
+	RefundableAmount float64  `json:"refundable_amount"`
+}
+
+// SalesReport represents aggregated sales figures for a date range
+type SalesReport struct {
+	From           *time.Time `json:"from,omitempty"`
+	To             *time.Time `json:"to,omitempty"`
+	OrderCount     int        `json:"order_count"`
+	GrossRevenue   float64    `json:"gross_revenue"`
+	RefundCount    int        `json:"refund_count"`
+	RefundedAmount float64    `json:"refunded_amount"`
+	NetRevenue     float64    `json:"net_revenue"`
+}
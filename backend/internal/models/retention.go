@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+// Retention setting keys stored in site_settings (value in days, 0 disables the rule)
+const (
+	SettingRetentionGuestOrdersDays    = "retention_guest_orders_days"
+	SettingRetentionAbandonedCartsDays = "retention_abandoned_carts_days"
+)
+
+// RetentionRuleResult describes what a retention rule purged, or would purge in a dry run
+type RetentionRuleResult struct {
+	Rule          string     `json:"rule"`
+	Description   string     `json:"description"`
+	SettingKey    string     `json:"setting_key"`
+	Days          int        `json:"days"`
+	Enabled       bool       `json:"enabled"`
+	Cutoff        *time.Time `json:"cutoff,omitempty"`
+	AffectedCount int64      `json:"affected_count"`
+}
+
+// RetentionReport is the outcome of evaluating all retention rules
+type RetentionReport struct {
+	DryRun  bool                  `json:"dry_run"`
+	RunAt   time.Time             `json:"run_at"`
+	Results []RetentionRuleResult `json:"results"`
+}
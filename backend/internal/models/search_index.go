@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// SearchReindexStatus is the progress of the last search index rebuild and the size
+// of the index
+type SearchReindexStatus struct {
+	Running         bool       `json:"running"`
+	Total           int        `json:"total"`
+	Indexed         int        `json:"indexed"`
+	StartedAt       *time.Time `json:"started_at,omitempty"`
+	FinishedAt      *time.Time `json:"finished_at,omitempty"`
+	Error           string     `json:"error,omitempty"`
+	IndexedProducts int        `json:"indexed_products"`
+	LastIndexedAt   *time.Time `json:"last_indexed_at,omitempty"`
+}
@@ -17,4 +17,20 @@ type UpdateSettingRequest struct {
 
 type SiteSettingsResponse struct {
 	Settings []SiteSetting `json:"settings"`
-}
\ No newline at end of file
+}
+
+// Checkout can be switched off while the shop stays browsable, e.g. during stock-taking
+const (
+	SettingCheckoutDisabled        = "checkout_disabled"
+	SettingCheckoutDisabledMessage = "checkout_disabled_message"
+	// CheckoutDisabledCode marks requests rejected because checkout is switched off
+	CheckoutDisabledCode = "checkout_disabled"
+	// DefaultCheckoutDisabledMessage is shown when checkout_disabled_message is empty
+	DefaultCheckoutDisabledMessage = "Ordering is temporarily unavailable. Please try again later."
+)
+
+// CheckoutStatus tells the storefront whether orders can be placed
+type CheckoutStatus struct {
+	Disabled bool   `json:"checkout_disabled"`
+	Message  string `json:"checkout_message,omitempty"`
+}
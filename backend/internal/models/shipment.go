@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// Shipment is a parcel ordered from a carrier for an order. TrackingNumber and
+// TrackingURL are set once the carrier assigns the tracking number.
+type Shipment struct {
+	ID                 int       `json:"id"`
+	OrderID            int       `json:"order_id"`
+	Provider           string    `json:"provider"`
+	ProviderShipmentID string    `json:"provider_shipment_id"`
+	Service            string    `json:"service"`
+	TargetPoint        *string   `json:"target_point,omitempty"`
+	Status             string    `json:"status"`
+	TrackingNumber     *string   `json:"tracking_number,omitempty"`
+	TrackingURL        *string   `json:"tracking_url,omitempty"`
+	CreatedBy          *int      `json:"created_by,omitempty"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// ShipmentRequest orders a shipment for an order. Locker shipments need the target
+// point and a parcel template, courier shipments the parcel's weight and dimensions.
+// Replace creates a new shipment for an order that already has one, e.g. after the
+// previous one was cancelled at the carrier.
+type ShipmentRequest struct {
+	Service        string  `json:"service" binding:"required,oneof=locker courier"`
+	TargetPoint    string  `json:"target_point" binding:"max=50"`
+	ParcelTemplate string  `json:"parcel_template" binding:"omitempty,oneof=small medium large"`
+	WeightKg       float64 `json:"weight_kg" binding:"min=0,max=100"`
+	LengthCm       float64 `json:"length_cm" binding:"min=0,max=300"`
+	WidthCm        float64 `json:"width_cm" binding:"min=0,max=300"`
+	HeightCm       float64 `json:"height_cm" binding:"min=0,max=300"`
+	// Insure declares the order total as the parcel's value
+	Insure  bool `json:"insure"`
+	Replace bool `json:"replace"`
+}
+
+// OrderTracking is where customers follow their parcel
+type OrderTracking struct {
+	Carrier string  `json:"carrier"`
+	Number  string  `json:"number"`
+	URL     *string `json:"url,omitempty"`
+	Status  *string `json:"status,omitempty"`
+}
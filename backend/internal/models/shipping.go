@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+// SettingOversizeShippingFee is the flat fee added to the shipping of an order with
+// at least one oversized product, such as the largest dog beds
+const (
+	SettingOversizeShippingFee = "oversize_shipping_fee"
+	DefaultOversizeShippingFee = 49.0
+)
+
+// ProductShipping is how a product affects shipping: oversized products need a
+// courier for large parcels, and ShippingSurcharge is added per unit ordered
+type ProductShipping struct {
+	ProductID         int     `json:"product_id"`
+	Oversize          bool    `json:"oversize"`
+	ShippingSurcharge float64 `json:"shipping_surcharge"`
+}
+
+// ProductShippingRequest sets how a product affects shipping
+type ProductShippingRequest struct {
+	Oversize          bool    `json:"oversize"`
+	ShippingSurcharge float64 `json:"shipping_surcharge" binding:"min=0,max=10000"`
+}
+
+// ShippingSurcharge is the surcharge of one cart or order line
+type ShippingSurcharge struct {
+	ProductID     int     `json:"product_id"`
+	ProductName   string  `json:"product_name"`
+	Quantity      int     `json:"quantity"`
+	UnitSurcharge float64 `json:"unit_surcharge"`
+	Amount        float64 `json:"amount"`
+}
+
+// ShippingBreakdown is what the shipping cost of a cart or order is made of. MethodID
+// and Method name the shipping method BaseCost is the price of; both are empty while
+// no method is set up.
+type ShippingBreakdown struct {
+	MethodID    *int                `json:"method_id,omitempty"`
+	Method      string              `json:"method,omitempty"`
+	BaseCost    float64             `json:"base_cost"`
+	OversizeFee float64             `json:"oversize_fee"`
+	Surcharges  []ShippingSurcharge `json:"surcharges"`
+	Total       float64             `json:"total"`
+}
+
+// ShippingMethod is a way of delivering orders the customer picks at checkout. Orders
+// whose discounted subtotal reaches FreeAbove ship without its Price; product
+// surcharges and the oversize fee still apply.
+type ShippingMethod struct {
+	ID          int             `json:"id"`
+	Name        string          `json:"name"`
+	Description *string         `json:"description,omitempty"`
+	Price       float64         `json:"price"`
+	FreeAbove   *float64        `json:"free_above,omitempty"`
+	Active      bool            `json:"active"`
+	SortOrder   int             `json:"sort_order"`
+	Formatted   FormattedPrices `json:"formatted,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// ShippingMethodRequest represents shipping method create/update input
+type ShippingMethodRequest struct {
+	Name        string   `json:"name" binding:"required,min=1,max=100"`
+	Description *string  `json:"description,omitempty" binding:"omitempty,max=500"`
+	Price       float64  `json:"price" binding:"min=0,max=10000"`
+	FreeAbove   *float64 `json:"free_above,omitempty" binding:"omitempty,min=0"`
+	Active      bool     `json:"active"`
+	SortOrder   int      `json:"sort_order"`
+}
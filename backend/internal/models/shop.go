@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// DefaultShopID is the shop created with the schema. It serves every host no other shop
+// claims, and the shop_id of the catalog and order tables defaults to it, so a single
+// shop deployment never has to know shops exist.
+const DefaultShopID = 1
+
+// Shop is a brand run on this backend, with its own domains, sender address and theme.
+// Products, categories, discount codes and orders carry the shop_id of the shop they
+// belong to. Orders record the shop they were placed in; catalog queries don't filter
+// by shop yet, so until they do every shop sells the default shop's catalog.
+type Shop struct {
+	ID          int               `json:"id"`
+	Slug        string            `json:"slug"`
+	Name        string            `json:"name"`
+	Domains     []string          `json:"domains"`
+	SenderEmail string            `json:"sender_email"`
+	SenderName  *string           `json:"sender_name,omitempty"`
+	Theme       map[string]string `json:"theme"`
+	IsDefault   bool              `json:"is_default"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+// ShopRequest represents shop create/update input. Domains are host names without
+// scheme or port, e.g. "shop.example.com"; theme tokens are free-form design values
+// such as {"primary_color": "#1f2937"}.
+type ShopRequest struct {
+	Slug        string            `json:"slug" binding:"required,min=1,max=50"`
+	Name        string            `json:"name" binding:"required,min=1,max=255"`
+	Domains     []string          `json:"domains"`
+	SenderEmail string            `json:"sender_email" binding:"required,email"`
+	SenderName  *string           `json:"sender_name,omitempty"`
+	Theme       map[string]string `json:"theme"`
+}
+
+// ShopProfile is the public part of a shop the storefront renders with
+type ShopProfile struct {
+	Slug   string            `json:"slug"`
+	Name   string            `json:"name"`
+	Domain *string           `json:"domain"`
+	Theme  map[string]string `json:"theme"`
+}
+
+// Profile returns the public profile of the shop; its first domain is the canonical one
+func (s *Shop) Profile() ShopProfile {
+	profile := ShopProfile{Slug: s.Slug, Name: s.Name, Theme: s.Theme}
+	if len(s.Domains) > 0 {
+		profile.Domain = &s.Domains[0]
+	}
+	if profile.Theme == nil {
+		profile.Theme = map[string]string{}
+	}
+	return profile
+}
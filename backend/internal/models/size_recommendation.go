@@ -0,0 +1,26 @@
+package models
+
+// SizeRecommendationRequest holds a pet's measurements keyed by size chart dimension (a-f)
+type SizeRecommendationRequest struct {
+	ProductID    int                `json:"product_id" binding:"required"`
+	Measurements map[string]float64 `json:"measurements" binding:"required"`
+}
+
+// SizeComparison compares one measurement with the recommended size
+type SizeComparison struct {
+	Dimension   string  `json:"dimension"`
+	Measurement float64 `json:"measurement"`
+	SizeValue   float64 `json:"size_value"`
+	Difference  float64 `json:"difference"`
+}
+
+// SizeRecommendationResponse is the best fitting size of a product for the measurements
+type SizeRecommendationResponse struct {
+	ProductID             int              `json:"product_id"`
+	Size                  SizeResponse     `json:"size"`
+	Fits                  bool             `json:"fits"`
+	Confidence            string           `json:"confidence"`
+	ConstrainingDimension string           `json:"constraining_dimension"`
+	Comparisons           []SizeComparison `json:"comparisons"`
+	UnmatchedDimensions   []string         `json:"unmatched_dimensions"`
+}
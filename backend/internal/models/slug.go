@@ -0,0 +1,16 @@
+package models
+
+// Entity types slugs are generated for
+const (
+	SlugTypeCategory = "category"
+	SlugTypeProduct  = "product"
+)
+
+// SlugPreviewResponse is the slug a category or product would get when saved without one
+type SlugPreviewResponse struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+	// Base is the slug made from the name; Slug differs from it when Base is taken
+	Base string `json:"base"`
+	Slug string `json:"slug"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// Component and overall states shown on the public status page
+const (
+	StatusOperational = "operational"
+	StatusDegraded    = "degraded"
+	StatusOutage      = "outage"
+)
+
+// Settings holding the states admins report for components the API can't check itself,
+// and the incident notes as a JSON array of StatusIncident
+const (
+	SettingStatusPayments  = "status_payments"
+	SettingStatusShipping  = "status_shipping"
+	SettingStatusIncidents = "status_incidents"
+)
+
+// StatusIncidentDays and MaxStatusIncidents limit the incidents shown on the status page
+const (
+	StatusIncidentDays = 30
+	MaxStatusIncidents = 10
+)
+
+// StatusComponent is the state of one part of the shop
+type StatusComponent struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+// StatusIncident is an incident note written by an admin; ResolvedAt is empty while it lasts
+type StatusIncident struct {
+	Title      string     `json:"title"`
+	Message    string     `json:"message"`
+	Status     string     `json:"status"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+}
+
+// StatusResponse is the data of the public status page
+type StatusResponse struct {
+	Status     string            `json:"status"`
+	Components []StatusComponent `json:"components"`
+	Incidents  []StatusIncident  `json:"incidents"`
+	CheckedAt  time.Time         `json:"checked_at"`
+}
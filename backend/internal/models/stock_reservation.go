@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// SettingStockReservationTTLMinutes is how long stock reserved for a checkout is held
+// before the sweeper returns it
+const (
+	SettingStockReservationTTLMinutes = "stock_reservation_ttl_minutes"
+	DefaultStockReservationTTLMinutes = 15
+)
+
+// StockReservationSweep is what one run of the reservation sweeper released
+type StockReservationSweep struct {
+	Swept            int `json:"swept"`
+	ReleasedQuantity int `json:"released_quantity"`
+}
+
+// StockReservationStats are the reservations currently held and the sweeper's totals
+// since the server started
+type StockReservationStats struct {
+	ActiveReservations int        `json:"active_reservations"`
+	ReservedQuantity   int        `json:"reserved_quantity"`
+	ExpiredPending     int        `json:"expired_pending"`
+	SweeperRuns        int64      `json:"sweeper_runs"`
+	Swept              int64      `json:"swept"`
+	ReleasedQuantity   int64      `json:"released_quantity"`
+	LastSweepAt        *time.Time `json:"last_sweep_at,omitempty"`
+	LastSwept          int        `json:"last_swept"`
+}
@@ -0,0 +1,43 @@
+package models
+
+// StorefrontContext holds the defaults the storefront pre-selects for a visitor and
+// everything else it needs to render the first page
+type StorefrontContext struct {
+	DetectedCountry     *string `json:"detected_country"`
+	ShippingCountryCode string  `json:"shipping_country_code"`
+	ShippingCountry     string  `json:"shipping_country"`
+	Currency            string  `json:"currency"`
+	Language            string  `json:"language"`
+
+	// Currencies are the currencies prices can be shown in, the base currency first
+	Currencies []string `json:"currencies"`
+
+	// Locale combines the language and shipping country, e.g. "en-DE"
+	Locale         string         `json:"locale"`
+	URLPrefix      string         `json:"url_prefix"`
+	CurrencyFormat CurrencyFormat `json:"currency_format"`
+
+	MaintenanceMode bool                  `json:"maintenance_mode"`
+	Checkout        CheckoutStatus        `json:"checkout"`
+	Features        map[string]bool       `json:"features"`
+	Captcha         CaptchaConfigResponse `json:"captcha"`
+	Cart            CartSummary           `json:"cart"`
+
+	// Shop is the brand serving the request's host
+	Shop *ShopProfile `json:"shop"`
+}
+
+// FormattedPrices are the display strings of the prices of a storefront response in the
+// requester's language, keyed by the JSON name of the price, e.g. {"total_price": "129,00 zł"}.
+// Clients show them as is so every platform renders the same amounts.
+type FormattedPrices map[string]string
+
+// CurrencyFormat describes how prices are displayed; SymbolPosition is "before" or "after"
+type CurrencyFormat struct {
+	Code               string `json:"code"`
+	Symbol             string `json:"symbol"`
+	Decimals           int    `json:"decimals"`
+	DecimalSeparator   string `json:"decimal_separator"`
+	ThousandsSeparator string `json:"thousands_separator"`
+	SymbolPosition     string `json:"symbol_position"`
+}
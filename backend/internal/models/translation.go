@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Kinds of catalog content that can be translated
+const (
+	TranslationProduct           = "product"
+	TranslationCategory          = "category"
+	TranslationAdditionalService = "additional_service"
+)
+
+// Translation is catalog content in one language other than the one it is written in.
+// Categories only have a name and services no short description; fields left empty
+// fall back to the original text.
+type Translation struct {
+	Language         string    `json:"language"`
+	Name             string    `json:"name"`
+	ShortDescription string    `json:"short_description,omitempty"`
+	Description      string    `json:"description,omitempty"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+type TranslationRequest struct {
+	Name             string `json:"name" binding:"required,min=1,max=256"`
+	ShortDescription string `json:"short_description" binding:"max=512"`
+	Description      string `json:"description"`
+}
+
+type TranslationListResponse struct {
+	// SourceLanguage is the language the content itself is written in
+	SourceLanguage string        `json:"source_language"`
+	Translations   []Translation `json:"translations"`
+}
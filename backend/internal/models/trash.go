@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// Entity types that go to the trash when deleted
+const (
+	TrashEntityProduct           = "product"
+	TrashEntityCategory          = "category"
+	TrashEntityColor             = "color"
+	TrashEntityAdditionalService = "additional_service"
+	TrashEntityClientReview      = "client_review"
+)
+
+// SettingRetentionTrashDays is how long deleted items stay restorable
+const SettingRetentionTrashDays = "retention_trash_days"
+
+// DefaultTrashRetentionDays is used when the setting is missing
+const DefaultTrashRetentionDays = 30
+
+// TrashItem is a deleted record kept for restore
+type TrashItem struct {
+	ID         int        `json:"id"`
+	EntityType string     `json:"entity_type"`
+	EntityID   int        `json:"entity_id"`
+	Label      string     `json:"label"`
+	DeletedBy  *int       `json:"deleted_by,omitempty"`
+	DeletedAt  time.Time  `json:"deleted_at"`
+	PurgeAt    *time.Time `json:"purge_at,omitempty"`
+	// Data holds the captured rows per table; only returned for a single item
+	Data map[string]CatalogSnapshotTable `json:"data,omitempty"`
+}
+
+// TrashListResponse is a paginated list of trash items
+type TrashListResponse struct {
+	Items []TrashItem `json:"items"`
+	Total int         `json:"total"`
+	Page  int         `json:"page"`
+	Limit int         `json:"limit"`
+}
+
+// TrashRestoreResult reports the restored entity
+type TrashRestoreResult struct {
+	EntityType string `json:"entity_type"`
+	EntityID   int    `json:"entity_id"`
+	Rows       int64  `json:"rows"`
+}
@@ -12,23 +12,31 @@ type User struct {
 	Role         string    `json:"role"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
+	// DeletedAt is set on deleted users, who keep their orders and content but can't log in
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// TokenVersion is embedded in the user's tokens; signing out everywhere increments it
+	TokenVersion int `json:"-"`
 }
 
 type UserRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
-	Role     string `json:"role,omitempty"`
+	Email             string             `json:"email" binding:"required,email"`
+	Password          string             `json:"password" binding:"required,min=6"`
+	Role              string             `json:"role,omitempty"`
+	AcceptedDocuments []AcceptedDocument `json:"accepted_documents,omitempty" binding:"omitempty,dive"`
 }
 
 type LoginRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required"`
+	Email             string             `json:"email" binding:"required,email"`
+	Password          string             `json:"password" binding:"required"`
+	AcceptedDocuments []AcceptedDocument `json:"accepted_documents,omitempty" binding:"omitempty,dive"`
 }
 
 type AuthResponse struct {
 	User         User   `json:"user"`
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
+	// CartMerge is set when logging in moved items from the user's other carts
+	CartMerge *CartMergeResult `json:"cart_merge,omitempty"`
 }
 
 type RefreshRequest struct {
@@ -38,8 +46,21 @@ type RefreshRequest struct {
 const (
 	RoleClient = "client"
 	RoleAdmin  = "admin"
+	// RoleEditor can propose content changes that an admin approves
+	RoleEditor = "editor"
+	// RoleSystem is the role of the system account, which can't log in
+	RoleSystem = "system"
 )
 
+// SystemUserEmail is the email of the system account that takes over the images of
+// deleted users
+const SystemUserEmail = "system@notsofluffy.invalid"
+
+// IsStaffRole reports whether the role may use the admin panel
+func IsStaffRole(role string) bool {
+	return role == RoleAdmin || role == RoleEditor
+}
+
 type Image struct {
 	ID           int       `json:"id"`
 	Filename     string    `json:"filename"`
@@ -48,6 +69,11 @@ type Image struct {
 	SizeBytes    int64     `json:"size_bytes"`
 	MimeType     string    `json:"mime_type"`
 	UploadedBy   int       `json:"uploaded_by"`
+	FocalX       float64   `json:"focal_x,omitempty"`
+	FocalY       float64   `json:"focal_y,omitempty"`
+	Crops        map[string]ImageCrop `json:"crops,omitempty"`
+	AltText      string    `json:"alt_text,omitempty"`
+	Title        string    `json:"title,omitempty"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
@@ -65,28 +91,71 @@ type ImageResponse struct {
 	SizeBytes    int64  `json:"size_bytes"`
 	MimeType     string `json:"mime_type"`
 	UploadedBy   int    `json:"uploaded_by"`
+	AltText      string `json:"alt_text"`
+	Title        string `json:"title,omitempty"`
+	FocalPoint   *ImageFocalPoint     `json:"focal_point,omitempty"`
+	Crops        map[string]ImageCrop `json:"crops,omitempty"`
+	Variants     map[string]string    `json:"variants,omitempty"`
+	Sizes        []ImageVariant       `json:"sizes,omitempty"`
+	Tags         []string             `json:"tags,omitempty"`
+	ReferenceCount *int               `json:"reference_count,omitempty"`
 	CreatedAt    string `json:"created_at"`
 	UpdatedAt    string `json:"updated_at"`
 }
 
 type ImageListResponse struct {
-	Images []ImageResponse `json:"images"`
-	Total  int             `json:"total"`
-	Page   int             `json:"page"`
-	Limit  int             `json:"limit"`
-}
+	Images     []ImageResponse `json:"images"`
+	Total      int             `json:"total"`
+	Page       int             `json:"page"`
+	Limit      int             `json:"limit"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+// AdminUserSummary is a user in the admin list with their activity. Order count and
+// lifetime value (order totals less completed refunds) leave out cancelled orders.
+type AdminUserSummary struct {
+	User
+	LastLoginAt   *time.Time `json:"last_login_at,omitempty"`
+	OrderCount    int        `json:"order_count"`
+	LifetimeValue float64    `json:"lifetime_value"`
+	LastOrderAt   *time.Time `json:"last_order_at,omitempty"`
+	// EmailUndeliverable is set when the user's email address bounced or complained
+	EmailUndeliverable bool `json:"email_undeliverable,omitempty"`
+}
+
+// UserListFilter narrows the admin user list; zero values are ignored. CreatedTo is exclusive.
+type UserListFilter struct {
+	Search      string
+	Role        string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	HasOrders   *bool
+	// Deleted lists the deleted users instead of the active ones
+	Deleted bool
+	// Sort is one of the UserSort constants
+	Sort string
+}
+
+// Admin user list sort orders, newest registration first by default
+const (
+	UserSortCreated       = "created_at"
+	UserSortLastLogin     = "last_login"
+	UserSortOrderCount    = "order_count"
+	UserSortLifetimeValue = "lifetime_value"
+)
 
 type UserListResponse struct {
-	Users []User `json:"users"`
+	Users []AdminUserSummary `json:"users"`
 	Total int    `json:"total"`
 	Page  int    `json:"page"`
 	Limit int    `json:"limit"`
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 type AdminUserRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password,omitempty" binding:"min=6"`
-	Role     string `json:"role" binding:"required,oneof=client admin"`
+	Role     string `json:"role" binding:"required,oneof=client admin editor"`
 }
 
 type Category struct {
@@ -110,11 +179,14 @@ type CategoryWithImage struct {
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	Image     *ImageResponse `json:"image,omitempty"`
+	// ProductCount is the number of products visible in the web shop
+	ProductCount int         `json:"product_count"`
 }
 
 type CategoryRequest struct {
 	Name      string `json:"name" binding:"required,min=1,max=256"`
-	Slug      string `json:"slug" binding:"required,min=1,max=256"`
+	// Slug is generated from the name when left empty on create and kept on update
+	Slug      string `json:"slug" binding:"omitempty,max=256"`
 	ImageID   *int   `json:"image_id"`
 	Active    bool   `json:"active"`
 	ChartOnly bool   `json:"chart_only"`
@@ -130,6 +202,8 @@ type CategoryResponse struct {
 	CreatedAt string         `json:"created_at"`
 	UpdatedAt string         `json:"updated_at"`
 	Image     *ImageResponse `json:"image,omitempty"`
+	// ProductCount is only filled in by the public category list
+	ProductCount *int        `json:"product_count,omitempty"`
 }
 
 type CategoryListResponse struct {
@@ -258,6 +332,7 @@ type AdditionalServiceListResponse struct {
 type Product struct {
 	ID               int       `json:"id"`
 	Name             string    `json:"name"`
+	Slug             string    `json:"slug"`
 	ShortDescription string    `json:"short_description"`
 	Description      string    `json:"description"`
 	MaterialID       *int      `json:"material_id"`
@@ -265,16 +340,40 @@ type Product struct {
 	CategoryID       *int      `json:"category_id"`
 	CreatedAt        time.Time `json:"created_at"`
 	UpdatedAt        time.Time `json:"updated_at"`
+	// Channels is nil on update when the sales channel flags are left unchanged
+	Channels *ProductChannels `json:"channels,omitempty"`
+}
+
+// Sales channel constants
+const (
+	SalesChannelWeb         = "web"
+	SalesChannelMarketplace = "marketplace"
+	SalesChannelB2B         = "b2b"
+)
+
+// ProductChannels controls where a product is offered: the public web shop,
+// marketplace feeds (Allegro) and the B2B wholesale catalog
+type ProductChannels struct {
+	Web         bool `json:"web"`
+	Marketplace bool `json:"marketplace"`
+	B2B         bool `json:"b2b"`
+}
+
+// DefaultProductChannels makes a new product available in every channel
+func DefaultProductChannels() ProductChannels {
+	return ProductChannels{Web: true, Marketplace: true, B2B: true}
 }
 
 type ProductWithRelations struct {
 	ID                 int                           `json:"id"`
 	Name               string                        `json:"name"`
+	Slug               string                        `json:"slug"`
 	ShortDescription   string                        `json:"short_description"`
 	Description        string                        `json:"description"`
 	MaterialID         *int                          `json:"material_id"`
 	MainImageID        int                           `json:"main_image_id"`
 	CategoryID         *int                          `json:"category_id"`
+	Channels           ProductChannels               `json:"channels"`
 	CreatedAt          time.Time                     `json:"created_at"`
 	UpdatedAt          time.Time                     `json:"updated_at"`
 	Material           *MaterialResponse             `json:"material,omitempty"`
@@ -287,6 +386,8 @@ type ProductWithRelations struct {
 
 type ProductRequest struct {
 	Name                   string `json:"name" binding:"required,min=1,max=256"`
+	// Slug is generated from the name when left empty on create
+	Slug                   string `json:"slug" binding:"omitempty,max=256"`
 	ShortDescription       string `json:"short_description" binding:"required,min=1,max=512"`
 	Description            string `json:"description" binding:"required,min=1"`
 	MaterialID             *int   `json:"material_id"`
@@ -294,16 +395,19 @@ type ProductRequest struct {
 	CategoryID             *int   `json:"category_id"`
 	ImageIDs               []int  `json:"image_ids" binding:"required,min=1"`
 	AdditionalServiceIDs   []int  `json:"additional_service_ids"`
+	Channels               *ProductChannels `json:"channels,omitempty"`
 }
 
 type ProductResponse struct {
 	ID                 int                           `json:"id"`
 	Name               string                        `json:"name"`
+	Slug               string                        `json:"slug"`
 	ShortDescription   string                        `json:"short_description"`
 	Description        string                        `json:"description"`
 	MaterialID         *int                          `json:"material_id"`
 	MainImageID        int                           `json:"main_image_id"`
 	CategoryID         *int                          `json:"category_id"`
+	Channels           *ProductChannels              `json:"channels,omitempty"`
 	CreatedAt          string                        `json:"created_at"`
 	UpdatedAt          string                        `json:"updated_at"`
 	Material           *MaterialResponse             `json:"material,omitempty"`
@@ -312,13 +416,33 @@ type ProductResponse struct {
 	Images             []ImageResponse               `json:"images"`
 	AdditionalServices []AdditionalServiceResponse   `json:"additional_services"`
 	MinPrice           float64                       `json:"min_price"`
+	Tags               []string                      `json:"tags,omitempty"`
+	Formatted          FormattedPrices               `json:"formatted,omitempty"`
+	// Rating is set in the shop for products with approved customer reviews
+	Rating *ProductRating `json:"rating,omitempty"`
 }
 
 type ProductListResponse struct {
-	Products []ProductResponse `json:"products"`
-	Total    int               `json:"total"`
-	Page     int               `json:"page"`
-	Limit    int               `json:"limit"`
+	Products   []ProductResponse `json:"products"`
+	Total      int               `json:"total"`
+	Page       int               `json:"page"`
+	Limit      int               `json:"limit"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
+// ChannelFeedItem is a product with its sizes as exported to a sales channel
+type ChannelFeedItem struct {
+	Product ProductResponse `json:"product"`
+	Sizes   []SizeResponse  `json:"sizes"`
+}
+
+// ChannelFeedResponse is a paginated product feed for a sales channel
+type ChannelFeedResponse struct {
+	Channel string            `json:"channel"`
+	Items   []ChannelFeedItem `json:"items"`
+	Total   int               `json:"total"`
+	Page    int               `json:"page"`
+	Limit   int               `json:"limit"`
 }
 
 type Size struct {
@@ -390,6 +514,7 @@ type SizeResponse struct {
 	CreatedAt        string          `json:"created_at"`
 	UpdatedAt        string          `json:"updated_at"`
 	Product          ProductResponse `json:"product"`
+	Formatted        FormattedPrices `json:"formatted,omitempty"`
 }
 
 type SizeListResponse struct {
@@ -0,0 +1,28 @@
+package models
+
+// Codes of validation warnings
+const (
+	WarningMainImageTooSmall   = "main_image_too_small"
+	WarningImageTooSmall       = "image_too_small"
+	WarningImageMissingAltText = "image_missing_alt_text"
+	WarningNoSizes             = "no_sizes"
+	WarningOutOfStock          = "out_of_stock"
+	WarningNoVariants          = "no_variants"
+	WarningNoDefaultVariant    = "no_default_variant"
+	WarningNoCategory          = "no_category"
+	WarningNoChannels          = "no_channels"
+)
+
+// ValidationWarning is a non-fatal issue found after saving a product or variant; the
+// change is stored, but the admin should probably fix it
+type ValidationWarning struct {
+	Code    string `json:"code"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ProductMutationResponse is the admin response to creating or updating a product
+type ProductMutationResponse struct {
+	ProductResponse
+	Warnings []ValidationWarning `json:"warnings"`
+}
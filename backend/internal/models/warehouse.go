@@ -0,0 +1,159 @@
+package models
+
+import (
+	"time"
+)
+
+// Warehouse allocation strategy constants
+const (
+	AllocationStrategyPriority = "priority"
+	AllocationStrategyNearest  = "nearest"
+
+	SettingWarehouseAllocationStrategy = "warehouse_allocation_strategy"
+)
+
+// Stock movement type constants
+const (
+	StockMovementAdjustment      = "adjustment"
+	StockMovementTransfer        = "transfer"
+	StockMovementOrderAllocation = "order_allocation"
+)
+
+// Warehouse represents a location holding stock (sewing studio, storage)
+type Warehouse struct {
+	ID         int       `json:"id"`
+	Code       string    `json:"code"`
+	Name       string    `json:"name"`
+	City       *string   `json:"city,omitempty"`
+	PostalCode *string   `json:"postal_code,omitempty"`
+	Country    string    `json:"country"`
+	Priority   int       `json:"priority"`
+	IsActive   bool      `json:"is_active"`
+	IsDefault  bool      `json:"is_default"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// WarehouseRequest represents warehouse create/update input
+type WarehouseRequest struct {
+	Code       string  `json:"code" binding:"required,min=1,max=20"`
+	Name       string  `json:"name" binding:"required,min=1,max=255"`
+	City       *string `json:"city,omitempty"`
+	PostalCode *string `json:"postal_code,omitempty"`
+	Country    string  `json:"country" binding:"required"`
+	Priority   int     `json:"priority"`
+	IsActive   bool    `json:"is_active"`
+	IsDefault  bool    `json:"is_default"`
+}
+
+// WarehouseStock is the quantity of a size held in one warehouse
+type WarehouseStock struct {
+	WarehouseID   int       `json:"warehouse_id"`
+	WarehouseCode string    `json:"warehouse_code"`
+	WarehouseName string    `json:"warehouse_name"`
+	SizeID        int       `json:"size_id"`
+	Quantity      int       `json:"quantity"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// WarehouseStockRequest sets the quantity of a size in a warehouse
+type WarehouseStockRequest struct {
+	SizeID   int     `json:"size_id" binding:"required"`
+	Quantity int     `json:"quantity" binding:"min=0"`
+	Note     *string `json:"note,omitempty"`
+}
+
+// Bulk stock update modes: set the quantity or add to it
+const (
+	BulkStockModeSet = "set"
+	BulkStockModeAdd = "add"
+)
+
+// BulkStockItem is one row of a bulk stock update; in add mode Quantity may be negative
+type BulkStockItem struct {
+	SizeID   int `json:"size_id" binding:"required"`
+	Quantity int `json:"quantity"`
+}
+
+// BulkStockRequest updates the stock of many sizes in one warehouse at once, e.g. after
+// a production run. WarehouseID defaults to the default warehouse, Mode to set.
+type BulkStockRequest struct {
+	WarehouseID *int            `json:"warehouse_id,omitempty"`
+	Mode        string          `json:"mode" binding:"omitempty,oneof=set add"`
+	Note        *string         `json:"note,omitempty"`
+	Items       []BulkStockItem `json:"items" binding:"required,min=1,max=500,dive"`
+}
+
+// BulkStockResult is the outcome of one row of a bulk stock update
+type BulkStockResult struct {
+	SizeID   int    `json:"size_id"`
+	Previous int    `json:"previous"`
+	Quantity int    `json:"quantity"`
+	Change   int    `json:"change"`
+	Error    string `json:"error,omitempty"`
+}
+
+// BulkStockResponse reports a bulk stock update; nothing is applied when any row failed
+type BulkStockResponse struct {
+	Applied     bool              `json:"applied"`
+	WarehouseID int               `json:"warehouse_id"`
+	Errors      int               `json:"errors"`
+	Results     []BulkStockResult `json:"results"`
+}
+
+// StockTransferRequest moves stock of a size between warehouses
+type StockTransferRequest struct {
+	FromWarehouseID int     `json:"from_warehouse_id" binding:"required"`
+	ToWarehouseID   int     `json:"to_warehouse_id" binding:"required,nefield=FromWarehouseID"`
+	SizeID          int     `json:"size_id" binding:"required"`
+	Quantity        int     `json:"quantity" binding:"required,min=1"`
+	Note            *string `json:"note,omitempty"`
+}
+
+// StockMovement is an entry in the stock movement log
+type StockMovement struct {
+	ID              int       `json:"id"`
+	SizeID          int       `json:"size_id"`
+	FromWarehouseID *int      `json:"from_warehouse_id,omitempty"`
+	ToWarehouseID   *int      `json:"to_warehouse_id,omitempty"`
+	Quantity        int       `json:"quantity"`
+	Type            string    `json:"type"`
+	OrderID         *int      `json:"order_id,omitempty"`
+	Note            *string   `json:"note,omitempty"`
+	CreatedBy       *int      `json:"created_by,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// StockMovementListResponse represents paginated stock movement list response
+type StockMovementListResponse struct {
+	Movements []StockMovement `json:"movements"`
+	Total     int             `json:"total"`
+	Page      int             `json:"page"`
+	Limit     int             `json:"limit"`
+}
+
+// PickListItem is an allocated order item waiting to be picked in a warehouse
+type PickListItem struct {
+	AllocationID int       `json:"allocation_id"`
+	OrderID      int       `json:"order_id"`
+	OrderItemID  int       `json:"order_item_id"`
+	OrderStatus  string    `json:"order_status"`
+	ProductName  string    `json:"product_name"`
+	VariantName  string    `json:"variant_name"`
+	ColorName    *string   `json:"color_name,omitempty"`
+	SizeID       int       `json:"size_id"`
+	SizeName     string    `json:"size_name"`
+	Quantity     int       `json:"quantity"`
+	OrderedAt    time.Time `json:"ordered_at"`
+}
+
+// PickListResponse lists items to pick in a warehouse
+type PickListResponse struct {
+	Warehouse Warehouse      `json:"warehouse"`
+	Items     []PickListItem `json:"items"`
+}
+
+// MarkPickedRequest marks allocations as picked
+type MarkPickedRequest struct {
+	AllocationIDs []int `json:"allocation_ids" binding:"required,min=1"`
+}
@@ -0,0 +1,115 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Events external services can subscribe to
+const (
+	WebhookEventOrderCreated       = "order.created"
+	WebhookEventOrderStatusChanged = "order.status_changed"
+	WebhookEventOrderCancelled     = "order.cancelled"
+	WebhookEventStockLow           = "stock.low"
+)
+
+// WebhookEvents lists the events an endpoint can subscribe to
+var WebhookEvents = []string{WebhookEventOrderCreated, WebhookEventOrderStatusChanged, WebhookEventOrderCancelled, WebhookEventStockLow}
+
+// Delivery states; failed deliveries ran out of attempts and can be replayed
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// SettingRetentionWebhookDeliveriesDays limits how long finished deliveries are kept, and
+// SettingLowStockThreshold is the available quantity at which stock.low is sent
+const (
+	SettingRetentionWebhookDeliveriesDays = "retention_webhook_deliveries_days"
+	SettingLowStockThreshold              = "low_stock_threshold"
+)
+
+// WebhookEndpoint is a URL notified about the events it subscribed to. Its secret signs
+// every delivery and is only shown when it is set.
+type WebhookEndpoint struct {
+	ID          int       `json:"id"`
+	URL         string    `json:"url"`
+	Description string    `json:"description"`
+	Events      []string  `json:"events"`
+	Active      bool      `json:"active"`
+	CreatedBy   *int      `json:"created_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// WebhookEndpointRequest registers or updates an endpoint. An empty secret generates one
+// on create and keeps the current one on update.
+type WebhookEndpointRequest struct {
+	URL         string   `json:"url" binding:"required,url,max=500"`
+	Description string   `json:"description" binding:"max=255"`
+	Events      []string `json:"events" binding:"required,min=1,dive,oneof=order.created order.status_changed order.cancelled stock.low"`
+	Active      *bool    `json:"active"`
+	Secret      string   `json:"secret" binding:"omitempty,min=16,max=128"`
+}
+
+// WebhookEndpointSecretResponse returns an endpoint with the secret that was just set
+type WebhookEndpointSecretResponse struct {
+	WebhookEndpoint
+	Secret string `json:"secret"`
+}
+
+// WebhookEvent is the body of every delivery
+type WebhookEvent struct {
+	ID        string      `json:"id"`
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// WebhookDelivery is one event queued for one endpoint, with the outcome of its last attempt
+type WebhookDelivery struct {
+	ID             int             `json:"id"`
+	EndpointID     int             `json:"endpoint_id"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty"`
+	LastStatusCode *int            `json:"last_status_code,omitempty"`
+	LastError      *string         `json:"last_error,omitempty"`
+	LastAttemptAt  *time.Time      `json:"last_attempt_at,omitempty"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+// WebhookDeliveryListResponse is a page of deliveries, newest first
+type WebhookDeliveryListResponse struct {
+	Deliveries []WebhookDelivery `json:"deliveries"`
+	Total      int               `json:"total"`
+	Page       int               `json:"page"`
+	Limit      int               `json:"limit"`
+}
+
+// OrderStatusChangedEvent is the data of order.status_changed and order.cancelled
+type OrderStatusChangedEvent struct {
+	OrderID        int    `json:"order_id"`
+	Email          string `json:"email"`
+	PreviousStatus string `json:"previous_status"`
+	Status         string `json:"status"`
+	// CustomStatus and PreviousCustomStatus are the codes of the custom statuses
+	// refining Status and PreviousStatus, if any
+	PreviousCustomStatus string `json:"previous_custom_status,omitempty"`
+	CustomStatus         string `json:"custom_status,omitempty"`
+	// IsTest suppresses the webhooks of test orders
+	IsTest bool `json:"-"`
+}
+
+// StockLowEvent is the data of stock.low
+type StockLowEvent struct {
+	ProductID int    `json:"product_id"`
+	SizeID    int    `json:"size_id"`
+	SizeName  string `json:"size_name"`
+	Available int    `json:"available"`
+	Threshold int    `json:"threshold"`
+}
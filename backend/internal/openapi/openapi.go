@@ -0,0 +1,284 @@
+// Package openapi builds an OpenAPI 3 specification from the routes registered with
+// gin and the annotations handlers give about their request and response bodies.
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Operation annotates a handler with what its route does not tell
+type Operation struct {
+	Summary string
+	// Request is a value of the JSON request body's type
+	Request interface{}
+	// Response is a value of the JSON response body's type, or Fields for a gin.H
+	Response interface{}
+	// Status of a successful response, 200 by default
+	Status int
+	// Query names the query parameters the handler reads
+	Query []string
+	// Auth marks routes outside the user and admin groups needing a bearer token
+	Auth bool
+}
+
+// Fields describes an object body field by field, with a value of each field's type
+type Fields map[string]interface{}
+
+// Annotations are the operations of handlers by handler name
+type Annotations map[string]Operation
+
+// Add annotates a handler, given as a method expression such as
+// (*handlers.AuthHandler).Login so it matches the handler name gin records for the
+// method value registered on a route
+func (a Annotations) Add(handler interface{}, op Operation) {
+	a[HandlerName(handler)] = op
+}
+
+// HandlerName returns the name gin uses for a handler, without the suffix of method
+// values
+func HandlerName(handler interface{}) string {
+	name := runtime.FuncForPC(reflect.ValueOf(handler).Pointer()).Name()
+	return strings.TrimSuffix(name, "-fm")
+}
+
+// Info is the title and version of the API
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Document is an OpenAPI 3 specification
+type Document struct {
+	OpenAPI    string                               `json:"openapi"`
+	Info       Info                                 `json:"info"`
+	Paths      map[string]map[string]*PathOperation `json:"paths"`
+	Components Components                           `json:"components"`
+}
+
+// PathOperation is what a method does on a path
+type PathOperation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary"`
+	Tags        []string              `json:"tags"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *Body                 `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+// Parameter is a path or query parameter
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+// Body is a request body
+type Body struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response is a response of an operation
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema of a body
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components are the schemas and security schemes operations refer to
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes"`
+}
+
+// SecurityScheme is a way requests authenticate
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	In           string `json:"in,omitempty"`
+	Name         string `json:"name,omitempty"`
+}
+
+// routeTags tag routes by path prefix, most specific first; other /api routes are public
+var routeTags = []struct{ prefix, tag string }{
+	{"/api/admin", "admin"},
+	{"/api/partner", "partner"},
+	{"/api/user", "user"},
+	{"/api/auth", "auth"},
+	{"/api/cart", "cart"},
+	{"/api/orders", "orders"},
+	{"/api/payments", "payments"},
+}
+
+// Build returns the specification of the routes, documenting annotated handlers' bodies.
+// Routes outside /api are left out.
+func Build(info Info, routes gin.RoutesInfo, annotations Annotations) *Document {
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info:    info,
+		Paths:   map[string]map[string]*PathOperation{},
+		Components: Components{
+			Schemas: map[string]*Schema{},
+			SecuritySchemes: map[string]SecurityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+				"apiKey":     {Type: "apiKey", In: "header", Name: "X-API-Key"},
+			},
+		},
+	}
+	schemas := newSchemaBuilder(doc.Components.Schemas)
+	doc.Components.Schemas["Error"] = &Schema{
+		Type:       "object",
+		Properties: map[string]*Schema{"error": {Type: "string"}, "request_id": {Type: "string"}},
+		Required:   []string{"error"},
+	}
+
+	sorted := append(gin.RoutesInfo{}, routes...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Path != sorted[j].Path {
+			return sorted[i].Path < sorted[j].Path
+		}
+		return sorted[i].Method < sorted[j].Method
+	})
+
+	operationIDs := map[string]bool{}
+	for _, route := range sorted {
+		if !strings.HasPrefix(route.Path, "/api/") {
+			continue
+		}
+		op := annotations[strings.TrimSuffix(route.Handler, "-fm")]
+		tag := routeTag(route.Path)
+		path, params := pathParameters(route.Path)
+
+		method := handlerMethod(route.Handler)
+		operationID := method
+		if operationIDs[operationID] {
+			operationID = tag + method
+		}
+		operationIDs[operationID] = true
+
+		item := &PathOperation{
+			OperationID: operationID,
+			Summary:     op.Summary,
+			Tags:        []string{tag},
+			Parameters:  params,
+			Responses:   map[string]*Response{},
+		}
+		if item.Summary == "" {
+			item.Summary = sentence(method)
+		}
+		for _, name := range op.Query {
+			item.Parameters = append(item.Parameters, Parameter{Name: name, In: "query", Schema: &Schema{Type: "string"}})
+		}
+		switch {
+		case tag == "admin" || tag == "user" || op.Auth:
+			item.Security = []map[string][]string{{"bearerAuth": {}}}
+		case tag == "partner":
+			item.Security = []map[string][]string{{"apiKey": {}}}
+		}
+
+		if op.Request != nil {
+			item.RequestBody = &Body{
+				Required: true,
+				Content:  map[string]MediaType{"application/json": {Schema: schemas.body(op.Request)}},
+			}
+		}
+		status := op.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		response := &Response{Description: http.StatusText(status)}
+		if op.Response != nil {
+			response.Content = map[string]MediaType{"application/json": {Schema: schemas.body(op.Response)}}
+		}
+		item.Responses[strconv.Itoa(status)] = response
+		item.Responses["default"] = &Response{
+			Description: "Error",
+			Content:     map[string]MediaType{"application/json": {Schema: &Schema{Ref: "#/components/schemas/Error"}}},
+		}
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = map[string]*PathOperation{}
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = item
+	}
+	return doc
+}
+
+func routeTag(path string) string {
+	for _, t := range routeTags {
+		if path == t.prefix || strings.HasPrefix(path, t.prefix+"/") {
+			return t.tag
+		}
+	}
+	return "public"
+}
+
+// pathParameters converts gin's :name and *name segments to {name} and returns their
+// parameters; IDs are integers
+func pathParameters(path string) (string, []Parameter) {
+	segments := strings.Split(path, "/")
+	params := []Parameter{}
+	for i, segment := range segments {
+		if segment == "" || (segment[0] != ':' && segment[0] != '*') {
+			continue
+		}
+		name := segment[1:]
+		schema := &Schema{Type: "string"}
+		if name == "id" || strings.HasSuffix(name, "Id") || strings.HasSuffix(name, "_id") {
+			schema = &Schema{Type: "integer"}
+		}
+		params = append(params, Parameter{Name: name, In: "path", Required: true, Schema: schema})
+		segments[i] = "{" + name + "}"
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// handlerMethod returns the method or function name of a handler name such as
+// notsofluffy-backend/internal/handlers.(*AuthHandler).Login-fm
+func handlerMethod(name string) string {
+	name = strings.TrimSuffix(name, "-fm")
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// sentence turns a method name such as GetUserOrders into "Get user orders", keeping
+// acronyms as in "List API keys"
+func sentence(name string) string {
+	runes := []rune(name)
+	words := []string{}
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		prevLower := unicode.IsLower(runes[i-1])
+		acronymEnd := unicode.IsUpper(runes[i-1]) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+		if unicode.IsUpper(runes[i]) && (prevLower || acronymEnd) {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	words = append(words, string(runes[start:]))
+
+	for i := 1; i < len(words); i++ {
+		if strings.ToUpper(words[i]) != words[i] || len(words[i]) == 1 {
+			words[i] = strings.ToLower(words[i])
+		}
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,124 @@
+package openapi
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type widgetHandler struct{}
+
+func (h *widgetHandler) GetWidget(c *gin.Context)    {}
+func (h *widgetHandler) CreateWidget(c *gin.Context) {}
+
+type widgetRequest struct {
+	Name  string `json:"name" binding:"required"`
+	Color string `json:"color" binding:"omitempty,oneof=red blue"`
+}
+
+type widget struct {
+	ID        int        `json:"id"`
+	Name      string     `json:"name"`
+	Parent    *widget    `json:"parent,omitempty"`
+	DeletedAt *time.Time `json:"deleted_at"`
+	secret    string
+	Internal  string `json:"-"`
+}
+
+func widgetRoutes() (gin.RoutesInfo, Annotations) {
+	gin.SetMode(gin.TestMode)
+	h := &widgetHandler{}
+	r := gin.New()
+	r.GET("/api/admin/widgets/:id", h.GetWidget)
+	r.POST("/api/widgets", h.CreateWidget)
+	r.GET("/health", h.GetWidget)
+
+	annotations := Annotations{}
+	annotations.Add((*widgetHandler).CreateWidget, Operation{
+		Request:  widgetRequest{},
+		Response: Fields{"widget": widget{}},
+		Status:   http.StatusCreated,
+		Query:    []string{"dry_run"},
+	})
+	return r.Routes(), annotations
+}
+
+func TestBuildDocumentsAPIRoutes(t *testing.T) {
+	routes, annotations := widgetRoutes()
+	doc := Build(Info{Title: "Test", Version: "dev"}, routes, annotations)
+
+	if len(doc.Paths) != 2 {
+		t.Fatalf("expected only the /api routes, got %v", doc.Paths)
+	}
+	get := doc.Paths["/api/admin/widgets/{id}"]["get"]
+	if get == nil {
+		t.Fatalf("expected the path parameter in braces, got %v", doc.Paths)
+	}
+	if get.OperationID != "GetWidget" || get.Summary != "Get widget" || get.Tags[0] != "admin" {
+		t.Fatalf("unexpected operation %+v", get)
+	}
+	if len(get.Security) != 1 || get.Security[0]["bearerAuth"] == nil {
+		t.Fatalf("admin routes need a bearer token, got %+v", get.Security)
+	}
+	if len(get.Parameters) != 1 || get.Parameters[0].In != "path" || get.Parameters[0].Schema.Type != "integer" {
+		t.Fatalf("expected an integer id path parameter, got %+v", get.Parameters)
+	}
+
+	create := doc.Paths["/api/widgets"]["post"]
+	if create.Tags[0] != "public" || create.Security != nil {
+		t.Fatalf("expected a public operation, got %+v", create)
+	}
+	if create.RequestBody == nil || create.Responses["201"] == nil || create.Responses["default"] == nil {
+		t.Fatalf("expected the annotated bodies, got %+v", create)
+	}
+	if len(create.Parameters) != 1 || create.Parameters[0].Name != "dry_run" || create.Parameters[0].In != "query" {
+		t.Fatalf("expected the dry_run query parameter, got %+v", create.Parameters)
+	}
+}
+
+func TestBuildSchemasFollowJSONTags(t *testing.T) {
+	routes, annotations := widgetRoutes()
+	doc := Build(Info{}, routes, annotations)
+
+	request := doc.Components.Schemas["widgetRequest"]
+	if request == nil || len(request.Required) != 1 || request.Required[0] != "name" {
+		t.Fatalf("expected name to be required, got %+v", request)
+	}
+	if enum := request.Properties["color"].Enum; len(enum) != 2 || enum[0] != "red" {
+		t.Fatalf("expected the oneof values as an enum, got %v", enum)
+	}
+
+	w := doc.Components.Schemas["widget"]
+	if w == nil {
+		t.Fatal("expected a widget component")
+	}
+	for _, name := range []string{"secret", "Internal", "-"} {
+		if _, ok := w.Properties[name]; ok {
+			t.Fatalf("%s is not written to JSON", name)
+		}
+	}
+	if w.Properties["parent"].Ref != "#/components/schemas/widget" {
+		t.Fatalf("expected the recursive field to refer to its component, got %+v", w.Properties["parent"])
+	}
+	deleted := w.Properties["deleted_at"]
+	if deleted.Type != "string" || deleted.Format != "date-time" || !deleted.Nullable {
+		t.Fatalf("expected a nullable date-time, got %+v", deleted)
+	}
+}
+
+func TestSentence(t *testing.T) {
+	cases := map[string]string{
+		"GetUserOrders":   "Get user orders",
+		"ListAPIKeys":     "List API keys",
+		"GetOrderByHash":  "Get order by hash",
+		"RecommendSize":   "Recommend size",
+		"GetXMLFeedItems": "Get XML feed items",
+	}
+	for name, want := range cases {
+		if got := sentence(name); got != want {
+			t.Fatalf("sentence(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Schema is a JSON schema of a body or a part of it
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	rawType       = reflect.TypeOf(json.RawMessage{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// schemaBuilder derives schemas from Go types the way encoding/json writes them.
+// Named structs become components referred to by name.
+type schemaBuilder struct {
+	components map[string]*Schema
+	types      map[string]reflect.Type
+}
+
+func newSchemaBuilder(components map[string]*Schema) *schemaBuilder {
+	return &schemaBuilder{components: components, types: map[string]reflect.Type{}}
+}
+
+// body returns the schema of an annotated body value
+func (b *schemaBuilder) body(value interface{}) *Schema {
+	if fields, ok := value.(Fields); ok {
+		schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		for name, field := range fields {
+			if field == nil {
+				schema.Properties[name] = &Schema{}
+				continue
+			}
+			schema.Properties[name] = b.schema(reflect.TypeOf(field))
+		}
+		return schema
+	}
+	return b.schema(reflect.TypeOf(value))
+}
+
+func (b *schemaBuilder) schema(t reflect.Type) *Schema {
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawType || t.Kind() == reflect.Interface:
+		return &Schema{}
+	case t.Kind() != reflect.Ptr && t.Implements(marshalerType):
+		// Custom JSON, the Go type says nothing about it
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema := b.schema(t.Elem())
+		if schema.Ref == "" {
+			schema.Nullable = true
+		}
+		return schema
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: b.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: b.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.object(t)
+		}
+		name := b.componentName(t)
+		if _, ok := b.components[name]; !ok {
+			// Registered before its fields so recursive types refer to themselves
+			b.components[name] = &Schema{}
+			*b.components[name] = *b.object(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+	return &Schema{}
+}
+
+// componentName names a struct's component, qualified by package when two packages
+// have types of the same name
+func (b *schemaBuilder) componentName(t reflect.Type) string {
+	name := t.Name()
+	if existing, ok := b.types[name]; ok && existing != t {
+		pkg := t.PkgPath()
+		pkg = pkg[strings.LastIndex(pkg, "/")+1:]
+		name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+	}
+	b.types[name] = t
+	return name
+}
+
+// object returns the schema of a struct's JSON fields
+func (b *schemaBuilder) object(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	b.addFields(schema, t)
+	return schema
+}
+
+func (b *schemaBuilder) addFields(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				b.addFields(schema, embedded)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := b.schema(field.Type)
+		binding := field.Tag.Get("binding")
+		for _, rule := range strings.Split(binding, ",") {
+			if values, ok := strings.CutPrefix(rule, "oneof="); ok && property.Ref == "" {
+				property.Enum = strings.Fields(values)
+			}
+		}
+		schema.Properties[name] = property
+		if strings.Contains(","+binding+",", ",required,") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+)
+
+// Payment intent statuses
+const (
+	IntentStatusPending   = "pending"
+	IntentStatusSucceeded = "succeeded"
+	IntentStatusFailed    = "failed"
+)
+
+// ErrInvalidSignature is returned for provider callbacks that fail signature verification
+var ErrInvalidSignature = errors.New("invalid payment callback signature")
+
+// IntentParams describes a payment to be collected for an order
+type IntentParams struct {
+	OrderID int
+	// Reference identifies the payment at the provider; it is unique per attempt
+	Reference   string
+	Amount      float64
+	Currency    string
+	Email       string
+	Description string
+	// ReturnURL is where the customer lands after paying on the provider's page
+	ReturnURL string
+}
+
+// Intent is a payment started at a provider. Customers are sent to RedirectURL, or the
+// storefront confirms the payment with ClientSecret, depending on the provider.
+type Intent struct {
+	ProviderIntentID string
+	RedirectURL      string
+	ClientSecret     string
+}
+
+// CallbackEvent is a verified payment update sent by a provider
+type CallbackEvent struct {
+	ProviderIntentID string
+	Status           string
+	Amount           float64
+}
+
+// IntentProvider is implemented by online payment providers charging customers
+type IntentProvider interface {
+	// Name returns the identifier used in the callback URL and stored with intents
+	Name() string
+	// CreateIntent starts a payment
+	CreateIntent(ctx context.Context, params IntentParams) (*Intent, error)
+	// HandleCallback verifies a callback and returns the payment update it carries, or
+	// nil for callbacks that don't change a payment
+	HandleCallback(ctx context.Context, header http.Header, body []byte) (*CallbackEvent, error)
+}
+
+// Config selects and configures the online payment provider
+type Config struct {
+	// Provider is "stripe" or "przelewy24"; online payments are off when it is empty
+	Provider string
+
+	StripeSecretKey     string
+	StripeWebhookSecret string
+
+	P24MerchantID int
+	P24PosID      int
+	P24APIKey     string
+	P24CRC        string
+	P24Sandbox    bool
+	// P24StatusURL is the callback URL Przelewy24 notifies about payments
+	P24StatusURL string
+}
+
+// NewIntentProvider returns the configured provider, or nil when online payments are off
+func NewIntentProvider(cfg Config, timeout time.Duration) (IntentProvider, error) {
+	switch cfg.Provider {
+	case "":
+		return nil, nil
+	case "stripe":
+		if cfg.StripeSecretKey == "" || cfg.StripeWebhookSecret == "" {
+			return nil, fmt.Errorf("stripe needs a secret key and a webhook secret")
+		}
+		return NewStripeProvider(cfg.StripeSecretKey, cfg.StripeWebhookSecret, timeout), nil
+	case "przelewy24":
+		if cfg.P24MerchantID == 0 || cfg.P24APIKey == "" || cfg.P24CRC == "" {
+			return nil, fmt.Errorf("przelewy24 needs a merchant ID, an API key and a CRC key")
+		}
+		return NewPrzelewy24Provider(cfg, timeout), nil
+	}
+	return nil, fmt.Errorf("unknown payment provider %q", cfg.Provider)
+}
+
+// minorUnits converts an amount to the smallest currency unit (grosze, cents)
+func minorUnits(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
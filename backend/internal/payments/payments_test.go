@@ -0,0 +1,107 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func stripeSignature(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(fmt.Sprintf("%d.", timestamp)))
+	mac.Write(body)
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
+
+func TestStripeHandleCallback(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	provider := NewStripeProvider("sk_test", "whsec_test", time.Second)
+	provider.now = func() time.Time { return now }
+
+	body := []byte(`{"type":"payment_intent.succeeded","data":{"object":{"id":"pi_1","amount":12990,"amount_received":12990}}}`)
+	header := http.Header{}
+	header.Set("Stripe-Signature", stripeSignature("whsec_test", now.Unix(), body))
+
+	event, err := provider.HandleCallback(context.Background(), header, body)
+	if err != nil || event == nil {
+		t.Fatalf("HandleCallback = %+v, %v, want event", event, err)
+	}
+	if event.ProviderIntentID != "pi_1" || event.Status != IntentStatusSucceeded || event.Amount != 129.90 {
+		t.Fatalf("unexpected event %+v", event)
+	}
+
+	tampered := []byte(`{"type":"payment_intent.succeeded","data":{"object":{"id":"pi_1","amount":1,"amount_received":1}}}`)
+	if _, err := provider.HandleCallback(context.Background(), header, tampered); err != ErrInvalidSignature {
+		t.Fatalf("tampered body: err = %v, want ErrInvalidSignature", err)
+	}
+
+	header.Set("Stripe-Signature", stripeSignature("whsec_test", now.Add(-10*time.Minute).Unix(), body))
+	if _, err := provider.HandleCallback(context.Background(), header, body); err != ErrInvalidSignature {
+		t.Fatalf("stale signature: err = %v, want ErrInvalidSignature", err)
+	}
+
+	other := []byte(`{"type":"charge.updated","data":{"object":{"id":"ch_1"}}}`)
+	header.Set("Stripe-Signature", stripeSignature("whsec_test", now.Unix(), other))
+	if event, err := provider.HandleCallback(context.Background(), header, other); err != nil || event != nil {
+		t.Fatalf("other event = %+v, %v, want ignored", event, err)
+	}
+}
+
+func TestP24Sign(t *testing.T) {
+	sign, err := p24Sign(struct {
+		SessionID  string `json:"sessionId"`
+		MerchantID int    `json:"merchantId"`
+		Amount     int64  `json:"amount"`
+		Currency   string `json:"currency"`
+		CRC        string `json:"crc"`
+	}{"order-1/a&b", 11111, 12990, "PLN", "crc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum := sha512.Sum384([]byte(`{"sessionId":"order-1/a&b","merchantId":11111,"amount":12990,"currency":"PLN","crc":"crc"}`))
+	if sign != hex.EncodeToString(sum[:]) {
+		t.Fatalf("sign = %s, want the hash of the unescaped JSON", sign)
+	}
+}
+
+func TestPrzelewy24HandleCallback(t *testing.T) {
+	verified := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != "/api/v1/transaction/verify" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		verified = true
+		w.Write([]byte(`{"data":{"status":"success"},"responseCode":0}`))
+	}))
+	defer server.Close()
+
+	provider := NewPrzelewy24Provider(Config{P24MerchantID: 11111, P24APIKey: "key", P24CRC: "crc"}, time.Second)
+	provider.baseURL = server.URL
+
+	n := p24Notification{MerchantID: 11111, PosID: 11111, SessionID: "order-1-abc", Amount: 12990, OriginAmount: 12990,
+		Currency: "PLN", OrderID: 42, MethodID: 25, Statement: "p24-A1"}
+	n.Sign, _ = provider.notificationSign(&n)
+	body := []byte(fmt.Sprintf(`{"merchantId":11111,"posId":11111,"sessionId":"order-1-abc","amount":12990,"originAmount":12990,"currency":"PLN","orderId":42,"methodId":25,"statement":"p24-A1","sign":"%s"}`, n.Sign))
+
+	event, err := provider.HandleCallback(context.Background(), http.Header{}, body)
+	if err != nil || event == nil || !verified {
+		t.Fatalf("HandleCallback = %+v, %v (verified %v), want verified event", event, err, verified)
+	}
+	if event.ProviderIntentID != "order-1-abc" || event.Status != IntentStatusSucceeded || event.Amount != 129.90 {
+		t.Fatalf("unexpected event %+v", event)
+	}
+
+	verified = false
+	forged := []byte(`{"merchantId":11111,"posId":11111,"sessionId":"order-1-abc","amount":12990,"originAmount":12990,"currency":"PLN","orderId":42,"methodId":25,"statement":"p24-A1","sign":"00"}`)
+	if _, err := provider.HandleCallback(context.Background(), http.Header{}, forged); err != ErrInvalidSignature || verified {
+		t.Fatalf("forged notification: err = %v (verified %v), want ErrInvalidSignature", err, verified)
+	}
+}
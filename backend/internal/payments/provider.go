@@ -0,0 +1,45 @@
+package payments
+
+import (
+	"context"
+)
+
+// RefundParams describes a refund to be issued through a payment provider
+type RefundParams struct {
+	OrderID       int
+	Amount        float64
+	Reason        string
+	PaymentMethod string
+}
+
+// RefundResult is returned by a provider after a refund has been accepted
+type RefundResult struct {
+	ProviderRefundID string
+}
+
+// Provider is implemented by every payment provider integration
+type Provider interface {
+	// Name returns the identifier stored with refunds issued by this provider
+	Name() string
+	// Refund returns money to the customer for the given order
+	Refund(ctx context.Context, params RefundParams) (*RefundResult, error)
+}
+
+// ManualProvider is used when payments are settled outside the shop (bank transfer,
+// cash on delivery). Refunds are performed by staff and only recorded here.
+type ManualProvider struct{}
+
+// NewManualProvider creates a provider that records refunds without calling any external API
+func NewManualProvider() *ManualProvider {
+	return &ManualProvider{}
+}
+
+// Name returns the provider identifier
+func (p *ManualProvider) Name() string {
+	return "manual"
+}
+
+// Refund accepts the refund immediately; the money is returned by staff
+func (p *ManualProvider) Refund(ctx context.Context, params RefundParams) (*RefundResult, error) {
+	return &RefundResult{}, nil
+}
@@ -0,0 +1,160 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// stripeSignatureTolerance bounds the age of a signed Stripe callback, against replays
+const stripeSignatureTolerance = 5 * time.Minute
+
+// StripeProvider collects payments with Stripe payment intents, confirmed by the
+// storefront with Stripe.js
+type StripeProvider struct {
+	secretKey     string
+	webhookSecret string
+	endpoint      string
+	client        *http.Client
+	now           func() time.Time
+}
+
+// NewStripeProvider creates a provider using the Stripe API
+func NewStripeProvider(secretKey, webhookSecret string, timeout time.Duration) *StripeProvider {
+	return &StripeProvider{
+		secretKey:     secretKey,
+		webhookSecret: webhookSecret,
+		endpoint:      "https://api.stripe.com/v1",
+		client:        &http.Client{Timeout: timeout},
+		now:           time.Now,
+	}
+}
+
+// Name returns the provider identifier
+func (p *StripeProvider) Name() string {
+	return "stripe"
+}
+
+// CreateIntent creates a Stripe payment intent; the reference doubles as idempotency key
+func (p *StripeProvider) CreateIntent(ctx context.Context, params IntentParams) (*Intent, error) {
+	form := url.Values{
+		"amount":                             {strconv.FormatInt(minorUnits(params.Amount), 10)},
+		"currency":                           {strings.ToLower(params.Currency)},
+		"description":                        {params.Description},
+		"automatic_payment_methods[enabled]": {"true"},
+		"metadata[order_id]":                 {strconv.Itoa(params.OrderID)},
+		"metadata[reference]":                {params.Reference},
+	}
+	if params.Email != "" {
+		form.Set("receipt_email", params.Email)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint+"/payment_intents", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stripe request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+p.secretKey)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Idempotency-Key", params.Reference)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call stripe: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		ID           string `json:"id"`
+		ClientSecret string `json:"client_secret"`
+		Error        *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode stripe response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if body.Error != nil {
+			return nil, fmt.Errorf("stripe returned status %d: %s", resp.StatusCode, body.Error.Message)
+		}
+		return nil, fmt.Errorf("stripe returned status %d", resp.StatusCode)
+	}
+
+	return &Intent{ProviderIntentID: body.ID, ClientSecret: body.ClientSecret}, nil
+}
+
+// HandleCallback verifies the Stripe-Signature header of a webhook event and returns
+// the update of payment_intent.succeeded and payment_intent.payment_failed events
+func (p *StripeProvider) HandleCallback(ctx context.Context, header http.Header, body []byte) (*CallbackEvent, error) {
+	if err := p.verifySignature(header.Get("Stripe-Signature"), body); err != nil {
+		return nil, err
+	}
+
+	var event struct {
+		Type string `json:"type"`
+		Data struct {
+			Object struct {
+				ID             string `json:"id"`
+				Amount         int64  `json:"amount"`
+				AmountReceived int64  `json:"amount_received"`
+			} `json:"object"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, fmt.Errorf("failed to decode stripe event: %w", err)
+	}
+
+	intent := event.Data.Object
+	switch event.Type {
+	case "payment_intent.succeeded":
+		return &CallbackEvent{ProviderIntentID: intent.ID, Status: IntentStatusSucceeded, Amount: float64(intent.AmountReceived) / 100}, nil
+	case "payment_intent.payment_failed":
+		return &CallbackEvent{ProviderIntentID: intent.ID, Status: IntentStatusFailed, Amount: float64(intent.Amount) / 100}, nil
+	}
+	return nil, nil
+}
+
+// verifySignature checks a "t=<unix>,v1=<hex hmac>" header against the webhook secret
+func (p *StripeProvider) verifySignature(header string, body []byte) error {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return ErrInvalidSignature
+	}
+	if age := p.now().Sub(time.Unix(unix, 0)); age > stripeSignatureTolerance || age < -stripeSignatureTolerance {
+		return ErrInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(p.webhookSecret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	for _, signature := range signatures {
+		if hmac.Equal([]byte(signature), []byte(expected)) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
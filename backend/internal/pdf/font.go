@@ -0,0 +1,114 @@
+package pdf
+
+// The fonts use WinAnsiEncoding, which covers ASCII and Latin-1, with the codes from
+// extraGlyphBase remapped to the Polish letters it lacks
+const extraGlyphBase = 128
+
+var extraGlyphNames = []string{
+	"Aogonek", "aogonek", "Cacute", "cacute", "Eogonek", "eogonek", "Lslash", "lslash",
+	"Nacute", "nacute", "Sacute", "sacute", "Zacute", "zacute", "Zdotaccent", "zdotaccent",
+}
+
+var extraGlyphRunes = []rune{'Ą', 'ą', 'Ć', 'ć', 'Ę', 'ę', 'Ł', 'ł', 'Ń', 'ń', 'Ś', 'ś', 'Ź', 'ź', 'Ż', 'ż'}
+
+var extraGlyphWidths = [2][]int{
+	{667, 556, 722, 500, 667, 556, 556, 222, 722, 556, 667, 500, 611, 500, 611, 500},
+	{722, 556, 722, 556, 667, 556, 611, 278, 722, 611, 667, 556, 611, 500, 611, 500},
+}
+
+// Typographic punctuation whose WinAnsi codes are taken by the extra glyphs
+var punctuationFallbacks = map[rune]byte{
+	'‘': '\'', '’': '\'', '‚': '\'',
+	'“': '"', '”': '"', '„': '"',
+	'–': '-', '—': '-', '…': '.',
+}
+
+// Glyph widths of ASCII 32-126 in thousandths of the font size, from the Helvetica AFMs
+var asciiWidths = [2][95]int{
+	{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	},
+	{
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	},
+}
+
+// encodeRune returns the font code of r, or '?' when the fonts have no glyph for it
+func encodeRune(r rune) byte {
+	switch {
+	case r >= 32 && r < 127:
+		return byte(r)
+	case r >= 0xA0 && r <= 0xFF:
+		return byte(r)
+	}
+	for i, extra := range extraGlyphRunes {
+		if r == extra {
+			return byte(extraGlyphBase + i)
+		}
+	}
+	if b, ok := punctuationFallbacks[r]; ok {
+		return b
+	}
+	if r == '\t' || r == '\n' || r == '\r' {
+		return ' '
+	}
+	return '?'
+}
+
+// encode converts UTF-8 text to font codes
+func encode(s string) string {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		out = append(out, encodeRune(r))
+	}
+	return string(out)
+}
+
+// TextWidth returns the width of text in points. Latin-1 letters are measured as an
+// average lower case letter, close enough for fitting text into a box.
+func TextWidth(text string, size float64, bold bool) float64 {
+	style := 0
+	if bold {
+		style = 1
+	}
+
+	total := 0
+	for _, r := range text {
+		code := encodeRune(r)
+		switch {
+		case code >= 32 && code < 127:
+			total += asciiWidths[style][code-32]
+		case code >= extraGlyphBase && int(code) < extraGlyphBase+len(extraGlyphRunes):
+			total += extraGlyphWidths[style][code-extraGlyphBase]
+		default:
+			total += asciiWidths[style]['o'-32]
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// Fit shortens text with an ellipsis so it is at most width points wide
+func Fit(text string, width, size float64, bold bool) string {
+	if TextWidth(text, size, bold) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		candidate := string(runes) + "..."
+		if TextWidth(candidate, size, bold) <= width {
+			return candidate
+		}
+	}
+	return ""
+}
@@ -0,0 +1,134 @@
+// Package pdf writes simple text documents as PDF using the standard Helvetica fonts,
+// so printouts need no font files or external libraries.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Page sizes in points
+const (
+	A4Width  = 595.28
+	A4Height = 841.89
+)
+
+// MM converts millimetres to points
+func MM(mm float64) float64 {
+	return mm * 72 / 25.4
+}
+
+// Document is a PDF being written page by page. Coordinates are in points with the
+// origin in the top left corner of the page.
+type Document struct {
+	width, height float64
+	pages         []*bytes.Buffer
+}
+
+// New creates an empty document with pages of the given size
+func New(width, height float64) *Document {
+	return &Document{width: width, height: height}
+}
+
+// AddPage starts a new page; subsequent drawing goes to it
+func (d *Document) AddPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+}
+
+// PageCount returns the number of pages added so far
+func (d *Document) PageCount() int {
+	return len(d.pages)
+}
+
+func (d *Document) page() *bytes.Buffer {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+	return d.pages[len(d.pages)-1]
+}
+
+// Text draws a line of text with its baseline at y
+func (d *Document) Text(x, y, size float64, bold bool, text string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(d.page(), "BT /%s %s Tf %s %s Td (%s) Tj ET\n",
+		font, num(size), num(x), num(d.height-y), escape(encode(text)))
+}
+
+// Rect strokes a rectangle with its top left corner at x, y
+func (d *Document) Rect(x, y, width, height, lineWidth float64) {
+	fmt.Fprintf(d.page(), "%s w %s %s %s %s re S\n",
+		num(lineWidth), num(x), num(d.height-y-height), num(width), num(height))
+}
+
+// DashedRect strokes a dashed rectangle, e.g. a cutting guide
+func (d *Document) DashedRect(x, y, width, height, lineWidth float64) {
+	fmt.Fprintf(d.page(), "q [3 3] 0 d %s w %s %s %s %s re S Q\n",
+		num(lineWidth), num(x), num(d.height-y-height), num(width), num(height))
+}
+
+// Bytes returns the finished PDF
+func (d *Document) Bytes() []byte {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+
+	var out bytes.Buffer
+	offsets := []int{}
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1-5 are fixed, then a page and its content stream per page
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 6+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding 5 0 R >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding 5 0 R >>")
+	object(fmt.Sprintf("<< /Type /Encoding /BaseEncoding /WinAnsiEncoding /Differences [%d %s] >>",
+		extraGlyphBase, "/"+strings.Join(extraGlyphNames, " /")))
+
+	for i, content := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			num(d.width), num(d.height), 7+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.Bytes()
+}
+
+// num formats a coordinate without needless digits
+func num(v float64) string {
+	s := strings.TrimRight(fmt.Sprintf("%.2f", v), "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// escape quotes the delimiters of a PDF string literal
+func escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\', '(', ')':
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,68 @@
+package pdf
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestEncodeMapsPolishLetters(t *testing.T) {
+	got := encode("Łódź ąę (x)")
+	want := string([]byte{extraGlyphBase + 6, 0xF3, 'd', extraGlyphBase + 13, ' ', extraGlyphBase + 1, extraGlyphBase + 5, ' ', '(', 'x', ')'})
+	if got != want {
+		t.Fatalf("encode = %q, want %q", got, want)
+	}
+	if encode("日") != "?" {
+		t.Fatalf("unsupported rune should encode as ?")
+	}
+}
+
+func TestEscape(t *testing.T) {
+	if got := escape(`a(b)\c`); got != `a\(b\)\\c` {
+		t.Fatalf("escape = %q", got)
+	}
+}
+
+func TestFit(t *testing.T) {
+	text := "ul. Bardzo Długa Nazwa Ulicy 123/45"
+	width := TextWidth(text, 10, false)
+	if Fit(text, width, 10, false) != text {
+		t.Fatalf("text that fits should be kept")
+	}
+	fitted := Fit(text, width/2, 10, false)
+	if TextWidth(fitted, 10, false) > width/2 || fitted[len(fitted)-3:] != "..." {
+		t.Fatalf("Fit = %q does not fit", fitted)
+	}
+}
+
+func TestBytesCrossReference(t *testing.T) {
+	doc := New(A4Width, A4Height)
+	doc.AddPage()
+	doc.Text(10, 20, 12, true, "Zażółć gęślą jaźń")
+	doc.AddPage()
+	doc.Rect(10, 10, 100, 50, 1)
+	out := doc.Bytes()
+
+	if !bytes.HasPrefix(out, []byte("%PDF-1.4")) || !bytes.HasSuffix(out, []byte("%%EOF\n")) {
+		t.Fatalf("missing PDF header or trailer")
+	}
+	if !bytes.Contains(out, []byte("/Count 2")) {
+		t.Fatalf("expected two pages")
+	}
+
+	// Every xref entry must point at the start of its object
+	xref := regexp.MustCompile(`startxref\n(\d+)`).FindSubmatch(out)
+	start, _ := strconv.Atoi(string(xref[1]))
+	entries := regexp.MustCompile(`(\d{10}) 00000 n`).FindAllSubmatch(out[start:], -1)
+	if len(entries) != 9 {
+		t.Fatalf("got %d objects, want 9", len(entries))
+	}
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		prefix := []byte(strconv.Itoa(i+1) + " 0 obj")
+		if !bytes.HasPrefix(out[offset:], prefix) {
+			t.Fatalf("xref entry %d points at %q", i+1, out[offset:offset+10])
+		}
+	}
+}
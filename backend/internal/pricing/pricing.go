@@ -0,0 +1,179 @@
+// Package pricing computes cart and order amounts. All amounts are rounded to whole
+// cents so the server and the storefront agree on every total.
+package pricing
+
+import (
+	"fmt"
+	"math"
+
+	"notsofluffy-backend/internal/models"
+)
+
+// CustomColorMarkup is the price factor of items made in a custom color
+const CustomColorMarkup = 1.1
+
+// Round rounds an amount to whole cents
+func Round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// UnitPrice returns the price of one item: the size's base price, marked up for a custom
+// color, plus its services and option surcharges
+func UnitPrice(basePrice float64, customColor bool, servicePrices, surcharges []float64) float64 {
+	price := basePrice
+	if customColor {
+		price *= CustomColorMarkup
+	}
+	for _, p := range servicePrices {
+		price += p
+	}
+	for _, s := range surcharges {
+		price += s
+	}
+	return Round(price)
+}
+
+// Discount returns what a discount code takes off subtotal, never more than subtotal
+func Discount(discountType string, value, subtotal float64) float64 {
+	amount := value
+	if discountType == models.DiscountTypePercentage {
+		amount = subtotal * value / 100
+	}
+	if amount > subtotal {
+		amount = subtotal
+	}
+	if amount < 0 {
+		amount = 0
+	}
+	return Round(amount)
+}
+
+// Total returns what the customer pays: the discounted subtotal, never below zero, plus
+// shipping and tax
+func Total(subtotal, discount, shipping, tax float64) float64 {
+	discounted := subtotal - discount
+	if discounted < 0 {
+		discounted = 0
+	}
+	return Round(discounted + shipping + tax)
+}
+
+// Shipping returns the shipping cost of the cart items: the price of the shipping
+// method, waived when the discounted subtotal reaches its free shipping threshold, plus
+// the per-unit surcharges of their products and, once, the oversize fee when any
+// product is oversized. Items left out of the cart totals are skipped. Without a
+// method the base cost is free.
+func Shipping(items []models.CartItemResponse, method *models.ShippingMethod, discountedSubtotal, oversizeFee float64) models.ShippingBreakdown {
+	breakdown := models.ShippingBreakdown{Surcharges: []models.ShippingSurcharge{}}
+	if method != nil {
+		breakdown.MethodID = &method.ID
+		breakdown.Method = method.Name
+		if method.FreeAbove == nil || Round(discountedSubtotal) < Round(*method.FreeAbove) {
+			breakdown.BaseCost = Round(method.Price)
+		}
+	}
+	oversize := false
+	for _, item := range items {
+		if item.Unavailable {
+			continue
+		}
+		oversize = oversize || item.Oversize
+		if item.ShippingSurcharge > 0 {
+			amount := Round(item.ShippingSurcharge * float64(item.Quantity))
+			breakdown.Surcharges = append(breakdown.Surcharges, models.ShippingSurcharge{
+				ProductID:     item.ProductID,
+				ProductName:   item.Product.Name,
+				Quantity:      item.Quantity,
+				UnitSurcharge: item.ShippingSurcharge,
+				Amount:        amount,
+			})
+			breakdown.Total += amount
+		}
+	}
+	if oversize {
+		breakdown.OversizeFee = Round(oversizeFee)
+	}
+	breakdown.Total = Round(breakdown.BaseCost + breakdown.OversizeFee + breakdown.Total)
+	return breakdown
+}
+
+// Compare returns every amount of expected that differs from computed by a cent or more.
+// Items are only compared when expected lists them.
+func Compare(expected, computed *models.OrderTotals) []models.TotalsMismatch {
+	mismatches := []models.TotalsMismatch{}
+	field := func(name string, e, c float64) {
+		if Round(e) != Round(c) {
+			mismatches = append(mismatches, models.TotalsMismatch{Field: name, Expected: e, Computed: c})
+		}
+	}
+	field("subtotal", expected.Subtotal, computed.Subtotal)
+	field("discount_amount", expected.DiscountAmount, computed.DiscountAmount)
+	field("shipping_cost", expected.ShippingCost, computed.ShippingCost)
+	field("tax_amount", expected.TaxAmount, computed.TaxAmount)
+	field("total_amount", expected.TotalAmount, computed.TotalAmount)
+
+	if len(expected.Items) == 0 {
+		return mismatches
+	}
+
+	computedItems := make(map[int]models.OrderItemTotal, len(computed.Items))
+	for _, item := range computed.Items {
+		computedItems[item.CartItemID] = item
+	}
+	seen := make(map[int]bool, len(expected.Items))
+	for _, e := range expected.Items {
+		id := e.CartItemID
+		seen[id] = true
+		c, ok := computedItems[id]
+		if !ok {
+			mismatches = append(mismatches, models.TotalsMismatch{Field: "items.unknown", CartItemID: &id, Expected: e.TotalPrice})
+			continue
+		}
+		if Round(e.UnitPrice) != Round(c.UnitPrice) {
+			mismatches = append(mismatches, models.TotalsMismatch{Field: "items.unit_price", CartItemID: &id, Expected: e.UnitPrice, Computed: c.UnitPrice})
+		}
+		if Round(e.TotalPrice) != Round(c.TotalPrice) {
+			mismatches = append(mismatches, models.TotalsMismatch{Field: "items.total_price", CartItemID: &id, Expected: e.TotalPrice, Computed: c.TotalPrice})
+		}
+	}
+	for _, c := range computed.Items {
+		if !seen[c.CartItemID] {
+			id := c.CartItemID
+			mismatches = append(mismatches, models.TotalsMismatch{Field: "items.missing", CartItemID: &id, Computed: c.TotalPrice})
+		}
+	}
+	return mismatches
+}
+
+// VerifyOrder checks that an order's amounts add up before it is stored: each item's
+// total is its unit price times its quantity, the items make up the subtotal, the
+// discount is within the subtotal, shipping matches its breakdown and the total follows
+// from the rest. It catches a bug or a tampered amount reaching the order.
+func VerifyOrder(order *models.Order, items []models.OrderItem) error {
+	subtotal := 0.0
+	for _, item := range items {
+		if item.Quantity <= 0 || item.UnitPrice < 0 {
+			return fmt.Errorf("item of variant %d has quantity %d at %.2f", item.VariantID, item.Quantity, item.UnitPrice)
+		}
+		if Round(item.TotalPrice) != Round(item.UnitPrice*float64(item.Quantity)) {
+			return fmt.Errorf("item of variant %d totals %.2f instead of %d x %.2f", item.VariantID, item.TotalPrice, item.Quantity, item.UnitPrice)
+		}
+		subtotal += item.TotalPrice
+	}
+	if Round(subtotal) != Round(order.Subtotal) {
+		return fmt.Errorf("subtotal %.2f does not match the items' %.2f", order.Subtotal, subtotal)
+	}
+	if order.DiscountAmount < 0 || Round(order.DiscountAmount) > Round(order.Subtotal) {
+		return fmt.Errorf("discount %.2f is outside the subtotal %.2f", order.DiscountAmount, order.Subtotal)
+	}
+	if order.ShippingCost < 0 || order.TaxAmount < 0 {
+		return fmt.Errorf("shipping %.2f and tax %.2f can't be negative", order.ShippingCost, order.TaxAmount)
+	}
+	if order.ShippingBreakdown != nil && Round(order.ShippingBreakdown.Total) != Round(order.ShippingCost) {
+		return fmt.Errorf("shipping %.2f does not match its breakdown's %.2f", order.ShippingCost, order.ShippingBreakdown.Total)
+	}
+	if total := Total(order.Subtotal, order.DiscountAmount, order.ShippingCost, order.TaxAmount); Round(order.TotalAmount) != total {
+		return fmt.Errorf("total %.2f does not match the computed %.2f", order.TotalAmount, total)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package pricing
+
+import (
+	"testing"
+
+	"notsofluffy-backend/internal/models"
+)
+
+func TestUnitPrice(t *testing.T) {
+	if got := UnitPrice(99.99, true, []float64{10}, []float64{5.5}); got != 125.49 {
+		t.Fatalf("expected 125.49, got %v", got)
+	}
+	if got := UnitPrice(0.1, false, []float64{0.2}, nil); got != 0.3 {
+		t.Fatalf("expected amounts to be rounded to cents, got %v", got)
+	}
+}
+
+func TestDiscount(t *testing.T) {
+	if got := Discount(models.DiscountTypePercentage, 15, 33.33); got != 5 {
+		t.Fatalf("expected 5, got %v", got)
+	}
+	if got := Discount(models.DiscountTypeFixedAmount, 50, 30); got != 30 {
+		t.Fatalf("fixed discount should be capped at the subtotal, got %v", got)
+	}
+}
+
+func TestShipping(t *testing.T) {
+	items := []models.CartItemResponse{
+		{ProductID: 1, Quantity: 2, ShippingSurcharge: 12.5, Oversize: true},
+		{ProductID: 2, Quantity: 1},
+		{ProductID: 3, Quantity: 1, ShippingSurcharge: 99, Oversize: true, Unavailable: true},
+	}
+	breakdown := Shipping(items, nil, 100, 49)
+	if breakdown.OversizeFee != 49 || len(breakdown.Surcharges) != 1 || breakdown.Surcharges[0].Amount != 25 {
+		t.Fatalf("unexpected breakdown %+v", breakdown)
+	}
+	if breakdown.Total != 74 {
+		t.Fatalf("expected 74, got %v", breakdown.Total)
+	}
+
+	if got := Shipping(items[1:2], nil, 100, 49); got.Total != 0 || got.OversizeFee != 0 {
+		t.Fatalf("regular items should ship without extra cost, got %+v", got)
+	}
+}
+
+func TestShippingMethod(t *testing.T) {
+	freeAbove := 300.0
+	method := &models.ShippingMethod{ID: 2, Name: "Courier", Price: 19.99, FreeAbove: &freeAbove}
+	items := []models.CartItemResponse{{ProductID: 1, Quantity: 1, ShippingSurcharge: 5}}
+
+	breakdown := Shipping(items, method, 299.99, 49)
+	if breakdown.BaseCost != 19.99 || breakdown.Total != 24.99 || breakdown.Method != "Courier" || *breakdown.MethodID != 2 {
+		t.Fatalf("unexpected breakdown below the threshold %+v", breakdown)
+	}
+	if got := Shipping(items, method, 300, 49); got.BaseCost != 0 || got.Total != 5 {
+		t.Fatalf("the method price should be waived from the threshold on, got %+v", got)
+	}
+	method.FreeAbove = nil
+	if got := Shipping(items, method, 10000, 49); got.BaseCost != 19.99 {
+		t.Fatalf("methods without a threshold are never free, got %+v", got)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	computed := &models.OrderTotals{
+		Subtotal: 120, DiscountAmount: 12, TotalAmount: 108,
+		Items: []models.OrderItemTotal{
+			{CartItemID: 1, UnitPrice: 50, TotalPrice: 100},
+			{CartItemID: 2, UnitPrice: 20, TotalPrice: 20},
+		},
+	}
+
+	matching := *computed
+	matching.Subtotal = 120.004
+	if mismatches := Compare(&matching, computed); len(mismatches) != 0 {
+		t.Fatalf("expected no mismatches below a cent, got %+v", mismatches)
+	}
+
+	expected := &models.OrderTotals{
+		Subtotal: 110, DiscountAmount: 12, TotalAmount: 98,
+		Items: []models.OrderItemTotal{
+			{CartItemID: 1, UnitPrice: 45, TotalPrice: 90},
+			{CartItemID: 3, UnitPrice: 20, TotalPrice: 20},
+		},
+	}
+	fields := map[string]bool{}
+	for _, m := range Compare(expected, computed) {
+		fields[m.Field] = true
+	}
+	for _, field := range []string{"subtotal", "total_amount", "items.unit_price", "items.total_price", "items.unknown", "items.missing"} {
+		if !fields[field] {
+			t.Errorf("expected a %s mismatch, got %v", field, fields)
+		}
+	}
+	if fields["discount_amount"] {
+		t.Error("discount amount matches and should not be reported")
+	}
+}
+
+func TestVerifyOrder(t *testing.T) {
+	items := []models.OrderItem{
+		{VariantID: 1, Quantity: 2, UnitPrice: 49.99, TotalPrice: 99.98},
+		{VariantID: 2, Quantity: 1, UnitPrice: 20, TotalPrice: 20},
+	}
+	valid := func() *models.Order {
+		return &models.Order{
+			Subtotal:          119.98,
+			DiscountAmount:    12,
+			ShippingCost:      15,
+			TotalAmount:       122.98,
+			ShippingBreakdown: &models.ShippingBreakdown{BaseCost: 15, Total: 15},
+		}
+	}
+	if err := VerifyOrder(valid(), items); err != nil {
+		t.Fatalf("expected a consistent order, got %v", err)
+	}
+
+	tampered := map[string]func(*models.Order){
+		"total":    func(o *models.Order) { o.TotalAmount = 1 },
+		"subtotal": func(o *models.Order) { o.Subtotal, o.TotalAmount = 100, 103 },
+		"discount": func(o *models.Order) { o.DiscountAmount, o.TotalAmount = 200, 15 },
+		"shipping": func(o *models.Order) { o.ShippingCost, o.TotalAmount = 0, 107.98 },
+		"negative": func(o *models.Order) { o.TaxAmount, o.TotalAmount = -10, 112.98 },
+	}
+	for name, tamper := range tampered {
+		order := valid()
+		tamper(order)
+		if err := VerifyOrder(order, items); err == nil {
+			t.Errorf("expected the %s change to be rejected", name)
+		}
+	}
+
+	cheap := []models.OrderItem{{VariantID: 1, Quantity: 2, UnitPrice: 49.99, TotalPrice: 49.99}, items[1]}
+	if err := VerifyOrder(&models.Order{Subtotal: 69.99, TotalAmount: 69.99}, cheap); err == nil {
+		t.Fatal("expected an item total not matching its quantity to be rejected")
+	}
+}
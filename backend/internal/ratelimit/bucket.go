@@ -0,0 +1,148 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Rule is a token bucket: it holds up to Burst requests and refills at Requests per
+// Period, so a client can send a burst and then keeps the average rate
+type Rule struct {
+	Requests int
+	Period   time.Duration
+	Burst    int
+}
+
+// Enabled reports whether the rule limits anything
+func (r Rule) Enabled() bool {
+	return r.Requests > 0 && r.Period > 0 && r.Burst > 0
+}
+
+// rate returns the tokens added per second
+func (r Rule) rate() float64 {
+	return float64(r.Requests) / r.Period.Seconds()
+}
+
+// ParseRule parses a rule written as "<requests>/<period>" with an optional
+// ":<burst>", e.g. "10/1m" or "5/1m:20"; the burst defaults to the requests. An
+// empty value or "off" returns a disabled rule.
+func ParseRule(value string) (Rule, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "off" {
+		return Rule{}, nil
+	}
+
+	rate, burst, hasBurst := strings.Cut(value, ":")
+	requests, period, ok := strings.Cut(rate, "/")
+	if !ok {
+		return Rule{}, fmt.Errorf("invalid rate limit %q, expected <requests>/<period>", value)
+	}
+
+	rule := Rule{}
+	var err error
+	if rule.Requests, err = strconv.Atoi(strings.TrimSpace(requests)); err != nil || rule.Requests <= 0 {
+		return Rule{}, fmt.Errorf("invalid rate limit %q: requests must be a positive number", value)
+	}
+	if rule.Period, err = time.ParseDuration(strings.TrimSpace(period)); err != nil || rule.Period <= 0 {
+		return Rule{}, fmt.Errorf("invalid rate limit %q: period must be a positive duration", value)
+	}
+	rule.Burst = rule.Requests
+	if hasBurst {
+		if rule.Burst, err = strconv.Atoi(strings.TrimSpace(burst)); err != nil || rule.Burst <= 0 {
+			return Rule{}, fmt.Errorf("invalid rate limit %q: burst must be a positive number", value)
+		}
+	}
+	return rule, nil
+}
+
+// Decision is the outcome of taking a token for one request
+type Decision struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter is how long a rejected client has to wait for the next token
+	RetryAfter time.Duration
+}
+
+// Store keeps token buckets by key
+type Store interface {
+	Take(ctx context.Context, key string, rule Rule) (Decision, error)
+}
+
+// decide takes a token from a bucket holding tokens, returning the decision and the
+// tokens left
+func decide(rule Rule, tokens float64) (Decision, float64) {
+	decision := Decision{Limit: rule.Burst}
+	if tokens < 1 {
+		decision.RetryAfter = time.Duration((1 - tokens) / rule.rate() * float64(time.Second))
+		return decision, tokens
+	}
+	tokens--
+	decision.Allowed = true
+	decision.Remaining = int(math.Floor(tokens))
+	return decision, tokens
+}
+
+// refill returns the tokens of a bucket last updated at last
+func refill(rule Rule, tokens float64, last, now time.Time) float64 {
+	if elapsed := now.Sub(last).Seconds(); elapsed > 0 {
+		tokens += elapsed * rule.rate()
+	}
+	return math.Min(tokens, float64(rule.Burst))
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	// full is when the bucket will have refilled completely
+	full time.Time
+}
+
+// MemoryStore keeps buckets in memory, so each server instance enforces its own limits
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]*bucket{}, now: time.Now}
+}
+
+// Take takes a token from the bucket of key. A new bucket starts full.
+func (s *MemoryStore) Take(_ context.Context, key string, rule Rule) (Decision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	b, ok := s.buckets[key]
+	if !ok {
+		if len(s.buckets) > 10000 {
+			s.prune(now)
+		}
+		b = &bucket{tokens: float64(rule.Burst), updated: now}
+		s.buckets[key] = b
+	}
+
+	b.tokens = refill(rule, b.tokens, b.updated, now)
+	b.updated = now
+	decision, tokens := decide(rule, b.tokens)
+	b.tokens = tokens
+	b.full = now.Add(time.Duration((float64(rule.Burst) - tokens) / rule.rate() * float64(time.Second)))
+	return decision, nil
+}
+
+// prune drops buckets that have refilled, which are the same as new ones
+func (s *MemoryStore) prune(now time.Time) {
+	for key, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		value string
+		want  Rule
+	}{
+		{"10/1m", Rule{Requests: 10, Period: time.Minute, Burst: 10}},
+		{" 5/1m:20 ", Rule{Requests: 5, Period: time.Minute, Burst: 20}},
+		{"100/1h", Rule{Requests: 100, Period: time.Hour, Burst: 100}},
+		{"", Rule{}},
+		{"off", Rule{}},
+	}
+	for _, tt := range tests {
+		got, err := ParseRule(tt.value)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseRule(%q) = %+v, %v; want %+v", tt.value, got, err, tt.want)
+		}
+	}
+
+	for _, value := range []string{"10", "0/1m", "10/0s", "10/minute", "10/1m:0", "10/1m:x"} {
+		if _, err := ParseRule(value); err == nil {
+			t.Errorf("ParseRule(%q) should fail", value)
+		}
+	}
+}
+
+func TestMemoryStoreTake(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	rule := Rule{Requests: 6, Period: time.Minute, Burst: 3}
+
+	for i := 0; i < 3; i++ {
+		decision, _ := store.Take(context.Background(), "key", rule)
+		if !decision.Allowed || decision.Remaining != 2-i {
+			t.Fatalf("request %d: unexpected decision %+v", i+1, decision)
+		}
+	}
+
+	decision, _ := store.Take(context.Background(), "key", rule)
+	if decision.Allowed || decision.RetryAfter != 10*time.Second {
+		t.Fatalf("request over the burst should wait for the next token, got %+v", decision)
+	}
+	if decision, _ := store.Take(context.Background(), "other", rule); !decision.Allowed {
+		t.Fatal("keys should have their own buckets")
+	}
+
+	// One token is added every 10 seconds, up to the burst
+	now = now.Add(10 * time.Second)
+	if decision, _ := store.Take(context.Background(), "key", rule); !decision.Allowed || decision.Remaining != 0 {
+		t.Fatalf("a refilled token should be taken, got %+v", decision)
+	}
+	now = now.Add(time.Hour)
+	if decision, _ := store.Take(context.Background(), "key", rule); !decision.Allowed || decision.Remaining != 2 {
+		t.Fatalf("the bucket should refill up to the burst, got %+v", decision)
+	}
+}
+
+func TestNewRedisStore(t *testing.T) {
+	store, err := NewRedisStore("redis://:secret@cache:6380/2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	options := store.client.Options()
+	if options.Addr != "cache:6380" || options.Password != "secret" || options.DB != 2 || options.TLSConfig != nil {
+		t.Fatalf("unexpected options %+v", options)
+	}
+	if store, err := NewRedisStore("rediss://localhost"); err != nil || store.client.Options().TLSConfig == nil || store.client.Options().Addr != "localhost:6379" {
+		t.Fatalf("rediss:// should use TLS on the default port, got %v", err)
+	}
+	if _, err := NewRedisStore("localhost:6379"); err == nil {
+		t.Fatal("a URL without the redis scheme should be rejected")
+	}
+}
@@ -0,0 +1,74 @@
+// Package ratelimit limits requests per key. Limiter counts them in fixed one minute
+// windows in memory, so each server instance enforces its own limit. Rules are token
+// buckets kept in a Store: in memory, or in Redis to share them between instances.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Window is the length of a counting window
+const Window = time.Minute
+
+// Result is the outcome of counting one request
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is when the current window ends and the count starts over
+	Reset time.Time
+}
+
+type window struct {
+	start time.Time
+	count int
+}
+
+// Limiter counts requests per key
+type Limiter struct {
+	mu      sync.Mutex
+	windows map[string]*window
+	now     func() time.Time
+}
+
+// New creates an empty limiter
+func New() *Limiter {
+	return &Limiter{windows: map[string]*window{}, now: time.Now}
+}
+
+// Allow counts a request for key against limit requests per window. Rejected requests
+// are not counted, so a client that backs off gets through in the next window.
+func (l *Limiter) Allow(key string, limit int) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	start := now.Truncate(Window)
+	w, ok := l.windows[key]
+	if !ok || !w.start.Equal(start) {
+		if len(l.windows) > 10000 {
+			l.prune(start)
+		}
+		w = &window{start: start}
+		l.windows[key] = w
+	}
+
+	result := Result{Limit: limit, Reset: start.Add(Window)}
+	if w.count >= limit {
+		return result
+	}
+	w.count++
+	result.Allowed = true
+	result.Remaining = limit - w.count
+	return result
+}
+
+// prune drops windows that ended before start
+func (l *Limiter) prune(start time.Time) {
+	for key, w := range l.windows {
+		if w.start.Before(start) {
+			delete(l.windows, key)
+		}
+	}
+}
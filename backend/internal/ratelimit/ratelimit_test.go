@@ -0,0 +1,36 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAllow(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 10, 0, time.UTC)
+	l := New()
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		result := l.Allow("key", 3)
+		if !result.Allowed || result.Remaining != 2-i {
+			t.Fatalf("request %d: unexpected result %+v", i+1, result)
+		}
+	}
+
+	result := l.Allow("key", 3)
+	if result.Allowed || result.Remaining != 0 {
+		t.Fatalf("request over the limit should be rejected, got %+v", result)
+	}
+	if !result.Reset.Equal(time.Date(2024, 5, 1, 12, 1, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected reset %v", result.Reset)
+	}
+
+	if !l.Allow("other", 3).Allowed {
+		t.Fatal("keys should be counted separately")
+	}
+
+	now = now.Add(time.Minute)
+	if result := l.Allow("key", 3); !result.Allowed || result.Remaining != 2 {
+		t.Fatalf("count should start over in the next window, got %+v", result)
+	}
+}
@@ -0,0 +1,85 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// takeScript refills and takes a token from a bucket in one step, using the Redis
+// server's clock in milliseconds so instances with skewed clocks share buckets
+// correctly. The bucket expires once it would have refilled.
+var takeScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local clock = redis.call('TIME')
+local now = tonumber(clock[1]) * 1000 + math.floor(tonumber(clock[2]) / 1000)
+local data = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+local tokens = tonumber(data[1]) or burst
+local updated = tonumber(data[2]) or now
+if now > updated then
+	tokens = math.min(burst, tokens + (now - updated) / 1000 * rate)
+end
+local allowed, retry = 0, 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) / rate * 1000)
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) / rate * 1000) + 1000)
+return {allowed, math.floor(tokens), retry}`)
+
+// redisKeyPrefix namespaces the buckets in a shared Redis database
+const redisKeyPrefix = "ratelimit:"
+
+// redisTimeout bounds connecting to Redis and each command, so a slow Redis delays
+// requests by little
+const redisTimeout = 2 * time.Second
+
+// RedisStore keeps buckets in Redis, so all server instances share the limits
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore creates a store for a Redis server given by a URL such as
+// redis://:password@localhost:6379/0, or rediss:// for TLS. Connections are pooled and
+// opened when first needed; failed commands are retried on a new connection.
+func NewRedisStore(rawURL string) (*RedisStore, error) {
+	options, err := redis.ParseURL(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redis URL, expected redis[s]://[:password@]host[:port][/db]: %w", err)
+	}
+	options.DialTimeout = redisTimeout
+	options.ReadTimeout = redisTimeout
+	options.WriteTimeout = redisTimeout
+	options.MaxRetries = 2
+	return &RedisStore{client: redis.NewClient(options)}, nil
+}
+
+// Take takes a token from the bucket of key. The script runs by its hash and is only
+// sent again when the server does not know it yet.
+func (s *RedisStore) Take(ctx context.Context, key string, rule Rule) (Decision, error) {
+	values, err := takeScript.Run(ctx, s.client, []string{redisKeyPrefix + key}, rule.rate(), rule.Burst).Int64Slice()
+	if err != nil {
+		return Decision{}, fmt.Errorf("failed to take rate limit token: %w", err)
+	}
+	if len(values) != 3 {
+		return Decision{}, fmt.Errorf("unexpected Redis reply %v", values)
+	}
+
+	return Decision{
+		Allowed:    values[0] == 1,
+		Limit:      rule.Burst,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Millisecond,
+	}, nil
+}
+
+// Close closes the connections to Redis
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
@@ -0,0 +1,289 @@
+package shipping
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// InPost ShipX services of the shipping services
+var inpostServices = map[string]string{
+	ServiceLocker:  "inpost_locker_standard",
+	ServiceCourier: "inpost_courier_standard",
+}
+
+// InPostProvider ships with InPost through the ShipX API. Shipments are bought as they
+// are created; InPost confirms them and assigns the tracking number shortly after.
+type InPostProvider struct {
+	token          string
+	organizationID string
+	baseURL        string
+	client         *http.Client
+}
+
+// NewInPostProvider creates a provider using the ShipX API, or its sandbox when
+// cfg.InPostSandbox is set
+func NewInPostProvider(cfg Config, timeout time.Duration) *InPostProvider {
+	baseURL := "https://api-shipx-pl.easypack24.net"
+	if cfg.InPostSandbox {
+		baseURL = "https://sandbox-api-shipx-pl.easypack24.net"
+	}
+	return &InPostProvider{
+		token:          cfg.InPostToken,
+		organizationID: cfg.InPostOrganizationID,
+		baseURL:        baseURL,
+		client:         &http.Client{Timeout: timeout},
+	}
+}
+
+// Name returns the provider identifier
+func (p *InPostProvider) Name() string {
+	return "inpost"
+}
+
+// Carrier returns the carrier name
+func (p *InPostProvider) Carrier() string {
+	return "InPost"
+}
+
+// inpostShipment is the part of a ShipX shipment we read
+type inpostShipment struct {
+	ID             int64   `json:"id"`
+	Status         string  `json:"status"`
+	TrackingNumber *string `json:"tracking_number"`
+}
+
+// inpostWaitingStatuses are the ShipX statuses of shipments not handed over to InPost
+var inpostWaitingStatuses = map[string]bool{
+	"created":         true,
+	"offers_prepared": true,
+	"offer_selected":  true,
+	"confirmed":       true,
+	"canceled":        true,
+}
+
+// inpostStage returns the stage of a ShipX status; the many statuses between the
+// handover and the delivery all mean the parcel is on its way
+func inpostStage(status string) string {
+	if status == "delivered" {
+		return StageDelivered
+	}
+	if inpostWaitingStatuses[status] {
+		return StageCreated
+	}
+	return StageInTransit
+}
+
+func (s *inpostShipment) shipment() *Shipment {
+	shipment := &Shipment{ProviderShipmentID: strconv.FormatInt(s.ID, 10), Status: s.Status, Stage: inpostStage(s.Status)}
+	if s.TrackingNumber != nil {
+		shipment.TrackingNumber = *s.TrackingNumber
+	}
+	return shipment
+}
+
+// CreateShipment creates and buys a ShipX shipment
+func (p *InPostProvider) CreateShipment(ctx context.Context, params ShipmentParams) (*Shipment, error) {
+	service, ok := inpostServices[params.Service]
+	if !ok {
+		return nil, fmt.Errorf("inpost does not offer the %q service", params.Service)
+	}
+
+	payload := map[string]interface{}{
+		"receiver":  inpostPeer(params.Receiver),
+		"parcels":   []interface{}{inpostParcel(params.Parcel)},
+		"service":   service,
+		"reference": params.Reference,
+	}
+	if params.Sender != nil {
+		payload["sender"] = inpostPeer(*params.Sender)
+	}
+	sendingMethod := "dispatch_order"
+	if params.DropOff {
+		sendingMethod = "any_point"
+	}
+	if params.Service == ServiceLocker {
+		if params.TargetPoint == "" {
+			return nil, fmt.Errorf("inpost locker shipments need a target point")
+		}
+		payload["custom_attributes"] = map[string]string{
+			"target_point":   params.TargetPoint,
+			"sending_method": sendingMethod,
+		}
+	} else if params.DropOff {
+		payload["custom_attributes"] = map[string]string{"sending_method": sendingMethod}
+	}
+	if params.InsuranceAmount > 0 {
+		payload["insurance"] = map[string]interface{}{
+			"amount":   math.Round(params.InsuranceAmount*100) / 100,
+			"currency": params.Currency,
+		}
+	}
+
+	var created inpostShipment
+	if err := p.call(ctx, http.MethodPost, "/v1/organizations/"+url.PathEscape(p.organizationID)+"/shipments", payload, &created); err != nil {
+		return nil, err
+	}
+	return created.shipment(), nil
+}
+
+// inpostPeer returns the ShipX sender or receiver of an address
+func inpostPeer(addr Address) map[string]interface{} {
+	firstName, lastName := splitName(addr.Name)
+	peer := map[string]interface{}{
+		"first_name": firstName,
+		"last_name":  lastName,
+		"email":      addr.Email,
+		"phone":      addr.Phone,
+		"address": map[string]string{
+			"line1":        addr.Line1,
+			"line2":        addr.Line2,
+			"city":         addr.City,
+			"post_code":    addr.PostalCode,
+			"country_code": addr.Country,
+		},
+	}
+	if addr.Company != "" {
+		peer["company_name"] = addr.Company
+	}
+	return peer
+}
+
+// inpostParcel returns the ShipX parcel of a parcel: its template when set, otherwise
+// its dimensions in millimetres and weight in kilograms
+func inpostParcel(parcel Parcel) map[string]interface{} {
+	if parcel.Template != "" {
+		return map[string]interface{}{"template": parcel.Template}
+	}
+	return map[string]interface{}{
+		"dimensions": map[string]interface{}{
+			"length": math.Round(parcel.LengthCm * 10),
+			"width":  math.Round(parcel.WidthCm * 10),
+			"height": math.Round(parcel.HeightCm * 10),
+			"unit":   "mm",
+		},
+		"weight": map[string]interface{}{
+			"amount": parcel.WeightKg,
+			"unit":   "kg",
+		},
+	}
+}
+
+// GetShipment returns the status and tracking number of a ShipX shipment
+func (p *InPostProvider) GetShipment(ctx context.Context, providerShipmentID string) (*Shipment, error) {
+	var shipment inpostShipment
+	if err := p.call(ctx, http.MethodGet, "/v1/shipments/"+url.PathEscape(providerShipmentID), nil, &shipment); err != nil {
+		return nil, err
+	}
+	return shipment.shipment(), nil
+}
+
+// Label returns the A6 PDF label of a shipment. ShipX only has labels of confirmed
+// shipments, which are the ones with a tracking number.
+func (p *InPostProvider) Label(ctx context.Context, providerShipmentID string) ([]byte, error) {
+	shipment, err := p.GetShipment(ctx, providerShipmentID)
+	if err != nil {
+		return nil, err
+	}
+	if shipment.TrackingNumber == "" {
+		return nil, ErrLabelNotReady
+	}
+
+	resp, err := p.do(ctx, http.MethodGet, "/v1/shipments/"+url.PathEscape(providerShipmentID)+"/label?format=pdf&type=A6", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, inpostError(resp)
+	}
+	label, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read inpost label: %w", err)
+	}
+	return label, nil
+}
+
+// TrackingURL returns the InPost tracking page of a tracking number
+func (p *InPostProvider) TrackingURL(trackingNumber string) string {
+	return "https://inpost.pl/sledzenie-przesylek?number=" + url.QueryEscape(trackingNumber)
+}
+
+// call sends a JSON request and decodes the JSON response into out
+func (p *InPostProvider) call(ctx context.Context, method, path string, payload interface{}, out interface{}) error {
+	resp, err := p.do(ctx, method, path, payload)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return inpostError(resp)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode inpost response: %w", err)
+	}
+	return nil
+}
+
+// do sends a request authenticated with the API token
+func (p *InPostProvider) do(ctx context.Context, method, path string, payload interface{}) (*http.Response, error) {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode inpost request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, p.baseURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create inpost request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+p.token)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call inpost: %w", err)
+	}
+	return resp, nil
+}
+
+// inpostError returns the error of a failed ShipX response, with its message and the
+// fields that failed validation when ShipX sent them
+func inpostError(resp *http.Response) error {
+	var body struct {
+		Error   string                 `json:"error"`
+		Message string                 `json:"message"`
+		Details map[string]interface{} `json:"details"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Message == "" {
+		return fmt.Errorf("inpost returned status %d", resp.StatusCode)
+	}
+	if len(body.Details) > 0 {
+		details, _ := json.Marshal(body.Details)
+		return fmt.Errorf("inpost returned status %d: %s %s", resp.StatusCode, body.Message, details)
+	}
+	return fmt.Errorf("inpost returned status %d: %s", resp.StatusCode, body.Message)
+}
+
+// splitName splits a full name into first and last name at its last space
+func splitName(name string) (string, string) {
+	name = strings.TrimSpace(name)
+	if i := strings.LastIndex(name, " "); i > 0 {
+		return strings.TrimSpace(name[:i]), name[i+1:]
+	}
+	return name, ""
+}
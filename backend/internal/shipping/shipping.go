@@ -0,0 +1,126 @@
+// Package shipping creates shipments with carrier APIs and fetches their tracking
+// numbers and labels. Each carrier is a Provider; InPost is the one integrated, others
+// such as Furgonetka plug in by implementing the interface and a case in NewProvider.
+package shipping
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Services a shipment can be sent with
+const (
+	// ServiceLocker delivers to a parcel locker or pickup point, given as TargetPoint
+	ServiceLocker = "locker"
+	// ServiceCourier delivers to the receiver's address
+	ServiceCourier = "courier"
+)
+
+// Stages of a shipment, the same for every carrier
+const (
+	// StageCreated shipments haven't been handed over to the carrier yet
+	StageCreated = "created"
+	// StageInTransit shipments are on their way
+	StageInTransit = "in_transit"
+	// StageDelivered shipments reached their receiver
+	StageDelivered = "delivered"
+)
+
+// ErrLabelNotReady is returned for labels of shipments the carrier hasn't confirmed yet
+var ErrLabelNotReady = errors.New("shipment label is not ready yet")
+
+// Address is the receiver of a shipment
+type Address struct {
+	Name       string
+	Company    string
+	Line1      string
+	Line2      string
+	PostalCode string
+	City       string
+	// Country is the ISO 3166-1 alpha-2 code
+	Country string
+	Phone   string
+	Email   string
+}
+
+// Parcel describes the package. Locker shipments use a size Template ("small",
+// "medium", "large"); courier shipments its weight and dimensions.
+type Parcel struct {
+	Template string
+	WeightKg float64
+	LengthCm float64
+	WidthCm  float64
+	HeightCm float64
+}
+
+// ShipmentParams describes a shipment to be created for an order
+type ShipmentParams struct {
+	OrderID int
+	// Reference is printed on the label, e.g. the order number
+	Reference   string
+	Service     string
+	TargetPoint string
+	Receiver    Address
+	// Sender hands the parcel over, e.g. a customer returning an order; nil sends it
+	// from the shop's address registered with the carrier
+	Sender *Address
+	// DropOff lets the sender drop the parcel off at any of the carrier's points
+	// instead of it being collected
+	DropOff bool
+	Parcel  Parcel
+	// InsuranceAmount is the declared value; zero leaves the parcel uninsured
+	InsuranceAmount float64
+	Currency        string
+}
+
+// Shipment is a shipment at a carrier. TrackingNumber is empty until the carrier
+// assigns it, which can happen some time after the shipment is created. Status is
+// the carrier's own status, Stage where it stands in any carrier's terms.
+type Shipment struct {
+	ProviderShipmentID string
+	Status             string
+	Stage              string
+	TrackingNumber     string
+}
+
+// Provider is implemented by every carrier integration
+type Provider interface {
+	// Name returns the identifier stored with shipments created by this provider
+	Name() string
+	// Carrier returns the carrier name shown to customers
+	Carrier() string
+	// CreateShipment orders a shipment
+	CreateShipment(ctx context.Context, params ShipmentParams) (*Shipment, error)
+	// GetShipment returns the current status and tracking number of a shipment
+	GetShipment(ctx context.Context, providerShipmentID string) (*Shipment, error)
+	// Label returns the PDF label of a shipment, or ErrLabelNotReady
+	Label(ctx context.Context, providerShipmentID string) ([]byte, error)
+	// TrackingURL returns the carrier's public tracking page of a tracking number
+	TrackingURL(trackingNumber string) string
+}
+
+// Config selects and configures the carrier integration
+type Config struct {
+	// Provider is "inpost"; shipments are created by hand when it is empty
+	Provider string
+
+	InPostToken          string
+	InPostOrganizationID string
+	InPostSandbox        bool
+}
+
+// NewProvider returns the configured provider, or nil when no carrier is integrated
+func NewProvider(cfg Config, timeout time.Duration) (Provider, error) {
+	switch cfg.Provider {
+	case "":
+		return nil, nil
+	case "inpost":
+		if cfg.InPostToken == "" || cfg.InPostOrganizationID == "" {
+			return nil, fmt.Errorf("inpost needs an API token and an organization ID")
+		}
+		return NewInPostProvider(cfg, timeout), nil
+	}
+	return nil, fmt.Errorf("unknown shipping provider %q", cfg.Provider)
+}
@@ -0,0 +1,163 @@
+package shipping
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTestInPost(t *testing.T, handler http.HandlerFunc) *InPostProvider {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return &InPostProvider{token: "token", organizationID: "42", baseURL: server.URL, client: server.Client()}
+}
+
+func TestInPostCreateLockerShipment(t *testing.T) {
+	var payload map[string]interface{}
+	provider := newTestInPost(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/organizations/42/shipments" {
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Fatalf("missing token, got %q", r.Header.Get("Authorization"))
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id": 1234, "status": "created", "tracking_number": null}`))
+	})
+
+	shipment, err := provider.CreateShipment(context.Background(), ShipmentParams{
+		Reference:   "NSF-17",
+		Service:     ServiceLocker,
+		TargetPoint: "KRA010",
+		Receiver:    Address{Name: "Anna Maria Nowak", Phone: "600100200", Email: "anna@example.com", Country: "PL"},
+		Parcel:      Parcel{Template: "small"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shipment.ProviderShipmentID != "1234" || shipment.Status != "created" || shipment.TrackingNumber != "" {
+		t.Fatalf("unexpected shipment %+v", shipment)
+	}
+
+	if payload["service"] != "inpost_locker_standard" {
+		t.Fatalf("expected the locker service, got %v", payload["service"])
+	}
+	attributes := payload["custom_attributes"].(map[string]interface{})
+	if attributes["target_point"] != "KRA010" {
+		t.Fatalf("expected the target point, got %v", attributes)
+	}
+	receiver := payload["receiver"].(map[string]interface{})
+	if receiver["first_name"] != "Anna Maria" || receiver["last_name"] != "Nowak" {
+		t.Fatalf("expected the name split at the last space, got %v", receiver)
+	}
+}
+
+func TestInPostCreateReturnShipment(t *testing.T) {
+	var payload map[string]interface{}
+	provider := newTestInPost(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&payload)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id": 1235, "status": "created", "tracking_number": null}`))
+	})
+
+	_, err := provider.CreateShipment(context.Background(), ShipmentParams{
+		Service:     ServiceLocker,
+		TargetPoint: "KRA010",
+		Receiver:    Address{Name: "NotSoFluffy", Phone: "600100200", Email: "sklep@example.com"},
+		Sender:      &Address{Name: "Jan Kowalski", Line1: "Długa 1", City: "Kraków", PostalCode: "30-001", Country: "PL"},
+		DropOff:     true,
+		Parcel:      Parcel{Template: "small"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sender, ok := payload["sender"].(map[string]interface{})
+	if !ok || sender["last_name"] != "Kowalski" || sender["address"].(map[string]interface{})["city"] != "Kraków" {
+		t.Fatalf("expected the customer as the sender, got %v", payload["sender"])
+	}
+	attributes := payload["custom_attributes"].(map[string]interface{})
+	if attributes["sending_method"] != "any_point" {
+		t.Fatalf("expected the parcel to be dropped off, got %v", attributes)
+	}
+}
+
+func TestInPostStage(t *testing.T) {
+	for status, stage := range map[string]string{
+		"confirmed":                 StageCreated,
+		"dispatched_by_sender":      StageInTransit,
+		"adopted_at_sorting_center": StageInTransit,
+		"ready_to_pickup":           StageInTransit,
+		"delivered":                 StageDelivered,
+	} {
+		if got := inpostStage(status); got != stage {
+			t.Errorf("inpostStage(%q) = %q, want %q", status, got, stage)
+		}
+	}
+}
+
+func TestInPostCreateShipmentError(t *testing.T) {
+	provider := newTestInPost(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"status": 400, "error": "validation_failed", "message": "There are some validation errors.", "details": {"receiver": [{"phone": ["invalid"]}]}}`))
+	})
+
+	_, err := provider.CreateShipment(context.Background(), ShipmentParams{
+		Service: ServiceCourier,
+		Parcel:  Parcel{WeightKg: 2, LengthCm: 40, WidthCm: 30, HeightCm: 10},
+	})
+	if err == nil || !strings.Contains(err.Error(), "validation errors") || !strings.Contains(err.Error(), "phone") {
+		t.Fatalf("expected the validation message and details, got %v", err)
+	}
+}
+
+func TestInPostLabelNotReady(t *testing.T) {
+	provider := newTestInPost(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/label") {
+			t.Fatal("the label of an unconfirmed shipment should not be requested")
+		}
+		w.Write([]byte(`{"id": 1234, "status": "created", "tracking_number": null}`))
+	})
+
+	if _, err := provider.Label(context.Background(), "1234"); !errors.Is(err, ErrLabelNotReady) {
+		t.Fatalf("expected ErrLabelNotReady, got %v", err)
+	}
+}
+
+func TestInPostLabel(t *testing.T) {
+	provider := newTestInPost(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/shipments/1234/label" {
+			if r.URL.Query().Get("format") != "pdf" {
+				t.Fatalf("expected a PDF label, got %q", r.URL.RawQuery)
+			}
+			w.Write([]byte("%PDF-1.4"))
+			return
+		}
+		w.Write([]byte(`{"id": 1234, "status": "confirmed", "tracking_number": "520000011395200025754311"}`))
+	})
+
+	label, err := provider.Label(context.Background(), "1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(label) != "%PDF-1.4" {
+		t.Fatalf("unexpected label %q", label)
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	if provider, err := NewProvider(Config{}, 0); provider != nil || err != nil {
+		t.Fatalf("no provider should be configured by default, got %v, %v", provider, err)
+	}
+	if _, err := NewProvider(Config{Provider: "inpost"}, 0); err == nil {
+		t.Fatal("inpost without credentials should fail")
+	}
+	if _, err := NewProvider(Config{Provider: "carrier-pigeon"}, 0); err == nil {
+		t.Fatal("unknown providers should fail")
+	}
+}
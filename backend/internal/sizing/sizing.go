@@ -0,0 +1,150 @@
+// Package sizing recommends a product size from a pet's measurements. Sizes carry the
+// garment's dimensions a-f, in the same units and order as the storefront size chart;
+// a size fits when each dimension is at least the pet's matching measurement.
+package sizing
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// Dimensions are the measured dimensions of a size, in size chart order
+var Dimensions = []string{"a", "b", "c", "d", "e", "f"}
+
+// Confidence levels of a recommendation
+const (
+	ConfidenceHigh   = "high"
+	ConfidenceMedium = "medium"
+	ConfidenceLow    = "low"
+)
+
+// looseFit is the relative slack above which a fitting size is considered loose
+const looseFit = 0.25
+
+// Errors returned by Recommend
+var (
+	ErrNoSizes        = errors.New("product has no sizes")
+	ErrNoMeasurements = errors.New("no measurement matches a dimension of the product's sizes")
+)
+
+// Size is a candidate size with its dimensions; zero means the dimension is not given
+type Size struct {
+	ID     int
+	Name   string
+	Values map[string]float64
+}
+
+// Comparison is one measured dimension of the recommended size
+type Comparison struct {
+	Dimension   string
+	Measurement float64
+	SizeValue   float64
+	// Difference is the size value minus the measurement; negative when too small
+	Difference float64
+}
+
+// Recommendation is the best fitting size. When no size fits, it is the size that comes
+// closest and Fits is false.
+type Recommendation struct {
+	Size       Size
+	Fits       bool
+	Confidence string
+	// ConstrainingDimension is the tightest dimension of the size, or the one that is
+	// too small when the size doesn't fit
+	ConstrainingDimension string
+	Comparisons           []Comparison
+	// Unmatched lists measurements the size has no value for, so they were not checked
+	Unmatched []string
+}
+
+type candidate struct {
+	size        Size
+	comparisons []Comparison
+	unmatched   []string
+	minSlack    float64
+	maxSlack    float64
+	totalSlack  float64
+	tightest    string
+}
+
+// Recommend picks the size fitting the measurements most closely: among the sizes that
+// fit, the one with the least total room, otherwise the one that is least too small
+func Recommend(sizes []Size, measurements map[string]float64) (*Recommendation, error) {
+	if len(sizes) == 0 {
+		return nil, ErrNoSizes
+	}
+
+	var candidates []candidate
+	for _, size := range sizes {
+		candidate := evaluate(size, measurements)
+		if len(candidate.comparisons) > 0 {
+			candidates = append(candidates, candidate)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, ErrNoMeasurements
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		aFits, bFits := a.minSlack >= 0, b.minSlack >= 0
+		switch {
+		case aFits != bFits:
+			return aFits
+		case aFits && len(a.comparisons) != len(b.comparisons):
+			return len(a.comparisons) > len(b.comparisons)
+		case aFits:
+			return a.totalSlack < b.totalSlack
+		}
+		return a.minSlack > b.minSlack
+	})
+
+	best := candidates[0]
+	recommendation := &Recommendation{
+		Size:                  best.size,
+		Fits:                  best.minSlack >= 0,
+		ConstrainingDimension: best.tightest,
+		Comparisons:           best.comparisons,
+		Unmatched:             best.unmatched,
+	}
+	switch {
+	case !recommendation.Fits:
+		recommendation.Confidence = ConfidenceLow
+	case len(best.unmatched) == 0 && best.maxSlack <= looseFit:
+		recommendation.Confidence = ConfidenceHigh
+	default:
+		recommendation.Confidence = ConfidenceMedium
+	}
+	return recommendation, nil
+}
+
+func evaluate(size Size, measurements map[string]float64) candidate {
+	result := candidate{size: size, minSlack: math.Inf(1), unmatched: []string{}}
+	for _, dimension := range Dimensions {
+		measurement, ok := measurements[dimension]
+		if !ok || measurement <= 0 {
+			continue
+		}
+		value := size.Values[dimension]
+		if value <= 0 {
+			result.unmatched = append(result.unmatched, dimension)
+			continue
+		}
+
+		slack := (value - measurement) / value
+		result.comparisons = append(result.comparisons, Comparison{
+			Dimension:   dimension,
+			Measurement: measurement,
+			SizeValue:   value,
+			Difference:  math.Round((value-measurement)*100) / 100,
+		})
+		result.totalSlack += slack
+		result.maxSlack = math.Max(result.maxSlack, slack)
+		if slack < result.minSlack {
+			result.minSlack = slack
+			result.tightest = dimension
+		}
+	}
+	return result
+}
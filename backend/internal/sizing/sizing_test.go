@@ -0,0 +1,54 @@
+package sizing
+
+import "testing"
+
+var testSizes = []Size{
+	{ID: 1, Name: "S", Values: map[string]float64{"a": 40, "b": 30, "c": 20}},
+	{ID: 2, Name: "M", Values: map[string]float64{"a": 46, "b": 35, "c": 24}},
+	{ID: 3, Name: "L", Values: map[string]float64{"a": 54, "b": 41, "c": 28}},
+}
+
+func TestRecommendPicksSmallestFittingSize(t *testing.T) {
+	got, err := Recommend(testSizes, map[string]float64{"a": 42, "b": 31, "c": 19})
+	if err != nil {
+		t.Fatalf("Recommend() error = %v", err)
+	}
+	if got.Size.Name != "M" || !got.Fits || got.Confidence != ConfidenceHigh {
+		t.Fatalf("Recommend() = %s fits=%v confidence=%s, want M fitting with high confidence", got.Size.Name, got.Fits, got.Confidence)
+	}
+	// a has the least room in M: (46-42)/46 against (35-31)/35 and (24-19)/24
+	if got.ConstrainingDimension != "a" {
+		t.Errorf("ConstrainingDimension = %s, want a", got.ConstrainingDimension)
+	}
+}
+
+func TestRecommendWhenNothingFits(t *testing.T) {
+	got, err := Recommend(testSizes, map[string]float64{"a": 50, "b": 45})
+	if err != nil {
+		t.Fatalf("Recommend() error = %v", err)
+	}
+	if got.Size.Name != "L" || got.Fits || got.Confidence != ConfidenceLow || got.ConstrainingDimension != "b" {
+		t.Errorf("Recommend() = %s fits=%v confidence=%s constraining=%s, want L not fitting on b",
+			got.Size.Name, got.Fits, got.Confidence, got.ConstrainingDimension)
+	}
+}
+
+func TestRecommendWithUnmatchedMeasurement(t *testing.T) {
+	got, err := Recommend(testSizes, map[string]float64{"a": 38, "f": 10})
+	if err != nil {
+		t.Fatalf("Recommend() error = %v", err)
+	}
+	if got.Size.Name != "S" || got.Confidence != ConfidenceMedium || len(got.Unmatched) != 1 || got.Unmatched[0] != "f" {
+		t.Errorf("Recommend() = %s confidence=%s unmatched=%v, want S with medium confidence and f unmatched",
+			got.Size.Name, got.Confidence, got.Unmatched)
+	}
+}
+
+func TestRecommendErrors(t *testing.T) {
+	if _, err := Recommend(nil, map[string]float64{"a": 10}); err != ErrNoSizes {
+		t.Errorf("Recommend(no sizes) error = %v, want ErrNoSizes", err)
+	}
+	if _, err := Recommend(testSizes, map[string]float64{"e": 10}); err != ErrNoMeasurements {
+		t.Errorf("Recommend(unmatched) error = %v, want ErrNoMeasurements", err)
+	}
+}
@@ -0,0 +1,84 @@
+// Package slug generates URL slugs for catalog entities, transliterating Polish
+// letters so "Łóżko dla psa" becomes "lozko-dla-psa".
+package slug
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MaxLength bounds generated slugs, leaving room for a uniqueness suffix
+const MaxLength = 200
+
+// maxAttempts bounds the suffixes tried by Unique
+const maxAttempts = 100
+
+var polishTransliteration = strings.NewReplacer(
+	"ą", "a", "ć", "c", "ę", "e", "ł", "l", "ń", "n", "ó", "o", "ś", "s", "ź", "z", "ż", "z",
+	"Ą", "a", "Ć", "c", "Ę", "e", "Ł", "l", "Ń", "n", "Ó", "o", "Ś", "s", "Ź", "z", "Ż", "z",
+)
+
+// Make turns a name into a lowercase URL slug
+func Make(name string) string {
+	name = strings.ToLower(polishTransliteration.Replace(name))
+
+	var b strings.Builder
+	dash := false
+	for _, r := range name {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+
+	slug := strings.TrimSuffix(b.String(), "-")
+	if len(slug) > MaxLength {
+		slug = slug[:MaxLength]
+		// Cut at a word boundary when there is one
+		if i := strings.LastIndexByte(slug, '-'); i > MaxLength/2 {
+			slug = slug[:i]
+		}
+		slug = strings.TrimSuffix(slug, "-")
+	}
+	return slug
+}
+
+// Valid reports whether s is a slug: lowercase letters and digits separated by single dashes
+func Valid(s string) bool {
+	if s == "" || len(s) > 256 || strings.HasPrefix(s, "-") || strings.HasSuffix(s, "-") || strings.Contains(s, "--") {
+		return false
+	}
+	for _, r := range s {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// Unique returns the slug of name, suffixed with -2, -3, ... until exists reports it
+// free. fallback is used when the name has no letters or digits, e.g. "product".
+func Unique(name, fallback string, exists func(slug string) (bool, error)) (string, error) {
+	base := Make(name)
+	if base == "" {
+		base = fallback
+	}
+
+	candidate := base
+	for attempt := 2; attempt <= maxAttempts+1; attempt++ {
+		taken, err := exists(candidate)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s-%d", base, attempt)
+	}
+	return "", fmt.Errorf("no free slug for %q", base)
+}
@@ -0,0 +1,55 @@
+package slug
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMake(t *testing.T) {
+	tests := map[string]string{
+		"Legowisko Puszek":         "legowisko-puszek",
+		"Łóżko dla psa – XL":       "lozko-dla-psa-xl",
+		"  Poduszka  Żółta!! ":     "poduszka-zolta",
+		"Kocyk 100% bawełna (2w1)": "kocyk-100-bawelna-2w1",
+		"???":                      "",
+	}
+
+	for name, want := range tests {
+		if got := Make(name); got != want {
+			t.Errorf("Make(%q) = %q, want %q", name, got, want)
+		}
+	}
+
+	long := Make(strings.Repeat("legowisko ", 40))
+	if len(long) > MaxLength || strings.HasSuffix(long, "-") || !strings.HasSuffix(long, "legowisko") {
+		t.Errorf("Make(long name) = %q, want a slug cut at a word boundary", long)
+	}
+}
+
+func TestValid(t *testing.T) {
+	for _, s := range []string{"legowiska", "kocyk-100", "a"} {
+		if !Valid(s) {
+			t.Errorf("Valid(%q) = false", s)
+		}
+	}
+	for _, s := range []string{"", "Legowiska", "-a", "a-", "a--b", "łóżko", "a b"} {
+		if Valid(s) {
+			t.Errorf("Valid(%q) = true", s)
+		}
+	}
+}
+
+func TestUnique(t *testing.T) {
+	taken := map[string]bool{"legowisko": true, "legowisko-2": true}
+	exists := func(s string) (bool, error) { return taken[s], nil }
+
+	if got, err := Unique("Legowisko", "product", exists); err != nil || got != "legowisko-3" {
+		t.Errorf("Unique(Legowisko) = %q, %v, want legowisko-3", got, err)
+	}
+	if got, err := Unique("Kocyk", "product", exists); err != nil || got != "kocyk" {
+		t.Errorf("Unique(Kocyk) = %q, %v, want kocyk", got, err)
+	}
+	if got, err := Unique("!!!", "product", exists); err != nil || got != "product" {
+		t.Errorf("Unique(!!!) = %q, %v, want the fallback", got, err)
+	}
+}
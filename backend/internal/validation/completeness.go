@@ -0,0 +1,70 @@
+package validation
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"notsofluffy-backend/internal/models"
+)
+
+// Requirements of a complete product
+const (
+	MinProductImages         = 3
+	MinDescriptionCharacters = 200
+)
+
+// ProductContent is what Completeness looks at
+type ProductContent struct {
+	Sizes           int
+	DefaultVariants int
+	Images          int
+	Category        bool
+	Description     string
+}
+
+// DescriptionLength counts the characters of a description the way the incomplete
+// product filter does, ignoring surrounding whitespace
+func DescriptionLength(description string) int {
+	return utf8.RuneCountInString(strings.TrimSpace(description))
+}
+
+// Completeness returns the checklist of a product with its score
+func Completeness(productID int, p ProductContent) models.ProductCompleteness {
+	category := 0
+	if p.Category {
+		category = 1
+	}
+
+	checks := []models.CompletenessCheck{
+		check(models.CompletenessHasSizes, "Has sizes", p.Sizes, 1),
+		check(models.CompletenessHasDefaultVariant, "Has a default variant", p.DefaultVariants, 1),
+		check(models.CompletenessHasImages, fmt.Sprintf("Has at least %d images", MinProductImages), p.Images, MinProductImages),
+		check(models.CompletenessHasCategory, "Has a category", category, 1),
+		check(models.CompletenessDescriptionLength, fmt.Sprintf("Description is at least %d characters", MinDescriptionCharacters), DescriptionLength(p.Description), MinDescriptionCharacters),
+	}
+
+	passed := 0
+	for _, c := range checks {
+		if c.Passed {
+			passed++
+		}
+	}
+
+	return models.ProductCompleteness{
+		ProductID: productID,
+		Score:     passed * 100 / len(checks),
+		Complete:  passed == len(checks),
+		Checks:    checks,
+	}
+}
+
+func check(key, label string, current, required int) models.CompletenessCheck {
+	return models.CompletenessCheck{
+		Key:      key,
+		Label:    label,
+		Passed:   current >= required,
+		Current:  current,
+		Required: required,
+	}
+}
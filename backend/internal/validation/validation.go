@@ -0,0 +1,169 @@
+// Package validation finds non-fatal issues in catalog entities after they are saved,
+// e.g. a product nobody can buy because it has no sizes. Unlike request validation,
+// these never block a change; they are returned to the admin as warnings.
+package validation
+
+import (
+	"fmt"
+
+	"notsofluffy-backend/internal/models"
+)
+
+// MinImageDimension is the smallest side, in pixels, of an image that stays sharp on
+// the product page
+const MinImageDimension = 800
+
+// Image is an image with its pixel size; Width and Height are zero when unknown.
+// HasAltText is set when the image is shown with an alt text.
+type Image struct {
+	ID         int
+	Width      int
+	Height     int
+	HasAltText bool
+}
+
+// tooSmall reports whether the image is known to be smaller than MinImageDimension
+func (i Image) tooSmall() bool {
+	if i.Width == 0 || i.Height == 0 {
+		return false
+	}
+	return i.Width < MinImageDimension || i.Height < MinImageDimension
+}
+
+// Product is what CheckProduct looks at
+type Product struct {
+	MainImage Image
+	Images    []Image
+	Category  bool
+	Channels  models.ProductChannels
+	Sizes     []models.SizeResponse
+	// Variants and DefaultVariants count the product's variants
+	Variants        int
+	DefaultVariants int
+}
+
+// Variant is what CheckVariant looks at
+type Variant struct {
+	Images []Image
+	// ProductSizes and ProductDefaultVariants describe the product the variant belongs to
+	ProductSizes           int
+	ProductDefaultVariants int
+}
+
+// CheckProduct returns the warnings for a saved product
+func CheckProduct(p Product) []models.ValidationWarning {
+	warnings := []models.ValidationWarning{}
+
+	if p.MainImage.tooSmall() {
+		warnings = append(warnings, models.ValidationWarning{
+			Code:    models.WarningMainImageTooSmall,
+			Field:   "main_image_id",
+			Message: fmt.Sprintf("Main image is %dx%d px, smaller than %dpx", p.MainImage.Width, p.MainImage.Height, MinImageDimension),
+		})
+	}
+
+	// Alt text matters once the product is published
+	if p.Channels.Web || p.Channels.Marketplace || p.Channels.B2B {
+		if !p.MainImage.HasAltText {
+			warnings = append(warnings, models.ValidationWarning{
+				Code:    models.WarningImageMissingAltText,
+				Field:   "main_image_id",
+				Message: "Main image has no alt text",
+			})
+		}
+		for _, image := range p.Images {
+			if !image.HasAltText && image.ID != p.MainImage.ID {
+				warnings = append(warnings, models.ValidationWarning{
+					Code:    models.WarningImageMissingAltText,
+					Field:   "image_ids",
+					Message: fmt.Sprintf("Image %d has no alt text", image.ID),
+				})
+			}
+		}
+	}
+
+	if len(p.Sizes) == 0 {
+		warnings = append(warnings, models.ValidationWarning{
+			Code:    models.WarningNoSizes,
+			Message: "No sizes defined, product won't be purchasable",
+		})
+	} else if allOutOfStock(p.Sizes) {
+		warnings = append(warnings, models.ValidationWarning{
+			Code:    models.WarningOutOfStock,
+			Message: "All sizes are out of stock",
+		})
+	}
+
+	switch {
+	case p.Variants == 0:
+		warnings = append(warnings, models.ValidationWarning{
+			Code:    models.WarningNoVariants,
+			Message: "No variants defined, product won't be purchasable",
+		})
+	case p.DefaultVariants == 0:
+		warnings = append(warnings, models.ValidationWarning{
+			Code:    models.WarningNoDefaultVariant,
+			Message: "No default variant set for the product",
+		})
+	}
+
+	if !p.Category {
+		warnings = append(warnings, models.ValidationWarning{
+			Code:    models.WarningNoCategory,
+			Field:   "category_id",
+			Message: "No category, product won't be listed under any category",
+		})
+	}
+
+	if !p.Channels.Web && !p.Channels.Marketplace && !p.Channels.B2B {
+		warnings = append(warnings, models.ValidationWarning{
+			Code:    models.WarningNoChannels,
+			Field:   "channels",
+			Message: "Product is not offered in any sales channel",
+		})
+	}
+
+	return warnings
+}
+
+// CheckVariant returns the warnings for a saved product variant
+func CheckVariant(v Variant) []models.ValidationWarning {
+	warnings := []models.ValidationWarning{}
+
+	for _, image := range v.Images {
+		if image.tooSmall() {
+			warnings = append(warnings, models.ValidationWarning{
+				Code:    models.WarningImageTooSmall,
+				Field:   "image_ids",
+				Message: fmt.Sprintf("Image %d is %dx%d px, smaller than %dpx", image.ID, image.Width, image.Height, MinImageDimension),
+			})
+		}
+	}
+
+	if v.ProductSizes == 0 {
+		warnings = append(warnings, models.ValidationWarning{
+			Code:    models.WarningNoSizes,
+			Field:   "product_id",
+			Message: "Product has no sizes defined, variant won't be purchasable",
+		})
+	}
+
+	if v.ProductDefaultVariants == 0 {
+		warnings = append(warnings, models.ValidationWarning{
+			Code:    models.WarningNoDefaultVariant,
+			Field:   "is_default",
+			Message: "Product has no default variant",
+		})
+	}
+
+	return warnings
+}
+
+func allOutOfStock(sizes []models.SizeResponse) bool {
+	for _, size := range sizes {
+		if !size.UseStock || size.AvailableStock > 0 {
+			return false
+		}
+	}
+	return true
+}
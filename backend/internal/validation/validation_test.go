@@ -0,0 +1,119 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+
+	"notsofluffy-backend/internal/models"
+)
+
+func codes(warnings []models.ValidationWarning) map[string]bool {
+	result := map[string]bool{}
+	for _, w := range warnings {
+		result[w.Code] = true
+	}
+	return result
+}
+
+func TestCheckProduct(t *testing.T) {
+	complete := Product{
+		MainImage:       Image{ID: 1, Width: 1200, Height: 1600, HasAltText: true},
+		Images:          []Image{{ID: 1, Width: 1200, Height: 1600, HasAltText: true}, {ID: 2, HasAltText: true}},
+		Category:        true,
+		Channels:        models.DefaultProductChannels(),
+		Sizes:           []models.SizeResponse{{ID: 1}},
+		Variants:        2,
+		DefaultVariants: 1,
+	}
+	if warnings := CheckProduct(complete); len(warnings) != 0 {
+		t.Fatalf("expected no warnings for a complete product, got %+v", warnings)
+	}
+
+	got := codes(CheckProduct(Product{MainImage: Image{ID: 1, Width: 640, Height: 900}, Channels: models.ProductChannels{}}))
+	for _, code := range []string{models.WarningMainImageTooSmall, models.WarningNoSizes, models.WarningNoVariants, models.WarningNoCategory, models.WarningNoChannels} {
+		if !got[code] {
+			t.Errorf("expected warning %s, got %v", code, got)
+		}
+	}
+
+	soldOut := complete
+	soldOut.Sizes = []models.SizeResponse{{ID: 1, UseStock: true, AvailableStock: 0}}
+	soldOut.DefaultVariants = 0
+	got = codes(CheckProduct(soldOut))
+	if !got[models.WarningOutOfStock] || !got[models.WarningNoDefaultVariant] {
+		t.Errorf("expected out of stock and no default variant warnings, got %v", got)
+	}
+}
+
+func TestCheckProductAltText(t *testing.T) {
+	product := Product{
+		MainImage: Image{ID: 1},
+		Images:    []Image{{ID: 1}, {ID: 2, HasAltText: true}, {ID: 3}},
+		Channels:  models.ProductChannels{Web: true},
+	}
+	var missing []string
+	for _, w := range CheckProduct(product) {
+		if w.Code == models.WarningImageMissingAltText {
+			missing = append(missing, w.Message)
+		}
+	}
+	// The main image is reported once even when it is also among the images
+	if len(missing) != 2 || missing[0] != "Main image has no alt text" || missing[1] != "Image 3 has no alt text" {
+		t.Errorf("unexpected alt text warnings %v", missing)
+	}
+
+	// Unpublished products are not checked
+	product.Channels = models.ProductChannels{}
+	if got := codes(CheckProduct(product)); got[models.WarningImageMissingAltText] {
+		t.Errorf("expected no alt text warning for an unpublished product, got %v", got)
+	}
+}
+
+func TestCheckProductUnknownImageSize(t *testing.T) {
+	// Images whose size can't be read are not reported as too small
+	got := codes(CheckProduct(Product{MainImage: Image{ID: 1}}))
+	if got[models.WarningMainImageTooSmall] {
+		t.Errorf("expected no image warning for an unknown size, got %v", got)
+	}
+}
+
+func TestCheckVariant(t *testing.T) {
+	warnings := CheckVariant(Variant{
+		Images:                 []Image{{ID: 1, Width: 800, Height: 800}, {ID: 2, Width: 400, Height: 300}},
+		ProductSizes:           0,
+		ProductDefaultVariants: 1,
+	})
+	if len(warnings) != 2 {
+		t.Fatalf("expected 2 warnings, got %+v", warnings)
+	}
+	if warnings[0].Code != models.WarningImageTooSmall || warnings[1].Code != models.WarningNoSizes {
+		t.Errorf("unexpected warnings %+v", warnings)
+	}
+}
+
+func TestCompleteness(t *testing.T) {
+	result := Completeness(5, ProductContent{
+		Sizes:           2,
+		DefaultVariants: 1,
+		Images:          2,
+		Category:        true,
+		Description:     "  krótki opis  ",
+	})
+	if result.Complete || result.Score != 60 {
+		t.Fatalf("expected an incomplete product scoring 60, got %+v", result)
+	}
+	for _, c := range result.Checks {
+		wantPassed := c.Key != models.CompletenessHasImages && c.Key != models.CompletenessDescriptionLength
+		if c.Passed != wantPassed {
+			t.Errorf("check %s passed = %v, want %v", c.Key, c.Passed, wantPassed)
+		}
+	}
+	if got := DescriptionLength("  krótki opis  "); got != 11 {
+		t.Errorf("DescriptionLength counted %d characters, want 11", got)
+	}
+
+	result = Completeness(5, ProductContent{Sizes: 1, DefaultVariants: 1, Images: 3, Category: true, Description: strings.Repeat("ż", MinDescriptionCharacters)})
+	if !result.Complete || result.Score != 100 {
+		t.Errorf("expected a complete product, got %+v", result)
+	}
+}
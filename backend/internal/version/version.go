@@ -0,0 +1,77 @@
+// Package version reports what build of the backend is running. The values are
+// stamped at build time:
+//
+//	go build -ldflags "-X notsofluffy-backend/internal/version.Commit=$(git rev-parse --short HEAD) \
+//		-X notsofluffy-backend/internal/version.Tag=$(git describe --tags --always) \
+//		-X notsofluffy-backend/internal/version.BuildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Unstamped builds fall back to the VCS information Go embeds in the binary.
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// Set with -ldflags -X
+var (
+	Commit    = ""
+	Tag       = ""
+	BuildDate = ""
+)
+
+// Info describes the running build
+type Info struct {
+	Tag       string `json:"tag"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"build_date"`
+	Modified  bool   `json:"modified,omitempty"`
+	GoVersion string `json:"go_version"`
+}
+
+// Get returns the build information, filling unstamped values from the embedded
+// VCS settings
+func Get() Info {
+	info := Info{Tag: Tag, Commit: Commit, BuildDate: BuildDate, GoVersion: runtime.Version()}
+
+	if buildInfo, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range buildInfo.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = setting.Value
+					if len(info.Commit) > 12 {
+						info.Commit = info.Commit[:12]
+					}
+				}
+			case "vcs.time":
+				if info.BuildDate == "" {
+					info.BuildDate = setting.Value
+				}
+			case "vcs.modified":
+				info.Modified = setting.Value == "true"
+			}
+		}
+	}
+
+	if info.Tag == "" {
+		info.Tag = "dev"
+	}
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.BuildDate == "" {
+		info.BuildDate = "unknown"
+	}
+	return info
+}
+
+// String formats the build information for --version output
+func (i Info) String() string {
+	commit := i.Commit
+	if i.Modified {
+		commit += "-dirty"
+	}
+	return fmt.Sprintf("%s (commit %s, built %s, %s)", i.Tag, commit, i.BuildDate, i.GoVersion)
+}
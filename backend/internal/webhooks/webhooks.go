@@ -0,0 +1,97 @@
+// Package webhooks delivers signed event notifications to endpoints registered by admins.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// MaxAttempts is how often a delivery is tried before it is given up
+const MaxAttempts = 8
+
+// Retry delays start at baseBackoff and double per attempt up to maxBackoff
+const (
+	baseBackoff = 30 * time.Second
+	maxBackoff  = 6 * time.Hour
+)
+
+// Backoff returns how long to wait before retrying a delivery that failed attempts times
+func Backoff(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	delay := baseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return delay
+}
+
+// Sign returns the X-Webhook-Signature of a delivery: the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with the endpoint's secret
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Delivery is one attempt to notify an endpoint
+type Delivery struct {
+	ID     int
+	URL    string
+	Secret string
+	Event  string
+	Body   []byte
+}
+
+// Client posts deliveries to endpoints
+type Client struct {
+	http *http.Client
+}
+
+// NewClient creates a client whose requests time out after timeout
+func NewClient(timeout time.Duration) *Client {
+	return &Client{http: &http.Client{Timeout: timeout}}
+}
+
+// Deliver posts the delivery and returns the endpoint's status code. Anything but a 2xx
+// response is an error.
+func (c *Client) Deliver(ctx context.Context, d Delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(d.Body))
+	if err != nil {
+		return 0, fmt.Errorf("invalid webhook request: %w", err)
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "NotSoFluffy-Webhooks/1.0")
+	req.Header.Set("X-Webhook-Event", d.Event)
+	req.Header.Set("X-Webhook-Delivery", strconv.Itoa(d.ID))
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Webhook-Signature", Sign(d.Secret, timestamp, d.Body))
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
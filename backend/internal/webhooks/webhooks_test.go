@@ -0,0 +1,66 @@
+package webhooks
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{4, 4 * time.Minute},
+		{20, 6 * time.Hour},
+	}
+	for _, tt := range tests {
+		if got := Backoff(tt.attempts); got != tt.want {
+			t.Errorf("Backoff(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestDeliverSignsRequest(t *testing.T) {
+	body := []byte(`{"event":"order.created"}`)
+	var signature, timestamp string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature = r.Header.Get("X-Webhook-Signature")
+		timestamp = r.Header.Get("X-Webhook-Timestamp")
+		if r.Header.Get("X-Webhook-Event") != "order.created" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(time.Second)
+	status, err := client.Deliver(context.Background(), Delivery{ID: 7, URL: server.URL, Secret: "s3cret", Event: "order.created", Body: body})
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("expected delivery to succeed, got %d %v", status, err)
+	}
+
+	ts, _ := strconv.ParseInt(timestamp, 10, 64)
+	if signature != Sign("s3cret", ts, body) {
+		t.Errorf("unexpected signature %s", signature)
+	}
+	if Sign("other", ts, body) == signature {
+		t.Error("signature should depend on the secret")
+	}
+}
+
+func TestDeliverFailsOnErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	status, err := NewClient(time.Second).Deliver(context.Background(), Delivery{URL: server.URL, Event: "stock.low"})
+	if err == nil || status != http.StatusServiceUnavailable {
+		t.Fatalf("expected failure with status 503, got %d %v", status, err)
+	}
+}
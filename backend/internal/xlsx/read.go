@@ -0,0 +1,223 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// MaxReadSize bounds the uncompressed size of the parts Read parses, so a small archive
+// can't expand into an unbounded amount of memory
+const MaxReadSize = 64 << 20
+
+// maxRows is the number of rows of a sheet in Excel
+const maxRows = 1 << 20
+
+// Read returns the cell values of the first sheet of a spreadsheet, a row per sheet row
+// from the first one. Rows the sheet leaves out are empty, as are missing cells. Numbers
+// are returned as written, so dates come back as serial numbers.
+func Read(r io.ReaderAt, size int64) ([][]string, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("not an xlsx file")
+	}
+	parts := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		parts[file.Name] = file
+	}
+
+	sheetPath, err := firstSheetPath(parts)
+	if err != nil {
+		return nil, err
+	}
+	var sharedStrings []string
+	if parts["xl/sharedStrings.xml"] != nil {
+		if sharedStrings, err = readSharedStrings(parts["xl/sharedStrings.xml"]); err != nil {
+			return nil, err
+		}
+	}
+	sheet := parts[sheetPath]
+	if sheet == nil {
+		return nil, fmt.Errorf("sheet %s is missing", sheetPath)
+	}
+	return readSheet(sheet, sharedStrings)
+}
+
+// decodePart unmarshals an XML part of the archive into v
+func decodePart(file *zip.File, v interface{}) error {
+	if file.UncompressedSize64 > MaxReadSize {
+		return fmt.Errorf("%s is too large", file.Name)
+	}
+	rc, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", file.Name, err)
+	}
+	defer rc.Close()
+	if err := xml.NewDecoder(io.LimitReader(rc, MaxReadSize)).Decode(v); err != nil {
+		return fmt.Errorf("invalid %s: %w", file.Name, err)
+	}
+	return nil
+}
+
+// firstSheetPath finds the part of the workbook's first sheet through its relationship
+func firstSheetPath(parts map[string]*zip.File) (string, error) {
+	workbookFile, relsFile := parts["xl/workbook.xml"], parts["xl/_rels/workbook.xml.rels"]
+	if workbookFile == nil || relsFile == nil {
+		return "", fmt.Errorf("not an xlsx file")
+	}
+
+	var workbook struct {
+		Sheets []struct {
+			ID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := decodePart(workbookFile, &workbook); err != nil {
+		return "", err
+	}
+	if len(workbook.Sheets) == 0 {
+		return "", fmt.Errorf("workbook has no sheets")
+	}
+
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := decodePart(relsFile, &rels); err != nil {
+		return "", err
+	}
+	for _, rel := range rels.Relationships {
+		if rel.ID == workbook.Sheets[0].ID {
+			// Targets are relative to xl/ unless they are absolute within the package
+			if strings.HasPrefix(rel.Target, "/") {
+				return strings.TrimPrefix(rel.Target, "/"), nil
+			}
+			return path.Join("xl", rel.Target), nil
+		}
+	}
+	return "", fmt.Errorf("first sheet is missing")
+}
+
+// richText is the text of a shared or inline string, either plain or in formatted runs
+type richText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t richText) String() string {
+	if len(t.Runs) == 0 {
+		return t.Text
+	}
+	var b strings.Builder
+	for _, run := range t.Runs {
+		b.WriteString(run.Text)
+	}
+	return b.String()
+}
+
+func readSharedStrings(file *zip.File) ([]string, error) {
+	var table struct {
+		Items []richText `xml:"si"`
+	}
+	if err := decodePart(file, &table); err != nil {
+		return nil, err
+	}
+	strs := make([]string, len(table.Items))
+	for i, item := range table.Items {
+		strs[i] = item.String()
+	}
+	return strs, nil
+}
+
+func readSheet(file *zip.File, sharedStrings []string) ([][]string, error) {
+	var sheet struct {
+		Rows []struct {
+			Number int `xml:"r,attr"`
+			Cells  []struct {
+				Ref    string   `xml:"r,attr"`
+				Type   string   `xml:"t,attr"`
+				Value  string   `xml:"v"`
+				Inline richText `xml:"is"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := decodePart(file, &sheet); err != nil {
+		return nil, err
+	}
+
+	rows := [][]string{}
+	for _, row := range sheet.Rows {
+		// Row and cell references may be left out, meaning the next row or cell
+		index := len(rows)
+		if row.Number > 0 {
+			index = row.Number - 1
+		}
+		if index < len(rows) || index >= maxRows {
+			return nil, fmt.Errorf("invalid row number %d", row.Number)
+		}
+		for len(rows) < index {
+			rows = append(rows, []string{})
+		}
+
+		record := []string{}
+		for _, cell := range row.Cells {
+			column := len(record)
+			if cell.Ref != "" {
+				var err error
+				if column, err = columnIndex(cell.Ref); err != nil {
+					return nil, err
+				}
+			}
+			if column < len(record) {
+				return nil, fmt.Errorf("invalid cell reference %s", cell.Ref)
+			}
+			for len(record) < column {
+				record = append(record, "")
+			}
+
+			value := cell.Value
+			switch cell.Type {
+			case "s":
+				i, err := strconv.Atoi(value)
+				if err != nil || i < 0 || i >= len(sharedStrings) {
+					return nil, fmt.Errorf("invalid shared string in cell %s", cell.Ref)
+				}
+				value = sharedStrings[i]
+			case "inlineStr":
+				value = cell.Inline.String()
+			case "b":
+				value = strconv.FormatBool(value == "1")
+			}
+			record = append(record, value)
+		}
+		rows = append(rows, record)
+	}
+	return rows, nil
+}
+
+// columnIndex returns the zero-based column of a cell reference such as "AB12"
+func columnIndex(ref string) (int, error) {
+	column := 0
+	letters := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		column = column*26 + int(r-'A'+1)
+		letters++
+		if letters > 3 {
+			return 0, fmt.Errorf("invalid cell reference %s", ref)
+		}
+	}
+	if letters == 0 {
+		return 0, fmt.Errorf("invalid cell reference %s", ref)
+	}
+	return column - 1, nil
+}
@@ -0,0 +1,194 @@
+// Package xlsx writes spreadsheets in the Office Open XML format Excel and LibreOffice
+// open natively. Rows are streamed into a single sheet as they are written, so a
+// spreadsheet of any size is written in constant memory. The first sheet of uploaded
+// spreadsheets can be read back for imports.
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// The parts of the package besides the sheet, which is written last
+var staticParts = []struct{ name, content string }{
+	{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+		`</Relationships>`},
+	// Style 1 is the bold header row
+	{"xl/styles.xml", xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+		`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+		`<borders count="1"><border/></borders>` +
+		`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+		`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+		`</styleSheet>`},
+}
+
+// Writer writes the rows of a one-sheet spreadsheet. It has the Write, Flush and Error
+// methods of csv.Writer, so code writing records can produce either format; Close must
+// be called to finish the file.
+type Writer struct {
+	zip     *zip.Writer
+	sheet   io.Writer
+	numeric map[int]bool
+	row     int
+	err     error
+}
+
+// NewWriter starts a spreadsheet with a sheet named sheetName and a bold header row.
+// Cells of the numeric columns, by index, are written as numbers.
+func NewWriter(w io.Writer, sheetName string, header []string, numeric ...int) (*Writer, error) {
+	zw := zip.NewWriter(w)
+	for _, part := range staticParts {
+		if err := writePart(zw, part.name, part.content); err != nil {
+			return nil, err
+		}
+	}
+	workbook := xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="` + escape(sheetName) + `" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	if err := writePart(zw, "xl/workbook.xml", workbook); err != nil {
+		return nil, err
+	}
+
+	sheet, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.WriteString(sheet, xml.Header+`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`+
+		`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`+
+		`<sheetData>`); err != nil {
+		return nil, err
+	}
+
+	writer := &Writer{zip: zw, sheet: sheet, numeric: map[int]bool{}}
+	if err := writer.writeRow(header, true); err != nil {
+		return nil, err
+	}
+	for _, column := range numeric {
+		writer.numeric[column] = true
+	}
+	return writer, nil
+}
+
+func writePart(zw *zip.Writer, name, content string) error {
+	part, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(part, content)
+	return err
+}
+
+// Write writes a row. Empty cells are left out; numeric cells that aren't numbers are
+// written as text.
+func (w *Writer) Write(record []string) error {
+	return w.writeRow(record, false)
+}
+
+func (w *Writer) writeRow(record []string, header bool) error {
+	if w.err != nil {
+		return w.err
+	}
+	w.row++
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<row r="%d">`, w.row)
+	for i, value := range record {
+		if value == "" {
+			continue
+		}
+		ref := ColumnName(i) + strconv.Itoa(w.row)
+		switch {
+		case header:
+			fmt.Fprintf(&b, `<c r="%s" s="1" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, escape(value))
+		case w.numeric[i] && isNumber(value):
+			fmt.Fprintf(&b, `<c r="%s"><v>%s</v></c>`, ref, value)
+		default:
+			fmt.Fprintf(&b, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, escape(value))
+		}
+	}
+	b.WriteString(`</row>`)
+
+	_, w.err = io.WriteString(w.sheet, b.String())
+	return w.err
+}
+
+// Flush is a no-op kept for symmetry with csv.Writer; rows are written to the archive
+// as they come and the archive is finished by Close
+func (w *Writer) Flush() {}
+
+// Error returns the first error that occurred writing rows
+func (w *Writer) Error() error {
+	return w.err
+}
+
+// Close finishes the sheet and the archive. It doesn't close the underlying writer.
+func (w *Writer) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	if _, err := io.WriteString(w.sheet, `</sheetData></worksheet>`); err != nil {
+		w.err = err
+		return err
+	}
+	w.err = w.zip.Close()
+	return w.err
+}
+
+// ColumnName returns the letters of the column with the given zero-based index: A, B,
+// ..., Z, AA, AB and so on
+func ColumnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+// number matches the plain decimals written as numbers
+var number = regexp.MustCompile(`^-?\d+(\.\d+)?$`)
+
+func isNumber(value string) bool {
+	return number.MatchString(value)
+}
+
+// escape escapes text for XML, dropping the control characters XML 1.0 doesn't allow
+func escape(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		if r < 0x20 && r != '\t' && r != '\n' && r != '\r' {
+			continue
+		}
+		switch r {
+		case '<':
+			b.WriteString("&lt;")
+		case '>':
+			b.WriteString("&gt;")
+		case '&':
+			b.WriteString("&amp;")
+		case '"':
+			b.WriteString("&quot;")
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,143 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestColumnName(t *testing.T) {
+	for index, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"} {
+		if got := ColumnName(index); got != want {
+			t.Fatalf("ColumnName(%d) = %q, want %q", index, got, want)
+		}
+	}
+}
+
+func TestWriterProducesWellFormedPackage(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, "Orders", []string{"id", "name", "total"}, 0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]string{"1", "Sweter <Łódź> & co", "129.9"})
+	w.Write([]string{"2", "", "n/a"})
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := map[string]string{}
+	for _, file := range archive.File {
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		parts[file.Name] = string(data)
+
+		decoder := xml.NewDecoder(bytes.NewReader(data))
+		for {
+			if _, err := decoder.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("%s is not well-formed: %v", file.Name, err)
+			}
+		}
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet1.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Fatalf("missing part %s", name)
+		}
+	}
+	sheet := parts["xl/worksheets/sheet1.xml"]
+	for _, want := range []string{
+		`<c r="A2"><v>1</v></c>`,
+		`<c r="B2" t="inlineStr"><is><t xml:space="preserve">Sweter &lt;Łódź&gt; &amp; co</t></is></c>`,
+		`<c r="C2"><v>129.9</v></c>`,
+		`<c r="C3" t="inlineStr"><is><t xml:space="preserve">n/a</t></is></c>`,
+	} {
+		if !strings.Contains(sheet, want) {
+			t.Fatalf("sheet should contain %s:\n%s", want, sheet)
+		}
+	}
+	if strings.Contains(sheet, `r="B3"`) {
+		t.Fatal("empty cells should be left out")
+	}
+}
+
+func TestReadRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, "Products", []string{"slug", "name", "price"}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]string{"legowisko", "Legowisko <XL>", "129.9"})
+	w.Write([]string{"koc", "", "59"})
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"slug", "name", "price"}, {"legowisko", "Legowisko <XL>", "129.9"}, {"koc", "", "59"}}
+	if len(rows) != len(want) {
+		t.Fatalf("expected %d rows, got %v", len(want), rows)
+	}
+	for i := range want {
+		if strings.Join(rows[i], "|") != strings.Join(want[i], "|") {
+			t.Fatalf("row %d: expected %v, got %v", i+1, want[i], rows[i])
+		}
+	}
+}
+
+func TestReadSharedStringsAndGaps(t *testing.T) {
+	// Spreadsheets saved by Excel keep text in the shared strings and leave out empty rows
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="Arkusz1" sheetId="1" r:id="rId3"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/data.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+			`<si><t>slug</t></si><si><r><t>Kocyk </t></r><r><t>polarowy</t></r></si></sst>`,
+		"xl/worksheets/data.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` +
+			`<row r="1"><c r="A1" t="s"><v>0</v></c></row>` +
+			`<row r="3"><c r="B3" t="s"><v>1</v></c><c r="C3" t="b"><v>1</v></c></row>` +
+			`</sheetData></worksheet>`,
+	} {
+		if err := writePart(zw, name, content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	zw.Close()
+
+	rows, err := Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 || rows[0][0] != "slug" || len(rows[1]) != 0 {
+		t.Fatalf("unexpected rows %q", rows)
+	}
+	if strings.Join(rows[2], "|") != "|Kocyk polarowy|true" {
+		t.Fatalf("unexpected third row %q", rows[2])
+	}
+}
+
+func TestReadRejectsOtherFiles(t *testing.T) {
+	data := []byte("slug,name\nkoc,Koc\n")
+	if _, err := Read(bytes.NewReader(data), int64(len(data))); err == nil {
+		t.Fatal("expected an error for a CSV file")
+	}
+}